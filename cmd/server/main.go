@@ -1,15 +1,24 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
+	"ropacal-backend/internal/cache"
 	"ropacal-backend/internal/database"
 	"ropacal-backend/internal/handlers"
 	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
 	"ropacal-backend/internal/services"
 	"ropacal-backend/internal/websocket"
+	"ropacal-backend/pkg/utils"
 
 	"github.com/go-chi/chi/v5"
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
@@ -30,6 +39,14 @@ func main() {
 		log.Println("✅ .env file loaded successfully")
 	}
 
+	// Operational alerts (Slack/email); configured before anything that can fail on deploy
+	alerter := services.NewAlerterFromEnv()
+	if sinks := alerter.SinkNames(); len(sinks) > 0 {
+		log.Printf("✅ Alerting enabled (%s)", strings.Join(sinks, ", "))
+	} else {
+		log.Println("⚠️  No alert sinks configured (set ALERT_SLACK_WEBHOOK_URL or ALERT_SMTP_HOST)")
+	}
+
 	// Get database URL
 	log.Println("🔍 Checking DATABASE_URL environment variable...")
 	dbURL := os.Getenv("DATABASE_URL")
@@ -67,6 +84,12 @@ func main() {
 		log.Println("❌ FATAL ERROR: Database migrations failed")
 		log.Printf("   Error: %v", err)
 		log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+		alerter.Notify(services.Alert{
+			Event:    services.AlertEventMigrationFailed,
+			Severity: services.AlertCritical,
+			Title:    "Database migrations failed",
+			Message:  "The server did not start because a database migration failed: " + err.Error(),
+		})
 		log.Fatal(err)
 	}
 	log.Println("✅ Database migrations completed")
@@ -91,6 +114,23 @@ func main() {
 	}
 	log.Println("✅ Bins seeded successfully")
 
+	// Reconcile shift state left in flight by the previous run (before the hub and dispatchers start)
+	if _, err := services.RecoverAfterRestart(db); err != nil {
+		log.Printf("⚠️  Startup recovery failed: %v (continuing)", err)
+	}
+
+	// One-time: fill shift incident counters from the incidents already on file
+	if _, err := services.BackfillShiftIncidentCounters(db); err != nil {
+		log.Printf("⚠️  Shift incident backfill failed: %v (continuing)", err)
+	}
+
+	// Read-through cache for user names, bin summaries and settings (0 disables)
+	if v := os.Getenv("CACHE_TTL_SECONDS"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+			cache.SetTTL(time.Duration(seconds) * time.Second)
+		}
+	}
+
 	// Initialize Firebase Cloud Messaging
 	// Supports both file path and base64-encoded credentials (for Railway/cloud deployments)
 	var fcmService *services.FCMService
@@ -102,6 +142,7 @@ func main() {
 		if err != nil {
 			log.Printf("⚠️  Failed to initialize FCM from base64: %v (push notifications disabled)", err)
 			fcmService = nil
+			alertFCMInitFailed(alerter, err)
 		} else {
 			log.Println("✅ Firebase Cloud Messaging initialized from base64 credentials")
 		}
@@ -116,16 +157,273 @@ func main() {
 		if err != nil {
 			log.Printf("⚠️  Failed to initialize FCM from file: %v (push notifications disabled)", err)
 			fcmService = nil
+			alertFCMInitFailed(alerter, err)
 		} else {
 			log.Println("✅ Firebase Cloud Messaging initialized from file")
 		}
 	}
 
-	// Initialize WebSocket hub
+	// Initialize WebSocket hub (area subscriptions resolve locations against the areas table)
 	wsHub := websocket.NewHub()
+	wsHub.SetAreaLocator(services.NewAreaLocator(db).Locate)
+	driverDisconnectGrace := 0
+	if v := os.Getenv("ALERT_DRIVER_DISCONNECT_GRACE_SECONDS"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil {
+			driverDisconnectGrace = seconds
+		}
+	}
+	services.WatchDriverDisconnects(db, wsHub, alerter, time.Duration(driverDisconnectGrace)*time.Second)
+
+	// Detect driver arrival at / departure from stops from streamed GPS (0 disables)
+	stopArrivalRadius := 50.0
+	if v := os.Getenv("STOP_ARRIVAL_RADIUS_METERS"); v != "" {
+		if meters, err := strconv.ParseFloat(v, 64); err == nil {
+			stopArrivalRadius = meters
+		}
+	}
+	if stopArrivalRadius > 0 {
+		wsHub.OnLocation(services.NewStopArrivalDetector(db, wsHub, stopArrivalRadius).Observe)
+		log.Printf("✅ Stop arrival detection enabled (%.0fm radius)", stopArrivalRadius)
+	}
+
+	// Alert managers when drivers enter or leave active no-go zones; re-entries within the window continue the visit
+	zoneReentrySeconds := 300
+	if v := os.Getenv("ZONE_ALERT_REENTRY_SECONDS"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil {
+			zoneReentrySeconds = seconds
+		}
+	}
+	wsHub.OnLocation(services.NewZoneEntryDetector(db, wsHub, time.Duration(zoneReentrySeconds)*time.Second).Observe)
+	log.Printf("✅ No-go zone entry alerts enabled (re-entry window %ds)", zoneReentrySeconds)
 	go wsHub.Run()
 	log.Println("✅ WebSocket hub started")
 
+	// Start check recommendation engine (cadence, fill trend, incident history)
+	checkRecommendationEngine := services.NewCheckRecommendationEngine(db)
+	checkRecommendationInterval := 60
+	if v := os.Getenv("CHECK_RECOMMENDATION_INTERVAL_MINUTES"); v != "" {
+		if minutes, err := strconv.Atoi(v); err == nil {
+			checkRecommendationInterval = minutes
+		}
+	}
+	if checkRecommendationInterval > 0 {
+		checkRecommendationEngine.Start(time.Duration(checkRecommendationInterval) * time.Minute)
+		log.Printf("✅ Check recommendation engine started (every %d min)", checkRecommendationInterval)
+	} else {
+		log.Println("⚠️  Check recommendation engine disabled (CHECK_RECOMMENDATION_INTERVAL_MINUTES=0)")
+	}
+
+	// Directions client for driver navigation legs (falls back to estimates without GOOGLE_MAPS_API_KEY)
+	directionsService := services.NewDirectionsService()
+
+	// Waypoint sequencing for custom routes when a shift starts
+	hereWaypointsService := services.NewHEREWaypointsService(handlers.HereAPIKey)
+
+	// Start area assigner (bins and no-go zones -> areas by point-in-polygon)
+	areaAssigner := services.NewAreaAssigner(db)
+	areaAssignmentInterval := 30
+	if v := os.Getenv("AREA_ASSIGNMENT_INTERVAL_MINUTES"); v != "" {
+		if minutes, err := strconv.Atoi(v); err == nil {
+			areaAssignmentInterval = minutes
+		}
+	}
+	if areaAssignmentInterval > 0 {
+		areaAssigner.Start(time.Duration(areaAssignmentInterval) * time.Minute)
+		log.Printf("✅ Area assigner started (every %d min)", areaAssignmentInterval)
+	} else {
+		log.Println("⚠️  Area assigner disabled (AREA_ASSIGNMENT_INTERVAL_MINUTES=0)")
+	}
+
+	// Start webhook dispatcher (signed delivery of queued events with retries)
+	webhookDispatcher := services.NewWebhookDispatcher(db)
+	webhookDeliveryInterval := 15
+	if v := os.Getenv("WEBHOOK_DELIVERY_INTERVAL_SECONDS"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil {
+			webhookDeliveryInterval = seconds
+		}
+	}
+	if webhookDeliveryInterval > 0 {
+		webhookDispatcher.Start(time.Duration(webhookDeliveryInterval) * time.Second)
+		log.Printf("✅ Webhook dispatcher started (every %ds)", webhookDeliveryInterval)
+	} else {
+		log.Println("⚠️  Webhook dispatcher disabled (WEBHOOK_DELIVERY_INTERVAL_SECONDS=0)")
+	}
+
+	// Start notification dispatcher (delivers WebSocket/FCM events queued in notification_outbox)
+	notificationDispatcher := services.NewNotificationDispatcher(db, wsHub, fcmService)
+	notificationDispatchInterval := 2
+	if v := os.Getenv("NOTIFICATION_OUTBOX_INTERVAL_SECONDS"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil {
+			notificationDispatchInterval = seconds
+		}
+	}
+	if notificationDispatchInterval > 0 {
+		notificationDispatcher.Start(time.Duration(notificationDispatchInterval) * time.Second)
+		log.Printf("✅ Notification dispatcher started (every %ds)", notificationDispatchInterval)
+	} else {
+		log.Println("⚠️  Notification dispatcher disabled (NOTIFICATION_OUTBOX_INTERVAL_SECONDS=0)")
+	}
+
+	// Record every FCM send per device and resend transient failures with backoff until they expire
+	if fcmService != nil {
+		fcmService.TrackDeliveries(db)
+		pushRetryInterval := 30
+		if v := os.Getenv("PUSH_RETRY_INTERVAL_SECONDS"); v != "" {
+			if seconds, err := strconv.Atoi(v); err == nil {
+				pushRetryInterval = seconds
+			}
+		}
+		if pushRetryInterval > 0 {
+			services.NewPushDeliveryRetrier(db, fcmService).Start(time.Duration(pushRetryInterval) * time.Second)
+			log.Printf("✅ Push delivery retrier started (every %ds)", pushRetryInterval)
+		} else {
+			log.Println("⚠️  Push delivery retrier disabled (PUSH_RETRY_INTERVAL_SECONDS=0)")
+		}
+	}
+
+	// Start route re-optimizer (re-orders remaining stops after move insertions, skips and cancellations)
+	routeReoptimizeDebounce := 60
+	if v := os.Getenv("ROUTE_REOPTIMIZE_DEBOUNCE_SECONDS"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+			routeReoptimizeDebounce = seconds
+		}
+	}
+	routeReoptimizer := services.NewRouteReoptimizer(db, time.Duration(routeReoptimizeDebounce)*time.Second)
+	routeReoptimizeInterval := 5
+	if v := os.Getenv("ROUTE_REOPTIMIZE_INTERVAL_SECONDS"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil {
+			routeReoptimizeInterval = seconds
+		}
+	}
+	if routeReoptimizeInterval > 0 {
+		routeReoptimizer.Start(time.Duration(routeReoptimizeInterval) * time.Second)
+		log.Printf("✅ Route re-optimizer started (every %ds, at most once per %ds per shift)", routeReoptimizeInterval, routeReoptimizeDebounce)
+	} else {
+		log.Println("⚠️  Route re-optimizer disabled (ROUTE_REOPTIMIZE_INTERVAL_SECONDS=0)")
+	}
+
+	// Start incident photo tagger (labels queued incident photos with a vision provider)
+	if visionProvider := services.NewVisionProviderFromEnv(); visionProvider != nil {
+		photoTagInterval := 30
+		if v := os.Getenv("INCIDENT_PHOTO_TAG_INTERVAL_SECONDS"); v != "" {
+			if seconds, err := strconv.Atoi(v); err == nil {
+				photoTagInterval = seconds
+			}
+		}
+		if photoTagInterval > 0 {
+			services.NewIncidentPhotoTagger(db, visionProvider).Start(time.Duration(photoTagInterval) * time.Second)
+			log.Printf("✅ Incident photo tagger started (%s provider, every %ds)", visionProvider.Name(), photoTagInterval)
+		} else {
+			log.Println("⚠️  Incident photo tagger disabled (INCIDENT_PHOTO_TAG_INTERVAL_SECONDS=0)")
+		}
+	} else {
+		log.Println("⚠️  No vision provider configured (set INCIDENT_VISION_URL); incident photos stay queued for tagging")
+	}
+
+	// Start shift template materializer (tomorrow's ready shifts from recurring templates)
+	shiftTemplateMaterializer := services.NewShiftTemplateMaterializer(db)
+	shiftTemplateInterval := 60
+	if v := os.Getenv("SHIFT_TEMPLATE_INTERVAL_MINUTES"); v != "" {
+		if minutes, err := strconv.Atoi(v); err == nil {
+			shiftTemplateInterval = minutes
+		}
+	}
+	if shiftTemplateInterval > 0 {
+		shiftTemplateMaterializer.Start(time.Duration(shiftTemplateInterval) * time.Minute)
+		log.Printf("✅ Shift template materializer started (every %d min)", shiftTemplateInterval)
+	} else {
+		log.Println("⚠️  Shift template materializer disabled (SHIFT_TEMPLATE_INTERVAL_MINUTES=0)")
+	}
+
+	// Start move request SLA checker (flags and escalates moves past their assignment/completion deadline)
+	moveSLAChecker := services.NewMoveSLAChecker(db)
+	moveSLAInterval := 5
+	if v := os.Getenv("MOVE_SLA_CHECK_INTERVAL_MINUTES"); v != "" {
+		if minutes, err := strconv.Atoi(v); err == nil {
+			moveSLAInterval = minutes
+		}
+	}
+	if moveSLAInterval > 0 {
+		moveSLAChecker.Start(time.Duration(moveSLAInterval) * time.Minute)
+		log.Printf("✅ Move SLA checker started (every %d min)", moveSLAInterval)
+	} else {
+		log.Println("⚠️  Move SLA checker disabled (MOVE_SLA_CHECK_INTERVAL_MINUTES=0)")
+	}
+
+	// Background jobs with retries and a dead letter queue (GET /api/manager/jobs); started below once the
+	// periodic jobs are registered, stopped with the HTTP server
+	jobRunner := services.NewJobRunner(db)
+
+	// Bins-at-risk digest job (sends the daily digest once its configured local time has passed)
+	binsAtRiskDigester := services.NewBinsAtRiskDigester(db)
+	digestInterval := 5
+	if v := os.Getenv("DIGEST_CHECK_INTERVAL_MINUTES"); v != "" {
+		if minutes, err := strconv.Atoi(v); err == nil {
+			digestInterval = minutes
+		}
+	}
+	if digestInterval > 0 {
+		jobRunner.Every(models.JobKindBinsAtRiskDigest, time.Duration(digestInterval)*time.Minute, binsAtRiskDigester.RunJob)
+		log.Printf("✅ Bins-at-risk digester scheduled (checking every %d min)", digestInterval)
+	} else {
+		log.Println("⚠️  Bins-at-risk digester disabled (DIGEST_CHECK_INTERVAL_MINUTES=0)")
+	}
+
+	// Incident summary job (daily incident subscriptions, sent at the digest's local time)
+	incidentSummaryNotifier := services.NewIncidentSummaryNotifier(db)
+	if digestInterval > 0 {
+		jobRunner.Every(models.JobKindIncidentSummary, time.Duration(digestInterval)*time.Minute, incidentSummaryNotifier.RunJob)
+		log.Printf("✅ Incident summary notifier scheduled (checking every %d min)", digestInterval)
+	}
+
+	// Data retention job (GPS breadcrumbs, diagnostic log uploads and old checks)
+	// Periods live in the retention settings; DRIVER_LOCATION_RETENTION_DAYS and DIAGNOSTIC_LOG_RETENTION_DAYS
+	// only seed them on first start, after that they're managed at /api/manager/settings/retention
+	retentionSeed := models.DefaultRetentionSettings()
+	seedRetention := false
+	if days, err := strconv.Atoi(os.Getenv("DRIVER_LOCATION_RETENTION_DAYS")); err == nil {
+		retentionSeed.DriverLocationDays = days
+		seedRetention = true
+	}
+	if days, err := strconv.Atoi(os.Getenv("DIAGNOSTIC_LOG_RETENTION_DAYS")); err == nil {
+		retentionSeed.DiagnosticLogDays = days
+		seedRetention = true
+	}
+	if seedRetention {
+		if err := retentionSeed.Validate(); err != nil {
+			log.Printf("⚠️  Ignoring retention environment variables: %v", err)
+		} else if err := database.SeedSetting(db, models.SettingKeyRetention, retentionSeed, time.Now().Unix()); err != nil {
+			log.Printf("⚠️  %v", err)
+		}
+	}
+	retentionIntervalHours := 6
+	if v := os.Getenv("DATA_RETENTION_INTERVAL_HOURS"); v != "" {
+		if hours, err := strconv.Atoi(v); err == nil {
+			retentionIntervalHours = hours
+		}
+	}
+	if retentionIntervalHours > 0 {
+		jobRunner.Every(models.JobKindDataRetention, time.Duration(retentionIntervalHours)*time.Hour, services.NewDataRetentionPurger(db).RunJob)
+		log.Printf("✅ Data retention purger scheduled (every %d h)", retentionIntervalHours)
+	} else {
+		log.Println("⚠️  Data retention purging disabled (DATA_RETENTION_INTERVAL_HOURS=0)")
+	}
+
+	jobPollInterval := 5
+	if v := os.Getenv("JOB_POLL_INTERVAL_SECONDS"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil && seconds > 0 {
+			jobPollInterval = seconds
+		}
+	}
+	jobRunner.Start(time.Duration(jobPollInterval) * time.Second)
+	log.Printf("✅ Job runner started (every %ds)", jobPollInterval)
+
+	// Route simulation for training and demos (virtual drivers on the manager map, never stored)
+	routeSimulator := services.NewRouteSimulator(db, wsHub, os.Getenv("SIMULATION_ENABLED") == "true")
+	if routeSimulator.Enabled() {
+		log.Println("✅ Route simulation enabled")
+	}
+
 	// Create router
 	r := chi.NewRouter()
 
@@ -133,17 +431,72 @@ func main() {
 	r.Use(chimiddleware.Logger)
 	r.Use(chimiddleware.Recoverer)
 	r.Use(chimiddleware.RequestID)
-	r.Use(chimiddleware.RealIP)
-
-	// CORS
-	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Content-Type", "Authorization"},
-		ExposedHeaders:   []string{"Link"},
-		AllowCredentials: false,
-		MaxAge:           300,
-	}))
+	r.Use(middleware.RequestIDHeader)
+	r.Use(middleware.RealIP(middleware.TrustedProxiesFromEnv())) // X-Forwarded-For only from TRUSTED_PROXIES
+
+	// Request locale from Accept-Language; authenticated groups re-resolve it with the user's stored preference
+	userLocaleLookup := func(userID string) (*string, error) {
+		return database.GetUserLocale(db, userID)
+	}
+	r.Use(middleware.Locale(nil))
+
+	// Tokens stay valid after a user is deactivated, so authenticated groups check the account is still active
+	userDeactivationLookup := func(userID string) (bool, error) {
+		return database.IsUserDeactivated(db, userID)
+	}
+
+	// Machine clients (sensor gateways) authenticate with API keys managed under /api/manager/api-keys
+	apiKeyLookup := func(keyHash string) (*models.APIKey, error) {
+		return database.GetActiveAPIKeyByHash(db, keyHash)
+	}
+	apiKeyMeter := func(apiKey *models.APIKey, endpoint string) (bool, int, int, error) {
+		return database.MeterAPIKeyRequest(db, apiKey, endpoint)
+	}
+
+	// Support mode tokens (admins impersonating drivers) are read-only and audited per request
+	impersonationLookup := func(sessionID string) (bool, error) {
+		return database.IsImpersonationActive(db, sessionID)
+	}
+	impersonationRecorder := func(sessionID, method, path string, status int, blocked bool) error {
+		return database.RecordImpersonatedRequest(db, sessionID, method, path, status, blocked)
+	}
+
+	// CORS (origins, methods and headers from CORS_* env vars)
+	r.Use(cors.Handler(middleware.CORSOptionsFromEnv()))
+
+	// Security headers (HSTS, nosniff, frame denial)
+	hstsMaxAge := 31536000
+	if v := os.Getenv("HSTS_MAX_AGE_SECONDS"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+			hstsMaxAge = seconds
+		} else {
+			log.Printf("⚠️  Invalid HSTS_MAX_AGE_SECONDS=%q, using default %d", v, hstsMaxAge)
+		}
+	}
+	r.Use(middleware.SecurityHeaders(hstsMaxAge))
+
+	// Request body size limit (413 beyond it)
+	maxBodyBytes := int64(10 << 20)
+	if v := os.Getenv("MAX_REQUEST_BODY_MB"); v != "" {
+		if mb, err := strconv.Atoi(v); err == nil && mb > 0 {
+			maxBodyBytes = int64(mb) << 20
+		} else {
+			log.Printf("⚠️  Invalid MAX_REQUEST_BODY_MB=%q, using default %d MB", v, maxBodyBytes>>20)
+		}
+	}
+	r.Use(middleware.LimitRequestBody(maxBodyBytes))
+
+	// Request body validation against the documented request types (400 with field errors)
+	apiSpec := handlers.APISpec()
+	r.Use(middleware.ValidateRequestBody(apiSpec))
+
+	// Unknown routes get the standard error body too
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		utils.RespondError(w, http.StatusNotFound, "Route not found")
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	})
 
 	// Health check
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -153,11 +506,36 @@ func main() {
 	// Authentication routes (no auth required)
 	r.Post("/api/auth/login", handlers.Login(db))
 
+	// API documentation (OpenAPI 3 document + Swagger UI)
+	r.Get("/api/openapi.json", apiSpec.Handler())
+	r.Get("/api/docs", apiSpec.SwaggerUIHandler("/api/openapi.json"))
+
 	// WebSocket endpoint (authentication handled in handler via query param)
 	r.Get("/ws", websocket.HandleWebSocket(wsHub, db))
 
+	// Per-request timeout for API routes (cancels in-flight queries via request context)
+	requestTimeout := 60 * time.Second
+	if v := os.Getenv("REQUEST_TIMEOUT_SECONDS"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil && seconds > 0 {
+			requestTimeout = time.Duration(seconds) * time.Second
+		} else {
+			log.Printf("⚠️  Invalid REQUEST_TIMEOUT_SECONDS=%q, using default %s", v, requestTimeout)
+		}
+	}
+	log.Printf("⏱️  API request timeout: %s", requestTimeout)
+
+	// How long a shutdown waits for in-flight requests and running jobs
+	shutdownTimeout := 30 * time.Second
+	if v := os.Getenv("SHUTDOWN_TIMEOUT_SECONDS"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil && seconds > 0 {
+			shutdownTimeout = time.Duration(seconds) * time.Second
+		}
+	}
+
 	// API routes
 	r.Route("/api", func(r chi.Router) {
+		r.Use(chimiddleware.Timeout(requestTimeout))
+
 		// Geocoding endpoints (no auth required)
 		r.Post("/geocoding/reverse", handlers.ReverseGeocode())
 		r.Post("/geocoding/reverse/batch", handlers.BatchReverseGeocode())
@@ -166,7 +544,9 @@ func main() {
 
 		// Bins endpoints
 		r.Get("/bins", handlers.GetBins(db))
+		r.Get("/bins/changes", handlers.GetBinChanges(db))        // Changed and deleted bins since a time, for the apps' cached lists
 		r.Get("/bins/priority", handlers.GetBinsWithPriority(db)) // Priority sorting & filtering
+		r.Get("/bins/nearby", handlers.GetNearbyBins(db))         // Bins within a radius of a point, nearest first
 		r.Post("/bins", handlers.CreateBin(db, wsHub))
 		r.Patch("/bins/{id}", handlers.UpdateBin(db, wsHub))
 		r.Delete("/bins/{id}", handlers.DeleteBin(db, wsHub))
@@ -176,22 +556,27 @@ func main() {
 		// Checks endpoints
 		r.Get("/bins/{id}/checks", handlers.GetChecks(db))
 		r.Get("/checks", handlers.GetAllChecks(db))
+		r.Get("/bins/{id}/photos", handlers.GetBinPhotos(db)) // Photo gallery (checks, incidents, maintenance)
+		r.Get("/bins/{id}/fill-history", handlers.GetBinFillHistory(db)) // Fill level over time for trend charts
 
 		// Moves endpoints
 		r.Get("/bins/{id}/moves", handlers.GetMoves(db))
 		r.Post("/bins/{id}/moves", handlers.CreateMove(db))
+		r.Get("/bins/{id}/location-history", handlers.GetBinLocationHistory(db)) // Dated location segments for a map timeline
 		r.Get("/bins/{id}/move-requests", handlers.GetBinMoveRequestsByBinID(db))
 
 		// Route management endpoints (route blueprints/templates)
 		r.Get("/routes", handlers.GetRoutes(db))
 		r.Get("/routes/{id}", handlers.GetRoute(db))
 		r.Post("/routes", handlers.CreateRoute(db))
+		r.Post("/routes/from-tags", handlers.GenerateRouteFromTags(db)) // Route from the active bins with some tags
 		r.Post("/routes/optimize-preview", handlers.OptimizeRoutePreview(db))
 		r.Post("/routes/test-here-optimization", handlers.TestHereOptimization(db))   // Testing endpoint for HERE Maps API
 		r.Post("/routes/test-mapbox-optimization", handlers.TestMapboxOptimization(db)) // Testing endpoint for Mapbox API v1
 		r.Patch("/routes/{id}", handlers.UpdateRoute(db))
 		r.Delete("/routes/{id}", handlers.DeleteRoute(db))
 		r.Post("/routes/{id}/duplicate", handlers.DuplicateRoute(db))
+		r.Get("/routes/{id}/versions", handlers.GetRouteVersions(db)) // Blueprint history with bin membership diffs
 
 		// No-Go Zones endpoints
 		r.Get("/no-go-zones", handlers.GetNoGoZones(db))
@@ -203,6 +588,9 @@ func main() {
 
 		// Analytics endpoints
 		r.Get("/analytics/areas", handlers.GetAreaPerformance(db))
+		r.Get("/analytics/routes/{id}/efficiency", handlers.GetRouteEfficiency(db)) // Planned vs actual across a route's shifts
+		r.Get("/analytics/coverage", handlers.GetCoverageReport(db))                // Bins with no checks, route stops or visits in a date range
+		r.Get("/analytics/capacity-plan", handlers.GetCapacityPlan(db))             // Drivers needed per day from the fill forecast, by area
 
 		// Potential Locations endpoints (managers can view all - no auth required)
 		r.Get("/potential-locations", handlers.GetPotentialLocations(db))
@@ -210,28 +598,46 @@ func main() {
 		// Driver shift endpoints (require authentication)
 		r.Group(func(r chi.Router) {
 			r.Use(middleware.Auth)
+			r.Use(middleware.RejectDeactivated(userDeactivationLookup))
+			r.Use(middleware.SupportMode(impersonationLookup, impersonationRecorder))
+			r.Use(middleware.Locale(userLocaleLookup))
 
 			// Auth status endpoint
 			r.Get("/auth/status", handlers.GetAuthStatus(db))
+			r.Put("/auth/locale", handlers.UpdateMyLocale(db))
+			r.Get("/config/client", handlers.GetClientConfig(db)) // App version gating and feature flags for this user
 
 			// Shift management
 			r.Get("/driver/shift/current", handlers.GetCurrentShift(db))
-			r.Post("/driver/shift/start", handlers.StartShift(db, wsHub))
+			r.Get("/driver/shift/pre-start-checklist", handlers.GetDriverPreStartChecklist(db))
+			r.Post("/driver/shift/pre-start-checklist", handlers.SubmitPreStartChecklist(db)) // Required before start when items are defined
+			r.Post("/driver/shift/start", handlers.StartShift(db, wsHub, hereWaypointsService))
 			r.Post("/driver/shift/pause", handlers.PauseShift(db, wsHub))
 			r.Post("/driver/shift/resume", handlers.ResumeShift(db, wsHub))
 			r.Post("/driver/shift/end", handlers.EndShift(db, wsHub))
 			r.Post("/driver/shift/complete-bin", handlers.CompleteBin(db, wsHub))
+			r.Post("/driver/shift/complete-pickup", handlers.CompletePickup(db, wsHub))   // Move request pickup waypoint
+			r.Post("/driver/shift/complete-dropoff", handlers.CompleteDropoff(db, wsHub)) // Move request dropoff waypoint
+			r.Post("/driver/moves/{id}/confirm-pickup", handlers.ConfirmMovePickup(db, wsHub))   // Pickup leg with photo/signature (relocation → picked_up)
+			r.Post("/driver/moves/{id}/confirm-dropoff", handlers.ConfirmMoveDropoff(db, wsHub)) // Dropoff leg: completes the move and relocates the bin
+			r.Get("/driver/shift/next-stop", handlers.GetNextStop(db, directionsService))   // Next stop with ETA + navigation deep links
 
 			// Shift history
 			r.Get("/driver/shift-history", handlers.GetDriverShiftHistory(db))
+			r.Get("/driver/earnings", handlers.GetDriverEarnings(db)) // Credits by week/month
 			r.Get("/driver/shift-details", handlers.GetShiftDetails(db))
 			r.Get("/driver/shift-move-requests", handlers.GetShiftMoveRequests(db))
 
 			// Location tracking (sent every 10 seconds during active shift)
 			r.Post("/driver/location", handlers.UpdateLocation(db, wsHub))
+			r.Post("/driver/locations/batch", handlers.UploadLocationBatch(db, wsHub)) // Buffered points, thinned server-side
+
+			// Field observations (incidents noticed outside a check; counted on the active shift)
+			r.Post("/driver/field-observations", handlers.ReportFieldObservation(db))
+			r.Get("/incident-types", handlers.GetIncidentTypes(db)) // Types drivers can report, most severe first
 
 			// FCM token registration
-			r.Post("/driver/fcm-token", handlers.RegisterFCMToken(db))
+			r.Post("/driver/fcm-token", handlers.RegisterFCMToken(db, fcmService))
 
 			// Route Task endpoints (task-based shift system)
 			r.Get("/shifts/{shiftId}/tasks", handlers.GetShiftTasks(db))
@@ -241,6 +647,10 @@ func main() {
 			// Potential Locations (drivers can create requests)
 			r.Post("/potential-locations", handlers.CreatePotentialLocation(db, wsHub))
 
+			// Move requests (non-admin requests wait for an admin to approve or reject them)
+			r.Post("/move-requests", handlers.ScheduleBinMove(db, wsHub, fcmService))
+			r.Get("/move-requests/mine", handlers.GetMyMoveRequests(db))
+
 			// Incident reporting (drivers can report both check-based and field observations)
 			// TODO: Implement CreateZoneIncident handler (currently handled in CompleteBin)
 			// r.Post("/zone-incidents", handlers.CreateZoneIncident(db))
@@ -249,56 +659,293 @@ func main() {
 		// Diagnostic logging endpoint (no auth required for easier debugging)
 		r.Post("/api/logs/diagnostic", handlers.ReceiveDiagnosticLog(db))
 
+		// IoT sensor ingestion (API key with the sensor_ingest scope)
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireAPIKey(apiKeyLookup, models.APIKeyScopeSensorIngest))
+			r.Post("/ingest/sensor-readings", handlers.IngestSensorReadings(db, wsHub))
+		})
+
+		// Public bin locator for embedding on public sites (API key with the bin_locator scope, rate limited per key)
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireAPIKey(apiKeyLookup, models.APIKeyScopeBinLocator))
+			r.Use(middleware.RateLimitAPIKey(apiKeyMeter))
+			r.Get("/public/bins", handlers.GetPublicBins(db))
+		})
+
 		// Manager endpoints (require authentication + admin role)
 		r.Group(func(r chi.Router) {
 			r.Use(middleware.Auth)
+			r.Use(middleware.RejectDeactivated(userDeactivationLookup))
+			r.Use(middleware.SupportMode(impersonationLookup, impersonationRecorder))
 			r.Use(middleware.RequireRole("admin"))
+			r.Use(middleware.Locale(userLocaleLookup))
 
 			r.Post("/manager/assign-route", handlers.AssignRoute(db, wsHub, fcmService))
+			r.Post("/manager/assign-route/preview", handlers.PreviewRouteAssignment(db)) // Projected driver workload vs the limits
+			r.Post("/manager/assign-route/split/preview", handlers.PreviewRouteSplit(db)) // Bin set split across several drivers
+			r.Post("/manager/assign-route/split", handlers.AssignSplitRoute(db, wsHub, fcmService)) // One shift per driver, all or nothing
+			r.Post("/manager/assign-routes", handlers.AssignRoutes(db, wsHub, fcmService)) // Several driver/route pairs, per-item results or all_or_nothing
+			r.Get("/manager/assign-route/recommendations", handlers.GetRouteAssignmentRecommendations(db)) // Drivers ranked by familiarity, proximity, workload
+			r.Get("/manager/shift-history", handlers.GetShiftHistory(db)) // Ended shifts with filters and summary
+			r.Get("/manager/export/payroll", handlers.ExportPayroll(db)) // Per-driver hours in payroll's CSV schema, optionally locking the period
+			r.Get("/manager/payroll/periods", handlers.GetPayrollPeriods(db))
+			r.Post("/manager/payroll/periods/{id}/unlock", handlers.UnlockPayrollPeriod(db))
 			r.Put("/manager/shifts/{id}/cancel", handlers.CancelShift(db, wsHub, fcmService))
+			r.Put("/manager/shifts/{id}/reorder", handlers.ReorderShiftRoute(db, wsHub))
+			r.Post("/manager/shifts/{id}/reoptimize", handlers.ReoptimizeShiftRoute(db, routeReoptimizer)) // Debounced background re-optimization
+			r.Post("/manager/shifts/{id}/start-on-behalf", handlers.StartShiftOnBehalf(db, wsHub, hereWaypointsService)) // Driver's phone down: act for them, audited
+			r.Post("/manager/shifts/{id}/complete-bin-on-behalf", handlers.CompleteBinOnBehalf(db, wsHub))
+			r.Get("/manager/on-behalf-actions", handlers.GetOnBehalfActions(db))
+			r.Get("/manager/shifts/{id}/polyline", handlers.GetShiftPolyline(db, directionsService)) // Route line, cached per stop sequence
+			r.Get("/manager/shifts/{id}/timeline", handlers.GetShiftTimeline(db)) // Replay: merged event stream
+			r.Get("/manager/shifts/{id}/debrief", handlers.GetShiftDebrief(db)) // Driver's end-of-shift debrief and notes
+			r.Post("/manager/shift-notes/{id}/dismiss", handlers.DismissShiftNote(db))
 			r.Post("/manager/shifts/cancel-all-active", handlers.CancelAllActiveShifts(db, wsHub, fcmService))
+			r.Post("/manager/shifts/repair-sequence", handlers.RepairShiftSequences(db)) // Fix duplicate/gapped shift stop sequence_order
 			r.Delete("/manager/shifts/clear", handlers.ClearAllShifts(db, wsHub))
 
 			// Task-based shift creation (agnostic shift builder)
 			r.Post("/manager/shifts/create-with-tasks", handlers.CreateShiftWithTasks(db, wsHub))
 			r.Get("/manager/shifts/{shiftId}", handlers.GetShiftByID(db))
 
+			// Recurring shift templates (materialized into tomorrow's ready shifts)
+			r.Get("/manager/shift-templates", handlers.GetShiftTemplates(db))
+			r.Post("/manager/shift-templates", handlers.CreateShiftTemplate(db))
+			r.Get("/manager/shift-templates/runs", handlers.GetShiftTemplateRuns(db)) // Created shifts and logged conflicts
+			r.Post("/manager/shift-templates/materialize", handlers.RunShiftTemplateMaterializer(shiftTemplateMaterializer))
+			r.Put("/manager/shift-templates/{id}", handlers.UpdateShiftTemplate(db))
+			r.Delete("/manager/shift-templates/{id}", handlers.DeleteShiftTemplate(db))
+
 			// One-time data migration endpoints (can be removed after use)
 			r.Post("/manager/bins/load-real", handlers.LoadRealBins(db))
 			r.Post("/manager/bins/fix-status", handlers.FixBinStatus(db))
+			r.Patch("/manager/bins/bulk", handlers.BulkUpdateBins(db, wsHub)) // Same field updates on many bins, per-bin results
+			// Bins on more than one open shift (stops assigned before reservations were enforced)
+			r.Get("/manager/bins/reservation-conflicts", handlers.GetBinReservationConflicts(db))
 
+			r.Get("/manager/bins", handlers.GetBins(db)) // Same as /bins, limited to the manager's areas
 			// Bin move request management
 			r.Post("/manager/bins/schedule-move", handlers.ScheduleBinMove(db, wsHub, fcmService))
 			r.Get("/manager/bins/move-requests", handlers.GetBinMoveRequests(db))            // List all move requests (register first - exact match)
 			r.Get("/manager/bins/move-requests/{id}", handlers.GetBinMoveRequest(db))        // Get single move request (register after)
 			r.Put("/manager/bins/move-requests/{id}", handlers.UpdateBinMoveRequest(db, wsHub)) // Update move request
 			r.Post("/manager/bins/move-requests/{id}/assign-to-shift", handlers.AssignMoveToShift(db, wsHub, fcmService))
+			r.Post("/manager/bins/move-requests/{id}/assign-to-shift/preview", handlers.PreviewAssignMoveToShift(db))
 			r.Put("/manager/bins/move-requests/{id}/cancel", handlers.CancelBinMoveRequest(db, wsHub))
+			r.Put("/manager/bins/move-requests/{id}/approve", handlers.ApproveMoveRequest(db, wsHub))
+			r.Put("/manager/bins/move-requests/{id}/reject", handlers.RejectMoveRequest(db, wsHub))
 			r.Put("/manager/bins/move-requests/{id}/assign-to-user", handlers.AssignMoveToUser(db))
 			r.Put("/manager/bins/move-requests/{id}/clear-assignment", handlers.ClearMoveAssignment(db))
 			r.Put("/manager/bins/move-requests/{id}/complete-manually", handlers.ManuallyCompleteMoveRequest(db))
 			r.Get("/manager/bins/move-requests/{id}/history", handlers.GetMoveRequestHistory(db)) // Get audit trail
+			r.Put("/manager/bins/move-requests/{id}/instructions", handlers.UpdateMoveRequestInstructions(db, wsHub))
+			r.Put("/manager/bins/move-requests/{id}/time-window", handlers.SetMoveRequestTimeWindow(db, wsHub))
+			r.Get("/manager/bins/move-requests/{id}/attachments", handlers.GetMoveRequestAttachments(db))
+			r.Post("/manager/bins/move-requests/{id}/attachments", handlers.AddMoveRequestAttachment(db, wsHub))
+			r.Delete("/manager/bins/move-requests/{id}/attachments/{attachmentId}", handlers.DeleteMoveRequestAttachment(db, wsHub))
 
-			// Bin check recommendations (7-day stale bin flagging)
+			// Bin check recommendations (7-day stale bin flagging + recommendation engine)
 			r.Post("/manager/bins/flag-stale", handlers.FlagStaleBins(db))
 			r.Get("/manager/bins/check-recommendations", handlers.GetBinCheckRecommendations(db))
 			r.Put("/manager/bins/check-recommendations/{id}/dismiss", handlers.DismissBinCheckRecommendation(db))
+			r.Post("/manager/bins/check-recommendations/{id}/convert", handlers.ConvertCheckRecommendationToShift(db, wsHub))
+			r.Post("/manager/bins/check-recommendations/run", handlers.RunCheckRecommendationEngine(checkRecommendationEngine))
+
+			// Bin maintenance (repaints, repairs, scheduled work)
+			r.Get("/manager/bins/{id}/maintenance", handlers.GetBinMaintenance(db))
+			r.Post("/manager/bins/{id}/maintenance", handlers.CreateBinMaintenance(db))
+			r.Get("/manager/bins/{id}/notes", handlers.GetBinNotes(db)) // Driver notes from shift debriefs
+			r.Get("/manager/maintenance", handlers.GetMaintenanceSchedule(db))
+			r.Put("/manager/maintenance/{id}", handlers.UpdateBinMaintenance(db))
+			r.Get("/manager/analytics/maintenance-costs", handlers.GetMaintenanceCosts(db))
+			r.Get("/manager/analytics/route-costs", handlers.GetRouteCostVariance(db)) // Estimated vs actual shift cost by route and month
+
+			// Coverage report bins (GET /api/analytics/coverage) → new draft route
+			r.Post("/manager/analytics/coverage/draft-route", handlers.CreateCoverageDraftRoute(db))
+
+			// Areas (city/region polygon boundaries)
+			r.Get("/manager/areas", handlers.GetAreas(db))
+			r.Post("/manager/areas", handlers.CreateArea(db, areaAssigner))
+			r.Post("/manager/areas/assign", handlers.RunAreaAssignment(areaAssigner))
+			r.Put("/manager/areas/{id}", handlers.UpdateArea(db, areaAssigner))
+			r.Put("/manager/areas/{id}/photo-required", handlers.SetAreaPhotoRequired(db))
+			r.Get("/manager/photo-requirements", handlers.GetPhotoRequirements(db))
+			r.Get("/manager/analytics/photo-compliance", handlers.GetPhotoComplianceReport(db))
+			r.Get("/manager/analytics/remote-completions", handlers.GetRemoteCompletionReport(db)) // Stops completed away from the stop, per driver
+			r.Get("/manager/analytics/notification-deliveries", handlers.GetPushDeliveryReport(db)) // Push delivery outcomes per driver
+			r.Delete("/manager/areas/{id}", handlers.DeleteArea(db, areaAssigner))
+
+			// Manager area assignments (scope manager lists and live updates; all_areas=true overrides)
+			r.Get("/manager/managers", handlers.GetManagerAreas(db))
+			r.Put("/manager/managers/{id}/areas", handlers.SetManagerAreas(db, wsHub))
+
+			// Bin tags (labels for grouping bins, many per bin)
+			r.Get("/manager/tags", handlers.GetTags(db))
+			r.Post("/manager/tags", handlers.CreateTag(db))
+			r.Put("/manager/tags/{id}", handlers.UpdateTag(db))
+			r.Delete("/manager/tags/{id}", handlers.DeleteTag(db))
+			r.Post("/manager/tags/{id}/bins", handlers.AddTagToBins(db))
+			r.Delete("/manager/tags/{id}/bins/{binId}", handlers.RemoveTagFromBin(db))
+			r.Put("/manager/bins/{id}/tags", handlers.SetBinTags(db))
+
+			// Incident types (categories with severity and zone impact; zone_incidents references them)
+			r.Get("/manager/incident-types", handlers.GetManagedIncidentTypes(db))
+			r.Post("/manager/incident-types", handlers.CreateIncidentType(db))
+			r.Put("/manager/incident-types/{key}", handlers.UpdateIncidentType(db))
+			r.Delete("/manager/incident-types/{key}", handlers.DeleteIncidentType(db))
+			r.Get("/manager/incident-subscriptions", handlers.GetIncidentSubscriptions(db)) // Which new incidents notify me, how and when
+			r.Post("/manager/incident-subscriptions", handlers.CreateIncidentSubscription(db))
+			r.Put("/manager/incident-subscriptions/{id}", handlers.UpdateIncidentSubscription(db))
+			r.Delete("/manager/incident-subscriptions/{id}", handlers.DeleteIncidentSubscription(db))
+
+			// Org-level settings (priority scoring weights)
+			r.Get("/manager/settings/priority-weights", handlers.GetPriorityWeights(db))
+			r.Put("/manager/settings/priority-weights", handlers.UpdatePriorityWeights(db))
+			r.Get("/manager/settings/earnings-rates", handlers.GetEarningsRates(db))
+			r.Put("/manager/settings/earnings-rates", handlers.UpdateEarningsRates(db))
+			r.Get("/manager/settings/move-sla", handlers.GetMoveSLAPolicy(db))
+			r.Put("/manager/settings/move-sla", handlers.UpdateMoveSLAPolicy(db))
+			r.Get("/manager/settings/client-config", handlers.GetClientConfigSettings(db))
+			r.Put("/manager/settings/client-config", handlers.UpdateClientConfig(db))
+			r.Get("/manager/settings/workload-limits", handlers.GetWorkloadLimits(db))
+			r.Put("/manager/settings/workload-limits", handlers.UpdateWorkloadLimits(db))
+			r.Get("/manager/settings/digest", handlers.GetDigestSettings(db))
+			r.Put("/manager/settings/digest", handlers.UpdateDigestSettings(db))
+			r.Get("/manager/settings/undo", handlers.GetUndoSettings(db))
+			r.Put("/manager/settings/undo", handlers.UpdateUndoSettings(db))
+			r.Get("/manager/settings/zone-risk-routing", handlers.GetZoneRiskRoutingSettings(db))
+			r.Put("/manager/settings/zone-risk-routing", handlers.UpdateZoneRiskRoutingSettings(db))
+			r.Get("/manager/settings/retention", handlers.GetRetentionSettings(db))
+			r.Put("/manager/settings/retention", handlers.UpdateRetentionSettings(db))
+			r.Get("/manager/settings/service-hours", handlers.GetServiceHoursSettings(db))
+			r.Put("/manager/settings/service-hours", handlers.UpdateServiceHoursSettings(db))
+			r.Get("/manager/settings/cost-rates", handlers.GetCostRates(db))
+			r.Put("/manager/settings/cost-rates", handlers.UpdateCostRates(db))
+			r.Get("/manager/settings/check-form", handlers.GetCheckForm(db))
+			r.Put("/manager/settings/check-form", handlers.UpdateCheckForm(db))
+			r.Get("/manager/settings/shift-debrief", handlers.GetDebriefSettings(db))
+			r.Put("/manager/settings/shift-debrief", handlers.UpdateDebriefSettings(db))
+			r.Get("/manager/settings/check-in-proximity", handlers.GetCheckInProximitySettings(db))
+			r.Put("/manager/settings/check-in-proximity", handlers.UpdateCheckInProximitySettings(db))
+			r.Get("/manager/settings/fill-thresholds", handlers.GetFillThresholds(db))
+			r.Put("/manager/settings/fill-thresholds", handlers.UpdateFillThresholds(db))
+			r.Get("/manager/settings/payroll-export", handlers.GetPayrollExportSettings(db))
+			r.Put("/manager/settings/payroll-export", handlers.UpdatePayrollExportSettings(db))
+			r.Get("/manager/settings/incident-dedup", handlers.GetIncidentDedupSettings(db))
+			r.Put("/manager/settings/incident-dedup", handlers.UpdateIncidentDedupSettings(db))
+
+			// Move request SLA compliance
+			r.Get("/manager/analytics/move-sla", handlers.GetMoveSLAReport(db))
+			r.Get("/manager/analytics/dwell-time", handlers.GetDwellTimeReport(db)) // GPS arrival-to-departure time at stops
+			r.Post("/manager/move-sla/check", handlers.RunMoveSLACheck(moveSLAChecker))
+
+			// Undo window for destructive actions (move request cancel / clear assignment)
+			r.Get("/manager/undo", handlers.GetPendingUndos(db))
+			r.Post("/manager/undo/{operation_id}", handlers.UndoOperation(db, wsHub))
+
+			// Daily bins-at-risk digest
+			r.Get("/manager/digest", handlers.GetManagerDigest(db))
+			r.Post("/manager/digest/send", handlers.SendManagerDigest(binsAtRiskDigester))
 
 			// Bin retirement
 			r.Post("/manager/bins/{id}/retire", handlers.RetireBin(db))
+			r.Put("/manager/bins/{id}/time-window", handlers.SetBinTimeWindow(db, wsHub))
+			r.Put("/manager/bins/{id}/photo-required", handlers.SetBinPhotoRequired(db, wsHub))
+
+			// Duplicate bins from imports (find, merge into the surviving bin, audit log)
+			r.Get("/manager/bins/duplicates", handlers.GetBinDuplicates(db))
+			r.Get("/manager/bins/merges", handlers.GetBinMerges(db))
+			r.Post("/manager/bins/{id}/merge-into/{targetId}", handlers.MergeBin(db, wsHub))
+			// Orphaned checks/moves/incidents/move requests after imports (proposed remaps, apply, audit log)
+			r.Get("/manager/bins/reconciliation", handlers.GetBinReconciliation(db))
+			r.Post("/manager/bins/reconciliation/apply", handlers.ApplyBinReconciliation(db))
+			r.Get("/manager/bins/reconciliation/history", handlers.GetBinReconciliations(db))
+
+			// Bins without coordinates (left out of routes until geocoded)
+			r.Get("/manager/bins/needs-geocoding", handlers.GetBinsNeedingGeocoding(db))
 
 			// Potential Locations management (managers can delete and convert)
 			r.Delete("/potential-locations/{id}", handlers.DeletePotentialLocation(db, wsHub))
 			r.Post("/potential-locations/{id}/convert", handlers.ConvertPotentialLocationToBin(db, wsHub))
 
+			// Push notification broadcast (FCM role/organization topics)
+			r.Post("/manager/notifications/broadcast", handlers.BroadcastNotification(fcmService))
+
+			// Webhooks for external systems (billing, ...)
+			r.Get("/manager/webhooks", handlers.GetWebhooks(db))
+			r.Post("/manager/webhooks", handlers.CreateWebhook(db))
+			r.Put("/manager/webhooks/{id}", handlers.UpdateWebhook(db))
+			r.Delete("/manager/webhooks/{id}", handlers.DeleteWebhook(db))
+			r.Post("/manager/webhooks/{id}/test", handlers.TestWebhook(db))
+			r.Get("/manager/webhooks/{id}/deliveries", handlers.GetWebhookDeliveries(db))
+			r.Post("/manager/webhooks/deliveries/{id}/redrive", handlers.RedriveWebhookDelivery(db))
+
+			// Checks flagged by the anomaly detector
+			r.Get("/manager/checks/review-queue", handlers.GetCheckReviewQueue(db))
+			r.Post("/manager/checks/{id}/review", handlers.ReviewCheck(db))
+
+			// API keys for machine clients (sensor gateways)
+			r.Get("/manager/api-keys", handlers.GetAPIKeys(db))
+			r.Post("/manager/api-keys", handlers.CreateAPIKey(db))
+			r.Get("/manager/api-keys/usage", handlers.GetAPIKeyUsage(db)) // Metered traffic per key (dashboard)
+			r.Patch("/manager/api-keys/{id}", handlers.UpdateAPIKey(db))
+			r.Delete("/manager/api-keys/{id}", handlers.RevokeAPIKey(db))
+
+			// IoT fill sensor registry
+			r.Get("/manager/sensors", handlers.GetBinSensors(db))
+			r.Post("/manager/sensors", handlers.CreateBinSensor(db))
+			r.Put("/manager/sensors/{id}", handlers.UpdateBinSensor(db))
+			r.Delete("/manager/sensors/{id}", handlers.DeleteBinSensor(db))
+
 			// Fleet management
 			r.Get("/manager/drivers", handlers.GetAllDrivers(db))
+			r.Get("/manager/drivers/{id}/familiarity", handlers.GetDriverFamiliarity(db))
+			r.Get("/manager/drivers/{id}/notification-deliveries", handlers.GetDriverPushDeliveries(db)) // Per-device FCM delivery log
 			r.Get("/manager/active-drivers", handlers.GetActiveDrivers(db))
+			r.Get("/manager/fleet/live", handlers.GetLiveFleet(db, wsHub)) // Connected drivers with staleness + ETA to current stop
+			r.Get("/manager/websocket/clients", handlers.GetWebSocketClients(db, wsHub))
+			r.Post("/manager/websocket/clients/{userId}/disconnect", handlers.DisconnectWebSocketClient(db, wsHub))
 			r.Get("/manager/driver-shift-details", handlers.GetDriverShiftDetails(db))
 
+			// Route simulation (virtual drivers for training and demos)
+			r.Get("/manager/simulations", handlers.GetSimulations(routeSimulator))
+			r.Post("/manager/simulations", handlers.StartSimulation(routeSimulator))
+			r.Get("/manager/simulations/{id}", handlers.GetSimulation(routeSimulator))
+			r.Post("/manager/simulations/{id}/stop", handlers.StopSimulation(routeSimulator))
+
+			// Pre-start vehicle inspection
+			r.Get("/manager/pre-start-checklist/items", handlers.GetPreStartChecklistItems(db))
+			r.Post("/manager/pre-start-checklist/items", handlers.CreatePreStartChecklistItem(db))
+			r.Put("/manager/pre-start-checklist/items/{id}", handlers.UpdatePreStartChecklistItem(db))
+			r.Delete("/manager/pre-start-checklist/items/{id}", handlers.DeletePreStartChecklistItem(db))
+			r.Get("/manager/pre-start-checklists", handlers.GetPreStartChecklists(db))
+			r.Get("/manager/shifts/{id}/pre-start-checklist", handlers.GetShiftPreStartChecklist(db))
+
 			// User management
 			r.Get("/users", handlers.GetAllUsers(db))
 			r.Post("/users", handlers.CreateUser(db))
+			r.Patch("/manager/users/{id}", handlers.UpdateUser(db, wsHub))
+			r.Post("/manager/users/{id}/unlock", handlers.UnlockUserAccount(db))
+			r.Get("/manager/users/{id}/data-export", handlers.ExportUserData(db))
+			r.Post("/manager/users/{id}/anonymize", handlers.AnonymizeUser(db))
+
+			// Support mode: see the app as a driver with a short-lived read-only token (every request is audited)
+			r.Post("/manager/users/{id}/impersonate", handlers.StartImpersonation(db))
+			r.Get("/manager/impersonations", handlers.GetImpersonationSessions(db))
+			r.Get("/manager/impersonations/{id}/requests", handlers.GetImpersonatedRequests(db))
+			r.Post("/manager/impersonations/{id}/end", handlers.EndImpersonation(db))
+
+			// Security audit log (logins, lockouts, unlocks)
+			r.Get("/manager/security/events", handlers.GetSecurityEvents(db))
+			r.Get("/manager/security/lockouts", handlers.GetLoginLockouts(db))
+
+			// Mobile diagnostic logs uploaded to POST /api/logs/diagnostic
+			r.Get("/manager/logs/diagnostic", handlers.GetDiagnosticLogs(db))
+
+			// Background jobs (retention, digests, summaries): failures, dead letters and retries
+			r.Get("/manager/jobs", handlers.GetJobs(db))
+			r.Get("/manager/jobs/stats", handlers.GetJobStats(db))
+			r.Post("/manager/jobs/{id}/retry", handlers.RetryJob(db))
 
 			// No-Go Zone management (admin only)
 			// TODO: Implement admin zone management handlers
@@ -309,8 +956,25 @@ func main() {
 			// Field observations management
 			r.Get("/field-observations", handlers.GetFieldObservations(db))
 			r.Patch("/field-observations/{id}/verify", handlers.VerifyFieldObservation(db))
+
+			// Drivers' visits to no-go zones (behind the driver_entered_zone / driver_exited_zone alerts)
+			r.Get("/manager/zone-visits", handlers.GetZoneVisits(db))
+
+			// Incident reporting for the city (quarterly export, backfill from their spreadsheets)
+			r.Get("/manager/export/incidents", handlers.ExportIncidents(db))
+			r.Post("/manager/import/incidents", handlers.ImportIncidents(db))
+
+			// Incidents filtered by the labels detected on their photos (graffiti, overflowing, damage)
+			r.Get("/manager/incidents", handlers.GetTaggedIncidents(db))
+
+			// Saved views for the bins and move request tables
+			r.Get("/manager/saved-views", handlers.GetSavedViews(db))
+			r.Post("/manager/saved-views", handlers.CreateSavedView(db))
+			r.Put("/manager/saved-views/{id}", handlers.UpdateSavedView(db))
+			r.Delete("/manager/saved-views/{id}", handlers.DeleteSavedView(db))
 		})
 	})
+	apiSpec.ReportUndocumented(r)
 
 	// Get port
 	log.Println("🔍 Checking PORT environment variable...")
@@ -328,8 +992,26 @@ func main() {
 	log.Println("🔌 Ready to accept requests!")
 	log.Println("═══════════════════════════════════════════════════════════════════")
 
-	// Start server
-	if err := http.ListenAndServe(":"+port, r); err != nil {
+	// Start server; SIGINT/SIGTERM stop accepting requests and let in-flight requests and jobs finish
+	server := &http.Server{Addr: ":" + port, Handler: r}
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		signals, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		<-signals.Done()
+
+		log.Printf("🛑 Shutting down (waiting up to %s for requests and jobs)...", shutdownTimeout)
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			log.Printf("⚠️  HTTP server shutdown: %v", err)
+		}
+		if err := jobRunner.Shutdown(ctx); err != nil {
+			log.Printf("⚠️  Job runner shutdown: %v (interrupted jobs run again on the next start)", err)
+		}
+	}()
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 		log.Println("❌ FATAL ERROR: Server failed to start")
 		log.Printf("   Error: %v", err)
@@ -337,4 +1019,16 @@ func main() {
 		log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 		log.Fatal(err)
 	}
+	<-shutdownDone
+	log.Println("👋 Server stopped")
+}
+
+// alertFCMInitFailed reports that push notifications are disabled for this run
+func alertFCMInitFailed(alerter *services.Alerter, err error) {
+	go alerter.Notify(services.Alert{
+		Event:    services.AlertEventFCMInitFailed,
+		Severity: services.AlertCritical,
+		Title:    "Firebase Cloud Messaging failed to initialize",
+		Message:  "Push notifications are disabled until the server restarts with valid credentials: " + err.Error(),
+	})
 }
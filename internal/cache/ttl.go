@@ -0,0 +1,107 @@
+// Package cache is a small in-memory read-through cache for hot lookups (user names, bin summaries, settings).
+//
+// Entries expire after a TTL and writers invalidate the keys they change, so an instance never serves its own
+// stale writes; other instances catch up within the TTL. Failed loads are not cached.
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultTTL is how long lookups are cached unless SetTTL is called
+const DefaultTTL = 30 * time.Second
+
+// ttl applies to every cache; stored as nanoseconds so it can change after the caches exist
+var ttl atomic.Int64
+
+func init() {
+	ttl.Store(int64(DefaultTTL))
+}
+
+// SetTTL changes how long new entries live in every cache (0 disables caching)
+func SetTTL(d time.Duration) {
+	ttl.Store(int64(d))
+}
+
+type entry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// TTL caches values by key, holding at most maxEntries
+type TTL[K comparable, V any] struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[K]entry[V]
+}
+
+// New returns an empty cache holding at most maxEntries
+func New[K comparable, V any](maxEntries int) *TTL[K, V] {
+	return &TTL[K, V]{maxEntries: maxEntries, entries: make(map[K]entry[V])}
+}
+
+// Get returns the cached value for key, if present and not expired
+func (c *TTL[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok || time.Now().After(e.expiresAt) {
+		var zero V
+		return zero, false
+	}
+	return e.value, true
+}
+
+// Set caches value for key
+// When full, expired entries are dropped first; if none expired the cache starts over
+func (c *TTL[K, V]) Set(key K, value V) {
+	lifetime := time.Duration(ttl.Load())
+	if lifetime <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if _, exists := c.entries[key]; !exists && len(c.entries) >= c.maxEntries {
+		for k, e := range c.entries {
+			if now.After(e.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= c.maxEntries {
+			c.entries = make(map[K]entry[V])
+		}
+	}
+	c.entries[key] = entry[V]{value: value, expiresAt: now.Add(lifetime)}
+}
+
+// Delete invalidates keys (after a write)
+func (c *TTL[K, V]) Delete(keys ...K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		delete(c.entries, key)
+	}
+}
+
+// Clear invalidates everything (after a bulk write)
+func (c *TTL[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[K]entry[V])
+}
+
+// GetOrLoad returns the cached value for key, calling load and caching its result on a miss
+func (c *TTL[K, V]) GetOrLoad(key K, load func() (V, error)) (V, error) {
+	if value, ok := c.Get(key); ok {
+		return value, nil
+	}
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+	c.Set(key, value)
+	return value, nil
+}
@@ -0,0 +1,53 @@
+package database
+
+import (
+	"log"
+
+	"ropacal-backend/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// backfillMoveRequestAddresses parses the one-line addresses of move requests created before the structured
+// address columns existed. Failures are logged; the rows are retried at the next startup
+func backfillMoveRequestAddresses(db *sqlx.DB) {
+	var rows []struct {
+		ID              string  `db:"id"`
+		OriginalAddress string  `db:"original_address"`
+		NewAddress      *string `db:"new_address"`
+	}
+	err := db.Select(&rows, `
+		SELECT id, original_address, new_address
+		FROM bin_move_requests
+		WHERE (original_street IS NULL AND original_address <> '')
+		   OR (new_street IS NULL AND new_address IS NOT NULL AND new_address <> '')
+	`)
+	if err != nil {
+		log.Printf("⚠️  Failed to load move request addresses to backfill: %v", err)
+		return
+	}
+	if len(rows) == 0 {
+		return
+	}
+
+	for _, row := range rows {
+		var moveRequest models.BinMoveRequest
+		moveRequest.SetOriginalAddress(models.ParseAddress(row.OriginalAddress))
+		if row.NewAddress != nil {
+			moveRequest.SetNewAddress(models.ParseAddress(*row.NewAddress))
+		}
+		_, err := db.Exec(`
+			UPDATE bin_move_requests
+			SET original_street = COALESCE(original_street, $2), original_city = COALESCE(original_city, $3),
+			    original_zip = COALESCE(original_zip, $4), new_street = COALESCE(new_street, $5),
+			    new_city = COALESCE(new_city, $6), new_zip = COALESCE(new_zip, $7)
+			WHERE id = $1
+		`, row.ID, moveRequest.OriginalStreet, moveRequest.OriginalCity, moveRequest.OriginalZip,
+			moveRequest.NewStreet, moveRequest.NewCity, moveRequest.NewZip)
+		if err != nil {
+			log.Printf("⚠️  Failed to backfill addresses of move request %s: %v", row.ID, err)
+			return
+		}
+	}
+	log.Printf("✓ Parsed the addresses of %d move requests", len(rows))
+}
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"ropacal-backend/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// apiKeyTouchInterval limits last_used_at writes to one per key per minute
+const apiKeyTouchInterval = 60
+
+// GetActiveAPIKeyByHash returns the unrevoked API key with the given hash (nil if none) and records its use
+func GetActiveAPIKeyByHash(db *sqlx.DB, keyHash string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := db.Get(&key, `SELECT * FROM api_keys WHERE key_hash = $1 AND revoked_at IS NULL`, keyHash)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load API key: %w", err)
+	}
+
+	now := time.Now().Unix()
+	if key.LastUsedAt == nil || now-*key.LastUsedAt >= apiKeyTouchInterval {
+		if _, err := db.Exec(`UPDATE api_keys SET last_used_at = $1 WHERE id = $2`, now, key.ID); err != nil {
+			return nil, fmt.Errorf("failed to record API key use: %w", err)
+		}
+		key.LastUsedAt = &now
+	}
+	return &key, nil
+}
+
+// MeterAPIKeyRequest counts a request by key to endpoint in the current minute and reports whether it is
+// within the key's rate limit, and the seconds until the minute resets. Refused requests are counted as
+// rejected. The limit applies across all of the key's metered endpoints; concurrent requests can overshoot
+// it by a few, which is fine for a traffic guard
+func MeterAPIKeyRequest(db *sqlx.DB, key *models.APIKey, endpoint string) (allowed bool, remaining, retryAfter int, err error) {
+	now := time.Now().Unix()
+	windowStart := now - now%60
+	retryAfter = int(windowStart + 60 - now)
+
+	var used int
+	err = db.Get(&used, `
+		SELECT COALESCE(SUM(requests), 0) FROM api_key_usage WHERE api_key_id = $1 AND window_start = $2
+	`, key.ID, windowStart)
+	if err != nil {
+		return false, 0, retryAfter, fmt.Errorf("failed to read API key usage: %w", err)
+	}
+
+	limit := key.RequestsPerMinute()
+	allowed = used < limit
+	requests, rejected := 1, 0
+	if !allowed {
+		requests, rejected = 0, 1
+	}
+	_, err = db.Exec(`
+		INSERT INTO api_key_usage (api_key_id, window_start, endpoint, requests, rejected)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (api_key_id, window_start, endpoint) DO UPDATE SET
+			requests = api_key_usage.requests + EXCLUDED.requests,
+			rejected = api_key_usage.rejected + EXCLUDED.rejected
+	`, key.ID, windowStart, endpoint, requests, rejected)
+	if err != nil {
+		return false, 0, retryAfter, fmt.Errorf("failed to record API key usage: %w", err)
+	}
+
+	remaining = limit - used - requests
+	if remaining < 0 {
+		remaining = 0
+	}
+	return allowed, remaining, retryAfter, nil
+}
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"ropacal-backend/internal/models"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// binReservationSelect loads models.BinReservation rows: unfinished stops on open shifts
+const binReservationSelect = `
+	SELECT rt.bin_id, b.bin_number, rt.shift_id, s.status AS shift_status, s.driver_id, u.name AS driver_name,
+	       rt.task_type, rt.move_request_id, rt.created_at AS reserved_at
+	FROM route_tasks rt
+	JOIN shifts s ON s.id = rt.shift_id
+	LEFT JOIN bins b ON b.id = rt.bin_id
+	LEFT JOIN users u ON u.id = s.driver_id
+	WHERE rt.bin_id IS NOT NULL AND rt.is_completed = 0 AND NOT rt.skipped
+	  AND s.status IN ('ready', 'active', 'paused')`
+
+// FindBinReservations returns the open shifts other than excludeShiftID holding any of binIDs
+// Stops of excludeMoveRequestID (when set) are ignored, like the route_tasks_bin_reservation trigger does
+func FindBinReservations(q sqlx.Queryer, binIDs []string, excludeShiftID string, excludeMoveRequestID *string) ([]models.BinReservation, error) {
+	reservations := []models.BinReservation{}
+	if len(binIDs) == 0 {
+		return reservations, nil
+	}
+	err := sqlx.Select(q, &reservations, binReservationSelect+`
+		  AND rt.bin_id = ANY($1) AND rt.shift_id <> $2
+		  AND ($3::TEXT IS NULL OR rt.move_request_id IS DISTINCT FROM $3)
+		ORDER BY b.bin_number, rt.created_at`, pq.Array(binIDs), excludeShiftID, excludeMoveRequestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check bin reservations: %w", err)
+	}
+	return reservations, nil
+}
+
+// GetBinReservationConflicts returns the bins reserved by more than one open shift
+func GetBinReservationConflicts(q sqlx.Queryer) ([]models.BinReservationConflict, error) {
+	var reservations []models.BinReservation
+	err := sqlx.Select(q, &reservations, binReservationSelect+`
+		  AND rt.bin_id IN (
+			SELECT rt2.bin_id
+			FROM route_tasks rt2
+			JOIN shifts s2 ON s2.id = rt2.shift_id
+			WHERE rt2.bin_id IS NOT NULL AND rt2.is_completed = 0 AND NOT rt2.skipped
+			  AND s2.status IN ('ready', 'active', 'paused')
+			GROUP BY rt2.bin_id
+			HAVING COUNT(DISTINCT rt2.shift_id) > 1
+		  )
+		ORDER BY b.bin_number, rt.created_at`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find bin reservation conflicts: %w", err)
+	}
+
+	conflicts := []models.BinReservationConflict{}
+	for _, reservation := range reservations {
+		if n := len(conflicts); n == 0 || conflicts[n-1].BinID != reservation.BinID {
+			conflicts = append(conflicts, models.BinReservationConflict{BinID: reservation.BinID, BinNumber: reservation.BinNumber})
+		}
+		conflicts[len(conflicts)-1].Shifts = append(conflicts[len(conflicts)-1].Shifts, reservation)
+	}
+	return conflicts, nil
+}
+
+// IsBinReservationViolation reports whether err is the route_tasks_bin_reservation trigger refusing a stop
+// (a concurrent assignment reserved the bin after the handler checked)
+func IsBinReservationViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23P01" && pqErr.Constraint == "bin_reservation"
+}
@@ -4,11 +4,68 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 )
 
+// Connection pool defaults (override with DB_* environment variables)
+const (
+	defaultMaxOpenConns       = 25
+	defaultMaxIdleConns       = 10
+	defaultConnMaxLifetime    = 30 * time.Minute
+	defaultConnMaxIdleTime    = 5 * time.Minute
+	defaultStatementTimeoutMs = 30000
+)
+
+// envInt reads an integer environment variable, falling back to def when unset or invalid
+func envInt(name string, def int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("⚠️  Invalid %s=%q, using default %d", name, value, def)
+		return def
+	}
+	return parsed
+}
+
+// withStatementTimeout adds a server-side statement_timeout (ms) to the connection string
+// lib/pq passes unknown connection parameters through as Postgres runtime parameters
+func withStatementTimeout(dbURL string, timeoutMs int) string {
+	if timeoutMs <= 0 {
+		return dbURL
+	}
+
+	if strings.Contains(dbURL, "://") {
+		parsed, err := url.Parse(dbURL)
+		if err != nil {
+			return dbURL
+		}
+		query := parsed.Query()
+		if query.Get("statement_timeout") == "" {
+			query.Set("statement_timeout", strconv.Itoa(timeoutMs))
+		}
+		parsed.RawQuery = query.Encode()
+		return parsed.String()
+	}
+
+	if strings.Contains(dbURL, "statement_timeout=") {
+		return dbURL
+	}
+	return fmt.Sprintf("%s statement_timeout=%d", dbURL, timeoutMs)
+}
+
+// Connect opens the database, configures the connection pool and verifies connectivity
+// Pool settings: DB_MAX_OPEN_CONNS, DB_MAX_IDLE_CONNS, DB_CONN_MAX_LIFETIME_SECONDS,
+// DB_CONN_MAX_IDLE_TIME_SECONDS, DB_STATEMENT_TIMEOUT_MS (0 disables the timeout)
 func Connect(dbURL string) (*sqlx.DB, error) {
 	log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	log.Println("🔌 DATABASE CONNECTION ATTEMPT")
@@ -16,6 +73,9 @@ func Connect(dbURL string) (*sqlx.DB, error) {
 	log.Printf("   📍 URL prefix: %s...", dbURL[:min(30, len(dbURL))])
 	log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 
+	statementTimeoutMs := envInt("DB_STATEMENT_TIMEOUT_MS", defaultStatementTimeoutMs)
+	dbURL = withStatementTimeout(dbURL, statementTimeoutMs)
+
 	log.Println("🔄 Step 1: Attempting sqlx.Connect()...")
 	db, err := sqlx.Connect("postgres", dbURL)
 	if err != nil {
@@ -39,6 +99,18 @@ func Connect(dbURL string) (*sqlx.DB, error) {
 	}
 	log.Println("✅ Step 2 Complete: Ping() succeeded")
 
+	maxOpenConns := envInt("DB_MAX_OPEN_CONNS", defaultMaxOpenConns)
+	maxIdleConns := envInt("DB_MAX_IDLE_CONNS", defaultMaxIdleConns)
+	connMaxLifetime := time.Duration(envInt("DB_CONN_MAX_LIFETIME_SECONDS", int(defaultConnMaxLifetime.Seconds()))) * time.Second
+	connMaxIdleTime := time.Duration(envInt("DB_CONN_MAX_IDLE_TIME_SECONDS", int(defaultConnMaxIdleTime.Seconds()))) * time.Second
+
+	db.SetMaxOpenConns(maxOpenConns)
+	db.SetMaxIdleConns(maxIdleConns)
+	db.SetConnMaxLifetime(connMaxLifetime)
+	db.SetConnMaxIdleTime(connMaxIdleTime)
+	log.Printf("✅ Pool configured: max_open=%d, max_idle=%d, max_lifetime=%s, max_idle_time=%s, statement_timeout=%dms",
+		maxOpenConns, maxIdleConns, connMaxLifetime, connMaxIdleTime, statementTimeoutMs)
+
 	log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	log.Println("✅ DATABASE CONNECTION SUCCESSFUL")
 	log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
@@ -52,6 +124,30 @@ func min(a, b int) int {
 	return b
 }
 
+// normalizeBinStatusesSQL rewrites legacy spellings ("Active", "Pending Move") to canonical bin statuses
+const normalizeBinStatusesSQL = `
+	UPDATE bins SET status = REPLACE(REPLACE(LOWER(TRIM(status)), ' ', '_'), '-', '_')
+	WHERE status <> REPLACE(REPLACE(LOWER(TRIM(status)), ' ', '_'), '-', '_')`
+
+// unknownBinStatusesSQL flags bins whose status is still not canonical so someone checks them
+const unknownBinStatusesSQL = `
+	UPDATE bins SET status = 'needs_check'
+	WHERE status NOT IN ('active', 'missing', 'retired', 'in_storage', 'pending_move', 'needs_check')`
+
+// NormalizeBinStatuses rewrites any non-canonical bin status and returns how many bins changed
+func NormalizeBinStatuses(db *sqlx.DB) (int64, error) {
+	var total int64
+	for _, query := range []string{normalizeBinStatusesSQL, unknownBinStatusesSQL} {
+		result, err := db.Exec(query)
+		if err != nil {
+			return total, fmt.Errorf("failed to normalize bin statuses: %w", err)
+		}
+		changed, _ := result.RowsAffected()
+		total += changed
+	}
+	return total, nil
+}
+
 func Migrate(db *sqlx.DB) error {
 	migrations := []string{
 		// Create users table
@@ -444,9 +540,8 @@ func Migrate(db *sqlx.DB) error {
 		`CREATE INDEX IF NOT EXISTS idx_zone_risk_overrides_manager ON zone_risk_overrides(manager_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_zone_risk_overrides_status ON zone_risk_overrides(status)`,
 
-		// Migration: Update zone_incidents incident_type constraint to include new types
+		// Migration: Drop the zone_incidents incident_type constraint (incident_types now lists the valid types)
 		`ALTER TABLE zone_incidents DROP CONSTRAINT IF EXISTS zone_incidents_incident_type_check`,
-		`ALTER TABLE zone_incidents ADD CONSTRAINT zone_incidents_incident_type_check CHECK(incident_type IN ('vandalism', 'landlord_complaint', 'theft', 'relocation_request', 'missing', 'damaged', 'vandalized', 'inaccessible'))`,
 
 		// Migration: Add bin retirement tracking fields
 		`ALTER TABLE bins ADD COLUMN IF NOT EXISTS last_checked_at BIGINT`,
@@ -474,6 +569,11 @@ func Migrate(db *sqlx.DB) error {
 		`CREATE INDEX IF NOT EXISTS idx_bins_retired_at ON bins(retired_at)`,
 		`CREATE INDEX IF NOT EXISTS idx_bins_status ON bins(status)`,
 
+		// Migration: Normalize legacy bin statuses ('Active', 'Missing', ...) before the constraint is applied
+		`ALTER TABLE bins DROP CONSTRAINT IF EXISTS bins_status_check`,
+		normalizeBinStatusesSQL,
+		unknownBinStatusesSQL,
+
 		// Migration: Update bins status constraint to include new statuses
 		`ALTER TABLE bins DROP CONSTRAINT IF EXISTS bins_status_check`,
 		`ALTER TABLE bins ADD CONSTRAINT bins_status_check CHECK(status IN ('active', 'missing', 'retired', 'in_storage', 'pending_move', 'needs_check'))`,
@@ -629,6 +729,1169 @@ func Migrate(db *sqlx.DB) error {
 		// Create index on move_request_id in shift_bins for faster lookups
 		`CREATE INDEX IF NOT EXISTS idx_shift_bins_move_request_id ON shift_bins(move_request_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_shift_bins_stop_type ON shift_bins(stop_type)`,
+
+		// Migration: Create settings table for org-level configuration (priority weights, etc.)
+		`CREATE TABLE IF NOT EXISTS settings (
+			key TEXT PRIMARY KEY,
+			value JSONB NOT NULL,
+			updated_at BIGINT NOT NULL DEFAULT EXTRACT(EPOCH FROM NOW())::BIGINT,
+			updated_by_user_id TEXT,
+			FOREIGN KEY (updated_by_user_id) REFERENCES users(id) ON DELETE SET NULL
+		)`,
+
+		// Migration: Check recommendation engine fields (score, details, expiry, conversion to shift)
+		`ALTER TABLE bin_check_recommendations ADD COLUMN IF NOT EXISTS score INT NOT NULL DEFAULT 0`,
+		`ALTER TABLE bin_check_recommendations ADD COLUMN IF NOT EXISTS details TEXT`,
+		`ALTER TABLE bin_check_recommendations ADD COLUMN IF NOT EXISTS expires_at BIGINT`,
+		`ALTER TABLE bin_check_recommendations ADD COLUMN IF NOT EXISTS converted_shift_id TEXT`,
+		`ALTER TABLE bin_check_recommendations DROP CONSTRAINT IF EXISTS bin_check_recommendations_status_check`,
+		`ALTER TABLE bin_check_recommendations ADD CONSTRAINT bin_check_recommendations_status_check CHECK(status IN ('pending', 'resolved', 'dismissed', 'expired', 'converted'))`,
+		`CREATE INDEX IF NOT EXISTS idx_bin_check_recommendations_expires_at ON bin_check_recommendations(expires_at) WHERE status = 'pending'`,
+
+		// Migration: Create areas table (city/region polygon boundaries as GeoJSON)
+		// Bounding box columns let the assigner skip areas cheaply before point-in-polygon
+		`CREATE TABLE IF NOT EXISTS areas (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL UNIQUE,
+			boundary JSONB NOT NULL,
+			min_latitude DOUBLE PRECISION NOT NULL,
+			max_latitude DOUBLE PRECISION NOT NULL,
+			min_longitude DOUBLE PRECISION NOT NULL,
+			max_longitude DOUBLE PRECISION NOT NULL,
+			created_by_user_id TEXT,
+			created_at BIGINT NOT NULL DEFAULT EXTRACT(EPOCH FROM NOW())::BIGINT,
+			updated_at BIGINT NOT NULL DEFAULT EXTRACT(EPOCH FROM NOW())::BIGINT,
+			FOREIGN KEY (created_by_user_id) REFERENCES users(id) ON DELETE SET NULL
+		)`,
+
+		// Migration: Assign bins and no-go zones to areas
+		`ALTER TABLE bins ADD COLUMN IF NOT EXISTS area_id TEXT REFERENCES areas(id) ON DELETE SET NULL`,
+		`ALTER TABLE no_go_zones ADD COLUMN IF NOT EXISTS area_id TEXT REFERENCES areas(id) ON DELETE SET NULL`,
+		`CREATE INDEX IF NOT EXISTS idx_bins_area_id ON bins(area_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_no_go_zones_area_id ON no_go_zones(area_id)`,
+
+		// Migration: Login brute-force protection (failed attempts per account and per IP)
+		`CREATE TABLE IF NOT EXISTS login_throttles (
+			scope TEXT NOT NULL CHECK(scope IN ('account', 'ip')),
+			key TEXT NOT NULL,
+			failed_count INT NOT NULL DEFAULT 0,
+			first_failed_at BIGINT NOT NULL,
+			last_failed_at BIGINT NOT NULL,
+			locked_until BIGINT NOT NULL DEFAULT 0,
+			is_locked_out BOOLEAN NOT NULL DEFAULT FALSE,
+			PRIMARY KEY (scope, key)
+		)`,
+
+		// Migration: Security event audit log (logins, lockouts, unlocks)
+		`CREATE TABLE IF NOT EXISTS security_events (
+			id TEXT PRIMARY KEY,
+			event_type TEXT NOT NULL,
+			user_id TEXT,
+			email TEXT,
+			ip_address TEXT,
+			user_agent TEXT,
+			actor_id TEXT,
+			details TEXT,
+			created_at BIGINT NOT NULL,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL,
+			FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE SET NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_security_events_created_at ON security_events(created_at DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_security_events_user_id ON security_events(user_id)`,
+
+		// Migration: Bin maintenance log (repaints, repairs, ...) and scheduled maintenance
+		`CREATE TABLE IF NOT EXISTS bin_maintenance (
+			id TEXT PRIMARY KEY,
+			bin_id TEXT NOT NULL,
+			maintenance_type TEXT NOT NULL CHECK(maintenance_type IN ('repaint', 'repair', 'cleaning', 'replacement', 'inspection', 'other')),
+			status TEXT NOT NULL DEFAULT 'completed' CHECK(status IN ('scheduled', 'completed', 'cancelled')),
+			scheduled_for BIGINT,
+			performed_at BIGINT,
+			performed_by_user_id TEXT,
+			cost NUMERIC(10, 2),
+			photo_urls TEXT[] NOT NULL DEFAULT '{}',
+			notes TEXT,
+			created_by_user_id TEXT,
+			created_at BIGINT NOT NULL DEFAULT EXTRACT(EPOCH FROM NOW())::BIGINT,
+			updated_at BIGINT NOT NULL DEFAULT EXTRACT(EPOCH FROM NOW())::BIGINT,
+			FOREIGN KEY (bin_id) REFERENCES bins(id) ON DELETE CASCADE,
+			FOREIGN KEY (performed_by_user_id) REFERENCES users(id) ON DELETE SET NULL,
+			FOREIGN KEY (created_by_user_id) REFERENCES users(id) ON DELETE SET NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_bin_maintenance_bin_id ON bin_maintenance(bin_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_bin_maintenance_scheduled ON bin_maintenance(scheduled_for) WHERE status = 'scheduled'`,
+
+		// Migration: Per-user language preference (NULL = follow the device's Accept-Language)
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS locale TEXT`,
+
+		// Migration: Individual pause periods (shifts only keep the running total) for shift replay
+		`CREATE TABLE IF NOT EXISTS shift_pauses (
+			id TEXT PRIMARY KEY,
+			shift_id TEXT NOT NULL,
+			paused_at BIGINT NOT NULL,
+			resumed_at BIGINT,
+			FOREIGN KEY (shift_id) REFERENCES shifts(id) ON DELETE CASCADE
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_shift_pauses_shift_id ON shift_pauses(shift_id, paused_at)`,
+
+		// Migration: Outbound webhooks for external systems (billing, ...) and their delivery log
+		`CREATE TABLE IF NOT EXISTS webhooks (
+			id TEXT PRIMARY KEY,
+			url TEXT NOT NULL,
+			secret TEXT NOT NULL,
+			event_types TEXT[] NOT NULL DEFAULT '{}',
+			description TEXT,
+			is_active BOOLEAN NOT NULL DEFAULT TRUE,
+			created_by_user_id TEXT,
+			created_at BIGINT NOT NULL,
+			updated_at BIGINT NOT NULL,
+			FOREIGN KEY (created_by_user_id) REFERENCES users(id) ON DELETE SET NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+			id TEXT PRIMARY KEY,
+			webhook_id TEXT NOT NULL,
+			event_id TEXT NOT NULL,
+			event_type TEXT NOT NULL,
+			payload JSONB NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending' CHECK(status IN ('pending', 'succeeded', 'failed')),
+			attempts INTEGER NOT NULL DEFAULT 0,
+			next_attempt_at BIGINT NOT NULL,
+			last_attempt_at BIGINT,
+			last_status_code INTEGER,
+			last_error TEXT,
+			created_at BIGINT NOT NULL,
+			updated_at BIGINT NOT NULL,
+			FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending'`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id ON webhook_deliveries(webhook_id, created_at DESC)`,
+
+		// Migration: Driver earnings calculated when a shift ends
+		`ALTER TABLE shift_history ADD COLUMN IF NOT EXISTS earned_credits DECIMAL(10,2) NOT NULL DEFAULT 0`,
+		`ALTER TABLE shift_history ADD COLUMN IF NOT EXISTS earnings_breakdown JSONB`,
+		`CREATE INDEX IF NOT EXISTS idx_shift_history_driver_ended ON shift_history(driver_id, ended_at DESC)`,
+
+		// Create route_tasks table (shift stops of every type - single source of truth for a shift's route)
+		`CREATE TABLE IF NOT EXISTS route_tasks (
+			id TEXT PRIMARY KEY,
+			shift_id TEXT NOT NULL,
+			sequence_order INT NOT NULL,
+			task_type TEXT NOT NULL CHECK(task_type IN ('collection', 'placement', 'pickup', 'dropoff', 'warehouse_stop')),
+			latitude DOUBLE PRECISION NOT NULL,
+			longitude DOUBLE PRECISION NOT NULL,
+			address TEXT,
+			bin_id TEXT,
+			bin_number INT,
+			fill_percentage INT,
+			potential_location_id TEXT,
+			new_bin_number TEXT,
+			move_request_id TEXT,
+			destination_latitude DOUBLE PRECISION,
+			destination_longitude DOUBLE PRECISION,
+			destination_address TEXT,
+			move_type TEXT,
+			warehouse_action TEXT,
+			bins_to_load INT,
+			route_id TEXT,
+			is_completed INT NOT NULL DEFAULT 0,
+			completed_at BIGINT,
+			skipped BOOLEAN NOT NULL DEFAULT FALSE,
+			updated_fill_percentage INT,
+			task_data JSONB,
+			created_at BIGINT NOT NULL DEFAULT EXTRACT(EPOCH FROM NOW())::BIGINT,
+			updated_at BIGINT,
+			FOREIGN KEY (shift_id) REFERENCES shifts(id) ON DELETE CASCADE
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_route_tasks_shift_seq ON route_tasks(shift_id, sequence_order)`,
+		`CREATE INDEX IF NOT EXISTS idx_route_tasks_move_request_id ON route_tasks(move_request_id)`,
+
+		// Migration: Move legacy shift_bins rows into route_tasks (once, recorded by the job_shift_bins_backfill setting)
+		// Whole shifts that only had shift_bins (assigned routes), plus move stops that were only written to
+		// shift_bins on task-based shifts (run POST /api/manager/shifts/repair-sequence to renumber those)
+		// Stops whose location is unknown (bin without coordinates, no dropoff location) are left in shift_bins
+		// rather than placed at (0,0); every other row is removed, shift_bins is no longer read or written
+		`DO $$
+		DECLARE
+			copied INT;
+			unplaced INT;
+		BEGIN
+			IF EXISTS (SELECT 1 FROM settings WHERE key = 'job_shift_bins_backfill') THEN
+				RETURN;
+			END IF;
+
+			CREATE TEMP TABLE shift_bins_backfill ON COMMIT DROP AS
+			SELECT sb.id, sb.shift_id, sb.sequence_order, COALESCE(sb.stop_type, 'collection') AS task_type,
+			       CASE WHEN sb.stop_type = 'dropoff' AND mr.new_latitude IS NOT NULL THEN mr.new_latitude ELSE b.latitude END AS latitude,
+			       CASE WHEN sb.stop_type = 'dropoff' AND mr.new_longitude IS NOT NULL THEN mr.new_longitude ELSE b.longitude END AS longitude,
+			       CASE WHEN sb.stop_type = 'dropoff' AND mr.new_address IS NOT NULL THEN mr.new_address ELSE b.current_street END AS address,
+			       sb.bin_id, b.bin_number, b.fill_percentage,
+			       sb.move_request_id, mr.new_latitude, mr.new_longitude, mr.new_address, mr.move_type,
+			       s.route_id, sb.is_completed, sb.completed_at, sb.updated_fill_percentage, sb.created_at
+			FROM shift_bins sb
+			JOIN shifts s ON s.id = sb.shift_id
+			JOIN bins b ON b.id = sb.bin_id
+			LEFT JOIN bin_move_requests mr ON mr.id = sb.move_request_id
+			WHERE NOT EXISTS (SELECT 1 FROM route_tasks rt WHERE rt.shift_id = sb.shift_id)
+			   OR (sb.move_request_id IS NOT NULL AND NOT EXISTS (
+					SELECT 1 FROM route_tasks rt
+					WHERE rt.shift_id = sb.shift_id
+					  AND rt.move_request_id = sb.move_request_id
+					  AND rt.task_type = COALESCE(sb.stop_type, 'collection')
+			   ));
+
+			INSERT INTO route_tasks (
+				id, shift_id, sequence_order, task_type, latitude, longitude, address,
+				bin_id, bin_number, fill_percentage,
+				move_request_id, destination_latitude, destination_longitude, destination_address, move_type,
+				route_id, is_completed, completed_at, skipped, updated_fill_percentage, created_at
+			)
+			SELECT 'shift-bin-' || id, shift_id, sequence_order, task_type, latitude, longitude, address,
+			       bin_id, bin_number, fill_percentage,
+			       move_request_id, new_latitude, new_longitude, new_address, move_type,
+			       route_id, is_completed, completed_at, FALSE, updated_fill_percentage, created_at
+			FROM shift_bins_backfill
+			WHERE latitude IS NOT NULL AND longitude IS NOT NULL
+			ON CONFLICT (id) DO NOTHING;
+			GET DIAGNOSTICS copied = ROW_COUNT;
+
+			DELETE FROM shift_bins
+			WHERE id NOT IN (SELECT id FROM shift_bins_backfill WHERE latitude IS NULL OR longitude IS NULL);
+			SELECT COUNT(*) INTO unplaced FROM shift_bins;
+			IF unplaced > 0 THEN
+				RAISE WARNING 'shift_bins backfill: % stops without a known location were left in shift_bins', unplaced;
+			END IF;
+
+			INSERT INTO settings (key, value, updated_at)
+			VALUES ('job_shift_bins_backfill',
+			        jsonb_build_object('copied', copied, 'left_in_shift_bins', unplaced, 'ran_at', EXTRACT(EPOCH FROM NOW())::BIGINT),
+			        EXTRACT(EPOCH FROM NOW())::BIGINT)
+			ON CONFLICT (key) DO NOTHING;
+		END $$`,
+
+		// Photos of a bin from every source (check photos, incident reports, maintenance logs) for the gallery
+		`CREATE OR REPLACE VIEW bin_photos AS
+			SELECT c.bin_id, c.photo_url, 'check' AS source, c.id::TEXT AS source_id,
+			       c.checked_on AS taken_at, c.checked_by AS taken_by, NULL::TEXT AS caption
+			FROM checks c
+			WHERE c.photo_url IS NOT NULL AND c.photo_url <> ''
+			UNION ALL
+			SELECT zi.bin_id, zi.photo_url, 'incident', zi.id,
+			       zi.reported_at, zi.reported_by_user_id, zi.incident_type
+			FROM zone_incidents zi
+			WHERE zi.photo_url IS NOT NULL AND zi.photo_url <> ''
+			UNION ALL
+			SELECT bm.bin_id, photo.url, 'maintenance', bm.id,
+			       COALESCE(bm.performed_at, bm.created_at), COALESCE(bm.performed_by_user_id, bm.created_by_user_id), bm.maintenance_type
+			FROM bin_maintenance bm
+			CROSS JOIN LATERAL unnest(bm.photo_urls) AS photo(url)
+			WHERE photo.url <> ''`,
+		`CREATE INDEX IF NOT EXISTS idx_checks_bin_photo ON checks(bin_id, checked_on DESC) WHERE photo_url IS NOT NULL`,
+
+		// Migration: Recurring shift templates materialized into ready shifts, and the materializer's decisions
+		`CREATE TABLE IF NOT EXISTS shift_templates (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			route_id TEXT NOT NULL,
+			driver_id TEXT,
+			start_window_start TEXT NOT NULL,
+			start_window_end TEXT NOT NULL,
+			days_of_week TEXT[] NOT NULL DEFAULT '{}',
+			timezone TEXT NOT NULL DEFAULT 'UTC',
+			is_active BOOLEAN NOT NULL DEFAULT TRUE,
+			created_by_user_id TEXT,
+			created_at BIGINT NOT NULL,
+			updated_at BIGINT NOT NULL,
+			FOREIGN KEY (driver_id) REFERENCES users(id) ON DELETE SET NULL,
+			FOREIGN KEY (created_by_user_id) REFERENCES users(id) ON DELETE SET NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS shift_template_runs (
+			id TEXT PRIMARY KEY,
+			template_id TEXT NOT NULL,
+			service_date TEXT NOT NULL,
+			status TEXT NOT NULL CHECK(status IN ('created', 'skipped')),
+			shift_id TEXT,
+			reason TEXT,
+			created_at BIGINT NOT NULL,
+			updated_at BIGINT NOT NULL,
+			UNIQUE (template_id, service_date),
+			FOREIGN KEY (template_id) REFERENCES shift_templates(id) ON DELETE CASCADE,
+			FOREIGN KEY (shift_id) REFERENCES shifts(id) ON DELETE SET NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_shift_template_runs_date ON shift_template_runs(service_date)`,
+		`ALTER TABLE shifts ADD COLUMN IF NOT EXISTS template_id TEXT`,
+		`ALTER TABLE shifts ADD COLUMN IF NOT EXISTS scheduled_start BIGINT`,
+		`ALTER TABLE shifts ADD COLUMN IF NOT EXISTS scheduled_end BIGINT`,
+
+		// Migration: Stored mobile diagnostic logs (rate limited per device, pruned after DIAGNOSTIC_LOG_RETENTION_DAYS)
+		`CREATE TABLE IF NOT EXISTS diagnostic_logs (
+			id BIGSERIAL PRIMARY KEY,
+			device_id TEXT NOT NULL,
+			platform TEXT,
+			app_version TEXT,
+			category TEXT NOT NULL DEFAULT 'general',
+			level TEXT NOT NULL DEFAULT 'INFO',
+			context TEXT,
+			message TEXT NOT NULL,
+			client_timestamp TEXT,
+			ip_address TEXT,
+			created_at BIGINT NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_diagnostic_logs_device ON diagnostic_logs(device_id, created_at DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_diagnostic_logs_created_at ON diagnostic_logs(created_at)`,
+		// Fixed-window upload counters per device ID and per caller IP (upserted atomically, so concurrent uploads can't overrun the quota)
+		`CREATE TABLE IF NOT EXISTS diagnostic_log_quotas (
+			scope TEXT NOT NULL CHECK(scope IN ('device', 'ip')),
+			key TEXT NOT NULL,
+			window_start BIGINT NOT NULL,
+			count INT NOT NULL DEFAULT 0,
+			PRIMARY KEY (scope, key)
+		)`,
+
+		// Migration: Planned vs actual route efficiency, calculated when a shift ends
+		`ALTER TABLE shift_history ADD COLUMN IF NOT EXISTS actual_distance_km DECIMAL(10,2)`,
+		`ALTER TABLE shift_history ADD COLUMN IF NOT EXISTS actual_duration_seconds BIGINT`,
+		`ALTER TABLE shift_history ADD COLUMN IF NOT EXISTS planned_distance_km DECIMAL(10,2)`,
+		`ALTER TABLE shift_history ADD COLUMN IF NOT EXISTS planned_duration_seconds BIGINT`,
+		`ALTER TABLE shift_history ADD COLUMN IF NOT EXISTS planned_source TEXT`,
+		`ALTER TABLE shift_history ADD COLUMN IF NOT EXISTS completed_stops INT`,
+		`ALTER TABLE shift_history ADD COLUMN IF NOT EXISTS out_of_sequence_stops INT`,
+		`ALTER TABLE shift_history ADD COLUMN IF NOT EXISTS sequence_deviation DECIMAL(5,2)`,
+		`ALTER TABLE shift_history ADD COLUMN IF NOT EXISTS stops_per_hour DECIMAL(6,2)`,
+		`CREATE INDEX IF NOT EXISTS idx_shift_history_route_ended ON shift_history(route_id, ended_at DESC)`,
+
+		// Migration: Transactional outbox for WebSocket and push notifications
+		`CREATE TABLE IF NOT EXISTS notification_outbox (
+			id TEXT PRIMARY KEY,
+			channel TEXT NOT NULL CHECK(channel IN ('websocket', 'push')),
+			target_type TEXT NOT NULL CHECK(target_type IN ('user', 'role')),
+			target TEXT NOT NULL,
+			event_type TEXT NOT NULL DEFAULT '',
+			payload JSONB NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending' CHECK(status IN ('pending', 'delivered', 'failed')),
+			attempts INTEGER NOT NULL DEFAULT 0,
+			next_attempt_at BIGINT NOT NULL,
+			last_error TEXT,
+			delivered_at BIGINT,
+			created_at BIGINT NOT NULL,
+			updated_at BIGINT NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_notification_outbox_due ON notification_outbox(next_attempt_at) WHERE status = 'pending'`,
+		`CREATE INDEX IF NOT EXISTS idx_notification_outbox_target ON notification_outbox(target, created_at DESC)`,
+
+		// Migration: Saved filters and sort for manager list views (bins, move requests)
+		`CREATE TABLE IF NOT EXISTS saved_views (
+			id TEXT PRIMARY KEY,
+			user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			name TEXT NOT NULL,
+			entity_type TEXT NOT NULL CHECK(entity_type IN ('bins', 'move_requests')),
+			filters JSONB NOT NULL DEFAULT '{}',
+			sort TEXT,
+			is_shared BOOLEAN NOT NULL DEFAULT FALSE,
+			created_at BIGINT NOT NULL,
+			updated_at BIGINT NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_saved_views_entity ON saved_views(entity_type, user_id)`,
+
+		// Migration: Move request SLA breach timestamps (set by the SLA checker, never cleared)
+		`ALTER TABLE bin_move_requests ADD COLUMN IF NOT EXISTS assign_sla_breached_at BIGINT`,
+		`ALTER TABLE bin_move_requests ADD COLUMN IF NOT EXISTS complete_sla_breached_at BIGINT`,
+
+		// Migration: Bounding-box prefilter for GET /api/bins/nearby
+		`CREATE INDEX IF NOT EXISTS idx_bins_lat_lng ON bins(latitude, longitude) WHERE latitude IS NOT NULL AND longitude IS NOT NULL`,
+
+		// Migration: User deactivation (deactivated users can't log in and are hidden from driver listings)
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS deactivated_at BIGINT`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS deactivated_by_user_id TEXT REFERENCES users(id) ON DELETE SET NULL`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS deactivation_reason TEXT`,
+
+		// Migration: Pre-start vehicle inspection (manager-defined items, answered once per shift before it starts)
+		`CREATE TABLE IF NOT EXISTS pre_start_checklist_items (
+			id TEXT PRIMARY KEY,
+			label TEXT NOT NULL,
+			description TEXT,
+			requires_photo BOOLEAN NOT NULL DEFAULT FALSE,
+			sort_order INT NOT NULL DEFAULT 0,
+			is_active BOOLEAN NOT NULL DEFAULT TRUE,
+			created_by_user_id TEXT REFERENCES users(id) ON DELETE SET NULL,
+			created_at BIGINT NOT NULL,
+			updated_at BIGINT NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS pre_start_checklists (
+			id TEXT PRIMARY KEY,
+			shift_id TEXT NOT NULL UNIQUE REFERENCES shifts(id) ON DELETE CASCADE,
+			driver_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			vehicle_id TEXT,
+			notes TEXT,
+			failed_count INT NOT NULL DEFAULT 0,
+			submitted_at BIGINT NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_pre_start_checklists_submitted_at ON pre_start_checklists(submitted_at DESC)`,
+		`CREATE TABLE IF NOT EXISTS pre_start_checklist_responses (
+			id TEXT PRIMARY KEY,
+			checklist_id TEXT NOT NULL REFERENCES pre_start_checklists(id) ON DELETE CASCADE,
+			item_id TEXT REFERENCES pre_start_checklist_items(id) ON DELETE SET NULL,
+			label TEXT NOT NULL,
+			passed BOOLEAN NOT NULL,
+			note TEXT,
+			photo_url TEXT
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_pre_start_checklist_responses_checklist ON pre_start_checklist_responses(checklist_id)`,
+
+		// Migration: Two-phase move completion (pickup and dropoff legs confirmed separately, bin on the truck in between)
+		// The status constraint carries every status, including the approval stage ('requested', 'rejected') added below:
+		// migrations re-run on every boot, so a narrower constraint here would fail once those statuses are in use
+		`ALTER TABLE bin_move_requests DROP CONSTRAINT IF EXISTS bin_move_requests_status_check`,
+		`ALTER TABLE bin_move_requests ADD CONSTRAINT bin_move_requests_status_check CHECK(status IN ('requested', 'rejected', 'pending', 'assigned', 'in_progress', 'picked_up', 'completed', 'cancelled'))`,
+		`ALTER TABLE bin_move_requests ADD COLUMN IF NOT EXISTS picked_up_at BIGINT`,
+		`ALTER TABLE route_tasks ADD COLUMN IF NOT EXISTS photo_url TEXT`,
+		`ALTER TABLE route_tasks ADD COLUMN IF NOT EXISTS signature_url TEXT`,
+
+		// Migration: Coordinates on moves for the bin location timeline (missing ones are geocoded on demand)
+		`ALTER TABLE moves ADD COLUMN IF NOT EXISTS from_latitude DOUBLE PRECISION`,
+		`ALTER TABLE moves ADD COLUMN IF NOT EXISTS from_longitude DOUBLE PRECISION`,
+		`ALTER TABLE moves ADD COLUMN IF NOT EXISTS to_latitude DOUBLE PRECISION`,
+		`ALTER TABLE moves ADD COLUMN IF NOT EXISTS to_longitude DOUBLE PRECISION`,
+		// move_request_id used to come only from migrations/add_manual_move_support.sql; fresh databases need it for the backfill
+		`ALTER TABLE moves ADD COLUMN IF NOT EXISTS move_request_id TEXT REFERENCES bin_move_requests(id) ON DELETE SET NULL`,
+		`CREATE INDEX IF NOT EXISTS idx_moves_move_request_id ON moves(move_request_id)`,
+		`UPDATE moves m
+		SET from_latitude = mr.original_latitude,
+		    from_longitude = mr.original_longitude,
+		    to_latitude = COALESCE(m.to_latitude, mr.new_latitude),
+		    to_longitude = COALESCE(m.to_longitude, mr.new_longitude)
+		FROM bin_move_requests mr
+		WHERE mr.id = m.move_request_id AND m.from_latitude IS NULL`,
+
+		// Migration: Schema that only came from the hand-applied scripts (migrations/add_manual_move_support.sql,
+		// migrations/add_move_request_history.sql, add_optimization_metadata.sql) and the route tasks rollout;
+		// the handlers rely on it, so fresh databases (tests, new environments) get it here
+		`ALTER TABLE bin_move_requests ADD COLUMN IF NOT EXISTS assignment_type VARCHAR(20) DEFAULT 'shift' CHECK(assignment_type IN ('shift', 'manual'))`,
+		`ALTER TABLE bin_move_requests ADD COLUMN IF NOT EXISTS assigned_user_id TEXT REFERENCES users(id) ON DELETE SET NULL`,
+		`CREATE INDEX IF NOT EXISTS idx_bin_move_requests_assigned_user ON bin_move_requests(assigned_user_id)`,
+		`ALTER TABLE moves ADD COLUMN IF NOT EXISTS move_type VARCHAR(20) DEFAULT 'shift' CHECK(move_type IN ('shift', 'manual'))`,
+		`ALTER TABLE moves ADD COLUMN IF NOT EXISTS from_street TEXT`,
+		`ALTER TABLE moves ADD COLUMN IF NOT EXISTS from_city TEXT`,
+		`ALTER TABLE moves ADD COLUMN IF NOT EXISTS from_zip TEXT`,
+		`ALTER TABLE moves ADD COLUMN IF NOT EXISTS to_street TEXT`,
+		`ALTER TABLE moves ADD COLUMN IF NOT EXISTS to_city TEXT`,
+		`ALTER TABLE moves ADD COLUMN IF NOT EXISTS to_zip TEXT`,
+		`ALTER TABLE moves ADD COLUMN IF NOT EXISTS completed_by_user_id TEXT REFERENCES users(id) ON DELETE SET NULL`,
+		`ALTER TABLE moves ADD COLUMN IF NOT EXISTS shift_id TEXT REFERENCES shifts(id) ON DELETE SET NULL`,
+		`CREATE INDEX IF NOT EXISTS idx_moves_move_type ON moves(move_type)`,
+		`CREATE INDEX IF NOT EXISTS idx_moves_completed_by_user_id ON moves(completed_by_user_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_moves_shift_id ON moves(shift_id)`,
+		`CREATE TABLE IF NOT EXISTS move_request_history (
+			id TEXT PRIMARY KEY DEFAULT gen_random_uuid()::TEXT,
+			move_request_id TEXT NOT NULL,
+			action_type VARCHAR(20) NOT NULL CHECK(action_type IN ('created', 'assigned', 'reassigned', 'unassigned', 'completed', 'cancelled', 'updated')),
+			actor_id TEXT NOT NULL,
+			actor_name TEXT NOT NULL,
+			actor_role VARCHAR(20),
+			previous_status VARCHAR(20),
+			new_status VARCHAR(20),
+			previous_assignment_type VARCHAR(20),
+			new_assignment_type VARCHAR(20),
+			previous_assigned_user_id TEXT,
+			new_assigned_user_id TEXT,
+			previous_assigned_user_name TEXT,
+			new_assigned_user_name TEXT,
+			previous_assigned_shift_id TEXT,
+			new_assigned_shift_id TEXT,
+			notes TEXT,
+			metadata JSONB,
+			created_at BIGINT NOT NULL DEFAULT EXTRACT(EPOCH FROM NOW())::BIGINT,
+			CONSTRAINT fk_move_request_history_move_request
+				FOREIGN KEY (move_request_id) REFERENCES bin_move_requests(id) ON DELETE CASCADE,
+			CONSTRAINT fk_move_request_history_actor
+				FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE SET NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_move_request_history_move_id ON move_request_history(move_request_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_move_request_history_created_at ON move_request_history(created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_move_request_history_actor ON move_request_history(actor_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_move_request_history_action_type ON move_request_history(action_type)`,
+		`CREATE INDEX IF NOT EXISTS idx_move_request_history_move_time ON move_request_history(move_request_id, created_at DESC)`,
+		`ALTER TABLE shifts ADD COLUMN IF NOT EXISTS optimization_metadata JSONB`,
+		`CREATE INDEX IF NOT EXISTS idx_shifts_optimization_metadata ON shifts USING GIN (optimization_metadata) WHERE optimization_metadata IS NOT NULL`,
+		`ALTER TABLE shifts ADD COLUMN IF NOT EXISTS truck_bin_capacity INT`,
+		`ALTER TABLE shifts ADD COLUMN IF NOT EXISTS warehouse_latitude DOUBLE PRECISION`,
+		`ALTER TABLE shifts ADD COLUMN IF NOT EXISTS warehouse_longitude DOUBLE PRECISION`,
+		`ALTER TABLE shifts ADD COLUMN IF NOT EXISTS warehouse_address TEXT`,
+
+		// Migration: API keys for machine clients (only a SHA-256 hash of the key is stored)
+		`CREATE TABLE IF NOT EXISTS api_keys (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			key_prefix TEXT NOT NULL,
+			key_hash TEXT NOT NULL UNIQUE,
+			scopes TEXT[] NOT NULL DEFAULT '{}',
+			last_used_at BIGINT,
+			created_by_user_id TEXT REFERENCES users(id) ON DELETE SET NULL,
+			created_at BIGINT NOT NULL,
+			revoked_at BIGINT
+		)`,
+
+		// Migration: IoT fill sensor registry (readings are stored as checks with checked_from = 'sensor')
+		`CREATE TABLE IF NOT EXISTS bin_sensors (
+			id TEXT PRIMARY KEY,
+			bin_id TEXT REFERENCES bins(id) ON DELETE SET NULL,
+			description TEXT,
+			is_active BOOLEAN NOT NULL DEFAULT TRUE,
+			last_reading_at BIGINT,
+			last_fill_percentage INT,
+			battery_percentage INT,
+			rejected_readings INT NOT NULL DEFAULT 0,
+			created_at BIGINT NOT NULL,
+			updated_at BIGINT NOT NULL
+		)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_bin_sensors_bin ON bin_sensors(bin_id) WHERE bin_id IS NOT NULL`,
+
+		// Migration: Check anomaly flags and the manager review queue (review_status is NULL for unflagged checks)
+		`ALTER TABLE checks ADD COLUMN IF NOT EXISTS anomaly_flags TEXT[] NOT NULL DEFAULT '{}'`,
+		`ALTER TABLE checks ADD COLUMN IF NOT EXISTS anomaly_details JSONB`,
+		`ALTER TABLE checks ADD COLUMN IF NOT EXISTS review_status TEXT CHECK(review_status IN ('pending', 'confirmed', 'dismissed'))`,
+		`ALTER TABLE checks ADD COLUMN IF NOT EXISTS reviewed_by_user_id TEXT REFERENCES users(id) ON DELETE SET NULL`,
+		`ALTER TABLE checks ADD COLUMN IF NOT EXISTS reviewed_at BIGINT`,
+		`ALTER TABLE checks ADD COLUMN IF NOT EXISTS review_notes TEXT`,
+		`CREATE INDEX IF NOT EXISTS idx_checks_review_status ON checks(review_status, checked_on DESC) WHERE review_status IS NOT NULL`,
+
+		// Migration: GPS breadcrumbs (timestamp in milliseconds), pruned after DRIVER_LOCATION_RETENTION_DAYS
+		`CREATE TABLE IF NOT EXISTS driver_locations (
+			id SERIAL PRIMARY KEY,
+			driver_id TEXT NOT NULL,
+			latitude DOUBLE PRECISION NOT NULL,
+			longitude DOUBLE PRECISION NOT NULL,
+			heading DOUBLE PRECISION,
+			speed DOUBLE PRECISION,
+			accuracy DOUBLE PRECISION,
+			shift_id TEXT,
+			timestamp BIGINT NOT NULL,
+			created_at BIGINT NOT NULL DEFAULT EXTRACT(EPOCH FROM NOW())::BIGINT,
+			FOREIGN KEY (driver_id) REFERENCES users(id) ON DELETE CASCADE,
+			FOREIGN KEY (shift_id) REFERENCES shifts(id) ON DELETE SET NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_driver_locations_shift ON driver_locations(shift_id, timestamp)`,
+		`CREATE INDEX IF NOT EXISTS idx_driver_locations_driver ON driver_locations(driver_id, timestamp DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_driver_locations_created ON driver_locations(created_at)`,
+
+		// Migration: Move request site instructions (JSON) and attachments
+		`ALTER TABLE bin_move_requests ADD COLUMN IF NOT EXISTS instructions JSONB`,
+		`CREATE TABLE IF NOT EXISTS move_request_attachments (
+			id TEXT PRIMARY KEY,
+			move_request_id TEXT NOT NULL REFERENCES bin_move_requests(id) ON DELETE CASCADE,
+			kind TEXT NOT NULL CHECK(kind IN ('photo', 'document')),
+			url TEXT NOT NULL,
+			caption TEXT,
+			uploaded_by_user_id TEXT REFERENCES users(id) ON DELETE SET NULL,
+			created_at BIGINT NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_move_request_attachments_move ON move_request_attachments(move_request_id, created_at)`,
+
+		// Migration: Service time windows ("HH:MM" local time) for bins and move requests
+		`ALTER TABLE bins ADD COLUMN IF NOT EXISTS time_window_start TEXT`,
+		`ALTER TABLE bins ADD COLUMN IF NOT EXISTS time_window_end TEXT`,
+		`ALTER TABLE bin_move_requests ADD COLUMN IF NOT EXISTS time_window_start TEXT`,
+		`ALTER TABLE bin_move_requests ADD COLUMN IF NOT EXISTS time_window_end TEXT`,
+
+		// Migration: Live incident counters on shifts (copied into shift_history at end of shift)
+		`ALTER TABLE shifts ADD COLUMN IF NOT EXISTS incidents_reported INT NOT NULL DEFAULT 0`,
+		`ALTER TABLE shifts ADD COLUMN IF NOT EXISTS field_observations INT NOT NULL DEFAULT 0`,
+
+		// Migration: Daily bins-at-risk digests for managers (one per local date)
+		`CREATE TABLE IF NOT EXISTS manager_digests (
+			id TEXT PRIMARY KEY,
+			digest_date TEXT NOT NULL UNIQUE,
+			payload JSONB NOT NULL,
+			generated_at BIGINT NOT NULL
+		)`,
+
+		// Migration: Arrival/departure at stops detected from driver GPS (dwell-time analytics)
+		`ALTER TABLE route_tasks ADD COLUMN IF NOT EXISTS arrived_at BIGINT`,
+		`ALTER TABLE route_tasks ADD COLUMN IF NOT EXISTS departed_at BIGINT`,
+		`CREATE INDEX IF NOT EXISTS idx_route_tasks_open_arrival ON route_tasks(shift_id) WHERE arrived_at IS NOT NULL AND departed_at IS NULL`,
+
+		// Migration: Undo window for destructive manager actions (snapshot taken before the change)
+		`CREATE TABLE IF NOT EXISTS undo_operations (
+			id TEXT PRIMARY KEY,
+			operation_type TEXT NOT NULL,
+			entity_type TEXT NOT NULL,
+			entity_id TEXT NOT NULL,
+			summary TEXT NOT NULL,
+			snapshot JSONB NOT NULL,
+			performed_by_user_id TEXT REFERENCES users(id) ON DELETE SET NULL,
+			performed_at BIGINT NOT NULL,
+			expires_at BIGINT NOT NULL,
+			undone_at BIGINT,
+			undone_by_user_id TEXT REFERENCES users(id) ON DELETE SET NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_undo_operations_pending ON undo_operations(expires_at) WHERE undone_at IS NULL`,
+
+		// Migration: Photo requirement per bin or per area (checks record whether one was required)
+		`ALTER TABLE bins ADD COLUMN IF NOT EXISTS photo_required BOOLEAN NOT NULL DEFAULT false`,
+		`ALTER TABLE areas ADD COLUMN IF NOT EXISTS photo_required BOOLEAN NOT NULL DEFAULT false`,
+		`ALTER TABLE checks ADD COLUMN IF NOT EXISTS photo_required BOOLEAN NOT NULL DEFAULT false`,
+
+		// Migration: Approval stage for move requests from non-admin users ('requested' until an admin approves or rejects)
+		// The 'requested' and 'rejected' statuses are in bin_move_requests_status_check (two-phase move completion above)
+		`ALTER TABLE bin_move_requests ADD COLUMN IF NOT EXISTS approval_status TEXT CHECK(approval_status IN ('requested', 'approved', 'rejected'))`,
+		`ALTER TABLE bin_move_requests ADD COLUMN IF NOT EXISTS approval_decided_by TEXT REFERENCES users(id) ON DELETE SET NULL`,
+		`ALTER TABLE bin_move_requests ADD COLUMN IF NOT EXISTS approval_decided_at BIGINT`,
+		`ALTER TABLE bin_move_requests ADD COLUMN IF NOT EXISTS approval_reason TEXT`,
+		`CREATE INDEX IF NOT EXISTS idx_bin_move_requests_awaiting_approval ON bin_move_requests(created_at) WHERE status = 'requested'`,
+
+		// Migration: Personal data erasure (the user row stays, anonymized, so shifts and history keep their owner)
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS anonymized_at BIGINT`,
+
+		// Migration: Pause reasons (required when pausing; older and recovered pauses have none)
+		`ALTER TABLE shift_pauses ADD COLUMN IF NOT EXISTS reason TEXT CHECK(reason IN ('traffic', 'breakdown', 'break', 'weather', 'other'))`,
+		`ALTER TABLE shift_pauses ADD COLUMN IF NOT EXISTS note TEXT`,
+
+		// Migration: Route blueprint versions (a snapshot per create/update; shifts record the version they were built from)
+		`CREATE TABLE IF NOT EXISTS route_versions (
+			id SERIAL PRIMARY KEY,
+			route_id TEXT NOT NULL,
+			version INT NOT NULL,
+			name TEXT NOT NULL,
+			description TEXT,
+			geographic_area TEXT NOT NULL,
+			schedule_pattern TEXT,
+			estimated_duration_hours DOUBLE PRECISION,
+			bin_ids TEXT[] NOT NULL DEFAULT '{}',
+			created_by_user_id TEXT REFERENCES users(id) ON DELETE SET NULL,
+			created_at BIGINT NOT NULL,
+			UNIQUE(route_id, version)
+		)`,
+		`ALTER TABLE shifts ADD COLUMN IF NOT EXISTS route_version INT`,
+		// Routes created before versioning start at version 1 (routes is created by migrations/create_routes_table.sql)
+		`DO $$
+		BEGIN
+			IF to_regclass('routes') IS NOT NULL AND to_regclass('route_bins') IS NOT NULL THEN
+				INSERT INTO route_versions (route_id, version, name, description, geographic_area, schedule_pattern,
+				                            estimated_duration_hours, bin_ids, created_by_user_id, created_at)
+				SELECT r.id, 1, r.name, r.description, r.geographic_area, r.schedule_pattern,
+				       r.estimated_duration_hours,
+				       ARRAY(SELECT rb.bin_id FROM route_bins rb WHERE rb.route_id = r.id ORDER BY rb.sequence_order),
+				       r.created_by_user_id, r.updated_at
+				FROM routes r
+				WHERE NOT EXISTS (SELECT 1 FROM route_versions v WHERE v.route_id = r.id);
+			END IF;
+		END $$;`,
+
+		// Migration: Driver visits to no-go zones (entry/exit alerts for managers, kept for later review)
+		`CREATE TABLE IF NOT EXISTS zone_visits (
+			id TEXT PRIMARY KEY,
+			zone_id TEXT NOT NULL REFERENCES no_go_zones(id) ON DELETE CASCADE,
+			driver_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			shift_id TEXT REFERENCES shifts(id) ON DELETE SET NULL,
+			entered_at BIGINT NOT NULL,
+			entry_latitude DOUBLE PRECISION NOT NULL,
+			entry_longitude DOUBLE PRECISION NOT NULL,
+			exited_at BIGINT,
+			exit_latitude DOUBLE PRECISION,
+			exit_longitude DOUBLE PRECISION
+		)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_zone_visits_open ON zone_visits(driver_id, zone_id) WHERE exited_at IS NULL`,
+		`CREATE INDEX IF NOT EXISTS idx_zone_visits_zone ON zone_visits(zone_id, entered_at DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_zone_visits_entered_at ON zone_visits(entered_at DESC)`,
+
+		// Migration: Bin merge audit (duplicates from imports folded into the surviving bin, which keeps their history)
+		`CREATE TABLE IF NOT EXISTS bin_merges (
+			id TEXT PRIMARY KEY,
+			source_bin_id TEXT NOT NULL,
+			source_bin_number INT NOT NULL,
+			target_bin_id TEXT REFERENCES bins(id) ON DELETE SET NULL,
+			target_bin_number INT NOT NULL,
+			source_snapshot JSONB NOT NULL,
+			reassigned JSONB NOT NULL,
+			merged_by_user_id TEXT REFERENCES users(id) ON DELETE SET NULL,
+			merged_at BIGINT NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_bin_merges_merged_at ON bin_merges(merged_at DESC)`,
+
+		// Migration: Shift cost tracking (estimate at assignment, actual when the shift ends)
+		`ALTER TABLE shifts ADD COLUMN IF NOT EXISTS estimated_hours DECIMAL(6,2)`,
+		`ALTER TABLE shifts ADD COLUMN IF NOT EXISTS estimated_distance_km DECIMAL(10,2)`,
+		`ALTER TABLE shifts ADD COLUMN IF NOT EXISTS estimated_cost DECIMAL(10,2)`,
+		`ALTER TABLE shift_history ADD COLUMN IF NOT EXISTS estimated_cost DECIMAL(10,2)`,
+		`ALTER TABLE shift_history ADD COLUMN IF NOT EXISTS actual_cost DECIMAL(10,2)`,
+		`ALTER TABLE shift_history ADD COLUMN IF NOT EXISTS cost_breakdown JSONB`,
+
+		// Migration: Route re-optimization queue (one row per shift, debounced by services.RouteReoptimizer)
+		`CREATE TABLE IF NOT EXISTS route_reoptimizations (
+			shift_id TEXT PRIMARY KEY REFERENCES shifts(id) ON DELETE CASCADE,
+			pending BOOLEAN NOT NULL DEFAULT TRUE,
+			reasons TEXT[] NOT NULL DEFAULT '{}',
+			requested_at BIGINT NOT NULL,
+			last_run_at BIGINT,
+			last_reasons TEXT[] NOT NULL DEFAULT '{}',
+			last_changed_stops INT NOT NULL DEFAULT 0
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_route_reoptimizations_pending ON route_reoptimizations(requested_at) WHERE pending`,
+
+		// Migration: API key rate limits and usage metering (requests per key, minute and endpoint)
+		`ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS rate_limit_per_minute INT`,
+		`CREATE TABLE IF NOT EXISTS api_key_usage (
+			api_key_id TEXT NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+			window_start BIGINT NOT NULL,
+			endpoint TEXT NOT NULL,
+			requests INT NOT NULL DEFAULT 0,
+			rejected INT NOT NULL DEFAULT 0,
+			PRIMARY KEY (api_key_id, window_start, endpoint)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_api_key_usage_window_start ON api_key_usage(window_start)`,
+
+		// Migration: Incident photo tagging (queued on upload, classified by services.IncidentPhotoTagger)
+		`CREATE TABLE IF NOT EXISTS incident_photo_jobs (
+			incident_id TEXT PRIMARY KEY REFERENCES zone_incidents(id) ON DELETE CASCADE,
+			photo_url TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending' CHECK(status IN ('pending', 'tagged', 'failed')),
+			attempts INT NOT NULL DEFAULT 0,
+			next_attempt_at BIGINT NOT NULL,
+			provider TEXT,
+			last_error TEXT,
+			created_at BIGINT NOT NULL,
+			updated_at BIGINT NOT NULL,
+			tagged_at BIGINT
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_incident_photo_jobs_due ON incident_photo_jobs(next_attempt_at) WHERE status = 'pending'`,
+		`CREATE TABLE IF NOT EXISTS incident_photo_labels (
+			incident_id TEXT NOT NULL REFERENCES zone_incidents(id) ON DELETE CASCADE,
+			label TEXT NOT NULL,
+			confidence DOUBLE PRECISION NOT NULL,
+			provider TEXT NOT NULL,
+			created_at BIGINT NOT NULL,
+			PRIMARY KEY (incident_id, label)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_incident_photo_labels_label ON incident_photo_labels(label, confidence)`,
+
+		// Migration: Draft routes (built from the coverage report; can't be assigned until published)
+		`DO $$
+		BEGIN
+			IF to_regclass('routes') IS NOT NULL THEN
+				ALTER TABLE routes ADD COLUMN IF NOT EXISTS is_draft BOOLEAN NOT NULL DEFAULT false;
+			END IF;
+		END $$;`,
+
+		// Migration: Support mode (admins impersonating drivers with read-only tokens; every request is recorded)
+		`CREATE TABLE IF NOT EXISTS impersonation_sessions (
+			id TEXT PRIMARY KEY,
+			admin_id TEXT REFERENCES users(id) ON DELETE SET NULL,
+			admin_email TEXT NOT NULL,
+			driver_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			reason TEXT NOT NULL,
+			created_at BIGINT NOT NULL,
+			expires_at BIGINT NOT NULL,
+			ended_at BIGINT,
+			ended_by_user_id TEXT REFERENCES users(id) ON DELETE SET NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_impersonation_sessions_created_at ON impersonation_sessions(created_at DESC)`,
+		`CREATE TABLE IF NOT EXISTS impersonated_requests (
+			id BIGSERIAL PRIMARY KEY,
+			session_id TEXT NOT NULL REFERENCES impersonation_sessions(id) ON DELETE CASCADE,
+			method TEXT NOT NULL,
+			path TEXT NOT NULL,
+			status INT NOT NULL,
+			blocked BOOLEAN NOT NULL DEFAULT false,
+			created_at BIGINT NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_impersonated_requests_session ON impersonated_requests(session_id, created_at)`,
+
+		// Migration: Bin reservations - an unfinished stop on a shift that hasn't ended reserves its bin, so the
+		// same bin can't be put on two open shifts (stops of the same move request don't conflict with each other)
+		`CREATE INDEX IF NOT EXISTS idx_route_tasks_bin_open ON route_tasks(bin_id) WHERE is_completed = 0 AND NOT skipped`,
+		`CREATE OR REPLACE FUNCTION enforce_bin_reservation() RETURNS trigger AS $$
+		DECLARE
+			holder TEXT;
+		BEGIN
+			IF NEW.bin_id IS NULL OR NEW.is_completed <> 0 OR NEW.skipped THEN
+				RETURN NEW;
+			END IF;
+			-- Serialize reservations of the same bin so two concurrent assignments can't both pass the check
+			PERFORM pg_advisory_xact_lock(hashtext('bin_reservation:' || NEW.bin_id));
+			SELECT rt.shift_id INTO holder
+			FROM route_tasks rt
+			JOIN shifts s ON s.id = rt.shift_id
+			WHERE rt.bin_id = NEW.bin_id AND rt.shift_id <> NEW.shift_id AND rt.id <> NEW.id
+			  AND rt.is_completed = 0 AND NOT rt.skipped
+			  AND s.status IN ('ready', 'active', 'paused')
+			  AND (NEW.move_request_id IS NULL OR rt.move_request_id IS DISTINCT FROM NEW.move_request_id)
+			LIMIT 1;
+			IF holder IS NOT NULL THEN
+				RAISE EXCEPTION 'bin % is already on open shift %', NEW.bin_id, holder
+					USING ERRCODE = 'exclusion_violation', CONSTRAINT = 'bin_reservation', DETAIL = holder;
+			END IF;
+			RETURN NEW;
+		END;
+		$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS route_tasks_bin_reservation ON route_tasks`,
+		`CREATE TRIGGER route_tasks_bin_reservation BEFORE INSERT OR UPDATE OF bin_id, shift_id ON route_tasks
+			FOR EACH ROW EXECUTE FUNCTION enforce_bin_reservation()`,
+
+		// Migration: Answers to the organization's custom check form fields (see models.CheckForm)
+		`ALTER TABLE checks ADD COLUMN IF NOT EXISTS form_responses JSONB`,
+
+		// Migration: End-of-shift debriefs and driver notes (bin notes are shown to the next driver with the bin)
+		`CREATE TABLE IF NOT EXISTS shift_debriefs (
+			shift_id TEXT PRIMARY KEY REFERENCES shift_history(id) ON DELETE CASCADE,
+			driver_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			responses JSONB NOT NULL DEFAULT '{}',
+			submitted_at BIGINT NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS shift_notes (
+			id SERIAL PRIMARY KEY,
+			shift_id TEXT NOT NULL REFERENCES shift_history(id) ON DELETE CASCADE,
+			driver_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			bin_id TEXT REFERENCES bins(id) ON DELETE CASCADE,
+			note TEXT NOT NULL,
+			created_at BIGINT NOT NULL,
+			dismissed_at BIGINT,
+			dismissed_by_user_id TEXT REFERENCES users(id) ON DELETE SET NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_shift_notes_shift ON shift_notes(shift_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_shift_notes_bin ON shift_notes(bin_id, created_at DESC) WHERE bin_id IS NOT NULL`,
+
+		// Migration: How far the driver was from the stop when completing it (NULL without a recent GPS fix);
+		// remote completions are further than the check-in proximity limit (see models.CheckInProximitySettings)
+		`ALTER TABLE checks ADD COLUMN IF NOT EXISTS completion_distance_meters DOUBLE PRECISION`,
+		`ALTER TABLE checks ADD COLUMN IF NOT EXISTS remote_completion BOOLEAN NOT NULL DEFAULT FALSE`,
+
+		// Migration: Bin tags (manager-defined labels such as "university" or "high theft"; a bin can have many)
+		`CREATE TABLE IF NOT EXISTS tags (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			color TEXT,
+			created_by_user_id TEXT REFERENCES users(id) ON DELETE SET NULL,
+			created_at BIGINT NOT NULL,
+			updated_at BIGINT NOT NULL
+		)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_name ON tags(LOWER(name))`,
+		`CREATE TABLE IF NOT EXISTS bin_tags (
+			bin_id TEXT NOT NULL REFERENCES bins(id) ON DELETE CASCADE,
+			tag_id TEXT NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+			created_at BIGINT NOT NULL,
+			PRIMARY KEY (bin_id, tag_id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_bin_tags_tag ON bin_tags(tag_id)`,
+
+		// Migration: Per-device FCM delivery log with retries of transient failures
+		`CREATE TABLE IF NOT EXISTS notification_deliveries (
+			id TEXT PRIMARY KEY,
+			user_id TEXT REFERENCES users(id) ON DELETE CASCADE,
+			token TEXT NOT NULL,
+			notification_type TEXT NOT NULL DEFAULT '',
+			title TEXT NOT NULL DEFAULT '',
+			body TEXT NOT NULL DEFAULT '',
+			data JSONB NOT NULL DEFAULT '{}',
+			status TEXT NOT NULL CHECK(status IN ('sent', 'retrying', 'failed', 'expired')),
+			fcm_message_id TEXT,
+			attempts INTEGER NOT NULL DEFAULT 1,
+			error_code TEXT,
+			last_error TEXT,
+			next_attempt_at BIGINT,
+			sent_at BIGINT,
+			created_at BIGINT NOT NULL,
+			updated_at BIGINT NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_notification_deliveries_due ON notification_deliveries(next_attempt_at) WHERE status = 'retrying'`,
+		`CREATE INDEX IF NOT EXISTS idx_notification_deliveries_user ON notification_deliveries(user_id, created_at DESC)`,
+
+		// Migration: Org-wide fill thresholds replace the priority weights' high/medium fill thresholds
+		// (an existing customization carries over)
+		`INSERT INTO settings (key, value, updated_at, updated_by_user_id)
+		SELECT 'fill_thresholds',
+			jsonb_build_object('warning', (value->>'medium_fill_threshold')::INT, 'critical', (value->>'high_fill_threshold')::INT),
+			updated_at, updated_by_user_id
+		FROM settings
+		WHERE key = 'priority_weights'
+			AND (value->>'medium_fill_threshold')::INT >= 1
+			AND (value->>'medium_fill_threshold')::INT < (value->>'high_fill_threshold')::INT
+			AND (value->>'high_fill_threshold')::INT <= 100
+		ON CONFLICT (key) DO NOTHING`,
+
+		// Migration: Payroll periods - each exported period, and whether it is locked; the shifts that started in a
+		// locked period can't be added, changed (hours, bins, credits) or removed
+		`CREATE TABLE IF NOT EXISTS payroll_periods (
+			id TEXT PRIMARY KEY,
+			label TEXT NOT NULL UNIQUE,
+			period_start BIGINT NOT NULL,
+			period_end BIGINT NOT NULL,
+			timezone TEXT NOT NULL,
+			drivers INTEGER NOT NULL DEFAULT 0,
+			regular_hours DOUBLE PRECISION NOT NULL DEFAULT 0,
+			export_count INTEGER NOT NULL DEFAULT 0,
+			last_exported_at BIGINT NOT NULL,
+			last_exported_by_user_id TEXT REFERENCES users(id) ON DELETE SET NULL,
+			locked_at BIGINT,
+			locked_by_user_id TEXT REFERENCES users(id) ON DELETE SET NULL,
+			created_at BIGINT NOT NULL,
+			updated_at BIGINT NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_payroll_periods_locked ON payroll_periods(period_start, period_end) WHERE locked_at IS NOT NULL`,
+		`CREATE INDEX IF NOT EXISTS idx_shift_history_start_time ON shift_history(start_time)`,
+		`CREATE OR REPLACE FUNCTION enforce_payroll_lock() RETURNS trigger AS $$
+		DECLARE
+			locked_label TEXT;
+		BEGIN
+			IF TG_OP <> 'INSERT' AND OLD.start_time IS NOT NULL THEN
+				SELECT label INTO locked_label FROM payroll_periods
+				WHERE locked_at IS NOT NULL AND OLD.start_time >= period_start AND OLD.start_time < period_end
+				LIMIT 1;
+			END IF;
+			IF locked_label IS NULL AND TG_OP <> 'DELETE' AND NEW.start_time IS NOT NULL THEN
+				SELECT label INTO locked_label FROM payroll_periods
+				WHERE locked_at IS NOT NULL AND NEW.start_time >= period_start AND NEW.start_time < period_end
+				LIMIT 1;
+			END IF;
+			IF locked_label IS NOT NULL THEN
+				RAISE EXCEPTION 'payroll period % is locked', locked_label
+					USING ERRCODE = 'check_violation', CONSTRAINT = 'payroll_lock', DETAIL = locked_label;
+			END IF;
+			IF TG_OP = 'DELETE' THEN
+				RETURN OLD;
+			END IF;
+			RETURN NEW;
+		END;
+		$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS shift_history_payroll_lock ON shift_history`,
+		`CREATE TRIGGER shift_history_payroll_lock
+			BEFORE INSERT OR DELETE OR UPDATE OF driver_id, start_time, end_time, total_pause_seconds, completed_bins, earned_credits
+			ON shift_history
+			FOR EACH ROW EXECUTE FUNCTION enforce_payroll_lock()`,
+
+		// Migration: Managed incident types replace the incident_type CHECK constraint; the built-in types keep the
+		// zone score and radius they had, and any other type already in use is kept with the old defaults
+		`CREATE TABLE IF NOT EXISTS incident_types (
+			key TEXT PRIMARY KEY,
+			label TEXT NOT NULL,
+			description TEXT,
+			severity TEXT NOT NULL DEFAULT 'medium' CHECK(severity IN ('low', 'medium', 'high', 'critical')),
+			zone_score INTEGER NOT NULL DEFAULT 5,
+			zone_radius_meters INTEGER NOT NULL DEFAULT 250,
+			active BOOLEAN NOT NULL DEFAULT true,
+			created_by_user_id TEXT REFERENCES users(id) ON DELETE SET NULL,
+			created_at BIGINT NOT NULL,
+			updated_at BIGINT NOT NULL
+		)`,
+		`INSERT INTO incident_types (key, label, severity, zone_score, zone_radius_meters, created_at, updated_at)
+		SELECT t.key, t.label, t.severity, t.zone_score, t.zone_radius_meters, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT
+		FROM (VALUES
+			('theft', 'Theft', 'high', 20, 500),
+			('vandalized', 'Vandalized', 'high', 15, 500),
+			('missing', 'Missing', 'medium', 12, 300),
+			('damaged', 'Damaged', 'medium', 10, 300),
+			('landlord_complaint', 'Landlord complaint', 'medium', 8, 200),
+			('vandalism', 'Vandalism', 'medium', 5, 250),
+			('inaccessible', 'Inaccessible', 'low', 5, 150),
+			('relocation_request', 'Relocation request', 'low', 3, 150)
+		) AS t(key, label, severity, zone_score, zone_radius_meters)
+		ON CONFLICT (key) DO NOTHING`,
+		`INSERT INTO incident_types (key, label, created_at, updated_at)
+		SELECT DISTINCT incident_type, INITCAP(REPLACE(incident_type, '_', ' ')), EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT
+		FROM zone_incidents
+		ON CONFLICT (key) DO NOTHING`,
+		`DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'zone_incidents_incident_type_fkey') THEN
+				ALTER TABLE zone_incidents ADD CONSTRAINT zone_incidents_incident_type_fkey
+					FOREIGN KEY (incident_type) REFERENCES incident_types(key);
+			END IF;
+		END $$`,
+
+		// Migration: Manager area assignments - a manager assigned to areas sees their drivers, bins, move requests
+		// and incidents by default (managers without assignments see every area)
+		`CREATE TABLE IF NOT EXISTS manager_areas (
+			user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			area_id TEXT NOT NULL REFERENCES areas(id) ON DELETE CASCADE,
+			assigned_by_user_id TEXT REFERENCES users(id) ON DELETE SET NULL,
+			created_at BIGINT NOT NULL,
+			PRIMARY KEY (user_id, area_id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_manager_areas_area ON manager_areas(area_id)`,
+
+		// Migration: Structured move request addresses (street/city/zip next to the one-line address; existing
+		// rows are parsed by backfillMoveRequestAddresses)
+		`ALTER TABLE bin_move_requests ADD COLUMN IF NOT EXISTS original_street TEXT`,
+		`ALTER TABLE bin_move_requests ADD COLUMN IF NOT EXISTS original_city TEXT`,
+		`ALTER TABLE bin_move_requests ADD COLUMN IF NOT EXISTS original_zip TEXT`,
+		`ALTER TABLE bin_move_requests ADD COLUMN IF NOT EXISTS new_street TEXT`,
+		`ALTER TABLE bin_move_requests ADD COLUMN IF NOT EXISTS new_city TEXT`,
+		`ALTER TABLE bin_move_requests ADD COLUMN IF NOT EXISTS new_zip TEXT`,
+
+		// Migration: Bin fill history - every check and sensor reading is recorded raw by a trigger on checks, and
+		// the retention purger rolls old points into hourly and then daily averages (see models.FillResolutionRaw)
+		`CREATE TABLE IF NOT EXISTS bin_fill_history (
+			id BIGSERIAL PRIMARY KEY,
+			bin_id TEXT NOT NULL REFERENCES bins(id) ON DELETE CASCADE,
+			resolution TEXT NOT NULL CHECK(resolution IN ('raw', 'hourly', 'daily')),
+			recorded_at BIGINT NOT NULL,
+			fill_percentage DOUBLE PRECISION NOT NULL,
+			min_fill INT NOT NULL,
+			max_fill INT NOT NULL,
+			sample_count INT NOT NULL DEFAULT 1,
+			source TEXT
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_bin_fill_history_bin ON bin_fill_history(bin_id, recorded_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_bin_fill_history_rollup ON bin_fill_history(resolution, recorded_at)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_bin_fill_history_bucket ON bin_fill_history(bin_id, resolution, recorded_at) WHERE resolution <> 'raw'`,
+		`CREATE OR REPLACE FUNCTION record_bin_fill_history() RETURNS trigger AS $$
+		BEGIN
+			IF NEW.fill_percentage IS NOT NULL AND NEW.fill_percentage >= 0 THEN
+				INSERT INTO bin_fill_history (bin_id, resolution, recorded_at, fill_percentage, min_fill, max_fill, source)
+				VALUES (NEW.bin_id, 'raw', NEW.checked_on, NEW.fill_percentage, NEW.fill_percentage, NEW.fill_percentage, NEW.checked_from);
+			END IF;
+			RETURN NEW;
+		END;
+		$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS checks_record_fill_history ON checks`,
+		`CREATE TRIGGER checks_record_fill_history
+			AFTER INSERT ON checks
+			FOR EACH ROW EXECUTE FUNCTION record_bin_fill_history()`,
+		// Seed the history from the checks recorded before it existed (once, while it's empty)
+		`INSERT INTO bin_fill_history (bin_id, resolution, recorded_at, fill_percentage, min_fill, max_fill, source)
+		SELECT bin_id, 'raw', checked_on, fill_percentage, fill_percentage, fill_percentage, checked_from
+		FROM checks
+		WHERE fill_percentage >= 0 AND NOT EXISTS (SELECT 1 FROM bin_fill_history)`,
+
+		// Migration: Driver actions managers performed on a driver's behalf (start shift, complete bin), refused ones included
+		`CREATE TABLE IF NOT EXISTS on_behalf_actions (
+			id TEXT PRIMARY KEY,
+			action TEXT NOT NULL,
+			shift_id TEXT NOT NULL,
+			on_behalf_of TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			performed_by TEXT REFERENCES users(id) ON DELETE SET NULL,
+			performed_by_email TEXT NOT NULL,
+			reason TEXT NOT NULL,
+			request JSONB NOT NULL DEFAULT '{}',
+			status_code INT NOT NULL,
+			created_at BIGINT NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_on_behalf_actions_created_at ON on_behalf_actions(created_at DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_on_behalf_actions_shift ON on_behalf_actions(shift_id)`,
+
+		// Migration: Route polylines computed from the Directions API, cached per shift and stop sequence
+		// (a reorder deletes the shift's; any other change to the stops changes the key)
+		`CREATE TABLE IF NOT EXISTS route_polylines (
+			shift_id TEXT NOT NULL REFERENCES shifts(id) ON DELETE CASCADE,
+			stops_key TEXT NOT NULL,
+			polyline TEXT NOT NULL,
+			legs JSONB NOT NULL DEFAULT '[]',
+			distance_meters INT NOT NULL DEFAULT 0,
+			duration_seconds INT NOT NULL DEFAULT 0,
+			source TEXT NOT NULL,
+			computed_at BIGINT NOT NULL,
+			PRIMARY KEY (shift_id, stops_key)
+		)`,
+
+		// Migration: Incident notification subscriptions (managers pick the types, severities and areas they hear
+		// about, the channels, and immediate or daily delivery) and the incidents waiting for a daily summary
+		`CREATE TABLE IF NOT EXISTS incident_subscriptions (
+			id TEXT PRIMARY KEY,
+			user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			incident_types TEXT[] NOT NULL DEFAULT '{}',
+			severities TEXT[] NOT NULL DEFAULT '{}',
+			area_ids TEXT[] NOT NULL DEFAULT '{}',
+			websocket BOOLEAN NOT NULL DEFAULT true,
+			push BOOLEAN NOT NULL DEFAULT false,
+			delivery TEXT NOT NULL DEFAULT 'immediate' CHECK (delivery IN ('immediate', 'daily')),
+			created_at BIGINT NOT NULL,
+			updated_at BIGINT NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_incident_subscriptions_user ON incident_subscriptions(user_id)`,
+		`CREATE TABLE IF NOT EXISTS incident_summary_queue (
+			user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			incident_id TEXT NOT NULL REFERENCES zone_incidents(id) ON DELETE CASCADE,
+			websocket BOOLEAN NOT NULL,
+			push BOOLEAN NOT NULL,
+			queued_at BIGINT NOT NULL,
+			PRIMARY KEY (user_id, incident_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS incident_summary_runs (
+			summary_date TEXT PRIMARY KEY,
+			sent_at BIGINT NOT NULL,
+			summaries INT NOT NULL DEFAULT 0
+		)`,
+
+		// Migration: Bin reconciliation audit log (orphaned checks/moves/incidents/move requests remapped or dropped)
+		`CREATE TABLE IF NOT EXISTS bin_reconciliations (
+			id TEXT PRIMARY KEY,
+			missing_bin_id TEXT NOT NULL,
+			action TEXT NOT NULL CHECK(action IN ('remap', 'delete')),
+			target_bin_id TEXT REFERENCES bins(id) ON DELETE SET NULL,
+			target_bin_number INT,
+			rows JSONB NOT NULL,
+			applied_by_user_id TEXT REFERENCES users(id) ON DELETE SET NULL,
+			applied_at BIGINT NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_bin_reconciliations_applied_at ON bin_reconciliations(applied_at DESC)`,
+
+		// Migration: Background jobs (services.JobRunner) with retries, backoff and a dead letter state
+		`CREATE TABLE IF NOT EXISTS jobs (
+			id TEXT PRIMARY KEY,
+			kind TEXT NOT NULL,
+			payload JSONB NOT NULL DEFAULT '{}',
+			unique_key TEXT,
+			status TEXT NOT NULL DEFAULT 'pending' CHECK(status IN ('pending', 'running', 'succeeded', 'dead')),
+			attempts INT NOT NULL DEFAULT 0,
+			max_attempts INT NOT NULL DEFAULT 5,
+			last_error TEXT,
+			run_at BIGINT NOT NULL,
+			locked_at BIGINT,
+			finished_at BIGINT,
+			created_at BIGINT NOT NULL,
+			updated_at BIGINT NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_jobs_due ON jobs(run_at) WHERE status = 'pending'`,
+		`CREATE INDEX IF NOT EXISTS idx_jobs_status_kind ON jobs(status, kind, updated_at DESC)`,
+		// At most one pending or running job per unique key (periodic jobs use their kind)
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_unique_key ON jobs(unique_key) WHERE status IN ('pending', 'running')`,
+
+		// Migration: Deleted bins (merges, deletes, cleanups) for GET /api/bins/changes, recorded by trigger so
+		// every way of deleting a bin is covered
+		`CREATE TABLE IF NOT EXISTS bin_deletions (
+			bin_id TEXT PRIMARY KEY,
+			bin_number INT,
+			deleted_at BIGINT NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_bin_deletions_deleted_at ON bin_deletions(deleted_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_bins_updated_at ON bins(updated_at)`,
+		`CREATE OR REPLACE FUNCTION record_bin_deletion() RETURNS TRIGGER AS $$
+		BEGIN
+			INSERT INTO bin_deletions (bin_id, bin_number, deleted_at)
+			VALUES (OLD.id, OLD.bin_number, EXTRACT(EPOCH FROM NOW())::BIGINT)
+			ON CONFLICT (bin_id) DO UPDATE SET bin_number = EXCLUDED.bin_number, deleted_at = EXCLUDED.deleted_at;
+			RETURN OLD;
+		END;
+		$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS bins_record_deletion ON bins`,
+		`CREATE TRIGGER bins_record_deletion AFTER DELETE ON bins
+			FOR EACH ROW EXECUTE FUNCTION record_bin_deletion()`,
+		// Tags are part of the bin payload, so tagging, untagging and renaming a tag count as changes to the bins
+		`CREATE OR REPLACE FUNCTION touch_tagged_bin() RETURNS TRIGGER AS $$
+		BEGIN
+			IF TG_TABLE_NAME = 'tags' THEN
+				UPDATE bins SET updated_at = GREATEST(updated_at, EXTRACT(EPOCH FROM NOW())::BIGINT)
+				WHERE id IN (SELECT bin_id FROM bin_tags WHERE tag_id = NEW.id);
+				RETURN NEW;
+			END IF;
+			IF TG_OP = 'DELETE' THEN
+				UPDATE bins SET updated_at = GREATEST(updated_at, EXTRACT(EPOCH FROM NOW())::BIGINT) WHERE id = OLD.bin_id;
+			ELSE
+				UPDATE bins SET updated_at = GREATEST(updated_at, EXTRACT(EPOCH FROM NOW())::BIGINT) WHERE id = NEW.bin_id;
+			END IF;
+			RETURN NULL;
+		END;
+		$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS bin_tags_touch_bin ON bin_tags`,
+		`CREATE TRIGGER bin_tags_touch_bin AFTER INSERT OR DELETE ON bin_tags
+			FOR EACH ROW EXECUTE FUNCTION touch_tagged_bin()`,
+		`DROP TRIGGER IF EXISTS tags_touch_bins ON tags`,
+		`CREATE TRIGGER tags_touch_bins AFTER UPDATE OF name, color ON tags
+			FOR EACH ROW EXECUTE FUNCTION touch_tagged_bin()`,
+
+		// Migration: Repeat reports of an incident link to the first report, which counts them
+		`ALTER TABLE zone_incidents ADD COLUMN IF NOT EXISTS parent_incident_id TEXT REFERENCES zone_incidents(id) ON DELETE SET NULL`,
+		`ALTER TABLE zone_incidents ADD COLUMN IF NOT EXISTS report_count INT NOT NULL DEFAULT 1`,
+		`CREATE INDEX IF NOT EXISTS idx_zone_incidents_parent ON zone_incidents(parent_incident_id) WHERE parent_incident_id IS NOT NULL`,
+		`CREATE INDEX IF NOT EXISTS idx_zone_incidents_dedup ON zone_incidents(bin_id, incident_type, reported_at DESC) WHERE parent_incident_id IS NULL`,
 	}
 
 	for _, migration := range migrations {
@@ -638,6 +1901,9 @@ func Migrate(db *sqlx.DB) error {
 	}
 
 	log.Println("✓ Database migrations completed")
+
+	backfillMoveRequestAddresses(db)
+	migratePostGIS(db)
 	return nil
 }
 
@@ -0,0 +1,40 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ImpersonationSessionSelect loads models.ImpersonationSession rows (s) with the driver's name and request counts
+const ImpersonationSessionSelect = `
+	SELECT s.*, d.name AS driver_name,
+	       (SELECT COUNT(*) FROM impersonated_requests ir WHERE ir.session_id = s.id) AS request_count,
+	       (SELECT COUNT(*) FROM impersonated_requests ir WHERE ir.session_id = s.id AND ir.blocked) AS blocked_count
+	FROM impersonation_sessions s
+	LEFT JOIN users d ON d.id = s.driver_id`
+
+// IsImpersonationActive reports whether a support mode session has neither expired nor been ended
+func IsImpersonationActive(db *sqlx.DB, sessionID string) (bool, error) {
+	var active bool
+	err := db.Get(&active, `
+		SELECT EXISTS(SELECT 1 FROM impersonation_sessions WHERE id = $1 AND ended_at IS NULL AND expires_at > $2)
+	`, sessionID, time.Now().Unix())
+	if err != nil {
+		return false, fmt.Errorf("failed to load impersonation session %s: %w", sessionID, err)
+	}
+	return active, nil
+}
+
+// RecordImpersonatedRequest adds a request made with a support mode token to the session's audit trail
+func RecordImpersonatedRequest(db *sqlx.DB, sessionID, method, path string, status int, blocked bool) error {
+	_, err := db.Exec(`
+		INSERT INTO impersonated_requests (session_id, method, path, status, blocked, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, sessionID, method, path, status, blocked, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("failed to record impersonated request: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"ropacal-backend/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// LoginThrottlePolicy controls backoff and lockout for failed logins in one scope
+type LoginThrottlePolicy struct {
+	FreeAttempts       int   // Failures allowed before backoff starts
+	BaseBackoffSeconds int64 // First backoff delay, doubled for each further failure
+	MaxBackoffSeconds  int64
+	LockoutThreshold   int   // Failures that trigger a lockout (0 = backoff only, never locked out)
+	LockoutSeconds     int64 // Lockout duration (admins can unlock accounts early)
+	ResetAfterSeconds  int64 // Failure count resets after this long without failures
+}
+
+// Login throttle policies per scope
+var (
+	AccountLoginPolicy = LoginThrottlePolicy{
+		FreeAttempts:       3,
+		BaseBackoffSeconds: 2,
+		MaxBackoffSeconds:  5 * 60,
+		LockoutThreshold:   10,
+		LockoutSeconds:     30 * 60,
+		ResetAfterSeconds:  60 * 60,
+	}
+	// An IP can be shared by a whole depot (NAT, carrier-grade NAT), so it only ever backs off briefly:
+	// a lockout here would stop everyone behind it, including users with the right password
+	IPLoginPolicy = LoginThrottlePolicy{
+		FreeAttempts:       10,
+		BaseBackoffSeconds: 1,
+		MaxBackoffSeconds:  60,
+		ResetAfterSeconds:  60 * 60,
+	}
+)
+
+// LoginPolicyForScope returns the throttle policy for a scope
+func LoginPolicyForScope(scope string) LoginThrottlePolicy {
+	if scope == models.LoginThrottleScopeIP {
+		return IPLoginPolicy
+	}
+	return AccountLoginPolicy
+}
+
+// GetActiveLoginThrottle returns the throttle for scope/key if it currently blocks logins, or nil
+func GetActiveLoginThrottle(db *sqlx.DB, scope, key string, now int64) (*models.LoginThrottle, error) {
+	var throttle models.LoginThrottle
+	err := db.Get(&throttle, `
+		SELECT scope, key, failed_count, first_failed_at, last_failed_at, locked_until, is_locked_out
+		FROM login_throttles
+		WHERE scope = $1 AND key = $2 AND locked_until > $3
+	`, scope, key, now)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to check login throttle: %w", err)
+	}
+	return &throttle, nil
+}
+
+// RecordLoginFailure counts a failed login and applies exponential backoff or lockout
+// Returns the updated throttle; IsLockedOut is true once the lockout threshold is reached
+func RecordLoginFailure(db *sqlx.DB, scope, key string, now int64) (*models.LoginThrottle, error) {
+	policy := LoginPolicyForScope(scope)
+
+	var throttle models.LoginThrottle
+	err := db.Get(&throttle, `
+		INSERT INTO login_throttles (scope, key, failed_count, first_failed_at, last_failed_at, locked_until, is_locked_out)
+		VALUES ($1, $2, 1, $3, $3, 0, FALSE)
+		ON CONFLICT (scope, key) DO UPDATE
+		SET failed_count = CASE WHEN login_throttles.last_failed_at < $4 THEN 1 ELSE login_throttles.failed_count + 1 END,
+		    first_failed_at = CASE WHEN login_throttles.last_failed_at < $4 THEN $3 ELSE login_throttles.first_failed_at END,
+		    last_failed_at = $3
+		RETURNING scope, key, failed_count, first_failed_at, last_failed_at, locked_until, is_locked_out
+	`, scope, key, now, now-policy.ResetAfterSeconds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record login failure: %w", err)
+	}
+
+	throttle.IsLockedOut = policy.LockoutThreshold > 0 && throttle.FailedCount >= policy.LockoutThreshold
+	throttle.LockedUntil = now + loginBackoffSeconds(policy, throttle.FailedCount)
+	if throttle.IsLockedOut {
+		throttle.LockedUntil = now + policy.LockoutSeconds
+	}
+
+	_, err = db.Exec(`
+		UPDATE login_throttles SET locked_until = $1, is_locked_out = $2
+		WHERE scope = $3 AND key = $4
+	`, throttle.LockedUntil, throttle.IsLockedOut, scope, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update login throttle: %w", err)
+	}
+
+	return &throttle, nil
+}
+
+// ClearLoginThrottle removes failure tracking for scope/key (successful login or admin unlock)
+// Returns true if a throttle existed
+func ClearLoginThrottle(db *sqlx.DB, scope, key string) (bool, error) {
+	result, err := db.Exec(`DELETE FROM login_throttles WHERE scope = $1 AND key = $2`, scope, key)
+	if err != nil {
+		return false, fmt.Errorf("failed to clear login throttle: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+// loginBackoffSeconds returns the delay before the next attempt after failedCount failures
+func loginBackoffSeconds(policy LoginThrottlePolicy, failedCount int) int64 {
+	if failedCount <= policy.FreeAttempts {
+		return 0
+	}
+	delay := policy.BaseBackoffSeconds
+	for i := policy.FreeAttempts + 1; i < failedCount && delay < policy.MaxBackoffSeconds; i++ {
+		delay *= 2
+	}
+	if delay > policy.MaxBackoffSeconds {
+		delay = policy.MaxBackoffSeconds
+	}
+	return delay
+}
@@ -0,0 +1,16 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// GetManagerAreaIDs returns the areas a manager is assigned to; none means the manager oversees every area
+func GetManagerAreaIDs(db sqlx.Queryer, userID string) ([]string, error) {
+	areaIDs := []string{}
+	if err := sqlx.Select(db, &areaIDs, `SELECT area_id FROM manager_areas WHERE user_id = $1 ORDER BY area_id`, userID); err != nil {
+		return nil, fmt.Errorf("failed to load manager areas: %w", err)
+	}
+	return areaIDs, nil
+}
@@ -0,0 +1,64 @@
+package database
+
+import (
+	"log"
+	"os"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// postgisEnabled is set once the PostGIS migrations below have run
+var postgisEnabled atomic.Bool
+
+// PostGISEnabled reports whether geo queries can use the geography columns and spatial indexes
+// When false, callers fall back to bounding boxes and haversine in Go
+func PostGISEnabled() bool {
+	return postgisEnabled.Load()
+}
+
+// PostGISPoint is the SQL for a geography point from longitude and latitude placeholders, e.g. PostGISPoint("$2", "$1")
+func PostGISPoint(lngParam, latParam string) string {
+	return "ST_SetSRID(ST_MakePoint(" + lngParam + ", " + latParam + "), 4326)::geography"
+}
+
+// Generated geography columns (kept in sync with the latitude/longitude columns by Postgres) and their GiST indexes
+// Every model scanned with SELECT * from these tables has a matching Geog field
+var postgisMigrations = []string{
+	`CREATE EXTENSION IF NOT EXISTS postgis`,
+
+	`ALTER TABLE bins ADD COLUMN IF NOT EXISTS geog geography(Point, 4326)
+		GENERATED ALWAYS AS (
+			CASE WHEN latitude IS NOT NULL AND longitude IS NOT NULL
+			     THEN ST_SetSRID(ST_MakePoint(longitude, latitude), 4326)::geography
+			END
+		) STORED`,
+	`CREATE INDEX IF NOT EXISTS idx_bins_geog ON bins USING GIST (geog)`,
+
+	`ALTER TABLE no_go_zones ADD COLUMN IF NOT EXISTS geog geography(Point, 4326)
+		GENERATED ALWAYS AS (ST_SetSRID(ST_MakePoint(center_longitude, center_latitude), 4326)::geography) STORED`,
+	`CREATE INDEX IF NOT EXISTS idx_no_go_zones_geog ON no_go_zones USING GIST (geog)`,
+
+	`ALTER TABLE driver_current_location ADD COLUMN IF NOT EXISTS geog geography(Point, 4326)
+		GENERATED ALWAYS AS (ST_SetSRID(ST_MakePoint(longitude, latitude), 4326)::geography) STORED`,
+	`CREATE INDEX IF NOT EXISTS idx_driver_current_location_geog ON driver_current_location USING GIST (geog)`,
+}
+
+// migratePostGIS enables PostGIS when POSTGIS_ENABLED=true and the extension is available on the server
+// Failures are logged and leave PostGIS disabled rather than stopping startup
+func migratePostGIS(db *sqlx.DB) {
+	if os.Getenv("POSTGIS_ENABLED") != "true" {
+		log.Println("ℹ️  PostGIS disabled (set POSTGIS_ENABLED=true to use spatial indexes)")
+		return
+	}
+
+	for _, migration := range postgisMigrations {
+		if _, err := db.Exec(migration); err != nil {
+			log.Printf("⚠️  PostGIS unavailable, using haversine fallbacks: %v", err)
+			return
+		}
+	}
+
+	postgisEnabled.Store(true)
+	log.Println("✓ PostGIS enabled (geography columns and spatial indexes ready)")
+}
@@ -214,37 +214,6 @@ func CreateShiftWithTasks(
 		}
 	}
 
-	// Populate shift_bins table for backward compatibility with StartShift handler
-	// Extract all collection tasks (bins to collect) and create shift_bins entries
-	shiftBinsQuery := `
-		INSERT INTO shift_bins (
-			shift_id, bin_id, sequence_order, is_completed, created_at
-		) VALUES ($1, $2, $3, 0, $4)
-	`
-
-	binSequence := 0
-	for i, taskData := range tasks {
-		taskType, _ := taskData["task_type"].(string)
-		// Only create shift_bins for collection tasks (bins to collect)
-		if taskType == "collection" {
-			binSequence++
-			if binIDVal, ok := taskData["bin_id"]; ok && binIDVal != nil {
-				binID, _ := binIDVal.(string)
-				if binID != "" {
-					_, err = tx.Exec(shiftBinsQuery, shiftID, binID, binSequence, now)
-					if err != nil {
-						log.Printf("⚠️  Warning: Failed to create shift_bins entry for task %d: %v", i+1, err)
-						// Don't fail the whole transaction for this
-					}
-				}
-			}
-		}
-	}
-
-	if binSequence > 0 {
-		log.Printf("✅ Created %d shift_bins entries for backward compatibility", binSequence)
-	}
-
 	err = tx.Commit()
 	if err != nil {
 		return "", 0, fmt.Errorf("failed to commit transaction: %w", err)
@@ -261,6 +230,7 @@ func CompleteTask(
 	updatedFillPercentage *int,
 	photoURL *string,
 	newBinID *string,
+	skipped bool,
 ) error {
 	now := time.Now().Unix()
 
@@ -269,11 +239,12 @@ func CompleteTask(
 		SET is_completed = 1,
 		    completed_at = $1,
 		    updated_fill_percentage = $2,
+		    skipped = $5,
 		    updated_at = $3
 		WHERE id = $4
 	`
 
-	result, err := db.Exec(query, now, updatedFillPercentage, now, taskID)
+	result, err := db.Exec(query, now, updatedFillPercentage, now, taskID, skipped)
 	if err != nil {
 		return fmt.Errorf("failed to complete task: %w", err)
 	}
@@ -0,0 +1,48 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// RecordRouteVersion snapshots a route's current blueprint (fields and bins in order) as its next version
+// Call it in the transaction that changed the route; returns sql.ErrNoRows (wrapped) if the route doesn't exist
+func RecordRouteVersion(q sqlx.Ext, routeID string, userID *string, now int64) (int, error) {
+	// Serializes versioning of the same route so two edits can't claim the same number
+	var lockedID string
+	if err := sqlx.Get(q, &lockedID, `SELECT id FROM routes WHERE id = $1 FOR UPDATE`, routeID); err != nil {
+		return 0, fmt.Errorf("failed to lock route %s: %w", routeID, err)
+	}
+
+	var version int
+	err := sqlx.Get(q, &version, `
+		INSERT INTO route_versions (route_id, version, name, description, geographic_area, schedule_pattern,
+		                            estimated_duration_hours, bin_ids, created_by_user_id, created_at)
+		SELECT r.id,
+		       COALESCE((SELECT MAX(v.version) FROM route_versions v WHERE v.route_id = r.id), 0) + 1,
+		       r.name, r.description, r.geographic_area, r.schedule_pattern, r.estimated_duration_hours,
+		       ARRAY(SELECT rb.bin_id FROM route_bins rb WHERE rb.route_id = r.id ORDER BY rb.sequence_order),
+		       $2, $3
+		FROM routes r
+		WHERE r.id = $1
+		RETURNING version
+	`, routeID, userID, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record version of route %s: %w", routeID, err)
+	}
+	return version, nil
+}
+
+// CurrentRouteVersion returns a route's latest version, recording version 1 for a route that has none yet
+func CurrentRouteVersion(q sqlx.Ext, routeID string, now int64) (int, error) {
+	var version sql.NullInt64
+	if err := sqlx.Get(q, &version, `SELECT MAX(version) FROM route_versions WHERE route_id = $1`, routeID); err != nil {
+		return 0, fmt.Errorf("failed to get version of route %s: %w", routeID, err)
+	}
+	if version.Valid {
+		return int(version.Int64), nil
+	}
+	return RecordRouteVersion(q, routeID, nil, now)
+}
@@ -0,0 +1,228 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"ropacal-backend/internal/cache"
+	"ropacal-backend/internal/models"
+)
+
+// settingsCache holds settings by key; nil marks a key that has never been saved
+var settingsCache = cache.New[string, *models.Setting](64)
+
+// GetSetting loads the raw JSON value for a settings key (cached briefly, refreshed by UpsertSetting)
+// Returns sql.ErrNoRows if the key has never been saved; the result is shared and must not be modified
+func GetSetting(db sqlx.Queryer, key string) (*models.Setting, error) {
+	setting, err := settingsCache.GetOrLoad(key, func() (*models.Setting, error) {
+		var setting models.Setting
+		err := sqlx.Get(db, &setting, `SELECT key, value, updated_at, updated_by_user_id FROM settings WHERE key = $1`, key)
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &setting, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if setting == nil {
+		return nil, sql.ErrNoRows
+	}
+	return setting, nil
+}
+
+// LoadSetting decodes a settings key over defaults(); keys missing from the stored JSON keep their default values
+// label names the setting in errors (e.g. "priority weights")
+func LoadSetting[T any](db sqlx.Queryer, key, label string, defaults func() T) (T, error) {
+	setting, err := GetSetting(db, key)
+	return decodeSetting(setting, err, label, defaults)
+}
+
+// LockSetting is LoadSetting for a read-modify-write inside tx: it reads past the cache and holds the key until tx
+// ends, so concurrent updates apply one after the other instead of overwriting each other
+// An existing key is row-locked; a key that has never been saved is covered by an advisory lock until its first save
+func LockSetting[T any](tx *sqlx.Tx, key, label string, defaults func() T) (T, error) {
+	setting, err := selectSettingForUpdate(tx, key)
+	if err == sql.ErrNoRows {
+		if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext($1))`, "settings:"+key); err != nil {
+			return defaults(), fmt.Errorf("failed to lock %s: %w", label, err)
+		}
+		// Whoever held the lock may have saved the key first
+		setting, err = selectSettingForUpdate(tx, key)
+	}
+	return decodeSetting(setting, err, label, defaults)
+}
+
+func selectSettingForUpdate(tx *sqlx.Tx, key string) (*models.Setting, error) {
+	var setting models.Setting
+	err := tx.Get(&setting, `SELECT key, value, updated_at, updated_by_user_id FROM settings WHERE key = $1 FOR UPDATE`, key)
+	if err != nil {
+		return nil, err
+	}
+	return &setting, nil
+}
+
+// decodeSetting unmarshals a loaded setting over defaults(), treating sql.ErrNoRows as "never saved"
+func decodeSetting[T any](setting *models.Setting, err error, label string, defaults func() T) (T, error) {
+	value := defaults()
+	if err == sql.ErrNoRows {
+		return value, nil
+	}
+	if err != nil {
+		return value, fmt.Errorf("failed to load %s: %w", label, err)
+	}
+
+	if err := json.Unmarshal(setting.Value, &value); err != nil {
+		return defaults(), fmt.Errorf("failed to parse %s: %w", label, err)
+	}
+
+	return value, nil
+}
+
+// UpsertSetting stores a JSON value for a settings key
+// Inside a transaction, call InvalidateSetting once it commits so readers don't keep the value from before it
+func UpsertSetting(db sqlx.Execer, key string, value interface{}, userID *string, now int64) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal setting %s: %w", key, err)
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO settings (key, value, updated_at, updated_by_user_id)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (key) DO UPDATE
+		SET value = EXCLUDED.value,
+		    updated_at = EXCLUDED.updated_at,
+		    updated_by_user_id = EXCLUDED.updated_by_user_id
+	`, key, string(raw), now, userID) // string so lib/pq sends JSON text, not bytea
+	if err != nil {
+		return fmt.Errorf("failed to save setting %s: %w", key, err)
+	}
+	settingsCache.Delete(key)
+
+	return nil
+}
+
+// InvalidateSetting drops a key from the settings cache so the next read goes to the database
+func InvalidateSetting(key string) {
+	settingsCache.Delete(key)
+}
+
+// GetPriorityWeights returns the stored priority weights merged over the defaults
+// Keys missing from the stored JSON keep their default values
+func GetPriorityWeights(db sqlx.Queryer) (models.PriorityWeights, error) {
+	return LoadSetting(db, models.SettingKeyPriorityWeights, "priority weights", models.DefaultPriorityWeights)
+}
+
+// GetEarningsRates returns the stored driver earnings rates merged over the defaults
+func GetEarningsRates(db sqlx.Queryer) (models.EarningsRates, error) {
+	return LoadSetting(db, models.SettingKeyEarningsRates, "earnings rates", models.DefaultEarningsRates)
+}
+
+// GetMoveSLAPolicy returns the stored move request SLA merged over the defaults
+func GetMoveSLAPolicy(db sqlx.Queryer) (models.MoveSLAPolicy, error) {
+	return LoadSetting(db, models.SettingKeyMoveSLA, "move SLA", models.DefaultMoveSLAPolicy)
+}
+
+// GetClientConfig returns the stored app version policy and feature flags, or the defaults
+func GetClientConfig(db sqlx.Queryer) (models.ClientConfig, error) {
+	return LoadSetting(db, models.SettingKeyClientConfig, "client config", models.DefaultClientConfig)
+}
+
+// GetWorkloadLimits returns the stored driver workload limits merged over the defaults
+func GetWorkloadLimits(db sqlx.Queryer) (models.WorkloadLimits, error) {
+	return LoadSetting(db, models.SettingKeyWorkloadLimits, "workload limits", models.DefaultWorkloadLimits)
+}
+
+// GetDigestSettings returns the stored bins-at-risk digest settings merged over the defaults
+func GetDigestSettings(db sqlx.Queryer) (models.DigestSettings, error) {
+	return LoadSetting(db, models.SettingKeyDigest, "digest settings", models.DefaultDigestSettings)
+}
+
+// GetUndoSettings returns the stored undo window settings merged over the defaults
+func GetUndoSettings(db sqlx.Queryer) (models.UndoSettings, error) {
+	return LoadSetting(db, models.SettingKeyUndo, "undo settings", models.DefaultUndoSettings)
+}
+
+// GetZoneRiskRoutingSettings returns the stored zone risk routing settings merged over the defaults
+func GetZoneRiskRoutingSettings(db sqlx.Queryer) (models.ZoneRiskRoutingSettings, error) {
+	return LoadSetting(db, models.SettingKeyZoneRiskRouting, "zone risk routing settings", models.DefaultZoneRiskRoutingSettings)
+}
+
+// SeedSetting stores a value for a settings key only if the key has never been saved
+func SeedSetting(db *sqlx.DB, key string, value interface{}, now int64) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal setting %s: %w", key, err)
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO settings (key, value, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO NOTHING
+	`, key, string(raw), now)
+	if err != nil {
+		return fmt.Errorf("failed to seed setting %s: %w", key, err)
+	}
+	settingsCache.Delete(key)
+
+	return nil
+}
+
+// GetRetentionSettings returns the stored data retention periods merged over the defaults
+func GetRetentionSettings(db sqlx.Queryer) (models.RetentionSettings, error) {
+	return LoadSetting(db, models.SettingKeyRetention, "retention settings", models.DefaultRetentionSettings)
+}
+
+// GetServiceHoursSettings returns the stored service hours merged over the defaults
+func GetServiceHoursSettings(db sqlx.Queryer) (models.ServiceHoursSettings, error) {
+	return LoadSetting(db, models.SettingKeyServiceHours, "service hours", models.DefaultServiceHoursSettings)
+}
+
+// GetCostRates returns the stored shift cost rates merged over the defaults
+func GetCostRates(db sqlx.Queryer) (models.CostRates, error) {
+	return LoadSetting(db, models.SettingKeyCostRates, "cost rates", models.DefaultCostRates)
+}
+
+// GetCheckForm returns the stored check form, or the default form (no extra fields)
+func GetCheckForm(db sqlx.Queryer) (models.CheckForm, error) {
+	form, err := LoadSetting(db, models.SettingKeyCheckForm, "check form", models.DefaultCheckForm)
+	if form.Fields == nil {
+		form.Fields = []models.CheckFormField{}
+	}
+	return form, err
+}
+
+// GetDebriefSettings returns the stored end-of-shift debrief settings, or the defaults (optional, no questions)
+func GetDebriefSettings(db sqlx.Queryer) (models.DebriefSettings, error) {
+	settings, err := LoadSetting(db, models.SettingKeyShiftDebrief, "shift debrief settings", models.DefaultDebriefSettings)
+	if settings.Questions == nil {
+		settings.Questions = []models.CheckFormField{}
+	}
+	return settings, err
+}
+
+// GetCheckInProximitySettings returns the stored check-in proximity settings, or the built-in defaults
+func GetCheckInProximitySettings(db sqlx.Queryer) (models.CheckInProximitySettings, error) {
+	return LoadSetting(db, models.SettingKeyCheckInProximity, "check-in proximity settings", models.DefaultCheckInProximitySettings)
+}
+
+// GetFillThresholds returns the stored bin fill thresholds, or the built-in defaults
+func GetFillThresholds(db sqlx.Queryer) (models.FillThresholds, error) {
+	return LoadSetting(db, models.SettingKeyFillThresholds, "fill thresholds", models.DefaultFillThresholds)
+}
+
+// GetPayrollExportSettings returns the payroll export column mapping, falling back to defaults when unset
+func GetPayrollExportSettings(db sqlx.Queryer) (models.PayrollExportSettings, error) {
+	return LoadSetting(db, models.SettingKeyPayrollExport, "payroll export settings", models.DefaultPayrollExportSettings)
+}
+
+// GetIncidentDedupSettings returns the stored incident deduplication settings, or the built-in defaults
+func GetIncidentDedupSettings(db sqlx.Queryer) (models.IncidentDedupSettings, error) {
+	return LoadSetting(db, models.SettingKeyIncidentDedup, "incident dedup settings", models.DefaultIncidentDedupSettings)
+}
@@ -0,0 +1,36 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"ropacal-backend/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// RecordShiftCostEstimate prices a newly assigned shift and stores the estimate on it
+// The route blueprint's estimated duration is used when set; otherwise hours, the caller's projection
+// from the stops. Custom bin selections pass an empty routeID
+func RecordShiftCostEstimate(q sqlx.Ext, shiftID, routeID string, hours, distanceKm float64, rates models.CostRates) (models.ShiftCost, error) {
+	if routeID != "" && routeID != "custom" {
+		var blueprintHours sql.NullFloat64
+		err := sqlx.Get(q, &blueprintHours, `SELECT estimated_duration_hours FROM routes WHERE id = $1`, routeID)
+		if err != nil && err != sql.ErrNoRows {
+			return models.ShiftCost{}, fmt.Errorf("failed to load estimated duration of route %s: %w", routeID, err)
+		}
+		if blueprintHours.Valid && blueprintHours.Float64 > 0 {
+			hours = blueprintHours.Float64
+		}
+	}
+
+	cost := rates.Calculate(hours, distanceKm)
+	_, err := q.Exec(`
+		UPDATE shifts SET estimated_hours = $1, estimated_distance_km = $2, estimated_cost = $3
+		WHERE id = $4
+	`, cost.Hours, cost.DistanceKm, cost.Total, shiftID)
+	if err != nil {
+		return cost, fmt.Errorf("failed to record cost estimate of shift %s: %w", shiftID, err)
+	}
+	return cost, nil
+}
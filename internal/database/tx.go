@@ -0,0 +1,41 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrRollback can be returned by a WithTx callback to discard its changes without failing (e.g. previews)
+var ErrRollback = errors.New("transaction rolled back")
+
+// WithTx runs fn in one transaction: committed if fn returns nil, rolled back if it returns an error or panics
+// fn is only handed the transaction, so everything it reads and writes is part of it - code inside it must
+// not reach for the *sqlx.DB, or those statements commit on their own and survive a rollback
+// fn's error is returned as-is (ErrRollback becomes nil); a failed begin or commit is wrapped
+func WithTx(ctx context.Context, db *sqlx.DB, fn func(tx *sqlx.Tx) error) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		if errors.Is(err, ErrRollback) {
+			return nil
+		}
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
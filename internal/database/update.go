@@ -0,0 +1,73 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrEmptyUpdate is returned by Build when no column was set; handlers answer 400 for an empty PATCH
+var ErrEmptyUpdate = errors.New("no columns to update")
+
+// Update builds an UPDATE statement from optional columns, for handlers that only change the fields a
+// request sent. Positional placeholders ($1, $2, ...) are numbered as values are added, so any number of
+// columns works and callers never count arguments themselves
+//
+//	u := database.NewUpdate("routes").Set("updated_at", now)
+//	if req.Name != nil {
+//		u.Set("name", *req.Name)
+//	}
+//	query, args, err := u.Where("id", routeID).Build()
+type Update struct {
+	table string
+	sets  []string
+	where []string
+	args  []interface{}
+}
+
+// NewUpdate starts an UPDATE of table
+func NewUpdate(table string) *Update {
+	return &Update{table: table}
+}
+
+// arg adds a value and returns its placeholder
+func (u *Update) arg(value interface{}) string {
+	u.args = append(u.args, value)
+	return fmt.Sprintf("$%d", len(u.args))
+}
+
+// Set sets column to value
+func (u *Update) Set(column string, value interface{}) *Update {
+	u.sets = append(u.sets, column+" = "+u.arg(value))
+	return u
+}
+
+// SetNull sets column to NULL
+func (u *Update) SetNull(column string) *Update {
+	u.sets = append(u.sets, column+" = NULL")
+	return u
+}
+
+// Where adds "column = value" to the WHERE clause (conditions are ANDed)
+func (u *Update) Where(column string, value interface{}) *Update {
+	u.where = append(u.where, column+" = "+u.arg(value))
+	return u
+}
+
+// Len returns the number of columns set so far
+func (u *Update) Len() int {
+	return len(u.sets)
+}
+
+// Build returns the statement and its arguments
+// It fails with ErrEmptyUpdate without a SET column, and refuses to build an update without a WHERE clause
+func (u *Update) Build() (string, []interface{}, error) {
+	if len(u.sets) == 0 {
+		return "", nil, fmt.Errorf("%w on %s", ErrEmptyUpdate, u.table)
+	}
+	if len(u.where) == 0 {
+		return "", nil, fmt.Errorf("update of %s has no WHERE clause", u.table)
+	}
+	query := "UPDATE " + u.table + " SET " + strings.Join(u.sets, ", ") + " WHERE " + strings.Join(u.where, " AND ")
+	return query, u.args, nil
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"ropacal-backend/internal/i18n"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// GetUserLocale returns a user's stored locale preference (nil if unset or the user doesn't exist)
+func GetUserLocale(db sqlx.Queryer, userID string) (*string, error) {
+	var locale *string
+	err := sqlx.Get(db, &locale, `SELECT locale FROM users WHERE id = $1`, userID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load locale: %w", err)
+	}
+	return locale, nil
+}
+
+// UserLocale returns the locale to use for server-initiated messages (push notifications, WebSocket events)
+// Falls back to the default locale when the user has no preference
+func UserLocale(db sqlx.Queryer, userID string) string {
+	locale, err := GetUserLocale(db, userID)
+	if err != nil {
+		return i18n.DefaultLocale
+	}
+	return i18n.Resolve(locale, "")
+}
+
+// UserLocales returns the locale for each of several users (see UserLocale), keyed by user ID
+func UserLocales(db *sqlx.DB, userIDs []string) map[string]string {
+	locales := make(map[string]string, len(userIDs))
+	for _, userID := range userIDs {
+		locales[userID] = i18n.DefaultLocale
+	}
+	if len(userIDs) == 0 {
+		return locales
+	}
+
+	var rows []struct {
+		ID     string  `db:"id"`
+		Locale *string `db:"locale"`
+	}
+	if err := db.Select(&rows, `SELECT id, locale FROM users WHERE id = ANY($1)`, pq.Array(userIDs)); err != nil {
+		return locales
+	}
+	for _, row := range rows {
+		locales[row.ID] = i18n.Resolve(row.Locale, "")
+	}
+	return locales
+}
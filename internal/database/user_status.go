@@ -0,0 +1,21 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// IsUserDeactivated reports whether a user has been deactivated (false if the user doesn't exist)
+func IsUserDeactivated(db *sqlx.DB, userID string) (bool, error) {
+	var deactivatedAt *int64
+	err := db.Get(&deactivatedAt, `SELECT deactivated_at FROM users WHERE id = $1`, userID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to load user status: %w", err)
+	}
+	return deactivatedAt != nil, nil
+}
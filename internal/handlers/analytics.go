@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"strconv"
 
+	"ropacal-backend/pkg/utils"
+
 	"github.com/jmoiron/sqlx"
 )
 
@@ -135,14 +137,14 @@ func GetTopPerformingBins(db *sqlx.DB) http.HandlerFunc {
 			`
 
 		default:
-			http.Error(w, "Invalid metric. Use: reliability, fill_rate, uptime, check_count", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Invalid metric. Use: reliability, fill_rate, uptime, check_count")
 			return
 		}
 
 		var results []BinPerformance
-		err := db.Select(&results, query, limit)
+		err := db.SelectContext(r.Context(), &results, query, limit)
 		if err != nil {
-			http.Error(w, "Failed to fetch top performers", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch top performers")
 			return
 		}
 
@@ -158,14 +160,15 @@ func GetTopPerformingBins(db *sqlx.DB) http.HandlerFunc {
 }
 
 // GetAreaPerformance returns area/ZIP code performance metrics
+// group_by=area (default) groups by the bin's assigned area; zip and city group by address fields, tag by the bin's tags
 func GetAreaPerformance(db *sqlx.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		groupBy := r.URL.Query().Get("group_by") // zip, city
+		groupBy := r.URL.Query().Get("group_by") // area, zip, city, tag
 		metric := r.URL.Query().Get("metric")    // success_rate, fill_rate, check_frequency
 		limitStr := r.URL.Query().Get("limit")
 
 		if groupBy == "" {
-			groupBy = "zip"
+			groupBy = "area"
 		}
 		if metric == "" {
 			metric = "success_rate"
@@ -179,28 +182,62 @@ func GetAreaPerformance(db *sqlx.DB) http.HandlerFunc {
 		}
 
 		type AreaPerformance struct {
-			GroupValue        string   `json:"group_value" db:"group_value"` // ZIP or city
+			GroupValue        string   `json:"group_value" db:"group_value"` // Area name, ZIP or city
+			AreaID            *string  `json:"area_id,omitempty" db:"area_id"`
+			TagID             *string  `json:"tag_id,omitempty" db:"tag_id"`
 			City              *string  `json:"city,omitempty" db:"city"`
 			TotalBins         int      `json:"total_bins" db:"total_bins"`
 			ActiveBins        int      `json:"active_bins" db:"active_bins"`
 			CleanBins         int      `json:"clean_bins" db:"clean_bins"`
 			ProblematicBins   int      `json:"problematic_bins" db:"problematic_bins"`
+			WarningFillBins   int      `json:"warning_fill_bins" db:"warning_fill_bins"`   // Latest fill at the warning threshold, below critical
+			CriticalFillBins  int      `json:"critical_fill_bins" db:"critical_fill_bins"` // Latest fill at or above the critical threshold
 			AvgFillPercentage *float64 `json:"avg_fill_percentage" db:"avg_fill_percentage"`
 			TotalChecks       int      `json:"total_checks" db:"total_checks"`
 			TotalIncidents    int      `json:"total_incidents" db:"total_incidents"`
+			MaintenanceCost   float64  `json:"maintenance_cost" db:"maintenance_cost"`
 			SuccessRate       float64  `json:"success_rate" db:"success_rate"`
 			AvgDaysActive     *float64 `json:"avg_days_active" db:"avg_days_active"`
 			AreaScore         float64  `json:"area_score" db:"area_score"`
 		}
 
-		var groupColumn string
-		var selectCity string
-		if groupBy == "city" {
-			groupColumn = "b.city"
-			selectCity = "NULL AS city"
-		} else {
-			groupColumn = "b.zip"
-			selectCity = "b.city"
+		// groupValue/selectArea/selectCity/selectTag are output columns, groupColumns is the GROUP BY list,
+		// sameGroup(alias) matches a correlated bins row (alias) to the current group
+		// A bin with several tags counts towards each of them
+		var groupValue, selectArea, selectCity, groupColumns, joinGroup string
+		selectTag := "NULL::TEXT AS tag_id"
+		var sameGroup func(alias string) string
+		switch groupBy {
+		case "tag":
+			groupValue = "t.name"
+			selectArea = "NULL::TEXT AS area_id"
+			selectCity = "NULL::TEXT AS city"
+			selectTag = "t.id AS tag_id"
+			groupColumns = "t.id, t.name"
+			joinGroup = "JOIN bin_tags bt ON bt.bin_id = b.id JOIN tags t ON t.id = bt.tag_id"
+			sameGroup = func(alias string) string {
+				return "EXISTS (SELECT 1 FROM bin_tags bt2 WHERE bt2.bin_id = " + alias + ".id AND bt2.tag_id = t.id)"
+			}
+		case "city":
+			groupValue = "b.city"
+			selectArea = "NULL::TEXT AS area_id"
+			selectCity = "NULL::TEXT AS city"
+			groupColumns = "b.city"
+			sameGroup = func(alias string) string { return alias + ".city = b.city" }
+		case "zip":
+			groupValue = "b.zip"
+			selectArea = "NULL::TEXT AS area_id"
+			selectCity = "MIN(b.city) AS city"
+			groupColumns = "b.zip"
+			sameGroup = func(alias string) string { return alias + ".zip = b.zip" }
+		default:
+			groupBy = "area"
+			groupValue = "COALESCE(a.name, 'Unassigned')"
+			selectArea = "b.area_id"
+			selectCity = "NULL::TEXT AS city"
+			groupColumns = "b.area_id, a.name"
+			joinGroup = "LEFT JOIN areas a ON a.id = b.area_id"
+			sameGroup = func(alias string) string { return alias + ".area_id IS NOT DISTINCT FROM b.area_id" }
 		}
 
 		var orderBy string
@@ -221,6 +258,8 @@ func GetAreaPerformance(db *sqlx.DB) http.HandlerFunc {
 			SELECT
 				%s AS group_value,
 				%s,
+				%s,
+				%s,
 				COUNT(DISTINCT b.id) AS total_bins,
 				COUNT(DISTINCT CASE WHEN b.status = 'active' THEN b.id END) AS active_bins,
 				COUNT(DISTINCT CASE
@@ -231,58 +270,70 @@ func GetAreaPerformance(db *sqlx.DB) http.HandlerFunc {
 					WHEN EXISTS (SELECT 1 FROM zone_incidents zi WHERE zi.bin_id = b.id)
 					THEN b.id
 				END) AS problematic_bins,
+				COUNT(DISTINCT CASE WHEN b.fill_percentage >= $2 AND b.fill_percentage < $3 THEN b.id END) AS warning_fill_bins,
+				COUNT(DISTINCT CASE WHEN b.fill_percentage >= $3 THEN b.id END) AS critical_fill_bins,
 				(SELECT AVG(c.fill_percentage)
 				 FROM checks c
 				 JOIN bins b2 ON c.bin_id = b2.id
-				 WHERE %s = %s) AS avg_fill_percentage,
+				 WHERE %s) AS avg_fill_percentage,
 				(SELECT COUNT(*)
 				 FROM checks c
 				 JOIN bins b2 ON c.bin_id = b2.id
-				 WHERE %s = %s) AS total_checks,
+				 WHERE %s) AS total_checks,
 				(SELECT COUNT(*)
 				 FROM zone_incidents zi
 				 JOIN bins b2 ON zi.bin_id = b2.id
-				 WHERE %s = %s) AS total_incidents,
+				 WHERE %s) AS total_incidents,
+				(SELECT COALESCE(SUM(bm.cost), 0)::DOUBLE PRECISION
+				 FROM bin_maintenance bm
+				 JOIN bins b2 ON bm.bin_id = b2.id
+				 WHERE bm.status = 'completed' AND %s) AS maintenance_cost,
 				ROUND(
 					(COUNT(DISTINCT CASE
 						WHEN NOT EXISTS (SELECT 1 FROM zone_incidents zi WHERE zi.bin_id = b.id)
 						THEN b.id
-					END)::float / NULLIF(COUNT(DISTINCT b.id)::float, 0)) * 100,
+					END)::float / NULLIF(COUNT(DISTINCT b.id)::float, 0))::numeric * 100,
 					2
 				) AS success_rate,
 				AVG((EXTRACT(EPOCH FROM NOW())::BIGINT - b.created_at) / 86400) AS avg_days_active,
 				ROUND(
-					(COUNT(DISTINCT CASE
+					((COUNT(DISTINCT CASE
 						WHEN NOT EXISTS (SELECT 1 FROM zone_incidents zi WHERE zi.bin_id = b.id)
 						THEN b.id
 					END)::float / NULLIF(COUNT(DISTINCT b.id)::float, 0)) * 100 *
-					GREATEST(1, (SELECT COUNT(*) FROM checks c JOIN bins b3 ON c.bin_id = b3.id WHERE %s = %s)::float / NULLIF(COUNT(DISTINCT b.id)::float * 4, 0)),
+					GREATEST(1, (SELECT COUNT(*) FROM checks c JOIN bins b3 ON c.bin_id = b3.id WHERE %s)::float / NULLIF(COUNT(DISTINCT b.id)::float * 4, 0)))::numeric,
 					2
 				) AS area_score
 			FROM bins b
+			%s
 			WHERE b.status = 'active'
-			GROUP BY %s, %s
+			GROUP BY %s
 			ORDER BY %s
 			LIMIT $1
-		`, groupColumn, selectCity,
-			groupColumn, groupColumn,
-			groupColumn, groupColumn,
-			groupColumn, groupColumn,
-			groupColumn, groupColumn,
-			groupColumn, selectCity, orderBy)
+		`, groupValue, selectArea, selectCity, selectTag,
+			sameGroup("b2"),
+			sameGroup("b2"),
+			sameGroup("b2"),
+			sameGroup("b2"),
+			sameGroup("b3"),
+			joinGroup,
+			groupColumns, orderBy)
+
+		thresholds := loadFillThresholds(db)
 
 		var results []AreaPerformance
-		err := db.Select(&results, query, limit)
+		err := db.SelectContext(r.Context(), &results, query, limit, thresholds.Warning, thresholds.Critical)
 		if err != nil {
-			http.Error(w, "Failed to fetch area performance", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch area performance")
 			return
 		}
 
 		response := map[string]interface{}{
-			"group_by": groupBy,
-			"metric":   metric,
-			"limit":    limit,
-			"areas":    results,
+			"group_by":        groupBy,
+			"metric":          metric,
+			"limit":           limit,
+			"areas":           results,
+			"fill_thresholds": thresholds,
 		}
 
 		w.Header().Set("Content-Type", "application/json")
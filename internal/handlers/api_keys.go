@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// apiKeyPrefixLength is how much of a key is kept in clear to tell keys apart
+const apiKeyPrefixLength = 12
+
+// newAPIKey generates a random API key
+func newAPIKey() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "rpk_" + hex.EncodeToString(buf), nil
+}
+
+// GetAPIKeys returns all API keys, including revoked ones (keys themselves are never returned after creation)
+// GET /api/manager/api-keys
+func GetAPIKeys(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		keys := []models.APIKey{}
+		if err := db.SelectContext(r.Context(), &keys, `SELECT * FROM api_keys ORDER BY created_at ASC`); err != nil {
+			log.Printf("❌ [API KEYS] Failed to fetch API keys: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch API keys")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    keys,
+		})
+	}
+}
+
+// CreateAPIKey issues a key for a machine client; the response includes the key (shown only once)
+// POST /api/manager/api-keys
+// Body: { "name": "Sensor gateway", "scopes": ["sensor_ingest"], "rate_limit_per_minute": 60 }
+func CreateAPIKey(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var req models.CreateAPIKeyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		req.Name = strings.TrimSpace(req.Name)
+		if req.Name == "" || len(req.Scopes) == 0 {
+			utils.RespondError(w, http.StatusBadRequest, "name and scopes are required")
+			return
+		}
+		for _, scope := range req.Scopes {
+			if !models.IsValidAPIKeyScope(scope) {
+				utils.RespondError(w, http.StatusBadRequest, fmt.Sprintf("Invalid scope: %s", scope))
+				return
+			}
+		}
+		if err := models.ValidateAPIKeyRateLimit(req.RateLimit); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		key, err := newAPIKey()
+		if err != nil {
+			log.Printf("❌ [API KEYS] Failed to generate key: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to create API key")
+			return
+		}
+
+		apiKey := models.APIKey{
+			ID:              uuid.New().String(),
+			Name:            req.Name,
+			KeyPrefix:       key[:apiKeyPrefixLength],
+			KeyHash:         middleware.HashAPIKey(key),
+			Scopes:          pq.StringArray(req.Scopes),
+			RateLimit:       req.RateLimit,
+			CreatedByUserID: &userClaims.UserID,
+			CreatedAt:       time.Now().Unix(),
+		}
+		_, err = db.NamedExecContext(r.Context(), `
+			INSERT INTO api_keys (id, name, key_prefix, key_hash, scopes, rate_limit_per_minute, created_by_user_id, created_at)
+			VALUES (:id, :name, :key_prefix, :key_hash, :scopes, :rate_limit_per_minute, :created_by_user_id, :created_at)
+		`, apiKey)
+		if err != nil {
+			log.Printf("❌ [API KEYS] Failed to create API key: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to create API key")
+			return
+		}
+
+		log.Printf("✅ [API KEYS] %s created API key %s (%s, scopes: %v)", userClaims.Email, apiKey.KeyPrefix, apiKey.Name, req.Scopes)
+
+		utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{
+			"success": true,
+			"data": map[string]interface{}{
+				"api_key": apiKey,
+				"key":     key,
+			},
+		})
+	}
+}
+
+// UpdateAPIKey renames a key or changes its rate limit
+// PATCH /api/manager/api-keys/{id}
+// Body: { "name": "Marketing site", "rate_limit_per_minute": 120 }
+func UpdateAPIKey(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		keyID := chi.URLParam(r, "id")
+
+		var req models.UpdateAPIKeyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if req.Name != nil {
+			name := strings.TrimSpace(*req.Name)
+			if name == "" {
+				utils.RespondError(w, http.StatusBadRequest, "name can't be empty")
+				return
+			}
+			req.Name = &name
+		}
+		if err := models.ValidateAPIKeyRateLimit(req.RateLimit); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var apiKey models.APIKey
+		err := db.GetContext(r.Context(), &apiKey, `
+			UPDATE api_keys
+			SET name = COALESCE($1, name),
+			    rate_limit_per_minute = COALESCE($2, rate_limit_per_minute)
+			WHERE id = $3
+			RETURNING *
+		`, req.Name, req.RateLimit, keyID)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "API key not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [API KEYS] Failed to update API key: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to update API key")
+			return
+		}
+
+		log.Printf("✅ [API KEYS] Updated API key %s (%s, %d requests/min)", apiKey.KeyPrefix, apiKey.Name, apiKey.RequestsPerMinute())
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    apiKey,
+		})
+	}
+}
+
+// RevokeAPIKey stops a key from authenticating (the row is kept for the audit trail)
+// DELETE /api/manager/api-keys/{id}
+func RevokeAPIKey(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		keyID := chi.URLParam(r, "id")
+
+		result, err := db.ExecContext(r.Context(), `
+			UPDATE api_keys SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL
+		`, time.Now().Unix(), keyID)
+		if err != nil {
+			log.Printf("❌ [API KEYS] Failed to revoke API key: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to revoke API key")
+			return
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			utils.RespondError(w, http.StatusNotFound, "API key not found or already revoked")
+			return
+		}
+
+		log.Printf("✅ [API KEYS] Revoked API key %s", keyID)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+		})
+	}
+}
+
+// apiKeyUsageMaxDays caps the usage report period
+const apiKeyUsageMaxDays = 90
+
+// GetAPIKeyUsage reports each API key's metered traffic: totals, requests refused by the rate limit,
+// the busiest minute, a breakdown by endpoint and an hourly or daily series (UTC buckets)
+// GET /api/manager/api-keys/usage?days=7&interval=hour|day (interval defaults to hour up to 2 days, else day)
+func GetAPIKeyUsage(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		days := 7
+		if v := q.Get("days"); v != "" {
+			parsed, err := strconv.Atoi(v)
+			if err != nil || parsed < 1 || parsed > apiKeyUsageMaxDays {
+				utils.RespondError(w, http.StatusBadRequest, fmt.Sprintf("days must be between 1 and %d", apiKeyUsageMaxDays))
+				return
+			}
+			days = parsed
+		}
+		interval := q.Get("interval")
+		if interval == "" {
+			interval = "day"
+			if days <= 2 {
+				interval = "hour"
+			}
+		}
+		bucketSeconds := int64(86400)
+		switch interval {
+		case "day":
+		case "hour":
+			bucketSeconds = 3600
+		default:
+			utils.RespondError(w, http.StatusBadRequest, "interval must be hour or day")
+			return
+		}
+
+		now := time.Now().Unix()
+		from := now - int64(days)*86400
+
+		keys := []models.APIKey{}
+		if err := db.SelectContext(r.Context(), &keys, `SELECT * FROM api_keys ORDER BY created_at ASC`); err != nil {
+			log.Printf("❌ [API KEYS] Failed to fetch API keys: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch API key usage")
+			return
+		}
+
+		var series []struct {
+			APIKeyID string `db:"api_key_id"`
+			models.APIKeyUsagePoint
+		}
+		err := db.SelectContext(r.Context(), &series, `
+			SELECT api_key_id, window_start - window_start % $2 AS start,
+			       SUM(requests) AS requests, SUM(rejected) AS rejected
+			FROM api_key_usage
+			WHERE window_start >= $1
+			GROUP BY 1, 2
+			ORDER BY 2 ASC
+		`, from, bucketSeconds)
+		if err != nil {
+			log.Printf("❌ [API KEYS] Failed to fetch usage series: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch API key usage")
+			return
+		}
+
+		var endpoints []struct {
+			APIKeyID string `db:"api_key_id"`
+			models.APIKeyEndpointUsage
+		}
+		err = db.SelectContext(r.Context(), &endpoints, `
+			SELECT api_key_id, endpoint, SUM(requests) AS requests, SUM(rejected) AS rejected
+			FROM api_key_usage
+			WHERE window_start >= $1
+			GROUP BY 1, 2
+			ORDER BY 3 DESC
+		`, from)
+		if err != nil {
+			log.Printf("❌ [API KEYS] Failed to fetch usage by endpoint: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch API key usage")
+			return
+		}
+
+		var peaks []struct {
+			APIKeyID string `db:"api_key_id"`
+			Peak     int    `db:"peak"`
+		}
+		err = db.SelectContext(r.Context(), &peaks, `
+			SELECT api_key_id, MAX(total) AS peak
+			FROM (
+				SELECT api_key_id, window_start, SUM(requests) AS total
+				FROM api_key_usage
+				WHERE window_start >= $1
+				GROUP BY 1, 2
+			) minutes
+			GROUP BY 1
+		`, from)
+		if err != nil {
+			log.Printf("❌ [API KEYS] Failed to fetch peak usage: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch API key usage")
+			return
+		}
+
+		usage := make(map[string]*models.APIKeyUsage, len(keys))
+		report := models.APIKeyUsageReport{From: from, To: now, Interval: interval, Keys: make([]models.APIKeyUsage, len(keys))}
+		for i, key := range keys {
+			report.Keys[i] = models.APIKeyUsage{
+				APIKeyID:   key.ID,
+				Name:       key.Name,
+				KeyPrefix:  key.KeyPrefix,
+				Scopes:     key.Scopes,
+				RateLimit:  key.RequestsPerMinute(),
+				Revoked:    key.RevokedAt != nil,
+				LastUsedAt: key.LastUsedAt,
+				Endpoints:  []models.APIKeyEndpointUsage{},
+				Series:     []models.APIKeyUsagePoint{},
+			}
+			usage[key.ID] = &report.Keys[i]
+		}
+		for _, point := range series {
+			if key, ok := usage[point.APIKeyID]; ok {
+				key.Series = append(key.Series, point.APIKeyUsagePoint)
+				key.Requests += point.Requests
+				key.Rejected += point.Rejected
+			}
+		}
+		for _, endpoint := range endpoints {
+			if key, ok := usage[endpoint.APIKeyID]; ok {
+				key.Endpoints = append(key.Endpoints, endpoint.APIKeyEndpointUsage)
+			}
+		}
+		for _, peak := range peaks {
+			if key, ok := usage[peak.APIKeyID]; ok {
+				key.PeakMinute = peak.Peak
+			}
+		}
+		sort.SliceStable(report.Keys, func(i, j int) bool {
+			return report.Keys[i].Requests+report.Keys[i].Rejected > report.Keys[j].Requests+report.Keys[j].Rejected
+		})
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    report,
+		})
+	}
+}
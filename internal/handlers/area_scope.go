@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/middleware"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// areaScopeDriverDays is how long a driver stays in an area's scope after their last completed stop there
+const areaScopeDriverDays = 30
+
+// managerAreaScope returns the areas a manager-facing list is limited to: the requesting manager's assigned
+// areas, or nil (every area) when the manager has none, the request sets all_areas=true, or it isn't a manager's
+// Queries apply it as ($n::TEXT[] IS NULL OR area_id = ANY($n)) with pq.Array
+func managerAreaScope(r *http.Request, db sqlx.Queryer) ([]string, error) {
+	userClaims, ok := middleware.GetUserFromContext(r)
+	if !ok || userClaims.Role != "admin" || r.URL.Query().Get("all_areas") == "true" {
+		return nil, nil
+	}
+	areaIDs, err := database.GetManagerAreaIDs(db, userClaims.UserID)
+	if err != nil || len(areaIDs) == 0 {
+		return nil, err
+	}
+	return areaIDs, nil
+}
+
+// driverInAreaScopeSQL is the condition for a driver (users u) being in an area scope bound to $n (see
+// managerAreaScope): their open shift has a stop in one of the areas, or they completed one there within
+// areaScopeDriverDays (since bound to $m)
+const driverInAreaScopeSQL = `EXISTS (
+	SELECT 1 FROM route_tasks scope_t
+	JOIN bins scope_b ON scope_b.id = scope_t.bin_id
+	WHERE scope_b.area_id = ANY($%[1]d)
+	  AND (scope_t.shift_id IN (SELECT id FROM shifts WHERE driver_id = u.id AND status IN ('ready', 'active', 'paused'))
+	       OR (scope_t.is_completed = 1 AND scope_t.completed_at >= $%[2]d
+	           AND scope_t.shift_id IN (SELECT id FROM shift_history WHERE driver_id = u.id))))`
@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/services"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// areaRequest is the body for creating or updating an area
+type areaRequest struct {
+	Name     *string         `json:"name"`
+	Boundary json.RawMessage `json:"boundary"` // GeoJSON Polygon, MultiPolygon or Feature
+}
+
+// reassignAreasAsync re-runs area assignment after boundaries change
+func reassignAreasAsync(assigner *services.AreaAssigner) {
+	go func() {
+		if _, err := assigner.Run(); err != nil {
+			log.Printf("❌ [AREAS] Reassignment failed: %v", err)
+		}
+	}()
+}
+
+// GetAreas returns all areas with their assigned bin and zone counts
+// GET /api/manager/areas
+func GetAreas(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		areas := []models.AreaWithCounts{}
+		err := db.SelectContext(r.Context(), &areas, `
+			SELECT a.*,
+			       (SELECT COUNT(*) FROM bins b WHERE b.area_id = a.id) AS bin_count,
+			       (SELECT COUNT(*) FROM no_go_zones z WHERE z.area_id = a.id) AS zone_count
+			FROM areas a
+			ORDER BY a.name ASC
+		`)
+		if err != nil {
+			log.Printf("❌ [AREAS] Failed to fetch areas: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch areas")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    areas,
+		})
+	}
+}
+
+// CreateArea creates an area from a GeoJSON boundary
+// POST /api/manager/areas
+// Body: { "name": "San Jose", "boundary": { "type": "Polygon", "coordinates": [[[lng, lat], ...]] } }
+func CreateArea(db *sqlx.DB, assigner *services.AreaAssigner) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var req areaRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if req.Name == nil || strings.TrimSpace(*req.Name) == "" {
+			utils.RespondError(w, http.StatusBadRequest, "name is required")
+			return
+		}
+		if len(req.Boundary) == 0 {
+			utils.RespondError(w, http.StatusBadRequest, "boundary is required")
+			return
+		}
+
+		boundary, err := services.ParseAreaBoundary(req.Boundary)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid boundary: "+err.Error())
+			return
+		}
+		minLat, maxLat, minLng, maxLng := boundary.BoundingBox()
+
+		now := time.Now().Unix()
+		area := models.Area{
+			ID:              uuid.New().String(),
+			Name:            strings.TrimSpace(*req.Name),
+			Boundary:        req.Boundary,
+			MinLatitude:     minLat,
+			MaxLatitude:     maxLat,
+			MinLongitude:    minLng,
+			MaxLongitude:    maxLng,
+			CreatedByUserID: &userClaims.UserID,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		}
+
+		_, err = db.ExecContext(r.Context(), `
+			INSERT INTO areas (id, name, boundary, min_latitude, max_latitude, min_longitude, max_longitude,
+			                   created_by_user_id, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		`, area.ID, area.Name, string(area.Boundary), area.MinLatitude, area.MaxLatitude,
+			area.MinLongitude, area.MaxLongitude, area.CreatedByUserID, area.CreatedAt, area.UpdatedAt)
+		if err != nil {
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+				utils.RespondError(w, http.StatusConflict, "An area with this name already exists")
+				return
+			}
+			log.Printf("❌ [AREAS] Failed to create area: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to create area")
+			return
+		}
+
+		log.Printf("✅ [AREAS] %s created area %s (%s)", userClaims.Email, area.Name, area.ID)
+		reassignAreasAsync(assigner)
+
+		utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{
+			"success": true,
+			"data":    area,
+		})
+	}
+}
+
+// UpdateArea renames an area and/or replaces its boundary
+// PUT /api/manager/areas/{id}
+// Body: { "name": "...", "boundary": { ... } } (both optional)
+func UpdateArea(db *sqlx.DB, assigner *services.AreaAssigner) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		areaID := chi.URLParam(r, "id")
+
+		var req areaRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		var area models.Area
+		err := db.GetContext(r.Context(), &area, `SELECT * FROM areas WHERE id = $1`, areaID)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "Area not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [AREAS] Failed to fetch area: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch area")
+			return
+		}
+
+		if req.Name != nil {
+			if strings.TrimSpace(*req.Name) == "" {
+				utils.RespondError(w, http.StatusBadRequest, "name cannot be empty")
+				return
+			}
+			area.Name = strings.TrimSpace(*req.Name)
+		}
+
+		boundaryChanged := len(req.Boundary) > 0
+		if boundaryChanged {
+			boundary, err := services.ParseAreaBoundary(req.Boundary)
+			if err != nil {
+				utils.RespondError(w, http.StatusBadRequest, "Invalid boundary: "+err.Error())
+				return
+			}
+			area.Boundary = req.Boundary
+			area.MinLatitude, area.MaxLatitude, area.MinLongitude, area.MaxLongitude = boundary.BoundingBox()
+		}
+
+		area.UpdatedAt = time.Now().Unix()
+		_, err = db.ExecContext(r.Context(), `
+			UPDATE areas
+			SET name = $1, boundary = $2, min_latitude = $3, max_latitude = $4,
+			    min_longitude = $5, max_longitude = $6, updated_at = $7
+			WHERE id = $8
+		`, area.Name, string(area.Boundary), area.MinLatitude, area.MaxLatitude,
+			area.MinLongitude, area.MaxLongitude, area.UpdatedAt, area.ID)
+		if err != nil {
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+				utils.RespondError(w, http.StatusConflict, "An area with this name already exists")
+				return
+			}
+			log.Printf("❌ [AREAS] Failed to update area: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to update area")
+			return
+		}
+
+		log.Printf("✅ [AREAS] Updated area %s (%s, boundary changed: %v)", area.Name, area.ID, boundaryChanged)
+		if boundaryChanged {
+			reassignAreasAsync(assigner)
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    area,
+		})
+	}
+}
+
+// DeleteArea deletes an area; its bins and zones become unassigned
+// DELETE /api/manager/areas/{id}
+func DeleteArea(db *sqlx.DB, assigner *services.AreaAssigner) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		areaID := chi.URLParam(r, "id")
+
+		result, err := db.ExecContext(r.Context(), `DELETE FROM areas WHERE id = $1`, areaID)
+		if err != nil {
+			log.Printf("❌ [AREAS] Failed to delete area: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to delete area")
+			return
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			utils.RespondError(w, http.StatusNotFound, "Area not found")
+			return
+		}
+
+		log.Printf("✅ [AREAS] Deleted area %s", areaID)
+		// Bins in overlapping areas may now fall into another area
+		reassignAreasAsync(assigner)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+		})
+	}
+}
+
+// RunAreaAssignment assigns every bin and no-go zone to its area immediately
+// POST /api/manager/areas/assign
+func RunAreaAssignment(assigner *services.AreaAssigner) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		result, err := assigner.Run()
+		if err != nil {
+			log.Printf("❌ [AREAS] Assignment failed: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to assign areas")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    result,
+		})
+	}
+}
@@ -3,11 +3,18 @@ package handlers
 import (
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/helpers"
+	"ropacal-backend/internal/i18n"
 	"ropacal-backend/internal/middleware"
 	"ropacal-backend/internal/models"
 	"ropacal-backend/pkg/utils"
@@ -18,21 +25,103 @@ import (
 )
 
 type LoginRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" validate:"required"`
+	Password string `json:"password" validate:"required"`
 }
 
 type LoginResponse struct {
-	OK    bool                 `json:"ok"`
-	Token string               `json:"token,omitempty"`
-	User  *models.UserResponse `json:"user,omitempty"`
+	OK                bool                 `json:"ok"`
+	Token             string               `json:"token,omitempty"`
+	User              *models.UserResponse `json:"user,omitempty"`
+	Error             string               `json:"error,omitempty"`
+	RetryAfterSeconds int64                `json:"retry_after_seconds,omitempty"`
+}
+
+// clientIP returns the caller's IP (RemoteAddr is already rewritten by middleware.RealIP for trusted proxies)
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// loginThrottleScopes returns the throttle keys a login attempt counts against: always the account, and the
+// caller's IP only when it is the client's own address (not an unlisted proxy that every client shares)
+func loginThrottleScopes(r *http.Request, email string) []struct{ scope, key string } {
+	scopes := []struct{ scope, key string }{
+		{models.LoginThrottleScopeAccount, normalizeLoginEmail(email)},
+	}
+	if middleware.ClientIPKnown(r) {
+		scopes = append(scopes, struct{ scope, key string }{models.LoginThrottleScopeIP, clientIP(r)})
+	}
+	return scopes
+}
+
+// normalizeLoginEmail is the account key used for login throttling
+func normalizeLoginEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// respondLoginBlocked rejects a login attempt during backoff or lockout
+func respondLoginBlocked(w http.ResponseWriter, r *http.Request, throttle *models.LoginThrottle, now int64) {
+	retryAfter := throttle.LockedUntil - now
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+
+	message := i18n.Tr(r, "Too many failed login attempts. Try again later.")
+	if throttle.IsLockedOut && throttle.Scope == models.LoginThrottleScopeAccount {
+		message = i18n.Tr(r, "Account temporarily locked due to too many failed login attempts.")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", strconv.FormatInt(retryAfter, 10))
+	w.WriteHeader(http.StatusTooManyRequests)
+	json.NewEncoder(w).Encode(LoginResponse{OK: false, Error: message, RetryAfterSeconds: retryAfter})
+}
+
+// recordLoginFailure counts a failed login against the account and IP and logs security events
+func recordLoginFailure(db *sqlx.DB, r *http.Request, email string, userID *string, reason string, now int64) {
+	ip := clientIP(r)
+	userAgent := r.UserAgent()
+	event := models.SecurityEvent{
+		UserID:    userID,
+		Email:     &email,
+		IPAddress: &ip,
+		UserAgent: &userAgent,
+		CreatedAt: now,
+	}
+
+	failed := event
+	failed.EventType = models.SecurityEventLoginFailed
+	failed.Details = &reason
+	helpers.LogSecurityEvent(db, failed)
+
+	for _, s := range loginThrottleScopes(r, email) {
+		throttle, err := database.RecordLoginFailure(db, s.scope, s.key, now)
+		if err != nil {
+			log.Printf("⚠️  [LOGIN] %v", err)
+			continue
+		}
+		// Log the lockout once, when the threshold is crossed
+		if throttle.IsLockedOut && throttle.FailedCount == database.LoginPolicyForScope(s.scope).LockoutThreshold {
+			details := fmt.Sprintf("%s %s locked until %s after %d failed attempts",
+				s.scope, s.key, time.Unix(throttle.LockedUntil, 0).Format(time.RFC3339), throttle.FailedCount)
+			log.Printf("🔒 [LOGIN] %s", details)
+
+			locked := event
+			locked.EventType = models.SecurityEventAccountLocked
+			locked.Details = &details
+			helpers.LogSecurityEvent(db, locked)
+		}
+	}
 }
 
 func Login(db *sqlx.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req LoginRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
 			return
 		}
 
@@ -48,26 +137,89 @@ func Login(db *sqlx.DB) http.HandlerFunc {
 			return
 		}
 
+		now := time.Now().Unix()
+		ip := clientIP(r)
+
+		// Reject attempts while the account or IP is in backoff/lockout
+		for _, scope := range loginThrottleScopes(r, req.Email) {
+			throttle, err := database.GetActiveLoginThrottle(db, scope.scope, scope.key, now)
+			if err != nil {
+				log.Printf("⚠️  [LOGIN] %v", err)
+				continue
+			}
+			if throttle != nil {
+				log.Printf("⛔ [LOGIN] Blocked attempt for %s from %s (%s throttled until %d)", req.Email, ip, scope.scope, throttle.LockedUntil)
+				details := fmt.Sprintf("%s throttled (%d failed attempts)", scope.scope, throttle.FailedCount)
+				userAgent := r.UserAgent()
+				helpers.LogSecurityEvent(db, models.SecurityEvent{
+					EventType: models.SecurityEventLoginBlocked,
+					Email:     &req.Email,
+					IPAddress: &ip,
+					UserAgent: &userAgent,
+					Details:   &details,
+					CreatedAt: now,
+				})
+				respondLoginBlocked(w, r, throttle, now)
+				return
+			}
+		}
+
 		// Find user by email
 		var user models.User
 		query := "SELECT * FROM users WHERE email = $1"
-		if err := db.Get(&user, query, req.Email); err != nil {
+		if err := db.GetContext(r.Context(), &user, query, req.Email); err != nil {
 			log.Printf("❌ User not found: %s", req.Email)
+			recordLoginFailure(db, r, req.Email, nil, "unknown email", now)
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusUnauthorized)
-			json.NewEncoder(w).Encode(LoginResponse{OK: false})
+			json.NewEncoder(w).Encode(LoginResponse{OK: false, Error: i18n.Tr(r, "Invalid email or password")})
 			return
 		}
 
 		// Verify password
 		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
 			log.Printf("❌ Invalid password for: %s", req.Email)
+			recordLoginFailure(db, r, req.Email, &user.ID, "invalid password", now)
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusUnauthorized)
-			json.NewEncoder(w).Encode(LoginResponse{OK: false})
+			json.NewEncoder(w).Encode(LoginResponse{OK: false, Error: i18n.Tr(r, "Invalid email or password")})
+			return
+		}
+
+		// Deactivated users keep their credentials but can't sign in
+		if user.IsDeactivated() {
+			log.Printf("⛔ [LOGIN] Deactivated account: %s", req.Email)
+			details := "account deactivated"
+			userAgent := r.UserAgent()
+			helpers.LogSecurityEvent(db, models.SecurityEvent{
+				EventType: models.SecurityEventLoginBlocked,
+				UserID:    &user.ID,
+				Email:     &user.Email,
+				IPAddress: &ip,
+				UserAgent: &userAgent,
+				Details:   &details,
+				CreatedAt: now,
+			})
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(LoginResponse{OK: false, Error: i18n.Tr(r, "This account has been deactivated. Contact your manager.")})
 			return
 		}
 
+		// Successful login clears the account's failure history (IP history is kept)
+		if _, err := database.ClearLoginThrottle(db, models.LoginThrottleScopeAccount, normalizeLoginEmail(user.Email)); err != nil {
+			log.Printf("⚠️  [LOGIN] %v", err)
+		}
+		userAgent := r.UserAgent()
+		helpers.LogSecurityEvent(db, models.SecurityEvent{
+			EventType: models.SecurityEventLoginSucceeded,
+			UserID:    &user.ID,
+			Email:     &user.Email,
+			IPAddress: &ip,
+			UserAgent: &userAgent,
+			CreatedAt: now,
+		})
+
 		// Create JWT token with user info
 		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 			"user_id": user.ID,
@@ -80,7 +232,7 @@ func Login(db *sqlx.DB) http.HandlerFunc {
 		tokenString, err := token.SignedString([]byte(jwtSecret))
 		if err != nil {
 			log.Println("❌ Failed to create token")
-			http.Error(w, "Failed to create token", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to create token")
 			return
 		}
 
@@ -114,7 +266,7 @@ func GetAuthStatus(db *sqlx.DB) http.HandlerFunc {
 		// Query full user data from database
 		var user models.User
 		query := "SELECT * FROM users WHERE id = $1"
-		if err := db.Get(&user, query, userClaims.UserID); err != nil {
+		if err := db.GetContext(r.Context(), &user, query, userClaims.UserID); err != nil {
 			if err == sql.ErrNoRows {
 				log.Printf("❌ User not found: %s", userClaims.UserID)
 				utils.RespondError(w, http.StatusNotFound, "User not found")
@@ -127,10 +279,75 @@ func GetAuthStatus(db *sqlx.DB) http.HandlerFunc {
 
 		log.Printf("✅ Auth status retrieved for: %s (%s)", user.Email, user.Role)
 
-		// Return user response (without password)
+		// Return user response (without password); support_mode tells clients to show the support banner
+		response := map[string]interface{}{
+			"success":      true,
+			"user":         user.ToUserResponse(),
+			"support_mode": userClaims.IsImpersonated(),
+		}
+		if userClaims.IsImpersonated() {
+			response["support_mode_by"] = userClaims.ImpersonatorEmail
+		}
+		utils.RespondJSON(w, http.StatusOK, response)
+	}
+}
+
+// updateLocaleRequest is the body for PUT /api/auth/locale
+type updateLocaleRequest struct {
+	Locale *string `json:"locale"` // null clears the preference
+}
+
+// UpdateMyLocale sets the current user's language for API messages and notifications
+// PUT /api/auth/locale
+// Body: { "locale": "es" } - null clears the preference (the device's Accept-Language is used)
+func UpdateMyLocale(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, i18n.Tr(r, "Unauthorized"))
+			return
+		}
+
+		var req updateLocaleRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, i18n.Tr(r, "Invalid request body"))
+			return
+		}
+
+		var locale *string
+		if req.Locale != nil {
+			normalized := i18n.Normalize(*req.Locale)
+			if normalized == "" {
+				utils.RespondError(w, http.StatusBadRequest, i18n.Tr(r, "Unsupported locale"))
+				return
+			}
+			locale = &normalized
+		}
+
+		result, err := db.ExecContext(r.Context(), `
+			UPDATE users SET locale = $1, updated_at = $2 WHERE id = $3
+		`, locale, time.Now().Unix(), userClaims.UserID)
+		if err != nil {
+			log.Printf("❌ [LOCALE] Failed to update locale for %s: %v", userClaims.Email, err)
+			utils.RespondError(w, http.StatusInternalServerError, i18n.Tr(r, "Failed to save locale"))
+			return
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			utils.RespondError(w, http.StatusNotFound, i18n.Tr(r, "User not found"))
+			return
+		}
+		middleware.InvalidateUserLocale(userClaims.UserID)
+
+		effective := i18n.Resolve(locale, r.Header.Get("Accept-Language"))
+		log.Printf("✅ [LOCALE] %s set locale to %v (effective: %s)", userClaims.Email, req.Locale, effective)
+
 		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
 			"success": true,
-			"user":    user.ToUserResponse(),
+			"data": map[string]interface{}{
+				"locale":            locale,
+				"effective_locale":  effective,
+				"supported_locales": i18n.SupportedLocales(),
+			},
 		})
 	}
 }
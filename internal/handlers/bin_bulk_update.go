@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/store"
+	"ropacal-backend/internal/websocket"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// BulkUpdateBins applies the same field updates to many bins
+// PATCH /api/manager/bins/bulk
+// Body: { "bin_ids": ["..."], "updates": { "status": "missing", "city": "...", "move_requested": false, "photo_required": true }, "all_or_nothing": false }
+// Each bin is validated on its own (it exists, the status change follows the lifecycle); the valid ones are updated
+// in one transaction and the others reported as failed. With all_or_nothing a single failure applies nothing (422)
+// Managers get one bins_bulk_updated event for the whole batch
+func BulkUpdateBins(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var req models.BulkBinUpdateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		var binIDs []string
+		seen := map[string]bool{}
+		for _, binID := range req.BinIDs {
+			if binID != "" && !seen[binID] {
+				seen[binID] = true
+				binIDs = append(binIDs, binID)
+			}
+		}
+		if len(binIDs) == 0 {
+			utils.RespondError(w, http.StatusBadRequest, "bin_ids is required")
+			return
+		}
+		if len(binIDs) > models.MaxBulkBinUpdate {
+			utils.RespondError(w, http.StatusBadRequest, fmt.Sprintf("At most %d bins can be updated at once", models.MaxBulkBinUpdate))
+			return
+		}
+		if req.Updates.IsEmpty() {
+			utils.RespondError(w, http.StatusBadRequest, "updates must set at least one of status, city, move_requested, photo_required")
+			return
+		}
+		updates := req.Updates
+		if updates.City != nil {
+			city := strings.TrimSpace(*updates.City)
+			if city == "" {
+				utils.RespondError(w, http.StatusBadRequest, "city can't be empty")
+				return
+			}
+			updates.City = &city
+		}
+		if updates.Status != nil {
+			status := models.NormalizeBinStatus(*updates.Status)
+			if !models.IsValidBinStatus(status) {
+				utils.RespondErrorCode(w, http.StatusBadRequest, utils.CodeValidationFailed, "Invalid bin status: "+*updates.Status, map[string]interface{}{
+					"allowed_statuses": models.BinStatuses,
+				})
+				return
+			}
+			if status == models.BinStatusRetired {
+				utils.RespondError(w, http.StatusBadRequest, "Bins can't be retired in bulk; use POST /api/manager/bins/{id}/retire")
+				return
+			}
+			updates.Status = &status
+		}
+
+		tx, err := db.BeginTxx(r.Context(), nil)
+		if err != nil {
+			log.Printf("❌ [BULK-BINS] Failed to start transaction: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to update bins")
+			return
+		}
+		defer tx.Rollback()
+
+		// Lock the bins so a concurrent edit can't slip between validation and the update
+		var existing []models.Bin
+		err = tx.SelectContext(r.Context(), &existing, `SELECT * FROM bins WHERE id = ANY($1) FOR UPDATE`, pq.Array(binIDs))
+		if err != nil {
+			log.Printf("❌ [BULK-BINS] Failed to load bins: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to load bins")
+			return
+		}
+		binsByID := make(map[string]*models.Bin, len(existing))
+		for i := range existing {
+			binsByID[existing[i].ID] = &existing[i]
+		}
+
+		result := models.BulkBinUpdateResult{Applied: true, Requested: len(binIDs), Results: make([]models.BulkBinUpdateItem, len(binIDs))}
+		var changedIDs []string
+		for i, binID := range binIDs {
+			item := &result.Results[i]
+			item.BinID = binID
+			bin, found := binsByID[binID]
+			if !found {
+				item.Result, item.Code, item.Error = models.BulkBinFailed, utils.CodeNotFound, "Bin not found"
+				result.Failed++
+				continue
+			}
+			item.BinNumber = &bin.BinNumber
+
+			if updates.Status != nil {
+				if err := models.ValidateBinStatusTransition(models.NormalizeBinStatus(bin.Status), *updates.Status); err != nil {
+					item.Result, item.Code, item.Error = models.BulkBinFailed, utils.CodeInvalidStatusTransition, err.Error()
+					result.Failed++
+					continue
+				}
+			}
+
+			if !bulkBinChanges(bin, updates) {
+				item.Result = models.BulkBinUnchanged
+				result.Unchanged++
+				continue
+			}
+			item.Result = models.BulkBinUpdated
+			changedIDs = append(changedIDs, binID)
+		}
+
+		if req.AllOrNothing && result.Failed > 0 {
+			result.Applied = false
+			for i := range result.Results {
+				if result.Results[i].Result != models.BulkBinFailed {
+					result.Results[i].Result = models.BulkBinUnchanged
+				}
+			}
+			result.Unchanged = result.Requested - result.Failed
+			utils.RespondErrorCode(w, http.StatusUnprocessableEntity, utils.CodeValidationFailed,
+				fmt.Sprintf("%d of %d bins can't be updated; nothing was changed", result.Failed, result.Requested), result)
+			return
+		}
+
+		now := time.Now().Unix()
+		for _, binID := range changedIDs {
+			if err := applyBulkBinUpdate(tx, binsByID[binID], updates, now); err != nil {
+				log.Printf("❌ [BULK-BINS] %v", err)
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to update bins")
+				return
+			}
+		}
+
+		// Read back every bin that passed validation for the response
+		var updated []models.Bin
+		if len(changedIDs) > 0 {
+			if err := tx.SelectContext(r.Context(), &updated, `SELECT * FROM bins WHERE id = ANY($1)`, pq.Array(changedIDs)); err != nil {
+				log.Printf("❌ [BULK-BINS] Failed to fetch updated bins: %v", err)
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch updated bins")
+				return
+			}
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Printf("❌ [BULK-BINS] Failed to commit: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to update bins")
+			return
+		}
+		store.InvalidateBins(changedIDs...)
+
+		responses := make(map[string]*models.BinResponse, len(binIDs))
+		for _, bin := range updated {
+			response := bin.ToBinResponse()
+			responses[bin.ID] = &response
+		}
+		for _, bin := range existing {
+			if _, ok := responses[bin.ID]; !ok {
+				response := bin.ToBinResponse()
+				responses[bin.ID] = &response
+			}
+		}
+		changedBins := make([]*models.BinResponse, 0, len(changedIDs))
+		for i := range result.Results {
+			item := &result.Results[i]
+			if item.Result == models.BulkBinFailed {
+				continue
+			}
+			item.Bin = responses[item.BinID]
+			if item.Result == models.BulkBinUpdated {
+				result.Updated++
+				changedBins = append(changedBins, item.Bin)
+			}
+		}
+
+		if len(changedBins) > 0 {
+			wsHub.BroadcastToRole("admin", map[string]interface{}{
+				"type": "bins_bulk_updated",
+				"data": map[string]interface{}{
+					"bin_ids":    changedIDs,
+					"updates":    updates,
+					"bins":       changedBins,
+					"updated_by": userClaims.UserID,
+				},
+			})
+		}
+
+		log.Printf("✅ [BULK-BINS] %s updated %d bins (%d unchanged, %d failed)",
+			userClaims.Email, result.Updated, result.Unchanged, result.Failed)
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    result,
+		})
+	}
+}
+
+// bulkBinChanges reports whether the updates change anything on the bin
+func bulkBinChanges(bin *models.Bin, updates models.BulkBinFields) bool {
+	return (updates.Status != nil && *updates.Status != models.NormalizeBinStatus(bin.Status)) ||
+		(updates.City != nil && *updates.City != bin.City) ||
+		(updates.MoveRequested != nil && *updates.MoveRequested != bin.MoveRequested) ||
+		(updates.PhotoRequired != nil && *updates.PhotoRequired != bin.PhotoRequired)
+}
+
+// applyBulkBinUpdate writes the updates to one bin
+func applyBulkBinUpdate(tx *sqlx.Tx, bin *models.Bin, updates models.BulkBinFields, now int64) error {
+	update := database.NewUpdate("bins").Set("updated_at", now)
+	if updates.Status != nil {
+		update.Set("status", *updates.Status)
+	}
+	if updates.City != nil {
+		update.Set("city", *updates.City)
+		if *updates.City != bin.City {
+			// Same as an address edit: the old coordinates no longer apply
+			update.SetNull("latitude").SetNull("longitude")
+		}
+	}
+	if updates.MoveRequested != nil {
+		update.Set("move_requested", *updates.MoveRequested)
+	}
+	if updates.PhotoRequired != nil {
+		update.Set("photo_required", *updates.PhotoRequired)
+	}
+
+	query, args, err := update.Where("id", bin.ID).Build()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return fmt.Errorf("failed to update bin %s: %w", bin.ID, err)
+	}
+	return nil
+}
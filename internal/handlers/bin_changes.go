@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ropacal-backend/internal/models"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// binListVersion fingerprints everything a bin list payload is built from; it changes whenever a bin is
+// added, changed (tags included, see the bin_tags_touch_bin trigger) or deleted, gets a photo, or has
+// maintenance come due
+type binListVersion struct {
+	Count         int   `db:"count"`
+	UpdatedAt     int64 `db:"updated_at"`
+	PhotoAt       int64 `db:"photo_at"`
+	MaintenanceAt int64 `db:"maintenance_at"`
+	DeletedAt     int64 `db:"deleted_at"`
+}
+
+// loadBinListVersion returns the current version of the bin list, with maintenance due by now
+func loadBinListVersion(ctx context.Context, db *sqlx.DB, now int64) (binListVersion, error) {
+	var version binListVersion
+	err := db.GetContext(ctx, &version, `
+		SELECT COUNT(*) AS count, COALESCE(MAX(updated_at), 0) AS updated_at,
+		       (SELECT COALESCE(MAX(taken_at), 0) FROM bin_photos) AS photo_at,
+		       (SELECT COALESCE(MAX(GREATEST(updated_at, CASE WHEN status = 'scheduled' AND scheduled_for <= $1
+		                                                      THEN scheduled_for ELSE 0 END)), 0)
+		        FROM bin_maintenance) AS maintenance_at,
+		       (SELECT COALESCE(MAX(deleted_at), 0) FROM bin_deletions) AS deleted_at
+		FROM bins
+	`, now)
+	if err != nil {
+		return version, fmt.Errorf("failed to load bin list version: %w", err)
+	}
+	return version, nil
+}
+
+// LastModified is when anything in the bin list last changed
+func (v binListVersion) LastModified() int64 {
+	latest := v.UpdatedAt
+	for _, at := range []int64{v.PhotoAt, v.MaintenanceAt, v.DeletedAt} {
+		if at > latest {
+			latest = at
+		}
+	}
+	return latest
+}
+
+// ETag identifies one rendering of the bin list: the version, plus the request's path and query (filters,
+// pagination, fields), the manager's area scope and the fill thresholds behind fill_level
+func (v binListVersion) ETag(r *http.Request, scope []string, thresholds models.FillThresholds) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%d|%d|%d|%d|%d|%s?%s|%s|%d/%d",
+		v.Count, v.UpdatedAt, v.PhotoAt, v.MaintenanceAt, v.DeletedAt,
+		r.URL.Path, r.URL.RawQuery, strings.Join(scope, ","), thresholds.Warning, thresholds.Critical)))
+	return `W/"` + hex.EncodeToString(hash[:16]) + `"`
+}
+
+// GetBinChanges returns the bins changed or added since a time and the bins deleted since then, so the apps
+// can update a cached bin list instead of downloading it again
+// GET /api/bins/changes?since=<unix seconds>&fields=...
+// A bin counts as changed when it was updated (tags included), got a photo, or had maintenance logged or come due
+func GetBinChanges(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		fields, err := utils.ParseFields(r, binResponseFields)
+		if err != nil {
+			utils.RespondFieldsError(w, err, binResponseFields)
+			return
+		}
+		since, err := strconv.ParseInt(r.URL.Query().Get("since"), 10, 64)
+		if err != nil || since < 0 {
+			utils.RespondError(w, http.StatusBadRequest, "since must be a unix timestamp (the next_since of the previous call, or 0)")
+			return
+		}
+
+		now := time.Now().Unix()
+		var bins []models.Bin
+		err = db.SelectContext(r.Context(), &bins, binListColumns+`
+			WHERE updated_at >= $2
+			   OR EXISTS (SELECT 1 FROM bin_photos bp WHERE bp.bin_id = bins.id AND bp.taken_at >= $2)
+			   OR EXISTS (
+			       SELECT 1 FROM bin_maintenance bm
+			       WHERE bm.bin_id = bins.id
+			       AND (bm.updated_at >= $2 OR (bm.status = 'scheduled' AND bm.scheduled_for BETWEEN $2 AND $1))
+			   )
+			ORDER BY bin_number ASC
+		`, now, since)
+		if err != nil {
+			log.Printf("❌ [BIN-CHANGES] Failed to fetch changed bins: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch bin changes")
+			return
+		}
+
+		// A bin deleted and then restored with the same ID is a change, not a deletion
+		deleted := []models.BinDeletion{}
+		err = db.SelectContext(r.Context(), &deleted, `
+			SELECT bin_id, bin_number, deleted_at FROM bin_deletions bd
+			WHERE deleted_at >= $1 AND NOT EXISTS (SELECT 1 FROM bins WHERE bins.id = bd.bin_id)
+			ORDER BY deleted_at ASC
+		`, since)
+		if err != nil {
+			log.Printf("❌ [BIN-CHANGES] Failed to fetch deleted bins: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch bin changes")
+			return
+		}
+
+		responses := binListResponses(r.Context(), db, bins, loadFillThresholds(db))
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data": models.BinChanges{
+				Bins:      fields.Apply(responses),
+				Deleted:   deleted,
+				Since:     since,
+				NextSince: now,
+			},
+		})
+	}
+}
@@ -1,12 +1,18 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 	"time"
 
+	"ropacal-backend/internal/middleware"
 	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/services"
+	"ropacal-backend/internal/websocket"
+	"ropacal-backend/pkg/utils"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -50,9 +56,9 @@ func FlagStaleBins(db *sqlx.DB) http.HandlerFunc {
 			DaysSinceCheck int    `db:"days_since_check"`
 		}
 
-		if err := db.Select(&staleBins, query, sevenDaysAgo); err != nil {
+		if err := db.SelectContext(r.Context(), &staleBins, query, sevenDaysAgo); err != nil {
 			log.Printf("❌ [FLAG-STALE-BINS] Database query failed: %v", err)
-			http.Error(w, "Failed to query stale bins", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to query stale bins")
 			return
 		}
 
@@ -71,10 +77,10 @@ func FlagStaleBins(db *sqlx.DB) http.HandlerFunc {
 
 		// Create recommendations for each stale bin
 		flaggedCount := 0
-		tx, err := db.Beginx()
+		tx, err := db.BeginTxx(r.Context(), nil)
 		if err != nil {
 			log.Printf("❌ [FLAG-STALE-BINS] Failed to start transaction: %v", err)
-			http.Error(w, "Failed to create recommendations", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to create recommendations")
 			return
 		}
 		defer tx.Rollback()
@@ -82,22 +88,25 @@ func FlagStaleBins(db *sqlx.DB) http.HandlerFunc {
 		for _, bin := range staleBins {
 			recID := uuid.New().String()
 
+			expiresAt := now + services.CheckRecommendationTTLSeconds
 			recommendation := models.BinCheckRecommendation{
 				ID:             recID,
 				BinID:          bin.ID,
-				Reason:         "time_based",
+				Reason:         models.CheckReasonTimeBased,
 				FlaggedAt:      now,
 				DaysSinceCheck: bin.DaysSinceCheck,
 				Status:         "pending",
+				Score:          bin.DaysSinceCheck,
+				ExpiresAt:      &expiresAt,
 				CreatedAt:      now,
 				UpdatedAt:      now,
 			}
 
 			_, err := tx.NamedExec(`
 				INSERT INTO bin_check_recommendations
-				(id, bin_id, reason, flagged_at, days_since_check, status, created_at, updated_at)
+				(id, bin_id, reason, flagged_at, days_since_check, status, score, expires_at, created_at, updated_at)
 				VALUES
-				(:id, :bin_id, :reason, :flagged_at, :days_since_check, :status, :created_at, :updated_at)
+				(:id, :bin_id, :reason, :flagged_at, :days_since_check, :status, :score, :expires_at, :created_at, :updated_at)
 			`, recommendation)
 
 			if err != nil {
@@ -111,7 +120,7 @@ func FlagStaleBins(db *sqlx.DB) http.HandlerFunc {
 
 		if err := tx.Commit(); err != nil {
 			log.Printf("❌ [FLAG-STALE-BINS] Failed to commit transaction: %v", err)
-			http.Error(w, "Failed to save recommendations", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to save recommendations")
 			return
 		}
 
@@ -140,7 +149,21 @@ func GetBinCheckRecommendations(db *sqlx.DB) http.HandlerFunc {
 		// Build query
 		query := `
 			SELECT
-				bcr.*,
+				bcr.id,
+				bcr.bin_id,
+				bcr.reason,
+				bcr.flagged_at,
+				bcr.days_since_check,
+				bcr.status,
+				bcr.resolved_at,
+				bcr.resolved_by_user_id,
+				bcr.notes,
+				bcr.score,
+				bcr.details,
+				bcr.expires_at,
+				bcr.converted_shift_id,
+				bcr.created_at,
+				bcr.updated_at,
 				b.id as "bin.id",
 				b.bin_number as "bin.bin_number",
 				b.current_street as "bin.current_street",
@@ -163,12 +186,12 @@ func GetBinCheckRecommendations(db *sqlx.DB) http.HandlerFunc {
 			args = append(args, binID)
 		}
 
-		query += " ORDER BY bcr.days_since_check DESC, bcr.flagged_at DESC"
+		query += " ORDER BY bcr.score DESC, bcr.days_since_check DESC, bcr.flagged_at DESC"
 
-		rows, err := db.Queryx(query, args...)
+		rows, err := db.QueryxContext(r.Context(), query, args...)
 		if err != nil {
 			log.Printf("❌ [GET-CHECK-RECOMMENDATIONS] Query failed: %v", err)
-			http.Error(w, "Failed to retrieve recommendations", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to retrieve recommendations")
 			return
 		}
 		defer rows.Close()
@@ -189,6 +212,10 @@ func GetBinCheckRecommendations(db *sqlx.DB) http.HandlerFunc {
 				&rec.ResolvedAt,
 				&rec.ResolvedByUserID,
 				&rec.Notes,
+				&rec.Score,
+				&rec.Details,
+				&rec.ExpiresAt,
+				&rec.ConvertedShiftID,
 				&rec.CreatedAt,
 				&rec.UpdatedAt,
 				&rec.Bin.ID,
@@ -230,14 +257,14 @@ func DismissBinCheckRecommendation(db *sqlx.DB) http.HandlerFunc {
 		}
 
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
 			return
 		}
 
 		now := time.Now().Unix()
 
 		// Update recommendation status to dismissed
-		result, err := db.Exec(`
+		result, err := db.ExecContext(r.Context(), `
 			UPDATE bin_check_recommendations
 			SET status = 'dismissed',
 			    resolved_at = $1,
@@ -250,13 +277,13 @@ func DismissBinCheckRecommendation(db *sqlx.DB) http.HandlerFunc {
 
 		if err != nil {
 			log.Printf("❌ [DISMISS-RECOMMENDATION] Update failed: %v", err)
-			http.Error(w, "Failed to dismiss recommendation", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to dismiss recommendation")
 			return
 		}
 
 		rowsAffected, _ := result.RowsAffected()
 		if rowsAffected == 0 {
-			http.Error(w, "Recommendation not found or already resolved", http.StatusNotFound)
+			utils.RespondError(w, http.StatusNotFound, "Recommendation not found or already resolved")
 			return
 		}
 
@@ -292,3 +319,219 @@ func autoResolveCheckRecommendation(db *sqlx.DB, binID string, userID string, no
 		log.Printf("✅ [AUTO-RESOLVE] Auto-resolved %d check recommendation(s) for bin %s", rowsAffected, binID)
 	}
 }
+
+// RunCheckRecommendationEngine triggers a check recommendation engine run on demand
+// The engine also runs on a schedule (see CHECK_RECOMMENDATION_INTERVAL_MINUTES)
+// POST /api/manager/bins/check-recommendations/run
+func RunCheckRecommendationEngine(engine *services.CheckRecommendationEngine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		result, err := engine.Run()
+		if err != nil {
+			log.Printf("❌ [CHECK-RECOMMENDATION-ENGINE] Manual run failed: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to run check recommendation engine")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    result,
+		})
+	}
+}
+
+// ConvertCheckRecommendationToShift adds the recommended bin to a driver's shift as a collection stop
+// The stop is appended before the shift's final warehouse stop (if any)
+// POST /api/manager/bins/check-recommendations/{id}/convert
+// Body: { "shift_id": "..." }
+func ConvertCheckRecommendationToShift(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		recommendationID := chi.URLParam(r, "id")
+
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var req struct {
+			ShiftID string `json:"shift_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if req.ShiftID == "" {
+			utils.RespondError(w, http.StatusBadRequest, "shift_id is required")
+			return
+		}
+
+		var rec models.BinCheckRecommendation
+		err := db.GetContext(r.Context(), &rec, `
+			SELECT id, bin_id, reason, flagged_at, days_since_check, status, resolved_at, resolved_by_user_id,
+			       notes, score, details, expires_at, converted_shift_id, created_at, updated_at
+			FROM bin_check_recommendations
+			WHERE id = $1
+		`, recommendationID)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "Recommendation not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [CONVERT-RECOMMENDATION] Failed to fetch recommendation: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch recommendation")
+			return
+		}
+		if rec.Status != "pending" {
+			utils.RespondError(w, http.StatusConflict, fmt.Sprintf("Recommendation is already %s", rec.Status))
+			return
+		}
+
+		var shift models.Shift
+		if err := db.GetContext(r.Context(), &shift, `SELECT * FROM shifts WHERE id = $1`, req.ShiftID); err != nil {
+			utils.RespondError(w, http.StatusNotFound, "Shift not found")
+			return
+		}
+		if shift.Status != models.ShiftStatusReady && shift.Status != models.ShiftStatusActive && shift.Status != models.ShiftStatusPaused {
+			utils.RespondError(w, http.StatusBadRequest, "Shift must be ready, active or paused")
+			return
+		}
+
+		var bin models.Bin
+		if err := db.GetContext(r.Context(), &bin, `SELECT * FROM bins WHERE id = $1`, rec.BinID); err != nil {
+			utils.RespondError(w, http.StatusNotFound, "Bin not found")
+			return
+		}
+		if bin.Latitude == nil || bin.Longitude == nil {
+			utils.RespondError(w, http.StatusBadRequest, "Bin has no coordinates")
+			return
+		}
+
+		var alreadyInShift bool
+		db.GetContext(r.Context(), &alreadyInShift, `
+			SELECT EXISTS (SELECT 1 FROM route_tasks WHERE shift_id = $1 AND bin_id = $2 AND is_completed = 0)
+		`, shift.ID, bin.ID)
+		if alreadyInShift {
+			utils.RespondError(w, http.StatusConflict, "Bin is already an open stop on this shift")
+			return
+		}
+
+		now := time.Now().Unix()
+
+		tx, err := db.BeginTxx(r.Context(), nil)
+		if err != nil {
+			log.Printf("❌ [CONVERT-RECOMMENDATION] Failed to start transaction: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to convert recommendation")
+			return
+		}
+		defer tx.Rollback()
+
+		// Insert before a trailing warehouse stop, otherwise append at the end
+		var lastTask struct {
+			SequenceOrder int    `db:"sequence_order"`
+			TaskType      string `db:"task_type"`
+			IsCompleted   int    `db:"is_completed"`
+		}
+		insertSequence := 1
+		err = tx.GetContext(r.Context(), &lastTask, `
+			SELECT sequence_order, task_type, is_completed FROM route_tasks
+			WHERE shift_id = $1
+			ORDER BY sequence_order DESC
+			LIMIT 1
+		`, shift.ID)
+		if err == nil {
+			insertSequence = lastTask.SequenceOrder + 1
+			if lastTask.TaskType == string(models.TaskTypeWarehouseStop) && lastTask.IsCompleted == 0 {
+				insertSequence = lastTask.SequenceOrder
+				_, err = tx.ExecContext(r.Context(), `
+					UPDATE route_tasks SET sequence_order = sequence_order + 1, updated_at = $1
+					WHERE shift_id = $2 AND sequence_order >= $3
+				`, now, shift.ID, insertSequence)
+				if err != nil {
+					log.Printf("❌ [CONVERT-RECOMMENDATION] Failed to shift sequence: %v", err)
+					utils.RespondError(w, http.StatusInternalServerError, "Failed to convert recommendation")
+					return
+				}
+			}
+		} else if err != sql.ErrNoRows {
+			log.Printf("❌ [CONVERT-RECOMMENDATION] Failed to read shift tasks: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to convert recommendation")
+			return
+		}
+
+		address := models.Address{Street: bin.CurrentStreet, City: bin.City, Zip: bin.Zip}.String()
+		_, err = tx.ExecContext(r.Context(), `
+			INSERT INTO route_tasks (
+				id, shift_id, sequence_order, task_type, latitude, longitude, address,
+				bin_id, bin_number, fill_percentage, created_at
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		`, uuid.New().String(), shift.ID, insertSequence, models.TaskTypeCollection,
+			*bin.Latitude, *bin.Longitude, address, bin.ID, bin.BinNumber, bin.FillPercentage, now)
+		if err != nil {
+			log.Printf("❌ [CONVERT-RECOMMENDATION] Failed to insert task: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to add bin to shift")
+			return
+		}
+
+		_, err = tx.ExecContext(r.Context(), `
+			UPDATE shifts SET total_bins = total_bins + 1, updated_at = $1 WHERE id = $2
+		`, now, shift.ID)
+		if err != nil {
+			log.Printf("❌ [CONVERT-RECOMMENDATION] Failed to update shift: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to update shift")
+			return
+		}
+
+		_, err = tx.ExecContext(r.Context(), `
+			UPDATE bin_check_recommendations
+			SET status = 'converted',
+			    converted_shift_id = $1,
+			    resolved_at = $2,
+			    resolved_by_user_id = $3,
+			    updated_at = $2
+			WHERE id = $4
+		`, shift.ID, now, userClaims.UserID, rec.ID)
+		if err != nil {
+			log.Printf("❌ [CONVERT-RECOMMENDATION] Failed to update recommendation: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to update recommendation")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Printf("❌ [CONVERT-RECOMMENDATION] Failed to commit: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to convert recommendation")
+			return
+		}
+
+		log.Printf("✅ [CONVERT-RECOMMENDATION] Bin #%d added to shift %s at sequence %d (recommendation %s)",
+			bin.BinNumber, shift.ID, insertSequence, rec.ID)
+
+		// Push updated route to the driver
+		db.GetContext(r.Context(), &shift, `SELECT * FROM shifts WHERE id = $1`, shift.ID)
+		bins, err := getRouteBinsWithDetails(db, shift.ID)
+		if err != nil {
+			log.Printf("⚠️  [CONVERT-RECOMMENDATION] Failed to load route for broadcast: %v", err)
+			bins = []models.ShiftBinWithDetails{}
+		}
+		logicalTotal, logicalCompleted := calculateLogicalBinCounts(bins)
+		hub.BroadcastToUser(shift.DriverID, map[string]interface{}{
+			"type": "shift_update",
+			"data": map[string]interface{}{
+				"id":             shift.ID,
+				"status":         shift.Status,
+				"completed_bins": logicalCompleted,
+				"total_bins":     logicalTotal,
+				"bins":           bins,
+			},
+		})
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data": map[string]interface{}{
+				"recommendation_id": rec.ID,
+				"shift_id":          shift.ID,
+				"bin_id":            bin.ID,
+				"sequence_order":    insertSequence,
+			},
+		})
+	}
+}
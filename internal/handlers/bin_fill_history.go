@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ropacal-backend/internal/models"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+)
+
+// binFillHistoryDefaultDays is the range of GET /api/bins/{id}/fill-history without since
+const binFillHistoryDefaultDays = 30
+
+// GetBinFillHistory returns a bin's fill level over time for trend charts, oldest first
+// GET /api/bins/{id}/fill-history?since=<unix>&until=<unix>&resolution=auto|raw|hourly|daily
+// since defaults to 30 days ago and until to now; auto picks raw, hourly or daily points by the range's length
+func GetBinFillHistory(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		binID := chi.URLParam(r, "id")
+		q := r.URL.Query()
+
+		now := time.Now()
+		history := models.BinFillHistory{
+			BinID:  binID,
+			Since:  now.AddDate(0, 0, -binFillHistoryDefaultDays).Unix(),
+			Until:  now.Unix(),
+			Points: []models.FillHistoryPoint{},
+		}
+		for _, bound := range []struct {
+			param string
+			value *int64
+		}{{"since", &history.Since}, {"until", &history.Until}} {
+			v := q.Get(bound.param)
+			if v == "" {
+				continue
+			}
+			parsed, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				utils.RespondError(w, http.StatusBadRequest, fmt.Sprintf("Invalid %s timestamp", bound.param))
+				return
+			}
+			*bound.value = parsed
+		}
+		if history.Since > history.Until {
+			utils.RespondError(w, http.StatusBadRequest, "since must be before until")
+			return
+		}
+
+		resolution, err := models.ParseFillResolution(q.Get("resolution"), history.Since, history.Until)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		history.Resolution = resolution
+
+		var exists bool
+		if err := db.GetContext(r.Context(), &exists, `SELECT EXISTS(SELECT 1 FROM bins WHERE id = $1)`, binID); err != nil {
+			log.Printf("❌ [FILL HISTORY] Failed to look up bin %s: %v", binID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch fill history")
+			return
+		}
+		if !exists {
+			utils.RespondError(w, http.StatusNotFound, "Bin not found")
+			return
+		}
+
+		// Stored points are averaged into buckets of the resolution (raw buckets are a second, i.e. the points as stored)
+		err = db.SelectContext(r.Context(), &history.Points, `
+			SELECT recorded_at - recorded_at % $4 AS recorded_at,
+			       SUM(fill_percentage * sample_count) / SUM(sample_count) AS fill_percentage,
+			       MIN(min_fill) AS min_fill, MAX(max_fill) AS max_fill, SUM(sample_count)::INT AS sample_count
+			FROM bin_fill_history
+			WHERE bin_id = $1 AND recorded_at >= $2 AND recorded_at <= $3
+			GROUP BY 1
+			ORDER BY 1
+		`, binID, history.Since, history.Until, models.FillResolutionSeconds[resolution])
+		if err != nil {
+			log.Printf("❌ [FILL HISTORY] Failed to fetch fill history of bin %s: %v", binID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch fill history")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    history,
+		})
+	}
+}
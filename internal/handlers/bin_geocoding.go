@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"ropacal-backend/internal/models"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// binsMissingCoordinatesSelect lists bins without coordinates with the routes and moves they hold up
+const binsMissingCoordinatesSelect = `
+	SELECT b.id, b.bin_number, b.current_street, b.city, b.zip, b.status, b.created_at,
+	       (SELECT COUNT(*) FROM route_bins rb WHERE rb.bin_id = b.id) AS route_count,
+	       (SELECT COUNT(*) FROM bin_move_requests mr
+	        WHERE mr.bin_id = b.id AND mr.status IN ('pending', 'assigned', 'in_progress')) AS open_move_requests
+	FROM bins b
+	WHERE (b.latitude IS NULL OR b.longitude IS NULL)`
+
+// findBinsMissingCoordinates returns the bins among binIDs that have no coordinates
+func findBinsMissingCoordinates(q sqlx.Queryer, binIDs []string) ([]models.BinMissingCoordinates, error) {
+	missing := []models.BinMissingCoordinates{}
+	if len(binIDs) == 0 {
+		return missing, nil
+	}
+	err := sqlx.Select(q, &missing, binsMissingCoordinatesSelect+` AND b.id = ANY($1) ORDER BY b.bin_number`, pq.Array(binIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check bin coordinates: %w", err)
+	}
+	return missing, nil
+}
+
+// missingCoordinatesWarning is the warning for a bin left out of a route for having no coordinates
+func missingCoordinatesWarning(bin models.BinMissingCoordinates) string {
+	return fmt.Sprintf("Bin #%d (%s) has no coordinates and was left out", bin.BinNumber, bin.CurrentStreet)
+}
+
+// respondBinsMissingCoordinates refuses a route change that would add bins without coordinates
+func respondBinsMissingCoordinates(w http.ResponseWriter, missing []models.BinMissingCoordinates) {
+	utils.RespondErrorCode(w, http.StatusUnprocessableEntity, utils.CodeMissingCoordinates,
+		fmt.Sprintf("%d bin(s) have no coordinates; geocode them before adding them to a route", len(missing)), missing)
+}
+
+// GetBinsNeedingGeocoding lists bins without coordinates, the ones on the most routes first
+// GET /api/manager/bins/needs-geocoding
+// Query params: include_retired=true, limit (default 200, max 1000)
+func GetBinsNeedingGeocoding(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+
+		query := binsMissingCoordinatesSelect
+		if q.Get("include_retired") != "true" {
+			query += ` AND b.status <> '` + models.BinStatusRetired + `'`
+		}
+
+		limit := 200
+		if parsed, err := strconv.Atoi(q.Get("limit")); err == nil && parsed > 0 && parsed <= 1000 {
+			limit = parsed
+		}
+
+		bins := []models.BinMissingCoordinates{}
+		err := db.SelectContext(r.Context(), &bins, query+`
+			ORDER BY route_count DESC, open_move_requests DESC, b.bin_number
+			LIMIT $1`, limit)
+		if err != nil {
+			log.Printf("❌ [GEOCODING] Failed to fetch bins without coordinates: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch bins needing geocoding")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    bins,
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/services"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+)
+
+// maxLocationHistoryGeocodes caps geocoding calls per request; the rest are filled on later requests
+const maxLocationHistoryGeocodes = 25
+
+// GetBinLocationHistory reconstructs where a bin has been from its moves, as dated segments for a map timeline
+// Missing move coordinates are geocoded from the recorded addresses and saved, so each address is looked up once
+// GET /api/bins/{id}/location-history?at=<unix>
+func GetBinLocationHistory(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		binID := chi.URLParam(r, "id")
+
+		var at *int64
+		if v := r.URL.Query().Get("at"); v != "" {
+			parsed, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				utils.RespondError(w, http.StatusBadRequest, "at must be a Unix timestamp")
+				return
+			}
+			at = &parsed
+		}
+
+		var bin models.Bin
+		err := db.GetContext(r.Context(), &bin, `
+			SELECT id, bin_number, current_street, city, zip, latitude, longitude, created_at
+			FROM bins WHERE id = $1
+		`, binID)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "Bin not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [LOCATION HISTORY] Failed to fetch bin %s: %v", binID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch bin")
+			return
+		}
+
+		var moves []models.Move
+		err = db.SelectContext(r.Context(), &moves, `
+			SELECT id, bin_id, moved_from, moved_to, moved_on, move_request_id,
+			       from_latitude, from_longitude, to_latitude, to_longitude
+			FROM moves
+			WHERE bin_id = $1
+			ORDER BY moved_on ASC, id ASC
+		`, binID)
+		if err != nil {
+			log.Printf("❌ [LOCATION HISTORY] Failed to fetch moves for bin %s: %v", binID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch moves")
+			return
+		}
+
+		geocoded := backfillMoveCoordinates(db, moves)
+
+		history := models.BinLocationHistory{
+			BinID:     bin.ID,
+			BinNumber: bin.BinNumber,
+			Segments:  buildBinLocationSegments(bin, moves),
+			Geocoded:  geocoded,
+		}
+		for i, segment := range history.Segments {
+			if segment.Latitude == nil {
+				history.Unlocated++
+			}
+			if at != nil && *at >= segment.From && (segment.To == nil || *at < *segment.To) {
+				history.At = &history.Segments[i]
+			}
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    history,
+		})
+	}
+}
+
+// buildBinLocationSegments turns a bin's moves (oldest first) into the locations between them
+// The first segment is where the bin was before its first move; the last is open-ended at its current location
+func buildBinLocationSegments(bin models.Bin, moves []models.Move) []models.BinLocationSegment {
+	current := bin.CurrentStreet + ", " + bin.City + " " + bin.Zip
+	if len(moves) == 0 {
+		return []models.BinLocationSegment{newBinLocationSegment(current, bin.Latitude, bin.Longitude, bin.CreatedAt, nil)}
+	}
+
+	segments := make([]models.BinLocationSegment, 0, len(moves)+1)
+	first := moves[0]
+	start := bin.CreatedAt
+	if first.MovedOn < start {
+		// Moves imported from before the bin row existed
+		start = first.MovedOn
+	}
+	segments = append(segments, newBinLocationSegment(first.MovedFrom, first.FromLatitude, first.FromLongitude, start, &first.MovedOn))
+
+	for i := range moves {
+		move := moves[i]
+		var end *int64
+		if i+1 < len(moves) {
+			end = &moves[i+1].MovedOn
+		}
+		lat, lng := move.ToLatitude, move.ToLongitude
+		if end == nil && bin.Latitude != nil && bin.Longitude != nil {
+			// The bin is still here, so its own (possibly corrected) coordinates apply
+			lat, lng = bin.Latitude, bin.Longitude
+		}
+		segment := newBinLocationSegment(move.MovedTo, lat, lng, move.MovedOn, end)
+		segment.ArrivedByMoveID = &move.ID
+		segment.MoveRequestID = move.MoveRequestID
+		segments = append(segments, segment)
+	}
+	return segments
+}
+
+func newBinLocationSegment(address string, lat, lng *float64, from int64, to *int64) models.BinLocationSegment {
+	segment := models.BinLocationSegment{
+		Address:   address,
+		Latitude:  lat,
+		Longitude: lng,
+		From:      from,
+		To:        to,
+		FromIso:   time.Unix(from, 0).Format(time.RFC3339),
+	}
+	if to != nil {
+		iso := time.Unix(*to, 0).Format(time.RFC3339)
+		segment.ToIso = &iso
+	}
+	return segment
+}
+
+// backfillMoveCoordinates geocodes move addresses without coordinates, saving them on the moves (updated in place)
+// Returns how many addresses were geocoded; geocoding is skipped when no API key is configured
+func backfillMoveCoordinates(db *sqlx.DB, moves []models.Move) int {
+	missing := false
+	for _, move := range moves {
+		if move.FromLatitude == nil || move.ToLatitude == nil {
+			missing = true
+			break
+		}
+	}
+	if !missing {
+		return 0
+	}
+
+	geocoder, err := services.NewGeocodingService()
+	if err != nil {
+		log.Printf("⚠️  [LOCATION HISTORY] Geocoding unavailable, leaving moves without coordinates: %v", err)
+		return 0
+	}
+
+	// Consecutive moves share an address (one's destination is the next one's origin), so look each up once
+	cache := map[string]*services.Coordinates{}
+	calls, found := 0, 0
+	lookup := func(address string) *services.Coordinates {
+		if coords, ok := cache[address]; ok {
+			return coords
+		}
+		if calls >= maxLocationHistoryGeocodes {
+			return nil
+		}
+		calls++
+		result, err := geocoder.Geocode(address)
+		if err != nil {
+			log.Printf("⚠️  [LOCATION HISTORY] Failed to geocode %q: %v", address, err)
+			cache[address] = nil
+			return nil
+		}
+		found++
+		cache[address] = &result.Coordinates
+		return cache[address]
+	}
+
+	for i := range moves {
+		move := &moves[i]
+		if move.FromLatitude == nil {
+			if coords := lookup(move.MovedFrom); coords != nil {
+				move.FromLatitude, move.FromLongitude = &coords.Lat, &coords.Lng
+				if _, err := db.Exec(`UPDATE moves SET from_latitude = $1, from_longitude = $2 WHERE id = $3 AND from_latitude IS NULL`,
+					coords.Lat, coords.Lng, move.ID); err != nil {
+					log.Printf("⚠️  [LOCATION HISTORY] Failed to save coordinates for move %d: %v", move.ID, err)
+				}
+			}
+		}
+		if move.ToLatitude == nil {
+			if coords := lookup(move.MovedTo); coords != nil {
+				move.ToLatitude, move.ToLongitude = &coords.Lat, &coords.Lng
+				if _, err := db.Exec(`UPDATE moves SET to_latitude = $1, to_longitude = $2 WHERE id = $3 AND to_latitude IS NULL`,
+					coords.Lat, coords.Lng, move.ID); err != nil {
+					log.Printf("⚠️  [LOCATION HISTORY] Failed to save coordinates for move %d: %v", move.ID, err)
+				}
+			}
+		}
+	}
+	if calls > 0 {
+		log.Printf("🌍 [LOCATION HISTORY] Geocoded %d of %d move address(es)", found, calls)
+	}
+	return found
+}
@@ -0,0 +1,406 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// binMaintenanceListSQL selects maintenance records with bin and performer details. $1 must be the current unix time
+const binMaintenanceListSQL = `
+	SELECT bm.*,
+	       b.bin_number,
+	       b.current_street,
+	       u.name AS performed_by_name,
+	       (bm.status = 'scheduled' AND bm.scheduled_for <= $1) AS is_overdue
+	FROM bin_maintenance bm
+	JOIN bins b ON b.id = bm.bin_id
+	LEFT JOIN users u ON u.id = bm.performed_by_user_id`
+
+// binMaintenanceRequest is the body for logging, scheduling or updating maintenance
+type binMaintenanceRequest struct {
+	MaintenanceType   *string   `json:"maintenance_type" validate:"oneof=repaint repair cleaning replacement inspection other"`
+	Status            *string   `json:"status" validate:"oneof=scheduled completed cancelled"`
+	ScheduledFor      *int64    `json:"scheduled_for"`
+	PerformedAt       *int64    `json:"performed_at"`
+	PerformedByUserID *string   `json:"performed_by_user_id"`
+	Cost              *float64  `json:"cost" validate:"min=0"`
+	PhotoURLs         *[]string `json:"photo_urls"`
+	Notes             *string   `json:"notes"`
+}
+
+// GetBinMaintenance returns the maintenance history and scheduled maintenance for a bin
+// GET /api/manager/bins/{id}/maintenance
+func GetBinMaintenance(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		binID := chi.URLParam(r, "id")
+
+		records := []models.BinMaintenanceWithBin{}
+		err := db.SelectContext(r.Context(), &records, binMaintenanceListSQL+`
+			WHERE bm.bin_id = $2
+			ORDER BY COALESCE(bm.performed_at, bm.scheduled_for, bm.created_at) DESC
+		`, time.Now().Unix(), binID)
+		if err != nil {
+			log.Printf("❌ [MAINTENANCE] Failed to fetch maintenance for bin %s: %v", binID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch maintenance")
+			return
+		}
+
+		var totalCost float64
+		for _, record := range records {
+			if record.Status == models.MaintenanceStatusCompleted && record.Cost != nil {
+				totalCost += *record.Cost
+			}
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data": map[string]interface{}{
+				"bin_id":     binID,
+				"records":    records,
+				"total_cost": totalCost,
+			},
+		})
+	}
+}
+
+// GetMaintenanceSchedule lists maintenance across all bins
+// GET /api/manager/maintenance
+// Query params: status (scheduled (default), completed, cancelled, all), due_only=true, limit (default 200)
+func GetMaintenanceSchedule(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := r.URL.Query().Get("status")
+		if status == "" {
+			status = models.MaintenanceStatusScheduled
+		}
+
+		limit := 200
+		if parsed, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && parsed > 0 && parsed <= 1000 {
+			limit = parsed
+		}
+
+		now := time.Now().Unix()
+		args := []interface{}{now}
+		whereClause := []string{}
+
+		if status != "all" {
+			args = append(args, status)
+			whereClause = append(whereClause, fmt.Sprintf("bm.status = $%d", len(args)))
+		}
+		if r.URL.Query().Get("due_only") == "true" {
+			whereClause = append(whereClause, "bm.status = 'scheduled' AND bm.scheduled_for <= $1")
+		}
+
+		query := binMaintenanceListSQL
+		if len(whereClause) > 0 {
+			query += " WHERE " + strings.Join(whereClause, " AND ")
+		}
+		args = append(args, limit)
+		query += fmt.Sprintf(" ORDER BY COALESCE(bm.scheduled_for, bm.performed_at, bm.created_at) ASC LIMIT $%d", len(args))
+
+		records := []models.BinMaintenanceWithBin{}
+		if err := db.SelectContext(r.Context(), &records, query, args...); err != nil {
+			log.Printf("❌ [MAINTENANCE] Failed to fetch maintenance schedule: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch maintenance")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    records,
+		})
+	}
+}
+
+// CreateBinMaintenance logs completed maintenance or schedules future maintenance for a bin
+// POST /api/manager/bins/{id}/maintenance
+// Body: { "maintenance_type": "repaint", "cost": 45.5, "photo_urls": [...], "notes": "..." } logs completed work
+// Body: { "maintenance_type": "repair", "scheduled_for": 1735689600 } schedules future work
+func CreateBinMaintenance(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		binID := chi.URLParam(r, "id")
+
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var req binMaintenanceRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if req.MaintenanceType == nil || !models.IsValidMaintenanceType(*req.MaintenanceType) {
+			utils.RespondError(w, http.StatusBadRequest, "maintenance_type must be one of: repaint, repair, cleaning, replacement, inspection, other")
+			return
+		}
+		if req.Cost != nil && *req.Cost < 0 {
+			utils.RespondError(w, http.StatusBadRequest, "cost must not be negative")
+			return
+		}
+
+		var exists bool
+		db.GetContext(r.Context(), &exists, `SELECT EXISTS (SELECT 1 FROM bins WHERE id = $1)`, binID)
+		if !exists {
+			utils.RespondError(w, http.StatusNotFound, "Bin not found")
+			return
+		}
+
+		now := time.Now().Unix()
+		record := models.BinMaintenance{
+			ID:              uuid.New().String(),
+			BinID:           binID,
+			MaintenanceType: *req.MaintenanceType,
+			Cost:            req.Cost,
+			PhotoURLs:       pq.StringArray{},
+			Notes:           req.Notes,
+			CreatedByUserID: &userClaims.UserID,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		}
+		if req.PhotoURLs != nil {
+			record.PhotoURLs = *req.PhotoURLs
+		}
+
+		// Scheduled unless the work has already been performed
+		if req.ScheduledFor != nil && req.PerformedAt == nil && (req.Status == nil || *req.Status == models.MaintenanceStatusScheduled) {
+			record.Status = models.MaintenanceStatusScheduled
+			record.ScheduledFor = req.ScheduledFor
+			record.PerformedByUserID = req.PerformedByUserID
+		} else {
+			record.Status = models.MaintenanceStatusCompleted
+			record.ScheduledFor = req.ScheduledFor
+			record.PerformedAt = &now
+			if req.PerformedAt != nil {
+				record.PerformedAt = req.PerformedAt
+			}
+			record.PerformedByUserID = &userClaims.UserID
+			if req.PerformedByUserID != nil {
+				record.PerformedByUserID = req.PerformedByUserID
+			}
+		}
+
+		_, err := db.NamedExecContext(r.Context(), `
+			INSERT INTO bin_maintenance (
+				id, bin_id, maintenance_type, status, scheduled_for, performed_at, performed_by_user_id,
+				cost, photo_urls, notes, created_by_user_id, created_at, updated_at
+			) VALUES (
+				:id, :bin_id, :maintenance_type, :status, :scheduled_for, :performed_at, :performed_by_user_id,
+				:cost, :photo_urls, :notes, :created_by_user_id, :created_at, :updated_at
+			)
+		`, record)
+		if err != nil {
+			log.Printf("❌ [MAINTENANCE] Failed to create maintenance for bin %s: %v", binID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to save maintenance")
+			return
+		}
+
+		log.Printf("✅ [MAINTENANCE] %s %s for bin %s by %s", record.Status, record.MaintenanceType, binID, userClaims.Email)
+
+		utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{
+			"success": true,
+			"data":    record,
+		})
+	}
+}
+
+// UpdateBinMaintenance edits a maintenance record, completes scheduled work or cancels it
+// PUT /api/manager/maintenance/{id}
+// Body: any subset of the create fields; "status": "completed" marks scheduled work as done
+func UpdateBinMaintenance(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		maintenanceID := chi.URLParam(r, "id")
+
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var req binMaintenanceRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		var record models.BinMaintenance
+		err := db.GetContext(r.Context(), &record, `SELECT * FROM bin_maintenance WHERE id = $1`, maintenanceID)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "Maintenance record not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [MAINTENANCE] Failed to fetch maintenance %s: %v", maintenanceID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch maintenance")
+			return
+		}
+
+		if req.MaintenanceType != nil {
+			if !models.IsValidMaintenanceType(*req.MaintenanceType) {
+				utils.RespondError(w, http.StatusBadRequest, "Invalid maintenance_type")
+				return
+			}
+			record.MaintenanceType = *req.MaintenanceType
+		}
+		if req.Cost != nil {
+			if *req.Cost < 0 {
+				utils.RespondError(w, http.StatusBadRequest, "cost must not be negative")
+				return
+			}
+			record.Cost = req.Cost
+		}
+		if req.PhotoURLs != nil {
+			record.PhotoURLs = *req.PhotoURLs
+		}
+		if req.Notes != nil {
+			record.Notes = req.Notes
+		}
+		if req.ScheduledFor != nil {
+			record.ScheduledFor = req.ScheduledFor
+		}
+		if req.PerformedAt != nil {
+			record.PerformedAt = req.PerformedAt
+		}
+		if req.PerformedByUserID != nil {
+			record.PerformedByUserID = req.PerformedByUserID
+		}
+
+		now := time.Now().Unix()
+		if req.Status != nil && *req.Status != record.Status {
+			switch {
+			case record.Status == models.MaintenanceStatusScheduled && *req.Status == models.MaintenanceStatusCompleted:
+				if record.PerformedAt == nil {
+					record.PerformedAt = &now
+				}
+				if record.PerformedByUserID == nil {
+					record.PerformedByUserID = &userClaims.UserID
+				}
+			case record.Status == models.MaintenanceStatusScheduled && *req.Status == models.MaintenanceStatusCancelled:
+			default:
+				utils.RespondError(w, http.StatusBadRequest, fmt.Sprintf("Cannot change status from %s to %s", record.Status, *req.Status))
+				return
+			}
+			record.Status = *req.Status
+		}
+		record.UpdatedAt = now
+
+		_, err = db.NamedExecContext(r.Context(), `
+			UPDATE bin_maintenance
+			SET maintenance_type = :maintenance_type,
+			    status = :status,
+			    scheduled_for = :scheduled_for,
+			    performed_at = :performed_at,
+			    performed_by_user_id = :performed_by_user_id,
+			    cost = :cost,
+			    photo_urls = :photo_urls,
+			    notes = :notes,
+			    updated_at = :updated_at
+			WHERE id = :id
+		`, record)
+		if err != nil {
+			log.Printf("❌ [MAINTENANCE] Failed to update maintenance %s: %v", maintenanceID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to update maintenance")
+			return
+		}
+
+		log.Printf("✅ [MAINTENANCE] Updated %s (%s, %s) by %s", record.ID, record.MaintenanceType, record.Status, userClaims.Email)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    record,
+		})
+	}
+}
+
+// GetMaintenanceCosts returns completed maintenance costs grouped by type, month, bin or area
+// GET /api/manager/analytics/maintenance-costs
+// Query params: group_by (type (default), month, bin, area), since, until (unix)
+func GetMaintenanceCosts(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		groupBy := r.URL.Query().Get("group_by")
+
+		var groupValue string
+		switch groupBy {
+		case "month":
+			groupValue = "TO_CHAR(TO_TIMESTAMP(bm.performed_at), 'YYYY-MM')"
+		case "bin":
+			groupValue = "b.bin_number::TEXT"
+		case "area":
+			groupValue = "COALESCE(a.name, 'Unassigned')"
+		default:
+			groupBy = "type"
+			groupValue = "bm.maintenance_type"
+		}
+
+		args := []interface{}{}
+		whereClause := []string{"bm.status = 'completed'"}
+		if since, err := strconv.ParseInt(r.URL.Query().Get("since"), 10, 64); err == nil {
+			args = append(args, since)
+			whereClause = append(whereClause, fmt.Sprintf("bm.performed_at >= $%d", len(args)))
+		}
+		if until, err := strconv.ParseInt(r.URL.Query().Get("until"), 10, 64); err == nil {
+			args = append(args, until)
+			whereClause = append(whereClause, fmt.Sprintf("bm.performed_at < $%d", len(args)))
+		}
+
+		type MaintenanceCostGroup struct {
+			GroupValue string  `json:"group_value" db:"group_value"`
+			EventCount int     `json:"event_count" db:"event_count"`
+			TotalCost  float64 `json:"total_cost" db:"total_cost"`
+			AvgCost    float64 `json:"avg_cost" db:"avg_cost"`
+		}
+
+		query := fmt.Sprintf(`
+			SELECT
+				%s AS group_value,
+				COUNT(*) AS event_count,
+				COALESCE(SUM(bm.cost), 0)::DOUBLE PRECISION AS total_cost,
+				COALESCE(AVG(bm.cost), 0)::DOUBLE PRECISION AS avg_cost
+			FROM bin_maintenance bm
+			JOIN bins b ON b.id = bm.bin_id
+			LEFT JOIN areas a ON a.id = b.area_id
+			WHERE %s
+			GROUP BY 1
+			ORDER BY total_cost DESC
+		`, groupValue, strings.Join(whereClause, " AND "))
+
+		groups := []MaintenanceCostGroup{}
+		if err := db.SelectContext(r.Context(), &groups, query, args...); err != nil {
+			log.Printf("❌ [MAINTENANCE] Failed to fetch maintenance costs: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch maintenance costs")
+			return
+		}
+
+		var totalCost float64
+		var eventCount int
+		for _, group := range groups {
+			totalCost += group.TotalCost
+			eventCount += group.EventCount
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data": map[string]interface{}{
+				"group_by":    groupBy,
+				"groups":      groups,
+				"total_cost":  totalCost,
+				"event_count": eventCount,
+			},
+		})
+	}
+}
@@ -0,0 +1,479 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/store"
+	"ropacal-backend/internal/websocket"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Duplicate detection defaults
+const (
+	defaultDuplicateRadiusMeters = 50.0
+	defaultDuplicateSimilarity   = 0.85
+)
+
+// streetAbbreviations folds common spellings of street words so "123 North Main Street" matches "123 N Main St"
+var streetAbbreviations = map[string]string{
+	"street": "st", "str": "st", "avenue": "ave", "av": "ave", "road": "rd", "drive": "dr", "boulevard": "blvd",
+	"lane": "ln", "court": "ct", "place": "pl", "parkway": "pkwy", "highway": "hwy", "expressway": "expy",
+	"circle": "cir", "terrace": "ter", "square": "sq", "suite": "ste", "apartment": "apt",
+	"north": "n", "south": "s", "east": "e", "west": "w",
+	"northeast": "ne", "northwest": "nw", "southeast": "se", "southwest": "sw",
+}
+
+// normalizeStreet lowercases an address, drops punctuation and abbreviates street words
+func normalizeStreet(street string) string {
+	fields := strings.FieldsFunc(strings.ToLower(street), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, field := range fields {
+		if abbr, ok := streetAbbreviations[field]; ok {
+			fields[i] = abbr
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+// addressSimilarity is 1 minus the edit distance between two normalized addresses over the longer length
+func addressSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return 1 - float64(prev[len(rb)])/float64(longest)
+}
+
+// GetBinDuplicates finds bins that look like the same physical bin: similar street addresses in the
+// same city or ZIP that, when both have coordinates, are within radius_meters of each other
+// Bins sharing the first word of their address (usually the house number) are compared; retired bins are skipped
+// GET /api/manager/bins/duplicates?radius_meters=50&min_similarity=0.85
+func GetBinDuplicates(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		radius := defaultDuplicateRadiusMeters
+		if v := r.URL.Query().Get("radius_meters"); v != "" {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil || parsed <= 0 || parsed > 1000 {
+				utils.RespondError(w, http.StatusBadRequest, "radius_meters must be between 0 and 1000")
+				return
+			}
+			radius = parsed
+		}
+		minSimilarity := defaultDuplicateSimilarity
+		if v := r.URL.Query().Get("min_similarity"); v != "" {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil || parsed <= 0 || parsed > 1 {
+				utils.RespondError(w, http.StatusBadRequest, "min_similarity must be between 0 and 1")
+				return
+			}
+			minSimilarity = parsed
+		}
+
+		var bins []models.Bin
+		err := db.SelectContext(r.Context(), &bins, `SELECT * FROM bins WHERE status != $1 ORDER BY bin_number`, models.BinStatusRetired)
+		if err != nil {
+			log.Printf("❌ [BIN-MERGE] Failed to fetch bins: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch bins")
+			return
+		}
+
+		normalized := make([]string, len(bins))
+		blocks := map[string][]int{}
+		for i, bin := range bins {
+			normalized[i] = normalizeStreet(bin.CurrentStreet)
+			key := addressBlock(normalized[i])
+			blocks[key] = append(blocks[key], i)
+		}
+
+		// Union-find over matched pairs, so chains of duplicates end up in one group
+		parent := make([]int, len(bins))
+		for i := range parent {
+			parent[i] = i
+		}
+		var find func(int) int
+		find = func(i int) int {
+			if parent[i] != i {
+				parent[i] = find(parent[i])
+			}
+			return parent[i]
+		}
+
+		var matches []models.BinDuplicateMatch
+		matchedIdx := [][2]int{}
+		for _, block := range blocks {
+			for x := 0; x < len(block); x++ {
+				for y := x + 1; y < len(block); y++ {
+					a, b := bins[block[x]], bins[block[y]]
+					if !strings.EqualFold(strings.TrimSpace(a.Zip), strings.TrimSpace(b.Zip)) &&
+						!strings.EqualFold(strings.TrimSpace(a.City), strings.TrimSpace(b.City)) {
+						continue
+					}
+					similarity := addressSimilarity(normalized[block[x]], normalized[block[y]])
+					if similarity < minSimilarity {
+						continue
+					}
+					match := models.BinDuplicateMatch{
+						BinID:             a.ID,
+						OtherBinID:        b.ID,
+						AddressSimilarity: math.Round(similarity*100) / 100,
+					}
+					if a.Latitude != nil && a.Longitude != nil && b.Latitude != nil && b.Longitude != nil {
+						meters := haversineDistanceKm(*a.Latitude, *a.Longitude, *b.Latitude, *b.Longitude) * 1000
+						if meters > radius {
+							continue
+						}
+						meters = math.Round(meters*10) / 10
+						match.DistanceMeters = &meters
+					}
+					matches = append(matches, match)
+					matchedIdx = append(matchedIdx, [2]int{block[x], block[y]})
+					parent[find(block[x])] = find(block[y])
+				}
+			}
+		}
+
+		// Suggest the bin with the most checks as the survivor, then the oldest
+		checkCounts := map[string]int{}
+		if len(matches) > 0 {
+			ids := make([]string, 0, len(matchedIdx)*2)
+			for _, pair := range matchedIdx {
+				ids = append(ids, bins[pair[0]].ID, bins[pair[1]].ID)
+			}
+			var counts []struct {
+				BinID string `db:"bin_id"`
+				Count int    `db:"count"`
+			}
+			err := db.SelectContext(r.Context(), &counts,
+				`SELECT bin_id, COUNT(*) AS count FROM checks WHERE bin_id = ANY($1) GROUP BY bin_id`, pq.Array(ids))
+			if err != nil {
+				log.Printf("❌ [BIN-MERGE] Failed to count checks: %v", err)
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to find duplicate bins")
+				return
+			}
+			for _, c := range counts {
+				checkCounts[c.BinID] = c.Count
+			}
+		}
+
+		groupsByRoot := map[int]*models.BinDuplicateGroup{}
+		members := map[int][]int{}
+		for i, pair := range matchedIdx {
+			root := find(pair[0])
+			group, ok := groupsByRoot[root]
+			if !ok {
+				group = &models.BinDuplicateGroup{}
+				groupsByRoot[root] = group
+			}
+			group.Matches = append(group.Matches, matches[i])
+			for _, idx := range pair {
+				if !containsInt(members[root], idx) {
+					members[root] = append(members[root], idx)
+				}
+			}
+		}
+
+		groups := make([]models.BinDuplicateGroup, 0, len(groupsByRoot))
+		for root, group := range groupsByRoot {
+			idxs := members[root]
+			sort.Slice(idxs, func(i, j int) bool { return bins[idxs[i]].BinNumber < bins[idxs[j]].BinNumber })
+			best := idxs[0]
+			for _, idx := range idxs {
+				group.Bins = append(group.Bins, bins[idx].ToBinResponse())
+				if checkCounts[bins[idx].ID] > checkCounts[bins[best].ID] ||
+					(checkCounts[bins[idx].ID] == checkCounts[bins[best].ID] && bins[idx].CreatedAt < bins[best].CreatedAt) {
+					best = idx
+				}
+			}
+			group.SuggestedTargetID = bins[best].ID
+			groups = append(groups, *group)
+		}
+		sort.Slice(groups, func(i, j int) bool {
+			if len(groups[i].Bins) != len(groups[j].Bins) {
+				return len(groups[i].Bins) > len(groups[j].Bins)
+			}
+			return groups[i].Bins[0].BinNumber < groups[j].Bins[0].BinNumber
+		})
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    groups,
+		})
+	}
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// binMergeReassignments moves a bin's history to the surviving bin; $1 is the duplicate, $2 the survivor
+// Route memberships and sensors are handled separately (they can't hold the same bin twice)
+var binMergeReassignments = []struct {
+	table string
+	query string
+}{
+	{"checks", `UPDATE checks SET bin_id = $2 WHERE bin_id = $1`},
+	{"bin_fill_history", `UPDATE bin_fill_history h SET bin_id = $2 WHERE bin_id = $1 AND (resolution = 'raw' OR NOT EXISTS (
+		SELECT 1 FROM bin_fill_history t WHERE t.bin_id = $2 AND t.resolution = h.resolution AND t.recorded_at = h.recorded_at))`},
+	{"moves", `UPDATE moves SET bin_id = $2 WHERE bin_id = $1`},
+	{"zone_incidents", `UPDATE zone_incidents SET bin_id = $2 WHERE bin_id = $1`},
+	{"zone_risk_overrides", `UPDATE zone_risk_overrides SET bin_id = $2 WHERE bin_id = $1`},
+	{"bin_move_requests", `UPDATE bin_move_requests SET bin_id = $2 WHERE bin_id = $1`},
+	{"bin_check_recommendations", `UPDATE bin_check_recommendations SET bin_id = $2 WHERE bin_id = $1`},
+	{"bin_maintenance", `UPDATE bin_maintenance SET bin_id = $2 WHERE bin_id = $1`},
+	{"shift_notes", `UPDATE shift_notes SET bin_id = $2 WHERE bin_id = $1`},
+	{"bin_tags", `UPDATE bin_tags SET bin_id = $2 WHERE bin_id = $1 AND tag_id NOT IN (SELECT tag_id FROM bin_tags WHERE bin_id = $2)`},
+	{"route_tasks", `UPDATE route_tasks SET bin_id = $2, bin_number = (SELECT bin_number FROM bins WHERE id = $2) WHERE bin_id = $1`},
+	{"potential_locations", `UPDATE potential_locations SET converted_to_bin_id = $2 WHERE converted_to_bin_id = $1`},
+	{"bin_sensors", `UPDATE bin_sensors SET bin_id = $2 WHERE bin_id = $1 AND NOT EXISTS (SELECT 1 FROM bin_sensors WHERE bin_id = $2)`},
+}
+
+// MergeBin folds a duplicate bin into the surviving bin: checks, moves, incidents, move requests,
+// maintenance, stops and route memberships move to the survivor, which takes the newer check and move
+// dates; the duplicate is deleted and the merge recorded in bin_merges
+// POST /api/manager/bins/{id}/merge-into/{targetId}
+func MergeBin(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sourceID := chi.URLParam(r, "id")
+		targetID := chi.URLParam(r, "targetId")
+
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		if sourceID == targetID {
+			utils.RespondError(w, http.StatusBadRequest, "A bin can't be merged into itself")
+			return
+		}
+
+		tx, err := db.BeginTxx(r.Context(), nil)
+		if err != nil {
+			log.Printf("❌ [BIN-MERGE] Failed to start transaction: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to merge bins")
+			return
+		}
+		defer tx.Rollback()
+
+		// Locked in ID order so two merges of the same pair can't deadlock
+		var locked []models.Bin
+		err = tx.SelectContext(r.Context(), &locked, `SELECT * FROM bins WHERE id = ANY($1) ORDER BY id FOR UPDATE`,
+			pq.Array([]string{sourceID, targetID}))
+		if err != nil {
+			log.Printf("❌ [BIN-MERGE] Failed to lock bins: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to merge bins")
+			return
+		}
+		var source, target *models.Bin
+		for i := range locked {
+			if locked[i].ID == sourceID {
+				source = &locked[i]
+			} else {
+				target = &locked[i]
+			}
+		}
+		if source == nil {
+			utils.RespondError(w, http.StatusNotFound, "Bin not found")
+			return
+		}
+		if target == nil {
+			utils.RespondError(w, http.StatusNotFound, "Target bin not found")
+			return
+		}
+		if target.Status == models.BinStatusRetired {
+			utils.RespondError(w, http.StatusConflict, "Can't merge into a retired bin")
+			return
+		}
+
+		now := time.Now().Unix()
+		counts := models.BinMergeCounts{}
+		for _, reassign := range binMergeReassignments {
+			result, err := tx.ExecContext(r.Context(), reassign.query, source.ID, target.ID)
+			if err != nil {
+				log.Printf("❌ [BIN-MERGE] Failed to reassign %s from %s: %v", reassign.table, source.ID, err)
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to merge bins")
+				return
+			}
+			if n, _ := result.RowsAffected(); n > 0 {
+				counts[reassign.table] = n
+			}
+		}
+
+		// Route memberships: routes that already have the survivor just drop the duplicate
+		var routeIDs []string
+		err = tx.SelectContext(r.Context(), &routeIDs, `SELECT DISTINCT route_id FROM route_bins WHERE bin_id = $1`, source.ID)
+		if err != nil {
+			log.Printf("❌ [BIN-MERGE] Failed to load routes of %s: %v", source.ID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to merge bins")
+			return
+		}
+		if len(routeIDs) > 0 {
+			_, err = tx.ExecContext(r.Context(), `
+				DELETE FROM route_bins
+				WHERE bin_id = $1 AND route_id IN (SELECT route_id FROM route_bins WHERE bin_id = $2)
+			`, source.ID, target.ID)
+			if err == nil {
+				_, err = tx.ExecContext(r.Context(), `UPDATE route_bins SET bin_id = $2 WHERE bin_id = $1`, source.ID, target.ID)
+			}
+			if err == nil {
+				_, err = tx.ExecContext(r.Context(), `
+					UPDATE routes SET bin_count = (SELECT COUNT(*) FROM route_bins WHERE route_id = routes.id), updated_at = $2
+					WHERE id = ANY($1)
+				`, pq.Array(routeIDs), now)
+			}
+			if err != nil {
+				log.Printf("❌ [BIN-MERGE] Failed to reassign route memberships of %s: %v", source.ID, err)
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to merge bins")
+				return
+			}
+			for _, routeID := range routeIDs {
+				if _, err := database.RecordRouteVersion(tx, routeID, &userClaims.UserID, now); err != nil {
+					log.Printf("❌ [BIN-MERGE] %v", err)
+					utils.RespondError(w, http.StatusInternalServerError, "Failed to merge bins")
+					return
+				}
+			}
+			counts["route_bins"] = int64(len(routeIDs))
+		}
+
+		// The survivor keeps the newest check (with its fill level) and move of the two
+		var merged models.Bin
+		err = tx.GetContext(r.Context(), &merged, `
+			UPDATE bins t SET
+				last_checked = GREATEST(t.last_checked, s.last_checked),
+				last_checked_at = GREATEST(t.last_checked_at, s.last_checked_at),
+				last_moved = GREATEST(t.last_moved, s.last_moved),
+				fill_percentage = CASE WHEN COALESCE(s.last_checked, 0) > COALESCE(t.last_checked, 0)
+				                       THEN s.fill_percentage ELSE t.fill_percentage END,
+				updated_at = $3
+			FROM bins s
+			WHERE t.id = $2 AND s.id = $1
+			RETURNING t.*
+		`, source.ID, target.ID, now)
+		if err != nil {
+			log.Printf("❌ [BIN-MERGE] Failed to update %s: %v", target.ID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to merge bins")
+			return
+		}
+
+		snapshot, err := json.Marshal(source)
+		if err != nil {
+			log.Printf("❌ [BIN-MERGE] Failed to snapshot %s: %v", source.ID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to merge bins")
+			return
+		}
+		reassigned, _ := json.Marshal(counts)
+
+		if _, err := tx.ExecContext(r.Context(), `DELETE FROM bins WHERE id = $1`, source.ID); err != nil {
+			log.Printf("❌ [BIN-MERGE] Failed to delete %s: %v", source.ID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to merge bins")
+			return
+		}
+
+		var record models.BinMerge
+		err = tx.GetContext(r.Context(), &record, `
+			INSERT INTO bin_merges (id, source_bin_id, source_bin_number, target_bin_id, target_bin_number,
+			                        source_snapshot, reassigned, merged_by_user_id, merged_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			RETURNING *
+		`, uuid.New().String(), source.ID, source.BinNumber, target.ID, target.BinNumber,
+			snapshot, reassigned, userClaims.UserID, now)
+		if err != nil {
+			log.Printf("❌ [BIN-MERGE] Failed to record merge of %s: %v", source.ID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to merge bins")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Printf("❌ [BIN-MERGE] Failed to commit merge of %s: %v", source.ID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to merge bins")
+			return
+		}
+		store.InvalidateBins(source.ID, target.ID)
+
+		wsHub.BroadcastToRole("admin", map[string]interface{}{
+			"type": "bin_deleted",
+			"data": map[string]interface{}{
+				"bin_id":             source.ID,
+				"merged_into_bin_id": target.ID,
+				"merge_id":           record.ID,
+			},
+		})
+		wsHub.BroadcastToRole("admin", map[string]interface{}{
+			"type": "bin_updated",
+			"data": merged.ToBinResponse(),
+		})
+		log.Printf("✅ [BIN-MERGE] %s merged bin #%d into #%d (%v)", userClaims.Email, source.BinNumber, target.BinNumber, counts)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    models.BinMergeResult{Merge: record, Bin: merged.ToBinResponse()},
+		})
+	}
+}
+
+// GetBinMerges returns the bin merge audit log, newest first
+// GET /api/manager/bins/merges?limit=100
+func GetBinMerges(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit := 100
+		if parsed, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && parsed > 0 && parsed <= 500 {
+			limit = parsed
+		}
+
+		merges := []models.BinMerge{}
+		err := db.SelectContext(r.Context(), &merges, `SELECT * FROM bin_merges ORDER BY merged_at DESC LIMIT $1`, limit)
+		if err != nil {
+			log.Printf("❌ [BIN-MERGE] Failed to fetch merges: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch bin merges")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    merges,
+		})
+	}
+}
@@ -1,23 +1,31 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
+	"ropacal-backend/internal/database"
 	"ropacal-backend/internal/helpers"
+	"ropacal-backend/internal/i18n"
 	"ropacal-backend/internal/middleware"
 	"ropacal-backend/internal/models"
 	"ropacal-backend/internal/services"
+	"ropacal-backend/internal/store"
 	"ropacal-backend/internal/websocket"
+	"ropacal-backend/pkg/utils"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 // stringPtrEqual compares two string pointers for equality
@@ -33,10 +41,10 @@ func stringPtrEqual(a, b *string) bool {
 }
 
 // calculateUrgency determines the urgency level based on status and scheduled date
-// Returns "resolved" for completed/cancelled moves, otherwise calculates time-based urgency
+// Returns "resolved" for completed/cancelled/rejected moves, otherwise calculates time-based urgency
 func calculateUrgency(status string, scheduledDate int64) string {
-	// If move is completed or cancelled, urgency is "resolved"
-	if status == "completed" || status == "cancelled" {
+	// If move is completed, cancelled or rejected, urgency is "resolved"
+	if models.IsFinalMoveStatus(status) {
 		return "resolved"
 	}
 
@@ -57,17 +65,18 @@ func calculateUrgency(status string, scheduledDate int64) string {
 
 // ScheduleBinMove creates a new bin move request (urgent or future scheduled)
 // POST /api/manager/bins/schedule-move
+// POST /api/move-requests (any signed-in user; non-admin requests wait for approval, see ApproveMoveRequest)
 func ScheduleBinMove(db *sqlx.DB, wsHub *websocket.Hub, fcmService *services.FCMService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req models.CreateBinMoveRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
 			return
 		}
 
 		// Validate required fields
 		if req.BinID == "" || req.MoveType == "" {
-			http.Error(w, "Missing required fields: bin_id, move_type", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Missing required fields: bin_id, move_type")
 			return
 		}
 
@@ -83,75 +92,101 @@ func ScheduleBinMove(db *sqlx.DB, wsHub *websocket.Hub, fcmService *services.FCM
 
 		// Validate move_type (accept both 'store' and 'pickup_only' for backward compatibility)
 		if req.MoveType != "store" && req.MoveType != "pickup_only" && req.MoveType != "relocation" {
-			http.Error(w, "Invalid move_type: must be 'store', 'pickup_only' (deprecated), or 'relocation'", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Invalid move_type: must be 'store', 'pickup_only' (deprecated), or 'relocation'")
 			return
 		}
 
 		// Validate pickup_only moves require disposal_action
 		if req.MoveType == "pickup_only" && req.DisposalAction == nil {
-			http.Error(w, "pickup_only moves require disposal_action ('retire' or 'store')", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "pickup_only moves require disposal_action ('retire' or 'store')")
 			return
 		}
 
-		// Build new address from separate fields or use provided address
-		var newAddress *string
+		// Build new address from separate fields or parse the provided address
+		var newAddress *models.Address
 		if req.NewStreet != nil && req.NewCity != nil && req.NewZip != nil {
-			// Build from separate fields (new format from frontend)
-			combined := fmt.Sprintf("%s, %s %s", *req.NewStreet, *req.NewCity, *req.NewZip)
-			newAddress = &combined
-		} else if req.NewAddress != nil {
-			// Use provided address (backward compatibility)
-			newAddress = req.NewAddress
+			// Separate fields (new format from frontend)
+			newAddress = &models.Address{Street: *req.NewStreet, City: *req.NewCity, Zip: *req.NewZip}
+		} else if req.NewAddress != nil && strings.TrimSpace(*req.NewAddress) != "" {
+			// Single address line (backward compatibility)
+			parsed := models.ParseAddress(*req.NewAddress)
+			newAddress = &parsed
+		}
+		if newAddress != nil {
+			if err := newAddress.Normalize(); err != nil {
+				utils.RespondError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			newAddress.Latitude, newAddress.Longitude = req.NewLatitude, req.NewLongitude
 		}
 
 		// Validate relocation moves require new location
 		if req.MoveType == "relocation" && (req.NewLatitude == nil || req.NewLongitude == nil || newAddress == nil) {
-			http.Error(w, "relocation moves require new_latitude, new_longitude, and address (either new_address or new_street+new_city+new_zip)", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "relocation moves require new_latitude, new_longitude, and address (either new_address or new_street+new_city+new_zip)")
 			return
 		}
 
+		timeWindow, err := models.NewTimeWindow(req.TimeWindowStart, req.TimeWindowEnd)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		var timeWindowStart, timeWindowEnd *string
+		if timeWindow != nil {
+			timeWindowStart, timeWindowEnd = &timeWindow.Start, &timeWindow.End
+		}
+
 		// Get requesting user ID from context (set by Auth middleware)
 		userClaims, ok := middleware.GetUserFromContext(r)
 		if !ok {
-			http.Error(w, "User not authenticated", http.StatusUnauthorized)
+			utils.RespondError(w, http.StatusUnauthorized, "User not authenticated")
 			return
 		}
 		userID := userClaims.UserID
 
+		// Moves requested by non-admins start in 'requested' and only take effect once an admin approves them
+		needsApproval := userClaims.Role != "admin"
+		if needsApproval && req.ShiftID != nil {
+			utils.RespondError(w, http.StatusForbidden, "Only admins can assign a move request to a shift")
+			return
+		}
+
 		// Fetch bin to get current location
 		var bin models.Bin
-		err := db.Get(&bin, `
+		err = db.GetContext(r.Context(), &bin, `
 			SELECT id, bin_number, current_street, city, zip, latitude, longitude, status
 			FROM bins
 			WHERE id = $1
 		`, req.BinID)
 		if err != nil {
 			if err == sql.ErrNoRows {
-				http.Error(w, "Bin not found", http.StatusNotFound)
+				utils.RespondError(w, http.StatusNotFound, "Bin not found")
 				return
 			}
 			log.Printf("Error fetching bin: %v", err)
-			http.Error(w, "Failed to fetch bin", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch bin")
 			return
 		}
 
 		// Validate bin has location
 		if bin.Latitude == nil || bin.Longitude == nil {
-			http.Error(w, "Bin must have latitude and longitude coordinates", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Bin must have latitude and longitude coordinates")
 			return
 		}
 
-		// Build original address
-		originalAddress := fmt.Sprintf("%s, %s %s", bin.CurrentStreet, bin.City, bin.Zip)
-
 		// Generate ID (now already declared above for urgency calculation)
 		id := uuid.New().String()
 
 		// Determine status and assignment type based on whether shift is assigned
-		status := "pending"
+		status := models.MoveStatusPending
 		var assignmentType *string // nil for unassigned moves
-		if req.ShiftID != nil {
-			status = "assigned" // Immediately assigned to shift
+		var approvalStatus *string // nil for moves created by admins
+		if needsApproval {
+			status = models.MoveStatusRequested
+			requested := models.MoveApprovalRequested
+			approvalStatus = &requested
+		} else if req.ShiftID != nil {
+			status = models.MoveStatusAssigned // Immediately assigned to shift
 			shiftType := "shift"
 			assignmentType = &shiftType
 		}
@@ -166,31 +201,40 @@ func ScheduleBinMove(db *sqlx.DB, wsHub *websocket.Hub, fcmService *services.FCM
 			Status:            status,
 			OriginalLatitude:  *bin.Latitude,
 			OriginalLongitude: *bin.Longitude,
-			OriginalAddress:   originalAddress,
 			NewLatitude:       req.NewLatitude,
 			NewLongitude:      req.NewLongitude,
-			NewAddress:        newAddress, // Built from separate fields or provided address
 			MoveType:          req.MoveType,
 			DisposalAction:    req.DisposalAction,
 			Reason:            req.Reason,
 			Notes:             req.Notes,
+			Instructions:      req.Instructions,
+			TimeWindowStart:   timeWindowStart,
+			TimeWindowEnd:     timeWindowEnd,
 			AssignmentType:    assignmentType, // Set based on whether shift is assigned
 			AssignedShiftID:   req.ShiftID,    // Assign to shift if provided
+			ApprovalStatus:    approvalStatus,
 			CreatedAt:         now,
 			UpdatedAt:         now,
 		}
+		moveRequest.SetOriginalAddress(models.Address{Street: bin.CurrentStreet, City: bin.City, Zip: bin.Zip})
+		if newAddress != nil {
+			moveRequest.SetNewAddress(*newAddress)
+		}
 
 		// Insert into database
-		_, err = db.Exec(`
+		_, err = db.ExecContext(r.Context(), `
 			INSERT INTO bin_move_requests (
 				id, bin_id, scheduled_date, urgency, requested_by, status,
 				original_latitude, original_longitude, original_address,
 				new_latitude, new_longitude, new_address,
 				move_type, disposal_action, reason, notes,
 				assignment_type, assigned_shift_id,
-				created_at, updated_at
+				created_at, updated_at, instructions,
+				time_window_start, time_window_end, approval_status,
+				original_street, original_city, original_zip, new_street, new_city, new_zip
 			)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24,
+				$25, $26, $27, $28, $29, $30)
 		`,
 			moveRequest.ID, moveRequest.BinID, moveRequest.ScheduledDate,
 			moveRequest.Urgency, moveRequest.RequestedBy, moveRequest.Status,
@@ -198,17 +242,20 @@ func ScheduleBinMove(db *sqlx.DB, wsHub *websocket.Hub, fcmService *services.FCM
 			moveRequest.NewLatitude, moveRequest.NewLongitude, moveRequest.NewAddress,
 			moveRequest.MoveType, moveRequest.DisposalAction, moveRequest.Reason, moveRequest.Notes,
 			moveRequest.AssignmentType, moveRequest.AssignedShiftID,
-			moveRequest.CreatedAt, moveRequest.UpdatedAt,
+			moveRequest.CreatedAt, moveRequest.UpdatedAt, moveRequest.Instructions,
+			moveRequest.TimeWindowStart, moveRequest.TimeWindowEnd, moveRequest.ApprovalStatus,
+			moveRequest.OriginalStreet, moveRequest.OriginalCity, moveRequest.OriginalZip,
+			moveRequest.NewStreet, moveRequest.NewCity, moveRequest.NewZip,
 		)
 		if err != nil {
 			log.Printf("Error creating bin move request: %v", err)
-			http.Error(w, "Failed to create move request", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to create move request")
 			return
 		}
 
 		// Log history: move request created
 		var userName string
-		err = db.Get(&userName, `SELECT name FROM users WHERE id = $1`, userID)
+		userName, err = store.New(db).Users.Name(userID)
 		if err != nil {
 			log.Printf("Warning: Failed to fetch user name for history: %v", err)
 			userName = "Unknown User"
@@ -219,19 +266,26 @@ func ScheduleBinMove(db *sqlx.DB, wsHub *websocket.Hub, fcmService *services.FCM
 			// Don't fail the request, just log the warning
 		}
 
-		// Update bin status to pending_move
-		_, err = db.Exec(`
-			UPDATE bins
-			SET status = 'pending_move', updated_at = $1
-			WHERE id = $2
-		`, now, req.BinID)
-		if err != nil {
-			log.Printf("Warning: Failed to update bin status: %v", err)
-			// Don't fail the request, just log the warning
+		// Update bin status to pending_move (requested moves wait until they are approved)
+		if !needsApproval {
+			_, err = db.ExecContext(r.Context(), `
+				UPDATE bins
+				SET status = 'pending_move', updated_at = $1
+				WHERE id = $2
+			`, now, req.BinID)
+			if err != nil {
+				log.Printf("Warning: Failed to update bin status: %v", err)
+				// Don't fail the request, just log the warning
+			}
+			store.InvalidateBins(req.BinID)
 		}
 
-		log.Printf("✅ Move request created successfully (status: pending)")
-		log.Printf("   To assign to a shift, use POST /api/manager/bins/move-requests/%s/assign-to-shift", id)
+		log.Printf("✅ Move request created successfully (status: %s)", status)
+		if needsApproval {
+			log.Printf("   Awaiting approval: PUT /api/manager/bins/move-requests/%s/approve or /reject", id)
+		} else {
+			log.Printf("   To assign to a shift, use POST /api/manager/bins/move-requests/%s/assign-to-shift", id)
+		}
 
 		// Return the created move request
 		response := moveRequest.ToBinMoveRequestResponse()
@@ -251,22 +305,13 @@ func ScheduleBinMove(db *sqlx.DB, wsHub *websocket.Hub, fcmService *services.FCM
 		response.City = bin.City
 		response.Zip = bin.Zip
 
-		// Parse new address into separate fields if available
-		if moveRequest.NewAddress != nil {
-			// Split "street, city zip" format
-			parts := strings.Split(*moveRequest.NewAddress, ", ")
-			if len(parts) >= 2 {
-				street := parts[0]
-				cityZip := strings.TrimSpace(parts[1])
-				cityZipParts := strings.Split(cityZip, " ")
-				if len(cityZipParts) >= 2 {
-					city := strings.Join(cityZipParts[:len(cityZipParts)-1], " ")
-					zip := cityZipParts[len(cityZipParts)-1]
-					response.NewStreet = &street
-					response.NewCity = &city
-					response.NewZip = &zip
-				}
-			}
+		// Let the dashboards know a move is waiting for a decision
+		if needsApproval {
+			response.RequestedByName = &userName
+			wsHub.BroadcastToRole("admin", map[string]interface{}{
+				"type": "move_request_approval_requested",
+				"data": response,
+			})
 		}
 
 		w.Header().Set("Content-Type", "application/json")
@@ -277,13 +322,26 @@ func ScheduleBinMove(db *sqlx.DB, wsHub *websocket.Hub, fcmService *services.FCM
 
 // AssignMoveToShift explicitly assigns a pending move request to a shift
 // POST /api/manager/bins/move-requests/:id/assign-to-shift
+// With ?preview=true nothing is saved; the response is the proposed route (see PreviewAssignMoveToShift)
 func AssignMoveToShift(db *sqlx.DB, wsHub *websocket.Hub, fcmService *services.FCMService) http.HandlerFunc {
+	return assignMoveToShiftHandler(db, wsHub, fcmService, false)
+}
+
+// PreviewAssignMoveToShift runs the full insertion and re-optimization for a move without saving it
+// POST /api/manager/bins/move-requests/:id/assign-to-shift/preview
+// Body: same as AssignMoveToShift. Returns the proposed sequence, added distance and ETA impact
+func PreviewAssignMoveToShift(db *sqlx.DB) http.HandlerFunc {
+	return assignMoveToShiftHandler(db, nil, nil, true)
+}
+
+func assignMoveToShiftHandler(db *sqlx.DB, wsHub *websocket.Hub, fcmService *services.FCMService, alwaysPreview bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		moveRequestID := chi.URLParam(r, "id")
+		preview := alwaysPreview || r.URL.Query().Get("preview") == "true"
 		log.Printf("🚚 [ASSIGN TO SHIFT] Starting assignment for move request: %s", moveRequestID)
 		if moveRequestID == "" {
 			log.Printf("❌ [ASSIGN TO SHIFT] Missing move request ID")
-			http.Error(w, "Missing move request ID", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Missing move request ID")
 			return
 		}
 
@@ -297,63 +355,44 @@ func AssignMoveToShift(db *sqlx.DB, wsHub *websocket.Hub, fcmService *services.F
 		log.Printf("🚚 [ASSIGN TO SHIFT] Request body - ShiftID: %v, InsertAfterBinID: %v, InsertPosition: %v",
 			req.ShiftID, req.InsertAfterBinID, req.InsertPosition)
 
-		// Fetch move request
-		var moveRequest models.BinMoveRequest
-		err := db.Get(&moveRequest, `
-			SELECT * FROM bin_move_requests WHERE id = $1
-		`, moveRequestID)
-		if err != nil {
-			if err == sql.ErrNoRows {
-				log.Printf("❌ [ASSIGN TO SHIFT] Move request not found: %s", moveRequestID)
-				http.Error(w, "Move request not found", http.StatusNotFound)
-				return
-			}
-			log.Printf("❌ [ASSIGN TO SHIFT] Error fetching move request: %v", err)
-			http.Error(w, "Failed to fetch move request", http.StatusInternalServerError)
-			return
-		}
-
-		log.Printf("🚚 [ASSIGN TO SHIFT] Found move request - Status: %s, BinID: %s", moveRequest.Status, moveRequest.BinID)
-
-		// Check if can be assigned (only pending, assigned, or in_progress moves can be reassigned)
-		if moveRequest.Status != "pending" && moveRequest.Status != "assigned" && moveRequest.Status != "in_progress" {
-			log.Printf("❌ [ASSIGN TO SHIFT] Cannot reassign move request with status: %s", moveRequest.Status)
-			http.Error(w, fmt.Sprintf("Cannot reassign move request with status: %s", moveRequest.Status), http.StatusBadRequest)
-			return
-		}
-
-		// Fetch bin details
-		var bin models.Bin
-		err = db.Get(&bin, "SELECT * FROM bins WHERE id = $1", moveRequest.BinID)
-		if err != nil {
-			log.Printf("❌ [ASSIGN TO SHIFT] Bin not found: %s", moveRequest.BinID)
-			http.Error(w, "Bin not found", http.StatusNotFound)
-			return
-		}
-
-		log.Printf("🚚 [ASSIGN TO SHIFT] Found bin - Number: %s", bin.BinNumber)
-
 		// Get manager ID and name from context
 		userClaims, ok := middleware.GetUserFromContext(r)
 		if !ok {
 			log.Printf("❌ [ASSIGN TO SHIFT] User not authenticated")
-			http.Error(w, "User not authenticated", http.StatusUnauthorized)
+			utils.RespondError(w, http.StatusUnauthorized, "User not authenticated")
 			return
 		}
 		managerID := userClaims.UserID
 
-		var managerName string
-		err = db.Get(&managerName, `SELECT name FROM users WHERE id = $1`, managerID)
+		managerName, err := store.New(db).Users.Name(managerID)
 		if err != nil {
 			log.Printf("Warning: Failed to fetch manager name: %v", err)
 			managerName = "Unknown Manager"
 		}
 
-		// Call the assignment logic
-		err = assignMoveToShift(db, wsHub, fcmService, moveRequest, bin, req.ShiftID, req.InsertAfterBinID, req.InsertPosition, managerID, managerName)
+		// Call the assignment logic (the move request is read and checked inside its transaction)
+		assignmentPreview, err := assignMoveToShift(r.Context(), db, fcmService, moveRequestID, req.ShiftID, req.InsertAfterBinID, req.InsertPosition, managerID, managerName, preview)
+		var txErr *txError
+		if errors.As(err, &txErr) {
+			respondTxError(w, err, "Failed to assign move to shift")
+			return
+		}
 		if err != nil {
 			log.Printf("❌ [ASSIGN TO SHIFT] Error assigning move to shift: %v", err)
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		if preview {
+			now := time.Now()
+			assignmentPreview.ServiceHours = loadServiceHours(db).CheckServiceHours(now,
+				time.Duration(assignmentPreview.ProposedFinishETA-now.Unix())*time.Second)
+			log.Printf("👀 [ASSIGN TO SHIFT] Previewed move request %s on shift %s (+%.2f km, +%ds)",
+				moveRequestID, assignmentPreview.ShiftID, assignmentPreview.AddedDistanceKm, assignmentPreview.ETADelaySeconds)
+			utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+				"success": true,
+				"data":    assignmentPreview,
+			})
 			return
 		}
 
@@ -365,10 +404,75 @@ func AssignMoveToShift(db *sqlx.DB, wsHub *websocket.Hub, fcmService *services.F
 	}
 }
 
+// removeMoveFromShift deletes a move request's incomplete stops from a shift and takes them off total_bins
+// Returns the number of stops removed
+func removeMoveFromShift(db sqlx.Ext, shiftID, moveRequestID string, now int64) (int64, error) {
+	removed, err := store.NewShiftStore(db).RemoveMoveStops(shiftID, moveRequestID)
+	if err != nil || removed == 0 {
+		return removed, err
+	}
+
+	_, err = db.Exec(`UPDATE shifts SET total_bins = GREATEST(total_bins - $1, 0), updated_at = $2 WHERE id = $3`,
+		removed, now, shiftID)
+	if err != nil {
+		return removed, fmt.Errorf("failed to update shift total_bins: %w", err)
+	}
+	return removed, nil
+}
+
 // assignMoveToShift inserts move at specified position in shift and re-optimizes route
-func assignMoveToShift(db *sqlx.DB, wsHub *websocket.Hub, fcmService *services.FCMService, moveRequest models.BinMoveRequest, bin models.Bin, shiftID *string, insertAfterBinID *string, insertPosition *string, managerID string, managerName string) error {
+// The move request is locked and its status checked inside the transaction, so a concurrent assign, cancel
+// or completion either waits for this one or is seen by it
+// In preview mode the same changes are made inside the transaction, the proposed route is read back and
+// the transaction is rolled back - no history, broadcasts or notifications
+// Otherwise the driver's WebSocket and push notifications are queued in the outbox with the change
+func assignMoveToShift(ctx context.Context, db *sqlx.DB, fcmService *services.FCMService, moveRequestID string, shiftID *string, insertAfterBinID *string, insertPosition *string, managerID string, managerName string, preview bool) (*MoveAssignmentPreview, error) {
+	var assignmentPreview *MoveAssignmentPreview
+	err := database.WithTx(ctx, db, func(tx *sqlx.Tx) error {
+		moveRequest, err := store.New(tx).MoveRequests.GetForUpdate(moveRequestID)
+		if err == sql.ErrNoRows {
+			log.Printf("❌ [ASSIGN TO SHIFT] Move request not found: %s", moveRequestID)
+			return txFail(http.StatusNotFound, "Move request not found")
+		}
+		if err != nil {
+			return err
+		}
+
+		log.Printf("🚚 [ASSIGN TO SHIFT] Found move request - Status: %s, BinID: %s", moveRequest.Status, moveRequest.BinID)
+
+		// Only pending, assigned or in-progress moves can be (re)assigned (see models.ValidateMoveStatusTransition)
+		if moveRequest.Status == models.MoveStatusRequested {
+			return txFailCode(http.StatusConflict, utils.CodeMoveAwaitingApproval, "Approve the move request before assigning it", nil)
+		}
+		if err := txMoveStatusTransition(moveRequest.Status, models.MoveStatusAssigned); err != nil {
+			log.Printf("❌ [ASSIGN TO SHIFT] Cannot reassign move request with status: %s", moveRequest.Status)
+			return err
+		}
+
+		var bin models.Bin
+		if err := tx.GetContext(ctx, &bin, "SELECT * FROM bins WHERE id = $1", moveRequest.BinID); err != nil {
+			if err == sql.ErrNoRows {
+				log.Printf("❌ [ASSIGN TO SHIFT] Bin not found: %s", moveRequest.BinID)
+				return txFail(http.StatusNotFound, "Bin not found")
+			}
+			return fmt.Errorf("failed to fetch bin: %w", err)
+		}
+
+		assignmentPreview, err = assignMoveToShiftTx(tx, fcmService, *moveRequest, bin, shiftID, insertAfterBinID, insertPosition, managerID, managerName, preview)
+		if err == nil && preview {
+			return database.ErrRollback
+		}
+		return err
+	})
+	return assignmentPreview, err
+}
+
+// assignMoveToShiftTx makes the assignment inside tx; history, outbox messages and the route change commit together
+func assignMoveToShiftTx(tx *sqlx.Tx, fcmService *services.FCMService, moveRequest models.BinMoveRequest, bin models.Bin, shiftID *string, insertAfterBinID *string, insertPosition *string, managerID string, managerName string, preview bool) (*MoveAssignmentPreview, error) {
 	log.Printf("🚚 ASSIGN MOVE: Assigning move request for bin #%d to shift", bin.BinNumber)
 
+	stores := store.New(tx)
+
 	// Store previous assignment info for history logging
 	previousAssignedShiftID := moveRequest.AssignedShiftID
 	var previousAssignedUserID *string
@@ -377,8 +481,7 @@ func assignMoveToShift(db *sqlx.DB, wsHub *websocket.Hub, fcmService *services.F
 
 	if moveRequest.AssignedUserID != nil {
 		previousAssignedUserID = moveRequest.AssignedUserID
-		var prevUserName string
-		if err := db.Get(&prevUserName, `SELECT name FROM users WHERE id = $1`, *moveRequest.AssignedUserID); err == nil {
+		if prevUserName, err := stores.Users.Name(*moveRequest.AssignedUserID); err == nil {
 			previousAssignedUserName = &prevUserName
 		}
 	}
@@ -393,17 +496,17 @@ func assignMoveToShift(db *sqlx.DB, wsHub *websocket.Hub, fcmService *services.F
 	if shiftID != nil && *shiftID != "" {
 		// Use specific shift ID
 		log.Printf("   Using specified shift ID: %s", *shiftID)
-		err = db.Get(&activeShift, "SELECT * FROM shifts WHERE id = $1", *shiftID)
+		err = tx.Get(&activeShift, "SELECT * FROM shifts WHERE id = $1", *shiftID)
 		if err != nil {
 			if err == sql.ErrNoRows {
-				return fmt.Errorf("shift not found: %s", *shiftID)
+				return nil, fmt.Errorf("shift not found: %s", *shiftID)
 			}
-			return fmt.Errorf("failed to fetch shift: %w", err)
+			return nil, fmt.Errorf("failed to fetch shift: %w", err)
 		}
 	} else {
 		// Auto-find active/paused shift
 		log.Printf("   Auto-finding active shift...")
-		err = db.Get(&activeShift, `
+		err = tx.Get(&activeShift, `
 			SELECT * FROM shifts
 			WHERE status IN ('active', 'paused')
 			ORDER BY
@@ -416,27 +519,35 @@ func assignMoveToShift(db *sqlx.DB, wsHub *websocket.Hub, fcmService *services.F
 		`)
 		if err != nil {
 			if err == sql.ErrNoRows {
-				return fmt.Errorf("no active shift found - please specify shift_id")
+				return nil, fmt.Errorf("no active shift found - please specify shift_id")
 			}
-			return fmt.Errorf("failed to find active shift: %w", err)
+			return nil, fmt.Errorf("failed to find active shift: %w", err)
 		}
 	}
 
 	log.Printf("   Found active shift: %s (driver: %s, status: %s)", activeShift.ID, activeShift.DriverID, activeShift.Status)
 
-	// 2. Determine current position in route (find first uncompleted bin)
-	var shiftBins []models.ShiftBinWithDetails
-	err = db.Select(&shiftBins, `
-		SELECT rb.id, rb.shift_id, rb.bin_id, rb.sequence_order, rb.is_completed,
-		       b.bin_number, b.current_street, b.city, b.zip, COALESCE(b.fill_percentage, 0) as fill_percentage,
-		       b.latitude, b.longitude
-		FROM shift_bins rb
-		JOIN bins b ON rb.bin_id = b.id
-		WHERE rb.shift_id = $1
-		ORDER BY rb.sequence_order ASC
-	`, activeShift.ID)
+	// Lock the shift so concurrent insertions compute positions from the committed route, not a stale snapshot
+	if err := stores.Shifts.Lock(activeShift.ID); err != nil {
+		return nil, err
+	}
+	if err := tx.Get(&activeShift, "SELECT * FROM shifts WHERE id = $1", activeShift.ID); err != nil {
+		return nil, fmt.Errorf("failed to fetch shift: %w", err)
+	}
+
+	// The bin can't be on another open shift (its own stops from an earlier assignment of this move don't count)
+	reservations, err := database.FindBinReservations(tx, []string{moveRequest.BinID}, activeShift.ID, &moveRequest.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(reservations) > 0 {
+		return nil, binReservedError(reservations)
+	}
+
+	// 2. Determine current position in route (find first uncompleted stop)
+	shiftBins, err := stores.Shifts.Stops(activeShift.ID)
 	if err != nil {
-		return fmt.Errorf("failed to fetch shift bins: %w", err)
+		return nil, fmt.Errorf("failed to fetch shift bins: %w", err)
 	}
 
 	// Determine where to insert the bin based on shift status and parameters
@@ -459,7 +570,7 @@ func assignMoveToShift(db *sqlx.DB, wsHub *websocket.Hub, fcmService *services.F
 		}
 
 		if targetIndex == -1 {
-			return fmt.Errorf("specified bin not found in shift route: %s", *insertAfterBinID)
+			return nil, fmt.Errorf("specified bin not found in shift route: %s", *insertAfterBinID)
 		}
 
 		insertSequenceOrder = shiftBins[targetIndex].SequenceOrder + 1
@@ -513,121 +624,113 @@ func assignMoveToShift(db *sqlx.DB, wsHub *websocket.Hub, fcmService *services.F
 		log.Printf("   Store move - will add pickup waypoint only")
 	}
 
-	tx, err := db.Beginx()
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+	// Snapshot the current route to compare against in preview mode
+	var currentStops []models.ShiftBinWithDetails
+	if preview {
+		currentStops, err = loadPreviewStops(tx, activeShift.ID)
+		if err != nil {
+			return nil, err
+		}
 	}
-	defer tx.Rollback()
 
-	// Shift all bins after insert position up by binsAdded
-	_, err = tx.Exec(`
-		UPDATE shift_bins
-		SET sequence_order = sequence_order + $1
-		WHERE shift_id = $2 AND sequence_order >= $3
-	`, binsAdded, activeShift.ID, insertSequenceOrder)
-	if err != nil {
-		return fmt.Errorf("failed to shift sequence order: %w", err)
+	// Shift all stops after insert position up by binsAdded
+	if err := stores.Shifts.ShiftSequence(activeShift.ID, insertSequenceOrder, binsAdded); err != nil {
+		return nil, err
 	}
 
-	// Insert pickup waypoint for move request
+	// Insert pickup waypoint for move request (at the bin, carrying the destination for relocations)
 	pickupSeq := insertSequenceOrder
-	log.Printf("   🔍 DEBUG: About to insert PICKUP - insertSequenceOrder=%d, pickupSeq=%d", insertSequenceOrder, pickupSeq)
-	log.Printf("   🔍 DEBUG: INSERT params: shift_id=%s, bin_id=%d, sequence=%d, stop_type=pickup, move_request_id=%s",
-		activeShift.ID, moveRequest.BinID, pickupSeq, moveRequest.ID)
-
-	_, err = tx.Exec(`
-		INSERT INTO shift_bins (shift_id, bin_id, sequence_order, is_completed, created_at, stop_type, move_request_id)
-		VALUES ($1, $2, $3, 0, $4, 'pickup', $5)
-	`, activeShift.ID, moveRequest.BinID, pickupSeq, now, moveRequest.ID)
-	if err != nil {
-		return fmt.Errorf("failed to insert pickup waypoint: %w", err)
+	moveType := moveRequest.MoveType
+	binNumber := bin.BinNumber
+	pickup := &models.RouteTask{
+		ShiftID:              activeShift.ID,
+		SequenceOrder:        pickupSeq,
+		TaskType:             models.TaskTypePickup,
+		Latitude:             moveRequest.OriginalLatitude,
+		Longitude:            moveRequest.OriginalLongitude,
+		Address:              &moveRequest.OriginalAddress,
+		BinID:                &moveRequest.BinID,
+		BinNumber:            &binNumber,
+		MoveRequestID:        &moveRequest.ID,
+		DestinationLatitude:  moveRequest.NewLatitude,
+		DestinationLongitude: moveRequest.NewLongitude,
+		DestinationAddress:   moveRequest.NewAddress,
+		MoveType:             &moveType,
+		RouteID:              activeShift.RouteID,
+		CreatedAt:            now,
+	}
+	if err := stores.Shifts.InsertStop(pickup); err != nil {
+		if database.IsBinReservationViolation(err) {
+			return nil, binReservationRaceError()
+		}
+		return nil, fmt.Errorf("failed to insert pickup waypoint: %w", err)
 	}
-	log.Printf("   ✅ Inserted pickup waypoint at sequence %d", pickupSeq)
+	log.Printf("   ✅ Inserted pickup waypoint at sequence %d (shift %s, move request %s)", pickupSeq, activeShift.ID, moveRequest.ID)
 
 	// For relocation moves, also insert dropoff waypoint immediately after pickup
 	if moveRequest.MoveType == "relocation" {
 		dropoffSeq := insertSequenceOrder + 1
-		log.Printf("   🔍 DEBUG: About to insert DROPOFF - insertSequenceOrder=%d, dropoffSeq=%d", insertSequenceOrder, dropoffSeq)
-		log.Printf("   🔍 DEBUG: INSERT params: shift_id=%s, bin_id=%d, sequence=%d, stop_type=dropoff, move_request_id=%s",
-			activeShift.ID, moveRequest.BinID, dropoffSeq, moveRequest.ID)
-
-		_, err = tx.Exec(`
-			INSERT INTO shift_bins (shift_id, bin_id, sequence_order, is_completed, created_at, stop_type, move_request_id)
-			VALUES ($1, $2, $3, 0, $4, 'dropoff', $5)
-		`, activeShift.ID, moveRequest.BinID, dropoffSeq, now, moveRequest.ID)
-		if err != nil {
-			return fmt.Errorf("failed to insert dropoff waypoint: %w", err)
+		dropoff := *pickup
+		dropoff.ID = ""
+		dropoff.SequenceOrder = dropoffSeq
+		dropoff.TaskType = models.TaskTypeDropoff
+		if moveRequest.NewLatitude != nil && moveRequest.NewLongitude != nil {
+			dropoff.Latitude = *moveRequest.NewLatitude
+			dropoff.Longitude = *moveRequest.NewLongitude
 		}
-		log.Printf("   ✅ Inserted dropoff waypoint at sequence %d", dropoffSeq)
-
-		// Verify both waypoints have different sequence_order values
-		var actualPickupSeq, actualDropoffSeq int
-		err = tx.Get(&actualPickupSeq, `
-			SELECT sequence_order FROM shift_bins
-			WHERE shift_id = $1 AND move_request_id = $2 AND stop_type = 'pickup'
-		`, activeShift.ID, moveRequest.ID)
-		if err != nil {
-			log.Printf("   ⚠️  Warning: Could not verify pickup sequence_order: %v", err)
-		}
-
-		err = tx.Get(&actualDropoffSeq, `
-			SELECT sequence_order FROM shift_bins
-			WHERE shift_id = $1 AND move_request_id = $2 AND stop_type = 'dropoff'
-		`, activeShift.ID, moveRequest.ID)
-		if err != nil {
-			log.Printf("   ⚠️  Warning: Could not verify dropoff sequence_order: %v", err)
-		}
-
-		log.Printf("   🔍 VERIFICATION: Expected pickup=%d, actual=%d | Expected dropoff=%d, actual=%d",
-			pickupSeq, actualPickupSeq, dropoffSeq, actualDropoffSeq)
-
-		if actualPickupSeq == actualDropoffSeq {
-			log.Printf("   ❌ ERROR: Pickup and dropoff have SAME sequence_order: %d", actualPickupSeq)
-			return fmt.Errorf("duplicate sequence_order detected: both pickup and dropoff at %d", actualPickupSeq)
+		if moveRequest.NewAddress != nil {
+			dropoff.Address = moveRequest.NewAddress
 		}
-		if actualPickupSeq >= actualDropoffSeq {
-			log.Printf("   ❌ ERROR: Pickup sequence (%d) >= Dropoff sequence (%d)", actualPickupSeq, actualDropoffSeq)
-			return fmt.Errorf("invalid sequence order: pickup at %d, dropoff at %d", actualPickupSeq, actualDropoffSeq)
+		if err := stores.Shifts.InsertStop(&dropoff); err != nil {
+			return nil, fmt.Errorf("failed to insert dropoff waypoint: %w", err)
 		}
-		log.Printf("   ✅ VALIDATION PASSED: Pickup (%d) < Dropoff (%d)", actualPickupSeq, actualDropoffSeq)
+		log.Printf("   ✅ Inserted dropoff waypoint at sequence %d", dropoffSeq)
 	}
 
 	// Update move request to assign it to this shift (clear any previous user assignment)
 	// If shift is already active, set status to 'in_progress', otherwise 'assigned'
-	moveRequestStatus := "assigned"
+	moveRequestStatus := models.MoveStatusAssigned
 	if isActiveShift {
-		moveRequestStatus = "in_progress"
+		moveRequestStatus = models.MoveStatusInProgress
+	}
+	if err := txMoveStatusTransition(moveRequest.Status, moveRequestStatus); err != nil {
+		return nil, err
+	}
+	// Guarded on the status read above, so a concurrent assign or cancel can't be overwritten
+	err = stores.MoveRequests.AssignToShift(moveRequest.ID, activeShift.ID, moveRequest.Status, moveRequestStatus, now)
+	if errors.Is(err, store.ErrMoveRequestChanged) {
+		return nil, txFailCode(http.StatusConflict, utils.CodeStaleUpdate,
+			"The move request changed while it was being assigned. Please refresh and try again.", nil)
 	}
-	_, err = tx.Exec(`
-		UPDATE bin_move_requests
-		SET assignment_type = 'shift', assigned_shift_id = $1, assigned_user_id = NULL, status = $2, updated_at = $3
-		WHERE id = $4
-	`, activeShift.ID, moveRequestStatus, now, moveRequest.ID)
 	if err != nil {
-		return fmt.Errorf("failed to update move request: %w", err)
+		return nil, err
 	}
 
 	// Get driver info from shift
 	var driverName string
-	err = db.Get(&driverName, `SELECT name FROM users WHERE id = $1`, activeShift.DriverID)
+	driverName, err = stores.Users.Name(activeShift.DriverID)
 	if err != nil {
 		log.Printf("Warning: Failed to fetch driver name for history: %v", err)
 		driverName = "Unknown Driver"
 	}
 
-	// Log history: check if reassignment or new assignment
+	// Log history: check if reassignment or new assignment (nothing is saved in preview mode)
 	newAssignmentType := "shift"
-	if previousAssignedShiftID == nil && previousAssignedUserID == nil {
+	if !preview && previousAssignedShiftID == nil && previousAssignedUserID == nil {
 		// New assignment
-		helpers.LogMoveRequestAssigned(db, moveRequest.ID, managerID, managerName,
-			newAssignmentType, &activeShift.DriverID, &driverName, &activeShift.ID)
-	} else {
+		if err := helpers.LogMoveRequestAssigned(tx, moveRequest.ID, managerID, managerName,
+			newAssignmentType, &activeShift.DriverID, &driverName, &activeShift.ID); err != nil {
+			return nil, err
+		}
+	} else if !preview {
 		// Reassignment
-		helpers.LogMoveRequestReassigned(db, moveRequest.ID, managerID, managerName,
+		if err := helpers.LogMoveRequestReassigned(tx, moveRequest.ID, managerID, managerName,
 			previousAssignmentType, &newAssignmentType,
 			previousAssignedUserID, &activeShift.DriverID,
 			previousAssignedUserName, &driverName,
-			previousAssignedShiftID, &activeShift.ID)
+			previousAssignedShiftID, &activeShift.ID); err != nil {
+			return nil, err
+		}
 	}
 
 	// Update shift total_bins count
@@ -637,36 +740,42 @@ func assignMoveToShift(db *sqlx.DB, wsHub *websocket.Hub, fcmService *services.F
 		WHERE id = $3
 	`, binsAdded, now, activeShift.ID)
 	if err != nil {
-		return fmt.Errorf("failed to update shift: %w", err)
+		return nil, fmt.Errorf("failed to update shift: %w", err)
 	}
 
 	// 4. Re-optimize remaining route (bins after the inserted move) - only for active shifts
 	if isActiveShift {
-		var remainingBins []models.ShiftBinWithDetails
-		err = tx.Select(&remainingBins, `
-			SELECT rb.id, rb.shift_id, rb.bin_id, rb.sequence_order,
-			       b.bin_number, b.current_street, b.city, b.zip, COALESCE(b.fill_percentage, 0) as fill_percentage,
-			       b.latitude, b.longitude
-			FROM shift_bins rb
-			JOIN bins b ON rb.bin_id = b.id
-			WHERE rb.shift_id = $1 AND rb.sequence_order > $2 AND rb.is_completed = 0
-			  AND rb.move_request_id IS NULL
-			ORDER BY rb.sequence_order ASC
-		`, activeShift.ID, insertSequenceOrder + binsAdded - 1)
+		stops, err := stores.Shifts.Stops(activeShift.ID)
 		if err != nil {
-			return fmt.Errorf("failed to fetch remaining bins: %w", err)
+			return nil, fmt.Errorf("failed to fetch remaining bins: %w", err)
+		}
+
+		// Only uncompleted collections after the move are reordered; other moves, placements and warehouse stops keep their slot
+		var remainingBins []models.ShiftBinWithDetails
+		for _, stop := range stops {
+			if stop.SequenceOrder > insertSequenceOrder+binsAdded-1 && stop.IsCompleted == 0 &&
+				stop.StopType == string(models.TaskTypeCollection) && stop.TaskID != nil {
+				remainingBins = append(remainingBins, stop)
+			}
 		}
 
 		if len(remainingBins) > 0 {
-			// Convert to BinWithPriority for optimizer
+			// Convert to BinWithPriority for optimizer (keyed by task ID, weighted by no-go zone risk)
+			remainingBinIDs := make([]string, len(remainingBins))
+			for i, sb := range remainingBins {
+				remainingBinIDs[i] = sb.BinID
+			}
+			risks := stopRisks(tx, remainingBinIDs)
 			binsToOptimize := make([]services.BinWithPriority, len(remainingBins))
 			for i, sb := range remainingBins {
 				binsToOptimize[i] = services.BinWithPriority{
-					ID:             sb.BinID,
+					ID:             *sb.TaskID,
 					Latitude:       sb.Latitude,
 					Longitude:      sb.Longitude,
 					FillPercentage: sb.FillPercentage,
 					CurrentStreet:  sb.CurrentStreet,
+					TimeWindow:     sb.TimeWindow(),
+					RiskWeight:     risks[sb.BinID].Weight,
 				}
 			}
 
@@ -693,16 +802,10 @@ func assignMoveToShift(db *sqlx.DB, wsHub *websocket.Hub, fcmService *services.F
 
 			log.Printf("   Re-optimizing %d remaining bins after inserted move", len(optimizedBins))
 
-			// Update sequence order for optimized bins
+			// Reuse the slots the collections already occupied, in optimized order
 			for i, optimizedBin := range optimizedBins {
-				newSequence := insertSequenceOrder + binsAdded + i // After both move waypoints
-				_, err = tx.Exec(`
-					UPDATE shift_bins
-					SET sequence_order = $1
-					WHERE shift_id = $2 AND bin_id = $3
-				`, newSequence, activeShift.ID, optimizedBin.ID)
-				if err != nil {
-					return fmt.Errorf("failed to update sequence order: %w", err)
+				if err := stores.Shifts.SetSequence(optimizedBin.ID, remainingBins[i].SequenceOrder); err != nil {
+					return nil, err
 				}
 			}
 
@@ -710,24 +813,40 @@ func assignMoveToShift(db *sqlx.DB, wsHub *websocket.Hub, fcmService *services.F
 		}
 	}
 
-	// Commit transaction
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	if preview {
+		// Read the proposed route back before assignMoveToShift rolls the transaction back
+		return buildMoveAssignmentPreview(tx, activeShift, currentStops, insertSequenceOrder, now)
+	}
+
+	// Close any gaps or duplicates left by earlier writers before committing
+	if reindexed, err := stores.Shifts.Reindex(activeShift.ID); err != nil {
+		return nil, err
+	} else if reindexed > 0 {
+		log.Printf("   🔧 Reindexed %d stops to keep sequence_order gapless", reindexed)
 	}
 
-	// 5. Get updated shift and bins for broadcast
+	// The rest of the route is re-optimized from the driver's position once the change settles
+	if isActiveShift {
+		if _, err := helpers.RequestRouteReoptimization(tx, activeShift.ID, models.ReoptimizeReasonMoveInserted); err != nil {
+			return nil, err
+		}
+	}
+
+	// 5. Queue the driver's notifications in the same transaction (delivered by the notification dispatcher)
 	var updatedShift models.Shift
-	db.Get(&updatedShift, `SELECT * FROM shifts WHERE id = $1`, activeShift.ID)
+	if err := tx.Get(&updatedShift, `SELECT * FROM shifts WHERE id = $1`, activeShift.ID); err != nil {
+		return nil, fmt.Errorf("failed to fetch updated shift: %w", err)
+	}
 
-	updatedBins, err := getRouteBinsWithDetails(db, activeShift.ID)
+	updatedBins, err := stores.Shifts.Stops(activeShift.ID)
 	if err != nil {
-		log.Printf("⚠️  Failed to fetch updated bins: %v", err)
-		updatedBins = []models.ShiftBinWithDetails{}
+		return nil, fmt.Errorf("failed to fetch updated bins: %w", err)
 	}
 
-	// 6. Send WebSocket update to driver
-	log.Printf("📡 Broadcasting urgent move update to driver %s", activeShift.DriverID)
-	wsHub.BroadcastToUser(activeShift.DriverID, map[string]interface{}{
+	// 6. WebSocket update to driver
+	log.Printf("📡 Queueing urgent move update for driver %s", activeShift.DriverID)
+	driverLocale := database.UserLocale(tx, activeShift.DriverID)
+	_, err = helpers.EnqueueUserMessage(tx, activeShift.DriverID, map[string]interface{}{
 		"type": "urgent_move_inserted",
 		"data": map[string]interface{}{
 			"shift": map[string]interface{}{
@@ -743,66 +862,52 @@ func assignMoveToShift(db *sqlx.DB, wsHub *websocket.Hub, fcmService *services.F
 				"city":           bin.City,
 				"zip":            bin.Zip,
 			},
-			"message": fmt.Sprintf("Urgent: Bin #%d added as your next stop", bin.BinNumber),
+			"message": i18n.T(driverLocale, "Urgent: Bin #%d added as your next stop", bin.BinNumber),
 		},
 	})
+	if err != nil {
+		return nil, err
+	}
 
-	// 6b. Send move_request_assigned WebSocket notification (for mobile app)
-	log.Printf("📡 Broadcasting move_request_assigned to driver %s", activeShift.DriverID)
-
-	// Fetch the updated move request with bin_number from database
+	// 6b. move_request_assigned WebSocket notification (for mobile app), with the updated move request and bin_number
 	var moveRequestWithBin struct {
 		models.BinMoveRequest
 		BinNumber int `db:"bin_number" json:"bin_number"`
 	}
-	err = db.Get(&moveRequestWithBin, `
+	err = tx.Get(&moveRequestWithBin, `
 		SELECT mr.*, b.bin_number
 		FROM bin_move_requests mr
 		JOIN bins b ON mr.bin_id = b.id
 		WHERE mr.id = $1
 	`, moveRequest.ID)
-	if err == nil {
-		wsHub.BroadcastToUser(activeShift.DriverID, map[string]interface{}{
-			"type": "move_request_assigned",
-			"data": map[string]interface{}{
-				"move_request": moveRequestWithBin,
-				"updated_route": map[string]interface{}{
-					"shift_id": activeShift.ID,
-					"bins":     updatedBins,
-				},
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch updated move request: %w", err)
+	}
+	_, err = helpers.EnqueueUserMessage(tx, activeShift.DriverID, map[string]interface{}{
+		"type": "move_request_assigned",
+		"data": map[string]interface{}{
+			"move_request": moveRequestWithBin,
+			"updated_route": map[string]interface{}{
+				"shift_id": activeShift.ID,
+				"bins":     updatedBins,
 			},
-		})
-		log.Printf("✅ move_request_assigned notification sent")
-	} else {
-		log.Printf("⚠️  Failed to fetch updated move request for WebSocket: %v", err)
+		},
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// 7. Send push notification to driver
+	// 7. Push notification to driver
 	if fcmService != nil {
-		var fcmToken models.FCMToken
-		tokenErr := db.Get(&fcmToken, `
-			SELECT * FROM fcm_tokens
-			WHERE user_id = $1
-			ORDER BY created_at DESC
-			LIMIT 1
-		`, activeShift.DriverID)
-
-		if tokenErr == nil {
-			err := fcmService.SendShiftUpdateNotification(
-				fcmToken.Token,
-				activeShift.ID,
-				fmt.Sprintf("urgent_move_bin_%d", bin.BinNumber),
-			)
-			if err != nil {
-				log.Printf("⚠️  Failed to send FCM notification: %v", err)
-			} else {
-				log.Printf("✅ Push notification sent successfully")
-			}
+		push := services.ShiftUpdateOutboxPush(driverLocale, activeShift.ID, fmt.Sprintf("urgent_move_bin_%d", bin.BinNumber))
+		push.Urgent = true // The driver is on shift; don't hold it for service hours
+		if _, err := helpers.EnqueuePush(tx, activeShift.DriverID, push); err != nil {
+			return nil, err
 		}
 	}
 
 	log.Printf("✅ Urgent move handled successfully")
-	return nil
+	return nil, nil
 }
 
 // GetBinMoveRequest returns a single move request by ID
@@ -811,22 +916,22 @@ func GetBinMoveRequest(db *sqlx.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := chi.URLParam(r, "id")
 		if id == "" {
-			http.Error(w, "Missing move request ID", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Missing move request ID")
 			return
 		}
 
 		// Fetch move request
 		var moveRequest models.BinMoveRequest
-		err := db.Get(&moveRequest, `
+		err := db.GetContext(r.Context(), &moveRequest, `
 			SELECT * FROM bin_move_requests WHERE id = $1
 		`, id)
 		if err != nil {
 			if err == sql.ErrNoRows {
-				http.Error(w, "Move request not found", http.StatusNotFound)
+				utils.RespondError(w, http.StatusNotFound, "Move request not found")
 				return
 			}
 			log.Printf("Error fetching move request: %v", err)
-			http.Error(w, "Failed to fetch move request", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch move request")
 			return
 		}
 
@@ -837,11 +942,7 @@ func GetBinMoveRequest(db *sqlx.DB) http.HandlerFunc {
 	response.Urgency = calculateUrgency(moveRequest.Status, moveRequest.ScheduledDate)
 
 		// Fetch associated bin details
-		var bin models.Bin
-		err = db.Get(&bin, `
-			SELECT id, bin_number, current_street, city, zip, latitude, longitude, status
-			FROM bins WHERE id = $1
-		`, moveRequest.BinID)
+		bin, err := store.New(db).Bins.Summary(moveRequest.BinID)
 		if err == nil {
 			binResp := bin.ToBinResponse()
 			response.Bin = &binResp
@@ -852,29 +953,17 @@ func GetBinMoveRequest(db *sqlx.DB) http.HandlerFunc {
 			response.Zip = bin.Zip
 		}
 
-		// Parse new address into separate fields if available
-		if moveRequest.NewAddress != nil {
-			// Split "street, city zip" format
-			parts := strings.Split(*moveRequest.NewAddress, ", ")
-			if len(parts) >= 2 {
-				street := parts[0]
-				cityZip := strings.TrimSpace(parts[1])
-				cityZipParts := strings.Split(cityZip, " ")
-				if len(cityZipParts) >= 2 {
-					city := strings.Join(cityZipParts[:len(cityZipParts)-1], " ")
-					zip := cityZipParts[len(cityZipParts)-1]
-					response.NewStreet = &street
-					response.NewCity = &city
-					response.NewZip = &zip
-				}
-			}
+		attachments, err := store.NewMoveRequestStore(db).Attachments(moveRequest.ID)
+		if err != nil {
+			log.Printf("Warning: Failed to fetch move request attachments: %v", err)
 		}
+		response.Attachments = attachments[moveRequest.ID]
 
 		// Fetch assigned driver name if assigned to a shift
 		if moveRequest.AssignedShiftID != nil {
 			var driverName string
-			err = db.Get(&driverName, `
-				SELECT u.full_name FROM shifts s
+			err = db.GetContext(r.Context(), &driverName, `
+				SELECT u.name FROM shifts s
 				JOIN users u ON s.driver_id = u.id
 				WHERE s.id = $1
 			`, *moveRequest.AssignedShiftID)
@@ -888,27 +977,54 @@ func GetBinMoveRequest(db *sqlx.DB) http.HandlerFunc {
 	}
 }
 
+// moveRequestResponseFields are the fields ?fields= can select from move request payloads
+var moveRequestResponseFields = utils.FieldsOf(models.BinMoveRequestResponse{})
+
 // GetBinMoveRequests returns all bin move requests with optional filtering
 // GET /api/manager/bins/move-requests?status=pending&urgency=urgent
+// Also: assigned=true|false, move_type, approval_status=requested|approved|rejected, sort=scheduled_date|created_at, limit, offset,
+// and view_id (a saved view whose filters and sort apply unless overridden)
+// fields= trims each move request to the listed fields (e.g. id,bin_number,status)
+// Managers assigned to areas get their areas' move requests unless all_areas=true
 func GetBinMoveRequests(db *sqlx.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		log.Printf("📥 REQUEST: GET /api/manager/bins/move-requests")
 
+		fields, err := utils.ParseFields(r, moveRequestResponseFields)
+		if err != nil {
+			utils.RespondFieldsError(w, err, moveRequestResponseFields)
+			return
+		}
+
+		if status, msg := applySavedView(db, r, models.SavedViewEntityMoveRequests); status != 0 {
+			utils.RespondError(w, status, msg)
+			return
+		}
+
 		// Parse query params
 		status := r.URL.Query().Get("status")
 		urgency := r.URL.Query().Get("urgency")
 		assigned := r.URL.Query().Get("assigned")
+		moveType := r.URL.Query().Get("move_type")
+		approvalStatus := r.URL.Query().Get("approval_status")
+		sortBy := r.URL.Query().Get("sort")
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
 
-		log.Printf("   Query params: status=%s, urgency=%s, assigned=%s", status, urgency, assigned)
+		log.Printf("   Query params: status=%s, urgency=%s, assigned=%s, move_type=%s, approval_status=%s, sort=%s", status, urgency, assigned, moveType, approvalStatus, sortBy)
 
 		// Build query
 		query := `
 			SELECT bmr.id, bmr.bin_id, bmr.scheduled_date, bmr.urgency, bmr.requested_by,
 			       bmr.status, bmr.original_latitude, bmr.original_longitude, bmr.original_address,
+			       bmr.original_street, bmr.original_city, bmr.original_zip,
 			       bmr.new_latitude, bmr.new_longitude, bmr.new_address,
+			       bmr.new_street, bmr.new_city, bmr.new_zip,
 			       bmr.move_type, bmr.disposal_action, bmr.reason, bmr.notes,
 		       bmr.assignment_type, bmr.assigned_shift_id, bmr.assigned_user_id,
-		       bmr.completed_at, bmr.created_at, bmr.updated_at
+		       bmr.completed_at, bmr.assign_sla_breached_at, bmr.complete_sla_breached_at,
+		       bmr.approval_status, bmr.approval_decided_by, bmr.approval_decided_at, bmr.approval_reason,
+		       bmr.created_at, bmr.updated_at
 			FROM bin_move_requests bmr
 			WHERE 1=1
 		`
@@ -927,14 +1043,61 @@ func GetBinMoveRequests(db *sqlx.DB) http.HandlerFunc {
 			argCount++
 		}
 
-		query += " ORDER BY bmr.scheduled_date ASC, bmr.created_at DESC"
+		if moveType != "" {
+			query += fmt.Sprintf(" AND bmr.move_type = $%d", argCount)
+			args = append(args, moveType)
+			argCount++
+		}
+
+		if approvalStatus != "" {
+			query += fmt.Sprintf(" AND bmr.approval_status = $%d", argCount)
+			args = append(args, approvalStatus)
+			argCount++
+		}
+
+		switch assigned {
+		case "true":
+			query += " AND (bmr.assigned_shift_id IS NOT NULL OR bmr.assigned_user_id IS NOT NULL)"
+		case "false":
+			query += " AND bmr.assigned_shift_id IS NULL AND bmr.assigned_user_id IS NULL"
+		}
+
+		// Managers assigned to areas only see moves of their areas' bins (unless all_areas=true)
+		scope, err := managerAreaScope(r, db)
+		if err != nil {
+			log.Printf("❌ Error loading area scope: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch move requests")
+			return
+		}
+		if scope != nil {
+			query += fmt.Sprintf(" AND bmr.bin_id IN (SELECT id FROM bins WHERE area_id = ANY($%d))", argCount)
+			args = append(args, pq.Array(scope))
+			argCount++
+		}
+
+		if sortBy == "created_at" {
+			query += " ORDER BY bmr.created_at DESC"
+		} else {
+			query += " ORDER BY bmr.scheduled_date ASC, bmr.created_at DESC"
+		}
+
+		if limit > 0 {
+			query += fmt.Sprintf(" LIMIT $%d", argCount)
+			args = append(args, limit)
+			argCount++
+		}
+		if offset > 0 {
+			query += fmt.Sprintf(" OFFSET $%d", argCount)
+			args = append(args, offset)
+			argCount++
+		}
 
 		// Fetch move requests
 		var moveRequests []models.BinMoveRequest
-		err := db.Select(&moveRequests, query, args...)
+		err = db.SelectContext(r.Context(), &moveRequests, query, args...)
 		if err != nil {
 			log.Printf("Error fetching move requests: %v", err)
-			http.Error(w, "Failed to fetch move requests", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch move requests")
 			return
 		}
 
@@ -947,12 +1110,7 @@ func GetBinMoveRequests(db *sqlx.DB) http.HandlerFunc {
 			responses[i].Urgency = calculateUrgency(mr.Status, mr.ScheduledDate)
 
 			// Fetch bin details
-			var bin models.Bin
-			err := db.Get(&bin, `
-				SELECT id, bin_number, current_street, city, zip, latitude, longitude, status
-				FROM bins
-				WHERE id = $1
-			`, mr.BinID)
+			bin, err := store.New(db).Bins.Summary(mr.BinID)
 			if err == nil {
 				binResp := bin.ToBinResponse()
 				responses[i].Bin = &binResp
@@ -965,50 +1123,23 @@ func GetBinMoveRequests(db *sqlx.DB) http.HandlerFunc {
 
 			// Fetch requester name
 			var requesterName string
-			err = db.Get(&requesterName, `
-				SELECT name FROM users WHERE id = $1
-			`, mr.RequestedBy)
+			requesterName, err = store.New(db).Users.Name(mr.RequestedBy)
 			if err == nil {
 				responses[i].RequestedByName = &requesterName
 			}
 
-			// Parse original address into separate fields
-			parts := strings.Split(mr.OriginalAddress, ", ")
-			if len(parts) >= 2 {
-				street := parts[0]
-				cityZip := strings.TrimSpace(parts[1])
-				cityZipParts := strings.Split(cityZip, " ")
-				if len(cityZipParts) >= 2 {
-					city := strings.Join(cityZipParts[:len(cityZipParts)-1], " ")
-					zip := cityZipParts[len(cityZipParts)-1]
-					responses[i].OriginalStreet = &street
-					responses[i].OriginalCity = &city
-					responses[i].OriginalZip = &zip
-				}
-			}
-
-			// Parse new address into separate fields if available
-			if mr.NewAddress != nil {
-				// Split "street, city zip" format
-				parts := strings.Split(*mr.NewAddress, ", ")
-				if len(parts) >= 2 {
-					street := parts[0]
-					cityZip := strings.TrimSpace(parts[1])
-					cityZipParts := strings.Split(cityZip, " ")
-					if len(cityZipParts) >= 2 {
-						city := strings.Join(cityZipParts[:len(cityZipParts)-1], " ")
-						zip := cityZipParts[len(cityZipParts)-1]
-						responses[i].NewStreet = &street
-						responses[i].NewCity = &city
-						responses[i].NewZip = &zip
-					}
+			// Fetch the name of the admin who approved or rejected it
+			if mr.ApprovalDecidedBy != nil {
+				deciderName, err := store.New(db).Users.Name(*mr.ApprovalDecidedBy)
+				if err == nil {
+					responses[i].ApprovalDecidedByName = &deciderName
 				}
 			}
 
 			// Fetch assigned driver name if assigned to a shift
 			if mr.AssignedShiftID != nil {
 				var driverName string
-				err := db.Get(&driverName, `
+				err := db.GetContext(r.Context(), &driverName, `
 					SELECT u.name FROM shifts s
 					JOIN users u ON s.driver_id = u.id
 					WHERE s.id = $1
@@ -1021,10 +1152,7 @@ func GetBinMoveRequests(db *sqlx.DB) http.HandlerFunc {
 
 			// Fetch assigned user name if manually assigned
 			if mr.AssignedUserID != nil {
-				var userName string
-				err := db.Get(&userName, `
-					SELECT name FROM users WHERE id = $1
-				`, *mr.AssignedUserID)
+				userName, err := store.New(db).Users.Name(*mr.AssignedUserID)
 				if err == nil {
 					responses[i].AssignedUserName = &userName
 					responses[i].DriverName = &userName // Set unified field
@@ -1043,7 +1171,7 @@ func GetBinMoveRequests(db *sqlx.DB) http.HandlerFunc {
 		}
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(responses)
+		json.NewEncoder(w).Encode(fields.Apply(responses))
 	}
 }
 
@@ -1053,7 +1181,7 @@ func GetBinMoveRequestsByBinID(db *sqlx.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		binID := chi.URLParam(r, "id")
 		if binID == "" {
-			http.Error(w, "Missing bin ID", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Missing bin ID")
 			return
 		}
 
@@ -1064,7 +1192,9 @@ func GetBinMoveRequestsByBinID(db *sqlx.DB) http.HandlerFunc {
 		query := `
 			SELECT bmr.id, bmr.bin_id, bmr.scheduled_date, bmr.urgency, bmr.requested_by,
 			       bmr.status, bmr.original_latitude, bmr.original_longitude, bmr.original_address,
+			       bmr.original_street, bmr.original_city, bmr.original_zip,
 			       bmr.new_latitude, bmr.new_longitude, bmr.new_address,
+			       bmr.new_street, bmr.new_city, bmr.new_zip,
 			       bmr.move_type, bmr.disposal_action, bmr.reason, bmr.notes,
 			       bmr.assigned_shift_id, bmr.completed_at, bmr.created_at, bmr.updated_at,
 			       bmr.assignment_type, bmr.assigned_user_id
@@ -1084,10 +1214,10 @@ func GetBinMoveRequestsByBinID(db *sqlx.DB) http.HandlerFunc {
 
 		// Fetch move requests
 		var moveRequests []models.BinMoveRequest
-		err := db.Select(&moveRequests, query, args...)
+		err := db.SelectContext(r.Context(), &moveRequests, query, args...)
 		if err != nil {
 			log.Printf("Error fetching move requests for bin %s: %v", binID, err)
-			http.Error(w, "Failed to fetch move requests", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch move requests")
 			return
 		}
 
@@ -1100,12 +1230,7 @@ func GetBinMoveRequestsByBinID(db *sqlx.DB) http.HandlerFunc {
 			responses[i].Urgency = calculateUrgency(mr.Status, mr.ScheduledDate)
 
 			// Fetch bin details
-			var bin models.Bin
-			err := db.Get(&bin, `
-				SELECT id, bin_number, current_street, city, zip, latitude, longitude, status
-				FROM bins
-				WHERE id = $1
-			`, mr.BinID)
+			bin, err := store.New(db).Bins.Summary(mr.BinID)
 			if err == nil {
 				binResp := bin.ToBinResponse()
 				responses[i].Bin = &binResp
@@ -1118,49 +1243,15 @@ func GetBinMoveRequestsByBinID(db *sqlx.DB) http.HandlerFunc {
 
 			// Fetch requester name
 			var requesterName string
-			err = db.Get(&requesterName, `
-				SELECT name FROM users WHERE id = $1
-			`, mr.RequestedBy)
+			requesterName, err = store.New(db).Users.Name(mr.RequestedBy)
 			if err == nil {
 				responses[i].RequestedByName = &requesterName
 			}
 
-			// Parse original address into separate fields
-			parts := strings.Split(mr.OriginalAddress, ", ")
-			if len(parts) >= 2 {
-				street := parts[0]
-				cityZip := strings.TrimSpace(parts[1])
-				cityZipParts := strings.Split(cityZip, " ")
-				if len(cityZipParts) >= 2 {
-					city := strings.Join(cityZipParts[:len(cityZipParts)-1], " ")
-					zip := cityZipParts[len(cityZipParts)-1]
-					responses[i].OriginalStreet = &street
-					responses[i].OriginalCity = &city
-					responses[i].OriginalZip = &zip
-				}
-			}
-
-			// Parse new address into separate fields if available
-			if mr.NewAddress != nil {
-				parts := strings.Split(*mr.NewAddress, ", ")
-				if len(parts) >= 2 {
-					street := parts[0]
-					cityZip := strings.TrimSpace(parts[1])
-					cityZipParts := strings.Split(cityZip, " ")
-					if len(cityZipParts) >= 2 {
-						city := strings.Join(cityZipParts[:len(cityZipParts)-1], " ")
-						zip := cityZipParts[len(cityZipParts)-1]
-						responses[i].NewStreet = &street
-						responses[i].NewCity = &city
-						responses[i].NewZip = &zip
-					}
-				}
-			}
-
 			// Fetch assigned driver name if assigned to a shift
 			if mr.AssignedShiftID != nil {
 				var driverName string
-				err := db.Get(&driverName, `
+				err := db.GetContext(r.Context(), &driverName, `
 					SELECT u.name FROM shifts s
 					JOIN users u ON s.driver_id = u.id
 					WHERE s.id = $1
@@ -1173,10 +1264,7 @@ func GetBinMoveRequestsByBinID(db *sqlx.DB) http.HandlerFunc {
 
 			// Fetch assigned user name if manually assigned
 			if mr.AssignedUserID != nil {
-				var userName string
-				err := db.Get(&userName, `
-					SELECT name FROM users WHERE id = $1
-				`, *mr.AssignedUserID)
+				userName, err := store.New(db).Users.Name(*mr.AssignedUserID)
 				if err == nil {
 					responses[i].AssignedUserName = &userName
 					responses[i].DriverName = &userName // Set unified field
@@ -1195,7 +1283,7 @@ func UpdateBinMoveRequest(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := chi.URLParam(r, "id")
 		if id == "" {
-			http.Error(w, "Missing move request ID", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Missing move request ID")
 			return
 		}
 
@@ -1224,21 +1312,30 @@ func UpdateBinMoveRequest(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
 			InsertAfterWaypoint          *int    `json:"insert_after_waypoint,omitempty"`          // For manual insertion
 		}
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
 			return
 		}
 
+		// New address, when all of its fields are provided
+		var newAddress *models.Address
+		if req.NewStreet != nil && req.NewCity != nil && req.NewZip != nil {
+			newAddress = &models.Address{Street: *req.NewStreet, City: *req.NewCity, Zip: *req.NewZip}
+			if err := newAddress.Normalize(); err != nil {
+				utils.RespondError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+
 		// Get authenticated user (manager making the update)
 		userClaims, ok := middleware.GetUserFromContext(r)
 		if !ok {
-			http.Error(w, "User not authenticated", http.StatusUnauthorized)
+			utils.RespondError(w, http.StatusUnauthorized, "User not authenticated")
 			return
 		}
 		managerUserID := userClaims.UserID
 
 		// Fetch manager's name for notifications
-		var managerName string
-		err := db.Get(&managerName, `SELECT name FROM users WHERE id = $1`, managerUserID)
+		managerName, err := store.New(db).Users.Name(managerUserID)
 		if err != nil {
 			log.Printf("Warning: Could not fetch manager name: %v", err)
 			managerName = "A manager" // Fallback
@@ -1252,12 +1349,12 @@ func UpdateBinMoveRequest(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
 			TotalWaypoints  *int    `db:"total_waypoints"`
 		}
 
-		err = db.Get(&moveRequest, `
+		err = db.GetContext(r.Context(), &moveRequest, `
 			SELECT
 				mr.*,
 				s.status as shift_status,
 				u.name as shift_driver_name,
-				(SELECT COUNT(*) FROM shift_bins WHERE shift_id = mr.assigned_shift_id) as total_waypoints
+				(SELECT COUNT(*) FROM route_tasks WHERE shift_id = mr.assigned_shift_id) as total_waypoints
 			FROM bin_move_requests mr
 			LEFT JOIN shifts s ON mr.assigned_shift_id = s.id
 			LEFT JOIN users u ON s.driver_id = u.id
@@ -1265,27 +1362,34 @@ func UpdateBinMoveRequest(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
 		`, id)
 		if err != nil {
 			if err == sql.ErrNoRows {
-				http.Error(w, "Move request not found", http.StatusNotFound)
+				utils.RespondError(w, http.StatusNotFound, "Move request not found")
 				return
 			}
 			log.Printf("Error fetching move request: %v", err)
-			http.Error(w, "Failed to fetch move request", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch move request")
 			return
 		}
 
-		// BLOCK: Completed or cancelled moves cannot be edited
-		if moveRequest.Status == "completed" || moveRequest.Status == "cancelled" {
-			http.Error(w, fmt.Sprintf("❌ Cannot edit %s move request. This move has been finalized and cannot be modified.", moveRequest.Status), http.StatusBadRequest)
+		// BLOCK: Completed, cancelled or rejected moves cannot be edited
+		if models.IsFinalMoveStatus(moveRequest.Status) {
+			utils.RespondErrorCode(w, http.StatusBadRequest, utils.CodeMoveFinalized,
+				fmt.Sprintf("Cannot edit %s move request. This move has been finalized and cannot be modified.", moveRequest.Status), nil)
+			return
+		}
+
+		// BLOCK: Requested moves are approved or rejected as submitted
+		if moveRequest.Status == models.MoveStatusRequested {
+			utils.RespondErrorCode(w, http.StatusConflict, utils.CodeMoveAwaitingApproval,
+				"Cannot edit a move request that is awaiting approval. Approve or reject it first.", nil)
 			return
 		}
 
 		// OPTIMISTIC LOCKING: Check if move was modified by another user
 		if req.ClientUpdatedAt != nil && moveRequest.UpdatedAt != *req.ClientUpdatedAt {
-			http.Error(w,
-				fmt.Sprintf("⚠️ Conflict: This move request was modified by another user while you were editing it. "+
+			utils.RespondErrorCode(w, http.StatusConflict, utils.CodeStaleUpdate,
+				"This move request was modified by another user while you were editing it. "+
 					"The driver may have completed this bin, or another manager may have reassigned it. "+
-					"Please refresh and try again with the latest data."),
-				http.StatusConflict)
+					"Please refresh and try again with the latest data.", nil)
 			return
 		}
 
@@ -1295,7 +1399,7 @@ func UpdateBinMoveRequest(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
 			*moveRequest.ShiftStatus == "active"
 
 		// CHECK: Is driver currently at this location?
-		isInProgress := moveRequest.Status == "in_progress"
+		isInProgress := moveRequest.Status == models.MoveStatusInProgress
 
 		// VALIDATION: In-progress moves require explicit action
 		if isInProgress && (req.AssignedShiftID != nil || req.AssignedUserID != nil) {
@@ -1304,12 +1408,11 @@ func UpdateBinMoveRequest(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
 				if moveRequest.ShiftDriverName != nil {
 					driverInfo = *moveRequest.ShiftDriverName
 				}
-				http.Error(w,
-					fmt.Sprintf("⚠️ Driver %s is currently at this location. "+
+				utils.RespondErrorCode(w, http.StatusBadRequest, utils.CodeInProgressActionRequired,
+					fmt.Sprintf("Driver %s is currently at this location. "+
 						"You must specify what should happen to this move by providing 'in_progress_action': "+
 						"'remove_from_route', 'insert_after_current', or 'reoptimize_route'.",
-						driverInfo),
-					http.StatusBadRequest)
+						driverInfo), nil)
 				return
 			}
 		}
@@ -1322,25 +1425,20 @@ func UpdateBinMoveRequest(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
 				driverName = *moveRequest.ShiftDriverName
 			}
 
-			http.Error(w,
-				fmt.Sprintf("⚠️ This move is on %s's active route. Changing it will affect their navigation. "+
-					"Please confirm by setting 'confirm_active_shift_change' to true.", driverName),
-				http.StatusBadRequest)
+			utils.RespondErrorCode(w, http.StatusBadRequest, utils.CodeActiveShiftConfirmation,
+				fmt.Sprintf("This move is on %s's active route. Changing it will affect their navigation. "+
+					"Please confirm by setting 'confirm_active_shift_change' to true.", driverName), nil)
 			return
 		}
 
 		now := time.Now().Unix()
 
 		// Build dynamic update query
-		updates := []string{"updated_at = $1"}
-		args := []interface{}{now}
-		argCount := 2
+		update := database.NewUpdate("bin_move_requests")
 
 		// Update scheduled date and recalculate urgency if date changed
 		if req.ScheduledDate != nil {
-			updates = append(updates, fmt.Sprintf("scheduled_date = $%d", argCount))
-			args = append(args, *req.ScheduledDate)
-			argCount++
+			update.Set("scheduled_date", *req.ScheduledDate)
 
 			// Recalculate urgency
 			hoursUntil := float64(*req.ScheduledDate-now) / 3600.0
@@ -1350,322 +1448,275 @@ func UpdateBinMoveRequest(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
 			} else {
 				newUrgency = "scheduled"
 			}
-			updates = append(updates, fmt.Sprintf("urgency = $%d", argCount))
-			args = append(args, newUrgency)
-			argCount++
+			update.Set("urgency", newUrgency)
 		}
 
 
 		if req.MoveType != nil {
-			updates = append(updates, fmt.Sprintf("move_type = $%d", argCount))
-			args = append(args, *req.MoveType)
-			argCount++
+			update.Set("move_type", *req.MoveType)
 		}
 		if req.Reason != nil {
-			updates = append(updates, fmt.Sprintf("reason = $%d", argCount))
-			args = append(args, *req.Reason)
-			argCount++
+			update.Set("reason", *req.Reason)
 		}
 
 		if req.Notes != nil {
-			updates = append(updates, fmt.Sprintf("notes = $%d", argCount))
-			args = append(args, *req.Notes)
-			argCount++
+			update.Set("notes", *req.Notes)
 		}
 
 		// Build new address if separate fields provided
-		if req.NewStreet != nil && req.NewCity != nil && req.NewZip != nil {
-			newAddress := fmt.Sprintf("%s, %s %s", *req.NewStreet, *req.NewCity, *req.NewZip)
-			updates = append(updates, fmt.Sprintf("new_address = $%d", argCount))
-			args = append(args, newAddress)
-			argCount++
+		if newAddress != nil {
+			var parts models.BinMoveRequest
+			parts.SetNewAddress(*newAddress)
+			update.Set("new_address", parts.NewAddress)
+			update.Set("new_street", parts.NewStreet)
+			update.Set("new_city", parts.NewCity)
+			update.Set("new_zip", parts.NewZip)
 		}
 
 		if req.NewLatitude != nil {
-			updates = append(updates, fmt.Sprintf("new_latitude = $%d", argCount))
-			args = append(args, *req.NewLatitude)
-			argCount++
+			update.Set("new_latitude", *req.NewLatitude)
 		}
 
 		if req.NewLongitude != nil {
-			updates = append(updates, fmt.Sprintf("new_longitude = $%d", argCount))
-			args = append(args, *req.NewLongitude)
-			argCount++
+			update.Set("new_longitude", *req.NewLongitude)
 		}
 
 		// ═══════════════════════════════════════════════════════════════════
 		// ASSIGNMENT HANDLING (Shift/User reassignment)
 		// ═══════════════════════════════════════════════════════════════════
 
-		// START TRANSACTION for assignment changes
-		tx, err := db.Beginx()
-		if err != nil {
-			log.Printf("Error starting transaction: %v", err)
-			http.Error(w, "Failed to start transaction", http.StatusInternalServerError)
-			return
-		}
-		defer tx.Rollback()
-
 		// Track if assignment changed (for WebSocket notification)
 		assignmentChanged := false
 		affectedDriverIDs := []string{}
 
-		// HANDLE IN-PROGRESS ACTION (driver is at location)
-		if isInProgress && req.InProgressAction != nil {
-			log.Printf("[IN-PROGRESS EDIT] Handling action: %s", *req.InProgressAction)
-
-			switch *req.InProgressAction {
-			case "remove_from_route":
-				// Remove from shift_bins, reset to pending
-				if moveRequest.AssignedShiftID != nil {
-					_, err = tx.Exec(`DELETE FROM shift_bins WHERE shift_id = $1 AND bin_id = $2`,
-						*moveRequest.AssignedShiftID, moveRequest.BinID)
-					if err != nil {
-						log.Printf("Error removing from shift_bins: %v", err)
-						http.Error(w, "Failed to remove from driver's route", http.StatusInternalServerError)
-						return
-					}
+		// Status after the update, validated and written with the other changes
+		newStatus := moveRequest.Status
 
-					_, err = tx.Exec(`UPDATE shifts SET total_bins = total_bins - 1, updated_at = $1 WHERE id = $2`,
-						now, *moveRequest.AssignedShiftID)
-					if err != nil {
-						log.Printf("Error updating shift total_bins: %v", err)
-					}
+		// Assignment changes and the update itself commit together or not at all
+		err = database.WithTx(r.Context(), db, func(tx *sqlx.Tx) error {
+			// HANDLE IN-PROGRESS ACTION (driver is at location)
+			if isInProgress && req.InProgressAction != nil {
+				log.Printf("[IN-PROGRESS EDIT] Handling action: %s", *req.InProgressAction)
 
-					log.Printf("[IN-PROGRESS EDIT] ✅ Removed bin from driver's route")
-					assignmentChanged = true
-					if moveRequest.AssignedUserID != nil {
-						affectedDriverIDs = append(affectedDriverIDs, *moveRequest.AssignedUserID)
-					}
-				}
+				switch *req.InProgressAction {
+				case "remove_from_route":
+					// Remove the move's stops from the shift, reset to pending
+					if moveRequest.AssignedShiftID != nil {
+						_, err = removeMoveFromShift(tx, *moveRequest.AssignedShiftID, id, now)
+						if err != nil {
+							log.Printf("Error removing from shift route: %v", err)
+							return txFail(http.StatusInternalServerError, "Failed to remove from driver's route")
+						}
 
-				// Clear assignment, return to pending
-				updates = append(updates, fmt.Sprintf("assigned_shift_id = NULL, assigned_user_id = NULL, assignment_type = NULL, status = 'pending'"))
+						log.Printf("[IN-PROGRESS EDIT] ✅ Removed bin from driver's route")
+						if _, err := helpers.RequestRouteReoptimization(tx, *moveRequest.AssignedShiftID, models.ReoptimizeReasonMoveRemoved); err != nil {
+							log.Printf("Error queueing route re-optimization: %v", err)
+							return txFail(http.StatusInternalServerError, "Failed to remove from driver's route")
+						}
+						assignmentChanged = true
+						if moveRequest.AssignedUserID != nil {
+							affectedDriverIDs = append(affectedDriverIDs, *moveRequest.AssignedUserID)
+						}
+					}
 
-			case "insert_after_current":
-				// Keep on route, adjust waypoint order
-				log.Printf("[IN-PROGRESS EDIT] Inserting after current waypoint")
-				// Implementation: Re-order waypoints (complex, may need route optimization logic)
-				// For now, just log - full implementation would update waypoint_order in shift_bins
+					// Clear assignment, return to pending
+					update.SetNull("assigned_shift_id").SetNull("assigned_user_id").SetNull("assignment_type")
+					newStatus = models.MoveStatusPending
 
-			case "reoptimize_route":
-				// Trigger route re-optimization
-				log.Printf("[IN-PROGRESS EDIT] Triggering route re-optimization")
-				// Implementation: Call route optimization service
-				// For now, just log - full implementation would recalculate optimal waypoint order
-			}
-		}
+				case "insert_after_current":
+					// Keep on route, adjust waypoint order
+					log.Printf("[IN-PROGRESS EDIT] Inserting after current waypoint")
+					// Implementation: Re-order waypoints (complex, may need route optimization logic)
+					// For now, just log - full implementation would update the stops' sequence_order
 
-		// HANDLE ASSIGNMENT CHANGES (for non-in-progress moves)
-		if !isInProgress {
-			// Remove from old shift if changing
-			if moveRequest.AssignedShiftID != nil && req.AssignedShiftID != nil && *req.AssignedShiftID != *moveRequest.AssignedShiftID {
-				_, err = tx.Exec(`DELETE FROM shift_bins WHERE shift_id = $1 AND bin_id = $2`,
-					*moveRequest.AssignedShiftID, moveRequest.BinID)
-				if err == nil {
-					_, err = tx.Exec(`UPDATE shifts SET total_bins = total_bins - 1, updated_at = $1 WHERE id = $2`,
-						now, *moveRequest.AssignedShiftID)
+				case "reoptimize_route":
+					// Queue the driver's remaining route for re-optimization (see services.RouteReoptimizer)
+					if moveRequest.AssignedShiftID != nil {
+						if _, err := helpers.RequestRouteReoptimization(tx, *moveRequest.AssignedShiftID, models.ReoptimizeReasonManual); err != nil {
+							log.Printf("Error queueing route re-optimization: %v", err)
+							return txFail(http.StatusInternalServerError, "Failed to queue route re-optimization")
+						}
+						log.Printf("[IN-PROGRESS EDIT] ✅ Queued route re-optimization for shift %s", *moveRequest.AssignedShiftID)
+					}
 				}
-				log.Printf("[REASSIGNMENT] Removed from old shift: %s", *moveRequest.AssignedShiftID)
-				assignmentChanged = true
 			}
 
-			// Add assignment fields to update (treat empty strings as NULL)
-			if req.AssignedShiftID != nil {
-				if *req.AssignedShiftID == "" {
-					updates = append(updates, "assigned_shift_id = NULL")
-					// Only mark as changed if it was previously set
-					if moveRequest.AssignedShiftID != nil {
-						assignmentChanged = true
-					}
-				} else {
-					updates = append(updates, fmt.Sprintf("assigned_shift_id = $%d", argCount))
-					args = append(args, *req.AssignedShiftID)
-					argCount++
-					// Only mark as changed if the value is different
-					if !stringPtrEqual(moveRequest.AssignedShiftID, req.AssignedShiftID) {
-						assignmentChanged = true
+			// HANDLE ASSIGNMENT CHANGES (for non-in-progress moves)
+			if !isInProgress {
+				// Remove from old shift if changing
+				if moveRequest.AssignedShiftID != nil && req.AssignedShiftID != nil && *req.AssignedShiftID != *moveRequest.AssignedShiftID {
+					if _, err := removeMoveFromShift(tx, *moveRequest.AssignedShiftID, id, now); err != nil {
+						return fmt.Errorf("failed to remove from old shift: %w", err)
 					}
+					log.Printf("[REASSIGNMENT] Removed from old shift: %s", *moveRequest.AssignedShiftID)
+					assignmentChanged = true
 				}
-			}
 
-			if req.AssignedUserID != nil {
-				if *req.AssignedUserID == "" {
-					updates = append(updates, "assigned_user_id = NULL")
-					// Only mark as changed if it was previously set
-					if moveRequest.AssignedUserID != nil {
-						assignmentChanged = true
+				// Add assignment fields to update (treat empty strings as NULL)
+				if req.AssignedShiftID != nil {
+					if *req.AssignedShiftID == "" {
+						update.SetNull("assigned_shift_id")
+						// Only mark as changed if it was previously set
+						if moveRequest.AssignedShiftID != nil {
+							assignmentChanged = true
+						}
+					} else {
+						update.Set("assigned_shift_id", *req.AssignedShiftID)
+						// Only mark as changed if the value is different
+						if !stringPtrEqual(moveRequest.AssignedShiftID, req.AssignedShiftID) {
+							assignmentChanged = true
+						}
 					}
-				} else {
-					updates = append(updates, fmt.Sprintf("assigned_user_id = $%d", argCount))
-					args = append(args, *req.AssignedUserID)
-					argCount++
-					affectedDriverIDs = append(affectedDriverIDs, *req.AssignedUserID)
-					// Only mark as changed if the value is different
-					if !stringPtrEqual(moveRequest.AssignedUserID, req.AssignedUserID) {
-						assignmentChanged = true
+				}
+
+				if req.AssignedUserID != nil {
+					if *req.AssignedUserID == "" {
+						update.SetNull("assigned_user_id")
+						// Only mark as changed if it was previously set
+						if moveRequest.AssignedUserID != nil {
+							assignmentChanged = true
+						}
+					} else {
+						update.Set("assigned_user_id", *req.AssignedUserID)
+						affectedDriverIDs = append(affectedDriverIDs, *req.AssignedUserID)
+						// Only mark as changed if the value is different
+						if !stringPtrEqual(moveRequest.AssignedUserID, req.AssignedUserID) {
+							assignmentChanged = true
+						}
 					}
 				}
-			}
 
-			// Determine final assignment state (after potential updates)
-			finalShiftID := moveRequest.AssignedShiftID
-			finalUserID := moveRequest.AssignedUserID
-			if req.AssignedShiftID != nil {
-				if *req.AssignedShiftID == "" {
-					finalShiftID = nil
-				} else {
-					finalShiftID = req.AssignedShiftID
+				// Determine final assignment state (after potential updates)
+				finalShiftID := moveRequest.AssignedShiftID
+				finalUserID := moveRequest.AssignedUserID
+				if req.AssignedShiftID != nil {
+					if *req.AssignedShiftID == "" {
+						finalShiftID = nil
+					} else {
+						finalShiftID = req.AssignedShiftID
+					}
 				}
-			}
-			if req.AssignedUserID != nil {
-				if *req.AssignedUserID == "" {
-					finalUserID = nil
-				} else {
-					finalUserID = req.AssignedUserID
+				if req.AssignedUserID != nil {
+					if *req.AssignedUserID == "" {
+						finalUserID = nil
+					} else {
+						finalUserID = req.AssignedUserID
+					}
 				}
-			}
 
-			// If both assignments are being cleared, also clear assignment_type
-			isUnassigning := (finalShiftID == nil || (finalShiftID != nil && *finalShiftID == "")) &&
-				(finalUserID == nil || (finalUserID != nil && *finalUserID == ""))
+				// If both assignments are being cleared, also clear assignment_type
+				isUnassigning := (finalShiftID == nil || (finalShiftID != nil && *finalShiftID == "")) &&
+					(finalUserID == nil || (finalUserID != nil && *finalUserID == ""))
 
-			log.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-			log.Printf("🔍 [UNASSIGNMENT DETECTION]")
-			log.Printf("   isUnassigning: %v", isUnassigning)
-			log.Printf("   finalShiftID: %v", finalShiftID)
-			log.Printf("   finalUserID: %v", finalUserID)
-			log.Printf("   moveRequest.AssignedShiftID: %v", moveRequest.AssignedShiftID)
-			log.Printf("   moveRequest.Status: %s", moveRequest.Status)
-			log.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+				log.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+				log.Printf("🔍 [UNASSIGNMENT DETECTION]")
+				log.Printf("   isUnassigning: %v", isUnassigning)
+				log.Printf("   finalShiftID: %v", finalShiftID)
+				log.Printf("   finalUserID: %v", finalUserID)
+				log.Printf("   moveRequest.AssignedShiftID: %v", moveRequest.AssignedShiftID)
+				log.Printf("   moveRequest.Status: %s", moveRequest.Status)
+				log.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 
-			if isUnassigning {
-				// Remove from shift_bins if previously assigned to a shift
-				if moveRequest.AssignedShiftID != nil {
-					log.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-					log.Printf("⭕ [UNASSIGNMENT] Starting shift removal")
-					log.Printf("   Move Request ID: %s", id)
-					log.Printf("   Old Shift ID: %s", *moveRequest.AssignedShiftID)
-					log.Printf("   Bin ID: %s", moveRequest.BinID)
-
-					_, err = tx.Exec(`DELETE FROM shift_bins WHERE shift_id = $1 AND bin_id = $2`,
-						*moveRequest.AssignedShiftID, moveRequest.BinID)
-					if err == nil {
-						log.Printf("   ✅ Removed bin from shift_bins")
-						_, err = tx.Exec(`UPDATE shifts SET total_bins = total_bins - 1, updated_at = $1 WHERE id = $2`,
-							now, *moveRequest.AssignedShiftID)
-						if err == nil {
-							log.Printf("   ✅ Updated shift total_bins count")
-						} else {
-							log.Printf("   ⚠️  Failed to update shift count: %v", err)
+				if isUnassigning {
+					// Remove the move's stops if previously assigned to a shift
+					if moveRequest.AssignedShiftID != nil {
+						log.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+						log.Printf("⭕ [UNASSIGNMENT] Starting shift removal")
+						log.Printf("   Move Request ID: %s", id)
+						log.Printf("   Old Shift ID: %s", *moveRequest.AssignedShiftID)
+						log.Printf("   Bin ID: %s", moveRequest.BinID)
+
+						removed, err := removeMoveFromShift(tx, *moveRequest.AssignedShiftID, id, now)
+						if err != nil {
+							return fmt.Errorf("failed to remove from shift route: %w", err)
 						}
-					} else {
-						log.Printf("   ❌ Failed to remove from shift_bins: %v", err)
-					}
+						log.Printf("   ✅ Removed %d stops and updated shift total_bins count", removed)
 
-					log.Printf("[UNASSIGNMENT] Removed from shift_bins for shift: %s", *moveRequest.AssignedShiftID)
-					assignmentChanged = true
+						log.Printf("[UNASSIGNMENT] Removed from route of shift: %s", *moveRequest.AssignedShiftID)
+						assignmentChanged = true
 
-					// Track affected driver for WebSocket notification
-					log.Printf("   Fetching driver ID for WebSocket notification...")
-					var driverID string
-					err = db.Get(&driverID, `SELECT driver_id FROM shifts WHERE id = $1`, *moveRequest.AssignedShiftID)
-					if err == nil {
+						// Track affected driver for WebSocket notification
+						log.Printf("   Fetching driver ID for WebSocket notification...")
+						var driverID string
+						err = tx.GetContext(r.Context(), &driverID, `SELECT driver_id FROM shifts WHERE id = $1`, *moveRequest.AssignedShiftID)
+						if err != nil {
+							return fmt.Errorf("failed to fetch driver ID: %w", err)
+						}
 						affectedDriverIDs = append(affectedDriverIDs, driverID)
 						log.Printf("   ✅ Driver ID found: %s", driverID)
 						log.Printf("   Driver will receive WebSocket notification")
+						log.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 					} else {
-						log.Printf("   ❌ Failed to fetch driver ID: %v", err)
+						log.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+						log.Printf("⚠️  [UNASSIGNMENT] No shift assignment to remove")
+						log.Printf("   Move request was not assigned to a shift")
+						log.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+					}
+
+					// Clear assignment_type and set status to pending when unassigning
+					update.SetNull("assignment_type")
+					newStatus = models.MoveStatusPending
+					log.Printf("[UNASSIGNMENT] Clearing assignment_type and setting status to pending")
+				} else if req.AssignmentType != nil {
+					// Only update assignment_type if provided and not unassigning
+					// Treat empty string as NULL
+					if *req.AssignmentType == "" {
+						update.SetNull("assignment_type")
+					} else {
+						update.Set("assignment_type", *req.AssignmentType)
 					}
-					log.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-				} else {
-					log.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-					log.Printf("⚠️  [UNASSIGNMENT] No shift assignment to remove")
-					log.Printf("   Move request was not assigned to a shift")
-					log.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 				}
 
-				// Clear assignment_type and set status to pending when unassigning
-				updates = append(updates, "assignment_type = NULL, status = 'pending'")
-				log.Printf("[UNASSIGNMENT] Clearing assignment_type and setting status to pending")
-			} else if req.AssignmentType != nil {
-				// Only update assignment_type if provided and not unassigning
-				// Treat empty string as NULL
-				if *req.AssignmentType == "" {
-					updates = append(updates, "assignment_type = NULL")
-				} else {
-					updates = append(updates, fmt.Sprintf("assignment_type = $%d", argCount))
-					args = append(args, *req.AssignmentType)
-					argCount++
+				// Pending and assigned moves follow their assignment: in progress on an active shift, assigned
+				// on any other shift or a user (a picked-up bin keeps its status until dropped off)
+				if !isUnassigning && (moveRequest.Status == models.MoveStatusPending || moveRequest.Status == models.MoveStatusAssigned) {
+					shiftStatus := moveRequest.ShiftStatus
+					if finalShiftID != nil && !stringPtrEqual(finalShiftID, moveRequest.AssignedShiftID) {
+						var status string
+						err := tx.GetContext(r.Context(), &status, `SELECT status FROM shifts WHERE id = $1`, *finalShiftID)
+						if err != nil && err != sql.ErrNoRows {
+							log.Printf("Error fetching shift status: %v", err)
+							return txFail(http.StatusInternalServerError, "Failed to verify assignment status")
+						}
+						shiftStatus = &status
+					}
+					newStatus = models.AssignedMoveStatus(finalShiftID, finalUserID, shiftStatus)
 				}
 			}
-		}
-
-		// Add ID parameter at the end
-		args = append(args, id)
-
-		// Execute update
-		query := fmt.Sprintf("UPDATE bin_move_requests SET %s WHERE id = $%d",
-			strings.Join(updates, ", "), argCount)
-
-		_, err = tx.Exec(query, args...)
-		if err != nil {
-			log.Printf("Error updating move request: %v", err)
-			http.Error(w, "Failed to update move request", http.StatusInternalServerError)
-			return
-		}
 
-		// CRITICAL FIX: After updating, check if move request should have correct status
-		// This ensures status matches the assignment state and shift status
-		var finalShiftID, finalUserID *string
-		var shiftStatus *string
-		err = tx.QueryRow(`
-			SELECT
-				mr.assigned_shift_id,
-				mr.assigned_user_id,
-				s.status as shift_status
-			FROM bin_move_requests mr
-			LEFT JOIN shifts s ON mr.assigned_shift_id = s.id
-			WHERE mr.id = $1
-		`, id).Scan(&finalShiftID, &finalUserID, &shiftStatus)
-		if err != nil {
-			log.Printf("Error checking final assignment status: %v", err)
-			http.Error(w, "Failed to verify assignment status", http.StatusInternalServerError)
-			return
-		}
+			if newStatus != moveRequest.Status {
+				if err := txMoveStatusTransition(moveRequest.Status, newStatus); err != nil {
+					return err
+				}
+				log.Printf("[UPDATE MOVE] Status %s → %s", moveRequest.Status, newStatus)
+				update.Set("status", newStatus)
+			}
 
-		// Set status based on assignment and shift state
-		// Only update status if it's not already in_progress or completed
-		if (finalShiftID != nil || finalUserID != nil) && moveRequest.Status != "in_progress" && moveRequest.Status != "completed" {
-			var newStatus string
+			if update.Len() == 0 {
+				return txFail(http.StatusBadRequest, "No fields to update")
+			}
 
-			// If assigned to an ACTIVE shift, status should be "in_progress"
-			// If assigned to a future/scheduled shift, status should be "assigned"
-			if finalShiftID != nil && shiftStatus != nil && *shiftStatus == "active" {
-				newStatus = "in_progress"
-				log.Printf("[UPDATE MOVE] Move request assigned to ACTIVE shift, setting status to 'in_progress'")
-			} else {
-				newStatus = "assigned"
-				log.Printf("[UPDATE MOVE] Move request has assignment (shift: %v, user: %v, shift_status: %v), setting status to 'assigned'",
-					finalShiftID, finalUserID, shiftStatus)
+			// Execute update; the status must still be the one the transition was validated from
+			query, args, err := update.Set("updated_at", now).Where("id", id).Where("status", moveRequest.Status).Build()
+			if err != nil {
+				log.Printf("Error building move request update: %v", err)
+				return txFail(http.StatusInternalServerError, "Failed to update move request")
 			}
 
-			_, err = tx.Exec(`
-				UPDATE bin_move_requests
-				SET status = $1
-				WHERE id = $2
-			`, newStatus, id)
+			result, err := tx.ExecContext(r.Context(), query, args...)
 			if err != nil {
-				log.Printf("Error setting status to %s: %v", newStatus, err)
-				http.Error(w, "Failed to update status", http.StatusInternalServerError)
-				return
+				log.Printf("Error updating move request: %v", err)
+				return txFail(http.StatusInternalServerError, "Failed to update move request")
+			}
+			if rows, _ := result.RowsAffected(); rows == 0 {
+				return txFailCode(http.StatusConflict, utils.CodeStaleUpdate,
+					"This move request's status changed while you were editing it. Please refresh and try again.", nil)
 			}
-		}
 
-		// Commit transaction
-		if err = tx.Commit(); err != nil {
-			log.Printf("Error committing transaction: %v", err)
-			http.Error(w, "Failed to commit changes", http.StatusInternalServerError)
+			return nil
+		})
+		if err != nil {
+			respondTxError(w, err, "Failed to update move request")
 			return
 		}
 
@@ -1677,7 +1728,7 @@ func UpdateBinMoveRequest(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
 			AssignedUserName   *string `db:"assigned_user_name"`
 			AssignedDriverName *string `db:"assigned_driver_name"`
 		}
-		err = db.Get(&updatedMove, `
+		err = db.GetContext(r.Context(), &updatedMove, `
 			SELECT
 				mr.*,
 				assigned_user.name AS assigned_user_name,
@@ -1690,7 +1741,7 @@ func UpdateBinMoveRequest(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
 		`, id)
 		if err != nil {
 			log.Printf("Error fetching updated move request: %v", err)
-			http.Error(w, "Failed to fetch updated move request", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch updated move request")
 			return
 		}
 
@@ -1732,15 +1783,14 @@ func UpdateBinMoveRequest(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
 				var oldAssignedUserName *string
 				if moveRequest.AssignedUserID != nil {
 					// Fetch the old assigned user's name
-					var userName string
-					nameErr := db.Get(&userName, `SELECT name FROM users WHERE id = $1`, *moveRequest.AssignedUserID)
+					userName, nameErr := store.New(db).Users.Name(*moveRequest.AssignedUserID)
 					if nameErr == nil {
 						oldAssignedUserName = &userName
 					}
 				} else if moveRequest.AssignedShiftID != nil {
 					// Fetch the old shift driver's name
 					var driverName string
-					nameErr := db.Get(&driverName, `SELECT u.name FROM shifts s JOIN users u ON s.driver_id = u.id WHERE s.id = $1`, *moveRequest.AssignedShiftID)
+					nameErr := db.GetContext(r.Context(), &driverName, `SELECT u.name FROM shifts s JOIN users u ON s.driver_id = u.id WHERE s.id = $1`, *moveRequest.AssignedShiftID)
 					if nameErr == nil {
 						oldAssignedUserName = &driverName
 					}
@@ -1762,14 +1812,13 @@ func UpdateBinMoveRequest(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
 				// Determine old assigned user name
 				var oldAssignedUserName *string
 				if moveRequest.AssignedUserID != nil {
-					var userName string
-					nameErr := db.Get(&userName, `SELECT name FROM users WHERE id = $1`, *moveRequest.AssignedUserID)
+					userName, nameErr := store.New(db).Users.Name(*moveRequest.AssignedUserID)
 					if nameErr == nil {
 						oldAssignedUserName = &userName
 					}
 				} else if moveRequest.AssignedShiftID != nil {
 					var driverName string
-					nameErr := db.Get(&driverName, `SELECT u.name FROM shifts s JOIN users u ON s.driver_id = u.id WHERE s.id = $1`, *moveRequest.AssignedShiftID)
+					nameErr := db.GetContext(r.Context(), &driverName, `SELECT u.name FROM shifts s JOIN users u ON s.driver_id = u.id WHERE s.id = $1`, *moveRequest.AssignedShiftID)
 					if nameErr == nil {
 						oldAssignedUserName = &driverName
 					}
@@ -1799,7 +1848,7 @@ func UpdateBinMoveRequest(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
 				}
 			}
 		} else if req.ScheduledDate != nil || req.MoveType != nil || req.Reason != nil || req.Notes != nil ||
-			(req.NewStreet != nil && req.NewCity != nil && req.NewZip != nil) ||
+			newAddress != nil ||
 			req.NewLatitude != nil || req.NewLongitude != nil {
 			// Only log "updated" if move detail fields (not just assignment) were actually provided
 
@@ -1915,8 +1964,8 @@ func UpdateBinMoveRequest(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
 
 					// Format new address from request (separate fields)
 					var newAddressPtr *string
-					if req.NewStreet != nil && req.NewCity != nil && req.NewZip != nil {
-						newAddr := fmt.Sprintf("%s, %s %s", *req.NewStreet, *req.NewCity, *req.NewZip)
+					if newAddress != nil {
+						newAddr := newAddress.String()
 						newAddressPtr = &newAddr
 					}
 
@@ -1959,7 +2008,7 @@ func UpdateBinMoveRequest(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
 				DriverID string `db:"driver_id"`
 				Status   string `db:"status"`
 			}
-			err = db.Get(&shift, `SELECT driver_id, status FROM shifts WHERE id = $1`, *updatedMove.AssignedShiftID)
+			err = db.GetContext(r.Context(), &shift, `SELECT driver_id, status FROM shifts WHERE id = $1`, *updatedMove.AssignedShiftID)
 			if err == nil && shift.Status == "active" {
 				// Add driver to affected list if not already present
 				driverAlreadyInList := false
@@ -2009,7 +2058,7 @@ func UpdateBinMoveRequest(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
 
 				// Fetch bin number for the notification
 				var binNumber int
-				err := db.Get(&binNumber, `SELECT bin_number FROM bins WHERE id = $1`, updatedMove.BinID)
+				err := db.GetContext(r.Context(), &binNumber, `SELECT bin_number FROM bins WHERE id = $1`, updatedMove.BinID)
 				if err != nil {
 					log.Printf("Warning: Could not fetch bin number: %v", err)
 					binNumber = 0 // Fallback
@@ -2075,11 +2124,7 @@ func UpdateBinMoveRequest(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
 		}
 
 		// Fetch bin details
-		var bin models.Bin
-		err = db.Get(&bin, `
-			SELECT id, bin_number, current_street, city, zip, latitude, longitude, status
-			FROM bins WHERE id = $1
-		`, updatedMove.BinID)
+		bin, err := store.New(db).Bins.Summary(updatedMove.BinID)
 		if err == nil {
 			binResp := bin.ToBinResponse()
 			response.Bin = &binResp
@@ -2090,24 +2135,6 @@ func UpdateBinMoveRequest(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
 			response.Zip = bin.Zip
 		}
 
-		// Parse new address into separate fields if available
-		if updatedMove.NewAddress != nil {
-			// Split "street, city zip" format
-			parts := strings.Split(*updatedMove.NewAddress, ", ")
-			if len(parts) >= 2 {
-				street := parts[0]
-				cityZip := strings.TrimSpace(parts[1])
-				cityZipParts := strings.Split(cityZip, " ")
-				if len(cityZipParts) >= 2 {
-					city := strings.Join(cityZipParts[:len(cityZipParts)-1], " ")
-					zip := cityZipParts[len(cityZipParts)-1]
-					response.NewStreet = &street
-					response.NewCity = &city
-					response.NewZip = &zip
-				}
-			}
-		}
-
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(response)
 	}
@@ -2119,62 +2146,71 @@ func CancelBinMoveRequest(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := chi.URLParam(r, "id")
 		if id == "" {
-			http.Error(w, "Missing move request ID", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Missing move request ID")
 			return
 		}
 
 		// Fetch move request to check status
 		var moveRequest models.BinMoveRequest
-		err := db.Get(&moveRequest, `
+		err := db.GetContext(r.Context(), &moveRequest, `
 			SELECT id, bin_id, status, assigned_shift_id
 			FROM bin_move_requests
 			WHERE id = $1
 		`, id)
 		if err != nil {
 			if err == sql.ErrNoRows {
-				http.Error(w, "Move request not found", http.StatusNotFound)
+				utils.RespondError(w, http.StatusNotFound, "Move request not found")
 				return
 			}
 			log.Printf("Error fetching move request: %v", err)
-			http.Error(w, "Failed to fetch move request", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch move request")
 			return
 		}
 
-		// Only allow cancelling pending or in_progress moves
-		if moveRequest.Status == "completed" {
-			http.Error(w, "Cannot cancel completed move request", http.StatusBadRequest)
+		// Completed, rejected and already cancelled moves can't be cancelled
+		if moveRequest.Status == models.MoveStatusCancelled {
+			respondMoveStatusConflict(w, moveRequest.Status, models.MoveStatusCancelled, "Move request already cancelled")
 			return
 		}
-		if moveRequest.Status == "cancelled" {
-			http.Error(w, "Move request already cancelled", http.StatusBadRequest)
+		if !checkMoveStatusTransition(w, moveRequest.Status, models.MoveStatusCancelled) {
 			return
 		}
 
 		// Get manager ID from context
 		userClaims, ok := middleware.GetUserFromContext(r)
 		if !ok {
-			http.Error(w, "User not authenticated", http.StatusUnauthorized)
+			utils.RespondError(w, http.StatusUnauthorized, "User not authenticated")
 			return
 		}
 		managerID := userClaims.UserID
 
 		now := time.Now().Unix()
 
-		// Update move request status to cancelled
-		_, err = db.Exec(`
+		undoSnapshot, err := captureMoveRequestUndo(db, id)
+		if err != nil {
+			log.Printf("Warning: Failed to capture undo snapshot: %v", err)
+		}
+
+		// Update move request status to cancelled (unless it changed since it was read)
+		result, err := db.ExecContext(r.Context(), `
 			UPDATE bin_move_requests
-			SET status = 'cancelled', updated_at = $1
-			WHERE id = $2
-		`, now, id)
+			SET status = $1, updated_at = $2
+			WHERE id = $3 AND status = $4
+		`, models.MoveStatusCancelled, now, id, moveRequest.Status)
 		if err != nil {
 			log.Printf("Error cancelling move request: %v", err)
-			http.Error(w, "Failed to cancel move request", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to cancel move request")
+			return
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			utils.RespondErrorCode(w, http.StatusConflict, utils.CodeStaleUpdate,
+				"The move request changed while it was being cancelled. Please refresh and try again.", nil)
 			return
 		}
 
 		// Log history: move request cancelled by manager
 		var managerName string
-		err = db.Get(&managerName, `SELECT name FROM users WHERE id = $1`, managerID)
+		managerName, err = store.New(db).Users.Name(managerID)
 		if err != nil {
 			log.Printf("Warning: Failed to fetch manager name for history: %v", err)
 			managerName = "Unknown Manager"
@@ -2185,25 +2221,28 @@ func CancelBinMoveRequest(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
 			log.Printf("Warning: Failed to log move request cancellation: %v", err)
 		}
 
-		// Update bin status back to active
-		_, err = db.Exec(`
-			UPDATE bins
-			SET status = 'active', updated_at = $1
-			WHERE id = $2
-		`, now, moveRequest.BinID)
-		if err != nil {
-			log.Printf("Warning: Failed to update bin status: %v", err)
+		// Update bin status back to active (a move still awaiting approval never changed it)
+		if moveRequest.Status != models.MoveStatusRequested {
+			_, err = db.ExecContext(r.Context(), `
+				UPDATE bins
+				SET status = 'active', updated_at = $1
+				WHERE id = $2
+			`, now, moveRequest.BinID)
+			if err != nil {
+				log.Printf("Warning: Failed to update bin status: %v", err)
+			}
+			store.InvalidateBins(moveRequest.BinID)
 		}
 
-		// If move was assigned to a shift, remove it from shift_bins
+		// If move was assigned to a shift, remove its stops from the route
 		if moveRequest.AssignedShiftID != nil {
-			_, err = db.Exec(`
-				DELETE FROM shift_bins
-				WHERE shift_id = $1 AND bin_id = $2
-			`, *moveRequest.AssignedShiftID, moveRequest.BinID)
+			_, err = removeMoveFromShift(db, *moveRequest.AssignedShiftID, moveRequest.ID, now)
 			if err != nil {
 				log.Printf("Warning: Failed to remove bin from shift: %v", err)
 			}
+			if _, err := helpers.RequestRouteReoptimization(db, *moveRequest.AssignedShiftID, models.ReoptimizeReasonMoveCancelled); err != nil {
+				log.Printf("Warning: %v", err)
+			}
 
 			// Send WebSocket update to driver
 			wsHub.BroadcastToUser(*moveRequest.AssignedShiftID, map[string]interface{}{
@@ -2213,9 +2252,19 @@ func CancelBinMoveRequest(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
 			})
 		}
 
+		// Offer an undo for the configured window (see UndoOperation)
+		var undo *models.UndoOperation
+		if undoSnapshot != nil {
+			undoSnapshot.AppliedStatus = models.MoveStatusCancelled
+			undoSnapshot.AppliedAt = now
+			undo = recordUndo(db, models.UndoMoveRequestCancel, "move_request", id,
+				fmt.Sprintf("Cancelled move request for bin #%d", undoSnapshot.BinNumber), undoSnapshot, managerID, now)
+		}
+
 		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(map[string]string{
+		json.NewEncoder(w).Encode(map[string]interface{}{
 			"message": "Move request cancelled successfully",
+			"undo":    undo,
 		})
 	}
 }
@@ -2228,7 +2277,7 @@ func AssignMoveToUser(db *sqlx.DB) http.HandlerFunc {
 		log.Printf("👤 [ASSIGN TO USER] Starting assignment for move request: %s", id)
 		if id == "" {
 			log.Printf("❌ [ASSIGN TO USER] Missing move request ID")
-			http.Error(w, "Missing move request ID", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Missing move request ID")
 			return
 		}
 
@@ -2237,7 +2286,7 @@ func AssignMoveToUser(db *sqlx.DB) http.HandlerFunc {
 		}
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			log.Printf("❌ [ASSIGN TO USER] Invalid request body: %v", err)
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
 			return
 		}
 
@@ -2245,43 +2294,52 @@ func AssignMoveToUser(db *sqlx.DB) http.HandlerFunc {
 
 		if req.UserID == "" {
 			log.Printf("❌ [ASSIGN TO USER] user_id is required but empty")
-			http.Error(w, "user_id is required", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "user_id is required")
 			return
 		}
 
 		// Fetch move request to check status
 		var moveRequest models.BinMoveRequest
-		err := db.Get(&moveRequest, `
-			SELECT id, bin_id, status, assignment_type
+		err := db.GetContext(r.Context(), &moveRequest, `
+			SELECT id, bin_id, status, assignment_type, assigned_shift_id
 			FROM bin_move_requests
 			WHERE id = $1
 		`, id)
 		if err != nil {
 			if err == sql.ErrNoRows {
 				log.Printf("❌ [ASSIGN TO USER] Move request not found: %s", id)
-				http.Error(w, "Move request not found", http.StatusNotFound)
+				utils.RespondError(w, http.StatusNotFound, "Move request not found")
 				return
 			}
 			log.Printf("❌ [ASSIGN TO USER] Error fetching move request: %v", err)
-			http.Error(w, "Failed to fetch move request", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch move request")
 			return
 		}
 
-		log.Printf("👤 [ASSIGN TO USER] Found move request - Status: %s, BinID: %s, CurrentType: %s", moveRequest.Status, moveRequest.BinID, moveRequest.AssignmentType)
+		log.Printf("👤 [ASSIGN TO USER] Found move request - Status: %s, BinID: %s, CurrentType: %v", moveRequest.Status, moveRequest.BinID, moveRequest.AssignmentType)
 
-		// Allow reassigning from any status except completed or cancelled
-		if moveRequest.Status == "completed" || moveRequest.Status == "cancelled" {
+		// Pending, assigned and in-progress moves can be assigned to a user
+		if moveRequest.Status == models.MoveStatusRequested {
+			utils.RespondErrorCode(w, http.StatusConflict, utils.CodeMoveAwaitingApproval, "Approve the move request before assigning it", nil)
+			return
+		}
+		if !checkMoveStatusTransition(w, moveRequest.Status, models.MoveStatusAssigned) {
 			log.Printf("❌ [ASSIGN TO USER] Cannot assign move request with status: %s", moveRequest.Status)
-			http.Error(w, fmt.Sprintf("Cannot reassign %s move request", moveRequest.Status), http.StatusBadRequest)
 			return
 		}
 
 		// Verify user exists
 		var userExists bool
-		err = db.Get(&userExists, "SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)", req.UserID)
+		err = db.GetContext(r.Context(), &userExists, "SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)", req.UserID)
 		if err != nil || !userExists {
 			log.Printf("❌ [ASSIGN TO USER] User not found: %s (error: %v, exists: %v)", req.UserID, err, userExists)
-			http.Error(w, "User not found", http.StatusNotFound)
+			utils.RespondError(w, http.StatusNotFound, "User not found")
+			return
+		}
+
+		if deactivated, err := database.IsUserDeactivated(db, req.UserID); err == nil && deactivated {
+			log.Printf("❌ [ASSIGN TO USER] User is deactivated: %s", req.UserID)
+			utils.RespondError(w, http.StatusBadRequest, "User is deactivated")
 			return
 		}
 
@@ -2289,62 +2347,44 @@ func AssignMoveToUser(db *sqlx.DB) http.HandlerFunc {
 
 		now := time.Now().Unix()
 
-		// Start transaction
-		tx, err := db.Beginx()
-		if err != nil {
-			log.Printf("❌ [ASSIGN TO USER] Failed to begin transaction: %v", err)
-			http.Error(w, "Failed to assign move request", http.StatusInternalServerError)
-			return
-		}
-		defer tx.Rollback()
+		// The route change and the assignment commit together
+		err = database.WithTx(r.Context(), db, func(tx *sqlx.Tx) error {
+			// If previously assigned to a shift, remove the move's stops from the route
+			if moveRequest.AssignedShiftID != nil {
+				log.Printf("👤 [ASSIGN TO USER] Removing bin from shift %s", *moveRequest.AssignedShiftID)
+				_, err = removeMoveFromShift(tx, *moveRequest.AssignedShiftID, moveRequest.ID, now)
+				if err != nil {
+					log.Printf("❌ [ASSIGN TO USER] Failed to remove from shift: %v", err)
+					return txFail(http.StatusInternalServerError, "Failed to remove from shift")
+				}
+			}
 
-		// If previously assigned to a shift, remove from shift_bins
-		if moveRequest.AssignedShiftID != nil {
-			log.Printf("👤 [ASSIGN TO USER] Removing bin from shift %s", *moveRequest.AssignedShiftID)
-			_, err = tx.Exec(`
-				DELETE FROM shift_bins
-				WHERE shift_id = $1 AND bin_id = $2
-			`, *moveRequest.AssignedShiftID, moveRequest.BinID)
+			// Update move request - clear shift assignment and set user assignment
+			result, err := tx.ExecContext(r.Context(), `
+				UPDATE bin_move_requests
+				SET assignment_type = 'manual',
+				    assigned_user_id = $1,
+				    assigned_shift_id = NULL,
+				    status = $2,
+				    updated_at = $3
+				WHERE id = $4 AND status = $5
+			`, req.UserID, models.MoveStatusAssigned, now, id, moveRequest.Status)
 			if err != nil {
-				log.Printf("❌ [ASSIGN TO USER] Failed to remove from shift_bins: %v", err)
-				http.Error(w, "Failed to remove from shift", http.StatusInternalServerError)
-				return
+				log.Printf("❌ [ASSIGN TO USER] Error updating move request: %v", err)
+				return txFail(http.StatusInternalServerError, "Failed to assign move request")
 			}
 
-			// Update shift total_bins count
-			_, err = tx.Exec(`
-				UPDATE shifts
-				SET total_bins = total_bins - 1, updated_at = $1
-				WHERE id = $2
-			`, now, *moveRequest.AssignedShiftID)
-			if err != nil {
-				log.Printf("❌ [ASSIGN TO USER] Failed to update shift count: %v", err)
+			rowsAffected, _ := result.RowsAffected()
+			log.Printf("👤 [ASSIGN TO USER] Update result - Rows affected: %d", rowsAffected)
+			if rowsAffected == 0 {
+				return txFailCode(http.StatusConflict, utils.CodeStaleUpdate,
+					"The move request changed while it was being assigned. Please refresh and try again.", nil)
 			}
-		}
 
-		// Update move request - clear shift assignment and set user assignment
-		result, err := tx.Exec(`
-			UPDATE bin_move_requests
-			SET assignment_type = 'manual',
-			    assigned_user_id = $1,
-			    assigned_shift_id = NULL,
-			    status = 'assigned',
-			    updated_at = $2
-			WHERE id = $3
-		`, req.UserID, now, id)
+			return nil
+		})
 		if err != nil {
-			log.Printf("❌ [ASSIGN TO USER] Error updating move request: %v", err)
-			http.Error(w, "Failed to assign move request", http.StatusInternalServerError)
-			return
-		}
-
-		rowsAffected, _ := result.RowsAffected()
-		log.Printf("👤 [ASSIGN TO USER] Update result - Rows affected: %d", rowsAffected)
-
-		// Commit transaction
-		if err := tx.Commit(); err != nil {
-			log.Printf("❌ [ASSIGN TO USER] Failed to commit transaction: %v", err)
-			http.Error(w, "Failed to assign move request", http.StatusInternalServerError)
+			respondTxError(w, err, "Failed to assign move request")
 			return
 		}
 
@@ -2357,14 +2397,14 @@ func AssignMoveToUser(db *sqlx.DB) http.HandlerFunc {
 		} else {
 			managerID := userClaims.UserID
 			var managerName string
-			err = db.Get(&managerName, `SELECT name FROM users WHERE id = $1`, managerID)
+			managerName, err = store.New(db).Users.Name(managerID)
 			if err != nil {
 				log.Printf("Warning: Failed to fetch manager name for history: %v", err)
 				managerName = "Unknown Manager"
 			}
 
 			var userName string
-			err = db.Get(&userName, `SELECT name FROM users WHERE id = $1`, req.UserID)
+			userName, err = store.New(db).Users.Name(req.UserID)
 			if err != nil {
 				log.Printf("Warning: Failed to fetch assigned user name for history: %v", err)
 				userName = "Unknown User"
@@ -2389,62 +2429,71 @@ func ManuallyCompleteMoveRequest(db *sqlx.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := chi.URLParam(r, "id")
 		if id == "" {
-			http.Error(w, "Missing move request ID", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Missing move request ID")
 			return
 		}
 
 		// Get user ID from context (person completing the move)
 		userClaims, ok := middleware.GetUserFromContext(r)
 		if !ok {
-			http.Error(w, "User not authenticated", http.StatusUnauthorized)
+			utils.RespondError(w, http.StatusUnauthorized, "User not authenticated")
 			return
 		}
 		userID := userClaims.UserID
 
 		// Fetch move request
 		var moveRequest models.BinMoveRequest
-		err := db.Get(&moveRequest, `SELECT * FROM bin_move_requests WHERE id = $1`, id)
+		err := db.GetContext(r.Context(), &moveRequest, `SELECT * FROM bin_move_requests WHERE id = $1`, id)
 		if err != nil {
 			if err == sql.ErrNoRows {
-				http.Error(w, "Move request not found", http.StatusNotFound)
+				utils.RespondError(w, http.StatusNotFound, "Move request not found")
 				return
 			}
 			log.Printf("Error fetching move request: %v", err)
-			http.Error(w, "Failed to fetch move request", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch move request")
 			return
 		}
 
 		// Verify this is a manual move
 		if moveRequest.AssignmentType == nil || *moveRequest.AssignmentType != "manual" {
-			http.Error(w, "This endpoint is only for manual moves. Use shift completion flow for shift-based moves.", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "This endpoint is only for manual moves. Use shift completion flow for shift-based moves.")
 			return
 		}
 
-		// Only allow completing assigned or in_progress manual moves
-		if moveRequest.Status != "assigned" && moveRequest.Status != "in_progress" {
-			http.Error(w, fmt.Sprintf("Cannot complete move request with status: %s", moveRequest.Status), http.StatusBadRequest)
+		// Only assigned, in-progress or picked-up manual moves can be completed
+		if moveRequest.Status == models.MoveStatusCompleted {
+			respondMoveStatusConflict(w, moveRequest.Status, models.MoveStatusCompleted, "Move request already completed")
+			return
+		}
+		if !checkMoveStatusTransition(w, moveRequest.Status, models.MoveStatusCompleted) {
 			return
 		}
 
 		now := time.Now().Unix()
 
-		// Mark move request as completed
-		_, err = db.Exec(`
+		// Mark move request as completed (unless it changed since it was read)
+		result, err := db.ExecContext(r.Context(), `
 			UPDATE bin_move_requests
-			SET status = 'completed', completed_at = $1, updated_at = $1
-			WHERE id = $2
-		`, now, moveRequest.ID)
+			SET status = $1, completed_at = $2, updated_at = $2
+			WHERE id = $3 AND status = $4
+		`, models.MoveStatusCompleted, now, moveRequest.ID, moveRequest.Status)
 		if err != nil {
 			log.Printf("Error completing move request: %v", err)
-			http.Error(w, "Failed to complete move request", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to complete move request")
+			return
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			utils.RespondErrorCode(w, http.StatusConflict, utils.CodeStaleUpdate,
+				"The move request changed while it was being completed. Please refresh and try again.", nil)
 			return
 		}
 
 		log.Printf("[MANUAL MOVE] ✅ Move request marked as completed")
+		emitMoveRequestCompletedWebhook(db, moveRequest, userID, now)
 
 		// Log history: move request manually completed by manager
 		var managerName string
-		err = db.Get(&managerName, `SELECT name FROM users WHERE id = $1`, userID)
+		managerName, err = store.New(db).Users.Name(userID)
 		if err != nil {
 			log.Printf("Warning: Failed to fetch manager name for history: %v", err)
 			managerName = "Unknown Manager"
@@ -2467,61 +2516,32 @@ func ManuallyCompleteMoveRequest(db *sqlx.DB) http.HandlerFunc {
 				}
 			}
 
-			_, err = db.Exec(`
+			_, err = db.ExecContext(r.Context(), `
 				UPDATE bins
 				SET status = $1, updated_at = $2
 				WHERE id = $3
 			`, newStatus, now, moveRequest.BinID)
 			if err != nil {
 				log.Printf("Error updating bin status: %v", err)
-				http.Error(w, "Failed to update bin status", http.StatusInternalServerError)
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to update bin status")
 				return
 			}
 
+			store.InvalidateBins(moveRequest.BinID)
 			log.Printf("[MANUAL MOVE] ✅ Bin status updated to %s", newStatus)
+			if newStatus == "retired" {
+				emitBinRetiredWebhook(db, moveRequest.BinID, userID, &moveRequest.ID, moveRequest.Reason, now)
+			}
 
 		} else if moveRequest.MoveType == "relocation" {
 			// Update bin location to new coordinates
 			log.Printf("[MANUAL MOVE]    → Relocating bin to new address")
 
-			// Parse address into separate fields
-			var fromStreet, fromCity, fromZip, toStreet, toCity, toZip *string
-
-			// Parse original address
-			if moveRequest.OriginalAddress != "" {
-				parts := strings.Split(moveRequest.OriginalAddress, ", ")
-				if len(parts) >= 2 {
-					street := parts[0]
-					fromStreet = &street
-					cityZip := strings.TrimSpace(parts[1])
-					cityZipParts := strings.Split(cityZip, " ")
-					if len(cityZipParts) >= 2 {
-						city := strings.Join(cityZipParts[:len(cityZipParts)-1], " ")
-						zip := cityZipParts[len(cityZipParts)-1]
-						fromCity = &city
-						fromZip = &zip
-					}
-				}
-			}
-
-			// Parse new address
-			if moveRequest.NewAddress != nil {
-				parts := strings.Split(*moveRequest.NewAddress, ", ")
-				if len(parts) >= 2 {
-					street := parts[0]
-					toStreet = &street
-					cityZip := strings.TrimSpace(parts[1])
-					cityZipParts := strings.Split(cityZip, " ")
-					if len(cityZipParts) >= 2 {
-						city := strings.Join(cityZipParts[:len(cityZipParts)-1], " ")
-						zip := cityZipParts[len(cityZipParts)-1]
-						toCity = &city
-						toZip = &zip
-					}
-				}
-			}
+			// Address parts stored with the move request
+			fromStreet, fromCity, fromZip := moveRequest.OriginalStreet, moveRequest.OriginalCity, moveRequest.OriginalZip
+			toStreet, toCity, toZip := moveRequest.NewStreet, moveRequest.NewCity, moveRequest.NewZip
 
-			_, err = db.Exec(`
+			_, err = db.ExecContext(r.Context(), `
 				UPDATE bins
 				SET latitude = $1,
 				    longitude = $2,
@@ -2536,19 +2556,21 @@ func ManuallyCompleteMoveRequest(db *sqlx.DB) http.HandlerFunc {
 				moveRequest.BinID)
 			if err != nil {
 				log.Printf("Error relocating bin: %v", err)
-				http.Error(w, "Failed to relocate bin", http.StatusInternalServerError)
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to relocate bin")
 				return
 			}
+			store.InvalidateBins(moveRequest.BinID)
 
 			// Record the move in moves table with manual flag
-			_, err = db.Exec(`
+			_, err = db.ExecContext(r.Context(), `
 				INSERT INTO moves (
 					bin_id, moved_from, moved_to, moved_on,
 					move_type, from_street, from_city, from_zip,
 					to_street, to_city, to_zip,
-					move_request_id, completed_by_user_id
+					move_request_id, completed_by_user_id,
+					from_latitude, from_longitude, to_latitude, to_longitude
 				)
-				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
 			`, moveRequest.BinID,
 				moveRequest.OriginalAddress,
 				*moveRequest.NewAddress,
@@ -2557,7 +2579,9 @@ func ManuallyCompleteMoveRequest(db *sqlx.DB) http.HandlerFunc {
 				fromStreet, fromCity, fromZip,
 				toStreet, toCity, toZip,
 				moveRequest.ID,
-				userID)
+				userID,
+				moveRequest.OriginalLatitude, moveRequest.OriginalLongitude,
+				moveRequest.NewLatitude, moveRequest.NewLongitude)
 			if err != nil {
 				log.Printf("[MANUAL MOVE] ⚠️  Failed to record move: %v", err)
 				// Don't fail - move is already completed
@@ -2584,13 +2608,13 @@ func ClearMoveAssignment(db *sqlx.DB) http.HandlerFunc {
 		log.Printf("🔄 [CLEAR ASSIGNMENT] Starting for move request: %s", id)
 		if id == "" {
 			log.Printf("❌ [CLEAR ASSIGNMENT] Missing move request ID")
-			http.Error(w, "Missing move request ID", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Missing move request ID")
 			return
 		}
 
 		// Fetch move request to check current assignment
 		var moveRequest models.BinMoveRequest
-		err := db.Get(&moveRequest, `
+		err := db.GetContext(r.Context(), &moveRequest, `
 			SELECT id, bin_id, status, assignment_type, assigned_shift_id, assigned_user_id
 			FROM bin_move_requests
 			WHERE id = $1
@@ -2598,21 +2622,23 @@ func ClearMoveAssignment(db *sqlx.DB) http.HandlerFunc {
 		if err != nil {
 			if err == sql.ErrNoRows {
 				log.Printf("❌ [CLEAR ASSIGNMENT] Move request not found: %s", id)
-				http.Error(w, "Move request not found", http.StatusNotFound)
+				utils.RespondError(w, http.StatusNotFound, "Move request not found")
 				return
 			}
 			log.Printf("❌ [CLEAR ASSIGNMENT] Error fetching move request: %v", err)
-			http.Error(w, "Failed to fetch move request", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch move request")
 			return
 		}
 
-		log.Printf("🔄 [CLEAR ASSIGNMENT] Current state - Status: %s, Type: %s, ShiftID: %v, UserID: %v",
+		log.Printf("🔄 [CLEAR ASSIGNMENT] Current state - Status: %s, Type: %v, ShiftID: %v, UserID: %v",
 			moveRequest.Status, moveRequest.AssignmentType, moveRequest.AssignedShiftID, moveRequest.AssignedUserID)
 
-		// Only allow clearing assignments from pending or assigned moves
-		if moveRequest.Status != "pending" && moveRequest.Status != "assigned" {
+		// Only pending or assigned moves can be unassigned (in-progress ones go through UpdateBinMoveRequest's
+		// in_progress_action so the driver's route is handled)
+		if moveRequest.Status != models.MoveStatusPending && moveRequest.Status != models.MoveStatusAssigned {
 			log.Printf("❌ [CLEAR ASSIGNMENT] Cannot clear assignment from status: %s", moveRequest.Status)
-			http.Error(w, fmt.Sprintf("Cannot clear assignment from %s move request", moveRequest.Status), http.StatusBadRequest)
+			respondMoveStatusConflict(w, moveRequest.Status, models.MoveStatusPending,
+				fmt.Sprintf("Cannot clear assignment from %s move request", moveRequest.Status))
 			return
 		}
 
@@ -2628,64 +2654,53 @@ func ClearMoveAssignment(db *sqlx.DB) http.HandlerFunc {
 
 		now := time.Now().Unix()
 
-		// Start transaction
-		tx, err := db.Beginx()
+		undoSnapshot, err := captureMoveRequestUndo(db, id)
 		if err != nil {
-			log.Printf("❌ [CLEAR ASSIGNMENT] Failed to begin transaction: %v", err)
-			http.Error(w, "Failed to clear assignment", http.StatusInternalServerError)
-			return
+			log.Printf("⚠️  [CLEAR ASSIGNMENT] Failed to capture undo snapshot: %v", err)
 		}
-		defer tx.Rollback()
 
-		// If assigned to a shift, remove from shift_bins
-		if moveRequest.AssignedShiftID != nil {
-			log.Printf("🔄 [CLEAR ASSIGNMENT] Removing bin from shift %s", *moveRequest.AssignedShiftID)
-			_, err = tx.Exec(`
-				DELETE FROM shift_bins
-				WHERE shift_id = $1 AND bin_id = $2
-			`, *moveRequest.AssignedShiftID, moveRequest.BinID)
-			if err != nil {
-				log.Printf("❌ [CLEAR ASSIGNMENT] Failed to remove from shift_bins: %v", err)
-				http.Error(w, "Failed to remove from shift", http.StatusInternalServerError)
-				return
+		// The route change and the cleared assignment commit together
+		err = database.WithTx(r.Context(), db, func(tx *sqlx.Tx) error {
+			// If assigned to a shift, remove the move's stops from the route
+			if moveRequest.AssignedShiftID != nil {
+				log.Printf("🔄 [CLEAR ASSIGNMENT] Removing bin from shift %s", *moveRequest.AssignedShiftID)
+				_, err = removeMoveFromShift(tx, *moveRequest.AssignedShiftID, moveRequest.ID, now)
+				if err != nil {
+					log.Printf("❌ [CLEAR ASSIGNMENT] Failed to remove from shift: %v", err)
+					return txFail(http.StatusInternalServerError, "Failed to remove from shift")
+				}
 			}
 
-			// Update shift total_bins count
-			_, err = tx.Exec(`
-				UPDATE shifts
-				SET total_bins = total_bins - 1, updated_at = $1
-				WHERE id = $2
-			`, now, *moveRequest.AssignedShiftID)
+			// Clear all assignments and reset to pending (unless the status changed since it was read)
+			result, err := tx.ExecContext(r.Context(), `
+				UPDATE bin_move_requests
+				SET assignment_type = '',
+				    assigned_shift_id = NULL,
+				    assigned_user_id = NULL,
+				    status = $1,
+				    updated_at = $2
+				WHERE id = $3 AND status = $4
+			`, models.MoveStatusPending, now, id, moveRequest.Status)
 			if err != nil {
-				log.Printf("❌ [CLEAR ASSIGNMENT] Failed to update shift count: %v", err)
+				log.Printf("❌ [CLEAR ASSIGNMENT] Error clearing assignment: %v", err)
+				return txFail(http.StatusInternalServerError, "Failed to clear assignment")
+			}
+			if rows, _ := result.RowsAffected(); rows == 0 {
+				return txFailCode(http.StatusConflict, utils.CodeStaleUpdate,
+					"The move request changed while its assignment was being cleared. Please refresh and try again.", nil)
 			}
-		}
 
-		// Clear all assignments and reset to pending
-		_, err = tx.Exec(`
-			UPDATE bin_move_requests
-			SET assignment_type = '',
-			    assigned_shift_id = NULL,
-			    assigned_user_id = NULL,
-			    status = 'pending',
-			    updated_at = $1
-			WHERE id = $2
-		`, now, id)
+			return nil
+		})
 		if err != nil {
-			log.Printf("❌ [CLEAR ASSIGNMENT] Error clearing assignment: %v", err)
-			http.Error(w, "Failed to clear assignment", http.StatusInternalServerError)
-			return
-		}
-
-		// Commit transaction
-		if err := tx.Commit(); err != nil {
-			log.Printf("❌ [CLEAR ASSIGNMENT] Failed to commit transaction: %v", err)
-			http.Error(w, "Failed to clear assignment", http.StatusInternalServerError)
+			respondTxError(w, err, "Failed to clear assignment")
 			return
 		}
 
 		log.Printf("✅ [CLEAR ASSIGNMENT] Assignment cleared successfully for move request %s", id)
 
+		var undo *models.UndoOperation
+
 		// Log history: move request unassigned
 		userClaims, ok := middleware.GetUserFromContext(r)
 		if !ok {
@@ -2693,7 +2708,7 @@ func ClearMoveAssignment(db *sqlx.DB) http.HandlerFunc {
 		} else {
 			managerID := userClaims.UserID
 			var managerName string
-			err = db.Get(&managerName, `SELECT name FROM users WHERE id = $1`, managerID)
+			managerName, err = store.New(db).Users.Name(managerID)
 			if err != nil {
 				log.Printf("Warning: Failed to fetch manager name for history: %v", err)
 				managerName = "Unknown Manager"
@@ -2707,7 +2722,7 @@ func ClearMoveAssignment(db *sqlx.DB) http.HandlerFunc {
 			if moveRequest.AssignedUserID != nil {
 				previousUserID = moveRequest.AssignedUserID
 				var userName string
-				err = db.Get(&userName, `SELECT name FROM users WHERE id = $1`, *moveRequest.AssignedUserID)
+				userName, err = store.New(db).Users.Name(*moveRequest.AssignedUserID)
 				if err == nil {
 					previousUserName = &userName
 				}
@@ -2722,11 +2737,20 @@ func ClearMoveAssignment(db *sqlx.DB) http.HandlerFunc {
 			if err != nil {
 				log.Printf("Warning: Failed to log move request unassignment: %v", err)
 			}
+
+			// Offer an undo for the configured window (see UndoOperation)
+			if undoSnapshot != nil {
+				undoSnapshot.AppliedStatus = models.MoveStatusPending
+				undoSnapshot.AppliedAt = now
+				undo = recordUndo(db, models.UndoMoveRequestClearAssignment, "move_request", id,
+					fmt.Sprintf("Cleared the assignment of the move request for bin #%d", undoSnapshot.BinNumber), undoSnapshot, managerID, now)
+			}
 		}
 
 		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(map[string]string{
+		json.NewEncoder(w).Encode(map[string]interface{}{
 			"message": "Assignment cleared successfully",
+			"undo":    undo,
 		})
 	}
 }
@@ -2737,7 +2761,7 @@ func GetMoveRequestHistory(db *sqlx.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := chi.URLParam(r, "id")
 		if id == "" {
-			http.Error(w, "Missing move request ID", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Missing move request ID")
 			return
 		}
 
@@ -2745,7 +2769,7 @@ func GetMoveRequestHistory(db *sqlx.DB) http.HandlerFunc {
 		history, err := helpers.GetMoveRequestHistory(db, id)
 		if err != nil {
 			log.Printf("Error fetching move request history: %v", err)
-			http.Error(w, "Failed to fetch history", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch history")
 			return
 		}
 
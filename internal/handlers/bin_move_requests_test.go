@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/testdb"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// moveAssignment reads back where a move request ended up
+func moveAssignment(t *testing.T, db *sqlx.DB, moveID string) (status string, shiftID *string, stops int) {
+	t.Helper()
+	err := db.QueryRow(`SELECT status, assigned_shift_id FROM bin_move_requests WHERE id = $1`, moveID).Scan(&status, &shiftID)
+	if err != nil {
+		t.Fatalf("failed to read move request: %v", err)
+	}
+	if err := db.Get(&stops, `SELECT COUNT(*) FROM route_tasks WHERE move_request_id = $1`, moveID); err != nil {
+		t.Fatalf("failed to count stops: %v", err)
+	}
+	return status, shiftID, stops
+}
+
+func TestAssignMoveToShift(t *testing.T) {
+	db := testdb.Open(t)
+	manager := testdb.User(t, db, "admin")
+	shiftID := testdb.Shift(t, db, testdb.User(t, db, "driver"), "ready")
+	moveID := testdb.MoveRequest(t, db, testdb.Bin(t, db), manager, models.MoveStatusPending)
+
+	// A preview makes the changes and rolls them back
+	preview, err := assignMoveToShift(context.Background(), db, nil, moveID, &shiftID, nil, nil, manager, "Test admin", true)
+	if err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	if preview == nil {
+		t.Fatal("preview: got no proposed route")
+	}
+	if status, assigned, stops := moveAssignment(t, db, moveID); status != models.MoveStatusPending || assigned != nil || stops != 0 {
+		t.Fatalf("after preview: status %s, shift %v, %d stops; want it untouched", status, assigned, stops)
+	}
+
+	if _, err := assignMoveToShift(context.Background(), db, nil, moveID, &shiftID, nil, nil, manager, "Test admin", false); err != nil {
+		t.Fatalf("assign: %v", err)
+	}
+	status, assigned, stops := moveAssignment(t, db, moveID)
+	if status != models.MoveStatusAssigned || assigned == nil || *assigned != shiftID || stops != 1 {
+		t.Fatalf("after assign: status %s, shift %v, %d stops; want assigned to %s with 1 stop", status, assigned, stops, shiftID)
+	}
+}
+
+func TestAssignMoveToShiftAwaitingApproval(t *testing.T) {
+	db := testdb.Open(t)
+	manager := testdb.User(t, db, "admin")
+	shiftID := testdb.Shift(t, db, testdb.User(t, db, "driver"), "ready")
+	moveID := testdb.MoveRequest(t, db, testdb.Bin(t, db), manager, models.MoveStatusRequested)
+
+	_, err := assignMoveToShift(context.Background(), db, nil, moveID, &shiftID, nil, nil, manager, "Test admin", false)
+	var txErr *txError
+	if !errors.As(err, &txErr) || txErr.status != http.StatusConflict || txErr.code != utils.CodeMoveAwaitingApproval {
+		t.Fatalf("assign a requested move: got %v, want 409 %s", err, utils.CodeMoveAwaitingApproval)
+	}
+	if status, assigned, stops := moveAssignment(t, db, moveID); status != models.MoveStatusRequested || assigned != nil || stops != 0 {
+		t.Fatalf("after refused assign: status %s, shift %v, %d stops; want it untouched", status, assigned, stops)
+	}
+}
+
+func TestAssignMoveToShiftNotFound(t *testing.T) {
+	db := testdb.Open(t)
+	manager := testdb.User(t, db, "admin")
+	shiftID := testdb.Shift(t, db, testdb.User(t, db, "driver"), "ready")
+
+	_, err := assignMoveToShift(context.Background(), db, nil, "no-such-move", &shiftID, nil, nil, manager, "Test admin", false)
+	var txErr *txError
+	if !errors.As(err, &txErr) || txErr.status != http.StatusNotFound {
+		t.Fatalf("assign a missing move: got %v, want 404", err)
+	}
+}
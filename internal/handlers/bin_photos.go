@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ropacal-backend/internal/models"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+)
+
+// Gallery page sizes for GET /api/bins/{id}/photos
+const (
+	binPhotosDefaultLimit = 50
+	binPhotosMaxLimit     = 200
+)
+
+// GetBinPhotos returns a bin's photos from checks, incident reports and maintenance, newest first
+// GET /api/bins/{id}/photos?from=<unix>&to=<unix>&source=check|incident|maintenance&limit=50&offset=0
+func GetBinPhotos(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		binID := chi.URLParam(r, "id")
+		q := r.URL.Query()
+
+		limit := binPhotosDefaultLimit
+		if parsed, err := strconv.Atoi(q.Get("limit")); err == nil && parsed > 0 && parsed <= binPhotosMaxLimit {
+			limit = parsed
+		}
+		offset := 0
+		if parsed, err := strconv.Atoi(q.Get("offset")); err == nil && parsed >= 0 {
+			offset = parsed
+		}
+
+		args := []interface{}{binID}
+		whereClause := []string{"bp.bin_id = $1"}
+
+		for _, bound := range []struct {
+			param string
+			op    string
+		}{{"from", ">="}, {"to", "<="}} {
+			v := q.Get(bound.param)
+			if v == "" {
+				continue
+			}
+			parsed, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				utils.RespondError(w, http.StatusBadRequest, fmt.Sprintf("Invalid %s timestamp", bound.param))
+				return
+			}
+			args = append(args, parsed)
+			whereClause = append(whereClause, fmt.Sprintf("bp.taken_at %s $%d", bound.op, len(args)))
+		}
+
+		if source := q.Get("source"); source != "" {
+			if source != models.BinPhotoSourceCheck && source != models.BinPhotoSourceIncident && source != models.BinPhotoSourceMaintenance {
+				utils.RespondError(w, http.StatusBadRequest, "source must be check, incident or maintenance")
+				return
+			}
+			args = append(args, source)
+			whereClause = append(whereClause, fmt.Sprintf("bp.source = $%d", len(args)))
+		}
+
+		where := " WHERE " + strings.Join(whereClause, " AND ")
+
+		var exists bool
+		if err := db.GetContext(r.Context(), &exists, `SELECT EXISTS(SELECT 1 FROM bins WHERE id = $1)`, binID); err != nil {
+			log.Printf("❌ [BIN PHOTOS] Failed to look up bin %s: %v", binID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch photos")
+			return
+		}
+		if !exists {
+			utils.RespondError(w, http.StatusNotFound, "Bin not found")
+			return
+		}
+
+		page := models.BinPhotoPage{Photos: []models.BinPhoto{}, Limit: limit, Offset: offset}
+		if err := db.GetContext(r.Context(), &page.Total, `SELECT COUNT(*) FROM bin_photos bp`+where, args...); err != nil {
+			log.Printf("❌ [BIN PHOTOS] Failed to count photos for bin %s: %v", binID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch photos")
+			return
+		}
+
+		pageArgs := append(args, limit, offset)
+		query := `
+			SELECT bp.bin_id, bp.photo_url, bp.source, bp.source_id, bp.taken_at, bp.taken_by,
+			       u.name AS taken_by_name, bp.caption
+			FROM bin_photos bp
+			LEFT JOIN users u ON u.id = bp.taken_by` + where +
+			fmt.Sprintf(" ORDER BY bp.taken_at DESC, bp.source, bp.source_id LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+
+		if err := db.SelectContext(r.Context(), &page.Photos, query, pageArgs...); err != nil {
+			log.Printf("❌ [BIN PHOTOS] Failed to fetch photos for bin %s: %v", binID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch photos")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    page,
+		})
+	}
+}
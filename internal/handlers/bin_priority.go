@@ -1,107 +1,139 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 	"strconv"
 	"time"
 
 	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/store"
+	"ropacal-backend/pkg/utils"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 // BinWithPriority extends Bin with calculated priority score and metadata
 type BinWithPriority struct {
 	models.Bin
-	PriorityScore          float64 `json:"priority_score"`
-	DaysSinceCheck         *int    `json:"days_since_check,omitempty"`
-	NextMoveRequestDate    *int64  `json:"next_move_request_date,omitempty"`
-	MoveRequestUrgency     *string `json:"move_request_urgency,omitempty"`
-	HasPendingMove         bool    `json:"has_pending_move"`
-	HasCheckRecommendation bool    `json:"has_check_recommendation"`
+	PriorityScore          float64 `json:"priority_score" db:"priority_score"`
+	DaysSinceCheck         *int    `json:"days_since_check,omitempty" db:"days_since_check"`
+	NextMoveRequestDate    *int64  `json:"next_move_request_date,omitempty" db:"next_move_request_date"`
+	MoveRequestUrgency     *string `json:"move_request_urgency,omitempty" db:"move_request_urgency"`
+	HasPendingMove         bool    `json:"has_pending_move" db:"has_pending_move"`
+	HasCheckRecommendation bool    `json:"has_check_recommendation" db:"has_check_recommendation"`
+	FillLevel              *string `json:"fill_level,omitempty" db:"-"` // normal, warning or critical (see models.FillThresholds)
 }
 
-// calculateBinPriority computes a weighted priority score for a bin
-// Higher score = higher priority
+// binPriorityScoreSQL returns a SQL expression computing the weighted priority score
+// for a row of binPriorityBaseSQL. Higher score = higher priority
 //
-// Scoring factors:
-// 1. Move requests (urgent: +1000, scheduled within 7 days: +500)
-// 2. Fill percentage (>80%: +300, >60%: +150, >40%: +50)
-// 3. Days since check (7+ days: +200, 14+ days: +400, 30+ days: +800)
+// Scoring factors (default weights, configurable via settings):
+// 1. Move requests (urgent: +1000, due within 1/3/7 days: +800/+600/+400, later: +100)
+// 2. Fill percentage (>=critical threshold, 80%: +300, >=warning threshold, 60%: +150, >=40%: +50)
+// 3. Days since check (7+ days: +200, 14+ days: +400, 30+ days: +800, never: +1000)
 // 4. Check recommendations (+100)
-func calculateBinPriority(bin models.Bin, moveRequest *models.BinMoveRequest, hasCheckRec bool, now int64) (float64, *int) {
-	score := 0.0
-	var daysSinceCheck *int
-
-	// Factor 1: Move requests (highest priority)
-	if moveRequest != nil && (moveRequest.Status == "pending" || moveRequest.Status == "in_progress") {
-		if moveRequest.Urgency == "urgent" {
-			score += 1000.0
-		} else {
-			// Scheduled move - check how soon
-			daysUntilMove := (moveRequest.ScheduledDate - now) / 86400
-			if daysUntilMove <= 1 {
-				score += 800.0 // Tomorrow or today
-			} else if daysUntilMove <= 3 {
-				score += 600.0 // Within 3 days
-			} else if daysUntilMove <= 7 {
-				score += 400.0 // Within a week
-			} else {
-				score += 100.0 // Future scheduled
-			}
-		}
-	}
-
-	// Factor 2: Fill percentage
-	if bin.FillPercentage != nil {
-		fill := *bin.FillPercentage
-		if fill >= 80 {
-			score += 300.0
-		} else if fill >= 60 {
-			score += 150.0
-		} else if fill >= 40 {
-			score += 50.0
-		}
-	}
-
-	// Factor 3: Days since last check
-	if bin.LastCheckedAt != nil {
-		daysSince := int((now - *bin.LastCheckedAt) / 86400)
-		daysSinceCheck = &daysSince
-
-		if daysSince >= 30 {
-			score += 800.0
-		} else if daysSince >= 14 {
-			score += 400.0
-		} else if daysSince >= 7 {
-			score += 200.0
-		}
-	} else {
-		// Never checked - highest time priority
-		daysSince := int((now - bin.CreatedAt) / 86400)
-		daysSinceCheck = &daysSince
-		score += 1000.0
-	}
-
-	// Factor 4: Check recommendations
-	if hasCheckRec {
-		score += 100.0
+// 5. Maintenance due (+150)
+//
+// Weights and thresholds are appended to args as query parameters
+func binPriorityScoreSQL(weights models.PriorityWeights, thresholds models.FillThresholds, now int64, args *[]interface{}) string {
+	param := func(v interface{}, cast string) string {
+		*args = append(*args, v)
+		return fmt.Sprintf("$%d::%s", len(*args), cast)
 	}
-
-	return score, daysSinceCheck
+	score := func(v float64) string { return param(v, "DOUBLE PRECISION") }
+	threshold := func(v int) string { return param(v, "INT") }
+
+	nowParam := param(now, "BIGINT")
+
+	return `(
+		-- Factor 1: Move requests (highest priority)
+		CASE
+			WHEN NOT p.has_pending_move THEN 0.0
+			WHEN p.move_request_urgency = 'urgent' THEN ` + score(weights.UrgentMoveScore) + `
+			WHEN (p.next_move_request_date - ` + nowParam + `) / 86400 <= 1 THEN ` + score(weights.MoveDue1DayScore) + `
+			WHEN (p.next_move_request_date - ` + nowParam + `) / 86400 <= 3 THEN ` + score(weights.MoveDue3DaysScore) + `
+			WHEN (p.next_move_request_date - ` + nowParam + `) / 86400 <= 7 THEN ` + score(weights.MoveDue7DaysScore) + `
+			ELSE ` + score(weights.MoveScheduledScore) + `
+		END
+		-- Factor 2: Fill percentage
+		+ CASE
+			WHEN p.fill_percentage IS NULL THEN 0.0
+			WHEN p.fill_percentage >= ` + threshold(thresholds.Critical) + ` THEN ` + score(weights.HighFillScore) + `
+			WHEN p.fill_percentage >= ` + threshold(thresholds.Warning) + ` THEN ` + score(weights.MediumFillScore) + `
+			WHEN p.fill_percentage >= ` + threshold(weights.LowFillThreshold) + ` THEN ` + score(weights.LowFillScore) + `
+			ELSE 0.0
+		END
+		-- Factor 3: Days since last check (never checked = highest time priority)
+		+ CASE
+			WHEN p.last_checked_at IS NULL THEN ` + score(weights.NeverCheckedScore) + `
+			WHEN p.days_since_check >= ` + threshold(weights.CriticalUncheckedDays) + ` THEN ` + score(weights.CriticalUncheckedScore) + `
+			WHEN p.days_since_check >= ` + threshold(weights.HighUncheckedDays) + ` THEN ` + score(weights.HighUncheckedScore) + `
+			WHEN p.days_since_check >= ` + threshold(weights.StaleUncheckedDays) + ` THEN ` + score(weights.StaleUncheckedScore) + `
+			ELSE 0.0
+		END
+		-- Factor 4: Check recommendations
+		+ CASE WHEN p.has_check_recommendation THEN ` + score(weights.CheckRecommendationScore) + ` ELSE 0.0 END
+		-- Factor 5: Scheduled maintenance that is due
+		+ CASE WHEN p.maintenance_due THEN ` + score(weights.MaintenanceDueScore) + ` ELSE 0.0 END
+	)::DOUBLE PRECISION`
 }
 
+// binPriorityBaseSQL selects bins joined with their most pressing open move request
+// and pending check recommendation flag. $1 must be the current unix time
+const binPriorityBaseSQL = `
+	SELECT
+		b.*,
+		mr.scheduled_date AS next_move_request_date,
+		mr.urgency AS move_request_urgency,
+		(mr.id IS NOT NULL) AS has_pending_move,
+		EXISTS (
+			SELECT 1 FROM bin_check_recommendations bcr
+			WHERE bcr.bin_id = b.id
+			AND bcr.status = 'pending'
+		) AS has_check_recommendation,
+		EXISTS (
+			SELECT 1 FROM bin_maintenance bm
+			WHERE bm.bin_id = b.id
+			AND bm.status = 'scheduled'
+			AND bm.scheduled_for <= $1::BIGINT
+		) AS maintenance_due,
+		(($1::BIGINT - COALESCE(b.last_checked_at, b.created_at)) / 86400)::INT AS days_since_check
+	FROM bins b
+	LEFT JOIN LATERAL (
+		SELECT id, scheduled_date, urgency
+		FROM bin_move_requests m
+		WHERE m.bin_id = b.id
+		AND m.status IN ('pending', 'in_progress', 'picked_up')
+		ORDER BY (m.urgency = 'urgent') DESC, m.scheduled_date ASC
+		LIMIT 1
+	) mr ON true`
+
 // GetBinsWithPriority returns bins with priority scores and filtering
+// Scoring, filtering, sorting and limiting all happen in a single SQL query
 // Query params:
 //   - sort: priority (default), bin_number, fill_percentage, days_since_check
-//   - filter: next_move_request, longest_unchecked, high_fill, has_check_recommendation, all (default)
+//   - filter: next_move_request, longest_unchecked, high_fill, has_check_recommendation, maintenance_due, all (default)
 //   - status: active (default), all, retired, pending_move, in_storage
+//   - area_id: only bins assigned to this area
+//   - tag_id: comma-separated tag IDs; only bins with any of them
 //   - limit: max results (default: 100)
+//   - offset: results to skip, for pagination (default: 0)
+//   - view_id: a saved view whose filters and sort apply unless overridden by the params above
+//
+// Response: { bins, weights, fill_thresholds } - the effective scoring weights and fill thresholds the scores came from
 func GetBinsWithPriority(db *sqlx.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		if status, msg := applySavedView(db, r, models.SavedViewEntityBins); status != 0 {
+			utils.RespondError(w, status, msg)
+			return
+		}
+
 		sortBy := r.URL.Query().Get("sort")
 		if sortBy == "" {
 			sortBy = "priority"
@@ -125,181 +157,106 @@ func GetBinsWithPriority(db *sqlx.DB) http.HandlerFunc {
 			}
 		}
 
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
 		now := time.Now().Unix()
+		weights := loadPriorityWeights(db)
+		thresholds := loadFillThresholds(db)
 
 		log.Printf("[GET-BINS-PRIORITY] Fetching bins (sort=%s, filter=%s, status=%s, limit=%d)", sortBy, filter, status, limit)
 
-		// Build base query
-		query := `SELECT * FROM bins WHERE 1=1`
+		// $1 = now (shared by base query and score expression)
 		args := []interface{}{}
+		scoreExpr := binPriorityScoreSQL(weights, thresholds, now, &args)
+
+		query := `SELECT p.*, ` + scoreExpr + ` AS priority_score
+			FROM (` + binPriorityBaseSQL + `) p
+			WHERE 1=1`
 
 		// Status filter
 		if status != "all" {
-			query += ` AND status = $1`
 			args = append(args, status)
+			query += fmt.Sprintf(` AND p.status = $%d`, len(args))
 		}
 
-		var bins []models.Bin
-		if len(args) > 0 {
-			if err := db.Select(&bins, query, args...); err != nil {
-				log.Printf("❌ [GET-BINS-PRIORITY] Database query failed: %v", err)
-				http.Error(w, "Failed to fetch bins", http.StatusInternalServerError)
-				return
-			}
-		} else {
-			if err := db.Select(&bins, query); err != nil {
-				log.Printf("❌ [GET-BINS-PRIORITY] Database query failed: %v", err)
-				http.Error(w, "Failed to fetch bins", http.StatusInternalServerError)
-				return
-			}
-		}
-
-		// Fetch all pending move requests
-		var moveRequests []models.BinMoveRequest
-		err := db.Select(&moveRequests, `
-			SELECT * FROM bin_move_requests
-			WHERE status IN ('pending', 'in_progress')
-		`)
-		if err != nil {
-			log.Printf("⚠️  [GET-BINS-PRIORITY] Failed to fetch move requests: %v", err)
-		}
-
-		// Create map for quick lookup
-		moveRequestMap := make(map[string]*models.BinMoveRequest)
-		for i := range moveRequests {
-			moveRequestMap[moveRequests[i].BinID] = &moveRequests[i]
-		}
-
-		// Fetch bins with pending check recommendations
-		var checkRecBinIDs []string
-		err = db.Select(&checkRecBinIDs, `
-			SELECT DISTINCT bin_id FROM bin_check_recommendations
-			WHERE status = 'pending'
-		`)
-		if err != nil {
-			log.Printf("⚠️  [GET-BINS-PRIORITY] Failed to fetch check recommendations: %v", err)
+		// Area filter (route planning per city/region)
+		if areaID := r.URL.Query().Get("area_id"); areaID != "" {
+			args = append(args, areaID)
+			query += fmt.Sprintf(` AND p.area_id = $%d`, len(args))
 		}
 
-		checkRecMap := make(map[string]bool)
-		for _, binID := range checkRecBinIDs {
-			checkRecMap[binID] = true
+		// Tag filter (bins with any of the tags)
+		if tagIDs := tagFilter(r.URL.Query()); tagIDs != nil {
+			args = append(args, pq.Array(tagIDs))
+			query += fmt.Sprintf(` AND EXISTS (SELECT 1 FROM bin_tags bt WHERE bt.bin_id = p.id AND bt.tag_id = ANY($%d))`, len(args))
 		}
 
-		// Calculate priorities and build response
-		var binsWithPriority []BinWithPriority
-		for _, bin := range bins {
-			moveReq := moveRequestMap[bin.ID]
-			hasCheckRec := checkRecMap[bin.ID]
-
-			priority, daysSinceCheck := calculateBinPriority(bin, moveReq, hasCheckRec, now)
-
-			binWithPriority := BinWithPriority{
-				Bin:                    bin,
-				PriorityScore:          priority,
-				DaysSinceCheck:         daysSinceCheck,
-				HasPendingMove:         moveReq != nil,
-				HasCheckRecommendation: hasCheckRec,
-			}
-
-			if moveReq != nil {
-				binWithPriority.NextMoveRequestDate = &moveReq.ScheduledDate
-				binWithPriority.MoveRequestUrgency = &moveReq.Urgency
-			}
-
-			// Apply filter
-			include := false
-			switch filter {
-			case "all":
-				include = true
-			case "next_move_request":
-				include = moveReq != nil
-			case "longest_unchecked":
-				include = daysSinceCheck != nil && *daysSinceCheck >= 7
-			case "high_fill":
-				include = bin.FillPercentage != nil && *bin.FillPercentage >= 60
-			case "has_check_recommendation":
-				include = hasCheckRec
-			default:
-				include = true
-			}
-
-			if include {
-				binsWithPriority = append(binsWithPriority, binWithPriority)
-			}
+		// Category filter
+		switch filter {
+		case "next_move_request":
+			query += ` AND p.has_pending_move`
+		case "longest_unchecked":
+			args = append(args, weights.StaleUncheckedDays)
+			query += fmt.Sprintf(` AND p.days_since_check >= $%d`, len(args))
+		case "high_fill":
+			args = append(args, thresholds.Warning)
+			query += fmt.Sprintf(` AND p.fill_percentage >= $%d`, len(args))
+		case "has_check_recommendation":
+			query += ` AND p.has_check_recommendation`
+		case "maintenance_due":
+			query += ` AND p.maintenance_due`
 		}
 
-		// Sort based on sortBy parameter
+		// Sorting (bin_number breaks ties so results are deterministic)
 		switch sortBy {
-		case "priority":
-			// Sort by priority score descending
-			for i := 0; i < len(binsWithPriority); i++ {
-				for j := i + 1; j < len(binsWithPriority); j++ {
-					if binsWithPriority[i].PriorityScore < binsWithPriority[j].PriorityScore {
-						binsWithPriority[i], binsWithPriority[j] = binsWithPriority[j], binsWithPriority[i]
-					}
-				}
-			}
 		case "bin_number":
-			// Sort by bin number ascending
-			for i := 0; i < len(binsWithPriority); i++ {
-				for j := i + 1; j < len(binsWithPriority); j++ {
-					if binsWithPriority[i].BinNumber > binsWithPriority[j].BinNumber {
-						binsWithPriority[i], binsWithPriority[j] = binsWithPriority[j], binsWithPriority[i]
-					}
-				}
-			}
+			query += ` ORDER BY p.bin_number ASC`
 		case "fill_percentage":
-			// Sort by fill percentage descending (nulls last)
-			for i := 0; i < len(binsWithPriority); i++ {
-				for j := i + 1; j < len(binsWithPriority); j++ {
-					iFill := 0
-					jFill := 0
-					if binsWithPriority[i].FillPercentage != nil {
-						iFill = *binsWithPriority[i].FillPercentage
-					}
-					if binsWithPriority[j].FillPercentage != nil {
-						jFill = *binsWithPriority[j].FillPercentage
-					}
-					if iFill < jFill {
-						binsWithPriority[i], binsWithPriority[j] = binsWithPriority[j], binsWithPriority[i]
-					}
-				}
-			}
+			query += ` ORDER BY COALESCE(p.fill_percentage, 0) DESC, p.bin_number ASC`
 		case "days_since_check":
-			// Sort by days since check descending (nulls first = never checked)
-			for i := 0; i < len(binsWithPriority); i++ {
-				for j := i + 1; j < len(binsWithPriority); j++ {
-					iDays := 0
-					jDays := 0
-					if binsWithPriority[i].DaysSinceCheck != nil {
-						iDays = *binsWithPriority[i].DaysSinceCheck
-					} else {
-						iDays = 999999 // Never checked = highest
-					}
-					if binsWithPriority[j].DaysSinceCheck != nil {
-						jDays = *binsWithPriority[j].DaysSinceCheck
-					} else {
-						jDays = 999999
-					}
-					if iDays < jDays {
-						binsWithPriority[i], binsWithPriority[j] = binsWithPriority[j], binsWithPriority[i]
-					}
-				}
-			}
+			query += ` ORDER BY (p.last_checked_at IS NULL) DESC, p.days_since_check DESC, p.bin_number ASC`
+		default:
+			query += ` ORDER BY priority_score DESC, p.bin_number ASC`
 		}
 
 		// Apply limit
-		if limit > 0 && len(binsWithPriority) > limit {
-			binsWithPriority = binsWithPriority[:limit]
+		if limit > 0 {
+			args = append(args, limit)
+			query += fmt.Sprintf(` LIMIT $%d`, len(args))
+		}
+		if offset > 0 {
+			args = append(args, offset)
+			query += fmt.Sprintf(` OFFSET $%d`, len(args))
+		}
+
+		binsWithPriority := []BinWithPriority{}
+		if err := db.SelectContext(r.Context(), &binsWithPriority, query, args...); err != nil {
+			log.Printf("❌ [GET-BINS-PRIORITY] Database query failed: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch bins")
+			return
+		}
+
+		for i := range binsWithPriority {
+			binsWithPriority[i].FillLevel = thresholds.Level(binsWithPriority[i].FillPercentage)
 		}
 
 		log.Printf("✅ [GET-BINS-PRIORITY] Returning %d bins", len(binsWithPriority))
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(binsWithPriority)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"bins":            binsWithPriority,
+			"weights":         weights,
+			"fill_thresholds": thresholds,
+		})
 	}
 }
 
+// retireBinRequest is the body for POST /api/manager/bins/{id}/retire
+type retireBinRequest struct {
+	Reason         *string `json:"reason"`
+	DisposalAction string  `json:"disposal_action" validate:"required,oneof=retire store"`
+}
+
 // RetireBin marks a bin as retired
 // POST /api/manager/bins/{id}/retire
 // Body: { "reason": "optional reason", "disposal_action": "retire|store" }
@@ -307,59 +264,80 @@ func RetireBin(db *sqlx.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		binID := chi.URLParam(r, "id")
 		if binID == "" {
-			http.Error(w, "Bin ID is required", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Bin ID is required")
 			return
 		}
 
 		userID, _ := r.Context().Value("user_id").(string)
 
-		var req struct {
-			Reason         *string `json:"reason"`
-			DisposalAction string  `json:"disposal_action"` // "retire" or "store"
-		}
+		var req retireBinRequest
 
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
 			return
 		}
 
 		if req.DisposalAction != "retire" && req.DisposalAction != "store" {
-			http.Error(w, "disposal_action must be 'retire' or 'store'", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "disposal_action must be 'retire' or 'store'")
 			return
 		}
 
 		now := time.Now().Unix()
 
 		// Determine new status
-		newStatus := "retired"
+		newStatus := models.BinStatusRetired
 		if req.DisposalAction == "store" {
-			newStatus = "in_storage"
+			newStatus = models.BinStatusInStorage
+		}
+
+		var currentStatus string
+		err := db.GetContext(r.Context(), &currentStatus, `SELECT status FROM bins WHERE id = $1`, binID)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "Bin not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [RETIRE-BIN] Failed to fetch bin %s: %v", binID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to retire bin")
+			return
+		}
+		currentStatus = models.NormalizeBinStatus(currentStatus)
+		if currentStatus == newStatus {
+			utils.RespondErrorCode(w, http.StatusConflict, utils.CodeInvalidStatusTransition, "Bin is already "+newStatus, nil)
+			return
+		}
+		if !checkBinStatusTransition(w, currentStatus, newStatus) {
+			return
 		}
 
-		// Update bin
-		result, err := db.Exec(`
+		// Update bin (only if nobody changed its status in the meantime)
+		result, err := db.ExecContext(r.Context(), `
 			UPDATE bins
 			SET status = $1,
 			    retired_at = $2,
 			    retired_by_user_id = $3,
 			    updated_at = $2
 			WHERE id = $4
-			AND status = 'active'
-		`, newStatus, now, userID, binID)
+			AND status = $5
+		`, newStatus, now, userID, binID, currentStatus)
 
 		if err != nil {
 			log.Printf("❌ [RETIRE-BIN] Database update failed: %v", err)
-			http.Error(w, "Failed to retire bin", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to retire bin")
 			return
 		}
 
 		rowsAffected, _ := result.RowsAffected()
 		if rowsAffected == 0 {
-			http.Error(w, "Bin not found or already retired", http.StatusNotFound)
+			utils.RespondErrorCode(w, http.StatusConflict, utils.CodeStaleUpdate, "Bin status changed, reload and try again", nil)
 			return
 		}
 
+		store.InvalidateBins(binID)
 		log.Printf("✅ [RETIRE-BIN] Bin %s retired by user %s (action: %s)", binID, userID, req.DisposalAction)
+		if newStatus == models.BinStatusRetired {
+			emitBinRetiredWebhook(db, binID, userID, nil, req.Reason, now)
+		}
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
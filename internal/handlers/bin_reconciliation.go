@@ -0,0 +1,500 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Remap proposal scoring: each piece of evidence is a probability the orphaned rows belong to the bin,
+// combined as independent evidence
+const (
+	reconcileNumberEvidence    = 0.5 // The bin has the number the missing bin had (imports renumber, so weak alone)
+	reconcileLocationEvidence  = 0.6 // An incident was reported within defaultDuplicateRadiusMeters of the bin
+	reconcileAddressWeight     = 0.9 // Times the address similarity, when it reaches defaultDuplicateSimilarity
+	reconcileProposeConfidence = 0.8 // The top candidate is proposed from this confidence...
+	reconcileProposeMargin     = 0.1 // ...when it leads the next one by at least this much
+	reconcileMaxCandidates     = 5
+	reconcileMaxAddresses      = 10
+	reconcileMaxMergeHops      = 10
+	reconcileMaxFixes          = 500
+)
+
+// binReconciliationTables are the tables whose rows can outlive their bin after a bulk import
+// hints selects, per distinct hint among the orphaned rows: bin_id, the address or reporter location recorded
+// with the rows, and how many rows carry it
+var binReconciliationTables = []struct {
+	table string
+	hints string
+}{
+	{"checks", `SELECT bin_id, checked_from AS address, NULL::float8 AS latitude, NULL::float8 AS longitude, COUNT(*) AS count
+		FROM checks t WHERE NOT EXISTS (SELECT 1 FROM bins b WHERE b.id = t.bin_id)
+		GROUP BY bin_id, checked_from`},
+	{"moves", `SELECT bin_id, moved_to AS address, NULL::float8 AS latitude, NULL::float8 AS longitude, COUNT(*) AS count
+		FROM moves t WHERE NOT EXISTS (SELECT 1 FROM bins b WHERE b.id = t.bin_id)
+		GROUP BY bin_id, moved_to`},
+	{"zone_incidents", `SELECT bin_id, NULL::text AS address, ROUND(reporter_latitude::numeric, 5)::float8 AS latitude,
+			ROUND(reporter_longitude::numeric, 5)::float8 AS longitude, COUNT(*) AS count
+		FROM zone_incidents t WHERE NOT EXISTS (SELECT 1 FROM bins b WHERE b.id = t.bin_id)
+		GROUP BY 1, 2, 3, 4`},
+	{"bin_move_requests", `SELECT bin_id, original_address AS address, NULL::float8 AS latitude, NULL::float8 AS longitude, COUNT(*) AS count
+		FROM bin_move_requests t WHERE NOT EXISTS (SELECT 1 FROM bins b WHERE b.id = t.bin_id)
+		GROUP BY bin_id, original_address`},
+}
+
+// orphanHint is a distinct address or location recorded with a missing bin's rows
+type orphanHint struct {
+	BinID     string   `db:"bin_id"`
+	Address   *string  `db:"address"`
+	Latitude  *float64 `db:"latitude"`
+	Longitude *float64 `db:"longitude"`
+	Count     int64    `db:"count"`
+}
+
+// binMergeHop is a bin_merges row, followed to find where a merged-away bin's rows belong
+type binMergeHop struct {
+	SourceBinID     string  `db:"source_bin_id"`
+	SourceBinNumber int     `db:"source_bin_number"`
+	TargetBinID     *string `db:"target_bin_id"`
+}
+
+// GetBinReconciliation finds checks, moves, incidents and move requests whose bin no longer exists (after bulk
+// imports that renumbered or re-created bins, or rows imported against merged bins) and proposes the bin each
+// missing bin ID should be remapped to: the bin it was merged into, a bin with its old number, or bins at the
+// addresses and locations its rows recorded. Nothing is changed; approved fixes go to the apply endpoint
+// GET /api/manager/bins/reconciliation
+func GetBinReconciliation(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		orphans, err := findOrphanedBinReferences(r.Context(), db)
+		if err != nil {
+			log.Printf("❌ [BIN-RECONCILE] %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to find orphaned bin references")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    orphans,
+		})
+	}
+}
+
+// findOrphanedBinReferences groups the orphaned rows by missing bin ID, most rows first, with remap candidates
+func findOrphanedBinReferences(ctx context.Context, db *sqlx.DB) ([]models.OrphanedBinReference, error) {
+	byID := map[string]*models.OrphanedBinReference{}
+	hints := map[string][]orphanHint{}
+	for _, t := range binReconciliationTables {
+		var rows []orphanHint
+		if err := db.SelectContext(ctx, &rows, t.hints); err != nil {
+			return nil, fmt.Errorf("failed to find orphaned %s: %w", t.table, err)
+		}
+		for _, row := range rows {
+			orphan, ok := byID[row.BinID]
+			if !ok {
+				orphan = &models.OrphanedBinReference{MissingBinID: row.BinID, Rows: models.BinMergeCounts{}, Addresses: []string{}}
+				byID[row.BinID] = orphan
+			}
+			orphan.Rows[t.table] += row.Count
+			hints[row.BinID] = append(hints[row.BinID], row)
+		}
+	}
+	orphans := make([]models.OrphanedBinReference, 0, len(byID))
+	if len(byID) == 0 {
+		return orphans, nil
+	}
+
+	var bins []models.Bin
+	if err := db.SelectContext(ctx, &bins, `SELECT * FROM bins WHERE status != $1 ORDER BY bin_number`, models.BinStatusRetired); err != nil {
+		return nil, fmt.Errorf("failed to fetch bins: %w", err)
+	}
+	binIdx := make(map[string]int, len(bins))
+	byNumber := make(map[int]int, len(bins))
+	streets := make([]string, len(bins))
+	fullAddresses := make([]string, len(bins))
+	blocks := map[string][]int{}
+	for i, bin := range bins {
+		binIdx[bin.ID] = i
+		byNumber[bin.BinNumber] = i
+		streets[i] = normalizeStreet(bin.CurrentStreet)
+		fullAddresses[i] = normalizeStreet(bin.CurrentStreet + " " + bin.City + " " + bin.Zip)
+		key := addressBlock(streets[i])
+		blocks[key] = append(blocks[key], i)
+	}
+
+	var merges []binMergeHop
+	if err := db.SelectContext(ctx, &merges, `SELECT source_bin_id, source_bin_number, target_bin_id FROM bin_merges ORDER BY merged_at ASC`); err != nil {
+		return nil, fmt.Errorf("failed to fetch bin merges: %w", err)
+	}
+	mergedInto := make(map[string]binMergeHop, len(merges))
+	for _, merge := range merges {
+		mergedInto[merge.SourceBinID] = merge
+	}
+
+	missingIDs := make([]string, 0, len(byID))
+	for id := range byID {
+		missingIDs = append(missingIDs, id)
+	}
+	var stopNumbers []struct {
+		BinID     string `db:"bin_id"`
+		BinNumber int    `db:"bin_number"`
+	}
+	err := db.SelectContext(ctx, &stopNumbers, `
+		SELECT DISTINCT ON (bin_id) bin_id, bin_number FROM route_tasks
+		WHERE bin_id = ANY($1) AND bin_number IS NOT NULL
+		ORDER BY bin_id, created_at DESC
+	`, pq.Array(missingIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch route stop bin numbers: %w", err)
+	}
+	knownNumbers := make(map[string]int, len(stopNumbers))
+	for _, stop := range stopNumbers {
+		knownNumbers[stop.BinID] = stop.BinNumber
+	}
+
+	for id, orphan := range byID {
+		evidence := map[int][]float64{}
+		reasons := map[int][]string{}
+		addEvidence := func(i int, reason string, p float64) {
+			for _, existing := range reasons[i] {
+				if existing == reason {
+					return
+				}
+			}
+			evidence[i] = append(evidence[i], p)
+			reasons[i] = append(reasons[i], reason)
+		}
+
+		// Merged away: follow the merges to the bin that survived
+		if merge, ok := mergedInto[id]; ok {
+			number := merge.SourceBinNumber
+			orphan.KnownBinNumber = &number
+			for hops := 0; ok && merge.TargetBinID != nil && hops < reconcileMaxMergeHops; hops++ {
+				if i, found := binIdx[*merge.TargetBinID]; found {
+					addEvidence(i, "merged", 1)
+					break
+				}
+				merge, ok = mergedInto[*merge.TargetBinID]
+			}
+		} else if number, ok := knownNumbers[id]; ok {
+			orphan.KnownBinNumber = &number
+		}
+		if orphan.KnownBinNumber != nil {
+			if i, ok := byNumber[*orphan.KnownBinNumber]; ok {
+				addEvidence(i, "bin_number", reconcileNumberEvidence)
+			}
+		}
+
+		// Addresses and reporter locations recorded with the rows, most common first
+		rowHints := hints[id]
+		sort.SliceStable(rowHints, func(a, b int) bool { return rowHints[a].Count > rowHints[b].Count })
+		bestSimilarity := map[int]float64{}
+		for _, hint := range rowHints {
+			if hint.Address != nil && strings.TrimSpace(*hint.Address) != "" {
+				if len(orphan.Addresses) < reconcileMaxAddresses && !containsString(orphan.Addresses, *hint.Address) {
+					orphan.Addresses = append(orphan.Addresses, *hint.Address)
+				}
+				normalized := normalizeStreet(*hint.Address)
+				for _, i := range blocks[addressBlock(normalized)] {
+					similarity := math.Max(addressSimilarity(normalized, streets[i]), addressSimilarity(normalized, fullAddresses[i]))
+					if similarity >= defaultDuplicateSimilarity && similarity > bestSimilarity[i] {
+						bestSimilarity[i] = similarity
+					}
+				}
+			}
+			if hint.Latitude != nil && hint.Longitude != nil {
+				for i, bin := range bins {
+					if bin.Latitude == nil || bin.Longitude == nil {
+						continue
+					}
+					if haversineDistanceKm(*hint.Latitude, *hint.Longitude, *bin.Latitude, *bin.Longitude)*1000 <= defaultDuplicateRadiusMeters {
+						addEvidence(i, "location", reconcileLocationEvidence)
+					}
+				}
+			}
+		}
+		for i, similarity := range bestSimilarity {
+			addEvidence(i, "address", reconcileAddressWeight*similarity)
+		}
+
+		for i, ps := range evidence {
+			doubt := 1.0
+			for _, p := range ps {
+				doubt *= 1 - p
+			}
+			bin := bins[i]
+			orphan.Candidates = append(orphan.Candidates, models.BinRemapCandidate{
+				BinID:      bin.ID,
+				BinNumber:  bin.BinNumber,
+				Address:    strings.TrimSpace(bin.CurrentStreet + ", " + bin.City + " " + bin.Zip),
+				Confidence: math.Round((1-doubt)*100) / 100,
+				Reasons:    reasons[i],
+			})
+		}
+		sort.Slice(orphan.Candidates, func(a, b int) bool {
+			if orphan.Candidates[a].Confidence != orphan.Candidates[b].Confidence {
+				return orphan.Candidates[a].Confidence > orphan.Candidates[b].Confidence
+			}
+			return orphan.Candidates[a].BinNumber < orphan.Candidates[b].BinNumber
+		})
+		if len(orphan.Candidates) > reconcileMaxCandidates {
+			orphan.Candidates = orphan.Candidates[:reconcileMaxCandidates]
+		}
+		if orphan.Candidates == nil {
+			orphan.Candidates = []models.BinRemapCandidate{}
+		}
+		if len(orphan.Candidates) > 0 && orphan.Candidates[0].Confidence >= reconcileProposeConfidence &&
+			(len(orphan.Candidates) == 1 || orphan.Candidates[0].Confidence-orphan.Candidates[1].Confidence >= reconcileProposeMargin) {
+			orphan.ProposedBinID = &orphan.Candidates[0].BinID
+		}
+		orphans = append(orphans, *orphan)
+	}
+
+	total := func(counts models.BinMergeCounts) int64 {
+		var n int64
+		for _, count := range counts {
+			n += count
+		}
+		return n
+	}
+	sort.Slice(orphans, func(a, b int) bool {
+		if ta, tb := total(orphans[a].Rows), total(orphans[b].Rows); ta != tb {
+			return ta > tb
+		}
+		return orphans[a].MissingBinID < orphans[b].MissingBinID
+	})
+	return orphans, nil
+}
+
+// addressBlock is the first word of a normalized address (usually the house number); only addresses in the
+// same block are compared
+func addressBlock(normalized string) string {
+	if space := strings.IndexByte(normalized, ' '); space >= 0 {
+		return normalized[:space]
+	}
+	return normalized
+}
+
+// ApplyBinReconciliation applies approved fixes for missing bin IDs in one transaction: remap points every
+// orphaned check, move, incident and move request at an existing bin (which takes the newer check and move
+// dates), delete drops them. Each fix is recorded in bin_reconciliations; if any fix is invalid nothing changes
+// POST /api/manager/bins/reconciliation/apply
+// Body: { "fixes": [{ "missing_bin_id": "...", "action": "remap", "bin_id": "..." }, { "missing_bin_id": "...", "action": "delete" }] }
+func ApplyBinReconciliation(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var req models.BinReconciliationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if len(req.Fixes) == 0 || len(req.Fixes) > reconcileMaxFixes {
+			utils.RespondError(w, http.StatusBadRequest, fmt.Sprintf("fixes must have between 1 and %d entries", reconcileMaxFixes))
+			return
+		}
+		missingIDs := make([]string, 0, len(req.Fixes))
+		var targetIDs []string
+		seen := map[string]bool{}
+		for i := range req.Fixes {
+			fix := &req.Fixes[i]
+			fix.MissingBinID = strings.TrimSpace(fix.MissingBinID)
+			fix.BinID = strings.TrimSpace(fix.BinID)
+			if fix.MissingBinID == "" {
+				utils.RespondError(w, http.StatusBadRequest, "missing_bin_id is required")
+				return
+			}
+			if seen[fix.MissingBinID] {
+				utils.RespondError(w, http.StatusBadRequest, fmt.Sprintf("missing_bin_id %s has more than one fix", fix.MissingBinID))
+				return
+			}
+			seen[fix.MissingBinID] = true
+			missingIDs = append(missingIDs, fix.MissingBinID)
+			switch fix.Action {
+			case models.BinReconciliationRemap:
+				if fix.BinID == "" {
+					utils.RespondError(w, http.StatusBadRequest, "bin_id is required to remap")
+					return
+				}
+				targetIDs = append(targetIDs, fix.BinID)
+			case models.BinReconciliationDelete:
+				fix.BinID = ""
+			default:
+				utils.RespondError(w, http.StatusBadRequest, fmt.Sprintf("action must be %s or %s",
+					models.BinReconciliationRemap, models.BinReconciliationDelete))
+				return
+			}
+		}
+
+		now := time.Now().Unix()
+		report := models.BinReconciliationReport{Applied: []models.BinReconciliation{}, Rows: models.BinMergeCounts{}}
+		err := database.WithTx(r.Context(), db, func(tx *sqlx.Tx) error {
+			var existing []string
+			if err := tx.SelectContext(r.Context(), &existing, `SELECT id FROM bins WHERE id = ANY($1)`, pq.Array(missingIDs)); err != nil {
+				log.Printf("❌ [BIN-RECONCILE] Failed to check missing bins: %v", err)
+				return txFail(http.StatusInternalServerError, "Failed to apply reconciliation")
+			}
+			if len(existing) > 0 {
+				return txFailCode(http.StatusConflict, utils.CodeConflict, "These bins exist, so their rows aren't orphaned", existing)
+			}
+
+			// Targets are locked so they can't be retired or deleted while their new history lands
+			var locked []models.Bin
+			err := tx.SelectContext(r.Context(), &locked, `SELECT * FROM bins WHERE id = ANY($1) ORDER BY id FOR UPDATE`, pq.Array(targetIDs))
+			if err != nil {
+				log.Printf("❌ [BIN-RECONCILE] Failed to lock target bins: %v", err)
+				return txFail(http.StatusInternalServerError, "Failed to apply reconciliation")
+			}
+			targets := make(map[string]models.Bin, len(locked))
+			for _, bin := range locked {
+				targets[bin.ID] = bin
+			}
+			var unknown, retired []string
+			for _, id := range targetIDs {
+				if bin, ok := targets[id]; !ok {
+					unknown = append(unknown, id)
+				} else if bin.Status == models.BinStatusRetired {
+					retired = append(retired, id)
+				}
+			}
+			if len(unknown) > 0 {
+				return txFailCode(http.StatusBadRequest, utils.CodeInvalidReference, "Unknown bin_id", unknown)
+			}
+			if len(retired) > 0 {
+				return txFailCode(http.StatusConflict, utils.CodeConflict, "Can't remap to a retired bin", retired)
+			}
+
+			var unreferenced []string
+			for _, fix := range req.Fixes {
+				counts := models.BinMergeCounts{}
+				for _, t := range binReconciliationTables {
+					query := `DELETE FROM ` + t.table + ` WHERE bin_id = $1`
+					args := []interface{}{fix.MissingBinID}
+					if fix.Action == models.BinReconciliationRemap {
+						query = `UPDATE ` + t.table + ` SET bin_id = $2 WHERE bin_id = $1`
+						args = append(args, fix.BinID)
+					}
+					result, err := tx.ExecContext(r.Context(), query, args...)
+					if err != nil {
+						log.Printf("❌ [BIN-RECONCILE] Failed to %s %s of %s: %v", fix.Action, t.table, fix.MissingBinID, err)
+						return txFail(http.StatusInternalServerError, "Failed to apply reconciliation")
+					}
+					if n, _ := result.RowsAffected(); n > 0 {
+						counts[t.table] = n
+						report.Rows[t.table] += n
+					}
+				}
+				if len(counts) == 0 {
+					unreferenced = append(unreferenced, fix.MissingBinID)
+					continue
+				}
+
+				record := models.BinReconciliation{
+					ID:              uuid.New().String(),
+					MissingBinID:    fix.MissingBinID,
+					Action:          fix.Action,
+					Rows:            counts,
+					AppliedByUserID: &userClaims.UserID,
+					AppliedAt:       now,
+				}
+				if fix.Action == models.BinReconciliationRemap {
+					target := targets[fix.BinID]
+					record.TargetBinID, record.TargetBinNumber = &target.ID, &target.BinNumber
+					_, err := tx.ExecContext(r.Context(), `
+						UPDATE bins SET
+							last_checked = GREATEST(last_checked, (SELECT MAX(checked_on) FROM checks WHERE bin_id = $1)),
+							last_moved = GREATEST(last_moved, (SELECT MAX(moved_on) FROM moves WHERE bin_id = $1)),
+							updated_at = $2
+						WHERE id = $1
+					`, target.ID, now)
+					if err != nil {
+						log.Printf("❌ [BIN-RECONCILE] Failed to update %s: %v", target.ID, err)
+						return txFail(http.StatusInternalServerError, "Failed to apply reconciliation")
+					}
+				}
+				rows, _ := json.Marshal(counts)
+				_, err = tx.ExecContext(r.Context(), `
+					INSERT INTO bin_reconciliations (id, missing_bin_id, action, target_bin_id, target_bin_number, rows,
+					                                 applied_by_user_id, applied_at)
+					VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+				`, record.ID, record.MissingBinID, record.Action, record.TargetBinID, record.TargetBinNumber, rows,
+					record.AppliedByUserID, record.AppliedAt)
+				if err != nil {
+					log.Printf("❌ [BIN-RECONCILE] Failed to record fix of %s: %v", fix.MissingBinID, err)
+					return txFail(http.StatusInternalServerError, "Failed to apply reconciliation")
+				}
+				report.Applied = append(report.Applied, record)
+			}
+			if len(unreferenced) > 0 {
+				return txFailCode(http.StatusConflict, utils.CodeConflict, "No orphaned rows reference these bin IDs", unreferenced)
+			}
+			return nil
+		})
+		if err != nil {
+			respondTxError(w, err, "Failed to apply reconciliation")
+			return
+		}
+
+		err = db.GetContext(r.Context(), &report.Remaining, `
+			SELECT COUNT(DISTINCT bin_id) FROM (
+				SELECT bin_id FROM checks UNION SELECT bin_id FROM moves
+				UNION SELECT bin_id FROM zone_incidents UNION SELECT bin_id FROM bin_move_requests
+			) o
+			WHERE NOT EXISTS (SELECT 1 FROM bins b WHERE b.id = o.bin_id)
+		`)
+		if err != nil {
+			log.Printf("⚠️  [BIN-RECONCILE] Failed to count remaining orphans: %v", err)
+		}
+
+		log.Printf("✅ [BIN-RECONCILE] %s applied %d fix(es) (%v), %d missing bin ID(s) remain",
+			userClaims.Email, len(report.Applied), report.Rows, report.Remaining)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    report,
+		})
+	}
+}
+
+// GetBinReconciliations returns the bin reconciliation audit log, newest first
+// GET /api/manager/bins/reconciliation/history?limit=100
+func GetBinReconciliations(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit := 100
+		if parsed, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && parsed > 0 && parsed <= 500 {
+			limit = parsed
+		}
+
+		reconciliations := []models.BinReconciliation{}
+		err := db.SelectContext(r.Context(), &reconciliations, `SELECT * FROM bin_reconciliations ORDER BY applied_at DESC LIMIT $1`, limit)
+		if err != nil {
+			log.Printf("❌ [BIN-RECONCILE] Failed to fetch reconciliations: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch bin reconciliations")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    reconciliations,
+		})
+	}
+}
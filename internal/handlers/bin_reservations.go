@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// binReservedError aborts an assignment whose bins are already on other open shifts, listing those shifts
+func binReservedError(reservations []models.BinReservation) error {
+	message := fmt.Sprintf("%d bin(s) are already on other open shifts", countReservedBins(reservations))
+	if len(reservations) == 1 {
+		r := reservations[0]
+		driver := r.DriverID
+		if r.DriverName != nil {
+			driver = *r.DriverName
+		}
+		binLabel := r.BinID
+		if r.BinNumber != nil {
+			binLabel = fmt.Sprintf("#%d", *r.BinNumber)
+		}
+		message = fmt.Sprintf("Bin %s is already on %s shift %s (%s)", binLabel, r.ShiftStatus, r.ShiftID, driver)
+	}
+	return txFailCode(http.StatusConflict, utils.CodeBinReserved, message, reservations)
+}
+
+func countReservedBins(reservations []models.BinReservation) int {
+	bins := map[string]bool{}
+	for _, r := range reservations {
+		bins[r.BinID] = true
+	}
+	return len(bins)
+}
+
+// binReservationRaceError is the response when the route_tasks_bin_reservation trigger refuses a stop the
+// handler's own check allowed (another assignment reserved the bin in the meantime)
+func binReservationRaceError() error {
+	return txFailCode(http.StatusConflict, utils.CodeBinReserved, "A bin was just put on another open shift; reload and try again", nil)
+}
+
+// GetBinReservationConflicts lists bins on more than one open shift
+// Reservations are only enforced for new stops, so these were assigned before enforcement started
+// GET /api/manager/bins/reservation-conflicts
+func GetBinReservationConflicts(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		conflicts, err := database.GetBinReservationConflicts(db)
+		if err != nil {
+			log.Printf("❌ [BIN-RESERVATIONS] %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch reservation conflicts")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    conflicts,
+		})
+	}
+}
@@ -0,0 +1,413 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/websocket"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Sensor reading anomaly filtering
+const (
+	sensorMaxFutureSkew = 5 * 60 // Readings further ahead of the server clock are rejected (seconds)
+	sensorSpikeMaxRise  = 50     // Largest plausible fill rise (points) within sensorSpikeWindow
+	sensorSpikeWindow   = 60 * 60
+)
+
+const binSensorSelect = `
+	SELECT s.*, b.bin_number
+	FROM bin_sensors s
+	LEFT JOIN bins b ON b.id = s.bin_id
+`
+
+// binSensorError maps constraint violations on bin_sensors to a client error ("" when err is something else)
+func binSensorError(err error) (int, string) {
+	if pqErr, ok := err.(*pq.Error); ok {
+		switch pqErr.Code {
+		case "23505":
+			if pqErr.Constraint == "bin_sensors_pkey" {
+				return http.StatusConflict, "A sensor with this ID is already registered"
+			}
+			return http.StatusConflict, "This bin already has a sensor"
+		case "23503":
+			return http.StatusBadRequest, "Bin not found"
+		}
+	}
+	return 0, ""
+}
+
+// GetBinSensors returns the sensor registry
+// GET /api/manager/sensors
+func GetBinSensors(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sensors := []models.BinSensor{}
+		if err := db.SelectContext(r.Context(), &sensors, binSensorSelect+` ORDER BY s.id ASC`); err != nil {
+			log.Printf("❌ [SENSORS] Failed to fetch sensors: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch sensors")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    sensors,
+		})
+	}
+}
+
+// CreateBinSensor registers a sensor, optionally mounting it on a bin
+// POST /api/manager/sensors
+// Body: { "id": "US-00412", "bin_id": "...", "description": "..." }
+func CreateBinSensor(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req models.BinSensorRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		sensorID := trimmedOrNil(req.ID)
+		if sensorID == nil {
+			utils.RespondError(w, http.StatusBadRequest, "id is required")
+			return
+		}
+
+		now := time.Now().Unix()
+		isActive := req.IsActive == nil || *req.IsActive
+		_, err := db.ExecContext(r.Context(), `
+			INSERT INTO bin_sensors (id, bin_id, description, is_active, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $5)
+		`, *sensorID, trimmedOrNil(req.BinID), trimmedOrNil(req.Description), isActive, now)
+		if err != nil {
+			if status, msg := binSensorError(err); msg != "" {
+				utils.RespondError(w, status, msg)
+				return
+			}
+			log.Printf("❌ [SENSORS] Failed to register sensor: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to register sensor")
+			return
+		}
+
+		var sensor models.BinSensor
+		if err := db.GetContext(r.Context(), &sensor, binSensorSelect+` WHERE s.id = $1`, *sensorID); err != nil {
+			log.Printf("❌ [SENSORS] Failed to fetch sensor: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch sensor")
+			return
+		}
+
+		log.Printf("✅ [SENSORS] Registered sensor %s (bin: %v)", sensor.ID, sensor.BinNumber)
+
+		utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{
+			"success": true,
+			"data":    sensor,
+		})
+	}
+}
+
+// UpdateBinSensor moves a sensor to another bin, unassigns it ("bin_id": ""), or changes its description/active flag
+// PUT /api/manager/sensors/{id}
+func UpdateBinSensor(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sensorID := chi.URLParam(r, "id")
+
+		var req models.BinSensorRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		var sensor models.BinSensor
+		err := db.GetContext(r.Context(), &sensor, binSensorSelect+` WHERE s.id = $1`, sensorID)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "Sensor not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [SENSORS] Failed to fetch sensor: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch sensor")
+			return
+		}
+
+		if req.BinID != nil {
+			newBinID := trimmedOrNil(req.BinID)
+			if (newBinID == nil) != (sensor.BinID == nil) || (newBinID != nil && *newBinID != *sensor.BinID) {
+				// A remounted sensor starts a new reading history, so the spike filter doesn't compare bins
+				sensor.LastFillPercentage = nil
+			}
+			sensor.BinID = newBinID
+		}
+		if req.Description != nil {
+			sensor.Description = trimmedOrNil(req.Description)
+		}
+		if req.IsActive != nil {
+			sensor.IsActive = *req.IsActive
+		}
+		sensor.UpdatedAt = time.Now().Unix()
+
+		_, err = db.ExecContext(r.Context(), `
+			UPDATE bin_sensors
+			SET bin_id = $1, description = $2, is_active = $3, last_fill_percentage = $4, updated_at = $5
+			WHERE id = $6
+		`, sensor.BinID, sensor.Description, sensor.IsActive, sensor.LastFillPercentage, sensor.UpdatedAt, sensor.ID)
+		if err != nil {
+			if status, msg := binSensorError(err); msg != "" {
+				utils.RespondError(w, status, msg)
+				return
+			}
+			log.Printf("❌ [SENSORS] Failed to update sensor: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to update sensor")
+			return
+		}
+
+		if err := db.GetContext(r.Context(), &sensor, binSensorSelect+` WHERE s.id = $1`, sensor.ID); err != nil {
+			log.Printf("❌ [SENSORS] Failed to fetch sensor: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch sensor")
+			return
+		}
+
+		log.Printf("✅ [SENSORS] Updated sensor %s (bin: %v, active: %v)", sensor.ID, sensor.BinNumber, sensor.IsActive)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    sensor,
+		})
+	}
+}
+
+// DeleteBinSensor removes a sensor from the registry (its past checks are kept)
+// DELETE /api/manager/sensors/{id}
+func DeleteBinSensor(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sensorID := chi.URLParam(r, "id")
+
+		result, err := db.ExecContext(r.Context(), `DELETE FROM bin_sensors WHERE id = $1`, sensorID)
+		if err != nil {
+			log.Printf("❌ [SENSORS] Failed to delete sensor: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to delete sensor")
+			return
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			utils.RespondError(w, http.StatusNotFound, "Sensor not found")
+			return
+		}
+
+		log.Printf("✅ [SENSORS] Deleted sensor %s", sensorID)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+		})
+	}
+}
+
+// sensorState is a sensor's last accepted reading while a batch is processed
+type sensorState struct {
+	sensor   models.BinSensor
+	accepted int
+	rejected int
+	battery  *int
+}
+
+// filterSensorReading returns why a reading should be dropped ("" to accept it)
+func filterSensorReading(state *sensorState, fill int, measuredAt, now int64) string {
+	if state == nil || !state.sensor.IsActive || state.sensor.BinID == nil {
+		return models.SensorRejectUnknownSensor
+	}
+	if fill < 0 || fill > 100 {
+		return models.SensorRejectOutOfRange
+	}
+	if measuredAt > now+sensorMaxFutureSkew {
+		return models.SensorRejectFuture
+	}
+	last := state.sensor.LastReadingAt
+	if last != nil && measuredAt <= *last {
+		return models.SensorRejectStale
+	}
+	// Bins fill gradually but empty at once (collection), so only sudden rises are suspect
+	// (typically something placed in front of the sensor)
+	if last != nil && state.sensor.LastFillPercentage != nil && measuredAt-*last < sensorSpikeWindow &&
+		fill-*state.sensor.LastFillPercentage > sensorSpikeMaxRise {
+		return models.SensorRejectSpike
+	}
+	return ""
+}
+
+// IngestSensorReadings records batched fill readings from IoT sensors
+// Accepted readings become checks (checked_from = 'sensor') and update the bin's fill_percentage unless a driver
+// has checked the bin since; anomalous readings are dropped and reported back per reading
+// POST /api/ingest/sensor-readings (X-API-Key with the sensor_ingest scope)
+// Body: { "readings": [{ "sensor_id": "US-00412", "fill_percentage": 72, "measured_at": 1735689600, "battery_percentage": 88 }] }
+func IngestSensorReadings(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var batch models.SensorReadingBatch
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if len(batch.Readings) == 0 {
+			utils.RespondError(w, http.StatusBadRequest, "readings is required")
+			return
+		}
+
+		now := time.Now().Unix()
+		result := models.SensorIngestResult{Received: len(batch.Readings), Rejected: []models.RejectedSensorReading{}}
+
+		sensorIDs := make([]string, 0, len(batch.Readings))
+		for i := range batch.Readings {
+			batch.Readings[i].SensorID = strings.TrimSpace(batch.Readings[i].SensorID)
+			sensorIDs = append(sensorIDs, batch.Readings[i].SensorID)
+		}
+
+		tx, err := db.BeginTxx(r.Context(), nil)
+		if err != nil {
+			log.Printf("❌ [SENSORS] Failed to start transaction: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to ingest readings")
+			return
+		}
+		defer tx.Rollback()
+
+		// Lock the sensors so concurrent batches from one gateway can't both pass the stale check
+		var sensors []models.BinSensor
+		err = tx.SelectContext(r.Context(), &sensors, `
+			SELECT s.*, NULL::INT AS bin_number FROM bin_sensors s WHERE s.id = ANY($1) FOR UPDATE
+		`, pq.Array(sensorIDs))
+		if err != nil {
+			log.Printf("❌ [SENSORS] Failed to load sensors: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to ingest readings")
+			return
+		}
+		states := make(map[string]*sensorState, len(sensors))
+		for _, sensor := range sensors {
+			states[sensor.ID] = &sensorState{sensor: sensor, battery: sensor.BatteryPercentage}
+		}
+
+		// Gateways may buffer and send readings out of order, so process each sensor's readings oldest first
+		order := make([]int, len(batch.Readings))
+		for i := range order {
+			order[i] = i
+			if batch.Readings[i].MeasuredAt == nil {
+				batch.Readings[i].MeasuredAt = &now
+			}
+		}
+		sort.SliceStable(order, func(a, b int) bool {
+			return *batch.Readings[order[a]].MeasuredAt < *batch.Readings[order[b]].MeasuredAt
+		})
+
+		latestByBin := map[string]models.SensorReading{}
+		for _, i := range order {
+			reading := batch.Readings[i]
+			state := states[reading.SensorID]
+			fill := -1
+			if reading.FillPercentage != nil {
+				fill = *reading.FillPercentage
+			}
+
+			if reason := filterSensorReading(state, fill, *reading.MeasuredAt, now); reason != "" {
+				if state != nil {
+					state.rejected++
+				}
+				result.Rejected = append(result.Rejected, models.RejectedSensorReading{Index: i, SensorID: reading.SensorID, Reason: reason})
+				continue
+			}
+
+			binID := *state.sensor.BinID
+			_, err := tx.ExecContext(r.Context(), `
+				INSERT INTO checks (bin_id, checked_from, fill_percentage, checked_on)
+				VALUES ($1, 'sensor', $2, $3)
+			`, binID, fill, *reading.MeasuredAt)
+			if err != nil {
+				log.Printf("❌ [SENSORS] Failed to record reading from %s: %v", reading.SensorID, err)
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to ingest readings")
+				return
+			}
+
+			state.accepted++
+			state.sensor.LastReadingAt = reading.MeasuredAt
+			state.sensor.LastFillPercentage = &fill
+			if reading.BatteryPercentage != nil {
+				state.battery = reading.BatteryPercentage
+			}
+			latestByBin[binID] = reading
+			result.Accepted++
+		}
+
+		for _, state := range states {
+			if state.accepted == 0 && state.rejected == 0 {
+				continue
+			}
+			_, err := tx.ExecContext(r.Context(), `
+				UPDATE bin_sensors
+				SET last_reading_at = $1, last_fill_percentage = $2, battery_percentage = $3,
+				    rejected_readings = rejected_readings + $4, updated_at = $5
+				WHERE id = $6
+			`, state.sensor.LastReadingAt, state.sensor.LastFillPercentage, state.battery, state.rejected, now, state.sensor.ID)
+			if err != nil {
+				log.Printf("❌ [SENSORS] Failed to update sensor %s: %v", state.sensor.ID, err)
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to ingest readings")
+				return
+			}
+		}
+
+		// A driver's check newer than the reading wins
+		var updatedBinIDs []string
+		for binID, reading := range latestByBin {
+			res, err := tx.ExecContext(r.Context(), `
+				UPDATE bins SET fill_percentage = $1, updated_at = $2
+				WHERE id = $3 AND COALESCE(last_checked_at, 0) <= $4
+			`, *reading.FillPercentage, now, binID, *reading.MeasuredAt)
+			if err != nil {
+				log.Printf("❌ [SENSORS] Failed to update fill of bin %s: %v", binID, err)
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to ingest readings")
+				return
+			}
+			if rows, _ := res.RowsAffected(); rows > 0 {
+				updatedBinIDs = append(updatedBinIDs, binID)
+			}
+		}
+		result.UpdatedBins = len(updatedBinIDs)
+
+		if err := tx.Commit(); err != nil {
+			log.Printf("❌ [SENSORS] Failed to commit readings: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to ingest readings")
+			return
+		}
+
+		source := "unknown key"
+		if apiKey, ok := middleware.GetAPIKeyFromContext(r); ok {
+			source = apiKey.Name
+		}
+		log.Printf("📡 [SENSORS] %s: %d reading(s), %d accepted, %d rejected, %d bin(s) updated",
+			source, result.Received, result.Accepted, len(result.Rejected), result.UpdatedBins)
+
+		if len(updatedBinIDs) > 0 {
+			var bins []models.Bin
+			query, args, err := sqlx.In(`SELECT * FROM bins WHERE id IN (?)`, updatedBinIDs)
+			if err == nil {
+				err = db.SelectContext(r.Context(), &bins, db.Rebind(query), args...)
+			}
+			if err != nil {
+				log.Printf("⚠️  [SENSORS] Failed to fetch updated bins for broadcast: %v", err)
+			}
+			for _, bin := range bins {
+				hub.BroadcastToRole("admin", map[string]interface{}{
+					"type": "bin_updated",
+					"data": bin.ToBinResponse(),
+				})
+			}
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    result,
+		})
+	}
+}
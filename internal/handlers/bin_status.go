@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+
+	"ropacal-backend/internal/models"
+	"ropacal-backend/pkg/utils"
+)
+
+// checkBinStatusTransition validates a manual status change; responds and returns false when it isn't allowed
+// An unknown status is a 400 listing the canonical statuses, a forbidden transition a 409 listing the allowed ones
+func checkBinStatusTransition(w http.ResponseWriter, from, to string) bool {
+	if !models.IsValidBinStatus(to) {
+		utils.RespondErrorCode(w, http.StatusBadRequest, utils.CodeValidationFailed, "Invalid bin status: "+to, map[string]interface{}{
+			"allowed_statuses": models.BinStatuses,
+		})
+		return false
+	}
+	if err := models.ValidateBinStatusTransition(from, to); err != nil {
+		utils.RespondErrorCode(w, http.StatusConflict, utils.CodeInvalidStatusTransition, err.Error(), map[string]interface{}{
+			"from":       from,
+			"to":         to,
+			"allowed_to": models.AllowedBinStatusTransitions(from),
+		})
+		return false
+	}
+	return true
+}
+
+// checkMoveStatusTransition validates a move request status change; responds 409 with the current status
+// and the allowed ones and returns false when it isn't allowed
+func checkMoveStatusTransition(w http.ResponseWriter, from, to string) bool {
+	if err := models.ValidateMoveStatusTransition(from, to); err != nil {
+		respondMoveStatusConflict(w, from, to, err.Error())
+		return false
+	}
+	return true
+}
+
+// txMoveStatusTransition is checkMoveStatusTransition for use inside database.WithTx: the 409 is returned as an error
+func txMoveStatusTransition(from, to string) error {
+	if err := models.ValidateMoveStatusTransition(from, to); err != nil {
+		return txFailCode(http.StatusConflict, utils.CodeInvalidStatusTransition, err.Error(), moveStatusConflictDetails(from, to))
+	}
+	return nil
+}
+
+// respondMoveStatusConflict responds 409 to a move request status change that can't be made
+func respondMoveStatusConflict(w http.ResponseWriter, from, to, message string) {
+	utils.RespondErrorCode(w, http.StatusConflict, utils.CodeInvalidStatusTransition, message, moveStatusConflictDetails(from, to))
+}
+
+// moveStatusConflictDetails are the details of a 409 for a move request status change
+func moveStatusConflictDetails(from, to string) map[string]interface{} {
+	return map[string]interface{}{
+		"from":       from,
+		"to":         to,
+		"allowed_to": models.AllowedMoveStatusTransitions(from),
+	}
+}
@@ -1,73 +1,173 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
+	"ropacal-backend/internal/database"
 	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/services"
+	"ropacal-backend/internal/store"
 	"ropacal-backend/internal/websocket"
+	"ropacal-backend/pkg/utils"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
+// binResponseFields are the fields ?fields= can select from bin payloads
+var binResponseFields = utils.FieldsOf(models.BinResponse{})
+
+// binListColumns are the columns of the bin list and delta payloads; $1 is the time maintenance is due by
+const binListColumns = `
+	SELECT id, bin_number, current_street, city, zip,
+	       last_moved, last_checked, status, fill_percentage,
+	       checked, move_requested, latitude, longitude, area_id,
+	       created_at, updated_at,
+	       EXISTS (
+	           SELECT 1 FROM bin_maintenance bm
+	           WHERE bm.bin_id = bins.id
+	           AND bm.status = 'scheduled'
+	           AND bm.scheduled_for <= $1
+	       ) AS maintenance_due,
+	       (
+	           SELECT bp.photo_url FROM bin_photos bp
+	           WHERE bp.bin_id = bins.id
+	           ORDER BY bp.taken_at DESC
+	           LIMIT 1
+	       ) AS latest_photo_url
+	FROM bins`
+
+// GetBins lists bins (GET /api/bins); under /api/manager/bins the list is limited to the manager's areas unless all_areas=true
+// Responses carry an ETag and Last-Modified; a client sending them back in If-None-Match / If-Modified-Since
+// gets a 304 while nothing it lists has changed (see GET /api/bins/changes for just the changes)
 func GetBins(db *sqlx.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		fields, err := utils.ParseFields(r, binResponseFields)
+		if err != nil {
+			utils.RespondFieldsError(w, err, binResponseFields)
+			return
+		}
+
 		// Auto-uncheck bins older than 3 days
+		now := time.Now().Unix()
 		threeDaysAgo := time.Now().Add(-3 * 24 * time.Hour).Unix()
-		_, err := db.Exec(`
+		_, err = db.ExecContext(r.Context(), `
 			UPDATE bins
-			SET checked = 0
+			SET checked = 0, updated_at = $2
 			WHERE checked = 1 AND last_checked IS NOT NULL AND last_checked < $1
-		`, threeDaysAgo)
+		`, threeDaysAgo, now)
 		if err != nil {
-			http.Error(w, "Failed to update bins", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to update bins")
 			return
 		}
 
-		// Get all bins
-		var bins []models.Bin
-		err = db.Select(&bins, `
-			SELECT id, bin_number, current_street, city, zip,
-			       last_moved, last_checked, status, fill_percentage,
-			       checked, move_requested, latitude, longitude,
-			       created_at, updated_at
-			FROM bins
-			ORDER BY bin_number ASC
-		`)
+		// A saved view (?view_id=) supplies defaults for the filters below
+		if status, msg := applySavedView(db, r, models.SavedViewEntityBins); status != 0 {
+			utils.RespondError(w, status, msg)
+			return
+		}
+
+		// Managers assigned to areas only see their areas' bins (unless all_areas=true)
+		scope, err := managerAreaScope(r, db)
 		if err != nil {
-			http.Error(w, "Failed to fetch bins", http.StatusInternalServerError)
+			log.Printf("❌ [GET-BINS] %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch bins")
 			return
 		}
 
-		// Convert to response format
-		responses := make([]models.BinResponse, len(bins))
-		for i, bin := range bins {
-			responses[i] = bin.ToBinResponse()
+		// Get all bins (optionally limited to one area, status or tags, and paginated with limit/offset)
+		var bins []models.Bin
+		areaID := r.URL.Query().Get("area_id")
+		tagIDs := tagFilter(r.URL.Query())
+		status := models.NormalizeBinStatus(r.URL.Query().Get("status"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		if limit <= 0 {
+			limit = -1 // LIMIT NULL returns every row
+		}
+		if offset < 0 {
+			offset = 0
+		}
+
+		thresholds := loadFillThresholds(db)
+		version, err := loadBinListVersion(r.Context(), db, now)
+		if err != nil {
+			log.Printf("⚠️  [GET-BINS] %v (sending the list without validators)", err)
+		} else {
+			etag := version.ETag(r, scope, thresholds)
+			if utils.NotModified(r, etag, version.LastModified()) {
+				utils.RespondNotModified(w, etag, version.LastModified())
+				return
+			}
+			utils.SetCacheValidators(w, etag, version.LastModified())
+		}
+
+		err = db.SelectContext(r.Context(), &bins, binListColumns+`
+			WHERE ($2 = '' OR area_id = $2)
+			  AND ($3 = '' OR $3 = 'all' OR status = $3)
+			  AND ($4::TEXT[] IS NULL OR EXISTS (
+			      SELECT 1 FROM bin_tags bt WHERE bt.bin_id = bins.id AND bt.tag_id = ANY($4)
+			  ))
+			  AND ($5::TEXT[] IS NULL OR area_id = ANY($5))
+			ORDER BY bin_number ASC
+			LIMIT NULLIF($6, -1) OFFSET $7
+		`, now, areaID, status, pq.Array(tagIDs), pq.Array(scope), limit, offset)
+		if err != nil {
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch bins")
+			return
 		}
 
+		responses := binListResponses(r.Context(), db, bins, thresholds)
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(responses)
+		json.NewEncoder(w).Encode(fields.Apply(responses))
+	}
+}
+
+// binListResponses converts bins loaded with binListColumns to their payloads with tags and fill levels
+func binListResponses(ctx context.Context, db *sqlx.DB, bins []models.Bin, thresholds models.FillThresholds) []models.BinResponse {
+	binIDs := make([]string, len(bins))
+	for i, bin := range bins {
+		binIDs[i] = bin.ID
+	}
+	tags, err := loadBinTags(ctx, db, binIDs)
+	if err != nil {
+		log.Printf("⚠️  [GET-BINS] %v", err)
 	}
+
+	responses := make([]models.BinResponse, len(bins))
+	for i, bin := range bins {
+		responses[i] = bin.ToBinResponse()
+		responses[i].Tags = tags[bin.ID]
+		responses[i].FillLevel = thresholds.Level(bin.FillPercentage)
+	}
+	return responses
 }
 
 func CreateBin(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req models.CreateBinRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
 			return
 		}
 
 		// Validate required fields
 		if req.CurrentStreet == "" || req.City == "" || req.Zip == "" || req.Status == "" {
-			http.Error(w, "Missing required fields (current_street, city, zip, status)", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Missing required fields (current_street, city, zip, status)")
+			return
+		}
+		req.Status = models.NormalizeBinStatus(req.Status)
+		if !checkBinStatusTransition(w, req.Status, req.Status) {
 			return
 		}
 
@@ -81,10 +181,10 @@ func CreateBin(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
 			// Auto-assign based on highest existing bin_number (including retired bins)
 			// This ensures continuity: if bins are 54, 55, 56, 57, next will be 58
 			var maxBinNumber sql.NullInt64
-			err := db.Get(&maxBinNumber, "SELECT MAX(bin_number) FROM bins")
+			err := db.GetContext(r.Context(), &maxBinNumber, "SELECT MAX(bin_number) FROM bins")
 			if err != nil {
 				log.Printf("❌ [CREATE-BIN] Failed to get max bin_number: %v", err)
-				http.Error(w, "Failed to generate bin number", http.StatusInternalServerError)
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to generate bin number")
 				return
 			}
 
@@ -111,7 +211,7 @@ func CreateBin(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
 		}
 
 		// Insert bin
-		_, err := db.Exec(`
+		_, err := db.ExecContext(r.Context(), `
 			INSERT INTO bins (
 				id, bin_number, current_street, city, zip, status,
 				fill_percentage, checked, move_requested, latitude, longitude,
@@ -128,20 +228,20 @@ func CreateBin(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
 			// Check if bin_number already exists
 			if strings.Contains(err.Error(), "duplicate key") {
 				log.Printf("❌ [CREATE-BIN] Bin number %d already exists", binNumber)
-				http.Error(w, "Bin number already exists", http.StatusConflict)
+				utils.RespondError(w, http.StatusConflict, "Bin number already exists")
 				return
 			}
 			log.Printf("❌ [CREATE-BIN] Database insert failed: %v", err)
-			http.Error(w, "Failed to create bin", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to create bin")
 			return
 		}
 
 		// Fetch created bin
 		var created models.Bin
-		err = db.Get(&created, "SELECT * FROM bins WHERE id = $1", id)
+		err = db.GetContext(r.Context(), &created, "SELECT * FROM bins WHERE id = $1", id)
 		if err != nil {
 			log.Printf("❌ [CREATE-BIN] Failed to fetch created bin: %v", err)
-			http.Error(w, "Failed to fetch created bin", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch created bin")
 			return
 		}
 
@@ -164,7 +264,7 @@ func UpdateBin(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := chi.URLParam(r, "id")
 		if id == "" {
-			http.Error(w, "Bad Request", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Bad Request")
 			return
 		}
 
@@ -178,19 +278,29 @@ func UpdateBin(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
 
 		var req models.UpdateBinRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
 			return
 		}
 
 		// Get existing bin
 		var existing models.Bin
-		err := db.Get(&existing, "SELECT * FROM bins WHERE id = $1", id)
+		err := db.GetContext(r.Context(), &existing, "SELECT * FROM bins WHERE id = $1", id)
 		if err == sql.ErrNoRows {
-			http.Error(w, "Not found", http.StatusNotFound)
+			utils.RespondError(w, http.StatusNotFound, "Not found")
 			return
 		}
 		if err != nil {
-			http.Error(w, "Database error", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+
+		// An omitted status keeps the current one; manual changes must follow the bin status lifecycle
+		currentStatus := models.NormalizeBinStatus(existing.Status)
+		req.Status = models.NormalizeBinStatus(req.Status)
+		if req.Status == "" {
+			req.Status = currentStatus
+		}
+		if !checkBinStatusTransition(w, currentStatus, req.Status) {
 			return
 		}
 
@@ -223,46 +333,45 @@ func UpdateBin(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
 			strings.TrimSpace(req.Zip) != existing.Zip
 
 		// Start transaction
-		tx, err := db.Beginx()
+		tx, err := db.BeginTxx(r.Context(), nil)
 		if err != nil {
-			http.Error(w, "Failed to begin transaction", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to begin transaction")
 			return
 		}
 		defer tx.Rollback()
 
 		// Build update query
-		query := `
-			UPDATE bins
-			SET current_street = $1, city = $2, zip = $3, status = $4,
-			    checked = $5, fill_percentage = $6, move_requested = $7`
+		update := database.NewUpdate("bins").
+			Set("current_street", req.CurrentStreet).
+			Set("city", req.City).
+			Set("zip", req.Zip).
+			Set("status", req.Status).
+			Set("checked", req.Checked).
+			Set("fill_percentage", req.FillPercentage).
+			Set("move_requested", req.MoveRequested)
 
-		args := []interface{}{
-			req.CurrentStreet, req.City, req.Zip, req.Status,
-			req.Checked, req.FillPercentage, req.MoveRequested,
-		}
-
-		paramCount := 7
 		if becomingChecked {
-			paramCount++
-			query += `, last_checked = $` + fmt.Sprintf("%d", paramCount)
-			args = append(args, now.Unix())
+			update.Set("last_checked", now.Unix())
 		}
 
 		if addrChanged {
-			query += `, latitude = NULL, longitude = NULL`
+			update.SetNull("latitude").SetNull("longitude")
 		}
 
-		paramCount++
-		query += `, updated_at = $` + fmt.Sprintf("%d", paramCount) + ` WHERE id = $` + fmt.Sprintf("%d", paramCount+1)
-		args = append(args, time.Now().Unix(), id)
+		query, args, err := update.Set("updated_at", time.Now().Unix()).Where("id", id).Build()
+		if err != nil {
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to update bin")
+			return
+		}
 
-		_, err = tx.Exec(query, args...)
+		_, err = tx.ExecContext(r.Context(), query, args...)
 		if err != nil {
-			http.Error(w, "Failed to update bin", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to update bin")
 			return
 		}
 
 		// If becoming checked, insert check record
+		var checkID int
 		if becomingChecked {
 			checkedFrom := ""
 			if req.CheckedFrom != nil && strings.TrimSpace(*req.CheckedFrom) != "" {
@@ -277,27 +386,35 @@ func UpdateBin(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
 			}
 
 			// Include checked_by (authenticated user) and photo_url if provided
-			_, err = tx.Exec(`
-				INSERT INTO checks (bin_id, checked_from, fill_percentage, checked_on, checked_by, photo_url)
-				VALUES ($1, $2, $3, $4, $5, $6)
-			`, id, checkedFrom, fillForCheck, now.Unix(), userID, req.PhotoUrl)
+			err = tx.QueryRowContext(r.Context(), `
+				INSERT INTO checks (bin_id, checked_from, fill_percentage, checked_on, checked_by, photo_url, photo_required)
+				VALUES ($1, $2, $3, $4, $5, $6, (`+binPhotoRequiredSQL+`))
+				RETURNING id
+			`, id, checkedFrom, fillForCheck, now.Unix(), userID, req.PhotoUrl).Scan(&checkID)
 			if err != nil {
-				http.Error(w, "Failed to create check record", http.StatusInternalServerError)
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to create check record")
 				return
 			}
 		}
 
 		// Commit transaction
 		if err := tx.Commit(); err != nil {
-			http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to commit transaction")
 			return
 		}
+		store.InvalidateBins(id)
+
+		if checkID != 0 {
+			if _, err := services.DetectCheckAnomalies(db, checkID); err != nil {
+				log.Printf("⚠️  [UPDATE-BIN] Check anomaly detection failed: %v", err)
+			}
+		}
 
 		// Fetch updated bin
 		var updated models.Bin
-		err = db.Get(&updated, "SELECT * FROM bins WHERE id = $1", id)
+		err = db.GetContext(r.Context(), &updated, "SELECT * FROM bins WHERE id = $1", id)
 		if err != nil {
-			http.Error(w, "Failed to fetch updated bin", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch updated bin")
 			return
 		}
 
@@ -317,21 +434,22 @@ func DeleteBin(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := chi.URLParam(r, "id")
 		if id == "" {
-			http.Error(w, "Bad Request", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Bad Request")
 			return
 		}
 
-		result, err := db.Exec("DELETE FROM bins WHERE id = $1", id)
+		result, err := db.ExecContext(r.Context(), "DELETE FROM bins WHERE id = $1", id)
 		if err != nil {
-			http.Error(w, "Failed to delete", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to delete")
 			return
 		}
 
 		rows, err := result.RowsAffected()
 		if err != nil || rows == 0 {
-			http.Error(w, "Not found", http.StatusNotFound)
+			utils.RespondError(w, http.StatusNotFound, "Not found")
 			return
 		}
+		store.InvalidateBins(id)
 
 		// Broadcast to all managers
 		wsHub.BroadcastToRole("admin", map[string]interface{}{
@@ -352,70 +470,71 @@ func LoadRealBins(db *sqlx.DB) http.HandlerFunc {
 		fmt.Println("🗑️  REQUEST: POST /api/admin/bins/load-real")
 
 		// Step 1: Delete all non-Dallas bins
-		deleteResult, err := db.Exec("DELETE FROM bins WHERE city != 'Dallas'")
+		deleteResult, err := db.ExecContext(r.Context(), "DELETE FROM bins WHERE city != 'Dallas'")
 		if err != nil {
 			fmt.Printf("❌ Error deleting test bins: %v\n", err)
-			http.Error(w, "Failed to delete test bins", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to delete test bins")
 			return
 		}
 
 		deletedRows, _ := deleteResult.RowsAffected()
+		store.InvalidateBins()
 		fmt.Printf("✅ Deleted %d test bins\n", deletedRows)
 
 		// Step 2: Insert real bins data
 		// Using the migration SQL directly embedded in code
 		migrationSQL := `
 INSERT INTO bins (id, bin_number, current_street, city, zip, last_moved, last_checked, status, fill_percentage, checked, move_requested, latitude, longitude, created_at, updated_at) VALUES
-('c96c3c41-fdbd-4777-86eb-326edba84309', 1, '143 E El Camino Real', 'Mountain View', '94040', NULL, 1723403460, 'Missing', 40, 0, 0, 37.37858, -122.071589, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('14a67be5-9b31-4acf-bf48-4aacb39d3130', 2, '1101 W El Camino Real', 'Sunnyvale', '94087', NULL, 1729984568, 'Active', 25, 0, 0, 37.37386, -122.05294, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('8f4f7f05-c61f-4e20-9bc4-6db3f4defd59', 3, '615 Coleman Ave', 'San Jose', '95110', NULL, 1732068105, 'Active', 100, 0, 0, 37.340408, -121.908161, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('2c7d4b00-c070-4515-b91d-da85ec6b53b7', 4, '2400 Charleston Rd', 'Mountain View', '94043', NULL, 1732414957, 'Active', 40, 0, 0, 37.42182, -122.09657, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('826043b0-07a1-455c-ad5e-f7f8b6198262', 5, '1060 E El Camino Real', 'Sunnyvale', '94087', 1729983361, 1732420652, 'Active', 30, 0, 0, NULL, NULL, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('8e35d3f5-ff41-454b-84e8-224677e740c9', 6, '2161 Monterey Rd', 'San Jose', '95125', NULL, 1723475220, 'Missing', 90, 0, 0, 37.30441, -121.86563, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('d26930d5-394f-4d2b-9b33-759881f791b7', 7, '5055 Almaden Expy', 'San Jose', '95118', NULL, 1732424057, 'Active', 10, 0, 0, 37.25727, -121.8765, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('ebf3cc8a-0c2c-409b-92fb-96fda4b4c2e3', 8, '1933 W El Camino Real', 'Mountain View', '94040', NULL, 1732418838, 'Active', 40, 0, 0, 37.393042, -122.097551, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('153afdd6-fb6c-4ce1-80cc-8ba59302d4db', 9, '5524 Monterey Rd', 'San Jose', '95138', NULL, 1730042536, 'Missing', 100, 0, 0, 37.25637, -121.79907, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('48b39523-183e-49ed-9df5-c0d7352cc29f', 10, '3635 El Camino Real', 'Santa Clara', '95051', NULL, 1732421011, 'Active', 5, 0, 0, 37.352291, -121.988535, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('48f7c1c0-9fab-4b74-bf03-fc8a86156afc', 11, '199 E Middlefield Rd Ste 200', 'Mountain View', '94043', NULL, 1732414062, 'Active', 30, 0, 0, 37.397222, -122.062096, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('0f0ef655-77e2-4738-8976-d04841b41c8c', 12, '1660 Winchester Blvd', 'Campbell', '95008', NULL, NULL, 'Missing', 0, 0, 0, 37.293058, -121.949259, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('f1649781-bcde-4412-a313-a49801de73ed', 13, '1305 S Winchester Blvd', 'San Jose', '95117', NULL, NULL, 'Missing', 0, 0, 0, 37.300899, -121.951779, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('ad92c60c-4b10-4c51-ae47-155b0def8baa', 14, '4644 Meridian Ave', 'San Jose', '95124', NULL, 1732423438, 'Active', 100, 0, 0, 37.256866, -121.897573, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('46f118f7-ac2f-436f-a658-f17ccb0b775c', 15, '1130 Branham Ln', 'San Jose', '95118', NULL, 1722433440, 'Missing', 15, 0, 0, 37.26202, -121.878139, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('2548deaf-8e80-49f6-a2ac-92aaffb45032', 16, '1721 E Bayshore Rd', 'Palo Alto', '94303', NULL, 1732409894, 'Active', 100, 0, 0, 37.460225, -122.137732, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('4c19a8b2-2ffa-47e4-9965-3cc0e4583cdb', 17, '2720 El Camino Real', 'Santa Clara', '95051', NULL, 1732421559, 'Active', 20, 0, 0, 37.352196, -121.975935, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('e30d6984-1ac5-4cce-bc8d-4840ab849197', 18, '1691 The Alameda San Jose, CA  95126 United States', 'San Jose', '95126', 1729448553, 1728108195, 'Missing', 30, 0, 0, 37.337079, -121.919615, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('35ee2834-8b1c-406f-a720-974ecd833dc1', 19, '1041 El Monte Ave', 'Mountain View', '94040', NULL, 1732418844, 'Active', 100, 0, 0, 37.390051, -122.094742, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('fa541645-13fa-479c-93d2-5b8d7cc958ec', 20, '2510 W El Camino Real Suite 2', 'Mountain View', '94040', NULL, 1732416763, 'Active', 25, 0, 0, 37.40003, -122.110255, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('bfa30cb2-14ee-4aa0-871c-7fa78fddbe6a', 21, 'Mountain View Shopping Center 121 E El Camino Real Mountain View, CA  94040 United States', 'Mountain View', '94040', 1729120112, 1732052767, 'Missing', 70, 0, 0, NULL, NULL, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('1959f69c-0d7e-4f09-a184-17fd47aa3b63', 22, '1757 W San Carlos St San Jose, CA  95128 United States', 'San Jose', '95128', 1728114850, 1732422663, 'Active', 40, 0, 0, NULL, NULL, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('baf638d9-a0b9-40bc-a232-4b17ccc6e828', 23, '1425 Lafayette St Santa Clara, CA  95050 United States', 'Santa Clara', '95050', 1726533799, 1732059576, 'Active', 40, 0, 0, 37.35466, -121.94572, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('e3f52b60-8164-4adb-9c1c-f908719d3d3a', 24, '3904 Middlefield Rd', 'Palo Alto', '94303', NULL, 1728508791, 'Missing', 20, 0, 0, 37.419241, -122.110524, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('fcbd018f-bfd2-4615-a5fa-06fa96fc0fbf', 25, '2811 Middlefield Rd', 'Palo Alto', '94306', NULL, 1732411205, 'Active', 80, 0, 0, 37.43288, -122.127406, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('c207b5fe-880b-414b-b1fe-02283b5aee36', 26, '887 E El Camino Real Sunnyvale, CA  94087 United States', 'Sunnyvale', '94087', 1725868335, 1732420107, 'Active', 25, 0, 0, 37.354035, -122.014985, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('c552735a-354d-45fe-8637-484f2daa1341', 27, '525 El Camino Real', 'Menlo Park', '94025', NULL, NULL, 'Active', 0, 0, 0, 37.452067, -122.178904, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('6da99eed-a351-4396-80a2-447b1d703eb5', 28, '200 Woodside Plaza', 'Redwood City', '94061', NULL, 1723388760, 'Missing', 100, 0, 0, 37.456535, -122.229593, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('75401257-1c80-4d37-ba9e-6ee484b41487', 29, '5269 Prospect Rd San Jose, CA  95129 United States', 'San Jose', '95129', 1726528434, 1726503609, 'Missing', 0, 0, 1, 37.292949, -121.994242, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('f4906f01-453b-4419-ba4f-6857c6a5ae49', 30, '2495 Lafayette St', 'Santa Clara', '95050', NULL, 1732063444, 'Active', 30, 0, 0, 37.36573, -121.94979, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('74da2fad-2e1e-44ce-896d-2437edb83b1d', 31, '590 Showers Dr', 'Mountain View', '94040', NULL, 1732416751, 'Active', 25, 0, 0, 37.402102, -122.110739, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('1a989b38-f538-4bd9-9444-dcdef185f8c6', 32, '20 Woodside Plaza', 'Redwood City', '94061', NULL, 1723995720, 'Missing', 100, 0, 0, 37.457956, -122.22879, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('efac394f-307a-4b1b-a0ab-21cd8a54d0ae', 33, '1349 Coleman Ave Santa Clara, CA  95050 United States', 'Santa Clara', '95050', 1729456542, 1730656400, 'Active', 20, 0, 0, 37.356773, -121.935764, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('b14813e2-48a1-4075-87ba-654fb362ada3', 34, '2485 El Camino Real', 'Redwood City', '94063', NULL, 1722351600, 'Missing', 50, 0, 0, 37.475639, -122.217094, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('5438c040-1b57-4087-8651-5e1a85fec954', 35, '1920 Camden Ave San Jose, CA  95124 United States', 'San Jose', '95124', 1730768552, 1732422258, 'Active', 2, 0, 0, NULL, NULL, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('3f9d27a3-bf21-4c7a-987a-5048969afaa4', 36, '2407 El Camino Real Redwood City, CA  94063 United States', 'Redwood City', '94063', 1725860739, 1732410071, 'Active', 100, 0, 0, 37.485323, -122.229117, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('6e7c94b9-e894-4a4c-b20b-d72b19226c5e', 37, '1884 S Norfolk St', 'San Mateo', '94403', NULL, 1732406522, 'Active', 100, 0, 0, 37.554401, -122.29191, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('0a3d7c94-17fd-4951-b11c-698630255f4d', 38, '516 El Camino Real', 'Belmont', '94002', NULL, 1732408347, 'Active', 80, 0, 0, 37.52528, -122.282498, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('da4d6093-eff1-41b4-bd2c-95e97a653122', 39, '1119 Industrial Rd Ste F', 'San Carlos', '94070', NULL, 1727367714, 'Missing', 30, 0, 1, 37.50393, -122.246335, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('5d6c53f6-a03d-4ac2-846c-1fe7382f5e12', 40, '2220 Bridgepointe Pkwy', 'San Mateo', '94404', NULL, 1732407068, 'Active', 100, 0, 0, 37.558595, -122.283297, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('2b92ba74-cd05-426f-8c2c-56094fd60512', 41, '640 Concar Dr', 'San Mateo', '94402', NULL, 1732405547, 'Active', 40, 0, 0, 37.553598, -122.304754, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('64ff1a7c-e994-4544-8188-d3e3ac87aa92', 42, '3904 Middlefield Rd', 'Palo Alto', '94303', 1728582476, 1732415730, 'Active', 40, 0, 0, NULL, NULL, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('35b5527b-25f8-4373-8255-fe1ab0eac396', 43, '2021 The Alameda', 'San Jose', '95126', NULL, 1729183170, 'Active', 5, 0, 0, 37.342805, -121.927995, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
-('f7aac47e-7479-458d-a717-b792963f9a4f', 44, '4960 Almaden Expy San Jose, CA  95118 United States', 'San Jose', '95118', 1727318409, 1731213193, 'Active', 50, 0, 0, 37.2605, -121.874759, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT);
+('c96c3c41-fdbd-4777-86eb-326edba84309', 1, '143 E El Camino Real', 'Mountain View', '94040', NULL, 1723403460, 'missing', 40, 0, 0, 37.37858, -122.071589, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('14a67be5-9b31-4acf-bf48-4aacb39d3130', 2, '1101 W El Camino Real', 'Sunnyvale', '94087', NULL, 1729984568, 'active', 25, 0, 0, 37.37386, -122.05294, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('8f4f7f05-c61f-4e20-9bc4-6db3f4defd59', 3, '615 Coleman Ave', 'San Jose', '95110', NULL, 1732068105, 'active', 100, 0, 0, 37.340408, -121.908161, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('2c7d4b00-c070-4515-b91d-da85ec6b53b7', 4, '2400 Charleston Rd', 'Mountain View', '94043', NULL, 1732414957, 'active', 40, 0, 0, 37.42182, -122.09657, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('826043b0-07a1-455c-ad5e-f7f8b6198262', 5, '1060 E El Camino Real', 'Sunnyvale', '94087', 1729983361, 1732420652, 'active', 30, 0, 0, NULL, NULL, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('8e35d3f5-ff41-454b-84e8-224677e740c9', 6, '2161 Monterey Rd', 'San Jose', '95125', NULL, 1723475220, 'missing', 90, 0, 0, 37.30441, -121.86563, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('d26930d5-394f-4d2b-9b33-759881f791b7', 7, '5055 Almaden Expy', 'San Jose', '95118', NULL, 1732424057, 'active', 10, 0, 0, 37.25727, -121.8765, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('ebf3cc8a-0c2c-409b-92fb-96fda4b4c2e3', 8, '1933 W El Camino Real', 'Mountain View', '94040', NULL, 1732418838, 'active', 40, 0, 0, 37.393042, -122.097551, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('153afdd6-fb6c-4ce1-80cc-8ba59302d4db', 9, '5524 Monterey Rd', 'San Jose', '95138', NULL, 1730042536, 'missing', 100, 0, 0, 37.25637, -121.79907, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('48b39523-183e-49ed-9df5-c0d7352cc29f', 10, '3635 El Camino Real', 'Santa Clara', '95051', NULL, 1732421011, 'active', 5, 0, 0, 37.352291, -121.988535, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('48f7c1c0-9fab-4b74-bf03-fc8a86156afc', 11, '199 E Middlefield Rd Ste 200', 'Mountain View', '94043', NULL, 1732414062, 'active', 30, 0, 0, 37.397222, -122.062096, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('0f0ef655-77e2-4738-8976-d04841b41c8c', 12, '1660 Winchester Blvd', 'Campbell', '95008', NULL, NULL, 'missing', 0, 0, 0, 37.293058, -121.949259, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('f1649781-bcde-4412-a313-a49801de73ed', 13, '1305 S Winchester Blvd', 'San Jose', '95117', NULL, NULL, 'missing', 0, 0, 0, 37.300899, -121.951779, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('ad92c60c-4b10-4c51-ae47-155b0def8baa', 14, '4644 Meridian Ave', 'San Jose', '95124', NULL, 1732423438, 'active', 100, 0, 0, 37.256866, -121.897573, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('46f118f7-ac2f-436f-a658-f17ccb0b775c', 15, '1130 Branham Ln', 'San Jose', '95118', NULL, 1722433440, 'missing', 15, 0, 0, 37.26202, -121.878139, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('2548deaf-8e80-49f6-a2ac-92aaffb45032', 16, '1721 E Bayshore Rd', 'Palo Alto', '94303', NULL, 1732409894, 'active', 100, 0, 0, 37.460225, -122.137732, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('4c19a8b2-2ffa-47e4-9965-3cc0e4583cdb', 17, '2720 El Camino Real', 'Santa Clara', '95051', NULL, 1732421559, 'active', 20, 0, 0, 37.352196, -121.975935, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('e30d6984-1ac5-4cce-bc8d-4840ab849197', 18, '1691 The Alameda San Jose, CA  95126 United States', 'San Jose', '95126', 1729448553, 1728108195, 'missing', 30, 0, 0, 37.337079, -121.919615, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('35ee2834-8b1c-406f-a720-974ecd833dc1', 19, '1041 El Monte Ave', 'Mountain View', '94040', NULL, 1732418844, 'active', 100, 0, 0, 37.390051, -122.094742, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('fa541645-13fa-479c-93d2-5b8d7cc958ec', 20, '2510 W El Camino Real Suite 2', 'Mountain View', '94040', NULL, 1732416763, 'active', 25, 0, 0, 37.40003, -122.110255, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('bfa30cb2-14ee-4aa0-871c-7fa78fddbe6a', 21, 'Mountain View Shopping Center 121 E El Camino Real Mountain View, CA  94040 United States', 'Mountain View', '94040', 1729120112, 1732052767, 'missing', 70, 0, 0, NULL, NULL, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('1959f69c-0d7e-4f09-a184-17fd47aa3b63', 22, '1757 W San Carlos St San Jose, CA  95128 United States', 'San Jose', '95128', 1728114850, 1732422663, 'active', 40, 0, 0, NULL, NULL, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('baf638d9-a0b9-40bc-a232-4b17ccc6e828', 23, '1425 Lafayette St Santa Clara, CA  95050 United States', 'Santa Clara', '95050', 1726533799, 1732059576, 'active', 40, 0, 0, 37.35466, -121.94572, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('e3f52b60-8164-4adb-9c1c-f908719d3d3a', 24, '3904 Middlefield Rd', 'Palo Alto', '94303', NULL, 1728508791, 'missing', 20, 0, 0, 37.419241, -122.110524, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('fcbd018f-bfd2-4615-a5fa-06fa96fc0fbf', 25, '2811 Middlefield Rd', 'Palo Alto', '94306', NULL, 1732411205, 'active', 80, 0, 0, 37.43288, -122.127406, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('c207b5fe-880b-414b-b1fe-02283b5aee36', 26, '887 E El Camino Real Sunnyvale, CA  94087 United States', 'Sunnyvale', '94087', 1725868335, 1732420107, 'active', 25, 0, 0, 37.354035, -122.014985, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('c552735a-354d-45fe-8637-484f2daa1341', 27, '525 El Camino Real', 'Menlo Park', '94025', NULL, NULL, 'active', 0, 0, 0, 37.452067, -122.178904, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('6da99eed-a351-4396-80a2-447b1d703eb5', 28, '200 Woodside Plaza', 'Redwood City', '94061', NULL, 1723388760, 'missing', 100, 0, 0, 37.456535, -122.229593, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('75401257-1c80-4d37-ba9e-6ee484b41487', 29, '5269 Prospect Rd San Jose, CA  95129 United States', 'San Jose', '95129', 1726528434, 1726503609, 'missing', 0, 0, 1, 37.292949, -121.994242, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('f4906f01-453b-4419-ba4f-6857c6a5ae49', 30, '2495 Lafayette St', 'Santa Clara', '95050', NULL, 1732063444, 'active', 30, 0, 0, 37.36573, -121.94979, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('74da2fad-2e1e-44ce-896d-2437edb83b1d', 31, '590 Showers Dr', 'Mountain View', '94040', NULL, 1732416751, 'active', 25, 0, 0, 37.402102, -122.110739, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('1a989b38-f538-4bd9-9444-dcdef185f8c6', 32, '20 Woodside Plaza', 'Redwood City', '94061', NULL, 1723995720, 'missing', 100, 0, 0, 37.457956, -122.22879, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('efac394f-307a-4b1b-a0ab-21cd8a54d0ae', 33, '1349 Coleman Ave Santa Clara, CA  95050 United States', 'Santa Clara', '95050', 1729456542, 1730656400, 'active', 20, 0, 0, 37.356773, -121.935764, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('b14813e2-48a1-4075-87ba-654fb362ada3', 34, '2485 El Camino Real', 'Redwood City', '94063', NULL, 1722351600, 'missing', 50, 0, 0, 37.475639, -122.217094, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('5438c040-1b57-4087-8651-5e1a85fec954', 35, '1920 Camden Ave San Jose, CA  95124 United States', 'San Jose', '95124', 1730768552, 1732422258, 'active', 2, 0, 0, NULL, NULL, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('3f9d27a3-bf21-4c7a-987a-5048969afaa4', 36, '2407 El Camino Real Redwood City, CA  94063 United States', 'Redwood City', '94063', 1725860739, 1732410071, 'active', 100, 0, 0, 37.485323, -122.229117, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('6e7c94b9-e894-4a4c-b20b-d72b19226c5e', 37, '1884 S Norfolk St', 'San Mateo', '94403', NULL, 1732406522, 'active', 100, 0, 0, 37.554401, -122.29191, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('0a3d7c94-17fd-4951-b11c-698630255f4d', 38, '516 El Camino Real', 'Belmont', '94002', NULL, 1732408347, 'active', 80, 0, 0, 37.52528, -122.282498, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('da4d6093-eff1-41b4-bd2c-95e97a653122', 39, '1119 Industrial Rd Ste F', 'San Carlos', '94070', NULL, 1727367714, 'missing', 30, 0, 1, 37.50393, -122.246335, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('5d6c53f6-a03d-4ac2-846c-1fe7382f5e12', 40, '2220 Bridgepointe Pkwy', 'San Mateo', '94404', NULL, 1732407068, 'active', 100, 0, 0, 37.558595, -122.283297, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('2b92ba74-cd05-426f-8c2c-56094fd60512', 41, '640 Concar Dr', 'San Mateo', '94402', NULL, 1732405547, 'active', 40, 0, 0, 37.553598, -122.304754, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('64ff1a7c-e994-4544-8188-d3e3ac87aa92', 42, '3904 Middlefield Rd', 'Palo Alto', '94303', 1728582476, 1732415730, 'active', 40, 0, 0, NULL, NULL, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('35b5527b-25f8-4373-8255-fe1ab0eac396', 43, '2021 The Alameda', 'San Jose', '95126', NULL, 1729183170, 'active', 5, 0, 0, 37.342805, -121.927995, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+('f7aac47e-7479-458d-a717-b792963f9a4f', 44, '4960 Almaden Expy San Jose, CA  95118 United States', 'San Jose', '95118', 1727318409, 1731213193, 'active', 50, 0, 0, 37.2605, -121.874759, EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT);
 `
 
-		_, err = db.Exec(migrationSQL)
+		_, err = db.ExecContext(r.Context(), migrationSQL)
 		if err != nil {
 			fmt.Printf("❌ Error inserting bins: %v\n", err)
-			http.Error(w, "Failed to load bins", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to load bins")
 			return
 		}
 
@@ -429,11 +548,11 @@ INSERT INTO bins (id, bin_number, current_street, city, zip, last_moved, last_ch
 			BinsWithoutCoords int `db:"bins_without_coords"`
 		}
 
-		err = db.Get(&summary, `
+		err = db.GetContext(r.Context(), &summary, `
 			SELECT
 				COUNT(*) AS total_bins,
-				COUNT(CASE WHEN status = 'Active' THEN 1 END) AS active_bins,
-				COUNT(CASE WHEN status = 'Missing' THEN 1 END) AS missing_bins,
+				COUNT(CASE WHEN status = 'active' THEN 1 END) AS active_bins,
+				COUNT(CASE WHEN status = 'missing' THEN 1 END) AS missing_bins,
 				COUNT(CASE WHEN latitude IS NULL OR longitude IS NULL THEN 1 END) AS bins_without_coords
 			FROM bins
 			WHERE city != 'Dallas'
@@ -462,20 +581,19 @@ INSERT INTO bins (id, bin_number, current_street, city, zip, last_moved, last_ch
 	}
 }
 
-// FixBinStatus lowercases all bin status values for Flutter compatibility
+// FixBinStatus rewrites legacy bin status values to the canonical statuses (also done on every startup)
 func FixBinStatus(db *sqlx.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		fmt.Println("🔧 REQUEST: POST /api/admin/bins/fix-status")
 
-		// Update all bin statuses to lowercase
-		result, err := db.Exec("UPDATE bins SET status = LOWER(status)")
+		rowsAffected, err := database.NormalizeBinStatuses(db)
 		if err != nil {
 			fmt.Printf("❌ Error updating status: %v\n", err)
-			http.Error(w, "Failed to update bin statuses", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to update bin statuses")
 			return
 		}
 
-		rowsAffected, _ := result.RowsAffected()
+		store.InvalidateBins()
 		fmt.Printf("✅ Updated %d bin statuses to lowercase\n", rowsAffected)
 
 		w.Header().Set("Content-Type", "application/json")
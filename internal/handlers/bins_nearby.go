@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	nearbyDefaultRadiusMeters = 500
+	nearbyMaxRadiusMeters     = 5000
+	nearbyDefaultLimit        = 50
+	nearbyMaxLimit            = 200
+	metersPerDegreeLatitude   = 111320.0
+)
+
+// nearbyBoundingBox returns the latitude/longitude box around a point that contains the whole radius,
+// so the query can use idx_bins_lat_lng before distances are computed exactly
+func nearbyBoundingBox(lat, lng, radiusMeters float64) (minLat, maxLat, minLng, maxLng float64) {
+	dLat := radiusMeters / metersPerDegreeLatitude
+	dLng := 180.0 // Near the poles every longitude is within reach
+	if cosLat := math.Cos(lat * math.Pi / 180); cosLat > 0.01 {
+		dLng = math.Min(radiusMeters/(metersPerDegreeLatitude*cosLat), 180)
+	}
+	return lat - dLat, lat + dLat, lng - dLng, lng + dLng
+}
+
+// GetNearbyBins returns the bins within a radius of a point, nearest first
+// GET /api/bins/nearby?lat=<lat>&lng=<lng>&radius=<meters, default 500, max 5000>&limit=50&include_retired=false
+func GetNearbyBins(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		lat, latErr := strconv.ParseFloat(q.Get("lat"), 64)
+		lng, lngErr := strconv.ParseFloat(q.Get("lng"), 64)
+		if latErr != nil || lngErr != nil || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+			utils.RespondError(w, http.StatusBadRequest, "lat and lng must be valid coordinates")
+			return
+		}
+
+		radius := float64(nearbyDefaultRadiusMeters)
+		if v := q.Get("radius"); v != "" {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil || parsed <= 0 || parsed > nearbyMaxRadiusMeters {
+				utils.RespondError(w, http.StatusBadRequest, "radius must be between 1 and 5000 meters")
+				return
+			}
+			radius = parsed
+		}
+
+		limit := nearbyDefaultLimit
+		if parsed, err := strconv.Atoi(q.Get("limit")); err == nil && parsed > 0 {
+			limit = int(math.Min(float64(parsed), nearbyMaxLimit))
+		}
+		includeRetired := q.Get("include_retired") == "true"
+
+		// PostGIS: ST_DWithin on the geography index, with distances from ST_Distance
+		// Otherwise: bounding box on idx_bins_lat_lng, then haversine below
+		args := []interface{}{includeRetired}
+		where, distance := "", "NULL::FLOAT8"
+		if database.PostGISEnabled() {
+			args = append(args, lat, lng, radius)
+			point := database.PostGISPoint("$3", "$2")
+			where = "ST_DWithin(b.geog, " + point + ", $4)"
+			distance = "ST_Distance(b.geog, " + point + ")"
+		} else {
+			minLat, maxLat, minLng, maxLng := nearbyBoundingBox(lat, lng, radius)
+			args = append(args, minLat, maxLat, minLng, maxLng)
+			where = "b.latitude BETWEEN $2 AND $3 AND b.longitude BETWEEN $4 AND $5"
+		}
+
+		var rows []struct {
+			models.Bin
+			DistanceMeters     *float64 `db:"distance_meters"`
+			PendingMoveID      *string  `db:"pending_move_id"`
+			PendingMoveUrgency *string  `db:"pending_move_urgency"`
+		}
+		err := db.SelectContext(r.Context(), &rows, `
+			SELECT b.id, b.bin_number, b.current_street, b.city, b.zip,
+			       b.last_moved, b.last_checked, b.status, b.fill_percentage,
+			       b.checked, b.move_requested, b.latitude, b.longitude, b.area_id,
+			       b.created_at, b.updated_at,
+			       `+distance+` AS distance_meters,
+			       mr.id AS pending_move_id, mr.urgency AS pending_move_urgency
+			FROM bins b
+			LEFT JOIN LATERAL (
+			    SELECT id, urgency FROM bin_move_requests
+			    WHERE bin_id = b.id AND status IN ('pending', 'in_progress', 'picked_up')
+			    ORDER BY scheduled_date ASC
+			    LIMIT 1
+			) mr ON true
+			WHERE `+where+`
+			  AND ($1 OR b.status <> 'retired')
+		`, args...)
+		if err != nil {
+			log.Printf("❌ [NEARBY] Failed to fetch bins near %.5f,%.5f: %v", lat, lng, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch nearby bins")
+			return
+		}
+
+		response := models.NearbyBinsResponse{
+			Latitude:     lat,
+			Longitude:    lng,
+			RadiusMeters: radius,
+			Bins:         []models.NearbyBin{},
+		}
+		for _, row := range rows {
+			distance := 0.0
+			if row.DistanceMeters != nil {
+				distance = *row.DistanceMeters
+			} else {
+				// The box is wider than the circle near its corners
+				distance = haversineDistanceKm(lat, lng, *row.Latitude, *row.Longitude) * 1000
+				if distance > radius {
+					continue
+				}
+			}
+			response.Bins = append(response.Bins, models.NearbyBin{
+				BinResponse:        row.Bin.ToBinResponse(),
+				DistanceMeters:     math.Round(distance*10) / 10,
+				HasPendingMove:     row.PendingMoveID != nil,
+				PendingMoveID:      row.PendingMoveID,
+				PendingMoveUrgency: row.PendingMoveUrgency,
+			})
+		}
+
+		sort.SliceStable(response.Bins, func(i, j int) bool {
+			return response.Bins[i].DistanceMeters < response.Bins[j].DistanceMeters
+		})
+		if len(response.Bins) > limit {
+			response.Bins = response.Bins[:limit]
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    response,
+		})
+	}
+}
@@ -0,0 +1,391 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Capacity plan defaults
+const (
+	capacityDefaultDays          = 7
+	capacityMaxDays              = 28
+	capacityHistoryDays          = 30   // Fill readings and ended shifts the forecast learns from
+	capacityDefaultStopsPerShift = 30.0 // When no driver ended a shift in the history window
+)
+
+// capacityPlanColumns is the header row of the exported capacity plan
+var capacityPlanColumns = []string{
+	"date", "service_day", "area_id", "area_name", "collections", "moves", "stops", "shifts_needed",
+	"drivers_available", "shortfall",
+}
+
+// capacityBin is a bin as the fill forecast sees it
+type capacityBin struct {
+	ID             string  `db:"id"`
+	AreaID         *string `db:"area_id"`
+	AreaName       string  `db:"area_name"`
+	FillPercentage *int    `db:"fill_percentage"`
+	ReadingAt      int64   `db:"reading_at"` // When fill_percentage was read
+}
+
+// capacityMove is a pending move request as the capacity plan sees it
+type capacityMove struct {
+	ScheduledDate int64   `db:"scheduled_date"`
+	AreaID        *string `db:"area_id"`
+	AreaName      string  `db:"area_name"`
+}
+
+// GetCapacityPlan estimates how many drivers are needed each day for the next days: bins are forecast from their
+// fill rate over the last 30 days (rises between readings; bins without readings use their area's average) to
+// reach the critical fill level, are collected that day and fill up again from empty; pending move requests add a
+// stop on their scheduled date. Stops per area are divided by the average stops per shift per driver
+// GET /api/analytics/capacity-plan?days=7&stops_per_shift=35&area_id=...&format=json|csv|xlsx
+func GetCapacityPlan(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+
+		days := capacityDefaultDays
+		if v := q.Get("days"); v != "" {
+			parsed, err := strconv.Atoi(v)
+			if err != nil || parsed < 1 || parsed > capacityMaxDays {
+				utils.RespondError(w, http.StatusBadRequest, fmt.Sprintf("days must be between 1 and %d", capacityMaxDays))
+				return
+			}
+			days = parsed
+		}
+		var stopsPerShift float64
+		if v := q.Get("stops_per_shift"); v != "" {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil || parsed < 1 || parsed > 500 {
+				utils.RespondError(w, http.StatusBadRequest, "stops_per_shift must be between 1 and 500")
+				return
+			}
+			stopsPerShift = parsed
+		}
+		format := strings.ToLower(q.Get("format"))
+		if format == "" {
+			format = "json"
+		}
+		if format != "json" && format != "csv" && format != "xlsx" {
+			utils.RespondError(w, http.StatusBadRequest, "format must be json, csv or xlsx")
+			return
+		}
+
+		plan, err := buildCapacityPlan(r.Context(), db, time.Now(), days, stopsPerShift, q.Get("area_id"))
+		if err != nil {
+			log.Printf("❌ [CAPACITY-PLAN] %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to build capacity plan")
+			return
+		}
+
+		if format == "json" {
+			utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+				"success": true,
+				"data":    plan,
+			})
+			return
+		}
+
+		rows := [][]string{capacityPlanColumns}
+		for _, day := range plan.Days {
+			rows = append(rows, []string{
+				day.Date, strconv.FormatBool(day.ServiceDay), "", "All areas",
+				strconv.Itoa(day.Collections), strconv.Itoa(day.Moves), strconv.Itoa(day.Stops), strconv.Itoa(day.ShiftsNeeded),
+				strconv.Itoa(plan.DriversAvailable), strconv.Itoa(day.Shortfall),
+			})
+			for _, area := range day.Areas {
+				rows = append(rows, []string{
+					day.Date, strconv.FormatBool(day.ServiceDay), formatOptionalString(area.AreaID), area.AreaName,
+					strconv.Itoa(area.Collections), strconv.Itoa(area.Moves), strconv.Itoa(area.Stops), strconv.Itoa(area.ShiftsNeeded),
+					"", "",
+				})
+			}
+		}
+
+		filename := "capacity-plan-" + plan.Days[0].Date + "." + format
+		var body bytes.Buffer
+		if format == "xlsx" {
+			err = utils.WriteXLSX(&body, "Capacity plan", rows)
+			w.Header().Set("Content-Type", utils.XLSXContentType)
+		} else {
+			cw := csv.NewWriter(&body)
+			cw.WriteAll(rows)
+			err = cw.Error()
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		}
+		if err != nil {
+			log.Printf("❌ [CAPACITY-PLAN] Failed to write %s: %v", format, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to export capacity plan")
+			return
+		}
+
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+		w.WriteHeader(http.StatusOK)
+		w.Write(body.Bytes())
+	}
+}
+
+// buildCapacityPlan forecasts the stops due each day from now's local date for days days
+// stopsPerShift of 0 uses the drivers' recent average; areaID ("unassigned" for bins outside every area) narrows
+// the plan to one area
+func buildCapacityPlan(ctx context.Context, db *sqlx.DB, now time.Time, days int, stopsPerShift float64, areaID string) (*models.CapacityPlan, error) {
+	thresholds, err := database.GetFillThresholds(db)
+	if err != nil {
+		log.Printf("⚠️  [CAPACITY-PLAN] %v (using defaults)", err)
+	}
+	hours, err := database.GetServiceHoursSettings(db)
+	if err != nil {
+		log.Printf("⚠️  [CAPACITY-PLAN] %v (using defaults)", err)
+	}
+	loc := hours.Location()
+	threshold := float64(thresholds.Critical)
+
+	plan := &models.CapacityPlan{
+		GeneratedAt:         now.Unix(),
+		Timezone:            loc.String(),
+		FillThreshold:       thresholds.Critical,
+		StopsPerShift:       stopsPerShift,
+		StopsPerShiftSource: models.StopsPerShiftOverride,
+		Days:                make([]models.CapacityPlanDay, days),
+	}
+
+	// Days are local dates; stops due on a day without service wait for the next service day
+	local := now.In(loc)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	dayStarts := make([]time.Time, days+1)
+	for i := range dayStarts {
+		dayStarts[i] = today.AddDate(0, 0, i)
+	}
+	serviceDay := make([]int, days) // Index of the day stops due on day i are planned for, -1 past the horizon
+	next := -1
+	for i := days - 1; i >= 0; i-- {
+		plan.Days[i] = models.CapacityPlanDay{
+			Date:       dayStarts[i].Format("2006-01-02"),
+			ServiceDay: hours.IsServiceDay(dayStarts[i].Add(12 * time.Hour)),
+			Areas:      []models.CapacityPlanArea{},
+		}
+		if plan.Days[i].ServiceDay {
+			next = i
+		}
+		serviceDay[i] = next
+	}
+	dayOf := func(t time.Time) int {
+		if t.Before(dayStarts[0]) {
+			return 0
+		}
+		for i := 1; i <= days; i++ {
+			if t.Before(dayStarts[i]) {
+				return i - 1
+			}
+		}
+		return days
+	}
+	daysFromNow := func(t time.Time) float64 { return t.Sub(now).Hours() / 24 }
+
+	var bins []capacityBin
+	err = db.SelectContext(ctx, &bins, `
+		SELECT b.id, b.area_id, COALESCE(a.name, 'Unassigned') AS area_name, b.fill_percentage,
+		       COALESCE(b.last_checked_at, b.last_checked, b.created_at) AS reading_at
+		FROM bins b
+		LEFT JOIN areas a ON a.id = b.area_id
+		WHERE b.status NOT IN ('retired', 'in_storage')
+		  AND ($1 = '' OR ($1 = 'unassigned' AND b.area_id IS NULL) OR b.area_id = $1)
+	`, areaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch bins: %w", err)
+	}
+	plan.BinsForecast = len(bins)
+
+	// Fill rate (% per day) from the rises between consecutive readings; drops are collections
+	var rateRows []struct {
+		BinID   string  `db:"bin_id"`
+		Rise    float64 `db:"rise"`
+		Seconds float64 `db:"seconds"`
+	}
+	err = db.SelectContext(ctx, &rateRows, `
+		SELECT bin_id, SUM(fill_percentage - prev_fill) AS rise, SUM(recorded_at - prev_at)::DOUBLE PRECISION AS seconds
+		FROM (
+			SELECT bin_id, recorded_at, fill_percentage,
+			       LAG(fill_percentage) OVER w AS prev_fill, LAG(recorded_at) OVER w AS prev_at
+			FROM bin_fill_history
+			WHERE recorded_at >= $1
+			WINDOW w AS (PARTITION BY bin_id ORDER BY recorded_at)
+		) h
+		WHERE prev_fill IS NOT NULL AND fill_percentage >= prev_fill AND recorded_at > prev_at
+		GROUP BY bin_id
+	`, now.AddDate(0, 0, -capacityHistoryDays).Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute fill rates: %w", err)
+	}
+	rates := make(map[string]float64, len(rateRows))
+	for _, row := range rateRows {
+		rates[row.BinID] = row.Rise / row.Seconds * 86400
+	}
+
+	areaKey := func(id *string) string {
+		if id == nil {
+			return ""
+		}
+		return *id
+	}
+	areaRateSum, areaRateCount := map[string]float64{}, map[string]int{}
+	var fleetRateSum float64
+	var fleetRateCount int
+	for _, bin := range bins {
+		if rate, ok := rates[bin.ID]; ok {
+			areaRateSum[areaKey(bin.AreaID)] += rate
+			areaRateCount[areaKey(bin.AreaID)]++
+			fleetRateSum += rate
+			fleetRateCount++
+		}
+	}
+
+	type areaDay struct {
+		collections, moves int
+	}
+	areaNames := map[string]*models.CapacityPlanArea{}
+	perArea := map[string][]areaDay{}
+	addStop := func(id *string, name string, day int, move bool) {
+		if day >= days || serviceDay[day] < 0 {
+			return
+		}
+		key := areaKey(id)
+		if _, ok := areaNames[key]; !ok {
+			areaNames[key] = &models.CapacityPlanArea{AreaID: id, AreaName: name}
+			perArea[key] = make([]areaDay, days)
+		}
+		if move {
+			perArea[key][serviceDay[day]].moves++
+		} else {
+			perArea[key][serviceDay[day]].collections++
+		}
+	}
+
+	for _, bin := range bins {
+		rate, ok := rates[bin.ID]
+		if !ok {
+			plan.BinsWithoutHistory++
+			if n := areaRateCount[areaKey(bin.AreaID)]; n > 0 {
+				rate = areaRateSum[areaKey(bin.AreaID)] / float64(n)
+			} else if fleetRateCount > 0 {
+				rate = fleetRateSum / float64(fleetRateCount)
+			}
+		}
+		level := 0.0
+		if bin.FillPercentage != nil {
+			level = float64(*bin.FillPercentage)
+		}
+		sinceReading := now.Sub(time.Unix(bin.ReadingAt, 0)).Hours() / 24
+		level = math.Min(level+rate*sinceReading, 100)
+
+		var due float64 // Days from now the bin reaches the threshold
+		if level < threshold {
+			if rate <= 0 {
+				continue
+			}
+			due = (threshold - level) / rate
+		}
+		for due < float64(days)+1 {
+			day := dayOf(now.Add(time.Duration(due * 24 * float64(time.Hour))))
+			if day >= days || serviceDay[day] < 0 {
+				break
+			}
+			addStop(bin.AreaID, bin.AreaName, day, false)
+			if rate <= 0 {
+				break
+			}
+			// Emptied on the day it's collected, then refills; collected at most once a day
+			collectedAt := math.Max(due, daysFromNow(dayStarts[serviceDay[day]]))
+			due = math.Max(collectedAt+threshold/rate, daysFromNow(dayStarts[serviceDay[day]+1]))
+		}
+	}
+
+	var moves []capacityMove
+	err = db.SelectContext(ctx, &moves, `
+		SELECT bmr.scheduled_date, b.area_id, COALESCE(a.name, 'Unassigned') AS area_name
+		FROM bin_move_requests bmr
+		JOIN bins b ON b.id = bmr.bin_id
+		LEFT JOIN areas a ON a.id = b.area_id
+		WHERE bmr.status = 'pending' AND bmr.scheduled_date < $1
+		  AND ($2 = '' OR ($2 = 'unassigned' AND b.area_id IS NULL) OR b.area_id = $2)
+	`, dayStarts[days].Unix(), areaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pending move requests: %w", err)
+	}
+	for _, move := range moves {
+		addStop(move.AreaID, move.AreaName, dayOf(time.Unix(move.ScheduledDate, 0)), true)
+	}
+
+	if stopsPerShift == 0 {
+		var average *float64
+		err = db.GetContext(ctx, &average, `
+			SELECT AVG(driver_average) FROM (
+				SELECT AVG(COALESCE(completed_stops, completed_bins)) AS driver_average
+				FROM shift_history
+				WHERE ended_at >= $1 AND COALESCE(completed_stops, completed_bins, 0) > 0
+				GROUP BY driver_id
+			) drivers
+		`, now.AddDate(0, 0, -capacityHistoryDays).Unix())
+		if err != nil {
+			return nil, fmt.Errorf("failed to average stops per shift: %w", err)
+		}
+		plan.StopsPerShift, plan.StopsPerShiftSource = capacityDefaultStopsPerShift, models.StopsPerShiftDefault
+		if average != nil && *average > 0 {
+			plan.StopsPerShift, plan.StopsPerShiftSource = math.Round(*average*10)/10, models.StopsPerShiftHistory
+		}
+	}
+
+	err = db.GetContext(ctx, &plan.DriversAvailable, `SELECT COUNT(*) FROM users WHERE role = 'driver' AND deactivated_at IS NULL`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count drivers: %w", err)
+	}
+
+	keys := make([]string, 0, len(areaNames))
+	for key := range areaNames {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if (keys[i] == "") != (keys[j] == "") {
+			return keys[j] == "" // Unassigned last
+		}
+		return areaNames[keys[i]].AreaName < areaNames[keys[j]].AreaName
+	})
+	for i := range plan.Days {
+		day := &plan.Days[i]
+		for _, key := range keys {
+			counts := perArea[key][i]
+			stops := counts.collections + counts.moves
+			if stops == 0 {
+				continue
+			}
+			area := *areaNames[key]
+			area.Collections, area.Moves, area.Stops = counts.collections, counts.moves, stops
+			area.ShiftsNeeded = int(math.Ceil(float64(stops) / plan.StopsPerShift))
+			day.Areas = append(day.Areas, area)
+			day.Collections += area.Collections
+			day.Moves += area.Moves
+			day.Stops += area.Stops
+			day.ShiftsNeeded += area.ShiftsNeeded
+		}
+		if day.ShiftsNeeded > plan.DriversAvailable {
+			day.Shortfall = day.ShiftsNeeded - plan.DriversAvailable
+		}
+	}
+
+	return plan, nil
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+)
+
+// GetCheckReviewQueue returns checks flagged by the anomaly detector, newest first
+// GET /api/manager/checks/review-queue?status=pending&flag=remote_completion&limit=100
+// status: pending (default), confirmed, dismissed or all; flag limits the queue to checks with that flag
+func GetCheckReviewQueue(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := r.URL.Query().Get("status")
+		if status == "" {
+			status = models.CheckReviewPending
+		}
+		switch status {
+		case models.CheckReviewPending, models.CheckReviewConfirmed, models.CheckReviewDismissed, "all":
+		default:
+			utils.RespondError(w, http.StatusBadRequest, "status must be pending, confirmed, dismissed or all")
+			return
+		}
+
+		flag := r.URL.Query().Get("flag")
+		switch flag {
+		case "", models.CheckAnomalyUnexplainedFillDrop, models.CheckAnomalyFarFromBin,
+			models.CheckAnomalyImpossibleTravel, models.CheckAnomalyRemoteCompletion:
+		default:
+			utils.RespondError(w, http.StatusBadRequest, "Invalid flag")
+			return
+		}
+
+		limit := 100
+		if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 500 {
+			limit = l
+		}
+
+		var rows []struct {
+			models.FlaggedCheck
+			AnomalyDetails []byte `db:"anomaly_details"`
+		}
+		err := db.SelectContext(r.Context(), &rows, `
+			SELECT c.id, c.bin_id, b.bin_number,
+			       CONCAT(b.current_street, ', ', b.city, ', ', b.zip) AS bin_location,
+			       c.fill_percentage, c.checked_on, c.photo_url, c.checked_by, u.name AS checked_by_name,
+			       c.shift_id, c.anomaly_flags, c.anomaly_details, c.review_status,
+			       c.reviewed_by_user_id, c.reviewed_at, c.review_notes
+			FROM checks c
+			JOIN bins b ON b.id = c.bin_id
+			LEFT JOIN users u ON u.id = c.checked_by
+			WHERE c.review_status IS NOT NULL AND ($1 = 'all' OR c.review_status = $1)
+			  AND ($3 = '' OR $3 = ANY(c.anomaly_flags))
+			ORDER BY c.checked_on DESC
+			LIMIT $2
+		`, status, limit, flag)
+		if err != nil {
+			log.Printf("❌ [CHECK-REVIEW] Failed to fetch review queue: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch review queue")
+			return
+		}
+
+		checks := make([]models.FlaggedCheck, len(rows))
+		for i, row := range rows {
+			checks[i] = row.FlaggedCheck
+			checks[i].Anomalies = []models.CheckAnomaly{}
+			if len(row.AnomalyDetails) > 0 {
+				if err := json.Unmarshal(row.AnomalyDetails, &checks[i].Anomalies); err != nil {
+					log.Printf("⚠️  [CHECK-REVIEW] Check %d has unreadable anomaly details: %v", row.ID, err)
+				}
+			}
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    checks,
+		})
+	}
+}
+
+// ReviewCheck records a manager's decision on a flagged check (a decision can be changed later)
+// POST /api/manager/checks/{id}/review
+// Body: { "decision": "confirmed" | "dismissed", "notes": "..." }
+func ReviewCheck(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		checkID, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid check ID")
+			return
+		}
+
+		var req models.ReviewCheckRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if req.Decision != models.CheckReviewConfirmed && req.Decision != models.CheckReviewDismissed {
+			utils.RespondError(w, http.StatusBadRequest, "decision must be confirmed or dismissed")
+			return
+		}
+
+		var reviewedAt int64
+		err = db.GetContext(r.Context(), &reviewedAt, `
+			UPDATE checks
+			SET review_status = $1, reviewed_by_user_id = $2, reviewed_at = $3, review_notes = $4
+			WHERE id = $5 AND review_status IS NOT NULL
+			RETURNING reviewed_at
+		`, req.Decision, userClaims.UserID, time.Now().Unix(), req.Notes, checkID)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "Flagged check not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [CHECK-REVIEW] Failed to review check %d: %v", checkID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to review check")
+			return
+		}
+
+		log.Printf("✅ [CHECK-REVIEW] %s marked check %d as %s", userClaims.Email, checkID, req.Decision)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data": map[string]interface{}{
+				"id":                  checkID,
+				"review_status":       req.Decision,
+				"reviewed_by_user_id": userClaims.UserID,
+				"reviewed_at":         reviewedAt,
+				"review_notes":        req.Notes,
+			},
+		})
+	}
+}
+
+// GetRemoteCompletionReport returns, per driver, how many shift stops were completed too far from the stop
+// Completions without a recent GPS fix are counted as unverified and left out of the rate
+// GET /api/manager/analytics/remote-completions?since=<unix>&until=<unix> (default: the last 30 days)
+func GetRemoteCompletionReport(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		report := models.RemoteCompletionReport{
+			From:     now.AddDate(0, 0, -30).Unix(),
+			To:       now.Unix(),
+			ByDriver: []models.RemoteCompletionStats{},
+			Overall:  models.RemoteCompletionStats{DriverName: "all"},
+		}
+		if since, err := strconv.ParseInt(r.URL.Query().Get("since"), 10, 64); err == nil {
+			report.From = since
+		}
+		if until, err := strconv.ParseInt(r.URL.Query().Get("until"), 10, 64); err == nil {
+			report.To = until
+		}
+		if report.From >= report.To {
+			utils.RespondError(w, http.StatusBadRequest, "since must be before until")
+			return
+		}
+
+		err := db.SelectContext(r.Context(), &report.ByDriver, `
+			SELECT c.checked_by AS driver_id, COALESCE(MIN(u.name), '') AS driver_name,
+			       COUNT(*) AS completions,
+			       COUNT(*) FILTER (WHERE c.remote_completion) AS remote,
+			       COUNT(*) FILTER (WHERE c.completion_distance_meters IS NULL) AS unverified
+			FROM checks c
+			LEFT JOIN users u ON u.id = c.checked_by
+			WHERE c.checked_from = 'shift' AND c.checked_by IS NOT NULL
+			  AND c.checked_on >= $1 AND c.checked_on < $2
+			GROUP BY c.checked_by
+			ORDER BY driver_name ASC
+		`, report.From, report.To)
+		if err != nil {
+			log.Printf("❌ [PROXIMITY] Failed to build remote completion report: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to build remote completion report")
+			return
+		}
+
+		for i := range report.ByDriver {
+			stats := &report.ByDriver[i]
+			stats.RemoteRate = complianceRate(stats.Remote, stats.Completions-stats.Unverified)
+
+			report.Overall.Completions += stats.Completions
+			report.Overall.Remote += stats.Remote
+			report.Overall.Unverified += stats.Unverified
+		}
+		report.Overall.RemoteRate = complianceRate(report.Overall.Remote, report.Overall.Completions-report.Overall.Unverified)
+
+		// Highest remote rate first; drivers with nothing verified last
+		sort.SliceStable(report.ByDriver, func(i, j int) bool {
+			a, b := report.ByDriver[i].RemoteRate, report.ByDriver[j].RemoteRate
+			if a == nil || b == nil {
+				return a != nil && b == nil
+			}
+			return *a > *b
+		})
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    report,
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"ropacal-backend/internal/models"
+	"ropacal-backend/pkg/utils"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/jmoiron/sqlx"
@@ -17,7 +18,7 @@ func GetChecks(db *sqlx.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		binID := chi.URLParam(r, "id")
 		if binID == "" {
-			http.Error(w, "Bad Request", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Bad Request")
 			return
 		}
 
@@ -31,7 +32,7 @@ func GetChecks(db *sqlx.DB) http.HandlerFunc {
 		}
 
 		var checksWithData []CheckWithEnhancedData
-		err := db.Select(&checksWithData, `
+		err := db.SelectContext(r.Context(), &checksWithData, `
 			SELECT
 				c.id,
 				c.bin_id,
@@ -42,6 +43,9 @@ func GetChecks(db *sqlx.DB) http.HandlerFunc {
 				c.checked_by,
 				c.shift_id,
 				c.move_request_id,
+				c.anomaly_flags,
+				c.review_status,
+				c.form_responses,
 				u.name AS checked_by_name,
 				s.status AS shift_status,
 				LAG(c.fill_percentage) OVER (ORDER BY c.checked_on) AS previous_fill_percentage,
@@ -54,7 +58,7 @@ func GetChecks(db *sqlx.DB) http.HandlerFunc {
 			ORDER BY c.checked_on DESC
 		`, binID)
 		if err != nil {
-			http.Error(w, "Failed to fetch checks", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch checks")
 			return
 		}
 
@@ -122,6 +126,9 @@ func GetAllChecks(db *sqlx.DB) http.HandlerFunc {
 				c.checked_on,
 				c.photo_url,
 				c.checked_by,
+				c.anomaly_flags,
+				c.review_status,
+				c.form_responses,
 				u.name AS checked_by_name
 			FROM checks c
 			LEFT JOIN users u ON c.checked_by = u.id
@@ -178,9 +185,9 @@ func GetAllChecks(db *sqlx.DB) http.HandlerFunc {
 		}
 
 		var checksWithNames []CheckWithName
-		err := db.Select(&checksWithNames, query, args...)
+		err := db.SelectContext(r.Context(), &checksWithNames, query, args...)
 		if err != nil {
-			http.Error(w, "Failed to fetch checks", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch checks")
 			return
 		}
 
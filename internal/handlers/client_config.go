@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/services"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// GetClientConfig returns the version policy and feature flags evaluated for the calling user and app,
+// and the organization's check form, shift debrief questions and bin fill thresholds
+// The app reports its version with ?app_version= or the X-App-Version header
+// GET /api/config/client
+func GetClientConfig(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		config, err := database.GetClientConfig(db)
+		if err != nil {
+			// Serve the defaults rather than lock every app out
+			log.Printf("⚠️  [CLIENT-CONFIG] %v (using defaults)", err)
+		}
+
+		appVersion := strings.TrimSpace(r.URL.Query().Get("app_version"))
+		if appVersion == "" {
+			appVersion = strings.TrimSpace(r.Header.Get("X-App-Version"))
+		}
+
+		envName := os.Getenv("APP_ENV")
+		if envName == "" {
+			envName = "development"
+		}
+		organization := services.OrganizationID()
+
+		response := models.ClientConfigResponse{
+			MinAppVersion:    config.MinAppVersion,
+			LatestAppVersion: config.LatestAppVersion,
+			FeatureFlags:     make(map[string]bool, len(config.FeatureFlags)),
+			Environment: models.ClientEnvironment{
+				Name:         envName,
+				Organization: organization,
+				ServerTime:   time.Now().Unix(),
+			},
+		}
+		if appVersion != "" {
+			response.AppVersion = &appVersion
+			response.ForceUpgrade = config.MinAppVersion != "" && models.CompareAppVersions(appVersion, config.MinAppVersion) < 0
+			response.UpgradeAvailable = config.LatestAppVersion != "" && models.CompareAppVersions(appVersion, config.LatestAppVersion) < 0
+		}
+		if response.ForceUpgrade || response.UpgradeAvailable {
+			response.UpgradeMessage = config.UpgradeMessage
+		}
+		response.CheckForm, err = database.GetCheckForm(db)
+		if err != nil {
+			log.Printf("⚠️  [CLIENT-CONFIG] %v (no check form fields)", err)
+		}
+		response.ShiftDebrief, err = database.GetDebriefSettings(db)
+		if err != nil {
+			log.Printf("⚠️  [CLIENT-CONFIG] %v (optional debrief, no questions)", err)
+		}
+		response.FillThresholds = loadFillThresholds(db)
+		for _, flag := range config.FeatureFlags {
+			response.FeatureFlags[flag.Key] = flag.IsEnabledFor(userClaims.Role, organization, appVersion)
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    response,
+		})
+	}
+}
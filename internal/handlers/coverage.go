@@ -0,0 +1,359 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const coverageDefaultDays = 7 // Weekly report unless from/to say otherwise
+
+// parseCoverageRange parses from/to (unix timestamp or YYYY-MM-DD, UTC), defaulting to the last week
+func parseCoverageRange(fromValue, toValue string) (int64, int64, error) {
+	now := time.Now()
+	from, to := now.AddDate(0, 0, -coverageDefaultDays).Unix(), now.Unix()
+	if parsed, err := parseReportBound(fromValue, time.UTC, false); err != nil {
+		return 0, 0, fmt.Errorf("from must be a unix timestamp or YYYY-MM-DD")
+	} else if parsed != nil {
+		from = *parsed
+	}
+	if parsed, err := parseReportBound(toValue, time.UTC, true); err != nil {
+		return 0, 0, fmt.Errorf("to must be a unix timestamp or YYYY-MM-DD")
+	} else if parsed != nil {
+		to = *parsed
+	}
+	if from >= to {
+		return 0, 0, fmt.Errorf("from must be before to")
+	}
+	return from, to, nil
+}
+
+// buildCoverageReport finds active bins with a coverage gap between from and to, grouped by area
+// gap is one of models.CoverageGaps or "any"; areaID ("unassigned" for bins outside every area) narrows it to one area
+func buildCoverageReport(ctx context.Context, q sqlx.QueryerContext, from, to int64, gap, areaID string) (*models.CoverageReport, error) {
+	var bins []models.CoverageBin
+	err := sqlx.SelectContext(ctx, q, &bins, `
+		SELECT b.id, b.bin_number, b.current_street, b.city, b.zip, b.latitude, b.longitude,
+		       b.area_id, a.name AS area_name,
+		       (SELECT COUNT(*) FROM checks c WHERE c.bin_id = b.id AND c.checked_on BETWEEN $1 AND $2) AS checks,
+		       (SELECT COUNT(*) FROM route_tasks rt
+		        WHERE rt.bin_id = b.id AND rt.created_at BETWEEN $1 AND $2) AS route_stops,
+		       (SELECT COUNT(*) FROM route_tasks rt
+		        WHERE rt.bin_id = b.id AND rt.is_completed = 1 AND NOT rt.skipped
+		          AND rt.completed_at BETWEEN $1 AND $2) AS completed_visits,
+		       (SELECT COUNT(*) FROM route_bins rb WHERE rb.bin_id = b.id) AS blueprint_routes,
+		       (SELECT MAX(c.checked_on) FROM checks c WHERE c.bin_id = b.id) AS last_checked_at,
+		       (SELECT MAX(rt.completed_at) FROM route_tasks rt
+		        WHERE rt.bin_id = b.id AND rt.is_completed = 1 AND NOT rt.skipped) AS last_visited_at
+		FROM bins b
+		LEFT JOIN areas a ON a.id = b.area_id
+		WHERE b.status = $3 AND b.retired_at IS NULL AND b.created_at <= $2
+		  AND ($4 = '' OR ($4 = 'unassigned' AND b.area_id IS NULL) OR b.area_id = $4)
+		ORDER BY b.bin_number ASC
+	`, from, to, models.BinStatusActive, areaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch bin coverage: %w", err)
+	}
+
+	report := &models.CoverageReport{
+		From:       from,
+		To:         to,
+		Gap:        gap,
+		ActiveBins: len(bins),
+		GapCounts:  map[string]int{},
+		Areas:      []models.CoverageArea{},
+	}
+	for _, g := range models.CoverageGaps {
+		report.GapCounts[g] = 0
+	}
+
+	byArea := map[string]int{} // Area ID ("" when unassigned) → index in report.Areas
+	for _, bin := range bins {
+		bin.Gaps = []string{}
+		if bin.Checks == 0 {
+			bin.Gaps = append(bin.Gaps, models.CoverageGapChecks)
+		}
+		if bin.RouteStops == 0 {
+			bin.Gaps = append(bin.Gaps, models.CoverageGapRoutes)
+		}
+		if bin.CompletedVisits == 0 {
+			bin.Gaps = append(bin.Gaps, models.CoverageGapVisits)
+		}
+		for _, g := range bin.Gaps {
+			report.GapCounts[g]++
+		}
+
+		matches := len(bin.Gaps) > 0
+		if gap != "any" {
+			matches = false
+			for _, g := range bin.Gaps {
+				if g == gap {
+					matches = true
+				}
+			}
+		}
+		if !matches {
+			continue
+		}
+		report.BinsWithGap++
+
+		key := ""
+		if bin.AreaID != nil {
+			key = *bin.AreaID
+		}
+		i, ok := byArea[key]
+		if !ok {
+			area := models.CoverageArea{AreaID: bin.AreaID, AreaName: "Unassigned", Bins: []models.CoverageBin{}}
+			if bin.AreaName != nil {
+				area.AreaName = *bin.AreaName
+			}
+			report.Areas = append(report.Areas, area)
+			i = len(report.Areas) - 1
+			byArea[key] = i
+		}
+		report.Areas[i].Bins = append(report.Areas[i].Bins, bin)
+	}
+
+	sort.SliceStable(report.Areas, func(i, j int) bool {
+		if len(report.Areas[i].Bins) != len(report.Areas[j].Bins) {
+			return len(report.Areas[i].Bins) > len(report.Areas[j].Bins)
+		}
+		return report.Areas[i].AreaName < report.Areas[j].AreaName
+	})
+	return report, nil
+}
+
+// validCoverageGap reports whether gap is one of models.CoverageGaps or "any"
+func validCoverageGap(gap string) bool {
+	if gap == "any" {
+		return true
+	}
+	for _, g := range models.CoverageGaps {
+		if g == gap {
+			return true
+		}
+	}
+	return false
+}
+
+// GetCoverageReport lists active bins that went uncovered in a date range, grouped by area
+// GET /api/analytics/coverage?from=<unix|YYYY-MM-DD>&to=<unix|YYYY-MM-DD>&gap=any&area_id=<id|unassigned> (default: the last 7 days)
+// A bin has a gap when it had no checks (checks), wasn't a stop on any shift (routes) or was never completed as a stop (visits);
+// skipped stops don't count as visits
+func GetCoverageReport(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+
+		from, to, err := parseCoverageRange(q.Get("from"), q.Get("to"))
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		gap := q.Get("gap")
+		if gap == "" {
+			gap = "any"
+		}
+		if !validCoverageGap(gap) {
+			utils.RespondError(w, http.StatusBadRequest, "gap must be checks, routes, visits or any")
+			return
+		}
+
+		report, err := buildCoverageReport(r.Context(), db, from, to, gap, q.Get("area_id"))
+		if err != nil {
+			log.Printf("❌ [COVERAGE] %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to build coverage report")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    report,
+		})
+	}
+}
+
+// CreateCoverageDraftRoute adds the bins from the coverage report to a new draft route
+// POST /api/manager/analytics/coverage/draft-route
+// Body: { "name": "...", "from": "2026-01-01", "to": "2026-01-07", "gap": "any", "area_id": "..." } or { "bin_ids": [...] }
+// Bins without coordinates are left off the route and listed in the response
+func CreateCoverageDraftRoute(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var req models.CoverageDraftRouteRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		from, to, err := parseCoverageRange(req.From, req.To)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if req.Gap == "" {
+			req.Gap = "any"
+		}
+		if !validCoverageGap(req.Gap) {
+			utils.RespondError(w, http.StatusBadRequest, "gap must be checks, routes, visits or any")
+			return
+		}
+
+		// Route order follows the report: largest area first, then bin number
+		var binIDs []string
+		areaNames := map[string]bool{}
+		if len(req.BinIDs) > 0 {
+			var bins []struct {
+				ID       string  `db:"id"`
+				AreaName *string `db:"area_name"`
+			}
+			err := db.SelectContext(r.Context(), &bins, `
+				SELECT b.id, a.name AS area_name
+				FROM bins b
+				LEFT JOIN areas a ON a.id = b.area_id
+				WHERE b.id = ANY($1) AND b.retired_at IS NULL
+				ORDER BY b.bin_number ASC
+			`, pq.Array(req.BinIDs))
+			if err != nil {
+				log.Printf("❌ [COVERAGE] Failed to fetch bins: %v", err)
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to create draft route")
+				return
+			}
+			requested := map[string]bool{}
+			for _, binID := range req.BinIDs {
+				requested[binID] = true
+			}
+			if len(bins) != len(requested) {
+				utils.RespondError(w, http.StatusBadRequest, "bin_ids contains unknown or retired bins")
+				return
+			}
+			for _, bin := range bins {
+				binIDs = append(binIDs, bin.ID)
+				if bin.AreaName != nil {
+					areaNames[*bin.AreaName] = true
+				}
+			}
+		} else {
+			report, err := buildCoverageReport(r.Context(), db, from, to, req.Gap, req.AreaID)
+			if err != nil {
+				log.Printf("❌ [COVERAGE] %v", err)
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to create draft route")
+				return
+			}
+			for _, area := range report.Areas {
+				for _, bin := range area.Bins {
+					binIDs = append(binIDs, bin.ID)
+				}
+				if area.AreaID != nil {
+					areaNames[area.AreaName] = true
+				}
+			}
+		}
+
+		missing, err := findBinsMissingCoordinates(db, binIDs)
+		if err != nil {
+			log.Printf("❌ [COVERAGE] %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to create draft route")
+			return
+		}
+		withoutCoordinates := make(map[string]bool, len(missing))
+		for _, bin := range missing {
+			withoutCoordinates[bin.ID] = true
+		}
+		routable := []string{}
+		for _, binID := range binIDs {
+			if !withoutCoordinates[binID] {
+				routable = append(routable, binID)
+			}
+		}
+		if len(binIDs) == 0 {
+			utils.RespondError(w, http.StatusUnprocessableEntity, "No bins with a coverage gap to add")
+			return
+		}
+		if len(routable) == 0 {
+			respondBinsMissingCoordinates(w, missing)
+			return
+		}
+
+		name := strings.TrimSpace(req.Name)
+		if name == "" {
+			name = fmt.Sprintf("Coverage gaps %s – %s",
+				time.Unix(from, 0).UTC().Format("2006-01-02"), time.Unix(to, 0).UTC().Format("2006-01-02"))
+		}
+		geographicArea := "Multiple areas"
+		if len(areaNames) == 1 {
+			for areaName := range areaNames {
+				geographicArea = areaName
+			}
+		} else if len(areaNames) == 0 {
+			geographicArea = "Unassigned"
+		}
+
+		route := models.Route{
+			ID:              uuid.New().String(),
+			Name:            name,
+			GeographicArea:  geographicArea,
+			BinCount:        len(routable),
+			CreatedByUserID: &userClaims.UserID,
+			IsDraft:         true,
+			CreatedAt:       time.Now().Unix(),
+		}
+		route.UpdatedAt = route.CreatedAt
+
+		err = database.WithTx(r.Context(), db, func(tx *sqlx.Tx) error {
+			_, err := tx.Exec(`
+				INSERT INTO routes (id, name, geographic_area, bin_count, created_by_user_id, is_draft, created_at, updated_at)
+				VALUES ($1, $2, $3, $4, $5, true, $6, $6)
+			`, route.ID, route.Name, route.GeographicArea, route.BinCount, route.CreatedByUserID, route.CreatedAt)
+			if err != nil {
+				return fmt.Errorf("failed to create route: %w", err)
+			}
+			for i, binID := range routable {
+				_, err := tx.Exec(`
+					INSERT INTO route_bins (route_id, bin_id, sequence_order, created_at)
+					VALUES ($1, $2, $3, $4)
+				`, route.ID, binID, i+1, route.CreatedAt)
+				if err != nil {
+					return fmt.Errorf("failed to add bin %s to route: %w", binID, err)
+				}
+			}
+			if _, err := database.RecordRouteVersion(tx, route.ID, route.CreatedByUserID, route.CreatedAt); err != nil {
+				return err
+			}
+			return nil
+		})
+		if err != nil {
+			respondTxError(w, err, "Failed to create draft route")
+			return
+		}
+
+		log.Printf("✅ [COVERAGE] %s created draft route %s with %d bin(s) (%d without coordinates left off)",
+			userClaims.Email, route.ID, len(routable), len(missing))
+
+		utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{
+			"success": true,
+			"data": models.CoverageDraftRouteResult{
+				Route:              route,
+				BinCount:           len(routable),
+				MissingCoordinates: missing,
+			},
+		})
+	}
+}
@@ -2,50 +2,211 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ropacal-backend/internal/models"
+	"ropacal-backend/pkg/utils"
 
 	"github.com/jmoiron/sqlx"
 )
 
+// Diagnostic log quotas (the endpoint is unauthenticated, so a buggy build must not flood the table)
+const (
+	diagnosticMaxBodyBytes           = 16 << 10 // Larger bodies are rejected with 413
+	diagnosticMaxMessageChars        = 8000     // Longer messages are truncated
+	diagnosticMaxFieldChars          = 200      // device_id, platform, app_version, category, context, timestamp
+	diagnosticRateLimit              = 30       // Logs accepted per device per window
+	diagnosticIPRateLimit            = 120      // Logs accepted per caller IP per window (several devices can share a depot's IP)
+	diagnosticRateWindowSeconds      = 60
+	diagnosticDuplicateWindowSeconds = 60 // Identical level + message from the same device is dropped within this window
+)
+
+// Diagnostic log quota scopes (device_id is client-supplied, so the caller's IP is limited too)
+const (
+	diagnosticQuotaDevice = "device"
+	diagnosticQuotaIP     = "ip"
+)
+
 // DiagnosticLog represents a diagnostic log from the mobile app
 type DiagnosticLog struct {
-	Timestamp string `json:"timestamp"`
-	Context   string `json:"context"`
-	Level     string `json:"level"`
-	Message   string `json:"message"`
-	Platform  string `json:"platform"`
+	Timestamp  string `json:"timestamp"`
+	Context    string `json:"context"`
+	Level      string `json:"level"`
+	Message    string `json:"message"`
+	Platform   string `json:"platform"`
+	DeviceID   string `json:"device_id"` // Falls back to the X-Device-ID header, then the caller's IP
+	AppVersion string `json:"app_version"`
+	Category   string `json:"category"` // e.g. location, sync, auth (default "general")
+}
+
+// truncateChars cuts s to at most max characters (not bytes)
+func truncateChars(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max])
+}
+
+// optionalDiagnosticField trims and truncates a field, returning nil when empty
+func optionalDiagnosticField(s string) *string {
+	s = truncateChars(strings.TrimSpace(s), diagnosticMaxFieldChars)
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// normalizeDiagnosticLevel maps the app's level names onto the stored levels
+func normalizeDiagnosticLevel(level string) string {
+	switch strings.ToUpper(strings.TrimSpace(level)) {
+	case models.DiagnosticLevelDebug:
+		return models.DiagnosticLevelDebug
+	case models.DiagnosticLevelWarning, "WARN":
+		return models.DiagnosticLevelWarning
+	case models.DiagnosticLevelError:
+		return models.DiagnosticLevelError
+	}
+	return models.DiagnosticLevelInfo
 }
 
 // ReceiveDiagnosticLog handles diagnostic logs from the mobile app
+// Bodies are capped, each device and caller IP is rate limited and repeated messages are dropped
 // POST /api/logs/diagnostic
 func ReceiveDiagnosticLog(db *sqlx.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, diagnosticMaxBodyBytes)
+
 		// Parse request body
 		var logEntry DiagnosticLog
 		if err := json.NewDecoder(r.Body).Decode(&logEntry); err != nil {
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				utils.RespondError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Diagnostic log exceeds %d bytes", diagnosticMaxBodyBytes))
+				return
+			}
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if strings.TrimSpace(logEntry.Message) == "" {
+			utils.RespondError(w, http.StatusBadRequest, "message is required")
+			return
+		}
+
+		ip := clientIP(r)
+		deviceID := logEntry.DeviceID
+		if deviceID == "" {
+			deviceID = r.Header.Get("X-Device-ID")
+		}
+		if deviceID == "" {
+			deviceID = "ip:" + ip
+		}
+
+		now := time.Now().Unix()
+		entry := models.DiagnosticLogEntry{
+			DeviceID:        truncateChars(deviceID, diagnosticMaxFieldChars),
+			Platform:        optionalDiagnosticField(logEntry.Platform),
+			AppVersion:      optionalDiagnosticField(logEntry.AppVersion),
+			Category:        "general",
+			Level:           normalizeDiagnosticLevel(logEntry.Level),
+			Context:         optionalDiagnosticField(logEntry.Context),
+			Message:         truncateChars(logEntry.Message, diagnosticMaxMessageChars),
+			ClientTimestamp: optionalDiagnosticField(logEntry.Timestamp),
+			IPAddress:       &ip,
+			CreatedAt:       now,
+		}
+		if category := optionalDiagnosticField(strings.ToLower(logEntry.Category)); category != nil {
+			entry.Category = *category
+		}
+
+		// Both counters are bumped in one upsert; the row locks serialize concurrent uploads from the same device or IP
+		var quotas []struct {
+			Scope string `db:"scope"`
+			Count int    `db:"count"`
+		}
+		err := db.SelectContext(r.Context(), &quotas, `
+			INSERT INTO diagnostic_log_quotas (scope, key, window_start, count)
+			VALUES ($1, $2, $5, 1), ($3, $4, $5, 1)
+			ON CONFLICT (scope, key) DO UPDATE
+			SET count = CASE WHEN diagnostic_log_quotas.window_start <= $6 THEN 1 ELSE diagnostic_log_quotas.count + 1 END,
+			    window_start = CASE WHEN diagnostic_log_quotas.window_start <= $6 THEN $5 ELSE diagnostic_log_quotas.window_start END
+			RETURNING scope, count`,
+			diagnosticQuotaDevice, entry.DeviceID, diagnosticQuotaIP, ip, now, now-diagnosticRateWindowSeconds)
+		if err != nil {
+			log.Printf("❌ [DIAGNOSTICS] Failed to check quota for %s (%s): %v", entry.DeviceID, ip, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to store diagnostic log")
+			return
+		}
+		for _, quota := range quotas {
+			limit, source := diagnosticRateLimit, "device"
+			if quota.Scope == diagnosticQuotaIP {
+				limit, source = diagnosticIPRateLimit, "address"
+			}
+			if quota.Count > limit {
+				w.Header().Set("Retry-After", strconv.Itoa(diagnosticRateWindowSeconds))
+				utils.RespondError(w, http.StatusTooManyRequests, "Too many diagnostic logs from this "+source)
+				return
+			}
+		}
+
+		// A repeat of the device's last message is dropped in the same statement that would store it
+		rows, err := db.NamedQueryContext(r.Context(), `
+			INSERT INTO diagnostic_logs (device_id, platform, app_version, category, level, context, message, client_timestamp, ip_address, created_at)
+			SELECT :device_id, :platform, :app_version, :category, :level, :context, :message, :client_timestamp, :ip_address, :created_at
+			WHERE NOT EXISTS (
+				SELECT 1 FROM diagnostic_logs
+				WHERE device_id = :device_id AND level = :level AND message = :message AND created_at > :duplicate_after
+			)
+			RETURNING id
+		`, struct {
+			models.DiagnosticLogEntry
+			DuplicateAfter int64 `db:"duplicate_after"`
+		}{entry, now - diagnosticDuplicateWindowSeconds})
+		if err != nil {
+			log.Printf("❌ [DIAGNOSTICS] Failed to store log from %s: %v", entry.DeviceID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to store diagnostic log")
+			return
+		}
+		stored := rows.Next()
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			log.Printf("❌ [DIAGNOSTICS] Failed to store log from %s: %v", entry.DeviceID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to store diagnostic log")
+			return
+		}
+		if !stored {
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(map[string]string{
+				"status": "duplicate",
+			})
 			return
 		}
 
 		// Log to console with color coding
 		prefix := "📱"
-		switch logEntry.Level {
-		case "ERROR":
+		switch entry.Level {
+		case models.DiagnosticLevelError:
 			prefix = "🔴"
-		case "WARNING":
+		case models.DiagnosticLevelWarning:
 			prefix = "🟡"
-		case "INFO":
+		case models.DiagnosticLevelInfo:
 			prefix = "🔵"
 		}
 
 		// Pretty print the diagnostic log
 		log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-		log.Printf("%s MOBILE DIAGNOSTIC [%s]", prefix, logEntry.Level)
+		log.Printf("%s MOBILE DIAGNOSTIC [%s] [%s]", prefix, entry.Level, entry.Category)
+		log.Printf("   Device:    %s", entry.DeviceID)
 		log.Printf("   Platform:  %s", logEntry.Platform)
 		log.Printf("   Context:   %s", logEntry.Context)
 		log.Printf("   Timestamp: %s", logEntry.Timestamp)
-		log.Printf("   Message:   %s", logEntry.Message)
+		log.Printf("   Message:   %s", entry.Message)
 		log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 
 		// Send success response
@@ -55,3 +216,70 @@ func ReceiveDiagnosticLog(db *sqlx.DB) http.HandlerFunc {
 		})
 	}
 }
+
+// GetDiagnosticLogs returns stored diagnostic logs, newest first
+// GET /api/manager/logs/diagnostic?device_id=&level=&category=&platform=&app_version=&since=<unix>&until=<unix>&q=<text>&limit=100&offset=0
+func GetDiagnosticLogs(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+
+		query := `SELECT * FROM diagnostic_logs`
+		whereClause := []string{}
+		args := []interface{}{}
+
+		for _, filter := range []struct{ param, column string }{
+			{"device_id", "device_id"},
+			{"category", "category"},
+			{"platform", "platform"},
+			{"app_version", "app_version"},
+		} {
+			if value := q.Get(filter.param); value != "" {
+				args = append(args, value)
+				whereClause = append(whereClause, fmt.Sprintf("%s = $%d", filter.column, len(args)))
+			}
+		}
+		if level := q.Get("level"); level != "" {
+			args = append(args, normalizeDiagnosticLevel(level))
+			whereClause = append(whereClause, fmt.Sprintf("level = $%d", len(args)))
+		}
+		if since, err := strconv.ParseInt(q.Get("since"), 10, 64); err == nil {
+			args = append(args, since)
+			whereClause = append(whereClause, fmt.Sprintf("created_at >= $%d", len(args)))
+		}
+		if until, err := strconv.ParseInt(q.Get("until"), 10, 64); err == nil {
+			args = append(args, until)
+			whereClause = append(whereClause, fmt.Sprintf("created_at <= $%d", len(args)))
+		}
+		if text := q.Get("q"); text != "" {
+			args = append(args, "%"+text+"%")
+			whereClause = append(whereClause, fmt.Sprintf("(message ILIKE $%d OR context ILIKE $%d)", len(args), len(args)))
+		}
+
+		if len(whereClause) > 0 {
+			query += " WHERE " + strings.Join(whereClause, " AND ")
+		}
+
+		limit := 100
+		if parsed, err := strconv.Atoi(q.Get("limit")); err == nil && parsed > 0 && parsed <= 500 {
+			limit = parsed
+		}
+		offset := 0
+		if parsed, err := strconv.Atoi(q.Get("offset")); err == nil && parsed >= 0 {
+			offset = parsed
+		}
+		args = append(args, limit, offset)
+		query += fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+		logs := []models.DiagnosticLogEntry{}
+		if err := db.SelectContext(r.Context(), &logs, query, args...); err != nil {
+			log.Printf("❌ [DIAGNOSTICS] Failed to fetch diagnostic logs: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch diagnostic logs")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    logs,
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/services"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// GetManagerDigest returns the latest bins-at-risk digest, or the one for ?date=YYYY-MM-DD
+// GET /api/manager/digest
+func GetManagerDigest(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := `SELECT payload FROM manager_digests ORDER BY generated_at DESC LIMIT 1`
+		args := []interface{}{}
+		if date := r.URL.Query().Get("date"); date != "" {
+			if _, err := time.Parse("2006-01-02", date); err != nil {
+				utils.RespondError(w, http.StatusBadRequest, "date must be YYYY-MM-DD")
+				return
+			}
+			query = `SELECT payload FROM manager_digests WHERE digest_date = $1`
+			args = append(args, date)
+		}
+
+		var payload []byte
+		err := db.GetContext(r.Context(), &payload, query, args...)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "No digest has been generated yet")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [DIGEST] Failed to load digest: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to load digest")
+			return
+		}
+
+		var digest models.BinsAtRiskDigest
+		if err := json.Unmarshal(payload, &digest); err != nil {
+			log.Printf("❌ [DIGEST] Failed to parse digest: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to load digest")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    digest,
+		})
+	}
+}
+
+// SendManagerDigest compiles and sends today's digest now (replacing it if already sent)
+// POST /api/manager/digest/send
+func SendManagerDigest(digester *services.BinsAtRiskDigester) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		digest, err := digester.SendNow(time.Now())
+		if err != nil {
+			log.Printf("❌ [DIGEST] Manual send failed: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to send digest")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    digest,
+		})
+	}
+}
@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"ropacal-backend/internal/models"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Route assignment recommendation weights (they add up to 1)
+const (
+	recommendationFamiliarityWeight = 0.5
+	recommendationProximityWeight   = 0.3
+	recommendationWorkloadWeight    = 0.2
+)
+
+// Familiarity tuning: history older than familiarityDefaultDays is ignored, route runs lose half their weight
+// every familiarityHalfLifeDays, and each familiarity component saturates at its "full" count
+const (
+	familiarityDefaultDays   = 180
+	familiarityHalfLifeDays  = 60
+	familiarityFullRouteRuns = 5.0
+	familiarityFullAreaStops = 50.0
+	proximityHalfScoreKm     = 10.0 // Distance at which the proximity score is 0.5
+	workloadHalfScoreStops   = 20.0 // Remaining stops at which the workload score is 0.5
+)
+
+// Shares of the familiarity score (they add up to 1)
+const (
+	familiarityRouteShare = 0.5 // Runs of the route itself
+	familiarityBinsShare  = 0.3 // Share of the route's bins the driver has serviced
+	familiarityAreasShare = 0.2 // Stops in the route's areas
+)
+
+// familiaritySince returns the start of the familiarity window from ?days= (default familiarityDefaultDays)
+func familiaritySince(r *http.Request) int64 {
+	days := familiarityDefaultDays
+	if parsed, err := strconv.Atoi(r.URL.Query().Get("days")); err == nil && parsed > 0 {
+		days = parsed
+	}
+	return time.Now().AddDate(0, 0, -days).Unix()
+}
+
+func roundScore(score float64) float64 {
+	return math.Round(score*1000) / 1000
+}
+
+// GetDriverFamiliarity lists the routes and areas a driver has worked, from their ended shifts
+// GET /api/manager/drivers/{id}/familiarity?days=180
+func GetDriverFamiliarity(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		driverID := chi.URLParam(r, "id")
+		familiarity := models.DriverFamiliarity{
+			DriverID: driverID,
+			Since:    familiaritySince(r),
+			Routes:   []models.DriverRouteFamiliarity{},
+			Areas:    []models.DriverAreaFamiliarity{},
+		}
+
+		err := db.GetContext(r.Context(), &familiarity.DriverName, `SELECT name FROM users WHERE id = $1 AND role = 'driver'`, driverID)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "Driver not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [FAMILIARITY] Failed to fetch driver %s: %v", driverID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch driver familiarity")
+			return
+		}
+
+		err = db.SelectContext(r.Context(), &familiarity.Routes, `
+			SELECT sh.route_id, rt.name AS route_name,
+			       COUNT(*) AS runs,
+			       MAX(sh.ended_at) AS last_run_at,
+			       AVG(sh.completion_rate)::FLOAT8 AS avg_completion_rate
+			FROM shift_history sh
+			LEFT JOIN routes rt ON rt.id = sh.route_id
+			WHERE sh.driver_id = $1 AND sh.ended_at >= $2
+			  AND sh.route_id IS NOT NULL AND sh.route_id NOT IN ('', 'custom')
+			  AND sh.completed_bins > 0
+			GROUP BY sh.route_id, rt.name
+			ORDER BY runs DESC, last_run_at DESC
+		`, driverID, familiarity.Since)
+		if err != nil {
+			log.Printf("❌ [FAMILIARITY] Failed to fetch routes for driver %s: %v", driverID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch driver familiarity")
+			return
+		}
+
+		err = db.SelectContext(r.Context(), &familiarity.Areas, `
+			SELECT a.id AS area_id, a.name AS area_name,
+			       COUNT(*) AS completed_stops,
+			       MAX(t.completed_at) AS last_stop_at
+			FROM route_tasks t
+			JOIN shift_history sh ON sh.id = t.shift_id
+			JOIN bins b ON b.id = t.bin_id
+			JOIN areas a ON a.id = b.area_id
+			WHERE sh.driver_id = $1 AND t.is_completed = 1 AND t.completed_at >= $2
+			GROUP BY a.id, a.name
+			ORDER BY completed_stops DESC
+		`, driverID, familiarity.Since)
+		if err != nil {
+			log.Printf("❌ [FAMILIARITY] Failed to fetch areas for driver %s: %v", driverID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch driver familiarity")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    familiarity,
+		})
+	}
+}
+
+// GetRouteAssignmentRecommendations ranks drivers for a route by familiarity, proximity and workload
+// GET /api/manager/assign-route/recommendations?route_id=<id>&days=180&limit=10
+func GetRouteAssignmentRecommendations(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		routeID := r.URL.Query().Get("route_id")
+		if routeID == "" {
+			utils.RespondError(w, http.StatusBadRequest, "route_id is required")
+			return
+		}
+		since := familiaritySince(r)
+		now := time.Now().Unix()
+
+		response := models.RouteDriverRecommendations{
+			RouteID: routeID,
+			Weights: map[string]float64{
+				"familiarity": recommendationFamiliarityWeight,
+				"proximity":   recommendationProximityWeight,
+				"workload":    recommendationWorkloadWeight,
+			},
+			Drivers: []models.RouteDriverRecommendation{},
+		}
+
+		err := db.GetContext(r.Context(), &response.RouteName, `SELECT name FROM routes WHERE id = $1`, routeID)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "Route not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [RECOMMEND] Failed to fetch route %s: %v", routeID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to build recommendations")
+			return
+		}
+
+		// The route's bins give its center and areas
+		var routeBins []struct {
+			Latitude  *float64 `db:"latitude"`
+			Longitude *float64 `db:"longitude"`
+			AreaID    *string  `db:"area_id"`
+		}
+		err = db.SelectContext(r.Context(), &routeBins, `
+			SELECT b.latitude, b.longitude, b.area_id
+			FROM route_bins rb
+			JOIN bins b ON b.id = rb.bin_id
+			WHERE rb.route_id = $1
+		`, routeID)
+		if err != nil {
+			log.Printf("❌ [RECOMMEND] Failed to fetch bins of route %s: %v", routeID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to build recommendations")
+			return
+		}
+		response.RouteBinCount = len(routeBins)
+
+		var sumLat, sumLng float64
+		located := 0
+		areaIDs := []string{}
+		seenAreas := map[string]bool{}
+		for _, bin := range routeBins {
+			if bin.Latitude != nil && bin.Longitude != nil {
+				sumLat += *bin.Latitude
+				sumLng += *bin.Longitude
+				located++
+			}
+			if bin.AreaID != nil && !seenAreas[*bin.AreaID] {
+				seenAreas[*bin.AreaID] = true
+				areaIDs = append(areaIDs, *bin.AreaID)
+			}
+		}
+		if located > 0 {
+			centerLat, centerLng := sumLat/float64(located), sumLng/float64(located)
+			response.CenterLatitude = &centerLat
+			response.CenterLongitude = &centerLng
+		}
+
+		var candidates []struct {
+			DriverID          string   `db:"driver_id"`
+			DriverName        string   `db:"driver_name"`
+			RouteRuns         int      `db:"route_runs"`
+			WeightedRuns      float64  `db:"weighted_runs"`
+			LastRouteRunAt    *int64   `db:"last_route_run_at"`
+			RouteBinsVisited  int      `db:"route_bins_visited"`
+			AreaStops         int      `db:"area_stops"`
+			Latitude          *float64 `db:"latitude"`
+			Longitude         *float64 `db:"longitude"`
+			LocationUpdatedAt *int64   `db:"location_updated_at"`
+			OpenShifts        int      `db:"open_shifts"`
+			RemainingStops    int      `db:"remaining_stops"`
+			OnShift           bool     `db:"on_shift"`
+		}
+		err = db.SelectContext(r.Context(), &candidates, `
+			SELECT u.id AS driver_id, u.name AS driver_name,
+			       runs.route_runs, runs.weighted_runs, runs.last_route_run_at,
+			       visited.route_bins_visited, area.area_stops,
+			       dcl.latitude, dcl.longitude, dcl.timestamp AS location_updated_at,
+			       workload.open_shifts, workload.remaining_stops, workload.on_shift
+			FROM users u
+			CROSS JOIN LATERAL (
+				SELECT COUNT(*) AS route_runs,
+				       COALESCE(SUM(POWER(0.5, ($3 - sh.ended_at) / ($4 * 86400.0)) * sh.completion_rate / 100.0), 0)::FLOAT8 AS weighted_runs,
+				       MAX(sh.ended_at) AS last_route_run_at
+				FROM shift_history sh
+				WHERE sh.driver_id = u.id AND sh.route_id = $1 AND sh.ended_at >= $2 AND sh.completed_bins > 0
+			) runs
+			CROSS JOIN LATERAL (
+				SELECT COUNT(DISTINCT t.bin_id) AS route_bins_visited
+				FROM route_tasks t
+				JOIN shift_history sh ON sh.id = t.shift_id
+				WHERE sh.driver_id = u.id AND t.is_completed = 1 AND t.completed_at >= $2
+				  AND t.bin_id IN (SELECT bin_id FROM route_bins WHERE route_id = $1)
+			) visited
+			CROSS JOIN LATERAL (
+				SELECT COUNT(*) AS area_stops
+				FROM route_tasks t
+				JOIN shift_history sh ON sh.id = t.shift_id
+				JOIN bins b ON b.id = t.bin_id
+				WHERE sh.driver_id = u.id AND t.is_completed = 1 AND t.completed_at >= $2
+				  AND b.area_id = ANY($5)
+			) area
+			CROSS JOIN LATERAL (
+				SELECT COUNT(*) AS open_shifts,
+				       COALESCE(SUM(GREATEST(s.total_bins - s.completed_bins, 0)), 0) AS remaining_stops,
+				       COALESCE(BOOL_OR(s.status IN ('active', 'paused')), FALSE) AS on_shift
+				FROM shifts s
+				WHERE s.driver_id = u.id AND s.status IN ('ready', 'active', 'paused')
+			) workload
+			LEFT JOIN driver_current_location dcl ON dcl.driver_id = u.id
+			WHERE u.role = 'driver' AND u.deactivated_at IS NULL
+		`, routeID, since, now, familiarityHalfLifeDays, pq.Array(areaIDs))
+		if err != nil {
+			log.Printf("❌ [RECOMMEND] Failed to score drivers for route %s: %v", routeID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to build recommendations")
+			return
+		}
+
+		for _, c := range candidates {
+			rec := models.RouteDriverRecommendation{
+				DriverID:          c.DriverID,
+				DriverName:        c.DriverName,
+				RouteRuns:         c.RouteRuns,
+				LastRouteRunAt:    c.LastRouteRunAt,
+				RouteBinsVisited:  c.RouteBinsVisited,
+				AreaStops:         c.AreaStops,
+				LocationUpdatedAt: c.LocationUpdatedAt,
+				OpenShifts:        c.OpenShifts,
+				RemainingStops:    c.RemainingStops,
+				OnShift:           c.OnShift,
+			}
+
+			familiarity := familiarityRouteShare * math.Min(c.WeightedRuns/familiarityFullRouteRuns, 1)
+			if response.RouteBinCount > 0 {
+				familiarity += familiarityBinsShare * float64(c.RouteBinsVisited) / float64(response.RouteBinCount)
+			}
+			if len(areaIDs) > 0 {
+				familiarity += familiarityAreasShare * math.Min(float64(c.AreaStops)/familiarityFullAreaStops, 1)
+			}
+			rec.FamiliarityScore = roundScore(familiarity)
+
+			if c.Latitude != nil && c.Longitude != nil && response.CenterLatitude != nil {
+				distance := haversineDistanceKm(*c.Latitude, *c.Longitude, *response.CenterLatitude, *response.CenterLongitude)
+				distance = math.Round(distance*100) / 100
+				rec.DistanceKm = &distance
+				rec.ProximityScore = roundScore(1 / (1 + distance/proximityHalfScoreKm))
+			}
+
+			rec.WorkloadScore = roundScore(1 / (1 + float64(c.RemainingStops)/workloadHalfScoreStops))
+
+			rec.Score = roundScore(recommendationFamiliarityWeight*rec.FamiliarityScore +
+				recommendationProximityWeight*rec.ProximityScore +
+				recommendationWorkloadWeight*rec.WorkloadScore)
+			response.Drivers = append(response.Drivers, rec)
+		}
+
+		sort.SliceStable(response.Drivers, func(i, j int) bool {
+			if response.Drivers[i].Score != response.Drivers[j].Score {
+				return response.Drivers[i].Score > response.Drivers[j].Score
+			}
+			return response.Drivers[i].DriverName < response.Drivers[j].DriverName
+		})
+		if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && limit > 0 && limit < len(response.Drivers) {
+			response.Drivers = response.Drivers[:limit]
+		}
+
+		log.Printf("✅ [RECOMMEND] Ranked %d drivers for route %s", len(response.Drivers), routeID)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    response,
+		})
+	}
+}
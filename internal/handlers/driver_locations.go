@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/websocket"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Server-side thinning of uploaded breadcrumbs: a point is stored when the driver moved far enough
+// from the last stored point, or enough time passed (so stops still show up on the timeline)
+const (
+	locationBatchMaxPoints        = 1000
+	locationThinMinDistanceMeters = 25.0
+	locationThinMaxIntervalMs     = 60000
+	locationMaxFutureSkewMs       = 5 * 60 * 1000
+)
+
+// locationBatchRequest is the body for POST /api/driver/locations/batch
+type locationBatchRequest struct {
+	ShiftID *string                 `json:"shift_id"` // Default for points without their own shift_id
+	Points  []locationUpdateRequest `json:"points" validate:"required,max=1000"`
+}
+
+// locationBatchResult is the response of POST /api/driver/locations/batch
+type locationBatchResult struct {
+	Received int `json:"received"`
+	Stored   int `json:"stored"`
+	Thinned  int `json:"thinned"`  // Dropped by downsampling
+	Rejected int `json:"rejected"` // Invalid coordinates or timestamps
+}
+
+// thinLocationPoints keeps the first and last point and, in between, only points that moved
+// locationThinMinDistanceMeters or came locationThinMaxIntervalMs after the last kept point
+// points must be sorted by timestamp
+func thinLocationPoints(points []locationUpdateRequest) []locationUpdateRequest {
+	if len(points) <= 2 {
+		return points
+	}
+	kept := []locationUpdateRequest{points[0]}
+	for i := 1; i < len(points)-1; i++ {
+		last := kept[len(kept)-1]
+		point := points[i]
+		moved := haversineDistanceKm(last.Latitude, last.Longitude, point.Latitude, point.Longitude) * 1000
+		if moved >= locationThinMinDistanceMeters || point.Timestamp-last.Timestamp >= locationThinMaxIntervalMs ||
+			!sameShift(last.ShiftID, point.ShiftID) {
+			kept = append(kept, point)
+		}
+	}
+	return append(kept, points[len(points)-1])
+}
+
+// fix converts the point for the hub's location listeners (arrival detection)
+func (p locationUpdateRequest) fix() websocket.LocationFix {
+	return websocket.LocationFix{Latitude: p.Latitude, Longitude: p.Longitude, Accuracy: p.Accuracy, Timestamp: p.Timestamp}
+}
+
+func sameShift(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// upsertDriverCurrentLocation moves the driver's live position forward (older points never overwrite it)
+// is_connected is left to the WebSocket connection
+func upsertDriverCurrentLocation(ctx context.Context, q sqlx.ExecerContext, driverID string, point locationUpdateRequest) error {
+	_, err := q.ExecContext(ctx, `
+		INSERT INTO driver_current_location (
+			driver_id, latitude, longitude, heading, speed, accuracy, shift_id, timestamp, is_connected, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, FALSE, EXTRACT(EPOCH FROM NOW())::BIGINT)
+		ON CONFLICT (driver_id)
+		DO UPDATE SET
+			latitude = EXCLUDED.latitude,
+			longitude = EXCLUDED.longitude,
+			heading = EXCLUDED.heading,
+			speed = EXCLUDED.speed,
+			accuracy = EXCLUDED.accuracy,
+			shift_id = EXCLUDED.shift_id,
+			timestamp = EXCLUDED.timestamp,
+			updated_at = EXCLUDED.updated_at
+		WHERE driver_current_location.timestamp <= EXCLUDED.timestamp
+	`, driverID, point.Latitude, point.Longitude, point.Heading, point.Speed, point.Accuracy, point.ShiftID, point.Timestamp)
+	return err
+}
+
+// UploadLocationBatch stores GPS points the app buffered (e.g. while offline or to save battery)
+// Points are sorted, de-duplicated and thinned before a single transaction inserts them; the newest
+// point updates driver_current_location and is broadcast to managers
+// POST /api/driver/locations/batch
+func UploadLocationBatch(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var req locationBatchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if len(req.Points) == 0 {
+			utils.RespondError(w, http.StatusBadRequest, "At least one point is required")
+			return
+		}
+		if len(req.Points) > locationBatchMaxPoints {
+			utils.RespondError(w, http.StatusRequestEntityTooLarge, "Too many points in one batch")
+			return
+		}
+
+		result := locationBatchResult{Received: len(req.Points)}
+		maxTimestamp := time.Now().UnixMilli() + locationMaxFutureSkewMs
+		valid := make([]locationUpdateRequest, 0, len(req.Points))
+		for _, point := range req.Points {
+			if (point.Latitude == 0 && point.Longitude == 0) || point.Latitude < -90 || point.Latitude > 90 ||
+				point.Longitude < -180 || point.Longitude > 180 || point.Timestamp <= 0 || point.Timestamp > maxTimestamp {
+				result.Rejected++
+				continue
+			}
+			if point.ShiftID == nil {
+				point.ShiftID = req.ShiftID
+			}
+			valid = append(valid, point)
+		}
+		sort.SliceStable(valid, func(i, j int) bool { return valid[i].Timestamp < valid[j].Timestamp })
+
+		deduped := valid[:0]
+		for _, point := range valid {
+			if len(deduped) > 0 && point.Timestamp == deduped[len(deduped)-1].Timestamp {
+				result.Rejected++
+				continue
+			}
+			deduped = append(deduped, point)
+		}
+		points := thinLocationPoints(deduped)
+		result.Thinned = len(deduped) - len(points)
+
+		if len(points) > 0 {
+			tx, err := db.BeginTxx(r.Context(), nil)
+			if err != nil {
+				log.Printf("❌ [LOCATIONS] Error starting transaction: %v", err)
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to save locations")
+				return
+			}
+			defer tx.Rollback()
+
+			stmt, err := tx.PreparexContext(r.Context(), `
+				INSERT INTO driver_locations (
+					driver_id, latitude, longitude, heading, speed, accuracy, shift_id, timestamp
+				) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			`)
+			if err != nil {
+				log.Printf("❌ [LOCATIONS] Error preparing insert: %v", err)
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to save locations")
+				return
+			}
+			defer stmt.Close()
+
+			for _, point := range points {
+				_, err := stmt.ExecContext(r.Context(), userClaims.UserID, point.Latitude, point.Longitude,
+					point.Heading, point.Speed, point.Accuracy, point.ShiftID, point.Timestamp)
+				if err != nil {
+					log.Printf("❌ [LOCATIONS] Error saving location: %v", err)
+					utils.RespondError(w, http.StatusInternalServerError, "Failed to save locations")
+					return
+				}
+			}
+
+			latest := points[len(points)-1]
+			if err := upsertDriverCurrentLocation(r.Context(), tx, userClaims.UserID, latest); err != nil {
+				log.Printf("❌ [LOCATIONS] Error updating current location: %v", err)
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to save locations")
+				return
+			}
+
+			if err := tx.Commit(); err != nil {
+				log.Printf("❌ [LOCATIONS] Error committing locations: %v", err)
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to save locations")
+				return
+			}
+			result.Stored = len(points)
+
+			fixes := make([]websocket.LocationFix, len(points))
+			for i, point := range points {
+				fixes[i] = point.fix()
+			}
+			hub.ReportLocation(userClaims.UserID, fixes...)
+
+			hub.BroadcastToRoleScoped("admin", websocket.EventScope{
+				Type:      "driver_location_update",
+				DriverID:  userClaims.UserID,
+				Latitude:  &latest.Latitude,
+				Longitude: &latest.Longitude,
+			}, map[string]interface{}{
+				"type": "driver_location_update",
+				"data": map[string]interface{}{
+					"driver_id": userClaims.UserID,
+					"latitude":  latest.Latitude,
+					"longitude": latest.Longitude,
+					"heading":   latest.Heading,
+					"speed":     latest.Speed,
+					"accuracy":  latest.Accuracy,
+					"shift_id":  latest.ShiftID,
+					"timestamp": latest.Timestamp,
+				},
+			})
+		}
+
+		log.Printf("📍 [LOCATIONS] Batch from %s: %d received, %d stored, %d thinned, %d rejected",
+			userClaims.UserID, result.Received, result.Stored, result.Thinned, result.Rejected)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    result,
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ropacal-backend/internal/models"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// GetDwellTimeReport returns how long drivers stay at stops, per driver and task type, for stops completed in
+// a period, and how often stops are marked done after driving away or without arriving at all
+// Arrivals and departures come from the driver's GPS (services.StopArrivalDetector)
+// GET /api/manager/analytics/dwell-time?since=<unix>&until=<unix>&driver_id=<id> (default: the last 30 days)
+func GetDwellTimeReport(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		report := models.DwellTimeReport{
+			From:  now.AddDate(0, 0, -30).Unix(),
+			To:    now.Unix(),
+			Stats: []models.DwellTimeStats{},
+		}
+		if since, err := strconv.ParseInt(r.URL.Query().Get("since"), 10, 64); err == nil {
+			report.From = since
+		}
+		if until, err := strconv.ParseInt(r.URL.Query().Get("until"), 10, 64); err == nil {
+			report.To = until
+		}
+		if report.From >= report.To {
+			utils.RespondError(w, http.StatusBadRequest, "since must be before until")
+			return
+		}
+
+		var driverID *string
+		if v := r.URL.Query().Get("driver_id"); v != "" {
+			driverID = &v
+		}
+
+		err := db.SelectContext(r.Context(), &report.Stats, `
+			SELECT s.driver_id, COALESCE(MIN(u.name), '') AS driver_name, rt.task_type,
+			       COUNT(*) AS stops_completed,
+			       COUNT(rt.arrived_at) AS stops_arrived,
+			       ROUND(AVG(rt.departed_at - rt.arrived_at)::NUMERIC, 1)::FLOAT8 AS avg_dwell_seconds,
+			       MAX(rt.departed_at - rt.arrived_at) AS max_dwell_seconds,
+			       COUNT(*) FILTER (WHERE rt.departed_at IS NOT NULL AND rt.completed_at > rt.departed_at) AS completed_after_departure,
+			       COUNT(*) FILTER (WHERE rt.arrived_at IS NULL) AS completed_without_arrival
+			FROM route_tasks rt
+			JOIN shifts s ON s.id = rt.shift_id
+			LEFT JOIN users u ON u.id = s.driver_id
+			WHERE rt.is_completed = 1 AND rt.skipped = false
+			  AND rt.completed_at >= $1 AND rt.completed_at < $2
+			  AND ($3::TEXT IS NULL OR s.driver_id = $3)
+			GROUP BY s.driver_id, rt.task_type
+			ORDER BY driver_name ASC, rt.task_type ASC
+		`, report.From, report.To, driverID)
+		if err != nil {
+			log.Printf("❌ [DWELL-TIME] Failed to build report: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to build dwell time report")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    report,
+		})
+	}
+}
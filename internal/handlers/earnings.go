@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/i18n"
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/store"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Default look-back windows for GET /api/driver/earnings
+const (
+	earningsDefaultWeeks  = 12
+	earningsDefaultMonths = 12
+	earningsRecentShifts  = 20
+)
+
+// loadEarningsRates returns the configured earnings rates, falling back to defaults on error
+func loadEarningsRates(db *sqlx.DB) models.EarningsRates {
+	rates, err := database.GetEarningsRates(db)
+	if err != nil {
+		log.Printf("⚠️  [EARNINGS] %v (using default rates)", err)
+	}
+	return rates
+}
+
+// calculateShiftEarnings credits a shift's completed stops with the configured rates
+// Returns the breakdown and its JSON text for shift_history.earnings_breakdown (nil on error)
+func calculateShiftEarnings(db *sqlx.DB, shift models.Shift) (models.ShiftEarnings, *string, error) {
+	stops, err := store.NewShiftStore(db).CompletedStops(shift.ID)
+	if err != nil {
+		return models.ShiftEarnings{}, nil, err
+	}
+
+	allCompleted := shift.TotalBins > 0 && shift.CompletedBins >= shift.TotalBins
+	earnings := loadEarningsRates(db).Calculate(stops, allCompleted)
+
+	raw, err := json.Marshal(earnings)
+	if err != nil {
+		return earnings, nil, err
+	}
+	breakdown := string(raw) // string so lib/pq sends JSON text, not bytea
+
+	log.Printf("💰 [EARNINGS] Shift %s: %.2f credits (%d collections, %d pickups, %d dropoffs, %d placements)",
+		shift.ID, earnings.Total, earnings.Collections, earnings.Pickups, earnings.Dropoffs, earnings.Placements)
+	return earnings, &breakdown, nil
+}
+
+// GetDriverEarnings returns the driver's credits aggregated by week or month
+// GET /api/driver/earnings?period=week|month&from=<unix>&to=<unix>
+// Defaults to the last 12 weeks (or 12 months)
+func GetDriverEarnings(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, i18n.Tr(r, "Unauthorized"))
+			return
+		}
+
+		period := r.URL.Query().Get("period")
+		if period == "" {
+			period = "week"
+		}
+		if period != "week" && period != "month" {
+			utils.RespondError(w, http.StatusBadRequest, i18n.Tr(r, "period must be week or month"))
+			return
+		}
+
+		now := time.Now().UTC()
+		to := now.Unix()
+		from := now.AddDate(0, 0, -7*earningsDefaultWeeks).Unix()
+		if period == "month" {
+			from = now.AddDate(0, -earningsDefaultMonths, 0).Unix()
+		}
+		if v := r.URL.Query().Get("from"); v != "" {
+			parsed, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				utils.RespondError(w, http.StatusBadRequest, i18n.Tr(r, "Invalid from timestamp"))
+				return
+			}
+			from = parsed
+		}
+		if v := r.URL.Query().Get("to"); v != "" {
+			parsed, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				utils.RespondError(w, http.StatusBadRequest, i18n.Tr(r, "Invalid to timestamp"))
+				return
+			}
+			to = parsed
+		}
+
+		periods := []models.EarningsPeriod{}
+		err := db.SelectContext(r.Context(), &periods, `
+			SELECT EXTRACT(EPOCH FROM date_trunc($2, to_timestamp(ended_at) AT TIME ZONE 'UTC'))::BIGINT AS period_start,
+			       COUNT(*) AS shifts,
+			       COALESCE(SUM(completed_bins), 0) AS completed_bins,
+			       COALESCE(SUM(earned_credits), 0)::FLOAT8 AS credits
+			FROM shift_history
+			WHERE driver_id = $1 AND ended_at >= $3 AND ended_at <= $4
+			GROUP BY 1
+			ORDER BY 1`,
+			userClaims.UserID, period, from, to)
+		if err != nil {
+			log.Printf("❌ [EARNINGS] Failed to aggregate earnings for %s: %v", userClaims.UserID, err)
+			utils.RespondError(w, http.StatusInternalServerError, i18n.Tr(r, "Failed to fetch earnings"))
+			return
+		}
+
+		recent := []models.ShiftEarningsSummary{}
+		err = db.SelectContext(r.Context(), &recent, `
+			SELECT id, ended_at, completed_bins, earned_credits::FLOAT8 AS earned_credits
+			FROM shift_history
+			WHERE driver_id = $1 AND ended_at >= $2 AND ended_at <= $3
+			ORDER BY ended_at DESC
+			LIMIT $4`,
+			userClaims.UserID, from, to, earningsRecentShifts)
+		if err != nil {
+			log.Printf("❌ [EARNINGS] Failed to fetch recent shifts for %s: %v", userClaims.UserID, err)
+			utils.RespondError(w, http.StatusInternalServerError, i18n.Tr(r, "Failed to fetch earnings"))
+			return
+		}
+
+		response := models.DriverEarningsResponse{
+			Period:       period,
+			From:         from,
+			To:           to,
+			Periods:      periods,
+			RecentShifts: recent,
+		}
+		for _, p := range periods {
+			response.TotalShifts += p.Shifts
+			response.TotalBins += p.CompletedBins
+			response.TotalCredits += p.Credits
+		}
+		response.TotalCredits = math.Round(response.TotalCredits*100) / 100
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    response,
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/websocket"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// fleetDefaultStaleAfterSeconds flags a connected driver as stale when no location update arrived for this long
+const fleetDefaultStaleAfterSeconds = 60
+
+// GetLiveFleet returns every driver connected to the WebSocket hub with their latest position,
+// current stop, ETA to it and a staleness flag
+// GET /api/manager/fleet/live?stale_after=<seconds>
+func GetLiveFleet(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		staleAfter := fleetDefaultStaleAfterSeconds
+		if v := r.URL.Query().Get("stale_after"); v != "" {
+			parsed, err := strconv.Atoi(v)
+			if err != nil || parsed <= 0 {
+				utils.RespondError(w, http.StatusBadRequest, "stale_after must be a positive number of seconds")
+				return
+			}
+			staleAfter = parsed
+		}
+
+		now := time.Now().Unix()
+		snapshot := models.FleetSnapshot{
+			GeneratedAt:       now,
+			StaleAfterSeconds: staleAfter,
+			Drivers:           []models.FleetDriver{},
+		}
+
+		connectedIDs := wsHub.GetConnectedClientIDs()
+		if len(connectedIDs) == 0 {
+			utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+				"success": true,
+				"data":    snapshot,
+			})
+			return
+		}
+
+		query, args, err := sqlx.In(`
+			SELECT u.id AS driver_id, u.name AS driver_name,
+			       s.id AS shift_id, s.status AS shift_status, s.total_bins, s.completed_bins,
+			       dcl.latitude, dcl.longitude, dcl.heading, dcl.speed, dcl.accuracy,
+			       dcl.updated_at AS location_at
+			FROM users u
+			LEFT JOIN shifts s ON s.driver_id = u.id AND s.status IN ('ready', 'active', 'paused')
+			LEFT JOIN driver_current_location dcl ON dcl.driver_id = u.id
+			WHERE u.id IN (?) AND u.role = 'driver' AND u.deactivated_at IS NULL
+			ORDER BY u.name`, connectedIDs)
+		if err != nil {
+			log.Printf("❌ [FLEET] Failed to build fleet query: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch fleet")
+			return
+		}
+		if err := db.SelectContext(r.Context(), &snapshot.Drivers, db.Rebind(query), args...); err != nil {
+			log.Printf("❌ [FLEET] Failed to fetch connected drivers: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch fleet")
+			return
+		}
+
+		stops, err := loadFleetCurrentStops(db, snapshot.Drivers)
+		if err != nil {
+			log.Printf("❌ [FLEET] %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch fleet")
+			return
+		}
+
+		for i := range snapshot.Drivers {
+			driver := &snapshot.Drivers[i]
+
+			// Unmoved pings are not written to driver_current_location, so prefer the hub's clock
+			lastPing := driver.LocationAt
+			if at, ok := wsHub.LastLocationPing(driver.DriverID); ok && (lastPing == nil || at > *lastPing) {
+				lastPing = &at
+			}
+			if lastPing != nil {
+				since := now - *lastPing
+				driver.LastPingAt = lastPing
+				driver.SecondsSincePing = &since
+			}
+			driver.Stale = lastPing == nil || now-*lastPing > int64(staleAfter)
+			if driver.Stale {
+				snapshot.Stale++
+			}
+
+			if driver.ShiftID == nil {
+				continue
+			}
+			stop, ok := stops[*driver.ShiftID]
+			if !ok {
+				continue
+			}
+			if driver.Latitude != nil && driver.Longitude != nil {
+				distance := haversineDistanceKm(*driver.Latitude, *driver.Longitude, stop.Latitude, stop.Longitude)
+				eta := int(distance / etaAverageSpeedKmh * 3600)
+				distance = math.Round(distance*100) / 100
+				stop.DistanceKm = &distance
+				stop.EtaSeconds = &eta
+			}
+			driver.CurrentStop = &stop
+		}
+		snapshot.Connected = len(snapshot.Drivers)
+
+		log.Printf("🗺️  [FLEET] Live snapshot: %d connected drivers, %d stale", snapshot.Connected, snapshot.Stale)
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    snapshot,
+		})
+	}
+}
+
+// loadFleetCurrentStops returns the first incomplete stop of each driver's shift, keyed by shift ID
+func loadFleetCurrentStops(db *sqlx.DB, drivers []models.FleetDriver) (map[string]models.FleetStop, error) {
+	shiftIDs := []string{}
+	for _, driver := range drivers {
+		if driver.ShiftID != nil {
+			shiftIDs = append(shiftIDs, *driver.ShiftID)
+		}
+	}
+	stops := map[string]models.FleetStop{}
+	if len(shiftIDs) == 0 {
+		return stops, nil
+	}
+
+	query, args, err := sqlx.In(`
+		SELECT DISTINCT ON (rt.shift_id)
+		       rt.id AS task_id, rt.shift_id, rt.task_type AS stop_type, rt.sequence_order,
+		       rt.bin_id, rt.bin_number, rt.address, rt.latitude, rt.longitude
+		FROM route_tasks rt
+		WHERE rt.shift_id IN (?) AND rt.is_completed = 0
+		ORDER BY rt.shift_id, rt.sequence_order ASC, rt.created_at ASC`, shiftIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build current stop query: %w", err)
+	}
+	var rows []models.FleetStop
+	if err := db.Select(&rows, db.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch current stops: %w", err)
+	}
+	for _, row := range rows {
+		stops[row.ShiftID] = row
+	}
+	return stops, nil
+}
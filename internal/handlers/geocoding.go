@@ -6,6 +6,7 @@ import (
 	"log"
 	"net/http"
 	"ropacal-backend/internal/services"
+	"ropacal-backend/pkg/utils"
 )
 
 // ReverseGeocodeRequest represents a request to reverse geocode coordinates
@@ -46,21 +47,21 @@ func ReverseGeocode() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req ReverseGeocodeRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
 			return
 		}
 
 		geocodingService, err := services.NewGeocodingService()
 		if err != nil {
 			log.Printf("Failed to create geocoding service: %v", err)
-			http.Error(w, "Geocoding service unavailable", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Geocoding service unavailable")
 			return
 		}
 
 		address, err := geocodingService.ReverseGeocode(req.Lat, req.Lng)
 		if err != nil {
 			log.Printf("Reverse geocoding failed: %v", err)
-			http.Error(w, fmt.Sprintf("Failed to reverse geocode: %v", err), http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to reverse geocode: %v", err))
 			return
 		}
 
@@ -74,19 +75,19 @@ func BatchReverseGeocode() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req BatchReverseGeocodeRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
 			return
 		}
 
 		if len(req.Coordinates) == 0 {
-			http.Error(w, "No coordinates provided", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "No coordinates provided")
 			return
 		}
 
 		geocodingService, err := services.NewGeocodingService()
 		if err != nil {
 			log.Printf("Failed to create geocoding service: %v", err)
-			http.Error(w, "Geocoding service unavailable", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Geocoding service unavailable")
 			return
 		}
 
@@ -120,26 +121,26 @@ func Geocode() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req GeocodeRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
 			return
 		}
 
 		if req.Address == "" {
-			http.Error(w, "Address is required", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Address is required")
 			return
 		}
 
 		geocodingService, err := services.NewGeocodingService()
 		if err != nil {
 			log.Printf("Failed to create geocoding service: %v", err)
-			http.Error(w, "Geocoding service unavailable", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Geocoding service unavailable")
 			return
 		}
 
 		address, err := geocodingService.Geocode(req.Address)
 		if err != nil {
 			log.Printf("Geocoding failed for address '%s': %v", req.Address, err)
-			http.Error(w, fmt.Sprintf("Failed to geocode: %v", err), http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to geocode: %v", err))
 			return
 		}
 
@@ -153,19 +154,19 @@ func BatchGeocode() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req BatchGeocodeRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
 			return
 		}
 
 		if len(req.Addresses) == 0 {
-			http.Error(w, "No addresses provided", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "No addresses provided")
 			return
 		}
 
 		geocodingService, err := services.NewGeocodingService()
 		if err != nil {
 			log.Printf("Failed to create geocoding service: %v", err)
-			http.Error(w, "Geocoding service unavailable", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Geocoding service unavailable")
 			return
 		}
 
@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/helpers"
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Support mode session lengths
+const (
+	impersonationDefaultMinutes = 30
+	impersonationMaxMinutes     = 120
+)
+
+// StartImpersonation issues a support mode token: the driver's view of the app for a limited time
+// The token only allows GET requests, and every request made with it is recorded (see middleware.SupportMode)
+// POST /api/manager/users/{id}/impersonate
+// Body: { "reason": "Driver reports an empty route list", "duration_minutes": 30 }
+func StartImpersonation(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		driverID := chi.URLParam(r, "id")
+
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var req models.StartImpersonationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		req.Reason = strings.TrimSpace(req.Reason)
+		if req.Reason == "" {
+			utils.RespondError(w, http.StatusBadRequest, "reason is required")
+			return
+		}
+		if req.DurationMinutes == 0 {
+			req.DurationMinutes = impersonationDefaultMinutes
+		}
+		if req.DurationMinutes < 1 || req.DurationMinutes > impersonationMaxMinutes {
+			utils.RespondError(w, http.StatusBadRequest, fmt.Sprintf("duration_minutes must be between 1 and %d", impersonationMaxMinutes))
+			return
+		}
+
+		var driver models.User
+		err := db.GetContext(r.Context(), &driver, `SELECT * FROM users WHERE id = $1`, driverID)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [SUPPORT-MODE] Failed to fetch user %s: %v", driverID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch user")
+			return
+		}
+		if driver.Role != "driver" {
+			utils.RespondError(w, http.StatusBadRequest, "Only drivers can be impersonated")
+			return
+		}
+		if driver.IsDeactivated() {
+			utils.RespondError(w, http.StatusBadRequest, "Driver is deactivated")
+			return
+		}
+
+		jwtSecret := os.Getenv("APP_JWT_SECRET")
+		if jwtSecret == "" {
+			log.Println("❌ JWT secret not configured")
+			utils.RespondError(w, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+
+		now := time.Now().Unix()
+		session := models.ImpersonationSession{
+			ID:         uuid.New().String(),
+			AdminID:    &userClaims.UserID,
+			AdminEmail: userClaims.Email,
+			DriverID:   driver.ID,
+			DriverName: &driver.Name,
+			Reason:     req.Reason,
+			CreatedAt:  now,
+			ExpiresAt:  now + int64(req.DurationMinutes)*60,
+		}
+
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"user_id":            driver.ID,
+			"email":              driver.Email,
+			"role":               driver.Role,
+			"impersonation_id":   session.ID,
+			"impersonator_id":    userClaims.UserID,
+			"impersonator_email": userClaims.Email,
+			"iat":                now,
+			"exp":                session.ExpiresAt,
+		}).SignedString([]byte(jwtSecret))
+		if err != nil {
+			log.Printf("❌ [SUPPORT-MODE] Failed to sign token: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to create token")
+			return
+		}
+
+		_, err = db.ExecContext(r.Context(), `
+			INSERT INTO impersonation_sessions (id, admin_id, admin_email, driver_id, reason, created_at, expires_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`, session.ID, session.AdminID, session.AdminEmail, session.DriverID, session.Reason, session.CreatedAt, session.ExpiresAt)
+		if err != nil {
+			log.Printf("❌ [SUPPORT-MODE] Failed to create session: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to start support mode")
+			return
+		}
+
+		ip := clientIP(r)
+		userAgent := r.UserAgent()
+		details := fmt.Sprintf("session %s for %d min: %s", session.ID, req.DurationMinutes, session.Reason)
+		helpers.LogSecurityEvent(db, models.SecurityEvent{
+			EventType: models.SecurityEventSupportModeStarted,
+			UserID:    &driver.ID,
+			Email:     &driver.Email,
+			IPAddress: &ip,
+			UserAgent: &userAgent,
+			ActorID:   &userClaims.UserID,
+			Details:   &details,
+			CreatedAt: now,
+		})
+		log.Printf("🕵️  [SUPPORT-MODE] %s is viewing the app as %s until %d (%s)", userClaims.Email, driver.Email, session.ExpiresAt, session.Reason)
+
+		utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{
+			"success": true,
+			"data":    models.ImpersonationTokenResponse{Token: token, Session: session},
+		})
+	}
+}
+
+// GetImpersonationSessions lists support mode sessions with their request counts, newest first
+// GET /api/manager/impersonations?driver_id=<id>&admin_id=<id>&active=true&limit=100
+func GetImpersonationSessions(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+
+		query := database.ImpersonationSessionSelect + ` WHERE 1=1`
+		var args []interface{}
+		if driverID := q.Get("driver_id"); driverID != "" {
+			args = append(args, driverID)
+			query += fmt.Sprintf(` AND s.driver_id = $%d`, len(args))
+		}
+		if adminID := q.Get("admin_id"); adminID != "" {
+			args = append(args, adminID)
+			query += fmt.Sprintf(` AND s.admin_id = $%d`, len(args))
+		}
+		if q.Get("active") == "true" {
+			args = append(args, time.Now().Unix())
+			query += fmt.Sprintf(` AND s.ended_at IS NULL AND s.expires_at > $%d`, len(args))
+		}
+
+		limit := 100
+		if parsed, err := strconv.Atoi(q.Get("limit")); err == nil && parsed > 0 && parsed <= 500 {
+			limit = parsed
+		}
+		args = append(args, limit)
+		query += fmt.Sprintf(` ORDER BY s.created_at DESC LIMIT $%d`, len(args))
+
+		sessions := []models.ImpersonationSession{}
+		if err := db.SelectContext(r.Context(), &sessions, query, args...); err != nil {
+			log.Printf("❌ [SUPPORT-MODE] Failed to fetch sessions: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch support mode sessions")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    sessions,
+		})
+	}
+}
+
+// GetImpersonatedRequests returns every request made in a support mode session, oldest first
+// GET /api/manager/impersonations/{id}/requests
+func GetImpersonatedRequests(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sessionID := chi.URLParam(r, "id")
+
+		var session models.ImpersonationSession
+		err := db.GetContext(r.Context(), &session, database.ImpersonationSessionSelect+` WHERE s.id = $1`, sessionID)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "Support mode session not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [SUPPORT-MODE] Failed to fetch session %s: %v", sessionID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch support mode session")
+			return
+		}
+
+		requests := []models.ImpersonatedRequest{}
+		err = db.SelectContext(r.Context(), &requests, `
+			SELECT * FROM impersonated_requests WHERE session_id = $1 ORDER BY created_at ASC, id ASC
+		`, sessionID)
+		if err != nil {
+			log.Printf("❌ [SUPPORT-MODE] Failed to fetch requests of session %s: %v", sessionID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch support mode session")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data": map[string]interface{}{
+				"session":  session,
+				"requests": requests,
+			},
+		})
+	}
+}
+
+// EndImpersonation ends a support mode session early; its token stops working immediately
+// POST /api/manager/impersonations/{id}/end
+func EndImpersonation(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sessionID := chi.URLParam(r, "id")
+
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		now := time.Now().Unix()
+		var session models.ImpersonationSession
+		err := db.GetContext(r.Context(), &session, `
+			UPDATE impersonation_sessions SET ended_at = $1, ended_by_user_id = $2
+			WHERE id = $3 AND ended_at IS NULL AND expires_at > $1
+			RETURNING *
+		`, now, userClaims.UserID, sessionID)
+		if err == sql.ErrNoRows {
+			var exists bool
+			if err := db.GetContext(r.Context(), &exists, `SELECT EXISTS(SELECT 1 FROM impersonation_sessions WHERE id = $1)`, sessionID); err == nil && !exists {
+				utils.RespondError(w, http.StatusNotFound, "Support mode session not found")
+				return
+			}
+			utils.RespondError(w, http.StatusConflict, "Support mode session has already ended")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [SUPPORT-MODE] Failed to end session %s: %v", sessionID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to end support mode session")
+			return
+		}
+
+		ip := clientIP(r)
+		userAgent := r.UserAgent()
+		details := fmt.Sprintf("session %s", session.ID)
+		helpers.LogSecurityEvent(db, models.SecurityEvent{
+			EventType: models.SecurityEventSupportModeEnded,
+			UserID:    &session.DriverID,
+			IPAddress: &ip,
+			UserAgent: &userAgent,
+			ActorID:   &userClaims.UserID,
+			Details:   &details,
+			CreatedAt: now,
+		})
+		log.Printf("✅ [SUPPORT-MODE] %s ended session %s", userClaims.Email, session.ID)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    session,
+		})
+	}
+}
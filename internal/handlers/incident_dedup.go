@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// findRepeatedIncident returns the open incident a new report repeats: the latest first report of the same
+// type at the same bin, made within the dedup window, in a zone that is still active
+// Returns nil when there's none or deduplication is off; a repeat is linked to it instead of scoring the zone again
+func findRepeatedIncident(ctx context.Context, db *sqlx.DB, binID, incidentType string, now int64) (*models.ZoneIncident, error) {
+	settings, err := database.GetIncidentDedupSettings(db)
+	if err != nil {
+		return nil, err
+	}
+	if settings.WindowHours == 0 {
+		return nil, nil
+	}
+
+	var parent models.ZoneIncident
+	err = db.GetContext(ctx, &parent, `
+		SELECT zi.* FROM zone_incidents zi
+		JOIN no_go_zones z ON z.id = zi.zone_id
+		WHERE zi.bin_id = $1 AND zi.incident_type = $2 AND zi.parent_incident_id IS NULL
+		AND zi.status <> 'resolved' AND z.status = 'active' AND zi.reported_at >= $3
+		ORDER BY zi.reported_at DESC
+		LIMIT 1
+	`, binID, incidentType, now-int64(settings.WindowHours)*3600)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up repeated incident: %w", err)
+	}
+	return &parent, nil
+}
+
+// countIncidentReports refreshes an incident's report_count from its linked repeats and returns it
+func countIncidentReports(ctx context.Context, db *sqlx.DB, incidentID string) (int, error) {
+	var count int
+	err := db.GetContext(ctx, &count, `
+		UPDATE zone_incidents
+		SET report_count = 1 + (SELECT COUNT(*) FROM zone_incidents c WHERE c.parent_incident_id = $1)
+		WHERE id = $1
+		RETURNING report_count
+	`, incidentID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count reports of incident %s: %w", incidentID, err)
+	}
+	return count, nil
+}
@@ -0,0 +1,727 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"ropacal-backend/internal/helpers"
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Incident import limits and matching
+const (
+	incidentImportMaxBytes     = 10 << 20 // Larger uploads are rejected with 413
+	incidentImportMaxRows      = 20000
+	incidentImportZoneRadiusM  = 100.0      // Incidents join an existing zone whose center is this close to the bin
+	incidentImportDefaultState = "resolved" // Historical incidents without a status column
+)
+
+// incidentExportColumns is the header of the export, which the import also accepts
+var incidentExportColumns = []string{
+	"incident_id", "reported_at", "incident_type", "status", "description", "photo_url",
+	"is_field_observation", "verified_at", "verified_by",
+	"zone_id", "zone_name", "zone_status", "zone_conflict_score",
+	"zone_center_latitude", "zone_center_longitude", "zone_radius_meters",
+	"bin_id", "bin_number", "bin_street", "bin_city", "bin_zip", "bin_latitude", "bin_longitude",
+	"reporter_id", "reporter_name", "reporter_email", "reporter_latitude", "reporter_longitude",
+	"shift_id", "check_id",
+}
+
+// incidentImportAliases lists the normalized spreadsheet headers accepted for each import field
+var incidentImportAliases = map[string][]string{
+	"bin_number":         {"bin_number", "bin", "bin_no"},
+	"incident_type":      {"incident_type", "type", "incident"},
+	"reported_at":        {"reported_at", "date", "reported", "reported_date", "incident_date"},
+	"status":             {"status"},
+	"description":        {"description", "notes", "details"},
+	"photo_url":          {"photo_url", "photo"},
+	"reporter_email":     {"reporter_email", "reported_by_email", "email"},
+	"reporter_latitude":  {"reporter_latitude", "latitude", "lat"},
+	"reporter_longitude": {"reporter_longitude", "longitude", "lng", "lon"},
+}
+
+var validIncidentStatuses = map[string]bool{"open": true, "resolved": true, "investigating": true}
+
+var nonAlphanumeric = regexp.MustCompile(`[^a-z0-9]+`)
+
+// normalizeImportHeader turns "Bin Number" or "Reported-At" into bin_number / reported_at
+func normalizeImportHeader(header string) string {
+	return strings.Trim(nonAlphanumeric.ReplaceAllString(strings.ToLower(strings.TrimSpace(header)), "_"), "_")
+}
+
+// parseReportBound parses ?from / ?to as a unix timestamp or a YYYY-MM-DD day in loc (to covers the whole day)
+func parseReportBound(value string, loc *time.Location, endOfDay bool) (*int64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if unix, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return &unix, nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", value, loc)
+	if err != nil {
+		return nil, err
+	}
+	if endOfDay {
+		day = day.AddDate(0, 0, 1).Add(-time.Second)
+	}
+	unix := day.Unix()
+	return &unix, nil
+}
+
+// parseImportedTimestamp accepts the date formats municipal spreadsheets use, including Excel serial dates
+// Values without an offset are read in loc
+func parseImportedTimestamp(value string, loc *time.Location) (int64, error) {
+	value = strings.TrimSpace(value)
+	if unix, err := strconv.ParseInt(value, 10, 64); err == nil && unix > 100000 {
+		return unix, nil
+	}
+	if serial, err := strconv.ParseFloat(value, 64); err == nil {
+		// Days since 1899-12-30 (Excel's epoch, including its 1900 leap year bug)
+		if serial < 1 || serial > 2958465 {
+			return 0, fmt.Errorf("invalid date %q", value)
+		}
+		days := math.Floor(serial)
+		seconds := math.Round((serial - days) * 86400)
+		return time.Date(1899, 12, 30, 0, 0, 0, 0, loc).AddDate(0, 0, int(days)).Add(time.Duration(seconds) * time.Second).Unix(), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.Unix(), nil
+	}
+	for _, layout := range []string{
+		"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02T15:04:05", "2006-01-02",
+		"01/02/2006 15:04:05", "01/02/2006 15:04", "01/02/2006", "1/2/2006 15:04", "1/2/2006",
+	} {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t.Unix(), nil
+		}
+	}
+	return 0, fmt.Errorf("unrecognized date %q (use YYYY-MM-DD, MM/DD/YYYY or RFC 3339)", value)
+}
+
+func formatOptionalString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func formatOptionalFloat(f *float64) string {
+	if f == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*f, 'f', -1, 64)
+}
+
+func formatOptionalInt(i *int) string {
+	if i == nil {
+		return ""
+	}
+	return strconv.Itoa(*i)
+}
+
+// ExportIncidents downloads zone incidents with their zone, bin and reporter flattened into one row each
+// Timestamps are written in the requested timezone (default UTC)
+// GET /api/manager/export/incidents?format=csv|xlsx&from=<unix|YYYY-MM-DD>&to=<unix|YYYY-MM-DD>&timezone=America/Los_Angeles
+func ExportIncidents(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+
+		format := strings.ToLower(q.Get("format"))
+		if format == "" {
+			format = "csv"
+		}
+		if format != "csv" && format != "xlsx" {
+			utils.RespondError(w, http.StatusBadRequest, "format must be csv or xlsx")
+			return
+		}
+
+		loc := time.UTC
+		if tz := q.Get("timezone"); tz != "" {
+			parsed, err := time.LoadLocation(tz)
+			if err != nil {
+				utils.RespondError(w, http.StatusBadRequest, fmt.Sprintf("Invalid timezone: %s", tz))
+				return
+			}
+			loc = parsed
+		}
+
+		from, err := parseReportBound(q.Get("from"), loc, false)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "from must be a unix timestamp or YYYY-MM-DD")
+			return
+		}
+		to, err := parseReportBound(q.Get("to"), loc, true)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "to must be a unix timestamp or YYYY-MM-DD")
+			return
+		}
+
+		incidents := []models.IncidentExportRow{}
+		err = db.SelectContext(r.Context(), &incidents, `
+			SELECT zi.id, zi.reported_at, zi.incident_type, zi.status, zi.description, zi.photo_url,
+			       zi.is_field_observation, zi.verified_at, verifier.name AS verified_by_name,
+			       z.id AS zone_id, z.name AS zone_name, z.status AS zone_status, z.conflict_score AS zone_conflict_score,
+			       z.center_latitude AS zone_center_latitude, z.center_longitude AS zone_center_longitude,
+			       z.radius_meters AS zone_radius_meters,
+			       zi.bin_id, b.bin_number, b.current_street AS bin_street, b.city AS bin_city, b.zip AS bin_zip,
+			       b.latitude AS bin_latitude, b.longitude AS bin_longitude,
+			       reporter.id AS reporter_id, reporter.name AS reporter_name, reporter.email AS reporter_email,
+			       zi.reporter_latitude, zi.reporter_longitude, zi.shift_id, zi.check_id
+			FROM zone_incidents zi
+			JOIN no_go_zones z ON z.id = zi.zone_id
+			LEFT JOIN bins b ON b.id = zi.bin_id
+			LEFT JOIN users reporter ON reporter.id = zi.reported_by_user_id
+			LEFT JOIN users verifier ON verifier.id = zi.verified_by_user_id
+			WHERE ($1::BIGINT IS NULL OR zi.reported_at >= $1)
+			  AND ($2::BIGINT IS NULL OR zi.reported_at <= $2)
+			ORDER BY zi.reported_at ASC, zi.id ASC`, from, to)
+		if err != nil {
+			log.Printf("❌ [INCIDENT-EXPORT] Failed to fetch incidents: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to export incidents")
+			return
+		}
+
+		formatTime := func(unix *int64) string {
+			if unix == nil {
+				return ""
+			}
+			return time.Unix(*unix, 0).In(loc).Format(time.RFC3339)
+		}
+
+		rows := [][]string{incidentExportColumns}
+		for _, incident := range incidents {
+			rows = append(rows, []string{
+				incident.ID,
+				formatTime(&incident.ReportedAt),
+				incident.IncidentType,
+				incident.Status,
+				formatOptionalString(incident.Description),
+				formatOptionalString(incident.PhotoURL),
+				strconv.FormatBool(incident.IsFieldObservation),
+				formatTime(incident.VerifiedAt),
+				formatOptionalString(incident.VerifiedByName),
+				incident.ZoneID,
+				incident.ZoneName,
+				incident.ZoneStatus,
+				strconv.Itoa(incident.ZoneConflictScore),
+				strconv.FormatFloat(incident.ZoneCenterLatitude, 'f', -1, 64),
+				strconv.FormatFloat(incident.ZoneCenterLongitude, 'f', -1, 64),
+				strconv.Itoa(incident.ZoneRadiusMeters),
+				incident.BinID,
+				formatOptionalInt(incident.BinNumber),
+				formatOptionalString(incident.BinStreet),
+				formatOptionalString(incident.BinCity),
+				formatOptionalString(incident.BinZip),
+				formatOptionalFloat(incident.BinLatitude),
+				formatOptionalFloat(incident.BinLongitude),
+				formatOptionalString(incident.ReporterID),
+				formatOptionalString(incident.ReporterName),
+				formatOptionalString(incident.ReporterEmail),
+				formatOptionalFloat(incident.ReporterLatitude),
+				formatOptionalFloat(incident.ReporterLongitude),
+				formatOptionalString(incident.ShiftID),
+				formatOptionalInt(incident.CheckID),
+			})
+		}
+
+		filename := "incidents-" + time.Now().In(loc).Format("2006-01-02") + "." + format
+		var body bytes.Buffer
+		if format == "xlsx" {
+			err = utils.WriteXLSX(&body, "Incidents", rows)
+			w.Header().Set("Content-Type", utils.XLSXContentType)
+		} else {
+			cw := csv.NewWriter(&body)
+			cw.WriteAll(rows)
+			err = cw.Error()
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		}
+		if err != nil {
+			log.Printf("❌ [INCIDENT-EXPORT] Failed to write %s: %v", format, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to export incidents")
+			return
+		}
+
+		log.Printf("📤 [INCIDENT-EXPORT] Exported %d incidents as %s", len(incidents), format)
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+		w.WriteHeader(http.StatusOK)
+		w.Write(body.Bytes())
+	}
+}
+
+// readImportSpreadsheet returns the rows of an uploaded CSV or XLSX file (raw body or multipart "file" field)
+func readImportSpreadsheet(w http.ResponseWriter, r *http.Request) ([][]string, int, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, incidentImportMaxBytes)
+
+	var data []byte
+	var err error
+	contentType := r.Header.Get("Content-Type")
+	if strings.HasPrefix(contentType, "multipart/") {
+		file, _, formErr := r.FormFile("file")
+		if formErr != nil {
+			err = formErr
+		} else {
+			defer file.Close()
+			data, err = io.ReadAll(file)
+		}
+	} else {
+		data, err = io.ReadAll(r.Body)
+	}
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, http.StatusRequestEntityTooLarge, fmt.Errorf("file exceeds %d bytes", incidentImportMaxBytes)
+		}
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to read file: %v", err)
+	}
+	if len(data) == 0 {
+		return nil, http.StatusBadRequest, fmt.Errorf("file is empty")
+	}
+
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		// XLSX files are zip archives
+		format = "csv"
+		if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+			format = "xlsx"
+		}
+	}
+
+	switch format {
+	case "xlsx":
+		rows, err := utils.ReadXLSX(data)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		return rows, 0, nil
+	case "csv":
+		reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+		reader.FieldsPerRecord = -1
+		reader.LazyQuotes = true
+		rows, err := reader.ReadAll()
+		if err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid csv: %v", err)
+		}
+		return rows, 0, nil
+	}
+	return nil, http.StatusBadRequest, fmt.Errorf("format must be csv or xlsx")
+}
+
+// importBin is a bin matched by number during an import
+type importBin struct {
+	ID            string   `db:"id"`
+	BinNumber     int      `db:"bin_number"`
+	CurrentStreet string   `db:"current_street"`
+	City          string   `db:"city"`
+	Latitude      *float64 `db:"latitude"`
+	Longitude     *float64 `db:"longitude"`
+}
+
+// importZone is an existing or new zone that imported incidents are attached to
+type importZone struct {
+	zone       models.NoGoZone
+	isNew      bool
+	scoreAdded int
+}
+
+// ImportIncidents backfills historical incidents from a CSV or XLSX spreadsheet
+// Columns are matched by header (the export's columns, or common names such as "Bin #", "Date", "Type", "Notes"):
+// bin_number, incident_type and reported_at are required; status defaults to resolved.
+// Rows for the same bin, type and day as an existing incident (or an earlier row) are skipped as duplicates.
+// Invalid rows are reported and skipped; valid rows are imported together. ?dry_run=true validates without saving.
+// POST /api/manager/import/incidents?format=csv|xlsx&timezone=America/Los_Angeles&dry_run=true
+func ImportIncidents(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		q := r.URL.Query()
+		loc := time.UTC
+		if tz := q.Get("timezone"); tz != "" {
+			parsed, err := time.LoadLocation(tz)
+			if err != nil {
+				utils.RespondError(w, http.StatusBadRequest, fmt.Sprintf("Invalid timezone: %s", tz))
+				return
+			}
+			loc = parsed
+		}
+
+		rows, status, err := readImportSpreadsheet(w, r)
+		if err != nil {
+			utils.RespondError(w, status, err.Error())
+			return
+		}
+		if len(rows) < 2 {
+			utils.RespondError(w, http.StatusBadRequest, "File must have a header row and at least one incident")
+			return
+		}
+		if len(rows)-1 > incidentImportMaxRows {
+			utils.RespondError(w, http.StatusBadRequest, fmt.Sprintf("File has more than %d rows", incidentImportMaxRows))
+			return
+		}
+
+		columns := map[string]int{}
+		for i, header := range rows[0] {
+			header = normalizeImportHeader(header)
+			for field, aliases := range incidentImportAliases {
+				for _, alias := range aliases {
+					if _, seen := columns[field]; !seen && alias == header {
+						columns[field] = i
+					}
+				}
+			}
+		}
+		for _, required := range []string{"bin_number", "incident_type", "reported_at"} {
+			if _, ok := columns[required]; !ok {
+				utils.RespondError(w, http.StatusBadRequest, fmt.Sprintf("Missing %s column", required))
+				return
+			}
+		}
+
+		// Reference data for matching rows
+		var bins []importBin
+		if err := db.SelectContext(r.Context(), &bins, `SELECT id, bin_number, current_street, city, latitude, longitude FROM bins`); err != nil {
+			log.Printf("❌ [INCIDENT-IMPORT] Failed to load bins: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to import incidents")
+			return
+		}
+		binsByNumber := make(map[int]importBin, len(bins))
+		for _, bin := range bins {
+			binsByNumber[bin.BinNumber] = bin
+		}
+
+		var users []struct {
+			ID    string `db:"id"`
+			Email string `db:"email"`
+		}
+		if err := db.SelectContext(r.Context(), &users, `SELECT id, email FROM users`); err != nil {
+			log.Printf("❌ [INCIDENT-IMPORT] Failed to load users: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to import incidents")
+			return
+		}
+		usersByEmail := make(map[string]string, len(users))
+		for _, user := range users {
+			usersByEmail[strings.ToLower(user.Email)] = user.ID
+		}
+
+		// Historical incidents may have archived types
+		incidentTypes, err := loadIncidentTypes(r.Context(), db)
+		if err != nil {
+			log.Printf("❌ [INCIDENT-IMPORT] %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to import incidents")
+			return
+		}
+
+		var existingZones []models.NoGoZone
+		if err := db.SelectContext(r.Context(), &existingZones, `SELECT * FROM no_go_zones WHERE merged_into_zone_id IS NULL`); err != nil {
+			log.Printf("❌ [INCIDENT-IMPORT] Failed to load zones: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to import incidents")
+			return
+		}
+		zones := make([]*importZone, len(existingZones))
+		for i := range existingZones {
+			zones[i] = &importZone{zone: existingZones[i]}
+		}
+
+		result := models.IncidentImportResult{
+			DryRun:      q.Get("dry_run") == "true",
+			TotalRows:   len(rows) - 1,
+			Duplicates:  []models.IncidentImportRowError{},
+			Errors:      []models.IncidentImportRowError{},
+			IncidentIDs: []string{},
+		}
+		now := time.Now().Unix()
+
+		type plannedIncident struct {
+			incident models.ZoneIncident
+			zone     *importZone
+		}
+		planned := []plannedIncident{}
+		seen := map[string]int{}
+
+		for i, row := range rows[1:] {
+			rowNumber := i + 2
+			cell := func(field string) string {
+				if index, ok := columns[field]; ok && index < len(row) {
+					return strings.TrimSpace(row[index])
+				}
+				return ""
+			}
+			rowError := func(format string, args ...interface{}) {
+				result.Errors = append(result.Errors, models.IncidentImportRowError{Row: rowNumber, Message: fmt.Sprintf(format, args...)})
+			}
+
+			empty := true
+			for _, value := range row {
+				if strings.TrimSpace(value) != "" {
+					empty = false
+					break
+				}
+			}
+			if empty {
+				result.TotalRows--
+				continue
+			}
+
+			binNumber, err := strconv.Atoi(strings.TrimPrefix(cell("bin_number"), "#"))
+			if err != nil {
+				rowError("Invalid bin_number %q", cell("bin_number"))
+				continue
+			}
+			bin, ok := binsByNumber[binNumber]
+			if !ok {
+				rowError("Bin #%d not found", binNumber)
+				continue
+			}
+
+			incidentType, ok := incidentTypes[normalizeImportHeader(cell("incident_type"))]
+			if !ok {
+				rowError("Invalid incident_type %q", cell("incident_type"))
+				continue
+			}
+
+			reportedAt, err := parseImportedTimestamp(cell("reported_at"), loc)
+			if err != nil {
+				rowError("%v", err)
+				continue
+			}
+			if reportedAt > now {
+				rowError("reported_at is in the future")
+				continue
+			}
+
+			status := strings.ToLower(cell("status"))
+			if status == "" {
+				status = incidentImportDefaultState
+			}
+			if !validIncidentStatuses[status] {
+				rowError("Invalid status %q (use open, investigating or resolved)", cell("status"))
+				continue
+			}
+
+			var reporterID *string
+			if email := strings.ToLower(cell("reporter_email")); email != "" {
+				id, ok := usersByEmail[email]
+				if !ok {
+					rowError("No user with email %s", email)
+					continue
+				}
+				reporterID = &id
+			}
+
+			var reporterLat, reporterLng *float64
+			if cell("reporter_latitude") != "" || cell("reporter_longitude") != "" {
+				lat, latErr := strconv.ParseFloat(cell("reporter_latitude"), 64)
+				lng, lngErr := strconv.ParseFloat(cell("reporter_longitude"), 64)
+				if latErr != nil || lngErr != nil || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+					rowError("Invalid reporter coordinates")
+					continue
+				}
+				reporterLat, reporterLng = &lat, &lng
+			}
+
+			// The zone is placed at the bin, or where it was reported when the bin has no coordinates
+			lat, lng := bin.Latitude, bin.Longitude
+			if lat == nil || lng == nil {
+				lat, lng = reporterLat, reporterLng
+			}
+			if lat == nil || lng == nil {
+				rowError("Bin #%d has no coordinates; add reporter_latitude and reporter_longitude", binNumber)
+				continue
+			}
+
+			// Duplicates: same bin, type and day (in the import timezone) as an earlier row or a stored incident
+			dayStart := time.Unix(reportedAt, 0).In(loc)
+			dayStart = time.Date(dayStart.Year(), dayStart.Month(), dayStart.Day(), 0, 0, 0, 0, loc)
+			key := fmt.Sprintf("%s|%s|%d", bin.ID, incidentType.Key, dayStart.Unix())
+			if earlier, ok := seen[key]; ok {
+				result.Duplicates = append(result.Duplicates, models.IncidentImportRowError{
+					Row: rowNumber, Message: fmt.Sprintf("Same bin, type and day as row %d", earlier),
+				})
+				continue
+			}
+			var existingID string
+			err = db.GetContext(r.Context(), &existingID, `
+				SELECT id FROM zone_incidents
+				WHERE bin_id = $1 AND incident_type = $2 AND reported_at >= $3 AND reported_at < $4
+				LIMIT 1`, bin.ID, incidentType.Key, dayStart.Unix(), dayStart.AddDate(0, 0, 1).Unix())
+			if err == nil {
+				result.Duplicates = append(result.Duplicates, models.IncidentImportRowError{
+					Row: rowNumber, Message: fmt.Sprintf("Matches existing incident %s", existingID),
+				})
+				continue
+			}
+			if !errors.Is(err, sql.ErrNoRows) {
+				log.Printf("❌ [INCIDENT-IMPORT] Failed to check duplicates: %v", err)
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to import incidents")
+				return
+			}
+			seen[key] = rowNumber
+
+			// Attach to the nearest zone within range, or start a new one at the bin
+			var zone *importZone
+			nearest := incidentImportZoneRadiusM
+			for _, candidate := range zones {
+				if d := calculateZoneDistance(*lat, *lng, candidate.zone.CenterLatitude, candidate.zone.CenterLongitude); d <= nearest {
+					zone, nearest = candidate, d
+				}
+			}
+			if zone == nil {
+				zone = &importZone{
+					isNew: true,
+					zone: models.NoGoZone{
+						ID:              uuid.New().String(),
+						Name:            fmt.Sprintf("%s - %s", bin.CurrentStreet, bin.City),
+						CenterLatitude:  *lat,
+						CenterLongitude: *lng,
+						RadiusMeters:    incidentType.ZoneRadiusMeters,
+						Status:          "resolved",
+						CreatedByUserID: &userClaims.UserID,
+						CreatedAt:       now,
+						UpdatedAt:       now,
+					},
+				}
+				zones = append(zones, zone)
+				result.ZonesCreated++
+			}
+			// Only incidents that are still open count towards the zone's current risk
+			if status != "resolved" {
+				zone.scoreAdded += incidentType.ZoneScore
+				if zone.isNew {
+					zone.zone.Status = "active"
+				}
+			}
+
+			description := cell("description")
+			photoURL := cell("photo_url")
+			incident := models.ZoneIncident{
+				ID:                uuid.New().String(),
+				ZoneID:            zone.zone.ID,
+				BinID:             bin.ID,
+				IncidentType:      incidentType.Key,
+				ReportedByUserID:  reporterID,
+				ReportedAt:        reportedAt,
+				ReporterLatitude:  reporterLat,
+				ReporterLongitude: reporterLng,
+				Status:            status,
+			}
+			if description != "" {
+				incident.Description = &description
+			}
+			if photoURL != "" {
+				incident.PhotoURL = &photoURL
+			}
+			planned = append(planned, plannedIncident{incident: incident, zone: zone})
+		}
+
+		result.Imported = len(planned)
+		for _, p := range planned {
+			result.IncidentIDs = append(result.IncidentIDs, p.incident.ID)
+		}
+
+		if result.DryRun || len(planned) == 0 {
+			utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+				"success": true,
+				"data":    result,
+			})
+			return
+		}
+
+		tx, err := db.BeginTxx(r.Context(), nil)
+		if err != nil {
+			log.Printf("❌ [INCIDENT-IMPORT] Failed to start transaction: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to import incidents")
+			return
+		}
+		defer tx.Rollback()
+
+		newActiveZones := []string{}
+		for _, zone := range zones {
+			if zone.isNew {
+				zone.zone.ConflictScore = zone.scoreAdded
+				if zone.zone.Status == "resolved" {
+					resolutionType := "manual_resolution"
+					notes := "Created by incident import (historical incidents only)"
+					zone.zone.ResolvedAt = &now
+					zone.zone.ResolvedByUserID = &userClaims.UserID
+					zone.zone.ResolutionType = &resolutionType
+					zone.zone.ResolutionNotes = &notes
+				} else {
+					newActiveZones = append(newActiveZones, zone.zone.ID)
+				}
+				_, err = tx.NamedExecContext(r.Context(), `
+					INSERT INTO no_go_zones (id, name, center_latitude, center_longitude, radius_meters, conflict_score, status,
+						created_by_user_id, created_at, updated_at, resolved_by_user_id, resolved_at, resolution_notes, resolution_type)
+					VALUES (:id, :name, :center_latitude, :center_longitude, :radius_meters, :conflict_score, :status,
+						:created_by_user_id, :created_at, :updated_at, :resolved_by_user_id, :resolved_at, :resolution_notes, :resolution_type)
+				`, zone.zone)
+			} else if zone.scoreAdded > 0 {
+				_, err = tx.ExecContext(r.Context(), `UPDATE no_go_zones SET conflict_score = conflict_score + $1, updated_at = $2 WHERE id = $3`,
+					zone.scoreAdded, now, zone.zone.ID)
+			}
+			if err != nil {
+				log.Printf("❌ [INCIDENT-IMPORT] Failed to save zone %s: %v", zone.zone.ID, err)
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to import incidents")
+				return
+			}
+		}
+
+		for _, p := range planned {
+			_, err = tx.NamedExecContext(r.Context(), `
+				INSERT INTO zone_incidents (id, zone_id, bin_id, incident_type, reported_by_user_id, reported_at, description, photo_url,
+					reporter_latitude, reporter_longitude, is_field_observation, status)
+				VALUES (:id, :zone_id, :bin_id, :incident_type, :reported_by_user_id, :reported_at, :description, :photo_url,
+					:reporter_latitude, :reporter_longitude, :is_field_observation, :status)
+			`, p.incident)
+			if err != nil {
+				log.Printf("❌ [INCIDENT-IMPORT] Failed to insert incident: %v", err)
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to import incidents")
+				return
+			}
+			if err := helpers.EnqueueIncidentPhoto(tx, p.incident.ID, p.incident.PhotoURL); err != nil {
+				log.Printf("❌ [INCIDENT-IMPORT] %v", err)
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to import incidents")
+				return
+			}
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Printf("❌ [INCIDENT-IMPORT] Failed to commit: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to import incidents")
+			return
+		}
+
+		// New active zones may overlap existing ones, same as zones created from driver reports
+		for _, zoneID := range newActiveZones {
+			if err := detectAndMergeZones(db, zoneID, now); err != nil {
+				log.Printf("⚠️  [INCIDENT-IMPORT] Zone merge check failed for %s: %v", zoneID, err)
+			}
+		}
+
+		log.Printf("📥 [INCIDENT-IMPORT] %s imported %d incidents (%d duplicates, %d errors, %d new zones)",
+			userClaims.Email, result.Imported, len(result.Duplicates), len(result.Errors), result.ZonesCreated)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    result,
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ropacal-backend/internal/models"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const (
+	taggedIncidentsDefaultLimit = 50
+	taggedIncidentsMaxLimit     = 200
+)
+
+// GetTaggedIncidents lists incidents with the labels detected on their photos, newest first
+// GET /api/manager/incidents?label=graffiti,damage&min_confidence=0.8&tag_status=tagged&zone_id=&status=&from=&to=&limit=50&offset=0
+// label matches incidents with any of the labels at min_confidence or above (default: any stored confidence)
+// Managers assigned to areas get the incidents of their areas' bins and zones unless all_areas=true
+func GetTaggedIncidents(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+
+		limit := taggedIncidentsDefaultLimit
+		if v := q.Get("limit"); v != "" {
+			parsed, err := strconv.Atoi(v)
+			if err != nil || parsed < 1 || parsed > taggedIncidentsMaxLimit {
+				utils.RespondError(w, http.StatusBadRequest, "limit must be between 1 and 200")
+				return
+			}
+			limit = parsed
+		}
+		offset := 0
+		if v := q.Get("offset"); v != "" {
+			parsed, err := strconv.Atoi(v)
+			if err != nil || parsed < 0 {
+				utils.RespondError(w, http.StatusBadRequest, "offset must be 0 or more")
+				return
+			}
+			offset = parsed
+		}
+
+		var labels []string
+		if v := q.Get("label"); v != "" {
+			for _, label := range strings.Split(v, ",") {
+				label = strings.ToLower(strings.TrimSpace(label))
+				if !models.IsValidIncidentPhotoLabel(label) {
+					utils.RespondError(w, http.StatusBadRequest,
+						fmt.Sprintf("label must be one of: %s", strings.Join(models.IncidentPhotoLabels, ", ")))
+					return
+				}
+				labels = append(labels, label)
+			}
+		}
+		minConfidence := 0.0
+		if v := q.Get("min_confidence"); v != "" {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil || parsed < 0 || parsed > 1 {
+				utils.RespondError(w, http.StatusBadRequest, "min_confidence must be between 0 and 1")
+				return
+			}
+			minConfidence = parsed
+		}
+
+		tagStatus := q.Get("tag_status")
+		if tagStatus != "" && tagStatus != models.IncidentPhotoPending &&
+			tagStatus != models.IncidentPhotoTagged && tagStatus != models.IncidentPhotoFailed {
+			utils.RespondError(w, http.StatusBadRequest, "tag_status must be pending, tagged or failed")
+			return
+		}
+		status := q.Get("status")
+		if status != "" && !validIncidentStatuses[status] {
+			utils.RespondError(w, http.StatusBadRequest, "status must be open, resolved or investigating")
+			return
+		}
+
+		from, err := parseReportBound(q.Get("from"), time.UTC, false)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "from must be a unix timestamp or YYYY-MM-DD")
+			return
+		}
+		to, err := parseReportBound(q.Get("to"), time.UTC, true)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "to must be a unix timestamp or YYYY-MM-DD")
+			return
+		}
+
+		where := ` WHERE 1=1`
+		var args []interface{}
+		addFilter := func(clause string, value interface{}) {
+			args = append(args, value)
+			where += fmt.Sprintf(clause, len(args))
+		}
+		if len(labels) > 0 {
+			args = append(args, pq.Array(labels), minConfidence)
+			where += fmt.Sprintf(` AND EXISTS (
+				SELECT 1 FROM incident_photo_labels l
+				WHERE l.incident_id = zi.id AND l.label = ANY($%d) AND l.confidence >= $%d)`, len(args)-1, len(args))
+		} else if minConfidence > 0 {
+			addFilter(` AND EXISTS (
+				SELECT 1 FROM incident_photo_labels l
+				WHERE l.incident_id = zi.id AND l.confidence >= $%d)`, minConfidence)
+		}
+		if tagStatus != "" {
+			addFilter(` AND j.status = $%d`, tagStatus)
+		}
+		if zoneID := q.Get("zone_id"); zoneID != "" {
+			addFilter(` AND zi.zone_id = $%d`, zoneID)
+		}
+		if status != "" {
+			addFilter(` AND zi.status = $%d`, status)
+		}
+		if from != nil {
+			addFilter(` AND zi.reported_at >= $%d`, *from)
+		}
+		if to != nil {
+			addFilter(` AND zi.reported_at <= $%d`, *to)
+		}
+		scope, err := managerAreaScope(r, db)
+		if err != nil {
+			log.Printf("❌ [INCIDENTS] %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch incidents")
+			return
+		}
+		if scope != nil {
+			addFilter(` AND (b.area_id = ANY($%[1]d) OR z.area_id = ANY($%[1]d))`, pq.Array(scope))
+		}
+
+		joins := `
+			FROM zone_incidents zi
+			LEFT JOIN no_go_zones z ON z.id = zi.zone_id
+			LEFT JOIN bins b ON b.id = zi.bin_id
+			LEFT JOIN incident_photo_jobs j ON j.incident_id = zi.id`
+
+		response := models.TaggedIncidentsResponse{Incidents: []models.TaggedIncident{}}
+		if err := db.GetContext(r.Context(), &response.Total, `SELECT COUNT(*)`+joins+where, args...); err != nil {
+			log.Printf("❌ [INCIDENTS] Failed to count incidents: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch incidents")
+			return
+		}
+
+		pageArgs := append(args, limit, offset)
+		err = db.SelectContext(r.Context(), &response.Incidents, `
+			SELECT zi.id, zi.zone_id, z.name AS zone_name, zi.bin_id, b.bin_number, zi.incident_type, zi.status,
+			       zi.description, zi.photo_url, zi.reported_by_user_id, zi.reported_at, zi.is_field_observation,
+			       zi.shift_id, j.status AS photo_tag_status`+joins+where+
+			fmt.Sprintf(` ORDER BY zi.reported_at DESC, zi.id ASC LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2),
+			pageArgs...)
+		if err != nil {
+			log.Printf("❌ [INCIDENTS] Failed to fetch incidents: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch incidents")
+			return
+		}
+
+		if len(response.Incidents) > 0 {
+			ids := make([]string, len(response.Incidents))
+			for i, incident := range response.Incidents {
+				ids[i] = incident.ID
+			}
+			var rows []struct {
+				IncidentID string `db:"incident_id"`
+				models.IncidentPhotoLabel
+			}
+			err := db.SelectContext(r.Context(), &rows, `
+				SELECT incident_id, label, confidence FROM incident_photo_labels
+				WHERE incident_id = ANY($1)
+				ORDER BY confidence DESC, label ASC`, pq.Array(ids))
+			if err != nil {
+				log.Printf("❌ [INCIDENTS] Failed to fetch photo labels: %v", err)
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch incidents")
+				return
+			}
+			byIncident := map[string][]models.IncidentPhotoLabel{}
+			for _, row := range rows {
+				byIncident[row.IncidentID] = append(byIncident[row.IncidentID], row.IncidentPhotoLabel)
+			}
+			for i := range response.Incidents {
+				response.Incidents[i].PhotoLabels = byIncident[response.Incidents[i].ID]
+				if response.Incidents[i].PhotoLabels == nil {
+					response.Incidents[i].PhotoLabels = []models.IncidentPhotoLabel{}
+				}
+			}
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    response,
+		})
+	}
+}
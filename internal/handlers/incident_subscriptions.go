@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// GetIncidentSubscriptions lists the requesting manager's incident subscriptions
+// Without any, the manager gets no incident notifications
+// GET /api/manager/incident-subscriptions
+func GetIncidentSubscriptions(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		subscriptions := []models.IncidentSubscription{}
+		err := db.SelectContext(r.Context(), &subscriptions,
+			`SELECT * FROM incident_subscriptions WHERE user_id = $1 ORDER BY created_at ASC`, userClaims.UserID)
+		if err != nil {
+			log.Printf("❌ [INCIDENT-SUBSCRIPTIONS] Failed to fetch subscriptions: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch incident subscriptions")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    subscriptions,
+		})
+	}
+}
+
+// CreateIncidentSubscription subscribes the requesting manager to new incidents matching the filters
+// POST /api/manager/incident-subscriptions
+// Body: { "incident_types": ["theft"], "severities": ["high", "critical"], "area_ids": [], "websocket": true,
+// "push": true, "delivery": "immediate" } (empty lists match everything; delivery "daily" batches into one summary a day)
+func CreateIncidentSubscription(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		req, ok := decodeIncidentSubscription(w, r, db)
+		if !ok {
+			return
+		}
+
+		now := time.Now().Unix()
+		subscription := models.IncidentSubscription{
+			ID:            uuid.New().String(),
+			UserID:        userClaims.UserID,
+			IncidentTypes: req.IncidentTypes,
+			Severities:    req.Severities,
+			AreaIDs:       req.AreaIDs,
+			WebSocket:     *req.WebSocket,
+			Push:          req.Push,
+			Delivery:      req.Delivery,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		_, err := db.NamedExecContext(r.Context(), `
+			INSERT INTO incident_subscriptions (id, user_id, incident_types, severities, area_ids, websocket, push, delivery,
+				created_at, updated_at)
+			VALUES (:id, :user_id, :incident_types, :severities, :area_ids, :websocket, :push, :delivery,
+				:created_at, :updated_at)
+		`, subscription)
+		if err != nil {
+			log.Printf("❌ [INCIDENT-SUBSCRIPTIONS] Failed to create subscription: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to create incident subscription")
+			return
+		}
+
+		log.Printf("✅ [INCIDENT-SUBSCRIPTIONS] %s subscribed to incidents (types %v, severities %v, areas %v, %s)",
+			userClaims.Email, subscription.IncidentTypes, subscription.Severities, subscription.AreaIDs, subscription.Delivery)
+
+		utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{
+			"success": true,
+			"data":    subscription,
+		})
+	}
+}
+
+// UpdateIncidentSubscription replaces the filters, channels and delivery of one of the manager's subscriptions
+// PUT /api/manager/incident-subscriptions/{id}
+// Body: as for POST
+func UpdateIncidentSubscription(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		subscriptionID := chi.URLParam(r, "id")
+
+		req, ok := decodeIncidentSubscription(w, r, db)
+		if !ok {
+			return
+		}
+
+		var subscription models.IncidentSubscription
+		err := db.GetContext(r.Context(), &subscription, `
+			UPDATE incident_subscriptions
+			SET incident_types = $1, severities = $2, area_ids = $3, websocket = $4, push = $5, delivery = $6, updated_at = $7
+			WHERE id = $8 AND user_id = $9
+			RETURNING *
+		`, pq.Array(req.IncidentTypes), pq.Array(req.Severities), pq.Array(req.AreaIDs), *req.WebSocket, req.Push, req.Delivery,
+			time.Now().Unix(), subscriptionID, userClaims.UserID)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "Incident subscription not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [INCIDENT-SUBSCRIPTIONS] Failed to update subscription %s: %v", subscriptionID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to update incident subscription")
+			return
+		}
+
+		log.Printf("✅ [INCIDENT-SUBSCRIPTIONS] %s updated subscription %s", userClaims.Email, subscriptionID)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    subscription,
+		})
+	}
+}
+
+// DeleteIncidentSubscription removes one of the manager's subscriptions (incidents already queued for the
+// daily summary are still summarized)
+// DELETE /api/manager/incident-subscriptions/{id}
+func DeleteIncidentSubscription(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		subscriptionID := chi.URLParam(r, "id")
+
+		result, err := db.ExecContext(r.Context(),
+			`DELETE FROM incident_subscriptions WHERE id = $1 AND user_id = $2`, subscriptionID, userClaims.UserID)
+		if err != nil {
+			log.Printf("❌ [INCIDENT-SUBSCRIPTIONS] Failed to delete subscription %s: %v", subscriptionID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to delete incident subscription")
+			return
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			utils.RespondError(w, http.StatusNotFound, "Incident subscription not found")
+			return
+		}
+
+		log.Printf("🗑️  [INCIDENT-SUBSCRIPTIONS] %s deleted subscription %s", userClaims.Email, subscriptionID)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+		})
+	}
+}
+
+// decodeIncidentSubscription reads and checks a subscription body, responding with the error when it's invalid
+// Unknown incident types and areas are rejected with invalid_reference
+func decodeIncidentSubscription(w http.ResponseWriter, r *http.Request, db *sqlx.DB) (*models.IncidentSubscriptionRequest, bool) {
+	var req models.IncidentSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+		return nil, false
+	}
+	if err := req.Normalize(); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+
+	incidentTypes, err := loadIncidentTypes(r.Context(), db)
+	if err != nil {
+		log.Printf("❌ [INCIDENT-SUBSCRIPTIONS] %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Failed to validate incident_types")
+		return nil, false
+	}
+	var unknownTypes []string
+	for _, key := range req.IncidentTypes {
+		if _, ok := incidentTypes[key]; !ok {
+			unknownTypes = append(unknownTypes, key)
+		}
+	}
+	if len(unknownTypes) > 0 {
+		utils.RespondErrorCode(w, http.StatusBadRequest, utils.CodeInvalidReference, "Unknown incident_types", unknownTypes)
+		return nil, false
+	}
+
+	var found []string
+	if err := db.SelectContext(r.Context(), &found, `SELECT id FROM areas WHERE id = ANY($1)`, pq.Array(req.AreaIDs)); err != nil {
+		log.Printf("❌ [INCIDENT-SUBSCRIPTIONS] Failed to look up areas: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Failed to validate area_ids")
+		return nil, false
+	}
+	if len(found) < len(req.AreaIDs) {
+		exists := make(map[string]bool, len(found))
+		for _, id := range found {
+			exists[id] = true
+		}
+		var missing []string
+		for _, id := range req.AreaIDs {
+			if !exists[id] {
+				missing = append(missing, id)
+			}
+		}
+		utils.RespondErrorCode(w, http.StatusBadRequest, utils.CodeInvalidReference, "Unknown area_ids", missing)
+		return nil, false
+	}
+	return &req, true
+}
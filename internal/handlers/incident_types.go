@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// lookupIncidentType returns the incident type with the key, or nil when there is none
+// Archived types are only returned with includeArchived (new incidents can't use them)
+func lookupIncidentType(ctx context.Context, db sqlx.QueryerContext, key string, includeArchived bool) (*models.IncidentType, error) {
+	var incidentType models.IncidentType
+	err := sqlx.GetContext(ctx, db, &incidentType, `SELECT * FROM incident_types WHERE key = $1 AND (active OR $2)`, key, includeArchived)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up incident type %q: %w", key, err)
+	}
+	return &incidentType, nil
+}
+
+// loadIncidentTypes returns every incident type, archived ones included, by key
+func loadIncidentTypes(ctx context.Context, db sqlx.QueryerContext) (map[string]models.IncidentType, error) {
+	var incidentTypes []models.IncidentType
+	if err := sqlx.SelectContext(ctx, db, &incidentTypes, `SELECT * FROM incident_types`); err != nil {
+		return nil, fmt.Errorf("failed to load incident types: %w", err)
+	}
+	byKey := make(map[string]models.IncidentType, len(incidentTypes))
+	for _, incidentType := range incidentTypes {
+		byKey[incidentType.Key] = incidentType
+	}
+	return byKey, nil
+}
+
+// GetIncidentTypes returns the incident types drivers can report, most severe first
+// GET /api/incident-types
+func GetIncidentTypes(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		incidentTypes := []models.IncidentType{}
+		err := db.SelectContext(r.Context(), &incidentTypes, `
+			SELECT * FROM incident_types
+			WHERE active
+			ORDER BY ARRAY_POSITION($1::TEXT[], severity) DESC, LOWER(label)
+		`, pq.Array(models.IncidentSeverities))
+		if err != nil {
+			log.Printf("❌ [INCIDENT-TYPES] Failed to fetch incident types: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch incident types")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    incidentTypes,
+		})
+	}
+}
+
+// GetManagedIncidentTypes returns every incident type, archived ones included, with their incident counts
+// GET /api/manager/incident-types
+func GetManagedIncidentTypes(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		incidentTypes := []models.IncidentTypeWithCount{}
+		err := db.SelectContext(r.Context(), &incidentTypes, `
+			SELECT it.*, (SELECT COUNT(*) FROM zone_incidents zi WHERE zi.incident_type = it.key) AS incident_count
+			FROM incident_types it
+			ORDER BY it.active DESC, ARRAY_POSITION($1::TEXT[], it.severity) DESC, LOWER(it.label)
+		`, pq.Array(models.IncidentSeverities))
+		if err != nil {
+			log.Printf("❌ [INCIDENT-TYPES] Failed to fetch incident types: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch incident types")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    incidentTypes,
+		})
+	}
+}
+
+// CreateIncidentType adds an incident type drivers can report right away
+// POST /api/manager/incident-types
+// Body: { "key": "illegal_dumping", "label": "Illegal dumping", "severity": "medium", "zone_score": 8, "zone_radius_meters": 200 }
+// (severity, zone_score and zone_radius_meters default to medium, 5 and 250)
+func CreateIncidentType(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var req models.IncidentTypeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if err := req.Normalize(true); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		now := time.Now().Unix()
+		incidentType := models.IncidentType{
+			Key:              *req.Key,
+			Label:            *req.Label,
+			Severity:         models.IncidentSeverityMedium,
+			ZoneScore:        models.IncidentTypeDefaultZoneScore,
+			ZoneRadiusMeters: models.IncidentTypeDefaultRadius,
+			Active:           true,
+			CreatedByUserID:  &userClaims.UserID,
+			CreatedAt:        now,
+			UpdatedAt:        now,
+		}
+		if req.Description != nil && *req.Description != "" {
+			incidentType.Description = req.Description
+		}
+		if req.Severity != nil {
+			incidentType.Severity = *req.Severity
+		}
+		if req.ZoneScore != nil {
+			incidentType.ZoneScore = *req.ZoneScore
+		}
+		if req.ZoneRadiusMeters != nil {
+			incidentType.ZoneRadiusMeters = *req.ZoneRadiusMeters
+		}
+		if req.Active != nil {
+			incidentType.Active = *req.Active
+		}
+
+		_, err := db.NamedExecContext(r.Context(), `
+			INSERT INTO incident_types (key, label, description, severity, zone_score, zone_radius_meters, active,
+				created_by_user_id, created_at, updated_at)
+			VALUES (:key, :label, :description, :severity, :zone_score, :zone_radius_meters, :active,
+				:created_by_user_id, :created_at, :updated_at)
+		`, incidentType)
+		if err != nil {
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+				utils.RespondError(w, http.StatusConflict, "An incident type with this key already exists")
+				return
+			}
+			log.Printf("❌ [INCIDENT-TYPES] Failed to create incident type: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to create incident type")
+			return
+		}
+
+		log.Printf("✅ [INCIDENT-TYPES] %s created incident type %s (%s, score %d)",
+			userClaims.Email, incidentType.Key, incidentType.Severity, incidentType.ZoneScore)
+
+		utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{
+			"success": true,
+			"data":    incidentType,
+		})
+	}
+}
+
+// UpdateIncidentType changes an incident type's label, severity or zone impact, or archives/restores it
+// The zone impact applies to incidents reported afterwards; zones keep the score they have
+// PUT /api/manager/incident-types/{key}
+// Body: any of label, description ("" clears it), severity, zone_score, zone_radius_meters, active
+func UpdateIncidentType(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		key := chi.URLParam(r, "key")
+
+		var req models.IncidentTypeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if err := req.Normalize(false); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		incidentType, err := lookupIncidentType(r.Context(), db, key, true)
+		if err != nil {
+			log.Printf("❌ [INCIDENT-TYPES] %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch incident type")
+			return
+		}
+		if incidentType == nil {
+			utils.RespondError(w, http.StatusNotFound, "Incident type not found")
+			return
+		}
+
+		if req.Label != nil {
+			incidentType.Label = *req.Label
+		}
+		if req.Description != nil {
+			incidentType.Description = req.Description
+			if *req.Description == "" {
+				incidentType.Description = nil
+			}
+		}
+		if req.Severity != nil {
+			incidentType.Severity = *req.Severity
+		}
+		if req.ZoneScore != nil {
+			incidentType.ZoneScore = *req.ZoneScore
+		}
+		if req.ZoneRadiusMeters != nil {
+			incidentType.ZoneRadiusMeters = *req.ZoneRadiusMeters
+		}
+		if req.Active != nil {
+			incidentType.Active = *req.Active
+		}
+		incidentType.UpdatedAt = time.Now().Unix()
+
+		_, err = db.NamedExecContext(r.Context(), `
+			UPDATE incident_types
+			SET label = :label, description = :description, severity = :severity, zone_score = :zone_score,
+				zone_radius_meters = :zone_radius_meters, active = :active, updated_at = :updated_at
+			WHERE key = :key
+		`, incidentType)
+		if err != nil {
+			log.Printf("❌ [INCIDENT-TYPES] Failed to update incident type %s: %v", key, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to update incident type")
+			return
+		}
+
+		log.Printf("✅ [INCIDENT-TYPES] %s updated incident type %s (%s, score %d, active %t)",
+			userClaims.Email, incidentType.Key, incidentType.Severity, incidentType.ZoneScore, incidentType.Active)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    incidentType,
+		})
+	}
+}
+
+// DeleteIncidentType deletes an incident type no incident has (archive used types with active: false instead)
+// DELETE /api/manager/incident-types/{key}
+func DeleteIncidentType(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := chi.URLParam(r, "key")
+
+		result, err := db.ExecContext(r.Context(), `DELETE FROM incident_types WHERE key = $1`, key)
+		if err != nil {
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+				utils.RespondError(w, http.StatusConflict, "Incidents have this type; archive it (active: false) instead")
+				return
+			}
+			log.Printf("❌ [INCIDENT-TYPES] Failed to delete incident type %s: %v", key, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to delete incident type")
+			return
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			utils.RespondError(w, http.StatusNotFound, "Incident type not found")
+			return
+		}
+
+		log.Printf("🗑️  [INCIDENT-TYPES] Deleted incident type %s", key)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+		})
+	}
+}
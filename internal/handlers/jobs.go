@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// GetJobs lists background jobs, most recently updated first (dead-lettered ones unless status says otherwise)
+// GET /api/manager/jobs?status=dead|pending|running|succeeded|failing&kind=...&limit=100
+// failing lists pending jobs whose last attempt failed (waiting out their backoff)
+func GetJobs(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		status := q.Get("status")
+		if status == "" {
+			status = models.JobStatusDead
+		}
+		failing := status == "failing"
+		if failing {
+			status = models.JobStatusPending
+		} else if !containsString(models.JobStatuses, status) {
+			utils.RespondError(w, http.StatusBadRequest, "status must be pending, running, succeeded, dead or failing")
+			return
+		}
+		limit := 100
+		if parsed, err := strconv.Atoi(q.Get("limit")); err == nil && parsed > 0 && parsed <= 500 {
+			limit = parsed
+		}
+
+		jobs := []models.Job{}
+		err := db.SelectContext(r.Context(), &jobs, `
+			SELECT * FROM jobs
+			WHERE status = $1 AND ($2 = '' OR kind = $2) AND (NOT $3 OR attempts > 0)
+			ORDER BY updated_at DESC
+			LIMIT $4
+		`, status, q.Get("kind"), failing, limit)
+		if err != nil {
+			log.Printf("❌ [JOBS] Failed to fetch jobs: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch jobs")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    jobs,
+		})
+	}
+}
+
+// GetJobStats counts the background jobs of each kind by status
+// GET /api/manager/jobs/stats
+func GetJobStats(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stats := []models.JobKindStats{}
+		err := db.SelectContext(r.Context(), &stats, `
+			SELECT kind,
+			       COUNT(*) FILTER (WHERE status = 'pending') AS pending,
+			       COUNT(*) FILTER (WHERE status = 'running') AS running,
+			       COUNT(*) FILTER (WHERE status = 'succeeded') AS succeeded,
+			       COUNT(*) FILTER (WHERE status = 'dead') AS dead,
+			       COUNT(*) FILTER (WHERE status = 'pending' AND attempts > 0) AS retrying,
+			       MIN(run_at) FILTER (WHERE status = 'pending') AS oldest_due_at,
+			       MAX(finished_at) FILTER (WHERE status = 'succeeded') AS last_succeeded_at
+			FROM jobs
+			GROUP BY kind
+			ORDER BY kind
+		`)
+		if err != nil {
+			log.Printf("❌ [JOBS] Failed to count jobs: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch job stats")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    stats,
+		})
+	}
+}
+
+// RetryJob puts a dead-lettered job back in the queue with a fresh set of attempts, or runs a job waiting
+// out its backoff now; last_error is kept until the next failure
+// POST /api/manager/jobs/{id}/retry
+func RetryJob(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		jobID := chi.URLParam(r, "id")
+
+		now := time.Now().Unix()
+		var job models.Job
+		err := db.GetContext(r.Context(), &job, `
+			UPDATE jobs SET status = $1, attempts = 0, run_at = $2, finished_at = NULL, updated_at = $2
+			WHERE id = $3 AND status IN ($1, $4)
+			RETURNING *
+		`, models.JobStatusPending, now, jobID, models.JobStatusDead)
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			utils.RespondErrorCode(w, http.StatusConflict, utils.CodeConflict, "Another run of this job is already queued", nil)
+			return
+		}
+		if err == sql.ErrNoRows {
+			var status string
+			err = db.GetContext(r.Context(), &status, `SELECT status FROM jobs WHERE id = $1`, jobID)
+			if err == sql.ErrNoRows {
+				utils.RespondError(w, http.StatusNotFound, "Job not found")
+				return
+			}
+			if err == nil {
+				utils.RespondErrorCode(w, http.StatusConflict, utils.CodeConflict, "Only dead or pending jobs can be retried", status)
+				return
+			}
+		}
+		if err != nil {
+			log.Printf("❌ [JOBS] Failed to retry job %s: %v", jobID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to retry job")
+			return
+		}
+
+		log.Printf("🔁 [JOBS] %s retried %s job %s", userClaims.Email, job.Kind, job.ID)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    job,
+		})
+	}
+}
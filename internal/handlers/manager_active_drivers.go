@@ -3,10 +3,15 @@ package handlers
 import (
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"time"
+
+	"ropacal-backend/pkg/utils"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 // DriverLocation represents the driver's current GPS location
@@ -22,6 +27,9 @@ type ActiveDriverResponse struct {
 	ShiftID         string          `json:"shift_id"`
 	RouteID         *string         `json:"route_id"`
 	Status          string          `json:"status"`
+	PausedAt        *int64          `json:"paused_at,omitempty"`    // Set while paused
+	PauseReason     *string         `json:"pause_reason,omitempty"` // nil for pauses recorded without a reason
+	PauseNote       *string         `json:"pause_note,omitempty"`
 	StartTime       *int64          `json:"start_time"`
 	TotalBins       int             `json:"total_bins"`
 	CompletedBins   int             `json:"completed_bins"`
@@ -42,9 +50,11 @@ type AllDriverResponse struct {
 	CompletedBins   int             `json:"completed_bins"`
 	CurrentLocation *DriverLocation `json:"current_location,omitempty"`
 	UpdatedAt       *int64          `json:"updated_at,omitempty"`
+	DeactivatedAt   *int64          `json:"deactivated_at,omitempty"` // Only listed with ?include_deactivated=true
 }
 
 // GetActiveDrivers returns all drivers with active shifts (ready, active, or paused)
+// Managers assigned to areas get the drivers working in their areas unless all_areas=true
 func GetActiveDrivers(db *sqlx.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		log.Println("📋 GetActiveDrivers: Fetching all active drivers...")
@@ -61,9 +71,13 @@ func GetActiveDrivers(db *sqlx.DB) http.HandlerFunc {
 				s.completed_bins,
 				s.updated_at,
 				dl.latitude,
-				dl.longitude
+				dl.longitude,
+				sp.paused_at,
+				sp.reason,
+				sp.note
 			FROM shifts s
 			INNER JOIN users u ON s.driver_id = u.id
+			LEFT JOIN shift_pauses sp ON sp.shift_id = s.id AND sp.resumed_at IS NULL AND s.status = 'paused'
 			LEFT JOIN (
 				-- Get the most recent location for each driver
 				SELECT DISTINCT ON (driver_id)
@@ -72,18 +86,22 @@ func GetActiveDrivers(db *sqlx.DB) http.HandlerFunc {
 				ORDER BY driver_id, timestamp DESC
 			) dl ON s.driver_id = dl.driver_id
 			WHERE s.status IN ('ready', 'active', 'paused')
+			  AND ($1::TEXT[] IS NULL OR ` + fmt.Sprintf(driverInAreaScopeSQL, 1, 2) + `)
 			ORDER BY s.updated_at DESC
 		`
 
-		rows, err := db.Query(query)
+		// Managers assigned to areas only see drivers working there (unless all_areas=true)
+		scope, err := managerAreaScope(r, db)
+		if err != nil {
+			log.Printf("❌ Error loading area scope: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch active drivers")
+			return
+		}
+
+		rows, err := db.QueryContext(r.Context(), query, pq.Array(scope), time.Now().AddDate(0, 0, -areaScopeDriverDays).Unix())
 		if err != nil {
 			log.Printf("❌ Database error: %v", err)
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]interface{}{
-				"success": false,
-				"error":   "Failed to fetch active drivers",
-			})
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch active drivers")
 			return
 		}
 		defer rows.Close()
@@ -106,6 +124,9 @@ func GetActiveDrivers(db *sqlx.DB) http.HandlerFunc {
 				&driver.UpdatedAt,
 				&latitude,
 				&longitude,
+				&driver.PausedAt,
+				&driver.PauseReason,
+				&driver.PauseNote,
 			)
 			if err != nil {
 				log.Printf("❌ Row scan error: %v", err)
@@ -125,12 +146,7 @@ func GetActiveDrivers(db *sqlx.DB) http.HandlerFunc {
 
 		if err = rows.Err(); err != nil {
 			log.Printf("❌ Rows iteration error: %v", err)
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]interface{}{
-				"success": false,
-				"error":   "Failed to process active drivers",
-			})
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to process active drivers")
 			return
 		}
 
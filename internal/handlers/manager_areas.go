@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/websocket"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// GetManagerAreas lists the managers (active admins) with the areas they're assigned to
+// GET /api/manager/managers
+func GetManagerAreas(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		managers := []models.ManagerAreas{}
+		err := db.SelectContext(r.Context(), &managers, `
+			SELECT id AS user_id, email, name
+			FROM users
+			WHERE role = 'admin' AND deactivated_at IS NULL
+			ORDER BY LOWER(name), email
+		`)
+		if err != nil {
+			log.Printf("❌ [MANAGER-AREAS] Failed to fetch managers: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch managers")
+			return
+		}
+
+		var assignments []struct {
+			UserID string `db:"user_id"`
+			AreaID string `db:"area_id"`
+		}
+		if err := db.SelectContext(r.Context(), &assignments, `SELECT user_id, area_id FROM manager_areas ORDER BY area_id`); err != nil {
+			log.Printf("❌ [MANAGER-AREAS] Failed to fetch manager areas: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch managers")
+			return
+		}
+		areasByUser := make(map[string][]string)
+		for _, assignment := range assignments {
+			areasByUser[assignment.UserID] = append(areasByUser[assignment.UserID], assignment.AreaID)
+		}
+		for i := range managers {
+			managers[i].AreaIDs = areasByUser[managers[i].UserID]
+			if managers[i].AreaIDs == nil {
+				managers[i].AreaIDs = []string{}
+			}
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    managers,
+		})
+	}
+}
+
+// SetManagerAreas replaces a manager's area assignments and rescopes their open WebSocket connection
+// PUT /api/manager/managers/{id}/areas
+// Body: { "area_ids": ["...", "..."] } (empty = every area)
+func SetManagerAreas(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		managerID := chi.URLParam(r, "id")
+
+		var req models.ManagerAreasRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		areaIDs := uniqueStrings(req.AreaIDs)
+
+		var manager models.ManagerAreas
+		var role string
+		err := db.QueryRowxContext(r.Context(), `SELECT id, email, name, role FROM users WHERE id = $1`, managerID).
+			Scan(&manager.UserID, &manager.Email, &manager.Name, &role)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [MANAGER-AREAS] Failed to look up user %s: %v", managerID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to update manager areas")
+			return
+		}
+		if role != "admin" {
+			utils.RespondError(w, http.StatusBadRequest, "Only managers (admins) can be assigned to areas")
+			return
+		}
+
+		var found []string
+		if err := db.SelectContext(r.Context(), &found, `SELECT id FROM areas WHERE id = ANY($1)`, pq.Array(areaIDs)); err != nil {
+			log.Printf("❌ [MANAGER-AREAS] Failed to look up areas: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to update manager areas")
+			return
+		}
+		if len(found) < len(areaIDs) {
+			exists := make(map[string]bool, len(found))
+			for _, id := range found {
+				exists[id] = true
+			}
+			var missing []string
+			for _, id := range areaIDs {
+				if !exists[id] {
+					missing = append(missing, id)
+				}
+			}
+			utils.RespondErrorCode(w, http.StatusBadRequest, utils.CodeInvalidReference, "Unknown area_ids", missing)
+			return
+		}
+
+		err = database.WithTx(r.Context(), db, func(tx *sqlx.Tx) error {
+			if _, err := tx.ExecContext(r.Context(), `DELETE FROM manager_areas WHERE user_id = $1 AND NOT (area_id = ANY($2))`, managerID, pq.Array(areaIDs)); err != nil {
+				log.Printf("❌ [MANAGER-AREAS] Failed to remove areas of %s: %v", managerID, err)
+				return txFail(http.StatusInternalServerError, "Failed to update manager areas")
+			}
+			if _, err := tx.ExecContext(r.Context(), `
+				INSERT INTO manager_areas (user_id, area_id, assigned_by_user_id, created_at)
+				SELECT $1, area_id, $3, $4 FROM UNNEST($2::TEXT[]) AS area_id
+				ON CONFLICT DO NOTHING
+			`, managerID, pq.Array(areaIDs), userClaims.UserID, time.Now().Unix()); err != nil {
+				log.Printf("❌ [MANAGER-AREAS] Failed to assign areas to %s: %v", managerID, err)
+				return txFail(http.StatusInternalServerError, "Failed to update manager areas")
+			}
+			return nil
+		})
+		if err != nil {
+			respondTxError(w, err, "Failed to commit transaction")
+			return
+		}
+
+		manager.AreaIDs = areaIDs
+		hub.SetUserAreaScope(managerID, areaIDs)
+
+		log.Printf("✅ [MANAGER-AREAS] %s assigned %s to %d areas", userClaims.Email, manager.Email, len(areaIDs))
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    manager,
+		})
+	}
+}
@@ -8,6 +8,8 @@ import (
 
 	"github.com/jmoiron/sqlx"
 	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/store"
+	"ropacal-backend/pkg/utils"
 )
 
 // DriverShiftDetailResponse represents detailed shift information for a specific driver
@@ -25,6 +27,7 @@ type DriverShiftDetailResponse struct {
 	CreatedAt         int64                        `json:"created_at"`
 	UpdatedAt         int64                        `json:"updated_at"`
 	Bins              []models.ShiftBinWithDetails `json:"bins"`
+	Pauses            models.ShiftPauseSummary     `json:"pauses"`
 }
 
 // GetDriverShiftDetails returns detailed shift information for a specific driver (manager view)
@@ -32,12 +35,7 @@ func GetDriverShiftDetails(db *sqlx.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		driverID := r.URL.Query().Get("driver_id")
 		if driverID == "" {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(map[string]interface{}{
-				"success": false,
-				"error":   "driver_id is required",
-			})
+			utils.RespondError(w, http.StatusBadRequest, "driver_id is required")
 			return
 		}
 
@@ -67,7 +65,7 @@ func GetDriverShiftDetails(db *sqlx.DB) http.HandlerFunc {
 	`
 
 		var detail DriverShiftDetailResponse
-		err := db.QueryRow(shiftQuery, driverID).Scan(
+		err := db.QueryRowContext(r.Context(), shiftQuery, driverID).Scan(
 			&detail.ShiftID,
 			&detail.DriverID,
 			&detail.DriverName,
@@ -84,92 +82,31 @@ func GetDriverShiftDetails(db *sqlx.DB) http.HandlerFunc {
 
 		if err == sql.ErrNoRows {
 			log.Printf("⚠️  No active shift found for driver: %s", driverID)
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusNotFound)
-			json.NewEncoder(w).Encode(map[string]interface{}{
-				"success": false,
-				"error":   "No active shift found for this driver",
-			})
+			utils.RespondError(w, http.StatusNotFound, "No active shift found for this driver")
 			return
 		}
 
 		if err != nil {
 			log.Printf("❌ Database error: %v", err)
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]interface{}{
-				"success": false,
-				"error":   "Failed to fetch shift details",
-			})
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch shift details")
 			return
 		}
 
-		// Now get the bins for this shift
-		binsQuery := `
-		SELECT
-			rb.id,
-			rb.shift_id,
-			rb.bin_id,
-			rb.sequence_order,
-			rb.is_completed,
-			rb.completed_at,
-			rb.updated_fill_percentage,
-			rb.created_at,
-			b.bin_number,
-			b.current_street,
-			b.city,
-			b.zip,
-			COALESCE(b.fill_percentage, 0) as fill_percentage,
-			b.latitude,
-			b.longitude
-		FROM shift_bins rb
-		INNER JOIN bins b ON rb.bin_id = b.id
-		WHERE rb.shift_id = $1
-		ORDER BY rb.sequence_order ASC
-	`
-
-		rows, err := db.Query(binsQuery, detail.ShiftID)
+		// Now get the stops for this shift
+		bins, err := store.NewShiftStore(db).Stops(detail.ShiftID)
 		if err != nil {
 			log.Printf("❌ Error fetching bins: %v", err)
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]interface{}{
-				"success": false,
-				"error":   "Failed to fetch bins",
-			})
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch bins")
 			return
 		}
-		defer rows.Close()
-
-		var bins []models.ShiftBinWithDetails
-		for rows.Next() {
-			var bin models.ShiftBinWithDetails
-			err := rows.Scan(
-				&bin.ID,
-				&bin.ShiftID,
-				&bin.BinID,
-				&bin.SequenceOrder,
-				&bin.IsCompleted,
-				&bin.CompletedAt,
-				&bin.UpdatedFillPercentage,
-				&bin.CreatedAt,
-				&bin.BinNumber,
-				&bin.CurrentStreet,
-				&bin.City,
-				&bin.Zip,
-				&bin.FillPercentage,
-				&bin.Latitude,
-				&bin.Longitude,
-			)
-			if err != nil {
-				log.Printf("❌ Error scanning bin: %v", err)
-				continue
-			}
-			bins = append(bins, bin)
-		}
 
 		detail.Bins = bins
 
+		detail.Pauses, err = loadShiftPauses(r.Context(), db, detail.ShiftID)
+		if err != nil {
+			log.Printf("❌ Error fetching pauses: %v", err)
+		}
+
 		log.Printf("✅ Found shift with %d bins for driver: %s", len(bins), detail.DriverName)
 
 		w.Header().Set("Content-Type", "application/json")
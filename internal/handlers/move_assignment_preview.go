@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/store"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// MoveAssignmentPreview is the proposed route after inserting a move request, computed without saving
+type MoveAssignmentPreview struct {
+	ShiftID             string             `json:"shift_id"`
+	DriverID            string             `json:"driver_id"`
+	ShiftStatus         models.ShiftStatus `json:"shift_status"`
+	InsertSequenceOrder int                `json:"insert_sequence_order"`
+	ProposedRoute       []PreviewRouteStop `json:"proposed_route"`
+	CurrentDistanceKm   float64            `json:"current_distance_km"`  // Remaining distance before the insert
+	ProposedDistanceKm  float64            `json:"proposed_distance_km"` // Remaining distance after the insert
+	AddedDistanceKm     float64            `json:"added_distance_km"`
+	CurrentFinishETA    int64              `json:"current_finish_eta"` // Unix timestamp of the last remaining stop
+	ProposedFinishETA   int64              `json:"proposed_finish_eta"`
+	ETADelaySeconds     int64              `json:"eta_delay_seconds"`
+
+	// When the proposed route would be done, against the close of service hours (nil when disabled)
+	ServiceHours *models.ServiceHoursCheck `json:"service_hours,omitempty"`
+}
+
+// PreviewRouteStop is a stop in the proposed route
+type PreviewRouteStop struct {
+	SequenceOrder int     `json:"sequence_order"`
+	BinID         string  `json:"bin_id"`
+	BinNumber     int     `json:"bin_number"`
+	CurrentStreet string  `json:"current_street"`
+	StopType      string  `json:"stop_type"`
+	MoveRequestID *string `json:"move_request_id,omitempty"`
+	Latitude      float64 `json:"latitude"`
+	Longitude     float64 `json:"longitude"`
+	IsCompleted   bool    `json:"is_completed"`
+	IsNew         bool    `json:"is_new"`                // Added by this assignment
+	DistanceKm    float64 `json:"distance_km,omitempty"` // Distance from the previous stop
+	ETA           *int64  `json:"eta,omitempty"`         // Remaining stops only
+}
+
+// loadPreviewStops loads a shift's stops as seen through q (the committed route, or the uncommitted one in a tx)
+func loadPreviewStops(q sqlx.Ext, shiftID string) ([]models.ShiftBinWithDetails, error) {
+	return store.NewShiftStore(q).Stops(shiftID)
+}
+
+// previewStopLocation returns where the driver goes for a stop
+func previewStopLocation(stop models.ShiftBinWithDetails) (float64, float64) {
+	if stop.StopType == "dropoff" && stop.NewLatitude != nil && stop.NewLongitude != nil {
+		return *stop.NewLatitude, *stop.NewLongitude
+	}
+	return stop.Latitude, stop.Longitude
+}
+
+// previewDriverPosition returns the driver's live position for the shift, if known
+func previewDriverPosition(q sqlx.Queryer, shift models.Shift) (lat, lng float64, ok bool) {
+	var location struct {
+		Latitude  float64 `db:"latitude"`
+		Longitude float64 `db:"longitude"`
+	}
+	err := sqlx.Get(q, &location, `
+		SELECT latitude, longitude FROM driver_current_location
+		WHERE driver_id = $1 AND shift_id = $2
+	`, shift.DriverID, shift.ID)
+	if err != nil {
+		return 0, 0, false
+	}
+	return location.Latitude, location.Longitude, true
+}
+
+// walkPreviewRoute estimates distance and ETAs for the remaining stops using the same
+// straight-line model as manual reorders (etaAverageSpeedKmh + etaServiceTimeSeconds)
+// Without a live position the walk starts from the last completed stop
+func walkPreviewRoute(stops []models.ShiftBinWithDetails, liveLat, liveLng float64, hasLive bool, now int64) (route []PreviewRouteStop, totalKm float64, finishETA int64) {
+	lat, lng, hasPosition := liveLat, liveLng, hasLive
+	clock := now
+	finishETA = now
+
+	for _, stop := range stops {
+		stopLat, stopLng := previewStopLocation(stop)
+		routeStop := PreviewRouteStop{
+			SequenceOrder: stop.SequenceOrder,
+			BinID:         stop.BinID,
+			BinNumber:     stop.BinNumber,
+			CurrentStreet: stop.CurrentStreet,
+			StopType:      stop.StopType,
+			MoveRequestID: stop.MoveRequestID,
+			Latitude:      stopLat,
+			Longitude:     stopLng,
+			IsCompleted:   stop.IsCompleted == 1,
+		}
+
+		if stop.IsCompleted == 1 {
+			if !hasLive {
+				lat, lng, hasPosition = stopLat, stopLng, true
+			}
+			route = append(route, routeStop)
+			continue
+		}
+
+		distance := 0.0
+		if hasPosition {
+			distance = haversineDistanceKm(lat, lng, stopLat, stopLng)
+		}
+		clock += int64(distance / etaAverageSpeedKmh * 3600)
+		eta := clock
+
+		routeStop.DistanceKm = distance
+		routeStop.ETA = &eta
+		route = append(route, routeStop)
+
+		totalKm += distance
+		finishETA = clock
+		clock += etaServiceTimeSeconds
+		lat, lng, hasPosition = stopLat, stopLng, true
+	}
+
+	return route, totalKm, finishETA
+}
+
+// buildMoveAssignmentPreview compares the route before the insert with the uncommitted route in tx
+func buildMoveAssignmentPreview(tx *sqlx.Tx, shift models.Shift, currentStops []models.ShiftBinWithDetails, insertSequenceOrder int, now int64) (*MoveAssignmentPreview, error) {
+	proposedStops, err := loadPreviewStops(tx, shift.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	liveLat, liveLng, hasLive := previewDriverPosition(tx, shift)
+	_, currentKm, currentFinish := walkPreviewRoute(currentStops, liveLat, liveLng, hasLive, now)
+	proposedRoute, proposedKm, proposedFinish := walkPreviewRoute(proposedStops, liveLat, liveLng, hasLive, now)
+
+	existing := make(map[string]bool, len(currentStops))
+	for _, stop := range currentStops {
+		if stop.TaskID != nil {
+			existing[*stop.TaskID] = true
+		}
+	}
+	for i, stop := range proposedStops {
+		proposedRoute[i].IsNew = stop.TaskID != nil && !existing[*stop.TaskID]
+	}
+
+	return &MoveAssignmentPreview{
+		ShiftID:             shift.ID,
+		DriverID:            shift.DriverID,
+		ShiftStatus:         shift.Status,
+		InsertSequenceOrder: insertSequenceOrder,
+		ProposedRoute:       proposedRoute,
+		CurrentDistanceKm:   currentKm,
+		ProposedDistanceKm:  proposedKm,
+		AddedDistanceKm:     proposedKm - currentKm,
+		CurrentFinishETA:    currentFinish,
+		ProposedFinishETA:   proposedFinish,
+		ETADelaySeconds:     proposedFinish - currentFinish,
+	}, nil
+}
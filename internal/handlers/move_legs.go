@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/helpers"
+	"ropacal-backend/internal/i18n"
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/store"
+	"ropacal-backend/internal/websocket"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+)
+
+// ConfirmMovePickup confirms the pickup leg of a move on the driver's active shift
+// Relocations become picked_up (the bin is on the truck); single-leg moves complete here
+// POST /api/driver/moves/{id}/confirm-pickup
+func ConfirmMovePickup(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
+	return confirmMoveLeg(db, hub, models.TaskTypePickup)
+}
+
+// ConfirmMoveDropoff confirms the dropoff leg of a picked-up relocation, completing the move and relocating the bin
+// POST /api/driver/moves/{id}/confirm-dropoff
+func ConfirmMoveDropoff(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
+	return confirmMoveLeg(db, hub, models.TaskTypeDropoff)
+}
+
+// confirmMoveLeg completes the move's pickup or dropoff waypoint with its photo/signature and advances the move status
+func confirmMoveLeg(db *sqlx.DB, hub *websocket.Hub, leg models.TaskType) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		moveID := chi.URLParam(r, "id")
+		log.Printf("📥 REQUEST: POST /api/driver/moves/%s/confirm-%s", moveID, leg)
+
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, i18n.Tr(r, "Unauthorized"))
+			return
+		}
+
+		var req models.ConfirmMoveLegRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, i18n.Tr(r, "Invalid request body"))
+			return
+		}
+		req.PhotoURL = trimmedOrNil(req.PhotoURL)
+		req.SignatureURL = trimmedOrNil(req.SignatureURL)
+		if req.PhotoURL == nil && req.SignatureURL == nil {
+			utils.RespondError(w, http.StatusBadRequest, i18n.Tr(r, "A photo or signature is required"))
+			return
+		}
+
+		var shift models.Shift
+		err := db.GetContext(r.Context(), &shift, `SELECT * FROM shifts WHERE driver_id = $1 AND status = 'active' ORDER BY created_at DESC LIMIT 1`, userClaims.UserID)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, i18n.Tr(r, "No active shift"))
+			return
+		}
+
+		var moveRequest models.BinMoveRequest
+		var taskID string
+		var legStatus string
+		now := time.Now().Unix()
+
+		err = database.WithTx(r.Context(), db, func(tx *sqlx.Tx) error {
+			err := tx.GetContext(r.Context(), &moveRequest, `SELECT * FROM bin_move_requests WHERE id = $1 FOR UPDATE`, moveID)
+			if err == sql.ErrNoRows {
+				return txFail(http.StatusNotFound, i18n.Tr(r, "Move request not found"))
+			}
+			if err != nil {
+				log.Printf("❌ [MOVE] Failed to fetch move request %s: %v", moveID, err)
+				return txFail(http.StatusInternalServerError, i18n.Tr(r, "Failed to confirm move"))
+			}
+			if moveRequest.AssignedShiftID == nil || *moveRequest.AssignedShiftID != shift.ID {
+				return txFail(http.StatusForbidden, i18n.Tr(r, "This move is not on your active shift"))
+			}
+
+			twoLeg := moveRequest.MoveType == "relocation"
+			switch {
+			case moveRequest.Status == models.MoveStatusCompleted || moveRequest.Status == models.MoveStatusCancelled:
+				return txFail(http.StatusConflict, i18n.Tr(r, "Move request is already %s", i18n.Tr(r, moveRequest.Status)))
+			case leg == models.TaskTypePickup && moveRequest.Status == models.MoveStatusPickedUp:
+				return txFail(http.StatusConflict, i18n.Tr(r, "Pickup already confirmed"))
+			case leg == models.TaskTypeDropoff && !twoLeg:
+				return txFail(http.StatusBadRequest, i18n.Tr(r, "This move has no dropoff"))
+			case leg == models.TaskTypeDropoff && moveRequest.Status != models.MoveStatusPickedUp:
+				return txFail(http.StatusConflict, i18n.Tr(r, "Confirm the pickup before the dropoff"))
+			}
+
+			// The pickup of a relocation leaves the bin picked up; the last leg completes the move
+			legStatus = models.MoveStatusCompleted
+			if leg == models.TaskTypePickup && twoLeg {
+				legStatus = models.MoveStatusPickedUp
+			}
+			if err := txMoveStatusTransition(moveRequest.Status, legStatus); err != nil {
+				return err
+			}
+
+			// Complete this leg's waypoint, keeping its proof on the stop
+			err = tx.GetContext(r.Context(), &taskID, `
+				UPDATE route_tasks
+				SET is_completed = 1, completed_at = $1, photo_url = $2, signature_url = $3, updated_at = $1
+				WHERE id = (
+					SELECT id FROM route_tasks
+					WHERE shift_id = $4 AND move_request_id = $5 AND task_type = $6 AND is_completed = 0
+					ORDER BY sequence_order ASC
+					LIMIT 1
+				)
+				RETURNING id
+			`, now, req.PhotoURL, req.SignatureURL, shift.ID, moveRequest.ID, string(leg))
+			if err == sql.ErrNoRows {
+				return txFail(http.StatusConflict, i18n.Tr(r, "No incomplete %s stop found for this move", i18n.Tr(r, string(leg))))
+			}
+			if err != nil {
+				log.Printf("❌ [MOVE] Failed to complete %s waypoint of move %s: %v", leg, moveRequest.ID, err)
+				return txFail(http.StatusInternalServerError, i18n.Tr(r, "Failed to complete task"))
+			}
+
+			_, err = tx.ExecContext(r.Context(), `UPDATE shifts SET completed_bins = completed_bins + 1, updated_at = $1 WHERE id = $2`, now, shift.ID)
+			if err != nil {
+				log.Printf("❌ [MOVE] Failed to update shift %s: %v", shift.ID, err)
+				return txFail(http.StatusInternalServerError, i18n.Tr(r, "Failed to confirm move"))
+			}
+
+			if legStatus == models.MoveStatusPickedUp {
+				if _, err := markMovePickedUp(r.Context(), tx, moveRequest.ID, now); err != nil {
+					log.Printf("❌ [MOVE] %v", err)
+					return txFail(http.StatusInternalServerError, i18n.Tr(r, "Failed to confirm move"))
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			respondTxError(w, err, i18n.Tr(r, "Failed to confirm move"))
+			return
+		}
+
+		pickedUp := legStatus == models.MoveStatusPickedUp
+		if pickedUp {
+			notifyMovePickedUp(db, hub, moveRequest, userClaims.UserID, now)
+		} else {
+			// Only the final leg moves the bin (relocated, retired or stored)
+			stop := completeStopRequest{BinID: moveRequest.BinID, PhotoUrl: req.PhotoURL, MoveRequestID: &moveRequest.ID}
+			if err := handleMoveRequestCompletion(db, hub, moveRequest, stop, now); err != nil {
+				log.Printf("❌ [MOVE] Error finalizing move request %s: %v", moveRequest.ID, err)
+			}
+		}
+		log.Printf("✅ [MOVE] %s confirmed for move %s by %s → %s", leg, moveRequest.ID, userClaims.Email, legStatus)
+
+		// Refresh the driver's route like any other stop completion
+		bins, err := getRouteBinsWithDetails(db, shift.ID)
+		if err != nil {
+			log.Printf("❌ Error fetching route bins: %v", err)
+			bins = []models.ShiftBinWithDetails{}
+		}
+		logicalTotal, logicalCompleted := calculateLogicalBinCounts(bins)
+		hub.BroadcastToUser(userClaims.UserID, map[string]interface{}{
+			"type": "shift_update",
+			"data": map[string]interface{}{
+				"id":             shift.ID,
+				"status":         shift.Status,
+				"completed_bins": logicalCompleted,
+				"total_bins":     logicalTotal,
+				"bins":           bins,
+			},
+		})
+
+		completionPercentage := 0.0
+		if logicalTotal > 0 {
+			completionPercentage = float64(logicalCompleted) / float64(logicalTotal) * 100
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data": models.MoveLegConfirmation{
+				MoveRequestID:        moveRequest.ID,
+				TaskID:               taskID,
+				Leg:                  leg,
+				Status:               legStatus,
+				CompletedBins:        logicalCompleted,
+				TotalBins:            logicalTotal,
+				CompletionPercentage: completionPercentage,
+			},
+		})
+	}
+}
+
+// markMovePickedUp moves an assigned or in-progress relocation to picked_up
+// Returns false when the move had already left those statuses
+func markMovePickedUp(ctx context.Context, db sqlx.ExecerContext, moveRequestID string, now int64) (bool, error) {
+	result, err := db.ExecContext(ctx, `
+		UPDATE bin_move_requests
+		SET status = 'picked_up', picked_up_at = $1, updated_at = $1
+		WHERE id = $2 AND status IN ('assigned', 'in_progress')
+	`, now, moveRequestID)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark move request %s picked up: %w", moveRequestID, err)
+	}
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+// notifyMovePickedUp records the pickup in the move history and tells dashboards the bin is on the truck
+func notifyMovePickedUp(db *sqlx.DB, hub *websocket.Hub, moveRequest models.BinMoveRequest, driverID string, now int64) {
+	driverName, err := store.New(db).Users.Name(driverID)
+	if err != nil {
+		log.Printf("Warning: Failed to fetch driver name for history: %v", err)
+		driverName = "Unknown Driver"
+	}
+	notes := "Bin picked up by " + driverName + " (awaiting dropoff)"
+	if err := helpers.LogMoveRequestUpdated(db, moveRequest.ID, driverID, driverName, &notes, nil); err != nil {
+		log.Printf("Warning: Failed to log move request pickup: %v", err)
+	}
+
+	message := map[string]interface{}{
+		"type": "move_request_status_updated",
+		"data": map[string]interface{}{
+			"move_request_id": moveRequest.ID,
+			"bin_id":          moveRequest.BinID,
+			"new_status":      "picked_up",
+			"picked_up_at":    now,
+		},
+	}
+	hub.BroadcastToRole("admin", message)
+	hub.BroadcastToRole("manager", message)
+	log.Printf("📡 Broadcast move_request_status_updated to managers: Move request %s → picked_up", moveRequest.ID)
+}
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/helpers"
+	"ropacal-backend/internal/i18n"
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/store"
+	"ropacal-backend/internal/websocket"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+)
+
+// ApproveMoveRequest approves a move requested by a non-admin; it becomes a normal pending move
+// PUT /api/manager/bins/move-requests/{id}/approve
+// Body (optional): { "reason": "..." }
+func ApproveMoveRequest(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
+	return decideMoveRequest(db, wsHub, models.MoveApprovalApproved)
+}
+
+// RejectMoveRequest rejects a move requested by a non-admin; the bin is left as it is
+// PUT /api/manager/bins/move-requests/{id}/reject
+// Body: { "reason": "..." } (required, shown to the requester)
+func RejectMoveRequest(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
+	return decideMoveRequest(db, wsHub, models.MoveApprovalRejected)
+}
+
+// decideMoveRequest records an admin's decision on a requested move and notifies the requester
+func decideMoveRequest(db *sqlx.DB, wsHub *websocket.Hub, decision string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "User not authenticated")
+			return
+		}
+
+		var req models.MoveApprovalDecisionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		var reason *string
+		if req.Reason != nil && strings.TrimSpace(*req.Reason) != "" {
+			trimmed := strings.TrimSpace(*req.Reason)
+			reason = &trimmed
+		}
+		if decision == models.MoveApprovalRejected && reason == nil {
+			utils.RespondError(w, http.StatusBadRequest, "reason is required to reject a move request")
+			return
+		}
+
+		newStatus := models.MoveStatusPending
+		if decision == models.MoveApprovalRejected {
+			newStatus = models.MoveStatusRejected
+		}
+
+		var moveRequest models.BinMoveRequest
+		var response models.BinMoveRequestResponse
+		var deciderName string
+
+		// The decision, the bin, history and the requester's notifications commit together
+		err := database.WithTx(r.Context(), db, func(tx *sqlx.Tx) error {
+			err := tx.Get(&moveRequest, `SELECT * FROM bin_move_requests WHERE id = $1 FOR UPDATE`, id)
+			if err == sql.ErrNoRows {
+				return txFail(http.StatusNotFound, "Move request not found")
+			}
+			if err != nil {
+				log.Printf("❌ [MOVE-APPROVAL] Failed to fetch move request %s: %v", id, err)
+				return txFail(http.StatusInternalServerError, "Failed to fetch move request")
+			}
+			if moveRequest.Status != models.MoveStatusRequested {
+				return txFailCode(http.StatusConflict, utils.CodeMoveAwaitingApproval,
+					fmt.Sprintf("Move request is not awaiting approval (status: %s)", moveRequest.Status), nil)
+			}
+			if err := txMoveStatusTransition(moveRequest.Status, newStatus); err != nil {
+				return err
+			}
+
+			now := time.Now().Unix()
+			err = tx.Get(&moveRequest, `
+				UPDATE bin_move_requests
+				SET status = $1, approval_status = $2, approval_decided_by = $3, approval_decided_at = $4,
+				    approval_reason = $5, updated_at = $4
+				WHERE id = $6
+				RETURNING *
+			`, newStatus, decision, userClaims.UserID, now, reason, id)
+			if err != nil {
+				log.Printf("❌ [MOVE-APPROVAL] Failed to update move request %s: %v", id, err)
+				return txFail(http.StatusInternalServerError, "Failed to update move request")
+			}
+
+			// An approved move now takes effect on the bin, as if an admin had scheduled it
+			if decision == models.MoveApprovalApproved {
+				if _, err := tx.Exec(`UPDATE bins SET status = 'pending_move', updated_at = $1 WHERE id = $2`, now, moveRequest.BinID); err != nil {
+					log.Printf("❌ [MOVE-APPROVAL] Failed to update bin %s: %v", moveRequest.BinID, err)
+					return txFail(http.StatusInternalServerError, "Failed to update move request")
+				}
+			}
+
+			stores := store.New(tx)
+			response = moveRequest.ToBinMoveRequestResponse()
+			response.Urgency = calculateUrgency(moveRequest.Status, moveRequest.ScheduledDate)
+			if bin, err := stores.Bins.Summary(moveRequest.BinID); err == nil {
+				response.BinNumber = bin.BinNumber
+				response.CurrentStreet = bin.CurrentStreet
+				response.City = bin.City
+				response.Zip = bin.Zip
+			}
+			deciderName, err = stores.Users.Name(userClaims.UserID)
+			if err != nil {
+				deciderName = "Unknown Manager"
+			} else {
+				response.ApprovalDecidedByName = &deciderName
+			}
+
+			// Tell the requester in the same transaction (delivered by the notification dispatcher)
+			if err := enqueueMoveDecision(tx, moveRequest.RequestedBy, response); err != nil {
+				log.Printf("❌ [MOVE-APPROVAL] Failed to queue notification: %v", err)
+				return txFail(http.StatusInternalServerError, "Failed to update move request")
+			}
+
+			// History only allows the standard action types, so the decision is logged as an update
+			notes := fmt.Sprintf("Move request %s", decision)
+			if reason != nil {
+				notes += ": " + *reason
+			}
+			if err := helpers.LogMoveRequestUpdated(tx, id, userClaims.UserID, deciderName, &notes, nil); err != nil {
+				return err
+			}
+
+			return nil
+		})
+		if err != nil {
+			respondTxError(w, err, "Failed to update move request")
+			return
+		}
+		if decision == models.MoveApprovalApproved {
+			store.InvalidateBins(moveRequest.BinID)
+		}
+
+		wsHub.BroadcastToRole("admin", map[string]interface{}{
+			"type": "move_request_status_updated",
+			"data": response,
+		})
+		log.Printf("✅ [MOVE-APPROVAL] Move request %s for bin #%d %s by %s", id, response.BinNumber, decision, deciderName)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    response,
+		})
+	}
+}
+
+// enqueueMoveDecision queues the WebSocket message and push telling a requester their move was approved or rejected
+func enqueueMoveDecision(tx *sqlx.Tx, requesterID string, moveRequest models.BinMoveRequestResponse) error {
+	locale := database.UserLocale(tx, requesterID)
+
+	title := i18n.T(locale, "Move request approved")
+	body := i18n.T(locale, "Your move request for bin #%d was approved", moveRequest.BinNumber)
+	if moveRequest.Status == models.MoveStatusRejected {
+		title = i18n.T(locale, "Move request rejected")
+		body = i18n.T(locale, "Your move request for bin #%d was rejected", moveRequest.BinNumber)
+	}
+	if moveRequest.ApprovalReason != nil {
+		body += ": " + *moveRequest.ApprovalReason
+	}
+
+	_, err := helpers.EnqueueUserMessage(tx, requesterID, map[string]interface{}{
+		"type": "move_request_decided",
+		"data": map[string]interface{}{
+			"move_request": moveRequest,
+			"message":      body,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = helpers.EnqueuePush(tx, requesterID, models.OutboxPush{
+		Title: title,
+		Body:  body,
+		Data: map[string]string{
+			"type":            "move_request_decided",
+			"move_request_id": moveRequest.ID,
+			"approval_status": *moveRequest.ApprovalStatus,
+		},
+	})
+	return err
+}
+
+// GetMyMoveRequests lists the move requests the signed-in user asked for, newest first
+// GET /api/move-requests/mine?approval_status=requested|approved|rejected
+func GetMyMoveRequests(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, i18n.Tr(r, "Unauthorized"))
+			return
+		}
+		approvalStatus := r.URL.Query().Get("approval_status")
+
+		var rows []struct {
+			models.BinMoveRequest
+			BinNumber int `db:"bin_number"`
+		}
+		err := db.SelectContext(r.Context(), &rows, `
+			SELECT bmr.*, b.bin_number
+			FROM bin_move_requests bmr
+			JOIN bins b ON b.id = bmr.bin_id
+			WHERE bmr.requested_by = $1
+			  AND ($2 = '' OR bmr.approval_status = $2)
+			ORDER BY bmr.created_at DESC
+			LIMIT 100
+		`, userClaims.UserID, approvalStatus)
+		if err != nil {
+			log.Printf("❌ [MOVE-APPROVAL] Failed to list move requests for %s: %v", userClaims.UserID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch move requests")
+			return
+		}
+
+		responses := make([]models.BinMoveRequestResponse, len(rows))
+		for i, row := range rows {
+			responses[i] = row.ToBinMoveRequestResponse()
+			responses[i].Urgency = calculateUrgency(row.Status, row.ScheduledDate)
+			responses[i].BinNumber = row.BinNumber
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    responses,
+		})
+	}
+}
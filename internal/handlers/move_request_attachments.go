@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"ropacal-backend/internal/helpers"
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/store"
+	"ropacal-backend/internal/websocket"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// maskedInstructionsJSON renders instructions for the audit trail with sensitive fields masked
+func maskedInstructionsJSON(instructions *models.MoveInstructions) *string {
+	if instructions == nil || instructions.IsEmpty() {
+		return nil
+	}
+	raw, err := json.Marshal(instructions.Masked())
+	if err != nil {
+		return nil
+	}
+	s := string(raw)
+	return &s
+}
+
+// logMoveSiteChange records an instructions or attachment change in the move request history
+func logMoveSiteChange(db *sqlx.DB, moveRequestID, userID, field, label, notes string, oldValue, newValue *string) {
+	userName, err := store.New(db).Users.Name(userID)
+	if err != nil {
+		userName = "A manager"
+	}
+	metadata, _ := json.Marshal(map[string]interface{}{
+		"changes": []map[string]interface{}{{"field": field, "label": label, "old": oldValue, "new": newValue}},
+	})
+	metadataStr := string(metadata)
+	if err := helpers.LogMoveRequestUpdated(db, moveRequestID, userID, userName, &notes, &metadataStr); err != nil {
+		log.Printf("Warning: Failed to log move request %s change: %v", field, err)
+	}
+}
+
+// notifyMoveSiteChange tells managers, and the driver if the move is on a running shift, that the move changed
+func notifyMoveSiteChange(db *sqlx.DB, wsHub *websocket.Hub, moveRequest *models.BinMoveRequest, message string) {
+	wsHub.BroadcastToRole("admin", map[string]interface{}{
+		"type":            "move_request_updated",
+		"move_request_id": moveRequest.ID,
+		"status":          moveRequest.Status,
+		"bin_id":          moveRequest.BinID,
+	})
+
+	if moveRequest.AssignedShiftID == nil {
+		return
+	}
+	var shift struct {
+		DriverID string `db:"driver_id"`
+		Status   string `db:"status"`
+	}
+	err := db.Get(&shift, `SELECT driver_id, status FROM shifts WHERE id = $1`, *moveRequest.AssignedShiftID)
+	if err != nil || (shift.Status != "active" && shift.Status != "paused") {
+		return
+	}
+	wsHub.BroadcastToUser(shift.DriverID, map[string]interface{}{
+		"type":            "route_updated",
+		"message":         message,
+		"move_request_id": moveRequest.ID,
+		"action_type":     "updated",
+	})
+}
+
+// GetMoveRequestAttachments lists a move request's attachments, oldest first
+// GET /api/manager/bins/move-requests/{id}/attachments
+func GetMoveRequestAttachments(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+
+		if _, err := store.NewMoveRequestStore(db).Get(id); err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "Move request not found")
+			return
+		} else if err != nil {
+			log.Printf("❌ [MOVE-ATTACHMENTS] %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch attachments")
+			return
+		}
+
+		attachments, err := store.NewMoveRequestStore(db).Attachments(id)
+		if err != nil {
+			log.Printf("❌ [MOVE-ATTACHMENTS] %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch attachments")
+			return
+		}
+		list := attachments[id]
+		if list == nil {
+			list = []models.MoveRequestAttachment{}
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    list,
+		})
+	}
+}
+
+// AddMoveRequestAttachment attaches an uploaded photo or document to a move request
+// POST /api/manager/bins/move-requests/{id}/attachments
+// Body: { "kind": "photo", "url": "https://...", "caption": "Place next to the loading dock" }
+func AddMoveRequestAttachment(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		id := chi.URLParam(r, "id")
+
+		var req models.CreateMoveRequestAttachmentRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if req.Kind != models.MoveAttachmentPhoto && req.Kind != models.MoveAttachmentDocument {
+			utils.RespondError(w, http.StatusBadRequest, "kind must be photo or document")
+			return
+		}
+		if strings.TrimSpace(req.URL) == "" {
+			utils.RespondError(w, http.StatusBadRequest, "url is required")
+			return
+		}
+
+		moveRequest, err := store.NewMoveRequestStore(db).Get(id)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "Move request not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [MOVE-ATTACHMENTS] %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to add attachment")
+			return
+		}
+		if models.IsFinalMoveStatus(moveRequest.Status) {
+			utils.RespondError(w, http.StatusConflict, fmt.Sprintf("Move request is already %s", moveRequest.Status))
+			return
+		}
+
+		attachment := models.MoveRequestAttachment{
+			ID:               uuid.New().String(),
+			MoveRequestID:    id,
+			Kind:             req.Kind,
+			URL:              req.URL,
+			Caption:          req.Caption,
+			UploadedByUserID: &userClaims.UserID,
+			CreatedAt:        time.Now().Unix(),
+		}
+		_, err = db.NamedExecContext(r.Context(), `
+			INSERT INTO move_request_attachments (id, move_request_id, kind, url, caption, uploaded_by_user_id, created_at)
+			VALUES (:id, :move_request_id, :kind, :url, :caption, :uploaded_by_user_id, :created_at)
+		`, attachment)
+		if err != nil {
+			log.Printf("❌ [MOVE-ATTACHMENTS] Failed to add attachment to %s: %v", id, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to add attachment")
+			return
+		}
+
+		logMoveSiteChange(db, id, userClaims.UserID, "attachments", "Attachments", "Added "+req.Kind, nil, &req.URL)
+		notifyMoveSiteChange(db, wsHub, moveRequest, "A "+req.Kind+" was attached to a move on your route")
+		log.Printf("✅ [MOVE-ATTACHMENTS] %s attached a %s to move request %s", userClaims.Email, req.Kind, id)
+
+		utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{
+			"success": true,
+			"data":    attachment,
+		})
+	}
+}
+
+// DeleteMoveRequestAttachment removes an attachment from a move request
+// DELETE /api/manager/bins/move-requests/{id}/attachments/{attachmentId}
+func DeleteMoveRequestAttachment(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		id := chi.URLParam(r, "id")
+		attachmentID := chi.URLParam(r, "attachmentId")
+
+		var attachment models.MoveRequestAttachment
+		err := db.GetContext(r.Context(), &attachment, `
+			DELETE FROM move_request_attachments WHERE id = $1 AND move_request_id = $2 RETURNING *
+		`, attachmentID, id)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "Attachment not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [MOVE-ATTACHMENTS] Failed to remove attachment %s: %v", attachmentID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to remove attachment")
+			return
+		}
+
+		logMoveSiteChange(db, id, userClaims.UserID, "attachments", "Attachments", "Removed "+attachment.Kind, &attachment.URL, nil)
+		if moveRequest, err := store.NewMoveRequestStore(db).Get(id); err == nil {
+			notifyMoveSiteChange(db, wsHub, moveRequest, "An attachment was removed from a move on your route")
+		}
+		log.Printf("✅ [MOVE-ATTACHMENTS] %s removed attachment %s from move request %s", userClaims.Email, attachmentID, id)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+		})
+	}
+}
+
+// UpdateMoveRequestInstructions replaces a move request's site instructions (omitted fields are cleared)
+// The audit trail records the change with the gate code and phone number masked
+// PUT /api/manager/bins/move-requests/{id}/instructions
+// Body: { "gate_code": "4521#", "contact_name": "...", "contact_phone": "...", "access_notes": "..." }
+func UpdateMoveRequestInstructions(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		id := chi.URLParam(r, "id")
+
+		var instructions models.MoveInstructions
+		if err := json.NewDecoder(r.Body).Decode(&instructions); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		moveRequest, err := store.NewMoveRequestStore(db).Get(id)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "Move request not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [MOVE-INSTRUCTIONS] %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to update instructions")
+			return
+		}
+		if models.IsFinalMoveStatus(moveRequest.Status) {
+			utils.RespondError(w, http.StatusConflict, fmt.Sprintf("Move request is already %s", moveRequest.Status))
+			return
+		}
+
+		var stored *models.MoveInstructions
+		if !instructions.IsEmpty() {
+			stored = &instructions
+		}
+		_, err = db.ExecContext(r.Context(), `
+			UPDATE bin_move_requests SET instructions = $1, updated_at = $2 WHERE id = $3
+		`, stored, time.Now().Unix(), id)
+		if err != nil {
+			log.Printf("❌ [MOVE-INSTRUCTIONS] Failed to update %s: %v", id, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to update instructions")
+			return
+		}
+
+		before, _ := json.Marshal(moveRequest.Instructions)
+		after, _ := json.Marshal(stored)
+		if string(before) != string(after) {
+			logMoveSiteChange(db, id, userClaims.UserID, "instructions", "Instructions", "Updated site instructions",
+				maskedInstructionsJSON(moveRequest.Instructions), maskedInstructionsJSON(stored))
+		}
+		moveRequest.Instructions = stored
+		notifyMoveSiteChange(db, wsHub, moveRequest, "Site instructions changed for a move on your route")
+		log.Printf("✅ [MOVE-INSTRUCTIONS] %s updated instructions of move request %s", userClaims.Email, id)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    stored,
+		})
+	}
+}
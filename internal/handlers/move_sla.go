@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/services"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// GetMoveSLAReport returns SLA compliance for move requests created in a period, per urgency
+// A move counts as compliant while neither deadline has been breached, so open moves can still drop out later
+// GET /api/manager/analytics/move-sla?since=<unix>&until=<unix> (default: the last 30 days)
+func GetMoveSLAReport(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		report := models.MoveSLAReport{
+			From:      now.AddDate(0, 0, -30).Unix(),
+			To:        now.Unix(),
+			ByUrgency: []models.MoveSLAUrgencyStats{},
+			Overall:   models.MoveSLAUrgencyStats{Urgency: "all"},
+		}
+		if since, err := strconv.ParseInt(r.URL.Query().Get("since"), 10, 64); err == nil {
+			report.From = since
+		}
+		if until, err := strconv.ParseInt(r.URL.Query().Get("until"), 10, 64); err == nil {
+			report.To = until
+		}
+		if report.From >= report.To {
+			utils.RespondError(w, http.StatusBadRequest, "since must be before until")
+			return
+		}
+
+		policy, err := database.GetMoveSLAPolicy(db)
+		if err != nil {
+			log.Printf("⚠️  [MOVE-SLA] %v (using defaults)", err)
+		}
+		report.Policy = policy
+
+		var rows []struct {
+			Urgency          string `db:"urgency"`
+			Total            int    `db:"total"`
+			AssignBreaches   int    `db:"assign_breaches"`
+			CompleteBreaches int    `db:"complete_breaches"`
+			Compliant        int    `db:"compliant"`
+			OpenBreaches     int    `db:"open_breaches"`
+		}
+		err = db.SelectContext(r.Context(), &rows, `
+			SELECT urgency,
+			       COUNT(*) AS total,
+			       COUNT(assign_sla_breached_at) AS assign_breaches,
+			       COUNT(complete_sla_breached_at) AS complete_breaches,
+			       COUNT(*) FILTER (WHERE assign_sla_breached_at IS NULL AND complete_sla_breached_at IS NULL) AS compliant,
+			       COUNT(*) FILTER (
+			           WHERE (assign_sla_breached_at IS NOT NULL OR complete_sla_breached_at IS NOT NULL)
+			           AND status IN ('pending', 'in_progress', 'picked_up')
+			       ) AS open_breaches
+			FROM bin_move_requests
+			WHERE created_at >= $1 AND created_at < $2
+			GROUP BY urgency
+			ORDER BY urgency ASC
+		`, report.From, report.To)
+		if err != nil {
+			log.Printf("❌ [MOVE-SLA] Failed to build report: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to build SLA report")
+			return
+		}
+
+		for _, row := range rows {
+			stats := models.MoveSLAUrgencyStats{
+				Urgency:          row.Urgency,
+				Total:            row.Total,
+				AssignBreaches:   row.AssignBreaches,
+				CompleteBreaches: row.CompleteBreaches,
+				Compliant:        row.Compliant,
+				OpenBreaches:     row.OpenBreaches,
+			}
+			stats.ComplianceRate = complianceRate(stats.Compliant, stats.Total)
+			report.ByUrgency = append(report.ByUrgency, stats)
+
+			report.Overall.Total += stats.Total
+			report.Overall.AssignBreaches += stats.AssignBreaches
+			report.Overall.CompleteBreaches += stats.CompleteBreaches
+			report.Overall.Compliant += stats.Compliant
+			report.Overall.OpenBreaches += stats.OpenBreaches
+		}
+		report.Overall.ComplianceRate = complianceRate(report.Overall.Compliant, report.Overall.Total)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    report,
+		})
+	}
+}
+
+// complianceRate returns compliant/total rounded to 4 decimals, or nil when there is nothing to measure
+func complianceRate(compliant, total int) *float64 {
+	if total == 0 {
+		return nil
+	}
+	rate := math.Round(float64(compliant)/float64(total)*10000) / 10000
+	return &rate
+}
+
+// RunMoveSLACheck runs the SLA checker now and returns the breaches it flagged
+// POST /api/manager/move-sla/check
+func RunMoveSLACheck(checker *services.MoveSLAChecker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		breaches, err := checker.Run(time.Now())
+		if err != nil {
+			log.Printf("❌ [MOVE-SLA] Manual check failed: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to check move SLAs")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    breaches,
+		})
+	}
+}
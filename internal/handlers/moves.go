@@ -7,7 +7,10 @@ import (
 	"strings"
 	"time"
 
+	"ropacal-backend/internal/database"
 	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/store"
+	"ropacal-backend/pkg/utils"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/jmoiron/sqlx"
@@ -17,12 +20,12 @@ func GetMoves(db *sqlx.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		binID := chi.URLParam(r, "id")
 		if binID == "" {
-			http.Error(w, "Bad Request", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Bad Request")
 			return
 		}
 
 		var moves []models.Move
-		err := db.Select(&moves, `
+		err := db.SelectContext(r.Context(), &moves, `
 			SELECT
 			id, bin_id, moved_from, moved_to, moved_on,
 			move_type, from_street, from_city, from_zip,
@@ -33,7 +36,7 @@ func GetMoves(db *sqlx.DB) http.HandlerFunc {
 			ORDER BY moved_on DESC
 		`, binID)
 		if err != nil {
-			http.Error(w, "Failed to fetch moves", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch moves")
 			return
 		}
 
@@ -52,31 +55,16 @@ func CreateMove(db *sqlx.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		binID := chi.URLParam(r, "id")
 		if binID == "" {
-			http.Error(w, "Bad Request", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Bad Request")
 			return
 		}
 
 		var req models.CreateMoveRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
 			return
 		}
 
-		// Get existing bin
-		var bin models.Bin
-		err := db.Get(&bin, "SELECT * FROM bins WHERE id = $1", binID)
-		if err == sql.ErrNoRows {
-			http.Error(w, "Bin not found", http.StatusNotFound)
-			return
-		}
-		if err != nil {
-			http.Error(w, "Database error", http.StatusInternalServerError)
-			return
-		}
-
-		movedFrom := bin.CurrentStreet + ", " + bin.City + " " + bin.Zip
-		movedTo := req.ToStreet + ", " + req.ToCity + " " + req.ToZip
-
 		// Determine move time
 		movedOn := time.Now()
 		if req.MovedOnIso != nil {
@@ -85,66 +73,70 @@ func CreateMove(db *sqlx.DB) http.HandlerFunc {
 			}
 		}
 
-		// Check if address changed
-		norm := func(s string) string {
-			return strings.ToLower(strings.TrimSpace(strings.Join(strings.Fields(s), " ")))
-		}
-		addrChanged := norm(bin.CurrentStreet) != norm(req.ToStreet) ||
-			norm(bin.City) != norm(req.ToCity) ||
-			norm(bin.Zip) != norm(req.ToZip)
+		// The bin is locked so its address can't change between reading "from" and writing "to"
+		err := database.WithTx(r.Context(), db, func(tx *sqlx.Tx) error {
+			var bin models.Bin
+			err := tx.GetContext(r.Context(), &bin, "SELECT * FROM bins WHERE id = $1 FOR UPDATE", binID)
+			if err == sql.ErrNoRows {
+				return txFail(http.StatusNotFound, "Bin not found")
+			}
+			if err != nil {
+				return txFail(http.StatusInternalServerError, "Database error")
+			}
 
-		// Start transaction
-		tx, err := db.Beginx()
-		if err != nil {
-			http.Error(w, "Failed to begin transaction", http.StatusInternalServerError)
-			return
-		}
-		defer tx.Rollback()
+			movedFrom := bin.CurrentStreet + ", " + bin.City + " " + bin.Zip
+			movedTo := req.ToStreet + ", " + req.ToCity + " " + req.ToZip
 
-		// Insert move record
-		_, err = tx.Exec(`
-			INSERT INTO moves (bin_id, moved_from, moved_to, moved_on)
-			VALUES ($1, $2, $3, $4)
-		`, binID, movedFrom, movedTo, movedOn.Unix())
-		if err != nil {
-			http.Error(w, "Failed to create move", http.StatusInternalServerError)
-			return
-		}
+			// Check if address changed
+			norm := func(s string) string {
+				return strings.ToLower(strings.TrimSpace(strings.Join(strings.Fields(s), " ")))
+			}
+			addrChanged := norm(bin.CurrentStreet) != norm(req.ToStreet) ||
+				norm(bin.City) != norm(req.ToCity) ||
+				norm(bin.Zip) != norm(req.ToZip)
+
+			// Insert move record (destination coordinates are geocoded later for the location timeline)
+			_, err = tx.ExecContext(r.Context(), `
+				INSERT INTO moves (bin_id, moved_from, moved_to, moved_on, from_latitude, from_longitude)
+				VALUES ($1, $2, $3, $4, $5, $6)
+			`, binID, movedFrom, movedTo, movedOn.Unix(), bin.Latitude, bin.Longitude)
+			if err != nil {
+				return txFail(http.StatusInternalServerError, "Failed to create move")
+			}
 
-		// Update bin
-		query := `
-			UPDATE bins
-			SET current_street = $1, city = $2, zip = $3,
-			    last_moved = $4, move_requested = 0, updated_at = $5`
-		args := []interface{}{
-			req.ToStreet, req.ToCity, req.ToZip,
-			movedOn.Unix(), time.Now().Unix(),
-		}
+			// Update bin
+			query := `
+				UPDATE bins
+				SET current_street = $1, city = $2, zip = $3,
+				    last_moved = $4, move_requested = 0, updated_at = $5`
+			args := []interface{}{
+				req.ToStreet, req.ToCity, req.ToZip,
+				movedOn.Unix(), time.Now().Unix(),
+			}
 
-		if addrChanged {
-			query += `, latitude = NULL, longitude = NULL`
-		}
+			if addrChanged {
+				query += `, latitude = NULL, longitude = NULL`
+			}
 
-		query += ` WHERE id = $6`
-		args = append(args, binID)
+			query += ` WHERE id = $6`
+			args = append(args, binID)
 
-		_, err = tx.Exec(query, args...)
+			if _, err := tx.ExecContext(r.Context(), query, args...); err != nil {
+				return txFail(http.StatusInternalServerError, "Failed to update bin")
+			}
+			return nil
+		})
 		if err != nil {
-			http.Error(w, "Failed to update bin", http.StatusInternalServerError)
-			return
-		}
-
-		// Commit transaction
-		if err := tx.Commit(); err != nil {
-			http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
+			respondTxError(w, err, "Failed to commit transaction")
 			return
 		}
+		store.InvalidateBins(binID)
 
 		// Fetch updated bin
 		var updated models.Bin
-		err = db.Get(&updated, "SELECT * FROM bins WHERE id = $1", binID)
+		err = db.GetContext(r.Context(), &updated, "SELECT * FROM bins WHERE id = $1", binID)
 		if err != nil {
-			http.Error(w, "Failed to fetch updated bin", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch updated bin")
 			return
 		}
 
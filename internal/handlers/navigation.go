@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/i18n"
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/services"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// NavigationDeepLinks are pre-built links that open turn-by-turn navigation to a stop
+type NavigationDeepLinks struct {
+	GoogleMaps string `json:"google_maps"`
+	AppleMaps  string `json:"apple_maps"`
+	Waze       string `json:"waze"`
+}
+
+// NextStopResponse is the driver's next uncompleted stop with navigation details
+type NextStopResponse struct {
+	ShiftID         string              `json:"shift_id"`
+	Task            models.RouteTask    `json:"task"`
+	StopType        models.TaskType     `json:"stop_type"`
+	RemainingStops  int                 `json:"remaining_stops"`
+	OriginLatitude  float64             `json:"origin_latitude"`
+	OriginLongitude float64             `json:"origin_longitude"`
+	OriginSource    string              `json:"origin_source"` // request, live_location, last_stop, warehouse
+	DistanceKm      float64             `json:"distance_km"`
+	DurationSeconds int                 `json:"duration_seconds"`
+	ETA             int64               `json:"eta"`
+	ETASource       string              `json:"eta_source"` // directions, estimate
+	DeepLinks       NavigationDeepLinks `json:"deep_links"`
+	Polyline        *string             `json:"polyline,omitempty"` // Encoded polyline for the leg (include_polyline=true)
+}
+
+// buildNavigationDeepLinks returns Google Maps, Apple Maps and Waze driving links to a destination
+func buildNavigationDeepLinks(lat, lng float64) NavigationDeepLinks {
+	destination := fmt.Sprintf("%f,%f", lat, lng)
+	return NavigationDeepLinks{
+		GoogleMaps: "https://www.google.com/maps/dir/?api=1&travelmode=driving&destination=" + url.QueryEscape(destination),
+		AppleMaps:  "https://maps.apple.com/?dirflg=d&daddr=" + url.QueryEscape(destination),
+		Waze:       "https://waze.com/ul?navigate=yes&ll=" + url.QueryEscape(destination),
+	}
+}
+
+// GetNextStop returns the driver's next uncompleted stop with ETA, distance and navigation links
+// Computed on every request, so it always reflects the latest completion
+// GET /api/driver/shift/next-stop
+// Query params:
+//   - lat, lng: current position (defaults to the last reported location, then the last completed stop)
+//   - include_polyline: true to include the encoded polyline for the leg (requires GOOGLE_MAPS_API_KEY)
+func GetNextStop(db *sqlx.DB, directions *services.DirectionsService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, i18n.Tr(r, "Unauthorized"))
+			return
+		}
+
+		var shift models.Shift
+		err := db.GetContext(r.Context(), &shift, `
+			SELECT * FROM shifts
+			WHERE driver_id = $1
+			AND status IN ('active', 'paused', 'ready')
+			ORDER BY
+				CASE status
+					WHEN 'active' THEN 1
+					WHEN 'paused' THEN 2
+					WHEN 'ready' THEN 3
+				END ASC,
+				created_at DESC
+			LIMIT 1
+		`, userClaims.UserID)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, i18n.Tr(r, "No active shift found"))
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [NEXT-STOP] Failed to fetch shift: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, i18n.Tr(r, "Failed to fetch shift"))
+			return
+		}
+
+		task, err := database.GetNextIncompleteTask(db, shift.ID)
+		if err != nil {
+			log.Printf("❌ [NEXT-STOP] %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, i18n.Tr(r, "Failed to fetch next stop"))
+			return
+		}
+		if task == nil {
+			utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+				"success": true,
+				"data":    nil,
+				"message": "All stops completed",
+			})
+			return
+		}
+
+		var remaining int
+		db.GetContext(r.Context(), &remaining, `
+			SELECT COUNT(*) FROM route_tasks WHERE shift_id = $1 AND is_completed = 0
+		`, shift.ID)
+
+		// Resolve the origin: explicit position > live location > last completed stop > warehouse
+		warehouse := services.GetWarehouseLocation()
+		originLat, originLng, originSource := warehouse.Latitude, warehouse.Longitude, "warehouse"
+		lat, latErr := strconv.ParseFloat(r.URL.Query().Get("lat"), 64)
+		lng, lngErr := strconv.ParseFloat(r.URL.Query().Get("lng"), 64)
+		if latErr == nil && lngErr == nil {
+			originLat, originLng, originSource = lat, lng, "request"
+		} else {
+			var position struct {
+				Latitude  float64 `db:"latitude"`
+				Longitude float64 `db:"longitude"`
+			}
+			if err := db.GetContext(r.Context(), &position, `
+				SELECT latitude, longitude FROM driver_current_location
+				WHERE driver_id = $1 AND shift_id = $2
+			`, userClaims.UserID, shift.ID); err == nil {
+				originLat, originLng, originSource = position.Latitude, position.Longitude, "live_location"
+			} else if err := db.GetContext(r.Context(), &position, `
+				SELECT latitude, longitude FROM route_tasks
+				WHERE shift_id = $1 AND is_completed = 1
+				ORDER BY sequence_order DESC
+				LIMIT 1
+			`, shift.ID); err == nil {
+				originLat, originLng, originSource = position.Latitude, position.Longitude, "last_stop"
+			}
+		}
+
+		// Dropoffs navigate to the move's destination when it is recorded separately
+		destLat, destLng := task.Latitude, task.Longitude
+		if task.TaskType == models.TaskTypeDropoff && task.DestinationLatitude != nil && task.DestinationLongitude != nil {
+			destLat, destLng = *task.DestinationLatitude, *task.DestinationLongitude
+		}
+
+		now := time.Now().Unix()
+		response := NextStopResponse{
+			ShiftID:         shift.ID,
+			Task:            *task,
+			StopType:        task.TaskType,
+			RemainingStops:  remaining,
+			OriginLatitude:  originLat,
+			OriginLongitude: originLng,
+			OriginSource:    originSource,
+			DeepLinks:       buildNavigationDeepLinks(destLat, destLng),
+		}
+
+		// Prefer road distance/duration with traffic; fall back to a straight-line estimate
+		leg, err := directions.GetLeg(r.Context(), originLat, originLng, destLat, destLng)
+		if err == nil {
+			response.DistanceKm = float64(leg.DistanceMeters) / 1000
+			response.DurationSeconds = leg.DurationSeconds
+			response.ETASource = "directions"
+			if r.URL.Query().Get("include_polyline") == "true" && leg.Polyline != "" {
+				response.Polyline = &leg.Polyline
+			}
+		} else {
+			if directions.Enabled() {
+				log.Printf("⚠️  [NEXT-STOP] Directions lookup failed, using estimate: %v", err)
+			}
+			response.DistanceKm = haversineDistanceKm(originLat, originLng, destLat, destLng)
+			response.DurationSeconds = int(response.DistanceKm / etaAverageSpeedKmh * 3600)
+			response.ETASource = "estimate"
+		}
+		response.ETA = now + int64(response.DurationSeconds)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    response,
+		})
+	}
+}
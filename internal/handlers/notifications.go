@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"ropacal-backend/internal/services"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// getUserFCMTokens returns every registered FCM token for a user (newest first)
+func getUserFCMTokens(db *sqlx.DB, userID string) []string {
+	var tokens []string
+	err := db.Select(&tokens, `SELECT token FROM fcm_tokens WHERE user_id = $1 ORDER BY updated_at DESC`, userID)
+	if err != nil {
+		log.Printf("⚠️  Failed to fetch FCM tokens for user %s: %v", userID, err)
+		return nil
+	}
+	return tokens
+}
+
+// getUsersFCMTokens returns FCM tokens for several users, keyed by user ID
+func getUsersFCMTokens(db *sqlx.DB, userIDs []string) map[string][]string {
+	tokensByUser := make(map[string][]string)
+	if len(userIDs) == 0 {
+		return tokensByUser
+	}
+
+	var rows []struct {
+		UserID string `db:"user_id"`
+		Token  string `db:"token"`
+	}
+	err := db.Select(&rows, `SELECT user_id, token FROM fcm_tokens WHERE user_id = ANY($1) ORDER BY updated_at DESC`, pq.Array(userIDs))
+	if err != nil {
+		log.Printf("⚠️  Failed to fetch FCM tokens for %d users: %v", len(userIDs), err)
+		return tokensByUser
+	}
+
+	for _, row := range rows {
+		tokensByUser[row.UserID] = append(tokensByUser[row.UserID], row.Token)
+	}
+	return tokensByUser
+}
+
+// retireFCMTokens deletes tokens that FCM reported as unregistered
+func retireFCMTokens(db *sqlx.DB, tokens []string) {
+	if len(tokens) == 0 {
+		return
+	}
+
+	result, err := db.Exec(`DELETE FROM fcm_tokens WHERE token = ANY($1)`, pq.Array(tokens))
+	if err != nil {
+		log.Printf("⚠️  Failed to retire %d unregistered FCM token(s): %v", len(tokens), err)
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	log.Printf("🧹 Retired %d unregistered FCM token(s)", rowsAffected)
+}
+
+// subscribeFCMTokenToTopics subscribes a device to its role topic and the organization topic
+func subscribeFCMTokenToTopics(db *sqlx.DB, fcmService *services.FCMService, token, role string) {
+	if fcmService == nil {
+		return
+	}
+
+	invalidTokens, err := fcmService.SubscribeToTopics([]string{token}, services.RoleTopic(role), services.OrganizationTopic())
+	if err != nil {
+		log.Printf("⚠️  Failed to subscribe FCM token to topics: %v", err)
+	}
+	retireFCMTokens(db, invalidTokens)
+}
+
+// BroadcastNotification sends a push notification to a role topic or the whole organization
+// POST /api/manager/notifications/broadcast
+// Body: { "role": "driver" (optional, omit for whole organization), "title": "...", "body": "..." }
+func BroadcastNotification(fcmService *services.FCMService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if fcmService == nil {
+			utils.RespondError(w, http.StatusServiceUnavailable, "Push notifications are not configured")
+			return
+		}
+
+		var req struct {
+			Role  *string `json:"role"`
+			Title string  `json:"title"`
+			Body  string  `json:"body"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		if req.Title == "" || req.Body == "" {
+			utils.RespondError(w, http.StatusBadRequest, "title and body are required")
+			return
+		}
+
+		topic := services.OrganizationTopic()
+		if req.Role != nil && *req.Role != "" {
+			topic = services.RoleTopic(*req.Role)
+		}
+
+		err := fcmService.SendToTopic(topic, req.Title, req.Body, map[string]string{
+			"type": "broadcast",
+		})
+		if err != nil {
+			log.Printf("❌ Failed to broadcast notification to %s: %v", topic, err)
+			utils.RespondError(w, http.StatusBadGateway, "Failed to send notification")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data": map[string]interface{}{
+				"topic": topic,
+			},
+		})
+	}
+}
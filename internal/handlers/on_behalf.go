@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ropacal-backend/internal/helpers"
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/services"
+	"ropacal-backend/internal/websocket"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// onBehalfMaxBody limits on-behalf request bodies (they're read whole to be recorded)
+const onBehalfMaxBody = 64 << 10
+
+// completeBinOnBehalfRequest documents complete-bin-on-behalf's body: complete-bin's plus the reason
+type completeBinOnBehalfRequest struct {
+	completeStopRequest
+	Reason string `json:"reason"`
+}
+
+// onBehalfContextKey holds the models.OnBehalfRequest of a driver action a manager performs for the driver
+type onBehalfContextKey struct{}
+
+// onBehalfOptions returns the manager's options when the request is a driver action performed on the driver's behalf
+func onBehalfOptions(r *http.Request) (models.OnBehalfRequest, bool) {
+	options, ok := r.Context().Value(onBehalfContextKey{}).(models.OnBehalfRequest)
+	return options, ok
+}
+
+// StartShiftOnBehalf starts a driver's ready shift for them, e.g. when their phone is dead or the app won't load
+// The shift starts exactly as if the driver had started it; without the driver's GPS, optimization starts from
+// latitude/longitude (or the driver's last known location), and skip_checklist starts it without the pre-start checklist
+// POST /api/manager/shifts/{id}/start-on-behalf
+// Body: { "reason": "Driver's phone is dead", "latitude": 37.33, "longitude": -121.88, "skip_checklist": false }
+func StartShiftOnBehalf(db *sqlx.DB, hub *websocket.Hub, hereService *services.HEREWaypointsService) http.HandlerFunc {
+	return onBehalf(db, models.OnBehalfActionStartShift, models.ShiftStatusReady, StartShift(db, hub, hereService))
+}
+
+// CompleteBinOnBehalf completes a stop on a driver's active shift for them
+// The body is complete-bin's plus a reason; the check is recorded as the driver's, and the check-in proximity
+// limit doesn't refuse it (the completion is still flagged as remote)
+// POST /api/manager/shifts/{id}/complete-bin-on-behalf
+// Body: { "reason": "Driver can't upload, confirmed by phone", "task_id": "...", "updated_fill_percentage": 80 }
+func CompleteBinOnBehalf(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
+	return onBehalf(db, models.OnBehalfActionCompleteBin, models.ShiftStatusActive, CompleteBin(db, hub))
+}
+
+// onBehalf runs a driver handler as the driver of shift {id} (which must be in requiredStatus), with the
+// manager's claims kept for the handler (see middleware.UserClaims.IsOnBehalf)
+// Every attempt is recorded in on_behalf_actions, and the driver's devices get a shift_action_on_behalf message
+// when the action succeeds (queued, so a device that's offline gets it when it reconnects)
+func onBehalf(db *sqlx.DB, action string, requiredStatus models.ShiftStatus, driverHandler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		shiftID := chi.URLParam(r, "id")
+
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, onBehalfMaxBody))
+		if err != nil {
+			utils.RespondError(w, http.StatusRequestEntityTooLarge, "Request body too large")
+			return
+		}
+		var options models.OnBehalfRequest
+		if err := json.Unmarshal(body, &options); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		options.Reason = strings.TrimSpace(options.Reason)
+		if options.Reason == "" {
+			utils.RespondError(w, http.StatusBadRequest, "reason is required")
+			return
+		}
+		if (options.Latitude == nil) != (options.Longitude == nil) {
+			utils.RespondError(w, http.StatusBadRequest, "latitude and longitude must be sent together")
+			return
+		}
+
+		var shift models.Shift
+		err = db.GetContext(r.Context(), &shift, `SELECT * FROM shifts WHERE id = $1`, shiftID)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "Shift not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [ON-BEHALF] Failed to fetch shift %s: %v", shiftID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch shift")
+			return
+		}
+		if shift.Status != requiredStatus {
+			utils.RespondErrorCode(w, http.StatusConflict, utils.CodeConflict,
+				fmt.Sprintf("Shift is %s; it must be %s", shift.Status, requiredStatus),
+				map[string]interface{}{"status": shift.Status})
+			return
+		}
+
+		var driver models.User
+		if err := db.GetContext(r.Context(), &driver, `SELECT * FROM users WHERE id = $1`, shift.DriverID); err != nil {
+			log.Printf("❌ [ON-BEHALF] Failed to fetch driver %s: %v", shift.DriverID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch driver")
+			return
+		}
+		if driver.IsDeactivated() {
+			utils.RespondError(w, http.StatusBadRequest, "Driver is deactivated")
+			return
+		}
+
+		// The driver's handler sees the driver's claims (and the manager behind them) and reads the body again
+		driverClaims := middleware.UserClaims{
+			UserID:               driver.ID,
+			Email:                driver.Email,
+			Role:                 driver.Role,
+			OnBehalfManagerID:    userClaims.UserID,
+			OnBehalfManagerEmail: userClaims.Email,
+		}
+		ctx := context.WithValue(r.Context(), middleware.UserContextKey, driverClaims)
+		ctx = context.WithValue(ctx, onBehalfContextKey{}, options)
+		driverRequest := r.WithContext(ctx)
+		driverRequest.Body = io.NopCloser(bytes.NewReader(body))
+
+		log.Printf("🤝 [ON-BEHALF] %s: %s for %s on shift %s (%s)", action, userClaims.Email, driver.Email, shift.ID, options.Reason)
+
+		ww := chimiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		driverHandler(ww, driverRequest)
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		now := time.Now().Unix()
+		record := models.OnBehalfAction{
+			ID:               uuid.New().String(),
+			Action:           action,
+			ShiftID:          shift.ID,
+			OnBehalfOf:       driver.ID,
+			PerformedBy:      &userClaims.UserID,
+			PerformedByEmail: userClaims.Email,
+			Reason:           options.Reason,
+			StatusCode:       status,
+			CreatedAt:        now,
+		}
+		_, err = db.Exec(`
+			INSERT INTO on_behalf_actions (id, action, shift_id, on_behalf_of, performed_by, performed_by_email, reason, request, status_code, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		`, record.ID, record.Action, record.ShiftID, record.OnBehalfOf, record.PerformedBy, record.PerformedByEmail,
+			record.Reason, string(body), record.StatusCode, record.CreatedAt)
+		if err != nil {
+			log.Printf("❌ [ON-BEHALF] Failed to record %s on shift %s by %s: %v", action, shift.ID, userClaims.Email, err)
+		}
+
+		if status >= 400 {
+			log.Printf("⚠️  [ON-BEHALF] %s on shift %s by %s was refused (%d)", action, shift.ID, userClaims.Email, status)
+			return
+		}
+
+		// The driver's app learns what was done for it, also when it was offline at the time
+		if _, err := helpers.EnqueueUserMessage(db, driver.ID, map[string]interface{}{
+			"type": "shift_action_on_behalf",
+			"data": map[string]interface{}{
+				"id":                 record.ID,
+				"action":             action,
+				"shift_id":           shift.ID,
+				"performed_by_email": userClaims.Email,
+				"reason":             options.Reason,
+				"created_at":         now,
+			},
+		}); err != nil {
+			log.Printf("⚠️  [ON-BEHALF] Failed to queue sync message for %s: %v", driver.Email, err)
+		}
+		log.Printf("✅ [ON-BEHALF] %s: %s for %s on shift %s", action, userClaims.Email, driver.Email, shift.ID)
+	}
+}
+
+// GetOnBehalfActions lists driver actions managers performed on drivers' behalf, newest first
+// GET /api/manager/on-behalf-actions?driver_id=<id>&shift_id=<id>&performed_by=<id>&limit=100
+func GetOnBehalfActions(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+
+		query := `SELECT a.*, u.name AS driver_name FROM on_behalf_actions a LEFT JOIN users u ON u.id = a.on_behalf_of WHERE 1=1`
+		var args []interface{}
+		if driverID := q.Get("driver_id"); driverID != "" {
+			args = append(args, driverID)
+			query += fmt.Sprintf(` AND a.on_behalf_of = $%d`, len(args))
+		}
+		if shiftID := q.Get("shift_id"); shiftID != "" {
+			args = append(args, shiftID)
+			query += fmt.Sprintf(` AND a.shift_id = $%d`, len(args))
+		}
+		if performedBy := q.Get("performed_by"); performedBy != "" {
+			args = append(args, performedBy)
+			query += fmt.Sprintf(` AND a.performed_by = $%d`, len(args))
+		}
+
+		limit := 100
+		if parsed, err := strconv.Atoi(q.Get("limit")); err == nil && parsed > 0 && parsed <= 500 {
+			limit = parsed
+		}
+		args = append(args, limit)
+		query += fmt.Sprintf(` ORDER BY a.created_at DESC LIMIT $%d`, len(args))
+
+		actions := []models.OnBehalfAction{}
+		if err := db.SelectContext(r.Context(), &actions, query, args...); err != nil {
+			log.Printf("❌ [ON-BEHALF] Failed to fetch actions: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch on-behalf actions")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    actions,
+		})
+	}
+}
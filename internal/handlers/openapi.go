@@ -0,0 +1,741 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/openapi"
+	"ropacal-backend/internal/services"
+)
+
+// Route access levels for API documentation
+const (
+	apiPublic = ""
+	apiDriver = "driver"  // Any authenticated user
+	apiAdmin  = "admin"   // Manager dashboard (admin role)
+	apiKey    = "api_key" // Machine clients (X-API-Key header)
+)
+
+// APISpec documents the HTTP API (served at /api/openapi.json)
+// Request types double as validation rules: POST/PUT/PATCH bodies for routes with a Request are
+// checked against its validate tags by middleware.ValidateRequestBody before the handler runs
+// Keep in sync with the routes in cmd/server/main.go (undocumented routes are logged at startup)
+func APISpec() *openapi.Spec {
+	spec := openapi.New("Ropacal API", "1.0.0")
+
+	limit := openapi.Param{Name: "limit", Type: "integer", Description: "Maximum results"}
+	viewID := openapi.Param{Name: "view_id", Type: "string", Description: "Apply a saved view's filters and sort (explicit params win)"}
+	includeDeactivated := openapi.Param{Name: "include_deactivated", Type: "boolean", Description: "Also list deactivated users"}
+	tagID := openapi.Param{Name: "tag_id", Type: "string", Description: "Comma-separated tag IDs; bins with any of them"}
+	allAreas := openapi.Param{Name: "all_areas", Type: "boolean", Description: "Include every area, not just the manager's assigned areas"}
+	fields := openapi.Param{Name: "fields", Type: "string", Description: "Comma-separated fields to return (e.g. id,bin_number; nested: bins.bin_number)"}
+	shiftHistoryFilters := []openapi.Param{
+		{Name: "from", Type: "integer", Description: "Unix seconds, on ended_at"},
+		{Name: "to", Type: "integer", Description: "Unix seconds, on ended_at"},
+		{Name: "end_reason", Type: "string", Description: "Comma-separated: " + strings.Join(models.ShiftEndReasons, ", ")},
+		{Name: "min_completion_rate", Type: "number", Description: "Percent"},
+		{Name: "max_completion_rate", Type: "number", Description: "Percent"},
+		{Name: "limit", Type: "integer", Description: "Default 50, max 200"},
+		{Name: "offset", Type: "integer"},
+	}
+
+	// Auth
+	spec.Add(
+		openapi.Operation{Method: http.MethodPost, Path: "/api/auth/login", Tag: "Auth", Summary: "Log in with email and password",
+			Request: LoginRequest{}, Response: LoginResponse{}, RawResponse: true},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/auth/status", Tag: "Auth", Auth: apiDriver, Summary: "Current user",
+			RawResponse: true},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/auth/locale", Tag: "Auth", Auth: apiDriver, Summary: "Set the current user's language",
+			Request: updateLocaleRequest{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/config/client", Tag: "Auth", Auth: apiDriver, Summary: "Minimum app version, feature flags, environment and check form for the current user",
+			Query: []openapi.Param{{Name: "app_version", Type: "string", Description: "The app's version (or send X-App-Version)"}}, Response: models.ClientConfigResponse{}},
+	)
+
+	// Documentation
+	spec.Add(
+		openapi.Operation{Method: http.MethodGet, Path: "/api/openapi.json", Tag: "Docs", Summary: "This OpenAPI document", RawResponse: true},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/docs", Tag: "Docs", Summary: "Swagger UI", RawResponse: true},
+	)
+
+	// Geocoding
+	spec.Add(
+		openapi.Operation{Method: http.MethodPost, Path: "/api/geocoding/reverse", Tag: "Geocoding", Summary: "Reverse geocode a coordinate",
+			Request: ReverseGeocodeRequest{}, RawResponse: true},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/geocoding/reverse/batch", Tag: "Geocoding", Summary: "Reverse geocode many coordinates",
+			Request: BatchReverseGeocodeRequest{}, Response: BatchReverseGeocodeResponse{}, RawResponse: true},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/geocoding/forward", Tag: "Geocoding", Summary: "Geocode an address",
+			Request: GeocodeRequest{}, RawResponse: true},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/geocoding/forward/batch", Tag: "Geocoding", Summary: "Geocode many addresses",
+			Request: BatchGeocodeRequest{}, Response: BatchGeocodeResponse{}, RawResponse: true},
+	)
+
+	// Bins, checks and moves
+	spec.Add(
+		openapi.Operation{Method: http.MethodGet, Path: "/api/bins", Tag: "Bins", Summary: "List bins (304 when If-None-Match or If-Modified-Since is current)",
+			Query: []openapi.Param{{Name: "area_id", Type: "string"}, tagID, {Name: "status", Type: "string"}, limit,
+				{Name: "offset", Type: "integer"}, viewID, fields}, Response: []models.BinResponse{}, RawResponse: true},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/bins", Tag: "Bins", Auth: apiAdmin, Summary: "List bins in the manager's areas (304 when If-None-Match or If-Modified-Since is current)",
+			Query: []openapi.Param{{Name: "area_id", Type: "string"}, tagID, {Name: "status", Type: "string"}, limit,
+				{Name: "offset", Type: "integer"}, viewID, fields, allAreas}, Response: []models.BinResponse{}, RawResponse: true},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/bins/changes", Tag: "Bins", Summary: "Bins changed, added or deleted since a time, to update a cached bin list",
+			Query: []openapi.Param{{Name: "since", Type: "integer", Description: "Unix seconds: next_since of the previous call, or 0 (required)"}, fields},
+			Response: models.BinChanges{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/bins/priority", Tag: "Bins", Summary: "List bins sorted and filtered by priority score, with the weights and fill thresholds used to score them",
+			Query: []openapi.Param{{Name: "sort", Type: "string"}, {Name: "filter", Type: "string"}, {Name: "status", Type: "string"},
+				{Name: "area_id", Type: "string"}, tagID, limit, {Name: "offset", Type: "integer"}, viewID}, RawResponse: true},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/bins/nearby", Tag: "Bins", Summary: "Bins within a radius of a point, nearest first",
+			Query: []openapi.Param{
+				{Name: "lat", Type: "number", Description: "Latitude (required)"},
+				{Name: "lng", Type: "number", Description: "Longitude (required)"},
+				{Name: "radius", Type: "number", Description: "Meters (default 500, max 5000)"},
+				limit,
+				{Name: "include_retired", Type: "boolean"},
+			}, Response: models.NearbyBinsResponse{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/bins", Tag: "Bins", Summary: "Create a bin",
+			Request: models.CreateBinRequest{}, Response: models.BinResponse{}, Status: http.StatusCreated, RawResponse: true},
+		openapi.Operation{Method: http.MethodPatch, Path: "/api/bins/{id}", Tag: "Bins", Summary: "Update a bin",
+			Request: models.UpdateBinRequest{}, Response: models.BinResponse{}, RawResponse: true},
+		openapi.Operation{Method: http.MethodDelete, Path: "/api/bins/{id}", Tag: "Bins", Summary: "Delete a bin", RawResponse: true},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/bins/top-performers", Tag: "Bins", Summary: "Bins with the most collections", RawResponse: true},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/bins/batch-geocode", Tag: "Bins", Summary: "Geocode all bins and compare with stored coordinates (optional body: {\"auto_update\": bool})", RawResponse: true},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/bins/{id}/checks", Tag: "Checks", Summary: "A bin's checks",
+			Response: []models.CheckResponse{}, RawResponse: true},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/checks", Tag: "Checks", Summary: "All checks",
+			Response: []models.CheckResponse{}, RawResponse: true},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/bins/{id}/photos", Tag: "Bins", Summary: "A bin's photos from checks, incidents and maintenance (newest first)",
+			Query: []openapi.Param{{Name: "from", Type: "integer", Description: "Unix timestamp"}, {Name: "to", Type: "integer", Description: "Unix timestamp"},
+				{Name: "source", Type: "string", Description: "check, incident or maintenance"}, limit, {Name: "offset", Type: "integer"}},
+			Response: models.BinPhotoPage{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/bins/{id}/fill-history", Tag: "Bins", Summary: "A bin's fill level over time for trend charts (oldest first)",
+			Query: []openapi.Param{{Name: "since", Type: "integer", Description: "Unix timestamp (default: 30 days ago)"}, {Name: "until", Type: "integer", Description: "Unix timestamp (default: now)"},
+				{Name: "resolution", Type: "string", Description: "auto (default: raw up to 2 days, hourly up to 14, daily beyond), raw, hourly or daily"}},
+			Response: models.BinFillHistory{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/bins/{id}/moves", Tag: "Moves", Summary: "A bin's move history",
+			Response: []models.MoveResponse{}, RawResponse: true},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/bins/{id}/moves", Tag: "Moves", Summary: "Record a bin move",
+			Request: models.CreateMoveRequest{}, RawResponse: true},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/bins/{id}/location-history", Tag: "Moves", Summary: "A bin's location timeline reconstructed from its moves",
+			Query:    []openapi.Param{{Name: "at", Type: "integer", Description: "Unix seconds; returns the segment covering this time as at"}},
+			Response: models.BinLocationHistory{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/bins/{id}/move-requests", Tag: "Move Requests", Summary: "A bin's move requests", RawResponse: true},
+	)
+
+	// Route blueprints
+	spec.Add(
+		openapi.Operation{Method: http.MethodGet, Path: "/api/routes", Tag: "Routes", Summary: "List routes", RawResponse: true},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/routes/{id}", Tag: "Routes", Summary: "Get a route with its bins", RawResponse: true},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/routes", Tag: "Routes", Summary: "Create a route",
+			Request: models.CreateRouteRequest{}, Status: http.StatusCreated, RawResponse: true},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/routes/from-tags", Tag: "Routes", Summary: "Create a route from the active bins with some tags, highest weighted priority score first",
+			Request: models.GenerateRouteFromTagsRequest{}, Status: http.StatusCreated},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/routes/optimize-preview", Tag: "Routes", Summary: "Preview an optimized stop order", RawResponse: true},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/routes/test-here-optimization", Tag: "Routes", Summary: "HERE optimization test", RawResponse: true},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/routes/test-mapbox-optimization", Tag: "Routes", Summary: "Mapbox optimization test", RawResponse: true},
+		openapi.Operation{Method: http.MethodPatch, Path: "/api/routes/{id}", Tag: "Routes", Summary: "Update a route",
+			Request: models.UpdateRouteRequest{}, RawResponse: true},
+		openapi.Operation{Method: http.MethodDelete, Path: "/api/routes/{id}", Tag: "Routes", Summary: "Delete a route", RawResponse: true},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/routes/{id}/duplicate", Tag: "Routes", Summary: "Duplicate a route",
+			Request: models.DuplicateRouteRequest{}, Status: http.StatusCreated, RawResponse: true},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/routes/{id}/versions", Tag: "Routes", Summary: "A route's versions, newest first, with the bins added, removed or reordered in each",
+			Response: models.RouteVersionHistory{}, RawResponse: true},
+	)
+
+	// No-go zones and incidents
+	spec.Add(
+		openapi.Operation{Method: http.MethodGet, Path: "/api/no-go-zones", Tag: "Zones", Summary: "List no-go zones"},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/no-go-zones/{id}", Tag: "Zones", Summary: "Get a no-go zone"},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/no-go-zones/{id}/incidents", Tag: "Zones", Summary: "A zone's incidents"},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/shifts/{id}/incidents", Tag: "Zones", Summary: "Incidents reported during a shift"},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/field-observations", Tag: "Zones", Auth: apiAdmin, Summary: "Driver field observations",
+			Query: []openapi.Param{allAreas}},
+		openapi.Operation{Method: http.MethodPatch, Path: "/api/field-observations/{id}/verify", Tag: "Zones", Auth: apiAdmin, Summary: "Verify a field observation"},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/zone-visits", Tag: "Zones", Auth: apiAdmin, Summary: "Drivers' visits to no-go zones, newest first",
+			Query: []openapi.Param{{Name: "driver_id", Type: "string"}, {Name: "zone_id", Type: "string"}, {Name: "shift_id", Type: "string"},
+				{Name: "since", Type: "integer", Description: "Entered at or after (unix)"}, {Name: "until", Type: "integer", Description: "Entered before (unix)"},
+				{Name: "open", Type: "boolean", Description: "Only drivers still inside"}, limit},
+			Response: []models.ZoneVisit{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/export/incidents", Tag: "Zones", Auth: apiAdmin,
+			Summary: "Download incidents with zone, bin and reporter details as CSV or XLSX",
+			Query: []openapi.Param{
+				{Name: "format", Type: "string", Description: "csv (default) or xlsx"},
+				{Name: "from", Type: "string", Description: "Unix timestamp or YYYY-MM-DD"},
+				{Name: "to", Type: "string", Description: "Unix timestamp or YYYY-MM-DD (inclusive)"},
+				{Name: "timezone", Type: "string", Description: "IANA timezone for dates (default UTC)"},
+			}, RawResponse: true},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/import/incidents", Tag: "Zones", Auth: apiAdmin,
+			Summary: "Backfill historical incidents from a CSV or XLSX file (raw body or multipart field \"file\")",
+			Query: []openapi.Param{
+				{Name: "format", Type: "string", Description: "csv or xlsx (detected when omitted)"},
+				{Name: "timezone", Type: "string", Description: "IANA timezone for dates without an offset (default UTC)"},
+				{Name: "dry_run", Type: "boolean", Description: "Validate without saving"},
+			}, Response: models.IncidentImportResult{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/incidents", Tag: "Zones", Auth: apiAdmin,
+			Summary: "Incidents with the labels detected on their photos, newest first",
+			Query: []openapi.Param{
+				{Name: "label", Type: "string", Description: "Comma-separated: graffiti, overflowing, damage (any matches)"},
+				{Name: "min_confidence", Type: "number", Description: "0-1; labels detected below this are ignored"},
+				{Name: "tag_status", Type: "string", Description: "pending, tagged or failed"},
+				{Name: "zone_id", Type: "string"},
+				{Name: "status", Type: "string", Description: "open, resolved or investigating"},
+				{Name: "from", Type: "string", Description: "Unix timestamp or YYYY-MM-DD"},
+				{Name: "to", Type: "string", Description: "Unix timestamp or YYYY-MM-DD (inclusive)"},
+				{Name: "limit", Type: "integer", Description: "1-200 (default 50)"},
+				{Name: "offset", Type: "integer"},
+				allAreas,
+			}, Response: models.TaggedIncidentsResponse{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/saved-views", Tag: "Saved Views", Auth: apiAdmin, Summary: "The caller's saved views and every shared view",
+			Query: []openapi.Param{{Name: "entity_type", Type: "string", Description: "bins or move_requests"}}, Response: []models.SavedView{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/saved-views", Tag: "Saved Views", Auth: apiAdmin, Summary: "Save a filter and sort for the bins or move requests list",
+			Request: models.SavedViewRequest{}, Response: models.SavedView{}, Status: http.StatusCreated},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/saved-views/{id}", Tag: "Saved Views", Auth: apiAdmin, Summary: "Update a saved view (owner only)",
+			Request: models.SavedViewRequest{}, Response: models.SavedView{}},
+		openapi.Operation{Method: http.MethodDelete, Path: "/api/manager/saved-views/{id}", Tag: "Saved Views", Auth: apiAdmin, Summary: "Delete a saved view (owner only)"},
+	)
+
+	// Potential locations
+	spec.Add(
+		openapi.Operation{Method: http.MethodGet, Path: "/api/potential-locations", Tag: "Potential Locations", Summary: "List potential bin locations",
+			Response: []models.PotentialLocationResponse{}, RawResponse: true},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/potential-locations", Tag: "Potential Locations", Auth: apiDriver,
+			Summary: "Suggest potential locations (a single object or an array of CreatePotentialLocationRequest)", Status: http.StatusCreated, RawResponse: true},
+		openapi.Operation{Method: http.MethodDelete, Path: "/api/potential-locations/{id}", Tag: "Potential Locations", Auth: apiAdmin, Summary: "Delete a potential location", RawResponse: true},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/potential-locations/{id}/convert", Tag: "Potential Locations", Auth: apiAdmin,
+			Summary: "Convert a potential location to a bin", Response: models.BinResponse{}, Status: http.StatusCreated, RawResponse: true},
+	)
+
+	// Analytics
+	spec.Add(
+		openapi.Operation{Method: http.MethodGet, Path: "/api/analytics/areas", Tag: "Analytics", Summary: "Per-area performance", RawResponse: true},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/analytics/routes/{id}/efficiency", Tag: "Analytics", Summary: "Planned vs actual distance, duration and stop order across a route's shifts",
+			Query:    []openapi.Param{{Name: "from", Type: "integer", Description: "Shifts ended at or after (unix)"}, {Name: "to", Type: "integer", Description: "Shifts ended at or before (unix)"}, limit},
+			Response: models.RouteEfficiencyResponse{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/analytics/coverage", Tag: "Analytics", Summary: "Active bins with no checks, route stops or completed visits in a date range, grouped by area",
+			Query: []openapi.Param{
+				{Name: "from", Type: "string", Description: "Unix timestamp or YYYY-MM-DD (default: 7 days ago)"},
+				{Name: "to", Type: "string", Description: "Unix timestamp or YYYY-MM-DD, inclusive (default: now)"},
+				{Name: "gap", Type: "string", Description: "checks, routes, visits or any (default)"},
+				{Name: "area_id", Type: "string", Description: "One area, or unassigned"},
+			}, Response: models.CoverageReport{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/analytics/capacity-plan", Tag: "Analytics",
+			Summary: "Shifts needed per day and area for the bins forecast to fill up and the scheduled move requests",
+			Query: []openapi.Param{
+				{Name: "days", Type: "integer", Description: "Days to plan from today (1-28, default 7)"},
+				{Name: "stops_per_shift", Type: "number", Description: "Override the drivers' recent average stops per shift"},
+				{Name: "area_id", Type: "string", Description: "One area, or unassigned"},
+				{Name: "format", Type: "string", Description: "json (default), csv or xlsx"},
+			}, Response: models.CapacityPlan{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/analytics/coverage/draft-route", Tag: "Analytics", Auth: apiAdmin,
+			Summary: "Add the coverage report's bins (or the given bin_ids) to a new draft route",
+			Request: models.CoverageDraftRouteRequest{}, Response: models.CoverageDraftRouteResult{}, Status: http.StatusCreated},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/analytics/maintenance-costs", Tag: "Analytics", Auth: apiAdmin, Summary: "Maintenance costs by type and bin"},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/analytics/route-costs", Tag: "Analytics", Auth: apiAdmin, Summary: "Estimated vs actual shift cost by route and month",
+			Query: []openapi.Param{
+				{Name: "route_id", Type: "string", Description: "Only this route blueprint"},
+				{Name: "since", Type: "integer", Description: "Shifts ended at or after (unix)"},
+				{Name: "until", Type: "integer", Description: "Shifts ended before (unix)"},
+			}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/analytics/dwell-time", Tag: "Analytics", Auth: apiAdmin, Summary: "Time spent at stops (GPS arrival to departure) per driver and task type",
+			Query: []openapi.Param{{Name: "since", Type: "integer", Description: "Stops completed at or after (unix, default: 30 days ago)"}, {Name: "until", Type: "integer", Description: "Stops completed before (unix, default: now)"},
+				{Name: "driver_id", Type: "string"}},
+			Response: models.DwellTimeReport{}},
+	)
+
+	// Driver shift
+	spec.Add(
+		openapi.Operation{Method: http.MethodGet, Path: "/api/driver/shift/current", Tag: "Driver", Auth: apiDriver, Summary: "The driver's current shift with stops",
+			Query: []openapi.Param{fields}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/driver/shift/pre-start-checklist", Tag: "Driver", Auth: apiDriver,
+			Summary: "Vehicle inspection items for the ready shift and any earlier submission", Response: models.DriverPreStartChecklist{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/driver/shift/pre-start-checklist", Tag: "Driver", Auth: apiDriver,
+			Summary: "Submit the vehicle inspection for the ready shift (failed items are flagged to managers)",
+			Request: models.PreStartChecklistRequest{}, Response: models.PreStartChecklist{}, Status: http.StatusCreated},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/driver/shift/start", Tag: "Driver", Auth: apiDriver,
+			Summary:  "Start the assigned shift (428 until the pre-start checklist is submitted, when items are defined)",
+			Response: models.Shift{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/driver/shift/pause", Tag: "Driver", Auth: apiDriver, Summary: "Pause the active shift with a reason (traffic, breakdown, break, weather, or other with a note)",
+			Request: models.PauseShiftRequest{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/driver/shift/resume", Tag: "Driver", Auth: apiDriver, Summary: "Resume a paused shift"},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/driver/shift/end", Tag: "Driver", Auth: apiDriver, Summary: "End the active shift (optional body: {\"debrief\": {\"responses\": {...}, \"notes\": [...]}}; 428 shift_debrief_required when the debrief is required)",
+			Response: models.ShiftEndResponse{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/driver/shift/complete-bin", Tag: "Driver", Auth: apiDriver, Summary: "Complete the next stop for a bin (422 too_far_from_stop when the organization rejects remote completions)",
+			Request: completeStopRequest{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/driver/shift/complete-pickup", Tag: "Driver", Auth: apiDriver, Summary: "Complete a move request pickup",
+			Request: completeStopRequest{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/driver/shift/complete-dropoff", Tag: "Driver", Auth: apiDriver, Summary: "Complete a move request dropoff",
+			Request: completeStopRequest{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/driver/moves/{id}/confirm-pickup", Tag: "Driver", Auth: apiDriver, Summary: "Confirm a move's pickup leg (relocations become picked_up)",
+			Request: models.ConfirmMoveLegRequest{}, Response: models.MoveLegConfirmation{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/driver/moves/{id}/confirm-dropoff", Tag: "Driver", Auth: apiDriver, Summary: "Confirm a move's dropoff leg, completing it and relocating the bin",
+			Request: models.ConfirmMoveLegRequest{}, Response: models.MoveLegConfirmation{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/driver/shift/next-stop", Tag: "Driver", Auth: apiDriver, Summary: "Next stop with ETA and navigation links",
+			Response: NextStopResponse{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/driver/shift-history", Tag: "Driver", Auth: apiDriver, Summary: "The driver's ended shifts, newest first, with a summary of all matching shifts",
+			Query: shiftHistoryFilters, Response: models.ShiftHistoryPage{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/driver/earnings", Tag: "Driver", Auth: apiDriver, Summary: "The driver's credits aggregated by week or month",
+			Query: []openapi.Param{
+				{Name: "period", Type: "string", Description: "week (default) or month"},
+				{Name: "from", Type: "integer", Description: "Unix seconds (default 12 weeks/months ago)"},
+				{Name: "to", Type: "integer", Description: "Unix seconds (default now)"},
+			},
+			Response: models.DriverEarningsResponse{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/driver/shift-details", Tag: "Driver", Auth: apiDriver, Summary: "Details of a past shift",
+			Query: []openapi.Param{{Name: "shift_id", Type: "string"}, fields}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/driver/shift-move-requests", Tag: "Driver", Auth: apiDriver, Summary: "Move requests on the driver's shift"},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/driver/location", Tag: "Driver", Auth: apiDriver, Summary: "Report the driver's GPS position",
+			Request: locationUpdateRequest{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/driver/locations/batch", Tag: "Driver", Auth: apiDriver, Summary: "Upload buffered GPS points (up to 1000, thinned server-side)",
+			Request: locationBatchRequest{}, Response: locationBatchResult{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/driver/field-observations", Tag: "Driver", Auth: apiDriver, Summary: "Report a field observation at a bin (a repeat of an open incident links to it via parent_incident_id)",
+			Request: fieldObservationRequest{}, Response: models.ZoneIncidentResponse{}, Status: http.StatusCreated, RawResponse: true},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/incident-types", Tag: "Driver", Auth: apiDriver, Summary: "Incident types that can be reported, most severe first",
+			Response: []models.IncidentType{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/driver/fcm-token", Tag: "Driver", Auth: apiDriver, Summary: "Register a push notification token",
+			Request: fcmTokenRequest{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/shifts/{shiftId}/tasks", Tag: "Tasks", Auth: apiDriver, Summary: "A shift's tasks"},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/shifts/{shiftId}/tasks/detailed", Tag: "Tasks", Auth: apiDriver, Summary: "A shift's tasks with bin details"},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/shifts/tasks/{taskId}/complete", Tag: "Tasks", Auth: apiDriver, Summary: "Complete a task",
+			Request: models.CompleteTaskRequest{}},
+	)
+
+	// Diagnostics (mounted under /api, so the path repeats it)
+	spec.Add(
+		openapi.Operation{Method: http.MethodPost, Path: "/api/api/logs/diagnostic", Tag: "Diagnostics", Summary: "Upload a mobile diagnostic log (rate limited per device and per caller IP)", RawResponse: true},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/logs/diagnostic", Tag: "Diagnostics", Auth: apiAdmin, Summary: "Stored mobile diagnostic logs",
+			Query: []openapi.Param{{Name: "device_id", Type: "string"}, {Name: "level", Type: "string", Description: "DEBUG, INFO, WARNING or ERROR"},
+				{Name: "category", Type: "string"}, {Name: "platform", Type: "string"}, {Name: "app_version", Type: "string"},
+				{Name: "since", Type: "integer"}, {Name: "until", Type: "integer"}, {Name: "q", Type: "string", Description: "Text search in message and context"},
+				limit, {Name: "offset", Type: "integer"}},
+			Response: []models.DiagnosticLogEntry{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/jobs", Tag: "Diagnostics", Auth: apiAdmin, Summary: "Background jobs, dead-lettered ones by default",
+			Query: []openapi.Param{{Name: "status", Type: "string", Description: "dead (default), pending, running, succeeded or failing (pending after a failed attempt)"},
+				{Name: "kind", Type: "string"}, limit},
+			Response: []models.Job{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/jobs/stats", Tag: "Diagnostics", Auth: apiAdmin, Summary: "Background job counts by kind and status",
+			Response: []models.JobKindStats{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/jobs/{id}/retry", Tag: "Diagnostics", Auth: apiAdmin, Summary: "Queue a dead or backing-off job to run now with fresh attempts",
+			Response: models.Job{}},
+	)
+
+	// Manager: shifts
+	spec.Add(
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/assign-route", Tag: "Shifts", Auth: apiAdmin, Summary: "Assign a route to a driver (409 with the workload preview when over the limits, unless force is set)",
+			Request: assignRouteRequest{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/assign-route/preview", Tag: "Shifts", Auth: apiAdmin, Summary: "Project the driver's workload with the route added (nothing is saved)",
+			Request: assignRouteRequest{}, Response: models.RouteAssignmentPreview{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/assign-route/split/preview", Tag: "Shifts", Auth: apiAdmin, Summary: "Split a bin set across several drivers within the per-driver bins and hours, with each driver's projected workload (nothing is saved)",
+			Request: splitRoutePreviewRequest{}, Response: models.RouteSplitPreview{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/assign-route/split", Tag: "Shifts", Auth: apiAdmin, Summary: "Create one shift per route of a split in one transaction (409 with the workload previews when a driver is over the limits, unless force is set)",
+			Request: splitRouteRequest{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/assign-routes", Tag: "Shifts", Auth: apiAdmin, Summary: "Assign routes to several drivers in one call, each validated up front, with a result per assignment (422 and nothing assigned when all_or_nothing is set and any fails)",
+			Request: models.BulkRouteAssignmentRequest{}, Response: models.BulkRouteAssignmentResult{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/shift-history", Tag: "Shifts", Auth: apiAdmin, Summary: "Ended shifts across drivers, newest first, with a summary of all matching shifts",
+			Query: append([]openapi.Param{{Name: "driver_id", Type: "string"}, {Name: "route_id", Type: "string"}}, shiftHistoryFilters...),
+			Response: models.ShiftHistoryPage{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/export/payroll", Tag: "Shifts", Auth: apiAdmin,
+			Summary: "Download per-driver hours, breaks, bins and credits for a payroll period in the configured columns (409 when lock is refused)",
+			Query: []openapi.Param{
+				{Name: "period", Type: "string", Description: "Required: 2026-10 (month), 2026-W41 (ISO week) or 2026-10-01..2026-10-15"},
+				{Name: "format", Type: "string", Description: "csv (default) or xlsx"},
+				{Name: "lock", Type: "boolean", Description: "Lock the period so its shifts can't be changed (it must have ended)"},
+			}, RawResponse: true},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/payroll/periods", Tag: "Shifts", Auth: apiAdmin, Summary: "Exported payroll periods, latest first, with their lock state",
+			Response: []models.PayrollPeriod{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/payroll/periods/{id}/unlock", Tag: "Shifts", Auth: apiAdmin, Summary: "Unlock a payroll period so its shifts can be corrected",
+			Response: models.PayrollPeriod{}},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/shifts/{id}/cancel", Tag: "Shifts", Auth: apiAdmin, Summary: "Cancel a shift"},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/shifts/{id}/reorder", Tag: "Shifts", Auth: apiAdmin, Summary: "Reorder a shift's remaining stops"},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/shifts/{id}/reoptimize", Tag: "Shifts", Auth: apiAdmin, Summary: "Queue a re-optimization of an active shift's remaining stops (debounced per shift; 202 with the expected run time)",
+			Response: routeReoptimizationResponse{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/shifts/{id}/start-on-behalf", Tag: "Shifts", Auth: apiAdmin,
+			Summary: "Start a driver's ready shift for them (recorded in the on-behalf audit log and synced to the driver's devices)",
+			Request: models.OnBehalfRequest{}, RawResponse: true},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/shifts/{id}/complete-bin-on-behalf", Tag: "Shifts", Auth: apiAdmin,
+			Summary: "Complete a stop on a driver's active shift for them (complete-bin's body plus a reason; never refused for distance)",
+			Request: completeBinOnBehalfRequest{}, RawResponse: true},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/on-behalf-actions", Tag: "Shifts", Auth: apiAdmin, Summary: "Driver actions managers performed on drivers' behalf, newest first",
+			Query: []openapi.Param{{Name: "driver_id", Type: "string"}, {Name: "shift_id", Type: "string"}, {Name: "performed_by", Type: "string", Description: "Manager ID"}, limit},
+			Response: []models.OnBehalfAction{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/shifts/{id}/polyline", Tag: "Shifts", Auth: apiAdmin,
+			Summary: "The shift's route line: driving legs between its stops in sequence order, chained into one encoded polyline (cached per stop sequence)",
+			Query:   []openapi.Param{{Name: "refresh", Type: "boolean", Description: "true recomputes the line instead of using the cache"}},
+			Response: models.RoutePolyline{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/shifts/{id}/timeline", Tag: "Shifts", Auth: apiAdmin, Summary: "Replay a shift as a merged event stream",
+			Query: []openapi.Param{
+				{Name: "granularity", Type: "integer", Description: "Seconds per location sample (default 30, 0 = every ping)"},
+				{Name: "locations", Type: "boolean", Description: "false omits location pings"},
+			}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/shifts/{id}/debrief", Tag: "Shifts", Auth: apiAdmin, Summary: "The driver's end-of-shift debrief and notes",
+			Response: models.ShiftDebrief{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/shift-notes/{id}/dismiss", Tag: "Shifts", Auth: apiAdmin, Summary: "Stop showing a bin note to drivers",
+			Response: models.ShiftNote{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/shifts/repair-sequence", Tag: "Shifts", Auth: apiAdmin, Summary: "Detect and fix duplicate or gapped stop sequence numbers",
+			Query: []openapi.Param{
+				{Name: "shift_id", Type: "string", Description: "Limit to one shift (default: all shifts)"},
+				{Name: "dry_run", Type: "boolean", Description: "true only reports conflicts"},
+			}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/shifts/cancel-all-active", Tag: "Shifts", Auth: apiAdmin, Summary: "Cancel every active, paused and ready shift"},
+		openapi.Operation{Method: http.MethodDelete, Path: "/api/manager/shifts/clear", Tag: "Shifts", Auth: apiAdmin, Summary: "Delete all shifts"},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/shifts/create-with-tasks", Tag: "Shifts", Auth: apiAdmin, Summary: "Create a shift from a task list",
+			Request: models.CreateShiftWithTasksRequest{}, Response: models.CreateShiftWithTasksResponse{}, Status: http.StatusCreated},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/shifts/{shiftId}", Tag: "Shifts", Auth: apiAdmin, Summary: "Get a shift"},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/shift-templates", Tag: "Shift templates", Auth: apiAdmin, Summary: "List shift templates",
+			Response: []models.ShiftTemplate{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/shift-templates", Tag: "Shift templates", Auth: apiAdmin, Summary: "Create a recurring shift template",
+			Request: shiftTemplateRequest{}, Response: models.ShiftTemplate{}, Status: http.StatusCreated},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/shift-templates/{id}", Tag: "Shift templates", Auth: apiAdmin, Summary: "Update a shift template",
+			Request: shiftTemplateRequest{}, Response: models.ShiftTemplate{}},
+		openapi.Operation{Method: http.MethodDelete, Path: "/api/manager/shift-templates/{id}", Tag: "Shift templates", Auth: apiAdmin, Summary: "Delete a shift template"},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/shift-templates/runs", Tag: "Shift templates", Auth: apiAdmin, Summary: "Materialized shifts and conflicts",
+			Query:    []openapi.Param{{Name: "date", Type: "string", Description: "Service date (YYYY-MM-DD)"}, {Name: "status", Type: "string", Description: "created or skipped"}},
+			Response: []models.ShiftTemplateRun{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/shift-templates/materialize", Tag: "Shift templates", Auth: apiAdmin, Summary: "Instantiate tomorrow's shifts from templates now",
+			Response: services.ShiftTemplateMaterializeResult{}},
+	)
+
+	// Manager: bins and move requests
+	spec.Add(
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/bins/load-real", Tag: "Bins", Auth: apiAdmin, Summary: "One-time bin import", RawResponse: true},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/bins/fix-status", Tag: "Bins", Auth: apiAdmin, Summary: "One-time bin status fix", RawResponse: true},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/bins/{id}/retire", Tag: "Bins", Auth: apiAdmin, Summary: "Retire or store a bin",
+			Request: retireBinRequest{}, RawResponse: true},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/bins/reservation-conflicts", Tag: "Bins", Auth: apiAdmin, Summary: "Bins that are stops on more than one open shift, with each shift and driver",
+			Response: []models.BinReservationConflict{}},
+		openapi.Operation{Method: http.MethodPatch, Path: "/api/manager/bins/bulk", Tag: "Bins", Auth: apiAdmin, Summary: "Set status, city or flags on many bins in one transaction, with a result per bin (422 with all_or_nothing when any bin fails)",
+			Request: models.BulkBinUpdateRequest{}, Response: models.BulkBinUpdateResult{}},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/bins/{id}/time-window", Tag: "Bins", Auth: apiAdmin, Summary: "Set or clear the hours a bin may be collected",
+			Request: models.SetTimeWindowRequest{}, Response: models.BinResponse{}},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/bins/{id}/photo-required", Tag: "Bins", Auth: apiAdmin, Summary: "Require a photo with every check of a bin (or stop requiring one)",
+			Request: setPhotoRequiredRequest{}, Response: models.BinResponse{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/bins/duplicates", Tag: "Bins", Auth: apiAdmin, Summary: "Groups of bins that look like the same bin (similar address, close together)",
+			Query: []openapi.Param{
+				{Name: "radius_meters", Type: "number", Description: "Maximum distance between bins with coordinates (default 50)"},
+				{Name: "min_similarity", Type: "number", Description: "Minimum address similarity, 0-1 (default 0.85)"},
+			}, Response: []models.BinDuplicateGroup{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/bins/merges", Tag: "Bins", Auth: apiAdmin, Summary: "Bin merge audit log, newest first",
+			Query: []openapi.Param{limit}, Response: []models.BinMerge{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/bins/{id}/merge-into/{targetId}", Tag: "Bins", Auth: apiAdmin,
+			Summary: "Merge a duplicate bin into another: its history moves to the target and it is deleted", Response: models.BinMergeResult{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/bins/reconciliation", Tag: "Bins", Auth: apiAdmin,
+			Summary: "Orphaned checks, moves, incidents and move requests by missing bin ID, with proposed remaps",
+			Response: []models.OrphanedBinReference{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/bins/reconciliation/apply", Tag: "Bins", Auth: apiAdmin,
+			Summary: "Remap or delete the orphaned rows of missing bin IDs in one transaction",
+			Request: models.BinReconciliationRequest{}, Response: models.BinReconciliationReport{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/bins/reconciliation/history", Tag: "Bins", Auth: apiAdmin,
+			Summary: "Bin reconciliation audit log, newest first", Query: []openapi.Param{limit}, Response: []models.BinReconciliation{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/bins/needs-geocoding", Tag: "Bins", Auth: apiAdmin, Summary: "Bins without coordinates, which can't be routed until geocoded",
+			Query: []openapi.Param{
+				{Name: "include_retired", Type: "boolean", Description: "Also list retired bins"},
+				limit,
+			}, Response: []models.BinMissingCoordinates{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/bins/schedule-move", Tag: "Move Requests", Auth: apiAdmin, Summary: "Schedule a bin move",
+			Request: models.CreateBinMoveRequest{}, RawResponse: true},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/bins/move-requests", Tag: "Move Requests", Auth: apiAdmin, Summary: "List move requests",
+			Query: []openapi.Param{{Name: "status", Type: "string"}, {Name: "urgency", Type: "string"}, {Name: "assigned", Type: "string"},
+				{Name: "move_type", Type: "string"}, {Name: "approval_status", Type: "string", Description: "requested, approved or rejected"},
+				{Name: "sort", Type: "string", Description: "scheduled_date (default) or created_at"},
+				limit, {Name: "offset", Type: "integer"}, viewID, fields, allAreas},
+			Response: []models.BinMoveRequestResponse{}, RawResponse: true},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/bins/move-requests/{id}", Tag: "Move Requests", Auth: apiAdmin, Summary: "Get a move request",
+			Response: models.BinMoveRequestResponse{}, RawResponse: true},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/bins/move-requests/{id}", Tag: "Move Requests", Auth: apiAdmin, Summary: "Update a move request", RawResponse: true},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/bins/move-requests/{id}/assign-to-shift", Tag: "Move Requests", Auth: apiAdmin,
+			Summary: "Insert a move request into a shift", Query: []openapi.Param{{Name: "preview", Type: "boolean", Description: "true returns the resulting route without saving"}}, RawResponse: true},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/bins/move-requests/{id}/assign-to-shift/preview", Tag: "Move Requests", Auth: apiAdmin,
+			Summary: "Preview inserting a move request into a shift", Response: MoveAssignmentPreview{}},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/bins/move-requests/{id}/cancel", Tag: "Move Requests", Auth: apiAdmin, Summary: "Cancel a move request (the response's undo can be reverted until it expires)", RawResponse: true},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/bins/move-requests/{id}/approve", Tag: "Move Requests", Auth: apiAdmin,
+			Summary: "Approve a requested move (it becomes pending)", Response: models.BinMoveRequestResponse{}},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/bins/move-requests/{id}/reject", Tag: "Move Requests", Auth: apiAdmin,
+			Summary: "Reject a requested move with a reason", Request: models.MoveApprovalDecisionRequest{}, Response: models.BinMoveRequestResponse{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/move-requests", Tag: "Move Requests", Auth: apiDriver,
+			Summary: "Request a bin move (non-admin requests wait for approval)", Request: models.CreateBinMoveRequest{}, RawResponse: true},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/move-requests/mine", Tag: "Move Requests", Auth: apiDriver, Summary: "Move requests the signed-in user asked for",
+			Query: []openapi.Param{{Name: "approval_status", Type: "string", Description: "requested, approved or rejected"}}, Response: []models.BinMoveRequestResponse{}},		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/bins/move-requests/{id}/assign-to-user", Tag: "Move Requests", Auth: apiAdmin, Summary: "Assign a move request to a user", RawResponse: true},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/bins/move-requests/{id}/clear-assignment", Tag: "Move Requests", Auth: apiAdmin, Summary: "Unassign a move request (the response's undo can be reverted until it expires)", RawResponse: true},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/bins/move-requests/{id}/complete-manually", Tag: "Move Requests", Auth: apiAdmin, Summary: "Complete a manual move request", RawResponse: true},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/bins/move-requests/{id}/history", Tag: "Move Requests", Auth: apiAdmin, Summary: "A move request's audit trail", RawResponse: true},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/bins/move-requests/{id}/instructions", Tag: "Move Requests", Auth: apiAdmin,
+			Summary: "Set a move request's site instructions (gate code, contact, access notes)", Request: models.MoveInstructions{}, Response: models.MoveInstructions{}},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/bins/move-requests/{id}/time-window", Tag: "Move Requests", Auth: apiAdmin,
+			Summary: "Set or clear a move request's service window", Request: models.SetTimeWindowRequest{}, Response: models.BinMoveRequestResponse{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/bins/move-requests/{id}/attachments", Tag: "Move Requests", Auth: apiAdmin,
+			Summary: "A move request's attachments", Response: []models.MoveRequestAttachment{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/bins/move-requests/{id}/attachments", Tag: "Move Requests", Auth: apiAdmin,
+			Summary: "Attach a photo or document to a move request", Request: models.CreateMoveRequestAttachmentRequest{}, Response: models.MoveRequestAttachment{}},
+		openapi.Operation{Method: http.MethodDelete, Path: "/api/manager/bins/move-requests/{id}/attachments/{attachmentId}", Tag: "Move Requests", Auth: apiAdmin,
+			Summary: "Remove a move request attachment"},
+	)
+
+	// Manager: check recommendations and maintenance
+	spec.Add(
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/bins/flag-stale", Tag: "Recommendations", Auth: apiAdmin, Summary: "Flag bins not checked in 7 days", RawResponse: true},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/bins/check-recommendations", Tag: "Recommendations", Auth: apiAdmin, Summary: "List check recommendations", RawResponse: true},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/bins/check-recommendations/{id}/dismiss", Tag: "Recommendations", Auth: apiAdmin, Summary: "Dismiss a recommendation", RawResponse: true},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/bins/check-recommendations/{id}/convert", Tag: "Recommendations", Auth: apiAdmin, Summary: "Turn a recommendation into a shift", RawResponse: true},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/bins/check-recommendations/run", Tag: "Recommendations", Auth: apiAdmin, Summary: "Run the recommendation engine now"},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/bins/{id}/maintenance", Tag: "Maintenance", Auth: apiAdmin, Summary: "A bin's maintenance history",
+			Response: []models.BinMaintenanceWithBin{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/bins/{id}/maintenance", Tag: "Maintenance", Auth: apiAdmin, Summary: "Log or schedule maintenance",
+			Request: binMaintenanceRequest{}, Response: models.BinMaintenance{}, Status: http.StatusCreated},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/bins/{id}/notes", Tag: "Bins", Auth: apiAdmin, Summary: "Notes drivers left about a bin in their shift debriefs",
+			Query: []openapi.Param{
+				{Name: "include_dismissed", Type: "boolean", Description: "Include dismissed notes"},
+				{Name: "limit", Type: "integer", Description: "Max results (default 50, max 500)"},
+			},
+			Response: []models.ShiftNote{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/maintenance", Tag: "Maintenance", Auth: apiAdmin, Summary: "Scheduled maintenance",
+			Response: []models.BinMaintenanceWithBin{}},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/maintenance/{id}", Tag: "Maintenance", Auth: apiAdmin, Summary: "Update a maintenance record",
+			Request: binMaintenanceRequest{}, Response: models.BinMaintenance{}},
+	)
+
+	// Manager: areas and settings
+	spec.Add(
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/areas", Tag: "Areas", Auth: apiAdmin, Summary: "List areas",
+			Response: []models.AreaWithCounts{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/areas", Tag: "Areas", Auth: apiAdmin, Summary: "Create an area from a GeoJSON boundary",
+			Request: areaRequest{}, Response: models.Area{}, Status: http.StatusCreated},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/areas/assign", Tag: "Areas", Auth: apiAdmin, Summary: "Reassign bins and zones to areas now"},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/areas/{id}", Tag: "Areas", Auth: apiAdmin, Summary: "Rename an area or replace its boundary",
+			Request: areaRequest{}, Response: models.Area{}},
+		openapi.Operation{Method: http.MethodDelete, Path: "/api/manager/areas/{id}", Tag: "Areas", Auth: apiAdmin, Summary: "Delete an area"},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/managers", Tag: "Areas", Auth: apiAdmin, Summary: "Managers with the areas they're assigned to",
+			Response: []models.ManagerAreas{}},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/managers/{id}/areas", Tag: "Areas", Auth: apiAdmin, Summary: "Replace a manager's areas (scoping their lists and live updates)",
+			Request: models.ManagerAreasRequest{}, Response: models.ManagerAreas{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/tags", Tag: "Tags", Auth: apiAdmin, Summary: "List bin tags with their bin counts",
+			Response: []models.TagWithCount{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/tags", Tag: "Tags", Auth: apiAdmin, Summary: "Create a bin tag",
+			Request: models.TagRequest{}, Response: models.Tag{}, Status: http.StatusCreated},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/tags/{id}", Tag: "Tags", Auth: apiAdmin, Summary: "Rename or recolor a tag",
+			Request: models.TagRequest{}, Response: models.Tag{}},
+		openapi.Operation{Method: http.MethodDelete, Path: "/api/manager/tags/{id}", Tag: "Tags", Auth: apiAdmin, Summary: "Delete a tag (removing it from every bin)"},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/tags/{id}/bins", Tag: "Tags", Auth: apiAdmin, Summary: "Add a tag to several bins",
+			Request: models.TagBinsRequest{}},
+		openapi.Operation{Method: http.MethodDelete, Path: "/api/manager/tags/{id}/bins/{binId}", Tag: "Tags", Auth: apiAdmin, Summary: "Remove a tag from a bin"},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/bins/{id}/tags", Tag: "Tags", Auth: apiAdmin, Summary: "Replace a bin's tags",
+			Request: models.BinTagsRequest{}, Response: []models.TagLabel{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/incident-types", Tag: "Zones", Auth: apiAdmin, Summary: "Incident types, archived ones included, with their incident counts",
+			Response: []models.IncidentTypeWithCount{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/incident-types", Tag: "Zones", Auth: apiAdmin, Summary: "Create an incident type with its severity and zone impact",
+			Request: models.IncidentTypeRequest{}, Response: models.IncidentType{}, Status: http.StatusCreated},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/incident-types/{key}", Tag: "Zones", Auth: apiAdmin, Summary: "Update an incident type, or archive it with active: false",
+			Request: models.IncidentTypeRequest{}, Response: models.IncidentType{}},
+		openapi.Operation{Method: http.MethodDelete, Path: "/api/manager/incident-types/{key}", Tag: "Zones", Auth: apiAdmin, Summary: "Delete an unused incident type (409 when incidents have it)"},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/incident-subscriptions", Tag: "Zones", Auth: apiAdmin, Summary: "Your incident notification subscriptions (none = no incident notifications)",
+			Response: []models.IncidentSubscription{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/incident-subscriptions", Tag: "Zones", Auth: apiAdmin,
+			Summary: "Subscribe to new incidents by type, severity and area over WebSocket and/or push, immediately or in a daily summary",
+			Request: models.IncidentSubscriptionRequest{}, Response: models.IncidentSubscription{}, Status: http.StatusCreated},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/incident-subscriptions/{id}", Tag: "Zones", Auth: apiAdmin, Summary: "Replace an incident subscription's filters, channels and delivery",
+			Request: models.IncidentSubscriptionRequest{}, Response: models.IncidentSubscription{}},
+		openapi.Operation{Method: http.MethodDelete, Path: "/api/manager/incident-subscriptions/{id}", Tag: "Zones", Auth: apiAdmin, Summary: "Delete an incident subscription"},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/areas/{id}/photo-required", Tag: "Areas", Auth: apiAdmin, Summary: "Require a photo with every check of the area's bins (or stop requiring one)",
+			Request: setPhotoRequiredRequest{}, Response: models.Area{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/photo-requirements", Tag: "Areas", Auth: apiAdmin, Summary: "Areas and bins that require a photo with every check",
+			Response: models.PhotoRequirements{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/analytics/photo-compliance", Tag: "Analytics", Auth: apiAdmin, Summary: "Checks of photo-required bins with and without a photo, per driver",
+			Query:    []openapi.Param{{Name: "since", Type: "integer", Description: "Unix timestamp (default: 30 days ago)"}, {Name: "until", Type: "integer", Description: "Unix timestamp (default: now)"}},
+			Response: models.PhotoComplianceReport{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/analytics/remote-completions", Tag: "Analytics", Auth: apiAdmin, Summary: "Shift stops completed too far from the stop, per driver",
+			Query:    []openapi.Param{{Name: "since", Type: "integer", Description: "Unix timestamp (default: 30 days ago)"}, {Name: "until", Type: "integer", Description: "Unix timestamp (default: now)"}},
+			Response: models.RemoteCompletionReport{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/analytics/notification-deliveries", Tag: "Analytics", Auth: apiAdmin, Summary: "Push notification delivery outcomes per driver (sent, retrying, failed, expired)",
+			Query:    []openapi.Param{{Name: "since", Type: "integer", Description: "Unix timestamp (default: 7 days ago)"}, {Name: "until", Type: "integer", Description: "Unix timestamp (default: now)"}},
+			Response: models.PushDeliveryReport{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/settings/priority-weights", Tag: "Settings", Auth: apiAdmin, Summary: "Priority scoring weights"},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/settings/priority-weights", Tag: "Settings", Auth: apiAdmin, Summary: "Update priority scoring weights"},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/settings/earnings-rates", Tag: "Settings", Auth: apiAdmin, Summary: "Driver earnings rates"},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/settings/earnings-rates", Tag: "Settings", Auth: apiAdmin, Summary: "Update driver earnings rates (applies to shifts ended afterwards)"},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/settings/move-sla", Tag: "Settings", Auth: apiAdmin, Summary: "Move request SLA (assignment and completion deadlines per urgency)"},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/settings/move-sla", Tag: "Settings", Auth: apiAdmin, Summary: "Update the move request SLA (any subset of fields, or {\"reset\": true})"},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/settings/client-config", Tag: "Settings", Auth: apiAdmin, Summary: "App version policy and feature flags"},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/settings/client-config", Tag: "Settings", Auth: apiAdmin, Summary: "Update the app version policy and feature flags (any subset of fields, or {\"reset\": true})"},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/settings/workload-limits", Tag: "Settings", Auth: apiAdmin, Summary: "Driver workload limits checked on route assignment"},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/settings/workload-limits", Tag: "Settings", Auth: apiAdmin, Summary: "Update the driver workload limits (any subset of fields, or {\"reset\": true})"},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/settings/digest", Tag: "Settings", Auth: apiAdmin, Summary: "Bins-at-risk digest schedule and thresholds"},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/settings/digest", Tag: "Settings", Auth: apiAdmin, Summary: "Update the digest schedule and thresholds (any subset of fields, or {\"reset\": true})"},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/settings/undo", Tag: "Settings", Auth: apiAdmin, Summary: "How long destructive actions can be undone"},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/settings/undo", Tag: "Settings", Auth: apiAdmin, Summary: "Update the undo window ({\"window_seconds\": n}, 0 turns undo off, or {\"reset\": true})"},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/settings/zone-risk-routing", Tag: "Settings", Auth: apiAdmin, Summary: "How strongly stops inside no-go zones are pulled earlier in optimized routes"},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/settings/zone-risk-routing", Tag: "Settings", Auth: apiAdmin, Summary: "Update zone risk routing weights (partial update, or {\"reset\": true})"},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/settings/retention", Tag: "Settings", Auth: apiAdmin, Summary: "Days GPS breadcrumbs, diagnostic logs and checks are kept (0 = forever)"},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/settings/retention", Tag: "Settings", Auth: apiAdmin, Summary: "Update data retention periods (partial update, or {\"reset\": true})"},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/settings/service-hours", Tag: "Settings", Auth: apiAdmin, Summary: "Service hours: when non-urgent pushes are delivered and how after-hours assignments are handled"},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/settings/service-hours", Tag: "Settings", Auth: apiAdmin, Summary: "Update service hours (partial update, or {\"reset\": true})"},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/settings/cost-rates", Tag: "Settings", Auth: apiAdmin, Summary: "Driver hourly and per-km rates shifts are priced with"},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/settings/cost-rates", Tag: "Settings", Auth: apiAdmin, Summary: "Update shift cost rates (partial update, or {\"reset\": true})"},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/settings/check-form", Tag: "Settings", Auth: apiAdmin, Summary: "Extra fields drivers fill in with each check (e.g. contamination level)"},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/settings/check-form", Tag: "Settings", Auth: apiAdmin, Summary: "Replace the check form fields ({\"fields\": [...]}, or {\"reset\": true})"},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/settings/shift-debrief", Tag: "Settings", Auth: apiAdmin, Summary: "End-of-shift debrief: whether it's required and its questions"},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/settings/shift-debrief", Tag: "Settings", Auth: apiAdmin, Summary: "Update the debrief settings (partial; questions are replaced as a whole; or {\"reset\": true})"},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/settings/check-in-proximity", Tag: "Settings", Auth: apiAdmin, Summary: "How far from a stop drivers may complete it, and whether remote completions are flagged or rejected"},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/settings/check-in-proximity", Tag: "Settings", Auth: apiAdmin, Summary: "Update the check-in proximity settings (partial, or {\"reset\": true})"},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/settings/fill-thresholds", Tag: "Settings", Auth: apiAdmin, Summary: "Fill percentages at which bins are flagged warning and critical"},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/settings/fill-thresholds", Tag: "Settings", Auth: apiAdmin, Summary: "Update the warning and critical fill thresholds (partial, or {\"reset\": true})"},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/settings/payroll-export", Tag: "Settings", Auth: apiAdmin, Summary: "Payroll export column mapping, timezone and hour rounding, with the available fields"},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/settings/payroll-export", Tag: "Settings", Auth: apiAdmin, Summary: "Update the payroll export settings (partial; columns are replaced as a whole; or {\"reset\": true})"},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/settings/incident-dedup", Tag: "Settings", Auth: apiAdmin, Summary: "Window within which a repeat report of an open incident (same bin and type) is linked to it instead of scoring the zone again"},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/settings/incident-dedup", Tag: "Settings", Auth: apiAdmin, Summary: "Update the incident dedup window (window_hours, 0 turns it off; or {\"reset\": true})"},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/undo", Tag: "Undo", Auth: apiAdmin, Summary: "Actions that can still be undone, newest first",
+			Response: []models.UndoOperation{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/undo/{operation_id}", Tag: "Undo", Auth: apiAdmin, Summary: "Undo an action within its window (409 if the record changed since, 410 once expired)",
+			Response: models.UndoOperation{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/analytics/move-sla", Tag: "Move Requests", Auth: apiAdmin, Summary: "SLA compliance of move requests created in a period, per urgency",
+			Query:    []openapi.Param{{Name: "since", Type: "integer", Description: "Unix timestamp (default: 30 days ago)"}, {Name: "until", Type: "integer", Description: "Unix timestamp (default: now)"}},
+			Response: models.MoveSLAReport{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/move-sla/check", Tag: "Move Requests", Auth: apiAdmin, Summary: "Run the SLA checker now and return the breaches it flagged",
+			Response: []models.MoveSLABreach{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/digest", Tag: "Digest", Auth: apiAdmin, Summary: "The latest bins-at-risk digest",
+			Query: []openapi.Param{{Name: "date", Type: "string", Description: "YYYY-MM-DD (default: the latest digest)"}},
+			Response: models.BinsAtRiskDigest{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/digest/send", Tag: "Digest", Auth: apiAdmin, Summary: "Compile and send today's digest now",
+			Response: models.BinsAtRiskDigest{}},
+	)
+
+	// Manager: notifications and webhooks
+	spec.Add(
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/notifications/broadcast", Tag: "Notifications", Auth: apiAdmin, Summary: "Broadcast a push notification to a role or everyone"},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/webhooks", Tag: "Webhooks", Auth: apiAdmin, Summary: "List webhooks",
+			Response: []models.Webhook{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/webhooks", Tag: "Webhooks", Auth: apiAdmin, Summary: "Register a webhook (the signing secret is returned once)",
+			Request: webhookRequest{}, Status: http.StatusCreated},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/webhooks/{id}", Tag: "Webhooks", Auth: apiAdmin, Summary: "Update a webhook",
+			Request: webhookRequest{}, Response: models.Webhook{}},
+		openapi.Operation{Method: http.MethodDelete, Path: "/api/manager/webhooks/{id}", Tag: "Webhooks", Auth: apiAdmin, Summary: "Delete a webhook"},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/webhooks/{id}/test", Tag: "Webhooks", Auth: apiAdmin, Summary: "Send a test event",
+			Status: http.StatusAccepted},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/webhooks/{id}/deliveries", Tag: "Webhooks", Auth: apiAdmin, Summary: "A webhook's delivery log",
+			Query: []openapi.Param{{Name: "status", Type: "string"}, {Name: "event_type", Type: "string"}, limit}, Response: []models.WebhookDelivery{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/webhooks/deliveries/{id}/redrive", Tag: "Webhooks", Auth: apiAdmin, Summary: "Requeue a delivery",
+			Response: models.WebhookDelivery{}},
+	)
+
+	// Machine clients: API keys and IoT fill sensors
+	spec.Add(
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/checks/review-queue", Tag: "Checks", Auth: apiAdmin, Summary: "Checks flagged as anomalous (newest first)",
+			Query: []openapi.Param{
+				{Name: "status", Type: "string", Description: "pending (default), confirmed, dismissed or all"},
+				{Name: "flag", Type: "string", Description: "Only checks with this flag (e.g. remote_completion)"},
+				limit,
+			},
+			Response: []models.FlaggedCheck{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/checks/{id}/review", Tag: "Checks", Auth: apiAdmin, Summary: "Confirm or dismiss a flagged check",
+			Request: models.ReviewCheckRequest{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/api-keys", Tag: "API Keys", Auth: apiAdmin, Summary: "List API keys",
+			Response: []models.APIKey{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/api-keys", Tag: "API Keys", Auth: apiAdmin, Summary: "Create an API key (the key is returned once)",
+			Request: models.CreateAPIKeyRequest{}, Status: http.StatusCreated},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/api-keys/usage", Tag: "API Keys", Auth: apiAdmin, Summary: "Metered traffic per API key: totals, rate-limited requests, peak minute, endpoints and a series",
+			Query: []openapi.Param{
+				{Name: "days", Type: "integer", Description: "Period in days (default 7, max 90)"},
+				{Name: "interval", Type: "string", Description: "hour or day (default hour up to 2 days, else day)"},
+			},
+			Response: models.APIKeyUsageReport{}},
+		openapi.Operation{Method: http.MethodPatch, Path: "/api/manager/api-keys/{id}", Tag: "API Keys", Auth: apiAdmin, Summary: "Rename an API key or change its rate limit",
+			Request: models.UpdateAPIKeyRequest{}, Response: models.APIKey{}},
+		openapi.Operation{Method: http.MethodDelete, Path: "/api/manager/api-keys/{id}", Tag: "API Keys", Auth: apiAdmin, Summary: "Revoke an API key"},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/sensors", Tag: "Sensors", Auth: apiAdmin, Summary: "Fill sensor registry",
+			Response: []models.BinSensor{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/sensors", Tag: "Sensors", Auth: apiAdmin, Summary: "Register a sensor",
+			Request: models.BinSensorRequest{}, Response: models.BinSensor{}, Status: http.StatusCreated},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/sensors/{id}", Tag: "Sensors", Auth: apiAdmin, Summary: "Remount, describe or deactivate a sensor",
+			Request: models.BinSensorRequest{}, Response: models.BinSensor{}},
+		openapi.Operation{Method: http.MethodDelete, Path: "/api/manager/sensors/{id}", Tag: "Sensors", Auth: apiAdmin, Summary: "Remove a sensor"},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/ingest/sensor-readings", Tag: "Sensors", Auth: apiKey,
+			Summary: "Ingest batched fill readings (anomalous readings are rejected individually)",
+			Request: models.SensorReadingBatch{}, Response: models.SensorIngestResult{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/public/bins", Tag: "Public", Auth: apiKey,
+			Summary: "Public bin locator: active bins' number, address and coordinates (bin_locator scope; 429 over the key's rate limit)",
+			Query: []openapi.Param{
+				{Name: "lat", Type: "number", Description: "Required: search around this point (with lng); nearest first"},
+				{Name: "lng", Type: "number", Description: "Required"},
+				{Name: "radius", Type: "number", Description: "Meters (default 5000, max 50000)"},
+				{Name: "limit", Type: "integer", Description: "Default 100, max 500"},
+				{Name: "offset", Type: "integer"},
+			},
+			Response: models.PublicBinsResponse{}},
+	)
+
+	// Manager: pre-start vehicle inspection
+	spec.Add(
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/pre-start-checklist/items", Tag: "Pre-Start Checklist", Auth: apiAdmin,
+			Summary: "Inspection items drivers answer before starting a shift", Response: []models.PreStartChecklistItem{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/pre-start-checklist/items", Tag: "Pre-Start Checklist", Auth: apiAdmin,
+			Summary: "Add an inspection item", Request: models.PreStartChecklistItemRequest{}, Response: models.PreStartChecklistItem{}, Status: http.StatusCreated},
+		openapi.Operation{Method: http.MethodPut, Path: "/api/manager/pre-start-checklist/items/{id}", Tag: "Pre-Start Checklist", Auth: apiAdmin,
+			Summary: "Update an inspection item", Request: models.PreStartChecklistItemRequest{}, Response: models.PreStartChecklistItem{}},
+		openapi.Operation{Method: http.MethodDelete, Path: "/api/manager/pre-start-checklist/items/{id}", Tag: "Pre-Start Checklist", Auth: apiAdmin,
+			Summary: "Delete an inspection item (past answers keep its label)"},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/pre-start-checklists", Tag: "Pre-Start Checklist", Auth: apiAdmin,
+			Summary: "Submitted inspections, newest first",
+			Query: []openapi.Param{{Name: "failed", Type: "boolean", Description: "Only inspections with failed items"}, {Name: "driver_id", Type: "string"},
+				{Name: "since", Type: "integer"}, limit},
+			Response: []models.PreStartChecklist{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/shifts/{id}/pre-start-checklist", Tag: "Pre-Start Checklist", Auth: apiAdmin,
+			Summary: "The inspection submitted for a shift", Response: models.PreStartChecklist{}},
+	)
+
+	// Manager: fleet, users and security
+	spec.Add(
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/drivers", Tag: "Fleet", Auth: apiAdmin, Summary: "All drivers with their current status",
+			Query: []openapi.Param{includeDeactivated, allAreas}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/drivers/{id}/familiarity", Tag: "Fleet", Auth: apiAdmin, Summary: "Routes and areas a driver has worked",
+			Query: []openapi.Param{{Name: "days", Type: "integer", Description: "History window in days (default 180)"}}, Response: models.DriverFamiliarity{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/drivers/{id}/notification-deliveries", Tag: "Fleet", Auth: apiAdmin, Summary: "A driver's latest push deliveries, one per device, with FCM message IDs and errors",
+			Query:    []openapi.Param{{Name: "status", Type: "string", Description: "sent, retrying, failed or expired"}, {Name: "limit", Type: "integer", Description: "Max deliveries (default 50, max 200)"}},
+			Response: []models.PushDelivery{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/assign-route/recommendations", Tag: "Shifts", Auth: apiAdmin,
+			Summary:  "Drivers ranked for a route by familiarity, proximity and current workload",
+			Query:    []openapi.Param{{Name: "route_id", Type: "string", Description: "Route blueprint (required)"}, {Name: "days", Type: "integer", Description: "History window in days (default 180)"}, limit},
+			Response: models.RouteDriverRecommendations{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/active-drivers", Tag: "Fleet", Auth: apiAdmin, Summary: "Drivers on shift with live positions",
+			Query: []openapi.Param{allAreas}, RawResponse: true},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/fleet/live", Tag: "Fleet", Auth: apiAdmin, Summary: "Connected drivers with position, current stop, ETA and staleness",
+			Query:    []openapi.Param{{Name: "stale_after", Type: "integer", Description: "Seconds without a location update before a driver is stale (default 60)"}},
+			Response: models.FleetSnapshot{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/websocket/clients", Tag: "Fleet", Auth: apiAdmin, Summary: "Users connected to the WebSocket hub with durations, last messages and topics",
+			Response: webSocketClientsResponse{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/websocket/clients/{userId}/disconnect", Tag: "Fleet", Auth: apiAdmin, Summary: "Force-disconnect a user's WebSocket connection",
+			Request: disconnectWebSocketClientRequest{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/driver-shift-details", Tag: "Fleet", Auth: apiAdmin, Summary: "A driver's shift in detail", RawResponse: true},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/simulations", Tag: "Fleet", Auth: apiAdmin, Summary: "Running and recently ended route simulations, newest first",
+			Response: simulationsResponse{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/simulations", Tag: "Fleet", Auth: apiAdmin, Summary: "Start virtual drivers on a route for training and demos (requires SIMULATION_ENABLED=true)",
+			Request: models.StartSimulationRequest{}, Response: models.SimulationRun{}, Status: http.StatusCreated},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/simulations/{id}", Tag: "Fleet", Auth: apiAdmin, Summary: "A route simulation with its drivers' positions",
+			Response: models.SimulationRun{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/simulations/{id}/stop", Tag: "Fleet", Auth: apiAdmin, Summary: "Stop a route simulation",
+			Response: models.SimulationRun{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/users", Tag: "Users", Auth: apiAdmin, Summary: "List users",
+			Query: []openapi.Param{includeDeactivated}, RawResponse: true},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/users", Tag: "Users", Auth: apiAdmin, Summary: "Create a user",
+			Request: CreateUserRequest{}, Response: CreateUserResponse{}, Status: http.StatusCreated, RawResponse: true},
+		openapi.Operation{Method: http.MethodPatch, Path: "/api/manager/users/{id}", Tag: "Users", Auth: apiAdmin,
+			Summary: "Deactivate a user (blocks login, ends their shift and releases their move requests) or reactivate them",
+			Request: UpdateUserRequest{}, Response: models.UserUpdateResponse{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/users/{id}/unlock", Tag: "Security", Auth: apiAdmin, Summary: "Clear a login lockout"},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/users/{id}/data-export", Tag: "Users", Auth: apiAdmin, Summary: "Export everything stored about a user as one JSON archive", Response: models.UserDataExport{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/users/{id}/anonymize", Tag: "Users", Auth: apiAdmin, Summary: "Erase a deactivated user's personal data, keeping aggregate history", Response: models.UserAnonymization{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/users/{id}/impersonate", Tag: "Security", Auth: apiAdmin,
+			Summary: "Start support mode: a short-lived, read-only token for seeing the app as a driver (responses carry X-Support-Mode)",
+			Request: models.StartImpersonationRequest{}, Response: models.ImpersonationTokenResponse{}, Status: http.StatusCreated},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/impersonations", Tag: "Security", Auth: apiAdmin, Summary: "Support mode sessions with request counts, newest first",
+			Query: []openapi.Param{{Name: "driver_id", Type: "string"}, {Name: "admin_id", Type: "string"},
+				{Name: "active", Type: "boolean", Description: "Only sessions that haven't expired or ended"}, limit},
+			Response: []models.ImpersonationSession{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/impersonations/{id}/requests", Tag: "Security", Auth: apiAdmin, Summary: "Every request made in a support mode session", RawResponse: true},
+		openapi.Operation{Method: http.MethodPost, Path: "/api/manager/impersonations/{id}/end", Tag: "Security", Auth: apiAdmin, Summary: "End a support mode session; its token stops working",
+			Response: models.ImpersonationSession{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/security/events", Tag: "Security", Auth: apiAdmin, Summary: "Security audit log",
+			Query: []openapi.Param{{Name: "event_type", Type: "string"}, {Name: "user_id", Type: "string"}, {Name: "email", Type: "string"},
+				{Name: "ip_address", Type: "string"}, {Name: "since", Type: "integer"}, limit},
+			Response: []models.SecurityEvent{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/api/manager/security/lockouts", Tag: "Security", Auth: apiAdmin, Summary: "Active login lockouts",
+			Response: []models.LoginThrottle{}},
+	)
+
+	return spec
+}
@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// payrollRowsQuery totals shift_history per driver for the shifts that started in [$1, $2)
+// Regular (active) time runs from start to end less breaks; shifts that never started aren't paid
+const payrollRowsQuery = `
+	SELECT u.id AS driver_id, COALESCE(u.name, '') AS driver_name, u.email AS driver_email,
+	       COUNT(*) AS shifts,
+	       COALESCE(SUM(GREATEST(COALESCE(sh.end_time, sh.ended_at) - sh.start_time - COALESCE(sh.total_pause_seconds, 0), 0)), 0) AS active_seconds,
+	       COALESCE(SUM(COALESCE(sh.total_pause_seconds, 0)), 0) AS break_seconds,
+	       COALESCE(SUM(GREATEST(COALESCE(sh.end_time, sh.ended_at) - sh.start_time, 0)), 0) AS on_clock_seconds,
+	       COALESCE(SUM(COALESCE(sh.completed_bins, 0)), 0) AS bins_completed,
+	       COALESCE(SUM(sh.earned_credits), 0)::FLOAT8 AS credits
+	FROM shift_history sh
+	JOIN users u ON u.id = sh.driver_id
+	WHERE sh.start_time >= $1 AND sh.start_time < $2
+	GROUP BY u.id, u.name, u.email
+	ORDER BY driver_name, u.id`
+
+// payrollValue formats one driver's value for a column of the payroll export
+func payrollValue(column models.PayrollColumn, row models.PayrollRow, period *models.PayrollPeriod, settings models.PayrollExportSettings, loc *time.Location) string {
+	hours := func(seconds int64) string {
+		return strconv.FormatFloat(float64(seconds)/3600, 'f', settings.HoursDecimals, 64)
+	}
+	switch column.Field {
+	case models.PayrollFieldDriverID:
+		return row.DriverID
+	case models.PayrollFieldDriverName:
+		return row.DriverName
+	case models.PayrollFieldDriverEmail:
+		return row.DriverEmail
+	case models.PayrollFieldPeriod:
+		return period.Label
+	case models.PayrollFieldPeriodStart:
+		return time.Unix(period.PeriodStart, 0).In(loc).Format("2006-01-02")
+	case models.PayrollFieldPeriodEnd:
+		return time.Unix(period.PeriodEnd, 0).In(loc).AddDate(0, 0, -1).Format("2006-01-02")
+	case models.PayrollFieldShifts:
+		return strconv.Itoa(row.Shifts)
+	case models.PayrollFieldRegularHours:
+		return hours(row.ActiveSeconds)
+	case models.PayrollFieldBreakHours:
+		return hours(row.BreakSeconds)
+	case models.PayrollFieldBreakMinutes:
+		return strconv.FormatFloat(math.Round(float64(row.BreakSeconds)/60), 'f', 0, 64)
+	case models.PayrollFieldTotalHours:
+		return hours(row.OnClockSeconds)
+	case models.PayrollFieldBinsCompleted:
+		return strconv.Itoa(row.BinsCompleted)
+	case models.PayrollFieldCredits:
+		return strconv.FormatFloat(row.Credits, 'f', 2, 64)
+	case models.PayrollFieldConstant:
+		return column.Value
+	}
+	return ""
+}
+
+// ExportPayroll downloads per-driver shift totals for a payroll period in the configured column mapping
+// (see GET /api/manager/settings/payroll-export); the period is read in the mapping's timezone and a shift
+// belongs to the period it started in
+// With lock=true the period is locked once exported: its shifts can no longer be added, changed or removed
+// (a period can only be locked once it has ended and none of its shifts are still running)
+// Every export is recorded in GET /api/manager/payroll/periods; a locked period keeps the bounds it was locked with
+// GET /api/manager/export/payroll?period=2026-10|2026-W41|2026-10-01..2026-10-15&format=csv|xlsx&lock=true
+func ExportPayroll(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		q := r.URL.Query()
+		format := strings.ToLower(q.Get("format"))
+		if format == "" {
+			format = "csv"
+		}
+		if format != "csv" && format != "xlsx" {
+			utils.RespondError(w, http.StatusBadRequest, "format must be csv or xlsx")
+			return
+		}
+		lock := q.Get("lock") == "true"
+
+		settings, err := database.GetPayrollExportSettings(db)
+		if err != nil {
+			log.Printf("❌ [PAYROLL] %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to load payroll export settings")
+			return
+		}
+		loc, err := time.LoadLocation(settings.Timezone)
+		if err != nil {
+			loc = time.UTC
+		}
+
+		label, start, end, err := models.ParsePayrollPeriod(q.Get("period"), loc)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		now := time.Now().Unix()
+		period := models.PayrollPeriod{}
+		rows := []models.PayrollRow{}
+		err = database.WithTx(r.Context(), db, func(tx *sqlx.Tx) error {
+			var existing models.PayrollPeriod
+			err := tx.GetContext(r.Context(), &existing, `SELECT * FROM payroll_periods WHERE label = $1 FOR UPDATE`, label)
+			if err != nil && err != sql.ErrNoRows {
+				return fmt.Errorf("failed to load payroll period: %w", err)
+			}
+			alreadyLocked := err == nil && existing.LockedAt != nil
+
+			periodStart, periodEnd, timezone := start.Unix(), end.Unix(), loc.String()
+			if alreadyLocked {
+				periodStart, periodEnd, timezone = existing.PeriodStart, existing.PeriodEnd, existing.Timezone
+			}
+
+			if lock && !alreadyLocked {
+				if periodEnd > now {
+					return txFail(http.StatusConflict, "The period hasn't ended yet; it can be locked once it is over")
+				}
+				var running []string
+				if err := tx.SelectContext(r.Context(), &running, `
+					SELECT id FROM shifts
+					WHERE status IN ('active', 'paused') AND start_time >= $1 AND start_time < $2
+					ORDER BY start_time`, periodStart, periodEnd); err != nil {
+					return fmt.Errorf("failed to check running shifts: %w", err)
+				}
+				if len(running) > 0 {
+					return txFailCode(http.StatusConflict, utils.CodeConflict,
+						fmt.Sprintf("%d shift(s) that started in the period are still running; end them before locking", len(running)),
+						map[string]interface{}{"shift_ids": running})
+				}
+			}
+
+			if err := tx.SelectContext(r.Context(), &rows, payrollRowsQuery, periodStart, periodEnd); err != nil {
+				return fmt.Errorf("failed to total shifts: %w", err)
+			}
+			var activeSeconds int64
+			for _, row := range rows {
+				activeSeconds += row.ActiveSeconds
+			}
+			regularHours := math.Round(float64(activeSeconds)/36) / 100
+
+			var lockedAt *int64
+			var lockedBy *string
+			if lock {
+				lockedAt, lockedBy = &now, &userClaims.UserID
+			}
+			return tx.GetContext(r.Context(), &period, `
+				INSERT INTO payroll_periods (id, label, period_start, period_end, timezone, drivers, regular_hours,
+					export_count, last_exported_at, last_exported_by_user_id, locked_at, locked_by_user_id, created_at, updated_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, 1, $8, $9, $10, $11, $8, $8)
+				ON CONFLICT (label) DO UPDATE SET
+					period_start = EXCLUDED.period_start,
+					period_end = EXCLUDED.period_end,
+					timezone = EXCLUDED.timezone,
+					drivers = EXCLUDED.drivers,
+					regular_hours = EXCLUDED.regular_hours,
+					export_count = payroll_periods.export_count + 1,
+					last_exported_at = EXCLUDED.last_exported_at,
+					last_exported_by_user_id = EXCLUDED.last_exported_by_user_id,
+					locked_at = COALESCE(payroll_periods.locked_at, EXCLUDED.locked_at),
+					locked_by_user_id = COALESCE(payroll_periods.locked_by_user_id, EXCLUDED.locked_by_user_id),
+					updated_at = EXCLUDED.updated_at
+				RETURNING *`,
+				uuid.New().String(), label, periodStart, periodEnd, timezone, len(rows), regularHours,
+				now, userClaims.UserID, lockedAt, lockedBy)
+		})
+		if err != nil {
+			respondTxError(w, err, "Failed to export payroll")
+			return
+		}
+
+		periodLoc, err := time.LoadLocation(period.Timezone)
+		if err != nil {
+			periodLoc = loc
+		}
+
+		header := make([]string, len(settings.Columns))
+		for i, column := range settings.Columns {
+			header[i] = column.Header
+		}
+		table := [][]string{header}
+		for _, row := range rows {
+			record := make([]string, len(settings.Columns))
+			for i, column := range settings.Columns {
+				record[i] = payrollValue(column, row, &period, settings, periodLoc)
+			}
+			table = append(table, record)
+		}
+
+		filename := "payroll-" + strings.ReplaceAll(period.Label, "..", "_to_") + "." + format
+		var body bytes.Buffer
+		if format == "xlsx" {
+			err = utils.WriteXLSX(&body, "Payroll", table)
+			w.Header().Set("Content-Type", utils.XLSXContentType)
+		} else {
+			cw := csv.NewWriter(&body)
+			cw.WriteAll(table)
+			err = cw.Error()
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		}
+		if err != nil {
+			log.Printf("❌ [PAYROLL] Failed to write %s: %v", format, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to export payroll")
+			return
+		}
+
+		log.Printf("📤 [PAYROLL] %s exported %s (%d drivers, %.2f h) as %s (locked: %t)",
+			userClaims.Email, period.Label, len(rows), period.RegularHours, format, period.LockedAt != nil)
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+		w.WriteHeader(http.StatusOK)
+		w.Write(body.Bytes())
+	}
+}
+
+// GetPayrollPeriods lists exported payroll periods, latest first, with their lock state
+// GET /api/manager/payroll/periods
+func GetPayrollPeriods(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		periods := []models.PayrollPeriod{}
+		if err := db.SelectContext(r.Context(), &periods, `SELECT * FROM payroll_periods ORDER BY period_start DESC, label`); err != nil {
+			log.Printf("❌ [PAYROLL] Failed to fetch periods: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch payroll periods")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    periods,
+		})
+	}
+}
+
+// UnlockPayrollPeriod unlocks a payroll period so its shifts can be corrected (export it again with lock=true afterwards)
+// POST /api/manager/payroll/periods/{id}/unlock
+func UnlockPayrollPeriod(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		periodID := chi.URLParam(r, "id")
+
+		var period models.PayrollPeriod
+		err := db.GetContext(r.Context(), &period, `
+			UPDATE payroll_periods SET locked_at = NULL, locked_by_user_id = NULL, updated_at = $2
+			WHERE id = $1
+			RETURNING *`, periodID, time.Now().Unix())
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "Payroll period not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [PAYROLL] Failed to unlock period %s: %v", periodID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to unlock payroll period")
+			return
+		}
+
+		log.Printf("🔓 [PAYROLL] %s unlocked %s", userClaims.Email, period.Label)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    period,
+		})
+	}
+}
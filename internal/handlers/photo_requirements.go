@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/store"
+	"ropacal-backend/internal/websocket"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+)
+
+// binPhotoRequiredSQL is whether the bin with ID $1 requires a photo with every check (its own flag or its area's)
+const binPhotoRequiredSQL = `
+	SELECT b.photo_required OR COALESCE(a.photo_required, false)
+	FROM bins b
+	LEFT JOIN areas a ON a.id = b.area_id
+	WHERE b.id = $1`
+
+// stopPhotoRequired reports whether completing a route stop needs a photo (false for stops without a bin)
+func stopPhotoRequired(ctx context.Context, db *sqlx.DB, taskID string) (bool, error) {
+	var required bool
+	err := db.GetContext(ctx, &required, `
+		SELECT COALESCE(b.photo_required, false) OR COALESCE(a.photo_required, false)
+		FROM route_tasks rt
+		LEFT JOIN bins b ON b.id = rt.bin_id
+		LEFT JOIN areas a ON a.id = b.area_id
+		WHERE rt.id = $1
+	`, taskID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return required, err
+}
+
+// hasPhoto reports whether a photo URL was sent
+func hasPhoto(url *string) bool {
+	return url != nil && strings.TrimSpace(*url) != ""
+}
+
+// setPhotoRequiredRequest is the body for the photo requirement endpoints
+type setPhotoRequiredRequest struct {
+	PhotoRequired *bool `json:"photo_required"`
+}
+
+// decodePhotoRequired reads a setPhotoRequiredRequest; responds 400 and returns false when it is invalid
+func decodePhotoRequired(w http.ResponseWriter, r *http.Request) (bool, bool) {
+	var req setPhotoRequiredRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+		return false, false
+	}
+	if req.PhotoRequired == nil {
+		utils.RespondError(w, http.StatusBadRequest, "photo_required is required")
+		return false, false
+	}
+	return *req.PhotoRequired, true
+}
+
+// SetBinPhotoRequired turns the photo requirement of a bin on or off
+// Drivers can't complete its collection stops without a photo while it is on
+// PUT /api/manager/bins/{id}/photo-required
+// Body: { "photo_required": true }
+func SetBinPhotoRequired(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+
+		required, ok := decodePhotoRequired(w, r)
+		if !ok {
+			return
+		}
+
+		var updated models.Bin
+		err := db.GetContext(r.Context(), &updated, `
+			UPDATE bins SET photo_required = $1, updated_at = $2
+			WHERE id = $3
+			RETURNING *
+		`, required, time.Now().Unix(), id)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "Bin not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [PHOTO-REQUIRED] Failed to update bin %s: %v", id, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to update photo requirement")
+			return
+		}
+		store.InvalidateBins(id)
+
+		wsHub.BroadcastToRole("admin", map[string]interface{}{
+			"type": "bin_updated",
+			"data": updated.ToBinResponse(),
+		})
+		log.Printf("✅ [PHOTO-REQUIRED] Bin #%d photo required: %v", updated.BinNumber, required)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    updated.ToBinResponse(),
+		})
+	}
+}
+
+// SetAreaPhotoRequired turns the photo requirement on or off for every bin in an area
+// PUT /api/manager/areas/{id}/photo-required
+// Body: { "photo_required": true }
+func SetAreaPhotoRequired(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+
+		required, ok := decodePhotoRequired(w, r)
+		if !ok {
+			return
+		}
+
+		var updated models.Area
+		err := db.GetContext(r.Context(), &updated, `
+			UPDATE areas SET photo_required = $1, updated_at = $2
+			WHERE id = $3
+			RETURNING *
+		`, required, time.Now().Unix(), id)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "Area not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [PHOTO-REQUIRED] Failed to update area %s: %v", id, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to update photo requirement")
+			return
+		}
+
+		log.Printf("✅ [PHOTO-REQUIRED] Area %s photo required: %v", updated.Name, required)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    updated,
+		})
+	}
+}
+
+// GetPhotoRequirements lists the areas and bins that require a photo with every check
+// GET /api/manager/photo-requirements
+func GetPhotoRequirements(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		policies := models.PhotoRequirements{
+			Areas: []models.PhotoRequirementArea{},
+			Bins:  []models.PhotoRequirementBin{},
+		}
+
+		err := db.SelectContext(r.Context(), &policies.Areas, `
+			SELECT a.id AS area_id, a.name,
+			       (SELECT COUNT(*) FROM bins b WHERE b.area_id = a.id AND b.status <> 'retired') AS bin_count
+			FROM areas a
+			WHERE a.photo_required = true
+			ORDER BY a.name ASC
+		`)
+		if err != nil {
+			log.Printf("❌ [PHOTO-REQUIRED] Failed to list areas: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to load photo requirements")
+			return
+		}
+
+		err = db.SelectContext(r.Context(), &policies.Bins, `
+			SELECT b.id AS bin_id, b.bin_number, b.current_street, b.city, b.area_id
+			FROM bins b
+			WHERE b.photo_required = true AND b.status <> 'retired'
+			ORDER BY b.bin_number ASC
+		`)
+		if err != nil {
+			log.Printf("❌ [PHOTO-REQUIRED] Failed to list bins: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to load photo requirements")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    policies,
+		})
+	}
+}
+
+// GetPhotoComplianceReport returns, per driver, how many checks of photo-required bins came with a photo
+// Checks record the requirement at check time, so turning a flag off later doesn't rewrite history
+// GET /api/manager/analytics/photo-compliance?since=<unix>&until=<unix> (default: the last 30 days)
+func GetPhotoComplianceReport(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		report := models.PhotoComplianceReport{
+			From:     now.AddDate(0, 0, -30).Unix(),
+			To:       now.Unix(),
+			ByDriver: []models.PhotoComplianceStats{},
+			Overall:  models.PhotoComplianceStats{DriverName: "all"},
+		}
+		if since, err := strconv.ParseInt(r.URL.Query().Get("since"), 10, 64); err == nil {
+			report.From = since
+		}
+		if until, err := strconv.ParseInt(r.URL.Query().Get("until"), 10, 64); err == nil {
+			report.To = until
+		}
+		if report.From >= report.To {
+			utils.RespondError(w, http.StatusBadRequest, "since must be before until")
+			return
+		}
+
+		err := db.SelectContext(r.Context(), &report.ByDriver, `
+			SELECT c.checked_by AS driver_id, COALESCE(MIN(u.name), '') AS driver_name,
+			       COUNT(*) AS required_checks,
+			       COUNT(*) FILTER (WHERE NULLIF(TRIM(c.photo_url), '') IS NOT NULL) AS with_photo
+			FROM checks c
+			LEFT JOIN users u ON u.id = c.checked_by
+			WHERE c.photo_required = true AND c.checked_by IS NOT NULL
+			  AND c.checked_on >= $1 AND c.checked_on < $2
+			GROUP BY c.checked_by
+			ORDER BY driver_name ASC
+		`, report.From, report.To)
+		if err != nil {
+			log.Printf("❌ [PHOTO-REQUIRED] Failed to build compliance report: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to build photo compliance report")
+			return
+		}
+
+		for i := range report.ByDriver {
+			stats := &report.ByDriver[i]
+			stats.MissingPhoto = stats.RequiredChecks - stats.WithPhoto
+			stats.ComplianceRate = complianceRate(stats.WithPhoto, stats.RequiredChecks)
+
+			report.Overall.RequiredChecks += stats.RequiredChecks
+			report.Overall.WithPhoto += stats.WithPhoto
+			report.Overall.MissingPhoto += stats.MissingPhoto
+		}
+		report.Overall.ComplianceRate = complianceRate(report.Overall.WithPhoto, report.Overall.RequiredChecks)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    report,
+		})
+	}
+}
@@ -10,7 +10,9 @@ import (
 
 	"ropacal-backend/internal/middleware"
 	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/store"
 	"ropacal-backend/internal/websocket"
+	"ropacal-backend/pkg/utils"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -41,10 +43,10 @@ func GetPotentialLocations(db *sqlx.DB) http.HandlerFunc {
 			ORDER BY pl.created_at DESC
 		`, whereClause)
 
-		rows, err := db.Query(query)
+		rows, err := db.QueryContext(r.Context(), query)
 		if err != nil {
 			log.Printf("❌ [GET-POTENTIAL-LOCATIONS] Database query failed: %v", err)
-			http.Error(w, "Failed to fetch potential locations", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch potential locations")
 			return
 		}
 		defer rows.Close()
@@ -102,7 +104,7 @@ func CreatePotentialLocation(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc
 		// Get user from context (set by auth middleware)
 		userClaims, ok := middleware.GetUserFromContext(r)
 		if !ok {
-			http.Error(w, "Unauthorized: user not found in context", http.StatusUnauthorized)
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized: user not found in context")
 			return
 		}
 
@@ -110,8 +112,7 @@ func CreatePotentialLocation(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc
 		userName := userClaims.Email
 
 		// Get full user name from database
-		var fullName string
-		err := db.Get(&fullName, "SELECT name FROM users WHERE id = $1", userID)
+		fullName, err := store.New(db).Users.Name(userID)
 		if err != nil {
 			log.Printf("❌ [CREATE-POTENTIAL-LOCATION] Failed to get user name: %v", err)
 			// Fallback to email if name lookup fails
@@ -123,7 +124,7 @@ func CreatePotentialLocation(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc
 		// Read raw JSON to detect if it's array or object
 		var rawMessage json.RawMessage
 		if err := json.NewDecoder(r.Body).Decode(&rawMessage); err != nil {
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
 			return
 		}
 
@@ -135,7 +136,7 @@ func CreatePotentialLocation(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc
 			// If that fails, try as single object
 			var singleReq models.CreatePotentialLocationRequest
 			if err := json.Unmarshal(rawMessage, &singleReq); err != nil {
-				http.Error(w, "Invalid request body format", http.StatusBadRequest)
+				utils.RespondError(w, http.StatusBadRequest, "Invalid request body format")
 				return
 			}
 			requests = []models.CreatePotentialLocationRequest{singleReq}
@@ -143,23 +144,23 @@ func CreatePotentialLocation(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc
 
 		// Validate we have at least one request
 		if len(requests) == 0 {
-			http.Error(w, "At least one location is required", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "At least one location is required")
 			return
 		}
 
 		// Validate all requests
 		for i, req := range requests {
 			if req.Street == "" || req.City == "" || req.Zip == "" {
-				http.Error(w, fmt.Sprintf("Missing required fields at index %d (street, city, zip)", i), http.StatusBadRequest)
+				utils.RespondError(w, http.StatusBadRequest, fmt.Sprintf("Missing required fields at index %d (street, city, zip)", i))
 				return
 			}
 		}
 
 		// Begin transaction
-		tx, err := db.Begin()
+		tx, err := db.BeginTx(r.Context(), nil)
 		if err != nil {
 			log.Printf("❌ [CREATE-POTENTIAL-LOCATION] Transaction begin failed: %v", err)
-			http.Error(w, "Failed to start transaction", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to start transaction")
 			return
 		}
 		defer tx.Rollback()
@@ -178,7 +179,7 @@ func CreatePotentialLocation(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc
 			createdIDs = append(createdIDs, id)
 
 			// Insert potential location
-			_, err = tx.Exec(`
+			_, err = tx.ExecContext(r.Context(), `
 				INSERT INTO potential_locations (
 					id, address, street, city, zip, latitude, longitude,
 					requested_by_user_id, requested_by_name, notes,
@@ -193,7 +194,7 @@ func CreatePotentialLocation(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc
 
 			if err != nil {
 				log.Printf("❌ [CREATE-POTENTIAL-LOCATION] Database insert failed: %v", err)
-				http.Error(w, "Failed to create potential location", http.StatusInternalServerError)
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to create potential location")
 				return
 			}
 
@@ -203,17 +204,17 @@ func CreatePotentialLocation(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc
 		// Commit transaction
 		if err = tx.Commit(); err != nil {
 			log.Printf("❌ [CREATE-POTENTIAL-LOCATION] Transaction commit failed: %v", err)
-			http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to commit transaction")
 			return
 		}
 
 		// Fetch all created locations
 		for _, id := range createdIDs {
 			var created models.PotentialLocation
-			err = db.Get(&created, "SELECT * FROM potential_locations WHERE id = $1", id)
+			err = db.GetContext(r.Context(), &created, "SELECT * FROM potential_locations WHERE id = $1", id)
 			if err != nil {
 				log.Printf("❌ [CREATE-POTENTIAL-LOCATION] Failed to fetch created location: %v", err)
-				http.Error(w, "Failed to fetch created location", http.StatusInternalServerError)
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch created location")
 				return
 			}
 
@@ -242,29 +243,29 @@ func DeletePotentialLocation(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := chi.URLParam(r, "id")
 		if id == "" {
-			http.Error(w, "Missing location ID", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Missing location ID")
 			return
 		}
 
 		// Check if location exists
 		var exists bool
-		err := db.Get(&exists, "SELECT EXISTS(SELECT 1 FROM potential_locations WHERE id = $1)", id)
+		err := db.GetContext(r.Context(), &exists, "SELECT EXISTS(SELECT 1 FROM potential_locations WHERE id = $1)", id)
 		if err != nil {
 			log.Printf("❌ [DELETE-POTENTIAL-LOCATION] Database check failed: %v", err)
-			http.Error(w, "Failed to check location existence", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to check location existence")
 			return
 		}
 
 		if !exists {
-			http.Error(w, "Potential location not found", http.StatusNotFound)
+			utils.RespondError(w, http.StatusNotFound, "Potential location not found")
 			return
 		}
 
 		// Delete location
-		_, err = db.Exec("DELETE FROM potential_locations WHERE id = $1", id)
+		_, err = db.ExecContext(r.Context(), "DELETE FROM potential_locations WHERE id = $1", id)
 		if err != nil {
 			log.Printf("❌ [DELETE-POTENTIAL-LOCATION] Database delete failed: %v", err)
-			http.Error(w, "Failed to delete potential location", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to delete potential location")
 			return
 		}
 
@@ -289,7 +290,7 @@ func ConvertPotentialLocationToBin(db *sqlx.DB, wsHub *websocket.Hub) http.Handl
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := chi.URLParam(r, "id")
 		if id == "" {
-			http.Error(w, "Missing location ID", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Missing location ID")
 			return
 		}
 
@@ -302,24 +303,24 @@ func ConvertPotentialLocationToBin(db *sqlx.DB, wsHub *websocket.Hub) http.Handl
 		// Get user from context (manager who is converting)
 		userClaims, ok := middleware.GetUserFromContext(r)
 		if !ok {
-			http.Error(w, "Unauthorized: user not found in context", http.StatusUnauthorized)
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized: user not found in context")
 			return
 		}
 
 		userID := userClaims.UserID
 
 		// Begin transaction
-		tx, err := db.Begin()
+		tx, err := db.BeginTx(r.Context(), nil)
 		if err != nil {
 			log.Printf("❌ [CONVERT-POTENTIAL-LOCATION] Transaction begin failed: %v", err)
-			http.Error(w, "Failed to start transaction", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to start transaction")
 			return
 		}
 		defer tx.Rollback()
 
 		// Fetch potential location
 		var location models.PotentialLocation
-		err = tx.QueryRow(`
+		err = tx.QueryRowContext(r.Context(), `
 			SELECT id, street, city, zip, latitude, longitude, requested_by_user_id
 			FROM potential_locations
 			WHERE id = $1 AND converted_at IS NULL
@@ -334,21 +335,21 @@ func ConvertPotentialLocationToBin(db *sqlx.DB, wsHub *websocket.Hub) http.Handl
 		)
 
 		if err == sql.ErrNoRows {
-			http.Error(w, "Potential location not found or already converted", http.StatusNotFound)
+			utils.RespondError(w, http.StatusNotFound, "Potential location not found or already converted")
 			return
 		}
 		if err != nil {
 			log.Printf("❌ [CONVERT-POTENTIAL-LOCATION] Failed to fetch location: %v", err)
-			http.Error(w, "Failed to fetch potential location", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch potential location")
 			return
 		}
 
 		// Auto-assign bin number
 		var maxBinNumber sql.NullInt64
-		err = tx.QueryRow("SELECT MAX(bin_number) FROM bins").Scan(&maxBinNumber)
+		err = tx.QueryRowContext(r.Context(), "SELECT MAX(bin_number) FROM bins").Scan(&maxBinNumber)
 		if err != nil {
 			log.Printf("❌ [CONVERT-POTENTIAL-LOCATION] Failed to get max bin_number: %v", err)
-			http.Error(w, "Failed to generate bin number", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to generate bin number")
 			return
 		}
 
@@ -367,7 +368,7 @@ func ConvertPotentialLocationToBin(db *sqlx.DB, wsHub *websocket.Hub) http.Handl
 			fillPercentage = *req.FillPercentage
 		}
 
-		_, err = tx.Exec(`
+		_, err = tx.ExecContext(r.Context(), `
 			INSERT INTO bins (
 				id, bin_number, current_street, city, zip, status,
 				fill_percentage, checked, move_requested, latitude, longitude,
@@ -382,12 +383,12 @@ func ConvertPotentialLocationToBin(db *sqlx.DB, wsHub *websocket.Hub) http.Handl
 
 		if err != nil {
 			log.Printf("❌ [CONVERT-POTENTIAL-LOCATION] Failed to create bin: %v", err)
-			http.Error(w, "Failed to create bin", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to create bin")
 			return
 		}
 
 		// Update potential location to mark as converted (soft delete)
-		_, err = tx.Exec(`
+		_, err = tx.ExecContext(r.Context(), `
 			UPDATE potential_locations
 			SET converted_to_bin_id = $1,
 			    converted_at = $2,
@@ -398,23 +399,23 @@ func ConvertPotentialLocationToBin(db *sqlx.DB, wsHub *websocket.Hub) http.Handl
 
 		if err != nil {
 			log.Printf("❌ [CONVERT-POTENTIAL-LOCATION] Failed to update location: %v", err)
-			http.Error(w, "Failed to update potential location", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to update potential location")
 			return
 		}
 
 		// Commit transaction
 		if err = tx.Commit(); err != nil {
 			log.Printf("❌ [CONVERT-POTENTIAL-LOCATION] Transaction commit failed: %v", err)
-			http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to commit transaction")
 			return
 		}
 
 		// Fetch created bin
 		var createdBin models.Bin
-		err = db.Get(&createdBin, "SELECT * FROM bins WHERE id = $1", binID)
+		err = db.GetContext(r.Context(), &createdBin, "SELECT * FROM bins WHERE id = $1", binID)
 		if err != nil {
 			log.Printf("❌ [CONVERT-POTENTIAL-LOCATION] Failed to fetch created bin: %v", err)
-			http.Error(w, "Failed to fetch created bin", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch created bin")
 			return
 		}
 
@@ -0,0 +1,583 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ropacal-backend/internal/helpers"
+	"ropacal-backend/internal/i18n"
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/store"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// activePreStartChecklistItems returns the items drivers must answer, in display order
+func activePreStartChecklistItems(q sqlx.Queryer) ([]models.PreStartChecklistItem, error) {
+	items := []models.PreStartChecklistItem{}
+	err := sqlx.Select(q, &items, `
+		SELECT * FROM pre_start_checklist_items
+		WHERE is_active = TRUE
+		ORDER BY sort_order ASC, created_at ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch checklist items: %w", err)
+	}
+	return items, nil
+}
+
+// preStartChecklistPending reports whether a shift still needs its pre-start checklist before it can start
+// Shifts never need one while managers have no active items
+func preStartChecklistPending(db *sqlx.DB, shiftID string) (bool, error) {
+	var pending bool
+	err := db.Get(&pending, `
+		SELECT EXISTS(SELECT 1 FROM pre_start_checklist_items WHERE is_active = TRUE)
+		   AND NOT EXISTS(SELECT 1 FROM pre_start_checklists WHERE shift_id = $1)
+	`, shiftID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check pre-start checklist: %w", err)
+	}
+	return pending, nil
+}
+
+// loadPreStartChecklistResponses fills in the answers of each checklist
+func loadPreStartChecklistResponses(db *sqlx.DB, checklists []models.PreStartChecklist) error {
+	if len(checklists) == 0 {
+		return nil
+	}
+	ids := make([]string, len(checklists))
+	for i := range checklists {
+		ids[i] = checklists[i].ID
+		checklists[i].Responses = []models.PreStartChecklistResponse{}
+	}
+
+	query, args, err := sqlx.In(`
+		SELECT r.* FROM pre_start_checklist_responses r
+		LEFT JOIN pre_start_checklist_items i ON i.id = r.item_id
+		WHERE r.checklist_id IN (?)
+		ORDER BY r.passed ASC, i.sort_order ASC NULLS LAST, r.label ASC
+	`, ids)
+	if err != nil {
+		return fmt.Errorf("failed to build checklist response query: %w", err)
+	}
+	var responses []models.PreStartChecklistResponse
+	if err := db.Select(&responses, db.Rebind(query), args...); err != nil {
+		return fmt.Errorf("failed to fetch checklist responses: %w", err)
+	}
+
+	byID := make(map[string]*models.PreStartChecklist, len(checklists))
+	for i := range checklists {
+		byID[checklists[i].ID] = &checklists[i]
+	}
+	for _, response := range responses {
+		if checklist, ok := byID[response.ChecklistID]; ok {
+			checklist.Responses = append(checklist.Responses, response)
+		}
+	}
+	return nil
+}
+
+// GetPreStartChecklistItems lists the inspection items (inactive ones too)
+// GET /api/manager/pre-start-checklist/items
+func GetPreStartChecklistItems(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		items := []models.PreStartChecklistItem{}
+		err := db.SelectContext(r.Context(), &items, `
+			SELECT * FROM pre_start_checklist_items ORDER BY is_active DESC, sort_order ASC, created_at ASC
+		`)
+		if err != nil {
+			log.Printf("❌ [CHECKLIST] Failed to fetch items: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch checklist items")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    items,
+		})
+	}
+}
+
+// CreatePreStartChecklistItem adds an item drivers must answer before starting a shift
+// POST /api/manager/pre-start-checklist/items
+// Body: { "label": "Brakes working", "description": "...", "requires_photo": false, "sort_order": 1 }
+func CreatePreStartChecklistItem(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var req models.PreStartChecklistItemRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		now := time.Now().Unix()
+		item := models.PreStartChecklistItem{
+			ID:              uuid.New().String(),
+			IsActive:        true,
+			CreatedByUserID: &userClaims.UserID,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		}
+		applyPreStartChecklistItemRequest(&item, req)
+		if item.Label == "" {
+			utils.RespondError(w, http.StatusBadRequest, "label is required")
+			return
+		}
+
+		_, err := db.NamedExecContext(r.Context(), `
+			INSERT INTO pre_start_checklist_items (id, label, description, requires_photo, sort_order, is_active, created_by_user_id, created_at, updated_at)
+			VALUES (:id, :label, :description, :requires_photo, :sort_order, :is_active, :created_by_user_id, :created_at, :updated_at)
+		`, item)
+		if err != nil {
+			log.Printf("❌ [CHECKLIST] Failed to create item: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to create checklist item")
+			return
+		}
+
+		log.Printf("✅ [CHECKLIST] %s added item %q", userClaims.Email, item.Label)
+
+		utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{
+			"success": true,
+			"data":    item,
+		})
+	}
+}
+
+// UpdatePreStartChecklistItem changes an item (set is_active=false to stop asking it)
+// PUT /api/manager/pre-start-checklist/items/{id}
+func UpdatePreStartChecklistItem(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		itemID := chi.URLParam(r, "id")
+
+		var req models.PreStartChecklistItemRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		var item models.PreStartChecklistItem
+		err := db.GetContext(r.Context(), &item, `SELECT * FROM pre_start_checklist_items WHERE id = $1`, itemID)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "Checklist item not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [CHECKLIST] Failed to fetch item %s: %v", itemID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to update checklist item")
+			return
+		}
+
+		applyPreStartChecklistItemRequest(&item, req)
+		if item.Label == "" {
+			utils.RespondError(w, http.StatusBadRequest, "label can't be empty")
+			return
+		}
+		item.UpdatedAt = time.Now().Unix()
+
+		_, err = db.NamedExecContext(r.Context(), `
+			UPDATE pre_start_checklist_items
+			SET label = :label, description = :description, requires_photo = :requires_photo,
+			    sort_order = :sort_order, is_active = :is_active, updated_at = :updated_at
+			WHERE id = :id
+		`, item)
+		if err != nil {
+			log.Printf("❌ [CHECKLIST] Failed to update item %s: %v", itemID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to update checklist item")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    item,
+		})
+	}
+}
+
+// DeletePreStartChecklistItem removes an item (past answers keep its label)
+// DELETE /api/manager/pre-start-checklist/items/{id}
+func DeletePreStartChecklistItem(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		itemID := chi.URLParam(r, "id")
+
+		result, err := db.ExecContext(r.Context(), `DELETE FROM pre_start_checklist_items WHERE id = $1`, itemID)
+		if err != nil {
+			log.Printf("❌ [CHECKLIST] Failed to delete item %s: %v", itemID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to delete checklist item")
+			return
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			utils.RespondError(w, http.StatusNotFound, "Checklist item not found")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"message": "Checklist item deleted",
+		})
+	}
+}
+
+func applyPreStartChecklistItemRequest(item *models.PreStartChecklistItem, req models.PreStartChecklistItemRequest) {
+	if req.Label != nil {
+		item.Label = strings.TrimSpace(*req.Label)
+	}
+	if req.Description != nil {
+		item.Description = req.Description
+		if strings.TrimSpace(*req.Description) == "" {
+			item.Description = nil
+		}
+	}
+	if req.RequiresPhoto != nil {
+		item.RequiresPhoto = *req.RequiresPhoto
+	}
+	if req.SortOrder != nil {
+		item.SortOrder = *req.SortOrder
+	}
+	if req.IsActive != nil {
+		item.IsActive = *req.IsActive
+	}
+}
+
+// GetPreStartChecklists lists submitted inspections, newest first
+// GET /api/manager/pre-start-checklists?failed=true&driver_id=<id>&since=<unix>&limit=50
+func GetPreStartChecklists(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		limit := 50
+		if parsed, err := strconv.Atoi(q.Get("limit")); err == nil && parsed > 0 && parsed <= 500 {
+			limit = parsed
+		}
+		var since int64
+		if parsed, err := strconv.ParseInt(q.Get("since"), 10, 64); err == nil {
+			since = parsed
+		}
+
+		checklists := []models.PreStartChecklist{}
+		err := db.SelectContext(r.Context(), &checklists, `
+			SELECT c.*, u.name AS driver_name
+			FROM pre_start_checklists c
+			LEFT JOIN users u ON u.id = c.driver_id
+			WHERE c.submitted_at >= $1
+			  AND ($2 = '' OR c.driver_id = $2)
+			  AND (NOT $3 OR c.failed_count > 0)
+			ORDER BY c.submitted_at DESC
+			LIMIT $4
+		`, since, q.Get("driver_id"), q.Get("failed") == "true", limit)
+		if err == nil {
+			err = loadPreStartChecklistResponses(db, checklists)
+		}
+		if err != nil {
+			log.Printf("❌ [CHECKLIST] Failed to fetch checklists: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch checklists")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    checklists,
+		})
+	}
+}
+
+// GetShiftPreStartChecklist returns the inspection submitted for a shift
+// GET /api/manager/shifts/{id}/pre-start-checklist
+func GetShiftPreStartChecklist(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		shiftID := chi.URLParam(r, "id")
+
+		checklists := []models.PreStartChecklist{}
+		err := db.SelectContext(r.Context(), &checklists, `
+			SELECT c.*, u.name AS driver_name
+			FROM pre_start_checklists c
+			LEFT JOIN users u ON u.id = c.driver_id
+			WHERE c.shift_id = $1
+		`, shiftID)
+		if err == nil {
+			err = loadPreStartChecklistResponses(db, checklists)
+		}
+		if err != nil {
+			log.Printf("❌ [CHECKLIST] Failed to fetch checklist for shift %s: %v", shiftID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch checklist")
+			return
+		}
+		if len(checklists) == 0 {
+			utils.RespondError(w, http.StatusNotFound, "No checklist submitted for this shift")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    checklists[0],
+		})
+	}
+}
+
+// GetDriverPreStartChecklist returns the items to answer for the driver's ready shift, and any earlier submission
+// GET /api/driver/shift/pre-start-checklist
+func GetDriverPreStartChecklist(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, i18n.Tr(r, "Unauthorized"))
+			return
+		}
+
+		var shiftID string
+		err := db.GetContext(r.Context(), &shiftID, `
+			SELECT id FROM shifts WHERE driver_id = $1 AND status = 'ready' ORDER BY created_at DESC LIMIT 1
+		`, userClaims.UserID)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusBadRequest, i18n.Tr(r, "No route assigned. Contact your manager."))
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [CHECKLIST] Failed to fetch ready shift for %s: %v", userClaims.Email, err)
+			utils.RespondError(w, http.StatusInternalServerError, i18n.Tr(r, "Database error"))
+			return
+		}
+
+		response := models.DriverPreStartChecklist{ShiftID: shiftID}
+		response.Items, err = activePreStartChecklistItems(db)
+		if err != nil {
+			log.Printf("❌ [CHECKLIST] %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, i18n.Tr(r, "Database error"))
+			return
+		}
+		response.Required = len(response.Items) > 0
+
+		var submitted []models.PreStartChecklist
+		err = db.SelectContext(r.Context(), &submitted, `SELECT * FROM pre_start_checklists WHERE shift_id = $1`, shiftID)
+		if err == nil {
+			err = loadPreStartChecklistResponses(db, submitted)
+		}
+		if err != nil {
+			log.Printf("❌ [CHECKLIST] Failed to fetch submission for shift %s: %v", shiftID, err)
+			utils.RespondError(w, http.StatusInternalServerError, i18n.Tr(r, "Database error"))
+			return
+		}
+		if len(submitted) > 0 {
+			response.Submitted = &submitted[0]
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    response,
+		})
+	}
+}
+
+// SubmitPreStartChecklist records the driver's vehicle inspection for their ready shift
+// Every active item must be answered (with a photo where required); resubmitting replaces the earlier answers.
+// Failed items are flagged to managers, but don't stop the shift from starting.
+// POST /api/driver/shift/pre-start-checklist
+// Body: { "vehicle_id": "Truck 4", "responses": [{"item_id": "...", "passed": false, "note": "Left mirror cracked", "photo_url": "https://..."}] }
+func SubmitPreStartChecklist(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, i18n.Tr(r, "Unauthorized"))
+			return
+		}
+
+		var req models.PreStartChecklistRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, i18n.Tr(r, "Invalid request body"))
+			return
+		}
+
+		tx, err := db.BeginTxx(r.Context(), nil)
+		if err != nil {
+			log.Printf("❌ [CHECKLIST] Failed to start transaction: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, i18n.Tr(r, "Failed to save checklist"))
+			return
+		}
+		defer tx.Rollback()
+
+		var shiftID string
+		err = tx.GetContext(r.Context(), &shiftID, `
+			SELECT id FROM shifts WHERE driver_id = $1 AND status = 'ready' ORDER BY created_at DESC LIMIT 1 FOR UPDATE
+		`, userClaims.UserID)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusBadRequest, i18n.Tr(r, "No route assigned. Contact your manager."))
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [CHECKLIST] Failed to fetch ready shift for %s: %v", userClaims.Email, err)
+			utils.RespondError(w, http.StatusInternalServerError, i18n.Tr(r, "Failed to save checklist"))
+			return
+		}
+
+		items, err := activePreStartChecklistItems(tx)
+		if err != nil {
+			log.Printf("❌ [CHECKLIST] %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, i18n.Tr(r, "Failed to save checklist"))
+			return
+		}
+
+		answers := make(map[string]models.PreStartChecklistAnswer, len(req.Responses))
+		for _, answer := range req.Responses {
+			answers[answer.ItemID] = answer
+		}
+		now := time.Now().Unix()
+		checklist := models.PreStartChecklist{
+			ID:          uuid.New().String(),
+			ShiftID:     shiftID,
+			DriverID:    userClaims.UserID,
+			VehicleID:   trimmedOrNil(req.VehicleID),
+			Notes:       trimmedOrNil(req.Notes),
+			SubmittedAt: now,
+			Responses:   make([]models.PreStartChecklistResponse, 0, len(items)),
+		}
+		for _, item := range items {
+			answer, answered := answers[item.ID]
+			if !answered || answer.Passed == nil {
+				utils.RespondError(w, http.StatusBadRequest, i18n.Tr(r, "Missing answer for %q", item.Label))
+				return
+			}
+			photoURL := trimmedOrNil(answer.PhotoURL)
+			if item.RequiresPhoto && photoURL == nil {
+				utils.RespondError(w, http.StatusBadRequest, i18n.Tr(r, "A photo is required for %q", item.Label))
+				return
+			}
+			itemID := item.ID
+			checklist.Responses = append(checklist.Responses, models.PreStartChecklistResponse{
+				ID:          uuid.New().String(),
+				ChecklistID: checklist.ID,
+				ItemID:      &itemID,
+				Label:       item.Label,
+				Passed:      *answer.Passed,
+				Note:        trimmedOrNil(answer.Note),
+				PhotoURL:    photoURL,
+			})
+			if !*answer.Passed {
+				checklist.FailedCount++
+			}
+		}
+
+		// Resubmission replaces the earlier checklist (its answers cascade)
+		if _, err := tx.ExecContext(r.Context(), `DELETE FROM pre_start_checklists WHERE shift_id = $1`, shiftID); err != nil {
+			log.Printf("❌ [CHECKLIST] Failed to replace checklist for shift %s: %v", shiftID, err)
+			utils.RespondError(w, http.StatusInternalServerError, i18n.Tr(r, "Failed to save checklist"))
+			return
+		}
+		_, err = tx.NamedExecContext(r.Context(), `
+			INSERT INTO pre_start_checklists (id, shift_id, driver_id, vehicle_id, notes, failed_count, submitted_at)
+			VALUES (:id, :shift_id, :driver_id, :vehicle_id, :notes, :failed_count, :submitted_at)
+		`, checklist)
+		for _, response := range checklist.Responses {
+			if err != nil {
+				break
+			}
+			_, err = tx.NamedExecContext(r.Context(), `
+				INSERT INTO pre_start_checklist_responses (id, checklist_id, item_id, label, passed, note, photo_url)
+				VALUES (:id, :checklist_id, :item_id, :label, :passed, :note, :photo_url)
+			`, response)
+		}
+		if err == nil && checklist.FailedCount > 0 {
+			err = flagFailedPreStartChecklist(tx, checklist, userClaims)
+		}
+		if err == nil {
+			err = tx.Commit()
+		}
+		if err != nil {
+			log.Printf("❌ [CHECKLIST] Failed to save checklist for shift %s: %v", shiftID, err)
+			utils.RespondError(w, http.StatusInternalServerError, i18n.Tr(r, "Failed to save checklist"))
+			return
+		}
+
+		log.Printf("✅ [CHECKLIST] %s submitted pre-start checklist for shift %s (%d/%d failed)",
+			userClaims.Email, shiftID, checklist.FailedCount, len(checklist.Responses))
+
+		utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{
+			"success": true,
+			"data":    checklist,
+		})
+	}
+}
+
+// flagFailedPreStartChecklist alerts the admin and manager dashboards and pushes to every admin or manager
+// with a registered device (queued in the caller's transaction)
+func flagFailedPreStartChecklist(tx *sqlx.Tx, checklist models.PreStartChecklist, driver middleware.UserClaims) error {
+	driverName, err := store.New(tx).Users.Name(driver.UserID)
+	if err != nil {
+		driverName = driver.Email
+	}
+	failed := []string{}
+	for _, response := range checklist.Responses {
+		if !response.Passed {
+			failed = append(failed, response.Label)
+		}
+	}
+
+	message := map[string]interface{}{
+		"type": "pre_start_checklist_failed",
+		"data": map[string]interface{}{
+			"checklist_id": checklist.ID,
+			"shift_id":     checklist.ShiftID,
+			"driver_id":    checklist.DriverID,
+			"driver_name":  driverName,
+			"vehicle_id":   checklist.VehicleID,
+			"failed_items": failed,
+			"submitted_at": checklist.SubmittedAt,
+		},
+	}
+	for _, role := range []string{"admin", "manager"} {
+		if _, err := helpers.EnqueueRoleMessage(tx, role, message); err != nil {
+			return fmt.Errorf("failed to queue checklist alert: %w", err)
+		}
+	}
+
+	var recipients []string
+	err = tx.Select(&recipients, `
+		SELECT DISTINCT u.id
+		FROM users u
+		JOIN fcm_tokens t ON t.user_id = u.id
+		WHERE u.role IN ('admin', 'manager')
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to load checklist alert recipients: %w", err)
+	}
+	push := models.OutboxPush{
+		Title: "Vehicle inspection failed",
+		Body:  fmt.Sprintf("%s reported %d failed item(s): %s", driverName, len(failed), strings.Join(failed, ", ")),
+		Data: map[string]string{
+			"type":         "pre_start_checklist_failed",
+			"checklist_id": checklist.ID,
+			"shift_id":     checklist.ShiftID,
+		},
+		Urgent: true, // Safety issue on a vehicle about to go out
+	}
+	for _, userID := range recipients {
+		if _, err := helpers.EnqueuePush(tx, userID, push); err != nil {
+			return fmt.Errorf("failed to queue checklist push: %w", err)
+		}
+	}
+	return nil
+}
+
+// trimmedOrNil returns nil for a missing or blank string, otherwise the trimmed value
+func trimmedOrNil(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*s)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"ropacal-backend/internal/models"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	publicBinsDefaultRadiusMeters = 5000
+	publicBinsMaxRadiusMeters     = 50000
+	publicBinsDefaultLimit        = 100
+	publicBinsMaxLimit            = 500
+	publicBinsCacheSeconds        = 300 // Bins rarely move; browsers may reuse a response this long
+)
+
+// GetPublicBins is the public bin locator: active bins with an address and coordinates, and nothing else
+// GET /api/public/bins?lat=<lat>&lng=<lng>&radius=<meters, default 5000, max 50000>&limit=100&offset=0
+// Authenticated by an API key with the bin_locator scope and metered against the key's rate limit
+// lat and lng are required: bins within the radius are returned nearest first, so a key can't page through the whole fleet
+func GetPublicBins(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+
+		limit := publicBinsDefaultLimit
+		if v := q.Get("limit"); v != "" {
+			parsed, err := strconv.Atoi(v)
+			if err != nil || parsed < 1 || parsed > publicBinsMaxLimit {
+				utils.RespondError(w, http.StatusBadRequest, "limit must be between 1 and 500")
+				return
+			}
+			limit = parsed
+		}
+		offset := 0
+		if v := q.Get("offset"); v != "" {
+			parsed, err := strconv.Atoi(v)
+			if err != nil || parsed < 0 {
+				utils.RespondError(w, http.StatusBadRequest, "offset must be 0 or more")
+				return
+			}
+			offset = parsed
+		}
+
+		if q.Get("lat") == "" || q.Get("lng") == "" {
+			utils.RespondError(w, http.StatusBadRequest, "lat and lng are required")
+			return
+		}
+		lat, latErr := strconv.ParseFloat(q.Get("lat"), 64)
+		lng, lngErr := strconv.ParseFloat(q.Get("lng"), 64)
+		if latErr != nil || lngErr != nil || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+			utils.RespondError(w, http.StatusBadRequest, "lat and lng must be valid coordinates")
+			return
+		}
+		radius := float64(publicBinsDefaultRadiusMeters)
+		if v := q.Get("radius"); v != "" {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil || parsed <= 0 || parsed > publicBinsMaxRadiusMeters {
+				utils.RespondError(w, http.StatusBadRequest, "radius must be between 1 and 50000 meters")
+				return
+			}
+			radius = parsed
+		}
+
+		// Only bins in service with a known location; fill, status and internal IDs are never exposed
+		minLat, maxLat, minLng, maxLng := nearbyBoundingBox(lat, lng, radius)
+		bins := []models.PublicBin{}
+		err := db.SelectContext(r.Context(), &bins, `
+			SELECT bin_number, current_street, city, zip, latitude, longitude
+			FROM bins
+			WHERE status = 'active' AND retired_at IS NULL
+			  AND latitude IS NOT NULL AND longitude IS NOT NULL
+			  AND latitude BETWEEN $1 AND $2 AND longitude BETWEEN $3 AND $4`,
+			minLat, maxLat, minLng, maxLng)
+		if err != nil {
+			log.Printf("❌ [PUBLIC-BINS] Failed to fetch bins: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch bins")
+			return
+		}
+
+		// The box is wider than the circle near its corners
+		inRadius := bins[:0]
+		for _, bin := range bins {
+			distance := math.Round(haversineDistanceKm(lat, lng, bin.Latitude, bin.Longitude)*10000) / 10
+			if distance <= radius {
+				bin.DistanceMeters = &distance
+				inRadius = append(inRadius, bin)
+			}
+		}
+		bins = inRadius
+		sort.SliceStable(bins, func(i, j int) bool {
+			if *bins[i].DistanceMeters != *bins[j].DistanceMeters {
+				return *bins[i].DistanceMeters < *bins[j].DistanceMeters
+			}
+			return bins[i].BinNumber < bins[j].BinNumber
+		})
+
+		response := models.PublicBinsResponse{Total: len(bins), Bins: []models.PublicBin{}}
+		if offset < len(bins) {
+			end := offset + limit
+			if end > len(bins) {
+				end = len(bins)
+			}
+			response.Bins = bins[offset:end]
+		}
+
+		w.Header().Set("Cache-Control", "private, max-age="+strconv.Itoa(publicBinsCacheSeconds))
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    response,
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"ropacal-backend/internal/models"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+)
+
+// Push delivery log page size
+const (
+	pushDeliveriesDefaultLimit = 50
+	pushDeliveriesMaxLimit     = 200
+)
+
+// GetPushDeliveryReport returns, per driver, how many push notifications reached FCM, are being retried,
+// failed or expired, with each driver's registered devices and latest failure
+// GET /api/manager/analytics/notification-deliveries?since=<unix>&until=<unix> (default: the last 7 days)
+func GetPushDeliveryReport(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		report := models.PushDeliveryReport{
+			From:     now.AddDate(0, 0, -7).Unix(),
+			To:       now.Unix(),
+			ByDriver: []models.PushDeliveryStats{},
+			Overall:  models.PushDeliveryStats{DriverName: "all"},
+		}
+		if since, err := strconv.ParseInt(r.URL.Query().Get("since"), 10, 64); err == nil {
+			report.From = since
+		}
+		if until, err := strconv.ParseInt(r.URL.Query().Get("until"), 10, 64); err == nil {
+			report.To = until
+		}
+		if report.From >= report.To {
+			utils.RespondError(w, http.StatusBadRequest, "since must be before until")
+			return
+		}
+
+		err := db.SelectContext(r.Context(), &report.ByDriver, `
+			SELECT u.id AS driver_id, COALESCE(u.name, '') AS driver_name,
+			       COUNT(nd.id) AS total,
+			       COUNT(nd.id) FILTER (WHERE nd.status = $3) AS sent,
+			       COUNT(nd.id) FILTER (WHERE nd.status = $4) AS retrying,
+			       COUNT(nd.id) FILTER (WHERE nd.status = $5) AS failed,
+			       COUNT(nd.id) FILTER (WHERE nd.status = $6) AS expired,
+			       COUNT(nd.id) FILTER (WHERE nd.attempts > 1) AS retried,
+			       (SELECT COUNT(*) FROM fcm_tokens ft WHERE ft.user_id = u.id) AS devices,
+			       MAX(nd.sent_at) AS last_sent_at,
+			       MAX(nd.updated_at) FILTER (WHERE nd.status IN ($5, $6)) AS last_failure_at,
+			       (ARRAY_AGG(nd.last_error ORDER BY nd.updated_at DESC) FILTER (WHERE nd.status IN ($5, $6)))[1] AS last_error
+			FROM users u
+			LEFT JOIN notification_deliveries nd ON nd.user_id = u.id AND nd.created_at >= $1 AND nd.created_at < $2
+			WHERE u.role = 'driver' AND u.deactivated_at IS NULL
+			GROUP BY u.id, u.name
+		`, report.From, report.To, models.PushDeliverySent, models.PushDeliveryRetrying,
+			models.PushDeliveryFailed, models.PushDeliveryExpired)
+		if err != nil {
+			log.Printf("❌ [PUSH] Failed to build delivery report: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to build notification delivery report")
+			return
+		}
+
+		for i := range report.ByDriver {
+			stats := &report.ByDriver[i]
+			stats.DeliveryRate = complianceRate(stats.Sent, stats.Total-stats.Retrying)
+
+			report.Overall.Total += stats.Total
+			report.Overall.Sent += stats.Sent
+			report.Overall.Retrying += stats.Retrying
+			report.Overall.Failed += stats.Failed
+			report.Overall.Expired += stats.Expired
+			report.Overall.Retried += stats.Retried
+			report.Overall.Devices += stats.Devices
+		}
+		report.Overall.DeliveryRate = complianceRate(report.Overall.Sent, report.Overall.Total-report.Overall.Retrying)
+
+		// Lowest delivery rate first; drivers with nothing finished last
+		sort.SliceStable(report.ByDriver, func(i, j int) bool {
+			a, b := report.ByDriver[i].DeliveryRate, report.ByDriver[j].DeliveryRate
+			if a == nil || b == nil {
+				return a != nil && b == nil
+			}
+			return *a < *b
+		})
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    report,
+		})
+	}
+}
+
+// GetDriverPushDeliveries returns a driver's latest push deliveries, one per device and notification
+// GET /api/manager/drivers/{id}/notification-deliveries?status=failed&limit=50
+func GetDriverPushDeliveries(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		driverID := chi.URLParam(r, "id")
+
+		limit := pushDeliveriesDefaultLimit
+		if parsed, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && parsed > 0 && parsed <= pushDeliveriesMaxLimit {
+			limit = parsed
+		}
+
+		status := r.URL.Query().Get("status")
+		switch status {
+		case "", models.PushDeliverySent, models.PushDeliveryRetrying, models.PushDeliveryFailed, models.PushDeliveryExpired:
+		default:
+			utils.RespondError(w, http.StatusBadRequest, "status must be sent, retrying, failed or expired")
+			return
+		}
+
+		deliveries := []models.PushDelivery{}
+		err := db.SelectContext(r.Context(), &deliveries, `
+			SELECT * FROM notification_deliveries
+			WHERE user_id = $1 AND ($2 = '' OR status = $2)
+			ORDER BY created_at DESC
+			LIMIT $3
+		`, driverID, status, limit)
+		if err != nil {
+			log.Printf("❌ [PUSH] Failed to fetch deliveries for %s: %v", driverID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch notification deliveries")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    deliveries,
+		})
+	}
+}
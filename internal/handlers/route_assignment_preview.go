@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// buildRouteAssignmentPreview projects the driver's workload with the route added and checks it against the
+// limits and service hours
+// The route's bins are walked in blueprint order (or as given for custom routes) since it is only optimized
+// when the driver starts
+func buildRouteAssignmentPreview(db *sqlx.DB, req assignRouteRequest, limits models.WorkloadLimits,
+	serviceHours models.ServiceHoursSettings, now int64) (*models.RouteAssignmentPreview, error) {
+	preview := &models.RouteAssignmentPreview{
+		DriverID: req.DriverID,
+		Limits:   limits,
+		Warnings: []string{},
+	}
+
+	// Work already assigned: remaining stops of open shifts, plus the time worked so far on a started shift
+	var shifts []models.Shift
+	err := db.Select(&shifts, `
+		SELECT * FROM shifts
+		WHERE driver_id = $1 AND status IN ('ready', 'active', 'paused')
+		ORDER BY created_at ASC
+	`, req.DriverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load driver's shifts: %w", err)
+	}
+	seconds := 0.0
+	remainingSeconds := 0.0 // Work still to do, without the time already worked
+	for _, shift := range shifts {
+		stops, err := loadPreviewStops(db, shift.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load stops of shift %s: %w", shift.ID, err)
+		}
+		liveLat, liveLng, hasLive := previewDriverPosition(db, shift)
+		_, km, finish := walkPreviewRoute(stops, liveLat, liveLng, hasLive, now)
+
+		remaining := 0
+		for _, stop := range stops {
+			if stop.IsCompleted == 0 {
+				remaining++
+			}
+		}
+		preview.Current.Shifts++
+		preview.Current.Bins += len(stops)
+		preview.Current.DistanceKm += km
+		if remaining > 0 {
+			seconds += float64(finish - now + etaServiceTimeSeconds)
+			remainingSeconds += float64(finish - now + etaServiceTimeSeconds)
+		}
+		seconds += shift.GetActiveShiftDuration().Seconds()
+	}
+	preview.Current.Hours = seconds / 3600
+
+	// The new route
+	ordered := req.BinIDs
+	if req.RouteID != "" && req.RouteID != "custom" {
+		var routeBins []string
+		err := db.Select(&routeBins, `SELECT bin_id FROM route_bins WHERE route_id = $1 ORDER BY sequence_order`, req.RouteID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load route bins: %w", err)
+		}
+		if len(routeBins) > 0 {
+			ordered = routeBins
+		}
+	}
+	var bins []struct {
+		ID        string   `db:"id"`
+		Latitude  *float64 `db:"latitude"`
+		Longitude *float64 `db:"longitude"`
+	}
+	if err := db.Select(&bins, `SELECT id, latitude, longitude FROM bins WHERE id = ANY($1)`, pq.Array(ordered)); err != nil {
+		return nil, fmt.Errorf("failed to load bins: %w", err)
+	}
+	coordinates := make(map[string][2]float64, len(bins))
+	for _, bin := range bins {
+		if bin.Latitude != nil && bin.Longitude != nil {
+			coordinates[bin.ID] = [2]float64{*bin.Latitude, *bin.Longitude}
+		}
+	}
+	var previous *[2]float64
+	for _, binID := range ordered {
+		point, ok := coordinates[binID]
+		if !ok {
+			continue
+		}
+		if previous != nil {
+			preview.Route.DistanceKm += haversineDistanceKm(previous[0], previous[1], point[0], point[1])
+		}
+		previous = &point
+	}
+	preview.Route.Shifts = 1
+	preview.Route.Bins = len(ordered)
+	preview.Route.Hours = (preview.Route.DistanceKm/etaAverageSpeedKmh*3600 + float64(len(ordered)*etaServiceTimeSeconds)) / 3600
+
+	preview.Projected = models.DriverWorkload{
+		Shifts:     preview.Current.Shifts + preview.Route.Shifts,
+		Bins:       preview.Current.Bins + preview.Route.Bins,
+		Hours:      preview.Current.Hours + preview.Route.Hours,
+		DistanceKm: preview.Current.DistanceKm + preview.Route.DistanceKm,
+	}
+	for _, workload := range []*models.DriverWorkload{&preview.Current, &preview.Route, &preview.Projected} {
+		workload.Hours = math.Round(workload.Hours*10) / 10
+		workload.DistanceKm = math.Round(workload.DistanceKm*10) / 10
+	}
+
+	if limits.MaxBins > 0 && preview.Projected.Bins > limits.MaxBins {
+		preview.Warnings = append(preview.Warnings, fmt.Sprintf("Projected %d bins exceeds the limit of %d", preview.Projected.Bins, limits.MaxBins))
+	}
+	if limits.MaxHours > 0 && preview.Projected.Hours > limits.MaxHours {
+		preview.Warnings = append(preview.Warnings, fmt.Sprintf("Projected %.1f hours exceeds the limit of %.1f", preview.Projected.Hours, limits.MaxHours))
+	}
+	if limits.MaxDistanceKm > 0 && preview.Projected.DistanceKm > limits.MaxDistanceKm {
+		preview.Warnings = append(preview.Warnings, fmt.Sprintf("Projected %.1f km exceeds the limit of %.1f km", preview.Projected.DistanceKm, limits.MaxDistanceKm))
+	}
+	preview.ExceedsLimits = len(preview.Warnings) > 0
+
+	// Open shifts are worked in order, so the new route is done after everything already assigned
+	duration := time.Duration(remainingSeconds+preview.Route.Hours*3600) * time.Second
+	preview.ServiceHours = serviceHours.CheckServiceHours(time.Unix(now, 0), duration)
+	if preview.ServiceHours != nil && preview.ServiceHours.ExceedsServiceHours {
+		end := time.Unix(preview.ServiceHours.ServiceEnd, 0).In(serviceHours.Location())
+		completion := time.Unix(preview.ServiceHours.EstimatedCompletion, 0).In(serviceHours.Location())
+		preview.Warnings = append(preview.Warnings, fmt.Sprintf("Estimated completion %s is after service hours end at %s",
+			completion.Format("Mon 15:04"), end.Format("Mon 15:04")))
+	}
+
+	return preview, nil
+}
+
+// loadWorkloadLimits returns the workload limits, falling back to the defaults if they can't be loaded
+func loadWorkloadLimits(db *sqlx.DB) models.WorkloadLimits {
+	limits, err := database.GetWorkloadLimits(db)
+	if err != nil {
+		log.Printf("⚠️  [WORKLOAD] %v (using defaults)", err)
+	}
+	return limits
+}
+
+// loadServiceHours returns the service hours, falling back to the defaults if they can't be loaded
+func loadServiceHours(db *sqlx.DB) models.ServiceHoursSettings {
+	settings, err := database.GetServiceHoursSettings(db)
+	if err != nil {
+		log.Printf("⚠️  [SERVICE-HOURS] %v (using defaults)", err)
+	}
+	return settings
+}
+
+// PreviewRouteAssignment projects a driver's workload before a route is assigned (nothing is saved)
+// POST /api/manager/assign-route/preview
+// Body: same as POST /api/manager/assign-route
+func PreviewRouteAssignment(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req assignRouteRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if req.DriverID == "" || len(req.BinIDs) == 0 {
+			utils.RespondError(w, http.StatusBadRequest, "driver_id and at least one bin_id are required")
+			return
+		}
+
+		preview, err := buildRouteAssignmentPreview(db, req, loadWorkloadLimits(db), loadServiceHours(db), time.Now().Unix())
+		if err != nil {
+			log.Printf("❌ [WORKLOAD] Failed to preview assignment for driver %s: %v", req.DriverID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to preview assignment")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    preview,
+		})
+	}
+}
@@ -0,0 +1,500 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/helpers"
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/services"
+	"ropacal-backend/internal/store"
+	"ropacal-backend/internal/websocket"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// bulkRoutePlan is a validated assignment of a bulk route assignment, ready to be created
+type bulkRoutePlan struct {
+	index     int // In the request
+	item      *models.BulkRouteAssignmentItem
+	routeID   string
+	binIDs    []string // Stops in order (bins without coordinates left out)
+	sequences []int    // Saved route order of each stop, or 0 for a custom selection (ordered when the driver starts)
+	fromRoute bool     // The stops are the route's saved bins
+}
+
+// AssignRoutes assigns routes to several drivers in one call, as POST /api/manager/assign-route would one at a time
+// Every assignment is validated up front (active driver with one route per request, published route, existing
+// bins with coordinates on no other open shift or assignment, workload limits and service hours unless force
+// is set); the valid ones are created, each in its own transaction, and the others reported as failed. With
+// all_or_nothing a single failure assigns nothing (422), and the rest are created in one transaction
+// Each driver gets their route_assigned message and push; managers get one routes_bulk_assigned event
+// POST /api/manager/assign-routes
+func AssignRoutes(db *sqlx.DB, hub *websocket.Hub, fcmService *services.FCMService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var req models.BulkRouteAssignmentRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if len(req.Assignments) == 0 {
+			utils.RespondError(w, http.StatusBadRequest, "At least one assignment is required")
+			return
+		}
+		if len(req.Assignments) > models.MaxBulkRouteAssignments {
+			utils.RespondError(w, http.StatusBadRequest, fmt.Sprintf("At most %d routes can be assigned at once", models.MaxBulkRouteAssignments))
+			return
+		}
+
+		result := models.BulkRouteAssignmentResult{
+			Applied:   true,
+			Requested: len(req.Assignments),
+			Results:   make([]models.BulkRouteAssignmentItem, len(req.Assignments)),
+		}
+		plans, err := planBulkRouteAssignments(r.Context(), db, hub, req, &result)
+		if err != nil {
+			log.Printf("❌ [ASSIGN-ROUTES] %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to validate assignments")
+			return
+		}
+
+		if req.AllOrNothing && result.Failed > 0 {
+			result.Applied = false
+			for _, plan := range plans {
+				plan.item.Result = models.BulkRouteNotAssigned
+			}
+			utils.RespondErrorCode(w, http.StatusUnprocessableEntity, utils.CodeValidationFailed,
+				fmt.Sprintf("%d of %d assignments can't be made; nothing was assigned", result.Failed, result.Requested), result)
+			return
+		}
+
+		now := time.Now().Unix()
+		costRates := loadCostRates(db)
+		var assigned []models.Shift
+		if req.AllOrNothing {
+			err := database.WithTx(r.Context(), db, func(tx *sqlx.Tx) error {
+				for _, plan := range plans {
+					shift, err := createBulkRouteShift(r.Context(), tx, hub, fcmService, plan, costRates, now)
+					if err != nil {
+						return err
+					}
+					assigned = append(assigned, shift)
+				}
+				return queueRoutesBulkAssigned(tx, assigned, userClaims.UserID, now)
+			})
+			if err != nil {
+				respondTxError(w, err, "Failed to assign routes")
+				return
+			}
+		} else {
+			for _, plan := range plans {
+				var shift models.Shift
+				err := database.WithTx(r.Context(), db, func(tx *sqlx.Tx) error {
+					var err error
+					shift, err = createBulkRouteShift(r.Context(), tx, hub, fcmService, plan, costRates, now)
+					return err
+				})
+				if err != nil {
+					failBulkRouteAssignment(plan.item, err)
+					result.Failed++
+					continue
+				}
+				assigned = append(assigned, shift)
+			}
+			if len(assigned) > 0 {
+				if err := queueRoutesBulkAssigned(db, assigned, userClaims.UserID, now); err != nil {
+					log.Printf("⚠️  [ASSIGN-ROUTES] Failed to queue routes_bulk_assigned: %v", err)
+				}
+			}
+		}
+		result.Assigned = len(assigned)
+
+		log.Printf("✅ [ASSIGN-ROUTES] %s assigned %d of %d routes (%d failed)",
+			userClaims.Email, result.Assigned, result.Requested, result.Failed)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    result,
+		})
+	}
+}
+
+// planBulkRouteAssignments fills in the result of every assignment, marking the invalid ones as failed, and
+// returns the plans of the valid ones in request order
+func planBulkRouteAssignments(ctx context.Context, db *sqlx.DB, hub *websocket.Hub, req models.BulkRouteAssignmentRequest,
+	result *models.BulkRouteAssignmentResult) ([]*bulkRoutePlan, error) {
+	fail := func(item *models.BulkRouteAssignmentItem, code, message string, details interface{}) {
+		item.Result, item.Code, item.Error, item.Details = models.BulkRouteFailed, code, message, details
+		result.Failed++
+	}
+
+	var driverIDs, routeIDs []string
+	for i, assignment := range req.Assignments {
+		driverID := strings.TrimSpace(assignment.DriverID)
+		routeID := strings.TrimSpace(assignment.RouteID)
+		result.Results[i] = models.BulkRouteAssignmentItem{DriverID: driverID, RouteID: routeID, Warnings: []string{}}
+		driverIDs = append(driverIDs, driverID)
+		if routeID != "" && routeID != splitRouteRouteID {
+			routeIDs = append(routeIDs, routeID)
+		}
+	}
+
+	var drivers []struct {
+		ID       string `db:"id"`
+		Role     string `db:"role"`
+		IsActive bool   `db:"is_active"`
+	}
+	err := db.SelectContext(ctx, &drivers, `SELECT id, role, deactivated_at IS NULL AS is_active FROM users WHERE id = ANY($1)`,
+		pq.Array(driverIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load drivers: %w", err)
+	}
+	driverRoles := make(map[string]string, len(drivers))
+	activeDrivers := make(map[string]bool, len(drivers))
+	for _, driver := range drivers {
+		driverRoles[driver.ID] = driver.Role
+		activeDrivers[driver.ID] = driver.IsActive
+	}
+
+	var routes []struct {
+		ID      string `db:"id"`
+		IsDraft bool   `db:"is_draft"`
+	}
+	if err := db.SelectContext(ctx, &routes, `SELECT id, is_draft FROM routes WHERE id = ANY($1)`, pq.Array(routeIDs)); err != nil {
+		return nil, fmt.Errorf("failed to load routes: %w", err)
+	}
+	draftRoutes := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		draftRoutes[route.ID] = route.IsDraft
+	}
+	var routeBins []struct {
+		RouteID       string `db:"route_id"`
+		BinID         string `db:"bin_id"`
+		SequenceOrder int    `db:"sequence_order"`
+	}
+	err = db.SelectContext(ctx, &routeBins, `
+		SELECT route_id, bin_id, sequence_order FROM route_bins WHERE route_id = ANY($1) ORDER BY route_id, sequence_order
+	`, pq.Array(routeIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load route bins: %w", err)
+	}
+
+	// Drivers, routes and stops of each assignment
+	var plans []*bulkRoutePlan
+	driverAssignment := map[string]int{}
+	for i, assignment := range req.Assignments {
+		item := &result.Results[i]
+		switch role, found := driverRoles[item.DriverID]; {
+		case item.DriverID == "":
+			fail(item, utils.CodeValidationFailed, "driver_id is required", nil)
+			continue
+		case !found:
+			fail(item, utils.CodeNotFound, "Driver not found", nil)
+			continue
+		case role != "driver":
+			fail(item, utils.CodeValidationFailed, "User is not a driver", nil)
+			continue
+		case !activeDrivers[item.DriverID]:
+			fail(item, utils.CodeValidationFailed, "Driver is deactivated", nil)
+			continue
+		}
+		if other, ok := driverAssignment[item.DriverID]; ok {
+			fail(item, utils.CodeConflict, fmt.Sprintf("Driver already has assignments[%d] in this request", other), nil)
+			continue
+		}
+		driverAssignment[item.DriverID] = i
+
+		plan := &bulkRoutePlan{index: i, item: item, routeID: item.RouteID}
+		if item.RouteID != "" && item.RouteID != splitRouteRouteID {
+			isDraft, found := draftRoutes[item.RouteID]
+			if !found {
+				fail(item, utils.CodeNotFound, "Route not found", nil)
+				continue
+			}
+			if isDraft {
+				fail(item, utils.CodeConflict, "Route is a draft; publish it before assigning", nil)
+				continue
+			}
+			for _, rb := range routeBins {
+				if rb.RouteID == item.RouteID {
+					plan.binIDs = append(plan.binIDs, rb.BinID)
+					plan.sequences = append(plan.sequences, rb.SequenceOrder)
+				}
+			}
+			plan.fromRoute = len(plan.binIDs) > 0
+		}
+		if !plan.fromRoute {
+			plan.binIDs = uniqueStrings(assignment.BinIDs)
+			plan.sequences = make([]int, len(plan.binIDs))
+		}
+		if len(plan.binIDs) == 0 {
+			fail(item, utils.CodeValidationFailed, "At least one bin_id is required (or a route with saved bins)", nil)
+			continue
+		}
+		plans = append(plans, plan)
+	}
+
+	// Bins: they exist, have coordinates and are on no other assignment or open shift
+	var allBinIDs []string
+	for _, plan := range plans {
+		allBinIDs = append(allBinIDs, plan.binIDs...)
+	}
+	var existing []string
+	if err := db.SelectContext(ctx, &existing, `SELECT id FROM bins WHERE id = ANY($1)`, pq.Array(allBinIDs)); err != nil {
+		return nil, fmt.Errorf("failed to load bins: %w", err)
+	}
+	existingBins := make(map[string]bool, len(existing))
+	for _, id := range existing {
+		existingBins[id] = true
+	}
+	missing, err := findBinsMissingCoordinates(db, allBinIDs)
+	if err != nil {
+		return nil, err
+	}
+	withoutCoordinates := make(map[string]models.BinMissingCoordinates, len(missing))
+	for _, bin := range missing {
+		withoutCoordinates[bin.ID] = bin
+	}
+	reservations, err := database.FindBinReservations(db, allBinIDs, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	reservationsByBin := map[string][]models.BinReservation{}
+	for _, reservation := range reservations {
+		reservationsByBin[reservation.BinID] = append(reservationsByBin[reservation.BinID], reservation)
+	}
+
+	valid := plans[:0]
+	binAssignment := map[string]int{}
+	for _, plan := range plans {
+		item := plan.item
+		var unknown []string
+		for _, binID := range plan.binIDs {
+			if !existingBins[binID] {
+				unknown = append(unknown, binID)
+			}
+		}
+		if len(unknown) > 0 {
+			fail(item, utils.CodeInvalidReference, "Unknown bin_ids", unknown)
+			continue
+		}
+
+		// Bins without coordinates are left out with a warning, as when assigning one route
+		located := make([]string, 0, len(plan.binIDs))
+		sequences := make([]int, 0, len(plan.binIDs))
+		var unlocated []models.BinMissingCoordinates
+		for j, binID := range plan.binIDs {
+			if bin, ok := withoutCoordinates[binID]; ok {
+				unlocated = append(unlocated, bin)
+				item.Warnings = append(item.Warnings, missingCoordinatesWarning(bin))
+				continue
+			}
+			located = append(located, binID)
+			sequences = append(sequences, plan.sequences[j])
+		}
+		if len(located) == 0 {
+			fail(item, utils.CodeMissingCoordinates, fmt.Sprintf("%d bin(s) have no coordinates; geocode them before adding them to a route",
+				len(unlocated)), unlocated)
+			continue
+		}
+		plan.binIDs, plan.sequences = located, sequences
+
+		var reserved []models.BinReservation
+		conflict := ""
+		for _, binID := range plan.binIDs {
+			reserved = append(reserved, reservationsByBin[binID]...)
+			if other, ok := binAssignment[binID]; ok && conflict == "" {
+				conflict = fmt.Sprintf("Bin %s is also on assignments[%d]", binID, other)
+			}
+		}
+		if len(reserved) > 0 {
+			var reservedErr *txError
+			errors.As(binReservedError(reserved), &reservedErr)
+			fail(item, reservedErr.code, reservedErr.message, reservedErr.details)
+			continue
+		}
+		if conflict != "" {
+			fail(item, utils.CodeBinReserved, conflict, nil)
+			continue
+		}
+		for _, binID := range plan.binIDs {
+			binAssignment[binID] = plan.index
+		}
+		item.TotalBins = len(plan.binIDs)
+		valid = append(valid, plan)
+	}
+	plans = valid
+
+	// Workload limits and service hours, refused without force as for a single assignment
+	limits := loadWorkloadLimits(db)
+	serviceHours := loadServiceHours(db)
+	now := time.Now().Unix()
+	valid = plans[:0]
+	for _, plan := range plans {
+		item := plan.item
+		item.DriverConnected = hub.IsUserConnected(item.DriverID)
+		preview, err := buildRouteAssignmentPreview(db, assignRouteRequest{DriverID: item.DriverID, RouteID: splitRouteRouteID, BinIDs: plan.binIDs},
+			limits, serviceHours, now)
+		if err != nil {
+			log.Printf("⚠️  [ASSIGN-ROUTES] Could not project workload for driver %s: %v", item.DriverID, err)
+			valid = append(valid, plan)
+			continue
+		}
+		item.Workload = preview
+		if preview.ExceedsLimits {
+			if !req.Force {
+				fail(item, utils.CodeWorkloadExceeded, "Assignment exceeds the driver's workload limits (resend with force=true to assign anyway)", nil)
+				continue
+			}
+			item.Warnings = append(item.Warnings, "Workload limits overridden")
+		} else if check := preview.ServiceHours; check != nil && check.ExceedsServiceHours && check.Policy == models.AfterHoursBlock {
+			if !req.Force {
+				fail(item, utils.CodeAfterServiceHours, "Assignment would finish after service hours (resend with force=true to assign anyway)", nil)
+				continue
+			}
+			item.Warnings = append(item.Warnings, "Service hours overridden")
+		}
+		valid = append(valid, plan)
+	}
+	return valid, nil
+}
+
+// createBulkRouteShift creates the shift of one planned assignment with its stops and cost estimate, and queues
+// the driver's notifications in tx
+func createBulkRouteShift(ctx context.Context, tx *sqlx.Tx, hub *websocket.Hub, fcmService *services.FCMService,
+	plan *bulkRoutePlan, costRates models.CostRates, now int64) (models.Shift, error) {
+	var shift models.Shift
+	stores := store.New(tx)
+
+	// Checked again in the transaction, in case another assignment took a bin since the validation
+	reservations, err := database.FindBinReservations(tx, plan.binIDs, "", nil)
+	if err != nil {
+		log.Printf("❌ [ASSIGN-ROUTES] %v", err)
+		return shift, txFail(http.StatusInternalServerError, "Failed to assign route")
+	}
+	if len(reservations) > 0 {
+		return shift, binReservedError(reservations)
+	}
+
+	shiftID := uuid.New().String()
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO shifts (id, driver_id, route_id, status, total_bins, created_at, updated_at)
+		VALUES ($1, $2, $3, 'ready', $4, $5, $6)
+	`, shiftID, plan.item.DriverID, plan.routeID, len(plan.binIDs), now, now)
+	if err != nil {
+		log.Printf("❌ [ASSIGN-ROUTES] Error creating shift for driver %s: %v", plan.item.DriverID, err)
+		return shift, txFail(http.StatusInternalServerError, "Failed to create shift")
+	}
+
+	if plan.fromRoute {
+		routeVersion, err := database.CurrentRouteVersion(tx, plan.routeID, now)
+		if err != nil {
+			log.Printf("❌ [ASSIGN-ROUTES] Error getting route version: %v", err)
+			return shift, txFail(http.StatusInternalServerError, "Failed to assign route")
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE shifts SET route_version = $1 WHERE id = $2`, routeVersion, shiftID); err != nil {
+			log.Printf("❌ [ASSIGN-ROUTES] Error recording route version: %v", err)
+			return shift, txFail(http.StatusInternalServerError, "Failed to assign route")
+		}
+	}
+
+	var routeID *string
+	if plan.routeID != "" {
+		routeID = &plan.routeID
+	}
+	for j, binID := range plan.binIDs {
+		if err := stores.Shifts.InsertCollectionStop(shiftID, binID, routeID, plan.sequences[j], now); err != nil {
+			if database.IsBinReservationViolation(err) {
+				return shift, binReservationRaceError()
+			}
+			log.Printf("❌ [ASSIGN-ROUTES] Error inserting shift stop: %v", err)
+			return shift, txFail(http.StatusInternalServerError, "Failed to assign bins to shift")
+		}
+	}
+
+	if preview := plan.item.Workload; preview != nil {
+		if _, err := database.RecordShiftCostEstimate(tx, shiftID, plan.routeID, preview.Route.Hours, preview.Route.DistanceKm, costRates); err != nil {
+			log.Printf("❌ [ASSIGN-ROUTES] Error recording cost estimate: %v", err)
+			return shift, txFail(http.StatusInternalServerError, "Failed to assign route")
+		}
+	}
+
+	if err := tx.GetContext(ctx, &shift, `SELECT * FROM shifts WHERE id = $1`, shiftID); err != nil {
+		log.Printf("❌ [ASSIGN-ROUTES] Error fetching created shift: %v", err)
+		return shift, txFail(http.StatusInternalServerError, "Failed to assign route")
+	}
+	bins, err := stores.Shifts.Stops(shiftID)
+	if err != nil {
+		log.Printf("❌ [ASSIGN-ROUTES] Error fetching shift stops: %v", err)
+		return shift, txFail(http.StatusInternalServerError, "Failed to fetch route bins")
+	}
+
+	notificationSent, err := queueRouteAssignedToDriver(tx, hub, fcmService, shift, bins, plan.routeID)
+	if err != nil {
+		log.Printf("❌ [ASSIGN-ROUTES] Error queueing route notification: %v", err)
+		return shift, txFail(http.StatusInternalServerError, "Failed to assign route")
+	}
+
+	plan.item.Result = models.BulkRouteAssigned
+	plan.item.ShiftID = &shift.ID
+	plan.item.NotificationSent = notificationSent
+	return shift, nil
+}
+
+// failBulkRouteAssignment records why creating a planned assignment failed
+func failBulkRouteAssignment(item *models.BulkRouteAssignmentItem, err error) {
+	item.Result, item.ShiftID, item.NotificationSent = models.BulkRouteFailed, nil, false
+	var txErr *txError
+	if errors.As(err, &txErr) {
+		item.Code, item.Error, item.Details = txErr.code, txErr.message, txErr.details
+		return
+	}
+	log.Printf("❌ [ASSIGN-ROUTES] Failed to assign route to driver %s: %v", item.DriverID, err)
+	item.Code, item.Error = utils.CodeInternal, "Failed to assign route"
+}
+
+// queueRoutesBulkAssigned queues one routes_bulk_assigned event for managers in place of a driver_shift_change
+// per shift
+func queueRoutesBulkAssigned(q sqlx.Ext, shifts []models.Shift, assignedBy string, now int64) error {
+	assigned := make([]map[string]interface{}, 0, len(shifts))
+	for _, shift := range shifts {
+		assigned = append(assigned, map[string]interface{}{
+			"shift_id":   shift.ID,
+			"driver_id":  shift.DriverID,
+			"route_id":   shift.RouteID,
+			"status":     shift.Status,
+			"total_bins": shift.TotalBins,
+		})
+	}
+	payload := map[string]interface{}{
+		"type": "routes_bulk_assigned",
+		"data": map[string]interface{}{
+			"shifts":      assigned,
+			"assigned_by": assignedBy,
+			"assigned_at": now,
+		},
+	}
+	for _, role := range []string{"admin", "manager"} {
+		if _, err := helpers.EnqueueRoleMessage(q, role, payload); err != nil {
+			return fmt.Errorf("failed to queue routes_bulk_assigned: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/testdb"
+	"ropacal-backend/internal/websocket"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// postAssignRoutes calls POST /api/manager/assign-routes as the manager and returns the status and result
+// It only reports errors with t.Errorf, so concurrent callers can use it from their own goroutines
+func postAssignRoutes(t *testing.T, db *sqlx.DB, managerID string, req models.BulkRouteAssignmentRequest) (int, models.BulkRouteAssignmentResult) {
+	t.Helper()
+	body, err := json.Marshal(req)
+	if err != nil {
+		t.Errorf("failed to encode request: %v", err)
+		return 0, models.BulkRouteAssignmentResult{}
+	}
+	r := httptest.NewRequest(http.MethodPost, "/api/manager/assign-routes", bytes.NewReader(body))
+	r = r.WithContext(context.WithValue(r.Context(), middleware.UserContextKey,
+		middleware.UserClaims{UserID: managerID, Email: "manager@test.ropacal.com", Role: "admin"}))
+	w := httptest.NewRecorder()
+	AssignRoutes(db, websocket.NewHub(), nil).ServeHTTP(w, r)
+
+	var resp struct {
+		Data models.BulkRouteAssignmentResult `json:"data"`
+	}
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Errorf("failed to decode response: %v (%s)", err, w.Body.String())
+		}
+	}
+	return w.Code, resp.Data
+}
+
+// driverShifts counts the driver's open shifts and their stops
+func driverShifts(t *testing.T, db *sqlx.DB, driverID string) (shifts, stops int) {
+	t.Helper()
+	err := db.QueryRow(`
+		SELECT COUNT(DISTINCT s.id), COUNT(rt.id)
+		FROM shifts s LEFT JOIN route_tasks rt ON rt.shift_id = s.id
+		WHERE s.driver_id = $1 AND s.status IN ('ready', 'active', 'paused')
+	`, driverID).Scan(&shifts, &stops)
+	if err != nil {
+		t.Fatalf("failed to count shifts: %v", err)
+	}
+	return shifts, stops
+}
+
+func TestAssignRoutesAllOrNothing(t *testing.T) {
+	db := testdb.Open(t)
+	manager := testdb.User(t, db, "admin")
+	driver := testdb.User(t, db, "driver")
+	routeID := testdb.Route(t, db, testdb.Bin(t, db), testdb.Bin(t, db))
+
+	req := models.BulkRouteAssignmentRequest{
+		Assignments: []models.BulkRouteAssignment{
+			{DriverID: driver, RouteID: routeID},
+			{DriverID: "no-such-driver", RouteID: routeID},
+		},
+		Force:        true,
+		AllOrNothing: true,
+	}
+	if status, _ := postAssignRoutes(t, db, manager, req); status != http.StatusUnprocessableEntity {
+		t.Fatalf("all_or_nothing with an invalid assignment: got %d, want 422", status)
+	}
+	if shifts, _ := driverShifts(t, db, driver); shifts != 0 {
+		t.Fatalf("all_or_nothing failed but the valid driver got %d shift(s)", shifts)
+	}
+
+	// Without all_or_nothing the valid assignment goes through
+	req.AllOrNothing = false
+	status, result := postAssignRoutes(t, db, manager, req)
+	if status != http.StatusOK || result.Assigned != 1 || result.Failed != 1 {
+		t.Fatalf("partial assignment: got %d with %d assigned, %d failed; want 200 with 1 and 1", status, result.Assigned, result.Failed)
+	}
+	if shifts, stops := driverShifts(t, db, driver); shifts != 1 || stops != 2 {
+		t.Fatalf("driver has %d shift(s) with %d stops, want 1 with 2", shifts, stops)
+	}
+}
+
+// Two managers assigning the same route's bins at once: the bin reservation lets exactly one through
+func TestAssignRoutesConcurrent(t *testing.T) {
+	db := testdb.Open(t)
+	manager := testdb.User(t, db, "admin")
+	routeID := testdb.Route(t, db, testdb.Bin(t, db), testdb.Bin(t, db), testdb.Bin(t, db))
+	drivers := []string{testdb.User(t, db, "driver"), testdb.User(t, db, "driver")}
+
+	results := make([]models.BulkRouteAssignmentResult, len(drivers))
+	var wg sync.WaitGroup
+	for i, driver := range drivers {
+		wg.Add(1)
+		go func(i int, driver string) {
+			defer wg.Done()
+			_, results[i] = postAssignRoutes(t, db, manager, models.BulkRouteAssignmentRequest{
+				Assignments: []models.BulkRouteAssignment{{DriverID: driver, RouteID: routeID}},
+				Force:       true,
+			})
+		}(i, driver)
+	}
+	wg.Wait()
+
+	assigned, stops := 0, 0
+	for i, driver := range drivers {
+		assigned += results[i].Assigned
+		_, driverStops := driverShifts(t, db, driver)
+		stops += driverStops
+	}
+	if assigned != 1 || stops != 3 {
+		t.Fatalf("concurrent assignments of the same bins: %d assigned with %d stops in total, want 1 with 3", assigned, stops)
+	}
+}
@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/store"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+)
+
+// GPS filtering for the actual distance of a shift
+const (
+	efficiencyMaxAccuracyMeters = 100.0 // Less accurate pings are ignored
+	efficiencyMaxSpeedKmh       = 150.0 // Faster jumps between pings are GPS glitches
+)
+
+// Route efficiency page sizes for GET /api/analytics/routes/{id}/efficiency
+const (
+	routeEfficiencyDefaultLimit = 50
+	routeEfficiencyMaxLimit     = 500
+)
+
+// efficiencyPing is a driver_locations row used for the actual distance
+type efficiencyPing struct {
+	Latitude  float64  `db:"latitude"`
+	Longitude float64  `db:"longitude"`
+	Accuracy  *float64 `db:"accuracy"`
+	Timestamp int64    `db:"timestamp"` // Milliseconds
+}
+
+// outOfSequenceStops returns how many stops must move for the planned orders (in completion order)
+// to be increasing: len(orders) minus their longest increasing subsequence
+func outOfSequenceStops(orders []int) int {
+	tails := []int{}
+	for _, order := range orders {
+		i := sort.SearchInts(tails, order)
+		if i == len(tails) {
+			tails = append(tails, order)
+		} else {
+			tails[i] = order
+		}
+	}
+	return len(orders) - len(tails)
+}
+
+// calculateShiftEfficiency compares a shift's driven distance, duration and stop order with its plan
+func calculateShiftEfficiency(db *sqlx.DB, shift models.Shift, activeSeconds int64) (models.ShiftEfficiency, error) {
+	efficiency := models.ShiftEfficiency{ActualDurationSeconds: activeSeconds}
+
+	stops, err := store.NewShiftStore(db).EfficiencyStops(shift.ID)
+	if err != nil {
+		return efficiency, err
+	}
+
+	// Planned: the optimizer's road distance when the route was optimized, else straight lines in planned order
+	if meta := shift.OptimizationMetadata; meta != nil && meta.TotalDistanceKm > 0 {
+		planned := meta.TotalDistanceKm
+		duration := int64(meta.TotalDurationSeconds)
+		source := models.PlannedSourceOptimizer
+		efficiency.PlannedDistanceKm = &planned
+		efficiency.PlannedDurationSeconds = &duration
+		efficiency.PlannedSource = &source
+	} else if len(stops) > 1 {
+		planned := 0.0
+		for i := 1; i < len(stops); i++ {
+			planned += haversineDistanceKm(stops[i-1].Latitude, stops[i-1].Longitude, stops[i].Latitude, stops[i].Longitude)
+		}
+		planned = math.Round(planned*100) / 100
+		source := models.PlannedSourceStraightLine
+		efficiency.PlannedDistanceKm = &planned
+		efficiency.PlannedSource = &source
+	}
+
+	// Sequence deviation: planned positions of the completed stops, in the order they were completed
+	completed := []models.EfficiencyStop{}
+	for _, stop := range stops {
+		if stop.CompletedAt != nil {
+			completed = append(completed, stop)
+		}
+	}
+	sort.SliceStable(completed, func(i, j int) bool { return *completed[i].CompletedAt < *completed[j].CompletedAt })
+	orders := make([]int, len(completed))
+	for i, stop := range completed {
+		orders[i] = stop.SequenceOrder
+	}
+	efficiency.CompletedStops = len(completed)
+	efficiency.OutOfSequenceStops = outOfSequenceStops(orders)
+	if efficiency.CompletedStops > 0 {
+		efficiency.SequenceDeviation = math.Round(float64(efficiency.OutOfSequenceStops)/float64(efficiency.CompletedStops)*10000) / 100
+	}
+	if activeSeconds > 0 {
+		efficiency.StopsPerHour = math.Round(float64(efficiency.CompletedStops)/(float64(activeSeconds)/3600)*100) / 100
+	}
+
+	// Actual: sum of the legs between accurate pings, skipping glitches
+	var pings []efficiencyPing
+	err = db.Select(&pings, `
+		SELECT latitude, longitude, accuracy, timestamp
+		FROM driver_locations
+		WHERE shift_id = $1
+		ORDER BY timestamp ASC`, shift.ID)
+	if err != nil {
+		return efficiency, fmt.Errorf("failed to load locations for shift %s: %w", shift.ID, err)
+	}
+	var last *efficiencyPing
+	distance := 0.0
+	for i := range pings {
+		ping := &pings[i]
+		if ping.Accuracy != nil && *ping.Accuracy > efficiencyMaxAccuracyMeters {
+			continue
+		}
+		if last != nil {
+			leg := haversineDistanceKm(last.Latitude, last.Longitude, ping.Latitude, ping.Longitude)
+			hours := float64(ping.Timestamp-last.Timestamp) / 3600000
+			if hours > 0 && leg/hours > efficiencyMaxSpeedKmh {
+				continue
+			}
+			distance += leg
+		}
+		last = ping
+	}
+	if last != nil {
+		actual := math.Round(distance*100) / 100
+		efficiency.ActualDistanceKm = &actual
+	}
+
+	return efficiency, nil
+}
+
+// recordShiftEfficiency calculates a shift's efficiency and stores it on its shift_history row, then its cost
+// Failures are logged only - the shift has already ended
+func recordShiftEfficiency(db *sqlx.DB, shift models.Shift, activeSeconds int64) {
+	efficiency, err := calculateShiftEfficiency(db, shift, activeSeconds)
+	if err != nil {
+		log.Printf("⚠️  [EFFICIENCY] Failed to calculate efficiency for shift %s: %v", shift.ID, err)
+		return
+	}
+
+	_, err = db.NamedExec(`
+		UPDATE shift_history
+		SET actual_distance_km = :actual_distance_km,
+		    actual_duration_seconds = :actual_duration_seconds,
+		    planned_distance_km = :planned_distance_km,
+		    planned_duration_seconds = :planned_duration_seconds,
+		    planned_source = :planned_source,
+		    completed_stops = :completed_stops,
+		    out_of_sequence_stops = :out_of_sequence_stops,
+		    sequence_deviation = :sequence_deviation,
+		    stops_per_hour = :stops_per_hour
+		WHERE id = :id`, struct {
+		ID string `db:"id"`
+		models.ShiftEfficiency
+	}{shift.ID, efficiency})
+	if err != nil {
+		log.Printf("⚠️  [EFFICIENCY] Failed to save efficiency for shift %s: %v", shift.ID, err)
+		return
+	}
+
+	log.Printf("📏 [EFFICIENCY] Shift %s: %d stops, %.1f stops/h, %.1f%% out of sequence",
+		shift.ID, efficiency.CompletedStops, efficiency.StopsPerHour, efficiency.SequenceDeviation)
+
+	// The actual cost is priced from the same driven distance and duration
+	recordShiftCost(db, shift, efficiency)
+}
+
+// GetRouteEfficiency compares a route blueprint's planned and actual distance, duration and stop order
+// across its ended shifts
+// GET /api/analytics/routes/{id}/efficiency?from=<unix>&to=<unix>&limit=50
+func GetRouteEfficiency(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		routeID := chi.URLParam(r, "id")
+		q := r.URL.Query()
+
+		response := models.RouteEfficiencyResponse{
+			RouteID:    routeID,
+			ByDriver:   []models.RouteDriverEfficiency{},
+			Executions: []models.RouteExecution{},
+		}
+
+		err := db.GetContext(r.Context(), &response.RouteName, `SELECT name FROM routes WHERE id = $1`, routeID)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "Route not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [EFFICIENCY] Failed to fetch route %s: %v", routeID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch route efficiency")
+			return
+		}
+
+		for _, bound := range []struct {
+			param string
+			dest  **int64
+		}{{"from", &response.From}, {"to", &response.To}} {
+			v := q.Get(bound.param)
+			if v == "" {
+				continue
+			}
+			parsed, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				utils.RespondError(w, http.StatusBadRequest, fmt.Sprintf("Invalid %s timestamp", bound.param))
+				return
+			}
+			*bound.dest = &parsed
+		}
+
+		limit := routeEfficiencyDefaultLimit
+		if parsed, err := strconv.Atoi(q.Get("limit")); err == nil && parsed > 0 && parsed <= routeEfficiencyMaxLimit {
+			limit = parsed
+		}
+
+		// Shifts that ended before efficiency tracking have no completed_stops and are left out
+		where := `
+			FROM shift_history sh
+			LEFT JOIN users u ON u.id = sh.driver_id
+			WHERE sh.route_id = $1 AND sh.completed_stops IS NOT NULL
+			  AND ($2::BIGINT IS NULL OR sh.ended_at >= $2)
+			  AND ($3::BIGINT IS NULL OR sh.ended_at <= $3)`
+		aggregates := `
+			COUNT(*) AS executions,
+			AVG(sh.actual_distance_km)::FLOAT8 AS avg_actual_distance_km,
+			AVG(sh.planned_distance_km)::FLOAT8 AS avg_planned_distance_km,
+			AVG(sh.actual_distance_km / NULLIF(sh.planned_distance_km, 0))::FLOAT8 AS avg_distance_ratio,
+			AVG(sh.actual_duration_seconds)::FLOAT8 AS avg_actual_duration_seconds,
+			AVG(sh.planned_duration_seconds)::FLOAT8 AS avg_planned_duration_seconds,
+			AVG(sh.sequence_deviation)::FLOAT8 AS avg_sequence_deviation,
+			AVG(sh.stops_per_hour)::FLOAT8 AS avg_stops_per_hour`
+		args := []interface{}{routeID, response.From, response.To}
+
+		if err := db.GetContext(r.Context(), &response.Summary, `SELECT`+aggregates+where, args...); err != nil {
+			log.Printf("❌ [EFFICIENCY] Failed to summarize route %s: %v", routeID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch route efficiency")
+			return
+		}
+
+		err = db.SelectContext(r.Context(), &response.ByDriver, `
+			SELECT sh.driver_id, MIN(u.name) AS driver_name,`+aggregates+where+`
+			GROUP BY sh.driver_id
+			ORDER BY avg_stops_per_hour DESC NULLS LAST`, args...)
+		if err != nil {
+			log.Printf("❌ [EFFICIENCY] Failed to summarize route %s by driver: %v", routeID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch route efficiency")
+			return
+		}
+
+		err = db.SelectContext(r.Context(), &response.Executions, `
+			SELECT sh.id AS shift_id, sh.driver_id, u.name AS driver_name, sh.ended_at, sh.end_reason,
+			       sh.actual_distance_km::FLOAT8 AS actual_distance_km, sh.actual_duration_seconds,
+			       sh.planned_distance_km::FLOAT8 AS planned_distance_km, sh.planned_duration_seconds, sh.planned_source,
+			       sh.completed_stops, sh.out_of_sequence_stops,
+			       sh.sequence_deviation::FLOAT8 AS sequence_deviation, sh.stops_per_hour::FLOAT8 AS stops_per_hour`+where+`
+			ORDER BY sh.ended_at DESC
+			LIMIT $4`, append(args, limit)...)
+		if err != nil {
+			log.Printf("❌ [EFFICIENCY] Failed to fetch executions of route %s: %v", routeID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch route efficiency")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    response,
+		})
+	}
+}
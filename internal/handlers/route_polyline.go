@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/services"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+)
+
+// polylineLegConcurrency limits the Directions API lookups one polyline makes at a time
+const polylineLegConcurrency = 4
+
+// polylineStop is a stop on a route polyline (dropoffs are drawn at the move's destination)
+type polylineStop struct {
+	TaskID    string  `db:"id"`
+	Latitude  float64 `db:"latitude"`
+	Longitude float64 `db:"longitude"`
+}
+
+// routePolylineKey identifies a stop sequence: the stops' task IDs and coordinates, in order
+func routePolylineKey(stops []polylineStop) string {
+	hash := sha256.New()
+	for _, stop := range stops {
+		fmt.Fprintf(hash, "%s:%.6f,%.6f;", stop.TaskID, stop.Latitude, stop.Longitude)
+	}
+	return hex.EncodeToString(hash.Sum(nil)[:16])
+}
+
+// GetShiftPolyline returns a shift's route line: driving legs between its stops in sequence order, chained into
+// one encoded polyline, so the dashboard doesn't call a directions API on every load
+// Lines that follow the roads are cached per stop sequence; legs the Directions API can't provide are straight
+// lines (source "estimate") and aren't cached
+// GET /api/manager/shifts/{id}/polyline?refresh=true
+func GetShiftPolyline(db *sqlx.DB, directions *services.DirectionsService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		shiftID := chi.URLParam(r, "id")
+
+		var exists bool
+		if err := db.GetContext(r.Context(), &exists, `SELECT EXISTS (SELECT 1 FROM shifts WHERE id = $1)`, shiftID); err != nil {
+			log.Printf("❌ [ROUTE-POLYLINE] Failed to fetch shift %s: %v", shiftID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch shift")
+			return
+		}
+		if !exists {
+			utils.RespondError(w, http.StatusNotFound, "Shift not found")
+			return
+		}
+
+		stops := []polylineStop{}
+		err := db.SelectContext(r.Context(), &stops, `
+			SELECT id,
+				CASE WHEN task_type = 'dropoff' AND destination_latitude IS NOT NULL AND destination_longitude IS NOT NULL
+					THEN destination_latitude ELSE latitude END AS latitude,
+				CASE WHEN task_type = 'dropoff' AND destination_latitude IS NOT NULL AND destination_longitude IS NOT NULL
+					THEN destination_longitude ELSE longitude END AS longitude
+			FROM route_tasks
+			WHERE shift_id = $1 AND NOT skipped AND (latitude <> 0 OR longitude <> 0)
+			ORDER BY sequence_order ASC, created_at ASC
+		`, shiftID)
+		if err != nil {
+			log.Printf("❌ [ROUTE-POLYLINE] Failed to load stops for shift %s: %v", shiftID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to load route")
+			return
+		}
+		stopsKey := routePolylineKey(stops)
+
+		if r.URL.Query().Get("refresh") != "true" {
+			var cached models.RoutePolyline
+			err := db.GetContext(r.Context(), &cached, `SELECT * FROM route_polylines WHERE shift_id = $1 AND stops_key = $2`, shiftID, stopsKey)
+			if err == nil {
+				cached.Cached = true
+				utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+					"success": true,
+					"data":    cached,
+				})
+				return
+			}
+			if err != sql.ErrNoRows {
+				log.Printf("⚠️  [ROUTE-POLYLINE] Failed to read cache for shift %s: %v", shiftID, err)
+			}
+		}
+
+		polyline, err := computeRoutePolyline(r, directions, shiftID, stopsKey, stops)
+		if err != nil {
+			log.Printf("❌ [ROUTE-POLYLINE] Failed to compute polyline for shift %s: %v", shiftID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to compute route polyline")
+			return
+		}
+
+		// Only lines that follow the roads are worth keeping; the shift's lines for other sequences are stale
+		if polyline.Source == models.RoutePolylineDirections {
+			err := database.WithTx(r.Context(), db, func(tx *sqlx.Tx) error {
+				if _, err := tx.Exec(`DELETE FROM route_polylines WHERE shift_id = $1 AND stops_key <> $2`, shiftID, stopsKey); err != nil {
+					return err
+				}
+				_, err := tx.Exec(`
+					INSERT INTO route_polylines (shift_id, stops_key, polyline, legs, distance_meters, duration_seconds, source, computed_at)
+					VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+					ON CONFLICT (shift_id, stops_key) DO UPDATE
+					SET polyline = EXCLUDED.polyline, legs = EXCLUDED.legs, distance_meters = EXCLUDED.distance_meters,
+						duration_seconds = EXCLUDED.duration_seconds, source = EXCLUDED.source, computed_at = EXCLUDED.computed_at
+				`, shiftID, stopsKey, polyline.Polyline, string(polyline.Legs), polyline.DistanceMeters, polyline.DurationSeconds,
+					polyline.Source, polyline.ComputedAt)
+				return err
+			})
+			if err != nil {
+				log.Printf("⚠️  [ROUTE-POLYLINE] Failed to cache polyline for shift %s: %v", shiftID, err)
+			}
+		}
+
+		log.Printf("🗺️  [ROUTE-POLYLINE] Computed %s polyline for shift %s: %d stops, %.1f km",
+			polyline.Source, shiftID, len(stops), float64(polyline.DistanceMeters)/1000)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    polyline,
+		})
+	}
+}
+
+// computeRoutePolyline fetches the driving leg between each pair of consecutive stops and chains them
+// Legs the Directions API can't provide are straight lines at etaAverageSpeedKmh
+func computeRoutePolyline(r *http.Request, directions *services.DirectionsService, shiftID, stopsKey string, stops []polylineStop) (*models.RoutePolyline, error) {
+	legs := make([]models.RoutePolylineLeg, 0, len(stops))
+	for i := 1; i < len(stops); i++ {
+		legs = append(legs, models.RoutePolylineLeg{FromTaskID: stops[i-1].TaskID, ToTaskID: stops[i].TaskID})
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, polylineLegConcurrency)
+	for i := range legs {
+		from, to := stops[i], stops[i+1]
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(leg *models.RoutePolylineLeg) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			result, err := directions.GetLeg(r.Context(), from.Latitude, from.Longitude, to.Latitude, to.Longitude)
+			if err == nil {
+				leg.Polyline, leg.DistanceMeters, leg.DurationSeconds = result.Polyline, result.DistanceMeters, result.DurationSeconds
+				leg.Source = models.RoutePolylineDirections
+				return
+			}
+			if directions.Enabled() {
+				log.Printf("⚠️  [ROUTE-POLYLINE] Directions lookup failed, using a straight line: %v", err)
+			}
+			distanceKm := haversineDistanceKm(from.Latitude, from.Longitude, to.Latitude, to.Longitude)
+			leg.Polyline = services.EncodePolyline([]services.OptimizerLocation{
+				{Latitude: from.Latitude, Longitude: from.Longitude},
+				{Latitude: to.Latitude, Longitude: to.Longitude},
+			})
+			leg.DistanceMeters = int(distanceKm * 1000)
+			leg.DurationSeconds = int(distanceKm / etaAverageSpeedKmh * 3600)
+			leg.Source = models.RoutePolylineEstimate
+		}(&legs[i])
+	}
+	wg.Wait()
+
+	polyline := &models.RoutePolyline{
+		ShiftID:    shiftID,
+		StopsKey:   stopsKey,
+		Source:     models.RoutePolylineDirections,
+		ComputedAt: time.Now().Unix(),
+	}
+	encoded := make([]string, len(legs))
+	for i, leg := range legs {
+		encoded[i] = leg.Polyline
+		polyline.DistanceMeters += leg.DistanceMeters
+		polyline.DurationSeconds += leg.DurationSeconds
+		if leg.Source != models.RoutePolylineDirections {
+			polyline.Source = models.RoutePolylineEstimate
+		}
+	}
+
+	var err error
+	if len(stops) == 1 {
+		polyline.Polyline = services.EncodePolyline([]services.OptimizerLocation{{Latitude: stops[0].Latitude, Longitude: stops[0].Longitude}})
+	} else if polyline.Polyline, err = services.ChainPolylines(encoded); err != nil {
+		return nil, fmt.Errorf("failed to chain legs: %w", err)
+	}
+	if polyline.Legs, err = json.Marshal(legs); err != nil {
+		return nil, fmt.Errorf("failed to encode legs: %w", err)
+	}
+	return polyline, nil
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+
+	"ropacal-backend/internal/helpers"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/services"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+)
+
+// routeReoptimizationResponse is the response of POST /api/manager/shifts/{id}/reoptimize
+type routeReoptimizationResponse struct {
+	models.RouteReoptimization
+	NextRunAt int64 `json:"next_run_at"` // Unix; when the queued request is expected to run
+}
+
+// ReoptimizeShiftRoute queues a re-optimization of a shift's remaining stops
+// POST /api/manager/shifts/{id}/reoptimize
+// The route is re-optimized in the background (see services.RouteReoptimizer), at most once per debounce
+// interval per shift; the driver gets a single shift_update when it runs
+func ReoptimizeShiftRoute(db *sqlx.DB, reoptimizer *services.RouteReoptimizer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		shiftID := chi.URLParam(r, "id")
+
+		var status models.ShiftStatus
+		err := db.GetContext(r.Context(), &status, `SELECT status FROM shifts WHERE id = $1`, shiftID)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "Shift not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [REOPTIMIZE] Failed to fetch shift: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch shift")
+			return
+		}
+
+		queued, err := helpers.RequestRouteReoptimization(db, shiftID, models.ReoptimizeReasonManual)
+		if err != nil {
+			log.Printf("❌ [REOPTIMIZE] %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to queue route re-optimization")
+			return
+		}
+		if queued == nil {
+			utils.RespondErrorCode(w, http.StatusConflict, utils.CodeConflict,
+				"Only active or paused shifts can be re-optimized", map[string]string{"status": string(status)})
+			return
+		}
+
+		log.Printf("🔀 [REOPTIMIZE] Queued shift %s (%v)", shiftID, queued.Reasons)
+		utils.RespondJSON(w, http.StatusAccepted, map[string]interface{}{
+			"success": true,
+			"data": routeReoptimizationResponse{
+				RouteReoptimization: *queued,
+				NextRunAt:           reoptimizer.NextRunAt(queued),
+			},
+		})
+	}
+}
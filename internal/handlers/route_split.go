@@ -0,0 +1,427 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/services"
+	"ropacal-backend/internal/store"
+	"ropacal-backend/internal/websocket"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// splitRouteRouteID is the route_id of shifts created from a split; their stops keep the planned order
+const splitRouteRouteID = "custom"
+
+// splitRoutePreviewRequest is the body for POST /api/manager/assign-route/split/preview
+type splitRoutePreviewRequest struct {
+	DriverIDs         []string `json:"driver_ids" validate:"required"` // Drivers to share the bins, each gets at most one route
+	BinIDs            []string `json:"bin_ids" validate:"required"`
+	MaxBinsPerDriver  int      `json:"max_bins_per_driver"`  // Defaults to the workload limit
+	MaxHoursPerDriver float64  `json:"max_hours_per_driver"` // Defaults to the workload limit
+}
+
+// splitRouteRequest is the body for POST /api/manager/assign-route/split: the routes of a preview, as confirmed
+type splitRouteRequest struct {
+	Routes []splitRouteAssignment `json:"routes" validate:"required"`
+	Force  bool                   `json:"force"` // Assign even if a driver's projected workload exceeds the limits or service hours
+}
+
+// splitRouteAssignment is one driver's route in a confirmed split
+type splitRouteAssignment struct {
+	DriverID string   `json:"driver_id" validate:"required"`
+	BinIDs   []string `json:"bin_ids" validate:"required"` // In planned order
+}
+
+// uniqueStrings returns values without blanks and repeats, keeping the first occurrence of each
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, value := range values {
+		if value != "" && !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
+
+// checkSplitDrivers returns a message for the first driver that can't take a route (unknown, not a driver or deactivated)
+func checkSplitDrivers(db *sqlx.DB, driverIDs []string) (string, error) {
+	var drivers []struct {
+		ID       string `db:"id"`
+		Role     string `db:"role"`
+		IsActive bool   `db:"is_active"`
+	}
+	err := db.Select(&drivers, `SELECT id, role, deactivated_at IS NULL AS is_active FROM users WHERE id = ANY($1)`, pq.Array(driverIDs))
+	if err != nil {
+		return "", fmt.Errorf("failed to load drivers: %w", err)
+	}
+	found := make(map[string]bool, len(drivers))
+	for _, driver := range drivers {
+		found[driver.ID] = true
+		if driver.Role != "driver" {
+			return fmt.Sprintf("User %s is not a driver", driver.ID), nil
+		}
+		if !driver.IsActive {
+			return fmt.Sprintf("Driver %s is deactivated", driver.ID), nil
+		}
+	}
+	for _, id := range driverIDs {
+		if !found[id] {
+			return fmt.Sprintf("Driver %s not found", id), nil
+		}
+	}
+	return "", nil
+}
+
+// PreviewRouteSplit splits a bin set too large for one driver across several (nothing is saved)
+// Bins are divided into compact sectors around the warehouse with at most the per-driver bins and hours each
+// (see services.RouteOptimizer.SplitRoute), and every route comes with its driver's projected workload;
+// confirm with POST /api/manager/assign-route/split
+// POST /api/manager/assign-route/split/preview
+func PreviewRouteSplit(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req splitRoutePreviewRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		driverIDs := uniqueStrings(req.DriverIDs)
+		binIDs := uniqueStrings(req.BinIDs)
+		if len(driverIDs) == 0 || len(binIDs) == 0 {
+			utils.RespondError(w, http.StatusBadRequest, "At least one driver_id and one bin_id are required")
+			return
+		}
+		if req.MaxBinsPerDriver < 0 || req.MaxHoursPerDriver < 0 || req.MaxHoursPerDriver > 24 {
+			utils.RespondError(w, http.StatusBadRequest, "max_bins_per_driver must not be negative and max_hours_per_driver must be between 0 and 24")
+			return
+		}
+
+		if message, err := checkSplitDrivers(db, driverIDs); err != nil {
+			log.Printf("❌ [ROUTE-SPLIT] %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to preview route split")
+			return
+		} else if message != "" {
+			utils.RespondError(w, http.StatusBadRequest, message)
+			return
+		}
+
+		bins := []models.Bin{}
+		if err := db.SelectContext(r.Context(), &bins, `SELECT * FROM bins WHERE id = ANY($1)`, pq.Array(binIDs)); err != nil {
+			log.Printf("❌ [ROUTE-SPLIT] Failed to load bins: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to preview route split")
+			return
+		}
+		if len(bins) != len(binIDs) {
+			utils.RespondError(w, http.StatusBadRequest, "One or more bin_ids are invalid")
+			return
+		}
+
+		limits := loadWorkloadLimits(db)
+		preview := models.RouteSplitPreview{
+			Routes:            []models.RouteSplitRoute{},
+			UnassignedBinIDs:  []string{},
+			MaxBinsPerDriver:  limits.MaxBins,
+			MaxHoursPerDriver: limits.MaxHours,
+			Warnings:          []string{},
+		}
+		if req.MaxBinsPerDriver > 0 {
+			preview.MaxBinsPerDriver = req.MaxBinsPerDriver
+		}
+		if req.MaxHoursPerDriver > 0 {
+			preview.MaxHoursPerDriver = req.MaxHoursPerDriver
+		}
+
+		// Bins without coordinates can't be placed; they're left out with a warning, as when assigning a route
+		located := make([]services.BinWithPriority, 0, len(bins))
+		for _, bin := range bins {
+			if bin.Latitude == nil || bin.Longitude == nil {
+				preview.Warnings = append(preview.Warnings, missingCoordinatesWarning(models.BinMissingCoordinates{
+					ID: bin.ID, BinNumber: bin.BinNumber, CurrentStreet: bin.CurrentStreet,
+				}))
+				continue
+			}
+			located = append(located, services.BinWithPriority{
+				ID:             bin.ID,
+				Latitude:       *bin.Latitude,
+				Longitude:      *bin.Longitude,
+				FillPercentage: fillOrZero(bin.FillPercentage),
+				CurrentStreet:  bin.CurrentStreet,
+				TimeWindow:     models.StopTimeWindow(nil, nil, bin.TimeWindowStart, bin.TimeWindowEnd),
+			})
+		}
+
+		warehouse := services.GetWarehouseLocation()
+		routes, unassigned := services.NewRouteOptimizer().SplitRoute(located, warehouse, len(driverIDs), services.RouteSplitLimits{
+			MaxBins:    preview.MaxBinsPerDriver,
+			MaxMinutes: preview.MaxHoursPerDriver * 60,
+		})
+
+		serviceHours := loadServiceHours(db)
+		now := time.Now().Unix()
+		for i, route := range routes {
+			split := models.RouteSplitRoute{
+				DriverID:   driverIDs[i],
+				BinIDs:     make([]string, len(route)),
+				Bins:       len(route),
+				DistanceKm: math.Round(services.RouteDistanceKm(route, warehouse)*10) / 10,
+				Hours:      math.Round(services.EstimateRouteMinutes(route, warehouse)/60*10) / 10,
+			}
+			for j, bin := range route {
+				split.BinIDs[j] = bin.ID
+			}
+			workload, err := buildRouteAssignmentPreview(db, assignRouteRequest{DriverID: split.DriverID, RouteID: splitRouteRouteID, BinIDs: split.BinIDs},
+				limits, serviceHours, now)
+			if err != nil {
+				log.Printf("⚠️  [ROUTE-SPLIT] Could not project workload for driver %s: %v", split.DriverID, err)
+			} else {
+				split.Workload = workload
+				if workload.ExceedsLimits || (workload.ServiceHours != nil && workload.ServiceHours.ExceedsServiceHours) {
+					preview.Warnings = append(preview.Warnings, fmt.Sprintf("Driver %s would be over their workload limits or service hours", split.DriverID))
+				}
+			}
+			preview.Routes = append(preview.Routes, split)
+		}
+		for _, bin := range unassigned {
+			preview.UnassignedBinIDs = append(preview.UnassignedBinIDs, bin.ID)
+		}
+		if len(unassigned) > 0 {
+			preview.Warnings = append(preview.Warnings, fmt.Sprintf("%d bin(s) don't fit within %d driver(s) at %d bins and %.1f hours each",
+				len(unassigned), len(driverIDs), preview.MaxBinsPerDriver, preview.MaxHoursPerDriver))
+		}
+		if len(routes) < len(driverIDs) {
+			preview.Warnings = append(preview.Warnings, fmt.Sprintf("%d driver(s) were not needed", len(driverIDs)-len(routes)))
+		}
+
+		log.Printf("🗺️  [ROUTE-SPLIT] %d bins split into %d routes (%d unassigned)", len(binIDs), len(routes), len(unassigned))
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    preview,
+		})
+	}
+}
+
+// AssignSplitRoute creates one shift per route of a confirmed split, all in one transaction: either every
+// driver gets their route or none do
+// Each route's bins keep the planned order (rotated from the driver's location at start). Drivers over their
+// workload limits, or finishing after service hours when the policy is to block, refuse the whole split
+// without force=true
+// POST /api/manager/assign-route/split
+func AssignSplitRoute(db *sqlx.DB, hub *websocket.Hub, fcmService *services.FCMService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var req splitRouteRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if len(req.Routes) == 0 {
+			utils.RespondError(w, http.StatusBadRequest, "At least one route is required")
+			return
+		}
+
+		// Each driver gets one route and each bin is on one route
+		driverIDs := make([]string, 0, len(req.Routes))
+		allBinIDs := []string{}
+		binRoute := map[string]int{}
+		for i := range req.Routes {
+			route := &req.Routes[i]
+			route.BinIDs = uniqueStrings(route.BinIDs)
+			if route.DriverID == "" || len(route.BinIDs) == 0 {
+				utils.RespondError(w, http.StatusBadRequest, fmt.Sprintf("routes[%d] needs a driver_id and at least one bin_id", i))
+				return
+			}
+			driverIDs = append(driverIDs, route.DriverID)
+			for _, binID := range route.BinIDs {
+				if other, ok := binRoute[binID]; ok {
+					utils.RespondError(w, http.StatusBadRequest, fmt.Sprintf("Bin %s is on routes[%d] and routes[%d]", binID, other, i))
+					return
+				}
+				binRoute[binID] = i
+				allBinIDs = append(allBinIDs, binID)
+			}
+		}
+		if len(uniqueStrings(driverIDs)) != len(driverIDs) {
+			utils.RespondError(w, http.StatusBadRequest, "Each driver can only have one route")
+			return
+		}
+
+		if message, err := checkSplitDrivers(db, driverIDs); err != nil {
+			log.Printf("❌ [ROUTE-SPLIT] %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to assign routes")
+			return
+		} else if message != "" {
+			utils.RespondError(w, http.StatusBadRequest, message)
+			return
+		}
+
+		missing, err := findBinsMissingCoordinates(db, allBinIDs)
+		if err != nil {
+			log.Printf("❌ [ROUTE-SPLIT] %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to assign routes")
+			return
+		}
+		if len(missing) > 0 {
+			respondBinsMissingCoordinates(w, missing)
+			return
+		}
+
+		// Project every driver's workload first, so a split that overloads anyone is refused as a whole
+		limits := loadWorkloadLimits(db)
+		serviceHours := loadServiceHours(db)
+		now := time.Now().Unix()
+		previews := make([]*models.RouteAssignmentPreview, len(req.Routes))
+		overLimits := []*models.RouteAssignmentPreview{}
+		afterHours := []*models.RouteAssignmentPreview{}
+		for i, route := range req.Routes {
+			preview, err := buildRouteAssignmentPreview(db, assignRouteRequest{DriverID: route.DriverID, RouteID: splitRouteRouteID, BinIDs: route.BinIDs},
+				limits, serviceHours, now)
+			if err != nil {
+				log.Printf("⚠️  [ROUTE-SPLIT] Could not project workload for driver %s: %v", route.DriverID, err)
+				continue
+			}
+			previews[i] = preview
+			if preview.ExceedsLimits {
+				overLimits = append(overLimits, preview)
+			} else if check := preview.ServiceHours; check != nil && check.ExceedsServiceHours && check.Policy == models.AfterHoursBlock {
+				afterHours = append(afterHours, preview)
+			}
+		}
+		if !req.Force {
+			if len(overLimits) > 0 {
+				utils.RespondErrorCode(w, http.StatusConflict, utils.CodeWorkloadExceeded,
+					fmt.Sprintf("%d driver(s) would exceed their workload limits (resend with force=true to assign anyway)", len(overLimits)), overLimits)
+				return
+			}
+			if len(afterHours) > 0 {
+				utils.RespondErrorCode(w, http.StatusConflict, utils.CodeAfterServiceHours,
+					fmt.Sprintf("%d route(s) would finish after service hours (resend with force=true to assign anyway)", len(afterHours)), afterHours)
+				return
+			}
+		} else if len(overLimits) > 0 || len(afterHours) > 0 {
+			log.Printf("⚠️  [ROUTE-SPLIT] Workload limits or service hours overridden by %s for %d driver(s)", userClaims.Email, len(overLimits)+len(afterHours))
+		}
+
+		type createdShift struct {
+			ShiftID          string                       `json:"shift_id"`
+			DriverID         string                       `json:"driver_id"`
+			Status           models.ShiftStatus           `json:"status"`
+			TotalBins        int                          `json:"total_bins"`
+			Bins             []models.ShiftBinWithDetails `json:"bins"`
+			NotificationSent bool                         `json:"notification_sent"`
+		}
+		created := make([]createdShift, 0, len(req.Routes))
+
+		err = database.WithTx(r.Context(), db, func(tx *sqlx.Tx) error {
+			stores := store.New(tx)
+
+			count, err := stores.Bins.CountExisting(allBinIDs)
+			if err != nil {
+				log.Printf("❌ [ROUTE-SPLIT] Error validating bins: %v", err)
+				return txFail(http.StatusInternalServerError, "Failed to validate bins")
+			}
+			if count != len(allBinIDs) {
+				return txFail(http.StatusBadRequest, "One or more bin_ids are invalid")
+			}
+
+			// A bin can only be on one open shift at a time
+			reservations, err := database.FindBinReservations(tx, allBinIDs, "", nil)
+			if err != nil {
+				log.Printf("❌ [ROUTE-SPLIT] %v", err)
+				return txFail(http.StatusInternalServerError, "Failed to assign routes")
+			}
+			if len(reservations) > 0 {
+				return binReservedError(reservations)
+			}
+
+			routeID := splitRouteRouteID
+			for i, route := range req.Routes {
+				shiftID := uuid.New().String()
+				_, err := tx.ExecContext(r.Context(), `
+					INSERT INTO shifts (id, driver_id, route_id, status, total_bins, created_at, updated_at)
+					VALUES ($1, $2, $3, 'ready', $4, $5, $6)
+				`, shiftID, route.DriverID, routeID, len(route.BinIDs), now, now)
+				if err != nil {
+					log.Printf("❌ [ROUTE-SPLIT] Error creating shift for driver %s: %v", route.DriverID, err)
+					return txFail(http.StatusInternalServerError, "Failed to create shift")
+				}
+
+				for j, binID := range route.BinIDs {
+					if err := stores.Shifts.InsertCollectionStop(shiftID, binID, &routeID, j+1, now); err != nil {
+						if database.IsBinReservationViolation(err) {
+							return binReservationRaceError()
+						}
+						log.Printf("❌ [ROUTE-SPLIT] Error inserting shift stop: %v", err)
+						return txFail(http.StatusInternalServerError, "Failed to assign bins to shift")
+					}
+				}
+
+				if preview := previews[i]; preview != nil {
+					if _, err := database.RecordShiftCostEstimate(tx, shiftID, routeID, preview.Route.Hours, preview.Route.DistanceKm, loadCostRates(tx)); err != nil {
+						log.Printf("❌ [ROUTE-SPLIT] Error recording cost estimate: %v", err)
+						return txFail(http.StatusInternalServerError, "Failed to assign routes")
+					}
+				}
+
+				var shift models.Shift
+				if err := tx.GetContext(r.Context(), &shift, `SELECT * FROM shifts WHERE id = $1`, shiftID); err != nil {
+					log.Printf("❌ [ROUTE-SPLIT] Error fetching created shift: %v", err)
+					return txFail(http.StatusInternalServerError, "Failed to assign routes")
+				}
+				bins, err := stores.Shifts.Stops(shiftID)
+				if err != nil {
+					log.Printf("❌ [ROUTE-SPLIT] Error fetching shift stops: %v", err)
+					return txFail(http.StatusInternalServerError, "Failed to fetch route bins")
+				}
+
+				notificationSent, err := queueRouteAssigned(tx, hub, fcmService, shift, bins, routeID)
+				if err != nil {
+					log.Printf("❌ [ROUTE-SPLIT] Error queueing route notification: %v", err)
+					return txFail(http.StatusInternalServerError, "Failed to assign routes")
+				}
+
+				created = append(created, createdShift{
+					ShiftID:          shiftID,
+					DriverID:         route.DriverID,
+					Status:           shift.Status,
+					TotalBins:        len(route.BinIDs),
+					Bins:             bins,
+					NotificationSent: notificationSent,
+				})
+			}
+			return nil
+		})
+		if err != nil {
+			respondTxError(w, err, "Failed to assign routes")
+			return
+		}
+
+		log.Printf("✅ [ROUTE-SPLIT] %d bins assigned across %d shifts by %s", len(allBinIDs), len(created), userClaims.Email)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data": map[string]interface{}{
+				"shifts":     created,
+				"total_bins": len(allBinIDs),
+			},
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/jmoiron/sqlx"
 	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/helpers"
 	"ropacal-backend/internal/middleware"
 	"ropacal-backend/internal/models"
 	"ropacal-backend/internal/websocket"
@@ -149,15 +150,16 @@ func CreateShiftWithTasks(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 				log.Printf("      - Assigned to driver: %s", req.DriverID)
 
 				updateQuery := `UPDATE bin_move_requests
-								SET status = 'in_progress',
+								SET status = $5,
 									assigned_shift_id = $1,
 									assigned_user_id = $2,
 									assignment_type = 'shift',
 									updated_at = $3
 								WHERE id = $4
-								AND status = 'pending'`
+								AND status = $6`
 
-				result, err := db.Exec(updateQuery, shiftID, req.DriverID, now, moveReqID)
+				result, err := db.ExecContext(r.Context(), updateQuery, shiftID, req.DriverID, now, moveReqID,
+					models.MoveStatusInProgress, models.MoveStatusPending)
 				if err != nil {
 					log.Printf("      ❌ Error updating move request %s: %v", moveReqID, err)
 					continue
@@ -235,15 +237,22 @@ func CompleteTask(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 		}
 
 		// Complete task
-		err = database.CompleteTask(db, taskID, req.UpdatedFillPercentage, req.PhotoURL, req.NewBinID)
+		err = database.CompleteTask(db, taskID, req.UpdatedFillPercentage, req.PhotoURL, req.NewBinID, req.Skipped)
 		if err != nil {
 			log.Printf("❌ Error completing task: %v", err)
 			utils.RespondError(w, http.StatusInternalServerError, "Failed to complete task")
 			return
 		}
 
+		// A skipped stop can leave the rest of the route out of order
+		if req.Skipped {
+			if _, err := helpers.RequestRouteReoptimization(db, task.ShiftID, models.ReoptimizeReasonStopSkipped); err != nil {
+				log.Printf("⚠️  Warning: %v", err)
+			}
+		}
+
 		// Update shift completed_bins count
-		_, err = db.Exec(
+		_, err = db.ExecContext(r.Context(), 
 			"UPDATE shifts SET completed_bins = completed_bins + 1, updated_at = $1 WHERE id = $2",
 			time.Now().Unix(),
 			task.ShiftID,
@@ -255,7 +264,7 @@ func CompleteTask(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 		// Get updated shift for WebSocket broadcast
 		var shift models.Shift
 		shiftQuery := `SELECT * FROM shifts WHERE id = $1`
-		err = db.Get(&shift, shiftQuery, task.ShiftID)
+		err = db.GetContext(r.Context(), &shift, shiftQuery, task.ShiftID)
 		if err != nil {
 			log.Printf("⚠️  Warning: Could not fetch shift for WebSocket: %v", err)
 		} else {
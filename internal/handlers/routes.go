@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -11,8 +13,12 @@ import (
 	"net/url"
 	"time"
 
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/middleware"
 	"ropacal-backend/internal/models"
 	"ropacal-backend/internal/services"
+	"ropacal-backend/internal/store"
+	"ropacal-backend/pkg/utils"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -30,15 +36,15 @@ const (
 func GetRoutes(db *sqlx.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var routes []models.Route
-		err := db.Select(&routes, `
+		err := db.SelectContext(r.Context(), &routes, `
 			SELECT id, name, description, geographic_area, schedule_pattern,
 			       bin_count, estimated_duration_hours, created_by_user_id,
-			       created_at, updated_at
+			       is_draft, created_at, updated_at
 			FROM routes
 			ORDER BY created_at DESC
 		`)
 		if err != nil {
-			http.Error(w, "Failed to fetch routes", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch routes")
 			return
 		}
 
@@ -54,19 +60,19 @@ func GetRoute(db *sqlx.DB) http.HandlerFunc {
 
 		// Get route
 		var route models.Route
-		err := db.Get(&route, `
+		err := db.GetContext(r.Context(), &route, `
 			SELECT id, name, description, geographic_area, schedule_pattern,
 			       bin_count, estimated_duration_hours, created_by_user_id,
-			       created_at, updated_at
+			       is_draft, created_at, updated_at
 			FROM routes
 			WHERE id = $1
 		`, routeID)
 		if err == sql.ErrNoRows {
-			http.Error(w, "Route not found", http.StatusNotFound)
+			utils.RespondError(w, http.StatusNotFound, "Route not found")
 			return
 		}
 		if err != nil {
-			http.Error(w, "Failed to fetch route", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch route")
 			return
 		}
 
@@ -77,7 +83,7 @@ func GetRoute(db *sqlx.DB) http.HandlerFunc {
 		}
 
 		var binsWithSequence []BinWithSequence
-		err = db.Select(&binsWithSequence, `
+		err = db.SelectContext(r.Context(), &binsWithSequence, `
 			SELECT b.id, b.bin_number, b.current_street, b.city, b.zip,
 			       b.last_moved, b.last_checked, b.status, COALESCE(b.fill_percentage, 0) as fill_percentage,
 			       b.checked, b.move_requested, b.latitude, b.longitude,
@@ -88,7 +94,7 @@ func GetRoute(db *sqlx.DB) http.HandlerFunc {
 			ORDER BY rb.sequence_order ASC
 		`, routeID)
 		if err != nil {
-			http.Error(w, "Failed to fetch route bins", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch route bins")
 			return
 		}
 
@@ -111,98 +117,117 @@ func GetRoute(db *sqlx.DB) http.HandlerFunc {
 	}
 }
 
+// createRoute creates a route blueprint with its bins in the given order, and records its first version
+func createRoute(ctx context.Context, db *sqlx.DB, req models.CreateRouteRequest, createdBy *string) (models.Route, error) {
+	var created models.Route
+
+	// Generate UUID and timestamp
+	id := uuid.New().String()
+	now := time.Now().Unix()
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return created, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Prepare optional fields
+	var description *string
+	if req.Description != "" {
+		description = &req.Description
+	}
+
+	var schedulePattern *string
+	if req.SchedulePattern != "" {
+		schedulePattern = &req.SchedulePattern
+	}
+
+	// Insert route
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO routes (
+			id, name, description, geographic_area, schedule_pattern,
+			bin_count, estimated_duration_hours, created_by_user_id,
+			created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`,
+		id, req.Name, description, req.GeographicArea, schedulePattern,
+		len(req.BinIDs), req.EstimatedDurationHours, createdBy, now, now,
+	)
+	if err != nil {
+		return created, fmt.Errorf("failed to insert route: %w", err)
+	}
+
+	// Insert route_bins
+	for i, binID := range req.BinIDs {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO route_bins (route_id, bin_id, sequence_order, created_at)
+			VALUES ($1, $2, $3, $4)
+		`, id, binID, i+1, now)
+		if err != nil {
+			return created, fmt.Errorf("failed to add bins to route: %w", err)
+		}
+	}
+
+	if _, err := database.RecordRouteVersion(tx, id, createdBy, now); err != nil {
+		return created, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return created, fmt.Errorf("failed to commit route: %w", err)
+	}
+
+	// Fetch created route
+	err = db.GetContext(ctx, &created, `
+		SELECT id, name, description, geographic_area, schedule_pattern,
+		       bin_count, estimated_duration_hours, created_by_user_id,
+		       is_draft, created_at, updated_at
+		FROM routes
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return created, fmt.Errorf("failed to fetch created route: %w", err)
+	}
+	return created, nil
+}
+
 // CreateRoute creates a new route blueprint
 func CreateRoute(db *sqlx.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req models.CreateRouteRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
 			return
 		}
 
 		// Validate required fields
 		if req.Name == "" || req.GeographicArea == "" || len(req.BinIDs) == 0 {
-			http.Error(w, "Missing required fields: name, geographic_area, and bin_ids", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Missing required fields: name, geographic_area, and bin_ids")
 			return
 		}
 
-		// Get user ID from context (set by auth middleware)
-		userID, _ := r.Context().Value("user_id").(string)
-
-		// Generate UUID and timestamp
-		id := uuid.New().String()
-		now := time.Now().Unix()
-
-		// Start transaction
-		tx, err := db.Beginx()
+		// Bins without coordinates can't be routed
+		missing, err := findBinsMissingCoordinates(db, req.BinIDs)
 		if err != nil {
-			http.Error(w, "Failed to start transaction", http.StatusInternalServerError)
+			log.Printf("❌ [ROUTES] %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to create route")
 			return
 		}
-		defer tx.Rollback()
-
-		// Prepare optional fields
-		var description *string
-		if req.Description != "" {
-			description = &req.Description
-		}
-
-		var schedulePattern *string
-		if req.SchedulePattern != "" {
-			schedulePattern = &req.SchedulePattern
-		}
-
-		var createdBy *string
-		if userID != "" {
-			createdBy = &userID
-		}
-
-		// Insert route
-		_, err = tx.Exec(`
-			INSERT INTO routes (
-				id, name, description, geographic_area, schedule_pattern,
-				bin_count, estimated_duration_hours, created_by_user_id,
-				created_at, updated_at
-			)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-		`,
-			id, req.Name, description, req.GeographicArea, schedulePattern,
-			len(req.BinIDs), req.EstimatedDurationHours, createdBy, now, now,
-		)
-		if err != nil {
-			http.Error(w, "Failed to create route", http.StatusInternalServerError)
+		if len(missing) > 0 {
+			respondBinsMissingCoordinates(w, missing)
 			return
 		}
 
-		// Insert route_bins
-		for i, binID := range req.BinIDs {
-			_, err = tx.Exec(`
-				INSERT INTO route_bins (route_id, bin_id, sequence_order, created_at)
-				VALUES ($1, $2, $3, $4)
-			`, id, binID, i+1, now)
-			if err != nil {
-				http.Error(w, "Failed to add bins to route", http.StatusInternalServerError)
-				return
-			}
-		}
-
-		// Commit transaction
-		if err = tx.Commit(); err != nil {
-			http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
-			return
+		// Get user ID from context (set by auth middleware)
+		var createdBy *string
+		if userClaims, ok := middleware.GetUserFromContext(r); ok {
+			createdBy = &userClaims.UserID
 		}
 
-		// Fetch created route
-		var created models.Route
-		err = db.Get(&created, `
-			SELECT id, name, description, geographic_area, schedule_pattern,
-			       bin_count, estimated_duration_hours, created_by_user_id,
-			       created_at, updated_at
-			FROM routes
-			WHERE id = $1
-		`, id)
+		created, err := createRoute(r.Context(), db, req, createdBy)
 		if err != nil {
-			http.Error(w, "Failed to fetch created route", http.StatusInternalServerError)
+			log.Printf("❌ [ROUTES] %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to create route")
 			return
 		}
 
@@ -219,116 +244,163 @@ func UpdateRoute(db *sqlx.DB) http.HandlerFunc {
 
 		var req models.UpdateRouteRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
 			return
 		}
+		if req.IsEmpty() {
+			utils.RespondError(w, http.StatusBadRequest, "No fields to update")
+			return
+		}
+
+		// Bins without coordinates can't be added (ones already on the route may stay until they're geocoded)
+		if req.BinIDs != nil {
+			missing, err := findBinsMissingCoordinates(db, req.BinIDs)
+			if err != nil {
+				log.Printf("❌ [ROUTES] %v", err)
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to update route")
+				return
+			}
+			if len(missing) > 0 {
+				var current []string
+				if err := db.SelectContext(r.Context(), &current, `SELECT bin_id FROM route_bins WHERE route_id = $1`, routeID); err != nil {
+					log.Printf("❌ [ROUTES] Failed to fetch bins of route %s: %v", routeID, err)
+					utils.RespondError(w, http.StatusInternalServerError, "Failed to update route")
+					return
+				}
+				onRoute := make(map[string]bool, len(current))
+				for _, binID := range current {
+					onRoute[binID] = true
+				}
+				added := []models.BinMissingCoordinates{}
+				for _, bin := range missing {
+					if !onRoute[bin.ID] {
+						added = append(added, bin)
+					}
+				}
+				if len(added) > 0 {
+					respondBinsMissingCoordinates(w, added)
+					return
+				}
+			}
+		}
 
 		now := time.Now().Unix()
 
 		// Start transaction
-		tx, err := db.Beginx()
+		tx, err := db.BeginTxx(r.Context(), nil)
 		if err != nil {
-			http.Error(w, "Failed to start transaction", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to start transaction")
 			return
 		}
 		defer tx.Rollback()
 
+		// Publishing (or unpublishing) a draft doesn't change the blueprint, so it isn't a new version
+		if req.IsDraft != nil {
+			result, err := tx.ExecContext(r.Context(), `UPDATE routes SET is_draft = $1, updated_at = $2 WHERE id = $3`,
+				*req.IsDraft, now, routeID)
+			if err != nil {
+				log.Printf("❌ [ROUTES] Failed to update draft state of route %s: %v", routeID, err)
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to update route")
+				return
+			}
+			if rows, _ := result.RowsAffected(); rows == 0 {
+				utils.RespondError(w, http.StatusNotFound, "Route not found")
+				return
+			}
+		}
+
 		// Build dynamic update query
-		updates := []string{}
-		args := []interface{}{}
-		argCount := 1
+		update := database.NewUpdate("routes")
 
 		if req.Name != nil {
-			updates = append(updates, "name = $"+string(rune('0'+argCount)))
-			args = append(args, *req.Name)
-			argCount++
+			update.Set("name", *req.Name)
 		}
 		if req.Description != nil {
-			updates = append(updates, "description = $"+string(rune('0'+argCount)))
-			args = append(args, *req.Description)
-			argCount++
+			update.Set("description", *req.Description)
 		}
 		if req.GeographicArea != nil {
-			updates = append(updates, "geographic_area = $"+string(rune('0'+argCount)))
-			args = append(args, *req.GeographicArea)
-			argCount++
+			update.Set("geographic_area", *req.GeographicArea)
 		}
 		if req.SchedulePattern != nil {
-			updates = append(updates, "schedule_pattern = $"+string(rune('0'+argCount)))
-			args = append(args, *req.SchedulePattern)
-			argCount++
+			update.Set("schedule_pattern", *req.SchedulePattern)
 		}
 		if req.EstimatedDurationHours != nil {
-			updates = append(updates, "estimated_duration_hours = $"+string(rune('0'+argCount)))
-			args = append(args, *req.EstimatedDurationHours)
-			argCount++
+			update.Set("estimated_duration_hours", *req.EstimatedDurationHours)
 		}
 
 		// Update bin_ids if provided
 		if req.BinIDs != nil {
-			updates = append(updates, "bin_count = $"+string(rune('0'+argCount)))
-			args = append(args, len(req.BinIDs))
-			argCount++
+			update.Set("bin_count", len(req.BinIDs))
 
 			// Delete existing bin associations
-			_, err = tx.Exec("DELETE FROM route_bins WHERE route_id = $1", routeID)
+			_, err = tx.ExecContext(r.Context(), "DELETE FROM route_bins WHERE route_id = $1", routeID)
 			if err != nil {
-				http.Error(w, "Failed to update route bins", http.StatusInternalServerError)
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to update route bins")
 				return
 			}
 
 			// Insert new bin associations
 			for i, binID := range req.BinIDs {
-				_, err = tx.Exec(`
+				_, err = tx.ExecContext(r.Context(), `
 					INSERT INTO route_bins (route_id, bin_id, sequence_order, created_at)
 					VALUES ($1, $2, $3, $4)
 				`, routeID, binID, i+1, now)
 				if err != nil {
-					http.Error(w, "Failed to add bins to route", http.StatusInternalServerError)
+					utils.RespondError(w, http.StatusInternalServerError, "Failed to add bins to route")
 					return
 				}
 			}
 		}
 
-		// Always update updated_at
-		updates = append(updates, "updated_at = $"+string(rune('0'+argCount)))
-		args = append(args, now)
-		argCount++
-
-		// Add route ID as final parameter
-		args = append(args, routeID)
-
 		// Execute update if there are changes
-		if len(updates) > 1 { // More than just updated_at
-			query := "UPDATE routes SET " + joinStrings(updates, ", ") + " WHERE id = $" + string(rune('0'+argCount))
-			_, err = tx.Exec(query, args...)
+		if update.Len() > 0 {
+			// Always update updated_at
+			query, args, err := update.Set("updated_at", now).Where("id", routeID).Build()
+			if err == nil {
+				_, err = tx.ExecContext(r.Context(), query, args...)
+			}
 			if err != nil {
-				http.Error(w, "Failed to update route", http.StatusInternalServerError)
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to update route")
+				return
+			}
+
+			// Shifts already built keep the version they were copied from; the next ones get this one
+			var updatedBy *string
+			if userClaims, ok := middleware.GetUserFromContext(r); ok {
+				updatedBy = &userClaims.UserID
+			}
+			if _, err := database.RecordRouteVersion(tx, routeID, updatedBy, now); err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					utils.RespondError(w, http.StatusNotFound, "Route not found")
+					return
+				}
+				log.Printf("❌ [ROUTES] %v", err)
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to update route")
 				return
 			}
 		}
 
 		// Commit transaction
 		if err = tx.Commit(); err != nil {
-			http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to commit transaction")
 			return
 		}
 
 		// Fetch updated route
 		var updated models.Route
-		err = db.Get(&updated, `
+		err = db.GetContext(r.Context(), &updated, `
 			SELECT id, name, description, geographic_area, schedule_pattern,
 			       bin_count, estimated_duration_hours, created_by_user_id,
-			       created_at, updated_at
+			       is_draft, created_at, updated_at
 			FROM routes
 			WHERE id = $1
 		`, routeID)
 		if err == sql.ErrNoRows {
-			http.Error(w, "Route not found", http.StatusNotFound)
+			utils.RespondError(w, http.StatusNotFound, "Route not found")
 			return
 		}
 		if err != nil {
-			http.Error(w, "Failed to fetch updated route", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch updated route")
 			return
 		}
 
@@ -342,15 +414,15 @@ func DeleteRoute(db *sqlx.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		routeID := chi.URLParam(r, "id")
 
-		result, err := db.Exec("DELETE FROM routes WHERE id = $1", routeID)
+		result, err := db.ExecContext(r.Context(), "DELETE FROM routes WHERE id = $1", routeID)
 		if err != nil {
-			http.Error(w, "Failed to delete route", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to delete route")
 			return
 		}
 
 		rowsAffected, _ := result.RowsAffected()
 		if rowsAffected == 0 {
-			http.Error(w, "Route not found", http.StatusNotFound)
+			utils.RespondError(w, http.StatusNotFound, "Route not found")
 			return
 		}
 
@@ -365,68 +437,65 @@ func DuplicateRoute(db *sqlx.DB) http.HandlerFunc {
 
 		var req models.DuplicateRouteRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
 			return
 		}
 
 		if req.Name == "" {
-			http.Error(w, "Name is required", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Name is required")
 			return
 		}
 
 		// Get source route
 		var sourceRoute models.Route
-		err := db.Get(&sourceRoute, `
+		err := db.GetContext(r.Context(), &sourceRoute, `
 			SELECT id, name, description, geographic_area, schedule_pattern,
 			       bin_count, estimated_duration_hours, created_by_user_id,
-			       created_at, updated_at
+			       is_draft, created_at, updated_at
 			FROM routes
 			WHERE id = $1
 		`, sourceRouteID)
 		if err == sql.ErrNoRows {
-			http.Error(w, "Source route not found", http.StatusNotFound)
+			utils.RespondError(w, http.StatusNotFound, "Source route not found")
 			return
 		}
 		if err != nil {
-			http.Error(w, "Failed to fetch source route", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch source route")
 			return
 		}
 
 		// Get source route bins
 		var sourceBins []models.RouteBin
-		err = db.Select(&sourceBins, `
+		err = db.SelectContext(r.Context(), &sourceBins, `
 			SELECT id, route_id, bin_id, sequence_order, created_at
 			FROM route_bins
 			WHERE route_id = $1
 			ORDER BY sequence_order ASC
 		`, sourceRouteID)
 		if err != nil {
-			http.Error(w, "Failed to fetch source route bins", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch source route bins")
 			return
 		}
 
-		// Get user ID from context
-		userID, _ := r.Context().Value("user_id").(string)
-
 		// Generate new UUID and timestamp
 		newID := uuid.New().String()
 		now := time.Now().Unix()
 
 		// Start transaction
-		tx, err := db.Beginx()
+		tx, err := db.BeginTxx(r.Context(), nil)
 		if err != nil {
-			http.Error(w, "Failed to start transaction", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to start transaction")
 			return
 		}
 		defer tx.Rollback()
 
 		var createdBy *string
-		if userID != "" {
-			createdBy = &userID
+		if userClaims, ok := middleware.GetUserFromContext(r); ok {
+			createdBy = &userClaims.UserID
 		}
 
 		// Create new route (duplicate)
-		_, err = tx.Exec(`
+		_, err = tx.ExecContext(r.Context(), `
 			INSERT INTO routes (
 				id, name, description, geographic_area, schedule_pattern,
 				bin_count, estimated_duration_hours, created_by_user_id,
@@ -439,39 +508,45 @@ func DuplicateRoute(db *sqlx.DB) http.HandlerFunc {
 			sourceRoute.EstimatedDurationHours, createdBy, now, now,
 		)
 		if err != nil {
-			http.Error(w, "Failed to create duplicate route", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to create duplicate route")
 			return
 		}
 
 		// Copy route bins
 		for _, bin := range sourceBins {
-			_, err = tx.Exec(`
+			_, err = tx.ExecContext(r.Context(), `
 				INSERT INTO route_bins (route_id, bin_id, sequence_order, created_at)
 				VALUES ($1, $2, $3, $4)
 			`, newID, bin.BinID, bin.SequenceOrder, now)
 			if err != nil {
-				http.Error(w, "Failed to copy route bins", http.StatusInternalServerError)
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to copy route bins")
 				return
 			}
 		}
 
+		if _, err := database.RecordRouteVersion(tx, newID, createdBy, now); err != nil {
+			log.Printf("❌ [ROUTES] %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to create duplicate route")
+			return
+		}
+
 		// Commit transaction
 		if err = tx.Commit(); err != nil {
-			http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to commit transaction")
 			return
 		}
 
 		// Fetch created route
 		var created models.Route
-		err = db.Get(&created, `
+		err = db.GetContext(r.Context(), &created, `
 			SELECT id, name, description, geographic_area, schedule_pattern,
 			       bin_count, estimated_duration_hours, created_by_user_id,
-			       created_at, updated_at
+			       is_draft, created_at, updated_at
 			FROM routes
 			WHERE id = $1
 		`, newID)
 		if err != nil {
-			http.Error(w, "Failed to fetch duplicated route", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch duplicated route")
 			return
 		}
 
@@ -481,7 +556,86 @@ func DuplicateRoute(db *sqlx.DB) http.HandlerFunc {
 	}
 }
 
+// GetRouteVersions returns a route's versions, newest first, each with the bins added, removed or
+// reordered and the fields changed since the version before it
+// GET /api/routes/{id}/versions
+func GetRouteVersions(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		routeID := chi.URLParam(r, "id")
+
+		var exists bool
+		if err := db.GetContext(r.Context(), &exists, `SELECT EXISTS(SELECT 1 FROM routes WHERE id = $1)`, routeID); err != nil {
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch route")
+			return
+		}
+		if !exists {
+			utils.RespondError(w, http.StatusNotFound, "Route not found")
+			return
+		}
+
+		var versions []models.RouteVersion
+		err := db.SelectContext(r.Context(), &versions, `
+			SELECT v.id, v.route_id, v.version, v.name, v.description, v.geographic_area, v.schedule_pattern,
+			       v.estimated_duration_hours, v.bin_ids, v.created_by_user_id, u.name AS created_by_name, v.created_at
+			FROM route_versions v
+			LEFT JOIN users u ON u.id = v.created_by_user_id
+			WHERE v.route_id = $1
+			ORDER BY v.version ASC
+		`, routeID)
+		if err != nil {
+			log.Printf("❌ [ROUTES] Failed to fetch versions of route %s: %v", routeID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch route versions")
+			return
+		}
+
+		var shiftCounts []struct {
+			Version int `db:"route_version"`
+			Count   int `db:"count"`
+		}
+		err = db.SelectContext(r.Context(), &shiftCounts, `
+			SELECT route_version, COUNT(*) AS count
+			FROM shifts
+			WHERE route_id = $1 AND route_version IS NOT NULL
+			GROUP BY route_version
+		`, routeID)
+		if err != nil {
+			log.Printf("❌ [ROUTES] Failed to count shifts of route %s: %v", routeID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch route versions")
+			return
+		}
+		shiftsByVersion := make(map[int]int, len(shiftCounts))
+		for _, sc := range shiftCounts {
+			shiftsByVersion[sc.Version] = sc.Count
+		}
+
+		history := models.RouteVersionHistory{
+			RouteID:  routeID,
+			Versions: make([]models.RouteVersionEntry, 0, len(versions)),
+		}
+		for i := len(versions) - 1; i >= 0; i-- {
+			entry := models.RouteVersionEntry{
+				RouteVersion: versions[i],
+				ShiftCount:   shiftsByVersion[versions[i].Version],
+			}
+			if i > 0 {
+				changes := models.DiffRouteVersions(versions[i-1], versions[i])
+				entry.Changes = &changes
+			}
+			history.Versions = append(history.Versions, entry)
+		}
+		if len(versions) > 0 {
+			history.CurrentVersion = versions[len(versions)-1].Version
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(history)
+	}
+}
+
 // OptimizeRoutePreview returns an optimized route order using Mapbox Optimization API
+// Bins with time windows are checked against estimated arrival times from departure_time (default now);
+// if Mapbox's order misses windows the window-aware optimizer's order is used when it misses fewer,
+// and reject_time_window_violations=true turns remaining violations into a 422
 func OptimizeRoutePreview(db *sqlx.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req struct {
@@ -490,21 +644,23 @@ func OptimizeRoutePreview(db *sqlx.DB) http.HandlerFunc {
 				Latitude  float64 `json:"latitude"`
 				Longitude float64 `json:"longitude"`
 			} `json:"start_location"` // Optional
+			DepartureTime              *time.Time `json:"departure_time"` // Optional (RFC 3339), default now
+			RejectTimeWindowViolations bool       `json:"reject_time_window_violations"`
 		}
 
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
 			return
 		}
 
 		if len(req.BinIDs) == 0 {
-			http.Error(w, "bin_ids cannot be empty", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "bin_ids cannot be empty")
 			return
 		}
 
 		// Mapbox Optimization API has a 12 waypoint limit on free tier
 		if len(req.BinIDs) > 12 {
-			http.Error(w, "Cannot optimize more than 12 bins (Mapbox API limit)", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Cannot optimize more than 12 bins (Mapbox API limit)")
 			return
 		}
 
@@ -512,21 +668,38 @@ func OptimizeRoutePreview(db *sqlx.DB) http.HandlerFunc {
 
 		// Fetch bins from database
 		query := `
-			SELECT id, bin_number, current_street, latitude, longitude, fill_percentage
+			SELECT id, bin_number, current_street, latitude, longitude, fill_percentage,
+			       time_window_start, time_window_end
 			FROM bins
 			WHERE id = ANY($1)
 			AND latitude IS NOT NULL
 			AND longitude IS NOT NULL
 		`
 		var bins []models.Bin
-		if err := db.Select(&bins, query, pq.Array(req.BinIDs)); err != nil {
+		if err := db.SelectContext(r.Context(), &bins, query, pq.Array(req.BinIDs)); err != nil {
 			log.Printf("❌ Error fetching bins: %v", err)
-			http.Error(w, "Failed to fetch bins", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch bins")
+			return
+		}
+
+		// Bins without coordinates are left out of the optimization with a warning
+		missing, err := findBinsMissingCoordinates(db, req.BinIDs)
+		if err != nil {
+			log.Printf("❌ %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch bins")
 			return
 		}
+		warnings := make([]string, 0, len(missing))
+		for _, bin := range missing {
+			warnings = append(warnings, missingCoordinatesWarning(bin))
+		}
 
 		if len(bins) == 0 {
-			http.Error(w, "No valid bins found", http.StatusNotFound)
+			if len(missing) > 0 {
+				respondBinsMissingCoordinates(w, missing)
+				return
+			}
+			utils.RespondError(w, http.StatusNotFound, "No valid bins found")
 			return
 		}
 
@@ -576,7 +749,7 @@ func OptimizeRoutePreview(db *sqlx.DB) http.HandlerFunc {
 		resp, err := http.Get(mapboxURL)
 		if err != nil {
 			log.Printf("❌ Mapbox API error: %v", err)
-			http.Error(w, "Failed to call Mapbox API", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to call Mapbox API")
 			return
 		}
 		defer resp.Body.Close()
@@ -584,7 +757,7 @@ func OptimizeRoutePreview(db *sqlx.DB) http.HandlerFunc {
 		if resp.StatusCode != http.StatusOK {
 			body, _ := io.ReadAll(resp.Body)
 			log.Printf("❌ Mapbox API returned status %d: %s", resp.StatusCode, string(body))
-			http.Error(w, "Mapbox API request failed", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Mapbox API request failed")
 			return
 		}
 
@@ -592,7 +765,7 @@ func OptimizeRoutePreview(db *sqlx.DB) http.HandlerFunc {
 		bodyBytes, err := io.ReadAll(resp.Body)
 		if err != nil {
 			log.Printf("❌ Failed to read Mapbox response body: %v", err)
-			http.Error(w, "Failed to read Mapbox response", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to read Mapbox response")
 			return
 		}
 		log.Printf("📡 Raw Mapbox Response: %s", string(bodyBytes))
@@ -611,13 +784,13 @@ func OptimizeRoutePreview(db *sqlx.DB) http.HandlerFunc {
 
 		if err := json.Unmarshal(bodyBytes, &mapboxResponse); err != nil {
 			log.Printf("❌ Failed to parse Mapbox response: %v", err)
-			http.Error(w, "Failed to parse Mapbox response", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to parse Mapbox response")
 			return
 		}
 
 		if mapboxResponse.Code != "Ok" || len(mapboxResponse.Trips) == 0 {
 			log.Printf("❌ Mapbox API returned code: %s", mapboxResponse.Code)
-			http.Error(w, "Mapbox optimization failed", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Mapbox optimization failed")
 			return
 		}
 
@@ -660,6 +833,42 @@ func OptimizeRoutePreview(db *sqlx.DB) http.HandlerFunc {
 			binMap[bin.ID] = bin
 		}
 
+		// Check the order against the bins' time windows
+		departure := time.Now()
+		if req.DepartureTime != nil {
+			departure = *req.DepartureTime
+		}
+		route := make([]services.BinWithPriority, len(optimizedBinIDs))
+		for i, binID := range optimizedBinIDs {
+			bin := binMap[binID]
+			route[i] = services.BinWithPriority{
+				ID:             bin.ID,
+				Latitude:       *bin.Latitude,
+				Longitude:      *bin.Longitude,
+				FillPercentage: fillOrZero(bin.FillPercentage),
+				CurrentStreet:  bin.CurrentStreet,
+				TimeWindow:     models.StopTimeWindow(nil, nil, bin.TimeWindowStart, bin.TimeWindowEnd),
+			}
+		}
+		violations := services.EvaluateTimeWindows(route, startLocation, departure)
+		reorderedForWindows := false
+		if len(violations) > 0 {
+			windowRoute, windowViolations := services.NewRouteOptimizer().OptimizeRouteWithTimeWindows(route, startLocation, departure)
+			if len(windowViolations) < len(violations) {
+				log.Printf("🕒 Mapbox order misses %d time windows, window-aware order misses %d - using it", len(violations), len(windowViolations))
+				reorderedForWindows = true
+				violations = windowViolations
+				for i, bin := range windowRoute {
+					optimizedBinIDs[i] = bin.ID
+				}
+			}
+		}
+		if len(violations) > 0 && req.RejectTimeWindowViolations {
+			utils.RespondErrorCode(w, http.StatusUnprocessableEntity, utils.CodeTimeWindowViolation,
+				fmt.Sprintf("%d bins can't be reached within their time windows", len(violations)), violations)
+			return
+		}
+
 		// Build response with bin details in optimized order
 		type BinInSequence struct {
 			ID             string  `json:"id"`
@@ -667,10 +876,15 @@ func OptimizeRoutePreview(db *sqlx.DB) http.HandlerFunc {
 			CurrentStreet  string  `json:"current_street"`
 			Latitude       float64 `json:"latitude"`
 			Longitude      float64 `json:"longitude"`
-			FillPercentage int     `json:"fill_percentage"`
-			SequenceOrder  int     `json:"sequence_order"`
+			FillPercentage  int     `json:"fill_percentage"`
+			SequenceOrder   int     `json:"sequence_order"`
+			TimeWindowStart *string `json:"time_window_start,omitempty"`
+			TimeWindowEnd   *string `json:"time_window_end,omitempty"`
+			RiskLevel       string  `json:"risk_level,omitempty"` // "high" or "elevated" inside a no-go zone
+			RiskZoneName    *string `json:"risk_zone_name,omitempty"`
 		}
 
+		risks := stopRisks(db, optimizedBinIDs)
 		binsInSequence := make([]BinInSequence, len(optimizedBinIDs))
 		for i, binID := range optimizedBinIDs {
 			bin := binMap[binID]
@@ -680,14 +894,33 @@ func OptimizeRoutePreview(db *sqlx.DB) http.HandlerFunc {
 				CurrentStreet:  bin.CurrentStreet,
 				Latitude:       *bin.Latitude,
 				Longitude:      *bin.Longitude,
-				FillPercentage: *bin.FillPercentage,
-				SequenceOrder:  i + 1,
+				FillPercentage:  fillOrZero(bin.FillPercentage),
+				SequenceOrder:   i + 1,
+				TimeWindowStart: bin.TimeWindowStart,
+				TimeWindowEnd:   bin.TimeWindowEnd,
+			}
+			if risk, ok := risks[binID]; ok {
+				zoneName := risk.ZoneName
+				binsInSequence[i].RiskLevel = risk.Level
+				binsInSequence[i].RiskZoneName = &zoneName
 			}
 		}
 
 		// Use Mapbox's distance and duration (convert to km and hours)
+		// A window-driven reorder isn't the trip Mapbox measured, so it gets straight-line estimates
 		totalDistanceKm := trip.Distance / 1000.0
 		durationHours := trip.Duration / 3600.0
+		if reorderedForWindows {
+			totalDistanceKm = 0
+			lat, lng := startLocation.Latitude, startLocation.Longitude
+			for _, binID := range optimizedBinIDs {
+				bin := binMap[binID]
+				totalDistanceKm += haversineDistance(lat, lng, *bin.Latitude, *bin.Longitude)
+				lat, lng = *bin.Latitude, *bin.Longitude
+			}
+			totalDistanceKm += haversineDistance(lat, lng, startLocation.Latitude, startLocation.Longitude)
+			durationHours = totalDistanceKm / etaAverageSpeedKmh
+		}
 
 		// Add collection time (5 minutes per bin)
 		minutesPerBin := 5.0
@@ -695,15 +928,21 @@ func OptimizeRoutePreview(db *sqlx.DB) http.HandlerFunc {
 		totalDurationHours := durationHours + collectionTimeHours
 
 		response := struct {
-			OptimizedBinIDs      []string        `json:"optimized_bin_ids"`
-			TotalDistanceKm      float64         `json:"total_distance_km"`
-			EstimatedDurationHrs float64         `json:"estimated_duration_hours"`
-			Bins                 []BinInSequence `json:"bins"`
+			OptimizedBinIDs      []string                       `json:"optimized_bin_ids"`
+			TotalDistanceKm      float64                        `json:"total_distance_km"`
+			EstimatedDurationHrs float64                        `json:"estimated_duration_hours"`
+			Bins                 []BinInSequence                `json:"bins"`
+			TimeWindowViolations []services.TimeWindowViolation `json:"time_window_violations"`
+			ReorderedForWindows  bool                           `json:"reordered_for_time_windows"`
+			Warnings             []string                       `json:"warnings"` // Bins left out (no coordinates)
 		}{
 			OptimizedBinIDs:      optimizedBinIDs,
 			TotalDistanceKm:      totalDistanceKm,
 			EstimatedDurationHrs: totalDurationHours,
 			Bins:                 binsInSequence,
+			TimeWindowViolations: violations,
+			ReorderedForWindows:  reorderedForWindows,
+			Warnings:             warnings,
 		}
 
 		log.Printf("✅ Route optimized: %.2f km, %.2f hours (including %.0f min collection time)",
@@ -714,6 +953,14 @@ func OptimizeRoutePreview(db *sqlx.DB) http.HandlerFunc {
 	}
 }
 
+// fillOrZero returns a bin's fill percentage, 0 when none has been recorded
+func fillOrZero(fill *int) int {
+	if fill == nil {
+		return 0
+	}
+	return *fill
+}
+
 // haversineDistance calculates the distance between two GPS coordinates in kilometers
 func haversineDistance(lat1, lon1, lat2, lon2 float64) float64 {
 	const earthRadius = 6371.0 // Earth's radius in kilometers
@@ -734,18 +981,6 @@ func haversineDistance(lat1, lon1, lat2, lon2 float64) float64 {
 	return earthRadius * c
 }
 
-// Helper function to join strings
-func joinStrings(strs []string, sep string) string {
-	if len(strs) == 0 {
-		return ""
-	}
-	result := strs[0]
-	for _, s := range strs[1:] {
-		result += sep + s
-	}
-	return result
-}
-
 // TestHereOptimization - Test endpoint for HERE Waypoints Sequence API with raw coordinates
 // This endpoint doesn't require database bins - just send coordinates directly
 func TestHereOptimization(db *sqlx.DB) http.HandlerFunc {
@@ -759,18 +994,18 @@ func TestHereOptimization(db *sqlx.DB) http.HandlerFunc {
 		}
 
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
 			return
 		}
 
 		if len(req.Locations) == 0 {
-			http.Error(w, "locations cannot be empty", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "locations cannot be empty")
 			return
 		}
 
 		// HERE Waypoints Sequence API supports up to 202 waypoints
 		if len(req.Locations) > 202 {
-			http.Error(w, "Cannot optimize more than 202 locations (HERE API limit)", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Cannot optimize more than 202 locations (HERE API limit)")
 			return
 		}
 
@@ -858,7 +1093,7 @@ func TestHereOptimization(db *sqlx.DB) http.HandlerFunc {
 		resp, err := http.Get(fullURL)
 		if err != nil {
 			log.Printf("❌ HERE API error: %v", err)
-			http.Error(w, "Failed to call HERE API", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to call HERE API")
 			return
 		}
 		defer resp.Body.Close()
@@ -866,7 +1101,7 @@ func TestHereOptimization(db *sqlx.DB) http.HandlerFunc {
 		if resp.StatusCode != http.StatusOK {
 			body, _ := io.ReadAll(resp.Body)
 			log.Printf("❌ HERE API returned status %d: %s", resp.StatusCode, string(body))
-			http.Error(w, "HERE API request failed", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "HERE API request failed")
 			return
 		}
 
@@ -894,13 +1129,13 @@ func TestHereOptimization(db *sqlx.DB) http.HandlerFunc {
 
 		if err := json.NewDecoder(resp.Body).Decode(&hereResp); err != nil {
 			log.Printf("❌ Failed to parse HERE response: %v", err)
-			http.Error(w, "Failed to parse HERE response", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to parse HERE response")
 			return
 		}
 
 		if len(hereResp.Results) == 0 {
 			log.Printf("❌ HERE optimization failed: no results")
-			http.Error(w, "Route optimization failed", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Route optimization failed")
 			return
 		}
 
@@ -1039,19 +1274,19 @@ func TestMapboxOptimization(db *sqlx.DB) http.HandlerFunc {
 		}
 
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
 			return
 		}
 
 		if len(req.Locations) == 0 {
-			http.Error(w, "locations cannot be empty", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "locations cannot be empty")
 			return
 		}
 
 		// Mapbox Optimization v1 supports up to 12 waypoints (including start/end)
 		// Since we add warehouse at both ends, max locations = 12 - 2 = 10
 		if len(req.Locations) > 10 {
-			http.Error(w, "Cannot optimize more than 10 locations (Mapbox v1 limit: 12 waypoints including warehouse at start/end)", http.StatusBadRequest)
+			utils.RespondError(w, http.StatusBadRequest, "Cannot optimize more than 10 locations (Mapbox v1 limit: 12 waypoints including warehouse at start/end)")
 			return
 		}
 
@@ -1087,7 +1322,7 @@ func TestMapboxOptimization(db *sqlx.DB) http.HandlerFunc {
 		resp, err := http.Get(mapboxURL)
 		if err != nil {
 			log.Printf("❌ Mapbox API error: %v", err)
-			http.Error(w, "Failed to call Mapbox API", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to call Mapbox API")
 			return
 		}
 		defer resp.Body.Close()
@@ -1095,7 +1330,7 @@ func TestMapboxOptimization(db *sqlx.DB) http.HandlerFunc {
 		if resp.StatusCode != http.StatusOK {
 			body, _ := io.ReadAll(resp.Body)
 			log.Printf("❌ Mapbox API returned status %d: %s", resp.StatusCode, string(body))
-			http.Error(w, "Mapbox API request failed", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Mapbox API request failed")
 			return
 		}
 
@@ -1114,13 +1349,13 @@ func TestMapboxOptimization(db *sqlx.DB) http.HandlerFunc {
 
 		if err := json.NewDecoder(resp.Body).Decode(&mapboxResponse); err != nil {
 			log.Printf("❌ Failed to parse Mapbox response: %v", err)
-			http.Error(w, "Failed to parse Mapbox response", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to parse Mapbox response")
 			return
 		}
 
 		if mapboxResponse.Code != "Ok" || len(mapboxResponse.Trips) == 0 {
 			log.Printf("❌ Mapbox optimization failed: code=%s", mapboxResponse.Code)
-			http.Error(w, "Route optimization failed", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Route optimization failed")
 			return
 		}
 
@@ -1229,14 +1464,14 @@ func BatchGeocodeBins(db *sqlx.DB) http.HandlerFunc {
 		}
 
 		var bins []Bin
-		err := db.Select(&bins, `
+		err := db.SelectContext(r.Context(), &bins, `
 			SELECT id, bin_number, current_street, city, zip, latitude, longitude
 			FROM bins
 			ORDER BY bin_number ASC
 		`)
 		if err != nil {
 			log.Printf("❌ Failed to fetch bins: %v", err)
-			http.Error(w, "Failed to fetch bins from database", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch bins from database")
 			return
 		}
 
@@ -1303,7 +1538,7 @@ func BatchGeocodeBins(db *sqlx.DB) http.HandlerFunc {
 
 			// Auto-update if enabled (updates all bins including flagged ones)
 			if req.AutoUpdate && result.GeocodeSuccess {
-				_, err := db.Exec(`
+				_, err := db.ExecContext(r.Context(), `
 					UPDATE bins
 					SET latitude = $1, longitude = $2, updated_at = EXTRACT(EPOCH FROM NOW())::BIGINT
 					WHERE id = $3
@@ -1314,6 +1549,7 @@ func BatchGeocodeBins(db *sqlx.DB) http.HandlerFunc {
 					result.ErrorMessage = fmt.Sprintf("Update failed: %v", err)
 				} else {
 					updatedCount++
+					store.InvalidateBins(bin.ID)
 					if needsReview {
 						log.Printf("      💾 Database updated (FLAGGED - moved %.2f km)", distance)
 					} else {
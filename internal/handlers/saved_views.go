@@ -0,0 +1,378 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"ropacal-backend/internal/helpers"
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// savedViewFilters lists the query parameters a saved view may set for each entity type
+var savedViewFilters = map[string][]string{
+	models.SavedViewEntityBins:         {"area_id", "tag_id", "status", "filter", "limit"},
+	models.SavedViewEntityMoveRequests: {"status", "urgency", "assigned", "move_type", "limit"},
+}
+
+// savedViewSorts lists the sort values each list endpoint supports
+var savedViewSorts = map[string][]string{
+	models.SavedViewEntityBins:         {"priority", "bin_number", "fill_percentage", "days_since_check"},
+	models.SavedViewEntityMoveRequests: {"scheduled_date", "created_at"},
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// applySavedView merges the saved view named by ?view_id= into the request's query parameters,
+// so the list handler filters, sorts and paginates with it. Parameters given in the request win over the view's.
+// Returns a status and message for the client when the view can't be applied.
+func applySavedView(db *sqlx.DB, r *http.Request, entityType string) (int, string) {
+	q := r.URL.Query()
+	viewID := q.Get("view_id")
+	if viewID == "" {
+		return 0, ""
+	}
+
+	var view models.SavedView
+	err := db.GetContext(r.Context(), &view, `SELECT * FROM saved_views WHERE id = $1`, viewID)
+	if err == sql.ErrNoRows {
+		return http.StatusNotFound, "Saved view not found"
+	}
+	if err != nil {
+		log.Printf("❌ [SAVED-VIEWS] Failed to fetch view %s: %v", viewID, err)
+		return http.StatusInternalServerError, "Failed to load saved view"
+	}
+	if view.EntityType != entityType {
+		return http.StatusBadRequest, fmt.Sprintf("Saved view is for %s, not %s", view.EntityType, entityType)
+	}
+
+	filters := map[string]string{}
+	if err := json.Unmarshal(view.Filters, &filters); err != nil {
+		log.Printf("❌ [SAVED-VIEWS] View %s has invalid filters: %v", viewID, err)
+		return http.StatusInternalServerError, "Failed to load saved view"
+	}
+	if view.Sort != nil {
+		filters["sort"] = *view.Sort
+	}
+	for key, value := range filters {
+		if q.Get(key) == "" {
+			q.Set(key, value)
+		}
+	}
+	q.Del("view_id")
+	r.URL.RawQuery = q.Encode()
+	return 0, ""
+}
+
+// validateSavedView checks a view's name, entity type, filters and sort, returning a message for the client
+func validateSavedView(view models.SavedView, filters map[string]string) string {
+	if view.Name == "" {
+		return "name is required"
+	}
+	allowed, ok := savedViewFilters[view.EntityType]
+	if !ok {
+		return "entity_type must be bins or move_requests"
+	}
+	for key := range filters {
+		if !containsString(allowed, key) {
+			return fmt.Sprintf("Unsupported filter %q for %s (use %s)", key, view.EntityType, strings.Join(allowed, ", "))
+		}
+	}
+	if view.Sort != nil && !containsString(savedViewSorts[view.EntityType], *view.Sort) {
+		return fmt.Sprintf("Unsupported sort %q for %s (use %s)", *view.Sort, view.EntityType, strings.Join(savedViewSorts[view.EntityType], ", "))
+	}
+	return ""
+}
+
+// enqueueSavedViewChange tells every manager dashboard that a shared view changed (queued in the caller's transaction)
+func enqueueSavedViewChange(tx *sqlx.Tx, action string, view models.SavedView) error {
+	message := map[string]interface{}{
+		"type": "saved_view_changed",
+		"data": map[string]interface{}{
+			"action": action, // created, updated, deleted (also sent when a view stops being shared)
+			"view":   view,
+		},
+	}
+	for _, role := range []string{"admin", "manager"} {
+		if _, err := helpers.EnqueueRoleMessage(tx, role, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetSavedViews returns the caller's views and every shared view
+// GET /api/manager/saved-views?entity_type=bins|move_requests
+func GetSavedViews(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		views := []models.SavedView{}
+		err := db.SelectContext(r.Context(), &views, `
+			SELECT v.*, u.name AS owner_name
+			FROM saved_views v
+			LEFT JOIN users u ON u.id = v.user_id
+			WHERE (v.user_id = $1 OR v.is_shared)
+			  AND ($2 = '' OR v.entity_type = $2)
+			ORDER BY v.entity_type ASC, v.name ASC`, userClaims.UserID, r.URL.Query().Get("entity_type"))
+		if err != nil {
+			log.Printf("❌ [SAVED-VIEWS] Failed to fetch views: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch saved views")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    views,
+		})
+	}
+}
+
+// CreateSavedView saves a named filter and sort for the bins or move requests list
+// POST /api/manager/saved-views
+// Body: { "name": "Full bins downtown", "entity_type": "bins", "filters": {"filter": "high_fill", "area_id": "..."}, "sort": "fill_percentage", "is_shared": true }
+func CreateSavedView(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var req models.SavedViewRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		now := time.Now().Unix()
+		view := models.SavedView{
+			ID:        uuid.New().String(),
+			UserID:    userClaims.UserID,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if req.Name != nil {
+			view.Name = strings.TrimSpace(*req.Name)
+		}
+		if req.EntityType != nil {
+			view.EntityType = *req.EntityType
+		}
+		if req.Sort != nil && *req.Sort != "" {
+			view.Sort = req.Sort
+		}
+		if req.IsShared != nil {
+			view.IsShared = *req.IsShared
+		}
+		filters := req.Filters
+		if filters == nil {
+			filters = map[string]string{}
+		}
+		if msg := validateSavedView(view, filters); msg != "" {
+			utils.RespondError(w, http.StatusBadRequest, msg)
+			return
+		}
+		view.Filters, _ = json.Marshal(filters)
+
+		tx, err := db.BeginTxx(r.Context(), nil)
+		if err != nil {
+			log.Printf("❌ [SAVED-VIEWS] Failed to start transaction: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to create saved view")
+			return
+		}
+		defer tx.Rollback()
+
+		_, err = tx.NamedExecContext(r.Context(), `
+			INSERT INTO saved_views (id, user_id, name, entity_type, filters, sort, is_shared, created_at, updated_at)
+			VALUES (:id, :user_id, :name, :entity_type, :filters, :sort, :is_shared, :created_at, :updated_at)
+		`, view)
+		if err == nil && view.IsShared {
+			err = enqueueSavedViewChange(tx, "created", view)
+		}
+		if err == nil {
+			err = tx.Commit()
+		}
+		if err != nil {
+			log.Printf("❌ [SAVED-VIEWS] Failed to create view: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to create saved view")
+			return
+		}
+
+		log.Printf("✅ [SAVED-VIEWS] %s created %s view %q (shared: %v)", userClaims.Email, view.EntityType, view.Name, view.IsShared)
+
+		utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{
+			"success": true,
+			"data":    view,
+		})
+	}
+}
+
+// UpdateSavedView changes the caller's saved view
+// PUT /api/manager/saved-views/{id}
+func UpdateSavedView(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		viewID := chi.URLParam(r, "id")
+
+		var req models.SavedViewRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		tx, err := db.BeginTxx(r.Context(), nil)
+		if err != nil {
+			log.Printf("❌ [SAVED-VIEWS] Failed to start transaction: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to update saved view")
+			return
+		}
+		defer tx.Rollback()
+
+		var view models.SavedView
+		err = tx.GetContext(r.Context(), &view, `SELECT * FROM saved_views WHERE id = $1 FOR UPDATE`, viewID)
+		if err == sql.ErrNoRows || (err == nil && view.UserID != userClaims.UserID && !view.IsShared) {
+			utils.RespondError(w, http.StatusNotFound, "Saved view not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [SAVED-VIEWS] Failed to fetch view %s: %v", viewID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to update saved view")
+			return
+		}
+		if view.UserID != userClaims.UserID {
+			utils.RespondError(w, http.StatusForbidden, "Only the owner can change a saved view")
+			return
+		}
+		wasShared := view.IsShared
+
+		if req.Name != nil {
+			view.Name = strings.TrimSpace(*req.Name)
+		}
+		if req.EntityType != nil && *req.EntityType != view.EntityType {
+			utils.RespondError(w, http.StatusBadRequest, "entity_type can't be changed")
+			return
+		}
+		if req.Sort != nil {
+			view.Sort = req.Sort
+			if *req.Sort == "" {
+				view.Sort = nil
+			}
+		}
+		if req.IsShared != nil {
+			view.IsShared = *req.IsShared
+		}
+		filters := req.Filters
+		if filters == nil {
+			filters = map[string]string{}
+			if err := json.Unmarshal(view.Filters, &filters); err != nil {
+				log.Printf("❌ [SAVED-VIEWS] View %s has invalid filters: %v", viewID, err)
+			}
+		}
+		if msg := validateSavedView(view, filters); msg != "" {
+			utils.RespondError(w, http.StatusBadRequest, msg)
+			return
+		}
+		view.Filters, _ = json.Marshal(filters)
+		view.UpdatedAt = time.Now().Unix()
+
+		_, err = tx.NamedExecContext(r.Context(), `
+			UPDATE saved_views
+			SET name = :name, filters = :filters, sort = :sort, is_shared = :is_shared, updated_at = :updated_at
+			WHERE id = :id
+		`, view)
+		if err == nil && (wasShared || view.IsShared) {
+			action := "updated"
+			if !wasShared {
+				action = "created"
+			} else if !view.IsShared {
+				action = "deleted"
+			}
+			err = enqueueSavedViewChange(tx, action, view)
+		}
+		if err == nil {
+			err = tx.Commit()
+		}
+		if err != nil {
+			log.Printf("❌ [SAVED-VIEWS] Failed to update view %s: %v", viewID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to update saved view")
+			return
+		}
+
+		log.Printf("✅ [SAVED-VIEWS] %s updated view %q (shared: %v)", userClaims.Email, view.Name, view.IsShared)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    view,
+		})
+	}
+}
+
+// DeleteSavedView removes the caller's saved view
+// DELETE /api/manager/saved-views/{id}
+func DeleteSavedView(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		viewID := chi.URLParam(r, "id")
+
+		tx, err := db.BeginTxx(r.Context(), nil)
+		if err != nil {
+			log.Printf("❌ [SAVED-VIEWS] Failed to start transaction: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to delete saved view")
+			return
+		}
+		defer tx.Rollback()
+
+		var view models.SavedView
+		err = tx.GetContext(r.Context(), &view, `DELETE FROM saved_views WHERE id = $1 AND user_id = $2 RETURNING *`, viewID, userClaims.UserID)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "Saved view not found")
+			return
+		}
+		if err == nil && view.IsShared {
+			err = enqueueSavedViewChange(tx, "deleted", view)
+		}
+		if err == nil {
+			err = tx.Commit()
+		}
+		if err != nil {
+			log.Printf("❌ [SAVED-VIEWS] Failed to delete view %s: %v", viewID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to delete saved view")
+			return
+		}
+
+		log.Printf("✅ [SAVED-VIEWS] %s deleted view %q", userClaims.Email, view.Name)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/helpers"
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+)
+
+// GetSecurityEvents returns the security audit log (newest first)
+// GET /api/manager/security/events
+// Query params: event_type, user_id, email, ip_address, since (unix), limit (default 100, max 500)
+func GetSecurityEvents(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+
+		query := `SELECT * FROM security_events`
+		whereClause := []string{}
+		args := []interface{}{}
+
+		for _, filter := range []struct{ param, column string }{
+			{"event_type", "event_type"},
+			{"user_id", "user_id"},
+			{"ip_address", "ip_address"},
+		} {
+			if value := q.Get(filter.param); value != "" {
+				args = append(args, value)
+				whereClause = append(whereClause, fmt.Sprintf("%s = $%d", filter.column, len(args)))
+			}
+		}
+		if email := q.Get("email"); email != "" {
+			args = append(args, normalizeLoginEmail(email))
+			whereClause = append(whereClause, fmt.Sprintf("LOWER(email) = $%d", len(args)))
+		}
+		if since, err := strconv.ParseInt(q.Get("since"), 10, 64); err == nil {
+			args = append(args, since)
+			whereClause = append(whereClause, fmt.Sprintf("created_at >= $%d", len(args)))
+		}
+
+		if len(whereClause) > 0 {
+			query += " WHERE " + strings.Join(whereClause, " AND ")
+		}
+
+		limit := 100
+		if parsed, err := strconv.Atoi(q.Get("limit")); err == nil && parsed > 0 && parsed <= 500 {
+			limit = parsed
+		}
+		args = append(args, limit)
+		query += fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d", len(args))
+
+		events := []models.SecurityEvent{}
+		if err := db.SelectContext(r.Context(), &events, query, args...); err != nil {
+			log.Printf("❌ [SECURITY] Failed to fetch security events: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch security events")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    events,
+		})
+	}
+}
+
+// GetLoginLockouts returns accounts and IPs that are currently locked out or in backoff
+// GET /api/manager/security/lockouts
+func GetLoginLockouts(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		throttles := []models.LoginThrottle{}
+		err := db.SelectContext(r.Context(), &throttles, `
+			SELECT scope, key, failed_count, first_failed_at, last_failed_at, locked_until, is_locked_out
+			FROM login_throttles
+			WHERE locked_until > $1
+			ORDER BY is_locked_out DESC, locked_until DESC
+		`, time.Now().Unix())
+		if err != nil {
+			log.Printf("❌ [SECURITY] Failed to fetch lockouts: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch lockouts")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    throttles,
+		})
+	}
+}
+
+// UnlockUserAccount clears a user's failed login history and lockout
+// POST /api/manager/users/{id}/unlock
+func UnlockUserAccount(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := chi.URLParam(r, "id")
+
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var user models.User
+		err := db.GetContext(r.Context(), &user, `SELECT * FROM users WHERE id = $1`, userID)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [SECURITY] Failed to fetch user: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch user")
+			return
+		}
+
+		cleared, err := database.ClearLoginThrottle(db, models.LoginThrottleScopeAccount, normalizeLoginEmail(user.Email))
+		if err != nil {
+			log.Printf("❌ [SECURITY] %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to unlock account")
+			return
+		}
+
+		ip := clientIP(r)
+		details := fmt.Sprintf("Unlocked by %s", userClaims.Email)
+		helpers.LogSecurityEvent(db, models.SecurityEvent{
+			EventType: models.SecurityEventAccountUnlocked,
+			UserID:    &user.ID,
+			Email:     &user.Email,
+			IPAddress: &ip,
+			ActorID:   &userClaims.UserID,
+			Details:   &details,
+		})
+
+		log.Printf("🔓 [SECURITY] %s unlocked %s (had lockout: %v)", userClaims.Email, user.Email, cleared)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data": map[string]interface{}{
+				"user_id":     user.ID,
+				"had_lockout": cleared,
+			},
+		})
+	}
+}
@@ -0,0 +1,640 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// loadPriorityWeights returns the configured priority weights, falling back to defaults on error
+func loadPriorityWeights(db *sqlx.DB) models.PriorityWeights {
+	weights, err := database.GetPriorityWeights(db)
+	if err != nil {
+		log.Printf("⚠️  [PRIORITY-WEIGHTS] %v (using defaults)", err)
+	}
+	return weights
+}
+
+// loadFillThresholds returns the configured fill thresholds, falling back to defaults on error
+func loadFillThresholds(db sqlx.Queryer) models.FillThresholds {
+	thresholds, err := database.GetFillThresholds(db)
+	if err != nil {
+		log.Printf("⚠️  [FILL-THRESHOLDS] %v (using defaults)", err)
+	}
+	return thresholds
+}
+
+// settingValue is a settings payload managers can read and update
+type settingValue interface {
+	Validate() error
+}
+
+// settingHandlers serves the GET/PUT pair for one settings key
+type settingHandlers[T settingValue] struct {
+	key      string
+	tag      string // Log tag, e.g. "PRIORITY-WEIGHTS"
+	label    string // Names the setting in errors, e.g. "priority weights"
+	field    string // Response field holding the value (default "settings")
+	defaults func() T
+	load     func(db sqlx.Queryer) (T, error) // The cached read the rest of the app uses, e.g. database.GetPriorityWeights
+
+	// merge applies an update body (without "reset") to the current value; nil decodes the body over it field by field
+	// A txFail is sent as-is; any other error is sent as a 400 prefixed with "Invalid <label>: "
+	merge func(value *T, body map[string]json.RawMessage) error
+
+	summary func(value T) string   // Detail for the update log line (default the user ID)
+	extra   map[string]interface{} // Additional response fields
+}
+
+// get returns the effective value and the built-in defaults
+func (s settingHandlers[T]) get(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		value, err := s.load(db)
+		if err != nil {
+			log.Printf("❌ [%s] %v", s.tag, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to load "+s.label)
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, s.response(value))
+	}
+}
+
+// update merges the body over the current value (or restores the defaults for { "reset": true }), validates it and
+// saves it in one transaction that holds the key, so concurrent updates can't drop each other's changes
+func (s settingHandlers[T]) update(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var body map[string]json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		var value T
+		err := database.WithTx(r.Context(), db, func(tx *sqlx.Tx) error {
+			if reset, exists := body["reset"]; exists && string(reset) == "true" {
+				value = s.defaults()
+			} else {
+				current, err := database.LockSetting(tx, s.key, s.label, s.defaults)
+				if err != nil {
+					log.Printf("❌ [%s] %v", s.tag, err)
+					return txFail(http.StatusInternalServerError, "Failed to load "+s.label)
+				}
+				value = current
+
+				delete(body, "reset")
+				if err := s.mergeBody(&value, body); err != nil {
+					return err
+				}
+			}
+
+			if err := value.Validate(); err != nil {
+				return txFail(http.StatusBadRequest, err.Error())
+			}
+
+			if err := database.UpsertSetting(tx, s.key, value, &userClaims.UserID, time.Now().Unix()); err != nil {
+				log.Printf("❌ [%s] %v", s.tag, err)
+				return txFail(http.StatusInternalServerError, "Failed to save "+s.label)
+			}
+			return nil
+		})
+		if err != nil {
+			respondTxError(w, err, "Failed to save "+s.label)
+			return
+		}
+		database.InvalidateSetting(s.key)
+
+		summary := userClaims.UserID
+		if s.summary != nil {
+			summary = s.summary(value)
+		}
+		log.Printf("✅ [%s] Updated by %s (%s)", s.tag, userClaims.Email, summary)
+
+		utils.RespondJSON(w, http.StatusOK, s.response(value))
+	}
+}
+
+func (s settingHandlers[T]) mergeBody(value *T, body map[string]json.RawMessage) error {
+	merge := s.merge
+	if merge == nil {
+		merge = mergeSettingBody[T]
+	}
+	if err := merge(value, body); err != nil {
+		var txErr *txError
+		if errors.As(err, &txErr) {
+			return err
+		}
+		return txFail(http.StatusBadRequest, "Invalid "+s.label+": "+err.Error())
+	}
+	return nil
+}
+
+func (s settingHandlers[T]) response(value T) map[string]interface{} {
+	field := s.field
+	if field == "" {
+		field = "settings"
+	}
+	data := map[string]interface{}{
+		field:      value,
+		"defaults": s.defaults(),
+	}
+	for k, v := range s.extra {
+		data[k] = v
+	}
+	return map[string]interface{}{
+		"success": true,
+		"data":    data,
+	}
+}
+
+// mergeSettingBody decodes body over value, so omitted fields keep their current value
+func mergeSettingBody[T any](value *T, body map[string]json.RawMessage) error {
+	raw, _ := json.Marshal(body)
+	return json.Unmarshal(raw, value)
+}
+
+var priorityWeightsSetting = settingHandlers[models.PriorityWeights]{
+	key:      models.SettingKeyPriorityWeights,
+	tag:      "PRIORITY-WEIGHTS",
+	label:    "priority weights",
+	field:    "weights",
+	defaults: models.DefaultPriorityWeights,
+	load:     database.GetPriorityWeights,
+}
+
+// GetPriorityWeights returns the effective priority scoring weights and thresholds
+// GET /api/manager/settings/priority-weights
+func GetPriorityWeights(db *sqlx.DB) http.HandlerFunc {
+	return priorityWeightsSetting.get(db)
+}
+
+// UpdatePriorityWeights updates the priority scoring weights and thresholds
+// PUT /api/manager/settings/priority-weights
+// Body: any subset of the weight fields; omitted fields keep their current value
+// Body: { "reset": true } restores the built-in defaults
+func UpdatePriorityWeights(db *sqlx.DB) http.HandlerFunc {
+	return priorityWeightsSetting.update(db)
+}
+
+var earningsRatesSetting = settingHandlers[models.EarningsRates]{
+	key:      models.SettingKeyEarningsRates,
+	tag:      "EARNINGS-RATES",
+	label:    "earnings rates",
+	field:    "rates",
+	defaults: models.DefaultEarningsRates,
+	load:     database.GetEarningsRates,
+}
+
+// GetEarningsRates returns the effective driver earnings rates
+// GET /api/manager/settings/earnings-rates
+func GetEarningsRates(db *sqlx.DB) http.HandlerFunc {
+	return earningsRatesSetting.get(db)
+}
+
+// UpdateEarningsRates updates the driver earnings rates (applies to shifts ended afterwards)
+// PUT /api/manager/settings/earnings-rates
+// Body: any subset of the rate fields; omitted fields keep their current value
+// Body: { "reset": true } restores the built-in defaults
+func UpdateEarningsRates(db *sqlx.DB) http.HandlerFunc {
+	return earningsRatesSetting.update(db)
+}
+
+var moveSLASetting = settingHandlers[models.MoveSLAPolicy]{
+	key:      models.SettingKeyMoveSLA,
+	tag:      "MOVE-SLA",
+	label:    "move SLA",
+	field:    "policy",
+	defaults: models.DefaultMoveSLAPolicy,
+	load:     database.GetMoveSLAPolicy,
+}
+
+// GetMoveSLAPolicy returns the effective move request SLA
+// GET /api/manager/settings/move-sla
+func GetMoveSLAPolicy(db *sqlx.DB) http.HandlerFunc {
+	return moveSLASetting.get(db)
+}
+
+// UpdateMoveSLAPolicy updates the move request SLA (applies from the next SLA check; past breaches stay recorded)
+// PUT /api/manager/settings/move-sla
+// Body: any subset of the policy fields; omitted fields keep their current value
+// Body: { "reset": true } restores the built-in defaults
+func UpdateMoveSLAPolicy(db *sqlx.DB) http.HandlerFunc {
+	return moveSLASetting.update(db)
+}
+
+var clientConfigSetting = settingHandlers[models.ClientConfig]{
+	key:      models.SettingKeyClientConfig,
+	tag:      "CLIENT-CONFIG",
+	label:    "client config",
+	field:    "config",
+	defaults: models.DefaultClientConfig,
+	load:     database.GetClientConfig,
+	merge: func(config *models.ClientConfig, body map[string]json.RawMessage) error {
+		if _, exists := body["feature_flags"]; exists {
+			config.FeatureFlags = nil // Replaced as a whole, not merged key by key
+		}
+		return mergeSettingBody(config, body)
+	},
+	summary: func(config models.ClientConfig) string {
+		return fmt.Sprintf("min version %q, %d flag(s)", config.MinAppVersion, len(config.FeatureFlags))
+	},
+}
+
+// GetClientConfigSettings returns the app version policy and feature flags as stored
+// GET /api/manager/settings/client-config
+func GetClientConfigSettings(db *sqlx.DB) http.HandlerFunc {
+	return clientConfigSetting.get(db)
+}
+
+// UpdateClientConfig updates the app version policy and feature flags
+// PUT /api/manager/settings/client-config
+// Body: any subset of the config fields; omitted fields keep their current value (feature_flags is replaced as a whole)
+// Body: { "reset": true } restores the built-in defaults
+func UpdateClientConfig(db *sqlx.DB) http.HandlerFunc {
+	return clientConfigSetting.update(db)
+}
+
+var workloadLimitsSetting = settingHandlers[models.WorkloadLimits]{
+	key:      models.SettingKeyWorkloadLimits,
+	tag:      "WORKLOAD",
+	label:    "workload limits",
+	field:    "limits",
+	defaults: models.DefaultWorkloadLimits,
+	load:     database.GetWorkloadLimits,
+}
+
+// GetWorkloadLimits returns the effective driver workload limits
+// GET /api/manager/settings/workload-limits
+func GetWorkloadLimits(db *sqlx.DB) http.HandlerFunc {
+	return workloadLimitsSetting.get(db)
+}
+
+// UpdateWorkloadLimits updates the driver workload limits checked on route assignment
+// PUT /api/manager/settings/workload-limits
+// Body: any subset of the limit fields; omitted fields keep their current value (0 disables a limit)
+// Body: { "reset": true } restores the built-in defaults
+func UpdateWorkloadLimits(db *sqlx.DB) http.HandlerFunc {
+	return workloadLimitsSetting.update(db)
+}
+
+var digestSetting = settingHandlers[models.DigestSettings]{
+	key:      models.SettingKeyDigest,
+	tag:      "DIGEST",
+	label:    "digest settings",
+	defaults: models.DefaultDigestSettings,
+	load:     database.GetDigestSettings,
+}
+
+// GetDigestSettings returns the effective bins-at-risk digest settings
+// GET /api/manager/settings/digest
+func GetDigestSettings(db *sqlx.DB) http.HandlerFunc {
+	return digestSetting.get(db)
+}
+
+// UpdateDigestSettings updates when the bins-at-risk digest is sent and what it lists (applies from the next run)
+// PUT /api/manager/settings/digest
+// Body: any subset of the settings fields; omitted fields keep their current value
+// Body: { "reset": true } restores the built-in defaults
+func UpdateDigestSettings(db *sqlx.DB) http.HandlerFunc {
+	return digestSetting.update(db)
+}
+
+var undoSetting = settingHandlers[models.UndoSettings]{
+	key:      models.SettingKeyUndo,
+	tag:      "UNDO",
+	label:    "undo settings",
+	defaults: models.DefaultUndoSettings,
+	load:     database.GetUndoSettings,
+}
+
+// GetUndoSettings returns the effective undo window
+// GET /api/manager/settings/undo
+func GetUndoSettings(db *sqlx.DB) http.HandlerFunc {
+	return undoSetting.get(db)
+}
+
+// UpdateUndoSettings updates how long destructive actions can be undone (applies to actions taken afterwards)
+// PUT /api/manager/settings/undo
+// Body: { "window_seconds": 120 } (0 turns undo off)
+// Body: { "reset": true } restores the built-in defaults
+func UpdateUndoSettings(db *sqlx.DB) http.HandlerFunc {
+	return undoSetting.update(db)
+}
+
+var zoneRiskRoutingSetting = settingHandlers[models.ZoneRiskRoutingSettings]{
+	key:      models.SettingKeyZoneRiskRouting,
+	tag:      "ZONE-RISK",
+	label:    "zone risk routing settings",
+	defaults: models.DefaultZoneRiskRoutingSettings,
+	load:     database.GetZoneRiskRoutingSettings,
+}
+
+// GetZoneRiskRoutingSettings returns the effective zone risk routing weights
+// GET /api/manager/settings/zone-risk-routing
+func GetZoneRiskRoutingSettings(db *sqlx.DB) http.HandlerFunc {
+	return zoneRiskRoutingSetting.get(db)
+}
+
+// UpdateZoneRiskRoutingSettings updates how strongly stops inside no-go zones are pulled forward (applies to the next optimization)
+// PUT /api/manager/settings/zone-risk-routing
+// Body: any subset of the settings fields; omitted fields keep their current value
+// Body: { "reset": true } restores the built-in defaults
+func UpdateZoneRiskRoutingSettings(db *sqlx.DB) http.HandlerFunc {
+	return zoneRiskRoutingSetting.update(db)
+}
+
+var retentionSetting = settingHandlers[models.RetentionSettings]{
+	key:      models.SettingKeyRetention,
+	tag:      "RETENTION",
+	label:    "retention settings",
+	defaults: models.DefaultRetentionSettings,
+	load:     database.GetRetentionSettings,
+}
+
+// GetRetentionSettings returns the effective data retention periods (0 = kept forever)
+// GET /api/manager/settings/retention
+func GetRetentionSettings(db *sqlx.DB) http.HandlerFunc {
+	return retentionSetting.get(db)
+}
+
+// UpdateRetentionSettings updates how long locations, diagnostic logs and checks are kept (applies from the next purge)
+// PUT /api/manager/settings/retention
+// Body: any subset of the settings fields; omitted fields keep their current value
+// Body: { "reset": true } restores the built-in defaults
+func UpdateRetentionSettings(db *sqlx.DB) http.HandlerFunc {
+	return retentionSetting.update(db)
+}
+
+var serviceHoursSetting = settingHandlers[models.ServiceHoursSettings]{
+	key:      models.SettingKeyServiceHours,
+	tag:      "SERVICE-HOURS",
+	label:    "service hours",
+	defaults: models.DefaultServiceHoursSettings,
+	load:     database.GetServiceHoursSettings,
+	merge: func(settings *models.ServiceHoursSettings, body map[string]json.RawMessage) error {
+		if err := mergeSettingBody(settings, body); err != nil {
+			return err
+		}
+		if settings.Days == nil {
+			settings.Days = []string{}
+		}
+		return nil
+	},
+}
+
+// GetServiceHoursSettings returns the effective service hours
+// GET /api/manager/settings/service-hours
+func GetServiceHoursSettings(db *sqlx.DB) http.HandlerFunc {
+	return serviceHoursSetting.get(db)
+}
+
+// UpdateServiceHoursSettings updates when drivers work, whether non-urgent pushes wait for opening and how
+// assignments finishing after closing are handled
+// PUT /api/manager/settings/service-hours
+// Body: any subset of the settings fields; omitted fields keep their current value
+// Body: { "reset": true } restores the built-in defaults
+func UpdateServiceHoursSettings(db *sqlx.DB) http.HandlerFunc {
+	return serviceHoursSetting.update(db)
+}
+
+var costRatesSetting = settingHandlers[models.CostRates]{
+	key:      models.SettingKeyCostRates,
+	tag:      "COSTS",
+	label:    "cost rates",
+	defaults: models.DefaultCostRates,
+	load:     database.GetCostRates,
+}
+
+// GetCostRates returns the effective shift cost rates
+// GET /api/manager/settings/cost-rates
+func GetCostRates(db *sqlx.DB) http.HandlerFunc {
+	return costRatesSetting.get(db)
+}
+
+// UpdateCostRates updates the driver hourly and per-km rates shifts are priced with (applies to shifts
+// assigned or ended from now on)
+// PUT /api/manager/settings/cost-rates
+// Body: any subset of the rate fields; omitted fields keep their current value
+// Body: { "reset": true } restores the built-in defaults
+func UpdateCostRates(db *sqlx.DB) http.HandlerFunc {
+	return costRatesSetting.update(db)
+}
+
+var checkFormSetting = settingHandlers[models.CheckForm]{
+	key:      models.SettingKeyCheckForm,
+	tag:      "CHECK-FORM",
+	label:    "check form",
+	defaults: models.DefaultCheckForm,
+	load:     database.GetCheckForm,
+	// The fields are replaced as a whole
+	merge: func(form *models.CheckForm, body map[string]json.RawMessage) error {
+		fields, exists := body["fields"]
+		if !exists {
+			return txFail(http.StatusBadRequest, "fields is required")
+		}
+		form.Fields = nil
+		if err := json.Unmarshal(fields, &form.Fields); err != nil {
+			return err
+		}
+		if form.Fields == nil {
+			form.Fields = []models.CheckFormField{}
+		}
+		for i := range form.Fields {
+			form.Fields[i].Key = strings.TrimSpace(form.Fields[i].Key)
+			form.Fields[i].Label = strings.TrimSpace(form.Fields[i].Label)
+		}
+		return nil
+	},
+	summary: func(form models.CheckForm) string {
+		return fmt.Sprintf("%d field(s)", len(form.Fields))
+	},
+}
+
+// GetCheckForm returns the extra fields drivers fill in with each check
+// GET /api/manager/settings/check-form
+func GetCheckForm(db *sqlx.DB) http.HandlerFunc {
+	return checkFormSetting.get(db)
+}
+
+// UpdateCheckForm replaces the extra check fields (applies to checks submitted from now on; stored answers
+// keep the keys they were given)
+// PUT /api/manager/settings/check-form
+// Body: { "fields": [{ "key": "contamination", "label": "Contamination", "type": "select", "required": true, "options": ["none", "low", "high"] }] }
+// Body: { "reset": true } removes every field
+func UpdateCheckForm(db *sqlx.DB) http.HandlerFunc {
+	return checkFormSetting.update(db)
+}
+
+var debriefSetting = settingHandlers[models.DebriefSettings]{
+	key:      models.SettingKeyShiftDebrief,
+	tag:      "DEBRIEF",
+	label:    "shift debrief settings",
+	defaults: models.DefaultDebriefSettings,
+	load:     database.GetDebriefSettings,
+	merge: func(settings *models.DebriefSettings, body map[string]json.RawMessage) error {
+		if required, exists := body["required"]; exists {
+			if err := json.Unmarshal(required, &settings.Required); err != nil {
+				return txFail(http.StatusBadRequest, "required must be true or false")
+			}
+		}
+		if questions, exists := body["questions"]; exists {
+			settings.Questions = nil
+			if err := json.Unmarshal(questions, &settings.Questions); err != nil {
+				return txFail(http.StatusBadRequest, "Invalid questions: "+err.Error())
+			}
+			for i := range settings.Questions {
+				settings.Questions[i].Key = strings.TrimSpace(settings.Questions[i].Key)
+				settings.Questions[i].Label = strings.TrimSpace(settings.Questions[i].Label)
+			}
+		}
+		if settings.Questions == nil {
+			settings.Questions = []models.CheckFormField{}
+		}
+		return nil
+	},
+	summary: func(settings models.DebriefSettings) string {
+		return fmt.Sprintf("required: %t, %d question(s)", settings.Required, len(settings.Questions))
+	},
+}
+
+// GetDebriefSettings returns the end-of-shift debrief settings
+// GET /api/manager/settings/shift-debrief
+func GetDebriefSettings(db *sqlx.DB) http.HandlerFunc {
+	return debriefSetting.get(db)
+}
+
+// UpdateDebriefSettings updates the end-of-shift debrief settings (omitted fields are unchanged; questions
+// are replaced as a whole)
+// PUT /api/manager/settings/shift-debrief
+// Body: { "required": true, "questions": [{ "key": "vehicle_issues", "label": "Any vehicle issues?", "type": "boolean", "required": true }] }
+// Body: { "reset": true } restores the defaults
+func UpdateDebriefSettings(db *sqlx.DB) http.HandlerFunc {
+	return debriefSetting.update(db)
+}
+
+var checkInProximitySetting = settingHandlers[models.CheckInProximitySettings]{
+	key:      models.SettingKeyCheckInProximity,
+	tag:      "PROXIMITY",
+	label:    "check-in proximity settings",
+	defaults: models.DefaultCheckInProximitySettings,
+	load:     database.GetCheckInProximitySettings,
+	summary: func(settings models.CheckInProximitySettings) string {
+		return fmt.Sprintf("enabled: %t, %.0f m, %s", settings.Enabled, settings.MaxDistanceMeters, settings.Action)
+	},
+}
+
+// GetCheckInProximitySettings returns how far from a stop drivers may complete it
+// GET /api/manager/settings/check-in-proximity
+func GetCheckInProximitySettings(db *sqlx.DB) http.HandlerFunc {
+	return checkInProximitySetting.get(db)
+}
+
+// UpdateCheckInProximitySettings updates the check-in proximity limit and whether remote completions are
+// flagged or rejected (applies to the next completion)
+// PUT /api/manager/settings/check-in-proximity
+// Body: any subset of the settings fields; omitted fields keep their current value
+// Body: { "reset": true } restores the built-in defaults
+func UpdateCheckInProximitySettings(db *sqlx.DB) http.HandlerFunc {
+	return checkInProximitySetting.update(db)
+}
+
+var fillThresholdsSetting = settingHandlers[models.FillThresholds]{
+	key:      models.SettingKeyFillThresholds,
+	tag:      "FILL-THRESHOLDS",
+	label:    "fill thresholds",
+	defaults: models.DefaultFillThresholds,
+	load:     database.GetFillThresholds,
+	summary: func(thresholds models.FillThresholds) string {
+		return fmt.Sprintf("warning %d%%, critical %d%%", thresholds.Warning, thresholds.Critical)
+	},
+}
+
+// GetFillThresholds returns the fill percentages at which bins are flagged as warning and critical
+// GET /api/manager/settings/fill-thresholds
+func GetFillThresholds(db *sqlx.DB) http.HandlerFunc {
+	return fillThresholdsSetting.get(db)
+}
+
+// UpdateFillThresholds updates the warning and critical fill thresholds (priority scoring, the digest,
+// check recommendations, analytics and bin fill levels use them from the next request or run)
+// PUT /api/manager/settings/fill-thresholds
+// Body: { "warning": 60, "critical": 80 } (either may be omitted to keep its current value)
+// Body: { "reset": true } restores the built-in defaults
+func UpdateFillThresholds(db *sqlx.DB) http.HandlerFunc {
+	return fillThresholdsSetting.update(db)
+}
+
+var payrollExportSetting = settingHandlers[models.PayrollExportSettings]{
+	key:      models.SettingKeyPayrollExport,
+	tag:      "PAYROLL",
+	label:    "payroll export settings",
+	defaults: models.DefaultPayrollExportSettings,
+	load:     database.GetPayrollExportSettings,
+	merge: func(settings *models.PayrollExportSettings, body map[string]json.RawMessage) error {
+		if _, exists := body["columns"]; exists {
+			settings.Columns = nil // Replaced as a whole, not merged by index
+		}
+		return mergeSettingBody(settings, body)
+	},
+	summary: func(settings models.PayrollExportSettings) string {
+		return fmt.Sprintf("%d columns", len(settings.Columns))
+	},
+	extra: map[string]interface{}{"fields": models.PayrollFields},
+}
+
+// GetPayrollExportSettings returns the payroll export column mapping, timezone and rounding
+// GET /api/manager/settings/payroll-export
+func GetPayrollExportSettings(db *sqlx.DB) http.HandlerFunc {
+	return payrollExportSetting.get(db)
+}
+
+// UpdatePayrollExportSettings updates the payroll export column mapping (applies to the next export)
+// PUT /api/manager/settings/payroll-export
+// Body: { "columns": [{ "header": "EmpID", "field": "driver_id" }, { "header": "Code", "field": "constant", "value": "REG" }],
+// "timezone": "America/Los_Angeles", "hours_decimals": 2 } (omitted fields keep their current value)
+// Body: { "reset": true } restores the built-in defaults
+func UpdatePayrollExportSettings(db *sqlx.DB) http.HandlerFunc {
+	return payrollExportSetting.update(db)
+}
+
+var incidentDedupSetting = settingHandlers[models.IncidentDedupSettings]{
+	key:      models.SettingKeyIncidentDedup,
+	tag:      "INCIDENT-DEDUP",
+	label:    "incident dedup settings",
+	defaults: models.DefaultIncidentDedupSettings,
+	load:     database.GetIncidentDedupSettings,
+	summary: func(settings models.IncidentDedupSettings) string {
+		return fmt.Sprintf("window: %dh", settings.WindowHours)
+	},
+}
+
+// GetIncidentDedupSettings returns the window within which repeat incident reports are linked to the first one
+// GET /api/manager/settings/incident-dedup
+func GetIncidentDedupSettings(db *sqlx.DB) http.HandlerFunc {
+	return incidentDedupSetting.get(db)
+}
+
+// UpdateIncidentDedupSettings updates the dedup window (applies to the next report; existing links are kept)
+// PUT /api/manager/settings/incident-dedup
+// Body: { "window_hours": 72 } (0 turns deduplication off)
+// Body: { "reset": true } restores the built-in defaults
+func UpdateIncidentDedupSettings(db *sqlx.DB) http.HandlerFunc {
+	return incidentDedupSetting.update(db)
+}
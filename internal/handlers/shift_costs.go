@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// loadCostRates returns the configured shift cost rates, falling back to defaults on error
+func loadCostRates(db sqlx.Queryer) models.CostRates {
+	rates, err := database.GetCostRates(db)
+	if err != nil {
+		log.Printf("⚠️  [COSTS] %v (using default rates)", err)
+	}
+	return rates
+}
+
+// recordShiftCost prices an ended shift's driver time and distance and stores it on its shift_history row
+// next to the estimate made at assignment. Failures are logged only - the shift has already ended
+func recordShiftCost(db *sqlx.DB, shift models.Shift, efficiency models.ShiftEfficiency) {
+	rates := loadCostRates(db)
+	breakdown := models.ShiftCostBreakdown{Rates: rates}
+
+	distanceKm := 0.0
+	switch {
+	case efficiency.ActualDistanceKm != nil:
+		distanceKm = *efficiency.ActualDistanceKm
+		breakdown.DistanceSource = models.CostDistanceGPS
+	case efficiency.PlannedDistanceKm != nil:
+		distanceKm = *efficiency.PlannedDistanceKm
+		breakdown.DistanceSource = models.CostDistancePlanned
+	case shift.EstimatedDistanceKm != nil:
+		distanceKm = *shift.EstimatedDistanceKm
+		breakdown.DistanceSource = models.CostDistanceEstimated
+	}
+	breakdown.Actual = rates.Calculate(float64(efficiency.ActualDurationSeconds)/3600, distanceKm)
+
+	if shift.EstimatedCost != nil {
+		estimated := models.ShiftCost{Total: *shift.EstimatedCost}
+		if shift.EstimatedHours != nil {
+			estimated.Hours = *shift.EstimatedHours
+		}
+		if shift.EstimatedDistanceKm != nil {
+			estimated.DistanceKm = *shift.EstimatedDistanceKm
+		}
+		breakdown.Estimated = &estimated
+	}
+
+	raw, err := json.Marshal(breakdown)
+	if err != nil {
+		log.Printf("⚠️  [COSTS] Failed to encode cost of shift %s: %v", shift.ID, err)
+		return
+	}
+
+	_, err = db.Exec(`
+		UPDATE shift_history
+		SET estimated_cost = $1, actual_cost = $2, cost_breakdown = $3
+		WHERE id = $4`, shift.EstimatedCost, breakdown.Actual.Total, string(raw), shift.ID)
+	if err != nil {
+		log.Printf("⚠️  [COSTS] Failed to save cost of shift %s: %v", shift.ID, err)
+		return
+	}
+
+	log.Printf("💵 [COSTS] Shift %s: actual %.2f (%.1f h, %.1f km %s)",
+		shift.ID, breakdown.Actual.Total, breakdown.Actual.Hours, breakdown.Actual.DistanceKm, breakdown.DistanceSource)
+}
+
+// GetRouteCostVariance compares estimated and actual shift costs grouped by route and month
+// GET /api/manager/analytics/route-costs
+// Query params: route_id, since, until (unix, on when the shift ended)
+func GetRouteCostVariance(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+
+		// Shifts that ended before cost tracking have no actual_cost and are left out
+		args := []interface{}{}
+		whereClause := []string{"sh.actual_cost IS NOT NULL"}
+		if routeID := q.Get("route_id"); routeID != "" {
+			args = append(args, routeID)
+			whereClause = append(whereClause, fmt.Sprintf("sh.route_id = $%d", len(args)))
+		}
+		if since, err := strconv.ParseInt(q.Get("since"), 10, 64); err == nil {
+			args = append(args, since)
+			whereClause = append(whereClause, fmt.Sprintf("sh.ended_at >= $%d", len(args)))
+		}
+		if until, err := strconv.ParseInt(q.Get("until"), 10, 64); err == nil {
+			args = append(args, until)
+			whereClause = append(whereClause, fmt.Sprintf("sh.ended_at < $%d", len(args)))
+		}
+
+		query := fmt.Sprintf(`
+			SELECT
+				NULLIF(sh.route_id, '') AS route_id,
+				MIN(rt.name) AS route_name,
+				TO_CHAR(TO_TIMESTAMP(sh.ended_at) AT TIME ZONE 'UTC', 'YYYY-MM') AS month,
+				COUNT(*) AS shifts,
+				COUNT(*) FILTER (WHERE sh.estimated_cost IS NULL) AS shifts_without_estimate,
+				COALESCE(SUM(sh.estimated_cost), 0)::DOUBLE PRECISION AS estimated_cost,
+				COALESCE(SUM(sh.actual_cost), 0)::DOUBLE PRECISION AS actual_cost,
+				COALESCE(SUM(sh.actual_cost - sh.estimated_cost), 0)::DOUBLE PRECISION AS variance,
+				ROUND(SUM(sh.actual_cost - sh.estimated_cost) / NULLIF(SUM(sh.estimated_cost), 0) * 100, 2)::DOUBLE PRECISION AS variance_percent
+			FROM shift_history sh
+			LEFT JOIN routes rt ON rt.id = sh.route_id
+			WHERE %s
+			GROUP BY 1, 3
+			ORDER BY 3 DESC, actual_cost DESC
+		`, strings.Join(whereClause, " AND "))
+
+		groups := []models.RouteCostVariance{}
+		if err := db.SelectContext(r.Context(), &groups, query, args...); err != nil {
+			log.Printf("❌ [COSTS] Failed to fetch route cost variance: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch route costs")
+			return
+		}
+
+		var estimatedCost, actualCost, variance float64
+		for _, group := range groups {
+			estimatedCost += group.EstimatedCost
+			actualCost += group.ActualCost
+			variance += group.Variance
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data": map[string]interface{}{
+				"groups":         groups,
+				"estimated_cost": estimatedCost,
+				"actual_cost":    actualCost,
+				"variance":       variance,
+			},
+		})
+	}
+}
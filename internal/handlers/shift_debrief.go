@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Bin notes shown with a stop: the newest few from the last few months
+const (
+	stopNotesMaxAgeDays = 90
+	stopNotesPerBin     = 3
+)
+
+// shiftNoteSelect selects shift notes with the driver's name and bin number
+const shiftNoteSelect = `
+	SELECT n.*, u.name AS driver_name, b.bin_number
+	FROM shift_notes n
+	LEFT JOIN users u ON u.id = n.driver_id
+	LEFT JOIN bins b ON b.id = n.bin_id`
+
+// missingNoteBins returns the bin IDs referenced by debrief notes that don't exist
+func missingNoteBins(ctx context.Context, db sqlx.QueryerContext, notes []models.ShiftNoteInput) ([]string, error) {
+	var binIDs []string
+	for _, note := range notes {
+		if note.BinID != nil {
+			binIDs = append(binIDs, *note.BinID)
+		}
+	}
+	if len(binIDs) == 0 {
+		return nil, nil
+	}
+
+	var found []string
+	if err := sqlx.SelectContext(ctx, db, &found, `SELECT id FROM bins WHERE id = ANY($1)`, pq.Array(binIDs)); err != nil {
+		return nil, fmt.Errorf("failed to look up note bins: %w", err)
+	}
+	exists := make(map[string]bool, len(found))
+	for _, id := range found {
+		exists[id] = true
+	}
+	var missing []string
+	for _, id := range binIDs {
+		if !exists[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing, nil
+}
+
+// saveShiftDebrief stores a driver's debrief and notes for an ended shift (its shift_history row must exist)
+func saveShiftDebrief(ctx context.Context, db *sqlx.DB, shift models.Shift, debrief models.ShiftDebriefRequest, responses map[string]interface{}, now int64) error {
+	raw, err := json.Marshal(responses)
+	if err != nil {
+		return fmt.Errorf("failed to encode debrief answers: %w", err)
+	}
+
+	return database.WithTx(ctx, db, func(tx *sqlx.Tx) error {
+		// string so lib/pq sends JSON text, not bytea
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO shift_debriefs (shift_id, driver_id, responses, submitted_at)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (shift_id) DO UPDATE SET responses = EXCLUDED.responses, submitted_at = EXCLUDED.submitted_at
+		`, shift.ID, shift.DriverID, string(raw), now)
+		if err != nil {
+			return fmt.Errorf("failed to save debrief: %w", err)
+		}
+
+		for _, note := range debrief.Notes {
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO shift_notes (shift_id, driver_id, bin_id, note, created_at) VALUES ($1, $2, $3, $4, $5)
+			`, shift.ID, shift.DriverID, note.BinID, note.Note, now)
+			if err != nil {
+				return fmt.Errorf("failed to save shift note: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// annotateStopNotes attaches the recent, undismissed notes other shifts left about each stop's bin, so the
+// next driver sees them (e.g. "gate locked after 5pm")
+// Failures are logged and the stops are returned without notes
+func annotateStopNotes(db *sqlx.DB, stops []models.ShiftBinWithDetails) {
+	if len(stops) == 0 {
+		return
+	}
+	binIDs := make([]string, 0, len(stops))
+	for _, stop := range stops {
+		if stop.BinID != "" {
+			binIDs = append(binIDs, stop.BinID)
+		}
+	}
+	if len(binIDs) == 0 {
+		return
+	}
+
+	since := time.Now().Unix() - stopNotesMaxAgeDays*24*60*60
+	notes := []models.ShiftNote{}
+	err := db.Select(&notes, `
+		SELECT id, shift_id, driver_id, bin_id, note, created_at, dismissed_at, dismissed_by_user_id, driver_name, bin_number
+		FROM (
+			SELECT n.*, u.name AS driver_name, b.bin_number,
+				ROW_NUMBER() OVER (PARTITION BY n.bin_id ORDER BY n.created_at DESC, n.id DESC) AS rank
+			FROM shift_notes n
+			LEFT JOIN users u ON u.id = n.driver_id
+			LEFT JOIN bins b ON b.id = n.bin_id
+			WHERE n.bin_id = ANY($1) AND n.shift_id <> $2 AND n.dismissed_at IS NULL AND n.created_at >= $3
+		) ranked
+		WHERE rank <= $4
+	`, pq.Array(binIDs), stops[0].ShiftID, since, stopNotesPerBin)
+	if err != nil {
+		log.Printf("⚠️  [DEBRIEF] Failed to load bin notes: %v", err)
+		return
+	}
+
+	byBin := map[string][]models.ShiftNote{}
+	for _, note := range notes {
+		byBin[*note.BinID] = append(byBin[*note.BinID], note)
+	}
+	for i := range stops {
+		stops[i].DriverNotes = byBin[stops[i].BinID]
+	}
+}
+
+// GetShiftDebrief returns the debrief a driver submitted when ending a shift, with their notes
+// GET /api/manager/shifts/{id}/debrief
+func GetShiftDebrief(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		shiftID := chi.URLParam(r, "id")
+
+		var debrief models.ShiftDebrief
+		err := db.GetContext(r.Context(), &debrief, `
+			SELECT d.*, u.name AS driver_name
+			FROM shift_debriefs d
+			LEFT JOIN users u ON u.id = d.driver_id
+			WHERE d.shift_id = $1
+		`, shiftID)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "No debrief for this shift")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [DEBRIEF] Failed to fetch debrief of shift %s: %v", shiftID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch shift debrief")
+			return
+		}
+
+		debrief.Notes = []models.ShiftNote{}
+		err = db.SelectContext(r.Context(), &debrief.Notes, shiftNoteSelect+` WHERE n.shift_id = $1 ORDER BY n.id ASC`, shiftID)
+		if err != nil {
+			log.Printf("❌ [DEBRIEF] Failed to fetch notes of shift %s: %v", shiftID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch shift debrief")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    debrief,
+		})
+	}
+}
+
+// GetBinNotes lists the notes drivers left about a bin in their shift debriefs, newest first
+// GET /api/manager/bins/{id}/notes?include_dismissed=true&limit=50
+func GetBinNotes(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		binID := chi.URLParam(r, "id")
+		q := r.URL.Query()
+
+		query := shiftNoteSelect + ` WHERE n.bin_id = $1`
+		if q.Get("include_dismissed") != "true" {
+			query += ` AND n.dismissed_at IS NULL`
+		}
+		limit := 50
+		if parsed, err := strconv.Atoi(q.Get("limit")); err == nil && parsed > 0 && parsed <= 500 {
+			limit = parsed
+		}
+		query += ` ORDER BY n.created_at DESC, n.id DESC LIMIT $2`
+
+		notes := []models.ShiftNote{}
+		if err := db.SelectContext(r.Context(), &notes, query, binID, limit); err != nil {
+			log.Printf("❌ [DEBRIEF] Failed to fetch notes of bin %s: %v", binID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch bin notes")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    notes,
+		})
+	}
+}
+
+// DismissShiftNote hides a bin note from drivers (e.g. the locked gate was fixed); it stays in the shift's debrief
+// POST /api/manager/shift-notes/{id}/dismiss
+func DismissShiftNote(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		noteID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid note ID")
+			return
+		}
+
+		result, err := db.ExecContext(r.Context(), `
+			UPDATE shift_notes SET dismissed_at = $1, dismissed_by_user_id = $2
+			WHERE id = $3 AND dismissed_at IS NULL
+		`, time.Now().Unix(), userClaims.UserID, noteID)
+		if err != nil {
+			log.Printf("❌ [DEBRIEF] Failed to dismiss note %d: %v", noteID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to dismiss note")
+			return
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			var exists bool
+			if err := db.GetContext(r.Context(), &exists, `SELECT EXISTS(SELECT 1 FROM shift_notes WHERE id = $1)`, noteID); err == nil && !exists {
+				utils.RespondError(w, http.StatusNotFound, "Note not found")
+				return
+			}
+			utils.RespondError(w, http.StatusConflict, "Note has already been dismissed")
+			return
+		}
+
+		var note models.ShiftNote
+		if err := db.GetContext(r.Context(), &note, shiftNoteSelect+` WHERE n.id = $1`, noteID); err != nil {
+			log.Printf("❌ [DEBRIEF] Failed to fetch note %d: %v", noteID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch note")
+			return
+		}
+
+		log.Printf("✅ [DEBRIEF] Note %d dismissed by %s", noteID, userClaims.Email)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    note,
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"ropacal-backend/internal/i18n"
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Shift history page sizes
+const (
+	shiftHistoryDefaultLimit = 50
+	shiftHistoryMaxLimit     = 200
+)
+
+// shiftHistoryColumns selects a models.ShiftHistoryEntry from shift_history sh joined with users u
+const shiftHistoryColumns = `
+	sh.id, sh.driver_id, u.name AS driver_name, NULLIF(sh.route_id, '') AS route_id,
+	sh.start_time, sh.end_time, sh.created_at, sh.ended_at,
+	COALESCE(sh.total_pause_seconds, 0) AS total_pause_seconds,
+	COALESCE(sh.total_bins, 0) AS total_bins,
+	COALESCE(sh.completed_bins, 0) AS completed_bins,
+	sh.completion_rate::FLOAT8 AS completion_rate,
+	COALESCE(sh.incidents_reported, 0) AS incidents_reported,
+	COALESCE(sh.field_observations, 0) AS field_observations,
+	sh.end_reason, sh.ended_by_user_id,
+	sh.earned_credits::FLOAT8 AS earned_credits,
+	sh.actual_distance_km::FLOAT8 AS actual_distance_km,
+	sh.actual_duration_seconds,
+	sh.stops_per_hour::FLOAT8 AS stops_per_hour`
+
+// shiftHistoryWhere builds the WHERE clause of a shift history query from its filters:
+// from/to (unix, on ended_at), driver_id, route_id, end_reason (comma-separated) and
+// min_completion_rate/max_completion_rate (percent)
+// driverID, when set, restricts the query to that driver whatever the driver_id filter says
+// The error is a message for the client
+func shiftHistoryWhere(q url.Values, driverID string) (string, []interface{}, error) {
+	args := []interface{}{}
+	whereClause := []string{"TRUE"}
+
+	if driverID == "" {
+		driverID = q.Get("driver_id")
+	}
+	if driverID != "" {
+		args = append(args, driverID)
+		whereClause = append(whereClause, fmt.Sprintf("sh.driver_id = $%d", len(args)))
+	}
+	if routeID := q.Get("route_id"); routeID != "" {
+		args = append(args, routeID)
+		whereClause = append(whereClause, fmt.Sprintf("sh.route_id = $%d", len(args)))
+	}
+
+	for _, bound := range []struct {
+		param string
+		op    string
+	}{{"from", ">="}, {"to", "<="}} {
+		v := q.Get(bound.param)
+		if v == "" {
+			continue
+		}
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s must be a unix timestamp", bound.param)
+		}
+		args = append(args, parsed)
+		whereClause = append(whereClause, fmt.Sprintf("sh.ended_at %s $%d", bound.op, len(args)))
+	}
+
+	if v := q.Get("end_reason"); v != "" {
+		reasons := strings.Split(v, ",")
+		for i, reason := range reasons {
+			reasons[i] = strings.TrimSpace(reason)
+			if !models.IsShiftEndReason(reasons[i]) {
+				return "", nil, fmt.Errorf("end_reason must be one of %s", strings.Join(models.ShiftEndReasons, ", "))
+			}
+		}
+		args = append(args, pq.Array(reasons))
+		whereClause = append(whereClause, fmt.Sprintf("sh.end_reason = ANY($%d)", len(args)))
+	}
+
+	for _, bound := range []struct {
+		param string
+		op    string
+	}{{"min_completion_rate", ">="}, {"max_completion_rate", "<="}} {
+		v := q.Get(bound.param)
+		if v == "" {
+			continue
+		}
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil || parsed < 0 || parsed > 100 {
+			return "", nil, fmt.Errorf("%s must be a percentage between 0 and 100", bound.param)
+		}
+		args = append(args, parsed)
+		whereClause = append(whereClause, fmt.Sprintf("sh.completion_rate %s $%d", bound.op, len(args)))
+	}
+
+	return " WHERE " + strings.Join(whereClause, " AND "), args, nil
+}
+
+// queryShiftHistory returns a page of the ended shifts matching where, newest first, with the summary of all of them
+func queryShiftHistory(ctx context.Context, db *sqlx.DB, where string, args []interface{}, limit, offset int) (*models.ShiftHistoryPage, error) {
+	page := &models.ShiftHistoryPage{Shifts: []models.ShiftHistoryEntry{}, Limit: limit, Offset: offset}
+
+	err := db.GetContext(ctx, &page.Summary, `
+		SELECT COUNT(*) AS shifts,
+			COALESCE(SUM(sh.total_bins), 0) AS total_bins,
+			COALESCE(SUM(sh.completed_bins), 0) AS completed_bins,
+			ROUND(AVG(sh.completion_rate), 2)::FLOAT8 AS average_completion_rate,
+			COALESCE(SUM(GREATEST(sh.end_time - sh.start_time - COALESCE(sh.total_pause_seconds, 0), 0)), 0)::BIGINT AS active_seconds,
+			COALESCE(SUM(sh.incidents_reported), 0) AS incidents_reported,
+			COALESCE(SUM(sh.earned_credits), 0)::FLOAT8 AS earned_credits
+		FROM shift_history sh`+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize shift history: %w", err)
+	}
+	page.Total = page.Summary.Shifts
+
+	var reasons []struct {
+		EndReason string `db:"end_reason"`
+		Shifts    int    `db:"shifts"`
+	}
+	if err := db.SelectContext(ctx, &reasons, `SELECT sh.end_reason, COUNT(*) AS shifts FROM shift_history sh`+where+` GROUP BY sh.end_reason`, args...); err != nil {
+		return nil, fmt.Errorf("failed to count end reasons: %w", err)
+	}
+	page.Summary.ByEndReason = make(map[string]int, len(reasons))
+	for _, reason := range reasons {
+		page.Summary.ByEndReason[reason.EndReason] = reason.Shifts
+	}
+
+	query := `SELECT` + shiftHistoryColumns + `
+		FROM shift_history sh
+		LEFT JOIN users u ON u.id = sh.driver_id` + where +
+		fmt.Sprintf(" ORDER BY sh.ended_at DESC, sh.id LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+	if err := db.SelectContext(ctx, &page.Shifts, query, append(args, limit, offset)...); err != nil {
+		return nil, fmt.Errorf("failed to fetch shift history: %w", err)
+	}
+	return page, nil
+}
+
+// shiftHistoryPaging reads limit and offset from the query string
+func shiftHistoryPaging(q url.Values) (int, int) {
+	limit := shiftHistoryDefaultLimit
+	if parsed, err := strconv.Atoi(q.Get("limit")); err == nil && parsed > 0 && parsed <= shiftHistoryMaxLimit {
+		limit = parsed
+	}
+	offset := 0
+	if parsed, err := strconv.Atoi(q.Get("offset")); err == nil && parsed >= 0 {
+		offset = parsed
+	}
+	return limit, offset
+}
+
+// GetDriverShiftHistory returns the authenticated driver's ended shifts from shift_history, newest first
+// GET /api/driver/shift-history?from=<unix>&to=<unix>&end_reason=completed,manual_end&min_completion_rate=&max_completion_rate=&limit=50&offset=0
+func GetDriverShiftHistory(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, i18n.Tr(r, "Unauthorized"))
+			return
+		}
+
+		q := r.URL.Query()
+		where, args, err := shiftHistoryWhere(q, userClaims.UserID)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		limit, offset := shiftHistoryPaging(q)
+
+		page, err := queryShiftHistory(r.Context(), db, where, args, limit, offset)
+		if err != nil {
+			log.Printf("❌ [SHIFT-HISTORY] Driver %s: %v", userClaims.UserID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch shift history")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    page,
+		})
+	}
+}
+
+// GetShiftHistory returns ended shifts across drivers from shift_history, newest first
+// GET /api/manager/shift-history?driver_id=&route_id=&from=<unix>&to=<unix>&end_reason=&min_completion_rate=&max_completion_rate=&limit=50&offset=0
+func GetShiftHistory(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		where, args, err := shiftHistoryWhere(q, "")
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		limit, offset := shiftHistoryPaging(q)
+
+		page, err := queryShiftHistory(r.Context(), db, where, args, limit, offset)
+		if err != nil {
+			log.Printf("❌ [SHIFT-HISTORY] %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch shift history")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    page,
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/testdb"
+	"ropacal-backend/internal/websocket"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// serveAs calls the handler as the user, with the chi URL parameters and the body (nil for none) encoded as JSON
+func serveAs(t *testing.T, handler http.HandlerFunc, claims middleware.UserClaims, path string, params map[string]string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	var raw []byte
+	if body != nil {
+		var err error
+		if raw, err = json.Marshal(body); err != nil {
+			t.Fatalf("failed to encode request: %v", err)
+		}
+	}
+	r := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(raw))
+	routeContext := chi.NewRouteContext()
+	for key, value := range params {
+		routeContext.URLParams.Add(key, value)
+	}
+	ctx := context.WithValue(r.Context(), chi.RouteCtxKey, routeContext)
+	r = r.WithContext(context.WithValue(ctx, middleware.UserContextKey, claims))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+// decodeResponse fails the test unless the response has the status, then decodes its body into v (if not nil)
+func decodeResponse(t *testing.T, w *httptest.ResponseRecorder, status int, v interface{}) {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("got %d, want %d (%s)", w.Code, status, w.Body.String())
+	}
+	if v == nil {
+		return
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("failed to decode response: %v (%s)", err, w.Body.String())
+	}
+}
+
+// shiftState reads back a shift's status and stop counts
+func shiftState(t *testing.T, db *sqlx.DB, shiftID string) (status string, completed, total int) {
+	t.Helper()
+	err := db.QueryRow(`SELECT status, completed_bins, total_bins FROM shifts WHERE id = $1`, shiftID).Scan(&status, &completed, &total)
+	if err != nil {
+		t.Fatalf("failed to read shift: %v", err)
+	}
+	return status, completed, total
+}
+
+// A driver's day end to end: a manager assigns a route, the driver starts the shift and collects its bins,
+// an urgent move is inserted into the running shift and picked up, and the driver ends the shift
+func TestShiftLifecycle(t *testing.T) {
+	db := testdb.Open(t)
+	hub := websocket.NewHub()
+	manager := middleware.UserClaims{UserID: testdb.User(t, db, "admin"), Email: "manager@test.ropacal.com", Role: "admin"}
+	driver := middleware.UserClaims{UserID: testdb.User(t, db, "driver"), Email: "driver@test.ropacal.com", Role: "driver"}
+	bins := []string{testdb.Bin(t, db), testdb.Bin(t, db)}
+	routeID := testdb.Route(t, db, bins...)
+
+	var assigned struct {
+		Data struct {
+			ShiftID string `json:"shift_id"`
+		} `json:"data"`
+	}
+	w := serveAs(t, AssignRoute(db, hub, nil), manager, "/api/manager/assign-route", nil,
+		assignRouteRequest{DriverID: driver.UserID, RouteID: routeID, BinIDs: bins, Force: true})
+	decodeResponse(t, w, http.StatusOK, &assigned)
+	shiftID := assigned.Data.ShiftID
+	if status, completed, total := shiftState(t, db, shiftID); status != "ready" || completed != 0 || total != 2 {
+		t.Fatalf("after assign: shift %s with %d/%d stops, want ready with 0/2", status, completed, total)
+	}
+
+	// The driver is at the stops, so the route is rotated from there (no HERE Maps call) and completions aren't remote
+	testdb.DriverLocation(t, db, driver.UserID)
+	w = serveAs(t, StartShift(db, hub, nil), driver, "/api/driver/shift/start", nil, nil)
+	decodeResponse(t, w, http.StatusOK, nil)
+	if status, _, _ := shiftState(t, db, shiftID); status != "active" {
+		t.Fatalf("after start: shift %s, want active", status)
+	}
+
+	fill := 60
+	for _, binID := range bins {
+		var completed struct {
+			Data models.CompleteBinResponse `json:"data"`
+		}
+		w = serveAs(t, CompleteBin(db, hub), driver, "/api/driver/shift/complete-bin", nil,
+			completeStopRequest{BinID: binID, UpdatedFillPercentage: &fill})
+		decodeResponse(t, w, http.StatusOK, &completed)
+		if completed.Data.CheckID == nil || completed.Data.RemoteCompletion {
+			t.Fatalf("complete bin %s: check %v, remote %v; want a check at the stop", binID, completed.Data.CheckID, completed.Data.RemoteCompletion)
+		}
+	}
+	var checked int
+	if err := db.Get(&checked, `SELECT COUNT(*) FROM bins WHERE id = ANY($1) AND fill_percentage = $2`, pq.Array(bins), fill); err != nil {
+		t.Fatalf("failed to read bins: %v", err)
+	}
+	if checked != len(bins) {
+		t.Fatalf("%d of %d collected bins have the reported fill", checked, len(bins))
+	}
+
+	// A move due today is urgent; assigning it to the running shift inserts its stop into the route
+	var move models.BinMoveRequestResponse
+	w = serveAs(t, ScheduleBinMove(db, hub, nil), manager, "/api/manager/bins/schedule-move", nil,
+		models.CreateBinMoveRequest{BinID: testdb.Bin(t, db), ScheduledDate: time.Now().Unix(), MoveType: "store"})
+	decodeResponse(t, w, http.StatusCreated, &move)
+	if move.Urgency != "urgent" {
+		t.Fatalf("move due now has urgency %s, want urgent", move.Urgency)
+	}
+	w = serveAs(t, AssignMoveToShift(db, hub, nil), manager, "/api/manager/bins/move-requests/"+move.ID+"/assign-to-shift",
+		map[string]string{"id": move.ID}, map[string]string{"shift_id": shiftID})
+	decodeResponse(t, w, http.StatusOK, nil)
+	if _, assignedShift, stops := moveAssignment(t, db, move.ID); assignedShift == nil || *assignedShift != shiftID || stops != 1 {
+		t.Fatalf("urgent move on shift %v with %d stops, want on %s with 1", assignedShift, stops, shiftID)
+	}
+	if status, completed, total := shiftState(t, db, shiftID); status != "active" || completed != 2 || total != 3 {
+		t.Fatalf("after urgent move: shift %s with %d/%d stops, want active with 2/3", status, completed, total)
+	}
+
+	photo := "https://storage.test.ropacal.com/pickup.jpg"
+	w = serveAs(t, ConfirmMovePickup(db, hub), driver, "/api/driver/moves/"+move.ID+"/confirm-pickup",
+		map[string]string{"id": move.ID}, models.ConfirmMoveLegRequest{PhotoURL: &photo})
+	decodeResponse(t, w, http.StatusOK, nil)
+	if status, _, _ := moveAssignment(t, db, move.ID); status != models.MoveStatusCompleted {
+		t.Fatalf("after pickup: store move %s, want completed", status)
+	}
+
+	var ended struct {
+		Data models.ShiftEndResponse `json:"data"`
+	}
+	w = serveAs(t, EndShift(db, hub), driver, "/api/driver/shift/end", nil, nil)
+	decodeResponse(t, w, http.StatusOK, &ended)
+	if ended.Data.CompletedBins != 3 || ended.Data.TotalBins != 3 {
+		t.Fatalf("end: %d/%d stops, want 3/3", ended.Data.CompletedBins, ended.Data.TotalBins)
+	}
+	if status, _, _ := shiftState(t, db, shiftID); status != "ended" {
+		t.Fatalf("after end: shift %s, want ended", status)
+	}
+	var endReason string
+	if err := db.Get(&endReason, `SELECT end_reason FROM shift_history WHERE id = $1`, shiftID); err != nil {
+		t.Fatalf("failed to read shift history: %v", err)
+	}
+	if endReason != "completed" {
+		t.Fatalf("shift history end reason %s, want completed", endReason)
+	}
+}
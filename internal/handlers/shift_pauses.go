@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"ropacal-backend/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// loadShiftPauses returns a shift's pause periods, oldest first, with totals per reason
+func loadShiftPauses(ctx context.Context, db *sqlx.DB, shiftID string) (models.ShiftPauseSummary, error) {
+	summary := models.ShiftPauseSummary{
+		Pauses:   []models.ShiftPause{},
+		ByReason: []models.PauseReasonTotal{},
+	}
+
+	err := db.SelectContext(ctx, &summary.Pauses, `
+		SELECT id, shift_id, paused_at, resumed_at, reason, note
+		FROM shift_pauses
+		WHERE shift_id = $1
+		ORDER BY paused_at ASC
+	`, shiftID)
+	if err != nil {
+		return summary, fmt.Errorf("failed to load pauses for shift %s: %w", shiftID, err)
+	}
+
+	now := time.Now().Unix()
+	totals := map[string]*models.PauseReasonTotal{}
+	for i := range summary.Pauses {
+		pause := &summary.Pauses[i]
+		end := now
+		if pause.ResumedAt != nil {
+			end = *pause.ResumedAt
+		}
+		if end > pause.PausedAt {
+			pause.DurationSeconds = end - pause.PausedAt
+		}
+
+		reason := models.PauseReasonUnspecified
+		if pause.Reason != nil {
+			reason = *pause.Reason
+		}
+		total, ok := totals[reason]
+		if !ok {
+			total = &models.PauseReasonTotal{Reason: reason}
+			totals[reason] = total
+		}
+		total.Count++
+		total.TotalSeconds += pause.DurationSeconds
+
+		if pause.ResumedAt == nil {
+			current := *pause
+			summary.CurrentPause = &current
+		}
+	}
+
+	for _, total := range totals {
+		summary.ByReason = append(summary.ByReason, *total)
+	}
+	sort.Slice(summary.ByReason, func(i, j int) bool {
+		if summary.ByReason[i].TotalSeconds != summary.ByReason[j].TotalSeconds {
+			return summary.ByReason[i].TotalSeconds > summary.ByReason[j].TotalSeconds
+		}
+		return summary.ByReason[i].Reason < summary.ByReason[j].Reason
+	})
+	return summary, nil
+}
@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/websocket"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+)
+
+// ETA estimation for manually reordered routes (straight-line distance)
+const (
+	etaAverageSpeedKmh    = 30.0 // Average urban driving speed
+	etaServiceTimeSeconds = 300  // Time spent at each stop
+)
+
+// RouteStopETA is the estimated arrival for a remaining stop
+type RouteStopETA struct {
+	TaskID        string  `json:"task_id"`
+	BinID         *string `json:"bin_id,omitempty"`
+	TaskType      string  `json:"task_type"`
+	SequenceOrder int     `json:"sequence_order"`
+	DistanceKm    float64 `json:"distance_km"` // Distance from the previous stop
+	ETA           int64   `json:"eta"`
+}
+
+// reorderTask is the subset of a route task needed to reorder and estimate ETAs
+type reorderTask struct {
+	ID            string  `db:"id"`
+	SequenceOrder int     `db:"sequence_order"`
+	TaskType      string  `db:"task_type"`
+	BinID         *string `db:"bin_id"`
+	Latitude      float64 `db:"latitude"`
+	Longitude     float64 `db:"longitude"`
+	IsCompleted   int     `db:"is_completed"`
+}
+
+// ReorderShiftRoute lets a manager override the order of a driver's remaining stops
+// PUT /api/manager/shifts/{id}/reorder
+// Body: { "bin_ids": ["..."] } - every remaining bin on the shift, in the desired order
+// Completed stops keep their position; stops without a bin (warehouse stops, placements) stay in place
+func ReorderShiftRoute(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		shiftID := chi.URLParam(r, "id")
+
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var req struct {
+			BinIDs []string `json:"bin_ids"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if len(req.BinIDs) == 0 {
+			utils.RespondError(w, http.StatusBadRequest, "bin_ids is required")
+			return
+		}
+
+		var shift models.Shift
+		now := time.Now().Unix()
+		err := database.WithTx(r.Context(), db, func(tx *sqlx.Tx) error {
+			err := tx.GetContext(r.Context(), &shift, `SELECT * FROM shifts WHERE id = $1 FOR UPDATE`, shiftID)
+			if err == sql.ErrNoRows {
+				return txFail(http.StatusNotFound, "Shift not found")
+			}
+			if err != nil {
+				log.Printf("❌ [REORDER-ROUTE] Failed to fetch shift: %v", err)
+				return txFail(http.StatusInternalServerError, "Failed to fetch shift")
+			}
+			if shift.Status != models.ShiftStatusReady && shift.Status != models.ShiftStatusActive && shift.Status != models.ShiftStatusPaused {
+				return txFail(http.StatusBadRequest, "Shift must be ready, active or paused")
+			}
+
+			// Lock the shift's tasks so a concurrent completion can't race the reorder
+			var tasks []reorderTask
+			err = tx.SelectContext(r.Context(), &tasks, `
+				SELECT id, sequence_order, task_type, bin_id, latitude, longitude, is_completed
+				FROM route_tasks
+				WHERE shift_id = $1
+				ORDER BY sequence_order ASC
+				FOR UPDATE
+			`, shift.ID)
+			if err != nil {
+				log.Printf("❌ [REORDER-ROUTE] Failed to load tasks: %v", err)
+				return txFail(http.StatusInternalServerError, "Failed to load route")
+			}
+
+			// Group remaining bin tasks by bin (a move can have a pickup and a dropoff for the same bin)
+			remainingByBin := make(map[string][]reorderTask)
+			completedBins := make(map[string]bool)
+			var slots []int
+			for _, task := range tasks {
+				if task.BinID == nil {
+					continue
+				}
+				if task.IsCompleted == 1 {
+					completedBins[*task.BinID] = true
+					continue
+				}
+				remainingByBin[*task.BinID] = append(remainingByBin[*task.BinID], task)
+				slots = append(slots, task.SequenceOrder)
+			}
+
+			// Validate the requested order covers exactly the remaining bins
+			seen := make(map[string]bool)
+			for _, binID := range req.BinIDs {
+				if seen[binID] {
+					return txFail(http.StatusBadRequest, fmt.Sprintf("Bin %s is listed more than once", binID))
+				}
+				seen[binID] = true
+
+				if _, remaining := remainingByBin[binID]; !remaining {
+					if completedBins[binID] {
+						return txFail(http.StatusBadRequest, fmt.Sprintf("Bin %s is already completed and cannot be reordered", binID))
+					}
+					return txFail(http.StatusBadRequest, fmt.Sprintf("Bin %s is not on this shift", binID))
+				}
+			}
+			if len(seen) != len(remainingByBin) {
+				return txFail(http.StatusBadRequest,
+					fmt.Sprintf("bin_ids must include all %d remaining bins (got %d)", len(remainingByBin), len(seen)))
+			}
+
+			// Fill the remaining bin slots in the requested order
+			slot := 0
+			for _, binID := range req.BinIDs {
+				for _, task := range remainingByBin[binID] {
+					newSequence := slots[slot]
+					slot++
+					if newSequence == task.SequenceOrder {
+						continue
+					}
+					_, err = tx.ExecContext(r.Context(), `
+						UPDATE route_tasks SET sequence_order = $1, updated_at = $2 WHERE id = $3
+					`, newSequence, now, task.ID)
+					if err != nil {
+						log.Printf("❌ [REORDER-ROUTE] Failed to update task %s: %v", task.ID, err)
+						return txFail(http.StatusInternalServerError, "Failed to reorder route")
+					}
+				}
+			}
+
+			// The cached route line follows the old order
+			if _, err := tx.ExecContext(r.Context(), `DELETE FROM route_polylines WHERE shift_id = $1`, shift.ID); err != nil {
+				log.Printf("❌ [REORDER-ROUTE] Failed to invalidate route polyline: %v", err)
+				return txFail(http.StatusInternalServerError, "Failed to reorder route")
+			}
+			return nil
+		})
+		if err != nil {
+			respondTxError(w, err, "Failed to reorder route")
+			return
+		}
+
+		log.Printf("✅ [REORDER-ROUTE] %s reordered %d remaining bins on shift %s", userClaims.Email, len(req.BinIDs), shift.ID)
+
+		etas, err := estimateRemainingETAs(db, shift, now)
+		if err != nil {
+			log.Printf("⚠️  [REORDER-ROUTE] Failed to estimate ETAs: %v", err)
+			etas = []RouteStopETA{}
+		}
+
+		// Push the new order to the driver
+		bins, err := getRouteBinsWithDetails(db, shift.ID)
+		if err != nil {
+			log.Printf("⚠️  [REORDER-ROUTE] Failed to load route for broadcast: %v", err)
+			bins = []models.ShiftBinWithDetails{}
+		}
+		logicalTotal, logicalCompleted := calculateLogicalBinCounts(bins)
+		hub.BroadcastToUser(shift.DriverID, map[string]interface{}{
+			"type": "shift_update",
+			"data": map[string]interface{}{
+				"id":             shift.ID,
+				"status":         shift.Status,
+				"completed_bins": logicalCompleted,
+				"total_bins":     logicalTotal,
+				"bins":           bins,
+				"etas":           etas,
+				"reordered_by":   userClaims.Email,
+			},
+		})
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data": map[string]interface{}{
+				"shift_id": shift.ID,
+				"bins":     bins,
+				"etas":     etas,
+			},
+		})
+	}
+}
+
+// estimateRemainingETAs estimates arrival times for the shift's remaining stops
+// Starts from the driver's current location, falling back to the last completed stop
+func estimateRemainingETAs(db *sqlx.DB, shift models.Shift, now int64) ([]RouteStopETA, error) {
+	var tasks []reorderTask
+	err := db.Select(&tasks, `
+		SELECT id, sequence_order, task_type, bin_id, latitude, longitude, is_completed
+		FROM route_tasks
+		WHERE shift_id = $1
+		ORDER BY sequence_order ASC
+	`, shift.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tasks: %w", err)
+	}
+
+	// Prefer the driver's live position for this shift
+	var lat, lng float64
+	hasPosition := false
+	var location struct {
+		Latitude  float64 `db:"latitude"`
+		Longitude float64 `db:"longitude"`
+	}
+	err = db.Get(&location, `
+		SELECT latitude, longitude FROM driver_current_location
+		WHERE driver_id = $1 AND shift_id = $2
+	`, shift.DriverID, shift.ID)
+	hasLivePosition := err == nil
+	if hasLivePosition {
+		lat, lng, hasPosition = location.Latitude, location.Longitude, true
+	}
+
+	etas := []RouteStopETA{}
+	clock := now
+	for _, task := range tasks {
+		if task.IsCompleted == 1 {
+			if !hasLivePosition {
+				lat, lng, hasPosition = task.Latitude, task.Longitude, true
+			}
+			continue
+		}
+
+		distance := 0.0
+		if hasPosition {
+			distance = haversineDistanceKm(lat, lng, task.Latitude, task.Longitude)
+		}
+		clock += int64(distance / etaAverageSpeedKmh * 3600)
+
+		etas = append(etas, RouteStopETA{
+			TaskID:        task.ID,
+			BinID:         task.BinID,
+			TaskType:      task.TaskType,
+			SequenceOrder: task.SequenceOrder,
+			DistanceKm:    distance,
+			ETA:           clock,
+		})
+
+		clock += etaServiceTimeSeconds
+		lat, lng, hasPosition = task.Latitude, task.Longitude, true
+	}
+
+	return etas, nil
+}
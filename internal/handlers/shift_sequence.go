@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/store"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// RepairShiftSequences detects and fixes duplicate or gapped sequence_order values in a shift's stops
+// POST /api/manager/shifts/repair-sequence?shift_id=<id>&dry_run=true
+// Without shift_id every shift is checked; dry_run only reports the conflicts
+func RepairShiftSequences(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		shiftID := r.URL.Query().Get("shift_id")
+		dryRun := r.URL.Query().Get("dry_run") == "true"
+
+		conflicts, err := store.NewShiftStore(db).SequenceConflicts(shiftID)
+		if err != nil {
+			log.Printf("❌ [SEQUENCE-REPAIR] %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to check shift sequences")
+			return
+		}
+
+		if !dryRun {
+			for i := range conflicts {
+				changed, err := repairShiftSequence(r.Context(), db, conflicts[i].ShiftID)
+				if err != nil {
+					log.Printf("❌ [SEQUENCE-REPAIR] Shift %s: %v", conflicts[i].ShiftID, err)
+					continue
+				}
+				conflicts[i].Repaired = true
+				conflicts[i].ChangedStops = changed
+				log.Printf("🔧 [SEQUENCE-REPAIR] Shift %s reindexed (%d duplicate orders, %d stops changed)",
+					conflicts[i].ShiftID, conflicts[i].DuplicateOrders, changed)
+			}
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data": map[string]interface{}{
+				"dry_run":   dryRun,
+				"conflicts": conflicts,
+			},
+		})
+	}
+}
+
+// repairShiftSequence reindexes one shift under the shift lock
+func repairShiftSequence(ctx context.Context, db *sqlx.DB, shiftID string) (int64, error) {
+	var changed int64
+	err := database.WithTx(ctx, db, func(tx *sqlx.Tx) error {
+		shifts := store.NewShiftStore(tx)
+		if err := shifts.Lock(shiftID); err != nil {
+			return err
+		}
+		var err error
+		changed, err = shifts.Reindex(shiftID)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return changed, nil
+}
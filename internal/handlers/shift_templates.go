@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/services"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// shiftTemplateRequest is the body for creating or updating a shift template
+type shiftTemplateRequest struct {
+	Name             *string  `json:"name"`
+	RouteID          *string  `json:"route_id"`
+	DriverID         *string  `json:"driver_id"` // "" clears the preferred driver
+	StartWindowStart *string  `json:"start_window_start"`
+	StartWindowEnd   *string  `json:"start_window_end"`
+	DaysOfWeek       []string `json:"days_of_week"` // Empty = every day
+	Timezone         *string  `json:"timezone"`
+	IsActive         *bool    `json:"is_active"`
+}
+
+// applyShiftTemplateRequest copies the provided fields onto the template and validates the result,
+// returning a message for the client
+func applyShiftTemplateRequest(db *sqlx.DB, template *models.ShiftTemplate, req shiftTemplateRequest) string {
+	if req.Name != nil {
+		template.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.RouteID != nil {
+		template.RouteID = *req.RouteID
+	}
+	if req.DriverID != nil {
+		if *req.DriverID == "" {
+			template.DriverID = nil
+		} else {
+			template.DriverID = req.DriverID
+		}
+	}
+	if req.StartWindowStart != nil {
+		template.StartWindowStart = *req.StartWindowStart
+	}
+	if req.StartWindowEnd != nil {
+		template.StartWindowEnd = *req.StartWindowEnd
+	}
+	if req.DaysOfWeek != nil {
+		template.DaysOfWeek = pq.StringArray(req.DaysOfWeek)
+	}
+	if req.Timezone != nil {
+		template.Timezone = *req.Timezone
+	}
+	if req.IsActive != nil {
+		template.IsActive = *req.IsActive
+	}
+
+	if template.Name == "" {
+		return "name is required"
+	}
+	if template.RouteID == "" {
+		return "route_id is required"
+	}
+	start, err := time.Parse("15:04", template.StartWindowStart)
+	if err != nil {
+		return "start_window_start must be HH:MM"
+	}
+	end, err := time.Parse("15:04", template.StartWindowEnd)
+	if err != nil {
+		return "start_window_end must be HH:MM"
+	}
+	if !end.After(start) {
+		return "start_window_end must be after start_window_start"
+	}
+	for _, day := range template.DaysOfWeek {
+		if !models.IsValidShiftTemplateDay(day) {
+			return fmt.Sprintf("Invalid day of week: %s (use mon, tue, wed, thu, fri, sat, sun)", day)
+		}
+	}
+	if _, err := time.LoadLocation(template.Timezone); err != nil {
+		return fmt.Sprintf("Invalid timezone: %s", template.Timezone)
+	}
+
+	var routeExists bool
+	if err := db.Get(&routeExists, `SELECT EXISTS(SELECT 1 FROM routes WHERE id = $1)`, template.RouteID); err != nil || !routeExists {
+		return "Route not found"
+	}
+	if template.DriverID != nil {
+		var role string
+		if err := db.Get(&role, `SELECT role FROM users WHERE id = $1`, *template.DriverID); err != nil || role != "driver" {
+			return "driver_id must be an existing driver"
+		}
+	}
+
+	return ""
+}
+
+// GetShiftTemplates returns all shift templates
+// GET /api/manager/shift-templates
+func GetShiftTemplates(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		templates := []models.ShiftTemplate{}
+		if err := db.SelectContext(r.Context(), &templates, `SELECT * FROM shift_templates ORDER BY name ASC`); err != nil {
+			log.Printf("❌ [SHIFT-TEMPLATES] Failed to fetch templates: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch shift templates")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    templates,
+		})
+	}
+}
+
+// CreateShiftTemplate creates a recurring shift template
+// POST /api/manager/shift-templates
+// Body: { "name": "Morning route A", "route_id": "...", "driver_id": "...", "start_window_start": "06:00", "start_window_end": "07:30", "days_of_week": ["mon", "wed", "fri"], "timezone": "America/Los_Angeles" }
+func CreateShiftTemplate(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var req shiftTemplateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		now := time.Now().Unix()
+		template := models.ShiftTemplate{
+			ID:              uuid.New().String(),
+			DaysOfWeek:      pq.StringArray{},
+			Timezone:        "UTC",
+			IsActive:        true,
+			CreatedByUserID: &userClaims.UserID,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		}
+		if msg := applyShiftTemplateRequest(db, &template, req); msg != "" {
+			utils.RespondError(w, http.StatusBadRequest, msg)
+			return
+		}
+
+		_, err := db.NamedExecContext(r.Context(), `
+			INSERT INTO shift_templates (id, name, route_id, driver_id, start_window_start, start_window_end,
+				days_of_week, timezone, is_active, created_by_user_id, created_at, updated_at)
+			VALUES (:id, :name, :route_id, :driver_id, :start_window_start, :start_window_end,
+				:days_of_week, :timezone, :is_active, :created_by_user_id, :created_at, :updated_at)
+		`, template)
+		if err != nil {
+			log.Printf("❌ [SHIFT-TEMPLATES] Failed to create template: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to create shift template")
+			return
+		}
+
+		log.Printf("✅ [SHIFT-TEMPLATES] %s created template %s (%s)", userClaims.Email, template.ID, template.Name)
+
+		utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{
+			"success": true,
+			"data":    template,
+		})
+	}
+}
+
+// UpdateShiftTemplate changes any field of a shift template
+// Shifts already materialized from it are not changed
+// PUT /api/manager/shift-templates/{id}
+func UpdateShiftTemplate(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		templateID := chi.URLParam(r, "id")
+
+		var req shiftTemplateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		var template models.ShiftTemplate
+		err := db.GetContext(r.Context(), &template, `SELECT * FROM shift_templates WHERE id = $1`, templateID)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "Shift template not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [SHIFT-TEMPLATES] Failed to fetch template: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch shift template")
+			return
+		}
+
+		if msg := applyShiftTemplateRequest(db, &template, req); msg != "" {
+			utils.RespondError(w, http.StatusBadRequest, msg)
+			return
+		}
+		template.UpdatedAt = time.Now().Unix()
+
+		_, err = db.NamedExecContext(r.Context(), `
+			UPDATE shift_templates
+			SET name = :name, route_id = :route_id, driver_id = :driver_id,
+			    start_window_start = :start_window_start, start_window_end = :start_window_end,
+			    days_of_week = :days_of_week, timezone = :timezone, is_active = :is_active, updated_at = :updated_at
+			WHERE id = :id
+		`, template)
+		if err != nil {
+			log.Printf("❌ [SHIFT-TEMPLATES] Failed to update template: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to update shift template")
+			return
+		}
+
+		log.Printf("✅ [SHIFT-TEMPLATES] Updated template %s (active: %v)", template.ID, template.IsActive)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    template,
+		})
+	}
+}
+
+// DeleteShiftTemplate removes a shift template and its run log (materialized shifts are kept)
+// DELETE /api/manager/shift-templates/{id}
+func DeleteShiftTemplate(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		templateID := chi.URLParam(r, "id")
+
+		result, err := db.ExecContext(r.Context(), `DELETE FROM shift_templates WHERE id = $1`, templateID)
+		if err != nil {
+			log.Printf("❌ [SHIFT-TEMPLATES] Failed to delete template: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to delete shift template")
+			return
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			utils.RespondError(w, http.StatusNotFound, "Shift template not found")
+			return
+		}
+
+		log.Printf("✅ [SHIFT-TEMPLATES] Deleted template %s", templateID)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+		})
+	}
+}
+
+// GetShiftTemplateRuns returns the materializer's decisions (created shifts and conflicts), newest first
+// GET /api/manager/shift-templates/runs?date=YYYY-MM-DD&status=created|skipped
+func GetShiftTemplateRuns(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		date := q.Get("date")
+		if date != "" {
+			if _, err := time.Parse("2006-01-02", date); err != nil {
+				utils.RespondError(w, http.StatusBadRequest, "date must be YYYY-MM-DD")
+				return
+			}
+		}
+		status := q.Get("status")
+		if status != "" && status != models.ShiftTemplateRunCreated && status != models.ShiftTemplateRunSkipped {
+			utils.RespondError(w, http.StatusBadRequest, "status must be created or skipped")
+			return
+		}
+
+		runs := []models.ShiftTemplateRun{}
+		err := db.SelectContext(r.Context(), &runs, `
+			SELECT r.*, t.name AS template_name
+			FROM shift_template_runs r
+			JOIN shift_templates t ON t.id = r.template_id
+			WHERE ($1 = '' OR r.service_date = $1)
+			  AND ($2 = '' OR r.status = $2)
+			ORDER BY r.service_date DESC, t.name ASC
+			LIMIT 500`, date, status)
+		if err != nil {
+			log.Printf("❌ [SHIFT-TEMPLATES] Failed to fetch runs: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch shift template runs")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    runs,
+		})
+	}
+}
+
+// RunShiftTemplateMaterializer instantiates tomorrow's shifts from templates on demand
+// The materializer also runs on a schedule (see SHIFT_TEMPLATE_INTERVAL_MINUTES)
+// POST /api/manager/shift-templates/materialize
+func RunShiftTemplateMaterializer(materializer *services.ShiftTemplateMaterializer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		result, err := materializer.Run(time.Now())
+		if err != nil {
+			log.Printf("❌ [SHIFT-TEMPLATES] Manual run failed: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to materialize shift templates")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    result,
+		})
+	}
+}
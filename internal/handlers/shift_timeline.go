@@ -0,0 +1,333 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"ropacal-backend/internal/models"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+)
+
+// Shift timeline event types
+const (
+	TimelineShiftAssigned  = "shift_assigned"
+	TimelineShiftStarted   = "shift_started"
+	TimelineShiftPaused    = "shift_paused"
+	TimelineShiftResumed   = "shift_resumed"
+	TimelineShiftEnded     = "shift_ended"
+	TimelineShiftCancelled = "shift_cancelled"
+	TimelineTaskCompleted  = "task_completed"
+	TimelineTaskSkipped    = "task_skipped"
+	TimelineLocation       = "location"
+	TimelineIncident       = "incident"
+	TimelineMoveRequest    = "move_request" // Assignment changes (assigned, reassigned, unassigned, ...)
+)
+
+// defaultTimelineGranularitySeconds keeps at most one location ping per window
+const defaultTimelineGranularitySeconds = 30
+
+// ShiftTimelineEvent is a single entry in a shift replay
+type ShiftTimelineEvent struct {
+	Timestamp int64                  `json:"timestamp"` // Unix seconds
+	Type      string                 `json:"type"`
+	Latitude  *float64               `json:"latitude,omitempty"`
+	Longitude *float64               `json:"longitude,omitempty"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+}
+
+// GetShiftTimeline reconstructs everything that happened during a shift as one chronological stream
+// GET /api/manager/shifts/{id}/timeline
+// Query params:
+//   - granularity: seconds per location sample (default 30, 0 = every ping)
+//   - locations: "false" omits location pings
+func GetShiftTimeline(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		shiftID := chi.URLParam(r, "id")
+
+		granularity := defaultTimelineGranularitySeconds
+		if v := r.URL.Query().Get("granularity"); v != "" {
+			parsed, err := strconv.Atoi(v)
+			if err != nil || parsed < 0 {
+				utils.RespondError(w, http.StatusBadRequest, "granularity must be a non-negative number of seconds")
+				return
+			}
+			granularity = parsed
+		}
+		includeLocations := r.URL.Query().Get("locations") != "false"
+
+		var shift models.Shift
+		err := db.GetContext(r.Context(), &shift, `SELECT * FROM shifts WHERE id = $1`, shiftID)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "Shift not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [TIMELINE] Failed to fetch shift %s: %v", shiftID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch shift")
+			return
+		}
+
+		events := []ShiftTimelineEvent{{
+			Timestamp: shift.CreatedAt,
+			Type:      TimelineShiftAssigned,
+			Data:      map[string]interface{}{"route_id": shift.RouteID, "total_bins": shift.TotalBins},
+		}}
+		if shift.StartTime != nil {
+			events = append(events, ShiftTimelineEvent{Timestamp: *shift.StartTime, Type: TimelineShiftStarted})
+		}
+
+		// Shift end (shift_history has the reason; fall back to the shift row)
+		var history struct {
+			EndedAt       int64   `db:"ended_at"`
+			EndTime       *int64  `db:"end_time"`
+			EndReason     string  `db:"end_reason"`
+			EndedByUserID *string `db:"ended_by_user_id"`
+			CompletedBins int     `db:"completed_bins"`
+		}
+		err = db.GetContext(r.Context(), &history, `
+			SELECT ended_at, end_time, end_reason, ended_by_user_id, completed_bins
+			FROM shift_history WHERE id = $1
+		`, shiftID)
+		switch {
+		case err == nil:
+			endType := TimelineShiftEnded
+			if history.EndReason == "manager_cancelled" {
+				endType = TimelineShiftCancelled
+			}
+			endedAt := history.EndedAt
+			if history.EndTime != nil {
+				endedAt = *history.EndTime
+			}
+			events = append(events, ShiftTimelineEvent{
+				Timestamp: endedAt,
+				Type:      endType,
+				Data: map[string]interface{}{
+					"end_reason":       history.EndReason,
+					"ended_by_user_id": history.EndedByUserID,
+					"completed_bins":   history.CompletedBins,
+				},
+			})
+		case err == sql.ErrNoRows:
+			if shift.Status == models.ShiftStatusEnded && shift.EndTime != nil {
+				events = append(events, ShiftTimelineEvent{Timestamp: *shift.EndTime, Type: TimelineShiftEnded})
+			} else if shift.Status == models.ShiftStatusCancelled {
+				events = append(events, ShiftTimelineEvent{Timestamp: shift.UpdatedAt, Type: TimelineShiftCancelled})
+			}
+		default:
+			log.Printf("⚠️  [TIMELINE] Failed to fetch shift history for %s: %v", shiftID, err)
+		}
+
+		// Pauses
+		var pauses []struct {
+			PausedAt  int64   `db:"paused_at"`
+			ResumedAt *int64  `db:"resumed_at"`
+			Reason    *string `db:"reason"`
+			Note      *string `db:"note"`
+		}
+		if err := db.SelectContext(r.Context(), &pauses, `
+			SELECT paused_at, resumed_at, reason, note FROM shift_pauses WHERE shift_id = $1
+		`, shiftID); err != nil {
+			log.Printf("❌ [TIMELINE] Failed to fetch pauses for %s: %v", shiftID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch shift timeline")
+			return
+		}
+		for _, pause := range pauses {
+			paused := ShiftTimelineEvent{Timestamp: pause.PausedAt, Type: TimelineShiftPaused}
+			if pause.Reason != nil {
+				paused.Data = map[string]interface{}{"reason": *pause.Reason, "note": pause.Note}
+			}
+			events = append(events, paused)
+			if pause.ResumedAt != nil {
+				events = append(events, ShiftTimelineEvent{
+					Timestamp: *pause.ResumedAt,
+					Type:      TimelineShiftResumed,
+					Data:      map[string]interface{}{"pause_seconds": *pause.ResumedAt - pause.PausedAt},
+				})
+			}
+		}
+
+		// Stop completions
+		var tasks []struct {
+			ID                    string  `db:"id"`
+			SequenceOrder         int     `db:"sequence_order"`
+			TaskType              string  `db:"task_type"`
+			BinID                 *string `db:"bin_id"`
+			BinNumber             *int    `db:"bin_number"`
+			MoveRequestID         *string `db:"move_request_id"`
+			Latitude              float64 `db:"latitude"`
+			Longitude             float64 `db:"longitude"`
+			CompletedAt           int64   `db:"completed_at"`
+			Skipped               bool    `db:"skipped"`
+			UpdatedFillPercentage *int    `db:"updated_fill_percentage"`
+		}
+		err = db.SelectContext(r.Context(), &tasks, `
+			SELECT id, sequence_order, task_type, bin_id, bin_number, move_request_id, latitude, longitude,
+			       completed_at, skipped, updated_fill_percentage
+			FROM route_tasks
+			WHERE shift_id = $1 AND is_completed = 1 AND completed_at IS NOT NULL
+		`, shiftID)
+		if err != nil {
+			log.Printf("❌ [TIMELINE] Failed to fetch tasks for %s: %v", shiftID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch shift timeline")
+			return
+		}
+		for _, task := range tasks {
+			eventType := TimelineTaskCompleted
+			if task.Skipped {
+				eventType = TimelineTaskSkipped
+			}
+			lat, lng := task.Latitude, task.Longitude
+			events = append(events, ShiftTimelineEvent{
+				Timestamp: task.CompletedAt,
+				Type:      eventType,
+				Latitude:  &lat,
+				Longitude: &lng,
+				Data: map[string]interface{}{
+					"task_id":                 task.ID,
+					"task_type":               task.TaskType,
+					"sequence_order":          task.SequenceOrder,
+					"bin_id":                  task.BinID,
+					"bin_number":              task.BinNumber,
+					"move_request_id":         task.MoveRequestID,
+					"updated_fill_percentage": task.UpdatedFillPercentage,
+				},
+			})
+		}
+
+		// Incidents reported during the shift
+		var incidents []struct {
+			ID                 string   `db:"id"`
+			ZoneID             string   `db:"zone_id"`
+			BinID              string   `db:"bin_id"`
+			IncidentType       string   `db:"incident_type"`
+			ReportedAt         int64    `db:"reported_at"`
+			Description        *string  `db:"description"`
+			PhotoURL           *string  `db:"photo_url"`
+			IsFieldObservation bool     `db:"is_field_observation"`
+			ReporterLatitude   *float64 `db:"reporter_latitude"`
+			ReporterLongitude  *float64 `db:"reporter_longitude"`
+		}
+		if err := db.SelectContext(r.Context(), &incidents, `
+			SELECT id, zone_id, bin_id, incident_type, reported_at, description, photo_url,
+			       is_field_observation, reporter_latitude, reporter_longitude
+			FROM zone_incidents WHERE shift_id = $1
+		`, shiftID); err != nil {
+			log.Printf("❌ [TIMELINE] Failed to fetch incidents for %s: %v", shiftID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch shift timeline")
+			return
+		}
+		for _, incident := range incidents {
+			events = append(events, ShiftTimelineEvent{
+				Timestamp: incident.ReportedAt,
+				Type:      TimelineIncident,
+				Latitude:  incident.ReporterLatitude,
+				Longitude: incident.ReporterLongitude,
+				Data: map[string]interface{}{
+					"incident_id":          incident.ID,
+					"zone_id":              incident.ZoneID,
+					"bin_id":               incident.BinID,
+					"incident_type":        incident.IncidentType,
+					"description":          incident.Description,
+					"photo_url":            incident.PhotoURL,
+					"is_field_observation": incident.IsFieldObservation,
+				},
+			})
+		}
+
+		// Move requests assigned to or taken off the shift
+		var moveHistory []models.MoveRequestHistory
+		if err := db.SelectContext(r.Context(), &moveHistory, `
+			SELECT * FROM move_request_history
+			WHERE new_assigned_shift_id = $1 OR previous_assigned_shift_id = $1
+		`, shiftID); err != nil {
+			log.Printf("❌ [TIMELINE] Failed to fetch move history for %s: %v", shiftID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch shift timeline")
+			return
+		}
+		for _, entry := range moveHistory {
+			events = append(events, ShiftTimelineEvent{
+				Timestamp: entry.CreatedAt,
+				Type:      TimelineMoveRequest,
+				Data: map[string]interface{}{
+					"move_request_id": entry.MoveRequestID,
+					"action_type":     entry.ActionType,
+					"actor_name":      entry.ActorName,
+					"added":           entry.NewAssignedShiftID != nil && *entry.NewAssignedShiftID == shiftID,
+					"new_status":      entry.NewStatus,
+				},
+			})
+		}
+
+		// Location pings (client timestamps are milliseconds), one per granularity window
+		locationCount := 0
+		if includeLocations {
+			var pings []struct {
+				Latitude  float64  `db:"latitude"`
+				Longitude float64  `db:"longitude"`
+				Heading   *float64 `db:"heading"`
+				Speed     *float64 `db:"speed"`
+				Accuracy  *float64 `db:"accuracy"`
+				Timestamp int64    `db:"timestamp"`
+			}
+			query := `
+				SELECT latitude, longitude, heading, speed, accuracy, timestamp
+				FROM driver_locations
+				WHERE shift_id = $1
+				ORDER BY timestamp ASC
+			`
+			args := []interface{}{shiftID}
+			if granularity > 0 {
+				query = `
+					SELECT DISTINCT ON (timestamp / $2) latitude, longitude, heading, speed, accuracy, timestamp
+					FROM driver_locations
+					WHERE shift_id = $1
+					ORDER BY timestamp / $2, timestamp ASC
+				`
+				args = append(args, int64(granularity)*1000)
+			}
+			if err := db.SelectContext(r.Context(), &pings, query, args...); err != nil {
+				log.Printf("❌ [TIMELINE] Failed to fetch locations for %s: %v", shiftID, err)
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch shift timeline")
+				return
+			}
+			for _, ping := range pings {
+				lat, lng := ping.Latitude, ping.Longitude
+				events = append(events, ShiftTimelineEvent{
+					Timestamp: ping.Timestamp / 1000,
+					Type:      TimelineLocation,
+					Latitude:  &lat,
+					Longitude: &lng,
+					Data: map[string]interface{}{
+						"heading":  ping.Heading,
+						"speed":    ping.Speed,
+						"accuracy": ping.Accuracy,
+					},
+				})
+			}
+			locationCount = len(pings)
+		}
+
+		sort.SliceStable(events, func(i, j int) bool {
+			return events[i].Timestamp < events[j].Timestamp
+		})
+
+		log.Printf("✅ [TIMELINE] Shift %s: %d events (%d locations, granularity %ds)", shiftID, len(events), locationCount, granularity)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data": map[string]interface{}{
+				"shift_id":            shift.ID,
+				"driver_id":           shift.DriverID,
+				"status":              shift.Status,
+				"granularity_seconds": granularity,
+				"events":              events,
+			},
+		})
+	}
+}
@@ -1,25 +1,32 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"math"
 	"net/http"
 	"strings"
 	"time"
 
+	"ropacal-backend/internal/database"
 	"ropacal-backend/internal/helpers"
+	"ropacal-backend/internal/i18n"
 	"ropacal-backend/internal/middleware"
 	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/openapi"
 	"ropacal-backend/internal/services"
+	"ropacal-backend/internal/store"
 	"ropacal-backend/internal/websocket"
 	"ropacal-backend/pkg/utils"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 // haversineDistanceKm calculates the distance between two GPS coordinates in kilometers
@@ -42,14 +49,39 @@ func haversineDistanceKm(lat1, lon1, lat2, lon2 float64) float64 {
 	return earthRadius * c
 }
 
+// shiftPayloadFields are the fields ?fields= can select from the driver shift payloads
+var shiftPayloadFields = utils.FieldSet{
+	"id":                  nil,
+	"driver_id":           nil,
+	"route_id":            nil,
+	"status":              nil,
+	"start_time":          nil,
+	"end_time":            nil,
+	"total_pause_seconds": nil,
+	"pause_start_time":    nil,
+	"total_bins":          nil,
+	"completed_bins":      nil,
+	"bins":                utils.FieldsOf(models.ShiftBinWithDetails{}),
+	"pauses":              utils.FieldsOf(models.ShiftPauseSummary{}),
+	"created_at":          nil,
+	"updated_at":          nil,
+}
+
 // GetCurrentShift returns the current active shift for the driver
+// Supports ?fields= (e.g. id,status,bins.bin_number) to trim the payload
 func GetCurrentShift(db *sqlx.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		log.Printf("📥 REQUEST: GET /api/driver/shift/current")
 
 		userClaims, ok := middleware.GetUserFromContext(r)
 		if !ok {
-			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			utils.RespondError(w, http.StatusUnauthorized, i18n.Tr(r, "Unauthorized"))
+			return
+		}
+
+		fields, err := utils.ParseFields(r, shiftPayloadFields)
+		if err != nil {
+			utils.RespondFieldsError(w, err, shiftPayloadFields)
 			return
 		}
 
@@ -58,7 +90,7 @@ func GetCurrentShift(db *sqlx.DB) http.HandlerFunc {
 		// Check what shifts exist for this driver (for debugging)
 		var allShifts []models.Shift
 		debugQuery := `SELECT id, status, created_at FROM shifts WHERE driver_id = $1 ORDER BY created_at DESC LIMIT 3`
-		db.Select(&allShifts, debugQuery, userClaims.UserID)
+		db.SelectContext(r.Context(), &allShifts, debugQuery, userClaims.UserID)
 		log.Printf("   🔍 DEBUG: Found %d total shifts for this driver:", len(allShifts))
 		for i, s := range allShifts {
 			log.Printf("      %d. Shift ID: %s, Status: %s, Created: %v", i+1, s.ID, s.Status, s.CreatedAt)
@@ -77,7 +109,7 @@ func GetCurrentShift(db *sqlx.DB) http.HandlerFunc {
 			    created_at DESC
 				  LIMIT 1`
 
-		err := db.Get(&shift, query, userClaims.UserID)
+		err = db.GetContext(r.Context(), &shift, query, userClaims.UserID)
 		if err == sql.ErrNoRows {
 			log.Printf("📤 RESPONSE: 200 - No active shift found")
 			utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
@@ -88,7 +120,7 @@ func GetCurrentShift(db *sqlx.DB) http.HandlerFunc {
 		}
 		if err != nil {
 			log.Printf("❌ Error getting current shift: %v", err)
-			utils.RespondError(w, http.StatusInternalServerError, "Database error")
+			utils.RespondError(w, http.StatusInternalServerError, i18n.Tr(r, "Database error"))
 			return
 		}
 
@@ -96,7 +128,7 @@ func GetCurrentShift(db *sqlx.DB) http.HandlerFunc {
 		bins, err := getRouteBinsWithDetails(db, shift.ID)
 		if err != nil {
 			log.Printf("❌ Error fetching route bins: %v", err)
-			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch route bins")
+			utils.RespondError(w, http.StatusInternalServerError, i18n.Tr(r, "Failed to fetch route bins"))
 			return
 		}
 
@@ -108,7 +140,7 @@ func GetCurrentShift(db *sqlx.DB) http.HandlerFunc {
 
 		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
 			"success": true,
-			"data": map[string]interface{}{
+			"data": fields.Apply(map[string]interface{}{
 				"id":                  shift.ID,
 				"driver_id":           shift.DriverID,
 				"route_id":            shift.RouteID,
@@ -122,7 +154,7 @@ func GetCurrentShift(db *sqlx.DB) http.HandlerFunc {
 				"bins":                bins,
 				"created_at":          shift.CreatedAt,
 				"updated_at":          shift.UpdatedAt,
-			},
+			}),
 		})
 	}
 }
@@ -135,7 +167,7 @@ func GetShiftByID(db *sqlx.DB) http.HandlerFunc {
 
 		userClaims, ok := middleware.GetUserFromContext(r)
 		if !ok {
-			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			utils.RespondError(w, http.StatusUnauthorized, i18n.Tr(r, "Unauthorized"))
 			return
 		}
 
@@ -144,15 +176,15 @@ func GetShiftByID(db *sqlx.DB) http.HandlerFunc {
 		var shift models.Shift
 		query := `SELECT * FROM shifts WHERE id = $1`
 
-		err := db.Get(&shift, query, shiftID)
+		err := db.GetContext(r.Context(), &shift, query, shiftID)
 		if err == sql.ErrNoRows {
 			log.Printf("📤 RESPONSE: 404 - Shift not found")
-			utils.RespondError(w, http.StatusNotFound, "Shift not found")
+			utils.RespondError(w, http.StatusNotFound, i18n.Tr(r, "Shift not found"))
 			return
 		}
 		if err != nil {
 			log.Printf("❌ Error getting shift: %v", err)
-			utils.RespondError(w, http.StatusInternalServerError, "Database error")
+			utils.RespondError(w, http.StatusInternalServerError, i18n.Tr(r, "Database error"))
 			return
 		}
 
@@ -160,10 +192,15 @@ func GetShiftByID(db *sqlx.DB) http.HandlerFunc {
 		bins, err := getRouteBinsWithDetails(db, shift.ID)
 		if err != nil {
 			log.Printf("❌ Error fetching route tasks: %v", err)
-			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch route tasks")
+			utils.RespondError(w, http.StatusInternalServerError, i18n.Tr(r, "Failed to fetch route tasks"))
 			return
 		}
 
+		pauses, err := loadShiftPauses(r.Context(), db, shift.ID)
+		if err != nil {
+			log.Printf("❌ Error fetching pauses: %v", err)
+		}
+
 		log.Printf("📤 RESPONSE: 200 OK")
 		log.Printf("   Shift ID: %s", shift.ID)
 		log.Printf("   Status: %s", shift.Status)
@@ -183,6 +220,7 @@ func GetShiftByID(db *sqlx.DB) http.HandlerFunc {
 				"total_bins":          shift.TotalBins,
 				"completed_bins":      shift.CompletedBins,
 				"bins":                bins,
+				"pauses":              pauses,
 				"created_at":          shift.CreatedAt,
 				"updated_at":          shift.UpdatedAt,
 			},
@@ -191,13 +229,14 @@ func GetShiftByID(db *sqlx.DB) http.HandlerFunc {
 }
 
 // StartShift starts an assigned shift
-func StartShift(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
+// Custom routes are sequenced by hereService (HERE Maps); with nil they go straight to the in-house optimizer
+func StartShift(db *sqlx.DB, hub *websocket.Hub, hereService *services.HEREWaypointsService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		log.Printf("📥 REQUEST: POST /api/driver/shift/start")
 
 		userClaims, ok := middleware.GetUserFromContext(r)
 		if !ok {
-			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			utils.RespondError(w, http.StatusUnauthorized, i18n.Tr(r, "Unauthorized"))
 			return
 		}
 
@@ -210,7 +249,7 @@ func StartShift(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 					  AND (status = 'active' OR status = 'paused')
 					  LIMIT 1`
 
-		existingErr := db.Get(&existingShift, existingQuery, userClaims.UserID)
+		existingErr := db.GetContext(r.Context(), &existingShift, existingQuery, userClaims.UserID)
 		if existingErr == nil {
 			// Found an existing active/paused shift - auto-end it
 			log.Printf("⚠️  Found existing %s shift (%s), auto-ending it before starting new shift", existingShift.Status, existingShift.ID)
@@ -233,14 +272,22 @@ func StartShift(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 				endReason = "completed"
 			}
 
+			// Credit the stops completed before the auto-end
+			earnings, earningsBreakdown, earnErr := calculateShiftEarnings(db, existingShift)
+			if earnErr != nil {
+				log.Printf("⚠️  Failed to calculate earnings for auto-ended shift: %v", earnErr)
+			}
+
 			// Insert into shift_history
 			historyQuery := `INSERT INTO shift_history (
 			id, driver_id, route_id, start_time, end_time, created_at, ended_at,
 			total_pause_seconds, total_bins, completed_bins, completion_rate,
-			end_reason, ended_by_user_id, end_reason_metadata
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`
+			incidents_reported, field_observations,
+			end_reason, ended_by_user_id, end_reason_metadata,
+			earned_credits, earnings_breakdown
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)`
 
-			_, histErr := db.Exec(
+			_, histErr := db.ExecContext(r.Context(),
 				historyQuery,
 				existingShift.ID,
 				existingShift.DriverID,
@@ -253,9 +300,13 @@ func StartShift(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 				existingShift.TotalBins,
 				existingShift.CompletedBins,
 				completionRate,
+				existingShift.IncidentsReported,
+				existingShift.FieldObservations,
 				endReason,
 				nil, // Driver action
 				nil, // No metadata
+				earnings.Total,
+				earningsBreakdown,
 			)
 			if histErr != nil {
 				log.Printf("❌ Error saving auto-ended shift to history: %v", histErr)
@@ -270,308 +321,308 @@ func StartShift(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 						 updated_at = $3
 					 WHERE id = $4`
 
-			_, err := db.Exec(endQuery, endNow, totalPause, endNow, existingShift.ID)
+			_, err := db.ExecContext(r.Context(), endQuery, endNow, totalPause, endNow, existingShift.ID)
 			if err != nil {
 				log.Printf("❌ Error auto-ending existing shift: %v", err)
 				// Don't fail - continue with starting new shift
 			} else {
 				log.Printf("✅ Auto-ended existing shift %s (saved to history)", existingShift.ID)
+				if histErr == nil && existingShift.StartTime != nil {
+					recordShiftEfficiency(db, existingShift, endNow-*existingShift.StartTime-totalPause)
+				}
+				emitShiftWebhook(db, models.WebhookEventShiftEnded, existingShift, map[string]interface{}{
+					"status":              models.ShiftStatusEnded,
+					"end_time":            endNow,
+					"total_pause_seconds": totalPause,
+					"end_reason":          endReason,
+					"completion_rate":     completionRate,
+				})
 			}
 		}
 
-		// Check if driver has a ready shift
+		// Check if driver has a ready shift (a manager starting it on the driver's behalf names the shift)
+		onBehalf, isOnBehalf := onBehalfOptions(r)
 		var shift models.Shift
 		query := `SELECT * FROM shifts
 				  WHERE driver_id = $1
 				  AND status = 'ready'
+				  ORDER BY id = $2 DESC
 				  LIMIT 1`
 
-		err := db.Get(&shift, query, userClaims.UserID)
+		err := db.GetContext(r.Context(), &shift, query, userClaims.UserID, chi.URLParam(r, "id"))
 		if err == sql.ErrNoRows {
 			log.Printf("📤 RESPONSE: 400 - No route assigned")
-			utils.RespondError(w, http.StatusBadRequest, "No route assigned. Contact your manager.")
+			utils.RespondError(w, http.StatusBadRequest, i18n.Tr(r, "No route assigned. Contact your manager."))
 			return
 		}
 		if err != nil {
 			log.Printf("❌ Error getting shift: %v", err)
-			utils.RespondError(w, http.StatusInternalServerError, "Database error")
+			utils.RespondError(w, http.StatusInternalServerError, i18n.Tr(r, "Database error"))
 			return
 		}
 
-
-	// Check if shift has any bins in shift_bins table (for backward compatibility)
-	// Shifts with only route_tasks (move requests, placements, etc.) won't have shift_bins entries
-	var hasShiftBins bool
-	err = db.Get(&hasShiftBins,
-		`SELECT EXISTS(SELECT 1 FROM shift_bins WHERE shift_id = $1)`,
-		shift.ID,
-	)
-	if err != nil {
-		log.Printf("❌ Error checking for shift_bins: %v", err)
-		hasShiftBins = false
-	}
-
-	if hasShiftBins {
-		// If shift needs optimization (sequence_order = 0), do it now using driver's current location
-		// Check if any bin has sequence_order = 0 (unoptimized)
-		var needsFullOptimization bool
-		err = db.Get(&needsFullOptimization,
-			`SELECT EXISTS(SELECT 1 FROM shift_bins WHERE shift_id = $1 AND sequence_order = 0)`,
-			shift.ID,
-		)
-		if err != nil {
-			log.Printf("❌ Error checking if shift needs optimization: %v", err)
-			needsFullOptimization = false // Default to false on error
-		}
-
-		// Get driver's CURRENT location (needed for both optimization types)
-		var driverLocation struct {
-			Latitude  float64 `db:"latitude"`
-			Longitude float64 `db:"longitude"`
-		}
-
-		locationErr := db.Get(&driverLocation,
-			`SELECT latitude, longitude FROM driver_current_location
-				 WHERE driver_id = $1 AND is_connected = true`,
-			userClaims.UserID,
-		)
-
-		if locationErr != nil {
-			log.Printf("❌ Driver location not available: %v", locationErr)
-			utils.RespondError(w, http.StatusBadRequest, "Please enable GPS to start shift")
+		// The vehicle inspection must be submitted before the shift can go active
+		if pending, err := preStartChecklistPending(db, shift.ID); err != nil {
+			log.Printf("⚠️  %v", err)
+		} else if pending && isOnBehalf && onBehalf.SkipChecklist {
+			log.Printf("⚠️  Pre-start checklist for shift %s skipped by %s", shift.ID, userClaims.OnBehalfManagerEmail)
+		} else if pending {
+			log.Printf("📤 RESPONSE: 428 - Pre-start checklist not submitted for shift %s", shift.ID)
+			utils.RespondErrorCode(w, http.StatusPreconditionRequired, utils.CodeChecklistRequired,
+				i18n.Tr(r, "Complete the pre-start checklist before starting your shift"), nil)
 			return
 		}
 
-		log.Printf("✅ Got driver location: (%.6f, %.6f)", driverLocation.Latitude, driverLocation.Longitude)
-
-		if needsFullOptimization {
-			// Case 1: Custom bin selection - Full HERE Maps optimization from driver's location
-			log.Printf("🔄 Custom route - performing HERE Maps route optimization from driver location")
-
-			// Fetch all bins assigned to this shift
-			var binDetails []struct {
-				ID             string  `db:"id"`
-				CurrentStreet  string  `db:"current_street"`
-				Latitude       float64 `db:"latitude"`
-				Longitude      float64 `db:"longitude"`
-				FillPercentage int     `db:"fill_percentage"`
-			}
-
-			binQuery := `
-				SELECT b.id, b.current_street, b.latitude, b.longitude, COALESCE(b.fill_percentage, 0) as fill_percentage
-				FROM bins b
-				JOIN shift_bins sb ON b.id = sb.bin_id
-				WHERE sb.shift_id = $1
-			`
-			err = db.Select(&binDetails, binQuery, shift.ID)
+		// Route-assigned shifts get their collection stops optimized (sequence_order = 0) or rotated
+		// from the driver's location; task-based shifts keep the order they were planned with
+		shiftStops := store.NewShiftStore(db)
+		var collectionStops []models.ShiftBinWithDetails
+		needsFullOptimization := false
+		sequenceOffset := 0 // Optimized collections go after stops already sequenced (moves inserted before the start)
+		if shift.RouteID != nil {
+			stops, err := shiftStops.Stops(shift.ID)
 			if err != nil {
-				log.Printf("❌ Error fetching bins: %v", err)
-				utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch bins")
+				log.Printf("❌ Error fetching shift stops: %v", err)
+				utils.RespondError(w, http.StatusInternalServerError, i18n.Tr(r, "Failed to fetch bins"))
 				return
 			}
+			for _, stop := range stops {
+				if stop.StopType == string(models.TaskTypeCollection) && stop.TaskID != nil && stop.IsCompleted == 0 {
+					collectionStops = append(collectionStops, stop)
+					if stop.SequenceOrder == 0 {
+						needsFullOptimization = true
+					}
+				} else if stop.SequenceOrder > sequenceOffset {
+					sequenceOffset = stop.SequenceOrder
+				}
+			}
+		}
 
-			log.Printf("📦 Fetched %d bins for HERE Maps optimization", len(binDetails))
+		if len(collectionStops) > 0 {
+			// Get driver's CURRENT location (needed for both optimization types)
+			var driverLocation struct {
+				Latitude  float64 `db:"latitude"`
+				Longitude float64 `db:"longitude"`
+			}
 
-			// Call HERE Maps Waypoints Sequence API
-			hereService := services.NewHEREWaypointsService(HereAPIKey)
+			locationErr := db.GetContext(r.Context(), &driverLocation,
+				`SELECT latitude, longitude FROM driver_current_location
+				 WHERE driver_id = $1 AND is_connected = true`,
+				userClaims.UserID,
+			)
 
-			// Convert bins to waypoint format
-			waypoints := make([]services.HEREWaypoint, len(binDetails))
-			for i, bin := range binDetails {
-				waypoints[i] = services.HEREWaypoint{
-					ID:        bin.ID,
-					Name:      bin.CurrentStreet,
-					Latitude:  bin.Latitude,
-					Longitude: bin.Longitude,
+			// Started on the driver's behalf, their app may not be reporting: use where the manager says the
+			// driver is, or the driver's last known location
+			if locationErr != nil && isOnBehalf {
+				if onBehalf.Latitude != nil {
+					driverLocation.Latitude, driverLocation.Longitude = *onBehalf.Latitude, *onBehalf.Longitude
+					locationErr = nil
+				} else {
+					locationErr = db.GetContext(r.Context(), &driverLocation,
+						`SELECT latitude, longitude FROM driver_current_location WHERE driver_id = $1`, userClaims.UserID)
+				}
+				if locationErr != nil {
+					log.Printf("❌ Driver location not available: %v", locationErr)
+					utils.RespondError(w, http.StatusBadRequest, "The driver's location is unknown: send latitude and longitude")
+					return
 				}
 			}
 
-			// Get warehouse location (end point)
-			warehouseLoc := services.GetWarehouseLocation()
+			if locationErr != nil {
+				log.Printf("❌ Driver location not available: %v", locationErr)
+				utils.RespondError(w, http.StatusBadRequest, i18n.Tr(r, "Please enable GPS to start shift"))
+				return
+			}
 
-			// Optimize route with current time for real-time traffic
-			departureTime := time.Now().Format(time.RFC3339)
-			optimizationResult, err := hereService.OptimizeWaypoints(
-				driverLocation.Latitude,
-				driverLocation.Longitude,
-				warehouseLoc.Latitude,
-				warehouseLoc.Longitude,
-				waypoints,
-				departureTime,
-			)
+			log.Printf("✅ Got driver location: (%.6f, %.6f)", driverLocation.Latitude, driverLocation.Longitude)
 
-			if err != nil {
-				log.Printf("❌ HERE Maps optimization failed: %v", err)
-				log.Printf("⚠️  Falling back to simple nearest-neighbor optimization")
+			if needsFullOptimization {
+				// Case 1: Custom bin selection - Full HERE Maps optimization from driver's location
+				log.Printf("🔄 Custom route - performing HERE Maps route optimization from driver location")
 
-				// Fallback to simple TSP optimization
-				binsToOptimize := make([]services.BinWithPriority, len(binDetails))
+				// Optimize the shift's collection stops (keyed by task ID)
+				binDetails := collectionStops
+
+				log.Printf("📦 Fetched %d bins for HERE Maps optimization", len(binDetails))
+
+				// Convert bins to waypoint format
+				waypoints := make([]services.HEREWaypoint, len(binDetails))
 				for i, bin := range binDetails {
-					binsToOptimize[i] = services.BinWithPriority{
-						ID:             bin.ID,
-						Latitude:       bin.Latitude,
-						Longitude:      bin.Longitude,
-						FillPercentage: bin.FillPercentage,
-						CurrentStreet:  bin.CurrentStreet,
+					waypoints[i] = services.HEREWaypoint{
+						ID:        *bin.TaskID,
+						Name:      bin.CurrentStreet,
+						Latitude:  bin.Latitude,
+						Longitude: bin.Longitude,
 					}
 				}
 
-				optimizer := services.NewRouteOptimizer()
-				startLocation := services.OptimizerLocation{
-					Latitude:  driverLocation.Latitude,
-					Longitude: driverLocation.Longitude,
-				}
-				optimizedBins := optimizer.OptimizeRoute(binsToOptimize, startLocation)
-
-				// Update shift_bins with optimized sequence_order
-				for i, bin := range optimizedBins {
-					updateQuery := `UPDATE shift_bins
-									SET sequence_order = $1
-									WHERE shift_id = $2 AND bin_id = $3`
+				// Get warehouse location (end point)
+				warehouseLoc := services.GetWarehouseLocation()
 
-					_, err = db.Exec(updateQuery, i+1, shift.ID, bin.ID)
-					if err != nil {
-						log.Printf("❌ Error updating bin sequence: %v", err)
-						utils.RespondError(w, http.StatusInternalServerError, "Failed to optimize route")
-						return
-					}
+				// Stops inside no-go zones are weighted to be visited earlier
+				binIDs := make([]string, len(binDetails))
+				for i, bin := range binDetails {
+					binIDs[i] = bin.BinID
+				}
+				risks := stopRisks(db, binIDs)
+
+				// Optimize route with current time for real-time traffic
+				// HERE doesn't know the stops' service windows or zone risk, so those routes go to the in-house optimizer
+				var optimizationResult *services.HEREOptimizationResult
+				var err error
+				if hereService == nil {
+					err = fmt.Errorf("HERE Maps optimization is disabled")
+				} else if stopsHaveTimeWindows(binDetails) {
+					err = fmt.Errorf("stops have time windows")
+				} else if hasRiskWeights(risks) {
+					err = fmt.Errorf("stops are inside no-go zones")
+				} else {
+					optimizationResult, err = hereService.OptimizeWaypoints(
+						driverLocation.Latitude,
+						driverLocation.Longitude,
+						warehouseLoc.Latitude,
+						warehouseLoc.Longitude,
+						waypoints,
+						time.Now().Format(time.RFC3339),
+					)
 				}
 
-				log.Printf("✅ Fallback optimization complete with %d bins", len(optimizedBins))
-			} else {
-				log.Printf("🎯 HERE Maps optimization successful! Order: %v", optimizationResult.OptimizedOrder)
+				if err != nil {
+					log.Printf("❌ HERE Maps optimization skipped or failed: %v", err)
+					log.Printf("⚠️  Falling back to simple nearest-neighbor optimization")
+
+					// Fallback to simple TSP optimization
+					binsToOptimize := make([]services.BinWithPriority, len(binDetails))
+					for i, bin := range binDetails {
+						binsToOptimize[i] = services.BinWithPriority{
+							ID:             *bin.TaskID,
+							Latitude:       bin.Latitude,
+							Longitude:      bin.Longitude,
+							FillPercentage: bin.FillPercentage,
+							CurrentStreet:  bin.CurrentStreet,
+							TimeWindow:     bin.TimeWindow(),
+							RiskWeight:     risks[bin.BinID].Weight,
+						}
+					}
 
-				// Update shift_bins with HERE Maps optimized sequence_order
-				for i, waypointID := range optimizationResult.OptimizedOrder {
-					updateQuery := `UPDATE shift_bins
-									SET sequence_order = $1
-									WHERE shift_id = $2 AND bin_id = $3`
+					optimizer := services.NewRouteOptimizer()
+					startLocation := services.OptimizerLocation{
+						Latitude:  driverLocation.Latitude,
+						Longitude: driverLocation.Longitude,
+					}
+					optimizedBins := optimizer.OptimizeRoute(binsToOptimize, startLocation)
+
+					// Save optimized sequence_order
+					for i, bin := range optimizedBins {
+						err = shiftStops.SetSequence(bin.ID, sequenceOffset+i+1)
+						if err != nil {
+							log.Printf("❌ Error updating bin sequence: %v", err)
+							utils.RespondError(w, http.StatusInternalServerError, i18n.Tr(r, "Failed to optimize route"))
+							return
+						}
+					}
 
-					_, err = db.Exec(updateQuery, i+1, shift.ID, waypointID)
-					if err != nil {
-						log.Printf("❌ Error updating bin sequence: %v", err)
-						utils.RespondError(w, http.StatusInternalServerError, "Failed to save optimized route")
-						return
+					log.Printf("✅ Fallback optimization complete with %d bins", len(optimizedBins))
+				} else {
+					log.Printf("🎯 HERE Maps optimization successful! Order: %v", optimizationResult.OptimizedOrder)
+
+					// Save HERE Maps optimized sequence_order
+					for i, waypointID := range optimizationResult.OptimizedOrder {
+						err = shiftStops.SetSequence(waypointID, sequenceOffset+i+1)
+						if err != nil {
+							log.Printf("❌ Error updating bin sequence: %v", err)
+							utils.RespondError(w, http.StatusInternalServerError, i18n.Tr(r, "Failed to save optimized route"))
+							return
+						}
 					}
-				}
 
-				// Save optimization metadata to shifts table
-				optimizationMetadata := models.OptimizationMetadata{
-					TotalDistanceKm:      optimizationResult.TotalDistanceKm,
-					TotalDurationSeconds: optimizationResult.TotalDurationSeconds,
-					OptimizedAt:          time.Now().Format(time.RFC3339),
-					EstimatedCompletion:  time.Now().Add(time.Duration(optimizationResult.TotalDurationSeconds) * time.Second).Format(time.RFC3339),
-				}
+					// Save optimization metadata to shifts table
+					optimizationMetadata := models.OptimizationMetadata{
+						TotalDistanceKm:      optimizationResult.TotalDistanceKm,
+						TotalDurationSeconds: optimizationResult.TotalDurationSeconds,
+						OptimizedAt:          time.Now().Format(time.RFC3339),
+						EstimatedCompletion:  time.Now().Add(time.Duration(optimizationResult.TotalDurationSeconds) * time.Second).Format(time.RFC3339),
+					}
 
-				metadataJSON, err := json.Marshal(optimizationMetadata)
-				if err != nil {
-					log.Printf("⚠️  Error marshaling optimization metadata: %v", err)
-					// Continue anyway - this is not critical
-				} else {
-					updateMetadataQuery := `UPDATE shifts SET optimization_metadata = $1, updated_at = $2 WHERE id = $3`
-					_, err = db.Exec(updateMetadataQuery, metadataJSON, time.Now().Unix(), shift.ID)
+					metadataJSON, err := json.Marshal(optimizationMetadata)
 					if err != nil {
-						log.Printf("⚠️  Error saving optimization metadata: %v", err)
+						log.Printf("⚠️  Error marshaling optimization metadata: %v", err)
 						// Continue anyway - this is not critical
 					} else {
-						log.Printf("✅ Saved optimization metadata: %.2f km, %.0f min",
-							optimizationMetadata.TotalDistanceKm,
-							float64(optimizationMetadata.TotalDurationSeconds)/60.0)
+						updateMetadataQuery := `UPDATE shifts SET optimization_metadata = $1, updated_at = $2 WHERE id = $3`
+						_, err = db.ExecContext(r.Context(), updateMetadataQuery, metadataJSON, time.Now().Unix(), shift.ID)
+						if err != nil {
+							log.Printf("⚠️  Error saving optimization metadata: %v", err)
+							// Continue anyway - this is not critical
+						} else {
+							log.Printf("✅ Saved optimization metadata: %.2f km, %.0f min",
+								optimizationMetadata.TotalDistanceKm,
+								float64(optimizationMetadata.TotalDurationSeconds)/60.0)
+						}
 					}
+
+					log.Printf("✅ HERE Maps optimization complete with %d bins", len(optimizationResult.OptimizedOrder))
 				}
+			} else {
+				// Case 2: Pre-defined route - Rotate sequence to start from closest bin
+				log.Printf("🔄 Pre-defined route - rotating sequence to start from closest bin")
 
-				log.Printf("✅ HERE Maps optimization complete with %d bins", len(optimizationResult.OptimizedOrder))
-			}
-		} else {
-			// Case 2: Pre-defined route - Rotate sequence to start from closest bin
-			log.Printf("🔄 Pre-defined route - rotating sequence to start from closest bin")
-
-			// Fetch bins with their current sequence order
-			var binDetails []struct {
-				ID            string  `db:"id"`
-				CurrentStreet string  `db:"current_street"`
-				Latitude      float64 `db:"latitude"`
-				Longitude     float64 `db:"longitude"`
-				SequenceOrder int     `db:"sequence_order"`
-			}
-
-			binQuery := `
-			SELECT b.id, b.current_street, b.latitude, b.longitude, sb.sequence_order
-			FROM bins b
-			JOIN shift_bins sb ON b.id = sb.bin_id
-			WHERE sb.shift_id = $1
-			ORDER BY sb.sequence_order
-		`
-			err = db.Select(&binDetails, binQuery, shift.ID)
-			if err != nil {
-				log.Printf("❌ Error fetching bins: %v", err)
-				utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch bins")
-				return
-			}
+				// Collection stops in their current sequence order
+				binDetails := collectionStops
 
-			log.Printf("📦 Fetched %d bins from pre-defined route", len(binDetails))
+				log.Printf("📦 Fetched %d bins from pre-defined route", len(binDetails))
 
-			// Find closest bin to driver's current location using Haversine distance
-			closestIdx := 0
-			minDistance := math.MaxFloat64
+				// Find closest bin to driver's current location using Haversine distance
+				closestIdx := 0
+				minDistance := math.MaxFloat64
 
-			for i, bin := range binDetails {
-				distance := haversineDistance(
-					driverLocation.Latitude, driverLocation.Longitude,
-					bin.Latitude, bin.Longitude,
-				)
-				if distance < minDistance {
-					minDistance = distance
-					closestIdx = i
+				for i, bin := range binDetails {
+					distance := haversineDistance(
+						driverLocation.Latitude, driverLocation.Longitude,
+						bin.Latitude, bin.Longitude,
+					)
+					if distance < minDistance {
+						minDistance = distance
+						closestIdx = i
+					}
 				}
-			}
-
-			log.Printf("🎯 Closest bin to driver: %s (%.2f km away)", binDetails[closestIdx].CurrentStreet, minDistance)
 
-			// Rotate the sequence to start from closest bin
-			// Example: [A, B, C, D, E] with closest = C becomes [C, D, E, A, B]
-			rotatedBins := make([]struct {
-				ID            string  `db:"id"`
-				CurrentStreet string  `db:"current_street"`
-				Latitude      float64 `db:"latitude"`
-				Longitude     float64 `db:"longitude"`
-				SequenceOrder int     `db:"sequence_order"`
-			}, len(binDetails))
+				log.Printf("🎯 Closest bin to driver: %s (%.2f km away)", binDetails[closestIdx].CurrentStreet, minDistance)
 
-			for i := 0; i < len(binDetails); i++ {
-				srcIdx := (closestIdx + i) % len(binDetails)
-				rotatedBins[i] = binDetails[srcIdx]
-			}
+				// Rotate the sequence to start from closest bin
+				// Example: [A, B, C, D, E] with closest = C becomes [C, D, E, A, B]
+				rotatedBins := make([]models.ShiftBinWithDetails, len(binDetails))
 
-			log.Printf("🔄 Rotated order: %v", func() []string {
-				streets := make([]string, len(rotatedBins))
-				for i, b := range rotatedBins {
-					streets[i] = b.CurrentStreet
+				for i := 0; i < len(binDetails); i++ {
+					srcIdx := (closestIdx + i) % len(binDetails)
+					rotatedBins[i] = binDetails[srcIdx]
 				}
-				return streets
-			}())
 
-			// Update shift_bins with rotated sequence_order
-			for i, bin := range rotatedBins {
-				updateQuery := `UPDATE shift_bins
-							SET sequence_order = $1
-							WHERE shift_id = $2 AND bin_id = $3`
+				log.Printf("🔄 Rotated order: %v", func() []string {
+					streets := make([]string, len(rotatedBins))
+					for i, b := range rotatedBins {
+						streets[i] = b.CurrentStreet
+					}
+					return streets
+				}())
 
-				_, err = db.Exec(updateQuery, i+1, shift.ID, bin.ID)
-				if err != nil {
-					log.Printf("❌ Error updating bin sequence: %v", err)
-					utils.RespondError(w, http.StatusInternalServerError, "Failed to rotate route")
-					return
+				// Save rotated sequence_order, reusing the slots the collections occupied so other stops keep theirs
+				for i, bin := range rotatedBins {
+					err = shiftStops.SetSequence(*bin.TaskID, binDetails[i].SequenceOrder)
+					if err != nil {
+						log.Printf("❌ Error updating bin sequence: %v", err)
+						utils.RespondError(w, http.StatusInternalServerError, i18n.Tr(r, "Failed to rotate route"))
+						return
+					}
 				}
-			}
 
-			log.Printf("✅ Route rotation complete with %d bins", len(rotatedBins))
+				log.Printf("✅ Route rotation complete with %d bins", len(rotatedBins))
 
-		}
+			}
 
-	} else {
-		log.Printf("ℹ️  Shift has no collection bins, skipping optimization")
-	}
+		} else {
+			log.Printf("ℹ️  Shift has no collection bins, skipping optimization")
+		}
 		// Update shift to active
 		now := time.Now().Unix()
 		updateQuery := `UPDATE shifts
@@ -580,19 +631,19 @@ func StartShift(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 							updated_at = $2
 						WHERE id = $3`
 
-		_, err = db.Exec(updateQuery, now, now, shift.ID)
+		_, err = db.ExecContext(r.Context(), updateQuery, now, now, shift.ID)
 		if err != nil {
 			log.Printf("❌ Error starting shift: %v", err)
-			utils.RespondError(w, http.StatusInternalServerError, "Failed to start shift")
+			utils.RespondError(w, http.StatusInternalServerError, i18n.Tr(r, "Failed to start shift"))
 			return
 		}
 
 		// Update all assigned move requests for this shift to in_progress
 		updateMovesQuery := `UPDATE bin_move_requests
-							 SET status = 'in_progress', updated_at = $1
+							 SET status = $3, updated_at = $1
 							 WHERE assigned_shift_id = $2
-							 AND status = 'assigned'`
-		result, err := db.Exec(updateMovesQuery, now, shift.ID)
+							 AND status = $4`
+		result, err := db.ExecContext(r.Context(), updateMovesQuery, now, shift.ID, models.MoveStatusInProgress, models.MoveStatusAssigned)
 		if err != nil {
 			log.Printf("⚠️ Error updating move requests to in_progress: %v", err)
 			// Don't fail the request - continue
@@ -605,19 +656,19 @@ func StartShift(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 				hub.BroadcastToRole("admin", map[string]interface{}{
 					"type": "move_request_status_updated",
 					"data": map[string]interface{}{
-						"shift_id":    shift.ID,
-						"new_status":  "in_progress",
-						"count":       rowsAffected,
-						"updated_at":  now,
+						"shift_id":   shift.ID,
+						"new_status": "in_progress",
+						"count":      rowsAffected,
+						"updated_at": now,
 					},
 				})
 				hub.BroadcastToRole("manager", map[string]interface{}{
 					"type": "move_request_status_updated",
 					"data": map[string]interface{}{
-						"shift_id":    shift.ID,
-						"new_status":  "in_progress",
-						"count":       rowsAffected,
-						"updated_at":  now,
+						"shift_id":   shift.ID,
+						"new_status": "in_progress",
+						"count":      rowsAffected,
+						"updated_at": now,
 					},
 				})
 				log.Printf("📡 Broadcast move_request_status_updated to managers: %d move requests → in_progress", rowsAffected)
@@ -625,7 +676,7 @@ func StartShift(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 		}
 
 		// Get updated shift
-		db.Get(&shift, `SELECT * FROM shifts WHERE id = $1`, shift.ID)
+		db.GetContext(r.Context(), &shift, `SELECT * FROM shifts WHERE id = $1`, shift.ID)
 
 		// Get route bins with details for WebSocket broadcast
 		bins, err := getRouteBinsWithDetails(db, shift.ID)
@@ -678,6 +729,7 @@ func StartShift(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 		log.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 
 		log.Printf("✅ Shift started: %s (Driver: %s)", shift.ID, userClaims.Email)
+		emitShiftWebhook(db, models.WebhookEventShiftStarted, shift, nil)
 		log.Printf("📤 RESPONSE: 200 OK")
 		log.Printf("   Shift ID: %s", shift.ID)
 		log.Printf("   Status: %s", shift.Status)
@@ -698,36 +750,62 @@ func PauseShift(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 
 		userClaims, ok := middleware.GetUserFromContext(r)
 		if !ok {
-			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			utils.RespondError(w, http.StatusUnauthorized, i18n.Tr(r, "Unauthorized"))
 			return
 		}
 
 		log.Printf("   User: %s (%s)", userClaims.Email, userClaims.UserID)
 
+		var req models.PauseShiftRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, i18n.Tr(r, "Invalid request body"))
+			return
+		}
+		if !models.IsValidPauseReason(req.Reason) {
+			utils.RespondError(w, http.StatusBadRequest, i18n.Tr(r, "reason must be one of: %s", strings.Join(models.PauseReasons, ", ")))
+			return
+		}
+		var note *string
+		if req.Note != nil && strings.TrimSpace(*req.Note) != "" {
+			trimmed := strings.TrimSpace(*req.Note)
+			note = &trimmed
+		}
+		if req.Reason == models.PauseReasonOther && note == nil {
+			utils.RespondError(w, http.StatusBadRequest, i18n.Tr(r, "A note is required when the pause reason is other"))
+			return
+		}
+
 		now := time.Now().Unix()
 		query := `UPDATE shifts
 				  SET status = 'paused',
 					  pause_start_time = $1,
 					  updated_at = $2
-				  WHERE driver_id = $1
+				  WHERE driver_id = $3
 				  AND status = 'active'`
 
-		result, err := db.Exec(query, now, now, userClaims.UserID)
+		result, err := db.ExecContext(r.Context(), query, now, now, userClaims.UserID)
 		if err != nil {
 			log.Printf("❌ Error pausing shift: %v", err)
-			utils.RespondError(w, http.StatusInternalServerError, "Failed to pause shift")
+			utils.RespondError(w, http.StatusInternalServerError, i18n.Tr(r, "Failed to pause shift"))
 			return
 		}
 
 		rowsAffected, _ := result.RowsAffected()
 		if rowsAffected == 0 {
-			utils.RespondError(w, http.StatusBadRequest, "No active shift to pause")
+			utils.RespondError(w, http.StatusBadRequest, i18n.Tr(r, "No active shift to pause"))
 			return
 		}
 
 		// Get updated shift
 		var shift models.Shift
-		db.Get(&shift, `SELECT * FROM shifts WHERE driver_id = $1 AND status = 'paused'`, userClaims.UserID)
+		db.GetContext(r.Context(), &shift, `SELECT * FROM shifts WHERE driver_id = $1 AND status = 'paused'`, userClaims.UserID)
+
+		// Record the pause period for the shift timeline
+		if _, err := db.ExecContext(r.Context(), `
+			INSERT INTO shift_pauses (id, shift_id, paused_at, reason, note) VALUES ($1, $2, $3, $4, $5)
+		`, uuid.New().String(), shift.ID, now, req.Reason, note); err != nil {
+			log.Printf("⚠️  Failed to record pause for shift %s: %v", shift.ID, err)
+		}
 
 		// Broadcast WebSocket update to driver
 		hub.BroadcastToUser(userClaims.UserID, map[string]interface{}{
@@ -739,22 +817,27 @@ func PauseShift(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 		broadcastPayload := map[string]interface{}{
 			"type": "driver_shift_change",
 			"data": map[string]interface{}{
-				"driver_id": shift.DriverID,
-				"status":    shift.Status,
-				"shift_id":  shift.ID,
+				"driver_id":    shift.DriverID,
+				"status":       shift.Status,
+				"shift_id":     shift.ID,
+				"paused_at":    now,
+				"pause_reason": req.Reason,
+				"pause_note":   note,
 			},
 		}
 		hub.BroadcastToRole("admin", broadcastPayload)
 		hub.BroadcastToRole("manager", broadcastPayload)
 		log.Printf("📡 Broadcast driver_shift_change to managers: Driver paused shift")
 
-		log.Printf("⏸️  Shift paused: %s", shift.ID)
+		log.Printf("⏸️  Shift paused: %s (%s)", shift.ID, req.Reason)
 
 		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
 			"success": true,
 			"data": map[string]interface{}{
 				"status":           shift.Status,
 				"pause_start_time": shift.PauseStartTime,
+				"pause_reason":     req.Reason,
+				"pause_note":       note,
 			},
 		})
 	}
@@ -765,15 +848,15 @@ func ResumeShift(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userClaims, ok := middleware.GetUserFromContext(r)
 		if !ok {
-			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			utils.RespondError(w, http.StatusUnauthorized, i18n.Tr(r, "Unauthorized"))
 			return
 		}
 
 		// Get current shift
 		var shift models.Shift
-		err := db.Get(&shift, `SELECT * FROM shifts WHERE driver_id = $1 AND status = 'paused'`, userClaims.UserID)
+		err := db.GetContext(r.Context(), &shift, `SELECT * FROM shifts WHERE driver_id = $1 AND status = 'paused'`, userClaims.UserID)
 		if err != nil {
-			utils.RespondError(w, http.StatusBadRequest, "No paused shift to resume")
+			utils.RespondError(w, http.StatusBadRequest, i18n.Tr(r, "No paused shift to resume"))
 			return
 		}
 
@@ -793,15 +876,22 @@ func ResumeShift(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 					  updated_at = $2
 				  WHERE id = $3`
 
-		_, err = db.Exec(query, totalPause, now, shift.ID)
+		_, err = db.ExecContext(r.Context(), query, totalPause, now, shift.ID)
 		if err != nil {
 			log.Printf("❌ Error resuming shift: %v", err)
-			utils.RespondError(w, http.StatusInternalServerError, "Failed to resume shift")
+			utils.RespondError(w, http.StatusInternalServerError, i18n.Tr(r, "Failed to resume shift"))
 			return
 		}
 
+		// Close the open pause period
+		if _, err := db.ExecContext(r.Context(), `
+			UPDATE shift_pauses SET resumed_at = $1 WHERE shift_id = $2 AND resumed_at IS NULL
+		`, now, shift.ID); err != nil {
+			log.Printf("⚠️  Failed to record resume for shift %s: %v", shift.ID, err)
+		}
+
 		// Get updated shift
-		db.Get(&shift, `SELECT * FROM shifts WHERE id = $1`, shift.ID)
+		db.GetContext(r.Context(), &shift, `SELECT * FROM shifts WHERE id = $1`, shift.ID)
 
 		// Broadcast WebSocket update to driver
 		hub.BroadcastToUser(userClaims.UserID, map[string]interface{}{
@@ -835,11 +925,20 @@ func ResumeShift(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 }
 
 // EndShift ends the current shift
+// Body (optional): { "debrief": { "responses": { "vehicle_issues": false }, "notes": [{ "note": "Gate locked after 5pm", "bin_id": "..." }] } }
+// The debrief is required when the organization's shift debrief settings say so
 func EndShift(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userClaims, ok := middleware.GetUserFromContext(r)
 		if !ok {
-			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			utils.RespondError(w, http.StatusUnauthorized, i18n.Tr(r, "Unauthorized"))
+			return
+		}
+
+		// Older apps send no body
+		var req models.EndShiftRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			utils.RespondError(w, http.StatusBadRequest, i18n.Tr(r, "Invalid request body"))
 			return
 		}
 
@@ -850,11 +949,54 @@ func EndShift(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 				  AND (status = 'active' OR status = 'paused')
 				  LIMIT 1`
 
-		err := db.Get(&shift, query, userClaims.UserID)
+		err := db.GetContext(r.Context(), &shift, query, userClaims.UserID)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, i18n.Tr(r, "No active shift to end"))
+			return
+		}
+
+		// Check the debrief before anything is saved so the driver can fix it and retry
+		debriefSettings, err := database.GetDebriefSettings(db)
 		if err != nil {
-			utils.RespondError(w, http.StatusBadRequest, "No active shift to end")
+			log.Printf("❌ Error loading shift debrief settings: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, i18n.Tr(r, "Failed to load shift debrief settings"))
+			return
+		}
+		if req.Debrief == nil && debriefSettings.Required {
+			log.Printf("📤 RESPONSE: 428 - Debrief not submitted for shift %s", shift.ID)
+			utils.RespondErrorCode(w, http.StatusPreconditionRequired, utils.CodeDebriefRequired,
+				i18n.Tr(r, "Complete the shift debrief before ending your shift"), nil)
 			return
 		}
+		var debriefResponses map[string]interface{}
+		if req.Debrief != nil {
+			if err := req.Debrief.Normalize(); err != nil {
+				utils.RespondError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			var debriefErrors []models.CheckFormFieldError
+			debriefResponses, debriefErrors = debriefSettings.ValidateResponses(req.Debrief.Responses)
+			if len(debriefErrors) > 0 {
+				fieldErrors := make([]openapi.FieldError, len(debriefErrors))
+				for i, e := range debriefErrors {
+					fieldErrors[i] = openapi.FieldError{Field: "debrief.responses." + e.Key, Message: e.Message}
+				}
+				utils.RespondErrorCode(w, http.StatusBadRequest, utils.CodeValidationFailed,
+					i18n.Tr(r, "Shift debrief answers are invalid"), fieldErrors)
+				return
+			}
+			missing, err := missingNoteBins(r.Context(), db, req.Debrief.Notes)
+			if err != nil {
+				log.Printf("❌ Error checking debrief note bins: %v", err)
+				utils.RespondError(w, http.StatusInternalServerError, i18n.Tr(r, "Failed to end shift"))
+				return
+			}
+			if len(missing) > 0 {
+				utils.RespondErrorCode(w, http.StatusBadRequest, utils.CodeInvalidReference,
+					i18n.Tr(r, "A note refers to a bin that doesn't exist"), map[string]interface{}{"bin_ids": missing})
+				return
+			}
+		}
 
 		// Calculate durations
 		now := time.Now().Unix()
@@ -879,40 +1021,29 @@ func EndShift(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 			completionRate = (float64(shift.CompletedBins) / float64(shift.TotalBins)) * 100
 		}
 
-		// Count incidents reported during this shift
-		var incidentStats struct {
-			TotalIncidents    int `db:"total_incidents"`
-			FieldObservations int `db:"field_observations"`
-		}
-		err = db.Get(&incidentStats, `
-			SELECT
-				COUNT(*) as total_incidents,
-				COUNT(*) FILTER (WHERE is_field_observation = true) as field_observations
-			FROM zone_incidents
-			WHERE shift_id = $1
-		`, shift.ID)
-		if err != nil {
-			log.Printf("⚠️  Warning: Failed to count incidents for shift: %v", err)
-			// Continue anyway - this is not critical
-			incidentStats.TotalIncidents = 0
-			incidentStats.FieldObservations = 0
-		}
-
 		// Determine end reason
 		endReason := "manual_end" // Default: driver ended shift manually
 		if shift.CompletedBins >= shift.TotalBins {
 			endReason = "completed" // All bins completed
 		}
 
+		// Calculate driver earnings for the completed stops
+		earnings, earningsBreakdown, err := calculateShiftEarnings(db, shift)
+		if err != nil {
+			log.Printf("⚠️  Warning: Failed to calculate earnings for shift: %v", err)
+			// Continue anyway - history is saved without credits
+		}
+
 		// Insert into shift_history BEFORE updating shift status
 		historyQuery := `INSERT INTO shift_history (
 			id, driver_id, route_id, start_time, end_time, created_at, ended_at,
 			total_pause_seconds, total_bins, completed_bins, completion_rate,
 			incidents_reported, field_observations,
-			end_reason, ended_by_user_id, end_reason_metadata
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)`
+			end_reason, ended_by_user_id, end_reason_metadata,
+			earned_credits, earnings_breakdown
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)`
 
-		_, err = db.Exec(
+		_, err = db.ExecContext(r.Context(), 
 			historyQuery,
 			shift.ID,
 			shift.DriverID,
@@ -925,20 +1056,34 @@ func EndShift(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 			shift.TotalBins,
 			shift.CompletedBins,
 			completionRate,
-			incidentStats.TotalIncidents,    // NEW: incidents_reported
-			incidentStats.FieldObservations, // NEW: field_observations
+			shift.IncidentsReported, // Counted live as incidents are reported
+			shift.FieldObservations,
 			endReason,
 			nil, // ended_by_user_id (NULL - driver action)
 			nil, // end_reason_metadata (NULL for basic driver ends)
+			earnings.Total,
+			earningsBreakdown,
 		)
 		if err != nil {
 			log.Printf("❌ Error inserting shift history: %v", err)
-			utils.RespondError(w, http.StatusInternalServerError, "Failed to save shift history")
+			utils.RespondError(w, http.StatusInternalServerError, i18n.Tr(r, "Failed to save shift history"))
 			return
 		}
 
 		log.Printf("✅ Shift history saved: %s (reason: %s, completion: %.1f%%)", shift.ID, endReason, completionRate)
 
+		if req.Debrief != nil {
+			if err := saveShiftDebrief(r.Context(), db, shift, *req.Debrief, debriefResponses, now); err != nil {
+				log.Printf("⚠️  Warning: Failed to save debrief for shift %s: %v", shift.ID, err)
+				// Continue anyway - the shift still ends
+			} else {
+				log.Printf("📝 Debrief saved for shift %s (%d note(s))", shift.ID, len(req.Debrief.Notes))
+			}
+		}
+
+		// Planned vs actual distance, duration and stop order
+		recordShiftEfficiency(db, shift, activeDuration)
+
 		// Update shift
 		updateQuery := `UPDATE shifts
 						SET status = 'ended',
@@ -948,48 +1093,31 @@ func EndShift(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 							updated_at = $3
 						WHERE id = $4`
 
-		_, err = db.Exec(updateQuery, endTime, totalPause, now, shift.ID)
+		_, err = db.ExecContext(r.Context(), updateQuery, endTime, totalPause, now, shift.ID)
 		if err != nil {
 			log.Printf("❌ Error ending shift: %v", err)
-			utils.RespondError(w, http.StatusInternalServerError, "Failed to end shift")
+			utils.RespondError(w, http.StatusInternalServerError, i18n.Tr(r, "Failed to end shift"))
 			return
 		}
 
 		// Update incomplete move requests back to pending and clear assignment
-		updateMovesQuery := `UPDATE bin_move_requests
-							 SET status = 'pending',
-							     assigned_shift_id = NULL,
-							     updated_at = $1
-							 WHERE assigned_shift_id = $2
-							 AND status = 'in_progress'`
-		result, err := db.Exec(updateMovesQuery, now, shift.ID)
+		stores := store.New(db)
+		rowsAffected, err := stores.MoveRequests.ReleaseInProgress(now, shift.ID)
 		if err != nil {
 			log.Printf("⚠️ Error updating incomplete move requests: %v", err)
 			// Don't fail the request - continue
-		} else {
-			rowsAffected, _ := result.RowsAffected()
-			if rowsAffected > 0 {
-				log.Printf("✅ Updated %d incomplete move request(s) back to pending", rowsAffected)
-			}
+		} else if rowsAffected > 0 {
+			log.Printf("✅ Updated %d incomplete move request(s) back to pending", rowsAffected)
 		}
 
-		// Remove incomplete move requests from shift_bins
-		deleteShiftBinsQuery := `DELETE FROM shift_bins
-								 WHERE shift_id = $1
-								 AND is_completed = 0
-								 AND bin_id IN (
-									SELECT bin_id FROM bin_move_requests
-									WHERE assigned_shift_id IS NULL
-									AND status = 'pending'
-								 )`
-		_, err = db.Exec(deleteShiftBinsQuery, shift.ID)
-		if err != nil {
+		// Remove the released move requests' stops from the route
+		if _, err := stores.Shifts.RemoveReleasedMoveStops(shift.ID); err != nil {
 			log.Printf("⚠️ Error removing incomplete move bins from shift: %v", err)
 			// Don't fail the request - continue
 		}
 
 		// Get updated shift with bins for WebSocket broadcast
-		db.Get(&shift, `SELECT * FROM shifts WHERE id = $1`, shift.ID)
+		db.GetContext(r.Context(), &shift, `SELECT * FROM shifts WHERE id = $1`, shift.ID)
 
 		// Broadcast WebSocket update to driver
 		hub.BroadcastToUser(userClaims.UserID, map[string]interface{}{
@@ -1011,6 +1139,13 @@ func EndShift(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 		log.Printf("📡 Broadcast driver_shift_change to managers: Driver ended shift")
 
 		log.Printf("🏁 Shift ended: %s (%dm active)", shift.ID, activeDuration/60)
+		emitShiftWebhook(db, models.WebhookEventShiftEnded, shift, map[string]interface{}{
+			"end_reason":              endReason,
+			"completion_rate":         completionRate,
+			"active_duration_seconds": activeDuration,
+			"incidents_reported":      shift.IncidentsReported,
+			"earned_credits":          earnings.Total,
+		})
 
 		response := models.ShiftEndResponse{
 			Status:                "ended",
@@ -1021,6 +1156,9 @@ func EndShift(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 			CompletedBins:         shift.CompletedBins,
 			TotalBins:             shift.TotalBins,
 		}
+		if earningsBreakdown != nil {
+			response.Earnings = &earnings
+		}
 
 		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
 			"success": true,
@@ -1029,37 +1167,60 @@ func EndShift(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 	}
 }
 
-// CompleteBin marks a bin as completed
+// CompleteBin marks the next incomplete stop for a bin as completed (collection, pickup or dropoff)
 func CompleteBin(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
+	return completeShiftStop(db, hub, "")
+}
+
+// CompletePickup completes the pickup waypoint of a move request
+func CompletePickup(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
+	return completeShiftStop(db, hub, models.TaskTypePickup)
+}
+
+// CompleteDropoff completes the dropoff waypoint of a move request (finalizes the move)
+func CompleteDropoff(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
+	return completeShiftStop(db, hub, models.TaskTypeDropoff)
+}
+
+// completeStopRequest is the body for completing a shift stop (complete-bin, complete-pickup, complete-dropoff)
+type completeStopRequest struct {
+	ShiftBinID            int     `json:"shift_bin_id"`                                             // DEPRECATED: legacy shift_bins ID (ignored), use task_id
+	TaskID                *string `json:"task_id,omitempty"`                                        // ID of route_tasks record (identifies specific waypoint)
+	BinID                 string  `json:"bin_id"`                                                   // DEPRECATED: Use shift_bin_id instead
+	UpdatedFillPercentage *int    `json:"updated_fill_percentage,omitempty" validate:"min=0,max=100"` // Now optional
+	PhotoUrl              *string `json:"photo_url,omitempty"`
+	MoveRequestID         *string `json:"move_request_id,omitempty"` // Links check to move request
+
+	// Incident reporting fields (all optional)
+	HasIncident         bool    `json:"has_incident"`
+	IncidentType        *string `json:"incident_type,omitempty"`
+	IncidentPhotoUrl    *string `json:"incident_photo_url,omitempty"`
+	IncidentDescription *string `json:"incident_description,omitempty"`
+
+	// Answers to the organization's check form, by field key (the form is in GET /api/config/client)
+	FormResponses map[string]json.RawMessage `json:"form_responses,omitempty"`
+}
+
+// completeShiftStop completes a stop on the driver's active shift
+// requiredTaskType restricts completion to that stop type ("" = next incomplete stop for the bin)
+func completeShiftStop(db *sqlx.DB, hub *websocket.Hub, requiredTaskType models.TaskType) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		log.Printf("[DIAGNOSTIC] ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-		log.Printf("[DIAGNOSTIC] 📥 REQUEST: POST /api/driver/shift/complete-bin")
+		log.Printf("[DIAGNOSTIC] 📥 REQUEST: POST %s", r.URL.Path)
 
 		userClaims, ok := middleware.GetUserFromContext(r)
 		if !ok {
-			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			utils.RespondError(w, http.StatusUnauthorized, i18n.Tr(r, "Unauthorized"))
 			return
 		}
 
 		log.Printf("[DIAGNOSTIC]    User: %s (%s)", userClaims.Email, userClaims.UserID)
 
 		// Parse request body
-		var req struct {
-			ShiftBinID            int     `json:"shift_bin_id"`                      // ID of shift_bins record (identifies specific waypoint)
-			BinID                 string  `json:"bin_id"`                            // DEPRECATED: Use shift_bin_id instead
-			UpdatedFillPercentage *int    `json:"updated_fill_percentage,omitempty"` // Now optional
-			PhotoUrl              *string `json:"photo_url,omitempty"`
-			MoveRequestID         *string `json:"move_request_id,omitempty"` // Links check to move request
-
-			// Incident reporting fields (all optional)
-			HasIncident         bool    `json:"has_incident"`
-			IncidentType        *string `json:"incident_type,omitempty"`
-			IncidentPhotoUrl    *string `json:"incident_photo_url,omitempty"`
-			IncidentDescription *string `json:"incident_description,omitempty"`
-		}
+		var req completeStopRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			log.Printf("[DIAGNOSTIC] ❌ Error decoding request body: %v", err)
-			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			utils.RespondError(w, http.StatusBadRequest, i18n.Tr(r, "Invalid request body"))
 			return
 		}
 
@@ -1084,40 +1245,47 @@ func CompleteBin(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 
 		// Validate: at least photo OR fill percentage required (unless incident is being reported)
 		if !req.HasIncident && req.PhotoUrl == nil && req.UpdatedFillPercentage == nil {
-			utils.RespondError(w, http.StatusBadRequest, "At least photo or fill percentage is required")
+			utils.RespondError(w, http.StatusBadRequest, i18n.Tr(r, "At least photo or fill percentage is required"))
 			return
 		}
 
 		// Validate fill percentage if provided
 		if req.UpdatedFillPercentage != nil && (*req.UpdatedFillPercentage < 0 || *req.UpdatedFillPercentage > 100) {
-			utils.RespondError(w, http.StatusBadRequest, "Fill percentage must be between 0 and 100")
+			utils.RespondError(w, http.StatusBadRequest, i18n.Tr(r, "Fill percentage must be between 0 and 100"))
 			return
 		}
 
 		// Validate incident fields if incident is being reported
+		var incidentType *models.IncidentType
 		if req.HasIncident {
 			if req.IncidentType == nil {
-				utils.RespondError(w, http.StatusBadRequest, "incident_type is required when reporting incident")
+				utils.RespondError(w, http.StatusBadRequest, i18n.Tr(r, "incident_type is required when reporting incident"))
 				return
 			}
 			// Validate incident type
-			validTypes := map[string]bool{"vandalism": true, "landlord_complaint": true, "theft": true, "relocation_request": true, "missing": true, "damaged": true, "inaccessible": true}
-			if !validTypes[*req.IncidentType] {
-				utils.RespondError(w, http.StatusBadRequest, "Invalid incident_type")
+			var err error
+			incidentType, err = lookupIncidentType(r.Context(), db, *req.IncidentType, false)
+			if err != nil {
+				log.Printf("❌ %v", err)
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to validate incident_type")
+				return
+			}
+			if incidentType == nil {
+				utils.RespondError(w, http.StatusBadRequest, i18n.Tr(r, "Invalid incident_type"))
 				return
 			}
 			// At least photo OR description required for incidents
 			if req.IncidentPhotoUrl == nil && req.IncidentDescription == nil {
-				utils.RespondError(w, http.StatusBadRequest, "Either incident photo or description is required")
+				utils.RespondError(w, http.StatusBadRequest, i18n.Tr(r, "Either incident photo or description is required"))
 				return
 			}
 		}
 
 		// Get current active shift
 		var shift models.Shift
-		err := db.Get(&shift, `SELECT * FROM shifts WHERE driver_id = $1 AND status = 'active' ORDER BY created_at DESC LIMIT 1`, userClaims.UserID)
+		err := db.GetContext(r.Context(), &shift, `SELECT * FROM shifts WHERE driver_id = $1 AND status = 'active' ORDER BY created_at DESC LIMIT 1`, userClaims.UserID)
 		if err != nil {
-			utils.RespondError(w, http.StatusBadRequest, "No active shift")
+			utils.RespondError(w, http.StatusBadRequest, i18n.Tr(r, "No active shift"))
 			return
 		}
 
@@ -1128,31 +1296,121 @@ func CompleteBin(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 		log.Printf("[DIAGNOSTIC]    Shift ID: %s", shift.ID)
 		log.Printf("[DIAGNOSTIC]    Bin ID: %s", req.BinID)
 
-		// Find the next incomplete task for this bin in this shift
+		// Find the requested task, or the next incomplete task for this bin in this shift
 		var taskID string
 		var taskType string
-		err = db.QueryRow(`
-			SELECT id, task_type
-			FROM route_tasks
-			WHERE shift_id = $1
-			  AND bin_id = $2
-			  AND is_completed = 0
-			ORDER BY sequence_order ASC
-			LIMIT 1
-		`, shift.ID, req.BinID).Scan(&taskID, &taskType)
+		var taskLatitude, taskLongitude float64
+		if req.TaskID != nil && *req.TaskID != "" {
+			err = db.QueryRowContext(r.Context(), `
+				SELECT id, task_type, latitude, longitude
+				FROM route_tasks
+				WHERE shift_id = $1
+				  AND id = $2
+				  AND is_completed = 0
+				  AND ($3 = '' OR task_type = $3)
+			`, shift.ID, *req.TaskID, string(requiredTaskType)).Scan(&taskID, &taskType, &taskLatitude, &taskLongitude)
+		} else {
+			err = db.QueryRowContext(r.Context(), `
+				SELECT id, task_type, latitude, longitude
+				FROM route_tasks
+				WHERE shift_id = $1
+				  AND bin_id = $2
+				  AND is_completed = 0
+				  AND ($3 = '' OR task_type = $3)
+				ORDER BY sequence_order ASC
+				LIMIT 1
+			`, shift.ID, req.BinID, string(requiredTaskType)).Scan(&taskID, &taskType, &taskLatitude, &taskLongitude)
+		}
 
 		if err == sql.ErrNoRows {
 			log.Printf("[DIAGNOSTIC] ⚠️  Task not found in route or already completed")
-			utils.RespondError(w, http.StatusBadRequest, "Bin not found in route or already completed")
+			if requiredTaskType != "" {
+				utils.RespondError(w, http.StatusBadRequest, i18n.Tr(r, "No incomplete %s stop found for this bin", i18n.Tr(r, string(requiredTaskType))))
+				return
+			}
+			utils.RespondError(w, http.StatusBadRequest, i18n.Tr(r, "Bin not found in route or already completed"))
 			return
 		}
 		if err != nil {
 			log.Printf("❌ Error finding task: %v", err)
-			utils.RespondError(w, http.StatusInternalServerError, "Failed to find task")
+			utils.RespondError(w, http.StatusInternalServerError, i18n.Tr(r, "Failed to find task"))
 			return
 		}
 
 		log.Printf("[DIAGNOSTIC] ✅ Found task: ID=%s, Type=%s", taskID, taskType)
+
+		// Bins (or areas) flagged photo_required can't be collected without a photo; an incident reported
+		// with its own photo counts (the bin may be missing)
+		photoRequired := false
+		if taskType == string(models.TaskTypeCollection) {
+			photoRequired, err = stopPhotoRequired(r.Context(), db, taskID)
+			if err != nil {
+				log.Printf("❌ Error checking photo requirement: %v", err)
+				utils.RespondError(w, http.StatusInternalServerError, i18n.Tr(r, "Failed to find task"))
+				return
+			}
+		}
+		if photoRequired && !hasPhoto(req.PhotoUrl) && !(req.HasIncident && hasPhoto(req.IncidentPhotoUrl)) {
+			log.Printf("[DIAGNOSTIC] ❌ Photo required for task %s but none was sent", taskID)
+			utils.RespondErrorCode(w, http.StatusUnprocessableEntity, utils.CodePhotoRequired,
+				i18n.Tr(r, "A photo is required for this bin"), map[string]string{"task_id": taskID})
+			return
+		}
+
+		// Answers to the organization's check form; required fields only apply to collections, and an
+		// incident report can skip them (the bin may be missing)
+		checkForm, err := database.GetCheckForm(db)
+		if err != nil {
+			log.Printf("❌ Error loading check form: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, i18n.Tr(r, "Failed to load check form"))
+			return
+		}
+		requireAnswers := taskType == string(models.TaskTypeCollection) && !req.HasIncident
+		formResponses, formErrors := checkForm.ValidateResponses(req.FormResponses, requireAnswers)
+		if len(formErrors) > 0 {
+			fieldErrors := make([]openapi.FieldError, len(formErrors))
+			for i, e := range formErrors {
+				fieldErrors[i] = openapi.FieldError{Field: "form_responses." + e.Key, Message: e.Message}
+			}
+			log.Printf("[DIAGNOSTIC] ❌ Check form answers rejected for task %s: %+v", taskID, fieldErrors)
+			utils.RespondErrorCode(w, http.StatusBadRequest, utils.CodeValidationFailed,
+				i18n.Tr(r, "Check form answers are invalid"), fieldErrors)
+			return
+		}
+		var formResponsesJSON *string
+		if len(formResponses) > 0 {
+			raw, _ := json.Marshal(formResponses)
+			value := string(raw) // string so lib/pq sends JSON text, not bytea
+			formResponsesJSON = &value
+		}
+
+		// The driver must be near the stop (a dropoff's stop is the move's new location); without a recent GPS
+		// fix the completion is accepted unverified
+		var proximity models.CheckInProximity
+		proximitySettings, err := database.GetCheckInProximitySettings(db)
+		if err != nil {
+			log.Printf("⚠️  Error loading check-in proximity settings (not checked): %v", err)
+		} else if proximitySettings.Enabled {
+			proximity, err = services.CheckInProximityOf(db, userClaims.UserID, taskLatitude, taskLongitude, proximitySettings, now)
+			if err != nil {
+				log.Printf("⚠️  Error checking proximity to task %s (not checked): %v", taskID, err)
+			}
+		}
+		if proximity.Remote {
+			log.Printf("[DIAGNOSTIC] 📍 Driver is %.0f m from task %s (limit %.0f m, action: %s)",
+				*proximity.DistanceMeters, taskID, proximitySettings.MaxDistanceMeters, proximitySettings.Action)
+			// A manager completing the stop on the driver's behalf isn't refused (the completion stays remote)
+			if proximitySettings.Action == models.CheckInProximityReject && !userClaims.IsOnBehalf() {
+				utils.RespondErrorCode(w, http.StatusUnprocessableEntity, utils.CodeTooFarFromStop,
+					i18n.Tr(r, "You're %.0f m from this stop. Get closer to complete it", *proximity.DistanceMeters),
+					map[string]interface{}{
+						"task_id":             taskID,
+						"distance_meters":     *proximity.DistanceMeters,
+						"max_distance_meters": proximitySettings.MaxDistanceMeters,
+					})
+				return
+			}
+		}
 		log.Printf("[DIAGNOSTIC] 💾 About to write fill_percentage to database:")
 		if req.UpdatedFillPercentage != nil {
 			log.Printf("[DIAGNOSTIC]    Writing value: %d%%", *req.UpdatedFillPercentage)
@@ -1167,17 +1425,17 @@ func CompleteBin(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 							updated_fill_percentage = $2,
 							updated_at = $3
 						WHERE id = $4`
-		result, err := db.Exec(updateQuery, now, req.UpdatedFillPercentage, now, taskID)
+		result, err := db.ExecContext(r.Context(), updateQuery, now, req.UpdatedFillPercentage, now, taskID)
 		if err != nil {
 			log.Printf("❌ Error marking task as completed: %v", err)
-			utils.RespondError(w, http.StatusInternalServerError, "Failed to complete task")
+			utils.RespondError(w, http.StatusInternalServerError, i18n.Tr(r, "Failed to complete task"))
 			return
 		}
 
 		rowsAffected, _ := result.RowsAffected()
 		if rowsAffected == 0 {
 			log.Printf("[DIAGNOSTIC] ⚠️  Task update affected 0 rows")
-			utils.RespondError(w, http.StatusBadRequest, "Failed to update task")
+			utils.RespondError(w, http.StatusBadRequest, i18n.Tr(r, "Failed to update task"))
 			return
 		}
 
@@ -1185,11 +1443,11 @@ func CompleteBin(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 
 		// Check if this bin is part of a move request
 		var moveRequest models.BinMoveRequest
-		moveErr := db.Get(&moveRequest, `
+		moveErr := db.GetContext(r.Context(), &moveRequest, `
 			SELECT * FROM bin_move_requests
 			WHERE bin_id = $1
 			AND assigned_shift_id = $2
-			AND status IN ('assigned', 'in_progress')
+			AND status IN ('assigned', 'in_progress', 'picked_up')
 		`, req.BinID, shift.ID)
 
 		if moveErr == nil {
@@ -1207,6 +1465,14 @@ func CompleteBin(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 					log.Printf("[DIAGNOSTIC] ❌ Error handling move request: %v", err)
 					// Don't fail - just log
 				}
+			} else if moveRequest.MoveType == "relocation" {
+				// The bin is on the truck until the dropoff is completed
+				log.Printf("[DIAGNOSTIC] This is the PICKUP - move request is now picked_up")
+				if pickedUp, err := markMovePickedUp(r.Context(), db, moveRequest.ID, now); err != nil {
+					log.Printf("[DIAGNOSTIC] ❌ Error marking move request picked up: %v", err)
+				} else if pickedUp {
+					notifyMovePickedUp(db, hub, moveRequest, userClaims.UserID, now)
+				}
 			} else {
 				log.Printf("[DIAGNOSTIC] This is the PICKUP - move request remains in_progress")
 			}
@@ -1220,7 +1486,7 @@ func CompleteBin(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 								       updated_at = $2
 								   WHERE id = $3`
 
-				_, err = db.Exec(binUpdateQuery, *req.UpdatedFillPercentage, now, req.BinID)
+				_, err = db.ExecContext(r.Context(), binUpdateQuery, *req.UpdatedFillPercentage, now, req.BinID)
 				if err != nil {
 					log.Printf("[DIAGNOSTIC] ❌ Error updating bin fill percentage: %v", err)
 					// Don't fail the request - the bin is already marked complete in route
@@ -1230,7 +1496,7 @@ func CompleteBin(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 			} else {
 				// Even without fill percentage, update last_checked_at
 				log.Printf("[DIAGNOSTIC] 📝 Updating last_checked_at (no fill percentage due to incident)...")
-				_, err = db.Exec(`UPDATE bins SET last_checked_at = $1, updated_at = $1 WHERE id = $2`, now, req.BinID)
+				_, err = db.ExecContext(r.Context(), `UPDATE bins SET last_checked_at = $1, updated_at = $1 WHERE id = $2`, now, req.BinID)
 				if err != nil {
 					log.Printf("[DIAGNOSTIC] ❌ Error updating last_checked_at: %v", err)
 				} else {
@@ -1248,12 +1514,14 @@ func CompleteBin(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 			log.Printf("[DIAGNOSTIC]    Inserting fill_percentage: NULL")
 		}
 		var checkID *int
-		checkQuery := `INSERT INTO checks (bin_id, checked_from, fill_percentage, checked_on, checked_by, photo_url, move_request_id)
-					   VALUES ($1, $2, $3, $4, $5, $6, $7)
+		checkQuery := `INSERT INTO checks (bin_id, checked_from, fill_percentage, checked_on, checked_by, photo_url, move_request_id, photo_required, form_responses,
+					   completion_distance_meters, remote_completion)
+					   VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 					   RETURNING id`
 
 		var returnedID int
-		err = db.QueryRow(checkQuery, req.BinID, "shift", req.UpdatedFillPercentage, now, userClaims.UserID, req.PhotoUrl, req.MoveRequestID).Scan(&returnedID)
+		err = db.QueryRowContext(r.Context(), checkQuery, req.BinID, "shift", req.UpdatedFillPercentage, now, userClaims.UserID, req.PhotoUrl, req.MoveRequestID, photoRequired, formResponsesJSON,
+			proximity.DistanceMeters, proximity.Remote).Scan(&returnedID)
 		if err != nil {
 			log.Printf("[DIAGNOSTIC] ❌ Error inserting check record: %v", err)
 			// Don't fail the request - the bin is already marked complete
@@ -1269,6 +1537,11 @@ func CompleteBin(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 
 			// Auto-resolve any pending check recommendations for this bin
 			autoResolveCheckRecommendation(db, req.BinID, userClaims.UserID, now)
+
+			// Flag suspicious checks for manager review (never blocks the driver)
+			if _, err := services.DetectCheckAnomalies(db, returnedID); err != nil {
+				log.Printf("[DIAGNOSTIC] ⚠️  Check anomaly detection failed: %v", err)
+			}
 		}
 
 		// Create incident if reported
@@ -1278,7 +1551,7 @@ func CompleteBin(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 
 			// Get bin details for zone creation
 			var bin models.Bin
-			err = db.Get(&bin, "SELECT * FROM bins WHERE id = $1", req.BinID)
+			err = db.GetContext(r.Context(), &bin, "SELECT * FROM bins WHERE id = $1", req.BinID)
 			if err != nil {
 				log.Printf("[DIAGNOSTIC] ❌ Error fetching bin details: %v", err)
 			} else {
@@ -1291,72 +1564,62 @@ func CompleteBin(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 				incidentID := uuid.New().String()
 				log.Printf("[DIAGNOSTIC]    Incident ID: %s", incidentID)
 
-				// Check for existing zone within 100m
+				// A repeat of an open report joins it instead of scoring the zone again
 				var zoneID string
-				var existingZone *models.NoGoZone
-				var zones []models.NoGoZone
-				err = db.Select(&zones, "SELECT * FROM no_go_zones WHERE status = 'active'")
-				if err != nil {
-					log.Printf("[DIAGNOSTIC] ⚠️  Error fetching zones: %v", err)
-				} else {
-					log.Printf("[DIAGNOSTIC]    Checking %d active zones for proximity...", len(zones))
-					for _, zone := range zones {
-						distance := calculateZoneDistance(*bin.Latitude, *bin.Longitude, zone.CenterLatitude, zone.CenterLongitude)
-						if distance < 100 {
-							existingZone = &zone
-							log.Printf("[DIAGNOSTIC]    Found existing zone within 100m (distance: %.2fm)", distance)
-							break
-						}
-					}
+				var parentIncidentID *string
+				parent, dedupErr := findRepeatedIncident(r.Context(), db, req.BinID, *req.IncidentType, now)
+				if dedupErr != nil {
+					log.Printf("[DIAGNOSTIC] ⚠️  %v", dedupErr)
 				}
-
-				// Create or update zone
-				if existingZone != nil {
-					zoneID = existingZone.ID
-					newScore := existingZone.ConflictScore + getIncidentScore(*req.IncidentType)
-					_, err = db.Exec(`UPDATE no_go_zones SET conflict_score = $1, updated_at = $2 WHERE id = $3`, newScore, now, zoneID)
-					if err != nil {
-						log.Printf("[DIAGNOSTIC] ❌ Error updating zone: %v", err)
-					} else {
-						log.Printf("[DIAGNOSTIC] ✅ Updated existing zone (new score: %d)", newScore)
-					}
+				if parent != nil {
+					log.Printf("[DIAGNOSTIC]    Repeat of incident %s, not scoring the zone again", parent.ID)
+					zoneID = parent.ZoneID
+					parentIncidentID = &parent.ID
 				} else {
-					zoneID = uuid.New().String()
-					zoneName := fmt.Sprintf("%s - %s", bin.CurrentStreet, bin.City)
-					radiusMeters := getZoneRadius(*req.IncidentType)
-					log.Printf("[DIAGNOSTIC]    Creating new zone: %s (radius: %dm)", zoneName, radiusMeters)
-					_, err = db.Exec(`
-						INSERT INTO no_go_zones (id, name, center_latitude, center_longitude, radius_meters, conflict_score, status, created_by_user_id, created_at, updated_at)
-						VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-					`, zoneID, zoneName, *bin.Latitude, *bin.Longitude, radiusMeters, getIncidentScore(*req.IncidentType), "active", nil, now, now)
-					if err != nil {
-						log.Printf("[DIAGNOSTIC] ❌ Error creating zone: %v", err)
-					} else {
-						log.Printf("[DIAGNOSTIC] ✅ Created new no-go zone (ID: %s)", zoneID)
-					}
-				}
-
-				// Check for zone merges after creating/updating zone
-				if err == nil {
-					log.Printf("[DIAGNOSTIC] 🔍 Checking for zone merges...")
-					if mergeErr := detectAndMergeZones(db, zoneID, now); mergeErr != nil {
-						log.Printf("[DIAGNOSTIC] ⚠️  Zone merge check failed: %v", mergeErr)
-						// Don't fail the request if merge fails - it's not critical
-					}
+					zoneID = recordIncidentZone(r.Context(), db, bin, *incidentType, now)
 				}
 
 				// Create incident record
 				log.Printf("[DIAGNOSTIC]    Inserting incident record...")
-				_, err = db.Exec(`
-					INSERT INTO zone_incidents (id, zone_id, bin_id, incident_type, reported_by_user_id, reported_at, description, photo_url, check_id, shift_id, is_field_observation, status)
-					VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
-				`, incidentID, zoneID, req.BinID, *req.IncidentType, userClaims.UserID, now, req.IncidentDescription, req.IncidentPhotoUrl, checkID, shift.ID, false, "open")
+				_, err = db.ExecContext(r.Context(), `
+					INSERT INTO zone_incidents (id, zone_id, bin_id, incident_type, reported_by_user_id, reported_at, description, photo_url, check_id, shift_id, is_field_observation, status, parent_incident_id)
+					VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+				`, incidentID, zoneID, req.BinID, *req.IncidentType, userClaims.UserID, now, req.IncidentDescription, req.IncidentPhotoUrl, checkID, shift.ID, false, "open", parentIncidentID)
 
 				if err != nil {
 					log.Printf("[DIAGNOSTIC] ❌ ERROR inserting incident: %v", err)
 				} else {
 					createdIncidentID = &incidentID
 					log.Printf("[DIAGNOSTIC] ✅ Incident created (ID: %s) and linked to check ID %d", incidentID, *checkID)
+					if err := store.NewShiftStore(db).CountIncident(shift.ID, false); err != nil {
+						log.Printf("[DIAGNOSTIC] ⚠️  %v", err)
+					}
+					if err := helpers.EnqueueIncidentPhoto(db, incidentID, req.IncidentPhotoUrl); err != nil {
+						log.Printf("[DIAGNOSTIC] ⚠️  %v", err)
+					}
+					// Subscribers already heard about the first report
+					if parent != nil {
+						if count, err := countIncidentReports(r.Context(), db, parent.ID); err != nil {
+							log.Printf("[DIAGNOSTIC] ⚠️  %v", err)
+						} else {
+							log.Printf("[DIAGNOSTIC] ✅ Incident %s now has %d reports", parent.ID, count)
+						}
+					} else if err := helpers.NotifyIncidentSubscribers(db, incidentID); err != nil {
+						log.Printf("[DIAGNOSTIC] ⚠️  %v", err)
+					}
+					helpers.EmitWebhookEvent(db, models.WebhookEventIncidentCreated, map[string]interface{}{
+						"incident_id":         incidentID,
+						"parent_incident_id":  parentIncidentID,
+						"zone_id":             zoneID,
+						"bin_id":              req.BinID,
+						"incident_type":       *req.IncidentType,
+						"description":         req.IncidentDescription,
+						"photo_url":           req.IncidentPhotoUrl,
+						"check_id":            checkID,
+						"shift_id":            shift.ID,
+						"reported_by_user_id": userClaims.UserID,
+						"reported_at":         now,
+					})
 				}
 			} else if err != nil {
 				log.Printf("[DIAGNOSTIC] ⚠️  Could not create incident: failed to fetch bin")
@@ -1371,7 +1634,7 @@ func CompleteBin(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 						   updated_at = $1
 					   WHERE id = $2`
 
-		_, err = db.Exec(shiftQuery, now, shift.ID)
+		_, err = db.ExecContext(r.Context(), shiftQuery, now, shift.ID)
 		if err != nil {
 			log.Printf("❌ Error updating shift: %v", err)
 			utils.RespondError(w, http.StatusInternalServerError, "Failed to update shift")
@@ -1379,7 +1642,7 @@ func CompleteBin(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 		}
 
 		// Get updated shift
-		db.Get(&shift, `SELECT * FROM shifts WHERE id = $1`, shift.ID)
+		db.GetContext(r.Context(), &shift, `SELECT * FROM shifts WHERE id = $1`, shift.ID)
 
 		// Get updated bins list
 		bins, err := getRouteBinsWithDetails(db, shift.ID)
@@ -1418,6 +1681,7 @@ func CompleteBin(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 			CompletionPercentage: completionPercentage,
 			CheckID:              checkID,
 			IncidentID:           createdIncidentID,
+			RemoteCompletion:     proximity.Remote,
 		}
 
 		log.Printf("[DIAGNOSTIC] 📤 RESPONSE: 200 OK")
@@ -1438,62 +1702,27 @@ func CompleteBin(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 	}
 }
 
-// GetDriverShiftHistory returns all completed shifts for the authenticated driver
-func GetDriverShiftHistory(db *sqlx.DB) http.HandlerFunc {
+// GetShiftDetails returns detailed information about a specific shift including all bins
+// Supports ?fields= like GetCurrentShift
+func GetShiftDetails(db *sqlx.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		log.Printf("📥 REQUEST: GET /api/driver/shift-history")
+		log.Printf("📥 REQUEST: GET /api/driver/shift-details")
 
 		userClaims, ok := middleware.GetUserFromContext(r)
 		if !ok {
-			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			utils.RespondError(w, http.StatusUnauthorized, i18n.Tr(r, "Unauthorized"))
 			return
 		}
 
-		log.Printf("   User: %s (%s)", userClaims.Email, userClaims.UserID)
-
-		// Query all shifts where start_time is NOT NULL (shift was actually started)
-		// Order by most recent first, limit to 100 for performance
-		query := `
-			SELECT id, driver_id, route_id, status, start_time, end_time,
-			       total_pause_seconds, total_bins, completed_bins,
-			       created_at, updated_at
-			FROM shifts
-			WHERE driver_id = $1 AND start_time IS NOT NULL
-			ORDER BY start_time DESC
-			LIMIT 100`
+		shiftID := r.URL.Query().Get("shift_id")
+		if shiftID == "" {
+			utils.RespondError(w, http.StatusBadRequest, "shift_id query parameter is required")
+			return
+		}
 
-		var shifts []models.Shift
-		err := db.Select(&shifts, query, userClaims.UserID)
+		fields, err := utils.ParseFields(r, shiftPayloadFields)
 		if err != nil {
-			log.Printf("❌ Error fetching shift history: %v", err)
-			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch shift history")
-			return
-		}
-
-		log.Printf("✅ Found %d shifts in history", len(shifts))
-		log.Printf("📤 RESPONSE: 200 OK")
-
-		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
-			"success": true,
-			"data":    shifts,
-		})
-	}
-}
-
-// GetShiftDetails returns detailed information about a specific shift including all bins
-func GetShiftDetails(db *sqlx.DB) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		log.Printf("📥 REQUEST: GET /api/driver/shift-details")
-
-		userClaims, ok := middleware.GetUserFromContext(r)
-		if !ok {
-			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
-			return
-		}
-
-		shiftID := r.URL.Query().Get("shift_id")
-		if shiftID == "" {
-			utils.RespondError(w, http.StatusBadRequest, "shift_id query parameter is required")
+			utils.RespondFieldsError(w, err, shiftPayloadFields)
 			return
 		}
 
@@ -1502,10 +1731,10 @@ func GetShiftDetails(db *sqlx.DB) http.HandlerFunc {
 
 		// Get shift details
 		var shift models.Shift
-		err := db.Get(&shift, `SELECT * FROM shifts WHERE id = $1 AND driver_id = $2`, shiftID, userClaims.UserID)
+		err = db.GetContext(r.Context(), &shift, `SELECT * FROM shifts WHERE id = $1 AND driver_id = $2`, shiftID, userClaims.UserID)
 		if err != nil {
 			log.Printf("❌ Error fetching shift: %v", err)
-			utils.RespondError(w, http.StatusNotFound, "Shift not found")
+			utils.RespondError(w, http.StatusNotFound, i18n.Tr(r, "Shift not found"))
 			return
 		}
 
@@ -1516,13 +1745,18 @@ func GetShiftDetails(db *sqlx.DB) http.HandlerFunc {
 			bins = []models.ShiftBinWithDetails{} // Return empty array on error
 		}
 
+		pauses, err := loadShiftPauses(r.Context(), db, shiftID)
+		if err != nil {
+			log.Printf("❌ Error fetching pauses: %v", err)
+		}
+
 		log.Printf("✅ Shift found with %d bins", len(bins))
 		log.Printf("📤 RESPONSE: 200 OK")
 
 		// Return shift with bins array
 		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
 			"success": true,
-			"data": map[string]interface{}{
+			"data": fields.Apply(map[string]interface{}{
 				"id":                  shift.ID,
 				"driver_id":           shift.DriverID,
 				"route_id":            shift.RouteID,
@@ -1535,7 +1769,8 @@ func GetShiftDetails(db *sqlx.DB) http.HandlerFunc {
 				"created_at":          shift.CreatedAt,
 				"updated_at":          shift.UpdatedAt,
 				"bins":                bins,
-			},
+				"pauses":              pauses,
+			}),
 		})
 	}
 }
@@ -1547,7 +1782,7 @@ func GetShiftMoveRequests(db *sqlx.DB) http.HandlerFunc {
 
 		userClaims, ok := middleware.GetUserFromContext(r)
 		if !ok {
-			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			utils.RespondError(w, http.StatusUnauthorized, i18n.Tr(r, "Unauthorized"))
 			return
 		}
 
@@ -1562,10 +1797,10 @@ func GetShiftMoveRequests(db *sqlx.DB) http.HandlerFunc {
 
 		// Verify shift exists and belongs to the driver
 		var shift models.Shift
-		err := db.Get(&shift, `SELECT * FROM shifts WHERE id = $1 AND driver_id = $2`, shiftID, userClaims.UserID)
+		err := db.GetContext(r.Context(), &shift, `SELECT * FROM shifts WHERE id = $1 AND driver_id = $2`, shiftID, userClaims.UserID)
 		if err != nil {
 			log.Printf("❌ Error fetching shift: %v", err)
-			utils.RespondError(w, http.StatusNotFound, "Shift not found")
+			utils.RespondError(w, http.StatusNotFound, i18n.Tr(r, "Shift not found"))
 			return
 		}
 
@@ -1588,6 +1823,9 @@ func GetShiftMoveRequests(db *sqlx.DB) http.HandlerFunc {
 				mr.disposal_action,
 				mr.reason,
 				mr.notes,
+				mr.instructions,
+				mr.time_window_start,
+				mr.time_window_end,
 				mr.assignment_type,
 				mr.assigned_shift_id,
 				mr.assigned_user_id,
@@ -1612,7 +1850,7 @@ func GetShiftMoveRequests(db *sqlx.DB) http.HandlerFunc {
 		}
 
 		var moveRequests []MoveRequestWithBinDetails
-		err = db.Select(&moveRequests, query, shiftID)
+		err = db.SelectContext(r.Context(), &moveRequests, query, shiftID)
 		if err != nil {
 			log.Printf("❌ Error fetching move requests: %v", err)
 			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch move requests")
@@ -1622,10 +1860,22 @@ func GetShiftMoveRequests(db *sqlx.DB) http.HandlerFunc {
 		log.Printf("✅ Found %d move requests for shift", len(moveRequests))
 		log.Printf("📤 RESPONSE: 200 OK")
 
+		moveRequestIDs := make([]string, len(moveRequests))
+		for i, mr := range moveRequests {
+			moveRequestIDs[i] = mr.ID
+		}
+		attachments, err := store.NewMoveRequestStore(db).Attachments(moveRequestIDs...)
+		if err != nil {
+			log.Printf("❌ Error fetching move request attachments: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch move requests")
+			return
+		}
+
 		// Convert to response format
 		responses := make([]models.BinMoveRequestResponse, 0, len(moveRequests))
 		for _, mr := range moveRequests {
 			resp := mr.BinMoveRequest.ToBinMoveRequestResponse()
+			resp.Attachments = attachments[mr.ID]
 			resp.BinNumber = mr.BinNumber
 			resp.CurrentStreet = mr.CurrentStreet
 			resp.City = mr.City
@@ -1657,22 +1907,16 @@ func calculateLogicalBinCounts(bins []models.ShiftBinWithDetails) (int, int) {
 				logicalTotal++
 				processedMoveRequests[moveReqID] = true
 
-				// Check if BOTH waypoints are completed
-				pickupCompleted := false
-				dropoffCompleted := false
+				// Check if ALL waypoints are completed (store moves only have a pickup)
+				allCompleted := true
 
 				for _, b := range bins {
-					if b.MoveRequestID != nil && *b.MoveRequestID == moveReqID {
-						if b.StopType == "pickup" && b.IsCompleted == 1 {
-							pickupCompleted = true
-						}
-						if b.StopType == "dropoff" && b.IsCompleted == 1 {
-							dropoffCompleted = true
-						}
+					if b.MoveRequestID != nil && *b.MoveRequestID == moveReqID && b.IsCompleted != 1 {
+						allCompleted = false
 					}
 				}
 
-				if pickupCompleted && dropoffCompleted {
+				if allCompleted {
 					logicalCompleted++
 				}
 			}
@@ -1688,47 +1932,27 @@ func calculateLogicalBinCounts(bins []models.ShiftBinWithDetails) (int, int) {
 	return logicalTotal, logicalCompleted
 }
 
-// getRouteBinsWithDetails fetches route tasks with full details
-// ONLY uses route_tasks table (new unified task system)
+// getRouteBinsWithDetails fetches a shift's stops (route_tasks) with full details
 func getRouteBinsWithDetails(db *sqlx.DB, shiftID string) ([]models.ShiftBinWithDetails, error) {
-	// Query route_tasks table
-	query := `
-		SELECT
-			0 as id,  -- route_tasks uses string id, not auto-increment
-			rt.shift_id,
-			COALESCE(rt.bin_id, '') as bin_id,
-			rt.sequence_order,
-			rt.is_completed,
-			rt.completed_at,
-			rt.updated_fill_percentage,
-			rt.created_at,
-			COALESCE(b.bin_number, 0) as bin_number,
-			COALESCE(rt.address, '') as current_street,
-			COALESCE(b.city, '') as city,
-			COALESCE(b.zip, '') as zip,
-			COALESCE(b.fill_percentage, 0) as fill_percentage,
-			rt.latitude,
-			rt.longitude,
-			rt.task_type as stop_type,
-			rt.move_request_id,
-			rt.address as original_address,
-			rt.destination_address as new_address,
-			rt.move_type
-		FROM route_tasks rt
-		LEFT JOIN bins b ON rt.bin_id = b.id
-		WHERE rt.shift_id = $1
-		ORDER BY rt.sequence_order ASC`
-
-	var bins []models.ShiftBinWithDetails
-	err := db.Select(&bins, query, shiftID)
+	bins, err := store.NewShiftStore(db).Stops(shiftID)
 	if err != nil {
 		return nil, err
 	}
 
 	log.Printf("📦 Loaded %d tasks from route_tasks table", len(bins))
+	annotateStopRisk(db, bins)
+	annotateStopNotes(db, bins)
 	return bins, nil
 }
 
+// assignRouteRequest is the body for POST /api/manager/assign-route
+type assignRouteRequest struct {
+	DriverID string   `json:"driver_id"`
+	RouteID  string   `json:"route_id"`
+	BinIDs   []string `json:"bin_ids" validate:"required"`
+	Force    bool     `json:"force"` // Assign even if the driver's projected workload exceeds the limits or service hours
+}
+
 // AssignRoute assigns a route to a driver (manager only)
 func AssignRoute(db *sqlx.DB, hub *websocket.Hub, fcmService *services.FCMService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -1745,11 +1969,7 @@ func AssignRoute(db *sqlx.DB, hub *websocket.Hub, fcmService *services.FCMServic
 		}
 
 		// Parse request body
-		var req struct {
-			DriverID string   `json:"driver_id"`
-			RouteID  string   `json:"route_id"`
-			BinIDs   []string `json:"bin_ids"`
-		}
+		var req assignRouteRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
 			return
@@ -1761,179 +1981,232 @@ func AssignRoute(db *sqlx.DB, hub *websocket.Hub, fcmService *services.FCMServic
 			return
 		}
 
-		log.Printf("📋 Assigning route %s to driver %s with %d bins", req.RouteID, req.DriverID, len(req.BinIDs))
-		log.Printf("🔄 Route will be optimized when driver starts shift (based on actual location)")
-
-		now := time.Now().Unix()
-
-		// Start transaction
-		tx, err := db.Beginx()
-		if err != nil {
-			log.Printf("❌ Error starting transaction: %v", err)
-			utils.RespondError(w, http.StatusInternalServerError, "Failed to assign route")
+		if deactivated, err := database.IsUserDeactivated(db, req.DriverID); err == nil && deactivated {
+			utils.RespondError(w, http.StatusBadRequest, "Driver is deactivated")
 			return
 		}
-		defer tx.Rollback()
 
-		// Validate all bins exist
-		query := `SELECT COUNT(*) FROM bins WHERE id IN (?)`
-		query, args, err := sqlx.In(query, req.BinIDs)
+		// Bins without coordinates can't be stops; they're left out of the shift with a warning
+		candidateIDs := req.BinIDs
+		if req.RouteID != "" && req.RouteID != "custom" {
+			var isDraft bool
+			if err := db.GetContext(r.Context(), &isDraft, `SELECT EXISTS(SELECT 1 FROM routes WHERE id = $1 AND is_draft)`, req.RouteID); err != nil {
+				log.Printf("❌ Error checking draft state of route %s: %v", req.RouteID, err)
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to assign route")
+				return
+			}
+			if isDraft {
+				utils.RespondError(w, http.StatusConflict, "Route is a draft; publish it before assigning")
+				return
+			}
+			var routeBinIDs []string
+			if err := db.SelectContext(r.Context(), &routeBinIDs, `SELECT bin_id FROM route_bins WHERE route_id = $1`, req.RouteID); err != nil {
+				log.Printf("❌ Error fetching route_bins: %v", err)
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to assign route")
+				return
+			}
+			candidateIDs = append(append([]string{}, req.BinIDs...), routeBinIDs...)
+		}
+		missing, err := findBinsMissingCoordinates(db, candidateIDs)
 		if err != nil {
-			log.Printf("❌ Error building query: %v", err)
-			utils.RespondError(w, http.StatusInternalServerError, "Failed to validate bins")
+			log.Printf("❌ %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to assign route")
 			return
 		}
-		query = tx.Rebind(query)
+		warnings := []string{}
+		withoutCoordinates := make(map[string]bool, len(missing))
+		for _, bin := range missing {
+			withoutCoordinates[bin.ID] = true
+			warnings = append(warnings, missingCoordinatesWarning(bin))
+		}
+		if len(missing) > 0 {
+			located := make([]string, 0, len(req.BinIDs))
+			for _, binID := range req.BinIDs {
+				if !withoutCoordinates[binID] {
+					located = append(located, binID)
+				}
+			}
+			if len(located) == 0 {
+				respondBinsMissingCoordinates(w, missing)
+				return
+			}
+			log.Printf("⚠️  Leaving %d bin(s) without coordinates out of the route", len(missing))
+			req.BinIDs = located
+		}
 
-		var count int
-		err = tx.Get(&count, query, args...)
+		// Refuse to overload the driver, or to plan work past the end of service hours when the policy
+		// is to block, unless the manager insists (see POST /api/manager/assign-route/preview)
+		preview, err := buildRouteAssignmentPreview(db, req, loadWorkloadLimits(db), loadServiceHours(db), time.Now().Unix())
 		if err != nil {
-			log.Printf("❌ Error validating bins: %v", err)
-			utils.RespondError(w, http.StatusInternalServerError, "Failed to validate bins")
-			return
-		}
-		if count != len(req.BinIDs) {
-			utils.RespondError(w, http.StatusBadRequest, "One or more bin_ids are invalid")
-			return
+			log.Printf("⚠️  Could not project workload for driver %s: %v", req.DriverID, err)
+		} else if preview.ExceedsLimits {
+			if !req.Force {
+				utils.RespondErrorCode(w, http.StatusConflict, utils.CodeWorkloadExceeded,
+					"Assignment exceeds the driver's workload limits (resend with force=true to assign anyway)", preview)
+				return
+			}
+			log.Printf("⚠️  Workload limits overridden by %s for driver %s: %v", userClaims.Email, req.DriverID, preview.Warnings)
+		} else if check := preview.ServiceHours; check != nil && check.ExceedsServiceHours {
+			if check.Policy == models.AfterHoursBlock && !req.Force {
+				utils.RespondErrorCode(w, http.StatusConflict, utils.CodeAfterServiceHours,
+					"Assignment would finish after service hours (resend with force=true to assign anyway)", preview)
+				return
+			}
+			log.Printf("🌙 Route for driver %s estimated to finish after service hours (assigned by %s)", req.DriverID, userClaims.Email)
 		}
 
-		// Create new shift (route optimization will happen when driver starts)
+		log.Printf("📋 Assigning route %s to driver %s with %d bins", req.RouteID, req.DriverID, len(req.BinIDs))
+		log.Printf("🔄 Route will be optimized when driver starts shift (based on actual location)")
+
+		now := time.Now().Unix()
+
 		shiftID := uuid.New().String()
 		totalBins := len(req.BinIDs)
+		var shift models.Shift
+		var bins []models.ShiftBinWithDetails
+		notificationSent := false
 
-		shiftQuery := `INSERT INTO shifts (id, driver_id, route_id, status, total_bins, created_at, updated_at)
-					   VALUES ($1, $2, $3, 'ready', $4, $5, $6)`
+		// The shift, its stops, cost estimate and queued notifications commit together
+		err = database.WithTx(r.Context(), db, func(tx *sqlx.Tx) error {
+			stores := store.New(tx)
 
-		_, err = tx.Exec(shiftQuery, shiftID, req.DriverID, req.RouteID, totalBins, now, now)
-		if err != nil {
-			log.Printf("❌ Error creating shift: %v", err)
-			utils.RespondError(w, http.StatusInternalServerError, "Failed to create shift")
-			return
-		}
+			// Validate all bins exist
+			count, err := stores.Bins.CountExisting(req.BinIDs)
+			if err != nil {
+				log.Printf("❌ Error validating bins: %v", err)
+				return txFail(http.StatusInternalServerError, "Failed to validate bins")
+			}
+			if count != len(req.BinIDs) {
+				return txFail(http.StatusBadRequest, "One or more bin_ids are invalid")
+			}
 
-		// Insert bins - preserve route sequence if from pre-defined route, otherwise mark as unoptimized
-		// Check if this is from a pre-defined route (has bins in route_bins table)
-		var routeBins []struct {
-			BinID         string `db:"bin_id"`
-			SequenceOrder int    `db:"sequence_order"`
-		}
+			// Create new shift (route optimization will happen when driver starts)
+			shiftQuery := `INSERT INTO shifts (id, driver_id, route_id, status, total_bins, created_at, updated_at)
+						   VALUES ($1, $2, $3, 'ready', $4, $5, $6)`
 
-		if req.RouteID != "" && req.RouteID != "custom" {
-			// Try to get pre-defined route bins with sequence
-			routeBinsQuery := `SELECT bin_id, sequence_order FROM route_bins
-							   WHERE route_id = $1
-							   ORDER BY sequence_order`
-			err = tx.Select(&routeBins, routeBinsQuery, req.RouteID)
-			if err != nil && err != sql.ErrNoRows {
-				log.Printf("❌ Error fetching route_bins: %v", err)
-				// Continue anyway - will treat as custom
-				routeBins = nil
+			_, err = tx.ExecContext(r.Context(), shiftQuery, shiftID, req.DriverID, req.RouteID, totalBins, now, now)
+			if err != nil {
+				log.Printf("❌ Error creating shift: %v", err)
+				return txFail(http.StatusInternalServerError, "Failed to create shift")
 			}
-		}
 
-		// If we found pre-defined route bins, use their sequence
-		if len(routeBins) > 0 {
-			log.Printf("✅ Using pre-defined route sequence with %d bins", len(routeBins))
-			for _, rb := range routeBins {
-				routeBinQuery := `INSERT INTO shift_bins (shift_id, bin_id, sequence_order, created_at)
-								  VALUES ($1, $2, $3, $4)`
+			// Insert bins - preserve route sequence if from pre-defined route, otherwise mark as unoptimized
+			// Check if this is from a pre-defined route (has bins in route_bins table)
+			var routeBins []struct {
+				BinID         string `db:"bin_id"`
+				SequenceOrder int    `db:"sequence_order"`
+			}
 
-				_, err = tx.Exec(routeBinQuery, shiftID, rb.BinID, rb.SequenceOrder, now)
+			if req.RouteID != "" && req.RouteID != "custom" {
+				// Try to get pre-defined route bins with sequence
+				routeBinsQuery := `SELECT bin_id, sequence_order FROM route_bins
+								   WHERE route_id = $1
+								   ORDER BY sequence_order`
+				err = tx.SelectContext(r.Context(), &routeBins, routeBinsQuery, req.RouteID)
 				if err != nil {
-					log.Printf("❌ Error inserting shift_bin: %v", err)
-					utils.RespondError(w, http.StatusInternalServerError, "Failed to assign bins to shift")
-					return
+					// A failed statement aborts the transaction, so there's no falling back to a custom selection
+					log.Printf("❌ Error fetching route_bins: %v", err)
+					return txFail(http.StatusInternalServerError, "Failed to assign route")
 				}
 			}
-		} else {
-			// Custom selection or route without pre-defined bins - insert with sequence_order = 0
-			log.Printf("ℹ️  Custom bin selection - will optimize from driver's start location")
-			for _, binID := range req.BinIDs {
-				routeBinQuery := `INSERT INTO shift_bins (shift_id, bin_id, sequence_order, created_at)
-								  VALUES ($1, $2, 0, $3)`
 
-				_, err = tx.Exec(routeBinQuery, shiftID, binID, now)
-				if err != nil {
-					log.Printf("❌ Error inserting shift_bin: %v", err)
-					utils.RespondError(w, http.StatusInternalServerError, "Failed to assign bins to shift")
-					return
-				}
+			var routeID *string
+			if req.RouteID != "" {
+				routeID = &req.RouteID
 			}
-		}
 
-		// Commit transaction
-		if err := tx.Commit(); err != nil {
-			log.Printf("❌ Error committing transaction: %v", err)
-			utils.RespondError(w, http.StatusInternalServerError, "Failed to assign route")
-			return
-		}
+			// A bin can only be on one open shift at a time
+			stopBinIDs := req.BinIDs
+			if len(routeBins) > 0 {
+				stopBinIDs = make([]string, 0, len(routeBins))
+				for _, rb := range routeBins {
+					if !withoutCoordinates[rb.BinID] {
+						stopBinIDs = append(stopBinIDs, rb.BinID)
+					}
+				}
+			}
+			reservations, err := database.FindBinReservations(tx, stopBinIDs, shiftID, nil)
+			if err != nil {
+				log.Printf("❌ %v", err)
+				return txFail(http.StatusInternalServerError, "Failed to assign route")
+			}
+			if len(reservations) > 0 {
+				return binReservedError(reservations)
+			}
 
-		// Get created shift
-		var shift models.Shift
-		db.Get(&shift, `SELECT * FROM shifts WHERE id = $1`, shiftID)
+			// If we found pre-defined route bins, use their sequence
+			if len(routeBins) > 0 {
+				log.Printf("✅ Using pre-defined route sequence with %d bins", len(routeBins))
+				routeVersion, err := database.CurrentRouteVersion(tx, req.RouteID, now)
+				if err != nil {
+					log.Printf("❌ Error getting route version: %v", err)
+					return txFail(http.StatusInternalServerError, "Failed to assign route")
+				}
+				if _, err := tx.ExecContext(r.Context(), `UPDATE shifts SET route_version = $1 WHERE id = $2`, routeVersion, shiftID); err != nil {
+					log.Printf("❌ Error recording route version: %v", err)
+					return txFail(http.StatusInternalServerError, "Failed to assign route")
+				}
+				for _, rb := range routeBins {
+					if withoutCoordinates[rb.BinID] {
+						continue
+					}
+					if err := stores.Shifts.InsertCollectionStop(shiftID, rb.BinID, routeID, rb.SequenceOrder, now); err != nil {
+						if database.IsBinReservationViolation(err) {
+							return binReservationRaceError()
+						}
+						log.Printf("❌ Error inserting shift stop: %v", err)
+						return txFail(http.StatusInternalServerError, "Failed to assign bins to shift")
+					}
+				}
+			} else {
+				// Custom selection or route without pre-defined bins - insert with sequence_order = 0
+				log.Printf("ℹ️  Custom bin selection - will optimize from driver's start location")
+				for _, binID := range req.BinIDs {
+					if err := stores.Shifts.InsertCollectionStop(shiftID, binID, routeID, 0, now); err != nil {
+						if database.IsBinReservationViolation(err) {
+							return binReservationRaceError()
+						}
+						log.Printf("❌ Error inserting shift stop: %v", err)
+						return txFail(http.StatusInternalServerError, "Failed to assign bins to shift")
+					}
+				}
+			}
 
-		// Get route bins with details
-		bins, err := getRouteBinsWithDetails(db, shiftID)
-		if err != nil {
-			log.Printf("❌ Error fetching route bins: %v", err)
-			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch route bins")
-			return
-		}
+			// Price the shift from the projection above (compared with its actual cost when it ends)
+			if preview != nil {
+				cost, err := database.RecordShiftCostEstimate(tx, shiftID, req.RouteID, preview.Route.Hours, preview.Route.DistanceKm, loadCostRates(tx))
+				if err != nil {
+					log.Printf("❌ Error recording cost estimate: %v", err)
+					return txFail(http.StatusInternalServerError, "Failed to assign route")
+				}
+				log.Printf("💵 Estimated cost of shift %s: %.2f (%.1f h, %.1f km)", shiftID, cost.Total, cost.Hours, cost.DistanceKm)
+			}
 
-		// Send push notification
-		var fcmToken models.FCMToken
-		tokenErr := db.Get(&fcmToken, `SELECT * FROM fcm_tokens WHERE user_id = $1 ORDER BY created_at DESC LIMIT 1`, req.DriverID)
-		notificationSent := false
+			// Get created shift
+			if err := tx.GetContext(r.Context(), &shift, `SELECT * FROM shifts WHERE id = $1`, shiftID); err != nil {
+				log.Printf("❌ Error fetching created shift: %v", err)
+				return txFail(http.StatusInternalServerError, "Failed to assign route")
+			}
 
-		if tokenErr == nil {
-			err := fcmService.SendRouteAssignedNotification(fcmToken.Token, req.RouteID, totalBins)
+			// Get route bins with details
+			bins, err = stores.Shifts.Stops(shiftID)
 			if err != nil {
-				log.Printf("⚠️  Failed to send FCM notification: %v", err)
-			} else {
-				notificationSent = true
+				log.Printf("❌ Error fetching route bins: %v", err)
+				return txFail(http.StatusInternalServerError, "Failed to fetch route bins")
 			}
-		}
 
-		// Broadcast WebSocket update to driver with FULL shift data
-		log.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-		log.Printf("📡 ATTEMPTING WEBSOCKET BROADCAST")
-		log.Printf("   Target driver_id: %s", req.DriverID)
-		log.Printf("   Is driver connected: %v", hub.IsUserConnected(req.DriverID))
-		log.Printf("   Total connected clients: %d", hub.GetClientCount())
-		log.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+			notificationSent, err = queueRouteAssigned(tx, hub, fcmService, shift, bins, req.RouteID)
+			if err != nil {
+				log.Printf("❌ Error queueing route notification: %v", err)
+				return txFail(http.StatusInternalServerError, "Failed to assign route")
+			}
 
-		hub.BroadcastToUser(req.DriverID, map[string]interface{}{
-			"type": "route_assigned",
-			"data": map[string]interface{}{
-				"id":                  shift.ID,
-				"driver_id":           shift.DriverID,
-				"route_id":            shift.RouteID,
-				"status":              shift.Status, // CRITICAL: Include status for ShiftState.fromJson()
-				"start_time":          shift.StartTime,
-				"end_time":            shift.EndTime,
-				"total_pause_seconds": shift.TotalPauseSeconds,
-				"pause_start_time":    shift.PauseStartTime,
-				"total_bins":          shift.TotalBins,
-				"completed_bins":      shift.CompletedBins,
-				"bins":                bins,
-				"created_at":          shift.CreatedAt,
-				"updated_at":          shift.UpdatedAt,
-				"message":             "New route assigned!",
-			},
+			return nil
 		})
-
-		// Broadcast shift state change to all managers (new driver assigned)
-		broadcastPayload := map[string]interface{}{
-			"type": "driver_shift_change",
-			"data": map[string]interface{}{
-				"driver_id": req.DriverID,
-				"status":    shift.Status,
-				"shift_id":  shiftID,
-			},
+		if err != nil {
+			respondTxError(w, err, "Failed to assign route")
+			return
 		}
-		hub.BroadcastToRole("admin", broadcastPayload)
-		hub.BroadcastToRole("manager", broadcastPayload)
-		log.Printf("📡 Broadcast driver_shift_change to managers: Route assigned to driver")
 
 		log.Printf("✅ Route assigned: %s to driver %s (%d bins)", req.RouteID, req.DriverID, totalBins)
 
@@ -1947,13 +2220,88 @@ func AssignRoute(db *sqlx.DB, hub *websocket.Hub, fcmService *services.FCMServic
 				"total_bins":        totalBins,
 				"bins":              bins,
 				"notification_sent": notificationSent,
+				"warnings":          warnings, // Bins left out (no coordinates)
 			},
 		})
 	}
 }
 
+// queueRouteAssigned queues the notifications for a newly assigned shift in its transaction, so they are only
+// delivered if it commits (see services.NotificationDispatcher - a driver who is offline gets the WebSocket
+// message on reconnect): a push and the full shift to the driver, and the shift change to managers
+// Returns whether a push was queued
+func queueRouteAssigned(tx *sqlx.Tx, hub *websocket.Hub, fcmService *services.FCMService, shift models.Shift,
+	bins []models.ShiftBinWithDetails, routeID string) (bool, error) {
+	pushed, err := queueRouteAssignedToDriver(tx, hub, fcmService, shift, bins, routeID)
+	if err != nil {
+		return false, err
+	}
+
+	// Shift state change for all managers (new driver assigned)
+	broadcastPayload := map[string]interface{}{
+		"type": "driver_shift_change",
+		"data": map[string]interface{}{
+			"driver_id": shift.DriverID,
+			"status":    shift.Status,
+			"shift_id":  shift.ID,
+		},
+	}
+	for _, role := range []string{"admin", "manager"} {
+		if _, err := helpers.EnqueueRoleMessage(tx, role, broadcastPayload); err != nil {
+			return false, fmt.Errorf("failed to queue driver_shift_change: %w", err)
+		}
+	}
+	return pushed, nil
+}
+
+// queueRouteAssignedToDriver queues the driver's part of queueRouteAssigned: the push and the full shift
+func queueRouteAssignedToDriver(tx *sqlx.Tx, hub *websocket.Hub, fcmService *services.FCMService, shift models.Shift,
+	bins []models.ShiftBinWithDetails, routeID string) (bool, error) {
+	pushed := false
+	if fcmService != nil {
+		push := services.RouteAssignedOutboxPush(database.UserLocale(tx, shift.DriverID), routeID, shift.TotalBins)
+		if _, err := helpers.EnqueuePush(tx, shift.DriverID, push); err != nil {
+			return false, fmt.Errorf("failed to queue push: %w", err)
+		}
+		pushed = true
+	}
+
+	// WebSocket update to driver with FULL shift data
+	log.Printf("📡 Queueing route_assigned for driver %s (connected: %v)", shift.DriverID, hub.IsUserConnected(shift.DriverID))
+	_, err := helpers.EnqueueUserMessage(tx, shift.DriverID, map[string]interface{}{
+		"type": "route_assigned",
+		"data": map[string]interface{}{
+			"id":                  shift.ID,
+			"driver_id":           shift.DriverID,
+			"route_id":            shift.RouteID,
+			"status":              shift.Status, // CRITICAL: Include status for ShiftState.fromJson()
+			"start_time":          shift.StartTime,
+			"end_time":            shift.EndTime,
+			"total_pause_seconds": shift.TotalPauseSeconds,
+			"pause_start_time":    shift.PauseStartTime,
+			"total_bins":          shift.TotalBins,
+			"completed_bins":      shift.CompletedBins,
+			"bins":                bins,
+			"created_at":          shift.CreatedAt,
+			"updated_at":          shift.UpdatedAt,
+			"message":             "New route assigned!",
+		},
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to queue route_assigned: %w", err)
+	}
+	return pushed, nil
+}
+
+// fcmTokenRequest is the body for POST /api/driver/fcm-token
+type fcmTokenRequest struct {
+	Token      string `json:"token" validate:"required"`
+	DeviceType string `json:"device_type" validate:"required,oneof=ios android"`
+}
+
 // RegisterFCMToken registers a Firebase Cloud Messaging token
-func RegisterFCMToken(db *sqlx.DB) http.HandlerFunc {
+// The device is also subscribed to its role topic and the organization topic
+func RegisterFCMToken(db *sqlx.DB, fcmService *services.FCMService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userClaims, ok := middleware.GetUserFromContext(r)
 		if !ok {
@@ -1962,10 +2310,7 @@ func RegisterFCMToken(db *sqlx.DB) http.HandlerFunc {
 		}
 
 		// Parse request body
-		var req struct {
-			Token      string `json:"token"`
-			DeviceType string `json:"device_type"`
-		}
+		var req fcmTokenRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
 			return
@@ -1986,7 +2331,7 @@ func RegisterFCMToken(db *sqlx.DB) http.HandlerFunc {
 					  device_type = excluded.device_type,
 					  updated_at = excluded.updated_at`
 
-		_, err := db.Exec(query, userClaims.UserID, req.Token, req.DeviceType, now, now)
+		_, err := db.ExecContext(r.Context(), query, userClaims.UserID, req.Token, req.DeviceType, now, now)
 		if err != nil {
 			log.Printf("❌ Error registering FCM token: %v", err)
 			utils.RespondError(w, http.StatusInternalServerError, "Failed to register FCM token")
@@ -1995,6 +2340,8 @@ func RegisterFCMToken(db *sqlx.DB) http.HandlerFunc {
 
 		log.Printf("📱 FCM token registered: %s (%s)", userClaims.Email, req.DeviceType)
 
+		subscribeFCMTokenToTopics(db, fcmService, req.Token, userClaims.Role)
+
 		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
 			"success": true,
 			"message": "FCM token registered successfully",
@@ -2010,7 +2357,7 @@ func ClearAllShifts(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 		// Get all affected driver IDs before deleting
 		var affectedDrivers []string
 		query := `SELECT DISTINCT driver_id FROM shifts WHERE status != 'inactive'`
-		err := db.Select(&affectedDrivers, query)
+		err := db.SelectContext(r.Context(), &affectedDrivers, query)
 		if err != nil && err != sql.ErrNoRows {
 			log.Printf("⚠️  Error getting affected drivers: %v", err)
 			affectedDrivers = []string{} // Continue even if this fails
@@ -2019,7 +2366,7 @@ func ClearAllShifts(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 		log.Printf("📊 Found %d drivers with active/ready shifts", len(affectedDrivers))
 
 		// Execute delete query
-		result, err := db.Exec("DELETE FROM shifts")
+		result, err := db.ExecContext(r.Context(), "DELETE FROM shifts")
 		if err != nil {
 			log.Printf("❌ Error clearing shifts: %v", err)
 			utils.RespondError(w, http.StatusInternalServerError, "Failed to clear shifts")
@@ -2065,6 +2412,17 @@ func ClearAllShifts(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 	}
 }
 
+// locationUpdateRequest is the body for POST /api/driver/location
+type locationUpdateRequest struct {
+	Latitude  float64  `json:"latitude" validate:"min=-90,max=90"`
+	Longitude float64  `json:"longitude" validate:"min=-180,max=180"`
+	Heading   *float64 `json:"heading"`
+	Speed     *float64 `json:"speed"`
+	Accuracy  *float64 `json:"accuracy"`
+	ShiftID   *string  `json:"shift_id"`
+	Timestamp int64    `json:"timestamp"` // Milliseconds
+}
+
 // UpdateLocation handles driver location updates (POST /api/driver/location)
 // Called every 10 seconds when driver is on active shift
 func UpdateLocation(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
@@ -2075,15 +2433,7 @@ func UpdateLocation(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 			return
 		}
 
-		var req struct {
-			Latitude  float64  `json:"latitude"`
-			Longitude float64  `json:"longitude"`
-			Heading   *float64 `json:"heading"`
-			Speed     *float64 `json:"speed"`
-			Accuracy  *float64 `json:"accuracy"`
-			ShiftID   *string  `json:"shift_id"`
-			Timestamp int64    `json:"timestamp"`
-		}
+		var req locationUpdateRequest
 
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
@@ -2107,7 +2457,7 @@ func UpdateLocation(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 		var locationID int
 		var createdAt int64
 
-		err := db.QueryRow(
+		err := db.QueryRowContext(r.Context(), 
 			query,
 			userClaims.UserID,
 			req.Latitude,
@@ -2125,6 +2475,11 @@ func UpdateLocation(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 			return
 		}
 
+		if err := upsertDriverCurrentLocation(r.Context(), db, userClaims.UserID, req); err != nil {
+			log.Printf("⚠️  Error updating current location: %v", err)
+		}
+		hub.ReportLocation(userClaims.UserID, req.fix())
+
 		// Broadcast location update to all connected managers via WebSocket
 		locationUpdate := map[string]interface{}{
 			"type": "driver_location_update",
@@ -2142,8 +2497,13 @@ func UpdateLocation(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 			},
 		}
 
-		// Broadcast to all managers (users with role "admin")
-		hub.BroadcastToRole("admin", locationUpdate)
+		// Broadcast to the managers subscribed to this driver or the area they're in
+		hub.BroadcastToRoleScoped("admin", websocket.EventScope{
+			Type:      "driver_location_update",
+			DriverID:  userClaims.UserID,
+			Latitude:  &req.Latitude,
+			Longitude: &req.Longitude,
+		}, locationUpdate)
 
 		// Return success response
 		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
@@ -2157,6 +2517,7 @@ func UpdateLocation(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
 // GetAllDrivers returns all drivers regardless of shift status
 // Drivers with active shifts will show their current shift info
 // Drivers without active shifts will show status as 'inactive'
+// Managers assigned to areas get the drivers working in their areas (see driverInAreaScopeSQL) unless all_areas=true
 // GET /api/manager/drivers
 func GetAllDrivers(db *sqlx.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -2175,7 +2536,8 @@ func GetAllDrivers(db *sqlx.DB) http.HandlerFunc {
 				s.completed_bins,
 				s.updated_at,
 				dl.latitude,
-				dl.longitude
+				dl.longitude,
+				u.deactivated_at
 			FROM users u
 			LEFT JOIN shifts s ON u.id = s.driver_id AND s.status IN ('ready', 'active', 'paused')
 			LEFT JOIN (
@@ -2186,6 +2548,8 @@ func GetAllDrivers(db *sqlx.DB) http.HandlerFunc {
 				ORDER BY driver_id, timestamp DESC
 			) dl ON u.id = dl.driver_id
 			WHERE u.role = 'driver'
+			  AND ($1 OR u.deactivated_at IS NULL)
+			  AND ($2::TEXT[] IS NULL OR ` + fmt.Sprintf(driverInAreaScopeSQL, 2, 3) + `)
 			ORDER BY
 				CASE
 					WHEN s.status IS NOT NULL THEN 0  -- Active drivers first
@@ -2195,15 +2559,19 @@ func GetAllDrivers(db *sqlx.DB) http.HandlerFunc {
 				u.name ASC
 		`
 
-		rows, err := db.Query(query)
+		// Managers assigned to areas only see drivers working there (unless all_areas=true)
+		scope, err := managerAreaScope(r, db)
+		if err != nil {
+			log.Printf("❌ Error loading area scope: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch drivers")
+			return
+		}
+
+		includeDeactivated := r.URL.Query().Get("include_deactivated") == "true"
+		rows, err := db.QueryContext(r.Context(), query, includeDeactivated, pq.Array(scope), time.Now().AddDate(0, 0, -areaScopeDriverDays).Unix())
 		if err != nil {
 			log.Printf("❌ Database error: %v", err)
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]interface{}{
-				"success": false,
-				"error":   "Failed to fetch drivers",
-			})
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch drivers")
 			return
 		}
 		defer rows.Close()
@@ -2230,6 +2598,7 @@ func GetAllDrivers(db *sqlx.DB) http.HandlerFunc {
 				&updatedAt,
 				&latitude,
 				&longitude,
+				&driver.DeactivatedAt,
 			)
 			if err != nil {
 				log.Printf("❌ Row scan error: %v", err)
@@ -2278,12 +2647,7 @@ func GetAllDrivers(db *sqlx.DB) http.HandlerFunc {
 
 		if err = rows.Err(); err != nil {
 			log.Printf("❌ Rows iteration error: %v", err)
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]interface{}{
-				"success": false,
-				"error":   "Failed to process drivers",
-			})
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to process drivers")
 			return
 		}
 
@@ -2299,44 +2663,6 @@ func GetAllDrivers(db *sqlx.DB) http.HandlerFunc {
 
 // Helper Functions for Incident Reporting and No-Go Zones
 
-// getZoneRadius returns the radius in meters based on incident type
-func getZoneRadius(incidentType string) int {
-	switch incidentType {
-	case "theft", "vandalized":
-		return 500 // High severity - 500m radius
-	case "damaged", "missing":
-		return 300 // Medium severity - 300m radius
-	case "landlord_complaint":
-		return 200 // Localized issue - 200m radius
-	case "inaccessible", "relocation_request":
-		return 150 // Very localized - 150m radius
-	default:
-		return 250 // Default radius
-	}
-}
-
-// getIncidentScore returns the conflict score to add based on incident type
-func getIncidentScore(incidentType string) int {
-	switch incidentType {
-	case "theft":
-		return 20 // Most severe
-	case "vandalized":
-		return 15
-	case "damaged":
-		return 10
-	case "landlord_complaint":
-		return 8
-	case "missing":
-		return 12
-	case "inaccessible":
-		return 5
-	case "relocation_request":
-		return 3
-	default:
-		return 5
-	}
-}
-
 // calculateZoneDistance calculates the distance in meters between a point and a zone center
 func calculateZoneDistance(lat1, lon1, lat2, lon2 float64) float64 {
 	const earthRadiusMeters = 6371000 // Earth's radius in meters
@@ -2356,6 +2682,98 @@ func calculateZoneDistance(lat1, lon1, lat2, lon2 float64) float64 {
 	return earthRadiusMeters * c
 }
 
+// recordIncidentZone adds an incident's zone score to the active zone within 100m of the bin, or opens a new
+// zone sized for the incident type, then merges overlapping zones; returns the zone ID for zone_incidents
+// Zone errors are logged, not returned - the incident is still worth recording
+func recordIncidentZone(ctx context.Context, db *sqlx.DB, bin models.Bin, incidentType models.IncidentType, now int64) string {
+	existingZone, distance, err := findActiveZoneNear(ctx, db, *bin.Latitude, *bin.Longitude, 100)
+	if err != nil {
+		log.Printf("[DIAGNOSTIC] ⚠️  Error fetching zones: %v", err)
+	} else if existingZone != nil {
+		log.Printf("[DIAGNOSTIC]    Found existing zone within 100m (distance: %.2fm)", distance)
+	}
+
+	var zoneID string
+	if existingZone != nil {
+		zoneID = existingZone.ID
+		newScore := existingZone.ConflictScore + incidentType.ZoneScore
+		_, err = db.ExecContext(ctx, `UPDATE no_go_zones SET conflict_score = $1, updated_at = $2 WHERE id = $3`, newScore, now, zoneID)
+		if err != nil {
+			log.Printf("[DIAGNOSTIC] ❌ Error updating zone: %v", err)
+		} else {
+			log.Printf("[DIAGNOSTIC] ✅ Updated existing zone (new score: %d)", newScore)
+		}
+	} else {
+		zoneID = uuid.New().String()
+		zoneName := fmt.Sprintf("%s - %s", bin.CurrentStreet, bin.City)
+		radiusMeters := incidentType.ZoneRadiusMeters
+		log.Printf("[DIAGNOSTIC]    Creating new zone: %s (radius: %dm)", zoneName, radiusMeters)
+		_, err = db.ExecContext(ctx, `
+			INSERT INTO no_go_zones (id, name, center_latitude, center_longitude, radius_meters, conflict_score, status, created_by_user_id, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		`, zoneID, zoneName, *bin.Latitude, *bin.Longitude, radiusMeters, incidentType.ZoneScore, "active", nil, now, now)
+		if err != nil {
+			log.Printf("[DIAGNOSTIC] ❌ Error creating zone: %v", err)
+		} else {
+			log.Printf("[DIAGNOSTIC] ✅ Created new no-go zone (ID: %s)", zoneID)
+		}
+	}
+
+	// Check for zone merges after creating/updating zone
+	if err == nil {
+		log.Printf("[DIAGNOSTIC] 🔍 Checking for zone merges...")
+		if mergeErr := detectAndMergeZones(db, zoneID, now); mergeErr != nil {
+			log.Printf("[DIAGNOSTIC] ⚠️  Zone merge check failed: %v", mergeErr)
+			// Don't fail the request if merge fails - it's not critical
+		}
+	}
+	return zoneID
+}
+
+// findActiveZoneNear returns the nearest active zone whose center is within maxMeters of a point, or nil
+// Uses the no_go_zones geography index when PostGIS is enabled, otherwise scans the active zones
+func findActiveZoneNear(ctx context.Context, db *sqlx.DB, lat, lng, maxMeters float64) (*models.NoGoZone, float64, error) {
+	if database.PostGISEnabled() {
+		var row struct {
+			models.NoGoZone
+			Distance float64 `db:"distance"`
+		}
+		point := database.PostGISPoint("$2", "$1")
+		err := db.GetContext(ctx, &row, `
+			SELECT *, ST_Distance(geog, `+point+`) AS distance
+			FROM no_go_zones
+			WHERE status = 'active' AND ST_DWithin(geog, `+point+`, $3)
+			ORDER BY distance ASC
+			LIMIT 1
+		`, lat, lng, maxMeters)
+		if err == sql.ErrNoRows {
+			return nil, 0, nil
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		return &row.NoGoZone, row.Distance, nil
+	}
+
+	var zones []models.NoGoZone
+	if err := db.SelectContext(ctx, &zones, "SELECT * FROM no_go_zones WHERE status = 'active'"); err != nil {
+		return nil, 0, err
+	}
+	var nearest *models.NoGoZone
+	nearestDistance := maxMeters
+	for i := range zones {
+		distance := calculateZoneDistance(lat, lng, zones[i].CenterLatitude, zones[i].CenterLongitude)
+		if distance < nearestDistance {
+			nearest = &zones[i]
+			nearestDistance = distance
+		}
+	}
+	if nearest == nil {
+		return nil, 0, nil
+	}
+	return nearest, nearestDistance, nil
+}
+
 // calculateZoneOverlap calculates the overlap percentage between two circular zones
 // Returns the percentage of overlap (0-100) based on the smaller zone
 func calculateZoneOverlap(lat1, lon1 float64, radius1 int, lat2, lon2 float64, radius2 int) float64 {
@@ -2398,9 +2816,15 @@ func detectAndMergeZones(db *sqlx.DB, zoneID string, now int64) error {
 		return fmt.Errorf("failed to fetch current zone: %w", err)
 	}
 
-	// Get all other active zones
+	// Get all other active zones (with PostGIS, only those close enough to touch this one)
 	var otherZones []models.NoGoZone
-	err = db.Select(&otherZones, "SELECT * FROM no_go_zones WHERE status = 'active' AND id != $1 AND merged_into_zone_id IS NULL", zoneID)
+	query := "SELECT * FROM no_go_zones WHERE status = 'active' AND id != $1 AND merged_into_zone_id IS NULL"
+	args := []interface{}{zoneID}
+	if database.PostGISEnabled() {
+		query += " AND ST_DWithin(geog, (SELECT geog FROM no_go_zones WHERE id = $1), radius_meters + $2)"
+		args = append(args, currentZone.RadiusMeters)
+	}
+	err = db.Select(&otherZones, query, args...)
 	if err != nil {
 		return fmt.Errorf("failed to fetch other zones: %w", err)
 	}
@@ -2505,35 +2929,36 @@ func executeMerge(db *sqlx.DB, primaryZone, secondaryZone models.NoGoZone, now i
 }
 
 // handleMoveRequestCompletion handles move request completion logic
-func handleMoveRequestCompletion(db *sqlx.DB, hub *websocket.Hub, moveRequest models.BinMoveRequest, req struct {
-	ShiftBinID            int     `json:"shift_bin_id"`
-	BinID                 string  `json:"bin_id"`
-	UpdatedFillPercentage *int    `json:"updated_fill_percentage,omitempty"`
-	PhotoUrl              *string `json:"photo_url,omitempty"`
-	MoveRequestID         *string `json:"move_request_id,omitempty"` // Links check to move request
-	HasIncident           bool    `json:"has_incident"`
-	IncidentType          *string `json:"incident_type,omitempty"`
-	IncidentPhotoUrl      *string `json:"incident_photo_url,omitempty"`
-	IncidentDescription   *string `json:"incident_description,omitempty"`
-}, now int64) error {
+func handleMoveRequestCompletion(db *sqlx.DB, hub *websocket.Hub, moveRequest models.BinMoveRequest, req completeStopRequest, now int64) error {
 	log.Printf("[MOVE] 🚚 Handling move request completion")
 	log.Printf("[MOVE]    Type: %s", moveRequest.MoveType)
 
-	// Mark move request as completed
-	_, err := db.Exec(`
+	// Mark move request as completed (a move cancelled meanwhile stays cancelled)
+	if err := models.ValidateMoveStatusTransition(moveRequest.Status, models.MoveStatusCompleted); err != nil {
+		return err
+	}
+	result, err := db.Exec(`
 		UPDATE bin_move_requests
-		SET status = 'completed', completed_at = $1, updated_at = $1
-		WHERE id = $2
-	`, now, moveRequest.ID)
+		SET status = $1, completed_at = $2, updated_at = $2
+		WHERE id = $3 AND status = $4
+	`, models.MoveStatusCompleted, now, moveRequest.ID, moveRequest.Status)
 	if err != nil {
 		return fmt.Errorf("failed to complete move request: %w", err)
 	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("move request %s changed from %s before it could be completed", moveRequest.ID, moveRequest.Status)
+	}
 	log.Printf("[MOVE] ✅ Move request marked as completed")
+	completedBy := ""
+	if moveRequest.AssignedUserID != nil {
+		completedBy = *moveRequest.AssignedUserID
+	}
+	emitMoveRequestCompletedWebhook(db, moveRequest, completedBy, now)
 
 	// Log history: move request completed by driver
 	if moveRequest.AssignedUserID != nil {
 		var driverName string
-		err = db.Get(&driverName, `SELECT name FROM users WHERE id = $1`, *moveRequest.AssignedUserID)
+		driverName, err = store.New(db).Users.Name(*moveRequest.AssignedUserID)
 		if err != nil {
 			log.Printf("Warning: Failed to fetch driver name for history: %v", err)
 			driverName = "Unknown Driver"
@@ -2588,7 +3013,11 @@ func handleMoveRequestCompletion(db *sqlx.DB, hub *websocket.Hub, moveRequest mo
 		if err != nil {
 			return fmt.Errorf("failed to update bin status: %w", err)
 		}
+		store.InvalidateBins(moveRequest.BinID)
 		log.Printf("[MOVE] ✅ Bin status updated to %s", newStatus)
+		if newStatus == "retired" {
+			emitBinRetiredWebhook(db, moveRequest.BinID, completedBy, &moveRequest.ID, moveRequest.Reason, now)
+		}
 
 	} else if moveRequest.MoveType == "relocation" {
 		// Update bin location to new coordinates
@@ -2609,53 +3038,22 @@ func handleMoveRequestCompletion(db *sqlx.DB, hub *websocket.Hub, moveRequest mo
 		if err != nil {
 			return fmt.Errorf("failed to relocate bin: %w", err)
 		}
+		store.InvalidateBins(moveRequest.BinID)
 
 		// Record the move in moves table
-		// Parse address into separate fields
-		var fromStreet, fromCity, fromZip, toStreet, toCity, toZip *string
-
-		// Parse original address
-		if moveRequest.OriginalAddress != "" {
-			parts := strings.Split(moveRequest.OriginalAddress, ", ")
-			if len(parts) >= 2 {
-				street := parts[0]
-				fromStreet = &street
-				cityZip := strings.TrimSpace(parts[1])
-				cityZipParts := strings.Split(cityZip, " ")
-				if len(cityZipParts) >= 2 {
-					city := strings.Join(cityZipParts[:len(cityZipParts)-1], " ")
-					zip := cityZipParts[len(cityZipParts)-1]
-					fromCity = &city
-					fromZip = &zip
-				}
-			}
-		}
-
-		// Parse new address
-		if moveRequest.NewAddress != nil {
-			parts := strings.Split(*moveRequest.NewAddress, ", ")
-			if len(parts) >= 2 {
-				street := parts[0]
-				toStreet = &street
-				cityZip := strings.TrimSpace(parts[1])
-				cityZipParts := strings.Split(cityZip, " ")
-				if len(cityZipParts) >= 2 {
-					city := strings.Join(cityZipParts[:len(cityZipParts)-1], " ")
-					zip := cityZipParts[len(cityZipParts)-1]
-					toCity = &city
-					toZip = &zip
-				}
-			}
-		}
+		// Address parts stored with the move request
+		fromStreet, fromCity, fromZip := moveRequest.OriginalStreet, moveRequest.OriginalCity, moveRequest.OriginalZip
+		toStreet, toCity, toZip := moveRequest.NewStreet, moveRequest.NewCity, moveRequest.NewZip
 
 		_, err = db.Exec(`
 			INSERT INTO moves (
 				bin_id, moved_from, moved_to, moved_on,
 				move_type, from_street, from_city, from_zip,
 				to_street, to_city, to_zip,
-				move_request_id, shift_id
+				move_request_id, shift_id,
+				from_latitude, from_longitude, to_latitude, to_longitude
 			)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
 		`, moveRequest.BinID,
 			moveRequest.OriginalAddress,
 			*moveRequest.NewAddress,
@@ -2664,7 +3062,9 @@ func handleMoveRequestCompletion(db *sqlx.DB, hub *websocket.Hub, moveRequest mo
 			fromStreet, fromCity, fromZip,
 			toStreet, toCity, toZip,
 			moveRequest.ID,
-			moveRequest.AssignedShiftID)
+			moveRequest.AssignedShiftID,
+			moveRequest.OriginalLatitude, moveRequest.OriginalLongitude,
+			moveRequest.NewLatitude, moveRequest.NewLongitude)
 		if err != nil {
 			log.Printf("[MOVE] ⚠️  Failed to record move: %v", err)
 			// Don't fail - move is already completed
@@ -2692,7 +3092,7 @@ func CancelShift(db *sqlx.DB, wsHub *websocket.Hub, fcmService *services.FCMServ
 
 		// Get shift details for websocket/FCM notifications
 		var shift models.Shift
-		err := db.Get(&shift, "SELECT * FROM shifts WHERE id = $1", shiftID)
+		err := db.GetContext(r.Context(), &shift, "SELECT * FROM shifts WHERE id = $1", shiftID)
 		if err != nil {
 			if err == sql.ErrNoRows {
 				utils.RespondError(w, http.StatusNotFound, "Shift not found")
@@ -2709,81 +3109,71 @@ func CancelShift(db *sqlx.DB, wsHub *websocket.Hub, fcmService *services.FCMServ
 			return
 		}
 
-		// Start transaction
-		tx, err := db.Beginx()
-		if err != nil {
-			log.Printf("❌ Error starting transaction: %v", err)
-			utils.RespondError(w, http.StatusInternalServerError, "Failed to start transaction")
-			return
-		}
-		defer tx.Rollback()
-
-		// 1. Update shift status to cancelled
-		_, err = tx.Exec(`
-			UPDATE shifts
-			SET status = 'cancelled', updated_at = $1
-			WHERE id = $2
-		`, now, shiftID)
-		if err != nil {
-			log.Printf("❌ Error updating shift status: %v", err)
-			utils.RespondError(w, http.StatusInternalServerError, "Failed to cancel shift")
-			return
-		}
+		// The cancellation and the released move requests commit together
+		err = database.WithTx(r.Context(), db, func(tx *sqlx.Tx) error {
+			// 1. Update shift status to cancelled
+			_, err = tx.ExecContext(r.Context(), `
+				UPDATE shifts
+				SET status = 'cancelled', updated_at = $1
+				WHERE id = $2
+			`, now, shiftID)
+			if err != nil {
+				log.Printf("❌ Error updating shift status: %v", err)
+				return txFail(http.StatusInternalServerError, "Failed to cancel shift")
+			}
 
-		// 2. Return all in_progress move requests to pending
-		result, err := tx.Exec(`
-			UPDATE bin_move_requests
-			SET status = 'pending',
-			    assigned_shift_id = NULL,
-			    updated_at = $1
-			WHERE assigned_shift_id = $2
-			AND status = 'in_progress'
-		`, now, shiftID)
-		if err != nil {
-			log.Printf("⚠️  Error returning move requests to pending: %v", err)
-			// Don't fail - continue
-		} else {
-			rowsAffected, _ := result.RowsAffected()
+			// 2. Return all in_progress move requests to pending
+			stores := store.New(tx)
+			rowsAffected, err := stores.MoveRequests.ReleaseInProgress(now, shiftID)
+			if err != nil {
+				return fmt.Errorf("failed to return move requests to pending: %w", err)
+			}
 			if rowsAffected > 0 {
 				log.Printf("✅ Returned %d move request(s) to pending status", rowsAffected)
 			}
-		}
 
-		// 3. Delete shift_bins entries (cleanup)
-		_, err = tx.Exec(`DELETE FROM shift_bins WHERE shift_id = $1`, shiftID)
-		if err != nil {
-			log.Printf("⚠️  Error deleting shift_bins: %v", err)
-			// Don't fail - continue
-		}
+			// 3. Remove their stops from the route (completed stops stay for history)
+			if _, err := stores.Shifts.RemoveReleasedMoveStops(shiftID); err != nil {
+				return fmt.Errorf("failed to remove move stops: %w", err)
+			}
 
-		// Commit transaction
-		if err := tx.Commit(); err != nil {
-			log.Printf("❌ Error committing transaction: %v", err)
-			utils.RespondError(w, http.StatusInternalServerError, "Failed to commit cancellation")
+			return nil
+		})
+		if err != nil {
+			respondTxError(w, err, "Failed to cancel shift")
 			return
 		}
 
 		log.Printf("✅ Shift %s cancelled successfully", shiftID)
+		cancelledBy := ""
+		if userClaims, ok := middleware.GetUserFromContext(r); ok {
+			cancelledBy = userClaims.UserID
+		}
+		emitShiftWebhook(db, models.WebhookEventShiftCancelled, shift, map[string]interface{}{
+			"status":               models.ShiftStatusCancelled,
+			"previous_status":      shift.Status,
+			"cancelled_at":         now,
+			"cancelled_by_user_id": cancelledBy,
+		})
 
 		// 4. Send WebSocket notification to driver's mobile app
+		driverLocale := database.UserLocale(db, shift.DriverID)
 		wsHub.BroadcastToUser(shift.DriverID, map[string]interface{}{
 			"type": "shift_cancelled",
 			"data": map[string]interface{}{
 				"shift_id":     shiftID,
 				"cancelled_at": now,
-				"message":      "Your shift has been cancelled by management",
+				"message":      i18n.T(driverLocale, "Your shift has been cancelled by management"),
 			},
 		})
 		log.Printf("📡 Sent shift_cancelled websocket to driver %s", shift.DriverID)
 
-		// 5. Send FCM push notification to driver
+		// 5. Send FCM push notification to all of the driver's devices
 		if fcmService != nil {
-			// Get driver's FCM token
-			var fcmToken sql.NullString
-			err = db.Get(&fcmToken, "SELECT fcm_token FROM users WHERE id = $1", shift.DriverID)
-			if err == nil && fcmToken.Valid && fcmToken.String != "" {
-				fcmErr := fcmService.SendShiftUpdateNotification(
-					fcmToken.String,
+			if tokens := getUserFCMTokens(db, shift.DriverID); len(tokens) > 0 {
+				invalidTokens, fcmErr := fcmService.SendShiftUpdateNotification(
+					tokens,
+					driverLocale,
 					shiftID,
 					"shift_cancelled",
 				)
@@ -2792,6 +3182,7 @@ func CancelShift(db *sqlx.DB, wsHub *websocket.Hub, fcmService *services.FCMServ
 				} else {
 					log.Printf("📱 Sent FCM notification to driver")
 				}
+				retireFCMTokens(db, invalidTokens)
 			}
 		}
 
@@ -2835,7 +3226,7 @@ func CancelAllActiveShifts(db *sqlx.DB, wsHub *websocket.Hub, fcmService *servic
 
 		// Get all active/paused shifts
 		var shifts []models.Shift
-		err := db.Select(&shifts, `
+		err := db.SelectContext(r.Context(), &shifts, `
 			SELECT * FROM shifts
 			WHERE status IN ('active', 'paused', 'ready')
 		`)
@@ -2858,86 +3249,79 @@ func CancelAllActiveShifts(db *sqlx.DB, wsHub *websocket.Hub, fcmService *servic
 
 		log.Printf("📋 Found %d active/paused shift(s) to cancel", len(shifts))
 
-		// Start transaction
-		tx, err := db.Beginx()
-		if err != nil {
-			log.Printf("❌ Error starting transaction: %v", err)
-			utils.RespondError(w, http.StatusInternalServerError, "Failed to start transaction")
-			return
-		}
-		defer tx.Rollback()
-
 		// Collect shift IDs
 		shiftIDs := make([]string, len(shifts))
 		for i, shift := range shifts {
 			shiftIDs[i] = shift.ID
 		}
 
-		// 1. Update all shifts to cancelled
-		query, args, err := sqlx.In(`
-			UPDATE shifts
-			SET status = 'cancelled', updated_at = ?
-			WHERE id IN (?)
-		`, now, shiftIDs)
-		if err != nil {
-			log.Printf("❌ Error building update query: %v", err)
-			utils.RespondError(w, http.StatusInternalServerError, "Failed to build query")
-			return
-		}
-		query = tx.Rebind(query)
-		_, err = tx.Exec(query, args...)
-		if err != nil {
-			log.Printf("❌ Error updating shifts: %v", err)
-			utils.RespondError(w, http.StatusInternalServerError, "Failed to cancel shifts")
-			return
-		}
-
-		// 2. Return all in_progress move requests to pending
-		moveQuery, moveArgs, err := sqlx.In(`
-			UPDATE bin_move_requests
-			SET status = 'pending',
-			    assigned_shift_id = NULL,
-			    updated_at = ?
-			WHERE assigned_shift_id IN (?)
-			AND status = 'in_progress'
-		`, now, shiftIDs)
-		if err != nil {
-			log.Printf("⚠️  Error building move request query: %v", err)
-		} else {
-			moveQuery = tx.Rebind(moveQuery)
-			result, err := tx.Exec(moveQuery, moveArgs...)
+		// The cancellations and the released move requests commit together
+		err = database.WithTx(r.Context(), db, func(tx *sqlx.Tx) error {
+			// 1. Update all shifts to cancelled
+			query, args, err := sqlx.In(`
+				UPDATE shifts
+				SET status = 'cancelled', updated_at = ?
+				WHERE id IN (?)
+			`, now, shiftIDs)
 			if err != nil {
-				log.Printf("⚠️  Error returning move requests to pending: %v", err)
-			} else {
-				rowsAffected, _ := result.RowsAffected()
-				if rowsAffected > 0 {
-					log.Printf("✅ Returned %d move request(s) to pending status", rowsAffected)
-				}
+				log.Printf("❌ Error building update query: %v", err)
+				return txFail(http.StatusInternalServerError, "Failed to build query")
+			}
+			query = tx.Rebind(query)
+			_, err = tx.ExecContext(r.Context(), query, args...)
+			if err != nil {
+				log.Printf("❌ Error updating shifts: %v", err)
+				return txFail(http.StatusInternalServerError, "Failed to cancel shifts")
 			}
-		}
 
-		// 3. Delete shift_bins entries
-		deleteQuery, deleteArgs, err := sqlx.In(`DELETE FROM shift_bins WHERE shift_id IN (?)`, shiftIDs)
-		if err != nil {
-			log.Printf("⚠️  Error building delete query: %v", err)
-		} else {
-			deleteQuery = tx.Rebind(deleteQuery)
-			_, err = tx.Exec(deleteQuery, deleteArgs...)
+			// 2. Return all in_progress move requests to pending
+			stores := store.New(tx)
+			rowsAffected, err := stores.MoveRequests.ReleaseInProgress(now, shiftIDs...)
 			if err != nil {
-				log.Printf("⚠️  Error deleting shift_bins: %v", err)
+				return fmt.Errorf("failed to return move requests to pending: %w", err)
+			}
+			if rowsAffected > 0 {
+				log.Printf("✅ Returned %d move request(s) to pending status", rowsAffected)
 			}
-		}
 
-		// Commit transaction
-		if err := tx.Commit(); err != nil {
-			log.Printf("❌ Error committing transaction: %v", err)
-			utils.RespondError(w, http.StatusInternalServerError, "Failed to commit cancellations")
+			// 3. Remove their stops from the routes (completed stops stay for history)
+			if _, err := stores.Shifts.RemoveReleasedMoveStops(shiftIDs...); err != nil {
+				return fmt.Errorf("failed to remove move stops: %w", err)
+			}
+
+			return nil
+		})
+		if err != nil {
+			respondTxError(w, err, "Failed to cancel shifts")
 			return
 		}
 
 		log.Printf("✅ Cancelled %d shift(s) successfully", len(shifts))
+		cancelledBy := ""
+		if userClaims, ok := middleware.GetUserFromContext(r); ok {
+			cancelledBy = userClaims.UserID
+		}
+		for _, shift := range shifts {
+			emitShiftWebhook(db, models.WebhookEventShiftCancelled, shift, map[string]interface{}{
+				"status":               models.ShiftStatusCancelled,
+				"previous_status":      shift.Status,
+				"cancelled_at":         now,
+				"cancelled_by_user_id": cancelledBy,
+			})
+		}
 
 		// 4. Send notifications to each affected driver
+		driverIDs := make([]string, 0, len(shifts))
+		for _, shift := range shifts {
+			driverIDs = append(driverIDs, shift.DriverID)
+		}
+		tokensByDriver := map[string][]string{}
+		if fcmService != nil {
+			tokensByDriver = getUsersFCMTokens(db, driverIDs)
+		}
+		localesByDriver := database.UserLocales(db, driverIDs)
+		var pushes []services.ShiftUpdatePush
+
 		for _, shift := range shifts {
 			// WebSocket notification
 			wsHub.BroadcastToUser(shift.DriverID, map[string]interface{}{
@@ -2945,25 +3329,27 @@ func CancelAllActiveShifts(db *sqlx.DB, wsHub *websocket.Hub, fcmService *servic
 				"data": map[string]interface{}{
 					"shift_id":     shift.ID,
 					"cancelled_at": now,
-					"message":      "Your shift has been cancelled by management",
+					"message":      i18n.T(localesByDriver[shift.DriverID], "Your shift has been cancelled by management"),
 				},
 			})
 
-			// FCM push notification
-			if fcmService != nil {
-				var fcmToken sql.NullString
-				err = db.Get(&fcmToken, "SELECT fcm_token FROM users WHERE id = $1", shift.DriverID)
-				if err == nil && fcmToken.Valid && fcmToken.String != "" {
-					fcmErr := fcmService.SendShiftUpdateNotification(
-						fcmToken.String,
-						shift.ID,
-						"shift_cancelled",
-					)
-					if fcmErr != nil {
-						log.Printf("⚠️  Failed to send FCM to driver %s: %v", shift.DriverID, fcmErr)
-					}
-				}
+			// Queue FCM push notifications (sent as one batch below)
+			for _, token := range tokensByDriver[shift.DriverID] {
+				pushes = append(pushes, services.ShiftUpdatePush{
+					Token:   token,
+					Locale:  localesByDriver[shift.DriverID],
+					ShiftID: shift.ID,
+					Status:  "shift_cancelled",
+				})
+			}
+		}
+
+		if fcmService != nil && len(pushes) > 0 {
+			invalidTokens, fcmErr := fcmService.SendShiftUpdateNotifications(pushes)
+			if fcmErr != nil {
+				log.Printf("⚠️  Failed to send batched FCM notifications: %v", fcmErr)
 			}
+			retireFCMTokens(db, invalidTokens)
 		}
 
 		log.Printf("📡 Sent notifications to %d driver(s)", len(shifts))
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/services"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// respondSimulationError maps route simulator errors to responses
+func respondSimulationError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrSimulationDisabled):
+		utils.RespondError(w, http.StatusForbidden, "Simulation mode is disabled on this server")
+	case errors.Is(err, services.ErrSimulationNotFound), errors.Is(err, services.ErrSimulationRouteNotFound):
+		utils.RespondError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrSimulationEnded), errors.Is(err, services.ErrSimulationLimit):
+		utils.RespondError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrSimulationRouteTooShort):
+		utils.RespondError(w, http.StatusUnprocessableEntity, err.Error())
+	default:
+		log.Printf("❌ [SIMULATION] %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, fallback)
+	}
+}
+
+// StartSimulation starts virtual drivers on a route for training and demos (needs SIMULATION_ENABLED=true)
+// They appear on the manager map through the usual WebSocket events, tagged "simulated": true, and are
+// never stored
+// POST /api/manager/simulations
+// Body: { "route_id": "...", "drivers": 3, "speed_kmh": 40, "stop_seconds": 20, "update_seconds": 2, "loop": true }
+func StartSimulation(simulator *services.RouteSimulator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var req models.StartSimulationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if err := req.Normalize(); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		run, err := simulator.Start(req, userClaims.Email)
+		if err != nil {
+			respondSimulationError(w, err, "Failed to start simulation")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{
+			"success": true,
+			"data":    run,
+		})
+	}
+}
+
+// simulationsResponse is the response of GET /api/manager/simulations
+type simulationsResponse struct {
+	Enabled     bool                   `json:"enabled"` // False unless SIMULATION_ENABLED=true
+	Simulations []models.SimulationRun `json:"simulations"`
+}
+
+// GetSimulations lists running and recently ended simulations with their drivers' positions, newest first
+// GET /api/manager/simulations
+func GetSimulations(simulator *services.RouteSimulator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data": simulationsResponse{
+				Enabled:     simulator.Enabled(),
+				Simulations: simulator.List(),
+			},
+		})
+	}
+}
+
+// GetSimulation returns one simulation with its drivers' positions
+// GET /api/manager/simulations/{id}
+func GetSimulation(simulator *services.RouteSimulator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		run, err := simulator.Get(chi.URLParam(r, "id"))
+		if err != nil {
+			respondSimulationError(w, err, "Failed to fetch simulation")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    run,
+		})
+	}
+}
+
+// StopSimulation stops a running simulation; its drivers go off shift on the map
+// POST /api/manager/simulations/{id}/stop
+func StopSimulation(simulator *services.RouteSimulator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		run, err := simulator.Stop(chi.URLParam(r, "id"))
+		if err != nil {
+			respondSimulationError(w, err, "Failed to stop simulation")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    run,
+		})
+	}
+}
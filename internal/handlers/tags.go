@@ -0,0 +1,505 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// tagFilter returns the tag IDs of a list's ?tag_id= filter (comma-separated; bins with any of them match), or nil
+func tagFilter(q url.Values) []string {
+	v := q.Get("tag_id")
+	if v == "" {
+		return nil
+	}
+	var tagIDs []string
+	for _, id := range strings.Split(v, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			tagIDs = append(tagIDs, id)
+		}
+	}
+	return tagIDs
+}
+
+// loadBinTags returns the tags of each of the bins, by name
+func loadBinTags(ctx context.Context, db sqlx.QueryerContext, binIDs []string) (map[string][]models.TagLabel, error) {
+	byBin := map[string][]models.TagLabel{}
+	if len(binIDs) == 0 {
+		return byBin, nil
+	}
+	var rows []struct {
+		BinID string `db:"bin_id"`
+		models.TagLabel
+	}
+	err := sqlx.SelectContext(ctx, db, &rows, `
+		SELECT bt.bin_id, t.id, t.name, t.color
+		FROM bin_tags bt
+		JOIN tags t ON t.id = bt.tag_id
+		WHERE bt.bin_id = ANY($1)
+		ORDER BY LOWER(t.name)
+	`, pq.Array(binIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load bin tags: %w", err)
+	}
+	for _, row := range rows {
+		byBin[row.BinID] = append(byBin[row.BinID], row.TagLabel)
+	}
+	return byBin, nil
+}
+
+// missingTags returns the tag IDs that don't exist
+func missingTags(ctx context.Context, db sqlx.QueryerContext, tagIDs []string) ([]string, error) {
+	var found []string
+	if err := sqlx.SelectContext(ctx, db, &found, `SELECT id FROM tags WHERE id = ANY($1)`, pq.Array(tagIDs)); err != nil {
+		return nil, fmt.Errorf("failed to look up tags: %w", err)
+	}
+	exists := make(map[string]bool, len(found))
+	for _, id := range found {
+		exists[id] = true
+	}
+	var missing []string
+	for _, id := range tagIDs {
+		if !exists[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing, nil
+}
+
+// isTagNameTaken reports whether err is a duplicate tag name
+func isTagNameTaken(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23505"
+}
+
+// GetTags returns all tags with their bin counts, by name
+// GET /api/manager/tags
+func GetTags(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tags := []models.TagWithCount{}
+		err := db.SelectContext(r.Context(), &tags, `
+			SELECT t.*, (SELECT COUNT(*) FROM bin_tags bt WHERE bt.tag_id = t.id) AS bin_count
+			FROM tags t
+			ORDER BY LOWER(t.name)
+		`)
+		if err != nil {
+			log.Printf("❌ [TAGS] Failed to fetch tags: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch tags")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    tags,
+		})
+	}
+}
+
+// CreateTag creates a tag
+// POST /api/manager/tags
+// Body: { "name": "high theft", "color": "#D32F2F" }
+func CreateTag(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var req models.TagRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if err := req.Normalize(true); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		now := time.Now().Unix()
+		tag := models.Tag{
+			ID:              uuid.New().String(),
+			Name:            *req.Name,
+			CreatedByUserID: &userClaims.UserID,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		}
+		if req.Color != nil && *req.Color != "" {
+			tag.Color = req.Color
+		}
+
+		_, err := db.ExecContext(r.Context(), `
+			INSERT INTO tags (id, name, color, created_by_user_id, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, tag.ID, tag.Name, tag.Color, tag.CreatedByUserID, tag.CreatedAt, tag.UpdatedAt)
+		if err != nil {
+			if isTagNameTaken(err) {
+				utils.RespondError(w, http.StatusConflict, "A tag with this name already exists")
+				return
+			}
+			log.Printf("❌ [TAGS] Failed to create tag: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to create tag")
+			return
+		}
+
+		log.Printf("✅ [TAGS] %s created tag %s (%s)", userClaims.Email, tag.Name, tag.ID)
+
+		utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{
+			"success": true,
+			"data":    tag,
+		})
+	}
+}
+
+// UpdateTag renames and/or recolors a tag
+// PUT /api/manager/tags/{id}
+// Body: { "name": "...", "color": "#RRGGBB" } (both optional; an empty color clears it)
+func UpdateTag(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tagID := chi.URLParam(r, "id")
+
+		var req models.TagRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if err := req.Normalize(false); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var tag models.Tag
+		err := db.GetContext(r.Context(), &tag, `SELECT * FROM tags WHERE id = $1`, tagID)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "Tag not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [TAGS] Failed to fetch tag %s: %v", tagID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch tag")
+			return
+		}
+
+		if req.Name != nil {
+			tag.Name = *req.Name
+		}
+		if req.Color != nil {
+			tag.Color = req.Color
+			if *req.Color == "" {
+				tag.Color = nil
+			}
+		}
+		tag.UpdatedAt = time.Now().Unix()
+
+		_, err = db.ExecContext(r.Context(), `UPDATE tags SET name = $1, color = $2, updated_at = $3 WHERE id = $4`,
+			tag.Name, tag.Color, tag.UpdatedAt, tag.ID)
+		if err != nil {
+			if isTagNameTaken(err) {
+				utils.RespondError(w, http.StatusConflict, "A tag with this name already exists")
+				return
+			}
+			log.Printf("❌ [TAGS] Failed to update tag %s: %v", tagID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to update tag")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    tag,
+		})
+	}
+}
+
+// DeleteTag deletes a tag and removes it from every bin
+// DELETE /api/manager/tags/{id}
+func DeleteTag(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tagID := chi.URLParam(r, "id")
+
+		result, err := db.ExecContext(r.Context(), `DELETE FROM tags WHERE id = $1`, tagID)
+		if err != nil {
+			log.Printf("❌ [TAGS] Failed to delete tag %s: %v", tagID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to delete tag")
+			return
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			utils.RespondError(w, http.StatusNotFound, "Tag not found")
+			return
+		}
+
+		log.Printf("🗑️  [TAGS] Deleted tag %s", tagID)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+		})
+	}
+}
+
+// SetBinTags replaces a bin's tags
+// PUT /api/manager/bins/{id}/tags
+// Body: { "tag_ids": ["...", "..."] } (empty removes every tag)
+func SetBinTags(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		binID := chi.URLParam(r, "id")
+
+		var req models.BinTagsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		tagIDs := uniqueStrings(req.TagIDs)
+
+		var exists bool
+		if err := db.GetContext(r.Context(), &exists, `SELECT EXISTS(SELECT 1 FROM bins WHERE id = $1)`, binID); err != nil {
+			log.Printf("❌ [TAGS] Failed to look up bin %s: %v", binID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to update bin tags")
+			return
+		}
+		if !exists {
+			utils.RespondError(w, http.StatusNotFound, "Bin not found")
+			return
+		}
+		missing, err := missingTags(r.Context(), db, tagIDs)
+		if err != nil {
+			log.Printf("❌ [TAGS] %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to update bin tags")
+			return
+		}
+		if len(missing) > 0 {
+			utils.RespondErrorCode(w, http.StatusBadRequest, utils.CodeInvalidReference, "Unknown tag_ids", missing)
+			return
+		}
+
+		err = database.WithTx(r.Context(), db, func(tx *sqlx.Tx) error {
+			if _, err := tx.ExecContext(r.Context(), `DELETE FROM bin_tags WHERE bin_id = $1 AND NOT (tag_id = ANY($2))`, binID, pq.Array(tagIDs)); err != nil {
+				log.Printf("❌ [TAGS] Failed to remove tags of bin %s: %v", binID, err)
+				return txFail(http.StatusInternalServerError, "Failed to update bin tags")
+			}
+			_, err := tx.ExecContext(r.Context(), `
+				INSERT INTO bin_tags (bin_id, tag_id, created_at)
+				SELECT $1, UNNEST($2::TEXT[]), $3
+				ON CONFLICT DO NOTHING
+			`, binID, pq.Array(tagIDs), time.Now().Unix())
+			if err != nil {
+				log.Printf("❌ [TAGS] Failed to add tags to bin %s: %v", binID, err)
+				return txFail(http.StatusInternalServerError, "Failed to update bin tags")
+			}
+			return nil
+		})
+		if err != nil {
+			respondTxError(w, err, "Failed to commit transaction")
+			return
+		}
+
+		tags, err := loadBinTags(r.Context(), db, []string{binID})
+		if err != nil {
+			log.Printf("❌ [TAGS] %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch bin tags")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    append([]models.TagLabel{}, tags[binID]...),
+		})
+	}
+}
+
+// AddTagToBins tags several bins at once (bins that already have the tag are left as they are)
+// POST /api/manager/tags/{id}/bins
+// Body: { "bin_ids": ["...", "..."] }
+func AddTagToBins(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tagID := chi.URLParam(r, "id")
+
+		var req models.TagBinsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		binIDs := uniqueStrings(req.BinIDs)
+		if len(binIDs) == 0 {
+			utils.RespondError(w, http.StatusBadRequest, "At least one bin_id is required")
+			return
+		}
+
+		if missing, err := missingTags(r.Context(), db, []string{tagID}); err != nil {
+			log.Printf("❌ [TAGS] %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to tag bins")
+			return
+		} else if len(missing) > 0 {
+			utils.RespondError(w, http.StatusNotFound, "Tag not found")
+			return
+		}
+
+		var count int
+		if err := db.GetContext(r.Context(), &count, `SELECT COUNT(*) FROM bins WHERE id = ANY($1)`, pq.Array(binIDs)); err != nil {
+			log.Printf("❌ [TAGS] Failed to validate bins: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to tag bins")
+			return
+		}
+		if count != len(binIDs) {
+			utils.RespondError(w, http.StatusBadRequest, "One or more bin_ids are invalid")
+			return
+		}
+
+		result, err := db.ExecContext(r.Context(), `
+			INSERT INTO bin_tags (bin_id, tag_id, created_at)
+			SELECT UNNEST($1::TEXT[]), $2, $3
+			ON CONFLICT DO NOTHING
+		`, pq.Array(binIDs), tagID, time.Now().Unix())
+		if err != nil {
+			log.Printf("❌ [TAGS] Failed to tag bins with %s: %v", tagID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to tag bins")
+			return
+		}
+		added, _ := result.RowsAffected()
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data": map[string]interface{}{
+				"tag_id": tagID,
+				"added":  added,
+			},
+		})
+	}
+}
+
+// RemoveTagFromBin removes a tag from one bin
+// DELETE /api/manager/tags/{id}/bins/{binId}
+func RemoveTagFromBin(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tagID := chi.URLParam(r, "id")
+		binID := chi.URLParam(r, "binId")
+
+		result, err := db.ExecContext(r.Context(), `DELETE FROM bin_tags WHERE tag_id = $1 AND bin_id = $2`, tagID, binID)
+		if err != nil {
+			log.Printf("❌ [TAGS] Failed to remove tag %s from bin %s: %v", tagID, binID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to remove tag")
+			return
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			utils.RespondError(w, http.StatusNotFound, "Bin doesn't have this tag")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+		})
+	}
+}
+
+// GenerateRouteFromTags creates a route blueprint from the active bins with the given tags, highest weighted
+// priority score first (e.g. a "university" route). Bins without coordinates are left out with a warning
+// POST /api/routes/from-tags
+func GenerateRouteFromTags(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req models.GenerateRouteFromTagsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		tagIDs := uniqueStrings(req.TagIDs)
+		if req.Name == "" || req.GeographicArea == "" || len(tagIDs) == 0 {
+			utils.RespondError(w, http.StatusBadRequest, "Missing required fields: name, geographic_area, and tag_ids")
+			return
+		}
+
+		missing, err := missingTags(r.Context(), db, tagIDs)
+		if err != nil {
+			log.Printf("❌ [TAGS] %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to generate route")
+			return
+		}
+		if len(missing) > 0 {
+			utils.RespondErrorCode(w, http.StatusBadRequest, utils.CodeInvalidReference, "Unknown tag_ids", missing)
+			return
+		}
+
+		// Bins with any (or all) of the tags, highest weighted priority first (same score as GET /api/bins/priority)
+		required := 1
+		if req.MatchAll {
+			required = len(tagIDs)
+		}
+		args := []interface{}{}
+		scoreExpr := binPriorityScoreSQL(loadPriorityWeights(db), loadFillThresholds(db), time.Now().Unix(), &args)
+		query := `SELECT p.*, ` + scoreExpr + ` AS priority_score
+			FROM (` + binPriorityBaseSQL + `) p
+			WHERE p.status = 'active'`
+		if req.AreaID != nil {
+			args = append(args, *req.AreaID)
+			query += fmt.Sprintf(` AND p.area_id = $%d`, len(args))
+		}
+		args = append(args, pq.Array(tagIDs), required)
+		query += fmt.Sprintf(` AND (SELECT COUNT(*) FROM bin_tags bt WHERE bt.bin_id = p.id AND bt.tag_id = ANY($%d)) >= $%d`, len(args)-1, len(args))
+		query += ` ORDER BY priority_score DESC, p.bin_number ASC`
+
+		bins := []BinWithPriority{}
+		err = db.SelectContext(r.Context(), &bins, query, args...)
+		if err != nil {
+			log.Printf("❌ [TAGS] Failed to fetch tagged bins: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to generate route")
+			return
+		}
+
+		warnings := []string{}
+		binIDs := make([]string, 0, len(bins))
+		for _, bin := range bins {
+			if bin.Latitude == nil || bin.Longitude == nil {
+				warnings = append(warnings, missingCoordinatesWarning(models.BinMissingCoordinates{
+					ID: bin.ID, BinNumber: bin.BinNumber, CurrentStreet: bin.CurrentStreet,
+				}))
+				continue
+			}
+			binIDs = append(binIDs, bin.ID)
+		}
+		if len(binIDs) == 0 {
+			utils.RespondError(w, http.StatusUnprocessableEntity, "No active bins with coordinates have these tags")
+			return
+		}
+
+		var createdBy *string
+		if userClaims, ok := middleware.GetUserFromContext(r); ok {
+			createdBy = &userClaims.UserID
+		}
+		route, err := createRoute(r.Context(), db, models.CreateRouteRequest{
+			Name:            req.Name,
+			Description:     req.Description,
+			GeographicArea:  req.GeographicArea,
+			SchedulePattern: req.SchedulePattern,
+			BinIDs:          binIDs,
+		}, createdBy)
+		if err != nil {
+			log.Printf("❌ [TAGS] %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to generate route")
+			return
+		}
+
+		log.Printf("✅ [TAGS] Generated route %s with %d tagged bins", route.ID, len(binIDs))
+
+		utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{
+			"success": true,
+			"data": map[string]interface{}{
+				"route":    route,
+				"bin_ids":  binIDs,
+				"warnings": warnings,
+			},
+		})
+	}
+}
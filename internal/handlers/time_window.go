@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/store"
+	"ropacal-backend/internal/websocket"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+)
+
+// decodeTimeWindow reads and validates a SetTimeWindowRequest (nil window = clear)
+// Responds 400 and returns false when the body is invalid
+func decodeTimeWindow(w http.ResponseWriter, r *http.Request) (*models.TimeWindow, bool) {
+	var req models.SetTimeWindowRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+		return nil, false
+	}
+	timeWindow, err := models.NewTimeWindow(req.TimeWindowStart, req.TimeWindowEnd)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	return timeWindow, true
+}
+
+// timeWindowColumns splits a window into nullable column values
+func timeWindowColumns(tw *models.TimeWindow) (start, end *string) {
+	if tw == nil {
+		return nil, nil
+	}
+	return &tw.Start, &tw.End
+}
+
+// SetBinTimeWindow sets or clears the hours a bin may be collected (route optimization plans around them)
+// PUT /api/manager/bins/{id}/time-window
+// Body: { "time_window_start": "06:00", "time_window_end": "09:00" } (nulls clear the window)
+func SetBinTimeWindow(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+
+		timeWindow, ok := decodeTimeWindow(w, r)
+		if !ok {
+			return
+		}
+		start, end := timeWindowColumns(timeWindow)
+
+		var updated models.Bin
+		err := db.GetContext(r.Context(), &updated, `
+			UPDATE bins SET time_window_start = $1, time_window_end = $2, updated_at = $3
+			WHERE id = $4
+			RETURNING *
+		`, start, end, time.Now().Unix(), id)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "Bin not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [TIME-WINDOW] Failed to update bin %s: %v", id, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to update time window")
+			return
+		}
+		store.InvalidateBins(id)
+
+		wsHub.BroadcastToRole("admin", map[string]interface{}{
+			"type": "bin_updated",
+			"data": updated.ToBinResponse(),
+		})
+		if timeWindow != nil {
+			log.Printf("✅ [TIME-WINDOW] Bin #%d may be collected %s-%s", updated.BinNumber, timeWindow.Start, timeWindow.End)
+		} else {
+			log.Printf("✅ [TIME-WINDOW] Cleared time window of bin #%d", updated.BinNumber)
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    updated.ToBinResponse(),
+		})
+	}
+}
+
+// SetMoveRequestTimeWindow sets or clears a move request's service window (overrides the bin's window)
+// PUT /api/manager/bins/move-requests/{id}/time-window
+// Body: { "time_window_start": "06:00", "time_window_end": "09:00" } (nulls clear the window)
+func SetMoveRequestTimeWindow(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		id := chi.URLParam(r, "id")
+
+		timeWindow, ok := decodeTimeWindow(w, r)
+		if !ok {
+			return
+		}
+
+		moveRequest, err := store.NewMoveRequestStore(db).Get(id)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "Move request not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [TIME-WINDOW] %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to update time window")
+			return
+		}
+		if models.IsFinalMoveStatus(moveRequest.Status) {
+			utils.RespondErrorCode(w, http.StatusConflict, utils.CodeMoveFinalized,
+				fmt.Sprintf("Move request is already %s", moveRequest.Status), nil)
+			return
+		}
+
+		start, end := timeWindowColumns(timeWindow)
+		_, err = db.ExecContext(r.Context(), `
+			UPDATE bin_move_requests SET time_window_start = $1, time_window_end = $2, updated_at = $3 WHERE id = $4
+		`, start, end, time.Now().Unix(), id)
+		if err != nil {
+			log.Printf("❌ [TIME-WINDOW] Failed to update move request %s: %v", id, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to update time window")
+			return
+		}
+
+		before := models.StopTimeWindow(moveRequest.TimeWindowStart, moveRequest.TimeWindowEnd, nil, nil)
+		if describeTimeWindow(before) != describeTimeWindow(timeWindow) {
+			oldValue, newValue := describeTimeWindow(before), describeTimeWindow(timeWindow)
+			logMoveSiteChange(db, id, userClaims.UserID, "time_window", "Time window", "Updated time window", &oldValue, &newValue)
+		}
+		moveRequest.TimeWindowStart, moveRequest.TimeWindowEnd = start, end
+		notifyMoveSiteChange(db, wsHub, moveRequest, "The time window changed for a move on your route")
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    moveRequest.ToBinMoveRequestResponse(),
+		})
+	}
+}
+
+// describeTimeWindow renders a window for the audit trail ("06:00-09:00", or "none")
+func describeTimeWindow(tw *models.TimeWindow) string {
+	if tw == nil {
+		return "none"
+	}
+	return tw.Start + "-" + tw.End
+}
+
+// stopsHaveTimeWindows reports whether any stop has a service window
+func stopsHaveTimeWindows(stops []models.ShiftBinWithDetails) bool {
+	for _, stop := range stops {
+		if stop.TimeWindow() != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"ropacal-backend/pkg/utils"
+)
+
+// txError is an error response decided inside a database.WithTx callback
+// Returning it rolls the transaction back; respondTxError then sends it to the client
+type txError struct {
+	status  int
+	code    string
+	message string
+	details interface{}
+}
+
+func (e *txError) Error() string {
+	return e.message
+}
+
+// txFail aborts a transaction with an error response using the status's default code
+func txFail(status int, message string) error {
+	return &txError{status: status, code: utils.CodeForStatus(status), message: message}
+}
+
+// txFailCode aborts a transaction with an error response using an explicit code and optional details
+func txFailCode(status int, code, message string, details interface{}) error {
+	return &txError{status: status, code: code, message: message, details: details}
+}
+
+// respondTxError sends the response for an error returned by database.WithTx
+// A txError is sent as-is; anything else (including a failed begin or commit) is logged and sent as a 500 with message
+func respondTxError(w http.ResponseWriter, err error, message string) {
+	var txErr *txError
+	if errors.As(err, &txErr) {
+		utils.RespondErrorCode(w, txErr.status, txErr.code, txErr.message, txErr.details)
+		return
+	}
+	log.Printf("❌ [TX] %s: %v", message, err)
+	utils.RespondError(w, http.StatusInternalServerError, message)
+}
@@ -0,0 +1,317 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/helpers"
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/store"
+	"ropacal-backend/internal/websocket"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// errUndoConflict means the record changed after the action, so restoring the snapshot would clobber newer work
+var errUndoConflict = errors.New("the record changed after this action and can no longer be undone")
+
+// captureMoveRequestUndo snapshots a move request before a destructive change: its status and assignment,
+// its bin's status and the incomplete stops it has on the assigned shift's route
+func captureMoveRequestUndo(db *sqlx.DB, moveRequestID string) (*models.MoveRequestUndoSnapshot, error) {
+	var moveRequest models.BinMoveRequest
+	if err := db.Get(&moveRequest, `SELECT * FROM bin_move_requests WHERE id = $1`, moveRequestID); err != nil {
+		return nil, fmt.Errorf("failed to load move request %s: %w", moveRequestID, err)
+	}
+
+	snapshot := &models.MoveRequestUndoSnapshot{
+		MoveRequestID:   moveRequest.ID,
+		BinID:           moveRequest.BinID,
+		Status:          moveRequest.Status,
+		AssignmentType:  moveRequest.AssignmentType,
+		AssignedShiftID: moveRequest.AssignedShiftID,
+		AssignedUserID:  moveRequest.AssignedUserID,
+		RemovedStops:    []models.RouteTask{},
+	}
+	err := db.QueryRow(`SELECT status, bin_number FROM bins WHERE id = $1`, moveRequest.BinID).Scan(&snapshot.BinStatus, &snapshot.BinNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load bin %s: %w", moveRequest.BinID, err)
+	}
+	if moveRequest.AssignedShiftID != nil {
+		err = db.Select(&snapshot.RemovedStops, `
+			SELECT * FROM route_tasks
+			WHERE shift_id = $1 AND move_request_id = $2 AND is_completed = 0
+			ORDER BY sequence_order ASC
+		`, *moveRequest.AssignedShiftID, moveRequest.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load stops of move request %s: %w", moveRequest.ID, err)
+		}
+	}
+	return snapshot, nil
+}
+
+// recordUndo stores an undoable operation for the configured window and returns it for the response
+// Returns nil when undo is turned off; failures are logged and never fail the action itself
+func recordUndo(db *sqlx.DB, operationType, entityType, entityID, summary string, snapshot interface{}, userID string, now int64) *models.UndoOperation {
+	settings, err := database.GetUndoSettings(db)
+	if err != nil {
+		log.Printf("⚠️  [UNDO] %v (using defaults)", err)
+	}
+	if settings.WindowSeconds == 0 {
+		return nil
+	}
+
+	raw, err := json.Marshal(snapshot)
+	if err != nil {
+		log.Printf("❌ [UNDO] Failed to marshal %s snapshot for %s: %v", operationType, entityID, err)
+		return nil
+	}
+
+	op := &models.UndoOperation{
+		ID:                uuid.New().String(),
+		OperationType:     operationType,
+		EntityType:        entityType,
+		EntityID:          entityID,
+		Summary:           summary,
+		Snapshot:          raw,
+		PerformedByUserID: &userID,
+		PerformedAt:       now,
+		ExpiresAt:         now + int64(settings.WindowSeconds),
+	}
+	_, err = db.NamedExec(`
+		INSERT INTO undo_operations (
+			id, operation_type, entity_type, entity_id, summary, snapshot,
+			performed_by_user_id, performed_at, expires_at
+		) VALUES (
+			:id, :operation_type, :entity_type, :entity_id, :summary, :snapshot,
+			:performed_by_user_id, :performed_at, :expires_at
+		)`, op)
+	if err != nil {
+		log.Printf("❌ [UNDO] Failed to record %s for %s: %v", operationType, entityID, err)
+		return nil
+	}
+
+	// Expired operations are only kept for a week, for the audit trail
+	if _, err := db.Exec(`DELETE FROM undo_operations WHERE expires_at < $1`, now-7*86400); err != nil {
+		log.Printf("⚠️  [UNDO] Failed to prune expired operations: %v", err)
+	}
+	return op
+}
+
+// GetPendingUndos lists the actions that can still be undone, newest first
+// GET /api/manager/undo
+func GetPendingUndos(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		operations := []models.UndoOperation{}
+		err := db.SelectContext(r.Context(), &operations, `
+			SELECT uo.*, u.name AS performed_by_name
+			FROM undo_operations uo
+			LEFT JOIN users u ON u.id = uo.performed_by_user_id
+			WHERE uo.undone_at IS NULL AND uo.expires_at > $1
+			ORDER BY uo.performed_at DESC
+		`, time.Now().Unix())
+		if err != nil {
+			log.Printf("❌ [UNDO] Failed to list pending operations: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to load pending undo operations")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    operations,
+		})
+	}
+}
+
+// UndoOperation restores the state captured before a destructive action, if its window hasn't expired
+// and the record hasn't changed since
+// POST /api/manager/undo/{operation_id}
+func UndoOperation(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "User not authenticated")
+			return
+		}
+		operationID := chi.URLParam(r, "operation_id")
+		now := time.Now().Unix()
+
+		var op models.UndoOperation
+		var restored *models.MoveRequestUndoSnapshot
+		err := database.WithTx(r.Context(), db, func(tx *sqlx.Tx) error {
+			err := tx.GetContext(r.Context(), &op, `
+				SELECT *, NULL::TEXT AS performed_by_name FROM undo_operations WHERE id = $1 FOR UPDATE
+			`, operationID)
+			if err == sql.ErrNoRows {
+				return txFail(http.StatusNotFound, "Undo operation not found")
+			}
+			if err != nil {
+				log.Printf("❌ [UNDO] Failed to load operation %s: %v", operationID, err)
+				return txFail(http.StatusInternalServerError, "Failed to undo")
+			}
+			if op.UndoneAt != nil {
+				return txFail(http.StatusConflict, "This action was already undone")
+			}
+			if op.ExpiresAt <= now {
+				return txFail(http.StatusGone, "The undo window for this action has expired")
+			}
+
+			switch op.OperationType {
+			case models.UndoMoveRequestCancel, models.UndoMoveRequestClearAssignment:
+				var snapshot models.MoveRequestUndoSnapshot
+				if err := json.Unmarshal(op.Snapshot, &snapshot); err != nil {
+					log.Printf("❌ [UNDO] Invalid snapshot for operation %s: %v", op.ID, err)
+					return txFail(http.StatusInternalServerError, "Failed to undo")
+				}
+				err = restoreMoveRequest(tx, snapshot, now)
+				restored = &snapshot
+			default:
+				err = fmt.Errorf("unknown operation type %q", op.OperationType)
+			}
+			if err == errUndoConflict {
+				return txFailCode(http.StatusConflict, utils.CodeStaleUpdate, err.Error(), nil)
+			}
+			if err != nil {
+				log.Printf("❌ [UNDO] Failed to undo %s %s: %v", op.OperationType, op.EntityID, err)
+				return txFail(http.StatusInternalServerError, "Failed to undo")
+			}
+
+			_, err = tx.ExecContext(r.Context(), `
+				UPDATE undo_operations SET undone_at = $2, undone_by_user_id = $3 WHERE id = $1
+			`, op.ID, now, userClaims.UserID)
+			if err != nil {
+				log.Printf("❌ [UNDO] Failed to mark operation %s undone: %v", op.ID, err)
+				return txFail(http.StatusInternalServerError, "Failed to undo")
+			}
+			return nil
+		})
+		if err != nil {
+			respondTxError(w, err, "Failed to undo")
+			return
+		}
+		op.UndoneAt = &now
+		op.UndoneByUserID = &userClaims.UserID
+
+		log.Printf("↩️  [UNDO] %s of %s undone by %s", op.OperationType, op.EntityID, userClaims.Email)
+
+		if restored != nil {
+			notifyMoveRequestRestored(db, wsHub, *restored, userClaims.UserID)
+		}
+		wsHub.BroadcastToRole("admin", map[string]interface{}{
+			"type": "undo_applied",
+			"data": op,
+		})
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    op,
+		})
+	}
+}
+
+// restoreMoveRequest puts a move request, its bin and its route stops back the way the snapshot found them
+// This reverts the change rather than making a status transition, so it may leave a final status
+func restoreMoveRequest(tx *sqlx.Tx, snapshot models.MoveRequestUndoSnapshot, now int64) error {
+	result, err := tx.Exec(`
+		UPDATE bin_move_requests
+		SET status = $2, assignment_type = $3, assigned_shift_id = $4, assigned_user_id = $5, updated_at = $6
+		WHERE id = $1 AND status = $7 AND updated_at = $8
+	`, snapshot.MoveRequestID, snapshot.Status, snapshot.AssignmentType, snapshot.AssignedShiftID, snapshot.AssignedUserID,
+		now, snapshot.AppliedStatus, snapshot.AppliedAt)
+	if err != nil {
+		return fmt.Errorf("failed to restore move request: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return errUndoConflict
+	}
+
+	// Cancelling resets the bin to active; leave it alone if something else changed it since
+	if snapshot.AppliedStatus == models.MoveStatusCancelled && snapshot.BinStatus != models.BinStatusActive {
+		if _, err := tx.Exec(`UPDATE bins SET status = $2, updated_at = $3 WHERE id = $1 AND status = 'active'`,
+			snapshot.BinID, snapshot.BinStatus, now); err != nil {
+			return fmt.Errorf("failed to restore bin status: %w", err)
+		}
+	}
+
+	if snapshot.AssignedShiftID == nil || len(snapshot.RemovedStops) == 0 {
+		return nil
+	}
+	shiftID := *snapshot.AssignedShiftID
+
+	var shiftStatus string
+	if err := tx.Get(&shiftStatus, `SELECT status FROM shifts WHERE id = $1`, shiftID); err != nil {
+		if err == sql.ErrNoRows {
+			return errUndoConflict
+		}
+		return fmt.Errorf("failed to load shift: %w", err)
+	}
+	if shiftStatus != "ready" && shiftStatus != "active" && shiftStatus != "paused" {
+		return errUndoConflict
+	}
+
+	shifts := store.NewShiftStore(tx)
+	if err := shifts.Lock(shiftID); err != nil {
+		return err
+	}
+	stops := append([]models.RouteTask(nil), snapshot.RemovedStops...)
+	sort.Slice(stops, func(i, j int) bool { return stops[i].SequenceOrder < stops[j].SequenceOrder })
+	for i := range stops {
+		if stops[i].SequenceOrder > 0 {
+			if err := shifts.ShiftSequence(shiftID, stops[i].SequenceOrder, 1); err != nil {
+				return err
+			}
+		}
+		if err := shifts.InsertStop(&stops[i]); err != nil {
+			return err
+		}
+	}
+	if _, err := shifts.Reindex(shiftID); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`UPDATE shifts SET total_bins = total_bins + $1, updated_at = $2 WHERE id = $3`, len(stops), now, shiftID)
+	if err != nil {
+		return fmt.Errorf("failed to update shift total_bins: %w", err)
+	}
+	return nil
+}
+
+// notifyMoveRequestRestored logs the undo in the move request history and tells the driver their route changed
+func notifyMoveRequestRestored(db *sqlx.DB, wsHub *websocket.Hub, snapshot models.MoveRequestUndoSnapshot, managerID string) {
+	store.InvalidateBins(snapshot.BinID)
+
+	managerName, err := store.New(db).Users.Name(managerID)
+	if err != nil {
+		log.Printf("Warning: Failed to fetch manager name for history: %v", err)
+		managerName = "Unknown Manager"
+	}
+	notes := fmt.Sprintf("Undo: restored to %s", snapshot.Status)
+	if err := helpers.LogMoveRequestUpdated(db, snapshot.MoveRequestID, managerID, managerName, &notes, nil); err != nil {
+		log.Printf("Warning: Failed to log move request undo: %v", err)
+	}
+
+	if snapshot.AssignedShiftID == nil || len(snapshot.RemovedStops) == 0 {
+		return
+	}
+	var driverID string
+	if err := db.Get(&driverID, `SELECT driver_id FROM shifts WHERE id = $1`, *snapshot.AssignedShiftID); err != nil {
+		log.Printf("Warning: Failed to find driver of shift %s: %v", *snapshot.AssignedShiftID, err)
+		return
+	}
+	wsHub.BroadcastToUser(driverID, map[string]interface{}{
+		"type":            "route_updated",
+		"message":         fmt.Sprintf("%s has restored a move to your route", managerName),
+		"move_request_id": snapshot.MoveRequestID,
+		"manager_name":    managerName,
+		"action_type":     "added",
+	})
+}
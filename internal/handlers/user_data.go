@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"ropacal-backend/internal/helpers"
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/store"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+)
+
+// anonymizedUserName replaces the name of an anonymized user everywhere it was copied
+const anonymizedUserName = "Deleted user"
+
+// userDataSection is one table in a user data export; $1 is the user ID
+type userDataSection struct {
+	name  string
+	query string
+}
+
+// userDataSections lists every table holding data about a user (FCM tokens are left out, they're credentials)
+var userDataSections = []userDataSection{
+	{"shifts", `SELECT * FROM shifts WHERE driver_id = $1 ORDER BY created_at`},
+	{"shift_history", `SELECT * FROM shift_history WHERE driver_id = $1 ORDER BY ended_at`},
+	{"checks", `SELECT * FROM checks WHERE checked_by = $1 ORDER BY checked_on`},
+	{"driver_locations", `SELECT * FROM driver_locations WHERE driver_id = $1 ORDER BY created_at`},
+	{"driver_current_location", `SELECT * FROM driver_current_location WHERE driver_id = $1`},
+	{"zone_visits", `SELECT * FROM zone_visits WHERE driver_id = $1 ORDER BY entered_at`},
+	{"move_requests", `SELECT * FROM bin_move_requests WHERE requested_by = $1 OR assigned_user_id = $1 ORDER BY created_at`},
+	{"move_request_history", `SELECT * FROM move_request_history WHERE actor_id = $1 ORDER BY created_at`},
+	{"potential_locations", `SELECT * FROM potential_locations WHERE requested_by_user_id = $1 ORDER BY created_at`},
+	{"zone_incidents", `SELECT * FROM zone_incidents WHERE reported_by_user_id = $1 ORDER BY reported_at`},
+	{"bin_maintenance", `SELECT * FROM bin_maintenance WHERE performed_by_user_id = $1 ORDER BY created_at`},
+	{"pre_start_checklists", `SELECT * FROM pre_start_checklists WHERE driver_id = $1 ORDER BY submitted_at`},
+	{"shift_debriefs", `SELECT * FROM shift_debriefs WHERE driver_id = $1 ORDER BY submitted_at`},
+	{"shift_notes", `SELECT * FROM shift_notes WHERE driver_id = $1 ORDER BY created_at`},
+	{"saved_views", `SELECT * FROM saved_views WHERE user_id = $1 ORDER BY created_at`},
+	{"devices", `SELECT id, device_type, created_at, updated_at FROM fcm_tokens WHERE user_id = $1 ORDER BY created_at`},
+	{"security_events", `SELECT * FROM security_events WHERE user_id = $1 ORDER BY created_at`},
+}
+
+// ExportUserData returns everything stored about a user as one JSON archive (subject access requests)
+// GET /api/manager/users/{id}/data-export
+func ExportUserData(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := chi.URLParam(r, "id")
+
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		// One snapshot, so sections exported from different tables agree with each other
+		tx, err := db.BeginTxx(r.Context(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+		if err != nil {
+			log.Printf("❌ [USER-DATA] Failed to start transaction: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to export user data")
+			return
+		}
+		defer tx.Rollback()
+
+		var user models.User
+		err = tx.GetContext(r.Context(), &user, `SELECT * FROM users WHERE id = $1`, userID)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [USER-DATA] Failed to fetch user %s: %v", userID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to export user data")
+			return
+		}
+
+		export := models.UserDataExport{
+			User:       user.ToUserResponse(),
+			ExportedAt: time.Now().Unix(),
+			Data:       make(map[string]json.RawMessage, len(userDataSections)),
+		}
+		for _, section := range userDataSections {
+			var rows []byte
+			err := tx.GetContext(r.Context(), &rows,
+				`SELECT COALESCE(json_agg(t), '[]'::json) FROM (`+section.query+`) t`, userID)
+			if err != nil {
+				log.Printf("❌ [USER-DATA] Failed to export %s for %s: %v", section.name, userID, err)
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to export user data")
+				return
+			}
+			export.Data[section.name] = rows
+		}
+
+		ip := clientIP(r)
+		details := fmt.Sprintf("Data exported by %s", userClaims.Email)
+		helpers.LogSecurityEvent(db, models.SecurityEvent{
+			EventType: models.SecurityEventDataExported,
+			UserID:    &user.ID,
+			Email:     &user.Email,
+			IPAddress: &ip,
+			ActorID:   &userClaims.UserID,
+			Details:   &details,
+			CreatedAt: export.ExportedAt,
+		})
+		log.Printf("✅ [USER-DATA] %s exported the data of %s", userClaims.Email, user.Email)
+
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="user-%s-data.json"`, user.ID))
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    export,
+		})
+	}
+}
+
+// AnonymizeUser erases a deactivated user's personal data (erasure requests)
+// The user row stays with a placeholder name and email so shifts, checks and move history keep their
+// aggregates; GPS breadcrumbs, zone visits, devices and saved views are deleted and copies of the name are replaced
+// POST /api/manager/users/{id}/anonymize
+func AnonymizeUser(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := chi.URLParam(r, "id")
+
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		if userID == userClaims.UserID {
+			utils.RespondError(w, http.StatusBadRequest, "You can't anonymize your own account")
+			return
+		}
+
+		tx, err := db.BeginTxx(r.Context(), nil)
+		if err != nil {
+			log.Printf("❌ [USER-DATA] Failed to start transaction: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to anonymize user")
+			return
+		}
+		defer tx.Rollback()
+
+		var user models.User
+		err = tx.GetContext(r.Context(), &user, `SELECT * FROM users WHERE id = $1 FOR UPDATE`, userID)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [USER-DATA] Failed to fetch user %s: %v", userID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to anonymize user")
+			return
+		}
+		if user.AnonymizedAt != nil {
+			utils.RespondError(w, http.StatusConflict, "User is already anonymized")
+			return
+		}
+		// Deactivation ends shifts and releases moves; anonymizing only erases what's left
+		if !user.IsDeactivated() {
+			utils.RespondError(w, http.StatusConflict, "Deactivate the user before anonymizing them")
+			return
+		}
+
+		now := time.Now().Unix()
+		originalEmail := user.Email
+		summary := models.UserAnonymization{}
+
+		deletes := []string{
+			`DELETE FROM driver_locations WHERE driver_id = $1`,
+			`DELETE FROM driver_current_location WHERE driver_id = $1`,
+			`DELETE FROM zone_visits WHERE driver_id = $1`,
+			`DELETE FROM fcm_tokens WHERE user_id = $1`,
+			`DELETE FROM saved_views WHERE user_id = $1`,
+		}
+		for _, query := range deletes {
+			result, err := tx.ExecContext(r.Context(), query, user.ID)
+			if err != nil {
+				log.Printf("❌ [USER-DATA] Failed to erase data of %s: %v", user.ID, err)
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to anonymize user")
+				return
+			}
+			deleted, _ := result.RowsAffected()
+			summary.DeletedRows += deleted
+		}
+
+		scrubs := []string{
+			`UPDATE potential_locations SET requested_by_name = $2 WHERE requested_by_user_id = $1`,
+			`UPDATE move_request_history SET actor_name = $2 WHERE actor_id = $1`,
+			`UPDATE move_request_history SET previous_assigned_user_name = $2 WHERE previous_assigned_user_id = $1`,
+			`UPDATE move_request_history SET new_assigned_user_name = $2 WHERE new_assigned_user_id = $1`,
+		}
+		for _, query := range scrubs {
+			result, err := tx.ExecContext(r.Context(), query, user.ID, anonymizedUserName)
+			if err != nil {
+				log.Printf("❌ [USER-DATA] Failed to scrub data of %s: %v", user.ID, err)
+				utils.RespondError(w, http.StatusInternalServerError, "Failed to anonymize user")
+				return
+			}
+			scrubbed, _ := result.RowsAffected()
+			summary.ScrubbedRows += scrubbed
+		}
+
+		// Security events stay for auditing, without the email, IP and device they were recorded with
+		result, err := tx.ExecContext(r.Context(), `
+			UPDATE security_events SET email = NULL, ip_address = NULL, user_agent = NULL WHERE user_id = $1
+		`, user.ID)
+		if err != nil {
+			log.Printf("❌ [USER-DATA] Failed to scrub security events of %s: %v", user.ID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to anonymize user")
+			return
+		}
+		scrubbed, _ := result.RowsAffected()
+		summary.ScrubbedRows += scrubbed
+
+		if _, err := tx.ExecContext(r.Context(), `DELETE FROM login_throttles WHERE scope = $1 AND key = $2`,
+			models.LoginThrottleScopeAccount, normalizeLoginEmail(originalEmail)); err != nil {
+			log.Printf("❌ [USER-DATA] Failed to clear login throttle of %s: %v", user.ID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to anonymize user")
+			return
+		}
+
+		// The password is emptied, which no bcrypt hash comparison accepts
+		err = tx.GetContext(r.Context(), &user, `
+			UPDATE users
+			SET name = $1, email = $2, password = '', locale = NULL,
+			    deactivation_reason = 'Personal data erased', anonymized_at = $3, updated_at = $3
+			WHERE id = $4
+			RETURNING *
+		`, anonymizedUserName, fmt.Sprintf("deleted-%s@anonymized.invalid", user.ID), now, user.ID)
+		if err != nil {
+			log.Printf("❌ [USER-DATA] Failed to anonymize user %s: %v", user.ID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to anonymize user")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Printf("❌ [USER-DATA] Failed to commit anonymization of %s: %v", user.ID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to anonymize user")
+			return
+		}
+		middleware.InvalidateUserStatus(user.ID)
+		middleware.InvalidateUserLocale(user.ID)
+		store.InvalidateUsers(user.ID)
+
+		details := fmt.Sprintf("Personal data erased by %s", userClaims.Email)
+		helpers.LogSecurityEvent(db, models.SecurityEvent{
+			EventType: models.SecurityEventAccountAnonymized,
+			UserID:    &user.ID,
+			ActorID:   &userClaims.UserID,
+			Details:   &details,
+			CreatedAt: now,
+		})
+		log.Printf("✅ [USER-DATA] %s anonymized user %s (%d rows deleted, %d scrubbed)",
+			userClaims.Email, user.ID, summary.DeletedRows, summary.ScrubbedRows)
+
+		summary.User = user.ToUserResponse()
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    summary,
+		})
+	}
+}
@@ -0,0 +1,376 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/helpers"
+	"ropacal-backend/internal/i18n"
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/store"
+	"ropacal-backend/internal/websocket"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// UpdateUserRequest is the body for PATCH /api/manager/users/{id}
+type UpdateUserRequest struct {
+	Active *bool   `json:"active"`           // false deactivates (offboards) the user, true reactivates them
+	Reason *string `json:"reason,omitempty"` // Why the user was deactivated (kept on the user)
+}
+
+// deactivatedShift is an open shift closed by a deactivation, with what's needed for notifications afterwards
+type deactivatedShift struct {
+	shift          models.Shift
+	previousStatus models.ShiftStatus
+	activeSeconds  int64
+	completionRate float64
+	earnedCredits  float64
+}
+
+// UpdateUser deactivates or reactivates a user
+// Deactivation blocks login (and existing tokens), ends the user's active or paused shift, cancels ready
+// shifts, and returns their assigned or in-progress move requests to pending
+// PATCH /api/manager/users/{id}
+// Body: { "active": false, "reason": "Left the company" }
+func UpdateUser(db *sqlx.DB, hub *websocket.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := chi.URLParam(r, "id")
+
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var req UpdateUserRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if req.Active == nil {
+			utils.RespondError(w, http.StatusBadRequest, "active is required")
+			return
+		}
+		if !*req.Active && userID == userClaims.UserID {
+			utils.RespondError(w, http.StatusBadRequest, "You can't deactivate your own account")
+			return
+		}
+
+		var user models.User
+		err := db.GetContext(r.Context(), &user, `SELECT * FROM users WHERE id = $1`, userID)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [USERS] Failed to fetch user %s: %v", userID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch user")
+			return
+		}
+
+		response := models.UserUpdateResponse{}
+		switch {
+		case *req.Active && user.IsDeactivated():
+			err = reactivateUser(r, db, &user, userClaims)
+		case !*req.Active && !user.IsDeactivated():
+			response.Deactivation, err = deactivateUser(r, db, hub, &user, userClaims, req.Reason)
+		}
+		if err != nil {
+			log.Printf("❌ [USERS] Failed to update %s: %v", user.Email, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to update user")
+			return
+		}
+
+		response.User = user.ToUserResponse()
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    response,
+		})
+	}
+}
+
+// reactivateUser clears the deactivation so the user can log in again (shifts and moves are not restored)
+func reactivateUser(r *http.Request, db *sqlx.DB, user *models.User, actor middleware.UserClaims) error {
+	now := time.Now().Unix()
+	_, err := db.ExecContext(r.Context(), `
+		UPDATE users
+		SET deactivated_at = NULL, deactivated_by_user_id = NULL, deactivation_reason = NULL, updated_at = $1
+		WHERE id = $2
+	`, now, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to reactivate user: %w", err)
+	}
+	middleware.InvalidateUserStatus(user.ID)
+
+	user.DeactivatedAt = nil
+	user.DeactivatedByUserID = nil
+	user.DeactivationReason = nil
+	user.UpdatedAt = now
+
+	ip := clientIP(r)
+	details := fmt.Sprintf("Reactivated by %s", actor.Email)
+	helpers.LogSecurityEvent(db, models.SecurityEvent{
+		EventType: models.SecurityEventAccountReactivated,
+		UserID:    &user.ID,
+		Email:     &user.Email,
+		IPAddress: &ip,
+		ActorID:   &actor.UserID,
+		Details:   &details,
+		CreatedAt: now,
+	})
+
+	log.Printf("✅ [USERS] %s reactivated %s", actor.Email, user.Email)
+	return nil
+}
+
+// deactivateUser offboards a user in one transaction, then sends notifications for what changed
+func deactivateUser(r *http.Request, db *sqlx.DB, hub *websocket.Hub, user *models.User, actor middleware.UserClaims, reason *string) (*models.UserDeactivation, error) {
+	now := time.Now().Unix()
+	if reason != nil {
+		trimmed := strings.TrimSpace(*reason)
+		reason = &trimmed
+		if trimmed == "" {
+			reason = nil
+		}
+	}
+	summary := &models.UserDeactivation{
+		EndedShiftIDs:        []string{},
+		CancelledShiftIDs:    []string{},
+		ReleasedMoveRequests: []string{},
+	}
+
+	var openShifts []models.Shift
+	err := db.SelectContext(r.Context(), &openShifts, `
+		SELECT * FROM shifts WHERE driver_id = $1 AND status IN ('ready', 'active', 'paused')
+	`, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch open shifts: %w", err)
+	}
+
+	// Pause time runs until now, as when the driver ends the shift
+	closed := make([]deactivatedShift, 0, len(openShifts))
+	shiftIDs := make([]string, 0, len(openShifts))
+	for _, shift := range openShifts {
+		entry := deactivatedShift{shift: shift, previousStatus: shift.Status}
+		if shift.Status != "ready" {
+			if shift.TotalBins > 0 {
+				entry.completionRate = float64(shift.CompletedBins) / float64(shift.TotalBins) * 100
+			}
+			totalPause := int64(shift.TotalPauseSeconds)
+			if shift.PauseStartTime != nil {
+				totalPause += now - *shift.PauseStartTime
+			}
+			entry.shift.TotalPauseSeconds = int(totalPause)
+			if shift.StartTime != nil {
+				entry.activeSeconds = now - *shift.StartTime - totalPause
+			}
+		}
+		closed = append(closed, entry)
+		shiftIDs = append(shiftIDs, shift.ID)
+	}
+
+	tx, err := db.BeginTxx(r.Context(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(r.Context(), `
+		UPDATE users
+		SET deactivated_at = $1, deactivated_by_user_id = $2, deactivation_reason = $3, updated_at = $1
+		WHERE id = $4
+	`, now, actor.UserID, reason, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deactivate user: %w", err)
+	}
+
+	metadata, _ := json.Marshal(map[string]interface{}{
+		"reason":               "user_deactivated",
+		"deactivation_note":    reason,
+		"deactivated_by":       actor.UserID,
+		"deactivated_by_email": actor.Email,
+	})
+	for i := range closed {
+		entry := &closed[i]
+		shift := &entry.shift
+
+		if entry.previousStatus == "ready" {
+			if _, err := tx.ExecContext(r.Context(), `
+				UPDATE shifts SET status = 'cancelled', updated_at = $1 WHERE id = $2
+			`, now, shift.ID); err != nil {
+				return nil, fmt.Errorf("failed to cancel shift %s: %w", shift.ID, err)
+			}
+			shift.Status = models.ShiftStatusCancelled
+			summary.CancelledShiftIDs = append(summary.CancelledShiftIDs, shift.ID)
+			continue
+		}
+
+		// Earnings only depend on completed stops, which this transaction doesn't touch
+		earnings, earningsBreakdown, err := calculateShiftEarnings(db, *shift)
+		if err != nil {
+			log.Printf("⚠️  [USERS] Failed to calculate earnings for shift %s: %v", shift.ID, err)
+		}
+		entry.earnedCredits = earnings.Total
+
+		_, err = tx.ExecContext(r.Context(), `
+			INSERT INTO shift_history (
+				id, driver_id, route_id, start_time, end_time, created_at, ended_at,
+				total_pause_seconds, total_bins, completed_bins, completion_rate,
+				incidents_reported, field_observations,
+				end_reason, ended_by_user_id, end_reason_metadata,
+				earned_credits, earnings_breakdown
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, $5, $7, $8, $9, $10, $11, $12, 'manager_ended', $13, $14, $15, $16)
+		`, shift.ID, shift.DriverID, shift.RouteID, shift.StartTime, now, shift.CreatedAt,
+			shift.TotalPauseSeconds, shift.TotalBins, shift.CompletedBins, entry.completionRate,
+			shift.IncidentsReported, shift.FieldObservations,
+			actor.UserID, string(metadata), earnings.Total, earningsBreakdown)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save history for shift %s: %w", shift.ID, err)
+		}
+
+		_, err = tx.ExecContext(r.Context(), `
+			UPDATE shifts
+			SET status = 'ended', end_time = $1, total_pause_seconds = $2, pause_start_time = NULL, updated_at = $1
+			WHERE id = $3
+		`, now, shift.TotalPauseSeconds, shift.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to end shift %s: %w", shift.ID, err)
+		}
+		shift.Status = models.ShiftStatusEnded
+		shift.EndTime = &now
+		shift.PauseStartTime = nil
+		summary.EndedShiftIDs = append(summary.EndedShiftIDs, shift.ID)
+	}
+
+	// Moves on the user's shifts or manually assigned to them go back to the unassigned queue
+	var released []models.BinMoveRequest
+	err = tx.SelectContext(r.Context(), &released, `
+		SELECT id, bin_id, status, assignment_type, assigned_shift_id, assigned_user_id
+		FROM bin_move_requests
+		WHERE status = ANY($3)
+		  AND (assigned_shift_id = ANY($1) OR assigned_user_id = $2)
+		FOR UPDATE
+	`, pq.Array(shiftIDs), user.ID, pq.Array([]string{models.MoveStatusAssigned, models.MoveStatusInProgress, models.MoveStatusPickedUp}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch assigned move requests: %w", err)
+	}
+	if len(released) > 0 {
+		releasedIDs := make([]string, len(released))
+		for i, moveRequest := range released {
+			releasedIDs[i] = moveRequest.ID
+		}
+		_, err = tx.ExecContext(r.Context(), `
+			UPDATE bin_move_requests
+			SET status = $3, assignment_type = '', assigned_shift_id = NULL, assigned_user_id = NULL, picked_up_at = NULL, updated_at = $1
+			WHERE id = ANY($2)
+		`, now, pq.Array(releasedIDs), models.MoveStatusPending)
+		if err != nil {
+			return nil, fmt.Errorf("failed to release move requests: %w", err)
+		}
+		summary.ReleasedMoveRequests = releasedIDs
+	}
+
+	// Their stops leave the closed routes (completed stops stay for history)
+	if _, err := store.New(tx).Shifts.RemoveReleasedMoveStops(shiftIDs...); err != nil {
+		return nil, err
+	}
+
+	// Devices stop receiving pushes; they register again on the next login
+	if _, err := tx.ExecContext(r.Context(), `DELETE FROM fcm_tokens WHERE user_id = $1`, user.ID); err != nil {
+		return nil, fmt.Errorf("failed to remove FCM tokens: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit deactivation: %w", err)
+	}
+	middleware.InvalidateUserStatus(user.ID)
+
+	user.DeactivatedAt = &now
+	user.DeactivatedByUserID = &actor.UserID
+	user.DeactivationReason = reason
+	user.UpdatedAt = now
+
+	log.Printf("🚫 [USERS] %s deactivated %s (%d shift(s) ended, %d cancelled, %d move(s) released)",
+		actor.Email, user.Email, len(summary.EndedShiftIDs), len(summary.CancelledShiftIDs), len(summary.ReleasedMoveRequests))
+
+	ip := clientIP(r)
+	details := fmt.Sprintf("Deactivated by %s", actor.Email)
+	if reason != nil {
+		details += ": " + *reason
+	}
+	helpers.LogSecurityEvent(db, models.SecurityEvent{
+		EventType: models.SecurityEventAccountDeactivated,
+		UserID:    &user.ID,
+		Email:     &user.Email,
+		IPAddress: &ip,
+		ActorID:   &actor.UserID,
+		Details:   &details,
+		CreatedAt: now,
+	})
+
+	actorName, err := store.New(db).Users.Name(actor.UserID)
+	if err != nil {
+		actorName = actor.Email
+	}
+	for _, moveRequest := range released {
+		var previousUserName *string
+		if moveRequest.AssignedUserID != nil {
+			previousUserName = &user.Name
+		}
+		if err := helpers.LogMoveRequestUnassigned(db, moveRequest.ID, actor.UserID, actorName,
+			moveRequest.AssignmentType, moveRequest.AssignedUserID, previousUserName, moveRequest.AssignedShiftID); err != nil {
+			log.Printf("⚠️  [USERS] Failed to log unassignment of move %s: %v", moveRequest.ID, err)
+		}
+	}
+
+	for _, entry := range closed {
+		if entry.previousStatus == "ready" {
+			emitShiftWebhook(db, models.WebhookEventShiftCancelled, entry.shift, map[string]interface{}{
+				"previous_status":      entry.previousStatus,
+				"cancelled_at":         now,
+				"cancelled_by_user_id": actor.UserID,
+			})
+		} else {
+			recordShiftEfficiency(db, entry.shift, entry.activeSeconds)
+			emitShiftWebhook(db, models.WebhookEventShiftEnded, entry.shift, map[string]interface{}{
+				"end_reason":              "manager_ended",
+				"completion_rate":         entry.completionRate,
+				"active_duration_seconds": entry.activeSeconds,
+				"earned_credits":          entry.earnedCredits,
+			})
+		}
+
+		broadcastPayload := map[string]interface{}{
+			"type": "driver_shift_change",
+			"data": map[string]interface{}{
+				"driver_id": entry.shift.DriverID,
+				"status":    entry.shift.Status,
+				"shift_id":  entry.shift.ID,
+			},
+		}
+		hub.BroadcastToRole("admin", broadcastPayload)
+		hub.BroadcastToRole("manager", broadcastPayload)
+	}
+
+	hub.BroadcastToUser(user.ID, map[string]interface{}{
+		"type": "account_deactivated",
+		"data": map[string]interface{}{
+			"deactivated_at": now,
+			"message":        i18n.T(database.UserLocale(db, user.ID), "Your account has been deactivated"),
+		},
+	})
+
+	return summary, nil
+}
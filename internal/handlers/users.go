@@ -15,10 +15,10 @@ import (
 )
 
 type CreateUserRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
-	Name     string `json:"name"`
-	Role     string `json:"role"` // "driver", "admin", or "manager"
+	Email    string `json:"email" validate:"required,format=email"`
+	Password string `json:"password" validate:"required"`
+	Name     string `json:"name" validate:"required"`
+	Role     string `json:"role" validate:"required,oneof=driver admin manager"` // "driver", "admin", or "manager"
 }
 
 type CreateUserResponse struct {
@@ -64,7 +64,7 @@ func CreateUser(db *sqlx.DB) http.HandlerFunc {
 		// Check if user already exists
 		var existingUser models.User
 		checkQuery := "SELECT id FROM users WHERE email = $1"
-		err := db.Get(&existingUser, checkQuery, req.Email)
+		err := db.GetContext(r.Context(), &existingUser, checkQuery, req.Email)
 		if err == nil {
 			log.Printf("❌ User already exists: %s", req.Email)
 			utils.RespondError(w, http.StatusConflict, "User with this email already exists")
@@ -98,7 +98,7 @@ func CreateUser(db *sqlx.DB) http.HandlerFunc {
 			INSERT INTO users (id, email, password, name, role, created_at, updated_at)
 			VALUES ($1, $2, $3, $4, $5, $6, $7)
 		`
-		_, err = db.Exec(
+		_, err = db.ExecContext(r.Context(), 
 			insertQuery,
 			user.ID,
 			user.Email,
@@ -140,14 +140,16 @@ func GetAllUsers(db *sqlx.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		log.Println("📤 REQUEST: GET /api/users - Fetch all users")
 
-		// Fetch all users
+		// Fetch all users (deactivated users only with ?include_deactivated=true)
 		var users []models.User
 		query := `
-			SELECT id, email, name, role, created_at, updated_at
+			SELECT id, email, name, role, deactivated_at, deactivation_reason, created_at, updated_at
 			FROM users
+			WHERE $1 OR deactivated_at IS NULL
 			ORDER BY name ASC
 		`
-		err := db.Select(&users, query)
+		includeDeactivated := r.URL.Query().Get("include_deactivated") == "true"
+		err := db.SelectContext(r.Context(), &users, query, includeDeactivated)
 		if err != nil {
 			log.Printf("❌ Database error: %v", err)
 			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch users")
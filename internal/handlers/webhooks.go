@@ -0,0 +1,389 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"ropacal-backend/internal/helpers"
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// webhookRequest is the body for registering or updating a webhook
+type webhookRequest struct {
+	URL         *string  `json:"url" validate:"format=uri"`
+	EventTypes  []string `json:"event_types"` // Empty = all events
+	Description *string  `json:"description"`
+	IsActive    *bool    `json:"is_active"`
+}
+
+// validateWebhookRequest checks the URL and event filters, returning a message for the client
+func validateWebhookRequest(req webhookRequest) string {
+	if req.URL != nil {
+		parsed, err := url.Parse(strings.TrimSpace(*req.URL))
+		if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+			return "url must be an absolute http(s) URL"
+		}
+	}
+	for _, eventType := range req.EventTypes {
+		if !models.IsValidWebhookEventType(eventType) {
+			return fmt.Sprintf("Invalid event type: %s", eventType)
+		}
+	}
+	return ""
+}
+
+// newWebhookSecret generates a random signing secret
+func newWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+// GetWebhooks returns all registered webhooks (secrets are never returned after creation)
+// GET /api/manager/webhooks
+func GetWebhooks(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		webhooks := []models.Webhook{}
+		if err := db.SelectContext(r.Context(), &webhooks, `SELECT * FROM webhooks ORDER BY created_at ASC`); err != nil {
+			log.Printf("❌ [WEBHOOKS] Failed to fetch webhooks: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch webhooks")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    webhooks,
+		})
+	}
+}
+
+// CreateWebhook registers a webhook URL; the response includes the signing secret (shown only once)
+// POST /api/manager/webhooks
+// Body: { "url": "https://billing.example.com/hooks/ropacal", "event_types": ["shift.ended", "bin.retired"], "description": "..." }
+func CreateWebhook(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var req webhookRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if req.URL == nil {
+			utils.RespondError(w, http.StatusBadRequest, "url is required")
+			return
+		}
+		if msg := validateWebhookRequest(req); msg != "" {
+			utils.RespondError(w, http.StatusBadRequest, msg)
+			return
+		}
+
+		secret, err := newWebhookSecret()
+		if err != nil {
+			log.Printf("❌ [WEBHOOKS] Failed to generate secret: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to create webhook")
+			return
+		}
+
+		now := time.Now().Unix()
+		webhook := models.Webhook{
+			ID:              uuid.New().String(),
+			URL:             strings.TrimSpace(*req.URL),
+			Secret:          secret,
+			EventTypes:      pq.StringArray(req.EventTypes),
+			Description:     req.Description,
+			IsActive:        req.IsActive == nil || *req.IsActive,
+			CreatedByUserID: &userClaims.UserID,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		}
+		if webhook.EventTypes == nil {
+			webhook.EventTypes = pq.StringArray{}
+		}
+
+		_, err = db.NamedExecContext(r.Context(), `
+			INSERT INTO webhooks (id, url, secret, event_types, description, is_active, created_by_user_id, created_at, updated_at)
+			VALUES (:id, :url, :secret, :event_types, :description, :is_active, :created_by_user_id, :created_at, :updated_at)
+		`, webhook)
+		if err != nil {
+			log.Printf("❌ [WEBHOOKS] Failed to create webhook: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to create webhook")
+			return
+		}
+
+		log.Printf("✅ [WEBHOOKS] %s registered webhook %s -> %s (events: %v)", userClaims.Email, webhook.ID, webhook.URL, req.EventTypes)
+
+		utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{
+			"success": true,
+			"data": map[string]interface{}{
+				"webhook": webhook,
+				"secret":  webhook.Secret,
+			},
+		})
+	}
+}
+
+// UpdateWebhook changes a webhook's URL, event filter, description or active flag
+// PUT /api/manager/webhooks/{id}
+// Body: any of { "url", "event_types", "description", "is_active" }
+func UpdateWebhook(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		webhookID := chi.URLParam(r, "id")
+
+		var req webhookRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if msg := validateWebhookRequest(req); msg != "" {
+			utils.RespondError(w, http.StatusBadRequest, msg)
+			return
+		}
+
+		var webhook models.Webhook
+		err := db.GetContext(r.Context(), &webhook, `SELECT * FROM webhooks WHERE id = $1`, webhookID)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "Webhook not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [WEBHOOKS] Failed to fetch webhook: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch webhook")
+			return
+		}
+
+		if req.URL != nil {
+			webhook.URL = strings.TrimSpace(*req.URL)
+		}
+		if req.EventTypes != nil {
+			webhook.EventTypes = pq.StringArray(req.EventTypes)
+		}
+		if req.Description != nil {
+			webhook.Description = req.Description
+		}
+		if req.IsActive != nil {
+			webhook.IsActive = *req.IsActive
+		}
+		webhook.UpdatedAt = time.Now().Unix()
+
+		_, err = db.ExecContext(r.Context(), `
+			UPDATE webhooks
+			SET url = $1, event_types = $2, description = $3, is_active = $4, updated_at = $5
+			WHERE id = $6
+		`, webhook.URL, webhook.EventTypes, webhook.Description, webhook.IsActive, webhook.UpdatedAt, webhook.ID)
+		if err != nil {
+			log.Printf("❌ [WEBHOOKS] Failed to update webhook: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to update webhook")
+			return
+		}
+
+		log.Printf("✅ [WEBHOOKS] Updated webhook %s (active: %v)", webhook.ID, webhook.IsActive)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    webhook,
+		})
+	}
+}
+
+// DeleteWebhook removes a webhook and its delivery log
+// DELETE /api/manager/webhooks/{id}
+func DeleteWebhook(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		webhookID := chi.URLParam(r, "id")
+
+		result, err := db.ExecContext(r.Context(), `DELETE FROM webhooks WHERE id = $1`, webhookID)
+		if err != nil {
+			log.Printf("❌ [WEBHOOKS] Failed to delete webhook: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to delete webhook")
+			return
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			utils.RespondError(w, http.StatusNotFound, "Webhook not found")
+			return
+		}
+
+		log.Printf("✅ [WEBHOOKS] Deleted webhook %s", webhookID)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+		})
+	}
+}
+
+// TestWebhook queues a webhook.ping event for one webhook (delivered on the next dispatcher run)
+// POST /api/manager/webhooks/{id}/test
+func TestWebhook(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		webhookID := chi.URLParam(r, "id")
+
+		var exists bool
+		if err := db.GetContext(r.Context(), &exists, `SELECT EXISTS(SELECT 1 FROM webhooks WHERE id = $1)`, webhookID); err != nil {
+			log.Printf("❌ [WEBHOOKS] Failed to fetch webhook: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch webhook")
+			return
+		}
+		if !exists {
+			utils.RespondError(w, http.StatusNotFound, "Webhook not found")
+			return
+		}
+
+		event := models.WebhookEvent{
+			ID:        uuid.New().String(),
+			Type:      models.WebhookEventPing,
+			CreatedAt: time.Now().Unix(),
+			Data:      map[string]interface{}{"webhook_id": webhookID},
+		}
+		if _, err := helpers.QueueWebhookEvent(db, event, webhookID); err != nil {
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to queue test event")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusAccepted, map[string]interface{}{
+			"success": true,
+			"data":    map[string]interface{}{"event_id": event.ID},
+		})
+	}
+}
+
+// GetWebhookDeliveries returns a webhook's delivery log, newest first
+// GET /api/manager/webhooks/{id}/deliveries
+// Query params: status (pending, succeeded, failed), event_type, limit (default 100, max 500)
+func GetWebhookDeliveries(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		webhookID := chi.URLParam(r, "id")
+		q := r.URL.Query()
+
+		query := `SELECT * FROM webhook_deliveries WHERE webhook_id = $1`
+		args := []interface{}{webhookID}
+		if status := q.Get("status"); status != "" {
+			args = append(args, status)
+			query += fmt.Sprintf(" AND status = $%d", len(args))
+		}
+		if eventType := q.Get("event_type"); eventType != "" {
+			args = append(args, eventType)
+			query += fmt.Sprintf(" AND event_type = $%d", len(args))
+		}
+
+		limit := 100
+		if parsed, err := strconv.Atoi(q.Get("limit")); err == nil && parsed > 0 && parsed <= 500 {
+			limit = parsed
+		}
+		args = append(args, limit)
+		query += fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d", len(args))
+
+		deliveries := []models.WebhookDelivery{}
+		if err := db.SelectContext(r.Context(), &deliveries, query, args...); err != nil {
+			log.Printf("❌ [WEBHOOKS] Failed to fetch deliveries: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch deliveries")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    deliveries,
+		})
+	}
+}
+
+// RedriveWebhookDelivery requeues a delivery for immediate sending with a fresh retry budget
+// POST /api/manager/webhooks/deliveries/{id}/redrive
+func RedriveWebhookDelivery(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		deliveryID := chi.URLParam(r, "id")
+		now := time.Now().Unix()
+
+		var delivery models.WebhookDelivery
+		err := db.GetContext(r.Context(), &delivery, `
+			UPDATE webhook_deliveries
+			SET status = $1, attempts = 0, next_attempt_at = $2, updated_at = $2
+			WHERE id = $3
+			RETURNING *
+		`, models.WebhookDeliveryPending, now, deliveryID)
+		if err == sql.ErrNoRows {
+			utils.RespondError(w, http.StatusNotFound, "Delivery not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [WEBHOOKS] Failed to redrive delivery: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to redrive delivery")
+			return
+		}
+
+		log.Printf("🔁 [WEBHOOKS] Redriving delivery %s (%s)", delivery.ID, delivery.EventType)
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    delivery,
+		})
+	}
+}
+
+// emitShiftWebhook queues a shift lifecycle event (shift.started, shift.ended, shift.cancelled)
+// extra adds event-specific fields (end_reason, cancelled_by, ...) and may override the shift's fields
+func emitShiftWebhook(db *sqlx.DB, eventType string, shift models.Shift, extra map[string]interface{}) {
+	data := map[string]interface{}{
+		"shift_id":            shift.ID,
+		"driver_id":           shift.DriverID,
+		"route_id":            shift.RouteID,
+		"status":              shift.Status,
+		"start_time":          shift.StartTime,
+		"end_time":            shift.EndTime,
+		"total_pause_seconds": shift.TotalPauseSeconds,
+		"total_bins":          shift.TotalBins,
+		"completed_bins":      shift.CompletedBins,
+	}
+	for key, value := range extra {
+		data[key] = value
+	}
+	helpers.EmitWebhookEvent(db, eventType, data)
+}
+
+// emitMoveRequestCompletedWebhook queues a move_request.completed event
+func emitMoveRequestCompletedWebhook(db *sqlx.DB, moveRequest models.BinMoveRequest, completedByUserID string, completedAt int64) {
+	helpers.EmitWebhookEvent(db, models.WebhookEventMoveRequestCompleted, map[string]interface{}{
+		"move_request_id":      moveRequest.ID,
+		"bin_id":               moveRequest.BinID,
+		"move_type":            moveRequest.MoveType,
+		"disposal_action":      moveRequest.DisposalAction,
+		"assignment_type":      moveRequest.AssignmentType,
+		"assigned_shift_id":    moveRequest.AssignedShiftID,
+		"original_address":     moveRequest.OriginalAddress,
+		"new_address":          moveRequest.NewAddress,
+		"completed_by_user_id": completedByUserID,
+		"completed_at":         completedAt,
+	})
+}
+
+// emitBinRetiredWebhook queues a bin.retired event (moveRequestID is set when a pickup move retired the bin)
+func emitBinRetiredWebhook(db *sqlx.DB, binID string, retiredByUserID string, moveRequestID *string, reason *string, retiredAt int64) {
+	helpers.EmitWebhookEvent(db, models.WebhookEventBinRetired, map[string]interface{}{
+		"bin_id":             binID,
+		"retired_by_user_id": retiredByUserID,
+		"move_request_id":    moveRequestID,
+		"reason":             reason,
+		"retired_at":         retiredAt,
+	})
+}
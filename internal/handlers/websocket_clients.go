@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"ropacal-backend/internal/helpers"
+	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/websocket"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+)
+
+// webSocketClient is a connected client with the user's name and email
+type webSocketClient struct {
+	websocket.ClientInfo
+	Name  *string `json:"name,omitempty"`
+	Email *string `json:"email,omitempty"`
+}
+
+// webSocketClientsResponse is the hub occupancy returned by GET /api/manager/websocket/clients
+type webSocketClientsResponse struct {
+	GeneratedAt int64             `json:"generated_at"`
+	Total       int               `json:"total"`
+	ByRole      map[string]int    `json:"by_role"`
+	Clients     []webSocketClient `json:"clients"`
+}
+
+// disconnectWebSocketClientRequest is the body of POST .../disconnect ({} for the default reason)
+type disconnectWebSocketClientRequest struct {
+	Reason *string `json:"reason" validate:"max=120"` // Sent to the client in the close frame
+}
+
+// GetWebSocketClients lists the users connected to the WebSocket hub (this server only)
+// GET /api/manager/websocket/clients
+func GetWebSocketClients(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		connected := wsHub.Clients()
+		response := webSocketClientsResponse{
+			GeneratedAt: time.Now().Unix(),
+			Total:       len(connected),
+			ByRole:      map[string]int{},
+			Clients:     make([]webSocketClient, len(connected)),
+		}
+
+		userIDs := make([]string, len(connected))
+		for i, client := range connected {
+			userIDs[i] = client.UserID
+			response.ByRole[client.Role]++
+			response.Clients[i] = webSocketClient{ClientInfo: client}
+		}
+
+		if len(userIDs) > 0 {
+			var users []struct {
+				ID    string `db:"id"`
+				Name  string `db:"name"`
+				Email string `db:"email"`
+			}
+			query, args, err := sqlx.In(`SELECT id, name, email FROM users WHERE id IN (?)`, userIDs)
+			if err == nil {
+				err = db.SelectContext(r.Context(), &users, db.Rebind(query), args...)
+			}
+			if err != nil {
+				// The hub state is still useful without names
+				log.Printf("⚠️ [WEBSOCKET] Failed to load connected users: %v", err)
+			}
+			byID := make(map[string]int, len(users))
+			for i, user := range users {
+				byID[user.ID] = i
+			}
+			for i := range response.Clients {
+				if j, ok := byID[response.Clients[i].UserID]; ok {
+					response.Clients[i].Name = &users[j].Name
+					response.Clients[i].Email = &users[j].Email
+				}
+			}
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    response,
+		})
+	}
+}
+
+// DisconnectWebSocketClient closes a user's WebSocket connection; the app may reconnect right away
+// (deactivate the user to keep them out)
+// POST /api/manager/websocket/clients/{userId}/disconnect
+func DisconnectWebSocketClient(db *sqlx.DB, wsHub *websocket.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		userID := chi.URLParam(r, "userId")
+
+		var req disconnectWebSocketClientRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		reason := "Disconnected by an administrator"
+		if req.Reason != nil && strings.TrimSpace(*req.Reason) != "" {
+			reason = strings.TrimSpace(*req.Reason)
+		}
+
+		if !wsHub.DisconnectUser(userID, reason) {
+			utils.RespondError(w, http.StatusNotFound, "User is not connected")
+			return
+		}
+
+		ip := clientIP(r)
+		details := fmt.Sprintf("Disconnected by %s: %s", userClaims.Email, reason)
+		helpers.LogSecurityEvent(db, models.SecurityEvent{
+			EventType: models.SecurityEventSocketDisconnected,
+			UserID:    &userID,
+			IPAddress: &ip,
+			ActorID:   &userClaims.UserID,
+			Details:   &details,
+		})
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data": map[string]interface{}{
+				"user_id": userID,
+				"reason":  reason,
+			},
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"log"
+
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/services"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// stopRisks looks up the no-go zone risk of each bin (keyed by bin ID)
+// Failures are logged and treated as no risk so routing never fails over an annotation
+func stopRisks(db sqlx.Queryer, binIDs []string) map[string]models.StopRisk {
+	risks, err := services.ZoneRisks(db, binIDs)
+	if err != nil {
+		log.Printf("⚠️  [ZONE-RISK] Failed to load zone risks: %v", err)
+		return map[string]models.StopRisk{}
+	}
+	return risks
+}
+
+// hasRiskWeights reports whether any of the risks should change the route order
+func hasRiskWeights(risks map[string]models.StopRisk) bool {
+	for _, risk := range risks {
+		if risk.Weight > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// annotateStopRisk sets the risk level and zone of every stop inside a no-go zone, for the driver app
+// Dropoffs are skipped: they happen at the move's new location, not where the bin stands now
+func annotateStopRisk(db *sqlx.DB, stops []models.ShiftBinWithDetails) {
+	binIDs := make([]string, 0, len(stops))
+	for _, stop := range stops {
+		if stop.BinID != "" && stop.StopType != string(models.TaskTypeDropoff) {
+			binIDs = append(binIDs, stop.BinID)
+		}
+	}
+	risks := stopRisks(db, binIDs)
+	for i := range stops {
+		if stops[i].StopType == string(models.TaskTypeDropoff) {
+			continue
+		}
+		if risk, ok := risks[stops[i].BinID]; ok {
+			zoneName := risk.ZoneName
+			stops[i].RiskLevel = risk.Level
+			stops[i].RiskZoneName = &zoneName
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ropacal-backend/internal/models"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// GetZoneVisits returns drivers' visits to no-go zones, newest first (the log behind the
+// driver_entered_zone / driver_exited_zone alerts)
+// GET /api/manager/zone-visits
+// Query params: driver_id, zone_id, shift_id, since / until (unix, on entered_at), open=true (drivers
+// still inside), limit (default 100, max 500)
+func GetZoneVisits(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+
+		query := `
+			SELECT v.id, v.zone_id, z.name AS zone_name, z.conflict_score, v.driver_id, u.name AS driver_name,
+			       v.shift_id, v.entered_at, v.entry_latitude, v.entry_longitude,
+			       v.exited_at, v.exit_latitude, v.exit_longitude, v.exited_at - v.entered_at AS duration_seconds
+			FROM zone_visits v
+			JOIN no_go_zones z ON z.id = v.zone_id
+			JOIN users u ON u.id = v.driver_id`
+		whereClause := []string{}
+		args := []interface{}{}
+
+		for _, filter := range []struct{ param, column string }{
+			{"driver_id", "v.driver_id"},
+			{"zone_id", "v.zone_id"},
+			{"shift_id", "v.shift_id"},
+		} {
+			if value := q.Get(filter.param); value != "" {
+				args = append(args, value)
+				whereClause = append(whereClause, fmt.Sprintf("%s = $%d", filter.column, len(args)))
+			}
+		}
+		if since, err := strconv.ParseInt(q.Get("since"), 10, 64); err == nil {
+			args = append(args, since)
+			whereClause = append(whereClause, fmt.Sprintf("v.entered_at >= $%d", len(args)))
+		}
+		if until, err := strconv.ParseInt(q.Get("until"), 10, 64); err == nil {
+			args = append(args, until)
+			whereClause = append(whereClause, fmt.Sprintf("v.entered_at < $%d", len(args)))
+		}
+		if q.Get("open") == "true" {
+			whereClause = append(whereClause, "v.exited_at IS NULL")
+		}
+
+		if len(whereClause) > 0 {
+			query += " WHERE " + strings.Join(whereClause, " AND ")
+		}
+
+		limit := 100
+		if parsed, err := strconv.Atoi(q.Get("limit")); err == nil && parsed > 0 && parsed <= 500 {
+			limit = parsed
+		}
+		args = append(args, limit)
+		query += fmt.Sprintf(" ORDER BY v.entered_at DESC LIMIT $%d", len(args))
+
+		visits := []models.ZoneVisit{}
+		if err := db.SelectContext(r.Context(), &visits, query, args...); err != nil {
+			log.Printf("❌ [ZONE-ALERT] Failed to fetch zone visits: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch zone visits")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    visits,
+		})
+	}
+}
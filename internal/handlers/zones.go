@@ -1,16 +1,23 @@
 package handlers
 
 import (
+	"database/sql"
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"strings"
 	"time"
 
+	"ropacal-backend/internal/helpers"
 	"ropacal-backend/internal/middleware"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/store"
 	"ropacal-backend/pkg/utils"
 
+	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 // NoGoZoneResponse represents a no-go zone with ISO timestamps for frontend
@@ -32,6 +39,7 @@ type NoGoZoneResponse struct {
 	MergedIntoZoneID *string `json:"merged_into_zone_id,omitempty"` // If this zone was merged into another
 	ResolutionType   *string `json:"resolution_type,omitempty"`     // 'merged' or 'manual_resolution'
 	MergedZoneCount  int     `json:"merged_zone_count,omitempty"`   // Count of zones that were merged into this one
+	AreaID           *string `json:"area_id,omitempty"`             // Area containing the zone center
 }
 
 // ZoneIncidentResponse represents an incident with ISO timestamps
@@ -54,14 +62,17 @@ type ZoneIncidentResponse struct {
 	VerifiedByUserID   *string  `json:"verified_by_user_id,omitempty"`
 	VerifiedAtISO      *string  `json:"verified_at_iso,omitempty"`
 	Status             string   `json:"status"`
+	ParentIncidentID   *string  `json:"parent_incident_id,omitempty"` // Set on repeat reports
+	ReportCount        int      `json:"report_count"`                 // This report plus its repeats
 }
 
-// GetNoGoZones returns all no-go zones (optionally filtered by status)
+// GetNoGoZones returns all no-go zones (optionally filtered by status and area)
 func GetNoGoZones(db *sqlx.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		log.Printf("📥 REQUEST: GET /api/no-go-zones")
 
 		status := r.URL.Query().Get("status")
+		areaID := r.URL.Query().Get("area_id")
 		includeMerged := r.URL.Query().Get("include_merged") == "true"
 
 		var zones []struct {
@@ -80,6 +91,8 @@ func GetNoGoZones(db *sqlx.DB) http.HandlerFunc {
 			ResolutionNotes  *string `db:"resolution_notes"`
 			MergedIntoZoneID *string `db:"merged_into_zone_id"`
 			ResolutionType   *string `db:"resolution_type"`
+			AreaID           *string `db:"area_id"`
+			Geog             *string `db:"geog"` // Only when PostGIS is enabled
 		}
 
 		// Build query with merge filter
@@ -100,13 +113,20 @@ func GetNoGoZones(db *sqlx.DB) http.HandlerFunc {
 			argIndex++
 		}
 
+		// Apply area filter if provided
+		if areaID != "" {
+			whereClause = append(whereClause, fmt.Sprintf("area_id = $%d", argIndex))
+			args = append(args, areaID)
+			argIndex++
+		}
+
 		if len(whereClause) > 0 {
 			query += " WHERE " + strings.Join(whereClause, " AND ")
 		}
 
 		query += " ORDER BY updated_at DESC"
 
-		if err := db.Select(&zones, query, args...); err != nil {
+		if err := db.SelectContext(r.Context(), &zones, query, args...); err != nil {
 			log.Printf("❌ Error fetching zones: %v", err)
 			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch zones")
 			return
@@ -117,7 +137,7 @@ func GetNoGoZones(db *sqlx.DB) http.HandlerFunc {
 		for i, zone := range zones {
 			// Count zones that were merged into this zone
 			mergedCount := 0
-			if err := db.Get(&mergedCount, "SELECT COUNT(*) FROM no_go_zones WHERE merged_into_zone_id = $1", zone.ID); err != nil {
+			if err := db.GetContext(r.Context(), &mergedCount, "SELECT COUNT(*) FROM no_go_zones WHERE merged_into_zone_id = $1", zone.ID); err != nil {
 				log.Printf("⚠️ Error counting merged zones for %s: %v", zone.ID, err)
 			}
 
@@ -137,6 +157,7 @@ func GetNoGoZones(db *sqlx.DB) http.HandlerFunc {
 				MergedIntoZoneID: zone.MergedIntoZoneID,
 				ResolutionType:   zone.ResolutionType,
 				MergedZoneCount:  mergedCount,
+				AreaID:           zone.AreaID,
 			}
 
 			if zone.ResolvedAt != nil {
@@ -172,9 +193,11 @@ func GetNoGoZone(db *sqlx.DB) http.HandlerFunc {
 			ResolutionNotes  *string `db:"resolution_notes"`
 			MergedIntoZoneID *string `db:"merged_into_zone_id"`
 			ResolutionType   *string `db:"resolution_type"`
+			AreaID           *string `db:"area_id"`
+			Geog             *string `db:"geog"` // Only when PostGIS is enabled
 		}
 
-		if err := db.Get(&zone, "SELECT * FROM no_go_zones WHERE id = $1", zoneID); err != nil {
+		if err := db.GetContext(r.Context(), &zone, "SELECT * FROM no_go_zones WHERE id = $1", zoneID); err != nil {
 			log.Printf("❌ Zone not found: %v", err)
 			utils.RespondError(w, http.StatusNotFound, "Zone not found")
 			return
@@ -182,7 +205,7 @@ func GetNoGoZone(db *sqlx.DB) http.HandlerFunc {
 
 		// Count zones that were merged into this zone
 		mergedCount := 0
-		if err := db.Get(&mergedCount, "SELECT COUNT(*) FROM no_go_zones WHERE merged_into_zone_id = $1", zone.ID); err != nil {
+		if err := db.GetContext(r.Context(), &mergedCount, "SELECT COUNT(*) FROM no_go_zones WHERE merged_into_zone_id = $1", zone.ID); err != nil {
 			log.Printf("⚠️ Error counting merged zones for %s: %v", zone.ID, err)
 		}
 
@@ -202,6 +225,7 @@ func GetNoGoZone(db *sqlx.DB) http.HandlerFunc {
 			MergedIntoZoneID: zone.MergedIntoZoneID,
 			ResolutionType:   zone.ResolutionType,
 			MergedZoneCount:  mergedCount,
+			AreaID:           zone.AreaID,
 		}
 
 		if zone.ResolvedAt != nil {
@@ -241,6 +265,8 @@ func GetZoneIncidents(db *sqlx.DB) http.HandlerFunc {
 			VerifiedByUserID   *string  `db:"verified_by_user_id"`
 			VerifiedAt         *int64   `db:"verified_at"`
 			Status             string   `db:"status"`
+			ParentIncidentID   *string  `db:"parent_incident_id"`
+			ReportCount        int      `db:"report_count"`
 		}
 
 		var query string
@@ -266,7 +292,7 @@ func GetZoneIncidents(db *sqlx.DB) http.HandlerFunc {
 			`
 		}
 
-		if err := db.Select(&incidents, query, zoneID); err != nil {
+		if err := db.SelectContext(r.Context(), &incidents, query, zoneID); err != nil {
 			log.Printf("❌ Error fetching incidents: %v", err)
 			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch incidents")
 			return
@@ -293,6 +319,8 @@ func GetZoneIncidents(db *sqlx.DB) http.HandlerFunc {
 				IsFieldObservation: incident.IsFieldObservation,
 				VerifiedByUserID:   incident.VerifiedByUserID,
 				Status:             incident.Status,
+				ParentIncidentID:   incident.ParentIncidentID,
+				ReportCount:        incident.ReportCount,
 			}
 
 			if incident.VerifiedAt != nil {
@@ -331,6 +359,8 @@ func GetShiftIncidents(db *sqlx.DB) http.HandlerFunc {
 			VerifiedByUserID   *string  `db:"verified_by_user_id"`
 			VerifiedAt         *int64   `db:"verified_at"`
 			Status             string   `db:"status"`
+			ParentIncidentID   *string  `db:"parent_incident_id"`
+			ReportCount        int      `db:"report_count"`
 		}
 
 		query := `
@@ -341,7 +371,7 @@ func GetShiftIncidents(db *sqlx.DB) http.HandlerFunc {
 			ORDER BY zi.reported_at DESC
 		`
 
-		if err := db.Select(&incidents, query, shiftID); err != nil {
+		if err := db.SelectContext(r.Context(), &incidents, query, shiftID); err != nil {
 			log.Printf("❌ Error fetching shift incidents: %v", err)
 			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch incidents")
 			return
@@ -368,6 +398,8 @@ func GetShiftIncidents(db *sqlx.DB) http.HandlerFunc {
 				IsFieldObservation: incident.IsFieldObservation,
 				VerifiedByUserID:   incident.VerifiedByUserID,
 				Status:             incident.Status,
+				ParentIncidentID:   incident.ParentIncidentID,
+				ReportCount:        incident.ReportCount,
 			}
 
 			if incident.VerifiedAt != nil {
@@ -382,6 +414,7 @@ func GetShiftIncidents(db *sqlx.DB) http.HandlerFunc {
 }
 
 // GetFieldObservations returns field observations for manager review
+// Managers assigned to areas get their areas' observations unless all_areas=true
 func GetFieldObservations(db *sqlx.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		log.Printf("📥 REQUEST: GET /api/field-observations")
@@ -409,13 +442,15 @@ func GetFieldObservations(db *sqlx.DB) http.HandlerFunc {
 			VerifiedByName     *string  `db:"verified_by_name"`
 			VerifiedAt         *int64   `db:"verified_at"`
 			Status             string   `db:"status"`
+			ParentIncidentID   *string  `db:"parent_incident_id"`
+			ReportCount        int      `db:"report_count"`
 		}
 
 		query := `
 			SELECT zi.*, 
 			       b.bin_number,
-			       u1.full_name as reported_by_name,
-			       u2.full_name as verified_by_name
+			       u1.name as reported_by_name,
+			       u2.name as verified_by_name
 			FROM zone_incidents zi
 			LEFT JOIN bins b ON zi.bin_id = b.id
 			LEFT JOIN users u1 ON zi.reported_by_user_id = u1.id
@@ -430,9 +465,17 @@ func GetFieldObservations(db *sqlx.DB) http.HandlerFunc {
 			query += " AND zi.verified_at IS NOT NULL"
 		}
 
+		scope, err := managerAreaScope(r, db)
+		if err != nil {
+			log.Printf("❌ Error loading area scope: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch field observations")
+			return
+		}
+		query += " AND ($1::TEXT[] IS NULL OR b.area_id = ANY($1) OR zi.zone_id IN (SELECT id FROM no_go_zones WHERE area_id = ANY($1)))"
+
 		query += " ORDER BY zi.reported_at DESC"
 
-		if err := db.Select(&incidents, query); err != nil {
+		if err := db.SelectContext(r.Context(), &incidents, query, pq.Array(scope)); err != nil {
 			log.Printf("❌ Error fetching field observations: %v", err)
 			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch field observations")
 			return
@@ -466,6 +509,8 @@ func GetFieldObservations(db *sqlx.DB) http.HandlerFunc {
 					IsFieldObservation: incident.IsFieldObservation,
 					VerifiedByUserID:   incident.VerifiedByUserID,
 					Status:             incident.Status,
+					ParentIncidentID:   incident.ParentIncidentID,
+					ReportCount:        incident.ReportCount,
 				},
 				ReportedByName: incident.ReportedByName,
 				VerifiedByName: incident.VerifiedByName,
@@ -482,6 +527,153 @@ func GetFieldObservations(db *sqlx.DB) http.HandlerFunc {
 	}
 }
 
+// fieldObservationRequest is the body of POST /api/driver/field-observations
+type fieldObservationRequest struct {
+	BinID        string   `json:"bin_id" validate:"required"`
+	IncidentType string   `json:"incident_type" validate:"required"` // Key of an active incident type
+	Description  *string  `json:"description" validate:"max=2000"`
+	PhotoURL     *string  `json:"photo_url" validate:"format=uri"`
+	Latitude     *float64 `json:"latitude"` // Where the driver was when reporting
+	Longitude    *float64 `json:"longitude"`
+}
+
+// ReportFieldObservation records something a driver noticed at a bin outside of a check
+// The observation scores the bin's zone like any incident and counts toward the driver's active shift;
+// managers review it through GET /api/field-observations
+// A repeat of an open incident (same bin and type, within the dedup window) is linked to it instead of
+// scoring the zone again
+func ReportFieldObservation(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Printf("📥 REQUEST: POST /api/driver/field-observations")
+
+		userClaims, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var req fieldObservationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		var bin models.Bin
+		if err := db.GetContext(r.Context(), &bin, "SELECT * FROM bins WHERE id = $1", req.BinID); err != nil {
+			if err == sql.ErrNoRows {
+				utils.RespondError(w, http.StatusNotFound, "Bin not found")
+				return
+			}
+			log.Printf("❌ Error fetching bin %s: %v", req.BinID, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch bin")
+			return
+		}
+		if bin.Latitude == nil || bin.Longitude == nil {
+			utils.RespondError(w, http.StatusUnprocessableEntity, "Bin has no coordinates")
+			return
+		}
+		incidentType, err := lookupIncidentType(r.Context(), db, req.IncidentType, false)
+		if err != nil {
+			log.Printf("❌ %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to validate incident_type")
+			return
+		}
+		if incidentType == nil {
+			utils.RespondError(w, http.StatusBadRequest, "Invalid incident_type")
+			return
+		}
+
+		// Observations made between shifts are kept, just not counted toward a shift
+		var shiftID *string
+		var activeShiftID string
+		err = db.GetContext(r.Context(), &activeShiftID, `
+			SELECT id FROM shifts WHERE driver_id = $1 AND status IN ('active', 'paused') LIMIT 1
+		`, userClaims.UserID)
+		if err == nil {
+			shiftID = &activeShiftID
+		} else if err != sql.ErrNoRows {
+			log.Printf("⚠️  Error fetching active shift for %s: %v", userClaims.UserID, err)
+		}
+
+		now := time.Now().Unix()
+
+		// A repeat of an open report joins it instead of scoring the zone again
+		parent, err := findRepeatedIncident(r.Context(), db, req.BinID, req.IncidentType, now)
+		if err != nil {
+			log.Printf("⚠️  %v", err)
+		}
+
+		incident := models.ZoneIncident{
+			ID:                 uuid.New().String(),
+			BinID:              req.BinID,
+			IncidentType:       req.IncidentType,
+			ReportedByUserID:   &userClaims.UserID,
+			ReportedAt:         now,
+			Description:        req.Description,
+			PhotoURL:           req.PhotoURL,
+			ShiftID:            shiftID,
+			ReporterLatitude:   req.Latitude,
+			ReporterLongitude:  req.Longitude,
+			IsFieldObservation: true,
+			Status:             "open",
+			ReportCount:        1,
+		}
+		if parent != nil {
+			incident.ZoneID = parent.ZoneID
+			incident.ParentIncidentID = &parent.ID
+		} else {
+			incident.ZoneID = recordIncidentZone(r.Context(), db, bin, *incidentType, now)
+		}
+		_, err = db.NamedExecContext(r.Context(), `
+			INSERT INTO zone_incidents (id, zone_id, bin_id, incident_type, reported_by_user_id, reported_at, description, photo_url,
+				shift_id, reporter_latitude, reporter_longitude, is_field_observation, status, parent_incident_id)
+			VALUES (:id, :zone_id, :bin_id, :incident_type, :reported_by_user_id, :reported_at, :description, :photo_url,
+				:shift_id, :reporter_latitude, :reporter_longitude, :is_field_observation, :status, :parent_incident_id)
+		`, incident)
+		if err != nil {
+			log.Printf("❌ Error inserting field observation: %v", err)
+			utils.RespondDBError(w, err, "Failed to save field observation")
+			return
+		}
+
+		if shiftID != nil {
+			if err := store.NewShiftStore(db).CountIncident(*shiftID, true); err != nil {
+				log.Printf("⚠️  %v", err)
+			}
+		}
+		if err := helpers.EnqueueIncidentPhoto(db, incident.ID, incident.PhotoURL); err != nil {
+			log.Printf("⚠️  %v", err)
+		}
+		// Subscribers already heard about the first report
+		if parent != nil {
+			if count, err := countIncidentReports(r.Context(), db, parent.ID); err != nil {
+				log.Printf("⚠️  %v", err)
+			} else {
+				log.Printf("✅ Field observation %s linked to incident %s as a repeat (%d reports)", incident.ID, parent.ID, count)
+			}
+		} else if err := helpers.NotifyIncidentSubscribers(db, incident.ID); err != nil {
+			log.Printf("⚠️  %v", err)
+		}
+
+		helpers.EmitWebhookEvent(db, models.WebhookEventIncidentCreated, map[string]interface{}{
+			"incident_id":          incident.ID,
+			"parent_incident_id":   incident.ParentIncidentID,
+			"zone_id":              incident.ZoneID,
+			"bin_id":               incident.BinID,
+			"incident_type":        incident.IncidentType,
+			"description":          incident.Description,
+			"photo_url":            incident.PhotoURL,
+			"shift_id":             incident.ShiftID,
+			"is_field_observation": true,
+			"reported_by_user_id":  userClaims.UserID,
+			"reported_at":          now,
+		})
+
+		log.Printf("✅ Field observation %s reported by %s (bin %s, %s)", incident.ID, userClaims.UserID, incident.BinID, incident.IncidentType)
+		utils.RespondJSON(w, http.StatusCreated, incident.ToResponse())
+	}
+}
+
 // VerifyFieldObservation marks a field observation as verified by a manager
 func VerifyFieldObservation(db *sqlx.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -498,7 +690,7 @@ func VerifyFieldObservation(db *sqlx.DB) http.HandlerFunc {
 		now := time.Now().Unix()
 
 		// Update the incident
-		result, err := db.Exec(`
+		result, err := db.ExecContext(r.Context(), `
 			UPDATE zone_incidents 
 			SET verified_by_user_id = $1, verified_at = $2, status = 'investigating'
 			WHERE id = $3 AND is_field_observation = true
@@ -0,0 +1,150 @@
+package helpers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/i18n"
+	"ropacal-backend/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// incidentNotice is a new incident as the incident subscriptions see it
+type incidentNotice struct {
+	ID                 string  `db:"id" json:"incident_id"`
+	IncidentType       string  `db:"incident_type" json:"incident_type"`
+	Label              string  `db:"label" json:"label"`
+	Severity           string  `db:"severity" json:"severity"`
+	BinID              string  `db:"bin_id" json:"bin_id"`
+	BinNumber          *int    `db:"bin_number" json:"bin_number,omitempty"`
+	AreaID             *string `db:"area_id" json:"area_id,omitempty"`
+	Description        *string `db:"description" json:"description,omitempty"`
+	PhotoURL           *string `db:"photo_url" json:"photo_url,omitempty"`
+	ShiftID            *string `db:"shift_id" json:"shift_id,omitempty"`
+	IsFieldObservation bool    `db:"is_field_observation" json:"is_field_observation"`
+	ReportedByUserID   *string `db:"reported_by_user_id" json:"reported_by_user_id,omitempty"`
+	ReportedAt         int64   `db:"reported_at" json:"reported_at"`
+}
+
+// incidentChannels are the channels a manager hears about an incident on, now and in the daily summary
+type incidentChannels struct {
+	websocket, push           bool
+	dailyWebSocket, dailyPush bool
+}
+
+// NotifyIncidentSubscribers routes a new incident through the managers' incident subscriptions: immediate
+// subscriptions queue an incident_created WebSocket message and/or push, daily ones queue the incident for the
+// manager's daily summary (see services.IncidentSummaryNotifier). A channel already used immediately isn't
+// repeated in the summary
+func NotifyIncidentSubscribers(db *sqlx.DB, incidentID string) error {
+	var incident incidentNotice
+	err := db.Get(&incident, `
+		SELECT zi.id, zi.incident_type, COALESCE(it.label, zi.incident_type) AS label,
+			COALESCE(it.severity, $2) AS severity, zi.bin_id, b.bin_number, b.area_id, zi.description, zi.photo_url,
+			zi.shift_id, zi.is_field_observation, zi.reported_by_user_id, zi.reported_at
+		FROM zone_incidents zi
+		LEFT JOIN incident_types it ON it.key = zi.incident_type
+		LEFT JOIN bins b ON b.id = zi.bin_id
+		WHERE zi.id = $1
+	`, incidentID, models.IncidentSeverityMedium)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load incident %s for notifications: %w", incidentID, err)
+	}
+
+	var subscriptions []models.IncidentSubscription
+	err = db.Select(&subscriptions, `
+		SELECT s.* FROM incident_subscriptions s
+		JOIN users u ON u.id = s.user_id
+		WHERE u.deactivated_at IS NULL
+		  AND (cardinality(s.incident_types) = 0 OR $1 = ANY(s.incident_types))
+		  AND (cardinality(s.severities) = 0 OR $2 = ANY(s.severities))
+		  AND (cardinality(s.area_ids) = 0 OR $3 = ANY(s.area_ids))
+	`, incident.IncidentType, incident.Severity, incident.AreaID)
+	if err != nil {
+		return fmt.Errorf("failed to load incident subscriptions: %w", err)
+	}
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	recipients := map[string]*incidentChannels{}
+	var order []string
+	for _, subscription := range subscriptions {
+		channels, ok := recipients[subscription.UserID]
+		if !ok {
+			channels = &incidentChannels{}
+			recipients[subscription.UserID] = channels
+			order = append(order, subscription.UserID)
+		}
+		if subscription.Delivery == models.IncidentDeliveryDaily {
+			channels.dailyWebSocket = channels.dailyWebSocket || subscription.WebSocket
+			channels.dailyPush = channels.dailyPush || subscription.Push
+		} else {
+			channels.websocket = channels.websocket || subscription.WebSocket
+			channels.push = channels.push || subscription.Push
+		}
+	}
+
+	return database.WithTx(context.Background(), db, func(tx *sqlx.Tx) error {
+		now := time.Now().Unix()
+		for _, userID := range order {
+			channels := recipients[userID]
+			if channels.websocket {
+				if _, err := EnqueueUserMessage(tx, userID, map[string]interface{}{
+					"type": "incident_created",
+					"data": incident,
+				}); err != nil {
+					return err
+				}
+			}
+			if channels.push {
+				if _, err := EnqueuePush(tx, userID, incidentPush(database.UserLocale(tx, userID), incident)); err != nil {
+					return err
+				}
+			}
+
+			dailyWebSocket := channels.dailyWebSocket && !channels.websocket
+			dailyPush := channels.dailyPush && !channels.push
+			if dailyWebSocket || dailyPush {
+				_, err := tx.Exec(`
+					INSERT INTO incident_summary_queue (user_id, incident_id, websocket, push, queued_at)
+					VALUES ($1, $2, $3, $4, $5)
+					ON CONFLICT (user_id, incident_id) DO NOTHING
+				`, userID, incident.ID, dailyWebSocket, dailyPush, now)
+				if err != nil {
+					return fmt.Errorf("failed to queue incident %s for %s's summary: %w", incident.ID, userID, err)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// incidentPush builds the push for a new incident; critical incidents are delivered outside service hours too
+func incidentPush(locale string, incident incidentNotice) models.OutboxPush {
+	body := i18n.T(locale, "Reported at a bin")
+	if incident.BinNumber != nil {
+		body = i18n.T(locale, "Reported at bin #%d", *incident.BinNumber)
+	}
+	return models.OutboxPush{
+		Title: i18n.T(locale, "New incident: %s", incident.Label),
+		Body:  body,
+		Data: map[string]string{
+			"type":          "incident_created",
+			"incident_id":   incident.ID,
+			"incident_type": incident.IncidentType,
+			"severity":      incident.Severity,
+			"bin_id":        incident.BinID,
+			"reported_at":   strconv.FormatInt(incident.ReportedAt, 10),
+		},
+		Urgent: incident.Severity == models.IncidentSeverityCritical,
+	}
+}
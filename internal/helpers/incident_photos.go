@@ -0,0 +1,34 @@
+package helpers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"ropacal-backend/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// EnqueueIncidentPhoto queues an incident's photo for classification by services.IncidentPhotoTagger
+// Does nothing when the incident has no photo. Queueing a different photo for the same incident starts
+// over (its old labels stay until the new photo is tagged); queueing the same photo again is a no-op
+func EnqueueIncidentPhoto(q sqlx.Execer, incidentID string, photoURL *string) error {
+	if photoURL == nil || strings.TrimSpace(*photoURL) == "" {
+		return nil
+	}
+
+	now := time.Now().Unix()
+	_, err := q.Exec(`
+		INSERT INTO incident_photo_jobs (incident_id, photo_url, status, attempts, next_attempt_at, created_at, updated_at)
+		VALUES ($1, $2, $3, 0, $4, $4, $4)
+		ON CONFLICT (incident_id) DO UPDATE SET
+			photo_url = EXCLUDED.photo_url, status = EXCLUDED.status, attempts = 0,
+			next_attempt_at = EXCLUDED.next_attempt_at, last_error = NULL, updated_at = EXCLUDED.updated_at
+		WHERE incident_photo_jobs.photo_url <> EXCLUDED.photo_url
+	`, incidentID, strings.TrimSpace(*photoURL), models.IncidentPhotoPending, now)
+	if err != nil {
+		return fmt.Errorf("failed to queue photo of incident %s for tagging: %w", incidentID, err)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"ropacal-backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// jobDefaultMaxAttempts is how often a job is tried before it's dead-lettered
+const jobDefaultMaxAttempts = 5
+
+// EnqueueJob queues a background job for services.JobRunner to run at runAt (unix; 0 runs it as soon as possible)
+// Pass the transaction that makes the change the job follows up on, so both commit or neither does
+func EnqueueJob(q sqlx.Execer, kind string, payload interface{}, runAt int64) (string, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode %s job: %w", kind, err)
+	}
+	if payload == nil {
+		raw = []byte("{}")
+	}
+
+	id := uuid.New().String()
+	now := time.Now().Unix()
+	if runAt == 0 {
+		runAt = now
+	}
+	_, err = q.Exec(`
+		INSERT INTO jobs (id, kind, payload, status, attempts, max_attempts, run_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, 0, $5, $6, $7, $7)
+	`, id, kind, string(raw), models.JobStatusPending, jobDefaultMaxAttempts, runAt, now)
+	if err != nil {
+		return "", fmt.Errorf("failed to queue %s job: %w", kind, err)
+	}
+	return id, nil
+}
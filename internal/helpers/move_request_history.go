@@ -9,7 +9,7 @@ import (
 )
 
 // LogMoveRequestCreated logs when a move request is created
-func LogMoveRequestCreated(db *sqlx.DB, moveRequestID string, actorID string, actorName string) error {
+func LogMoveRequestCreated(db sqlx.Execer, moveRequestID string, actorID string, actorName string) error {
 	historyID := uuid.New().String()
 
 	query := `
@@ -36,7 +36,7 @@ func LogMoveRequestCreated(db *sqlx.DB, moveRequestID string, actorID string, ac
 }
 
 // LogMoveRequestAssigned logs when a move request is assigned
-func LogMoveRequestAssigned(db *sqlx.DB, moveRequestID string, actorID string, actorName string, assignmentType string, assignedUserID *string, assignedUserName *string, assignedShiftID *string) error {
+func LogMoveRequestAssigned(db sqlx.Execer, moveRequestID string, actorID string, actorName string, assignmentType string, assignedUserID *string, assignedUserName *string, assignedShiftID *string) error {
 	historyID := uuid.New().String()
 
 	query := `
@@ -69,7 +69,7 @@ func LogMoveRequestAssigned(db *sqlx.DB, moveRequestID string, actorID string, a
 }
 
 // LogMoveRequestReassigned logs when a move request is reassigned
-func LogMoveRequestReassigned(db *sqlx.DB, moveRequestID string, actorID string, actorName string,
+func LogMoveRequestReassigned(db sqlx.Execer, moveRequestID string, actorID string, actorName string,
 	previousAssignmentType *string, newAssignmentType *string,
 	previousAssignedUserID *string, newAssignedUserID *string,
 	previousAssignedUserName *string, newAssignedUserName *string,
@@ -114,7 +114,7 @@ func LogMoveRequestReassigned(db *sqlx.DB, moveRequestID string, actorID string,
 }
 
 // LogMoveRequestUnassigned logs when a move request is unassigned
-func LogMoveRequestUnassigned(db *sqlx.DB, moveRequestID string, actorID string, actorName string,
+func LogMoveRequestUnassigned(db sqlx.Execer, moveRequestID string, actorID string, actorName string,
 	previousAssignmentType *string, previousAssignedUserID *string, previousAssignedUserName *string, previousAssignedShiftID *string) error {
 
 	historyID := uuid.New().String()
@@ -149,7 +149,7 @@ func LogMoveRequestUnassigned(db *sqlx.DB, moveRequestID string, actorID string,
 }
 
 // LogMoveRequestCompleted logs when a move request is completed
-func LogMoveRequestCompleted(db *sqlx.DB, moveRequestID string, actorID string, actorName string) error {
+func LogMoveRequestCompleted(db sqlx.Execer, moveRequestID string, actorID string, actorName string) error {
 	historyID := uuid.New().String()
 
 	query := `
@@ -182,7 +182,7 @@ func LogMoveRequestCompleted(db *sqlx.DB, moveRequestID string, actorID string,
 }
 
 // LogMoveRequestCancelled logs when a move request is cancelled
-func LogMoveRequestCancelled(db *sqlx.DB, moveRequestID string, actorID string, actorName string, reason *string) error {
+func LogMoveRequestCancelled(db sqlx.Execer, moveRequestID string, actorID string, actorName string, reason *string) error {
 	historyID := uuid.New().String()
 
 	query := `
@@ -216,7 +216,7 @@ func LogMoveRequestCancelled(db *sqlx.DB, moveRequestID string, actorID string,
 }
 
 // LogMoveRequestUpdated logs when a move request details are updated
-func LogMoveRequestUpdated(db *sqlx.DB, moveRequestID string, actorID string, actorName string, notes *string, metadata *string) error {
+func LogMoveRequestUpdated(db sqlx.Execer, moveRequestID string, actorID string, actorName string, notes *string, metadata *string) error {
 	historyID := uuid.New().String()
 
 	query := `
@@ -0,0 +1,56 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"ropacal-backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// EnqueueUserMessage queues a WebSocket message for one user
+// Pass the transaction that makes the change the message announces, so both commit or neither does
+func EnqueueUserMessage(q sqlx.Ext, userID string, message map[string]interface{}) (string, error) {
+	return enqueueOutboxEvent(q, models.OutboxChannelWebSocket, models.OutboxTargetUser, userID, message)
+}
+
+// EnqueueRoleMessage queues a WebSocket message for every connected user with a role
+func EnqueueRoleMessage(q sqlx.Ext, role string, message map[string]interface{}) (string, error) {
+	return enqueueOutboxEvent(q, models.OutboxChannelWebSocket, models.OutboxTargetRole, role, message)
+}
+
+// EnqueuePush queues a push notification to every registered device of a user
+func EnqueuePush(q sqlx.Ext, userID string, push models.OutboxPush) (string, error) {
+	return enqueueOutboxEvent(q, models.OutboxChannelPush, models.OutboxTargetUser, userID, push)
+}
+
+// enqueueOutboxEvent inserts a pending outbox event (see services.NotificationDispatcher)
+func enqueueOutboxEvent(q sqlx.Ext, channel, targetType, target string, payload interface{}) (string, error) {
+	var eventType string
+	switch p := payload.(type) {
+	case map[string]interface{}:
+		eventType, _ = p["type"].(string)
+	case models.OutboxPush:
+		eventType = p.Data["type"]
+	}
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode %s notification: %w", eventType, err)
+	}
+
+	id := uuid.New().String()
+	now := time.Now().Unix()
+	_, err = q.Exec(`
+		INSERT INTO notification_outbox (
+			id, channel, target_type, target, event_type, payload, status, attempts, next_attempt_at, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, 0, $8, $8, $8)
+	`, id, channel, targetType, target, eventType, string(raw), models.OutboxStatusPending, now)
+	if err != nil {
+		return "", fmt.Errorf("failed to queue %s notification for %s: %w", eventType, target, err)
+	}
+	return id, nil
+}
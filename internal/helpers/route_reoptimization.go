@@ -0,0 +1,39 @@
+package helpers
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"ropacal-backend/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// RequestRouteReoptimization queues a shift's remaining route for re-optimization by services.RouteReoptimizer
+// A request made while another is pending is merged into it, so bursts of changes cause a single run
+// Only active and paused shifts are queued; nil is returned for any other shift
+// Pass the transaction that changes the route, so the request commits with it
+func RequestRouteReoptimization(q sqlx.Ext, shiftID, reason string) (*models.RouteReoptimization, error) {
+	var queued models.RouteReoptimization
+	err := sqlx.Get(q, &queued, `
+		INSERT INTO route_reoptimizations (shift_id, pending, reasons, requested_at)
+		SELECT id, TRUE, ARRAY[$2::text], $3 FROM shifts WHERE id = $1 AND status IN ('active', 'paused')
+		ON CONFLICT (shift_id) DO UPDATE SET
+			reasons = CASE
+				WHEN NOT route_reoptimizations.pending THEN ARRAY[$2::text]
+				WHEN $2 = ANY(route_reoptimizations.reasons) THEN route_reoptimizations.reasons
+				ELSE array_append(route_reoptimizations.reasons, $2::text)
+			END,
+			requested_at = CASE WHEN route_reoptimizations.pending THEN route_reoptimizations.requested_at ELSE $3 END,
+			pending = TRUE
+		RETURNING *
+	`, shiftID, reason, time.Now().Unix())
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to queue route re-optimization for shift %s: %w", shiftID, err)
+	}
+	return &queued, nil
+}
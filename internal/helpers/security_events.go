@@ -0,0 +1,34 @@
+package helpers
+
+import (
+	"log"
+	"time"
+
+	"ropacal-backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// LogSecurityEvent records an authentication/security event in the audit log
+func LogSecurityEvent(db *sqlx.DB, event models.SecurityEvent) error {
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+	if event.CreatedAt == 0 {
+		event.CreatedAt = time.Now().Unix()
+	}
+
+	_, err := db.NamedExec(`
+		INSERT INTO security_events (
+			id, event_type, user_id, email, ip_address, user_agent, actor_id, details, created_at
+		) VALUES (
+			:id, :event_type, :user_id, :email, :ip_address, :user_agent, :actor_id, :details, :created_at
+		)
+	`, event)
+	if err != nil {
+		log.Printf("[SECURITY] Failed to log '%s' event: %v", event.EventType, err)
+	}
+
+	return err
+}
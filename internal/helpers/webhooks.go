@@ -0,0 +1,68 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"ropacal-backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// EmitWebhookEvent queues an event for every active webhook subscribed to its type
+// Delivery happens asynchronously (see services.WebhookDispatcher)
+func EmitWebhookEvent(db *sqlx.DB, eventType string, data interface{}) error {
+	now := time.Now().Unix()
+	event := models.WebhookEvent{
+		ID:        uuid.New().String(),
+		Type:      eventType,
+		CreatedAt: now,
+		Data:      data,
+	}
+	_, err := QueueWebhookEvent(db, event, "")
+	return err
+}
+
+// QueueWebhookEvent inserts pending deliveries of an event, either to one webhook (webhookID set)
+// or to every active webhook subscribed to the event type. Returns the number of deliveries queued
+func QueueWebhookEvent(db *sqlx.DB, event models.WebhookEvent, webhookID string) (int64, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("[WEBHOOKS] Failed to encode '%s' event: %v", event.Type, err)
+		return 0, fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	var webhookIDs []string
+	if webhookID != "" {
+		webhookIDs = []string{webhookID}
+	} else {
+		err = db.Select(&webhookIDs, `
+			SELECT id FROM webhooks
+			WHERE is_active = TRUE AND (cardinality(event_types) = 0 OR $1 = ANY(event_types))
+		`, event.Type)
+		if err != nil {
+			log.Printf("[WEBHOOKS] Failed to find subscribers for '%s' event: %v", event.Type, err)
+			return 0, fmt.Errorf("failed to find subscribed webhooks: %w", err)
+		}
+	}
+
+	now := time.Now().Unix()
+	var queued int64
+	for _, id := range webhookIDs {
+		_, err := db.Exec(`
+			INSERT INTO webhook_deliveries (
+				id, webhook_id, event_id, event_type, payload, status, attempts, next_attempt_at, created_at, updated_at
+			) VALUES ($1, $2, $3, $4, $5, $6, 0, $7, $7, $7)
+		`, uuid.New().String(), id, event.ID, event.Type, string(payload), models.WebhookDeliveryPending, now)
+		if err != nil {
+			log.Printf("[WEBHOOKS] Failed to queue '%s' event for webhook %s: %v", event.Type, id, err)
+			return queued, fmt.Errorf("failed to queue event: %w", err)
+		}
+		queued++
+	}
+
+	return queued, nil
+}
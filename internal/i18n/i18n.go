@@ -0,0 +1,125 @@
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Supported locales
+const (
+	English = "en"
+	Spanish = "es"
+
+	DefaultLocale = English
+)
+
+type contextKey string
+
+const localeContextKey contextKey = "locale"
+
+// catalogs maps locale -> English message -> translation
+// English messages double as keys, so a missing translation falls back to the original text
+var catalogs = map[string]map[string]string{
+	Spanish: spanishMessages,
+}
+
+// SupportedLocales returns the locales users can choose
+func SupportedLocales() []string {
+	return []string{English, Spanish}
+}
+
+// Normalize maps a language tag ("es-MX", "ES", "en_US") to a supported locale, or "" if unsupported
+func Normalize(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	switch tag {
+	case English, Spanish:
+		return tag
+	}
+	return ""
+}
+
+// FromAcceptLanguage picks the best supported locale from an Accept-Language header, or "" if none match
+func FromAcceptLanguage(header string) string {
+	type candidate struct {
+		locale string
+		q      float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		locale := Normalize(fields[0])
+		if locale == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if parsed, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{locale: locale, q: q})
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+	return candidates[0].locale
+}
+
+// T translates an English message (optionally a fmt format string) into the locale
+func T(locale, message string, args ...interface{}) string {
+	if translated, ok := catalogs[Normalize(locale)][message]; ok {
+		message = translated
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
+
+// WithLocale returns a context carrying the request locale
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeContextKey, locale)
+}
+
+// FromContext returns the locale set by the Locale middleware, or the default locale
+func FromContext(ctx context.Context) string {
+	if locale, ok := ctx.Value(localeContextKey).(string); ok && locale != "" {
+		return locale
+	}
+	return DefaultLocale
+}
+
+// Tr translates a message into the request's locale
+func Tr(r *http.Request, message string, args ...interface{}) string {
+	return T(FromContext(r.Context()), message, args...)
+}
+
+// Resolve returns the stored user preference if set, then the Accept-Language match, then the default
+func Resolve(preference *string, acceptLanguage string) string {
+	if preference != nil {
+		if locale := Normalize(*preference); locale != "" {
+			return locale
+		}
+	}
+	if locale := FromAcceptLanguage(acceptLanguage); locale != "" {
+		return locale
+	}
+	return DefaultLocale
+}
@@ -0,0 +1,132 @@
+package i18n
+
+// spanishMessages translates driver-facing messages, notifications and errors
+// Keys must match the English text passed to T/Tr exactly (including fmt verbs)
+var spanishMessages = map[string]string{
+	// Common errors
+	"Unauthorized":           "No autorizado",
+	"Invalid request body":   "Solicitud no válida",
+	"Database error":         "Error de base de datos",
+	"Unsupported locale":     "Idioma no compatible",
+	"User not found":         "Usuario no encontrado",
+	"Failed to save locale":  "No se pudo guardar el idioma",
+	"Validation failed":      "Datos no válidos",
+	"Request body too large": "La solicitud es demasiado grande",
+
+	// Login
+	"Invalid email or password":                                         "Correo electrónico o contraseña incorrectos",
+	"Too many failed login attempts. Try again later.":                  "Demasiados intentos fallidos. Inténtalo de nuevo más tarde.",
+	"Account temporarily locked due to too many failed login attempts.": "Cuenta bloqueada temporalmente por demasiados intentos fallidos.",
+	"This account has been deactivated. Contact your manager.":          "Esta cuenta ha sido desactivada. Contacta a tu supervisor.",
+	"Your account has been deactivated":                                 "Tu cuenta ha sido desactivada",
+
+	// Pre-start checklist
+	"Missing answer for %q":                                       "Falta la respuesta para %q",
+	"A photo is required for %q":                                  "Se requiere una foto para %q",
+	"Failed to save checklist":                                    "No se pudo guardar la lista de verificación",
+	"Complete the pre-start checklist before starting your shift": "Completa la lista de verificación antes de iniciar el turno",
+
+	// Shift lifecycle
+	"Shift not found":                          "Turno no encontrado",
+	"No active shift":                          "No hay un turno activo",
+	"No active shift found":                    "No se encontró un turno activo",
+	"No route assigned. Contact your manager.": "No tienes una ruta asignada. Contacta a tu supervisor.",
+	"Please enable GPS to start shift":         "Activa el GPS para iniciar el turno",
+	"Failed to fetch shift":                    "No se pudo obtener el turno",
+	"Failed to fetch route bins":               "No se pudieron obtener los contenedores de la ruta",
+	"Failed to fetch route tasks":              "No se pudieron obtener las tareas de la ruta",
+	"Failed to fetch bins":                     "No se pudieron obtener los contenedores",
+	"Failed to optimize route":                 "No se pudo optimizar la ruta",
+	"Failed to save optimized route":           "No se pudo guardar la ruta optimizada",
+	"Failed to rotate route":                   "No se pudo reordenar la ruta",
+	"Failed to start shift":                    "No se pudo iniciar el turno",
+	"Failed to pause shift":                    "No se pudo pausar el turno",
+	"No active shift to pause":                 "No hay un turno activo para pausar",
+	"No paused shift to resume":                "No hay un turno pausado para reanudar",
+	"Failed to resume shift":                   "No se pudo reanudar el turno",
+	"No active shift to end":                   "No hay un turno activo para terminar",
+	"Failed to save shift history":             "No se pudo guardar el historial del turno",
+	"Failed to end shift":                      "No se pudo terminar el turno",
+
+	// Shift debrief
+	"Failed to load shift debrief settings":               "No se pudo cargar la configuración del informe de fin de turno",
+	"Complete the shift debrief before ending your shift": "Completa el informe de fin de turno antes de terminar tu turno",
+	"Shift debrief answers are invalid":                   "Las respuestas del informe de fin de turno no son válidas",
+	"A note refers to a bin that doesn't exist":           "Una nota hace referencia a un contenedor que no existe",
+
+	// Earnings
+	"period must be week or month": "period debe ser week o month",
+	"Invalid from timestamp":       "Fecha de inicio no válida",
+	"Invalid to timestamp":         "Fecha de fin no válida",
+	"Failed to fetch earnings":     "No se pudieron obtener las ganancias",
+
+	// Stop completion
+	"At least photo or fill percentage is required":     "Se requiere una foto o el porcentaje de llenado",
+	"Fill percentage must be between 0 and 100":         "El porcentaje de llenado debe estar entre 0 y 100",
+	"incident_type is required when reporting incident": "Indica el tipo de incidente al reportarlo",
+	"Invalid incident_type":                             "Tipo de incidente no válido",
+	"Either incident photo or description is required":  "Se requiere una foto o una descripción del incidente",
+	"No incomplete %s stop found for this bin":          "No hay una parada de %s pendiente para este contenedor",
+	"Bin not found in route or already completed":       "El contenedor no está en la ruta o ya fue completado",
+	"Failed to find task":                               "No se pudo encontrar la tarea",
+	"Failed to complete task":                           "No se pudo completar la tarea",
+	"Failed to update task":                             "No se pudo actualizar la tarea",
+	"Failed to fetch next stop":                         "No se pudo obtener la siguiente parada",
+	"A photo is required for this bin":                  "Este contenedor requiere una foto",
+	"Failed to load check form":                         "No se pudo cargar el formulario de revisión",
+	"Check form answers are invalid":                    "Las respuestas del formulario de revisión no son válidas",
+
+	// Check-in proximity
+	"You're %.0f m from this stop. Get closer to complete it": "Estás a %.0f m de esta parada. Acércate para completarla",
+
+	// Move legs
+	"A photo or signature is required":          "Se requiere una foto o una firma",
+	"Failed to confirm move":                    "No se pudo confirmar el traslado",
+	"Move request not found":                    "Traslado no encontrado",
+	"This move is not on your active shift":     "Este traslado no está en tu turno activo",
+	"Move request is already %s":                "El traslado ya está %s",
+	"Pickup already confirmed":                  "La recogida ya fue confirmada",
+	"This move has no dropoff":                  "Este traslado no tiene entrega",
+	"Confirm the pickup before the dropoff":     "Confirma la recogida antes de la entrega",
+	"No incomplete %s stop found for this move": "No hay una parada de %s pendiente para este traslado",
+	"completed": "completado",
+	"cancelled": "cancelado",
+
+	// Task types (used in stop completion errors)
+	"collection": "recolección",
+	"pickup":     "recogida",
+	"dropoff":    "entrega",
+
+	// Push notifications
+	"New Route Assigned!": "¡Nueva ruta asignada!",
+	"You have %d bins to collect today. Slide to start your shift.": "Tienes %d contenedores por recoger hoy. Desliza para iniciar tu turno.",
+	"Shift Update": "Actualización de turno",
+	"Your shift status has been updated to: %s": "El estado de tu turno cambió a: %s",
+
+	// Move request approval notifications
+	"Move request approved":                      "Solicitud de traslado aprobada",
+	"Move request rejected":                      "Solicitud de traslado rechazada",
+	"Your move request for bin #%d was approved": "Tu solicitud de traslado del contenedor #%d fue aprobada",
+	"Your move request for bin #%d was rejected": "Tu solicitud de traslado del contenedor #%d fue rechazada",
+
+	// Incident notifications (incident subscriptions)
+	"New incident: %s":                             "Nuevo incidente: %s",
+	"Reported at a bin":                            "Reportado en un contenedor",
+	"Reported at bin #%d":                          "Reportado en el contenedor #%d",
+	"Incident summary":                             "Resumen de incidentes",
+	"%d incidents reported since the last summary": "%d incidentes reportados desde el último resumen",
+
+	// Shift pause validation
+	"reason must be one of: %s":                         "reason debe ser uno de: %s",
+	"A note is required when the pause reason is other": "Se requiere una nota cuando el motivo de la pausa es other",
+
+	// Shift status labels (used in notification bodies)
+	"shift_cancelled": "turno cancelado",
+	"active":          "activo",
+	"paused":          "pausado",
+	"ended":           "terminado",
+
+	// WebSocket messages
+	"Your shift has been cancelled by management": "La gerencia canceló tu turno",
+	"Urgent: Bin #%d added as your next stop":     "Urgente: el contenedor #%d se agregó como tu siguiente parada",
+}
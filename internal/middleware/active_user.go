@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"ropacal-backend/pkg/utils"
+)
+
+// DeactivationLookup reports whether a user has been deactivated
+type DeactivationLookup func(userID string) (bool, error)
+
+// userStatusTTL is how long a user's deactivation status is cached between lookups
+const userStatusTTL = time.Minute
+
+type cachedStatus struct {
+	deactivated bool
+	loadedAt    time.Time
+}
+
+var userStatusCache sync.Map // user ID -> cachedStatus
+
+// InvalidateUserStatus drops a cached status after a user is deactivated or reactivated
+func InvalidateUserStatus(userID string) {
+	userStatusCache.Delete(userID)
+}
+
+// RejectDeactivated answers 403 for tokens issued to users who have since been deactivated
+// Register it after Auth so user claims are available
+func RejectDeactivated(lookup DeactivationLookup) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if userClaims, ok := GetUserFromContext(r); ok && IsUserDeactivated(lookup, userClaims.UserID) {
+				log.Printf("⛔ Rejected request from deactivated user %s", userClaims.Email)
+				utils.RespondError(w, http.StatusForbidden, "Account deactivated")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// IsUserDeactivated returns the cached deactivation status, loading it when stale
+// Lookup errors let the request through rather than locking everyone out
+func IsUserDeactivated(lookup DeactivationLookup, userID string) bool {
+	if cached, ok := userStatusCache.Load(userID); ok {
+		entry := cached.(cachedStatus)
+		if time.Since(entry.loadedAt) < userStatusTTL {
+			return entry.deactivated
+		}
+	}
+
+	deactivated, err := lookup(userID)
+	if err != nil {
+		log.Printf("⚠️  Failed to load status for user %s: %v", userID, err)
+		return false
+	}
+	userStatusCache.Store(userID, cachedStatus{deactivated: deactivated, loadedAt: time.Now()})
+	return deactivated
+}
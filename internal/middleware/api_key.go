@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ropacal-backend/internal/models"
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// APIKeyContextKey holds the authenticated *models.APIKey
+const APIKeyContextKey contextKey = "api_key"
+
+// APIKeyLookup returns the unrevoked key with the given SHA-256 hash (nil when there is none)
+type APIKeyLookup func(keyHash string) (*models.APIKey, error)
+
+// HashAPIKey returns the stored form of an API key
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// RequireAPIKey authenticates machine clients by the X-API-Key header (or "Authorization: ApiKey <key>")
+// and requires the key to grant scope
+func RequireAPIKey(lookup APIKeyLookup, scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := strings.TrimSpace(r.Header.Get("X-API-Key"))
+			if key == "" {
+				if rest, ok := strings.CutPrefix(r.Header.Get("Authorization"), "ApiKey "); ok {
+					key = strings.TrimSpace(rest)
+				}
+			}
+			if key == "" {
+				utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+				return
+			}
+
+			apiKey, err := lookup(HashAPIKey(key))
+			if err != nil {
+				log.Printf("❌ Failed to look up API key: %v", err)
+				utils.RespondError(w, http.StatusInternalServerError, "Internal server error")
+				return
+			}
+			if apiKey == nil {
+				log.Printf("❌ Invalid or revoked API key for %s %s", r.Method, r.URL.Path)
+				utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+				return
+			}
+			if !apiKey.HasScope(scope) {
+				log.Printf("❌ API key %s (%s) lacks scope %s", apiKey.KeyPrefix, apiKey.Name, scope)
+				utils.RespondError(w, http.StatusForbidden, "Forbidden")
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), APIKeyContextKey, apiKey)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GetAPIKeyFromContext returns the key authenticated by RequireAPIKey
+func GetAPIKeyFromContext(r *http.Request) (*models.APIKey, bool) {
+	apiKey, ok := r.Context().Value(APIKeyContextKey).(*models.APIKey)
+	return apiKey, ok
+}
+
+// APIKeyMeter records a request by an API key to an endpoint and reports whether it is within the key's
+// rate limit, how many requests remain in the window and the seconds until it resets
+type APIKeyMeter func(apiKey *models.APIKey, endpoint string) (allowed bool, remaining, retryAfter int, err error)
+
+// RateLimitAPIKey meters requests authenticated by RequireAPIKey and refuses them with 429 over the key's limit
+// Requests are metered by route pattern, so usage reports group them by endpoint
+func RateLimitAPIKey(meter APIKeyMeter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			apiKey, ok := GetAPIKeyFromContext(r)
+			if !ok {
+				utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+				return
+			}
+
+			endpoint := r.Method + " " + r.URL.Path
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				endpoint = r.Method + " " + rctx.RoutePattern()
+			}
+
+			allowed, remaining, retryAfter, err := meter(apiKey, endpoint)
+			if err != nil {
+				// Metering must not take the API down; let the request through
+				log.Printf("⚠️  Failed to meter API key %s: %v", apiKey.KeyPrefix, err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(apiKey.RequestsPerMinute()))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+			if !allowed {
+				log.Printf("🚦 API key %s (%s) is over its rate limit on %s", apiKey.KeyPrefix, apiKey.Name, endpoint)
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+				utils.RespondErrorCode(w, http.StatusTooManyRequests, utils.CodeRateLimited,
+					"Rate limit exceeded, retry later", map[string]interface{}{"retry_after_seconds": retryAfter})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"os"
 	"strings"
 
+	"ropacal-backend/pkg/utils"
+
 	"github.com/golang-jwt/jwt/v5"
 )
 
@@ -18,6 +20,39 @@ type UserClaims struct {
 	UserID string `json:"user_id"`
 	Email  string `json:"email"`
 	Role   string `json:"role"`
+
+	// Set on support mode tokens (an admin impersonating the user, see SupportMode)
+	ImpersonationID   string `json:"impersonation_id,omitempty"`
+	ImpersonatorID    string `json:"impersonator_id,omitempty"`
+	ImpersonatorEmail string `json:"impersonator_email,omitempty"`
+
+	// Set while a manager performs a driver action on the driver's behalf (never in tokens, see
+	// handlers.StartShiftOnBehalf)
+	OnBehalfManagerID    string `json:"-"`
+	OnBehalfManagerEmail string `json:"-"`
+}
+
+// IsImpersonated reports whether the token was issued to an admin impersonating the user
+func (c UserClaims) IsImpersonated() bool {
+	return c.ImpersonationID != ""
+}
+
+// IsOnBehalf reports whether a manager is performing the driver's action for them
+func (c UserClaims) IsOnBehalf() bool {
+	return c.OnBehalfManagerID != ""
+}
+
+// UserClaimsFromJWT converts validated token claims
+func UserClaimsFromJWT(claims jwt.MapClaims) UserClaims {
+	userClaims := UserClaims{
+		UserID: claims["user_id"].(string),
+		Email:  claims["email"].(string),
+		Role:   claims["role"].(string),
+	}
+	userClaims.ImpersonationID, _ = claims["impersonation_id"].(string)
+	userClaims.ImpersonatorID, _ = claims["impersonator_id"].(string)
+	userClaims.ImpersonatorEmail, _ = claims["impersonator_email"].(string)
+	return userClaims
 }
 
 // Auth middleware validates JWT token and adds user claims to context
@@ -30,7 +65,7 @@ func Auth(next http.Handler) http.Handler {
 		if authHeader == "" {
 			log.Println("❌ No authorization header")
 			log.Printf("   Request headers: %v", r.Header)
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
 			return
 		}
 
@@ -43,7 +78,7 @@ func Auth(next http.Handler) http.Handler {
 			if len(parts) > 0 {
 				log.Printf("   First part: %s", parts[0])
 			}
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
 			return
 		}
 
@@ -55,7 +90,7 @@ func Auth(next http.Handler) http.Handler {
 		jwtSecret := os.Getenv("APP_JWT_SECRET")
 		if jwtSecret == "" {
 			log.Println("❌ JWT secret not configured")
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			utils.RespondError(w, http.StatusInternalServerError, "Internal server error")
 			return
 		}
 		// log.Printf("   JWT secret configured: YES (length: %d)", len(jwtSecret))
@@ -78,7 +113,7 @@ func Auth(next http.Handler) http.Handler {
 				log.Printf("   Error details: %+v", err)
 			}
 			log.Printf("   Token valid: %v", token.Valid)
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
 			return
 		}
 
@@ -89,18 +124,14 @@ func Auth(next http.Handler) http.Handler {
 		if !ok {
 			log.Println("❌ Failed to parse claims")
 			log.Printf("   Claims type: %T", token.Claims)
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
 			return
 		}
 
 		// log.Printf("   ✓ Claims extracted: %v", claims)
 
 		// Convert to UserClaims struct
-		userClaims := UserClaims{
-			UserID: claims["user_id"].(string),
-			Email:  claims["email"].(string),
-			Role:   claims["role"].(string),
-		}
+		userClaims := UserClaimsFromJWT(claims)
 
 		// log.Printf("✅ Authenticated: %s (%s)", userClaims.Email, userClaims.Role)
 		// log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
@@ -118,13 +149,13 @@ func RequireRole(role string) func(http.Handler) http.Handler {
 			userClaims, ok := r.Context().Value(UserContextKey).(UserClaims)
 			if !ok {
 				log.Println("❌ User claims not found in context")
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
 				return
 			}
 
 			if userClaims.Role != role {
 				log.Printf("❌ Insufficient permissions: required %s, got %s", role, userClaims.Role)
-				http.Error(w, "Forbidden", http.StatusForbidden)
+				utils.RespondError(w, http.StatusForbidden, "Forbidden")
 				return
 			}
 
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"ropacal-backend/internal/i18n"
+)
+
+// LocaleLookup returns a user's stored locale preference (nil if unset)
+type LocaleLookup func(userID string) (*string, error)
+
+// userLocaleTTL is how long a user's stored preference is cached between lookups
+const userLocaleTTL = 5 * time.Minute
+
+type cachedLocale struct {
+	preference *string
+	loadedAt   time.Time
+}
+
+var userLocaleCache sync.Map // user ID -> cachedLocale
+
+// InvalidateUserLocale drops a cached preference after the user changes it
+func InvalidateUserLocale(userID string) {
+	userLocaleCache.Delete(userID)
+}
+
+// Locale resolves the request locale and stores it in the context (read with i18n.FromContext)
+// Authenticated users get their stored preference; otherwise Accept-Language decides, defaulting to English
+// Register it after Auth so user claims are available
+func Locale(lookup LocaleLookup) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var preference *string
+			if userClaims, ok := GetUserFromContext(r); ok && lookup != nil {
+				preference = cachedUserLocale(lookup, userClaims.UserID)
+			}
+
+			locale := i18n.Resolve(preference, r.Header.Get("Accept-Language"))
+			w.Header().Set("Content-Language", locale)
+			next.ServeHTTP(w, r.WithContext(i18n.WithLocale(r.Context(), locale)))
+		})
+	}
+}
+
+func cachedUserLocale(lookup LocaleLookup, userID string) *string {
+	if cached, ok := userLocaleCache.Load(userID); ok {
+		entry := cached.(cachedLocale)
+		if time.Since(entry.loadedAt) < userLocaleTTL {
+			return entry.preference
+		}
+	}
+
+	preference, err := lookup(userID)
+	if err != nil {
+		log.Printf("⚠️  [LOCALE] Failed to load locale for user %s: %v", userID, err)
+		return nil
+	}
+	userLocaleCache.Store(userID, cachedLocale{preference: preference, loadedAt: time.Now()})
+	return preference
+}
@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// clientIPKnownKey marks whether RealIP could tell the client's own address (see ClientIPKnown)
+const clientIPKnownKey contextKey = "client_ip_known"
+
+// TrustedProxiesFromEnv parses TRUSTED_PROXIES: comma-separated IPs or CIDRs of the load balancers and
+// proxies in front of the server (e.g. 10.0.0.0/8). Invalid entries are logged and skipped
+// Unset means no proxy is trusted and forwarded headers are ignored. Catch-all ranges (0.0.0.0/0, ::/0) are
+// refused: trusting every peer would let any client choose its own X-Forwarded-For address
+func TrustedProxiesFromEnv() []*net.IPNet {
+	var proxies []*net.IPNet
+	for _, entry := range envList("TRUSTED_PROXIES") {
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			log.Printf("⚠️  Invalid TRUSTED_PROXIES entry %q, skipping", entry)
+			continue
+		}
+		if ones, _ := network.Mask.Size(); ones == 0 {
+			log.Printf("⚠️  TRUSTED_PROXIES entry %q trusts every address, skipping", entry)
+			continue
+		}
+		proxies = append(proxies, network)
+	}
+	if len(proxies) == 0 {
+		if os.Getenv("APP_ENV") == "production" {
+			log.Println("⚠️⚠️⚠️  TRUSTED_PROXIES is not set in production ⚠️⚠️⚠️")
+			log.Println("⚠️  Behind a load balancer every client arrives from the proxy's address: audit logs record the proxy,")
+			log.Println("⚠️  and per-IP login throttling is skipped for forwarded requests. Set TRUSTED_PROXIES to the proxy's IPs/CIDRs.")
+		} else {
+			log.Println("⚠️  TRUSTED_PROXIES not set: client IPs are taken from the connection, X-Forwarded-For is ignored")
+		}
+	}
+	return proxies
+}
+
+// RealIP rewrites RemoteAddr to the client's IP when the request came through a trusted proxy
+// Unlike chi's RealIP it never believes a header sent by the client itself: X-Forwarded-For is read from the
+// right, skipping trusted proxies, and the first other address is the client; X-Real-IP is only used when
+// the connecting peer is a trusted proxy and there is no X-Forwarded-For
+// Per-IP limits (login throttling) and audit records rely on this, so spoofed headers can't dodge them
+func RealIP(trusted []*net.IPNet) func(http.Handler) http.Handler {
+	isTrusted := func(ip net.IP) bool {
+		for _, network := range trusted {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			known := !hasForwardingHeaders(r)
+			if len(trusted) > 0 {
+				if client := forwardedClientIP(r, isTrusted); client != "" {
+					r.RemoteAddr = client
+					known = true
+				}
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clientIPKnownKey, known)))
+		})
+	}
+}
+
+// forwardedClientIP returns the client IP the trusted proxies forwarded, or "" to keep the peer address
+func forwardedClientIP(r *http.Request, isTrusted func(net.IP) bool) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	peer := net.ParseIP(host)
+	if peer == nil || !isTrusted(peer) {
+		return ""
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(hops[i]))
+		if ip == nil {
+			return "" // Malformed chain: don't guess
+		}
+		if !isTrusted(ip) {
+			return ip.String()
+		}
+	}
+	if len(hops) > 0 {
+		return net.ParseIP(strings.TrimSpace(hops[0])).String() // Every hop is a trusted proxy
+	}
+
+	if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+		return ip.String()
+	}
+	return ""
+}
+
+// ClientIPKnown reports whether RemoteAddr is the client's own address: a direct connection, or one resolved
+// through trusted proxies. It is false for forwarded requests from a peer missing from TRUSTED_PROXIES - that
+// peer is a proxy many clients share, so per-IP limits must not be applied to its address
+func ClientIPKnown(r *http.Request) bool {
+	if known, ok := r.Context().Value(clientIPKnownKey).(bool); ok {
+		return known
+	}
+	return !hasForwardingHeaders(r)
+}
+
+// hasForwardingHeaders reports whether the request says it was forwarded by a proxy
+func hasForwardingHeaders(r *http.Request) bool {
+	return r.Header.Get("X-Forwarded-For") != "" || r.Header.Get("X-Real-IP") != ""
+}
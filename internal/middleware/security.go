@@ -0,0 +1,176 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"ropacal-backend/internal/i18n"
+	"ropacal-backend/pkg/utils"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/cors"
+)
+
+// Defaults used when the CORS_* variables are unset
+var (
+	defaultCORSMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	defaultCORSHeaders = []string{"Content-Type", "Authorization", "Accept-Language", "X-App-Version", "X-API-Key"}
+)
+
+// publicCORSPathPrefix is where the API-key endpoints meant for embedding on other sites live (the bin locator)
+const publicCORSPathPrefix = "/api/public/"
+
+// defaultCORSMaxAge is how long browsers may cache preflight responses (seconds)
+const defaultCORSMaxAge = 300
+
+// CORSOptionsFromEnv builds the CORS policy from the environment:
+//   - CORS_ALLOWED_ORIGINS: comma-separated origins (e.g. https://dashboard.ropacal.com); "*" allows any origin
+//   - CORS_ALLOWED_METHODS, CORS_ALLOWED_HEADERS: comma-separated overrides of the defaults
+//   - CORS_ALLOW_CREDENTIALS=true: allow cookies (ignored with "*")
+//   - CORS_MAX_AGE_SECONDS: preflight cache lifetime
+//   - PUBLIC_CORS_ALLOWED_ORIGINS: comma-separated origins (or "*") also allowed on /api/public/ only, e.g. the
+//     marketing site embedding the bin locator, without opening the rest of the API to them
+//
+// With no origins configured, APP_ENV=production allows no cross-origin requests; other environments allow any origin
+func CORSOptionsFromEnv() cors.Options {
+	options := cors.Options{
+		AllowedOrigins: envList("CORS_ALLOWED_ORIGINS"),
+		AllowedMethods: envList("CORS_ALLOWED_METHODS"),
+		AllowedHeaders: envList("CORS_ALLOWED_HEADERS"),
+		ExposedHeaders: []string{"Link", utils.RequestIDHeader, utils.SupportModeHeader, utils.SupportModeByHeader},
+		MaxAge:         defaultCORSMaxAge,
+	}
+	if len(options.AllowedMethods) == 0 {
+		options.AllowedMethods = defaultCORSMethods
+	}
+	if len(options.AllowedHeaders) == 0 {
+		options.AllowedHeaders = defaultCORSHeaders
+	}
+	if v := os.Getenv("CORS_MAX_AGE_SECONDS"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+			options.MaxAge = seconds
+		} else {
+			log.Printf("⚠️  Invalid CORS_MAX_AGE_SECONDS=%q, using default %d", v, defaultCORSMaxAge)
+		}
+	}
+
+	wildcard := false
+	for _, origin := range options.AllowedOrigins {
+		if origin == "*" {
+			wildcard = true
+		}
+	}
+	options.AllowCredentials = os.Getenv("CORS_ALLOW_CREDENTIALS") == "true" && !wildcard
+
+	switch {
+	case len(options.AllowedOrigins) > 0:
+		log.Printf("🌐 CORS allowed origins: %s", strings.Join(options.AllowedOrigins, ", "))
+	case os.Getenv("APP_ENV") == "production":
+		// An empty origin list means "any" to the cors package, so reject explicitly
+		options.AllowOriginFunc = func(r *http.Request, origin string) bool { return false }
+		log.Println("⚠️  CORS_ALLOWED_ORIGINS not set: cross-origin requests are blocked")
+	default:
+		options.AllowedOrigins = []string{"*"}
+		log.Println("⚠️  CORS_ALLOWED_ORIGINS not set: allowing any origin (set it before deploying)")
+	}
+
+	publicOrigins := envList("PUBLIC_CORS_ALLOWED_ORIGINS")
+	if len(publicOrigins) == 0 {
+		if os.Getenv("APP_ENV") == "production" && !originAllowed(options.AllowedOrigins, "*") {
+			log.Println("⚠️  PUBLIC_CORS_ALLOWED_ORIGINS not set: the public bin locator can only be called from CORS_ALLOWED_ORIGINS")
+		}
+		return options
+	}
+
+	// The cors package ignores AllowedOrigins once AllowOriginFunc is set, so the API-wide policy is applied here too
+	apiOrigins, blockAPI := options.AllowedOrigins, options.AllowOriginFunc != nil
+	options.AllowOriginFunc = func(r *http.Request, origin string) bool {
+		if strings.HasPrefix(r.URL.Path, publicCORSPathPrefix) && originAllowed(publicOrigins, origin) {
+			return true
+		}
+		return !blockAPI && originAllowed(apiOrigins, origin)
+	}
+	log.Printf("🌐 CORS allowed origins on %s: %s", publicCORSPathPrefix, strings.Join(publicOrigins, ", "))
+	return options
+}
+
+// originAllowed matches an origin against CORS origin patterns: "*", an exact origin, or one with a single
+// wildcard such as https://*.example.com (the same patterns the cors package accepts)
+func originAllowed(patterns []string, origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if pattern == "*" || pattern == origin {
+			return true
+		}
+		if i := strings.IndexByte(pattern, '*'); i >= 0 && len(origin) >= len(pattern)-1 &&
+			strings.HasPrefix(origin, pattern[:i]) && strings.HasSuffix(origin, pattern[i+1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// envList splits a comma-separated environment variable, dropping empty entries
+func envList(name string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(name), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// SecurityHeaders sets hardening headers on every response
+// HSTS is sent when hstsMaxAge > 0 (browsers only honor it over HTTPS)
+func SecurityHeaders(hstsMaxAge int) func(http.Handler) http.Handler {
+	hsts := ""
+	if hstsMaxAge > 0 {
+		hsts = "max-age=" + strconv.Itoa(hstsMaxAge) + "; includeSubDomains"
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := w.Header()
+			if hsts != "" {
+				header.Set("Strict-Transport-Security", hsts)
+			}
+			header.Set("X-Content-Type-Options", "nosniff")
+			header.Set("X-Frame-Options", "DENY")
+			header.Set("Referrer-Policy", "no-referrer")
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// LimitRequestBody rejects bodies larger than maxBytes with 413
+// Declared lengths are checked up front; chunked bodies fail when the handler reads past the limit
+func LimitRequestBody(maxBytes int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > maxBytes {
+				log.Printf("⚠️  %s %s rejected: body of %d bytes exceeds %d", r.Method, r.URL.Path, r.ContentLength, maxBytes)
+				utils.RespondError(w, http.StatusRequestEntityTooLarge, i18n.Tr(r, "Request body too large"))
+				return
+			}
+			if r.Body != nil {
+				r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequestIDHeader echoes the request ID (from chi's RequestID middleware) as the X-Request-Id response header
+// Error responses repeat it in their body so client reports can be matched to server logs
+func RequestIDHeader(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requestID := chimiddleware.GetReqID(r.Context()); requestID != "" {
+			w.Header().Set(utils.RequestIDHeader, requestID)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
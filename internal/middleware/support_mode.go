@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"ropacal-backend/pkg/utils"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+// ImpersonationLookup reports whether a support mode session is still active (not expired or ended)
+type ImpersonationLookup func(sessionID string) (bool, error)
+
+// ImpersonationRecorder adds a request made in support mode to the session's audit trail
+// blocked is true when the request was refused for trying to change something
+type ImpersonationRecorder func(sessionID, method, path string, status int, blocked bool) error
+
+// SupportMode handles requests made with impersonation tokens (register it after Auth):
+// ended or expired sessions get 401, anything but GET/HEAD/OPTIONS is refused with 403 so support staff
+// can't act as the driver, every request is recorded, and responses carry the X-Support-Mode headers
+// Requests with regular tokens pass straight through
+func SupportMode(lookup ImpersonationLookup, record ImpersonationRecorder) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userClaims, ok := GetUserFromContext(r)
+			if !ok || !userClaims.IsImpersonated() {
+				next.ServeHTTP(w, r)
+				return
+			}
+			sessionID := userClaims.ImpersonationID
+
+			recordRequest := func(status int, blocked bool) {
+				if err := record(sessionID, r.Method, r.URL.Path, status, blocked); err != nil {
+					log.Printf("⚠️  [SUPPORT-MODE] %v", err)
+				}
+			}
+
+			active, err := lookup(sessionID)
+			if err != nil {
+				log.Printf("❌ [SUPPORT-MODE] %v", err)
+				utils.RespondError(w, http.StatusInternalServerError, "Internal server error")
+				return
+			}
+			if !active {
+				recordRequest(http.StatusUnauthorized, false)
+				utils.RespondErrorCode(w, http.StatusUnauthorized, utils.CodeSupportModeEnded, "Support mode session has ended", nil)
+				return
+			}
+
+			w.Header().Set(utils.SupportModeHeader, "true")
+			w.Header().Set(utils.SupportModeByHeader, userClaims.ImpersonatorEmail)
+
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+			default:
+				log.Printf("🛑 [SUPPORT-MODE] Blocked %s %s by %s as %s", r.Method, r.URL.Path, userClaims.ImpersonatorEmail, userClaims.Email)
+				recordRequest(http.StatusForbidden, true)
+				utils.RespondErrorCode(w, http.StatusForbidden, utils.CodeSupportModeReadOnly,
+					"Support mode is read-only", map[string]interface{}{"method": r.Method, "path": r.URL.Path})
+				return
+			}
+
+			ww := chimiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			recordRequest(status, false)
+		})
+	}
+}
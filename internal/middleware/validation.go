@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+
+	"ropacal-backend/internal/i18n"
+	"ropacal-backend/internal/openapi"
+	"ropacal-backend/pkg/utils"
+)
+
+// maxValidatedBodyBytes caps the request bodies read for validation
+const maxValidatedBodyBytes = 10 << 20
+
+// ValidateRequestBody rejects POST/PUT/PATCH requests whose JSON body doesn't match the documented
+// request type of the route, responding 400 with field-level errors (openapi.ValidationErrorResponse)
+// Routes without a documented request type pass through untouched
+func ValidateRequestBody(spec *openapi.Spec) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost && r.Method != http.MethodPut && r.Method != http.MethodPatch {
+				next.ServeHTTP(w, r)
+				return
+			}
+			requestType := spec.RequestType(r.Method, r.URL.Path)
+			if requestType == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxValidatedBodyBytes+1))
+			r.Body.Close()
+			if err != nil {
+				utils.RespondError(w, http.StatusBadRequest, i18n.Tr(r, "Invalid request body"))
+				return
+			}
+			if len(body) > maxValidatedBodyBytes {
+				utils.RespondError(w, http.StatusRequestEntityTooLarge, i18n.Tr(r, "Request body too large"))
+				return
+			}
+
+			if fieldErrors := openapi.ValidateJSON(body, requestType); len(fieldErrors) > 0 {
+				log.Printf("⚠️  [VALIDATION] %s %s rejected: %+v", r.Method, r.URL.Path, fieldErrors)
+				utils.RespondErrorCode(w, http.StatusBadRequest, utils.CodeValidationFailed, i18n.Tr(r, "Validation failed"), fieldErrors)
+				return
+			}
+
+			// Handlers decode the body themselves
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
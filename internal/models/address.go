@@ -0,0 +1,135 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Address is a US street address split into its parts
+// One-line addresses ("123 Main St, San Jose, CA 95113") are parsed with ParseAddress and built with String
+type Address struct {
+	Street    string   `json:"street"` // May contain commas (e.g. "Bldg 4, 100 Main St")
+	City      string   `json:"city"`
+	State     string   `json:"state,omitempty"` // Two-letter USPS code
+	Zip       string   `json:"zip"`             // 12345 or 12345-6789
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
+}
+
+var zipPattern = regexp.MustCompile(`^(\d{5})(?:[- ]?(\d{4}))?$`)
+
+// usStates are the USPS state, district and territory codes
+var usStates = map[string]bool{
+	"AL": true, "AK": true, "AZ": true, "AR": true, "CA": true, "CO": true, "CT": true, "DE": true, "DC": true,
+	"FL": true, "GA": true, "HI": true, "ID": true, "IL": true, "IN": true, "IA": true, "KS": true, "KY": true,
+	"LA": true, "ME": true, "MD": true, "MA": true, "MI": true, "MN": true, "MS": true, "MO": true, "MT": true,
+	"NE": true, "NV": true, "NH": true, "NJ": true, "NM": true, "NY": true, "NC": true, "ND": true, "OH": true,
+	"OK": true, "OR": true, "PA": true, "RI": true, "SC": true, "SD": true, "TN": true, "TX": true, "UT": true,
+	"VT": true, "VA": true, "WA": true, "WV": true, "WI": true, "WY": true,
+	"AS": true, "GU": true, "MP": true, "PR": true, "VI": true,
+}
+
+// addressCountries are trailing country parts ParseAddress drops (geocoders append them)
+var addressCountries = map[string]bool{"us": true, "usa": true, "united states": true, "united states of america": true}
+
+// NormalizeZip checks a USPS ZIP code and returns it as 12345 or 12345-6789 (accepting 123456789 and "12345 6789")
+func NormalizeZip(zip string) (string, error) {
+	match := zipPattern.FindStringSubmatch(strings.TrimSpace(zip))
+	if match == nil || match[1] == "00000" {
+		return "", fmt.Errorf("invalid ZIP code %q: expected 12345 or 12345-6789", zip)
+	}
+	if match[2] != "" {
+		return match[1] + "-" + match[2], nil
+	}
+	return match[1], nil
+}
+
+// ParseAddress splits a one-line address into street, city, state and ZIP
+// Parts are comma-separated; the ZIP and state are read from the end of the last part, the city is the last part
+// left and the street is everything before it, commas included. A trailing country is dropped, and an address
+// without commas is all street. Invalid ZIPs are left in the city, so call Normalize to check the result
+func ParseAddress(line string) Address {
+	var parts []string
+	for _, part := range strings.Split(line, ",") {
+		if part = strings.Join(strings.Fields(part), " "); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) > 1 && addressCountries[strings.ToLower(parts[len(parts)-1])] {
+		parts = parts[:len(parts)-1]
+	}
+	if len(parts) == 0 {
+		return Address{}
+	}
+
+	var address Address
+	if len(parts) > 1 {
+		tokens := strings.Fields(parts[len(parts)-1])
+		if zip, err := NormalizeZip(tokens[len(tokens)-1]); err == nil {
+			address.Zip = zip
+			tokens = tokens[:len(tokens)-1]
+		}
+		if n := len(tokens); n > 0 && usStates[tokens[n-1]] {
+			address.State = tokens[n-1]
+			tokens = tokens[:n-1]
+		}
+		if len(tokens) == 0 {
+			parts = parts[:len(parts)-1]
+		} else {
+			parts[len(parts)-1] = strings.Join(tokens, " ")
+		}
+	}
+	if len(parts) > 1 {
+		address.City = parts[len(parts)-1]
+		parts = parts[:len(parts)-1]
+	}
+	address.Street = strings.Join(parts, ", ")
+	return address
+}
+
+// Normalize collapses whitespace in the parts, uppercases the state and checks the state and ZIP (when set)
+func (a *Address) Normalize() error {
+	a.Street = strings.Join(strings.Fields(a.Street), " ")
+	a.City = strings.Join(strings.Fields(a.City), " ")
+	a.State = strings.ToUpper(strings.TrimSpace(a.State))
+	if a.State != "" && !usStates[a.State] {
+		return fmt.Errorf("invalid state %q: expected a two-letter USPS code", a.State)
+	}
+	if strings.TrimSpace(a.Zip) != "" {
+		zip, err := NormalizeZip(a.Zip)
+		if err != nil {
+			return err
+		}
+		a.Zip = zip
+	} else {
+		a.Zip = ""
+	}
+	return nil
+}
+
+// String formats the address on one line: "street, city zip" or "street, city, ST zip" (ParseAddress reads it back)
+func (a Address) String() string {
+	locality := a.City
+	if a.State != "" {
+		if locality != "" {
+			locality += ", "
+		}
+		locality += a.State
+	}
+	if a.Zip != "" {
+		locality = strings.TrimSpace(locality + " " + a.Zip)
+	}
+	if a.Street == "" {
+		return locality
+	}
+	if locality == "" {
+		return a.Street
+	}
+	return a.Street + ", " + locality
+}
+
+// IsZero reports whether the address has no street, city, state or ZIP
+func (a Address) IsZero() bool {
+	return a.Street == "" && a.City == "" && a.State == "" && a.Zip == ""
+}
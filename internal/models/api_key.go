@@ -0,0 +1,119 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// API key scopes
+const (
+	APIKeyScopeSensorIngest = "sensor_ingest" // POST /api/ingest/sensor-readings
+	APIKeyScopeBinLocator   = "bin_locator"   // GET /api/public/bins (read-only, for embedding on public sites)
+)
+
+// API key rate limits (requests per minute, across the key's metered endpoints)
+const (
+	DefaultAPIKeyRateLimit = 60
+	MaxAPIKeyRateLimit     = 10000
+)
+
+// APIKey authenticates a machine client (sensor gateway, partner integration) via the X-API-Key header
+type APIKey struct {
+	ID              string         `json:"id" db:"id"`
+	Name            string         `json:"name" db:"name"`
+	KeyPrefix       string         `json:"key_prefix" db:"key_prefix"` // First characters of the key, to tell keys apart
+	KeyHash         string         `json:"-" db:"key_hash"`            // SHA-256 of the key; the key itself is only returned on create
+	Scopes          pq.StringArray `json:"scopes" db:"scopes"`
+	RateLimit       *int           `json:"rate_limit_per_minute,omitempty" db:"rate_limit_per_minute"` // nil uses DefaultAPIKeyRateLimit
+	LastUsedAt      *int64         `json:"last_used_at,omitempty" db:"last_used_at"`
+	CreatedByUserID *string        `json:"created_by_user_id,omitempty" db:"created_by_user_id"`
+	CreatedAt       int64          `json:"created_at" db:"created_at"`
+	RevokedAt       *int64         `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+// HasScope reports whether the key grants scope
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// RequestsPerMinute returns the key's rate limit
+func (k *APIKey) RequestsPerMinute() int {
+	if k.RateLimit != nil {
+		return *k.RateLimit
+	}
+	return DefaultAPIKeyRateLimit
+}
+
+// IsValidAPIKeyScope reports whether scope can be granted to a key
+func IsValidAPIKeyScope(scope string) bool {
+	switch scope {
+	case APIKeyScopeSensorIngest, APIKeyScopeBinLocator:
+		return true
+	}
+	return false
+}
+
+// CreateAPIKeyRequest is the body for POST /api/manager/api-keys
+type CreateAPIKeyRequest struct {
+	Name      string   `json:"name" validate:"required"`
+	Scopes    []string `json:"scopes" validate:"required"`
+	RateLimit *int     `json:"rate_limit_per_minute,omitempty"` // Omit for DefaultAPIKeyRateLimit
+}
+
+// UpdateAPIKeyRequest is the body for PATCH /api/manager/api-keys/{id}
+type UpdateAPIKeyRequest struct {
+	Name      *string `json:"name,omitempty"`
+	RateLimit *int    `json:"rate_limit_per_minute,omitempty"`
+}
+
+// ValidateAPIKeyRateLimit checks a requested rate limit (nil keeps the default)
+func ValidateAPIKeyRateLimit(limit *int) error {
+	if limit != nil && (*limit < 1 || *limit > MaxAPIKeyRateLimit) {
+		return fmt.Errorf("rate_limit_per_minute must be between 1 and %d", MaxAPIKeyRateLimit)
+	}
+	return nil
+}
+
+// APIKeyUsagePoint is one key's traffic in one hour or day of the usage report
+type APIKeyUsagePoint struct {
+	Start    int64 `json:"start" db:"start"` // Unix start of the bucket
+	Requests int   `json:"requests" db:"requests"`
+	Rejected int   `json:"rejected" db:"rejected"` // Refused by the rate limit
+}
+
+// APIKeyEndpointUsage is one key's traffic on one endpoint over the report period
+type APIKeyEndpointUsage struct {
+	Endpoint string `json:"endpoint" db:"endpoint"`
+	Requests int    `json:"requests" db:"requests"`
+	Rejected int    `json:"rejected" db:"rejected"`
+}
+
+// APIKeyUsage is one key's metered traffic over the report period
+type APIKeyUsage struct {
+	APIKeyID   string                `json:"api_key_id"`
+	Name       string                `json:"name"`
+	KeyPrefix  string                `json:"key_prefix"`
+	Scopes     []string              `json:"scopes"`
+	RateLimit  int                   `json:"rate_limit_per_minute"`
+	Revoked    bool                  `json:"revoked"`
+	LastUsedAt *int64                `json:"last_used_at,omitempty"`
+	Requests   int                   `json:"requests"`
+	Rejected   int                   `json:"rejected"`
+	PeakMinute int                   `json:"peak_minute_requests"` // Busiest minute, to compare with the limit
+	Endpoints  []APIKeyEndpointUsage `json:"endpoints"`
+	Series     []APIKeyUsagePoint    `json:"series"` // Buckets with traffic, oldest first
+}
+
+// APIKeyUsageReport is the response of GET /api/manager/api-keys/usage
+type APIKeyUsageReport struct {
+	From     int64         `json:"from"`
+	To       int64         `json:"to"`
+	Interval string        `json:"interval"` // "hour" or "day"
+	Keys     []APIKeyUsage `json:"keys"`     // Busiest first; keys without traffic are included
+}
@@ -0,0 +1,41 @@
+package models
+
+import "encoding/json"
+
+// Area is a named city/region with a polygon boundary
+// Bins and no-go zones are assigned to areas by point-in-polygon (see services.AreaAssigner)
+type Area struct {
+	ID              string          `json:"id" db:"id"`
+	Name            string          `json:"name" db:"name"`
+	Boundary        json.RawMessage `json:"boundary" db:"boundary"` // GeoJSON Polygon or MultiPolygon ([lng, lat] positions)
+	MinLatitude     float64         `json:"min_latitude" db:"min_latitude"`
+	MaxLatitude     float64         `json:"max_latitude" db:"max_latitude"`
+	MinLongitude    float64         `json:"min_longitude" db:"min_longitude"`
+	MaxLongitude    float64         `json:"max_longitude" db:"max_longitude"`
+	PhotoRequired   bool            `json:"photo_required" db:"photo_required"` // Checks of every bin in the area must include a photo
+	CreatedByUserID *string         `json:"created_by_user_id,omitempty" db:"created_by_user_id"`
+	CreatedAt       int64           `json:"created_at" db:"created_at"`
+	UpdatedAt       int64           `json:"updated_at" db:"updated_at"`
+}
+
+// AreaWithCounts is an area with the number of bins and zones currently assigned to it
+type AreaWithCounts struct {
+	Area
+	BinCount  int `json:"bin_count" db:"bin_count"`
+	ZoneCount int `json:"zone_count" db:"zone_count"`
+}
+
+// ManagerAreas is a manager (admin) with the areas they're assigned to
+// Their driver, bin, move request and incident lists and live updates are limited to these areas unless they
+// pass all_areas=true; a manager without areas sees every area
+type ManagerAreas struct {
+	UserID  string   `json:"user_id" db:"user_id"`
+	Email   string   `json:"email" db:"email"`
+	Name    string   `json:"name" db:"name"`
+	AreaIDs []string `json:"area_ids" db:"-"`
+}
+
+// ManagerAreasRequest replaces a manager's area assignments (empty = every area)
+type ManagerAreasRequest struct {
+	AreaIDs []string `json:"area_ids"`
+}
@@ -11,17 +11,24 @@ type Bin struct {
 	LastMoved       *int64   `json:"last_moved,omitempty" db:"last_moved"`           // Unix timestamp
 	LastChecked     *int64   `json:"last_checked,omitempty" db:"last_checked"`       // Unix timestamp
 	LastCheckedAt   *int64   `json:"last_checked_at,omitempty" db:"last_checked_at"` // Unix timestamp (for priority calc)
-	Status          string   `json:"status" db:"status"`                             // 'active', 'retired', 'in_storage', 'pending_move', 'needs_check'
+	Status          string   `json:"status" db:"status"`                             // One of BinStatuses; manual changes follow ValidateBinStatusTransition
 	FillPercentage  *int     `json:"fill_percentage,omitempty" db:"fill_percentage"`
 	Checked         bool     `json:"checked" db:"checked"`
 	MoveRequested   bool     `json:"move_requested" db:"move_requested"`
 	Latitude        *float64 `json:"latitude,omitempty" db:"latitude"`
 	Longitude       *float64 `json:"longitude,omitempty" db:"longitude"`
+	AreaID          *string  `json:"area_id,omitempty" db:"area_id"`                       // Area containing the bin (assigned by point-in-polygon)
 	CreatedByUserID *string  `json:"created_by_user_id,omitempty" db:"created_by_user_id"` // User who created the bin
 	RetiredAt       *int64   `json:"retired_at,omitempty" db:"retired_at"`                 // Unix timestamp when retired
 	RetiredByUserID *string  `json:"retired_by_user_id,omitempty" db:"retired_by_user_id"` // User who retired the bin
+	TimeWindowStart *string  `json:"time_window_start,omitempty" db:"time_window_start"`   // Local "HH:MM" the host allows collection from
+	TimeWindowEnd   *string  `json:"time_window_end,omitempty" db:"time_window_end"`       // Local "HH:MM" the host allows collection until
+	PhotoRequired   bool     `json:"photo_required" db:"photo_required"`                   // Checks must include a photo (also set per area)
 	CreatedAt       int64    `json:"created_at" db:"created_at"`                           // Unix timestamp
 	UpdatedAt       int64    `json:"updated_at" db:"updated_at"`                           // Unix timestamp
+	MaintenanceDue  *bool    `json:"maintenance_due,omitempty" db:"maintenance_due"`       // Computed (not a column): scheduled maintenance is due
+	LatestPhotoURL  *string  `json:"latest_photo_url,omitempty" db:"latest_photo_url"`     // Computed (not a column): newest photo from bin_photos
+	Geog            *string  `json:"-" db:"geog"`                                          // PostGIS geography (only when enabled), for SELECT *
 }
 
 // BinResponse is what we send to the client with ISO timestamps
@@ -40,10 +47,19 @@ type BinResponse struct {
 	MoveRequested    bool     `json:"move_requested"`
 	Latitude         *float64 `json:"latitude,omitempty"`
 	Longitude        *float64 `json:"longitude,omitempty"`
+	AreaID           *string  `json:"area_id,omitempty"`
+	MaintenanceDue   *bool    `json:"maintenance_due,omitempty"`
 	CreatedByUserID  *string  `json:"created_by_user_id,omitempty"`
 	RetiredAtIso     *string  `json:"retiredAtIso,omitempty"`
 	RetiredByUserID  *string  `json:"retired_by_user_id,omitempty"`
 	PriorityScore    *float64 `json:"priority_score,omitempty"` // Calculated priority (used for sorting)
+	LatestPhotoURL   *string  `json:"latest_photo_url,omitempty"`
+	TimeWindowStart  *string  `json:"time_window_start,omitempty"`
+	TimeWindowEnd    *string  `json:"time_window_end,omitempty"`
+	PhotoRequired    bool     `json:"photo_required"`
+
+	Tags      []TagLabel `json:"tags,omitempty"`       // Set by GET /api/bins
+	FillLevel *string    `json:"fill_level,omitempty"` // Set by GET /api/bins: normal, warning or critical (see FillThresholds)
 }
 
 // UpdateBinRequest is the request body for PATCH /api/bins/:id
@@ -53,7 +69,7 @@ type UpdateBinRequest struct {
 	Zip            string  `json:"zip"`
 	Status         string  `json:"status"`
 	Checked        bool    `json:"checked"`
-	FillPercentage *int    `json:"fill_percentage,omitempty"`
+	FillPercentage *int    `json:"fill_percentage,omitempty" validate:"min=0,max=100"`
 	MoveRequested  bool    `json:"move_requested"`
 	CheckedFrom    *string `json:"checkedFrom,omitempty"`
 	CheckedOnIso   *string `json:"checkedOnIso,omitempty"`
@@ -63,13 +79,13 @@ type UpdateBinRequest struct {
 // CreateBinRequest is the request body for POST /api/bins
 type CreateBinRequest struct {
 	BinNumber      *int     `json:"bin_number,omitempty"` // Optional - auto-assigned if not provided
-	CurrentStreet  string   `json:"current_street"`
-	City           string   `json:"city"`
-	Zip            string   `json:"zip"`
-	Status         string   `json:"status"`
-	FillPercentage *int     `json:"fill_percentage,omitempty"`
-	Latitude       *float64 `json:"latitude,omitempty"`
-	Longitude      *float64 `json:"longitude,omitempty"`
+	CurrentStreet  string   `json:"current_street" validate:"required"`
+	City           string   `json:"city" validate:"required"`
+	Zip            string   `json:"zip" validate:"required"`
+	Status         string   `json:"status" validate:"required"`
+	FillPercentage *int     `json:"fill_percentage,omitempty" validate:"min=0,max=100"`
+	Latitude       *float64 `json:"latitude,omitempty" validate:"min=-90,max=90"`
+	Longitude      *float64 `json:"longitude,omitempty" validate:"min=-180,max=180"`
 }
 
 // ToBinResponse converts a Bin to BinResponse
@@ -86,7 +102,13 @@ func (b *Bin) ToBinResponse() BinResponse {
 		MoveRequested:   b.MoveRequested,
 		Latitude:        b.Latitude,
 		Longitude:       b.Longitude,
+		AreaID:          b.AreaID,
+		MaintenanceDue:  b.MaintenanceDue,
 		CreatedByUserID: b.CreatedByUserID,
+		LatestPhotoURL:  b.LatestPhotoURL,
+		TimeWindowStart: b.TimeWindowStart,
+		TimeWindowEnd:   b.TimeWindowEnd,
+		PhotoRequired:   b.PhotoRequired,
 	}
 
 	if b.LastMoved != nil {
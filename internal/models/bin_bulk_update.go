@@ -0,0 +1,52 @@
+package models
+
+// MaxBulkBinUpdate caps the bins in one bulk update
+const MaxBulkBinUpdate = 500
+
+// Outcomes of one bin in a bulk update
+const (
+	BulkBinUpdated   = "updated"
+	BulkBinUnchanged = "unchanged" // Already had every requested value
+	BulkBinFailed    = "failed"
+)
+
+// BulkBinFields are the fields a bulk update can set; omitted fields are left alone
+// Changing the city clears the coordinates, like editing the address of a single bin
+type BulkBinFields struct {
+	Status        *string `json:"status,omitempty"` // Follows the bin status lifecycle; retire bins one at a time
+	City          *string `json:"city,omitempty"`
+	MoveRequested *bool   `json:"move_requested,omitempty"`
+	PhotoRequired *bool   `json:"photo_required,omitempty"`
+}
+
+// IsEmpty reports whether no field is set
+func (f BulkBinFields) IsEmpty() bool {
+	return f.Status == nil && f.City == nil && f.MoveRequested == nil && f.PhotoRequired == nil
+}
+
+// BulkBinUpdateRequest is the body of PATCH /api/manager/bins/bulk
+type BulkBinUpdateRequest struct {
+	BinIDs       []string      `json:"bin_ids"`
+	Updates      BulkBinFields `json:"updates"`
+	AllOrNothing bool          `json:"all_or_nothing"` // Apply nothing if any bin fails validation
+}
+
+// BulkBinUpdateItem is the outcome for one bin of a bulk update
+type BulkBinUpdateItem struct {
+	BinID     string       `json:"bin_id"`
+	BinNumber *int         `json:"bin_number,omitempty"`
+	Result    string       `json:"result"` // BulkBinUpdated, BulkBinUnchanged or BulkBinFailed
+	Code      string       `json:"code,omitempty"`
+	Error     string       `json:"error,omitempty"`
+	Bin       *BinResponse `json:"bin,omitempty"` // The bin after the update
+}
+
+// BulkBinUpdateResult is the response of PATCH /api/manager/bins/bulk
+type BulkBinUpdateResult struct {
+	Applied   bool                `json:"applied"` // False when all_or_nothing stopped the update
+	Requested int                 `json:"requested"`
+	Updated   int                 `json:"updated"`
+	Unchanged int                 `json:"unchanged"`
+	Failed    int                 `json:"failed"`
+	Results   []BulkBinUpdateItem `json:"results"` // In request order
+}
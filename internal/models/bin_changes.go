@@ -0,0 +1,17 @@
+package models
+
+// BinDeletion is a deleted bin (deleted outright, merged into another or cleaned up)
+type BinDeletion struct {
+	BinID     string `json:"bin_id" db:"bin_id"`
+	BinNumber *int   `json:"bin_number,omitempty" db:"bin_number"`
+	DeletedAt int64  `json:"deleted_at" db:"deleted_at"`
+}
+
+// BinChanges is the response of GET /api/bins/changes: what changed in the bin list since the client's copy
+// Pass next_since as since on the next call; a bin can show up in two consecutive responses
+type BinChanges struct {
+	Bins      interface{}   `json:"bins"` // []BinResponse changed or added, projected by ?fields=
+	Deleted   []BinDeletion `json:"deleted"`
+	Since     int64         `json:"since"`
+	NextSince int64         `json:"next_since"`
+}
@@ -1,17 +1,30 @@
 package models
 
+// Recommendation reasons
+const (
+	CheckReasonTimeBased       = "time_based"       // Not checked in 7+ days
+	CheckReasonManualFlag      = "manual_flag"      // Flagged by a manager
+	CheckReasonCadence         = "cadence"          // Overdue relative to the bin's usual check interval
+	CheckReasonFillTrend       = "fill_trend"       // Fill rate projects the bin is nearly full
+	CheckReasonIncidentHistory = "incident_history" // Recent incidents reported at this bin
+)
+
 // BinCheckRecommendation represents a recommendation for a bin that needs checking
-// Simple time-based system: bins not checked in 7+ days are flagged (no priority levels)
+// Produced by FlagStaleBins (time-based) and the check recommendation engine (cadence, fill trend, incidents)
 type BinCheckRecommendation struct {
 	ID               string  `json:"id" db:"id"`
 	BinID            string  `json:"bin_id" db:"bin_id"`
-	Reason           string  `json:"reason" db:"reason"` // 'time_based' or 'manual_flag'
+	Reason           string  `json:"reason" db:"reason"` // See CheckReason* constants
 	FlaggedAt        int64   `json:"flagged_at" db:"flagged_at"`
 	DaysSinceCheck   int     `json:"days_since_check" db:"days_since_check"`
-	Status           string  `json:"status" db:"status"` // 'pending', 'resolved', 'dismissed'
+	Status           string  `json:"status" db:"status"` // 'pending', 'resolved', 'dismissed', 'expired', 'converted'
 	ResolvedAt       *int64  `json:"resolved_at,omitempty" db:"resolved_at"`
 	ResolvedByUserID *string `json:"resolved_by_user_id,omitempty" db:"resolved_by_user_id"`
 	Notes            *string `json:"notes,omitempty" db:"notes"`
+	Score            int     `json:"score" db:"score"`                                     // Engine confidence, higher = check sooner
+	Details          *string `json:"details,omitempty" db:"details"`                       // Human-readable explanation
+	ExpiresAt        *int64  `json:"expires_at,omitempty" db:"expires_at"`                 // Pending recommendations expire after this time
+	ConvertedShiftID *string `json:"converted_shift_id,omitempty" db:"converted_shift_id"` // Shift the bin was added to
 	CreatedAt        int64   `json:"created_at" db:"created_at"`
 	UpdatedAt        int64   `json:"updated_at" db:"updated_at"`
 }
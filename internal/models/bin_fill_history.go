@@ -0,0 +1,61 @@
+package models
+
+import "fmt"
+
+// Fill history resolutions (bin_fill_history.resolution)
+// Every check and sensor reading is stored raw; the retention purger rolls raw points older than
+// RetentionSettings.FillHistoryRawDays into hourly averages and those older than FillHistoryHourlyDays into daily ones
+const (
+	FillResolutionRaw    = "raw"
+	FillResolutionHourly = "hourly"
+	FillResolutionDaily  = "daily"
+)
+
+// FillResolutionSeconds is the bucket size of each resolution (raw points keep their own timestamp)
+var FillResolutionSeconds = map[string]int64{
+	FillResolutionRaw:    1,
+	FillResolutionHourly: 3600,
+	FillResolutionDaily:  86400,
+}
+
+// AutoFillResolution picks the resolution for a chart of the range: raw up to 2 days, hourly up to 14, daily beyond
+func AutoFillResolution(since, until int64) string {
+	switch span := until - since; {
+	case span <= 2*86400:
+		return FillResolutionRaw
+	case span <= 14*86400:
+		return FillResolutionHourly
+	default:
+		return FillResolutionDaily
+	}
+}
+
+// ParseFillResolution reads the resolution query parameter ("" or "auto" picks one for the range)
+func ParseFillResolution(value string, since, until int64) (string, error) {
+	if value == "" || value == "auto" {
+		return AutoFillResolution(since, until), nil
+	}
+	if _, ok := FillResolutionSeconds[value]; !ok {
+		return "", fmt.Errorf("resolution must be auto, raw, hourly or daily")
+	}
+	return value, nil
+}
+
+// FillHistoryPoint is a bin's fill level at a time, or its average over an hour or day (UTC)
+type FillHistoryPoint struct {
+	RecordedAt     int64   `json:"recorded_at" db:"recorded_at"`         // Reading time, or the start of the bucket
+	FillPercentage float64 `json:"fill_percentage" db:"fill_percentage"` // Average of the readings in the bucket
+	MinFill        int     `json:"min_fill" db:"min_fill"`
+	MaxFill        int     `json:"max_fill" db:"max_fill"`
+	SampleCount    int     `json:"sample_count" db:"sample_count"` // Checks and sensor readings behind the point
+}
+
+// BinFillHistory is GET /api/bins/{id}/fill-history: a bin's fill level over time, oldest first
+// Points come from raw readings where they are still kept and from hourly/daily rollups before that
+type BinFillHistory struct {
+	BinID      string             `json:"bin_id"`
+	Resolution string             `json:"resolution"`
+	Since      int64              `json:"since"`
+	Until      int64              `json:"until"`
+	Points     []FillHistoryPoint `json:"points"`
+}
@@ -0,0 +1,15 @@
+package models
+
+// BinMissingCoordinates is a bin without a latitude/longitude: it can't be put on a route or optimized until
+// it is geocoded (PATCH /api/bins/{id} with latitude and longitude)
+type BinMissingCoordinates struct {
+	ID               string `json:"id" db:"id"`
+	BinNumber        int    `json:"bin_number" db:"bin_number"`
+	CurrentStreet    string `json:"current_street" db:"current_street"`
+	City             string `json:"city" db:"city"`
+	Zip              string `json:"zip" db:"zip"`
+	Status           string `json:"status" db:"status"`
+	RouteCount       int    `json:"route_count" db:"route_count"`               // Route blueprints that list the bin
+	OpenMoveRequests int    `json:"open_move_requests" db:"open_move_requests"` // Pending, assigned or in progress
+	CreatedAt        int64  `json:"created_at" db:"created_at"`
+}
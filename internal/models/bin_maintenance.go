@@ -0,0 +1,56 @@
+package models
+
+import "github.com/lib/pq"
+
+// Maintenance types
+const (
+	MaintenanceTypeRepaint     = "repaint"
+	MaintenanceTypeRepair      = "repair"
+	MaintenanceTypeCleaning    = "cleaning"
+	MaintenanceTypeReplacement = "replacement"
+	MaintenanceTypeInspection  = "inspection"
+	MaintenanceTypeOther       = "other"
+)
+
+// Maintenance statuses
+const (
+	MaintenanceStatusScheduled = "scheduled"
+	MaintenanceStatusCompleted = "completed"
+	MaintenanceStatusCancelled = "cancelled"
+)
+
+// BinMaintenance is a maintenance event performed on (or scheduled for) a bin
+type BinMaintenance struct {
+	ID                string         `json:"id" db:"id"`
+	BinID             string         `json:"bin_id" db:"bin_id"`
+	MaintenanceType   string         `json:"maintenance_type" db:"maintenance_type"` // See MaintenanceType* constants
+	Status            string         `json:"status" db:"status"`                     // scheduled, completed, cancelled
+	ScheduledFor      *int64         `json:"scheduled_for,omitempty" db:"scheduled_for"`
+	PerformedAt       *int64         `json:"performed_at,omitempty" db:"performed_at"`
+	PerformedByUserID *string        `json:"performed_by_user_id,omitempty" db:"performed_by_user_id"`
+	Cost              *float64       `json:"cost,omitempty" db:"cost"`
+	PhotoURLs         pq.StringArray `json:"photo_urls" db:"photo_urls"`
+	Notes             *string        `json:"notes,omitempty" db:"notes"`
+	CreatedByUserID   *string        `json:"created_by_user_id,omitempty" db:"created_by_user_id"`
+	CreatedAt         int64          `json:"created_at" db:"created_at"`
+	UpdatedAt         int64          `json:"updated_at" db:"updated_at"`
+}
+
+// BinMaintenanceWithBin includes bin details for list views
+type BinMaintenanceWithBin struct {
+	BinMaintenance
+	BinNumber       int     `json:"bin_number" db:"bin_number"`
+	CurrentStreet   string  `json:"current_street" db:"current_street"`
+	PerformedByName *string `json:"performed_by_name,omitempty" db:"performed_by_name"`
+	IsOverdue       bool    `json:"is_overdue" db:"is_overdue"` // Scheduled and past due
+}
+
+// IsValidMaintenanceType reports whether t is a known maintenance type
+func IsValidMaintenanceType(t string) bool {
+	switch t {
+	case MaintenanceTypeRepaint, MaintenanceTypeRepair, MaintenanceTypeCleaning,
+		MaintenanceTypeReplacement, MaintenanceTypeInspection, MaintenanceTypeOther:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package models
+
+import "encoding/json"
+
+// BinDuplicateMatch is a pair of bins that look like the same physical bin
+type BinDuplicateMatch struct {
+	BinID             string   `json:"bin_id"`
+	OtherBinID        string   `json:"other_bin_id"`
+	AddressSimilarity float64  `json:"address_similarity"`        // 0..1 on the normalized street address
+	DistanceMeters    *float64 `json:"distance_meters,omitempty"` // nil when either bin has no coordinates
+}
+
+// BinDuplicateGroup is a set of bins linked by duplicate matches
+// SuggestedTargetID is the bin with the most history, the one to merge the others into
+type BinDuplicateGroup struct {
+	Bins              []BinResponse       `json:"bins"`
+	Matches           []BinDuplicateMatch `json:"matches"`
+	SuggestedTargetID string              `json:"suggested_target_id"`
+}
+
+// BinMerge is the audit record of a duplicate bin merged into a surviving bin
+// The source bin is deleted; SourceSnapshot keeps its row as it was
+type BinMerge struct {
+	ID              string          `json:"id" db:"id"`
+	SourceBinID     string          `json:"source_bin_id" db:"source_bin_id"`
+	SourceBinNumber int             `json:"source_bin_number" db:"source_bin_number"`
+	TargetBinID     *string         `json:"target_bin_id,omitempty" db:"target_bin_id"` // nil if the surviving bin was deleted later
+	TargetBinNumber int             `json:"target_bin_number" db:"target_bin_number"`
+	SourceSnapshot  json.RawMessage `json:"source_snapshot" db:"source_snapshot"`
+	Reassigned      BinMergeCounts  `json:"reassigned" db:"reassigned"`
+	MergedByUserID  *string         `json:"merged_by_user_id,omitempty" db:"merged_by_user_id"`
+	MergedAt        int64           `json:"merged_at" db:"merged_at"`
+}
+
+// BinMergeResult is the response of POST /api/manager/bins/{id}/merge-into/{targetId}
+type BinMergeResult struct {
+	Merge BinMerge    `json:"merge"`
+	Bin   BinResponse `json:"bin"` // The surviving bin after the merge
+}
+
+// BinMergeCounts counts the rows moved to the surviving bin, by table
+type BinMergeCounts map[string]int64
+
+// Scan implements the sql.Scanner interface for BinMergeCounts
+func (c *BinMergeCounts) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+
+	return json.Unmarshal(bytes, c)
+}
+
+// Bin reconciliation actions
+const (
+	BinReconciliationRemap  = "remap"  // Point the orphaned rows at an existing bin
+	BinReconciliationDelete = "delete" // Drop the orphaned rows
+)
+
+// OrphanedBinReference is a bin ID that checks, moves, incidents or move requests still point at but no bin has,
+// usually left behind by a bulk import that renumbered or re-created bins
+type OrphanedBinReference struct {
+	MissingBinID   string              `json:"missing_bin_id"`
+	Rows           BinMergeCounts      `json:"rows"`                       // Orphaned rows by table
+	KnownBinNumber *int                `json:"known_bin_number,omitempty"` // The number the bin had, from a merge or route stop
+	Addresses      []string            `json:"addresses"`                  // Check, move and move request addresses the rows recorded
+	Candidates     []BinRemapCandidate `json:"candidates"`                 // Most likely first
+	ProposedBinID  *string             `json:"proposed_bin_id,omitempty"`  // Top candidate, when it's confident and unambiguous
+}
+
+// BinRemapCandidate is an existing bin the orphaned rows might belong to
+// Confidence combines the evidence in Reasons: merged, bin_number, address, location
+type BinRemapCandidate struct {
+	BinID      string   `json:"bin_id"`
+	BinNumber  int      `json:"bin_number"`
+	Address    string   `json:"address"`
+	Confidence float64  `json:"confidence"` // 0..1
+	Reasons    []string `json:"reasons"`
+}
+
+// BinReconciliationFix is an approved fix for one missing bin ID
+type BinReconciliationFix struct {
+	MissingBinID string `json:"missing_bin_id"`
+	Action       string `json:"action"`           // remap or delete
+	BinID        string `json:"bin_id,omitempty"` // Required for remap
+}
+
+// BinReconciliationRequest is the body of POST /api/manager/bins/reconciliation/apply
+type BinReconciliationRequest struct {
+	Fixes []BinReconciliationFix `json:"fixes"`
+}
+
+// BinReconciliation is the audit record of an applied reconciliation fix
+type BinReconciliation struct {
+	ID              string         `json:"id" db:"id"`
+	MissingBinID    string         `json:"missing_bin_id" db:"missing_bin_id"`
+	Action          string         `json:"action" db:"action"`
+	TargetBinID     *string        `json:"target_bin_id,omitempty" db:"target_bin_id"`
+	TargetBinNumber *int           `json:"target_bin_number,omitempty" db:"target_bin_number"`
+	Rows            BinMergeCounts `json:"rows" db:"rows"` // Rows remapped or deleted, by table
+	AppliedByUserID *string        `json:"applied_by_user_id,omitempty" db:"applied_by_user_id"`
+	AppliedAt       int64          `json:"applied_at" db:"applied_at"`
+}
+
+// BinReconciliationReport is the response of POST /api/manager/bins/reconciliation/apply
+type BinReconciliationReport struct {
+	Applied   []BinReconciliation `json:"applied"`
+	Rows      BinMergeCounts      `json:"rows"`      // Totals by table
+	Remaining int                 `json:"remaining"` // Missing bin IDs still referenced after the fixes
+}
@@ -8,17 +8,23 @@ type BinMoveRequest struct {
 	ScheduledDate int64  `json:"scheduled_date" db:"scheduled_date"` // Unix timestamp
 	Urgency       string `json:"urgency" db:"urgency"`               // 'urgent' or 'scheduled'
 	RequestedBy   string `json:"requested_by" db:"requested_by"`     // User ID
-	Status        string `json:"status" db:"status"`                 // 'pending', 'in_progress', 'completed', 'cancelled'
+	Status        string `json:"status" db:"status"`                 // 'requested', 'rejected', 'pending', 'assigned', 'in_progress', 'picked_up', 'completed', 'cancelled'
 
 	// Original location
 	OriginalLatitude  float64 `json:"original_latitude" db:"original_latitude"`
 	OriginalLongitude float64 `json:"original_longitude" db:"original_longitude"`
 	OriginalAddress   string  `json:"original_address" db:"original_address"`
+	OriginalStreet    *string `json:"original_street,omitempty" db:"original_street"` // Parts of OriginalAddress (see SetOriginalAddress)
+	OriginalCity      *string `json:"original_city,omitempty" db:"original_city"`
+	OriginalZip       *string `json:"original_zip,omitempty" db:"original_zip"`
 
 	// New location (nullable for pickup-only)
 	NewLatitude  *float64 `json:"new_latitude,omitempty" db:"new_latitude"`
 	NewLongitude *float64 `json:"new_longitude,omitempty" db:"new_longitude"`
 	NewAddress   *string  `json:"new_address,omitempty" db:"new_address"`
+	NewStreet    *string  `json:"new_street,omitempty" db:"new_street"` // Parts of NewAddress (see SetNewAddress)
+	NewCity      *string  `json:"new_city,omitempty" db:"new_city"`
+	NewZip       *string  `json:"new_zip,omitempty" db:"new_zip"`
 
 	// Move metadata
 	MoveType       string  `json:"move_type" db:"move_type"`                       // 'store' or 'relocation'
@@ -26,12 +32,30 @@ type BinMoveRequest struct {
 	Reason         *string `json:"reason,omitempty" db:"reason"`
 	Notes          *string `json:"notes,omitempty" db:"notes"`
 
+	// Site instructions for the driver (gate code, contact, access notes)
+	Instructions *MoveInstructions `json:"instructions,omitempty" db:"instructions"`
+
+	// Service time window (local "HH:MM"); overrides the bin's window
+	TimeWindowStart *string `json:"time_window_start,omitempty" db:"time_window_start"`
+	TimeWindowEnd   *string `json:"time_window_end,omitempty" db:"time_window_end"`
+
 	// Assignment (shift-based or manual)
 	AssignmentType  *string `json:"assignment_type,omitempty" db:"assignment_type"` // 'shift' or 'manual', NULL for unassigned
 	AssignedShiftID *string `json:"assigned_shift_id,omitempty" db:"assigned_shift_id"`
 	AssignedUserID  *string `json:"assigned_user_id,omitempty" db:"assigned_user_id"` // For manual moves
+	PickedUpAt      *int64  `json:"picked_up_at,omitempty" db:"picked_up_at"`         // Pickup leg confirmed (relocations)
 	CompletedAt     *int64  `json:"completed_at,omitempty" db:"completed_at"`
 
+	// SLA breaches (set once by the SLA checker; see MoveSLAPolicy)
+	AssignSLABreachedAt   *int64 `json:"assign_sla_breached_at,omitempty" db:"assign_sla_breached_at"`
+	CompleteSLABreachedAt *int64 `json:"complete_sla_breached_at,omitempty" db:"complete_sla_breached_at"`
+
+	// Approval (only for moves requested by non-admins; NULL when an admin created the move)
+	ApprovalStatus    *string `json:"approval_status,omitempty" db:"approval_status"` // 'requested', 'approved', 'rejected'
+	ApprovalDecidedBy *string `json:"approval_decided_by,omitempty" db:"approval_decided_by"`
+	ApprovalDecidedAt *int64  `json:"approval_decided_at,omitempty" db:"approval_decided_at"`
+	ApprovalReason    *string `json:"approval_reason,omitempty" db:"approval_reason"` // Admin's note on the decision (required to reject)
+
 	// Timestamps
 	CreatedAt int64 `json:"created_at" db:"created_at"`
 	UpdatedAt int64 `json:"updated_at" db:"updated_at"`
@@ -76,6 +100,14 @@ type BinMoveRequestResponse struct {
 	Reason         *string `json:"reason,omitempty"`
 	Notes          *string `json:"notes,omitempty"`
 
+	// Site instructions and attachments (attachments are only populated where noted)
+	Instructions *MoveInstructions       `json:"instructions,omitempty"`
+	Attachments  []MoveRequestAttachment `json:"attachments,omitempty"`
+
+	// Service time window (local "HH:MM")
+	TimeWindowStart *string `json:"time_window_start,omitempty"`
+	TimeWindowEnd   *string `json:"time_window_end,omitempty"`
+
 	// Assignment (shift-based or manual)
 	AssignmentType     *string `json:"assignment_type,omitempty"`     // 'shift' or 'manual', NULL for unassigned
 	AssignedShiftID    *string `json:"assigned_shift_id,omitempty"`
@@ -83,8 +115,20 @@ type BinMoveRequestResponse struct {
 	AssignedUserID     *string `json:"assigned_user_id,omitempty"`
 	AssignedUserName   *string `json:"assigned_user_name,omitempty"` // User's full name (populated when assigned manually)
 	DriverName         *string `json:"driver_name,omitempty"`         // Unified field: returns driver or user name (whichever is set)
+	PickedUpAtIso      *string `json:"picked_up_at_iso,omitempty"`
 	CompletedAtIso     *string `json:"completed_at_iso,omitempty"`
 
+	// SLA breaches
+	AssignSLABreachedAt   *int64 `json:"assign_sla_breached_at,omitempty"`
+	CompleteSLABreachedAt *int64 `json:"complete_sla_breached_at,omitempty"`
+
+	// Approval
+	ApprovalStatus        *string `json:"approval_status,omitempty"`
+	ApprovalDecidedBy     *string `json:"approval_decided_by,omitempty"`
+	ApprovalDecidedByName *string `json:"approval_decided_by_name,omitempty"`
+	ApprovalDecidedAt     *int64  `json:"approval_decided_at,omitempty"`
+	ApprovalReason        *string `json:"approval_reason,omitempty"`
+
 	// Timestamps
 	CreatedAtIso string `json:"created_at_iso"`
 	UpdatedAtIso string `json:"updated_at_iso"`
@@ -95,8 +139,8 @@ type BinMoveRequestResponse struct {
 
 // CreateBinMoveRequest is the request body for POST /api/manager/bins/schedule-move
 type CreateBinMoveRequest struct {
-	BinID         string `json:"bin_id" binding:"required"`
-	ScheduledDate int64  `json:"scheduled_date" binding:"required"` // Unix timestamp
+	BinID         string `json:"bin_id" validate:"required"`
+	ScheduledDate int64  `json:"scheduled_date" validate:"required"` // Unix timestamp
 	// Urgency is now auto-calculated on backend, not required from frontend
 
 	// New location (optional for pickup-only)
@@ -109,11 +153,18 @@ type CreateBinMoveRequest struct {
 	NewZip       *string  `json:"new_zip,omitempty"`
 
 	// Move metadata
-	MoveType       string  `json:"move_type" binding:"required"` // 'store' or 'relocation'
-	DisposalAction *string `json:"disposal_action,omitempty"`    // DEPRECATED: kept for backward compatibility
+	MoveType       string  `json:"move_type" validate:"required,oneof=store pickup_only relocation"` // 'store' or 'relocation'
+	DisposalAction *string `json:"disposal_action,omitempty" validate:"oneof=retire store"`        // DEPRECATED: kept for backward compatibility
 	Reason         *string `json:"reason,omitempty"`
 	Notes          *string `json:"notes,omitempty"`
 
+	// Site instructions for the driver (optional)
+	Instructions *MoveInstructions `json:"instructions,omitempty"`
+
+	// Service time window (optional, local "HH:MM"; both or neither)
+	TimeWindowStart *string `json:"time_window_start,omitempty" validate:"max=5"`
+	TimeWindowEnd   *string `json:"time_window_end,omitempty" validate:"max=5"`
+
 	// Assignment (optional - if provided, assigns to shift immediately)
 	ShiftID *string `json:"shift_id,omitempty"`
 }
@@ -131,13 +182,22 @@ func (bmr *BinMoveRequest) ToBinMoveRequestResponse() BinMoveRequestResponse {
 		OriginalLatitude:  bmr.OriginalLatitude,
 		OriginalLongitude: bmr.OriginalLongitude,
 		OriginalAddress:   bmr.OriginalAddress,
+		OriginalStreet:    bmr.OriginalStreet,
+		OriginalCity:      bmr.OriginalCity,
+		OriginalZip:       bmr.OriginalZip,
 		NewLatitude:       bmr.NewLatitude,
 		NewLongitude:      bmr.NewLongitude,
 		NewAddress:        bmr.NewAddress,
+		NewStreet:         bmr.NewStreet,
+		NewCity:           bmr.NewCity,
+		NewZip:            bmr.NewZip,
 		MoveType:          bmr.MoveType,
 		DisposalAction:    bmr.DisposalAction,
 		Reason:            bmr.Reason,
 		Notes:             bmr.Notes,
+		Instructions:      bmr.Instructions,
+		TimeWindowStart:   bmr.TimeWindowStart,
+		TimeWindowEnd:     bmr.TimeWindowEnd,
 		AssignmentType:    bmr.AssignmentType,
 		AssignedShiftID:   bmr.AssignedShiftID,
 		AssignedUserID:    bmr.AssignedUserID,
@@ -145,10 +205,75 @@ func (bmr *BinMoveRequest) ToBinMoveRequestResponse() BinMoveRequestResponse {
 		UpdatedAtIso:      time.Unix(bmr.UpdatedAt, 0).Format(time.RFC3339),
 	}
 
+	if bmr.PickedUpAt != nil {
+		iso := time.Unix(*bmr.PickedUpAt, 0).Format(time.RFC3339)
+		resp.PickedUpAtIso = &iso
+	}
 	if bmr.CompletedAt != nil {
 		iso := time.Unix(*bmr.CompletedAt, 0).Format(time.RFC3339)
 		resp.CompletedAtIso = &iso
 	}
+	resp.AssignSLABreachedAt = bmr.AssignSLABreachedAt
+	resp.CompleteSLABreachedAt = bmr.CompleteSLABreachedAt
+	resp.ApprovalStatus = bmr.ApprovalStatus
+	resp.ApprovalDecidedBy = bmr.ApprovalDecidedBy
+	resp.ApprovalDecidedAt = bmr.ApprovalDecidedAt
+	resp.ApprovalReason = bmr.ApprovalReason
 
 	return resp
 }
+
+// SetOriginalAddress sets the original address line and its parts
+func (bmr *BinMoveRequest) SetOriginalAddress(address Address) {
+	bmr.OriginalAddress = address.String()
+	bmr.OriginalStreet, bmr.OriginalCity, bmr.OriginalZip = addressParts(address)
+}
+
+// SetNewAddress sets the new address line and its parts
+func (bmr *BinMoveRequest) SetNewAddress(address Address) {
+	line := address.String()
+	bmr.NewAddress = &line
+	bmr.NewStreet, bmr.NewCity, bmr.NewZip = addressParts(address)
+}
+
+// addressParts returns an address's street, city and ZIP for the nullable columns (nil when empty)
+func addressParts(address Address) (street, city, zip *string) {
+	nonEmpty := func(value string) *string {
+		if value == "" {
+			return nil
+		}
+		return &value
+	}
+	return nonEmpty(address.Street), nonEmpty(address.City), nonEmpty(address.Zip)
+}
+
+// ConfirmMoveLegRequest is the body for POST /api/driver/moves/{id}/confirm-pickup and /confirm-dropoff
+// At least a photo or a signature is required as proof for the leg
+type ConfirmMoveLegRequest struct {
+	PhotoURL     *string `json:"photo_url" validate:"format=uri"`
+	SignatureURL *string `json:"signature_url" validate:"format=uri"`
+}
+
+// MoveLegConfirmation is returned after a driver confirms a pickup or dropoff leg
+type MoveLegConfirmation struct {
+	MoveRequestID        string   `json:"move_request_id"`
+	TaskID               string   `json:"task_id"`
+	Leg                  TaskType `json:"leg"`    // "pickup" or "dropoff"
+	Status               string   `json:"status"` // Move status after the leg: "picked_up" or "completed"
+	CompletedBins        int      `json:"completed_bins"`
+	TotalBins            int      `json:"total_bins"`
+	CompletionPercentage float64  `json:"completion_percentage"`
+}
+
+// Move request approval states (BinMoveRequest.ApprovalStatus)
+// A requested move also has status 'requested'; approving moves it to 'pending', rejecting to 'rejected'
+const (
+	MoveApprovalRequested = "requested"
+	MoveApprovalApproved  = "approved"
+	MoveApprovalRejected  = "rejected"
+)
+
+// MoveApprovalDecisionRequest is the body for PUT /api/manager/bins/move-requests/{id}/approve and /reject
+type MoveApprovalDecisionRequest struct {
+	Reason *string `json:"reason"` // Optional when approving, required when rejecting
+}
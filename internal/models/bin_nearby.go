@@ -0,0 +1,18 @@
+package models
+
+// NearbyBin is a bin returned by GET /api/bins/nearby, with its distance from the search point
+type NearbyBin struct {
+	BinResponse
+	DistanceMeters     float64 `json:"distance_meters"`
+	HasPendingMove     bool    `json:"has_pending_move"` // A move request is pending or in progress
+	PendingMoveID      *string `json:"pending_move_id,omitempty"`
+	PendingMoveUrgency *string `json:"pending_move_urgency,omitempty"`
+}
+
+// NearbyBinsResponse is the response of GET /api/bins/nearby
+type NearbyBinsResponse struct {
+	Latitude     float64     `json:"latitude"`
+	Longitude    float64     `json:"longitude"`
+	RadiusMeters float64     `json:"radius_meters"`
+	Bins         []NearbyBin `json:"bins"`
+}
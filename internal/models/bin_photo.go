@@ -0,0 +1,28 @@
+package models
+
+// Bin photo sources
+const (
+	BinPhotoSourceCheck       = "check"
+	BinPhotoSourceIncident    = "incident"
+	BinPhotoSourceMaintenance = "maintenance"
+)
+
+// BinPhoto is a photo of a bin taken during a check, incident report or maintenance (from the bin_photos view)
+type BinPhoto struct {
+	BinID       string  `json:"bin_id" db:"bin_id"`
+	PhotoURL    string  `json:"photo_url" db:"photo_url"`
+	Source      string  `json:"source" db:"source"`       // See BinPhotoSource* constants
+	SourceID    string  `json:"source_id" db:"source_id"` // ID of the check, incident or maintenance record
+	TakenAt     int64   `json:"taken_at" db:"taken_at"`   // Unix timestamp
+	TakenBy     *string `json:"taken_by,omitempty" db:"taken_by"`
+	TakenByName *string `json:"taken_by_name,omitempty" db:"taken_by_name"`
+	Caption     *string `json:"caption,omitempty" db:"caption"` // Incident type or maintenance type
+}
+
+// BinPhotoPage is one page of a bin's photo gallery, newest first
+type BinPhotoPage struct {
+	Photos []BinPhoto `json:"photos"`
+	Total  int        `json:"total"`
+	Limit  int        `json:"limit"`
+	Offset int        `json:"offset"`
+}
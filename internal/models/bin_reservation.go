@@ -0,0 +1,22 @@
+package models
+
+// BinReservation is a bin held by an open shift: an unfinished stop on a shift that is ready, active or paused
+// A bin can only be reserved by one shift at a time (stops of the same move request don't conflict)
+type BinReservation struct {
+	BinID         string  `json:"bin_id" db:"bin_id"`
+	BinNumber     *int    `json:"bin_number,omitempty" db:"bin_number"`
+	ShiftID       string  `json:"shift_id" db:"shift_id"`
+	ShiftStatus   string  `json:"shift_status" db:"shift_status"`
+	DriverID      string  `json:"driver_id" db:"driver_id"`
+	DriverName    *string `json:"driver_name,omitempty" db:"driver_name"`
+	TaskType      string  `json:"task_type" db:"task_type"`
+	MoveRequestID *string `json:"move_request_id,omitempty" db:"move_request_id"`
+	ReservedAt    int64   `json:"reserved_at" db:"reserved_at"` // When the stop was added
+}
+
+// BinReservationConflict is a bin on more than one open shift (from before reservations were enforced)
+type BinReservationConflict struct {
+	BinID     string           `json:"bin_id"`
+	BinNumber *int             `json:"bin_number,omitempty"`
+	Shifts    []BinReservation `json:"shifts"` // Oldest reservation first
+}
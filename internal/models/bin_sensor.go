@@ -0,0 +1,61 @@
+package models
+
+// BinSensor is a registered IoT fill sensor and the bin it is mounted on
+type BinSensor struct {
+	ID                 string  `json:"id" db:"id"` // Sensor ID reported by the device
+	BinID              *string `json:"bin_id,omitempty" db:"bin_id"`
+	BinNumber          *int    `json:"bin_number,omitempty" db:"bin_number"`
+	Description        *string `json:"description,omitempty" db:"description"`
+	IsActive           bool    `json:"is_active" db:"is_active"` // Readings from inactive sensors are rejected
+	LastReadingAt      *int64  `json:"last_reading_at,omitempty" db:"last_reading_at"`
+	LastFillPercentage *int    `json:"last_fill_percentage,omitempty" db:"last_fill_percentage"`
+	BatteryPercentage  *int    `json:"battery_percentage,omitempty" db:"battery_percentage"`
+	RejectedReadings   int     `json:"rejected_readings" db:"rejected_readings"` // Readings dropped by anomaly filtering
+	CreatedAt          int64   `json:"created_at" db:"created_at"`
+	UpdatedAt          int64   `json:"updated_at" db:"updated_at"`
+}
+
+// BinSensorRequest is the body for registering or updating a sensor (omitted fields are unchanged on update)
+type BinSensorRequest struct {
+	ID          *string `json:"id" validate:"min=1"` // Required on register
+	BinID       *string `json:"bin_id"`              // "" unassigns the sensor
+	Description *string `json:"description"`
+	IsActive    *bool   `json:"is_active"`
+}
+
+// SensorReading is one fill measurement from a sensor
+type SensorReading struct {
+	SensorID          string `json:"sensor_id" validate:"required"`
+	FillPercentage    *int   `json:"fill_percentage" validate:"required"`
+	MeasuredAt        *int64 `json:"measured_at"` // Unix seconds; defaults to when the batch was received
+	BatteryPercentage *int   `json:"battery_percentage"`
+}
+
+// SensorReadingBatch is the body for POST /api/ingest/sensor-readings
+type SensorReadingBatch struct {
+	Readings []SensorReading `json:"readings" validate:"required,max=500"`
+}
+
+// Reasons a sensor reading is rejected
+const (
+	SensorRejectUnknownSensor = "unknown_sensor" // Not registered, inactive, or not mounted on a bin
+	SensorRejectOutOfRange    = "out_of_range"   // Fill outside 0-100
+	SensorRejectFuture        = "future_timestamp"
+	SensorRejectStale         = "stale" // Not newer than the sensor's last accepted reading
+	SensorRejectSpike         = "spike" // Implausibly fast rise since the last accepted reading
+)
+
+// RejectedSensorReading explains why a reading in a batch was dropped
+type RejectedSensorReading struct {
+	Index    int    `json:"index"`
+	SensorID string `json:"sensor_id"`
+	Reason   string `json:"reason"`
+}
+
+// SensorIngestResult summarizes a batch
+type SensorIngestResult struct {
+	Received    int                     `json:"received"`
+	Accepted    int                     `json:"accepted"`
+	Rejected    []RejectedSensorReading `json:"rejected"`
+	UpdatedBins int                     `json:"updated_bins"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Canonical bin statuses (enforced by bins_status_check)
+const (
+	BinStatusActive      = "active"
+	BinStatusMissing     = "missing"
+	BinStatusNeedsCheck  = "needs_check"
+	BinStatusPendingMove = "pending_move"
+	BinStatusInStorage   = "in_storage"
+	BinStatusRetired     = "retired"
+)
+
+// BinStatuses lists every canonical bin status
+var BinStatuses = []string{
+	BinStatusActive, BinStatusMissing, BinStatusNeedsCheck, BinStatusPendingMove, BinStatusInStorage, BinStatusRetired,
+}
+
+// binStatusTransitions lists the statuses a bin may move to from each status (staying put is always allowed)
+// Retired is final: a retired bin's number stays reserved and it never comes back into service.
+// A bin pending a move can't be stored or retired until the move is completed or cancelled
+var binStatusTransitions = map[string][]string{
+	BinStatusActive:      {BinStatusMissing, BinStatusNeedsCheck, BinStatusPendingMove, BinStatusInStorage, BinStatusRetired},
+	BinStatusMissing:     {BinStatusActive, BinStatusNeedsCheck, BinStatusInStorage, BinStatusRetired},
+	BinStatusNeedsCheck:  {BinStatusActive, BinStatusMissing, BinStatusPendingMove, BinStatusInStorage, BinStatusRetired},
+	BinStatusPendingMove: {BinStatusActive, BinStatusMissing, BinStatusNeedsCheck},
+	BinStatusInStorage:   {BinStatusActive, BinStatusPendingMove, BinStatusRetired},
+	BinStatusRetired:     {},
+}
+
+// NormalizeBinStatus maps legacy spellings ("Active", "Pending Move", "in-storage") to the canonical status
+// Unknown values are returned lowercased so IsValidBinStatus rejects them
+func NormalizeBinStatus(status string) string {
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(strings.TrimSpace(status)))
+}
+
+// IsValidBinStatus reports whether status is a canonical bin status
+func IsValidBinStatus(status string) bool {
+	_, ok := binStatusTransitions[status]
+	return ok
+}
+
+// ValidateBinStatusTransition checks a bin may move from one canonical status to another
+func ValidateBinStatusTransition(from, to string) error {
+	if !IsValidBinStatus(to) {
+		return fmt.Errorf("invalid bin status %q (must be one of: %s)", to, strings.Join(BinStatuses, ", "))
+	}
+	if from == to {
+		return nil
+	}
+	for _, allowed := range binStatusTransitions[from] {
+		if allowed == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("a bin can't go from %s to %s", from, to)
+}
+
+// AllowedBinStatusTransitions lists the statuses a bin in the given status may move to
+func AllowedBinStatusTransitions(from string) []string {
+	return append([]string{}, binStatusTransitions[from]...)
+}
@@ -0,0 +1,44 @@
+package models
+
+// Where a capacity plan's stops per shift came from
+const (
+	StopsPerShiftHistory  = "history"  // Average over the drivers' recent shifts
+	StopsPerShiftOverride = "override" // The stops_per_shift query parameter
+	StopsPerShiftDefault  = "default"  // No recent shifts to average
+)
+
+// CapacityPlan estimates the shifts (one driver each) needed per day to collect the bins forecast to reach the
+// critical fill level and the scheduled move requests, by area
+type CapacityPlan struct {
+	GeneratedAt         int64             `json:"generated_at"`
+	Timezone            string            `json:"timezone"` // Service hours timezone the days are in
+	FillThreshold       int               `json:"fill_threshold"`
+	StopsPerShift       float64           `json:"stops_per_shift"`
+	StopsPerShiftSource string            `json:"stops_per_shift_source"`
+	DriversAvailable    int               `json:"drivers_available"` // Active drivers
+	BinsForecast        int               `json:"bins_forecast"`
+	BinsWithoutHistory  int               `json:"bins_without_history"` // Forecast at their area's (or the fleet's) average fill rate
+	Days                []CapacityPlanDay `json:"days"`
+}
+
+// CapacityPlanDay is one day of a capacity plan; stops due on days without service move to the next service day
+type CapacityPlanDay struct {
+	Date         string             `json:"date"` // YYYY-MM-DD
+	ServiceDay   bool               `json:"service_day"`
+	Collections  int                `json:"collections"` // Bins forecast to reach the fill threshold
+	Moves        int                `json:"moves"`       // Pending move requests scheduled that day (overdue ones on the first day)
+	Stops        int                `json:"stops"`
+	ShiftsNeeded int                `json:"shifts_needed"` // Sum of the areas' shifts (a shift stays in one area)
+	Shortfall    int                `json:"shortfall"`     // Shifts beyond the drivers available
+	Areas        []CapacityPlanArea `json:"areas"`
+}
+
+// CapacityPlanArea is an area's share of a capacity plan day
+type CapacityPlanArea struct {
+	AreaID       *string `json:"area_id,omitempty"` // nil for bins outside every area
+	AreaName     string  `json:"area_name"`
+	Collections  int     `json:"collections"`
+	Moves        int     `json:"moves"`
+	Stops        int     `json:"stops"`
+	ShiftsNeeded int     `json:"shifts_needed"`
+}
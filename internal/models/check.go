@@ -1,35 +1,47 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+)
 
 type Check struct {
-	ID             int     `json:"id" db:"id"`
-	BinID          string  `json:"bin_id" db:"bin_id"`
-	CheckedFrom    string  `json:"checked_from" db:"checked_from"`
-	FillPercentage *int    `json:"fill_percentage" db:"fill_percentage"` // Nullable for incident-only check-ins
-	CheckedOn      int64   `json:"checked_on" db:"checked_on"`           // Unix timestamp
-	PhotoUrl       *string `json:"photo_url" db:"photo_url"`             // Cloudinary URL
-	CheckedBy      *string `json:"checked_by" db:"checked_by"`           // User ID who performed the check
-	ShiftID        *string `json:"shift_id" db:"shift_id"`               // Shift during which check was performed
-	MoveRequestID  *string `json:"move_request_id" db:"move_request_id"` // Links to move request if this check was for pickup/dropoff
+	ID             int             `json:"id" db:"id"`
+	BinID          string          `json:"bin_id" db:"bin_id"`
+	CheckedFrom    string          `json:"checked_from" db:"checked_from"`
+	FillPercentage *int            `json:"fill_percentage" db:"fill_percentage"`         // Nullable for incident-only check-ins
+	CheckedOn      int64           `json:"checked_on" db:"checked_on"`                   // Unix timestamp
+	PhotoUrl       *string         `json:"photo_url" db:"photo_url"`                     // Cloudinary URL
+	CheckedBy      *string         `json:"checked_by" db:"checked_by"`                   // User ID who performed the check
+	ShiftID        *string         `json:"shift_id" db:"shift_id"`                       // Shift during which check was performed
+	MoveRequestID  *string         `json:"move_request_id" db:"move_request_id"`         // Links to move request if this check was for pickup/dropoff
+	AnomalyFlags   pq.StringArray  `json:"anomaly_flags" db:"anomaly_flags"`             // Set by the anomaly detector (see CheckAnomaly*)
+	ReviewStatus   *string         `json:"review_status" db:"review_status"`             // pending, confirmed, dismissed (NULL when not flagged)
+	PhotoRequired  bool            `json:"photo_required" db:"photo_required"`           // The bin required a photo when it was checked
+	FormResponses  json.RawMessage `json:"form_responses,omitempty" db:"form_responses"` // Answers to the check form fields (see CheckForm)
 }
 
 // CheckResponse is what we send to the client
 type CheckResponse struct {
-	ID                     int     `json:"id"`
-	BinID                  string  `json:"binId"`
-	CheckedFrom            string  `json:"checkedFrom"`
-	FillPercentage         *int    `json:"fillPercentage"`         // Current fill % after check
-	PreviousFillPercentage *int    `json:"previousFillPercentage"` // Previous fill % before this check (calculated from prior check)
-	CheckedOnIso           string  `json:"checkedOnIso"`
-	CheckedOn              string  `json:"checkedOn"`      // formatted date
-	PhotoUrl               *string `json:"photoUrl"`       // Cloudinary URL
-	CheckedBy              *string `json:"checkedBy"`      // User ID
-	CheckedByName          *string `json:"checkedByName"`  // Driver's name (joined from users table)
-	ShiftID                *string `json:"shiftId"`        // Shift ID during which check was performed
-	ShiftStatus            *string `json:"shiftStatus"`    // Shift status (active, ended, etc.) - joined from shifts table
-	MoveRequestID          *string `json:"moveRequestId"`  // Links to move request if this check was for pickup/dropoff
-	BinLocation            *string `json:"binLocation"`    // Bin's actual address (joined from bins table)
+	ID                     int             `json:"id"`
+	BinID                  string          `json:"binId"`
+	CheckedFrom            string          `json:"checkedFrom"`
+	FillPercentage         *int            `json:"fillPercentage"`         // Current fill % after check
+	PreviousFillPercentage *int            `json:"previousFillPercentage"` // Previous fill % before this check (calculated from prior check)
+	CheckedOnIso           string          `json:"checkedOnIso"`
+	CheckedOn              string          `json:"checkedOn"`               // formatted date
+	PhotoUrl               *string         `json:"photoUrl"`                // Cloudinary URL
+	CheckedBy              *string         `json:"checkedBy"`               // User ID
+	CheckedByName          *string         `json:"checkedByName"`           // Driver's name (joined from users table)
+	ShiftID                *string         `json:"shiftId"`                 // Shift ID during which check was performed
+	ShiftStatus            *string         `json:"shiftStatus"`             // Shift status (active, ended, etc.) - joined from shifts table
+	MoveRequestID          *string         `json:"moveRequestId"`           // Links to move request if this check was for pickup/dropoff
+	BinLocation            *string         `json:"binLocation"`             // Bin's actual address (joined from bins table)
+	AnomalyFlags           []string        `json:"anomalyFlags"`            // Why the check looks suspicious (empty when not flagged)
+	ReviewStatus           *string         `json:"reviewStatus"`            // Manager review of the flags: pending, confirmed, dismissed
+	FormResponses          json.RawMessage `json:"formResponses,omitempty"` // Answers to the check form fields, by field key
 }
 
 // ToCheckResponse converts a Check to CheckResponse
@@ -51,5 +63,16 @@ func (c *Check) ToCheckResponse() CheckResponse {
 		ShiftStatus:            nil, // Must be populated by handler with JOIN query
 		MoveRequestID:          c.MoveRequestID,
 		BinLocation:            nil, // Must be populated by handler with JOIN query
+		AnomalyFlags:           c.flags(),
+		ReviewStatus:           c.ReviewStatus,
+		FormResponses:          c.FormResponses,
+	}
+}
+
+// flags returns the anomaly flags, never nil (queries that don't select the column leave it unset)
+func (c *Check) flags() []string {
+	if c.AnomalyFlags == nil {
+		return []string{}
 	}
+	return c.AnomalyFlags
 }
@@ -0,0 +1,57 @@
+package models
+
+import "github.com/lib/pq"
+
+// Check anomaly flags
+const (
+	CheckAnomalyUnexplainedFillDrop = "unexplained_fill_drop" // Fill fell sharply with no collection or move since the previous check
+	CheckAnomalyFarFromBin          = "far_from_bin"          // The driver's GPS was too far from the bin when the check was submitted
+	CheckAnomalyImpossibleTravel    = "impossible_travel"     // Reached from the driver's previous check faster than possible
+	CheckAnomalyRemoteCompletion    = "remote_completion"     // The stop was completed further from it than the check-in proximity limit
+)
+
+// Check review statuses
+const (
+	CheckReviewPending   = "pending"
+	CheckReviewConfirmed = "confirmed" // The manager agrees the check is bogus
+	CheckReviewDismissed = "dismissed" // False alarm
+)
+
+// CheckAnomaly explains one flag raised on a check
+type CheckAnomaly struct {
+	Flag    string `json:"flag"`
+	Message string `json:"message"`
+
+	PreviousCheckID    *int     `json:"previous_check_id,omitempty"`
+	PreviousFill       *int     `json:"previous_fill,omitempty"`
+	DistanceMeters     *float64 `json:"distance_meters,omitempty"`
+	ElapsedSeconds     *int64   `json:"elapsed_seconds,omitempty"`
+	ImpliedSpeedKmh    *float64 `json:"implied_speed_kmh,omitempty"`
+	LocationAgeSeconds *int64   `json:"location_age_seconds,omitempty"` // How old the driver's GPS fix was
+}
+
+// FlaggedCheck is a check in the manager review queue
+type FlaggedCheck struct {
+	ID               int            `json:"id" db:"id"`
+	BinID            string         `json:"bin_id" db:"bin_id"`
+	BinNumber        int            `json:"bin_number" db:"bin_number"`
+	BinLocation      string         `json:"bin_location" db:"bin_location"`
+	FillPercentage   *int           `json:"fill_percentage,omitempty" db:"fill_percentage"`
+	CheckedOn        int64          `json:"checked_on" db:"checked_on"`
+	PhotoURL         *string        `json:"photo_url,omitempty" db:"photo_url"`
+	CheckedBy        *string        `json:"checked_by,omitempty" db:"checked_by"`
+	CheckedByName    *string        `json:"checked_by_name,omitempty" db:"checked_by_name"`
+	ShiftID          *string        `json:"shift_id,omitempty" db:"shift_id"`
+	AnomalyFlags     pq.StringArray `json:"anomaly_flags" db:"anomaly_flags"`
+	Anomalies        []CheckAnomaly `json:"anomalies" db:"-"`
+	ReviewStatus     string         `json:"review_status" db:"review_status"`
+	ReviewedByUserID *string        `json:"reviewed_by_user_id,omitempty" db:"reviewed_by_user_id"`
+	ReviewedAt       *int64         `json:"reviewed_at,omitempty" db:"reviewed_at"`
+	ReviewNotes      *string        `json:"review_notes,omitempty" db:"review_notes"`
+}
+
+// ReviewCheckRequest is the body for POST /api/manager/checks/{id}/review
+type ReviewCheckRequest struct {
+	Decision string  `json:"decision" validate:"required,oneof=confirmed dismissed"`
+	Notes    *string `json:"notes"`
+}
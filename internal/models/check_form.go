@@ -0,0 +1,198 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Check form field types
+const (
+	CheckFormFieldNumber  = "number"
+	CheckFormFieldText    = "text"
+	CheckFormFieldBoolean = "boolean"
+	CheckFormFieldSelect  = "select" // One of the field's options
+)
+
+// checkFormTextMaxLength caps text answers
+const checkFormTextMaxLength = 1000
+
+// checkFormKeyPattern is the shape of field keys (they're the keys of checks.form_responses)
+var checkFormKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// CheckForm is the organization's extra check fields, asked alongside fill % when drivers complete a stop
+// (e.g. contamination level or odor); answers are stored in checks.form_responses
+type CheckForm struct {
+	Fields []CheckFormField `json:"fields"` // In display order
+}
+
+// CheckFormField is one admin-defined question on the check form
+type CheckFormField struct {
+	Key      string   `json:"key"` // Lowercase letters, digits and underscores; the answer's key in form_responses
+	Label    string   `json:"label"`
+	HelpText string   `json:"help_text,omitempty"`
+	Type     string   `json:"type"`              // number, text, boolean or select
+	Required bool     `json:"required"`          // Must be answered when collecting a bin (not when reporting an incident)
+	Options  []string `json:"options,omitempty"` // select only
+	Min      *float64 `json:"min,omitempty"`     // number only
+	Max      *float64 `json:"max,omitempty"`     // number only
+}
+
+// CheckFormFieldError is an answer that doesn't fit its field
+type CheckFormFieldError struct {
+	Key     string
+	Message string
+}
+
+// DefaultCheckForm returns the built-in form used when no settings are stored (no extra fields)
+func DefaultCheckForm() CheckForm {
+	return CheckForm{Fields: []CheckFormField{}}
+}
+
+// Validate checks field keys, types and per-type settings
+func (f CheckForm) Validate() error {
+	seen := map[string]bool{}
+	for i, field := range f.Fields {
+		if !checkFormKeyPattern.MatchString(field.Key) {
+			return fmt.Errorf("fields[%d].key must be lowercase letters, digits and underscores, starting with a letter", i)
+		}
+		if seen[field.Key] {
+			return fmt.Errorf("duplicate field key %s", field.Key)
+		}
+		seen[field.Key] = true
+		if strings.TrimSpace(field.Label) == "" {
+			return fmt.Errorf("field %s needs a label", field.Key)
+		}
+
+		switch field.Type {
+		case CheckFormFieldNumber:
+			if field.Min != nil && field.Max != nil && *field.Min > *field.Max {
+				return fmt.Errorf("field %s: min must not be greater than max", field.Key)
+			}
+		case CheckFormFieldSelect:
+			if len(field.Options) == 0 {
+				return fmt.Errorf("field %s: select fields need options", field.Key)
+			}
+			options := map[string]bool{}
+			for _, option := range field.Options {
+				if strings.TrimSpace(option) == "" {
+					return fmt.Errorf("field %s: options must not be empty", field.Key)
+				}
+				if options[option] {
+					return fmt.Errorf("field %s: duplicate option %s", field.Key, option)
+				}
+				options[option] = true
+			}
+		case CheckFormFieldText, CheckFormFieldBoolean:
+		default:
+			return fmt.Errorf("field %s: type must be number, text, boolean or select", field.Key)
+		}
+		if field.Type != CheckFormFieldSelect && len(field.Options) > 0 {
+			return fmt.Errorf("field %s: only select fields have options", field.Key)
+		}
+		if field.Type != CheckFormFieldNumber && (field.Min != nil || field.Max != nil) {
+			return fmt.Errorf("field %s: only number fields have min and max", field.Key)
+		}
+	}
+	return nil
+}
+
+// ValidateResponses checks a driver's answers against the form and returns them normalized for storage
+// (text trimmed, unanswered fields left out); requireAll enforces the required flags
+// Answers to fields that aren't on the form are rejected so typos don't disappear silently
+func (f CheckForm) ValidateResponses(responses map[string]json.RawMessage, requireAll bool) (map[string]interface{}, []CheckFormFieldError) {
+	values := map[string]interface{}{}
+	var errs []CheckFormFieldError
+
+	fields := make(map[string]bool, len(f.Fields))
+	for _, field := range f.Fields {
+		fields[field.Key] = true
+
+		raw, answered := responses[field.Key]
+		if answered && string(raw) == "null" {
+			answered = false
+		}
+		if !answered {
+			if requireAll && field.Required {
+				errs = append(errs, CheckFormFieldError{Key: field.Key, Message: field.Label + " is required"})
+			}
+			continue
+		}
+
+		value, err := field.parse(raw)
+		if err != nil {
+			errs = append(errs, CheckFormFieldError{Key: field.Key, Message: err.Error()})
+			continue
+		}
+		if value == nil {
+			if requireAll && field.Required {
+				errs = append(errs, CheckFormFieldError{Key: field.Key, Message: field.Label + " is required"})
+			}
+			continue
+		}
+		values[field.Key] = value
+	}
+
+	var unknown []string
+	for key := range responses {
+		if !fields[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		errs = append(errs, CheckFormFieldError{Key: key, Message: "not a field on this form"})
+	}
+	return values, errs
+}
+
+// parse decodes one answer; returns nil for an empty text answer
+func (field CheckFormField) parse(raw json.RawMessage) (interface{}, error) {
+	switch field.Type {
+	case CheckFormFieldNumber:
+		var n float64
+		if err := json.Unmarshal(raw, &n); err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+			return nil, fmt.Errorf("must be a number")
+		}
+		if field.Min != nil && n < *field.Min {
+			return nil, fmt.Errorf("must be at least %g", *field.Min)
+		}
+		if field.Max != nil && n > *field.Max {
+			return nil, fmt.Errorf("must be at most %g", *field.Max)
+		}
+		return n, nil
+	case CheckFormFieldBoolean:
+		var b bool
+		if err := json.Unmarshal(raw, &b); err != nil {
+			return nil, fmt.Errorf("must be true or false")
+		}
+		return b, nil
+	case CheckFormFieldSelect:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, fmt.Errorf("must be one of %s", strings.Join(field.Options, ", "))
+		}
+		for _, option := range field.Options {
+			if s == option {
+				return s, nil
+			}
+		}
+		return nil, fmt.Errorf("must be one of %s", strings.Join(field.Options, ", "))
+	default:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, fmt.Errorf("must be text")
+		}
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return nil, nil
+		}
+		if len(s) > checkFormTextMaxLength {
+			return nil, fmt.Errorf("must be at most %d characters", checkFormTextMaxLength)
+		}
+		return s, nil
+	}
+}
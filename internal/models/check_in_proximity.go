@@ -0,0 +1,69 @@
+package models
+
+import "fmt"
+
+// What happens to a completion submitted too far from the stop
+const (
+	CheckInProximityFlag   = "flag"   // Accept it, flagged remote_completion for manager review
+	CheckInProximityReject = "reject" // Refuse it until the driver is near the stop
+)
+
+// CheckInProximitySettings controls the server-side check that drivers are at the stop when they complete it,
+// comparing the stop with the driver's latest GPS fix (driver_current_location)
+// Without a recent fix the completion is accepted unverified: GPS gaps shouldn't block a route
+type CheckInProximitySettings struct {
+	Enabled               bool    `json:"enabled"`
+	MaxDistanceMeters     float64 `json:"max_distance_meters"`      // Further than this is a remote completion
+	MaxLocationAgeSeconds int64   `json:"max_location_age_seconds"` // Older fixes can't tell where the driver is
+	Action                string  `json:"action"`                   // flag or reject
+}
+
+// DefaultCheckInProximitySettings returns the built-in settings used when none are stored
+func DefaultCheckInProximitySettings() CheckInProximitySettings {
+	return CheckInProximitySettings{
+		Enabled:               true,
+		MaxDistanceMeters:     150,
+		MaxLocationAgeSeconds: 300,
+		Action:                CheckInProximityFlag,
+	}
+}
+
+// Validate checks the ranges and the action
+func (s CheckInProximitySettings) Validate() error {
+	if s.MaxDistanceMeters < 10 || s.MaxDistanceMeters > 5000 {
+		return fmt.Errorf("max_distance_meters must be between 10 and 5000")
+	}
+	if s.MaxLocationAgeSeconds < 30 || s.MaxLocationAgeSeconds > 3600 {
+		return fmt.Errorf("max_location_age_seconds must be between 30 and 3600")
+	}
+	if s.Action != CheckInProximityFlag && s.Action != CheckInProximityReject {
+		return fmt.Errorf("action must be flag or reject")
+	}
+	return nil
+}
+
+// CheckInProximity is where the driver was, relative to the stop, when completing it
+type CheckInProximity struct {
+	Verified           bool     // A recent enough GPS fix was available
+	DistanceMeters     *float64 // From the stop (verified only)
+	LocationAgeSeconds *int64
+	Remote             bool // Verified and further than the limit
+}
+
+// RemoteCompletionStats is one driver's shift stop completions and how many were remote
+type RemoteCompletionStats struct {
+	DriverID    string   `json:"driver_id" db:"driver_id"`
+	DriverName  string   `json:"driver_name" db:"driver_name"`
+	Completions int      `json:"completions" db:"completions"`
+	Remote      int      `json:"remote" db:"remote"`
+	Unverified  int      `json:"unverified" db:"unverified"` // No recent GPS fix to compare with
+	RemoteRate  *float64 `json:"remote_rate"`                // Remote / verified completions (null when none were verified)
+}
+
+// RemoteCompletionReport summarizes remote completions in a period
+type RemoteCompletionReport struct {
+	From     int64                   `json:"from"`
+	To       int64                   `json:"to"`
+	ByDriver []RemoteCompletionStats `json:"by_driver"` // Highest remote rate first
+	Overall  RemoteCompletionStats   `json:"overall"`
+}
@@ -0,0 +1,172 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Feature flag keys the apps check
+const (
+	FeatureNewIncidentFlow = "new_incident_flow"
+)
+
+// ClientConfig is the app version policy and feature flags served to the mobile and dashboard apps
+type ClientConfig struct {
+	MinAppVersion    string        `json:"min_app_version"`    // Older apps must upgrade before continuing ("" = no minimum)
+	LatestAppVersion string        `json:"latest_app_version"` // Older apps are offered an optional upgrade ("" = none)
+	UpgradeMessage   string        `json:"upgrade_message"`
+	FeatureFlags     []FeatureFlag `json:"feature_flags"`
+}
+
+// FeatureFlag turns a feature on for the matching clients
+// Empty roles/organizations match everyone; min_app_version keeps the feature off for older apps
+type FeatureFlag struct {
+	Key           string   `json:"key"`
+	Description   string   `json:"description,omitempty"`
+	Enabled       bool     `json:"enabled"`
+	Roles         []string `json:"roles,omitempty"`
+	Organizations []string `json:"organizations,omitempty"`
+	MinAppVersion string   `json:"min_app_version,omitempty"`
+}
+
+// DefaultClientConfig returns the built-in config used when no settings are stored
+func DefaultClientConfig() ClientConfig {
+	return ClientConfig{
+		FeatureFlags: []FeatureFlag{
+			{Key: FeatureNewIncidentFlow, Description: "Redesigned incident reporting flow", Enabled: true},
+		},
+	}
+}
+
+// Validate checks version formats and flag keys
+func (c ClientConfig) Validate() error {
+	for name, version := range map[string]string{"min_app_version": c.MinAppVersion, "latest_app_version": c.LatestAppVersion} {
+		if version != "" {
+			if _, err := ParseAppVersion(version); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	}
+	if c.MinAppVersion != "" && c.LatestAppVersion != "" && CompareAppVersions(c.MinAppVersion, c.LatestAppVersion) > 0 {
+		return fmt.Errorf("min_app_version must not be newer than latest_app_version")
+	}
+
+	seen := map[string]bool{}
+	for _, flag := range c.FeatureFlags {
+		if strings.TrimSpace(flag.Key) == "" {
+			return fmt.Errorf("feature flags need a key")
+		}
+		if seen[flag.Key] {
+			return fmt.Errorf("duplicate feature flag %s", flag.Key)
+		}
+		seen[flag.Key] = true
+		if flag.MinAppVersion != "" {
+			if _, err := ParseAppVersion(flag.MinAppVersion); err != nil {
+				return fmt.Errorf("feature flag %s: %w", flag.Key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ParseAppVersion parses a dotted numeric version ("2.4.1"), ignoring any "-beta"/"+build" suffix
+func ParseAppVersion(version string) ([]int, error) {
+	core := strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(core, "-+ "); i >= 0 {
+		core = core[:i]
+	}
+	if core == "" {
+		return nil, fmt.Errorf("invalid app version %q", version)
+	}
+	parts := strings.Split(core, ".")
+	numbers := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid app version %q", version)
+		}
+		numbers[i] = n
+	}
+	return numbers, nil
+}
+
+// CompareAppVersions returns -1, 0 or 1 as a is older than, equal to or newer than b
+// Missing components count as 0 ("2.4" == "2.4.0"); unparseable versions sort oldest
+func CompareAppVersions(a, b string) int {
+	va, errA := ParseAppVersion(a)
+	vb, errB := ParseAppVersion(b)
+	switch {
+	case errA != nil && errB != nil:
+		return 0
+	case errA != nil:
+		return -1
+	case errB != nil:
+		return 1
+	}
+	for i := 0; i < len(va) || i < len(vb); i++ {
+		var x, y int
+		if i < len(va) {
+			x = va[i]
+		}
+		if i < len(vb) {
+			y = vb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// IsEnabledFor reports whether the flag is on for a client (appVersion "" = unknown, treated as too old
+// for flags with a minimum version)
+func (f FeatureFlag) IsEnabledFor(role, organization, appVersion string) bool {
+	if !f.Enabled {
+		return false
+	}
+	if len(f.Roles) > 0 && !containsFold(f.Roles, role) {
+		return false
+	}
+	if len(f.Organizations) > 0 && !containsFold(f.Organizations, organization) {
+		return false
+	}
+	if f.MinAppVersion != "" && (appVersion == "" || CompareAppVersions(appVersion, f.MinAppVersion) < 0) {
+		return false
+	}
+	return true
+}
+
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientEnvironment describes the server the app is talking to
+type ClientEnvironment struct {
+	Name         string `json:"name"` // APP_ENV (development when unset)
+	Organization string `json:"organization"`
+	ServerTime   int64  `json:"server_time"`
+}
+
+// ClientConfigResponse is GET /api/config/client: the config evaluated for the calling user and app version
+type ClientConfigResponse struct {
+	AppVersion       *string           `json:"app_version,omitempty"` // As reported by the app
+	MinAppVersion    string            `json:"min_app_version"`
+	LatestAppVersion string            `json:"latest_app_version"`
+	ForceUpgrade     bool              `json:"force_upgrade"`     // The app is older than min_app_version
+	UpgradeAvailable bool              `json:"upgrade_available"` // The app is older than latest_app_version
+	UpgradeMessage   string            `json:"upgrade_message,omitempty"`
+	FeatureFlags     map[string]bool   `json:"feature_flags"`
+	Environment      ClientEnvironment `json:"environment"`
+	CheckForm        CheckForm         `json:"check_form"`      // Extra fields drivers fill in with each check
+	ShiftDebrief     DebriefSettings   `json:"shift_debrief"`   // End-of-shift debrief questions
+	FillThresholds   FillThresholds    `json:"fill_thresholds"` // Fill % for warning and critical bin badges
+}
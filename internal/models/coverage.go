@@ -0,0 +1,67 @@
+package models
+
+// Coverage gaps a bin can have over the report's date range
+const (
+	CoverageGapChecks = "checks" // No checks recorded
+	CoverageGapRoutes = "routes" // Not a stop on any shift
+	CoverageGapVisits = "visits" // Never completed as a stop
+)
+
+// CoverageGaps lists every gap, in display order
+var CoverageGaps = []string{CoverageGapChecks, CoverageGapRoutes, CoverageGapVisits}
+
+// CoverageBin is an active bin with at least one coverage gap in the report's date range
+type CoverageBin struct {
+	ID              string   `json:"id" db:"id"`
+	BinNumber       int      `json:"bin_number" db:"bin_number"`
+	CurrentStreet   string   `json:"current_street" db:"current_street"`
+	City            string   `json:"city" db:"city"`
+	Zip             string   `json:"zip" db:"zip"`
+	Latitude        *float64 `json:"latitude,omitempty" db:"latitude"`
+	Longitude       *float64 `json:"longitude,omitempty" db:"longitude"`
+	AreaID          *string  `json:"area_id,omitempty" db:"area_id"`
+	AreaName        *string  `json:"-" db:"area_name"`
+	Checks          int      `json:"checks" db:"checks"`                     // In the date range
+	RouteStops      int      `json:"route_stops" db:"route_stops"`           // Shift stops created in the date range
+	CompletedVisits int      `json:"completed_visits" db:"completed_visits"` // Stops completed in the date range
+	BlueprintRoutes int      `json:"blueprint_routes" db:"blueprint_routes"` // Route blueprints the bin is on today
+	LastCheckedAt   *int64   `json:"last_checked_at,omitempty" db:"last_checked_at"`
+	LastVisitedAt   *int64   `json:"last_visited_at,omitempty" db:"last_visited_at"`
+	Gaps            []string `json:"gaps" db:"-"` // Subset of CoverageGaps
+}
+
+// CoverageArea groups the report's bins by the area they're assigned to
+type CoverageArea struct {
+	AreaID   *string       `json:"area_id,omitempty"` // Nil for bins outside every area
+	AreaName string        `json:"area_name"`
+	Bins     []CoverageBin `json:"bins"` // By bin number
+}
+
+// CoverageReport is the response of GET /api/analytics/coverage
+type CoverageReport struct {
+	From        int64          `json:"from"`
+	To          int64          `json:"to"`
+	Gap         string         `json:"gap"`         // The gap bins were filtered by, or "any"
+	ActiveBins  int            `json:"active_bins"` // Active bins considered
+	BinsWithGap int            `json:"bins_with_gap"`
+	GapCounts   map[string]int `json:"gap_counts"` // Bins per gap (a bin can have several)
+	Areas       []CoverageArea `json:"areas"`      // Largest gap first
+}
+
+// CoverageDraftRouteRequest is the body of POST /api/manager/analytics/coverage/draft-route
+// Without bin_ids, every bin in the coverage report for from/to/gap/area_id is added
+type CoverageDraftRouteRequest struct {
+	Name   string   `json:"name"` // Defaults to "Coverage gaps <from> – <to>"
+	From   string   `json:"from"` // Unix timestamp or YYYY-MM-DD
+	To     string   `json:"to"`
+	Gap    string   `json:"gap"` // checks, routes, visits or any (default)
+	AreaID string   `json:"area_id"`
+	BinIDs []string `json:"bin_ids"`
+}
+
+// CoverageDraftRouteResult is the response of POST /api/manager/analytics/coverage/draft-route
+type CoverageDraftRouteResult struct {
+	Route              Route                   `json:"route"`
+	BinCount           int                     `json:"bin_count"`
+	MissingCoordinates []BinMissingCoordinates `json:"missing_coordinates"` // Left off the route (can't be routed)
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// maxRetentionDays caps every retention period at ten years
+const maxRetentionDays = 3650
+
+// RetentionSettings controls how long personal and operational data is kept before the purger deletes it
+// A period of 0 keeps that data forever
+type RetentionSettings struct {
+	DriverLocationDays int `json:"driver_location_days"` // GPS breadcrumbs (driver_locations)
+	DiagnosticLogDays  int `json:"diagnostic_log_days"`  // Mobile diagnostic log uploads
+	CheckDays          int `json:"check_days"`           // Bin checks (each bin's latest check is always kept)
+	APIKeyUsageDays    int `json:"api_key_usage_days"`   // Per-minute API key usage counters
+
+	// Fill history isn't deleted but downsampled: raw readings become hourly averages after FillHistoryRawDays,
+	// hourly averages become daily ones after FillHistoryHourlyDays (0 keeps that resolution forever)
+	FillHistoryRawDays    int `json:"fill_history_raw_days"`
+	FillHistoryHourlyDays int `json:"fill_history_hourly_days"`
+}
+
+// DefaultRetentionSettings returns the built-in retention periods used when none are stored
+func DefaultRetentionSettings() RetentionSettings {
+	return RetentionSettings{
+		DriverLocationDays: 90,
+		DiagnosticLogDays:  14,
+		CheckDays:          0,
+		APIKeyUsageDays:    90,
+
+		FillHistoryRawDays:    7,
+		FillHistoryHourlyDays: 90,
+	}
+}
+
+// Validate checks every period is between 0 (keep forever) and ten years, and that fill history is rolled up
+// into daily averages only after it has been hourly
+func (s RetentionSettings) Validate() error {
+	periods := map[string]int{
+		"driver_location_days": s.DriverLocationDays,
+		"diagnostic_log_days":  s.DiagnosticLogDays,
+		"check_days":           s.CheckDays,
+		"api_key_usage_days":   s.APIKeyUsageDays,
+
+		"fill_history_raw_days":    s.FillHistoryRawDays,
+		"fill_history_hourly_days": s.FillHistoryHourlyDays,
+	}
+	for name, days := range periods {
+		if days < 0 || days > maxRetentionDays {
+			return fmt.Errorf("%s must be between 0 and %d", name, maxRetentionDays)
+		}
+	}
+	if s.FillHistoryHourlyDays > 0 && (s.FillHistoryRawDays == 0 || s.FillHistoryHourlyDays <= s.FillHistoryRawDays) {
+		return fmt.Errorf("fill_history_hourly_days must be longer than fill_history_raw_days (and needs it set)")
+	}
+	return nil
+}
+
+// RetentionPurge counts the rows removed by one run of the retention purger
+type RetentionPurge struct {
+	DriverLocations int64 `json:"driver_locations"`
+	DiagnosticLogs  int64 `json:"diagnostic_logs"`
+	Checks          int64 `json:"checks"`
+	APIKeyUsage     int64 `json:"api_key_usage"`
+	FillHistory     int64 `json:"fill_history"` // Hourly and daily fill history points written by rollups
+}
+
+// UserDataExport is the archive returned by GET /api/manager/users/{id}/data-export
+// Each section of Data is a JSON array of the user's rows from one table
+type UserDataExport struct {
+	User       UserResponse               `json:"user"`
+	ExportedAt int64                      `json:"exported_at"`
+	Data       map[string]json.RawMessage `json:"data"`
+}
+
+// UserAnonymization summarizes POST /api/manager/users/{id}/anonymize
+// Shifts, checks and move history stay for aggregate reporting, attributed to the anonymized user
+type UserAnonymization struct {
+	User         UserResponse `json:"user"`
+	DeletedRows  int64        `json:"deleted_rows"`  // GPS breadcrumbs, zone visits, devices and saved views removed
+	ScrubbedRows int64        `json:"scrubbed_rows"` // Rows kept with copies of the name, email or IP cleared
+}
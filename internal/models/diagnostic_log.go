@@ -0,0 +1,24 @@
+package models
+
+// Diagnostic log levels (anything else is stored as INFO)
+const (
+	DiagnosticLevelDebug   = "DEBUG"
+	DiagnosticLevelInfo    = "INFO"
+	DiagnosticLevelWarning = "WARNING"
+	DiagnosticLevelError   = "ERROR"
+)
+
+// DiagnosticLogEntry is a stored mobile diagnostic log (POST /api/logs/diagnostic)
+type DiagnosticLogEntry struct {
+	ID              int64   `json:"id" db:"id"`
+	DeviceID        string  `json:"device_id" db:"device_id"` // X-Device-ID / device_id, or the caller's IP when missing
+	Platform        *string `json:"platform,omitempty" db:"platform"`
+	AppVersion      *string `json:"app_version,omitempty" db:"app_version"`
+	Category        string  `json:"category" db:"category"`
+	Level           string  `json:"level" db:"level"`
+	Context         *string `json:"context,omitempty" db:"context"`
+	Message         string  `json:"message" db:"message"`
+	ClientTimestamp *string `json:"client_timestamp,omitempty" db:"client_timestamp"`
+	IPAddress       *string `json:"ip_address,omitempty" db:"ip_address"`
+	CreatedAt       int64   `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// DigestSettings controls the daily bins-at-risk digest sent to admins and managers
+type DigestSettings struct {
+	Enabled       bool   `json:"enabled"`
+	SendAt        string `json:"send_at"`        // Local "HH:MM" the digest is compiled and sent
+	Timezone      string `json:"timezone"`       // IANA timezone for send_at and the digest date
+	UncheckedDays int    `json:"unchecked_days"` // Bins not checked for longer than this are listed as unchecked
+}
+
+// DefaultDigestSettings returns the built-in digest settings used when none are stored
+func DefaultDigestSettings() DigestSettings {
+	return DigestSettings{
+		Enabled:       true,
+		SendAt:        "07:00",
+		Timezone:      "America/Los_Angeles",
+		UncheckedDays: 14,
+	}
+}
+
+// Validate checks the send time, timezone and thresholds
+func (s DigestSettings) Validate() error {
+	if _, err := ParseTimeOfDay(s.SendAt); err != nil {
+		return fmt.Errorf("send_at: %w", err)
+	}
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return fmt.Errorf("timezone %q is not a valid IANA timezone", s.Timezone)
+	}
+	if s.UncheckedDays < 1 {
+		return fmt.Errorf("unchecked_days must be at least 1")
+	}
+	return nil
+}
+
+// HighSeverityIncidentLevels are the incident type severities listed in the digest while open
+var HighSeverityIncidentLevels = []string{IncidentSeverityHigh, IncidentSeverityCritical}
+
+// DigestBin is a bin listed in the digest
+type DigestBin struct {
+	BinID          string `json:"bin_id" db:"bin_id"`
+	BinNumber      int    `json:"bin_number" db:"bin_number"`
+	CurrentStreet  string `json:"current_street" db:"current_street"`
+	City           string `json:"city" db:"city"`
+	FillPercentage *int   `json:"fill_percentage,omitempty" db:"fill_percentage"`
+	LastCheckedAt  *int64 `json:"last_checked_at,omitempty" db:"last_checked_at"`
+	DaysUnchecked  int    `json:"days_unchecked" db:"days_unchecked"` // Since the last check, or since creation if never checked
+}
+
+// DigestMove is an open move request past its completion deadline (see MoveSLAPolicy.CompleteDeadline)
+type DigestMove struct {
+	MoveRequestID  string  `json:"move_request_id" db:"move_request_id"`
+	BinID          string  `json:"bin_id" db:"bin_id"`
+	BinNumber      int     `json:"bin_number" db:"bin_number"`
+	Urgency        string  `json:"urgency" db:"urgency"`
+	Status         string  `json:"status" db:"status"`
+	ScheduledDate  int64   `json:"scheduled_date" db:"scheduled_date"`
+	Deadline       int64   `json:"deadline" db:"deadline"`
+	AssignedUserID *string `json:"assigned_user_id,omitempty" db:"assigned_user_id"`
+}
+
+// DigestIncident is an open high-severity incident
+type DigestIncident struct {
+	IncidentID   string  `json:"incident_id" db:"incident_id"`
+	ZoneID       string  `json:"zone_id" db:"zone_id"`
+	BinID        string  `json:"bin_id" db:"bin_id"`
+	BinNumber    *int    `json:"bin_number,omitempty" db:"bin_number"`
+	IncidentType string  `json:"incident_type" db:"incident_type"`
+	Status       string  `json:"status" db:"status"`
+	Description  *string `json:"description,omitempty" db:"description"`
+	ReportedAt   int64   `json:"reported_at" db:"reported_at"`
+}
+
+// BinsAtRiskDigest is one day's digest, as stored and returned by GET /api/manager/digest
+type BinsAtRiskDigest struct {
+	ID                    string           `json:"id"`
+	DigestDate            string           `json:"digest_date"` // YYYY-MM-DD in the digest timezone
+	GeneratedAt           int64            `json:"generated_at"`
+	FillThreshold         int              `json:"fill_threshold"` // Critical fill threshold: bins at or above it are overfilled
+	UncheckedDays         int              `json:"unchecked_days"`
+	OverfilledBins        []DigestBin      `json:"overfilled_bins"`
+	UncheckedBins         []DigestBin      `json:"unchecked_bins"`
+	OverdueMoveRequests   []DigestMove     `json:"overdue_move_requests"`
+	HighSeverityIncidents []DigestIncident `json:"high_severity_incidents"`
+}
+
+// Summary is the one-line push notification text for the digest
+func (d BinsAtRiskDigest) Summary() string {
+	return fmt.Sprintf("%d overfilled, %d unchecked >%dd, %d overdue moves, %d open high-severity incidents",
+		len(d.OverfilledBins), len(d.UncheckedBins), d.UncheckedDays, len(d.OverdueMoveRequests), len(d.HighSeverityIncidents))
+}
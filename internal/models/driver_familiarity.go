@@ -0,0 +1,64 @@
+package models
+
+// DriverRouteFamiliarity is a route blueprint a driver has run (ended shifts in shift_history)
+type DriverRouteFamiliarity struct {
+	RouteID           string   `json:"route_id" db:"route_id"`
+	RouteName         *string  `json:"route_name,omitempty" db:"route_name"`
+	Runs              int      `json:"runs" db:"runs"`
+	LastRunAt         int64    `json:"last_run_at" db:"last_run_at"`
+	AvgCompletionRate *float64 `json:"avg_completion_rate,omitempty" db:"avg_completion_rate"`
+}
+
+// DriverAreaFamiliarity is an area where a driver has completed stops
+type DriverAreaFamiliarity struct {
+	AreaID         string `json:"area_id" db:"area_id"`
+	AreaName       string `json:"area_name" db:"area_name"`
+	CompletedStops int    `json:"completed_stops" db:"completed_stops"`
+	LastStopAt     int64  `json:"last_stop_at" db:"last_stop_at"`
+}
+
+// DriverFamiliarity lists the routes and areas a driver knows
+type DriverFamiliarity struct {
+	DriverID   string                   `json:"driver_id"`
+	DriverName string                   `json:"driver_name"`
+	Since      int64                    `json:"since"` // Only history after this is counted
+	Routes     []DriverRouteFamiliarity `json:"routes"`
+	Areas      []DriverAreaFamiliarity  `json:"areas"`
+}
+
+// RouteDriverRecommendation ranks a driver for a route assignment
+// Score is the weighted sum of the 0-1 familiarity, proximity and workload scores
+type RouteDriverRecommendation struct {
+	DriverID   string  `json:"driver_id"`
+	DriverName string  `json:"driver_name"`
+	Score      float64 `json:"score"`
+
+	// Familiarity: runs of this route (recent runs count more), share of the route's bins visited, stops in its areas
+	FamiliarityScore float64 `json:"familiarity_score"`
+	RouteRuns        int     `json:"route_runs"`
+	LastRouteRunAt   *int64  `json:"last_route_run_at,omitempty"`
+	RouteBinsVisited int     `json:"route_bins_visited"`
+	AreaStops        int     `json:"area_stops"`
+
+	// Proximity: distance from the driver's last known location to the middle of the route (0 when unknown)
+	ProximityScore    float64  `json:"proximity_score"`
+	DistanceKm        *float64 `json:"distance_km,omitempty"`
+	LocationUpdatedAt *int64   `json:"location_updated_at,omitempty"`
+
+	// Workload: stops left on the driver's ready, active and paused shifts
+	WorkloadScore  float64 `json:"workload_score"`
+	OpenShifts     int     `json:"open_shifts"`
+	RemainingStops int     `json:"remaining_stops"`
+	OnShift        bool    `json:"on_shift"` // Has an active or paused shift
+}
+
+// RouteDriverRecommendations is the response of GET /api/manager/assign-route/recommendations
+type RouteDriverRecommendations struct {
+	RouteID         string                      `json:"route_id"`
+	RouteName       string                      `json:"route_name"`
+	RouteBinCount   int                         `json:"route_bin_count"`
+	CenterLatitude  *float64                    `json:"center_latitude,omitempty"`
+	CenterLongitude *float64                    `json:"center_longitude,omitempty"`
+	Weights         map[string]float64          `json:"weights"`
+	Drivers         []RouteDriverRecommendation `json:"drivers"`
+}
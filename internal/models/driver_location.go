@@ -25,3 +25,47 @@ type DriverStatus struct {
 	TotalBins    int             `json:"total_bins,omitempty"`
 	LastLocation *DriverLocation `json:"last_location,omitempty"`
 }
+
+// FleetStop is the stop a driver is heading to on the live fleet map
+type FleetStop struct {
+	TaskID        string   `json:"task_id" db:"task_id"`
+	ShiftID       string   `json:"-" db:"shift_id"`
+	StopType      string   `json:"stop_type" db:"stop_type"`
+	SequenceOrder int      `json:"sequence_order" db:"sequence_order"`
+	BinID         *string  `json:"bin_id,omitempty" db:"bin_id"`
+	BinNumber     *int     `json:"bin_number,omitempty" db:"bin_number"`
+	Address       *string  `json:"address,omitempty" db:"address"`
+	Latitude      float64  `json:"latitude" db:"latitude"`
+	Longitude     float64  `json:"longitude" db:"longitude"`
+	DistanceKm    *float64 `json:"distance_km,omitempty" db:"-"`
+	EtaSeconds    *int     `json:"eta_seconds,omitempty" db:"-"` // Straight-line estimate from the driver's last position
+}
+
+// FleetDriver is one connected driver on the live fleet map (GET /api/manager/fleet/live)
+type FleetDriver struct {
+	DriverID         string     `json:"driver_id" db:"driver_id"`
+	DriverName       string     `json:"driver_name" db:"driver_name"`
+	ShiftID          *string    `json:"shift_id,omitempty" db:"shift_id"`
+	ShiftStatus      *string    `json:"shift_status,omitempty" db:"shift_status"`
+	TotalBins        *int       `json:"total_bins,omitempty" db:"total_bins"`
+	CompletedBins    *int       `json:"completed_bins,omitempty" db:"completed_bins"`
+	Latitude         *float64   `json:"latitude,omitempty" db:"latitude"`
+	Longitude        *float64   `json:"longitude,omitempty" db:"longitude"`
+	Heading          *float64   `json:"heading,omitempty" db:"heading"`
+	Speed            *float64   `json:"speed,omitempty" db:"speed"` // m/s
+	Accuracy         *float64   `json:"accuracy,omitempty" db:"accuracy"`
+	LocationAt       *int64     `json:"location_at,omitempty" db:"location_at"` // Last stored position (seconds)
+	LastPingAt       *int64     `json:"last_ping_at,omitempty" db:"-"`          // Last location update, including unmoved pings
+	SecondsSincePing *int64     `json:"seconds_since_ping,omitempty" db:"-"`
+	Stale            bool       `json:"stale" db:"-"`
+	CurrentStop      *FleetStop `json:"current_stop,omitempty" db:"-"`
+}
+
+// FleetSnapshot is the live fleet map payload
+type FleetSnapshot struct {
+	GeneratedAt       int64         `json:"generated_at"`
+	StaleAfterSeconds int           `json:"stale_after_seconds"`
+	Connected         int           `json:"connected"`
+	Stale             int           `json:"stale"`
+	Drivers           []FleetDriver `json:"drivers"`
+}
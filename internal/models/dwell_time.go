@@ -0,0 +1,22 @@
+package models
+
+// DwellTimeStats is stop dwell time for one driver and task type
+// Dwell is measured from GPS arrival to departure (route_tasks.arrived_at/departed_at)
+type DwellTimeStats struct {
+	DriverID                string   `json:"driver_id" db:"driver_id"`
+	DriverName              string   `json:"driver_name" db:"driver_name"`
+	TaskType                string   `json:"task_type" db:"task_type"`
+	StopsCompleted          int      `json:"stops_completed" db:"stops_completed"`
+	StopsArrived            int      `json:"stops_arrived" db:"stops_arrived"`         // Completed stops with a detected arrival
+	AvgDwellSeconds         *float64 `json:"avg_dwell_seconds" db:"avg_dwell_seconds"` // Over stops with both arrival and departure (null when there are none)
+	MaxDwellSeconds         *int64   `json:"max_dwell_seconds" db:"max_dwell_seconds"`
+	CompletedAfterDeparture int      `json:"completed_after_departure" db:"completed_after_departure"` // Marked done after driving away (e.g. from the parking lot)
+	CompletedWithoutArrival int      `json:"completed_without_arrival" db:"completed_without_arrival"` // Marked done without ever coming within the arrival radius
+}
+
+// DwellTimeReport summarizes dwell times for stops completed in a period
+type DwellTimeReport struct {
+	From  int64            `json:"from"`
+	To    int64            `json:"to"`
+	Stats []DwellTimeStats `json:"stats"`
+}
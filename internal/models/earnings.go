@@ -0,0 +1,171 @@
+package models
+
+import (
+	"fmt"
+	"math"
+)
+
+// EarningsRates holds the per-stop credits and bonuses used to calculate driver earnings
+// Credits are computed when a shift ends and stored in shift_history
+type EarningsRates struct {
+	// Flat credit for every ended shift
+	ShiftBaseCredit float64 `json:"shift_base_credit"`
+
+	// Per completed stop
+	CollectionCredit float64 `json:"collection_credit"`
+	PlacementCredit  float64 `json:"placement_credit"`
+	PickupCredit     float64 `json:"pickup_credit"`
+	DropoffCredit    float64 `json:"dropoff_credit"`
+
+	// Bonuses
+	HighFillThreshold   int     `json:"high_fill_threshold"`   // Collections at or above this fill % earn HighFillBonus
+	HighFillBonus       float64 `json:"high_fill_bonus"`       // Per high-fill collection
+	UrgentMoveBonus     float64 `json:"urgent_move_bonus"`     // Per urgent move request picked up
+	FullCompletionBonus float64 `json:"full_completion_bonus"` // When every stop of the shift was completed
+}
+
+// DefaultEarningsRates returns the built-in rates used when no settings are stored
+func DefaultEarningsRates() EarningsRates {
+	return EarningsRates{
+		ShiftBaseCredit: 0,
+
+		CollectionCredit: 2.50,
+		PlacementCredit:  5.00,
+		PickupCredit:     4.00,
+		DropoffCredit:    4.00,
+
+		HighFillThreshold:   80,
+		HighFillBonus:       1.00,
+		UrgentMoveBonus:     5.00,
+		FullCompletionBonus: 10.00,
+	}
+}
+
+// Validate checks that credits are non-negative and the fill threshold is a percentage
+func (er EarningsRates) Validate() error {
+	credits := map[string]float64{
+		"shift_base_credit":     er.ShiftBaseCredit,
+		"collection_credit":     er.CollectionCredit,
+		"placement_credit":      er.PlacementCredit,
+		"pickup_credit":         er.PickupCredit,
+		"dropoff_credit":        er.DropoffCredit,
+		"high_fill_bonus":       er.HighFillBonus,
+		"urgent_move_bonus":     er.UrgentMoveBonus,
+		"full_completion_bonus": er.FullCompletionBonus,
+	}
+	for name, credit := range credits {
+		if credit < 0 {
+			return fmt.Errorf("%s must not be negative", name)
+		}
+	}
+
+	if er.HighFillThreshold < 0 || er.HighFillThreshold > 100 {
+		return fmt.Errorf("high_fill_threshold must be between 0 and 100")
+	}
+
+	return nil
+}
+
+// EarningsStop is a completed shift stop as seen by the earnings calculation
+type EarningsStop struct {
+	StopType       string `db:"stop_type"`       // collection, placement, pickup, dropoff
+	FillPercentage *int   `db:"fill_percentage"` // Fill recorded at completion (collections)
+	Urgent         bool   `db:"urgent"`          // Stop belongs to an urgent move request
+}
+
+// ShiftEarnings is the credit breakdown for one shift (stored as shift_history.earnings_breakdown)
+type ShiftEarnings struct {
+	BaseCredit float64 `json:"base_credit"`
+
+	Collections       int     `json:"collections"`
+	CollectionCredits float64 `json:"collection_credits"`
+	Placements        int     `json:"placements"`
+	PlacementCredits  float64 `json:"placement_credits"`
+	Pickups           int     `json:"pickups"`
+	PickupCredits     float64 `json:"pickup_credits"`
+	Dropoffs          int     `json:"dropoffs"`
+	DropoffCredits    float64 `json:"dropoff_credits"`
+
+	HighFillBins    int     `json:"high_fill_bins"`
+	HighFillBonus   float64 `json:"high_fill_bonus"`
+	UrgentMoves     int     `json:"urgent_moves"`
+	UrgentMoveBonus float64 `json:"urgent_move_bonus"`
+	CompletionBonus float64 `json:"completion_bonus"`
+
+	Total float64 `json:"total"`
+}
+
+// Calculate credits a shift's completed stops
+// allCompleted grants the full completion bonus
+func (er EarningsRates) Calculate(stops []EarningsStop, allCompleted bool) ShiftEarnings {
+	earnings := ShiftEarnings{BaseCredit: er.ShiftBaseCredit}
+
+	for _, stop := range stops {
+		switch stop.StopType {
+		case "pickup":
+			earnings.Pickups++
+			// Urgent bonus is paid once per move, at pickup
+			if stop.Urgent {
+				earnings.UrgentMoves++
+			}
+		case "dropoff":
+			earnings.Dropoffs++
+		case "placement":
+			earnings.Placements++
+		default:
+			earnings.Collections++
+			if stop.FillPercentage != nil && *stop.FillPercentage >= er.HighFillThreshold {
+				earnings.HighFillBins++
+			}
+		}
+	}
+
+	earnings.CollectionCredits = roundCredits(float64(earnings.Collections) * er.CollectionCredit)
+	earnings.PlacementCredits = roundCredits(float64(earnings.Placements) * er.PlacementCredit)
+	earnings.PickupCredits = roundCredits(float64(earnings.Pickups) * er.PickupCredit)
+	earnings.DropoffCredits = roundCredits(float64(earnings.Dropoffs) * er.DropoffCredit)
+	earnings.HighFillBonus = roundCredits(float64(earnings.HighFillBins) * er.HighFillBonus)
+	earnings.UrgentMoveBonus = roundCredits(float64(earnings.UrgentMoves) * er.UrgentMoveBonus)
+	if allCompleted && len(stops) > 0 {
+		earnings.CompletionBonus = er.FullCompletionBonus
+	}
+
+	earnings.Total = roundCredits(earnings.BaseCredit +
+		earnings.CollectionCredits + earnings.PlacementCredits + earnings.PickupCredits + earnings.DropoffCredits +
+		earnings.HighFillBonus + earnings.UrgentMoveBonus + earnings.CompletionBonus)
+
+	return earnings
+}
+
+// roundCredits rounds to cents
+func roundCredits(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// EarningsPeriod aggregates a driver's shift earnings over a week or month
+type EarningsPeriod struct {
+	PeriodStart   int64   `json:"period_start" db:"period_start"` // Unix seconds (UTC week/month start)
+	Shifts        int     `json:"shifts" db:"shifts"`
+	CompletedBins int     `json:"completed_bins" db:"completed_bins"`
+	Credits       float64 `json:"credits" db:"credits"`
+}
+
+// DriverEarningsResponse is returned by GET /api/driver/earnings
+type DriverEarningsResponse struct {
+	Period       string                 `json:"period"` // week or month
+	From         int64                  `json:"from"`
+	To           int64                  `json:"to"`
+	TotalShifts  int                    `json:"total_shifts"`
+	TotalBins    int                    `json:"total_bins"`
+	TotalCredits float64                `json:"total_credits"`
+	Periods      []EarningsPeriod       `json:"periods"`
+	RecentShifts []ShiftEarningsSummary `json:"recent_shifts"`
+}
+
+// ShiftEarningsSummary is one ended shift with its credits
+type ShiftEarningsSummary struct {
+	ShiftID       string  `json:"shift_id" db:"id"`
+	EndedAt       int64   `json:"ended_at" db:"ended_at"`
+	CompletedBins int     `json:"completed_bins" db:"completed_bins"`
+	Credits       float64 `json:"credits" db:"earned_credits"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import "fmt"
+
+// Bin fill levels, from the latest fill percentage and the FillThresholds
+const (
+	FillLevelNormal   = "normal"
+	FillLevelWarning  = "warning"  // At or above the warning threshold
+	FillLevelCritical = "critical" // At or above the critical threshold
+)
+
+// FillThresholds are the organization's fill percentages at which a bin is filling up (warning) and full (critical)
+// Priority scoring, the bins-at-risk digest, check recommendations and analytics all use them,
+// and bin responses carry the resulting fill_level so every app shows the same badge
+type FillThresholds struct {
+	Warning  int `json:"warning"`
+	Critical int `json:"critical"`
+}
+
+// DefaultFillThresholds returns the built-in thresholds used when none are stored
+func DefaultFillThresholds() FillThresholds {
+	return FillThresholds{
+		Warning:  60,
+		Critical: 80,
+	}
+}
+
+// Validate checks the range and that warning comes before critical
+func (t FillThresholds) Validate() error {
+	if t.Warning < 1 || t.Critical > 100 {
+		return fmt.Errorf("thresholds must be between 1 and 100")
+	}
+	if t.Warning >= t.Critical {
+		return fmt.Errorf("warning must be below critical")
+	}
+	return nil
+}
+
+// Level returns the fill level of a bin with the given fill percentage, or nil when it is unknown
+func (t FillThresholds) Level(fillPercentage *int) *string {
+	if fillPercentage == nil {
+		return nil
+	}
+	level := FillLevelNormal
+	switch {
+	case *fillPercentage >= t.Critical:
+		level = FillLevelCritical
+	case *fillPercentage >= t.Warning:
+		level = FillLevelWarning
+	}
+	return &level
+}
@@ -0,0 +1,41 @@
+package models
+
+// ImpersonationSession is a support mode session: an admin seeing the app as a driver with a
+// short-lived, read-only token (see middleware.SupportMode)
+type ImpersonationSession struct {
+	ID            string  `json:"id" db:"id"`
+	AdminID       *string `json:"admin_id,omitempty" db:"admin_id"` // Nil once the admin's account is deleted
+	AdminEmail    string  `json:"admin_email" db:"admin_email"`
+	DriverID      string  `json:"driver_id" db:"driver_id"`
+	DriverName    *string `json:"driver_name,omitempty" db:"driver_name"`
+	Reason        string  `json:"reason" db:"reason"`
+	CreatedAt     int64   `json:"created_at" db:"created_at"`
+	ExpiresAt     int64   `json:"expires_at" db:"expires_at"`
+	EndedAt       *int64  `json:"ended_at,omitempty" db:"ended_at"` // Ended early by an admin
+	EndedByUserID *string `json:"ended_by_user_id,omitempty" db:"ended_by_user_id"`
+	RequestCount  int     `json:"request_count" db:"request_count"`
+	BlockedCount  int     `json:"blocked_count" db:"blocked_count"` // Requests refused for changing something
+}
+
+// ImpersonatedRequest is one request made with a support mode token, kept for the audit trail
+type ImpersonatedRequest struct {
+	ID        int64  `json:"id" db:"id"`
+	SessionID string `json:"session_id" db:"session_id"`
+	Method    string `json:"method" db:"method"`
+	Path      string `json:"path" db:"path"`
+	Status    int    `json:"status" db:"status"`
+	Blocked   bool   `json:"blocked" db:"blocked"` // Refused because it would have changed something
+	CreatedAt int64  `json:"created_at" db:"created_at"`
+}
+
+// StartImpersonationRequest is the body of POST /api/manager/users/{id}/impersonate
+type StartImpersonationRequest struct {
+	Reason          string `json:"reason"`           // Required; shown in the audit log
+	DurationMinutes int    `json:"duration_minutes"` // Default 30, max 120
+}
+
+// ImpersonationTokenResponse is the response of POST /api/manager/users/{id}/impersonate
+type ImpersonationTokenResponse struct {
+	Token   string               `json:"token"` // Use as the driver's bearer token; GET requests only
+	Session ImpersonationSession `json:"session"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import "fmt"
+
+// IncidentDedupSettings controls how repeat reports of an incident are detected: a new report of the same
+// type at the same bin, within the window of an open report, links to it as a repeat instead of scoring
+// the zone again (the first report's report_count counts them)
+type IncidentDedupSettings struct {
+	WindowHours int `json:"window_hours"` // 0 turns deduplication off
+}
+
+// DefaultIncidentDedupSettings returns the built-in settings used when none are stored
+func DefaultIncidentDedupSettings() IncidentDedupSettings {
+	return IncidentDedupSettings{
+		WindowHours: 168,
+	}
+}
+
+// Validate checks the window range (up to 90 days)
+func (s IncidentDedupSettings) Validate() error {
+	if s.WindowHours < 0 || s.WindowHours > 2160 {
+		return fmt.Errorf("window_hours must be between 0 and 2160")
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package models
+
+// IncidentExportRow is a zone incident flattened with its zone, bin and reporter for municipal reporting
+type IncidentExportRow struct {
+	ID                  string   `db:"id"`
+	ReportedAt          int64    `db:"reported_at"`
+	IncidentType        string   `db:"incident_type"`
+	Status              string   `db:"status"`
+	Description         *string  `db:"description"`
+	PhotoURL            *string  `db:"photo_url"`
+	IsFieldObservation  bool     `db:"is_field_observation"`
+	VerifiedAt          *int64   `db:"verified_at"`
+	VerifiedByName      *string  `db:"verified_by_name"`
+	ZoneID              string   `db:"zone_id"`
+	ZoneName            string   `db:"zone_name"`
+	ZoneStatus          string   `db:"zone_status"`
+	ZoneConflictScore   int      `db:"zone_conflict_score"`
+	ZoneCenterLatitude  float64  `db:"zone_center_latitude"`
+	ZoneCenterLongitude float64  `db:"zone_center_longitude"`
+	ZoneRadiusMeters    int      `db:"zone_radius_meters"`
+	BinID               string   `db:"bin_id"`
+	BinNumber           *int     `db:"bin_number"`
+	BinStreet           *string  `db:"bin_street"`
+	BinCity             *string  `db:"bin_city"`
+	BinZip              *string  `db:"bin_zip"`
+	BinLatitude         *float64 `db:"bin_latitude"`
+	BinLongitude        *float64 `db:"bin_longitude"`
+	ReporterID          *string  `db:"reporter_id"`
+	ReporterName        *string  `db:"reporter_name"`
+	ReporterEmail       *string  `db:"reporter_email"`
+	ReporterLatitude    *float64 `db:"reporter_latitude"`
+	ReporterLongitude   *float64 `db:"reporter_longitude"`
+	ShiftID             *string  `db:"shift_id"`
+	CheckID             *int     `db:"check_id"`
+}
+
+// IncidentImportRowError explains why a spreadsheet row was not imported
+type IncidentImportRowError struct {
+	Row     int    `json:"row"` // Spreadsheet row number (the header is row 1)
+	Message string `json:"message"`
+}
+
+// IncidentImportResult summarizes an incident import
+type IncidentImportResult struct {
+	DryRun       bool                     `json:"dry_run"`
+	TotalRows    int                      `json:"total_rows"`
+	Imported     int                      `json:"imported"` // Would be imported when dry_run is true
+	Duplicates   []IncidentImportRowError `json:"duplicates"`
+	Errors       []IncidentImportRowError `json:"errors"`
+	ZonesCreated int                      `json:"zones_created"`
+	IncidentIDs  []string                 `json:"incident_ids"`
+}
@@ -0,0 +1,83 @@
+package models
+
+// Labels an incident photo can be tagged with; anything else a vision provider returns is dropped
+const (
+	IncidentPhotoLabelGraffiti    = "graffiti"
+	IncidentPhotoLabelOverflowing = "overflowing"
+	IncidentPhotoLabelDamage      = "damage"
+)
+
+// IncidentPhotoLabels lists every label, in display order
+var IncidentPhotoLabels = []string{IncidentPhotoLabelGraffiti, IncidentPhotoLabelOverflowing, IncidentPhotoLabelDamage}
+
+// IsValidIncidentPhotoLabel reports whether label is one of IncidentPhotoLabels
+func IsValidIncidentPhotoLabel(label string) bool {
+	for _, l := range IncidentPhotoLabels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+// Statuses of an incident photo tagging job
+const (
+	IncidentPhotoPending = "pending" // Waiting for (or between) classification attempts
+	IncidentPhotoTagged  = "tagged"
+	IncidentPhotoFailed  = "failed" // Gave up after the maximum number of attempts
+)
+
+// IncidentPhotoJob is an incident's row in incident_photo_jobs: its photo queued for classification
+// A new photo on the same incident re-queues it (see helpers.EnqueueIncidentPhoto)
+type IncidentPhotoJob struct {
+	IncidentID    string  `json:"incident_id" db:"incident_id"`
+	PhotoURL      string  `json:"photo_url" db:"photo_url"`
+	Status        string  `json:"status" db:"status"`
+	Attempts      int     `json:"attempts" db:"attempts"`
+	NextAttemptAt int64   `json:"next_attempt_at" db:"next_attempt_at"`
+	Provider      *string `json:"provider,omitempty" db:"provider"`
+	LastError     *string `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt     int64   `json:"created_at" db:"created_at"`
+	UpdatedAt     int64   `json:"updated_at" db:"updated_at"`
+	TaggedAt      *int64  `json:"tagged_at,omitempty" db:"tagged_at"`
+}
+
+// IncidentPhotoLabel is one label detected on an incident's photo
+type IncidentPhotoLabel struct {
+	Label      string  `json:"label" db:"label"`
+	Confidence float64 `json:"confidence" db:"confidence"` // 0-1
+}
+
+// IncidentPhotoTagResult summarizes a single tagging run
+type IncidentPhotoTagResult struct {
+	Attempted int   `json:"attempted"`
+	Tagged    int   `json:"tagged"`
+	Retrying  int   `json:"retrying"`
+	Failed    int   `json:"failed"` // Gave up (max attempts reached)
+	RanAt     int64 `json:"ran_at"`
+}
+
+// TaggedIncident is an incident in GET /api/manager/incidents, with what was detected on its photo
+type TaggedIncident struct {
+	ID                 string               `json:"id" db:"id"`
+	ZoneID             string               `json:"zone_id" db:"zone_id"`
+	ZoneName           *string              `json:"zone_name,omitempty" db:"zone_name"`
+	BinID              string               `json:"bin_id" db:"bin_id"`
+	BinNumber          *int                 `json:"bin_number,omitempty" db:"bin_number"`
+	IncidentType       string               `json:"incident_type" db:"incident_type"`
+	Status             string               `json:"status" db:"status"`
+	Description        *string              `json:"description,omitempty" db:"description"`
+	PhotoURL           *string              `json:"photo_url,omitempty" db:"photo_url"`
+	ReportedByUserID   *string              `json:"reported_by_user_id,omitempty" db:"reported_by_user_id"`
+	ReportedAt         int64                `json:"reported_at" db:"reported_at"`
+	IsFieldObservation bool                 `json:"is_field_observation" db:"is_field_observation"`
+	ShiftID            *string              `json:"shift_id,omitempty" db:"shift_id"`
+	PhotoTagStatus     *string              `json:"photo_tag_status,omitempty" db:"photo_tag_status"` // Nil when the incident has no photo
+	PhotoLabels        []IncidentPhotoLabel `json:"photo_labels" db:"-"`                              // Highest confidence first
+}
+
+// TaggedIncidentsResponse is the response of GET /api/manager/incidents
+type TaggedIncidentsResponse struct {
+	Incidents []TaggedIncident `json:"incidents"`
+	Total     int              `json:"total"` // Matching incidents before limit/offset
+}
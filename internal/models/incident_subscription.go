@@ -0,0 +1,107 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Incident subscription deliveries
+const (
+	IncidentDeliveryImmediate = "immediate" // Each incident as it's reported
+	IncidentDeliveryDaily     = "daily"     // One summary a day (at the digest's send_at), meant for low-severity types
+)
+
+// IncidentSubscription routes new incidents to a manager: an incident is sent when its type, severity and bin's
+// area each match (an empty list matches everything). A manager without subscriptions gets no incident notifications
+type IncidentSubscription struct {
+	ID            string         `json:"id" db:"id"`
+	UserID        string         `json:"user_id" db:"user_id"`
+	IncidentTypes pq.StringArray `json:"incident_types" db:"incident_types"` // Incident type keys
+	Severities    pq.StringArray `json:"severities" db:"severities"`
+	AreaIDs       pq.StringArray `json:"area_ids" db:"area_ids"` // Incidents at bins without an area only match an empty list
+	WebSocket     bool           `json:"websocket" db:"websocket"`
+	Push          bool           `json:"push" db:"push"` // FCM to the manager's registered devices
+	Delivery      string         `json:"delivery" db:"delivery"`
+	CreatedAt     int64          `json:"created_at" db:"created_at"`
+	UpdatedAt     int64          `json:"updated_at" db:"updated_at"`
+}
+
+// IncidentSubscriptionRequest is the body for creating or replacing an incident subscription
+type IncidentSubscriptionRequest struct {
+	IncidentTypes []string `json:"incident_types"`
+	Severities    []string `json:"severities"`
+	AreaIDs       []string `json:"area_ids"`
+	WebSocket     *bool    `json:"websocket"` // Default true
+	Push          bool     `json:"push"`
+	Delivery      string   `json:"delivery"` // immediate (default) or daily
+}
+
+// Normalize applies the defaults, drops duplicates and checks the severities, channels and delivery
+// (incident types and areas are checked against the database by the handler)
+func (r *IncidentSubscriptionRequest) Normalize() error {
+	r.IncidentTypes = uniqueTrimmed(r.IncidentTypes)
+	r.Severities = uniqueTrimmed(r.Severities)
+	r.AreaIDs = uniqueTrimmed(r.AreaIDs)
+	for _, severity := range r.Severities {
+		valid := false
+		for _, known := range IncidentSeverities {
+			if severity == known {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return fmt.Errorf("severities must be among %s", strings.Join(IncidentSeverities, ", "))
+		}
+	}
+	if r.WebSocket == nil {
+		websocket := true
+		r.WebSocket = &websocket
+	}
+	if !*r.WebSocket && !r.Push {
+		return fmt.Errorf("enable websocket, push or both")
+	}
+	if r.Delivery == "" {
+		r.Delivery = IncidentDeliveryImmediate
+	}
+	if r.Delivery != IncidentDeliveryImmediate && r.Delivery != IncidentDeliveryDaily {
+		return fmt.Errorf("delivery must be %s or %s", IncidentDeliveryImmediate, IncidentDeliveryDaily)
+	}
+	return nil
+}
+
+// uniqueTrimmed trims the values and drops empty and repeated ones, keeping the order
+func uniqueTrimmed(values []string) []string {
+	result := []string{}
+	seen := map[string]bool{}
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value != "" && !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// IncidentSummary is the daily summary of the incidents a manager's daily subscriptions matched
+type IncidentSummary struct {
+	Date      string                `json:"date"` // Local date (digest timezone) the summary was sent
+	Total     int                   `json:"total"`
+	ByType    map[string]int        `json:"by_type"`
+	Incidents []IncidentSummaryItem `json:"incidents"` // Oldest first
+}
+
+// IncidentSummaryItem is an incident listed in a daily summary
+type IncidentSummaryItem struct {
+	IncidentID   string  `json:"incident_id" db:"incident_id"`
+	IncidentType string  `json:"incident_type" db:"incident_type"`
+	Label        string  `json:"label" db:"label"`
+	Severity     string  `json:"severity" db:"severity"`
+	BinID        string  `json:"bin_id" db:"bin_id"`
+	BinNumber    *int    `json:"bin_number,omitempty" db:"bin_number"`
+	Description  *string `json:"description,omitempty" db:"description"`
+	ReportedAt   int64   `json:"reported_at" db:"reported_at"`
+}
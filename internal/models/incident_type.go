@@ -0,0 +1,113 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Incident severities, from least to most severe
+const (
+	IncidentSeverityLow      = "low"
+	IncidentSeverityMedium   = "medium"
+	IncidentSeverityHigh     = "high"
+	IncidentSeverityCritical = "critical"
+)
+
+// IncidentSeverities are the valid IncidentType severities
+var IncidentSeverities = []string{IncidentSeverityLow, IncidentSeverityMedium, IncidentSeverityHigh, IncidentSeverityCritical}
+
+// Limits and defaults of incident types
+const (
+	IncidentTypeLabelMaxLength   = 50
+	IncidentTypeMaxZoneScore     = 100
+	IncidentTypeMinZoneRadius    = 50
+	IncidentTypeMaxZoneRadius    = 2000
+	IncidentTypeDefaultZoneScore = 5
+	IncidentTypeDefaultRadius    = 250
+)
+
+var incidentTypeKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,49}$`)
+
+// IncidentType is a managed incident category (zone_incidents.incident_type references its key)
+// Reporting an incident adds ZoneScore to the zone near the bin, or opens a zone of ZoneRadiusMeters
+// Archived (inactive) types can't be reported anymore but keep their past incidents
+type IncidentType struct {
+	Key              string  `json:"key" db:"key"` // Stored on incidents; can't be changed
+	Label            string  `json:"label" db:"label"`
+	Description      *string `json:"description,omitempty" db:"description"`
+	Severity         string  `json:"severity" db:"severity"`
+	ZoneScore        int     `json:"zone_score" db:"zone_score"` // Conflict score added to the zone per incident
+	ZoneRadiusMeters int     `json:"zone_radius_meters" db:"zone_radius_meters"`
+	Active           bool    `json:"active" db:"active"`
+	CreatedByUserID  *string `json:"created_by_user_id,omitempty" db:"created_by_user_id"`
+	CreatedAt        int64   `json:"created_at" db:"created_at"`
+	UpdatedAt        int64   `json:"updated_at" db:"updated_at"`
+}
+
+// IncidentTypeWithCount is an incident type with how many incidents have it
+type IncidentTypeWithCount struct {
+	IncidentType
+	IncidentCount int `json:"incident_count" db:"incident_count"`
+}
+
+// IncidentTypeRequest is the body for creating or updating an incident type (fields are optional on update;
+// key is only read when creating)
+type IncidentTypeRequest struct {
+	Key              *string `json:"key"`
+	Label            *string `json:"label"`
+	Description      *string `json:"description"` // "" clears it
+	Severity         *string `json:"severity"`
+	ZoneScore        *int    `json:"zone_score"`
+	ZoneRadiusMeters *int    `json:"zone_radius_meters"`
+	Active           *bool   `json:"active"`
+}
+
+// Normalize trims the fields and checks them; key and label are required when creating
+func (r *IncidentTypeRequest) Normalize(creating bool) error {
+	if creating {
+		if r.Key == nil {
+			return fmt.Errorf("key is required")
+		}
+		key := strings.TrimSpace(*r.Key)
+		r.Key = &key
+		if !incidentTypeKeyPattern.MatchString(key) {
+			return fmt.Errorf("key must be 2 to 50 lowercase letters, digits or underscores, starting with a letter")
+		}
+	}
+	if r.Label != nil {
+		label := strings.TrimSpace(*r.Label)
+		r.Label = &label
+		if label == "" {
+			return fmt.Errorf("label cannot be empty")
+		}
+		if len(label) > IncidentTypeLabelMaxLength {
+			return fmt.Errorf("label must be at most %d characters", IncidentTypeLabelMaxLength)
+		}
+	} else if creating {
+		return fmt.Errorf("label is required")
+	}
+	if r.Description != nil {
+		description := strings.TrimSpace(*r.Description)
+		r.Description = &description
+	}
+	if r.Severity != nil {
+		valid := false
+		for _, severity := range IncidentSeverities {
+			if *r.Severity == severity {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return fmt.Errorf("severity must be one of %s", strings.Join(IncidentSeverities, ", "))
+		}
+	}
+	if r.ZoneScore != nil && (*r.ZoneScore < 0 || *r.ZoneScore > IncidentTypeMaxZoneScore) {
+		return fmt.Errorf("zone_score must be between 0 and %d", IncidentTypeMaxZoneScore)
+	}
+	if r.ZoneRadiusMeters != nil && (*r.ZoneRadiusMeters < IncidentTypeMinZoneRadius || *r.ZoneRadiusMeters > IncidentTypeMaxZoneRadius) {
+		return fmt.Errorf("zone_radius_meters must be between %d and %d", IncidentTypeMinZoneRadius, IncidentTypeMaxZoneRadius)
+	}
+	return nil
+}
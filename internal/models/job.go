@@ -0,0 +1,51 @@
+package models
+
+import "encoding/json"
+
+// Background job statuses
+const (
+	JobStatusPending   = "pending"   // Waiting for run_at (first run or a retry after backoff)
+	JobStatusRunning   = "running"   // Claimed by a worker
+	JobStatusSucceeded = "succeeded" // Done; pruned after a week
+	JobStatusDead      = "dead"      // Failed max_attempts times; waits in the dead letter queue for a manual retry
+)
+
+// JobStatuses lists every job status
+var JobStatuses = []string{JobStatusPending, JobStatusRunning, JobStatusSucceeded, JobStatusDead}
+
+// Background job kinds run by services.JobRunner
+const (
+	JobKindDataRetention    = "data_retention"      // Periodic: services.DataRetentionPurger
+	JobKindBinsAtRiskDigest = "bins_at_risk_digest" // Periodic: services.BinsAtRiskDigester
+	JobKindIncidentSummary  = "incident_summary"    // Periodic: services.IncidentSummaryNotifier
+)
+
+// Job is a unit of background work with its retry state
+// A failed attempt is retried with exponential backoff until max_attempts, then the job is dead-lettered
+type Job struct {
+	ID          string          `json:"id" db:"id"`
+	Kind        string          `json:"kind" db:"kind"`
+	Payload     json.RawMessage `json:"payload" db:"payload"`
+	UniqueKey   *string         `json:"unique_key,omitempty" db:"unique_key"` // Only one pending or running job per key
+	Status      string          `json:"status" db:"status"`
+	Attempts    int             `json:"attempts" db:"attempts"`
+	MaxAttempts int             `json:"max_attempts" db:"max_attempts"`
+	LastError   *string         `json:"last_error,omitempty" db:"last_error"`
+	RunAt       int64           `json:"run_at" db:"run_at"`                 // Next attempt no earlier than this
+	LockedAt    *int64          `json:"locked_at,omitempty" db:"locked_at"` // When the running attempt started
+	FinishedAt  *int64          `json:"finished_at,omitempty" db:"finished_at"`
+	CreatedAt   int64           `json:"created_at" db:"created_at"`
+	UpdatedAt   int64           `json:"updated_at" db:"updated_at"`
+}
+
+// JobKindStats counts a job kind's jobs by status
+type JobKindStats struct {
+	Kind            string `json:"kind" db:"kind"`
+	Pending         int    `json:"pending" db:"pending"`
+	Running         int    `json:"running" db:"running"`
+	Succeeded       int    `json:"succeeded" db:"succeeded"` // In the last week (older ones are pruned)
+	Dead            int    `json:"dead" db:"dead"`
+	Retrying        int    `json:"retrying" db:"retrying"`                     // Pending after a failed attempt
+	OldestDueAt     *int64 `json:"oldest_due_at,omitempty" db:"oldest_due_at"` // Earliest run_at of the pending jobs
+	LastSucceededAt *int64 `json:"last_succeeded_at,omitempty" db:"last_succeeded_at"`
+}
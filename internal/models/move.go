@@ -20,6 +20,12 @@ type Move struct {
 	MoveRequestID     *string `json:"move_request_id" db:"move_request_id"`
 	CompletedByUserID *string `json:"completed_by_user_id" db:"completed_by_user_id"`
 	ShiftID           *string `json:"shift_id" db:"shift_id"`
+
+	// Coordinates (recorded when known, otherwise geocoded from the addresses for the location timeline)
+	FromLatitude  *float64 `json:"from_latitude,omitempty" db:"from_latitude"`
+	FromLongitude *float64 `json:"from_longitude,omitempty" db:"from_longitude"`
+	ToLatitude    *float64 `json:"to_latitude,omitempty" db:"to_latitude"`
+	ToLongitude   *float64 `json:"to_longitude,omitempty" db:"to_longitude"`
 }
 
 // MoveResponse is what we send to the client
@@ -75,3 +81,26 @@ func (m *Move) ToMoveResponse() MoveResponse {
 		ShiftID:           m.ShiftID,
 	}
 }
+
+// BinLocationSegment is a period during which a bin stayed at one location
+type BinLocationSegment struct {
+	Address         string   `json:"address"`
+	Latitude        *float64 `json:"latitude,omitempty"` // Nil when the address could not be geocoded
+	Longitude       *float64 `json:"longitude,omitempty"`
+	From            int64    `json:"from"`         // Unix timestamp the bin arrived (created_at for the first segment)
+	To              *int64   `json:"to,omitempty"` // Unix timestamp the bin left, nil for the current location
+	FromIso         string   `json:"from_iso"`
+	ToIso           *string  `json:"to_iso,omitempty"`
+	ArrivedByMoveID *int     `json:"arrived_by_move_id,omitempty"` // Move that brought the bin here (nil for the first segment)
+	MoveRequestID   *string  `json:"move_request_id,omitempty"`    // Move request behind that move, if any
+}
+
+// BinLocationHistory is the response for GET /api/bins/{id}/location-history
+type BinLocationHistory struct {
+	BinID     string               `json:"bin_id"`
+	BinNumber int                  `json:"bin_number"`
+	Segments  []BinLocationSegment `json:"segments"`     // Oldest first
+	At        *BinLocationSegment  `json:"at,omitempty"` // Segment covering ?at= when requested
+	Geocoded  int                  `json:"geocoded"`     // Move addresses geocoded (and saved) for this response
+	Unlocated int                  `json:"unlocated"`    // Segments still without coordinates
+}
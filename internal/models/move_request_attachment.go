@@ -0,0 +1,99 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"strings"
+)
+
+// Move request attachment kinds
+const (
+	MoveAttachmentPhoto    = "photo"    // Site photo (where to put the bin, access point)
+	MoveAttachmentDocument = "document" // Permit, site plan, etc.
+)
+
+// MoveInstructions tells the driver how to get to and handle a move site
+// Stored as JSON in bin_move_requests.instructions; gate codes and phone numbers are masked in the audit trail
+type MoveInstructions struct {
+	GateCode     *string `json:"gate_code,omitempty"`
+	ContactName  *string `json:"contact_name,omitempty"`
+	ContactPhone *string `json:"contact_phone,omitempty"`
+	AccessNotes  *string `json:"access_notes,omitempty"`
+}
+
+// IsEmpty reports whether no instruction is set
+func (i MoveInstructions) IsEmpty() bool {
+	for _, field := range []*string{i.GateCode, i.ContactName, i.ContactPhone, i.AccessNotes} {
+		if field != nil && strings.TrimSpace(*field) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Masked returns a copy safe for audit logs: the gate code is hidden and only the last 2 digits of the phone are kept
+func (i MoveInstructions) Masked() MoveInstructions {
+	masked := i
+	if i.GateCode != nil && *i.GateCode != "" {
+		hidden := "****"
+		masked.GateCode = &hidden
+	}
+	if i.ContactPhone != nil && *i.ContactPhone != "" {
+		phone := *i.ContactPhone
+		digits := 0
+		runes := []rune(phone)
+		for j := len(runes) - 1; j >= 0; j-- {
+			if runes[j] < '0' || runes[j] > '9' {
+				continue
+			}
+			digits++
+			if digits > 2 {
+				runes[j] = '*'
+			}
+		}
+		hidden := string(runes)
+		masked.ContactPhone = &hidden
+	}
+	return masked
+}
+
+// Scan implements the sql.Scanner interface for MoveInstructions
+func (i *MoveInstructions) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+
+	return json.Unmarshal(bytes, i)
+}
+
+// Value implements the driver.Valuer interface for MoveInstructions
+func (i MoveInstructions) Value() (driver.Value, error) {
+	bytes, err := json.Marshal(i)
+	if err != nil {
+		return nil, err
+	}
+	return string(bytes), nil
+}
+
+// MoveRequestAttachment is a photo or document a manager attached to a move request
+type MoveRequestAttachment struct {
+	ID               string  `json:"id" db:"id"`
+	MoveRequestID    string  `json:"move_request_id" db:"move_request_id"`
+	Kind             string  `json:"kind" db:"kind"`
+	URL              string  `json:"url" db:"url"`
+	Caption          *string `json:"caption,omitempty" db:"caption"`
+	UploadedByUserID *string `json:"uploaded_by_user_id,omitempty" db:"uploaded_by_user_id"`
+	CreatedAt        int64   `json:"created_at" db:"created_at"`
+}
+
+// CreateMoveRequestAttachmentRequest is the body for POST /api/manager/bins/move-requests/{id}/attachments
+type CreateMoveRequestAttachmentRequest struct {
+	Kind    string  `json:"kind" validate:"required,oneof=photo document"`
+	URL     string  `json:"url" validate:"required,format=uri"`
+	Caption *string `json:"caption" validate:"max=500"`
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Move request statuses (enforced by bin_move_requests_status_check)
+const (
+	MoveStatusRequested  = "requested" // Submitted by a non-admin, awaiting approval
+	MoveStatusRejected   = "rejected"
+	MoveStatusPending    = "pending" // Approved or scheduled, not assigned
+	MoveStatusAssigned   = "assigned"
+	MoveStatusInProgress = "in_progress" // On an active shift (or being worked manually)
+	MoveStatusPickedUp   = "picked_up"   // Relocation picked up, dropoff outstanding
+	MoveStatusCompleted  = "completed"
+	MoveStatusCancelled  = "cancelled"
+)
+
+// MoveStatuses lists every move request status
+var MoveStatuses = []string{
+	MoveStatusRequested, MoveStatusRejected, MoveStatusPending, MoveStatusAssigned,
+	MoveStatusInProgress, MoveStatusPickedUp, MoveStatusCompleted, MoveStatusCancelled,
+}
+
+// moveStatusTransitions lists the statuses a move request may move to from each status (staying put is
+// always allowed). Completed, cancelled and rejected are final; undo restores a snapshot instead of
+// transitioning. A picked-up bin can only be dropped off, released back to pending or cancelled
+var moveStatusTransitions = map[string][]string{
+	MoveStatusRequested:  {MoveStatusPending, MoveStatusRejected, MoveStatusCancelled},
+	MoveStatusRejected:   {},
+	MoveStatusPending:    {MoveStatusAssigned, MoveStatusInProgress, MoveStatusCancelled},
+	MoveStatusAssigned:   {MoveStatusPending, MoveStatusInProgress, MoveStatusPickedUp, MoveStatusCompleted, MoveStatusCancelled},
+	MoveStatusInProgress: {MoveStatusPending, MoveStatusAssigned, MoveStatusPickedUp, MoveStatusCompleted, MoveStatusCancelled},
+	MoveStatusPickedUp:   {MoveStatusPending, MoveStatusCompleted, MoveStatusCancelled},
+	MoveStatusCompleted:  {},
+	MoveStatusCancelled:  {},
+}
+
+// IsValidMoveStatus reports whether status is a move request status
+func IsValidMoveStatus(status string) bool {
+	_, ok := moveStatusTransitions[status]
+	return ok
+}
+
+// IsFinalMoveStatus reports whether a move request in the status can no longer change
+func IsFinalMoveStatus(status string) bool {
+	return status == MoveStatusCompleted || status == MoveStatusCancelled || status == MoveStatusRejected
+}
+
+// ValidateMoveStatusTransition checks a move request may move from one status to another
+func ValidateMoveStatusTransition(from, to string) error {
+	if !IsValidMoveStatus(to) {
+		return fmt.Errorf("invalid move request status %q (must be one of: %s)", to, strings.Join(MoveStatuses, ", "))
+	}
+	if from == to {
+		return nil
+	}
+	for _, allowed := range moveStatusTransitions[from] {
+		if allowed == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("a move request can't go from %s to %s", from, to)
+}
+
+// AllowedMoveStatusTransitions lists the statuses a move request in the given status may move to
+func AllowedMoveStatusTransitions(from string) []string {
+	return append([]string{}, moveStatusTransitions[from]...)
+}
+
+// AssignedMoveStatus is the status an assignment puts a pending or assigned move request in:
+// in_progress on an active shift, assigned on any other shift or a user, pending without either
+func AssignedMoveStatus(shiftID, userID, shiftStatus *string) string {
+	switch {
+	case shiftID != nil && shiftStatus != nil && *shiftStatus == "active":
+		return MoveStatusInProgress
+	case shiftID != nil || userID != nil:
+		return MoveStatusAssigned
+	default:
+		return MoveStatusPending
+	}
+}
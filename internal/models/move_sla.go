@@ -0,0 +1,102 @@
+package models
+
+import "fmt"
+
+// MoveSLAPolicy holds the assignment and completion deadlines for move requests, per urgency
+// Urgent moves are measured from when they were requested; scheduled moves from their scheduled date
+type MoveSLAPolicy struct {
+	UrgentAssignHours   float64 `json:"urgent_assign_hours"`   // Urgent moves must be assigned this long after creation
+	UrgentCompleteHours float64 `json:"urgent_complete_hours"` // Urgent moves must be completed this long after creation
+
+	ScheduledAssignLeadHours    float64 `json:"scheduled_assign_lead_hours"`    // Scheduled moves must be assigned this long before the scheduled date
+	ScheduledCompleteGraceHours float64 `json:"scheduled_complete_grace_hours"` // Scheduled moves must be completed this long after the scheduled date
+}
+
+// DefaultMoveSLAPolicy returns the built-in SLA used when no settings are stored
+func DefaultMoveSLAPolicy() MoveSLAPolicy {
+	return MoveSLAPolicy{
+		UrgentAssignHours:   2,
+		UrgentCompleteHours: 24,
+
+		ScheduledAssignLeadHours:    24,
+		ScheduledCompleteGraceHours: 24,
+	}
+}
+
+// Validate checks that every deadline is non-negative
+func (p MoveSLAPolicy) Validate() error {
+	hours := map[string]float64{
+		"urgent_assign_hours":            p.UrgentAssignHours,
+		"urgent_complete_hours":          p.UrgentCompleteHours,
+		"scheduled_assign_lead_hours":    p.ScheduledAssignLeadHours,
+		"scheduled_complete_grace_hours": p.ScheduledCompleteGraceHours,
+	}
+	for name, h := range hours {
+		if h < 0 {
+			return fmt.Errorf("%s must not be negative", name)
+		}
+	}
+	if p.UrgentAssignHours > p.UrgentCompleteHours {
+		return fmt.Errorf("urgent_assign_hours must not exceed urgent_complete_hours")
+	}
+	return nil
+}
+
+// AssignDeadline returns when a move request must be assigned by
+// A scheduled move requested inside its lead time gets the urgent assignment window instead
+func (p MoveSLAPolicy) AssignDeadline(urgency string, createdAt, scheduledDate int64) int64 {
+	urgentDeadline := createdAt + int64(p.UrgentAssignHours*3600)
+	if urgency == "urgent" {
+		return urgentDeadline
+	}
+	if deadline := scheduledDate - int64(p.ScheduledAssignLeadHours*3600); deadline > urgentDeadline {
+		return deadline
+	}
+	return urgentDeadline
+}
+
+// CompleteDeadline returns when a move request must be completed by
+func (p MoveSLAPolicy) CompleteDeadline(urgency string, createdAt, scheduledDate int64) int64 {
+	if urgency == "urgent" {
+		return createdAt + int64(p.UrgentCompleteHours*3600)
+	}
+	return scheduledDate + int64(p.ScheduledCompleteGraceHours*3600)
+}
+
+// Move SLA breach kinds
+const (
+	MoveSLABreachAssign   = "assign"   // Still unassigned after the assignment deadline
+	MoveSLABreachComplete = "complete" // Not completed by the completion deadline
+)
+
+// MoveSLABreach is a move request that passed one of its SLA deadlines
+type MoveSLABreach struct {
+	MoveRequestID string `json:"move_request_id"`
+	BinID         string `json:"bin_id"`
+	BinNumber     int    `json:"bin_number"`
+	Urgency       string `json:"urgency"`
+	Status        string `json:"status"`
+	Kind          string `json:"kind"` // assign or complete
+	Deadline      int64  `json:"deadline"`
+	BreachedAt    int64  `json:"breached_at"`
+}
+
+// MoveSLAUrgencyStats is SLA compliance for the move requests of one urgency
+type MoveSLAUrgencyStats struct {
+	Urgency          string   `json:"urgency"`
+	Total            int      `json:"total"`
+	AssignBreaches   int      `json:"assign_breaches"`
+	CompleteBreaches int      `json:"complete_breaches"`
+	Compliant        int      `json:"compliant"`       // No breach of either deadline
+	ComplianceRate   *float64 `json:"compliance_rate"` // Compliant / total (null when there are none)
+	OpenBreaches     int      `json:"open_breaches"`   // Breached and still pending or in progress
+}
+
+// MoveSLAReport summarizes SLA compliance for move requests created in a period
+type MoveSLAReport struct {
+	From      int64                 `json:"from"`
+	To        int64                 `json:"to"`
+	Policy    MoveSLAPolicy         `json:"policy"`
+	ByUrgency []MoveSLAUrgencyStats `json:"by_urgency"`
+	Overall   MoveSLAUrgencyStats   `json:"overall"`
+}
@@ -16,13 +16,17 @@ type NoGoZone struct {
 	ResolvedByUserID *string `json:"resolved_by_user_id" db:"resolved_by_user_id"`
 	ResolvedAt       *int64  `json:"resolved_at" db:"resolved_at"`
 	ResolutionNotes  *string `json:"resolution_notes" db:"resolution_notes"`
+	MergedIntoZoneID *string `json:"merged_into_zone_id" db:"merged_into_zone_id"`
+	ResolutionType   *string `json:"resolution_type" db:"resolution_type"` // merged, manual_resolution
+	AreaID           *string `json:"area_id" db:"area_id"`
+	Geog             *string `json:"-" db:"geog"` // PostGIS geography (only when enabled), for SELECT *
 }
 
 type ZoneIncident struct {
 	ID                 string   `json:"id" db:"id"`
 	ZoneID             string   `json:"zone_id" db:"zone_id"`
 	BinID              string   `json:"bin_id" db:"bin_id"`
-	IncidentType       string   `json:"incident_type" db:"incident_type"` // vandalism, landlord_complaint, theft, relocation_request
+	IncidentType       string   `json:"incident_type" db:"incident_type"` // Key of an incident_types row (theft, vandalized, ...)
 	ReportedByUserID   *string  `json:"reported_by_user_id" db:"reported_by_user_id"`
 	ReportedAt         int64    `json:"reported_at" db:"reported_at"`
 	Description        *string  `json:"description" db:"description"`
@@ -35,7 +39,9 @@ type ZoneIncident struct {
 	IsFieldObservation bool     `json:"is_field_observation" db:"is_field_observation"`
 	VerifiedByUserID   *string  `json:"verified_by_user_id" db:"verified_by_user_id"`
 	VerifiedAt         *int64   `json:"verified_at" db:"verified_at"`
-	Status             string   `json:"status" db:"status"` // open, resolved, investigating
+	Status             string   `json:"status" db:"status"`                         // open, resolved, investigating
+	ParentIncidentID   *string  `json:"parent_incident_id" db:"parent_incident_id"` // Set on repeat reports (see IncidentDedupSettings)
+	ReportCount        int      `json:"report_count" db:"report_count"`             // This report plus its repeats
 }
 
 type ZoneRiskOverride struct {
@@ -88,6 +94,8 @@ type ZoneIncidentResponse struct {
 	VerifiedByName     *string  `json:"verified_by_name,omitempty"` // Joined from users table
 	VerifiedAtIso      *string  `json:"verified_at_iso,omitempty"`
 	Status             string   `json:"status"`
+	ParentIncidentID   *string  `json:"parent_incident_id,omitempty"`
+	ReportCount        int      `json:"report_count"`
 }
 
 type ZoneRiskOverrideResponse struct {
@@ -149,6 +157,8 @@ func (i *ZoneIncident) ToResponse() ZoneIncidentResponse {
 		IsFieldObservation: i.IsFieldObservation,
 		VerifiedByUserID:   i.VerifiedByUserID,
 		Status:             i.Status,
+		ParentIncidentID:   i.ParentIncidentID,
+		ReportCount:        i.ReportCount,
 	}
 
 	if i.VerifiedAt != nil {
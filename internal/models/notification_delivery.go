@@ -0,0 +1,57 @@
+package models
+
+import "encoding/json"
+
+// Push delivery statuses
+const (
+	PushDeliverySent     = "sent"     // Accepted by FCM (fcm_message_id is set)
+	PushDeliveryRetrying = "retrying" // Transient FCM failure, resent by services.PushDeliveryRetrier
+	PushDeliveryFailed   = "failed"   // Permanent failure (unregistered token, invalid message) or out of attempts
+	PushDeliveryExpired  = "expired"  // Still failing when it was too old to be worth sending
+)
+
+// PushDelivery is one push notification sent to one device token
+// Every FCM send is recorded, whether it came from the notification outbox or was sent directly
+type PushDelivery struct {
+	ID               string          `json:"id" db:"id"`
+	UserID           *string         `json:"user_id,omitempty" db:"user_id"` // Owner of the token when it was sent
+	Token            string          `json:"token" db:"token"`
+	NotificationType string          `json:"notification_type" db:"notification_type"` // data.type, e.g. shift_update
+	Title            string          `json:"title" db:"title"`
+	Body             string          `json:"body" db:"body"`
+	Data             json.RawMessage `json:"data" db:"data"`
+	Status           string          `json:"status" db:"status"`
+	FCMMessageID     *string         `json:"fcm_message_id,omitempty" db:"fcm_message_id"`
+	Attempts         int             `json:"attempts" db:"attempts"`
+	ErrorCode        *string         `json:"error_code,omitempty" db:"error_code"` // unregistered, unavailable, internal, quota_exceeded, ...
+	LastError        *string         `json:"last_error,omitempty" db:"last_error"`
+	NextAttemptAt    *int64          `json:"next_attempt_at,omitempty" db:"next_attempt_at"`
+	SentAt           *int64          `json:"sent_at,omitempty" db:"sent_at"`
+	CreatedAt        int64           `json:"created_at" db:"created_at"`
+	UpdatedAt        int64           `json:"updated_at" db:"updated_at"`
+}
+
+// PushDeliveryStats counts a driver's push deliveries in a period
+type PushDeliveryStats struct {
+	DriverID      string   `json:"driver_id" db:"driver_id"`
+	DriverName    string   `json:"driver_name" db:"driver_name"`
+	Total         int      `json:"total" db:"total"`
+	Sent          int      `json:"sent" db:"sent"`
+	Retrying      int      `json:"retrying" db:"retrying"`
+	Failed        int      `json:"failed" db:"failed"`
+	Expired       int      `json:"expired" db:"expired"`
+	Retried       int      `json:"retried" db:"retried"` // Sent or not, needed more than one attempt
+	DeliveryRate  *float64 `json:"delivery_rate"`        // Sent / finished deliveries (null when none finished)
+	Devices       int      `json:"devices" db:"devices"` // Tokens registered now
+	LastSentAt    *int64   `json:"last_sent_at,omitempty" db:"last_sent_at"`
+	LastFailureAt *int64   `json:"last_failure_at,omitempty" db:"last_failure_at"`
+	LastError     *string  `json:"last_error,omitempty" db:"last_error"` // Of the latest failed or expired delivery
+}
+
+// PushDeliveryReport summarizes push deliveries in a period
+type PushDeliveryReport struct {
+	From     int64               `json:"from"`
+	To       int64               `json:"to"`
+	ByDriver []PushDeliveryStats `json:"by_driver"` // Lowest delivery rate first
+	Overall  PushDeliveryStats   `json:"overall"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import "encoding/json"
+
+// Notification outbox channels
+const (
+	OutboxChannelWebSocket = "websocket"
+	OutboxChannelPush      = "push" // FCM to every registered device of the user
+)
+
+// Notification outbox targets
+const (
+	OutboxTargetUser = "user"
+	OutboxTargetRole = "role" // WebSocket only: every connected user with the role
+)
+
+// Notification outbox statuses
+const (
+	OutboxStatusPending   = "pending"
+	OutboxStatusDelivered = "delivered"
+	OutboxStatusFailed    = "failed" // Gave up (max attempts, no devices, push disabled)
+)
+
+// OutboxEvent is a notification written in the same transaction as the state change it announces
+// and delivered afterwards by services.NotificationDispatcher. Its ID is sent to clients as event_id
+// so a redelivered event can be recognized
+type OutboxEvent struct {
+	ID            string          `json:"id" db:"id"`
+	Channel       string          `json:"channel" db:"channel"`
+	TargetType    string          `json:"target_type" db:"target_type"`
+	Target        string          `json:"target" db:"target"` // User ID or role
+	EventType     string          `json:"event_type" db:"event_type"`
+	Payload       json.RawMessage `json:"payload" db:"payload"` // WebSocket message, or OutboxPush
+	Status        string          `json:"status" db:"status"`
+	Attempts      int             `json:"attempts" db:"attempts"`
+	NextAttemptAt int64           `json:"next_attempt_at" db:"next_attempt_at"`
+	LastError     *string         `json:"last_error,omitempty" db:"last_error"`
+	DeliveredAt   *int64          `json:"delivered_at,omitempty" db:"delivered_at"`
+	CreatedAt     int64           `json:"created_at" db:"created_at"`
+	UpdatedAt     int64           `json:"updated_at" db:"updated_at"`
+}
+
+// OutboxPush is the payload of a push outbox event (text is already localized for the recipient)
+type OutboxPush struct {
+	Title string            `json:"title"`
+	Body  string            `json:"body"`
+	Data  map[string]string `json:"data"`
+
+	// Delivered even outside service hours (see ServiceHoursSettings.DeferPush); other pushes wait for the next opening
+	Urgent bool `json:"urgent,omitempty"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "encoding/json"
+
+// Driver actions a manager can perform on a driver's behalf
+const (
+	OnBehalfActionStartShift  = "start_shift"
+	OnBehalfActionCompleteBin = "complete_bin"
+)
+
+// OnBehalfRequest holds the fields an on-behalf request adds to the driver action's body
+type OnBehalfRequest struct {
+	Reason string `json:"reason"` // Required; shown in the audit log and to the driver
+
+	// start-on-behalf only
+	Latitude      *float64 `json:"latitude,omitempty"`       // Where the driver is, used when their app isn't reporting GPS
+	Longitude     *float64 `json:"longitude,omitempty"`      // (optimization starts from it)
+	SkipChecklist bool     `json:"skip_checklist,omitempty"` // Start without the pre-start checklist
+}
+
+// OnBehalfAction is a driver action a manager performed for the driver, kept for the audit trail
+// Refused attempts are recorded too (status_code >= 400)
+type OnBehalfAction struct {
+	ID               string          `json:"id" db:"id"`
+	Action           string          `json:"action" db:"action"` // start_shift or complete_bin
+	ShiftID          string          `json:"shift_id" db:"shift_id"`
+	OnBehalfOf       string          `json:"on_behalf_of" db:"on_behalf_of"` // The driver
+	DriverName       *string         `json:"driver_name,omitempty" db:"driver_name"`
+	PerformedBy      *string         `json:"performed_by,omitempty" db:"performed_by"` // The manager; nil once their account is deleted
+	PerformedByEmail string          `json:"performed_by_email" db:"performed_by_email"`
+	Reason           string          `json:"reason" db:"reason"`
+	Request          json.RawMessage `json:"request" db:"request"` // The body sent
+	StatusCode       int             `json:"status_code" db:"status_code"`
+	CreatedAt        int64           `json:"created_at" db:"created_at"`
+}
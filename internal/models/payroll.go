@@ -0,0 +1,180 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Payroll export fields a column can hold
+const (
+	PayrollFieldDriverID      = "driver_id"
+	PayrollFieldDriverName    = "driver_name"
+	PayrollFieldDriverEmail   = "driver_email"
+	PayrollFieldPeriod        = "period"       // The ?period= value, e.g. 2026-10
+	PayrollFieldPeriodStart   = "period_start" // First day, YYYY-MM-DD
+	PayrollFieldPeriodEnd     = "period_end"   // Last day, YYYY-MM-DD
+	PayrollFieldShifts        = "shifts"
+	PayrollFieldRegularHours  = "regular_hours" // Active time: start to end, less breaks
+	PayrollFieldBreakHours    = "break_hours"
+	PayrollFieldBreakMinutes  = "break_minutes"
+	PayrollFieldTotalHours    = "total_hours" // Start to end, breaks included
+	PayrollFieldBinsCompleted = "bins_completed"
+	PayrollFieldCredits       = "credits"
+	PayrollFieldConstant      = "constant" // The column's value, e.g. a company or pay code
+)
+
+// PayrollFields are the valid PayrollColumn fields
+var PayrollFields = []string{
+	PayrollFieldDriverID, PayrollFieldDriverName, PayrollFieldDriverEmail,
+	PayrollFieldPeriod, PayrollFieldPeriodStart, PayrollFieldPeriodEnd,
+	PayrollFieldShifts, PayrollFieldRegularHours, PayrollFieldBreakHours, PayrollFieldBreakMinutes, PayrollFieldTotalHours,
+	PayrollFieldBinsCompleted, PayrollFieldCredits, PayrollFieldConstant,
+}
+
+// PayrollColumn is one column of the payroll export, in the payroll provider's naming
+type PayrollColumn struct {
+	Header string `json:"header"`          // Column title in the file
+	Field  string `json:"field"`           // One of PayrollFields
+	Value  string `json:"value,omitempty"` // Written in every row when field is constant
+}
+
+// PayrollExportSettings maps shift totals to the payroll provider's CSV schema
+type PayrollExportSettings struct {
+	Columns       []PayrollColumn `json:"columns"`        // In file order
+	Timezone      string          `json:"timezone"`       // IANA timezone periods and dates are read in
+	HoursDecimals int             `json:"hours_decimals"` // Decimal places of hour columns
+}
+
+// DefaultPayrollExportSettings returns the built-in column mapping used when none is stored
+func DefaultPayrollExportSettings() PayrollExportSettings {
+	return PayrollExportSettings{
+		Columns: []PayrollColumn{
+			{Header: "Employee ID", Field: PayrollFieldDriverID},
+			{Header: "Employee Name", Field: PayrollFieldDriverName},
+			{Header: "Email", Field: PayrollFieldDriverEmail},
+			{Header: "Period Start", Field: PayrollFieldPeriodStart},
+			{Header: "Period End", Field: PayrollFieldPeriodEnd},
+			{Header: "Shifts", Field: PayrollFieldShifts},
+			{Header: "Regular Hours", Field: PayrollFieldRegularHours},
+			{Header: "Break Hours", Field: PayrollFieldBreakHours},
+			{Header: "Bins Completed", Field: PayrollFieldBinsCompleted},
+			{Header: "Credits", Field: PayrollFieldCredits},
+		},
+		Timezone:      "America/Los_Angeles",
+		HoursDecimals: 2,
+	}
+}
+
+// Validate checks the columns, timezone and rounding
+func (s PayrollExportSettings) Validate() error {
+	if len(s.Columns) == 0 || len(s.Columns) > 50 {
+		return fmt.Errorf("columns must have between 1 and 50 entries")
+	}
+	for i, column := range s.Columns {
+		if strings.TrimSpace(column.Header) == "" {
+			return fmt.Errorf("columns[%d]: header is required", i)
+		}
+		valid := false
+		for _, field := range PayrollFields {
+			if column.Field == field {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return fmt.Errorf("columns[%d]: field must be one of %s", i, strings.Join(PayrollFields, ", "))
+		}
+	}
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return fmt.Errorf("timezone %q is not a valid IANA timezone", s.Timezone)
+	}
+	if s.HoursDecimals < 0 || s.HoursDecimals > 4 {
+		return fmt.Errorf("hours_decimals must be between 0 and 4")
+	}
+	return nil
+}
+
+// PayrollPeriodMaxDays caps custom payroll periods
+const PayrollPeriodMaxDays = 62
+
+// ParsePayrollPeriod reads a payroll period in loc: a month (2026-10), an ISO week (2026-W41)
+// or an inclusive range of days (2026-10-01..2026-10-15)
+// Returns the normalized label and the period's bounds (end exclusive)
+func ParsePayrollPeriod(value string, loc *time.Location) (string, time.Time, time.Time, error) {
+	value = strings.TrimSpace(value)
+	invalid := fmt.Errorf("period must be a month (2026-10), an ISO week (2026-W41) or a range of days (2026-10-01..2026-10-15)")
+
+	if from, to, ok := strings.Cut(value, ".."); ok {
+		start, err := time.ParseInLocation("2006-01-02", from, loc)
+		if err != nil {
+			return "", time.Time{}, time.Time{}, invalid
+		}
+		last, err := time.ParseInLocation("2006-01-02", to, loc)
+		if err != nil {
+			return "", time.Time{}, time.Time{}, invalid
+		}
+		if last.Before(start) {
+			return "", time.Time{}, time.Time{}, fmt.Errorf("period must not end before it starts")
+		}
+		end := last.AddDate(0, 0, 1)
+		if end.Sub(start) > PayrollPeriodMaxDays*24*time.Hour+time.Hour { // An hour of slack for DST
+			return "", time.Time{}, time.Time{}, fmt.Errorf("period must be at most %d days", PayrollPeriodMaxDays)
+		}
+		return start.Format("2006-01-02") + ".." + last.Format("2006-01-02"), start, end, nil
+	}
+
+	if year, week, ok := strings.Cut(value, "-W"); ok {
+		y, yErr := strconv.Atoi(year)
+		w, wErr := strconv.Atoi(week)
+		if yErr != nil || wErr != nil || len(year) != 4 || w < 1 || w > 53 {
+			return "", time.Time{}, time.Time{}, invalid
+		}
+		// January 4th is always in week 1; weeks start on Monday
+		jan4 := time.Date(y, time.January, 4, 0, 0, 0, 0, loc)
+		start := jan4.AddDate(0, 0, -((int(jan4.Weekday())+6)%7)+(w-1)*7)
+		if isoYear, isoWeek := start.ISOWeek(); isoYear != y || isoWeek != w {
+			return "", time.Time{}, time.Time{}, fmt.Errorf("%d has no week %d", y, w)
+		}
+		return fmt.Sprintf("%04d-W%02d", y, w), start, start.AddDate(0, 0, 7), nil
+	}
+
+	start, err := time.ParseInLocation("2006-01", value, loc)
+	if err != nil {
+		return "", time.Time{}, time.Time{}, invalid
+	}
+	return start.Format("2006-01"), start, start.AddDate(0, 1, 0), nil
+}
+
+// PayrollPeriod is a payroll period that has been exported, and whether it is locked
+// The shifts of a locked period (by start time) can't be changed: shift_history rejects it
+type PayrollPeriod struct {
+	ID               string  `json:"id" db:"id"`
+	Label            string  `json:"label" db:"label"` // e.g. 2026-10, 2026-W41, 2026-10-01..2026-10-15
+	PeriodStart      int64   `json:"period_start" db:"period_start"`
+	PeriodEnd        int64   `json:"period_end" db:"period_end"` // Exclusive
+	Timezone         string  `json:"timezone" db:"timezone"`
+	Drivers          int     `json:"drivers" db:"drivers"` // Rows in the latest export
+	RegularHours     float64 `json:"regular_hours" db:"regular_hours"`
+	ExportCount      int     `json:"export_count" db:"export_count"`
+	LastExportedAt   int64   `json:"last_exported_at" db:"last_exported_at"`
+	LastExportedByID *string `json:"last_exported_by_user_id,omitempty" db:"last_exported_by_user_id"`
+	LockedAt         *int64  `json:"locked_at,omitempty" db:"locked_at"`
+	LockedByUserID   *string `json:"locked_by_user_id,omitempty" db:"locked_by_user_id"`
+	CreatedAt        int64   `json:"created_at" db:"created_at"`
+	UpdatedAt        int64   `json:"updated_at" db:"updated_at"`
+}
+
+// PayrollRow is one driver's totals for a payroll period
+type PayrollRow struct {
+	DriverID       string  `db:"driver_id"`
+	DriverName     string  `db:"driver_name"`
+	DriverEmail    string  `db:"driver_email"`
+	Shifts         int     `db:"shifts"`
+	ActiveSeconds  int64   `db:"active_seconds"`
+	BreakSeconds   int64   `db:"break_seconds"`
+	OnClockSeconds int64   `db:"on_clock_seconds"`
+	BinsCompleted  int     `db:"bins_completed"`
+	Credits        float64 `db:"credits"`
+}
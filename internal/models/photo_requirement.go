@@ -0,0 +1,41 @@
+package models
+
+// PhotoRequirementArea is an area whose bins all require a photo with every check
+type PhotoRequirementArea struct {
+	AreaID   string `json:"area_id" db:"area_id"`
+	Name     string `json:"name" db:"name"`
+	BinCount int    `json:"bin_count" db:"bin_count"`
+}
+
+// PhotoRequirementBin is a bin flagged to require a photo with every check
+type PhotoRequirementBin struct {
+	BinID         string  `json:"bin_id" db:"bin_id"`
+	BinNumber     int     `json:"bin_number" db:"bin_number"`
+	CurrentStreet string  `json:"current_street" db:"current_street"`
+	City          string  `json:"city" db:"city"`
+	AreaID        *string `json:"area_id,omitempty" db:"area_id"`
+}
+
+// PhotoRequirements lists the photo requirement policies in effect
+type PhotoRequirements struct {
+	Areas []PhotoRequirementArea `json:"areas"`
+	Bins  []PhotoRequirementBin  `json:"bins"` // Flagged individually (bins covered only by their area's flag aren't listed)
+}
+
+// PhotoComplianceStats is photo compliance on checks of photo-required bins for one driver
+type PhotoComplianceStats struct {
+	DriverID       string   `json:"driver_id" db:"driver_id"`
+	DriverName     string   `json:"driver_name" db:"driver_name"`
+	RequiredChecks int      `json:"required_checks" db:"required_checks"`
+	WithPhoto      int      `json:"with_photo" db:"with_photo"`
+	MissingPhoto   int      `json:"missing_photo"`
+	ComplianceRate *float64 `json:"compliance_rate"` // With photo / required (null when there are none)
+}
+
+// PhotoComplianceReport summarizes photo compliance for checks in a period
+type PhotoComplianceReport struct {
+	From     int64                  `json:"from"`
+	To       int64                  `json:"to"`
+	ByDriver []PhotoComplianceStats `json:"by_driver"`
+	Overall  PhotoComplianceStats   `json:"overall"`
+}
@@ -0,0 +1,71 @@
+package models
+
+// PreStartChecklistItem is a manager-defined vehicle inspection item drivers answer before starting a shift
+type PreStartChecklistItem struct {
+	ID              string  `json:"id" db:"id"`
+	Label           string  `json:"label" db:"label"`
+	Description     *string `json:"description,omitempty" db:"description"`
+	RequiresPhoto   bool    `json:"requires_photo" db:"requires_photo"` // A photo must be attached to the answer
+	SortOrder       int     `json:"sort_order" db:"sort_order"`
+	IsActive        bool    `json:"is_active" db:"is_active"` // Only active items are asked
+	CreatedByUserID *string `json:"created_by_user_id,omitempty" db:"created_by_user_id"`
+	CreatedAt       int64   `json:"created_at" db:"created_at"`
+	UpdatedAt       int64   `json:"updated_at" db:"updated_at"`
+}
+
+// PreStartChecklistItemRequest is the body for creating or updating a checklist item (omitted fields are unchanged on update)
+type PreStartChecklistItemRequest struct {
+	Label         *string `json:"label" validate:"min=1"`
+	Description   *string `json:"description"` // "" clears the description
+	RequiresPhoto *bool   `json:"requires_photo"`
+	SortOrder     *int    `json:"sort_order"`
+	IsActive      *bool   `json:"is_active"`
+}
+
+// PreStartChecklist is a driver's inspection submitted for a shift (one per shift, replaced on resubmission)
+type PreStartChecklist struct {
+	ID          string                      `json:"id" db:"id"`
+	ShiftID     string                      `json:"shift_id" db:"shift_id"`
+	DriverID    string                      `json:"driver_id" db:"driver_id"`
+	DriverName  *string                     `json:"driver_name,omitempty" db:"driver_name"`
+	VehicleID   *string                     `json:"vehicle_id,omitempty" db:"vehicle_id"` // Truck number or plate, as entered by the driver
+	Notes       *string                     `json:"notes,omitempty" db:"notes"`
+	FailedCount int                         `json:"failed_count" db:"failed_count"`
+	SubmittedAt int64                       `json:"submitted_at" db:"submitted_at"`
+	Responses   []PreStartChecklistResponse `json:"responses" db:"-"`
+}
+
+// PreStartChecklistResponse is the answer to one item (the label is kept in case the item changes later)
+type PreStartChecklistResponse struct {
+	ID          string  `json:"id" db:"id"`
+	ChecklistID string  `json:"checklist_id" db:"checklist_id"`
+	ItemID      *string `json:"item_id,omitempty" db:"item_id"` // NULL once the item is deleted
+	Label       string  `json:"label" db:"label"`
+	Passed      bool    `json:"passed" db:"passed"`
+	Note        *string `json:"note,omitempty" db:"note"`
+	PhotoURL    *string `json:"photo_url,omitempty" db:"photo_url"`
+}
+
+// PreStartChecklistAnswer is one answer in a checklist submission
+type PreStartChecklistAnswer struct {
+	ItemID   string  `json:"item_id" validate:"required"`
+	Passed   *bool   `json:"passed" validate:"required"`
+	Note     *string `json:"note"`
+	PhotoURL *string `json:"photo_url" validate:"format=uri"`
+}
+
+// PreStartChecklistRequest is the body for POST /api/driver/shift/pre-start-checklist
+// Every active item must be answered
+type PreStartChecklistRequest struct {
+	VehicleID *string                   `json:"vehicle_id"`
+	Notes     *string                   `json:"notes"`
+	Responses []PreStartChecklistAnswer `json:"responses" validate:"required"`
+}
+
+// DriverPreStartChecklist is what the driver app shows before starting: the items to answer and any earlier submission
+type DriverPreStartChecklist struct {
+	ShiftID   string                  `json:"shift_id"`
+	Required  bool                    `json:"required"` // False when no items are active
+	Items     []PreStartChecklistItem `json:"items"`
+	Submitted *PreStartChecklist      `json:"submitted,omitempty"`
+}
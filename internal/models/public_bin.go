@@ -0,0 +1,18 @@
+package models
+
+// PublicBin is a bin as shown on the public bin locator: where to find it, nothing about its fill or status
+type PublicBin struct {
+	BinNumber      int      `json:"bin_number" db:"bin_number"`
+	Street         string   `json:"street" db:"current_street"`
+	City           string   `json:"city" db:"city"`
+	Zip            string   `json:"zip" db:"zip"`
+	Latitude       float64  `json:"latitude" db:"latitude"`
+	Longitude      float64  `json:"longitude" db:"longitude"`
+	DistanceMeters *float64 `json:"distance_meters" db:"-"` // From the searched point
+}
+
+// PublicBinsResponse is the response of GET /api/public/bins
+type PublicBinsResponse struct {
+	Bins  []PublicBin `json:"bins"`
+	Total int         `json:"total"` // Matching bins before limit/offset
+}
@@ -10,6 +10,7 @@ type Route struct {
 	BinCount               int      `json:"bin_count" db:"bin_count"`
 	EstimatedDurationHours *float64 `json:"estimated_duration_hours,omitempty" db:"estimated_duration_hours"`
 	CreatedByUserID        *string  `json:"created_by_user_id,omitempty" db:"created_by_user_id"`
+	IsDraft                bool     `json:"is_draft" db:"is_draft"`     // Can't be assigned until published
 	CreatedAt              int64    `json:"created_at" db:"created_at"` // Unix timestamp
 	UpdatedAt              int64    `json:"updated_at" db:"updated_at"` // Unix timestamp
 }
@@ -40,11 +41,11 @@ type BinInRoute struct {
 
 // CreateRouteRequest is the request body for POST /api/routes
 type CreateRouteRequest struct {
-	Name                   string   `json:"name"`
+	Name                   string   `json:"name" validate:"required"`
 	Description            string   `json:"description"`
-	GeographicArea         string   `json:"geographic_area"`
+	GeographicArea         string   `json:"geographic_area" validate:"required"`
 	SchedulePattern        string   `json:"schedule_pattern"`
-	BinIDs                 []string `json:"bin_ids"`
+	BinIDs                 []string `json:"bin_ids" validate:"required"`
 	EstimatedDurationHours *float64 `json:"estimated_duration_hours,omitempty"`
 }
 
@@ -56,6 +57,13 @@ type UpdateRouteRequest struct {
 	SchedulePattern        *string  `json:"schedule_pattern,omitempty"`
 	BinIDs                 []string `json:"bin_ids,omitempty"`
 	EstimatedDurationHours *float64 `json:"estimated_duration_hours,omitempty"`
+	IsDraft                *bool    `json:"is_draft,omitempty"` // false publishes a draft route
+}
+
+// IsEmpty reports whether no field is set
+func (r UpdateRouteRequest) IsEmpty() bool {
+	return r.Name == nil && r.Description == nil && r.GeographicArea == nil && r.SchedulePattern == nil &&
+		r.BinIDs == nil && r.EstimatedDurationHours == nil && r.IsDraft == nil
 }
 
 // DuplicateRouteRequest is the request body for POST /api/routes/:id/duplicate
@@ -1,7 +1,7 @@
 package models
 
 // ShiftBin represents a bin assigned to an active shift (from shift_bins table)
-// Note: This was formerly called RouteBin, but renamed for clarity
+// Deprecated: shift stops live in route_tasks (see RouteTask); shift_bins is no longer read or written
 type ShiftBin struct {
 	ID            int     `db:"id" json:"id"`
 	ShiftID       string  `db:"shift_id" json:"shift_id"`
@@ -14,9 +14,11 @@ type ShiftBin struct {
 	MoveRequestID *string `db:"move_request_id" json:"move_request_id"`
 }
 
-// ShiftBinWithDetails extends ShiftBin with bin details for API responses
+// ShiftBinWithDetails is a shift stop (route_tasks row) with bin details for API responses
+// Move request waypoints carry stop_type 'pickup' or 'dropoff' plus the move's origin and target location
 type ShiftBinWithDetails struct {
 	ID                    int      `db:"id" json:"id"`
+	TaskID                *string  `db:"task_id" json:"task_id,omitempty"` // route_tasks ID (task-based shifts)
 	ShiftID               string   `db:"shift_id" json:"shift_id"`
 	BinID                 string   `db:"bin_id" json:"bin_id"`
 	SequenceOrder         int      `db:"sequence_order" json:"sequence_order"`
@@ -35,5 +37,30 @@ type ShiftBinWithDetails struct {
 	MoveRequestID         *string  `db:"move_request_id" json:"move_request_id"`
 	OriginalAddress       *string  `db:"original_address" json:"original_address"`
 	NewAddress            *string  `db:"new_address" json:"new_address"`
+	NewLatitude           *float64 `db:"new_latitude" json:"new_latitude"`   // Dropoff target (relocation moves)
+	NewLongitude          *float64 `db:"new_longitude" json:"new_longitude"` // Dropoff target (relocation moves)
 	MoveType              *string  `db:"move_type" json:"move_type"`
+
+	// Move request site instructions and attachments (move stops only)
+	MoveInstructions *MoveInstructions       `db:"move_instructions" json:"move_instructions,omitempty"`
+	MoveAttachments  []MoveRequestAttachment `db:"-" json:"move_attachments,omitempty"`
+
+	// Service window (local "HH:MM"): the move request's if set, else the bin's
+	TimeWindowStart *string `db:"time_window_start" json:"time_window_start,omitempty"`
+	TimeWindowEnd   *string `db:"time_window_end" json:"time_window_end,omitempty"`
+
+	// Completing the stop needs a photo (the bin or its area requires one; collection stops only)
+	PhotoRequired bool `db:"photo_required" json:"photo_required"`
+
+	// Risk from the no-go zone the bin lies in ("high" or "elevated"; empty outside zones)
+	RiskLevel    string  `db:"-" json:"risk_level,omitempty"`
+	RiskZoneName *string `db:"-" json:"risk_zone_name,omitempty"`
+
+	// Notes earlier drivers left about the bin in their shift debriefs (newest first)
+	DriverNotes []ShiftNote `db:"-" json:"driver_notes,omitempty"`
+}
+
+// TimeWindow returns the stop's service window, or nil
+func (s ShiftBinWithDetails) TimeWindow() *TimeWindow {
+	return StopTimeWindow(s.TimeWindowStart, s.TimeWindowEnd, nil, nil)
 }
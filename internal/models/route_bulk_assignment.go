@@ -0,0 +1,51 @@
+package models
+
+// MaxBulkRouteAssignments caps the assignments in one bulk route assignment
+const MaxBulkRouteAssignments = 50
+
+// Outcomes of one assignment in a bulk route assignment
+const (
+	BulkRouteAssigned    = "assigned"
+	BulkRouteNotAssigned = "not_assigned" // Valid, but all_or_nothing held it back because another assignment failed
+	BulkRouteFailed      = "failed"
+)
+
+// BulkRouteAssignmentRequest is the body of POST /api/manager/assign-routes
+type BulkRouteAssignmentRequest struct {
+	Assignments  []BulkRouteAssignment `json:"assignments"`
+	Force        bool                  `json:"force"`          // Assign even if a driver's projected workload exceeds the limits or service hours
+	AllOrNothing bool                  `json:"all_or_nothing"` // Assign nothing if any assignment fails validation
+}
+
+// BulkRouteAssignment is one driver's route, as for POST /api/manager/assign-route
+// bin_ids can be left out for a route with saved bins, which are assigned in their saved order
+type BulkRouteAssignment struct {
+	DriverID string   `json:"driver_id"`
+	RouteID  string   `json:"route_id"`
+	BinIDs   []string `json:"bin_ids"`
+}
+
+// BulkRouteAssignmentItem is the outcome of one assignment of a bulk route assignment
+type BulkRouteAssignmentItem struct {
+	DriverID         string                  `json:"driver_id"`
+	RouteID          string                  `json:"route_id"`
+	Result           string                  `json:"result"` // BulkRouteAssigned, BulkRouteNotAssigned or BulkRouteFailed
+	Code             string                  `json:"code,omitempty"`
+	Error            string                  `json:"error,omitempty"`
+	Details          interface{}             `json:"details,omitempty"` // Reserved bins or bins without coordinates behind the error
+	ShiftID          *string                 `json:"shift_id,omitempty"`
+	TotalBins        int                     `json:"total_bins"`
+	DriverConnected  bool                    `json:"driver_connected"` // Offline drivers get the route when they reconnect
+	NotificationSent bool                    `json:"notification_sent"`
+	Workload         *RouteAssignmentPreview `json:"workload,omitempty"`
+	Warnings         []string                `json:"warnings"` // Bins left out (no coordinates), limits overridden
+}
+
+// BulkRouteAssignmentResult is the response of POST /api/manager/assign-routes
+type BulkRouteAssignmentResult struct {
+	Applied   bool                      `json:"applied"` // False when all_or_nothing stopped the assignment
+	Requested int                       `json:"requested"`
+	Assigned  int                       `json:"assigned"`
+	Failed    int                       `json:"failed"`
+	Results   []BulkRouteAssignmentItem `json:"results"` // In request order
+}
@@ -0,0 +1,69 @@
+package models
+
+// Planned distance sources for shift efficiency
+const (
+	PlannedSourceOptimizer    = "optimizer"     // shifts.optimization_metadata (road distance and duration)
+	PlannedSourceStraightLine = "straight_line" // Straight lines between stops in planned order (no duration)
+)
+
+// EfficiencyStop is a shift stop's planned position and completion time
+type EfficiencyStop struct {
+	SequenceOrder int     `db:"sequence_order"`
+	Latitude      float64 `db:"latitude"`
+	Longitude     float64 `db:"longitude"`
+	CompletedAt   *int64  `db:"completed_at"` // Nil unless completed (skipped stops excluded)
+}
+
+// ShiftEfficiency compares how a shift actually went with its plan
+// Stored on shift_history when the shift ends
+type ShiftEfficiency struct {
+	ActualDistanceKm       *float64 `json:"actual_distance_km" db:"actual_distance_km"`           // From driver_locations pings (nil without pings)
+	ActualDurationSeconds  int64    `json:"actual_duration_seconds" db:"actual_duration_seconds"` // Excludes pauses
+	PlannedDistanceKm      *float64 `json:"planned_distance_km" db:"planned_distance_km"`
+	PlannedDurationSeconds *int64   `json:"planned_duration_seconds" db:"planned_duration_seconds"`
+	PlannedSource          *string  `json:"planned_source" db:"planned_source"` // optimizer or straight_line
+	CompletedStops         int      `json:"completed_stops" db:"completed_stops"`
+	OutOfSequenceStops     int      `json:"out_of_sequence_stops" db:"out_of_sequence_stops"` // Fewest stops to move to match the planned order
+	SequenceDeviation      float64  `json:"sequence_deviation" db:"sequence_deviation"`       // Out-of-sequence stops as % of completed stops
+	StopsPerHour           float64  `json:"stops_per_hour" db:"stops_per_hour"`
+}
+
+// RouteExecution is one ended shift of a route blueprint with its efficiency metrics
+type RouteExecution struct {
+	ShiftID    string  `json:"shift_id" db:"shift_id"`
+	DriverID   string  `json:"driver_id" db:"driver_id"`
+	DriverName *string `json:"driver_name,omitempty" db:"driver_name"`
+	EndedAt    int64   `json:"ended_at" db:"ended_at"`
+	EndReason  string  `json:"end_reason" db:"end_reason"`
+	ShiftEfficiency
+}
+
+// RouteEfficiencySummary averages a route's executions (nil when no execution has the metric)
+type RouteEfficiencySummary struct {
+	Executions                int      `json:"executions" db:"executions"`
+	AvgActualDistanceKm       *float64 `json:"avg_actual_distance_km" db:"avg_actual_distance_km"`
+	AvgPlannedDistanceKm      *float64 `json:"avg_planned_distance_km" db:"avg_planned_distance_km"`
+	AvgDistanceRatio          *float64 `json:"avg_distance_ratio" db:"avg_distance_ratio"` // actual / planned, executions with both only
+	AvgActualDurationSeconds  *float64 `json:"avg_actual_duration_seconds" db:"avg_actual_duration_seconds"`
+	AvgPlannedDurationSeconds *float64 `json:"avg_planned_duration_seconds" db:"avg_planned_duration_seconds"`
+	AvgSequenceDeviation      *float64 `json:"avg_sequence_deviation" db:"avg_sequence_deviation"`
+	AvgStopsPerHour           *float64 `json:"avg_stops_per_hour" db:"avg_stops_per_hour"`
+}
+
+// RouteDriverEfficiency is a route's efficiency summary for one driver
+type RouteDriverEfficiency struct {
+	DriverID   string  `json:"driver_id" db:"driver_id"`
+	DriverName *string `json:"driver_name,omitempty" db:"driver_name"`
+	RouteEfficiencySummary
+}
+
+// RouteEfficiencyResponse is the body of GET /api/analytics/routes/{id}/efficiency
+type RouteEfficiencyResponse struct {
+	RouteID    string                  `json:"route_id"`
+	RouteName  *string                 `json:"route_name,omitempty"`
+	From       *int64                  `json:"from,omitempty"`
+	To         *int64                  `json:"to,omitempty"`
+	Summary    RouteEfficiencySummary  `json:"summary"`
+	ByDriver   []RouteDriverEfficiency `json:"by_driver"`
+	Executions []RouteExecution        `json:"executions"` // Most recent first
+}
@@ -0,0 +1,33 @@
+package models
+
+import "encoding/json"
+
+// Route polyline sources
+const (
+	RoutePolylineDirections = "directions" // Every leg follows the roads (Directions API)
+	RoutePolylineEstimate   = "estimate"   // Some legs are straight lines (no API key, or the lookup failed)
+)
+
+// RoutePolyline is a shift's route line: a driving leg between each pair of consecutive stops, in sequence order,
+// chained into one encoded polyline
+type RoutePolyline struct {
+	ShiftID         string          `json:"shift_id" db:"shift_id"`
+	StopsKey        string          `json:"stops_key" db:"stops_key"` // Hash of the stop sequence the line was computed for
+	Polyline        string          `json:"polyline" db:"polyline"`   // Google encoded polyline (precision 5)
+	Legs            json.RawMessage `json:"legs" db:"legs"`           // []RoutePolylineLeg
+	DistanceMeters  int             `json:"distance_meters" db:"distance_meters"`
+	DurationSeconds int             `json:"duration_seconds" db:"duration_seconds"`
+	Source          string          `json:"source" db:"source"`
+	ComputedAt      int64           `json:"computed_at" db:"computed_at"`
+	Cached          bool            `json:"cached" db:"-"`
+}
+
+// RoutePolylineLeg is the leg between two consecutive stops of a route polyline
+type RoutePolylineLeg struct {
+	FromTaskID      string `json:"from_task_id"`
+	ToTaskID        string `json:"to_task_id"`
+	Polyline        string `json:"polyline"`
+	DistanceMeters  int    `json:"distance_meters"`
+	DurationSeconds int    `json:"duration_seconds"`
+	Source          string `json:"source"` // directions or estimate
+}
@@ -0,0 +1,34 @@
+package models
+
+import "github.com/lib/pq"
+
+// Reasons a shift's remaining route is queued for re-optimization
+const (
+	ReoptimizeReasonMoveInserted  = "move_inserted"  // A move request was added to the active shift
+	ReoptimizeReasonMoveRemoved   = "move_removed"   // A move request was taken off the shift
+	ReoptimizeReasonMoveCancelled = "move_cancelled" // A move request on the shift was cancelled
+	ReoptimizeReasonStopSkipped   = "stop_skipped"   // The driver skipped a stop
+	ReoptimizeReasonManual        = "manual"         // A manager asked for it
+)
+
+// RouteReoptimization is a shift's row in route_reoptimizations
+// Requests made while one is pending are merged into it (reasons accumulate), and a shift is
+// re-optimized at most once per debounce interval (see services.RouteReoptimizer)
+type RouteReoptimization struct {
+	ShiftID          string         `json:"shift_id" db:"shift_id"`
+	Pending          bool           `json:"pending" db:"pending"`
+	Reasons          pq.StringArray `json:"reasons" db:"reasons"`
+	RequestedAt      int64          `json:"requested_at" db:"requested_at"` // First request since the last run
+	LastRunAt        *int64         `json:"last_run_at,omitempty" db:"last_run_at"`
+	LastReasons      pq.StringArray `json:"last_reasons" db:"last_reasons"`
+	LastChangedStops int            `json:"last_changed_stops" db:"last_changed_stops"`
+}
+
+// RouteReoptimizationResult is the outcome of re-optimizing one shift
+type RouteReoptimizationResult struct {
+	ShiftID      string   `json:"shift_id"`
+	Reasons      []string `json:"reasons"`
+	Stops        int      `json:"stops"`         // Remaining collection stops considered
+	ChangedStops int      `json:"changed_stops"` // Stops whose sequence order changed
+	Skipped      string   `json:"skipped,omitempty"`
+}
@@ -0,0 +1,22 @@
+package models
+
+// RouteSplitPreview is a bin set split across several drivers, one route each (nothing is saved)
+type RouteSplitPreview struct {
+	Routes            []RouteSplitRoute `json:"routes"`             // In the order the drivers were given; drivers left without bins are omitted
+	UnassignedBinIDs  []string          `json:"unassigned_bin_ids"` // Bins no driver could take within the limits
+	MaxBinsPerDriver  int               `json:"max_bins_per_driver"`
+	MaxHoursPerDriver float64           `json:"max_hours_per_driver"`
+	Warnings          []string          `json:"warnings"`
+}
+
+// RouteSplitRoute is one driver's share of a split route
+type RouteSplitRoute struct {
+	DriverID   string   `json:"driver_id"`
+	BinIDs     []string `json:"bin_ids"` // Planned order from the warehouse (rotated from the driver's location at start)
+	Bins       int      `json:"bins"`
+	DistanceKm float64  `json:"distance_km"` // Straight-line, from the warehouse and back
+	Hours      float64  `json:"hours"`       // Driving plus service time
+
+	// The driver's projected workload with this route added (nil when it couldn't be projected)
+	Workload *RouteAssignmentPreview `json:"workload,omitempty"`
+}
@@ -49,10 +49,16 @@ type RouteTask struct {
 	RouteID *string `json:"route_id,omitempty" db:"route_id"`
 
 	// Completion tracking
-	IsCompleted           int    `json:"is_completed" db:"is_completed"`
-	CompletedAt           *int64 `json:"completed_at,omitempty" db:"completed_at"`
-	Skipped               bool   `json:"skipped" db:"skipped"`
-	UpdatedFillPercentage *int   `json:"updated_fill_percentage,omitempty" db:"updated_fill_percentage"`
+	IsCompleted           int     `json:"is_completed" db:"is_completed"`
+	CompletedAt           *int64  `json:"completed_at,omitempty" db:"completed_at"`
+	Skipped               bool    `json:"skipped" db:"skipped"`
+	UpdatedFillPercentage *int    `json:"updated_fill_percentage,omitempty" db:"updated_fill_percentage"`
+	PhotoURL              *string `json:"photo_url,omitempty" db:"photo_url"`         // Proof captured when a move leg is confirmed
+	SignatureURL          *string `json:"signature_url,omitempty" db:"signature_url"` // Signature captured when a move leg is confirmed
+
+	// Proximity tracking (set from the driver's GPS, see services.StopArrivalDetector)
+	ArrivedAt  *int64 `json:"arrived_at,omitempty" db:"arrived_at"`
+	DepartedAt *int64 `json:"departed_at,omitempty" db:"departed_at"`
 
 	// Metadata
 	TaskData  json.RawMessage `json:"task_data,omitempty" db:"task_data"`
@@ -72,9 +78,10 @@ type CreateShiftWithTasksRequest struct {
 
 // CompleteTaskRequest represents the request to complete a task
 type CompleteTaskRequest struct {
-	UpdatedFillPercentage *int    `json:"updated_fill_percentage,omitempty"`
+	UpdatedFillPercentage *int    `json:"updated_fill_percentage,omitempty" validate:"min=0,max=100"`
 	PhotoURL              *string `json:"photo_url,omitempty"`
 	NewBinID              *string `json:"new_bin_id,omitempty"` // For placement tasks
+	Skipped               bool    `json:"skipped"`              // The driver skipped the stop instead of servicing it
 	HasIncident           bool    `json:"has_incident"`
 	IncidentType          *string `json:"incident_type,omitempty"`
 	IncidentPhotoURL      *string `json:"incident_photo_url,omitempty"`
@@ -0,0 +1,114 @@
+package models
+
+import "github.com/lib/pq"
+
+// RouteVersion is a snapshot of a route blueprint, recorded each time the route is created or updated
+// Shifts built from the route keep the version number in route_version
+type RouteVersion struct {
+	ID                     int            `json:"-" db:"id"`
+	RouteID                string         `json:"route_id" db:"route_id"`
+	Version                int            `json:"version" db:"version"`
+	Name                   string         `json:"name" db:"name"`
+	Description            *string        `json:"description,omitempty" db:"description"`
+	GeographicArea         string         `json:"geographic_area" db:"geographic_area"`
+	SchedulePattern        *string        `json:"schedule_pattern,omitempty" db:"schedule_pattern"`
+	EstimatedDurationHours *float64       `json:"estimated_duration_hours,omitempty" db:"estimated_duration_hours"`
+	BinIDs                 pq.StringArray `json:"bin_ids" db:"bin_ids"` // In sequence order
+	CreatedByUserID        *string        `json:"created_by_user_id,omitempty" db:"created_by_user_id"`
+	CreatedByName          *string        `json:"created_by_name,omitempty" db:"created_by_name"`
+	CreatedAt              int64          `json:"created_at" db:"created_at"`
+}
+
+// RouteVersionChanges is what changed in a route version compared to the version before it
+type RouteVersionChanges struct {
+	AddedBinIDs   []string `json:"added_bin_ids"`
+	RemovedBinIDs []string `json:"removed_bin_ids"`
+	Reordered     bool     `json:"reordered"`      // Bins kept from the previous version are visited in a different order
+	ChangedFields []string `json:"changed_fields"` // Blueprint fields other than the bins, e.g. "name"
+}
+
+// RouteVersionEntry is one version in GET /api/routes/{id}/versions
+// Changes is nil for the first recorded version
+type RouteVersionEntry struct {
+	RouteVersion
+	ShiftCount int                  `json:"shift_count"` // Shifts built from this version
+	Changes    *RouteVersionChanges `json:"changes,omitempty"`
+}
+
+// RouteVersionHistory is the response of GET /api/routes/{id}/versions (newest version first)
+type RouteVersionHistory struct {
+	RouteID        string              `json:"route_id"`
+	CurrentVersion int                 `json:"current_version"`
+	Versions       []RouteVersionEntry `json:"versions"`
+}
+
+// DiffRouteVersions compares a route version with the version before it
+func DiffRouteVersions(prev, cur RouteVersion) RouteVersionChanges {
+	changes := RouteVersionChanges{
+		AddedBinIDs:   []string{},
+		RemovedBinIDs: []string{},
+		ChangedFields: []string{},
+	}
+
+	inPrev := make(map[string]bool, len(prev.BinIDs))
+	for _, binID := range prev.BinIDs {
+		inPrev[binID] = true
+	}
+	inCur := make(map[string]bool, len(cur.BinIDs))
+	for _, binID := range cur.BinIDs {
+		inCur[binID] = true
+	}
+
+	// Bins kept in both versions, in each version's order
+	var keptPrev, keptCur []string
+	for _, binID := range prev.BinIDs {
+		if inCur[binID] {
+			keptPrev = append(keptPrev, binID)
+		} else {
+			changes.RemovedBinIDs = append(changes.RemovedBinIDs, binID)
+		}
+	}
+	for _, binID := range cur.BinIDs {
+		if inPrev[binID] {
+			keptCur = append(keptCur, binID)
+		} else {
+			changes.AddedBinIDs = append(changes.AddedBinIDs, binID)
+		}
+	}
+	for i := range keptCur {
+		if keptPrev[i] != keptCur[i] {
+			changes.Reordered = true
+			break
+		}
+	}
+
+	if prev.Name != cur.Name {
+		changes.ChangedFields = append(changes.ChangedFields, "name")
+	}
+	if !equalStringPtr(prev.Description, cur.Description) {
+		changes.ChangedFields = append(changes.ChangedFields, "description")
+	}
+	if prev.GeographicArea != cur.GeographicArea {
+		changes.ChangedFields = append(changes.ChangedFields, "geographic_area")
+	}
+	if !equalStringPtr(prev.SchedulePattern, cur.SchedulePattern) {
+		changes.ChangedFields = append(changes.ChangedFields, "schedule_pattern")
+	}
+	if (prev.EstimatedDurationHours == nil) != (cur.EstimatedDurationHours == nil) ||
+		(prev.EstimatedDurationHours != nil && *prev.EstimatedDurationHours != *cur.EstimatedDurationHours) {
+		changes.ChangedFields = append(changes.ChangedFields, "estimated_duration_hours")
+	}
+	return changes
+}
+
+// equalStringPtr treats nil and "" as the same value
+func equalStringPtr(a, b *string) bool {
+	var av, bv string
+	if a != nil {
+		av = *a
+	}
+	if b != nil {
+		bv = *b
+	}
+	return av == bv
+}
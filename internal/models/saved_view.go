@@ -0,0 +1,32 @@
+package models
+
+import "encoding/json"
+
+// Saved view entity types (the list endpoint each view applies to)
+const (
+	SavedViewEntityBins         = "bins"          // GET /api/bins and /api/bins/priority
+	SavedViewEntityMoveRequests = "move_requests" // GET /api/manager/bins/move-requests
+)
+
+// SavedView is a manager's named filter and sort for a list, applied server side with ?view_id=
+type SavedView struct {
+	ID         string          `json:"id" db:"id"`
+	UserID     string          `json:"user_id" db:"user_id"` // Owner; only the owner can change or delete it
+	OwnerName  *string         `json:"owner_name,omitempty" db:"owner_name"`
+	Name       string          `json:"name" db:"name"`
+	EntityType string          `json:"entity_type" db:"entity_type"`
+	Filters    json.RawMessage `json:"filters" db:"filters"` // Query parameters of the list endpoint, e.g. {"status": "active", "area_id": "..."}
+	Sort       *string         `json:"sort,omitempty" db:"sort"`
+	IsShared   bool            `json:"is_shared" db:"is_shared"` // Visible to (and applicable by) every manager
+	CreatedAt  int64           `json:"created_at" db:"created_at"`
+	UpdatedAt  int64           `json:"updated_at" db:"updated_at"`
+}
+
+// SavedViewRequest is the body for creating or updating a saved view (omitted fields are unchanged on update)
+type SavedViewRequest struct {
+	Name       *string           `json:"name"`
+	EntityType *string           `json:"entity_type" validate:"oneof=bins move_requests"`
+	Filters    map[string]string `json:"filters"`
+	Sort       *string           `json:"sort"` // "" clears the sort
+	IsShared   *bool             `json:"is_shared"`
+}
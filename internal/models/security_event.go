@@ -0,0 +1,47 @@
+package models
+
+// Security event types recorded in security_events
+const (
+	SecurityEventLoginSucceeded     = "login_succeeded"
+	SecurityEventLoginFailed        = "login_failed"
+	SecurityEventLoginBlocked       = "login_blocked"        // Attempt rejected during backoff or lockout
+	SecurityEventAccountLocked      = "account_locked"       // Account or IP crossed the lockout threshold
+	SecurityEventAccountUnlocked    = "account_unlocked"     // Lockout cleared by an admin
+	SecurityEventAccountDeactivated = "account_deactivated"  // User offboarded by an admin
+	SecurityEventAccountReactivated = "account_reactivated"  // Deactivated user restored by an admin
+	SecurityEventSocketDisconnected = "socket_disconnected"  // WebSocket connection closed by an admin
+	SecurityEventDataExported       = "data_exported"        // User's personal data exported by an admin
+	SecurityEventAccountAnonymized  = "account_anonymized"   // User's personal data erased by an admin
+	SecurityEventSupportModeStarted = "support_mode_started" // Admin started impersonating a driver
+	SecurityEventSupportModeEnded   = "support_mode_ended"   // Impersonation session ended early by an admin
+)
+
+// Login throttle scopes
+const (
+	LoginThrottleScopeAccount = "account"
+	LoginThrottleScopeIP      = "ip"
+)
+
+// SecurityEvent is an audit log entry for authentication and account security
+type SecurityEvent struct {
+	ID        string  `json:"id" db:"id"`
+	EventType string  `json:"event_type" db:"event_type"`
+	UserID    *string `json:"user_id,omitempty" db:"user_id"`
+	Email     *string `json:"email,omitempty" db:"email"`
+	IPAddress *string `json:"ip_address,omitempty" db:"ip_address"`
+	UserAgent *string `json:"user_agent,omitempty" db:"user_agent"`
+	ActorID   *string `json:"actor_id,omitempty" db:"actor_id"` // Admin who performed the action (unlocks)
+	Details   *string `json:"details,omitempty" db:"details"`
+	CreatedAt int64   `json:"created_at" db:"created_at"`
+}
+
+// LoginThrottle tracks recent failed logins for an account (email) or IP address
+type LoginThrottle struct {
+	Scope         string `json:"scope" db:"scope"` // account, ip
+	Key           string `json:"key" db:"key"`     // Normalized email or IP address
+	FailedCount   int    `json:"failed_count" db:"failed_count"`
+	FirstFailedAt int64  `json:"first_failed_at" db:"first_failed_at"`
+	LastFailedAt  int64  `json:"last_failed_at" db:"last_failed_at"`
+	LockedUntil   int64  `json:"locked_until" db:"locked_until"` // No attempts accepted before this time (backoff or lockout)
+	IsLockedOut   bool   `json:"is_locked_out" db:"is_locked_out"`
+}
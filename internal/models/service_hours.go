@@ -0,0 +1,177 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// After-hours assignment policies
+const (
+	AfterHoursWarn  = "warn"  // Assignments finishing after service hours are flagged in the preview
+	AfterHoursBlock = "block" // ...and refused without force=true
+)
+
+// serviceDays maps the day names accepted in ServiceHoursSettings.Days
+var serviceDays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ServiceHoursSettings is when drivers work: outside these hours non-urgent push notifications wait
+// for the next opening, and assignments estimated to finish after closing are flagged (or refused)
+type ServiceHoursSettings struct {
+	Enabled               bool     `json:"enabled"`
+	Timezone              string   `json:"timezone"` // IANA timezone for start and end
+	Start                 string   `json:"start"`    // Local "HH:MM" service opens
+	End                   string   `json:"end"`      // Local "HH:MM" service closes (after start)
+	Days                  []string `json:"days"`     // "mon".."sun"; empty means every day
+	DeferPush             bool     `json:"defer_push"`
+	AfterHoursAssignments string   `json:"after_hours_assignments"` // "warn" or "block"
+}
+
+// DefaultServiceHoursSettings returns the built-in service hours used when none are stored
+func DefaultServiceHoursSettings() ServiceHoursSettings {
+	return ServiceHoursSettings{
+		Enabled:               true,
+		Timezone:              "America/Los_Angeles",
+		Start:                 "06:00",
+		End:                   "20:00",
+		Days:                  []string{},
+		DeferPush:             true,
+		AfterHoursAssignments: AfterHoursWarn,
+	}
+}
+
+// Validate checks the times, timezone, days and policy
+func (s ServiceHoursSettings) Validate() error {
+	start, err := ParseTimeOfDay(s.Start)
+	if err != nil {
+		return fmt.Errorf("start: %w", err)
+	}
+	end, err := ParseTimeOfDay(s.End)
+	if err != nil {
+		return fmt.Errorf("end: %w", err)
+	}
+	if start >= end {
+		return fmt.Errorf("start must be before end")
+	}
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return fmt.Errorf("timezone %q is not a valid IANA timezone", s.Timezone)
+	}
+	for _, day := range s.Days {
+		if _, ok := serviceDays[strings.ToLower(day)]; !ok {
+			return fmt.Errorf("days: %q is not a day (use mon, tue, wed, thu, fri, sat, sun)", day)
+		}
+	}
+	if s.AfterHoursAssignments != AfterHoursWarn && s.AfterHoursAssignments != AfterHoursBlock {
+		return fmt.Errorf("after_hours_assignments must be %q or %q", AfterHoursWarn, AfterHoursBlock)
+	}
+	return nil
+}
+
+// Location returns the service hours timezone, falling back to UTC
+func (s ServiceHoursSettings) Location() *time.Location {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// window returns the service window on the local date of t, or ok=false if that isn't a service day
+func (s ServiceHoursSettings) window(t time.Time) (open, close time.Time, ok bool) {
+	if len(s.Days) > 0 {
+		for _, day := range s.Days {
+			if serviceDays[strings.ToLower(day)] == t.Weekday() {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return time.Time{}, time.Time{}, false
+		}
+	}
+	start, err := ParseTimeOfDay(s.Start)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	end, err := ParseTimeOfDay(s.End)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	year, month, date := t.Date()
+	open = time.Date(year, month, date, start/60, start%60, 0, 0, t.Location())
+	close = time.Date(year, month, date, end/60, end%60, 0, 0, t.Location())
+	return open, close, true
+}
+
+// IsOpen reports whether t falls within service hours (always true when disabled)
+func (s ServiceHoursSettings) IsOpen(t time.Time) bool {
+	if !s.Enabled {
+		return true
+	}
+	local := t.In(s.Location())
+	open, close, ok := s.window(local)
+	return ok && !local.Before(open) && local.Before(close)
+}
+
+// IsServiceDay reports whether drivers work on t's local date (always true when disabled)
+func (s ServiceHoursSettings) IsServiceDay(t time.Time) bool {
+	if !s.Enabled {
+		return true
+	}
+	_, _, ok := s.window(t.In(s.Location()))
+	return ok
+}
+
+// NextWindow returns the service window in progress at t, or the next one to open
+// ok is false when disabled or no service day comes up within a week
+func (s ServiceHoursSettings) NextWindow(t time.Time) (open, close time.Time, ok bool) {
+	if !s.Enabled {
+		return time.Time{}, time.Time{}, false
+	}
+	local := t.In(s.Location())
+	for i := 0; i <= 7; i++ {
+		open, close, ok := s.window(local.AddDate(0, 0, i))
+		if ok && local.Before(close) {
+			return open, close, true
+		}
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+// ServiceHoursCheck is how an assignment's estimated finish compares with service hours
+type ServiceHoursCheck struct {
+	EstimatedStart      int64  `json:"estimated_start"`      // Unix: now, or the next opening if service is closed
+	EstimatedCompletion int64  `json:"estimated_completion"` // Unix
+	ServiceEnd          int64  `json:"service_end"`          // Unix close of the service window the work starts in
+	ExceedsServiceHours bool   `json:"exceeds_service_hours"`
+	Policy              string `json:"policy"` // AfterHoursWarn or AfterHoursBlock
+}
+
+// CheckServiceHours estimates when work of the given duration assigned at now finishes, and whether that
+// is after the close of the service window it starts in. Returns nil when service hours are disabled
+func (s ServiceHoursSettings) CheckServiceHours(now time.Time, duration time.Duration) *ServiceHoursCheck {
+	open, close, ok := s.NextWindow(now)
+	if !ok {
+		return nil
+	}
+	start := now
+	if start.Before(open) {
+		start = open
+	}
+	completion := start.Add(duration)
+	return &ServiceHoursCheck{
+		EstimatedStart:      start.Unix(),
+		EstimatedCompletion: completion.Unix(),
+		ServiceEnd:          close.Unix(),
+		ExceedsServiceHours: completion.After(close),
+		Policy:              s.AfterHoursAssignments,
+	}
+}
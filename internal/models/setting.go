@@ -0,0 +1,134 @@
+package models
+
+import "fmt"
+
+// Setting keys stored in the settings table
+const (
+	SettingKeyPriorityWeights  = "priority_weights"
+	SettingKeyEarningsRates    = "earnings_rates"
+	SettingKeyMoveSLA          = "move_sla"
+	SettingKeyClientConfig     = "client_config"
+	SettingKeyWorkloadLimits   = "workload_limits"
+	SettingKeyDigest           = "digest"
+	SettingKeyUndo             = "undo"
+	SettingKeyZoneRiskRouting  = "zone_risk_routing"
+	SettingKeyRetention        = "retention"
+	SettingKeyServiceHours     = "service_hours"
+	SettingKeyCostRates        = "cost_rates"
+	SettingKeyCheckForm        = "check_form"
+	SettingKeyShiftDebrief     = "shift_debrief"
+	SettingKeyCheckInProximity = "check_in_proximity"
+	SettingKeyFillThresholds   = "fill_thresholds"
+	SettingKeyPayrollExport    = "payroll_export"
+	SettingKeyIncidentDedup    = "incident_dedup"
+
+	// Markers for one-time data jobs (value records when the job ran)
+	SettingKeyShiftIncidentBackfill = "job_shift_incident_backfill"
+	SettingKeyShiftBinsBackfill     = "job_shift_bins_backfill" // Set by the shift_bins -> route_tasks migration
+)
+
+// Setting represents an org-level configuration value stored as JSON
+type Setting struct {
+	Key             string  `json:"key" db:"key"`
+	Value           []byte  `json:"-" db:"value"` // Raw JSON, decoded by the caller
+	UpdatedAt       int64   `json:"updated_at" db:"updated_at"`
+	UpdatedByUserID *string `json:"updated_by_user_id,omitempty" db:"updated_by_user_id"`
+}
+
+// PriorityWeights holds the scores and thresholds used to rank bins by priority
+// Scores are added together; thresholds decide which score tier applies
+type PriorityWeights struct {
+	// Move requests
+	UrgentMoveScore    float64 `json:"urgent_move_score"`
+	MoveDue1DayScore   float64 `json:"move_due_1_day_score"`
+	MoveDue3DaysScore  float64 `json:"move_due_3_days_score"`
+	MoveDue7DaysScore  float64 `json:"move_due_7_days_score"`
+	MoveScheduledScore float64 `json:"move_scheduled_score"`
+
+	// Fill percentage: high applies from the critical fill threshold, medium from the warning one (see FillThresholds)
+	HighFillScore    float64 `json:"high_fill_score"`
+	MediumFillScore  float64 `json:"medium_fill_score"`
+	LowFillThreshold int     `json:"low_fill_threshold"` // Below the warning threshold
+	LowFillScore     float64 `json:"low_fill_score"`
+
+	// Days since last check
+	CriticalUncheckedDays  int     `json:"critical_unchecked_days"`
+	CriticalUncheckedScore float64 `json:"critical_unchecked_score"`
+	HighUncheckedDays      int     `json:"high_unchecked_days"`
+	HighUncheckedScore     float64 `json:"high_unchecked_score"`
+	StaleUncheckedDays     int     `json:"stale_unchecked_days"`
+	StaleUncheckedScore    float64 `json:"stale_unchecked_score"`
+	NeverCheckedScore      float64 `json:"never_checked_score"`
+
+	// Check recommendations
+	CheckRecommendationScore float64 `json:"check_recommendation_score"`
+
+	// Maintenance
+	MaintenanceDueScore float64 `json:"maintenance_due_score"`
+}
+
+// DefaultPriorityWeights returns the built-in weights used when no settings are stored
+func DefaultPriorityWeights() PriorityWeights {
+	return PriorityWeights{
+		UrgentMoveScore:    1000,
+		MoveDue1DayScore:   800,
+		MoveDue3DaysScore:  600,
+		MoveDue7DaysScore:  400,
+		MoveScheduledScore: 100,
+
+		HighFillScore:    300,
+		MediumFillScore:  150,
+		LowFillThreshold: 40,
+		LowFillScore:     50,
+
+		CriticalUncheckedDays:  30,
+		CriticalUncheckedScore: 800,
+		HighUncheckedDays:      14,
+		HighUncheckedScore:     400,
+		StaleUncheckedDays:     7,
+		StaleUncheckedScore:    200,
+		NeverCheckedScore:      1000,
+
+		CheckRecommendationScore: 100,
+
+		MaintenanceDueScore: 150,
+	}
+}
+
+// Validate checks that scores are non-negative and thresholds are ordered
+func (pw PriorityWeights) Validate() error {
+	scores := map[string]float64{
+		"urgent_move_score":          pw.UrgentMoveScore,
+		"move_due_1_day_score":       pw.MoveDue1DayScore,
+		"move_due_3_days_score":      pw.MoveDue3DaysScore,
+		"move_due_7_days_score":      pw.MoveDue7DaysScore,
+		"move_scheduled_score":       pw.MoveScheduledScore,
+		"high_fill_score":            pw.HighFillScore,
+		"medium_fill_score":          pw.MediumFillScore,
+		"low_fill_score":             pw.LowFillScore,
+		"critical_unchecked_score":   pw.CriticalUncheckedScore,
+		"high_unchecked_score":       pw.HighUncheckedScore,
+		"stale_unchecked_score":      pw.StaleUncheckedScore,
+		"never_checked_score":        pw.NeverCheckedScore,
+		"check_recommendation_score": pw.CheckRecommendationScore,
+		"maintenance_due_score":      pw.MaintenanceDueScore,
+	}
+	for name, score := range scores {
+		if score < 0 {
+			return fmt.Errorf("%s must not be negative", name)
+		}
+	}
+
+	if pw.LowFillThreshold < 0 || pw.LowFillThreshold > 100 {
+		return fmt.Errorf("low_fill_threshold must be between 0 and 100")
+	}
+
+	if pw.StaleUncheckedDays < 0 {
+		return fmt.Errorf("unchecked day thresholds must not be negative")
+	}
+	if !(pw.StaleUncheckedDays <= pw.HighUncheckedDays && pw.HighUncheckedDays <= pw.CriticalUncheckedDays) {
+		return fmt.Errorf("unchecked day thresholds must satisfy stale <= high <= critical")
+	}
+
+	return nil
+}
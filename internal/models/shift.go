@@ -31,6 +31,7 @@ type Shift struct {
 	ID                   string                `json:"id" db:"id"`
 	DriverID             string                `json:"driver_id" db:"driver_id"`
 	RouteID              *string               `json:"route_id" db:"route_id"`
+	RouteVersion         *int                  `json:"route_version,omitempty" db:"route_version"` // Route blueprint version the stops were copied from
 	Status               ShiftStatus           `json:"status" db:"status"`
 	StartTime            *int64                `json:"start_time" db:"start_time"`
 	EndTime              *int64                `json:"end_time" db:"end_time"`
@@ -43,6 +44,14 @@ type Shift struct {
 	WarehouseLongitude   *float64              `json:"warehouse_longitude" db:"warehouse_longitude"`
 	WarehouseAddress     *string               `json:"warehouse_address" db:"warehouse_address"`
 	OptimizationMetadata *OptimizationMetadata `json:"optimization_metadata,omitempty" db:"optimization_metadata"`
+	TemplateID           *string               `json:"template_id,omitempty" db:"template_id"`         // Shift template this shift was materialized from
+	ScheduledStart       *int64                `json:"scheduled_start,omitempty" db:"scheduled_start"` // Start window (from the template)
+	ScheduledEnd         *int64                `json:"scheduled_end,omitempty" db:"scheduled_end"`
+	IncidentsReported    int                   `json:"incidents_reported" db:"incidents_reported"` // Incidents reported on this shift (field observations included)
+	FieldObservations    int                   `json:"field_observations" db:"field_observations"`
+	EstimatedHours       *float64              `json:"estimated_hours,omitempty" db:"estimated_hours"` // Cost estimate made at assignment (see CostRates)
+	EstimatedDistanceKm  *float64              `json:"estimated_distance_km,omitempty" db:"estimated_distance_km"`
+	EstimatedCost        *float64              `json:"estimated_cost,omitempty" db:"estimated_cost"`
 	CreatedAt            int64                 `json:"created_at" db:"created_at"`
 	UpdatedAt            int64                 `json:"updated_at" db:"updated_at"`
 }
@@ -97,13 +106,14 @@ func (s *Shift) GetCompletionPercentage() float64 {
 
 // ShiftEndResponse contains details when shift ends
 type ShiftEndResponse struct {
-	Status                ShiftStatus `json:"status"`
-	EndTime               int64       `json:"end_time"`
-	TotalDurationSeconds  int64       `json:"total_duration_seconds"`
-	ActiveDurationSeconds int64       `json:"active_duration_seconds"`
-	TotalPauseSeconds     int         `json:"total_pause_seconds"`
-	CompletedBins         int         `json:"completed_bins"`
-	TotalBins             int         `json:"total_bins"`
+	Status                ShiftStatus    `json:"status"`
+	EndTime               int64          `json:"end_time"`
+	TotalDurationSeconds  int64          `json:"total_duration_seconds"`
+	ActiveDurationSeconds int64          `json:"active_duration_seconds"`
+	TotalPauseSeconds     int            `json:"total_pause_seconds"`
+	CompletedBins         int            `json:"completed_bins"`
+	TotalBins             int            `json:"total_bins"`
+	Earnings              *ShiftEarnings `json:"earnings,omitempty"` // Credits earned this shift
 }
 
 // CompleteBinResponse contains bin completion progress
@@ -113,6 +123,7 @@ type CompleteBinResponse struct {
 	CompletionPercentage float64 `json:"completion_percentage"`
 	CheckID              *int    `json:"check_id,omitempty"`    // ID of created check record (for linking incidents)
 	IncidentID           *string `json:"incident_id,omitempty"` // ID of created incident (if incident was reported)
+	RemoteCompletion     bool    `json:"remote_completion"`     // Completed too far from the stop; flagged for manager review
 }
 
 // ToNullInt64 converts a pointer to int64 to sql.NullInt64
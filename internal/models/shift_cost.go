@@ -0,0 +1,79 @@
+package models
+
+import "fmt"
+
+// CostRates holds what a shift costs to run: the driver's time and the vehicle's mileage
+// Shifts get an estimated cost when assigned and an actual cost when they end (stored in shift_history)
+type CostRates struct {
+	DriverHourlyRate float64 `json:"driver_hourly_rate"` // Per hour of driver time (pauses excluded)
+	PerKmRate        float64 `json:"per_km_rate"`        // Fuel and wear per km driven
+}
+
+// DefaultCostRates returns the built-in rates used when no settings are stored
+func DefaultCostRates() CostRates {
+	return CostRates{
+		DriverHourlyRate: 25.00,
+		PerKmRate:        0.40,
+	}
+}
+
+// Validate checks that rates are non-negative
+func (cr CostRates) Validate() error {
+	if cr.DriverHourlyRate < 0 {
+		return fmt.Errorf("driver_hourly_rate must not be negative")
+	}
+	if cr.PerKmRate < 0 {
+		return fmt.Errorf("per_km_rate must not be negative")
+	}
+	return nil
+}
+
+// ShiftCost is a shift's cost from its hours and distance
+type ShiftCost struct {
+	Hours       float64 `json:"hours"`
+	DistanceKm  float64 `json:"distance_km"`
+	LaborCost   float64 `json:"labor_cost"`
+	MileageCost float64 `json:"mileage_cost"`
+	Total       float64 `json:"total"`
+}
+
+// Calculate prices hours of driver time and km driven
+func (cr CostRates) Calculate(hours, distanceKm float64) ShiftCost {
+	cost := ShiftCost{
+		Hours:       roundCredits(hours),
+		DistanceKm:  roundCredits(distanceKm),
+		LaborCost:   roundCredits(hours * cr.DriverHourlyRate),
+		MileageCost: roundCredits(distanceKm * cr.PerKmRate),
+	}
+	cost.Total = roundCredits(cost.LaborCost + cost.MileageCost)
+	return cost
+}
+
+// Actual distance sources for ShiftCostBreakdown
+const (
+	CostDistanceGPS       = "gps"       // Driven distance from driver_locations (see ShiftEfficiency)
+	CostDistancePlanned   = "planned"   // No usable GPS: the planned distance
+	CostDistanceEstimated = "estimated" // No plan either: the distance estimated at assignment
+)
+
+// ShiftCostBreakdown is stored as shift_history.cost_breakdown when a shift ends
+type ShiftCostBreakdown struct {
+	Estimated      *ShiftCost `json:"estimated"` // Nil for shifts created without an estimate
+	Actual         ShiftCost  `json:"actual"`
+	DistanceSource string     `json:"distance_source"`
+	Rates          CostRates  `json:"rates"` // Rates the actual cost was calculated with
+}
+
+// RouteCostVariance compares estimated and actual cost for a route's shifts that ended in a month
+// Variance is actual minus estimated over shifts that have both
+type RouteCostVariance struct {
+	RouteID               *string  `json:"route_id" db:"route_id"` // Nil for custom bin selections
+	RouteName             *string  `json:"route_name,omitempty" db:"route_name"`
+	Month                 string   `json:"month" db:"month"` // YYYY-MM (UTC)
+	Shifts                int      `json:"shifts" db:"shifts"`
+	ShiftsWithoutEstimate int      `json:"shifts_without_estimate" db:"shifts_without_estimate"`
+	EstimatedCost         float64  `json:"estimated_cost" db:"estimated_cost"`
+	ActualCost            float64  `json:"actual_cost" db:"actual_cost"`
+	Variance              float64  `json:"variance" db:"variance"`
+	VariancePercent       *float64 `json:"variance_percent" db:"variance_percent"` // Nil when nothing was estimated
+}
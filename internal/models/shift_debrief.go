@@ -0,0 +1,100 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Shift note limits
+const (
+	ShiftNoteMaxLength   = 1000
+	ShiftNotesPerDebrief = 20
+)
+
+// DebriefSettings is the organization's end-of-shift debrief: whether drivers must fill it in before
+// ending a shift, and the questions asked alongside their free-text notes
+type DebriefSettings struct {
+	Required  bool             `json:"required"`  // EndShift is refused until a debrief is sent
+	Questions []CheckFormField `json:"questions"` // Same field types as the check form, in display order
+}
+
+// DefaultDebriefSettings returns the settings used when none are stored (optional, notes only)
+func DefaultDebriefSettings() DebriefSettings {
+	return DebriefSettings{Questions: []CheckFormField{}}
+}
+
+// Validate checks the questions
+func (s DebriefSettings) Validate() error {
+	if err := (CheckForm{Fields: s.Questions}).Validate(); err != nil {
+		return fmt.Errorf("questions: %w", err)
+	}
+	return nil
+}
+
+// ValidateResponses checks a driver's answers to the debrief questions (required questions are enforced)
+func (s DebriefSettings) ValidateResponses(responses map[string]json.RawMessage) (map[string]interface{}, []CheckFormFieldError) {
+	return CheckForm{Fields: s.Questions}.ValidateResponses(responses, true)
+}
+
+// EndShiftRequest is the optional body of POST /api/driver/shift/end
+type EndShiftRequest struct {
+	Debrief *ShiftDebriefRequest `json:"debrief"`
+}
+
+// ShiftDebriefRequest is the driver's end-of-shift debrief
+type ShiftDebriefRequest struct {
+	Responses map[string]json.RawMessage `json:"responses"` // Keyed by question key
+	Notes     []ShiftNoteInput           `json:"notes"`
+}
+
+// ShiftNoteInput is one note in a debrief; notes linked to a bin are shown to the next driver with that bin
+type ShiftNoteInput struct {
+	Note  string  `json:"note"`
+	BinID *string `json:"bin_id"`
+}
+
+// Normalize trims the notes and checks their limits
+func (r *ShiftDebriefRequest) Normalize() error {
+	if len(r.Notes) > ShiftNotesPerDebrief {
+		return fmt.Errorf("at most %d notes per shift", ShiftNotesPerDebrief)
+	}
+	for i := range r.Notes {
+		r.Notes[i].Note = strings.TrimSpace(r.Notes[i].Note)
+		if r.Notes[i].Note == "" {
+			return fmt.Errorf("notes[%d].note is required", i)
+		}
+		if len(r.Notes[i].Note) > ShiftNoteMaxLength {
+			return fmt.Errorf("notes[%d].note must be at most %d characters", i, ShiftNoteMaxLength)
+		}
+		if r.Notes[i].BinID != nil && strings.TrimSpace(*r.Notes[i].BinID) == "" {
+			r.Notes[i].BinID = nil
+		}
+	}
+	return nil
+}
+
+// ShiftDebrief is a driver's submitted debrief for an ended shift (one per shift_history row)
+type ShiftDebrief struct {
+	ShiftID     string          `json:"shift_id" db:"shift_id"`
+	DriverID    string          `json:"driver_id" db:"driver_id"`
+	DriverName  *string         `json:"driver_name,omitempty" db:"driver_name"`
+	Responses   json.RawMessage `json:"responses" db:"responses"` // Answers keyed by question key
+	SubmittedAt int64           `json:"submitted_at" db:"submitted_at"`
+	Notes       []ShiftNote     `json:"notes" db:"-"`
+}
+
+// ShiftNote is a driver's free-text note from a shift debrief, optionally about one bin
+// (e.g. "gate locked after 5pm"); bin notes stay visible to later drivers until dismissed
+type ShiftNote struct {
+	ID                int64   `json:"id" db:"id"`
+	ShiftID           string  `json:"shift_id" db:"shift_id"`
+	DriverID          string  `json:"driver_id" db:"driver_id"`
+	DriverName        *string `json:"driver_name,omitempty" db:"driver_name"`
+	BinID             *string `json:"bin_id,omitempty" db:"bin_id"`
+	BinNumber         *int    `json:"bin_number,omitempty" db:"bin_number"`
+	Note              string  `json:"note" db:"note"`
+	CreatedAt         int64   `json:"created_at" db:"created_at"`
+	DismissedAt       *int64  `json:"dismissed_at,omitempty" db:"dismissed_at"`
+	DismissedByUserID *string `json:"dismissed_by_user_id,omitempty" db:"dismissed_by_user_id"`
+}
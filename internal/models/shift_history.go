@@ -0,0 +1,59 @@
+package models
+
+// ShiftEndReasons are the values of shift_history.end_reason
+var ShiftEndReasons = []string{"completed", "manual_end", "manager_ended", "manager_cancelled", "driver_disconnected", "system_timeout"}
+
+// IsShiftEndReason reports whether reason is a valid shift_history.end_reason
+func IsShiftEndReason(reason string) bool {
+	for _, r := range ShiftEndReasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// ShiftHistoryEntry is an ended shift as recorded in shift_history
+type ShiftHistoryEntry struct {
+	ID                    string   `json:"id" db:"id"`
+	DriverID              string   `json:"driver_id" db:"driver_id"`
+	DriverName            *string  `json:"driver_name,omitempty" db:"driver_name"`
+	RouteID               *string  `json:"route_id,omitempty" db:"route_id"`
+	StartTime             *int64   `json:"start_time,omitempty" db:"start_time"`
+	EndTime               *int64   `json:"end_time,omitempty" db:"end_time"`
+	CreatedAt             int64    `json:"created_at" db:"created_at"`
+	EndedAt               int64    `json:"ended_at" db:"ended_at"`
+	TotalPauseSeconds     int      `json:"total_pause_seconds" db:"total_pause_seconds"`
+	TotalBins             int      `json:"total_bins" db:"total_bins"`
+	CompletedBins         int      `json:"completed_bins" db:"completed_bins"`
+	CompletionRate        float64  `json:"completion_rate" db:"completion_rate"` // Percent of bins completed
+	IncidentsReported     int      `json:"incidents_reported" db:"incidents_reported"`
+	FieldObservations     int      `json:"field_observations" db:"field_observations"`
+	EndReason             string   `json:"end_reason" db:"end_reason"`
+	EndedByUserID         *string  `json:"ended_by_user_id,omitempty" db:"ended_by_user_id"`
+	EarnedCredits         float64  `json:"earned_credits" db:"earned_credits"`
+	ActualDistanceKm      *float64 `json:"actual_distance_km,omitempty" db:"actual_distance_km"`
+	ActualDurationSeconds *int64   `json:"actual_duration_seconds,omitempty" db:"actual_duration_seconds"`
+	StopsPerHour          *float64 `json:"stops_per_hour,omitempty" db:"stops_per_hour"`
+}
+
+// ShiftHistorySummary aggregates every shift matching a shift history query (not just the page returned)
+type ShiftHistorySummary struct {
+	Shifts                int            `json:"shifts" db:"shifts"`
+	TotalBins             int            `json:"total_bins" db:"total_bins"`
+	CompletedBins         int            `json:"completed_bins" db:"completed_bins"`
+	AverageCompletionRate *float64       `json:"average_completion_rate" db:"average_completion_rate"` // Null without shifts
+	ActiveSeconds         int64          `json:"active_seconds" db:"active_seconds"`                   // Start to end, less pauses
+	IncidentsReported     int            `json:"incidents_reported" db:"incidents_reported"`
+	EarnedCredits         float64        `json:"earned_credits" db:"earned_credits"`
+	ByEndReason           map[string]int `json:"by_end_reason" db:"-"`
+}
+
+// ShiftHistoryPage is one page of ended shifts, newest first, with the summary of all matching shifts
+type ShiftHistoryPage struct {
+	Shifts  []ShiftHistoryEntry `json:"shifts"`
+	Total   int                 `json:"total"`
+	Limit   int                 `json:"limit"`
+	Offset  int                 `json:"offset"`
+	Summary ShiftHistorySummary `json:"summary"`
+}
@@ -0,0 +1,57 @@
+package models
+
+// Pause reason codes a driver picks when pausing a shift
+const (
+	PauseReasonTraffic   = "traffic"
+	PauseReasonBreakdown = "breakdown"
+	PauseReasonBreak     = "break"
+	PauseReasonWeather   = "weather"
+	PauseReasonOther     = "other" // Requires a note
+
+	// PauseReasonUnspecified groups pauses recorded before reasons were required (and recovered pauses)
+	PauseReasonUnspecified = "unspecified"
+)
+
+// PauseReasons lists the reason codes accepted by POST /api/driver/shift/pause
+var PauseReasons = []string{PauseReasonTraffic, PauseReasonBreakdown, PauseReasonBreak, PauseReasonWeather, PauseReasonOther}
+
+// IsValidPauseReason reports whether reason is one of PauseReasons
+func IsValidPauseReason(reason string) bool {
+	for _, r := range PauseReasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// PauseShiftRequest is the body for POST /api/driver/shift/pause
+type PauseShiftRequest struct {
+	Reason string  `json:"reason"`         // One of PauseReasons
+	Note   *string `json:"note,omitempty"` // Free text; required for "other"
+}
+
+// ShiftPause is one pause period of a shift
+type ShiftPause struct {
+	ID              string  `json:"id" db:"id"`
+	ShiftID         string  `json:"shift_id" db:"shift_id"`
+	PausedAt        int64   `json:"paused_at" db:"paused_at"`
+	ResumedAt       *int64  `json:"resumed_at,omitempty" db:"resumed_at"` // nil while the pause is ongoing
+	Reason          *string `json:"reason,omitempty" db:"reason"`
+	Note            *string `json:"note,omitempty" db:"note"`
+	DurationSeconds int64   `json:"duration_seconds" db:"-"` // Up to now for an ongoing pause
+}
+
+// PauseReasonTotal totals a shift's pauses for one reason
+type PauseReasonTotal struct {
+	Reason       string `json:"reason"`
+	Count        int    `json:"count"`
+	TotalSeconds int64  `json:"total_seconds"`
+}
+
+// ShiftPauseSummary is a shift's pauses with totals per reason, as shown in shift details
+type ShiftPauseSummary struct {
+	Pauses       []ShiftPause       `json:"pauses"`
+	ByReason     []PauseReasonTotal `json:"by_reason"`               // Longest total first
+	CurrentPause *ShiftPause        `json:"current_pause,omitempty"` // Set while the shift is paused
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Shift template run statuses
+const (
+	ShiftTemplateRunCreated = "created" // Ready shift instantiated
+	ShiftTemplateRunSkipped = "skipped" // Conflict (driver unavailable, empty route, ...) - retried on the next run
+)
+
+// ShiftTemplate is a recurring shift definition ("morning route A", "weekend run")
+// The materializer instantiates a ready shift from it for every matching day
+type ShiftTemplate struct {
+	ID               string         `json:"id" db:"id"`
+	Name             string         `json:"name" db:"name"`
+	RouteID          string         `json:"route_id" db:"route_id"`
+	DriverID         *string        `json:"driver_id,omitempty" db:"driver_id"`         // Preferred driver
+	StartWindowStart string         `json:"start_window_start" db:"start_window_start"` // HH:MM local time
+	StartWindowEnd   string         `json:"start_window_end" db:"start_window_end"`     // HH:MM local time
+	DaysOfWeek       pq.StringArray `json:"days_of_week" db:"days_of_week"`             // mon..sun, empty = every day
+	Timezone         string         `json:"timezone" db:"timezone"`                     // IANA name, e.g. America/Los_Angeles
+	IsActive         bool           `json:"is_active" db:"is_active"`
+	CreatedByUserID  *string        `json:"created_by_user_id,omitempty" db:"created_by_user_id"`
+	CreatedAt        int64          `json:"created_at" db:"created_at"`
+	UpdatedAt        int64          `json:"updated_at" db:"updated_at"`
+}
+
+// ShiftTemplateRun records one materializer decision for a template and service date
+type ShiftTemplateRun struct {
+	ID           string  `json:"id" db:"id"`
+	TemplateID   string  `json:"template_id" db:"template_id"`
+	TemplateName *string `json:"template_name,omitempty" db:"template_name"`
+	ServiceDate  string  `json:"service_date" db:"service_date"` // YYYY-MM-DD in the template's timezone
+	Status       string  `json:"status" db:"status"`             // created, skipped
+	ShiftID      *string `json:"shift_id,omitempty" db:"shift_id"`
+	Reason       *string `json:"reason,omitempty" db:"reason"`
+	CreatedAt    int64   `json:"created_at" db:"created_at"`
+	UpdatedAt    int64   `json:"updated_at" db:"updated_at"`
+}
+
+// shiftTemplateDays maps days_of_week values to weekdays
+var shiftTemplateDays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// IsValidShiftTemplateDay reports whether d is a days_of_week value (mon..sun)
+func IsValidShiftTemplateDay(d string) bool {
+	_, ok := shiftTemplateDays[d]
+	return ok
+}
+
+// RunsOn reports whether the template recurs on the given weekday
+func (t ShiftTemplate) RunsOn(day time.Weekday) bool {
+	if len(t.DaysOfWeek) == 0 {
+		return true
+	}
+	for _, d := range t.DaysOfWeek {
+		if weekday, ok := shiftTemplateDays[d]; ok && weekday == day {
+			return true
+		}
+	}
+	return false
+}
+
+// StartWindow returns the start window on a service date as unix timestamps
+func (t ShiftTemplate) StartWindow(date time.Time) (int64, int64, error) {
+	start, err := time.Parse("15:04", t.StartWindowStart)
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := time.Parse("15:04", t.StartWindowEnd)
+	if err != nil {
+		return 0, 0, err
+	}
+	y, m, d := date.Date()
+	loc := date.Location()
+	return time.Date(y, m, d, start.Hour(), start.Minute(), 0, 0, loc).Unix(),
+		time.Date(y, m, d, end.Hour(), end.Minute(), 0, 0, loc).Unix(), nil
+}
@@ -0,0 +1,92 @@
+package models
+
+import "fmt"
+
+// Simulation run statuses
+const (
+	SimulationRunning  = "running"
+	SimulationFinished = "finished" // Every driver reached the end of the route
+	SimulationStopped  = "stopped"  // Stopped by an admin (or the server shut down)
+)
+
+// SimulationRun is a route simulation: virtual drivers driving a route for training and demos
+// Runs live in memory only; their drivers, shifts and completions are never stored, so they can't
+// leak into analytics. Every event they broadcast carries "simulated": true
+type SimulationRun struct {
+	ID            string            `json:"id"`
+	RouteID       string            `json:"route_id"`
+	RouteName     string            `json:"route_name"`
+	Status        string            `json:"status"`
+	SpeedKmh      float64           `json:"speed_kmh"`
+	StopSeconds   int               `json:"stop_seconds"`   // Time spent at each stop
+	UpdateSeconds int               `json:"update_seconds"` // Between location updates
+	Loop          bool              `json:"loop"`           // Drivers start over after the last stop instead of finishing
+	StartedBy     string            `json:"started_by"`     // Admin's email
+	StartedAt     int64             `json:"started_at"`
+	EndedAt       *int64            `json:"ended_at,omitempty"`
+	Drivers       []SimulatedDriver `json:"drivers"`
+	Stops         []SimulationStop  `json:"stops"`
+}
+
+// SimulatedDriver is a virtual driver's current state in a simulation run
+// IDs start with "sim-" and never match a user
+type SimulatedDriver struct {
+	ID             string  `json:"id"`
+	Name           string  `json:"name"`
+	ShiftID        string  `json:"shift_id"` // Virtual shift, not in the shifts table
+	Latitude       float64 `json:"latitude"`
+	Longitude      float64 `json:"longitude"`
+	Heading        float64 `json:"heading"`
+	NextStop       int     `json:"next_stop"` // Index into the run's stops
+	CompletedStops int     `json:"completed_stops"`
+	Finished       bool    `json:"finished"`
+}
+
+// SimulationStop is a bin on the simulated route, in route order
+type SimulationStop struct {
+	BinID     string  `json:"bin_id" db:"bin_id"`
+	BinNumber int     `json:"bin_number" db:"bin_number"`
+	Latitude  float64 `json:"latitude" db:"latitude"`
+	Longitude float64 `json:"longitude" db:"longitude"`
+}
+
+// StartSimulationRequest is the body of POST /api/manager/simulations
+type StartSimulationRequest struct {
+	RouteID       string  `json:"route_id" validate:"required"`
+	Drivers       int     `json:"drivers"`        // 1-10, default 1; spread out along the route
+	SpeedKmh      float64 `json:"speed_kmh"`      // 5-200, default 40
+	StopSeconds   *int    `json:"stop_seconds"`   // 0-600, default 20
+	UpdateSeconds int     `json:"update_seconds"` // 1-30, default 2
+	Loop          bool    `json:"loop"`
+}
+
+// Normalize applies the defaults and checks the ranges
+func (r *StartSimulationRequest) Normalize() error {
+	if r.RouteID == "" {
+		return fmt.Errorf("route_id is required")
+	}
+	if r.Drivers == 0 {
+		r.Drivers = 1
+	}
+	if r.SpeedKmh == 0 {
+		r.SpeedKmh = 40
+	}
+	if r.StopSeconds == nil {
+		stopSeconds := 20
+		r.StopSeconds = &stopSeconds
+	}
+	if r.UpdateSeconds == 0 {
+		r.UpdateSeconds = 2
+	}
+	switch {
+	case r.Drivers < 1 || r.Drivers > 10:
+		return fmt.Errorf("drivers must be between 1 and 10")
+	case r.SpeedKmh < 5 || r.SpeedKmh > 200:
+		return fmt.Errorf("speed_kmh must be between 5 and 200")
+	case *r.StopSeconds < 0 || *r.StopSeconds > 600:
+		return fmt.Errorf("stop_seconds must be between 0 and 600")
+	case r.UpdateSeconds < 1 || r.UpdateSeconds > 30:
+		return fmt.Errorf("update_seconds must be between 1 and 30")
+	}
+	return nil
+}
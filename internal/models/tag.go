@@ -0,0 +1,88 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// TagNameMaxLength caps tag names
+const TagNameMaxLength = 50
+
+var tagColorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// Tag is a manager-defined label for grouping bins (e.g. "university", "church lot", "high theft")
+// A bin can have any number of tags (bin_tags)
+type Tag struct {
+	ID              string  `json:"id" db:"id"`
+	Name            string  `json:"name" db:"name"`             // Unique, ignoring case
+	Color           *string `json:"color,omitempty" db:"color"` // "#RRGGBB" for the dashboard
+	CreatedByUserID *string `json:"created_by_user_id,omitempty" db:"created_by_user_id"`
+	CreatedAt       int64   `json:"created_at" db:"created_at"`
+	UpdatedAt       int64   `json:"updated_at" db:"updated_at"`
+}
+
+// TagWithCount is a tag with how many bins have it
+type TagWithCount struct {
+	Tag
+	BinCount int `json:"bin_count" db:"bin_count"`
+}
+
+// TagLabel is a tag as shown on a bin
+type TagLabel struct {
+	ID    string  `json:"id" db:"id"`
+	Name  string  `json:"name" db:"name"`
+	Color *string `json:"color,omitempty" db:"color"`
+}
+
+// TagRequest is the body for creating or updating a tag (fields are optional on update)
+type TagRequest struct {
+	Name  *string `json:"name"`
+	Color *string `json:"color"` // "" clears it
+}
+
+// Normalize trims the fields and checks them; the name is required when creating
+func (r *TagRequest) Normalize(creating bool) error {
+	if r.Name != nil {
+		name := strings.TrimSpace(*r.Name)
+		r.Name = &name
+		if name == "" {
+			return fmt.Errorf("name cannot be empty")
+		}
+		if len(name) > TagNameMaxLength {
+			return fmt.Errorf("name must be at most %d characters", TagNameMaxLength)
+		}
+	} else if creating {
+		return fmt.Errorf("name is required")
+	}
+	if r.Color != nil {
+		color := strings.TrimSpace(*r.Color)
+		r.Color = &color
+		if color != "" && !tagColorPattern.MatchString(color) {
+			return fmt.Errorf("color must be #RRGGBB")
+		}
+	}
+	return nil
+}
+
+// BinTagsRequest is the body for PUT /api/manager/bins/{id}/tags (replaces the bin's tags)
+type BinTagsRequest struct {
+	TagIDs []string `json:"tag_ids"`
+}
+
+// TagBinsRequest is the body for POST /api/manager/tags/{id}/bins (adds the tag to bins)
+type TagBinsRequest struct {
+	BinIDs []string `json:"bin_ids" validate:"required"`
+}
+
+// GenerateRouteFromTagsRequest is the body for POST /api/routes/from-tags
+// The route gets the active bins with the tags, highest weighted priority score first (see PriorityWeights)
+type GenerateRouteFromTagsRequest struct {
+	Name            string   `json:"name" validate:"required"`
+	Description     string   `json:"description"`
+	GeographicArea  string   `json:"geographic_area" validate:"required"`
+	SchedulePattern string   `json:"schedule_pattern"`
+	TagIDs          []string `json:"tag_ids" validate:"required"`
+	MatchAll        bool     `json:"match_all"` // Bins must have every tag (default: any of them)
+	AreaID          *string  `json:"area_id,omitempty"`
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TimeWindow limits when a stop may be serviced, as local "HH:MM" times (start inclusive, end exclusive)
+// Set on bins (e.g. hosts that only allow collection 06:00-09:00) and on move requests, which take precedence
+type TimeWindow struct {
+	Start string `json:"time_window_start"`
+	End   string `json:"time_window_end"`
+}
+
+// SetTimeWindowRequest is the body for PUT .../time-window; null for both clears the window
+type SetTimeWindowRequest struct {
+	TimeWindowStart *string `json:"time_window_start" validate:"max=5"`
+	TimeWindowEnd   *string `json:"time_window_end" validate:"max=5"`
+}
+
+// ParseTimeOfDay converts "HH:MM" (24-hour) to minutes after midnight
+func ParseTimeOfDay(value string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a valid time (use HH:MM)", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// NewTimeWindow validates a start/end pair: both or neither must be set, and start must be before end
+// Returns nil when neither is set
+func NewTimeWindow(start, end *string) (*TimeWindow, error) {
+	hasStart := start != nil && strings.TrimSpace(*start) != ""
+	hasEnd := end != nil && strings.TrimSpace(*end) != ""
+	if !hasStart && !hasEnd {
+		return nil, nil
+	}
+	if !hasStart || !hasEnd {
+		return nil, fmt.Errorf("time_window_start and time_window_end must be set together")
+	}
+	startMinute, err := ParseTimeOfDay(*start)
+	if err != nil {
+		return nil, fmt.Errorf("time_window_start: %w", err)
+	}
+	endMinute, err := ParseTimeOfDay(*end)
+	if err != nil {
+		return nil, fmt.Errorf("time_window_end: %w", err)
+	}
+	if startMinute >= endMinute {
+		return nil, fmt.Errorf("time_window_start must be before time_window_end")
+	}
+	return &TimeWindow{
+		Start: fmt.Sprintf("%02d:%02d", startMinute/60, startMinute%60),
+		End:   fmt.Sprintf("%02d:%02d", endMinute/60, endMinute%60),
+	}, nil
+}
+
+// Minutes returns the window as minutes after midnight
+func (tw TimeWindow) Minutes() (start, end int) {
+	start, _ = ParseTimeOfDay(tw.Start)
+	end, _ = ParseTimeOfDay(tw.End)
+	return start, end
+}
+
+// StopTimeWindow picks a stop's effective window: the move request's window if set, else the bin's
+// Windows are stored already validated, so an unparsable pair is treated as no window
+func StopTimeWindow(moveStart, moveEnd, binStart, binEnd *string) *TimeWindow {
+	if tw, err := NewTimeWindow(moveStart, moveEnd); err == nil && tw != nil {
+		return tw
+	}
+	if tw, err := NewTimeWindow(binStart, binEnd); err == nil && tw != nil {
+		return tw
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// UndoSettings controls how long destructive manager actions can be undone
+type UndoSettings struct {
+	WindowSeconds int `json:"window_seconds"` // 0 turns undo off (no snapshots are taken)
+}
+
+// DefaultUndoSettings returns the built-in undo settings used when none are stored
+func DefaultUndoSettings() UndoSettings {
+	return UndoSettings{WindowSeconds: 60}
+}
+
+// Validate checks the window is between 0 and one hour
+func (s UndoSettings) Validate() error {
+	if s.WindowSeconds < 0 || s.WindowSeconds > 3600 {
+		return fmt.Errorf("window_seconds must be between 0 and 3600")
+	}
+	return nil
+}
+
+// Undoable operation types
+const (
+	UndoMoveRequestCancel          = "move_request.cancel"
+	UndoMoveRequestClearAssignment = "move_request.clear_assignment"
+)
+
+// UndoOperation is a destructive manager action that can be reverted until it expires
+type UndoOperation struct {
+	ID                string          `json:"id" db:"id"`
+	OperationType     string          `json:"operation_type" db:"operation_type"`
+	EntityType        string          `json:"entity_type" db:"entity_type"`
+	EntityID          string          `json:"entity_id" db:"entity_id"`
+	Summary           string          `json:"summary" db:"summary"` // Shown on the dashboard's undo prompt
+	Snapshot          json.RawMessage `json:"-" db:"snapshot"`      // State before the action, decoded per operation type
+	PerformedByUserID *string         `json:"performed_by_user_id,omitempty" db:"performed_by_user_id"`
+	PerformedByName   *string         `json:"performed_by_name,omitempty" db:"performed_by_name"`
+	PerformedAt       int64           `json:"performed_at" db:"performed_at"`
+	ExpiresAt         int64           `json:"expires_at" db:"expires_at"`
+	UndoneAt          *int64          `json:"undone_at,omitempty" db:"undone_at"`
+	UndoneByUserID    *string         `json:"undone_by_user_id,omitempty" db:"undone_by_user_id"`
+}
+
+// MoveRequestUndoSnapshot is a move request's state before it was cancelled or had its assignment cleared
+type MoveRequestUndoSnapshot struct {
+	MoveRequestID   string      `json:"move_request_id"`
+	BinID           string      `json:"bin_id"`
+	BinNumber       int         `json:"bin_number"`
+	Status          string      `json:"status"`
+	AssignmentType  *string     `json:"assignment_type,omitempty"`
+	AssignedShiftID *string     `json:"assigned_shift_id,omitempty"`
+	AssignedUserID  *string     `json:"assigned_user_id,omitempty"`
+	BinStatus       string      `json:"bin_status"`
+	RemovedStops    []RouteTask `json:"removed_stops"` // Incomplete stops taken off the assigned shift's route
+
+	// What the action left behind; undo refuses if the move request changed since
+	AppliedStatus string `json:"applied_status"`
+	AppliedAt     int64  `json:"applied_at"`
+}
@@ -1,29 +1,62 @@
 package models
 
 type User struct {
-	ID        string `json:"id" db:"id"`
-	Email     string `json:"email" db:"email"`
-	Password  string `json:"-" db:"password"` // Never return password in JSON
-	Name      string `json:"name" db:"name"`
-	Role      string `json:"role" db:"role"` // "driver" or "admin"
-	CreatedAt int64  `json:"created_at" db:"created_at"`
-	UpdatedAt int64  `json:"updated_at" db:"updated_at"`
+	ID                  string  `json:"id" db:"id"`
+	Email               string  `json:"email" db:"email"`
+	Password            string  `json:"-" db:"password"` // Never return password in JSON
+	Name                string  `json:"name" db:"name"`
+	Role                string  `json:"role" db:"role"`               // "driver" or "admin"
+	Locale              *string `json:"locale,omitempty" db:"locale"` // "en" or "es"; nil = use Accept-Language
+	DeactivatedAt       *int64  `json:"deactivated_at,omitempty" db:"deactivated_at"`
+	DeactivatedByUserID *string `json:"deactivated_by_user_id,omitempty" db:"deactivated_by_user_id"`
+	DeactivationReason  *string `json:"deactivation_reason,omitempty" db:"deactivation_reason"`
+	AnonymizedAt        *int64  `json:"anonymized_at,omitempty" db:"anonymized_at"` // Personal data erased; the row stays for history
+	CreatedAt           int64   `json:"created_at" db:"created_at"`
+	UpdatedAt           int64   `json:"updated_at" db:"updated_at"`
 }
 
 type UserResponse struct {
-	ID        string `json:"id"`
-	Email     string `json:"email"`
-	Name      string `json:"name"`
-	Role      string `json:"role"`
-	CreatedAt int64  `json:"created_at"`
+	ID                 string  `json:"id"`
+	Email              string  `json:"email"`
+	Name               string  `json:"name"`
+	Role               string  `json:"role"`
+	Locale             *string `json:"locale,omitempty"`
+	Active             bool    `json:"active"`
+	DeactivatedAt      *int64  `json:"deactivated_at,omitempty"`
+	DeactivationReason *string `json:"deactivation_reason,omitempty"`
+	AnonymizedAt       *int64  `json:"anonymized_at,omitempty"`
+	CreatedAt          int64   `json:"created_at"`
+}
+
+// IsDeactivated reports whether the user has been offboarded
+func (u *User) IsDeactivated() bool {
+	return u.DeactivatedAt != nil
 }
 
 func (u *User) ToUserResponse() UserResponse {
 	return UserResponse{
-		ID:        u.ID,
-		Email:     u.Email,
-		Name:      u.Name,
-		Role:      u.Role,
-		CreatedAt: u.CreatedAt,
+		ID:                 u.ID,
+		Email:              u.Email,
+		Name:               u.Name,
+		Role:               u.Role,
+		Locale:             u.Locale,
+		Active:             !u.IsDeactivated(),
+		DeactivatedAt:      u.DeactivatedAt,
+		DeactivationReason: u.DeactivationReason,
+		AnonymizedAt:       u.AnonymizedAt,
+		CreatedAt:          u.CreatedAt,
 	}
 }
+
+// UserDeactivation summarizes the offboarding done by PATCH /api/manager/users/{id}
+type UserDeactivation struct {
+	EndedShiftIDs        []string `json:"ended_shift_ids"`        // Active or paused shifts ended (history kept)
+	CancelledShiftIDs    []string `json:"cancelled_shift_ids"`    // Ready shifts that never started
+	ReleasedMoveRequests []string `json:"released_move_requests"` // Assigned or in-progress moves returned to pending
+}
+
+// UserUpdateResponse is the response of PATCH /api/manager/users/{id}
+type UserUpdateResponse struct {
+	User         UserResponse      `json:"user"`
+	Deactivation *UserDeactivation `json:"deactivation,omitempty"` // Set when the request deactivated the user
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"encoding/json"
+
+	"github.com/lib/pq"
+)
+
+// Webhook event types
+const (
+	WebhookEventShiftStarted         = "shift.started"
+	WebhookEventShiftEnded           = "shift.ended"
+	WebhookEventShiftCancelled       = "shift.cancelled"
+	WebhookEventMoveRequestCompleted = "move_request.completed"
+	WebhookEventIncidentCreated      = "incident.created"
+	WebhookEventBinRetired           = "bin.retired"
+	WebhookEventPing                 = "webhook.ping" // Sent by the test endpoint only
+)
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed" // Gave up after the maximum number of attempts
+)
+
+// Webhook is an external URL that receives signed event notifications
+type Webhook struct {
+	ID              string         `json:"id" db:"id"`
+	URL             string         `json:"url" db:"url"`
+	Secret          string         `json:"-" db:"secret"`                // HMAC signing key (only returned on create)
+	EventTypes      pq.StringArray `json:"event_types" db:"event_types"` // Empty = all events
+	Description     *string        `json:"description,omitempty" db:"description"`
+	IsActive        bool           `json:"is_active" db:"is_active"`
+	CreatedByUserID *string        `json:"created_by_user_id,omitempty" db:"created_by_user_id"`
+	CreatedAt       int64          `json:"created_at" db:"created_at"`
+	UpdatedAt       int64          `json:"updated_at" db:"updated_at"`
+}
+
+// WebhookDelivery is one event queued for (or delivered to) a webhook
+type WebhookDelivery struct {
+	ID             string          `json:"id" db:"id"`
+	WebhookID      string          `json:"webhook_id" db:"webhook_id"`
+	EventID        string          `json:"event_id" db:"event_id"` // Shared by all deliveries of the same event
+	EventType      string          `json:"event_type" db:"event_type"`
+	Payload        json.RawMessage `json:"payload" db:"payload"`
+	Status         string          `json:"status" db:"status"` // pending, succeeded, failed
+	Attempts       int             `json:"attempts" db:"attempts"`
+	NextAttemptAt  int64           `json:"next_attempt_at" db:"next_attempt_at"`
+	LastAttemptAt  *int64          `json:"last_attempt_at,omitempty" db:"last_attempt_at"`
+	LastStatusCode *int            `json:"last_status_code,omitempty" db:"last_status_code"`
+	LastError      *string         `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt      int64           `json:"created_at" db:"created_at"`
+	UpdatedAt      int64           `json:"updated_at" db:"updated_at"`
+}
+
+// WebhookEvent is the JSON body POSTed to webhook URLs
+type WebhookEvent struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt int64       `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// IsValidWebhookEventType reports whether t is an event type webhooks can subscribe to
+func IsValidWebhookEventType(t string) bool {
+	switch t {
+	case WebhookEventShiftStarted, WebhookEventShiftEnded, WebhookEventShiftCancelled,
+		WebhookEventMoveRequestCompleted, WebhookEventIncidentCreated, WebhookEventBinRetired:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package models
+
+import "fmt"
+
+// WorkloadLimits caps how much work a driver may have planned; assignments past a limit need force=true
+// A limit of 0 is not enforced
+type WorkloadLimits struct {
+	MaxBins       int     `json:"max_bins"`        // Stops across the driver's open shifts
+	MaxHours      float64 `json:"max_hours"`       // Hours worked today plus estimated hours still planned
+	MaxDistanceKm float64 `json:"max_distance_km"` // Estimated distance still to drive
+}
+
+// DefaultWorkloadLimits returns the built-in limits used when no settings are stored
+func DefaultWorkloadLimits() WorkloadLimits {
+	return WorkloadLimits{
+		MaxBins:       60,
+		MaxHours:      10,
+		MaxDistanceKm: 200,
+	}
+}
+
+// Validate checks that every limit is non-negative
+func (l WorkloadLimits) Validate() error {
+	if l.MaxBins < 0 {
+		return fmt.Errorf("max_bins must not be negative")
+	}
+	if l.MaxHours < 0 || l.MaxHours > 24 {
+		return fmt.Errorf("max_hours must be between 0 and 24")
+	}
+	if l.MaxDistanceKm < 0 {
+		return fmt.Errorf("max_distance_km must not be negative")
+	}
+	return nil
+}
+
+// DriverWorkload is an estimate of a driver's planned work (straight-line distances at the ETA model's
+// average speed, plus service time per stop)
+type DriverWorkload struct {
+	Shifts     int     `json:"shifts"`
+	Bins       int     `json:"bins"`
+	Hours      float64 `json:"hours"`
+	DistanceKm float64 `json:"distance_km"`
+}
+
+// RouteAssignmentPreview projects a driver's workload if a route is assigned to them
+type RouteAssignmentPreview struct {
+	DriverID      string         `json:"driver_id"`
+	Current       DriverWorkload `json:"current"`   // Ready, active and paused shifts already assigned
+	Route         DriverWorkload `json:"route"`     // The route being assigned
+	Projected     DriverWorkload `json:"projected"` // Current + route
+	Limits        WorkloadLimits `json:"limits"`
+	Warnings      []string       `json:"warnings"`
+	ExceedsLimits bool           `json:"exceeds_limits"` // AssignRoute refuses without force=true
+
+	// When the driver's remaining work would be done, against the close of service hours (nil when disabled)
+	// AssignRoute also refuses without force=true when it exceeds them and the policy is "block"
+	ServiceHours *ServiceHoursCheck `json:"service_hours,omitempty"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import "fmt"
+
+// Stop risk levels from the no-go zone a bin lies in
+const (
+	RiskLevelHigh     = "high"     // Inside an active zone
+	RiskLevelElevated = "elevated" // Inside a zone under monitoring
+)
+
+// ZoneRiskRoutingSettings controls how the route optimizer treats bins inside no-go zones
+// A stop's weight makes it look proportionally closer (weight 1 = half the distance), so risky
+// stops are visited earlier in the shift, while it is still light out
+type ZoneRiskRoutingSettings struct {
+	Enabled              bool    `json:"enabled"`
+	ActiveZoneWeight     float64 `json:"active_zone_weight"`     // Weight of a bin inside an active zone
+	MonitoringZoneWeight float64 `json:"monitoring_zone_weight"` // Weight of a bin inside a zone under monitoring
+	ConflictScoreWeight  float64 `json:"conflict_score_weight"`  // Added per point of the zone's conflict score
+	MaxWeight            float64 `json:"max_weight"`             // Cap on a stop's total weight
+}
+
+// DefaultZoneRiskRoutingSettings returns the built-in zone risk settings used when none are stored
+func DefaultZoneRiskRoutingSettings() ZoneRiskRoutingSettings {
+	return ZoneRiskRoutingSettings{
+		Enabled:              true,
+		ActiveZoneWeight:     1.0,
+		MonitoringZoneWeight: 0.5,
+		ConflictScoreWeight:  0.01,
+		MaxWeight:            3.0,
+	}
+}
+
+// Validate checks the weights are non-negative and the cap is at most 10
+func (s ZoneRiskRoutingSettings) Validate() error {
+	if s.ActiveZoneWeight < 0 || s.MonitoringZoneWeight < 0 || s.ConflictScoreWeight < 0 {
+		return fmt.Errorf("zone weights must not be negative")
+	}
+	if s.MaxWeight < 0 || s.MaxWeight > 10 {
+		return fmt.Errorf("max_weight must be between 0 and 10")
+	}
+	return nil
+}
+
+// Weight is the routing weight of a bin in a zone with the given status and conflict score (0 when disabled)
+func (s ZoneRiskRoutingSettings) Weight(zoneStatus string, conflictScore int) float64 {
+	if !s.Enabled {
+		return 0
+	}
+	weight := s.MonitoringZoneWeight
+	if zoneStatus == "active" {
+		weight = s.ActiveZoneWeight
+	}
+	weight += float64(conflictScore) * s.ConflictScoreWeight
+	if weight > s.MaxWeight {
+		weight = s.MaxWeight
+	}
+	return weight
+}
+
+// StopRisk is a bin's risk from the riskiest no-go zone it lies in
+type StopRisk struct {
+	Level    string  `json:"risk_level"` // RiskLevelHigh or RiskLevelElevated
+	Weight   float64 `json:"risk_weight"`
+	ZoneID   string  `json:"risk_zone_id"`
+	ZoneName string  `json:"risk_zone_name"`
+}
@@ -0,0 +1,20 @@
+package models
+
+// ZoneVisit is a period a driver spent inside an active no-go zone, detected from their GPS
+// ExitedAt is nil while the driver is still inside
+type ZoneVisit struct {
+	ID              string   `json:"id" db:"id"`
+	ZoneID          string   `json:"zone_id" db:"zone_id"`
+	ZoneName        string   `json:"zone_name" db:"zone_name"`
+	ConflictScore   int      `json:"conflict_score" db:"conflict_score"`
+	DriverID        string   `json:"driver_id" db:"driver_id"`
+	DriverName      string   `json:"driver_name" db:"driver_name"`
+	ShiftID         *string  `json:"shift_id,omitempty" db:"shift_id"`
+	EnteredAt       int64    `json:"entered_at" db:"entered_at"`
+	EntryLatitude   float64  `json:"entry_latitude" db:"entry_latitude"`
+	EntryLongitude  float64  `json:"entry_longitude" db:"entry_longitude"`
+	ExitedAt        *int64   `json:"exited_at,omitempty" db:"exited_at"`
+	ExitLatitude    *float64 `json:"exit_latitude,omitempty" db:"exit_latitude"`
+	ExitLongitude   *float64 `json:"exit_longitude,omitempty" db:"exit_longitude"`
+	DurationSeconds *int64   `json:"duration_seconds,omitempty" db:"duration_seconds"` // Set once the driver has left
+}
@@ -0,0 +1,216 @@
+package openapi
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var rawMessageType = reflect.TypeOf(json.RawMessage{})
+
+// schemaRegistry converts Go types to JSON schemas, collecting named structs as reusable components
+type schemaRegistry struct {
+	components map[string]interface{}
+	names      map[reflect.Type]string
+}
+
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{components: map[string]interface{}{}, names: map[reflect.Type]string{}}
+}
+
+// componentName picks a unique component name for a named struct (package-qualified on collision)
+func (s *schemaRegistry) componentName(t reflect.Type) string {
+	if name, ok := s.names[t]; ok {
+		return name
+	}
+	name := strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+	for _, taken := range s.names {
+		if taken == name {
+			pkg := path.Base(t.PkgPath())
+			name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+			break
+		}
+	}
+	s.names[t] = name
+	return name
+}
+
+// schemaFor returns the schema of a type, as a $ref for named structs
+func (s *schemaRegistry) schemaFor(t reflect.Type) map[string]interface{} {
+	nullable := false
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		nullable = true
+	}
+
+	schema := s.schemaForValue(t)
+	if nullable {
+		if _, isRef := schema["$ref"]; isRef {
+			// OpenAPI 3.0 ignores siblings of $ref
+			return map[string]interface{}{"allOf": []interface{}{schema}, "nullable": true}
+		}
+		schema["nullable"] = true
+	}
+	return schema
+}
+
+func (s *schemaRegistry) schemaForValue(t reflect.Type) map[string]interface{} {
+	if t == rawMessageType {
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": s.schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": s.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.structSchema(t)
+		}
+		name := s.componentName(t)
+		if _, done := s.components[name]; !done {
+			s.components[name] = map[string]interface{}{} // Placeholder for recursive types
+			s.components[name] = s.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	}
+	return map[string]interface{}{} // interface{} and anything else: any value
+}
+
+// structSchema builds an object schema from json and validate tags (embedded structs are flattened)
+func (s *schemaRegistry) structSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	var required []string
+
+	for _, field := range jsonFields(t) {
+		schema := s.schemaFor(field.Type)
+		rules := parseRules(field.Tag.Get("validate"))
+		if rules.required {
+			required = append(required, field.name)
+		}
+		applyRules(schema, field.Type, rules)
+		properties[field.name] = schema
+	}
+
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// applyRules documents validate tag constraints on a property schema
+func applyRules(schema map[string]interface{}, t reflect.Type, r rules) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	minKey, maxKey := "minimum", "maximum"
+	switch t.Kind() {
+	case reflect.String:
+		minKey, maxKey = "minLength", "maxLength"
+	case reflect.Slice, reflect.Array:
+		minKey, maxKey = "minItems", "maxItems"
+	}
+	if r.min != nil {
+		schema[minKey] = *r.min
+	}
+	if r.max != nil {
+		schema[maxKey] = *r.max
+	}
+	if len(r.oneOf) > 0 {
+		schema["enum"] = r.oneOf
+	}
+	if r.format != "" {
+		schema["format"] = r.format
+	}
+}
+
+// jsonField is a struct field as it appears in JSON
+type jsonField struct {
+	reflect.StructField
+	name  string
+	index []int
+}
+
+// jsonFields lists the JSON-visible fields of a struct, flattening untagged embedded structs like encoding/json
+func jsonFields(t reflect.Type) []jsonField {
+	var fields []jsonField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				for _, inner := range jsonFields(embedded) {
+					inner.index = append([]int{i}, inner.index...)
+					fields = append(fields, inner)
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, jsonField{StructField: field, name: name, index: []int{i}})
+	}
+	return fields
+}
+
+// rules are the parsed constraints of a validate tag, e.g. validate:"required,min=0,max=100,oneof=ios android,format=uri"
+type rules struct {
+	required bool
+	min      *float64
+	max      *float64
+	oneOf    []string
+	format   string // uri, email
+}
+
+func parseRules(tag string) rules {
+	var r rules
+	for _, rule := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch key {
+		case "required":
+			r.required = true
+		case "min", "max":
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			if key == "min" {
+				r.min = &n
+			} else {
+				r.max = &n
+			}
+		case "oneof":
+			r.oneOf = strings.Fields(value)
+		case "format":
+			r.format = value
+		}
+	}
+	return r
+}
@@ -0,0 +1,304 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"ropacal-backend/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Operation documents one API route
+// Request and Response are zero values of the typed body structs (nil = no body / untyped);
+// the response is wrapped in the standard { "success": true, "data": ... } envelope
+type Operation struct {
+	Method      string
+	Path        string // chi pattern, e.g. /api/manager/areas/{id}
+	Summary     string
+	Tag         string
+	Auth        string // "", "driver" (any authenticated user), "admin" or "api_key" (X-API-Key header)
+	Query       []Param
+	Request     interface{}
+	Response    interface{}
+	Status      int  // Success status (default 200)
+	RawResponse bool // Response is returned as-is instead of inside the success/data envelope
+}
+
+// Param is a documented query parameter
+type Param struct {
+	Name        string
+	Type        string // string, integer, number, boolean
+	Description string
+}
+
+// Spec is a registry of documented operations that renders an OpenAPI 3 document
+// It also drives request body validation (see middleware.ValidateRequestBody)
+type Spec struct {
+	title      string
+	version    string
+	operations []Operation
+
+	once     sync.Once
+	document []byte
+}
+
+// New creates an empty spec
+func New(title, version string) *Spec {
+	return &Spec{title: title, version: version}
+}
+
+// Add registers operations
+func (s *Spec) Add(ops ...Operation) {
+	s.operations = append(s.operations, ops...)
+}
+
+// RequestType returns the documented request body type of the route matching method and path (nil if none)
+func (s *Spec) RequestType(method, path string) reflect.Type {
+	for _, op := range s.operations {
+		if op.Method == method && op.Request != nil && matchPattern(op.Path, path) {
+			return reflect.TypeOf(op.Request)
+		}
+	}
+	return nil
+}
+
+// matchPattern reports whether a request path matches a chi pattern ({param} matches one segment)
+func matchPattern(pattern, path string) bool {
+	patternParts := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(patternParts) != len(pathParts) {
+		return false
+	}
+	for i, part := range patternParts {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			if pathParts[i] == "" {
+				return false
+			}
+			continue
+		}
+		if part != pathParts[i] {
+			return false
+		}
+	}
+	return true
+}
+
+var pathParamPattern = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+// Document builds the OpenAPI 3 document
+func (s *Spec) Document() map[string]interface{} {
+	schemas := newSchemaRegistry()
+	paths := map[string]map[string]interface{}{}
+
+	for _, op := range s.operations {
+		operation := map[string]interface{}{
+			"summary":     op.Summary,
+			"operationId": operationID(op),
+		}
+		if op.Tag != "" {
+			operation["tags"] = []string{op.Tag}
+		}
+		if op.Auth == "api_key" {
+			operation["security"] = []map[string][]string{{"apiKeyAuth": {}}}
+		} else if op.Auth != "" {
+			operation["security"] = []map[string][]string{{"bearerAuth": {}}}
+		}
+
+		var params []map[string]interface{}
+		for _, match := range pathParamPattern.FindAllStringSubmatch(op.Path, -1) {
+			params = append(params, map[string]interface{}{
+				"name":     match[1],
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]interface{}{"type": "string"},
+			})
+		}
+		for _, q := range op.Query {
+			param := map[string]interface{}{
+				"name":   q.Name,
+				"in":     "query",
+				"schema": map[string]interface{}{"type": q.Type},
+			}
+			if q.Description != "" {
+				param["description"] = q.Description
+			}
+			params = append(params, param)
+		}
+		if len(params) > 0 {
+			operation["parameters"] = params
+		}
+
+		if op.Request != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": schemas.schemaFor(reflect.TypeOf(op.Request))},
+				},
+			}
+		}
+
+		status := op.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		operation["responses"] = map[string]interface{}{
+			fmt.Sprint(status): responseFor(op, schemas),
+			"400":              map[string]interface{}{"$ref": "#/components/responses/BadRequest"},
+			"default":          map[string]interface{}{"$ref": "#/components/responses/Error"},
+		}
+		if op.Auth != "" {
+			operation["responses"].(map[string]interface{})["401"] = map[string]interface{}{"$ref": "#/components/responses/Error"}
+		}
+
+		path := pathParamPattern.ReplaceAllString(op.Path, "{$1}")
+		if paths[path] == nil {
+			paths[path] = map[string]interface{}{}
+		}
+		paths[path][strings.ToLower(op.Method)] = operation
+	}
+
+	validationErrorSchema := schemas.schemaFor(reflect.TypeOf(ValidationErrorResponse{}))
+	errorSchema := schemas.schemaFor(reflect.TypeOf(utils.ErrorResponse{}))
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   s.title,
+			"version": s.version,
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas.components,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+				"apiKeyAuth": map[string]interface{}{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+			},
+			"responses": map[string]interface{}{
+				"BadRequest": map[string]interface{}{
+					"description": "Malformed or invalid request body",
+					"content": map[string]interface{}{
+						"application/json": map[string]interface{}{"schema": validationErrorSchema},
+					},
+				},
+				"Error": map[string]interface{}{
+					"description": "Error",
+					"content": map[string]interface{}{
+						"application/json": map[string]interface{}{"schema": errorSchema},
+					},
+				},
+			},
+		},
+	}
+}
+
+// responseFor returns the success response object of an operation
+func responseFor(op Operation, schemas *schemaRegistry) map[string]interface{} {
+	response := map[string]interface{}{"description": "Success"}
+
+	var schema map[string]interface{}
+	switch {
+	case op.RawResponse && op.Response != nil:
+		schema = schemas.schemaFor(reflect.TypeOf(op.Response))
+	case op.RawResponse:
+		return response
+	default:
+		properties := map[string]interface{}{"success": map[string]interface{}{"type": "boolean"}}
+		if op.Response != nil {
+			properties["data"] = schemas.schemaFor(reflect.TypeOf(op.Response))
+		}
+		schema = map[string]interface{}{"type": "object", "properties": properties}
+	}
+
+	response["content"] = map[string]interface{}{
+		"application/json": map[string]interface{}{"schema": schema},
+	}
+	return response
+}
+
+// operationID derives a stable operationId from the method and path
+func operationID(op Operation) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(op.Method))
+	for _, part := range strings.FieldsFunc(strings.TrimPrefix(op.Path, "/api"), func(r rune) bool {
+		return r == '/' || r == '-' || r == '{' || r == '}' || r == '_'
+	}) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// Handler serves the OpenAPI document as JSON (built once, on first request)
+func (s *Spec) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.once.Do(func() {
+			document, err := json.MarshalIndent(s.Document(), "", "  ")
+			if err != nil {
+				log.Printf("❌ [OPENAPI] Failed to render document: %v", err)
+				return
+			}
+			s.document = document
+		})
+		if s.document == nil {
+			utils.RespondError(w, http.StatusInternalServerError, "Failed to render OpenAPI document")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(s.document)
+	}
+}
+
+// swaggerUIPage loads Swagger UI from the CDN and points it at the spec
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>%s</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: %q, dom_id: "#swagger-ui", persistAuthorization: true });
+  </script>
+</body>
+</html>`
+
+// SwaggerUIHandler serves a Swagger UI page for the document at specURL
+func (s *Spec) SwaggerUIHandler(specURL string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, swaggerUIPage, s.title, specURL)
+	}
+}
+
+// ReportUndocumented logs registered routes that have no documented operation
+func (s *Spec) ReportUndocumented(routes chi.Routes) {
+	documented := map[string]bool{}
+	for _, op := range s.operations {
+		documented[op.Method+" "+op.Path] = true
+	}
+
+	var missing []string
+	chi.Walk(routes, func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		route = strings.TrimSuffix(strings.ReplaceAll(route, "/*/", "/"), "/")
+		if method != http.MethodOptions && !documented[method+" "+route] && strings.HasPrefix(route, "/api/") {
+			missing = append(missing, method+" "+route)
+		}
+		return nil
+	})
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		log.Printf("⚠️  [OPENAPI] %d routes are not documented: %s", len(missing), strings.Join(missing, ", "))
+	}
+}
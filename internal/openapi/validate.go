@@ -0,0 +1,233 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// FieldError is a validation failure for one request field (dotted JSON path, e.g. "stops[2].latitude")
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrorResponse is the 400 body returned for malformed or invalid request payloads
+// (the standard error body, see utils.ErrorResponse, with code "validation_failed" and the field errors as details)
+type ValidationErrorResponse struct {
+	Success   bool         `json:"success"`
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	Error     string       `json:"error"`
+	Details   []FieldError `json:"details"`
+	RequestID string       `json:"request_id,omitempty"`
+}
+
+// ValidateJSON decodes body into a new value of type t and checks its validate tags
+// Returns nil when the payload is valid
+func ValidateJSON(body []byte, t reflect.Type) []FieldError {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return []FieldError{{Field: "", Message: "request body is required"}}
+	}
+
+	value := reflect.New(t)
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	if err := decoder.Decode(value.Interface()); err != nil {
+		return []FieldError{decodeError(err)}
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return []FieldError{{Field: "", Message: "request body must contain a single JSON value"}}
+	}
+
+	var errs []FieldError
+	validateValue(value.Elem(), "", &errs)
+	return errs
+}
+
+// decodeError converts a JSON decoding error to a field error
+func decodeError(err error) FieldError {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return FieldError{Field: typeErr.Field, Message: fmt.Sprintf("must be %s, got %s", jsonTypeName(typeErr.Type), typeErr.Value)}
+	}
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return FieldError{Field: "", Message: fmt.Sprintf("malformed JSON at offset %d", syntaxErr.Offset)}
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return FieldError{Field: "", Message: "malformed JSON (unexpected end of input)"}
+	}
+	return FieldError{Field: "", Message: err.Error()}
+}
+
+// jsonTypeName describes a Go type in JSON terms for error messages
+func jsonTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Struct, reflect.Map:
+		return "an object"
+	}
+	return t.String()
+}
+
+// validateValue walks a decoded value, checking validate tags on struct fields
+func validateValue(v reflect.Value, path string, errs *[]FieldError) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if v.Type() == rawMessageType {
+			return
+		}
+		for _, field := range jsonFields(v.Type()) {
+			fieldValue, ok := fieldByIndex(v, field.index)
+			if !ok {
+				continue
+			}
+			fieldPath := field.name
+			if path != "" {
+				fieldPath = path + "." + field.name
+			}
+			if msg := checkRules(fieldValue, parseRules(field.Tag.Get("validate"))); msg != "" {
+				*errs = append(*errs, FieldError{Field: fieldPath, Message: msg})
+				continue
+			}
+			validateValue(fieldValue, fieldPath, errs)
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Type() == rawMessageType {
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			validateValue(v.Index(i), path+"["+strconv.Itoa(i)+"]", errs)
+		}
+	}
+}
+
+// fieldByIndex is reflect.Value.FieldByIndex that stops at nil embedded pointers
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 {
+			if v.Kind() == reflect.Ptr {
+				if v.IsNil() {
+					return reflect.Value{}, false
+				}
+				v = v.Elem()
+			}
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// checkRules returns a message for the first rule the value breaks ("" if valid)
+// Optional (nil) pointers and empty optional values skip the remaining rules
+func checkRules(v reflect.Value, r rules) string {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			if r.required {
+				return "is required"
+			}
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		s := v.String()
+		if strings.TrimSpace(s) == "" {
+			if r.required {
+				return "is required"
+			}
+			return ""
+		}
+		if r.min != nil && float64(len([]rune(s))) < *r.min {
+			return fmt.Sprintf("must be at least %g characters", *r.min)
+		}
+		if r.max != nil && float64(len([]rune(s))) > *r.max {
+			return fmt.Sprintf("must be at most %g characters", *r.max)
+		}
+		if len(r.oneOf) > 0 && !containsString(r.oneOf, s) {
+			return "must be one of: " + strings.Join(r.oneOf, ", ")
+		}
+		switch r.format {
+		case "uri":
+			parsed, err := url.Parse(s)
+			if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+				return "must be an absolute URL"
+			}
+		case "email":
+			if _, err := mail.ParseAddress(s); err != nil {
+				return "must be a valid email address"
+			}
+		}
+
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if v.Len() == 0 && r.required {
+			return "must not be empty"
+		}
+		if r.min != nil && float64(v.Len()) < *r.min {
+			return fmt.Sprintf("must have at least %g items", *r.min)
+		}
+		if r.max != nil && float64(v.Len()) > *r.max {
+			return fmt.Sprintf("must have at most %g items", *r.max)
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return checkNumber(float64(v.Int()), r)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return checkNumber(float64(v.Uint()), r)
+	case reflect.Float32, reflect.Float64:
+		return checkNumber(v.Float(), r)
+	}
+	return ""
+}
+
+// checkNumber checks min/max bounds (required non-pointer numbers must be non-zero)
+func checkNumber(n float64, r rules) string {
+	if r.required && n == 0 {
+		return "is required"
+	}
+	if r.min != nil && n < *r.min {
+		return fmt.Sprintf("must be at least %g", *r.min)
+	}
+	if r.max != nil && n > *r.max {
+		return fmt.Sprintf("must be at most %g", *r.max)
+	}
+	return ""
+}
+
+func containsString(values []string, s string) bool {
+	for _, value := range values {
+		if value == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,327 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/smtp"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ropacal-backend/internal/websocket"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// AlertSeverity orders operational alerts; alerts below the configured threshold are dropped
+type AlertSeverity int
+
+const (
+	AlertInfo AlertSeverity = iota
+	AlertWarning
+	AlertCritical
+)
+
+func (s AlertSeverity) String() string {
+	switch s {
+	case AlertInfo:
+		return "info"
+	case AlertWarning:
+		return "warning"
+	default:
+		return "critical"
+	}
+}
+
+// ParseAlertSeverity parses "info", "warning" or "critical"
+func ParseAlertSeverity(value string) (AlertSeverity, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "info":
+		return AlertInfo, nil
+	case "warning":
+		return AlertWarning, nil
+	case "critical":
+		return AlertCritical, nil
+	}
+	return AlertInfo, fmt.Errorf("unknown alert severity %q (use info, warning or critical)", value)
+}
+
+// Operational events that raise alerts
+const (
+	AlertEventMigrationFailed    = "migration_failed"
+	AlertEventFCMInitFailed      = "fcm_init_failed"
+	AlertEventDriverDisconnected = "driver_disconnected_mid_shift"
+)
+
+// Alerting defaults
+const (
+	defaultAlertThrottle         = 15 * time.Minute
+	defaultDriverDisconnectGrace = 2 * time.Minute // Drivers reconnecting within this long are not reported
+	alertRequestTimeout          = 10 * time.Second
+)
+
+// Alert is an operational incident sent to every configured sink
+type Alert struct {
+	Event    string
+	Severity AlertSeverity
+	Title    string
+	Message  string
+	Key      string            // Distinguishes alerts of the same event for throttling (e.g. the driver ID)
+	Fields   map[string]string // Extra context, rendered as key/value lines
+	At       time.Time
+}
+
+// AlertSink delivers alerts to one channel
+type AlertSink interface {
+	Name() string
+	Send(alert Alert) error
+}
+
+// Alerter sends operational alerts to its sinks, dropping those below the severity threshold
+// and repeats of the same event and key within the throttle window
+type Alerter struct {
+	sinks       []AlertSink
+	minSeverity AlertSeverity
+	throttle    time.Duration
+
+	mu         sync.Mutex
+	lastSent   map[string]time.Time
+	suppressed map[string]int // Repeats dropped since the last alert sent for the key
+}
+
+// NewAlerter creates an alerter; with no sinks alerts are only logged
+func NewAlerter(sinks []AlertSink, minSeverity AlertSeverity, throttle time.Duration) *Alerter {
+	return &Alerter{
+		sinks:       sinks,
+		minSeverity: minSeverity,
+		throttle:    throttle,
+		lastSent:    make(map[string]time.Time),
+		suppressed:  make(map[string]int),
+	}
+}
+
+// NewAlerterFromEnv configures sinks from the environment:
+// ALERT_SLACK_WEBHOOK_URL for Slack; ALERT_SMTP_HOST, ALERT_EMAIL_FROM and ALERT_EMAIL_TO (comma-separated) for email,
+// with ALERT_SMTP_PORT (587), ALERT_SMTP_USERNAME and ALERT_SMTP_PASSWORD.
+// ALERT_MIN_SEVERITY (warning) and ALERT_THROTTLE_MINUTES (15) control what is sent
+func NewAlerterFromEnv() *Alerter {
+	var sinks []AlertSink
+	if url := os.Getenv("ALERT_SLACK_WEBHOOK_URL"); url != "" {
+		sinks = append(sinks, NewSlackAlertSink(url))
+	}
+	if host := os.Getenv("ALERT_SMTP_HOST"); host != "" {
+		port := os.Getenv("ALERT_SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		var to []string
+		for _, addr := range strings.Split(os.Getenv("ALERT_EMAIL_TO"), ",") {
+			if addr = strings.TrimSpace(addr); addr != "" {
+				to = append(to, addr)
+			}
+		}
+		from := os.Getenv("ALERT_EMAIL_FROM")
+		if from == "" || len(to) == 0 {
+			log.Println("⚠️  [ALERTS] ALERT_SMTP_HOST is set but ALERT_EMAIL_FROM or ALERT_EMAIL_TO is missing (email alerts disabled)")
+		} else {
+			sinks = append(sinks, NewSMTPAlertSink(host, port, os.Getenv("ALERT_SMTP_USERNAME"), os.Getenv("ALERT_SMTP_PASSWORD"), from, to))
+		}
+	}
+
+	minSeverity := AlertWarning
+	if v := os.Getenv("ALERT_MIN_SEVERITY"); v != "" {
+		parsed, err := ParseAlertSeverity(v)
+		if err != nil {
+			log.Printf("⚠️  [ALERTS] %v (using warning)", err)
+		} else {
+			minSeverity = parsed
+		}
+	}
+
+	throttle := defaultAlertThrottle
+	if v := os.Getenv("ALERT_THROTTLE_MINUTES"); v != "" {
+		if minutes, err := strconv.Atoi(v); err == nil && minutes >= 0 {
+			throttle = time.Duration(minutes) * time.Minute
+		}
+	}
+
+	return NewAlerter(sinks, minSeverity, throttle)
+}
+
+// SinkNames lists the configured sinks
+func (a *Alerter) SinkNames() []string {
+	names := make([]string, 0, len(a.sinks))
+	for _, sink := range a.sinks {
+		names = append(names, sink.Name())
+	}
+	return names
+}
+
+// Notify sends the alert to every sink, blocking until they finish (use a goroutine on request paths)
+// Returns false when the alert was dropped by the severity threshold or throttling
+func (a *Alerter) Notify(alert Alert) bool {
+	if alert.Severity < a.minSeverity {
+		return false
+	}
+	if alert.At.IsZero() {
+		alert.At = time.Now()
+	}
+
+	key := alert.Event + ":" + alert.Key
+	a.mu.Lock()
+	if last, ok := a.lastSent[key]; ok && alert.At.Sub(last) < a.throttle {
+		a.suppressed[key]++
+		a.mu.Unlock()
+		log.Printf("🔕 [ALERTS] Throttled %s alert: %s", alert.Event, alert.Title)
+		return false
+	}
+	suppressed := a.suppressed[key]
+	a.lastSent[key] = alert.At
+	delete(a.suppressed, key)
+	a.mu.Unlock()
+
+	if suppressed > 0 {
+		alert.Message += fmt.Sprintf("\n(%d similar alert(s) suppressed since the last one)", suppressed)
+	}
+
+	log.Printf("🚨 [ALERTS] [%s] %s: %s", alert.Severity, alert.Title, alert.Message)
+	for _, sink := range a.sinks {
+		if err := sink.Send(alert); err != nil {
+			log.Printf("❌ [ALERTS] Failed to send alert via %s: %v", sink.Name(), err)
+		}
+	}
+	return true
+}
+
+// alertText renders an alert as plain text for email and Slack
+func alertText(alert Alert) string {
+	var b strings.Builder
+	b.WriteString(alert.Message)
+	keys := make([]string, 0, len(alert.Fields))
+	for k := range alert.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "\n%s: %s", k, alert.Fields[k])
+	}
+	fmt.Fprintf(&b, "\nevent: %s\nseverity: %s\ntime: %s", alert.Event, alert.Severity, alert.At.UTC().Format(time.RFC3339))
+	return b.String()
+}
+
+// SlackAlertSink posts alerts to a Slack incoming webhook
+type SlackAlertSink struct {
+	webhookURL string
+	client     *http.Client
+}
+
+// NewSlackAlertSink creates a sink for a Slack incoming webhook URL
+func NewSlackAlertSink(webhookURL string) *SlackAlertSink {
+	return &SlackAlertSink{webhookURL: webhookURL, client: &http.Client{Timeout: alertRequestTimeout}}
+}
+
+func (s *SlackAlertSink) Name() string { return "slack" }
+
+func (s *SlackAlertSink) Send(alert Alert) error {
+	icon := map[AlertSeverity]string{AlertInfo: "ℹ️", AlertWarning: "⚠️", AlertCritical: "🚨"}[alert.Severity]
+	body, err := json.Marshal(map[string]string{
+		"text": fmt.Sprintf("%s *%s*\n%s", icon, alert.Title, alertText(alert)),
+	})
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Post(s.webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("slack webhook returned HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// SMTPAlertSink emails alerts through an SMTP server (STARTTLS when offered)
+type SMTPAlertSink struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+	to       []string
+}
+
+// NewSMTPAlertSink creates an email sink; username may be empty for servers without authentication
+func NewSMTPAlertSink(host, port, username, password, from string, to []string) *SMTPAlertSink {
+	return &SMTPAlertSink{host: host, port: port, username: username, password: password, from: from, to: to}
+}
+
+func (s *SMTPAlertSink) Name() string { return "email" }
+
+func (s *SMTPAlertSink) Send(alert Alert) error {
+	var auth smtp.Auth
+	if s.username != "" {
+		auth = smtp.PlainAuth("", s.username, s.password, s.host)
+	}
+	subject := fmt.Sprintf("[Ropacal %s] %s", strings.ToUpper(alert.Severity.String()), alert.Title)
+	msg := "From: " + s.from + "\r\n" +
+		"To: " + strings.Join(s.to, ", ") + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" +
+		strings.ReplaceAll(alertText(alert), "\n", "\r\n") + "\r\n"
+	return smtp.SendMail(s.host+":"+s.port, auth, s.from, s.to, []byte(msg))
+}
+
+// WatchDriverDisconnects alerts when a driver with an active shift drops off the WebSocket
+// and has not reconnected within the grace period
+func WatchDriverDisconnects(db *sqlx.DB, hub *websocket.Hub, alerter *Alerter, grace time.Duration) {
+	if grace <= 0 {
+		grace = defaultDriverDisconnectGrace
+	}
+	hub.OnDisconnect(func(userID, role string) {
+		if role != "driver" {
+			return
+		}
+		disconnectedAt := time.Now()
+		time.AfterFunc(grace, func() {
+			if hub.IsUserConnected(userID) {
+				return
+			}
+			var shift struct {
+				ID   string `db:"id"`
+				Name string `db:"name"`
+			}
+			err := db.Get(&shift, `
+				SELECT s.id, u.name
+				FROM shifts s
+				JOIN users u ON u.id = s.driver_id
+				WHERE s.driver_id = $1 AND s.status = 'active'
+				ORDER BY s.created_at DESC
+				LIMIT 1
+			`, userID)
+			if err != nil {
+				// No active shift (or the lookup failed): nothing to report
+				return
+			}
+			alerter.Notify(Alert{
+				Event:    AlertEventDriverDisconnected,
+				Severity: AlertWarning,
+				Title:    "Driver disconnected mid-shift",
+				Message:  fmt.Sprintf("%s lost their live connection during an active shift and has not reconnected", shift.Name),
+				Key:      userID,
+				Fields: map[string]string{
+					"driver_id":       userID,
+					"shift_id":        shift.ID,
+					"disconnected_at": disconnectedAt.UTC().Format(time.RFC3339),
+				},
+			})
+		})
+	})
+}
@@ -0,0 +1,316 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"ropacal-backend/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// AreaBoundary is a parsed GeoJSON Polygon/MultiPolygon
+// Each polygon is a list of rings (first = outer, rest = holes); each ring is a list of [lng, lat] points
+type AreaBoundary struct {
+	Polygons [][][][2]float64
+}
+
+// ParseAreaBoundary parses and validates a GeoJSON Polygon or MultiPolygon geometry
+// A GeoJSON Feature wrapping the geometry is also accepted
+func ParseAreaBoundary(raw json.RawMessage) (*AreaBoundary, error) {
+	var geometry struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+		Geometry    json.RawMessage `json:"geometry"`
+	}
+	if err := json.Unmarshal(raw, &geometry); err != nil {
+		return nil, fmt.Errorf("boundary must be a GeoJSON object: %w", err)
+	}
+
+	if geometry.Type == "Feature" {
+		if len(geometry.Geometry) == 0 {
+			return nil, errors.New("GeoJSON feature has no geometry")
+		}
+		return ParseAreaBoundary(geometry.Geometry)
+	}
+
+	boundary := &AreaBoundary{}
+	switch geometry.Type {
+	case "Polygon":
+		var polygon [][][2]float64
+		if err := json.Unmarshal(geometry.Coordinates, &polygon); err != nil {
+			return nil, fmt.Errorf("invalid Polygon coordinates: %w", err)
+		}
+		boundary.Polygons = [][][][2]float64{polygon}
+	case "MultiPolygon":
+		if err := json.Unmarshal(geometry.Coordinates, &boundary.Polygons); err != nil {
+			return nil, fmt.Errorf("invalid MultiPolygon coordinates: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("boundary type must be Polygon or MultiPolygon, got %q", geometry.Type)
+	}
+
+	if len(boundary.Polygons) == 0 {
+		return nil, errors.New("boundary has no polygons")
+	}
+	for _, polygon := range boundary.Polygons {
+		if len(polygon) == 0 {
+			return nil, errors.New("polygon has no rings")
+		}
+		for _, ring := range polygon {
+			if len(ring) < 4 {
+				return nil, errors.New("each polygon ring needs at least 4 positions (closed)")
+			}
+			for _, point := range ring {
+				if point[0] < -180 || point[0] > 180 || point[1] < -90 || point[1] > 90 {
+					return nil, fmt.Errorf("position [%f, %f] is out of range (expected [lng, lat])", point[0], point[1])
+				}
+			}
+		}
+	}
+
+	return boundary, nil
+}
+
+// BoundingBox returns the min/max latitude and longitude of the boundary
+func (b *AreaBoundary) BoundingBox() (minLat, maxLat, minLng, maxLng float64) {
+	minLat, minLng = math.MaxFloat64, math.MaxFloat64
+	maxLat, maxLng = -math.MaxFloat64, -math.MaxFloat64
+	for _, polygon := range b.Polygons {
+		for _, point := range polygon[0] {
+			minLng = math.Min(minLng, point[0])
+			maxLng = math.Max(maxLng, point[0])
+			minLat = math.Min(minLat, point[1])
+			maxLat = math.Max(maxLat, point[1])
+		}
+	}
+	return minLat, maxLat, minLng, maxLng
+}
+
+// Contains reports whether the point falls inside the boundary (inside an outer ring and outside its holes)
+func (b *AreaBoundary) Contains(lat, lng float64) bool {
+	for _, polygon := range b.Polygons {
+		if !ringContains(polygon[0], lat, lng) {
+			continue
+		}
+		inHole := false
+		for _, hole := range polygon[1:] {
+			if ringContains(hole, lat, lng) {
+				inHole = true
+				break
+			}
+		}
+		if !inHole {
+			return true
+		}
+	}
+	return false
+}
+
+// ringContains is a ray-casting point-in-polygon test
+func ringContains(ring [][2]float64, lat, lng float64) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		xi, yi := ring[i][0], ring[i][1]
+		xj, yj := ring[j][0], ring[j][1]
+		if (yi > lat) != (yj > lat) && lng < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// AreaAssigner assigns bins and no-go zones to areas by point-in-polygon
+type AreaAssigner struct {
+	db *sqlx.DB
+	mu sync.Mutex // Serializes runs (periodic job + on-demand after area changes)
+}
+
+// AreaAssignmentResult summarizes a single assignment run
+type AreaAssignmentResult struct {
+	BinsUpdated   int   `json:"bins_updated"`
+	BinsUnmatched int   `json:"bins_unmatched"`
+	ZonesUpdated  int   `json:"zones_updated"`
+	RanAt         int64 `json:"ran_at"`
+}
+
+// NewAreaAssigner creates a new area assigner
+func NewAreaAssigner(db *sqlx.DB) *AreaAssigner {
+	return &AreaAssigner{db: db}
+}
+
+// Start runs the assigner immediately and then on every interval until the process exits
+func (a *AreaAssigner) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := a.Run(); err != nil {
+				log.Printf("❌ [AREA-ASSIGNER] Run failed: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+type parsedArea struct {
+	models.Area
+	boundary *AreaBoundary
+}
+
+// Run recomputes area_id for every located bin and no-go zone, updating only rows that changed
+// When areas overlap the oldest area wins
+func (a *AreaAssigner) Run() (*AreaAssignmentResult, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	result := &AreaAssignmentResult{RanAt: time.Now().Unix()}
+
+	var areas []models.Area
+	if err := a.db.Select(&areas, `SELECT * FROM areas ORDER BY created_at ASC, id ASC`); err != nil {
+		return nil, fmt.Errorf("failed to load areas: %w", err)
+	}
+
+	parsed := make([]parsedArea, 0, len(areas))
+	for _, area := range areas {
+		boundary, err := ParseAreaBoundary(area.Boundary)
+		if err != nil {
+			log.Printf("⚠️  [AREA-ASSIGNER] Skipping area %s (%s): %v", area.Name, area.ID, err)
+			continue
+		}
+		parsed = append(parsed, parsedArea{Area: area, boundary: boundary})
+	}
+
+	var bins []struct {
+		ID        string  `db:"id"`
+		Latitude  float64 `db:"latitude"`
+		Longitude float64 `db:"longitude"`
+		AreaID    *string `db:"area_id"`
+	}
+	err := a.db.Select(&bins, `
+		SELECT id, latitude, longitude, area_id FROM bins
+		WHERE latitude IS NOT NULL AND longitude IS NOT NULL
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load bins: %w", err)
+	}
+
+	var zones []struct {
+		ID        string  `db:"id"`
+		Latitude  float64 `db:"center_latitude"`
+		Longitude float64 `db:"center_longitude"`
+		AreaID    *string `db:"area_id"`
+	}
+	err = a.db.Select(&zones, `SELECT id, center_latitude, center_longitude, area_id FROM no_go_zones`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load zones: %w", err)
+	}
+
+	tx, err := a.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().Unix()
+	for _, bin := range bins {
+		areaID := findArea(parsed, bin.Latitude, bin.Longitude)
+		if areaID == nil {
+			result.BinsUnmatched++
+		}
+		if sameAreaID(areaID, bin.AreaID) {
+			continue
+		}
+		if _, err := tx.Exec(`UPDATE bins SET area_id = $1, updated_at = $2 WHERE id = $3`, areaID, now, bin.ID); err != nil {
+			return nil, fmt.Errorf("failed to update bin %s: %w", bin.ID, err)
+		}
+		result.BinsUpdated++
+	}
+
+	for _, zone := range zones {
+		areaID := findArea(parsed, zone.Latitude, zone.Longitude)
+		if sameAreaID(areaID, zone.AreaID) {
+			continue
+		}
+		if _, err := tx.Exec(`UPDATE no_go_zones SET area_id = $1 WHERE id = $2`, areaID, zone.ID); err != nil {
+			return nil, fmt.Errorf("failed to update zone %s: %w", zone.ID, err)
+		}
+		result.ZonesUpdated++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit area assignments: %w", err)
+	}
+
+	log.Printf("✅ [AREA-ASSIGNER] %d areas: updated %d bins (%d outside any area), %d zones",
+		len(parsed), result.BinsUpdated, result.BinsUnmatched, result.ZonesUpdated)
+	return result, nil
+}
+
+// findArea returns the ID of the first area containing the point, or nil
+func findArea(areas []parsedArea, lat, lng float64) *string {
+	for i := range areas {
+		area := &areas[i]
+		if lat < area.MinLatitude || lat > area.MaxLatitude || lng < area.MinLongitude || lng > area.MaxLongitude {
+			continue
+		}
+		if area.boundary.Contains(lat, lng) {
+			return &area.ID
+		}
+	}
+	return nil
+}
+
+func sameAreaID(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// areaLocatorTTL is how long AreaLocator reuses the loaded areas
+const areaLocatorTTL = 60 * time.Second
+
+// AreaLocator finds the area containing a point from an in-memory copy of the areas, reloaded at most
+// once per areaLocatorTTL (used to match WebSocket area subscriptions)
+type AreaLocator struct {
+	db *sqlx.DB
+
+	mu       sync.Mutex
+	areas    []parsedArea
+	loadedAt time.Time
+}
+
+// NewAreaLocator creates an area locator
+func NewAreaLocator(db *sqlx.DB) *AreaLocator {
+	return &AreaLocator{db: db}
+}
+
+// Locate returns the ID of the oldest area containing the point, or nil
+// If the areas can't be reloaded the previous copy is used
+func (l *AreaLocator) Locate(lat, lng float64) *string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.areas == nil || time.Since(l.loadedAt) >= areaLocatorTTL {
+		var areas []models.Area
+		if err := l.db.Select(&areas, `SELECT * FROM areas ORDER BY created_at ASC, id ASC`); err != nil {
+			log.Printf("⚠️  [AREA-LOCATOR] Failed to load areas: %v", err)
+		} else {
+			l.areas = make([]parsedArea, 0, len(areas))
+			for _, area := range areas {
+				if boundary, err := ParseAreaBoundary(area.Boundary); err == nil {
+					l.areas = append(l.areas, parsedArea{Area: area, boundary: boundary})
+				}
+			}
+		}
+		l.loadedAt = time.Now()
+	}
+	return findArea(l.areas, lat, lng)
+}
@@ -0,0 +1,248 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/helpers"
+	"ropacal-backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// BinsAtRiskDigester compiles the daily bins-at-risk digest (overfilled and long-unchecked bins, overdue
+// move requests, open high-severity incidents) once the configured local send time has passed, stores it
+// and notifies admins and managers through the notification outbox
+type BinsAtRiskDigester struct {
+	db *sqlx.DB
+}
+
+// NewBinsAtRiskDigester creates a new bins-at-risk digester
+func NewBinsAtRiskDigester(db *sqlx.DB) *BinsAtRiskDigester {
+	return &BinsAtRiskDigester{db: db}
+}
+
+// RunJob is the JobHandler of the periodic models.JobKindBinsAtRiskDigest job
+func (d *BinsAtRiskDigester) RunJob(ctx context.Context, job models.Job) error {
+	_, err := d.Run(time.Now())
+	return err
+}
+
+// Run sends today's digest if it is enabled, the send time has passed and it wasn't sent yet
+// Returns nil when there was nothing to send
+func (d *BinsAtRiskDigester) Run(now time.Time) (*models.BinsAtRiskDigest, error) {
+	settings, err := database.GetDigestSettings(d.db)
+	if err != nil {
+		log.Printf("⚠️  [DIGEST] %v (using defaults)", err)
+	}
+	if !settings.Enabled {
+		return nil, nil
+	}
+
+	local := now.In(digestLocation(settings))
+	sendAt, _ := models.ParseTimeOfDay(settings.SendAt)
+	if local.Hour()*60+local.Minute() < sendAt {
+		return nil, nil
+	}
+
+	var sent bool
+	err = d.db.Get(&sent, `SELECT EXISTS (SELECT 1 FROM manager_digests WHERE digest_date = $1)`, local.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check today's digest: %w", err)
+	}
+	if sent {
+		return nil, nil
+	}
+
+	return d.send(now, settings, false)
+}
+
+// SendNow compiles and sends the digest regardless of the schedule, replacing today's
+func (d *BinsAtRiskDigester) SendNow(now time.Time) (*models.BinsAtRiskDigest, error) {
+	settings, err := database.GetDigestSettings(d.db)
+	if err != nil {
+		log.Printf("⚠️  [DIGEST] %v (using defaults)", err)
+	}
+	return d.send(now, settings, true)
+}
+
+// send compiles the digest, stores it and queues the notifications in one transaction
+// Unless replace is set, a digest already stored for the date wins and nothing is sent
+func (d *BinsAtRiskDigester) send(now time.Time, settings models.DigestSettings, replace bool) (*models.BinsAtRiskDigest, error) {
+	digest, err := d.Compile(now, settings)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(digest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal digest: %w", err)
+	}
+
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	conflict := `ON CONFLICT (digest_date) DO NOTHING`
+	if replace {
+		conflict = `ON CONFLICT (digest_date) DO UPDATE SET id = EXCLUDED.id, payload = EXCLUDED.payload, generated_at = EXCLUDED.generated_at`
+	}
+	result, err := tx.Exec(`
+		INSERT INTO manager_digests (id, digest_date, payload, generated_at)
+		VALUES ($1, $2, $3, $4) `+conflict,
+		digest.ID, digest.DigestDate, payload, digest.GeneratedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store digest: %w", err)
+	}
+	if stored, _ := result.RowsAffected(); stored == 0 {
+		return nil, nil // Another run sent it first
+	}
+
+	if err := d.notify(tx, digest); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit digest: %w", err)
+	}
+
+	log.Printf("📰 [DIGEST] Sent digest for %s: %s", digest.DigestDate, digest.Summary())
+	return digest, nil
+}
+
+// Compile builds the digest as of now without storing or sending it
+func (d *BinsAtRiskDigester) Compile(now time.Time, settings models.DigestSettings) (*models.BinsAtRiskDigest, error) {
+	nowUnix := now.Unix()
+	thresholds, err := database.GetFillThresholds(d.db)
+	if err != nil {
+		log.Printf("⚠️  [DIGEST] %v (using defaults)", err)
+	}
+
+	digest := &models.BinsAtRiskDigest{
+		ID:                    uuid.New().String(),
+		DigestDate:            now.In(digestLocation(settings)).Format("2006-01-02"),
+		GeneratedAt:           nowUnix,
+		FillThreshold:         thresholds.Critical,
+		UncheckedDays:         settings.UncheckedDays,
+		OverfilledBins:        []models.DigestBin{},
+		UncheckedBins:         []models.DigestBin{},
+		OverdueMoveRequests:   []models.DigestMove{},
+		HighSeverityIncidents: []models.DigestIncident{},
+	}
+
+	const binColumns = `
+		SELECT b.id AS bin_id, b.bin_number, b.current_street, b.city, b.fill_percentage, b.last_checked_at,
+		       (($1::BIGINT - COALESCE(b.last_checked_at, b.created_at)) / 86400)::INT AS days_unchecked
+		FROM bins b`
+
+	err = d.db.Select(&digest.OverfilledBins, binColumns+`
+		WHERE b.status NOT IN ('retired', 'in_storage') AND b.fill_percentage >= $2
+		ORDER BY b.fill_percentage DESC, b.bin_number ASC
+	`, nowUnix, thresholds.Critical)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load overfilled bins: %w", err)
+	}
+
+	err = d.db.Select(&digest.UncheckedBins, binColumns+`
+		WHERE b.status NOT IN ('retired', 'in_storage') AND COALESCE(b.last_checked_at, b.created_at) < $2
+		ORDER BY COALESCE(b.last_checked_at, b.created_at) ASC, b.bin_number ASC
+	`, nowUnix, nowUnix-int64(settings.UncheckedDays)*86400)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load unchecked bins: %w", err)
+	}
+
+	// Deadlines mirror MoveSLAPolicy.CompleteDeadline
+	policy, err := database.GetMoveSLAPolicy(d.db)
+	if err != nil {
+		log.Printf("⚠️  [DIGEST] %v (using default SLA)", err)
+	}
+	err = d.db.Select(&digest.OverdueMoveRequests, `
+		SELECT * FROM (
+			SELECT bmr.id AS move_request_id, bmr.bin_id, b.bin_number, bmr.urgency, bmr.status, bmr.scheduled_date,
+			       CASE WHEN bmr.urgency = 'urgent'
+			            THEN bmr.created_at + $2
+			            ELSE bmr.scheduled_date + $3
+			       END AS deadline,
+			       bmr.assigned_user_id
+			FROM bin_move_requests bmr
+			JOIN bins b ON b.id = bmr.bin_id
+			WHERE bmr.status IN ('pending', 'in_progress', 'picked_up')
+		) moves
+		WHERE deadline <= $1
+		ORDER BY deadline ASC
+	`, nowUnix, int64(policy.UrgentCompleteHours*3600), int64(policy.ScheduledCompleteGraceHours*3600))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load overdue move requests: %w", err)
+	}
+
+	err = d.db.Select(&digest.HighSeverityIncidents, `
+		SELECT zi.id AS incident_id, zi.zone_id, zi.bin_id, b.bin_number, zi.incident_type, zi.status, zi.description, zi.reported_at
+		FROM zone_incidents zi
+		JOIN incident_types it ON it.key = zi.incident_type
+		LEFT JOIN bins b ON b.id = zi.bin_id
+		WHERE zi.status IN ('open', 'investigating') AND it.severity = ANY($1) AND zi.parent_incident_id IS NULL
+		ORDER BY zi.reported_at DESC
+	`, pq.Array(models.HighSeverityIncidentLevels))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load high-severity incidents: %w", err)
+	}
+
+	return digest, nil
+}
+
+// notify queues the digest for the admin and manager dashboards and a push to every admin or
+// manager with a registered device
+func (d *BinsAtRiskDigester) notify(tx *sqlx.Tx, digest *models.BinsAtRiskDigest) error {
+	message := map[string]interface{}{
+		"type": "bins_at_risk_digest",
+		"data": digest,
+	}
+	for _, role := range []string{"admin", "manager"} {
+		if _, err := helpers.EnqueueRoleMessage(tx, role, message); err != nil {
+			return fmt.Errorf("failed to queue digest: %w", err)
+		}
+	}
+
+	var recipients []string
+	err := tx.Select(&recipients, `
+		SELECT DISTINCT u.id
+		FROM users u
+		JOIN fcm_tokens t ON t.user_id = u.id
+		WHERE u.role IN ('admin', 'manager') AND u.deactivated_at IS NULL
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to load digest recipients: %w", err)
+	}
+
+	push := models.OutboxPush{
+		Title: "Bins at risk today",
+		Body:  digest.Summary(),
+		Data: map[string]string{
+			"type":        "bins_at_risk_digest",
+			"digest_id":   digest.ID,
+			"digest_date": digest.DigestDate,
+		},
+	}
+	for _, userID := range recipients {
+		if _, err := helpers.EnqueuePush(tx, userID, push); err != nil {
+			return fmt.Errorf("failed to queue digest push: %w", err)
+		}
+	}
+	return nil
+}
+
+// digestLocation returns the digest timezone, falling back to UTC
+func digestLocation(settings models.DigestSettings) *time.Location {
+	loc, err := time.LoadLocation(settings.Timezone)
+	if err != nil {
+		log.Printf("⚠️  [DIGEST] Invalid timezone %q, using UTC", settings.Timezone)
+		return time.UTC
+	}
+	return loc
+}
@@ -0,0 +1,259 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+
+	"ropacal-backend/internal/models"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Check anomaly thresholds
+const (
+	anomalyFillDropPoints        = 30   // A fall of this many points needs a collection or move to explain it
+	anomalyMaxDriverDistanceM    = 1000 // Checks submitted further than this from the bin are flagged
+	anomalyMaxLocationAgeSeconds = 300  // Older GPS fixes say nothing about where the driver was
+	anomalyMaxSpeedKmh           = 110  // Faster travel between two checks is not physically possible in the city
+	anomalyMinTravelDistanceM    = 200  // Bins closer than this can be checked back to back (GPS and geocoding noise)
+)
+
+type anomalyCheck struct {
+	ID             int      `db:"id"`
+	BinID          string   `db:"bin_id"`
+	FillPercentage *int     `db:"fill_percentage"`
+	CheckedOn      int64    `db:"checked_on"`
+	CheckedBy      *string  `db:"checked_by"`
+	BinLatitude    *float64 `db:"bin_latitude"`
+	BinLongitude   *float64 `db:"bin_longitude"`
+
+	RemoteCompletion         bool     `db:"remote_completion"`
+	CompletionDistanceMeters *float64 `db:"completion_distance_meters"`
+}
+
+// DetectCheckAnomalies evaluates a driver's check right after it is recorded, saving any flags on the check
+// and queueing it for manager review. Sensor checks (no driver) are not evaluated
+// The driver's GPS is read from driver_current_location, so this must run when the check is submitted
+func DetectCheckAnomalies(db *sqlx.DB, checkID int) ([]models.CheckAnomaly, error) {
+	var check anomalyCheck
+	err := db.Get(&check, `
+		SELECT c.id, c.bin_id, c.fill_percentage, c.checked_on, c.checked_by,
+		       b.latitude AS bin_latitude, b.longitude AS bin_longitude,
+		       c.remote_completion, c.completion_distance_meters
+		FROM checks c
+		JOIN bins b ON b.id = c.bin_id
+		WHERE c.id = $1
+	`, checkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load check %d: %w", checkID, err)
+	}
+	if check.CheckedBy == nil {
+		return nil, nil
+	}
+
+	var anomalies []models.CheckAnomaly
+	for _, detect := range []func(*sqlx.DB, anomalyCheck) (*models.CheckAnomaly, error){
+		detectUnexplainedFillDrop,
+		detectRemoteCompletion,
+		detectFarFromBin,
+		detectImpossibleTravel,
+	} {
+		anomaly, err := detect(db, check)
+		if err != nil {
+			return nil, fmt.Errorf("check %d: %w", checkID, err)
+		}
+		if anomaly != nil {
+			anomalies = append(anomalies, *anomaly)
+		}
+	}
+	if len(anomalies) == 0 {
+		return nil, nil
+	}
+
+	flags := make([]string, len(anomalies))
+	for i, anomaly := range anomalies {
+		flags[i] = anomaly.Flag
+	}
+	details, _ := json.Marshal(anomalies)
+	_, err = db.Exec(`
+		UPDATE checks SET anomaly_flags = $1, anomaly_details = $2, review_status = $3
+		WHERE id = $4
+	`, pq.Array(flags), string(details), models.CheckReviewPending, check.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to flag check %d: %w", checkID, err)
+	}
+
+	log.Printf("🚩 [CHECK-ANOMALY] Check %d on bin %s flagged: %v", check.ID, check.BinID, flags)
+	return anomalies, nil
+}
+
+// detectUnexplainedFillDrop flags a sharp fall in fill since the bin's previous reading when no collection stop
+// or move happened in between (the check's own collection stop counts)
+func detectUnexplainedFillDrop(db *sqlx.DB, check anomalyCheck) (*models.CheckAnomaly, error) {
+	if check.FillPercentage == nil {
+		return nil, nil
+	}
+
+	var previous struct {
+		ID             int   `db:"id"`
+		FillPercentage int   `db:"fill_percentage"`
+		CheckedOn      int64 `db:"checked_on"`
+	}
+	err := db.Get(&previous, `
+		SELECT id, fill_percentage, checked_on
+		FROM checks
+		WHERE bin_id = $1 AND id <> $2 AND fill_percentage IS NOT NULL AND checked_on <= $3
+		ORDER BY checked_on DESC, id DESC
+		LIMIT 1
+	`, check.BinID, check.ID, check.CheckedOn)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load previous check: %w", err)
+	}
+	if previous.FillPercentage-*check.FillPercentage < anomalyFillDropPoints {
+		return nil, nil
+	}
+
+	var emptied bool
+	err = db.Get(&emptied, `
+		SELECT EXISTS(
+			SELECT 1 FROM route_tasks
+			WHERE bin_id = $1 AND task_type = 'collection' AND is_completed = 1
+			  AND completed_at > $2 AND completed_at <= $3
+		) OR EXISTS(
+			SELECT 1 FROM moves WHERE bin_id = $1 AND moved_on > $2 AND moved_on <= $3
+		)
+	`, check.BinID, previous.CheckedOn, check.CheckedOn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up collections: %w", err)
+	}
+	if emptied {
+		return nil, nil
+	}
+
+	return &models.CheckAnomaly{
+		Flag:            models.CheckAnomalyUnexplainedFillDrop,
+		Message:         fmt.Sprintf("Fill dropped from %d%% to %d%% with no collection or move recorded since", previous.FillPercentage, *check.FillPercentage),
+		PreviousCheckID: &previous.ID,
+		PreviousFill:    &previous.FillPercentage,
+	}, nil
+}
+
+// detectRemoteCompletion flags a shift stop completed too far from the stop (measured when it was submitted,
+// see CheckInProximityOf)
+func detectRemoteCompletion(db *sqlx.DB, check anomalyCheck) (*models.CheckAnomaly, error) {
+	if !check.RemoteCompletion || check.CompletionDistanceMeters == nil {
+		return nil, nil
+	}
+	distance := *check.CompletionDistanceMeters
+	return &models.CheckAnomaly{
+		Flag:           models.CheckAnomalyRemoteCompletion,
+		Message:        fmt.Sprintf("Stop completed %.0f m away from it", distance),
+		DistanceMeters: &distance,
+	}, nil
+}
+
+// detectFarFromBin flags a check submitted while the driver's recent GPS fix was far from the bin
+// Remote completions are already flagged with the stricter proximity limit
+func detectFarFromBin(db *sqlx.DB, check anomalyCheck) (*models.CheckAnomaly, error) {
+	if check.BinLatitude == nil || check.BinLongitude == nil || check.RemoteCompletion {
+		return nil, nil
+	}
+
+	var location struct {
+		Latitude  float64 `db:"latitude"`
+		Longitude float64 `db:"longitude"`
+		UpdatedAt int64   `db:"updated_at"`
+	}
+	err := db.Get(&location, `SELECT latitude, longitude, updated_at FROM driver_current_location WHERE driver_id = $1`, *check.CheckedBy)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load driver location: %w", err)
+	}
+	age := check.CheckedOn - location.UpdatedAt
+	if age < 0 {
+		age = 0
+	}
+	if age > anomalyMaxLocationAgeSeconds {
+		return nil, nil
+	}
+
+	distance := haversineDistance(location.Latitude, location.Longitude, *check.BinLatitude, *check.BinLongitude) * 1000
+	if distance <= anomalyMaxDriverDistanceM {
+		return nil, nil
+	}
+	distance = math.Round(distance)
+	return &models.CheckAnomaly{
+		Flag:               models.CheckAnomalyFarFromBin,
+		Message:            fmt.Sprintf("Driver was %.0f m from the bin when the check was submitted", distance),
+		DistanceMeters:     &distance,
+		LocationAgeSeconds: &age,
+	}, nil
+}
+
+// detectImpossibleTravel flags a check reached from the same driver's previous check (at another bin) faster
+// than a vehicle could drive there
+func detectImpossibleTravel(db *sqlx.DB, check anomalyCheck) (*models.CheckAnomaly, error) {
+	if check.BinLatitude == nil || check.BinLongitude == nil {
+		return nil, nil
+	}
+
+	var previous struct {
+		ID        int      `db:"id"`
+		CheckedOn int64    `db:"checked_on"`
+		Latitude  *float64 `db:"latitude"`
+		Longitude *float64 `db:"longitude"`
+	}
+	err := db.Get(&previous, `
+		SELECT c.id, c.checked_on, b.latitude, b.longitude
+		FROM checks c
+		JOIN bins b ON b.id = c.bin_id
+		WHERE c.checked_by = $1 AND c.id <> $2 AND c.bin_id <> $3 AND c.checked_on <= $4
+		ORDER BY c.checked_on DESC, c.id DESC
+		LIMIT 1
+	`, *check.CheckedBy, check.ID, check.BinID, check.CheckedOn)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load driver's previous check: %w", err)
+	}
+	if previous.Latitude == nil || previous.Longitude == nil {
+		return nil, nil
+	}
+
+	distance := haversineDistance(*previous.Latitude, *previous.Longitude, *check.BinLatitude, *check.BinLongitude) * 1000
+	if distance < anomalyMinTravelDistanceM {
+		return nil, nil
+	}
+	elapsed := check.CheckedOn - previous.CheckedOn
+	speed := math.Inf(1)
+	if elapsed > 0 {
+		speed = distance / 1000 / (float64(elapsed) / 3600)
+	}
+	if speed <= anomalyMaxSpeedKmh {
+		return nil, nil
+	}
+
+	distance = math.Round(distance)
+	anomaly := &models.CheckAnomaly{
+		Flag:            models.CheckAnomalyImpossibleTravel,
+		Message:         fmt.Sprintf("%.0f m from the previous check in %ds", distance, elapsed),
+		PreviousCheckID: &previous.ID,
+		DistanceMeters:  &distance,
+		ElapsedSeconds:  &elapsed,
+	}
+	if !math.IsInf(speed, 1) {
+		rounded := math.Round(speed)
+		anomaly.ImpliedSpeedKmh = &rounded
+	}
+	return anomaly, nil
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+
+	"ropacal-backend/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// CheckInProximityOf compares the driver's latest GPS fix with a stop's coordinates
+// The result is unverified when the driver has no fix or it is older than the settings allow
+func CheckInProximityOf(db sqlx.Queryer, driverID string, latitude, longitude float64, settings models.CheckInProximitySettings, now int64) (models.CheckInProximity, error) {
+	var result models.CheckInProximity
+
+	var location struct {
+		Latitude  float64 `db:"latitude"`
+		Longitude float64 `db:"longitude"`
+		UpdatedAt int64   `db:"updated_at"`
+	}
+	err := sqlx.Get(db, &location, `SELECT latitude, longitude, updated_at FROM driver_current_location WHERE driver_id = $1`, driverID)
+	if err == sql.ErrNoRows {
+		return result, nil
+	}
+	if err != nil {
+		return result, fmt.Errorf("failed to load driver location: %w", err)
+	}
+
+	age := now - location.UpdatedAt
+	if age < 0 {
+		age = 0
+	}
+	if age > settings.MaxLocationAgeSeconds {
+		return result, nil
+	}
+
+	distance := math.Round(haversineDistance(location.Latitude, location.Longitude, latitude, longitude) * 1000)
+	result.Verified = true
+	result.DistanceMeters = &distance
+	result.LocationAgeSeconds = &age
+	result.Remote = distance > settings.MaxDistanceMeters
+	return result, nil
+}
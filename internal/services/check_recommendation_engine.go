@@ -0,0 +1,325 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// CheckRecommendationTTLSeconds is how long a pending recommendation stays open before it expires
+const CheckRecommendationTTLSeconds int64 = 14 * 24 * 60 * 60
+
+// Engine tuning
+const (
+	staleCheckDays         = 7   // Bins unchecked this long are always flagged
+	cadenceOverdueFactor   = 1.5 // Flag when days since check exceeds usual interval × factor
+	minCadenceOverdueDays  = 2   // Never flag a cadence miss earlier than this
+	incidentLookbackDays   = 30  // Incident history window
+	recentChecksPerBin     = 6   // Checks used to estimate cadence and fill trend
+	secondsPerDay          = 86400
+	maxRecommendationScore = 100
+)
+
+// CheckRecommendationEngine analyzes check cadence, fill trends and incident history
+// to create and expire bin_check_recommendations
+type CheckRecommendationEngine struct {
+	db *sqlx.DB
+}
+
+// CheckRecommendationRunResult summarizes a single engine run
+type CheckRecommendationRunResult struct {
+	Created  int            `json:"created"`
+	Expired  int            `json:"expired"`
+	ByReason map[string]int `json:"by_reason"`
+	RanAt    int64          `json:"ran_at"`
+}
+
+// NewCheckRecommendationEngine creates a new check recommendation engine
+func NewCheckRecommendationEngine(db *sqlx.DB) *CheckRecommendationEngine {
+	return &CheckRecommendationEngine{db: db}
+}
+
+// Start runs the engine immediately and then on every interval until the process exits
+func (e *CheckRecommendationEngine) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := e.Run(); err != nil {
+				log.Printf("❌ [CHECK-RECOMMENDATION-ENGINE] Run failed: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// binCheckStats holds the per-bin inputs the engine scores
+type binCheckStats struct {
+	ID             string `db:"id"`
+	BinNumber      int    `db:"bin_number"`
+	FillPercentage *int   `db:"fill_percentage"`
+	LastCheckedAt  *int64 `db:"last_checked_at"`
+	CreatedAt      int64  `db:"created_at"`
+}
+
+type recentCheck struct {
+	BinID          string `db:"bin_id"`
+	CheckedOn      int64  `db:"checked_on"`
+	FillPercentage *int   `db:"fill_percentage"`
+}
+
+// Run expires outdated recommendations and creates new ones for bins that need attention
+func (e *CheckRecommendationEngine) Run() (*CheckRecommendationRunResult, error) {
+	now := time.Now().Unix()
+	result := &CheckRecommendationRunResult{
+		ByReason: make(map[string]int),
+		RanAt:    now,
+	}
+
+	log.Println("[CHECK-RECOMMENDATION-ENGINE] Starting run...")
+
+	// 1. Expire pending recommendations past their TTL or for bins that are no longer active
+	expireResult, err := e.db.Exec(`
+		UPDATE bin_check_recommendations bcr
+		SET status = 'expired',
+		    updated_at = $1
+		WHERE bcr.status = 'pending'
+		AND (
+			(bcr.expires_at IS NOT NULL AND bcr.expires_at < $1)
+			OR EXISTS (SELECT 1 FROM bins b WHERE b.id = bcr.bin_id AND b.status != 'active')
+		)
+	`, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire recommendations: %w", err)
+	}
+	expired, _ := expireResult.RowsAffected()
+	result.Expired = int(expired)
+
+	// Bins projected to reach the critical fill threshold should be checked
+	thresholds, err := database.GetFillThresholds(e.db)
+	if err != nil {
+		log.Printf("⚠️  [CHECK-RECOMMENDATION-ENGINE] %v (using defaults)", err)
+	}
+	projectedFullThreshold := float64(thresholds.Critical)
+
+	// 2. Load active bins without a pending recommendation
+	var bins []binCheckStats
+	err = e.db.Select(&bins, `
+		SELECT b.id, b.bin_number, b.fill_percentage, b.last_checked_at, b.created_at
+		FROM bins b
+		WHERE b.status = 'active'
+		AND NOT EXISTS (
+			SELECT 1 FROM bin_check_recommendations bcr
+			WHERE bcr.bin_id = b.id
+			AND bcr.status = 'pending'
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load bins: %w", err)
+	}
+
+	if len(bins) == 0 {
+		log.Printf("✅ [CHECK-RECOMMENDATION-ENGINE] No candidate bins (expired %d)", result.Expired)
+		return result, nil
+	}
+
+	// 3. Load recent checks (newest first) for cadence and fill trend
+	var checks []recentCheck
+	err = e.db.Select(&checks, `
+		SELECT bin_id, checked_on, fill_percentage
+		FROM (
+			SELECT c.bin_id, c.checked_on, c.fill_percentage,
+			       ROW_NUMBER() OVER (PARTITION BY c.bin_id ORDER BY c.checked_on DESC) AS rn
+			FROM checks c
+			JOIN bins b ON b.id = c.bin_id
+			WHERE b.status = 'active'
+		) ranked
+		WHERE rn <= $1
+		ORDER BY bin_id, checked_on DESC
+	`, recentChecksPerBin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load recent checks: %w", err)
+	}
+
+	checksByBin := make(map[string][]recentCheck)
+	for _, c := range checks {
+		checksByBin[c.BinID] = append(checksByBin[c.BinID], c)
+	}
+
+	// 4. Load recent incident counts (repeat reports count once)
+	var incidentRows []struct {
+		BinID string `db:"bin_id"`
+		Count int    `db:"count"`
+	}
+	err = e.db.Select(&incidentRows, `
+		SELECT bin_id, COUNT(*) AS count
+		FROM zone_incidents
+		WHERE bin_id IS NOT NULL
+		AND reported_at >= $1
+		AND parent_incident_id IS NULL
+		GROUP BY bin_id
+	`, now-incidentLookbackDays*secondsPerDay)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load incident history: %w", err)
+	}
+
+	incidentsByBin := make(map[string]int)
+	for _, row := range incidentRows {
+		incidentsByBin[row.BinID] = row.Count
+	}
+
+	// 5. Score each bin and keep the strongest signal
+	var recommendations []models.BinCheckRecommendation
+	for _, bin := range bins {
+		rec := evaluateBin(bin, checksByBin[bin.ID], incidentsByBin[bin.ID], projectedFullThreshold, now)
+		if rec != nil {
+			recommendations = append(recommendations, *rec)
+		}
+	}
+
+	if len(recommendations) == 0 {
+		log.Printf("✅ [CHECK-RECOMMENDATION-ENGINE] No bins need attention (expired %d)", result.Expired)
+		return result, nil
+	}
+
+	// 6. Insert recommendations
+	tx, err := e.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, rec := range recommendations {
+		_, err := tx.NamedExec(`
+			INSERT INTO bin_check_recommendations
+			(id, bin_id, reason, flagged_at, days_since_check, status, score, details, expires_at, created_at, updated_at)
+			VALUES
+			(:id, :bin_id, :reason, :flagged_at, :days_since_check, :status, :score, :details, :expires_at, :created_at, :updated_at)
+		`, rec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create recommendation for bin %s: %w", rec.BinID, err)
+		}
+		result.Created++
+		result.ByReason[rec.Reason]++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit recommendations: %w", err)
+	}
+
+	log.Printf("✅ [CHECK-RECOMMENDATION-ENGINE] Created %d, expired %d (%v)", result.Created, result.Expired, result.ByReason)
+	return result, nil
+}
+
+// evaluateBin returns a recommendation for the strongest signal, or nil if the bin looks fine
+// checks must be ordered newest first; projectedFullThreshold is the fill % at which a projected bin should be checked
+func evaluateBin(bin binCheckStats, checks []recentCheck, incidentCount int, projectedFullThreshold float64, now int64) *models.BinCheckRecommendation {
+	lastChecked := bin.CreatedAt
+	if bin.LastCheckedAt != nil {
+		lastChecked = *bin.LastCheckedAt
+	}
+	daysSinceCheck := int((now - lastChecked) / secondsPerDay)
+
+	bestReason := ""
+	bestScore := 0
+	bestDetails := ""
+
+	consider := func(reason string, score int, details string) {
+		if score > maxRecommendationScore {
+			score = maxRecommendationScore
+		}
+		if score > bestScore {
+			bestReason, bestScore, bestDetails = reason, score, details
+		}
+	}
+
+	// Signal 1: Plain staleness (same rule as FlagStaleBins)
+	if daysSinceCheck >= staleCheckDays {
+		consider(models.CheckReasonTimeBased,
+			40+2*minInt(daysSinceCheck-staleCheckDays, 30),
+			fmt.Sprintf("Not checked in %d days", daysSinceCheck))
+	}
+
+	// Signal 2: Check cadence - overdue compared to this bin's usual interval
+	if len(checks) >= 2 {
+		newest := checks[0].CheckedOn
+		oldest := checks[len(checks)-1].CheckedOn
+		avgGapDays := float64(newest-oldest) / float64(len(checks)-1) / secondsPerDay
+		if avgGapDays > 0 {
+			overdueAfter := math.Max(minCadenceOverdueDays, math.Ceil(avgGapDays*cadenceOverdueFactor))
+			if float64(daysSinceCheck) >= overdueAfter {
+				ratio := float64(daysSinceCheck) / avgGapDays
+				consider(models.CheckReasonCadence,
+					30+int(math.Max(ratio-cadenceOverdueFactor, 0)*20),
+					fmt.Sprintf("Usually checked every %.1f days, last check %d days ago", avgGapDays, daysSinceCheck))
+			}
+		}
+	}
+
+	// Signal 3: Fill trend - project current fill from the last two readings
+	var fills []recentCheck
+	for _, c := range checks {
+		if c.FillPercentage != nil {
+			fills = append(fills, c)
+			if len(fills) == 2 {
+				break
+			}
+		}
+	}
+	if len(fills) == 2 && fills[0].CheckedOn > fills[1].CheckedOn {
+		latest, previous := fills[0], fills[1]
+		daysBetween := float64(latest.CheckedOn-previous.CheckedOn) / secondsPerDay
+		ratePerDay := float64(*latest.FillPercentage-*previous.FillPercentage) / math.Max(daysBetween, 1)
+		if ratePerDay > 0 && float64(*latest.FillPercentage) < projectedFullThreshold {
+			daysSinceReading := float64(now-latest.CheckedOn) / secondsPerDay
+			projected := float64(*latest.FillPercentage) + ratePerDay*daysSinceReading
+			if projected >= projectedFullThreshold {
+				consider(models.CheckReasonFillTrend,
+					50+int(projected-projectedFullThreshold),
+					fmt.Sprintf("Filling %.1f%%/day, projected at %.0f%% (last reading %d%%)", ratePerDay, math.Min(projected, 100), *latest.FillPercentage))
+			}
+		}
+	}
+
+	// Signal 4: Incident history
+	if incidentCount > 0 {
+		consider(models.CheckReasonIncidentHistory,
+			30+15*incidentCount,
+			fmt.Sprintf("%d incident(s) reported in the last %d days", incidentCount, incidentLookbackDays))
+	}
+
+	if bestReason == "" {
+		return nil
+	}
+
+	expiresAt := now + CheckRecommendationTTLSeconds
+	details := bestDetails
+	return &models.BinCheckRecommendation{
+		ID:             uuid.New().String(),
+		BinID:          bin.ID,
+		Reason:         bestReason,
+		FlaggedAt:      now,
+		DaysSinceCheck: daysSinceCheck,
+		Status:         "pending",
+		Score:          bestScore,
+		Details:        &details,
+		ExpiresAt:      &expiresAt,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// checkPruneBatch bounds each DELETE of old checks, like driverLocationPruneBatch
+const checkPruneBatch = 10000
+
+// DataRetentionPurger deletes data older than the retention periods in the retention settings
+// Settings are re-read on every run, so changes apply from the next run without a restart
+type DataRetentionPurger struct {
+	db *sqlx.DB
+}
+
+// NewDataRetentionPurger creates a purger driven by the stored retention settings
+func NewDataRetentionPurger(db *sqlx.DB) *DataRetentionPurger {
+	return &DataRetentionPurger{db: db}
+}
+
+// RunJob is the JobHandler of the periodic models.JobKindDataRetention job
+func (p *DataRetentionPurger) RunJob(ctx context.Context, job models.Job) error {
+	_, err := p.Run()
+	return err
+}
+
+// Run applies every retention period that isn't 0 and returns how many rows were removed
+// A failure in one kind of data doesn't stop the others from being purged
+func (p *DataRetentionPurger) Run() (models.RetentionPurge, error) {
+	var purge models.RetentionPurge
+
+	settings, err := database.GetRetentionSettings(p.db)
+	if err != nil {
+		return purge, err
+	}
+
+	var errs []error
+	if settings.DriverLocationDays > 0 {
+		purge.DriverLocations, err = NewDriverLocationPruner(p.db, settings.DriverLocationDays).Run()
+		errs = append(errs, err)
+	}
+	if settings.DiagnosticLogDays > 0 {
+		purge.DiagnosticLogs, err = NewDiagnosticLogPruner(p.db, settings.DiagnosticLogDays).Run()
+		errs = append(errs, err)
+	}
+	if settings.CheckDays > 0 {
+		purge.Checks, err = p.pruneChecks(settings.CheckDays)
+		errs = append(errs, err)
+	}
+	if settings.APIKeyUsageDays > 0 {
+		purge.APIKeyUsage, err = p.pruneAPIKeyUsage(settings.APIKeyUsageDays)
+		errs = append(errs, err)
+	}
+	if settings.FillHistoryRawDays > 0 {
+		var merged int64
+		merged, err = p.rollUpFillHistory(models.FillResolutionRaw, models.FillResolutionHourly, settings.FillHistoryRawDays)
+		purge.FillHistory += merged
+		errs = append(errs, err)
+	}
+	if settings.FillHistoryHourlyDays > 0 {
+		var merged int64
+		merged, err = p.rollUpFillHistory(models.FillResolutionHourly, models.FillResolutionDaily, settings.FillHistoryHourlyDays)
+		purge.FillHistory += merged
+		errs = append(errs, err)
+	}
+	return purge, errors.Join(errs...)
+}
+
+// pruneChecks deletes checks older than retentionDays in batches, keeping each bin's latest check
+// so fill levels and "last checked" stay known for bins that haven't been visited in a long time
+func (p *DataRetentionPurger) pruneChecks(retentionDays int) (int64, error) {
+	cutoff := time.Now().AddDate(0, 0, -retentionDays).Unix()
+
+	var total int64
+	for {
+		result, err := p.db.Exec(`
+			DELETE FROM checks
+			WHERE id IN (
+				SELECT c.id FROM checks c
+				WHERE c.checked_on < $1
+				  AND c.id <> (
+					SELECT latest.id FROM checks latest
+					WHERE latest.bin_id = c.bin_id
+					ORDER BY latest.checked_on DESC, latest.id DESC
+					LIMIT 1
+				  )
+				LIMIT $2
+			)
+		`, cutoff, checkPruneBatch)
+		if err != nil {
+			return total, fmt.Errorf("failed to prune checks: %w", err)
+		}
+		deleted, _ := result.RowsAffected()
+		total += deleted
+		if deleted < checkPruneBatch {
+			break
+		}
+	}
+	if total > 0 {
+		log.Printf("🧹 [RETENTION] Pruned %d checks older than %d days", total, retentionDays)
+	}
+	return total, nil
+}
+
+// pruneAPIKeyUsage deletes API key usage counters older than retentionDays
+func (p *DataRetentionPurger) pruneAPIKeyUsage(retentionDays int) (int64, error) {
+	cutoff := time.Now().AddDate(0, 0, -retentionDays).Unix()
+	result, err := p.db.Exec(`DELETE FROM api_key_usage WHERE window_start < $1`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune API key usage: %w", err)
+	}
+	deleted, _ := result.RowsAffected()
+	if deleted > 0 {
+		log.Printf("🧹 [RETENTION] Pruned %d API key usage counters older than %d days", deleted, retentionDays)
+	}
+	return deleted, nil
+}
+
+// rollUpFillHistory replaces fill history points of one resolution older than retentionDays with averages per
+// bucket of the next resolution, merging into buckets that already exist (late sensor readings)
+func (p *DataRetentionPurger) rollUpFillHistory(from, to string, retentionDays int) (int64, error) {
+	cutoff := time.Now().AddDate(0, 0, -retentionDays).Unix()
+	bucket := models.FillResolutionSeconds[to]
+	// Only whole buckets are rolled up, so a bucket's points are never split across two runs
+	cutoff -= cutoff % bucket
+
+	result, err := p.db.Exec(`
+		WITH rolled AS (
+			DELETE FROM bin_fill_history
+			WHERE resolution = $1 AND recorded_at < $3
+			RETURNING bin_id, recorded_at, fill_percentage, min_fill, max_fill, sample_count
+		)
+		INSERT INTO bin_fill_history (bin_id, resolution, recorded_at, fill_percentage, min_fill, max_fill, sample_count)
+		SELECT bin_id, $2::TEXT, recorded_at - recorded_at % $4,
+		       SUM(fill_percentage * sample_count) / SUM(sample_count), MIN(min_fill), MAX(max_fill), SUM(sample_count)
+		FROM rolled
+		GROUP BY bin_id, 3
+		ON CONFLICT (bin_id, resolution, recorded_at) WHERE resolution <> 'raw' DO UPDATE SET
+			fill_percentage = (bin_fill_history.fill_percentage * bin_fill_history.sample_count
+			                   + EXCLUDED.fill_percentage * EXCLUDED.sample_count)
+			                  / (bin_fill_history.sample_count + EXCLUDED.sample_count),
+			min_fill = LEAST(bin_fill_history.min_fill, EXCLUDED.min_fill),
+			max_fill = GREATEST(bin_fill_history.max_fill, EXCLUDED.max_fill),
+			sample_count = bin_fill_history.sample_count + EXCLUDED.sample_count
+	`, from, to, cutoff, bucket)
+	if err != nil {
+		return 0, fmt.Errorf("failed to roll up %s fill history: %w", from, err)
+	}
+	buckets, _ := result.RowsAffected()
+	if buckets > 0 {
+		log.Printf("🧹 [RETENTION] Rolled %s fill history older than %d days into %d %s points", from, retentionDays, buckets, to)
+	}
+	return buckets, nil
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// DiagnosticLogPruner deletes mobile diagnostic logs older than the retention period
+type DiagnosticLogPruner struct {
+	db            *sqlx.DB
+	retentionDays int
+}
+
+// NewDiagnosticLogPruner creates a pruner that keeps retentionDays of diagnostic logs
+func NewDiagnosticLogPruner(db *sqlx.DB, retentionDays int) *DiagnosticLogPruner {
+	return &DiagnosticLogPruner{db: db, retentionDays: retentionDays}
+}
+
+// Start prunes immediately and then on every interval until the process exits
+func (p *DiagnosticLogPruner) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := p.Run(); err != nil {
+				log.Printf("❌ [DIAGNOSTICS] Prune failed: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// Run deletes logs older than the retention period (and idle quota counters) and returns how many logs were removed
+func (p *DiagnosticLogPruner) Run() (int64, error) {
+	cutoff := time.Now().AddDate(0, 0, -p.retentionDays).Unix()
+
+	result, err := p.db.Exec(`DELETE FROM diagnostic_logs WHERE created_at < $1`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune diagnostic logs: %w", err)
+	}
+	deleted, _ := result.RowsAffected()
+
+	// Quota windows are a minute long; anything idle for a day is just clutter
+	if _, err := p.db.Exec(`DELETE FROM diagnostic_log_quotas WHERE window_start < $1`, time.Now().Add(-24*time.Hour).Unix()); err != nil {
+		return deleted, fmt.Errorf("failed to prune diagnostic log quotas: %w", err)
+	}
+	if deleted > 0 {
+		log.Printf("🧹 [DIAGNOSTICS] Pruned %d diagnostic logs older than %d days", deleted, p.retentionDays)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// DirectionsLeg is a driving leg between two points
+type DirectionsLeg struct {
+	DistanceMeters  int    `json:"distance_meters"`
+	DurationSeconds int    `json:"duration_seconds"`
+	Polyline        string `json:"polyline"` // Google encoded polyline
+}
+
+// DirectionsService fetches driving legs from the Google Directions API
+type DirectionsService struct {
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewDirectionsService creates a directions client using GOOGLE_MAPS_API_KEY
+func NewDirectionsService() *DirectionsService {
+	apiKey := os.Getenv("GOOGLE_MAPS_API_KEY")
+	if apiKey == "" {
+		log.Printf("⚠️  GOOGLE_MAPS_API_KEY not set - turn-by-turn legs will use straight-line estimates")
+	}
+
+	return &DirectionsService{
+		apiKey: apiKey,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// Enabled reports whether an API key is configured
+func (s *DirectionsService) Enabled() bool {
+	return s != nil && s.apiKey != ""
+}
+
+// GetLeg returns the driving distance, duration (with current traffic) and polyline between two points
+func (s *DirectionsService) GetLeg(ctx context.Context, fromLat, fromLng, toLat, toLng float64) (*DirectionsLeg, error) {
+	if !s.Enabled() {
+		return nil, fmt.Errorf("directions API not configured")
+	}
+
+	params := url.Values{}
+	params.Set("origin", fmt.Sprintf("%f,%f", fromLat, fromLng))
+	params.Set("destination", fmt.Sprintf("%f,%f", toLat, toLng))
+	params.Set("mode", "driving")
+	params.Set("departure_time", "now")
+	params.Set("key", s.apiKey)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		"https://maps.googleapis.com/maps/api/directions/json?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build directions request: %w", err)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("directions request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Status       string `json:"status"`
+		ErrorMessage string `json:"error_message"`
+		Routes       []struct {
+			OverviewPolyline struct {
+				Points string `json:"points"`
+			} `json:"overview_polyline"`
+			Legs []struct {
+				Distance struct {
+					Value int `json:"value"`
+				} `json:"distance"`
+				Duration struct {
+					Value int `json:"value"`
+				} `json:"duration"`
+				DurationInTraffic *struct {
+					Value int `json:"value"`
+				} `json:"duration_in_traffic"`
+			} `json:"legs"`
+		} `json:"routes"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode directions response: %w", err)
+	}
+	if result.Status != "OK" {
+		return nil, fmt.Errorf("directions API returned %s: %s", result.Status, result.ErrorMessage)
+	}
+	if len(result.Routes) == 0 || len(result.Routes[0].Legs) == 0 {
+		return nil, fmt.Errorf("directions API returned no route")
+	}
+
+	route := result.Routes[0]
+	leg := route.Legs[0]
+	duration := leg.Duration.Value
+	if leg.DurationInTraffic != nil {
+		duration = leg.DurationInTraffic.Value
+	}
+
+	return &DirectionsLeg{
+		DistanceMeters:  leg.Distance.Value,
+		DurationSeconds: duration,
+		Polyline:        route.OverviewPolyline.Points,
+	}, nil
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// driverLocationPruneBatch bounds each DELETE so pruning a large backlog doesn't hold long locks
+const driverLocationPruneBatch = 10000
+
+// DriverLocationPruner deletes GPS breadcrumbs older than the retention period
+type DriverLocationPruner struct {
+	db            *sqlx.DB
+	retentionDays int
+}
+
+// NewDriverLocationPruner creates a pruner that keeps retentionDays of driver_locations
+func NewDriverLocationPruner(db *sqlx.DB, retentionDays int) *DriverLocationPruner {
+	return &DriverLocationPruner{db: db, retentionDays: retentionDays}
+}
+
+// Start prunes immediately and then on every interval until the process exits
+func (p *DriverLocationPruner) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := p.Run(); err != nil {
+				log.Printf("❌ [LOCATIONS] Prune failed: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// Run deletes breadcrumbs older than the retention period in batches and returns how many were removed
+func (p *DriverLocationPruner) Run() (int64, error) {
+	cutoff := time.Now().AddDate(0, 0, -p.retentionDays).Unix()
+
+	var total int64
+	for {
+		result, err := p.db.Exec(`
+			DELETE FROM driver_locations
+			WHERE id IN (SELECT id FROM driver_locations WHERE created_at < $1 LIMIT $2)
+		`, cutoff, driverLocationPruneBatch)
+		if err != nil {
+			return total, fmt.Errorf("failed to prune driver locations: %w", err)
+		}
+		deleted, _ := result.RowsAffected()
+		total += deleted
+		if deleted < driverLocationPruneBatch {
+			break
+		}
+	}
+	if total > 0 {
+		log.Printf("🧹 [LOCATIONS] Pruned %d driver locations older than %d days", total, p.retentionDays)
+	}
+	return total, nil
+}
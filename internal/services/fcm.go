@@ -5,16 +5,22 @@ import (
 	"encoding/base64"
 	"fmt"
 	"log"
+	"os"
 	"strconv"
 
+	"ropacal-backend/internal/i18n"
+	"ropacal-backend/internal/models"
+
 	firebase "firebase.google.com/go/v4"
 	"firebase.google.com/go/v4/messaging"
+	"github.com/jmoiron/sqlx"
 	"google.golang.org/api/option"
 )
 
 // FCMService handles Firebase Cloud Messaging
 type FCMService struct {
 	client *messaging.Client
+	db     *sqlx.DB // Records every send in notification_deliveries when set (see TrackDeliveries)
 }
 
 // NewFCMService creates a new FCM service instance from a credentials file
@@ -64,109 +70,270 @@ func NewFCMServiceFromBase64(credentialsBase64 string) (*FCMService, error) {
 	return &FCMService{client: client}, nil
 }
 
-// SendRouteAssignedNotification sends a notification when a route is assigned
-func (s *FCMService) SendRouteAssignedNotification(token, routeID string, totalBins int) error {
-	ctx := context.Background()
+// fcmBatchSize is the maximum number of tokens/messages FCM accepts per batch call
+const fcmBatchSize = 500
 
-	message := &messaging.Message{
-		Token: token,
-		Notification: &messaging.Notification{
-			Title: "New Route Assigned!",
-			Body:  fmt.Sprintf("You have %d bins to collect today. Slide to start your shift.", totalBins),
+// Topic names used for FCM topic subscriptions
+const (
+	fcmRoleTopicPrefix         = "role_"
+	fcmOrganizationTopicPrefix = "org_"
+)
+
+// RoleTopic returns the FCM topic all devices of users with the given role subscribe to
+func RoleTopic(role string) string {
+	return fcmRoleTopicPrefix + role
+}
+
+// OrganizationID identifies this deployment's organization, from FCM_ORGANIZATION_ID (defaults to "ropacal")
+func OrganizationID() string {
+	orgID := os.Getenv("FCM_ORGANIZATION_ID")
+	if orgID == "" {
+		orgID = "ropacal"
+	}
+	return orgID
+}
+
+// OrganizationTopic returns the FCM topic all devices in the organization subscribe to
+func OrganizationTopic() string {
+	return fcmOrganizationTopicPrefix + OrganizationID()
+}
+
+// ShiftUpdatePush is a single shift update notification addressed to one device
+type ShiftUpdatePush struct {
+	Token   string
+	Locale  string // Recipient's locale for the notification text
+	ShiftID string
+	Status  string
+}
+
+// shiftUpdateText returns the localized title and body of a shift update notification
+func shiftUpdateText(locale, status string) (string, string) {
+	return i18n.T(locale, "Shift Update"),
+		i18n.T(locale, "Your shift status has been updated to: %s", i18n.T(locale, status))
+}
+
+// ShiftUpdateOutboxPush builds the notification sent when a driver's shift changes, for helpers.EnqueuePush
+func ShiftUpdateOutboxPush(locale, shiftID, status string) models.OutboxPush {
+	title, body := shiftUpdateText(locale, status)
+	return models.OutboxPush{
+		Title: title,
+		Body:  body,
+		Data: map[string]string{
+			"type":     "shift_update",
+			"shift_id": shiftID,
+			"status":   status,
+		},
+	}
+}
+
+// defaultAndroidConfig returns the Android delivery options shared by all notifications
+func defaultAndroidConfig() *messaging.AndroidConfig {
+	return &messaging.AndroidConfig{
+		Priority: "high",
+	}
+}
+
+// defaultAPNSConfig returns the iOS delivery options shared by all notifications
+func defaultAPNSConfig() *messaging.APNSConfig {
+	return &messaging.APNSConfig{
+		Payload: &messaging.APNSPayload{
+			Aps: &messaging.Aps{
+				ContentAvailable: true,
+				Sound:            "default",
+			},
 		},
+	}
+}
+
+// RouteAssignedOutboxPush builds the notification sent when a route is assigned to a driver, for helpers.EnqueuePush
+func RouteAssignedOutboxPush(locale, routeID string, totalBins int) models.OutboxPush {
+	return models.OutboxPush{
+		Title: i18n.T(locale, "New Route Assigned!"),
+		Body:  i18n.T(locale, "You have %d bins to collect today. Slide to start your shift.", totalBins),
 		Data: map[string]string{
 			"type":       "route_assigned",
 			"route_id":   routeID,
 			"total_bins": strconv.Itoa(totalBins),
 		},
-		Android: &messaging.AndroidConfig{
-			Priority: "high",
-		},
-		APNS: &messaging.APNSConfig{
-			Payload: &messaging.APNSPayload{
-				Aps: &messaging.Aps{
-					ContentAvailable: true,
-					Sound:            "default",
-				},
+	}
+}
+
+// SendRouteAssignedNotification sends a notification to all of a driver's devices when a route is assigned
+// Returns the tokens FCM reported as unregistered so the caller can retire them
+func (s *FCMService) SendRouteAssignedNotification(tokens []string, locale, routeID string, totalBins int) ([]string, error) {
+	push := RouteAssignedOutboxPush(locale, routeID, totalBins)
+	return s.SendMulticast(tokens, push.Title, push.Body, push.Data)
+}
+
+// SendShiftUpdateNotification sends a shift update notification to all of a driver's devices
+// Returns the tokens FCM reported as unregistered so the caller can retire them
+func (s *FCMService) SendShiftUpdateNotification(tokens []string, locale, shiftID, status string) ([]string, error) {
+	push := ShiftUpdateOutboxPush(locale, shiftID, status)
+	return s.SendMulticast(tokens, push.Title, push.Body, push.Data)
+}
+
+// SendShiftUpdateNotifications sends many shift update notifications in batches (bulk operations)
+// Returns the tokens FCM reported as unregistered so the caller can retire them
+func (s *FCMService) SendShiftUpdateNotifications(pushes []ShiftUpdatePush) ([]string, error) {
+	messages := make([]*messaging.Message, 0, len(pushes))
+	for _, push := range pushes {
+		title, body := shiftUpdateText(push.Locale, push.Status)
+		messages = append(messages, &messaging.Message{
+			Token: push.Token,
+			Notification: &messaging.Notification{
+				Title: title,
+				Body:  body,
 			},
-		},
+			Data: map[string]string{
+				"type":     "shift_update",
+				"shift_id": push.ShiftID,
+				"status":   push.Status,
+			},
+			Android: defaultAndroidConfig(),
+			APNS:    defaultAPNSConfig(),
+		})
 	}
 
-	response, err := s.client.Send(ctx, message)
-	if err != nil {
-		return fmt.Errorf("error sending FCM message: %w", err)
+	return s.sendEach(messages)
+}
+
+// SendMulticast sends the same message to multiple tokens, batching by the FCM limit
+// Returns the tokens FCM reported as unregistered so the caller can retire them
+func (s *FCMService) SendMulticast(tokens []string, title, body string, data map[string]string) ([]string, error) {
+	ctx := context.Background()
+
+	var invalidTokens []string
+	successCount, failureCount := 0, 0
+
+	for start := 0; start < len(tokens); start += fcmBatchSize {
+		end := start + fcmBatchSize
+		if end > len(tokens) {
+			end = len(tokens)
+		}
+		batch := tokens[start:end]
+
+		message := &messaging.MulticastMessage{
+			Tokens: batch,
+			Notification: &messaging.Notification{
+				Title: title,
+				Body:  body,
+			},
+			Data:    data,
+			Android: defaultAndroidConfig(),
+			APNS:    defaultAPNSConfig(),
+		}
+
+		response, err := s.client.SendEachForMulticast(ctx, message)
+		s.recordDeliveries(multicastMessages(message), response, err)
+		if err != nil {
+			return invalidTokens, fmt.Errorf("error sending multicast message: %w", err)
+		}
+
+		successCount += response.SuccessCount
+		failureCount += response.FailureCount
+		for i, result := range response.Responses {
+			if !result.Success && messaging.IsUnregistered(result.Error) {
+				invalidTokens = append(invalidTokens, batch[i])
+			}
+		}
 	}
 
-	log.Printf("✅ FCM notification sent successfully: %s", response)
-	return nil
+	log.Printf("✅ Multicast sent: %d success, %d failures (%d unregistered)", successCount, failureCount, len(invalidTokens))
+
+	if len(tokens) > 0 && successCount == 0 {
+		return invalidTokens, fmt.Errorf("multicast delivered to 0 of %d devices", len(tokens))
+	}
+	return invalidTokens, nil
 }
 
-// SendShiftUpdateNotification sends a notification for shift updates
-func (s *FCMService) SendShiftUpdateNotification(token, shiftID, status string) error {
+// sendEach sends individually addressed messages, batching by the FCM limit
+// Returns the tokens FCM reported as unregistered
+func (s *FCMService) sendEach(messages []*messaging.Message) ([]string, error) {
+	ctx := context.Background()
+
+	var invalidTokens []string
+	successCount, failureCount := 0, 0
+
+	for start := 0; start < len(messages); start += fcmBatchSize {
+		end := start + fcmBatchSize
+		if end > len(messages) {
+			end = len(messages)
+		}
+		batch := messages[start:end]
+
+		response, err := s.client.SendEach(ctx, batch)
+		s.recordDeliveries(batch, response, err)
+		if err != nil {
+			return invalidTokens, fmt.Errorf("error sending FCM batch: %w", err)
+		}
+
+		successCount += response.SuccessCount
+		failureCount += response.FailureCount
+		for i, result := range response.Responses {
+			if !result.Success && messaging.IsUnregistered(result.Error) {
+				invalidTokens = append(invalidTokens, batch[i].Token)
+			}
+		}
+	}
+
+	log.Printf("✅ FCM batch sent: %d success, %d failures (%d unregistered)", successCount, failureCount, len(invalidTokens))
+	return invalidTokens, nil
+}
+
+// SendToTopic sends a notification to every device subscribed to a topic
+func (s *FCMService) SendToTopic(topic, title, body string, data map[string]string) error {
 	ctx := context.Background()
 
 	message := &messaging.Message{
-		Token: token,
+		Topic: topic,
 		Notification: &messaging.Notification{
-			Title: "Shift Update",
-			Body:  fmt.Sprintf("Your shift status has been updated to: %s", status),
-		},
-		Data: map[string]string{
-			"type":     "shift_update",
-			"shift_id": shiftID,
-			"status":   status,
-		},
-		Android: &messaging.AndroidConfig{
-			Priority: "high",
-		},
-		APNS: &messaging.APNSConfig{
-			Payload: &messaging.APNSPayload{
-				Aps: &messaging.Aps{
-					ContentAvailable: true,
-					Sound:            "default",
-				},
-			},
+			Title: title,
+			Body:  body,
 		},
+		Data:    data,
+		Android: defaultAndroidConfig(),
+		APNS:    defaultAPNSConfig(),
 	}
 
 	response, err := s.client.Send(ctx, message)
 	if err != nil {
-		return fmt.Errorf("error sending FCM message: %w", err)
+		return fmt.Errorf("error sending FCM topic message: %w", err)
 	}
 
-	log.Printf("✅ FCM notification sent successfully: %s", response)
+	log.Printf("✅ FCM topic notification sent to %s: %s", topic, response)
 	return nil
 }
 
-// SendMulticast sends the same message to multiple tokens
-func (s *FCMService) SendMulticast(tokens []string, title, body string, data map[string]string) error {
+// SubscribeToTopics subscribes tokens to each of the given topics
+// Returns the tokens FCM reported as unregistered so the caller can retire them
+func (s *FCMService) SubscribeToTopics(tokens []string, topics ...string) ([]string, error) {
 	ctx := context.Background()
 
-	message := &messaging.MulticastMessage{
-		Tokens: tokens,
-		Notification: &messaging.Notification{
-			Title: title,
-			Body:  body,
-		},
-		Data: data,
-		Android: &messaging.AndroidConfig{
-			Priority: "high",
-		},
-		APNS: &messaging.APNSConfig{
-			Payload: &messaging.APNSPayload{
-				Aps: &messaging.Aps{
-					ContentAvailable: true,
-					Sound:            "default",
-				},
-			},
-		},
+	var invalidTokens []string
+	for _, topic := range topics {
+		response, err := s.client.SubscribeToTopic(ctx, tokens, topic)
+		if err != nil {
+			return invalidTokens, fmt.Errorf("error subscribing to topic %s: %w", topic, err)
+		}
+		for _, topicErr := range response.Errors {
+			if topicErr.Reason == "NOT_FOUND" && topicErr.Index < len(tokens) {
+				invalidTokens = append(invalidTokens, tokens[topicErr.Index])
+			}
+		}
 	}
 
-	response, err := s.client.SendEachForMulticast(ctx, message)
-	if err != nil {
-		return fmt.Errorf("error sending multicast message: %w", err)
+	return invalidTokens, nil
+}
+
+// UnsubscribeFromTopics removes tokens from each of the given topics
+func (s *FCMService) UnsubscribeFromTopics(tokens []string, topics ...string) error {
+	ctx := context.Background()
+
+	for _, topic := range topics {
+		if _, err := s.client.UnsubscribeFromTopic(ctx, tokens, topic); err != nil {
+			return fmt.Errorf("error unsubscribing from topic %s: %w", topic, err)
+		}
 	}
 
-	log.Printf("✅ Multicast sent: %d success, %d failures", response.SuccessCount, response.FailureCount)
 	return nil
 }
@@ -0,0 +1,286 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Incident photo tagging policy
+const (
+	incidentPhotoMaxAttempts     = 5
+	incidentPhotoBaseBackoff     = time.Minute // Delay before the 2nd attempt; doubles after each failure
+	incidentPhotoMaxBackoff      = 2 * time.Hour
+	incidentPhotoBatchSize       = 20 // Photos classified per run
+	incidentPhotoRequestTimeout  = 30 * time.Second
+	incidentPhotoMaxErrorLength  = 500 // Error text kept in last_error
+	defaultIncidentPhotoMinScore = 0.5
+)
+
+// VisionProvider classifies a photo; implementations may return any labels with a 0-1 confidence,
+// the tagger keeps those in models.IncidentPhotoLabels
+type VisionProvider interface {
+	Name() string
+	Classify(ctx context.Context, photoURL string, labels []string) ([]models.IncidentPhotoLabel, error)
+}
+
+// HTTPVisionProvider calls a classification endpoint over HTTP:
+// POST {"image_url": "...", "labels": ["graffiti", ...]} → {"labels": [{"label": "graffiti", "confidence": 0.93}]}
+// with "Authorization: Bearer <key>" when a key is set
+type HTTPVisionProvider struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+// NewHTTPVisionProvider creates a provider for the endpoint at url
+func NewHTTPVisionProvider(url, apiKey string) *HTTPVisionProvider {
+	return &HTTPVisionProvider{
+		url:    url,
+		apiKey: apiKey,
+		client: &http.Client{Timeout: incidentPhotoRequestTimeout},
+	}
+}
+
+// Name identifies the provider in incident_photo_labels
+func (p *HTTPVisionProvider) Name() string {
+	return "http"
+}
+
+// Classify sends the photo URL and candidate labels to the endpoint
+func (p *HTTPVisionProvider) Classify(ctx context.Context, photoURL string, labels []string) ([]models.IncidentPhotoLabel, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"image_url": photoURL,
+		"labels":    labels,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		text, _ := io.ReadAll(io.LimitReader(resp.Body, incidentPhotoMaxErrorLength))
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(text))
+	}
+
+	var result struct {
+		Labels []models.IncidentPhotoLabel `json:"labels"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	return result.Labels, nil
+}
+
+// NewVisionProviderFromEnv configures the vision provider from INCIDENT_VISION_URL and INCIDENT_VISION_API_KEY
+// Returns nil when no provider is configured (photos stay queued until one is)
+func NewVisionProviderFromEnv() VisionProvider {
+	url := os.Getenv("INCIDENT_VISION_URL")
+	if url == "" {
+		return nil
+	}
+	return NewHTTPVisionProvider(url, os.Getenv("INCIDENT_VISION_API_KEY"))
+}
+
+// IncidentPhotoTagger classifies queued incident photos with a vision provider and stores the labels
+// it detects on the incident, retrying failures with exponential backoff
+type IncidentPhotoTagger struct {
+	db       *sqlx.DB
+	provider VisionProvider
+	minScore float64    // Labels below this confidence are not stored
+	mu       sync.Mutex // Serializes runs so a photo is never classified twice concurrently
+}
+
+// NewIncidentPhotoTagger creates a tagger; INCIDENT_VISION_MIN_CONFIDENCE (0.5) sets the confidence labels need
+func NewIncidentPhotoTagger(db *sqlx.DB, provider VisionProvider) *IncidentPhotoTagger {
+	minScore := defaultIncidentPhotoMinScore
+	if v := os.Getenv("INCIDENT_VISION_MIN_CONFIDENCE"); v != "" {
+		if parsed, err := strconv.ParseFloat(v, 64); err == nil && parsed >= 0 && parsed <= 1 {
+			minScore = parsed
+		} else {
+			log.Printf("⚠️  [PHOTO-TAGS] Invalid INCIDENT_VISION_MIN_CONFIDENCE %q (using %.2f)", v, minScore)
+		}
+	}
+	return &IncidentPhotoTagger{db: db, provider: provider, minScore: minScore}
+}
+
+// Start runs the tagger immediately and then on every interval until the process exits
+func (t *IncidentPhotoTagger) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := t.Run(); err != nil {
+				log.Printf("❌ [PHOTO-TAGS] Tagging failed: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// incidentPhotoBackoff returns the delay before the next attempt after the given number of failed attempts
+func incidentPhotoBackoff(attempts int) time.Duration {
+	backoff := incidentPhotoBaseBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= incidentPhotoMaxBackoff {
+			return incidentPhotoMaxBackoff
+		}
+	}
+	return backoff
+}
+
+// Run classifies every queued photo that is due, oldest first
+func (t *IncidentPhotoTagger) Run() (*models.IncidentPhotoTagResult, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	result := &models.IncidentPhotoTagResult{RanAt: time.Now().Unix()}
+
+	var jobs []models.IncidentPhotoJob
+	err := t.db.Select(&jobs, `
+		SELECT * FROM incident_photo_jobs
+		WHERE status = $1 AND next_attempt_at <= $2
+		ORDER BY next_attempt_at ASC
+		LIMIT $3
+	`, models.IncidentPhotoPending, result.RanAt, incidentPhotoBatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load queued photos: %w", err)
+	}
+
+	for _, job := range jobs {
+		result.Attempted++
+		switch t.tag(job) {
+		case models.IncidentPhotoTagged:
+			result.Tagged++
+		case models.IncidentPhotoFailed:
+			result.Failed++
+		default:
+			result.Retrying++
+		}
+	}
+
+	if result.Attempted > 0 {
+		log.Printf("🏷️  [PHOTO-TAGS] Classified %d photos: %d tagged, %d retrying, %d failed",
+			result.Attempted, result.Tagged, result.Retrying, result.Failed)
+	}
+	return result, nil
+}
+
+// tag classifies one photo and records the outcome, returning the job's new status
+func (t *IncidentPhotoTagger) tag(job models.IncidentPhotoJob) string {
+	ctx, cancel := context.WithTimeout(context.Background(), incidentPhotoRequestTimeout)
+	defer cancel()
+
+	detected, err := t.provider.Classify(ctx, job.PhotoURL, models.IncidentPhotoLabels)
+	if err == nil {
+		err = t.saveLabels(job, detected)
+		if err == nil {
+			return models.IncidentPhotoTagged
+		}
+	}
+	return t.recordFailure(job, err)
+}
+
+// saveLabels replaces the incident's labels with the known ones detected with enough confidence
+// Nothing is saved if a different photo was queued for the incident while this one was being classified
+func (t *IncidentPhotoTagger) saveLabels(job models.IncidentPhotoJob, detected []models.IncidentPhotoLabel) error {
+	best := map[string]float64{}
+	for _, label := range detected {
+		if !models.IsValidIncidentPhotoLabel(label.Label) || label.Confidence < t.minScore || label.Confidence > 1 {
+			continue
+		}
+		if label.Confidence > best[label.Label] {
+			best[label.Label] = label.Confidence
+		}
+	}
+
+	now := time.Now().Unix()
+	provider := t.provider.Name()
+	return database.WithTx(context.Background(), t.db, func(tx *sqlx.Tx) error {
+		res, err := tx.Exec(`
+			UPDATE incident_photo_jobs
+			SET status = $1, attempts = attempts + 1, provider = $2, last_error = NULL, tagged_at = $3, updated_at = $3
+			WHERE incident_id = $4 AND photo_url = $5 AND status = $6
+		`, models.IncidentPhotoTagged, provider, now, job.IncidentID, job.PhotoURL, models.IncidentPhotoPending)
+		if err != nil {
+			return fmt.Errorf("failed to update job: %w", err)
+		}
+		if rows, _ := res.RowsAffected(); rows == 0 {
+			return nil
+		}
+
+		if _, err := tx.Exec(`DELETE FROM incident_photo_labels WHERE incident_id = $1`, job.IncidentID); err != nil {
+			return fmt.Errorf("failed to clear old labels: %w", err)
+		}
+		for label, confidence := range best {
+			_, err := tx.Exec(`
+				INSERT INTO incident_photo_labels (incident_id, label, confidence, provider, created_at)
+				VALUES ($1, $2, $3, $4, $5)
+			`, job.IncidentID, label, confidence, provider, now)
+			if err != nil {
+				return fmt.Errorf("failed to save label %s: %w", label, err)
+			}
+		}
+		log.Printf("🏷️  [PHOTO-TAGS] Incident %s tagged with %d label(s)", job.IncidentID, len(best))
+		return nil
+	})
+}
+
+// recordFailure schedules the next attempt, or gives up after the maximum number of attempts
+func (t *IncidentPhotoTagger) recordFailure(job models.IncidentPhotoJob, tagErr error) string {
+	now := time.Now()
+	attempts := job.Attempts + 1
+
+	message := tagErr.Error()
+	if len(message) > incidentPhotoMaxErrorLength {
+		message = message[:incidentPhotoMaxErrorLength]
+	}
+
+	status := models.IncidentPhotoPending
+	nextAttemptAt := now.Add(incidentPhotoBackoff(attempts)).Unix()
+	if attempts >= incidentPhotoMaxAttempts {
+		status = models.IncidentPhotoFailed
+		log.Printf("❌ [PHOTO-TAGS] Giving up on photo of incident %s after %d attempts: %s", job.IncidentID, attempts, message)
+	} else {
+		log.Printf("⚠️  [PHOTO-TAGS] Photo of incident %s attempt %d failed, retrying at %d: %s", job.IncidentID, attempts, nextAttemptAt, message)
+	}
+
+	_, err := t.db.Exec(`
+		UPDATE incident_photo_jobs
+		SET status = $1, attempts = $2, next_attempt_at = $3, provider = $4, last_error = $5, updated_at = $6
+		WHERE incident_id = $7 AND photo_url = $8 AND status = $9
+	`, status, attempts, nextAttemptAt, t.provider.Name(), message, now.Unix(), job.IncidentID, job.PhotoURL, models.IncidentPhotoPending)
+	if err != nil {
+		log.Printf("❌ [PHOTO-TAGS] Failed to record outcome for incident %s: %v", job.IncidentID, err)
+	}
+	return status
+}
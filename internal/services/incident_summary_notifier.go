@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/helpers"
+	"ropacal-backend/internal/i18n"
+	"ropacal-backend/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// IncidentSummaryNotifier sends each manager one summary a day of the incidents their daily incident
+// subscriptions matched (see helpers.NotifyIncidentSubscribers), once the digest's local send time has passed
+// Incidents queued after the day's summary wait for the next one
+type IncidentSummaryNotifier struct {
+	db *sqlx.DB
+}
+
+// NewIncidentSummaryNotifier creates a new incident summary notifier
+func NewIncidentSummaryNotifier(db *sqlx.DB) *IncidentSummaryNotifier {
+	return &IncidentSummaryNotifier{db: db}
+}
+
+// RunJob is the JobHandler of the periodic models.JobKindIncidentSummary job
+func (n *IncidentSummaryNotifier) RunJob(ctx context.Context, job models.Job) error {
+	_, err := n.Run(time.Now())
+	return err
+}
+
+// incidentSummaryRow is a queued incident with its recipient and channels
+type incidentSummaryRow struct {
+	models.IncidentSummaryItem
+	UserID    string `db:"user_id"`
+	WebSocket bool   `db:"websocket"`
+	Push      bool   `db:"push"`
+}
+
+// Run sends today's summaries if the send time has passed and they weren't sent yet
+// Returns the number of managers a summary was sent to
+func (n *IncidentSummaryNotifier) Run(now time.Time) (int, error) {
+	settings, err := database.GetDigestSettings(n.db)
+	if err != nil {
+		log.Printf("⚠️  [INCIDENT-SUMMARY] %v (using defaults)", err)
+	}
+	local := now.In(digestLocation(settings))
+	sendAt, _ := models.ParseTimeOfDay(settings.SendAt)
+	if local.Hour()*60+local.Minute() < sendAt {
+		return 0, nil
+	}
+	summaryDate := local.Format("2006-01-02")
+
+	tx, err := n.db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO incident_summary_runs (summary_date, sent_at) VALUES ($1, $2)
+		ON CONFLICT (summary_date) DO NOTHING
+	`, summaryDate, now.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to record summary run: %w", err)
+	}
+	if claimed, _ := result.RowsAffected(); claimed == 0 {
+		return 0, nil // Already sent today
+	}
+
+	var rows []incidentSummaryRow
+	err = tx.Select(&rows, `
+		SELECT q.user_id, q.websocket, q.push, zi.id AS incident_id, zi.incident_type,
+			COALESCE(it.label, zi.incident_type) AS label, COALESCE(it.severity, $2) AS severity,
+			zi.bin_id, b.bin_number, zi.description, zi.reported_at
+		FROM incident_summary_queue q
+		JOIN users u ON u.id = q.user_id
+		JOIN zone_incidents zi ON zi.id = q.incident_id
+		LEFT JOIN incident_types it ON it.key = zi.incident_type
+		LEFT JOIN bins b ON b.id = zi.bin_id
+		WHERE q.queued_at <= $1 AND u.deactivated_at IS NULL
+		ORDER BY q.user_id, zi.reported_at ASC
+	`, now.Unix(), models.IncidentSeverityMedium)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load queued incidents: %w", err)
+	}
+
+	type recipient struct {
+		summary         models.IncidentSummary
+		websocket, push bool
+	}
+	recipients := map[string]*recipient{}
+	for _, row := range rows {
+		r, ok := recipients[row.UserID]
+		if !ok {
+			r = &recipient{summary: models.IncidentSummary{Date: summaryDate, ByType: map[string]int{}}}
+			recipients[row.UserID] = r
+		}
+		r.summary.Incidents = append(r.summary.Incidents, row.IncidentSummaryItem)
+		r.summary.ByType[row.IncidentType]++
+		r.summary.Total++
+		r.websocket = r.websocket || row.WebSocket
+		r.push = r.push || row.Push
+	}
+
+	userIDs := make([]string, 0, len(recipients))
+	for userID := range recipients {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Strings(userIDs)
+	for _, userID := range userIDs {
+		r := recipients[userID]
+		if r.websocket {
+			if _, err := helpers.EnqueueUserMessage(tx, userID, map[string]interface{}{
+				"type": "incident_summary",
+				"data": r.summary,
+			}); err != nil {
+				return 0, err
+			}
+		}
+		if r.push {
+			locale := database.UserLocale(tx, userID)
+			if _, err := helpers.EnqueuePush(tx, userID, models.OutboxPush{
+				Title: i18n.T(locale, "Incident summary"),
+				Body:  i18n.T(locale, "%d incidents reported since the last summary", r.summary.Total),
+				Data: map[string]string{
+					"type":  "incident_summary",
+					"date":  summaryDate,
+					"total": strconv.Itoa(r.summary.Total),
+				},
+			}); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	// Everything queued up to now is summarized (deactivated managers' incidents are dropped)
+	if _, err := tx.Exec(`DELETE FROM incident_summary_queue WHERE queued_at <= $1`, now.Unix()); err != nil {
+		return 0, fmt.Errorf("failed to clear summarized incidents: %w", err)
+	}
+	if _, err := tx.Exec(`UPDATE incident_summary_runs SET summaries = $1 WHERE summary_date = $2`, len(userIDs), summaryDate); err != nil {
+		return 0, fmt.Errorf("failed to record summary run: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit summaries: %w", err)
+	}
+
+	if len(userIDs) > 0 {
+		log.Printf("📰 [INCIDENT-SUMMARY] Sent %s summaries to %d manager(s) (%d incidents)", summaryDate, len(userIDs), len(rows))
+	}
+	return len(userIDs), nil
+}
@@ -0,0 +1,312 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"ropacal-backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Background job policy
+const (
+	jobPeriodicMaxAttempts = 3                // Periodic jobs run again at their next interval anyway
+	jobBaseBackoff         = 30 * time.Second // Delay before the 2nd attempt; doubles after each failure
+	jobMaxBackoff          = time.Hour
+	jobBatchSize           = 20               // Jobs claimed per run
+	jobStaleAfter          = 30 * time.Minute // Running jobs older than this were lost with their server and are retried
+	jobMaxErrorLength      = 500
+	jobRetentionDays       = 7 // Succeeded jobs are deleted after this long; dead ones stay until retried
+)
+
+// JobHandler runs one attempt of a job; returning an error retries it with backoff
+// ctx is cancelled when a shutdown runs out of time
+type JobHandler func(ctx context.Context, job models.Job) error
+
+// JobRunner runs the background jobs queued in the jobs table by helpers.EnqueueJob and the periodic jobs
+// registered with Every. Failed attempts are retried with exponential backoff; after max_attempts the job is
+// dead-lettered for an admin to look at and retry (GET /api/manager/jobs)
+// Jobs are claimed with FOR UPDATE SKIP LOCKED, so several servers can share the queue
+type JobRunner struct {
+	db       *sqlx.DB
+	handlers map[string]JobHandler
+	periodic map[string]time.Duration
+	mu       sync.Mutex // Serializes runs
+
+	ctx      context.Context // Cancelled when a shutdown times out
+	cancel   context.CancelFunc
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// JobRunResult summarizes a single run
+type JobRunResult struct {
+	Claimed   int   `json:"claimed"`
+	Succeeded int   `json:"succeeded"`
+	Retrying  int   `json:"retrying"`
+	Dead      int   `json:"dead"`
+	Recovered int64 `json:"recovered"` // Stale running jobs put back in the queue
+	Pruned    int64 `json:"pruned"`
+	RanAt     int64 `json:"ran_at"`
+}
+
+// NewJobRunner creates a job runner without handlers
+func NewJobRunner(db *sqlx.DB) *JobRunner {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &JobRunner{
+		db:       db,
+		handlers: map[string]JobHandler{},
+		periodic: map[string]time.Duration{},
+		ctx:      ctx,
+		cancel:   cancel,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Register sets the handler for a job kind; jobs of kinds without a handler stay queued
+func (r *JobRunner) Register(kind string, handler JobHandler) {
+	r.handlers[kind] = handler
+}
+
+// Every registers a periodic job: it runs as soon as the runner starts and then interval after each run
+// finishes, whether it succeeded or was dead-lettered
+func (r *JobRunner) Every(kind string, interval time.Duration, handler JobHandler) {
+	r.handlers[kind] = handler
+	r.periodic[kind] = interval
+}
+
+// Start runs due jobs immediately and then on every interval until Shutdown
+func (r *JobRunner) Start(interval time.Duration) {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := r.Run(); err != nil {
+				log.Printf("❌ [JOBS] Run failed: %v", err)
+			}
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Shutdown stops claiming jobs and waits for the running ones to finish
+// If ctx ends first, the running jobs' context is cancelled; jobs interrupted that way are put back in the
+// queue without counting the attempt
+func (r *JobRunner) Shutdown(ctx context.Context) error {
+	r.stopOnce.Do(func() { close(r.stop) })
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		r.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// stopping reports whether Shutdown was called
+func (r *JobRunner) stopping() bool {
+	select {
+	case <-r.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// jobBackoff returns the delay before the next attempt after the given number of failed attempts
+func jobBackoff(attempts int) time.Duration {
+	backoff := jobBaseBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= jobMaxBackoff {
+			return jobMaxBackoff
+		}
+	}
+	return backoff
+}
+
+// Run recovers stale jobs, schedules the periodic ones and runs every due job it has a handler for, oldest first
+func (r *JobRunner) Run() (*JobRunResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().Unix()
+	result := &JobRunResult{RanAt: now}
+
+	recovered, err := r.db.Exec(`
+		UPDATE jobs SET
+			status = CASE WHEN attempts >= max_attempts THEN $1 ELSE $2 END,
+			finished_at = CASE WHEN attempts >= max_attempts THEN $3 END,
+			last_error = 'Worker stopped before the job finished', run_at = $3, locked_at = NULL, updated_at = $3
+		WHERE status = $4 AND locked_at < $5
+	`, models.JobStatusDead, models.JobStatusPending, now, models.JobStatusRunning, now-int64(jobStaleAfter.Seconds()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to recover stale jobs: %w", err)
+	}
+	result.Recovered, _ = recovered.RowsAffected()
+
+	for kind := range r.periodic {
+		if err := r.schedule(r.db, kind, now); err != nil {
+			return nil, err
+		}
+	}
+
+	if !r.stopping() && len(r.handlers) > 0 {
+		kinds := make([]string, 0, len(r.handlers))
+		for kind := range r.handlers {
+			kinds = append(kinds, kind)
+		}
+		var jobs []models.Job
+		err = r.db.Select(&jobs, `
+			UPDATE jobs SET status = $1, attempts = attempts + 1, locked_at = $2, updated_at = $2
+			WHERE id IN (
+				SELECT id FROM jobs
+				WHERE status = $3 AND run_at <= $2 AND kind = ANY($4)
+				ORDER BY run_at ASC
+				LIMIT $5
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING *
+		`, models.JobStatusRunning, now, models.JobStatusPending, pq.Array(kinds), jobBatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to claim due jobs: %w", err)
+		}
+		result.Claimed = len(jobs)
+
+		for _, job := range jobs {
+			status, err := r.execute(job)
+			if err != nil {
+				return nil, err
+			}
+			switch status {
+			case models.JobStatusSucceeded:
+				result.Succeeded++
+			case models.JobStatusDead:
+				result.Dead++
+			default:
+				result.Retrying++
+			}
+		}
+	}
+
+	pruned, err := r.db.Exec(`DELETE FROM jobs WHERE status = $1 AND finished_at < $2`,
+		models.JobStatusSucceeded, now-jobRetentionDays*86400)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prune finished jobs: %w", err)
+	}
+	result.Pruned, _ = pruned.RowsAffected()
+
+	if result.Claimed > 0 || result.Recovered > 0 {
+		log.Printf("✅ [JOBS] Ran %d job(s): %d succeeded, %d retrying, %d dead (%d recovered, %d pruned)",
+			result.Claimed, result.Succeeded, result.Retrying, result.Dead, result.Recovered, result.Pruned)
+	}
+	return result, nil
+}
+
+// execute runs one claimed attempt and records its outcome, returning the job's new status
+func (r *JobRunner) execute(job models.Job) (string, error) {
+	started := time.Now()
+	jobErr := r.attempt(job)
+	now := time.Now().Unix()
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return "", fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	status := models.JobStatusSucceeded
+	switch {
+	case jobErr == nil:
+		_, err = tx.Exec(`
+			UPDATE jobs SET status = $1, finished_at = $2, locked_at = NULL, updated_at = $2 WHERE id = $3
+		`, status, now, job.ID)
+	case r.ctx.Err() != nil:
+		// Interrupted by the shutdown: run it again on the next start without counting the attempt
+		status = models.JobStatusPending
+		_, err = tx.Exec(`
+			UPDATE jobs SET status = $1, attempts = attempts - 1, run_at = $2, locked_at = NULL, updated_at = $2 WHERE id = $3
+		`, status, now, job.ID)
+	default:
+		message := jobErr.Error()
+		if len(message) > jobMaxErrorLength {
+			message = message[:jobMaxErrorLength]
+		}
+		if job.Attempts >= job.MaxAttempts {
+			status = models.JobStatusDead
+			log.Printf("☠️  [JOBS] %s job %s dead after %d attempts: %s", job.Kind, job.ID, job.Attempts, message)
+			_, err = tx.Exec(`
+				UPDATE jobs SET status = $1, last_error = $2, finished_at = $3, locked_at = NULL, updated_at = $3 WHERE id = $4
+			`, status, message, now, job.ID)
+		} else {
+			status = models.JobStatusPending
+			log.Printf("⚠️  [JOBS] %s job %s failed (attempt %d/%d), retrying: %s", job.Kind, job.ID, job.Attempts, job.MaxAttempts, message)
+			_, err = tx.Exec(`
+				UPDATE jobs SET status = $1, last_error = $2, run_at = $3, locked_at = NULL, updated_at = $4 WHERE id = $5
+			`, status, message, now+int64(jobBackoff(job.Attempts).Seconds()), now, job.ID)
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to record %s job %s: %w", job.Kind, job.ID, err)
+	}
+
+	// A periodic job's next run is due an interval after this one finished
+	if interval, ok := r.periodic[job.Kind]; ok && status != models.JobStatusPending {
+		if err := r.schedule(tx, job.Kind, now+int64(interval.Seconds())); err != nil {
+			return "", err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to record %s job %s: %w", job.Kind, job.ID, err)
+	}
+
+	if elapsed := time.Since(started); elapsed > time.Minute {
+		log.Printf("🐢 [JOBS] %s job %s took %s", job.Kind, job.ID, elapsed.Round(time.Second))
+	}
+	return status, nil
+}
+
+// attempt calls the job's handler, turning a panic into an error
+func (r *JobRunner) attempt(job models.Job) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+	return r.handlers[job.Kind](r.ctx, job)
+}
+
+// schedule queues a periodic job's next run unless one is already pending or running
+func (r *JobRunner) schedule(q sqlx.Execer, kind string, runAt int64) error {
+	now := time.Now().Unix()
+	_, err := q.Exec(`
+		INSERT INTO jobs (id, kind, payload, unique_key, status, attempts, max_attempts, run_at, created_at, updated_at)
+		VALUES ($1, $2, '{}', $2, $3, 0, $4, $5, $6, $6)
+		ON CONFLICT (unique_key) WHERE status IN ('pending', 'running') DO NOTHING
+	`, uuid.New().String(), kind, models.JobStatusPending, jobPeriodicMaxAttempts, runAt, now)
+	if err != nil {
+		return fmt.Errorf("failed to schedule %s job: %w", kind, err)
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/helpers"
+	"ropacal-backend/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// MoveSLAChecker flags move requests that passed their assignment or completion deadline
+// (see models.MoveSLAPolicy) and escalates each breach once to admins and managers
+type MoveSLAChecker struct {
+	db *sqlx.DB
+}
+
+// NewMoveSLAChecker creates a new move request SLA checker
+func NewMoveSLAChecker(db *sqlx.DB) *MoveSLAChecker {
+	return &MoveSLAChecker{db: db}
+}
+
+// Start checks immediately and then on every interval until the process exits
+func (c *MoveSLAChecker) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := c.Run(time.Now()); err != nil {
+				log.Printf("❌ [MOVE-SLA] Check failed: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// moveSLABreachRow is a move request returned by the breach-flagging updates
+type moveSLABreachRow struct {
+	ID            string `db:"id"`
+	BinID         string `db:"bin_id"`
+	BinNumber     int    `db:"bin_number"`
+	Urgency       string `db:"urgency"`
+	Status        string `db:"status"`
+	ScheduledDate int64  `db:"scheduled_date"`
+	CreatedAt     int64  `db:"created_at"`
+}
+
+// Run records the breaches that happened by now and queues their escalation in the same transaction
+func (c *MoveSLAChecker) Run(now time.Time) ([]models.MoveSLABreach, error) {
+	policy, err := database.GetMoveSLAPolicy(c.db)
+	if err != nil {
+		log.Printf("⚠️  [MOVE-SLA] %v (using defaults)", err)
+	}
+	nowUnix := now.Unix()
+
+	tx, err := c.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Deadlines mirror MoveSLAPolicy.AssignDeadline and CompleteDeadline
+	var assignRows []moveSLABreachRow
+	err = tx.Select(&assignRows, `
+		UPDATE bin_move_requests bmr
+		SET assign_sla_breached_at = $1
+		FROM bins b
+		WHERE b.id = bmr.bin_id
+		  AND bmr.assign_sla_breached_at IS NULL
+		  AND bmr.status = 'pending'
+		  AND bmr.assigned_shift_id IS NULL
+		  AND bmr.assigned_user_id IS NULL
+		  AND CASE WHEN bmr.urgency = 'urgent'
+		           THEN bmr.created_at + $2
+		           ELSE GREATEST(bmr.scheduled_date - $3, bmr.created_at + $2)
+		      END <= $1
+		RETURNING bmr.id, bmr.bin_id, b.bin_number, bmr.urgency, bmr.status, bmr.scheduled_date, bmr.created_at
+	`, nowUnix, int64(policy.UrgentAssignHours*3600), int64(policy.ScheduledAssignLeadHours*3600))
+	if err != nil {
+		return nil, fmt.Errorf("failed to flag assignment breaches: %w", err)
+	}
+
+	var completeRows []moveSLABreachRow
+	err = tx.Select(&completeRows, `
+		UPDATE bin_move_requests bmr
+		SET complete_sla_breached_at = $1
+		FROM bins b
+		WHERE b.id = bmr.bin_id
+		  AND bmr.complete_sla_breached_at IS NULL
+		  AND bmr.status IN ('pending', 'in_progress', 'picked_up')
+		  AND CASE WHEN bmr.urgency = 'urgent'
+		           THEN bmr.created_at + $2
+		           ELSE bmr.scheduled_date + $3
+		      END <= $1
+		RETURNING bmr.id, bmr.bin_id, b.bin_number, bmr.urgency, bmr.status, bmr.scheduled_date, bmr.created_at
+	`, nowUnix, int64(policy.UrgentCompleteHours*3600), int64(policy.ScheduledCompleteGraceHours*3600))
+	if err != nil {
+		return nil, fmt.Errorf("failed to flag completion breaches: %w", err)
+	}
+
+	breaches := make([]models.MoveSLABreach, 0, len(assignRows)+len(completeRows))
+	for _, row := range assignRows {
+		breaches = append(breaches, row.breach(models.MoveSLABreachAssign, policy.AssignDeadline(row.Urgency, row.CreatedAt, row.ScheduledDate), nowUnix))
+	}
+	for _, row := range completeRows {
+		breaches = append(breaches, row.breach(models.MoveSLABreachComplete, policy.CompleteDeadline(row.Urgency, row.CreatedAt, row.ScheduledDate), nowUnix))
+	}
+	if len(breaches) == 0 {
+		return breaches, nil
+	}
+
+	if err := c.escalate(tx, breaches); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit SLA breaches: %w", err)
+	}
+
+	log.Printf("🚨 [MOVE-SLA] Flagged %d assignment and %d completion breaches", len(assignRows), len(completeRows))
+	return breaches, nil
+}
+
+func (row moveSLABreachRow) breach(kind string, deadline, now int64) models.MoveSLABreach {
+	return models.MoveSLABreach{
+		MoveRequestID: row.ID,
+		BinID:         row.BinID,
+		BinNumber:     row.BinNumber,
+		Urgency:       row.Urgency,
+		Status:        row.Status,
+		Kind:          kind,
+		Deadline:      deadline,
+		BreachedAt:    now,
+	}
+}
+
+// escalate queues a WebSocket alert for the admin and manager dashboards and a push to
+// every admin or manager with a registered device
+func (c *MoveSLAChecker) escalate(tx *sqlx.Tx, breaches []models.MoveSLABreach) error {
+	var recipients []string
+	err := tx.Select(&recipients, `
+		SELECT DISTINCT u.id
+		FROM users u
+		JOIN fcm_tokens t ON t.user_id = u.id
+		WHERE u.role IN ('admin', 'manager')
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to load escalation recipients: %w", err)
+	}
+
+	for _, breach := range breaches {
+		message := map[string]interface{}{
+			"type": "move_sla_breached",
+			"data": breach,
+		}
+		for _, role := range []string{"admin", "manager"} {
+			if _, err := helpers.EnqueueRoleMessage(tx, role, message); err != nil {
+				return fmt.Errorf("failed to queue SLA alert: %w", err)
+			}
+		}
+
+		push := models.OutboxPush{
+			Title: "Move request SLA breached",
+			Body:  fmt.Sprintf("Bin #%d (%s move) has not been assigned in time", breach.BinNumber, breach.Urgency),
+			Data: map[string]string{
+				"type":            "move_sla_breached",
+				"move_request_id": breach.MoveRequestID,
+				"kind":            breach.Kind,
+			},
+		}
+		if breach.Kind == models.MoveSLABreachComplete {
+			push.Body = fmt.Sprintf("Bin #%d (%s move) has not been completed in time", breach.BinNumber, breach.Urgency)
+		}
+		for _, userID := range recipients {
+			if _, err := helpers.EnqueuePush(tx, userID, push); err != nil {
+				return fmt.Errorf("failed to queue SLA push: %w", err)
+			}
+		}
+	}
+	return nil
+}
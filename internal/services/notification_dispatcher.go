@@ -0,0 +1,269 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/websocket"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Notification outbox delivery policy
+const (
+	outboxMaxAttempts    = 10              // Events are marked failed after this many attempts
+	outboxBaseBackoff    = 5 * time.Second // Delay before the 2nd attempt; doubles after each failure
+	outboxMaxBackoff     = 10 * time.Minute
+	outboxBatchSize      = 200 // Events delivered per run
+	outboxMaxErrorLength = 500
+	outboxRetentionDays  = 7 // Delivered and failed events are deleted after this long
+)
+
+// NotificationDispatcher delivers notification_outbox events to the WebSocket hub and FCM
+// Each event is delivered at most once by this server: rows are claimed with FOR UPDATE SKIP LOCKED
+// and marked delivered in the same transaction. Clients receive the event ID as event_id to
+// ignore a redelivery after a crash between sending and committing
+type NotificationDispatcher struct {
+	db  *sqlx.DB
+	hub *websocket.Hub
+	fcm *FCMService // nil when push notifications are disabled
+	mu  sync.Mutex  // Serializes runs
+}
+
+// NotificationDispatchResult summarizes a single dispatch run
+type NotificationDispatchResult struct {
+	Attempted int   `json:"attempted"`
+	Delivered int   `json:"delivered"`
+	Retrying  int   `json:"retrying"`
+	Deferred  int   `json:"deferred"` // Non-urgent pushes held until service hours open
+	Failed    int   `json:"failed"`
+	Pruned    int64 `json:"pruned"`
+	RanAt     int64 `json:"ran_at"`
+}
+
+// NewNotificationDispatcher creates a new notification dispatcher
+func NewNotificationDispatcher(db *sqlx.DB, hub *websocket.Hub, fcm *FCMService) *NotificationDispatcher {
+	return &NotificationDispatcher{db: db, hub: hub, fcm: fcm}
+}
+
+// Start runs the dispatcher immediately and then on every interval until the process exits
+func (d *NotificationDispatcher) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := d.Run(); err != nil {
+				log.Printf("❌ [OUTBOX] Dispatch failed: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// outboxBackoff returns the delay before the next attempt after the given number of failed attempts
+func outboxBackoff(attempts int) time.Duration {
+	backoff := outboxBaseBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= outboxMaxBackoff {
+			return outboxMaxBackoff
+		}
+	}
+	return backoff
+}
+
+// Run delivers every pending event that is due, oldest first
+func (d *NotificationDispatcher) Run() (*NotificationDispatchResult, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	result := &NotificationDispatchResult{RanAt: now.Unix()}
+
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var events []models.OutboxEvent
+	err = tx.Select(&events, `
+		SELECT * FROM notification_outbox
+		WHERE status = $1 AND next_attempt_at <= $2
+		ORDER BY created_at ASC
+		LIMIT $3
+		FOR UPDATE SKIP LOCKED
+	`, models.OutboxStatusPending, result.RanAt, outboxBatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load due events: %w", err)
+	}
+
+	// Outside service hours non-urgent pushes wait for the next opening instead of waking drivers up
+	var deferUntil int64
+	serviceHours, err := database.GetServiceHoursSettings(d.db)
+	if err != nil {
+		log.Printf("⚠️  [OUTBOX] %v (using defaults)", err)
+	}
+	if serviceHours.DeferPush && !serviceHours.IsOpen(now) {
+		if open, _, ok := serviceHours.NextWindow(now); ok {
+			deferUntil = open.Unix()
+		}
+	}
+
+	var invalidTokens []string
+	for _, event := range events {
+		if deferUntil > 0 && event.Channel == models.OutboxChannelPush && !isUrgentPush(event) {
+			_, err := tx.Exec(`
+				UPDATE notification_outbox SET next_attempt_at = $1, updated_at = $2 WHERE id = $3
+			`, deferUntil, result.RanAt, event.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to defer event %s: %w", event.ID, err)
+			}
+			result.Deferred++
+			continue
+		}
+		result.Attempted++
+
+		status := models.OutboxStatusDelivered
+		retired, deliveryErr := d.deliver(tx, event)
+		invalidTokens = append(invalidTokens, retired...)
+		attempts := event.Attempts + 1
+		nextAttemptAt := event.NextAttemptAt
+		var deliveredAt *int64
+		var lastError *string
+
+		if deliveryErr != nil {
+			message := deliveryErr.Error()
+			if len(message) > outboxMaxErrorLength {
+				message = message[:outboxMaxErrorLength]
+			}
+			lastError = &message
+
+			if _, permanent := deliveryErr.(outboxPermanentError); permanent || attempts >= outboxMaxAttempts {
+				status = models.OutboxStatusFailed
+				log.Printf("❌ [OUTBOX] Giving up on %s %s to %s after %d attempts: %s", event.Channel, event.EventType, event.Target, attempts, message)
+			} else {
+				status = models.OutboxStatusPending
+				nextAttemptAt = now.Add(outboxBackoff(attempts)).Unix()
+			}
+		} else {
+			deliveredAt = &result.RanAt
+		}
+
+		switch status {
+		case models.OutboxStatusDelivered:
+			result.Delivered++
+		case models.OutboxStatusFailed:
+			result.Failed++
+		default:
+			result.Retrying++
+		}
+
+		_, err := tx.Exec(`
+			UPDATE notification_outbox
+			SET status = $1, attempts = $2, next_attempt_at = $3, last_error = $4, delivered_at = $5, updated_at = $6
+			WHERE id = $7
+		`, status, attempts, nextAttemptAt, lastError, deliveredAt, result.RanAt, event.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record outcome of event %s: %w", event.ID, err)
+		}
+	}
+
+	pruned, err := tx.Exec(`
+		DELETE FROM notification_outbox
+		WHERE status <> $1 AND updated_at < $2
+	`, models.OutboxStatusPending, now.AddDate(0, 0, -outboxRetentionDays).Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to prune delivered events: %w", err)
+	}
+	result.Pruned, _ = pruned.RowsAffected()
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit: %w", err)
+	}
+
+	if len(invalidTokens) > 0 {
+		if _, err := d.db.Exec(`DELETE FROM fcm_tokens WHERE token = ANY($1)`, pq.Array(invalidTokens)); err != nil {
+			log.Printf("⚠️  [OUTBOX] Failed to retire %d unregistered FCM token(s): %v", len(invalidTokens), err)
+		}
+	}
+
+	if result.Attempted > 0 {
+		log.Printf("📬 [OUTBOX] Dispatched %d events: %d delivered, %d retrying, %d failed",
+			result.Attempted, result.Delivered, result.Retrying, result.Failed)
+	}
+	if result.Deferred > 0 {
+		log.Printf("🌙 [OUTBOX] Deferred %d non-urgent push(es) until service hours open", result.Deferred)
+	}
+	return result, nil
+}
+
+// isUrgentPush reports whether a push event must be delivered outside service hours
+// An unreadable payload counts as urgent so deliver reports it as failed rather than holding it
+func isUrgentPush(event models.OutboxEvent) bool {
+	var push models.OutboxPush
+	if err := json.Unmarshal(event.Payload, &push); err != nil {
+		return true
+	}
+	return push.Urgent
+}
+
+// outboxPermanentError marks a delivery failure that retrying cannot fix
+type outboxPermanentError string
+
+func (e outboxPermanentError) Error() string { return string(e) }
+
+// deliver sends one event; WebSocket messages to disconnected users are retried until they reconnect
+// Returns the FCM tokens reported as unregistered so they can be retired after the run commits
+func (d *NotificationDispatcher) deliver(tx *sqlx.Tx, event models.OutboxEvent) ([]string, error) {
+	switch event.Channel {
+	case models.OutboxChannelWebSocket:
+		var message map[string]interface{}
+		if err := json.Unmarshal(event.Payload, &message); err != nil {
+			return nil, outboxPermanentError(fmt.Sprintf("invalid payload: %v", err))
+		}
+		message["event_id"] = event.ID
+
+		if event.TargetType == models.OutboxTargetRole {
+			d.hub.BroadcastToRole(event.Target, message)
+			return nil, nil
+		}
+		if !d.hub.IsUserConnected(event.Target) {
+			return nil, fmt.Errorf("user %s is not connected", event.Target)
+		}
+		d.hub.BroadcastToUser(event.Target, message)
+		return nil, nil
+
+	case models.OutboxChannelPush:
+		if d.fcm == nil {
+			return nil, outboxPermanentError("push notifications are disabled")
+		}
+		var push models.OutboxPush
+		if err := json.Unmarshal(event.Payload, &push); err != nil {
+			return nil, outboxPermanentError(fmt.Sprintf("invalid payload: %v", err))
+		}
+		if push.Data == nil {
+			push.Data = map[string]string{}
+		}
+		push.Data["event_id"] = event.ID
+
+		var tokens []string
+		if err := tx.Select(&tokens, `SELECT token FROM fcm_tokens WHERE user_id = $1 ORDER BY updated_at DESC`, event.Target); err != nil {
+			return nil, fmt.Errorf("failed to fetch FCM tokens: %w", err)
+		}
+		if len(tokens) == 0 {
+			return nil, outboxPermanentError("no registered devices")
+		}
+
+		return d.fcm.SendMulticast(tokens, push.Title, push.Body, push.Data)
+	}
+
+	return nil, outboxPermanentError(fmt.Sprintf("unknown channel %q", event.Channel))
+}
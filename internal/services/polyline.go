@@ -0,0 +1,87 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// EncodePolyline encodes points in Google's encoded polyline format (precision 5, as the Directions API returns)
+func EncodePolyline(points []OptimizerLocation) string {
+	var b strings.Builder
+	var prevLat, prevLng int64
+	for _, point := range points {
+		lat := int64(math.Round(point.Latitude * 1e5))
+		lng := int64(math.Round(point.Longitude * 1e5))
+		encodePolylineValue(&b, lat-prevLat)
+		encodePolylineValue(&b, lng-prevLng)
+		prevLat, prevLng = lat, lng
+	}
+	return b.String()
+}
+
+func encodePolylineValue(b *strings.Builder, value int64) {
+	shifted := value << 1
+	if value < 0 {
+		shifted = ^shifted
+	}
+	for shifted >= 0x20 {
+		b.WriteByte(byte((0x20 | (shifted & 0x1f)) + 63))
+		shifted >>= 5
+	}
+	b.WriteByte(byte(shifted + 63))
+}
+
+// DecodePolyline decodes a Google encoded polyline (precision 5)
+func DecodePolyline(encoded string) ([]OptimizerLocation, error) {
+	var points []OptimizerLocation
+	var lat, lng int64
+	for i := 0; i < len(encoded); {
+		var deltas [2]int64
+		for d := range deltas {
+			var result int64
+			var shift uint
+			for {
+				if i >= len(encoded) {
+					return nil, fmt.Errorf("polyline ends mid-value")
+				}
+				c := int64(encoded[i]) - 63
+				i++
+				if c < 0 || c > 0x3f {
+					return nil, fmt.Errorf("invalid polyline character %q", encoded[i-1])
+				}
+				result |= (c & 0x1f) << shift
+				shift += 5
+				if c < 0x20 {
+					break
+				}
+			}
+			if result&1 != 0 {
+				deltas[d] = ^(result >> 1)
+			} else {
+				deltas[d] = result >> 1
+			}
+		}
+		lat += deltas[0]
+		lng += deltas[1]
+		points = append(points, OptimizerLocation{Latitude: float64(lat) / 1e5, Longitude: float64(lng) / 1e5})
+	}
+	return points, nil
+}
+
+// ChainPolylines joins consecutive legs' encoded polylines into one line, dropping the point where
+// one leg ends and the next starts when they coincide
+func ChainPolylines(legs []string) (string, error) {
+	var points []OptimizerLocation
+	for _, leg := range legs {
+		legPoints, err := DecodePolyline(leg)
+		if err != nil {
+			return "", err
+		}
+		if len(points) > 0 && len(legPoints) > 0 && EncodePolyline(points[len(points)-1:]) == EncodePolyline(legPoints[:1]) {
+			legPoints = legPoints[1:]
+		}
+		points = append(points, legPoints...)
+	}
+	return EncodePolyline(points), nil
+}
@@ -0,0 +1,341 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"ropacal-backend/internal/models"
+
+	"firebase.google.com/go/v4/messaging"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Push delivery retry policy
+const (
+	pushDeliveryMaxAttempts    = 5                // Deliveries are marked failed after this many attempts
+	pushDeliveryBaseBackoff    = 30 * time.Second // Delay before the 2nd attempt; doubles after each failure
+	pushDeliveryMaxBackoff     = 15 * time.Minute
+	pushDeliveryMaxAge         = time.Hour // Deliveries still retrying after this long expire: a late shift update only confuses
+	pushDeliveryLease          = 2 * time.Minute
+	pushDeliveryBatchSize      = 200 // Deliveries resent per run
+	pushDeliveryMaxErrorLength = 500
+	pushDeliveryRetentionDays  = 30 // Finished deliveries are kept this long for "I never got the notification" reports
+)
+
+// TrackDeliveries records every push sent from now on in notification_deliveries, one row per device,
+// so transient failures can be retried by a PushDeliveryRetrier
+func (s *FCMService) TrackDeliveries(db *sqlx.DB) {
+	s.db = db
+}
+
+// multicastMessages expands a multicast message into the per-device messages FCM sends
+func multicastMessages(message *messaging.MulticastMessage) []*messaging.Message {
+	messages := make([]*messaging.Message, 0, len(message.Tokens))
+	for _, token := range message.Tokens {
+		messages = append(messages, &messaging.Message{
+			Token:        token,
+			Notification: message.Notification,
+			Data:         message.Data,
+			Android:      message.Android,
+			APNS:         message.APNS,
+		})
+	}
+	return messages
+}
+
+// pushErrorCode classifies an FCM send error and reports whether retrying it can succeed
+// Errors FCM doesn't classify (network failures, timeouts) count as transient
+func pushErrorCode(err error) (string, bool) {
+	switch {
+	case messaging.IsUnregistered(err):
+		return "unregistered", false
+	case messaging.IsInvalidArgument(err):
+		return "invalid_argument", false
+	case messaging.IsSenderIDMismatch(err):
+		return "sender_id_mismatch", false
+	case messaging.IsThirdPartyAuthError(err):
+		return "third_party_auth", false
+	case messaging.IsQuotaExceeded(err):
+		return "quota_exceeded", true
+	case messaging.IsUnavailable(err):
+		return "unavailable", true
+	case messaging.IsInternal(err):
+		return "internal", true
+	}
+	return "unknown", true
+}
+
+// truncatePushError shortens an error message to what last_error keeps
+func truncatePushError(err error) string {
+	message := err.Error()
+	if len(message) > pushDeliveryMaxErrorLength {
+		message = message[:pushDeliveryMaxErrorLength]
+	}
+	return message
+}
+
+// pushDeliveryBackoff returns the delay before the next attempt after the given number of failed attempts
+func pushDeliveryBackoff(attempts int) time.Duration {
+	backoff := pushDeliveryBaseBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= pushDeliveryMaxBackoff {
+			return pushDeliveryMaxBackoff
+		}
+	}
+	return backoff
+}
+
+// recordDeliveries stores the outcome of one FCM batch, a row per message
+// batchErr is set when the whole batch failed, in which case response is nil
+// Recording failures are only logged: tracking must never stop a push from going out
+func (s *FCMService) recordDeliveries(messages []*messaging.Message, response *messaging.BatchResponse, batchErr error) {
+	if s.db == nil || len(messages) == 0 {
+		return
+	}
+
+	n := len(messages)
+	ids, tokens, types := make([]string, n), make([]string, n), make([]string, n)
+	titles, bodies, data := make([]string, n), make([]string, n), make([]string, n)
+	statuses, messageIDs := make([]string, n), make([]string, n)
+	errorCodes, lastErrors := make([]string, n), make([]string, n)
+
+	for i, message := range messages {
+		ids[i] = uuid.New().String()
+		tokens[i] = message.Token
+		types[i] = message.Data["type"]
+		if message.Notification != nil {
+			titles[i] = message.Notification.Title
+			bodies[i] = message.Notification.Body
+		}
+		data[i] = "{}"
+		if len(message.Data) > 0 {
+			if raw, err := json.Marshal(message.Data); err == nil {
+				data[i] = string(raw)
+			}
+		}
+
+		sendErr := batchErr
+		if sendErr == nil {
+			if response == nil || i >= len(response.Responses) {
+				sendErr = fmt.Errorf("no response from FCM")
+			} else if result := response.Responses[i]; result.Success {
+				statuses[i] = models.PushDeliverySent
+				messageIDs[i] = result.MessageID
+				continue
+			} else {
+				sendErr = result.Error
+			}
+		}
+
+		code, transient := pushErrorCode(sendErr)
+		statuses[i] = models.PushDeliveryFailed
+		if transient {
+			statuses[i] = models.PushDeliveryRetrying
+		}
+		errorCodes[i] = code
+		lastErrors[i] = truncatePushError(sendErr)
+	}
+
+	now := time.Now()
+	_, err := s.db.Exec(`
+		INSERT INTO notification_deliveries (
+			id, user_id, token, notification_type, title, body, data, status, fcm_message_id,
+			attempts, error_code, last_error, next_attempt_at, sent_at, created_at, updated_at
+		)
+		SELECT d.id, t.user_id, d.token, d.notification_type, d.title, d.body, d.data::JSONB, d.status,
+			NULLIF(d.fcm_message_id, ''), 1, NULLIF(d.error_code, ''), NULLIF(d.last_error, ''),
+			CASE WHEN d.status = $11 THEN $14::BIGINT END,
+			CASE WHEN d.status = $12 THEN $13::BIGINT END,
+			$13, $13
+		FROM UNNEST($1::TEXT[], $2::TEXT[], $3::TEXT[], $4::TEXT[], $5::TEXT[], $6::TEXT[], $7::TEXT[], $8::TEXT[], $9::TEXT[], $10::TEXT[])
+			AS d(id, token, notification_type, title, body, data, status, fcm_message_id, error_code, last_error)
+		LEFT JOIN fcm_tokens t ON t.token = d.token
+	`, pq.Array(ids), pq.Array(tokens), pq.Array(types), pq.Array(titles), pq.Array(bodies), pq.Array(data),
+		pq.Array(statuses), pq.Array(messageIDs), pq.Array(errorCodes), pq.Array(lastErrors),
+		models.PushDeliveryRetrying, models.PushDeliverySent, now.Unix(), now.Add(pushDeliveryBackoff(1)).Unix())
+	if err != nil {
+		log.Printf("⚠️  [PUSH] Failed to record %d delivery attempt(s): %v", n, err)
+	}
+}
+
+// PushDeliveryRetrier resends pushes that failed with a transient FCM error, backing off exponentially,
+// and expires the ones still failing after pushDeliveryMaxAge
+// Due deliveries are claimed with FOR UPDATE SKIP LOCKED and a short lease, so several servers can run it
+type PushDeliveryRetrier struct {
+	db  *sqlx.DB
+	fcm *FCMService
+	mu  sync.Mutex // Serializes runs
+}
+
+// PushRetryResult summarizes a single retry run
+type PushRetryResult struct {
+	Attempted int   `json:"attempted"`
+	Sent      int   `json:"sent"`
+	Retrying  int   `json:"retrying"`
+	Failed    int   `json:"failed"`
+	Expired   int64 `json:"expired"`
+	Pruned    int64 `json:"pruned"`
+	RanAt     int64 `json:"ran_at"`
+}
+
+// NewPushDeliveryRetrier creates a new push delivery retrier
+func NewPushDeliveryRetrier(db *sqlx.DB, fcm *FCMService) *PushDeliveryRetrier {
+	return &PushDeliveryRetrier{db: db, fcm: fcm}
+}
+
+// Start runs the retrier immediately and then on every interval until the process exits
+func (r *PushDeliveryRetrier) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := r.Run(); err != nil {
+				log.Printf("❌ [PUSH] Retry run failed: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// Run expires stale deliveries, resends every retrying delivery that is due and prunes old finished ones
+func (r *PushDeliveryRetrier) Run() (*PushRetryResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	result := &PushRetryResult{RanAt: now.Unix()}
+
+	expired, err := r.db.Exec(`
+		UPDATE notification_deliveries
+		SET status = $1, next_attempt_at = NULL, updated_at = $2
+		WHERE status = $3 AND created_at < $4
+	`, models.PushDeliveryExpired, result.RanAt, models.PushDeliveryRetrying, now.Add(-pushDeliveryMaxAge).Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire stale deliveries: %w", err)
+	}
+	result.Expired, _ = expired.RowsAffected()
+
+	var deliveries []models.PushDelivery
+	err = r.db.Select(&deliveries, `
+		UPDATE notification_deliveries SET next_attempt_at = $1
+		WHERE id IN (
+			SELECT id FROM notification_deliveries
+			WHERE status = $2 AND next_attempt_at <= $3
+			ORDER BY next_attempt_at ASC
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *
+	`, now.Add(pushDeliveryLease).Unix(), models.PushDeliveryRetrying, result.RanAt, pushDeliveryBatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim due deliveries: %w", err)
+	}
+
+	var invalidTokens []string
+	for _, delivery := range deliveries {
+		result.Attempted++
+		status, unregistered := r.resend(delivery)
+		switch status {
+		case models.PushDeliverySent:
+			result.Sent++
+		case models.PushDeliveryRetrying:
+			result.Retrying++
+		default:
+			result.Failed++
+		}
+		if unregistered {
+			invalidTokens = append(invalidTokens, delivery.Token)
+		}
+	}
+
+	pruned, err := r.db.Exec(`
+		DELETE FROM notification_deliveries
+		WHERE status <> $1 AND created_at < $2
+	`, models.PushDeliveryRetrying, now.AddDate(0, 0, -pushDeliveryRetentionDays).Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to prune old deliveries: %w", err)
+	}
+	result.Pruned, _ = pruned.RowsAffected()
+
+	if len(invalidTokens) > 0 {
+		if _, err := r.db.Exec(`DELETE FROM fcm_tokens WHERE token = ANY($1)`, pq.Array(invalidTokens)); err != nil {
+			log.Printf("⚠️  [PUSH] Failed to retire %d unregistered FCM token(s): %v", len(invalidTokens), err)
+		}
+	}
+
+	if result.Attempted > 0 {
+		log.Printf("📬 [PUSH] Retried %d deliveries: %d sent, %d retrying, %d failed",
+			result.Attempted, result.Sent, result.Retrying, result.Failed)
+	}
+	if result.Expired > 0 {
+		log.Printf("⌛ [PUSH] Expired %d delivery(ies) still failing after %s", result.Expired, pushDeliveryMaxAge)
+	}
+	return result, nil
+}
+
+// resend sends one delivery again and records the outcome, returning the delivery's new status
+// and whether FCM reported its token as unregistered
+func (r *PushDeliveryRetrier) resend(delivery models.PushDelivery) (string, bool) {
+	now := time.Now()
+	attempts := delivery.Attempts + 1
+
+	var data map[string]string
+	sendErr := json.Unmarshal(delivery.Data, &data)
+
+	var messageID string
+	if sendErr == nil {
+		messageID, sendErr = r.fcm.client.Send(context.Background(), &messaging.Message{
+			Token: delivery.Token,
+			Notification: &messaging.Notification{
+				Title: delivery.Title,
+				Body:  delivery.Body,
+			},
+			Data:    data,
+			Android: defaultAndroidConfig(),
+			APNS:    defaultAPNSConfig(),
+		})
+	}
+
+	status := models.PushDeliverySent
+	var fcmMessageID, errorCode, lastError *string
+	var nextAttemptAt, sentAt *int64
+	unregistered := false
+	if sendErr == nil {
+		sent := now.Unix()
+		fcmMessageID, sentAt = &messageID, &sent
+	} else {
+		code, transient := pushErrorCode(sendErr)
+		message := truncatePushError(sendErr)
+		errorCode, lastError = &code, &message
+		unregistered = messaging.IsUnregistered(sendErr)
+
+		if transient && attempts < pushDeliveryMaxAttempts {
+			status = models.PushDeliveryRetrying
+			next := now.Add(pushDeliveryBackoff(attempts)).Unix()
+			nextAttemptAt = &next
+		} else {
+			status = models.PushDeliveryFailed
+			log.Printf("❌ [PUSH] Giving up on %s push %s after %d attempts: %s", delivery.NotificationType, delivery.ID, attempts, message)
+		}
+	}
+
+	_, err := r.db.Exec(`
+		UPDATE notification_deliveries
+		SET status = $1, attempts = $2, fcm_message_id = $3, error_code = $4, last_error = $5,
+		    next_attempt_at = $6, sent_at = $7, updated_at = $8
+		WHERE id = $9
+	`, status, attempts, fcmMessageID, errorCode, lastError, nextAttemptAt, sentAt, now.Unix(), delivery.ID)
+	if err != nil {
+		log.Printf("❌ [PUSH] Failed to record outcome of delivery %s: %v", delivery.ID, err)
+	}
+
+	return status, unregistered
+}
@@ -1,8 +1,14 @@
 package services
 
 import (
+	"fmt"
 	"log"
 	"math"
+	"os"
+	"sync"
+	"time"
+
+	"ropacal-backend/internal/models"
 )
 
 // Warehouse constants - all routes end here
@@ -33,6 +39,51 @@ type BinWithPriority struct {
 	Longitude      float64
 	FillPercentage int
 	CurrentStreet  string
+	TimeWindow     *models.TimeWindow // Optional local service window
+	RiskWeight     float64            // No-go zone risk (see ZoneRisks); weighted stops are pulled earlier in the route
+}
+
+// Planning assumptions used to estimate arrival times against time windows
+// Distances are straight-line, so the speed is deliberately conservative
+const (
+	optimizerAverageSpeedKmh = 30.0
+	optimizerServiceMinutes  = 5.0  // Time spent at each stop (matches the route preview's collection time)
+	windowUrgencyMinutes     = 30.0 // A window closing within this long after arrival is served first
+)
+
+// defaultRouteTimezone is used when ROUTE_TIMEZONE is unset (the warehouse is in San Jose)
+const defaultRouteTimezone = "America/Los_Angeles"
+
+var (
+	routeTimezoneOnce sync.Once
+	routeTimezone     *time.Location
+)
+
+// RouteTimezone is the zone stop time windows are expressed in (ROUTE_TIMEZONE, default America/Los_Angeles)
+func RouteTimezone() *time.Location {
+	routeTimezoneOnce.Do(func() {
+		name := os.Getenv("ROUTE_TIMEZONE")
+		if name == "" {
+			name = defaultRouteTimezone
+		}
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			log.Printf("⚠️  Invalid ROUTE_TIMEZONE=%q, using UTC", name)
+			loc = time.UTC
+		}
+		routeTimezone = loc
+	})
+	return routeTimezone
+}
+
+// TimeWindowViolation is a stop the planned route reaches after its window has closed
+type TimeWindowViolation struct {
+	ID               string `json:"id"`
+	CurrentStreet    string `json:"current_street"`
+	TimeWindowStart  string `json:"time_window_start"`
+	TimeWindowEnd    string `json:"time_window_end"`
+	EstimatedArrival string `json:"estimated_arrival"` // Local "HH:MM"
+	MinutesLate      int    `json:"minutes_late"`
 }
 
 // RouteOptimizer handles route optimization using TSP algorithms
@@ -44,7 +95,7 @@ func NewRouteOptimizer() *RouteOptimizer {
 }
 
 // OptimizeRoute optimizes bin order using nearest neighbor TSP
-// Minimizes total distance by always selecting the closest remaining bin
+// Minimizes total distance by always selecting the closest remaining bin (risk-weighted bins count as closer)
 func (ro *RouteOptimizer) OptimizeRoute(
 	bins []BinWithPriority,
 	startLocation OptimizerLocation,
@@ -57,6 +108,15 @@ func (ro *RouteOptimizer) OptimizeRoute(
 		return bins
 	}
 
+	if HasTimeWindows(bins) {
+		optimized, violations := ro.OptimizeRouteWithTimeWindows(bins, startLocation, time.Now())
+		for _, v := range violations {
+			log.Printf("   ⚠️  %s reached at %s, %d min after its %s-%s window",
+				v.CurrentStreet, v.EstimatedArrival, v.MinutesLate, v.TimeWindowStart, v.TimeWindowEnd)
+		}
+		return optimized
+	}
+
 	log.Printf("🎯 Starting route optimization from (%.6f, %.6f)",
 		startLocation.Latitude, startLocation.Longitude)
 	log.Printf("   Total bins to optimize: %d", len(bins))
@@ -72,6 +132,7 @@ func (ro *RouteOptimizer) OptimizeRoute(
 	for len(remaining) > 0 {
 		bestIdx := 0
 		bestDistance := math.MaxFloat64
+		bestScore := math.MaxFloat64
 
 		for i, bin := range remaining {
 			// Calculate straight-line distance (Haversine)
@@ -82,8 +143,9 @@ func (ro *RouteOptimizer) OptimizeRoute(
 				bin.Longitude,
 			)
 
-			// Select the nearest bin (shortest distance)
-			if distance < bestDistance {
+			// Select the nearest bin (shortest risk-adjusted distance)
+			if score := riskAdjusted(distance, bin); score < bestScore {
+				bestScore = score
 				bestDistance = distance
 				bestIdx = i
 			}
@@ -133,6 +195,146 @@ func (ro *RouteOptimizer) OptimizeRoute(
 	return optimized
 }
 
+// HasTimeWindows reports whether any bin has a service window
+func HasTimeWindows(bins []BinWithPriority) bool {
+	for _, bin := range bins {
+		if bin.TimeWindow != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// OptimizeRouteWithTimeWindows orders bins by nearest neighbor while respecting service windows
+// Stops are simulated from departure: a window about to close is served first, waiting for a window
+// to open counts against a stop, and stops that can no longer be reached in time come last
+// Returns the route and the stops it still reaches late
+func (ro *RouteOptimizer) OptimizeRouteWithTimeWindows(
+	bins []BinWithPriority,
+	startLocation OptimizerLocation,
+	departure time.Time,
+) ([]BinWithPriority, []TimeWindowViolation) {
+	log.Printf("🎯 Starting time-window route optimization for %d bins from (%.6f, %.6f)",
+		len(bins), startLocation.Latitude, startLocation.Longitude)
+
+	optimized := make([]BinWithPriority, 0, len(bins))
+	remaining := make([]BinWithPriority, len(bins))
+	copy(remaining, bins)
+
+	current := startLocation
+	clock := minuteOfDay(departure)
+
+	for len(remaining) > 0 {
+		bestIdx, bestUrgentIdx, bestLateIdx := -1, -1, -1
+		var bestStart, bestUrgentSlack, bestLateStart float64
+
+		for i, bin := range remaining {
+			arrival := clock + travelMinutes(current, bin)
+			// Ranking uses risk-adjusted travel; the clock keeps the real arrival
+			serviceStart := clock + riskAdjusted(travelMinutes(current, bin), bin)
+			if bin.TimeWindow == nil {
+				if bestIdx < 0 || serviceStart < bestStart {
+					bestIdx, bestStart = i, serviceStart
+				}
+				continue
+			}
+
+			windowStart, windowEnd := bin.TimeWindow.Minutes()
+			if arrival > float64(windowEnd) {
+				if bestLateIdx < 0 || arrival < bestLateStart {
+					bestLateIdx, bestLateStart = i, arrival
+				}
+				continue
+			}
+			serviceStart = math.Max(serviceStart, float64(windowStart))
+			if slack := float64(windowEnd) - arrival; slack <= windowUrgencyMinutes && (bestUrgentIdx < 0 || slack < bestUrgentSlack) {
+				bestUrgentIdx, bestUrgentSlack = i, slack
+			}
+			if bestIdx < 0 || serviceStart < bestStart {
+				bestIdx, bestStart = i, serviceStart
+			}
+		}
+
+		chosen := bestIdx
+		if bestUrgentIdx >= 0 {
+			chosen = bestUrgentIdx
+		}
+		if chosen < 0 {
+			chosen = bestLateIdx
+		}
+
+		bin := remaining[chosen]
+		clock = serviceStartMinute(clock+travelMinutes(current, bin), bin) + optimizerServiceMinutes
+		optimized = append(optimized, bin)
+		current = OptimizerLocation{Latitude: bin.Latitude, Longitude: bin.Longitude}
+		remaining = append(remaining[:chosen], remaining[chosen+1:]...)
+	}
+
+	violations := EvaluateTimeWindows(optimized, startLocation, departure)
+	log.Printf("✅ Time-window optimization complete (%d window violations)", len(violations))
+	return optimized, violations
+}
+
+// EvaluateTimeWindows simulates a route in the given order and lists the stops reached after their window closes
+func EvaluateTimeWindows(route []BinWithPriority, startLocation OptimizerLocation, departure time.Time) []TimeWindowViolation {
+	violations := []TimeWindowViolation{}
+	current := startLocation
+	clock := minuteOfDay(departure)
+	for _, bin := range route {
+		arrival := clock + travelMinutes(current, bin)
+		if bin.TimeWindow != nil {
+			_, windowEnd := bin.TimeWindow.Minutes()
+			if arrival > float64(windowEnd) {
+				violations = append(violations, TimeWindowViolation{
+					ID:               bin.ID,
+					CurrentStreet:    bin.CurrentStreet,
+					TimeWindowStart:  bin.TimeWindow.Start,
+					TimeWindowEnd:    bin.TimeWindow.End,
+					EstimatedArrival: formatMinuteOfDay(arrival),
+					MinutesLate:      int(math.Ceil(arrival - float64(windowEnd))),
+				})
+			}
+		}
+		clock = serviceStartMinute(arrival, bin) + optimizerServiceMinutes
+		current = OptimizerLocation{Latitude: bin.Latitude, Longitude: bin.Longitude}
+	}
+	return violations
+}
+
+// travelMinutes estimates the driving time to a bin
+func travelMinutes(from OptimizerLocation, bin BinWithPriority) float64 {
+	return haversineDistance(from.Latitude, from.Longitude, bin.Latitude, bin.Longitude) / optimizerAverageSpeedKmh * 60
+}
+
+// riskAdjusted shrinks a distance or travel time to a bin by its zone risk weight, so risky stops come sooner
+func riskAdjusted(cost float64, bin BinWithPriority) float64 {
+	if bin.RiskWeight <= 0 {
+		return cost
+	}
+	return cost / (1 + bin.RiskWeight)
+}
+
+// serviceStartMinute is when work at a bin can begin: on arrival, or once its window opens
+func serviceStartMinute(arrival float64, bin BinWithPriority) float64 {
+	if bin.TimeWindow == nil {
+		return arrival
+	}
+	windowStart, _ := bin.TimeWindow.Minutes()
+	return math.Max(arrival, float64(windowStart))
+}
+
+// minuteOfDay is the local time of day of t in minutes (in the route timezone)
+func minuteOfDay(t time.Time) float64 {
+	local := t.In(RouteTimezone())
+	return float64(local.Hour()*60+local.Minute()) + float64(local.Second())/60
+}
+
+// formatMinuteOfDay renders minutes after midnight as "HH:MM" (wrapping past midnight)
+func formatMinuteOfDay(minutes float64) string {
+	m := int(math.Round(minutes)) % (24 * 60)
+	return fmt.Sprintf("%02d:%02d", m/60, m%60)
+}
+
 // haversineDistance calculates the distance between two GPS coordinates in kilometers
 func haversineDistance(lat1, lon1, lat2, lon2 float64) float64 {
 	const earthRadius = 6371.0 // Earth's radius in kilometers
@@ -0,0 +1,288 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"ropacal-backend/internal/helpers"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/store"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// RouteReoptimizeSettle is how long a queued request waits before it runs, so the changes of one
+// action (e.g. inserting a move and its dropoff) are picked up together
+const RouteReoptimizeSettle = 5 * time.Second
+
+// RouteReoptimizer re-optimizes the remaining route of shifts queued in route_reoptimizations
+// (see helpers.RequestRouteReoptimization). Each shift runs at most once per debounce interval:
+// requests made in between are merged and handled by the next run
+//
+// Only the shift's incomplete collection stops are reordered, into the slots they already occupy;
+// move, placement and warehouse stops keep their position. The order is computed from the driver's
+// last known location and applied in one transaction, which also queues a single shift_update for
+// the driver and a route_reoptimized event for managers
+type RouteReoptimizer struct {
+	db       *sqlx.DB
+	debounce time.Duration
+	mu       sync.Mutex // Serializes runs
+}
+
+// NewRouteReoptimizer creates a new route re-optimizer
+func NewRouteReoptimizer(db *sqlx.DB, debounce time.Duration) *RouteReoptimizer {
+	return &RouteReoptimizer{db: db, debounce: debounce}
+}
+
+// NextRunAt returns when a queued request is expected to run
+func (o *RouteReoptimizer) NextRunAt(queued *models.RouteReoptimization) int64 {
+	next := queued.RequestedAt + int64(RouteReoptimizeSettle/time.Second)
+	if queued.LastRunAt != nil {
+		if debounced := *queued.LastRunAt + int64(o.debounce/time.Second); debounced > next {
+			next = debounced
+		}
+	}
+	return next
+}
+
+// Start runs the re-optimizer immediately and then on every interval until the process exits
+func (o *RouteReoptimizer) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := o.Run(); err != nil {
+				log.Printf("❌ [REOPTIMIZE] Run failed: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// Run re-optimizes every queued shift that is due
+func (o *RouteReoptimizer) Run() ([]models.RouteReoptimizationResult, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	now := time.Now()
+	var shiftIDs []string
+	err := o.db.Select(&shiftIDs, `
+		SELECT shift_id FROM route_reoptimizations
+		WHERE pending
+		  AND requested_at <= $1
+		  AND (last_run_at IS NULL OR last_run_at <= $2)
+		ORDER BY requested_at ASC
+	`, now.Add(-RouteReoptimizeSettle).Unix(), now.Add(-o.debounce).Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list queued re-optimizations: %w", err)
+	}
+
+	results := make([]models.RouteReoptimizationResult, 0, len(shiftIDs))
+	for _, shiftID := range shiftIDs {
+		result, err := o.reoptimize(shiftID, now.Unix())
+		if err != nil {
+			log.Printf("❌ [REOPTIMIZE] Shift %s: %v", shiftID, err)
+			continue
+		}
+		if result != nil {
+			results = append(results, *result)
+		}
+	}
+	return results, nil
+}
+
+// reoptimize re-optimizes one shift; nil means another server claimed it first
+func (o *RouteReoptimizer) reoptimize(shiftID string, now int64) (*models.RouteReoptimizationResult, error) {
+	tx, err := o.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var queued models.RouteReoptimization
+	err = tx.Get(&queued, `SELECT * FROM route_reoptimizations WHERE shift_id = $1 AND pending FOR UPDATE SKIP LOCKED`, shiftID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim re-optimization: %w", err)
+	}
+
+	stores := store.New(tx)
+	if err := stores.Shifts.Lock(shiftID); err != nil {
+		return nil, err
+	}
+	var shift models.Shift
+	if err := tx.Get(&shift, `SELECT * FROM shifts WHERE id = $1`, shiftID); err != nil {
+		return nil, fmt.Errorf("failed to fetch shift: %w", err)
+	}
+
+	result := &models.RouteReoptimizationResult{ShiftID: shiftID, Reasons: queued.Reasons}
+	if shift.Status == models.ShiftStatusActive || shift.Status == models.ShiftStatusPaused {
+		if err := o.reorderRemaining(tx, stores, &shift, result); err != nil {
+			return nil, err
+		}
+	} else {
+		result.Skipped = "shift is " + string(shift.Status)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE route_reoptimizations
+		SET pending = FALSE, reasons = '{}', last_run_at = $2, last_reasons = $3, last_changed_stops = $4
+		WHERE shift_id = $1
+	`, shiftID, now, queued.Reasons, result.ChangedStops)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record re-optimization: %w", err)
+	}
+
+	if result.ChangedStops > 0 {
+		if err := queueReoptimizedRoute(tx, stores, shiftID, result); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit re-optimization: %w", err)
+	}
+
+	if result.Skipped != "" {
+		log.Printf("⏭️  [REOPTIMIZE] Shift %s not re-optimized: %s", shiftID, result.Skipped)
+	} else {
+		log.Printf("🔀 [REOPTIMIZE] Shift %s: %d of %d stops reordered (%v)", shiftID, result.ChangedStops, result.Stops, result.Reasons)
+	}
+	return result, nil
+}
+
+// reorderRemaining reorders the shift's incomplete collection stops within their current slots
+func (o *RouteReoptimizer) reorderRemaining(tx *sqlx.Tx, stores store.Stores, shift *models.Shift, result *models.RouteReoptimizationResult) error {
+	stops, err := stores.Shifts.Stops(shift.ID)
+	if err != nil {
+		return err
+	}
+
+	var remaining []models.ShiftBinWithDetails
+	var lastCompleted *models.ShiftBinWithDetails
+	for i, stop := range stops {
+		if stop.IsCompleted == 1 {
+			if stop.CompletedAt != nil && (lastCompleted == nil || *stop.CompletedAt >= *lastCompleted.CompletedAt) {
+				lastCompleted = &stops[i]
+			}
+			continue
+		}
+		if stop.StopType == string(models.TaskTypeCollection) && stop.TaskID != nil && stop.SequenceOrder > 0 {
+			remaining = append(remaining, stop)
+		}
+	}
+	result.Stops = len(remaining)
+	if len(remaining) < 2 {
+		result.Skipped = "fewer than 2 collection stops remain"
+		return nil
+	}
+
+	binIDs := make([]string, len(remaining))
+	for i, stop := range remaining {
+		binIDs[i] = stop.BinID
+	}
+	risks, err := ZoneRisks(o.db, binIDs)
+	if err != nil {
+		log.Printf("⚠️  [REOPTIMIZE] Failed to load zone risks: %v", err)
+	}
+
+	bins := make([]BinWithPriority, len(remaining))
+	for i, stop := range remaining {
+		bins[i] = BinWithPriority{
+			ID:             *stop.TaskID,
+			Latitude:       stop.Latitude,
+			Longitude:      stop.Longitude,
+			FillPercentage: stop.FillPercentage,
+			CurrentStreet:  stop.CurrentStreet,
+			TimeWindow:     stop.TimeWindow(),
+			RiskWeight:     risks[stop.BinID].Weight,
+		}
+	}
+
+	optimized := NewRouteOptimizer().OptimizeRoute(bins, o.startLocation(tx, shift, lastCompleted))
+	for i, bin := range optimized {
+		if bin.ID == *remaining[i].TaskID {
+			continue
+		}
+		if err := stores.Shifts.SetSequence(bin.ID, remaining[i].SequenceOrder); err != nil {
+			return err
+		}
+		result.ChangedStops++
+	}
+	return nil
+}
+
+// startLocation is the driver's last known location on the shift, else the last completed stop, else the warehouse
+func (o *RouteReoptimizer) startLocation(tx *sqlx.Tx, shift *models.Shift, lastCompleted *models.ShiftBinWithDetails) OptimizerLocation {
+	var location OptimizerLocation
+	err := tx.QueryRow(`
+		SELECT latitude, longitude FROM driver_current_location
+		WHERE driver_id = $1 AND shift_id = $2
+	`, shift.DriverID, shift.ID).Scan(&location.Latitude, &location.Longitude)
+	if err == nil {
+		return location
+	}
+	if lastCompleted != nil {
+		return OptimizerLocation{Latitude: lastCompleted.Latitude, Longitude: lastCompleted.Longitude}
+	}
+	return GetWarehouseLocation()
+}
+
+// queueReoptimizedRoute queues the consolidated route update for the driver and managers
+func queueReoptimizedRoute(tx *sqlx.Tx, stores store.Stores, shiftID string, result *models.RouteReoptimizationResult) error {
+	var shift models.Shift
+	if err := tx.Get(&shift, `SELECT * FROM shifts WHERE id = $1`, shiftID); err != nil {
+		return fmt.Errorf("failed to fetch shift: %w", err)
+	}
+	bins, err := stores.Shifts.Stops(shiftID)
+	if err != nil {
+		return err
+	}
+
+	_, err = helpers.EnqueueUserMessage(tx, shift.DriverID, map[string]interface{}{
+		"type": "shift_update",
+		"data": map[string]interface{}{
+			"id":                  shift.ID,
+			"driver_id":           shift.DriverID,
+			"route_id":            shift.RouteID,
+			"status":              shift.Status,
+			"start_time":          shift.StartTime,
+			"end_time":            shift.EndTime,
+			"total_pause_seconds": shift.TotalPauseSeconds,
+			"pause_start_time":    shift.PauseStartTime,
+			"total_bins":          shift.TotalBins,
+			"completed_bins":      shift.CompletedBins,
+			"bins":                bins,
+			"created_at":          shift.CreatedAt,
+			"updated_at":          shift.UpdatedAt,
+			"reoptimized":         true,
+			"reoptimize_reasons":  result.Reasons,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	message := map[string]interface{}{
+		"type": "route_reoptimized",
+		"data": map[string]interface{}{
+			"shift_id":      shift.ID,
+			"driver_id":     shift.DriverID,
+			"reasons":       result.Reasons,
+			"changed_stops": result.ChangedStops,
+			"bins":          bins,
+		},
+	}
+	for _, role := range []string{"admin", "manager"} {
+		if _, err := helpers.EnqueueRoleMessage(tx, role, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,448 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/websocket"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Route simulation limits
+const (
+	simulationMaxRunning      = 5
+	simulationKeptEnded       = 20 // Ended runs listed by GET /api/manager/simulations
+	simulatedDriverIDPrefix   = "sim-"
+	simulatedLocationAccuracy = 5.0
+)
+
+// Route simulator errors the handlers map to responses
+var (
+	ErrSimulationDisabled      = errors.New("simulation mode is disabled on this server (set SIMULATION_ENABLED=true)")
+	ErrSimulationLimit         = fmt.Errorf("at most %d simulations can run at once", simulationMaxRunning)
+	ErrSimulationNotFound      = errors.New("simulation not found")
+	ErrSimulationEnded         = errors.New("simulation has already ended")
+	ErrSimulationRouteNotFound = errors.New("route not found")
+	ErrSimulationRouteTooShort = errors.New("route needs at least 2 bins with coordinates to simulate")
+)
+
+// RouteSimulator drives virtual drivers along a route for training and demos
+// Each run moves its drivers stop to stop in straight lines at the configured speed, pausing at every stop,
+// and broadcasts the same driver_location_update and driver_shift_change events real drivers produce,
+// tagged "simulated": true. Nothing is written to the database and the hub's location listeners (arrival
+// detection, zone entries) aren't fed, so simulations never show up in analytics
+type RouteSimulator struct {
+	db      *sqlx.DB
+	hub     *websocket.Hub
+	enabled bool
+
+	mu    sync.Mutex
+	runs  map[string]*simulation
+	order []string // Run IDs, oldest first
+}
+
+// simulation is a run and the state only its goroutine uses
+type simulation struct {
+	run     models.SimulationRun // Guarded by RouteSimulator.mu
+	drivers []simulatedDriverState
+	stop    chan struct{}
+}
+
+// simulatedDriverState tracks where a driver is in its stop cycle
+type simulatedDriverState struct {
+	atStop bool
+	dwell  float64 // Seconds left at the current stop
+}
+
+// simulatedCompletion is a stop a driver finished during a step
+type simulatedCompletion struct {
+	driver models.SimulatedDriver
+	stop   models.SimulationStop
+}
+
+// NewRouteSimulator creates a route simulator; disabled simulators refuse to start runs
+func NewRouteSimulator(db *sqlx.DB, hub *websocket.Hub, enabled bool) *RouteSimulator {
+	return &RouteSimulator{db: db, hub: hub, enabled: enabled, runs: map[string]*simulation{}}
+}
+
+// Enabled reports whether runs can be started
+func (s *RouteSimulator) Enabled() bool {
+	return s.enabled
+}
+
+// Start begins a run on a route; req must already be normalized (see models.StartSimulationRequest.Normalize)
+func (s *RouteSimulator) Start(req models.StartSimulationRequest, startedBy string) (models.SimulationRun, error) {
+	if !s.enabled {
+		return models.SimulationRun{}, ErrSimulationDisabled
+	}
+
+	var routeName string
+	err := s.db.Get(&routeName, `SELECT name FROM routes WHERE id = $1`, req.RouteID)
+	if err == sql.ErrNoRows {
+		return models.SimulationRun{}, ErrSimulationRouteNotFound
+	}
+	if err != nil {
+		return models.SimulationRun{}, fmt.Errorf("failed to load route %s: %w", req.RouteID, err)
+	}
+
+	stops := []models.SimulationStop{}
+	err = s.db.Select(&stops, `
+		SELECT b.id AS bin_id, b.bin_number, b.latitude, b.longitude
+		FROM route_bins rb
+		JOIN bins b ON b.id = rb.bin_id
+		WHERE rb.route_id = $1 AND b.latitude IS NOT NULL AND b.longitude IS NOT NULL
+		ORDER BY rb.sequence_order`, req.RouteID)
+	if err != nil {
+		return models.SimulationRun{}, fmt.Errorf("failed to load bins of route %s: %w", req.RouteID, err)
+	}
+	if len(stops) < 2 {
+		return models.SimulationRun{}, ErrSimulationRouteTooShort
+	}
+
+	now := time.Now().Unix()
+	sim := &simulation{
+		run: models.SimulationRun{
+			ID:            uuid.New().String(),
+			RouteID:       req.RouteID,
+			RouteName:     routeName,
+			Status:        models.SimulationRunning,
+			SpeedKmh:      req.SpeedKmh,
+			StopSeconds:   *req.StopSeconds,
+			UpdateSeconds: req.UpdateSeconds,
+			Loop:          req.Loop,
+			StartedBy:     startedBy,
+			StartedAt:     now,
+			Drivers:       make([]models.SimulatedDriver, req.Drivers),
+			Stops:         stops,
+		},
+		drivers: make([]simulatedDriverState, req.Drivers),
+		stop:    make(chan struct{}),
+	}
+	// Spread the drivers out along the route; each starts at its first stop
+	for i := range sim.run.Drivers {
+		first := i * len(stops) / req.Drivers
+		sim.run.Drivers[i] = models.SimulatedDriver{
+			ID:        simulatedDriverIDPrefix + uuid.New().String(),
+			Name:      fmt.Sprintf("Demo Driver %d", i+1),
+			ShiftID:   simulatedDriverIDPrefix + uuid.New().String(),
+			Latitude:  stops[first].Latitude,
+			Longitude: stops[first].Longitude,
+			NextStop:  first,
+		}
+		sim.drivers[i] = simulatedDriverState{atStop: true, dwell: float64(*req.StopSeconds)}
+	}
+
+	s.mu.Lock()
+	running := 0
+	for _, existing := range s.runs {
+		if existing.run.Status == models.SimulationRunning {
+			running++
+		}
+	}
+	if running >= simulationMaxRunning {
+		s.mu.Unlock()
+		return models.SimulationRun{}, ErrSimulationLimit
+	}
+	s.runs[sim.run.ID] = sim
+	s.order = append(s.order, sim.run.ID)
+	snapshot := sim.snapshot()
+	s.mu.Unlock()
+
+	for _, driver := range snapshot.Drivers {
+		s.broadcastShiftChange(snapshot, driver, "active", nil)
+		s.broadcastLocation(snapshot, driver, false)
+	}
+	log.Printf("🎬 [SIMULATION] %s started %s on route %s (%d driver(s), %.0f km/h)",
+		startedBy, snapshot.ID, routeName, len(snapshot.Drivers), snapshot.SpeedKmh)
+
+	go s.drive(sim)
+	return snapshot, nil
+}
+
+// Stop ends a running simulation; its drivers go off shift
+func (s *RouteSimulator) Stop(id string) (models.SimulationRun, error) {
+	s.mu.Lock()
+	sim, ok := s.runs[id]
+	if !ok {
+		s.mu.Unlock()
+		return models.SimulationRun{}, ErrSimulationNotFound
+	}
+	if sim.run.Status != models.SimulationRunning {
+		s.mu.Unlock()
+		return models.SimulationRun{}, ErrSimulationEnded
+	}
+	close(sim.stop)
+	snapshot := s.endLocked(sim, models.SimulationStopped)
+	s.mu.Unlock()
+
+	s.broadcastEnded(snapshot)
+	log.Printf("⏹️  [SIMULATION] %s stopped", id)
+	return snapshot, nil
+}
+
+// Get returns a run by ID
+func (s *RouteSimulator) Get(id string) (models.SimulationRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sim, ok := s.runs[id]
+	if !ok {
+		return models.SimulationRun{}, ErrSimulationNotFound
+	}
+	return sim.snapshot(), nil
+}
+
+// List returns running and recently ended runs, newest first
+func (s *RouteSimulator) List() []models.SimulationRun {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	runs := make([]models.SimulationRun, 0, len(s.order))
+	for i := len(s.order) - 1; i >= 0; i-- {
+		runs = append(runs, s.runs[s.order[i]].snapshot())
+	}
+	return runs
+}
+
+// drive advances a run every update interval until it finishes or is stopped
+func (s *RouteSimulator) drive(sim *simulation) {
+	ticker := time.NewTicker(time.Duration(sim.run.UpdateSeconds) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-sim.stop:
+			return
+		case <-ticker.C:
+			if done := s.step(sim); done {
+				return
+			}
+		}
+	}
+}
+
+// step moves every driver forward by one update interval and broadcasts the result
+// Returns true once the run has ended
+func (s *RouteSimulator) step(sim *simulation) bool {
+	s.mu.Lock()
+	if sim.run.Status != models.SimulationRunning {
+		s.mu.Unlock()
+		return true
+	}
+
+	var completions []simulatedCompletion
+	var finished []models.SimulatedDriver
+	moving := make([]bool, len(sim.run.Drivers))
+	for i := range sim.run.Drivers {
+		driver := &sim.run.Drivers[i]
+		if driver.Finished {
+			continue
+		}
+		moving[i], completions = sim.advance(i, completions)
+		if driver.Finished {
+			finished = append(finished, *driver)
+		}
+	}
+	snapshot := sim.snapshot()
+	done := len(finished) > 0 && sim.allFinished()
+	if done {
+		snapshot = s.endLocked(sim, models.SimulationFinished)
+		log.Printf("🏁 [SIMULATION] %s finished", snapshot.ID)
+	}
+	s.mu.Unlock()
+
+	for i, driver := range snapshot.Drivers {
+		if !driver.Finished || moving[i] {
+			s.broadcastLocation(snapshot, driver, moving[i])
+		}
+	}
+	for _, completion := range completions {
+		s.broadcastShiftChange(snapshot, completion.driver, "active", &completion.stop)
+	}
+	for _, driver := range finished {
+		s.broadcastShiftChange(snapshot, driver, "ended", nil)
+	}
+	return done
+}
+
+// advance moves driver i by one update interval: driving toward its next stop, then waiting there
+// for stop_seconds before completing it; returns whether the driver moved
+func (sim *simulation) advance(i int, completions []simulatedCompletion) (bool, []simulatedCompletion) {
+	driver := &sim.run.Drivers[i]
+	state := &sim.drivers[i]
+	stops := sim.run.Stops
+	budget := float64(sim.run.UpdateSeconds)
+	moved := false
+
+	// Bounded so a loop over stops at the same spot with no stop time can't spin forever
+	for steps := 0; budget > 0 && !driver.Finished && steps < 2*len(stops); steps++ {
+		if state.atStop {
+			spent := math.Min(state.dwell, budget)
+			state.dwell -= spent
+			budget -= spent
+			if state.dwell > 0 {
+				break
+			}
+			state.atStop = false
+			driver.CompletedStops++
+			completions = append(completions, simulatedCompletion{driver: *driver, stop: stops[driver.NextStop]})
+			driver.NextStop = (driver.NextStop + 1) % len(stops)
+			if !sim.run.Loop && driver.CompletedStops >= len(stops) {
+				driver.Finished = true
+			}
+			continue
+		}
+
+		target := stops[driver.NextStop]
+		remainingKm := haversineDistance(driver.Latitude, driver.Longitude, target.Latitude, target.Longitude)
+		if remainingKm > 0 {
+			driver.Heading = simulationBearing(driver.Latitude, driver.Longitude, target.Latitude, target.Longitude)
+			moved = true
+		}
+		reachKm := sim.run.SpeedKmh * budget / 3600
+		if reachKm >= remainingKm {
+			driver.Latitude, driver.Longitude = target.Latitude, target.Longitude
+			budget -= remainingKm / sim.run.SpeedKmh * 3600
+			state.atStop = true
+			state.dwell = float64(sim.run.StopSeconds)
+			continue
+		}
+		fraction := reachKm / remainingKm
+		driver.Latitude += (target.Latitude - driver.Latitude) * fraction
+		driver.Longitude += (target.Longitude - driver.Longitude) * fraction
+		budget = 0
+	}
+	return moved, completions
+}
+
+// allFinished reports whether every driver completed the route
+func (sim *simulation) allFinished() bool {
+	for _, driver := range sim.run.Drivers {
+		if !driver.Finished {
+			return false
+		}
+	}
+	return true
+}
+
+// snapshot copies the run for use outside the lock
+func (sim *simulation) snapshot() models.SimulationRun {
+	run := sim.run
+	run.Drivers = append([]models.SimulatedDriver(nil), sim.run.Drivers...)
+	return run
+}
+
+// endLocked marks a run ended and drops the oldest ended runs beyond simulationKeptEnded
+// Must be called with s.mu held
+func (s *RouteSimulator) endLocked(sim *simulation, status string) models.SimulationRun {
+	now := time.Now().Unix()
+	sim.run.Status = status
+	sim.run.EndedAt = &now
+
+	var ended []string
+	for _, id := range s.order {
+		if s.runs[id].run.Status != models.SimulationRunning {
+			ended = append(ended, id)
+		}
+	}
+	if excess := len(ended) - simulationKeptEnded; excess > 0 {
+		drop := map[string]bool{}
+		for _, id := range ended[:excess] {
+			drop[id] = true
+			delete(s.runs, id)
+		}
+		kept := s.order[:0]
+		for _, id := range s.order {
+			if !drop[id] {
+				kept = append(kept, id)
+			}
+		}
+		s.order = kept
+	}
+	return sim.snapshot()
+}
+
+// broadcastEnded takes the drivers of a stopped run off shift
+func (s *RouteSimulator) broadcastEnded(run models.SimulationRun) {
+	for _, driver := range run.Drivers {
+		if !driver.Finished {
+			s.broadcastShiftChange(run, driver, "ended", nil)
+		}
+	}
+}
+
+// broadcastLocation sends a driver_location_update like UpdateLocation's, tagged as simulated
+func (s *RouteSimulator) broadcastLocation(run models.SimulationRun, driver models.SimulatedDriver, moving bool) {
+	speed := 0.0
+	if moving {
+		speed = run.SpeedKmh / 3.6 // m/s, as the apps report it
+	}
+	heading := driver.Heading
+	accuracy := simulatedLocationAccuracy
+	now := time.Now()
+	latitude, longitude := driver.Latitude, driver.Longitude
+
+	s.hub.BroadcastToRoleScoped("admin", websocket.EventScope{
+		Type:      "driver_location_update",
+		DriverID:  driver.ID,
+		Latitude:  &latitude,
+		Longitude: &longitude,
+	}, map[string]interface{}{
+		"type": "driver_location_update",
+		"data": map[string]interface{}{
+			"driver_id":     driver.ID,
+			"driver_name":   driver.Name,
+			"latitude":      latitude,
+			"longitude":     longitude,
+			"heading":       &heading,
+			"speed":         &speed,
+			"accuracy":      &accuracy,
+			"shift_id":      driver.ShiftID,
+			"timestamp":     now.UnixMilli(),
+			"created_at":    now.Unix(),
+			"simulated":     true,
+			"simulation_id": run.ID,
+		},
+	})
+}
+
+// broadcastShiftChange sends a driver_shift_change to managers, tagged as simulated
+// stop is set when the change is a completed stop
+func (s *RouteSimulator) broadcastShiftChange(run models.SimulationRun, driver models.SimulatedDriver, status string, stop *models.SimulationStop) {
+	data := map[string]interface{}{
+		"driver_id":      driver.ID,
+		"driver_name":    driver.Name,
+		"status":         status,
+		"shift_id":       driver.ShiftID,
+		"route_id":       run.RouteID,
+		"completed_bins": driver.CompletedStops,
+		"total_bins":     len(run.Stops),
+		"simulated":      true,
+		"simulation_id":  run.ID,
+	}
+	if stop != nil {
+		data["bin_id"] = stop.BinID
+		data["bin_number"] = stop.BinNumber
+	}
+	payload := map[string]interface{}{
+		"type": "driver_shift_change",
+		"data": data,
+	}
+	s.hub.BroadcastToRole("admin", payload)
+	s.hub.BroadcastToRole("manager", payload)
+}
+
+// simulationBearing is the compass heading in degrees from one point to another
+func simulationBearing(lat1, lon1, lat2, lon2 float64) float64 {
+	lat1Rad, lat2Rad := lat1*math.Pi/180, lat2*math.Pi/180
+	deltaLon := (lon2 - lon1) * math.Pi / 180
+	y := math.Sin(deltaLon) * math.Cos(lat2Rad)
+	x := math.Cos(lat1Rad)*math.Sin(lat2Rad) - math.Sin(lat1Rad)*math.Cos(lat2Rad)*math.Cos(deltaLon)
+	return math.Mod(math.Atan2(y, x)*180/math.Pi+360, 360)
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"log"
+	"math"
+	"sort"
+)
+
+// RouteSplitLimits caps each vehicle's share when a bin set is split across drivers (0 is not enforced)
+type RouteSplitLimits struct {
+	MaxBins    int
+	MaxMinutes float64 // Estimated minutes out from the start and back, including service time at each stop
+}
+
+// SplitRoute divides bins into at most vehicles routes that each respect the limits (capacitated VRP)
+// It uses the sweep heuristic: bins are ordered by bearing from the start, beginning after the widest empty
+// sector, and each route takes consecutive bins up to an even share of what's left or its limits, so every
+// driver works one compact sector. Each route is then ordered with OptimizeRoute
+// Bins that fit in no route (vehicles ran out, or a single stop is already over the time limit) are returned
+// as unassigned, in sweep order
+func (ro *RouteOptimizer) SplitRoute(
+	bins []BinWithPriority,
+	startLocation OptimizerLocation,
+	vehicles int,
+	limits RouteSplitLimits,
+) ([][]BinWithPriority, []BinWithPriority) {
+	routes := [][]BinWithPriority{}
+	unassigned := []BinWithPriority{}
+	if len(bins) == 0 || vehicles <= 0 {
+		return routes, append(unassigned, bins...)
+	}
+
+	log.Printf("🗺️  Splitting %d bins across up to %d vehicles (max %d bins, %.0f min each)",
+		len(bins), vehicles, limits.MaxBins, limits.MaxMinutes)
+
+	swept := sweepOrder(bins, startLocation)
+	current := []BinWithPriority{}
+	target := evenShare(len(swept), vehicles)
+	for i, bin := range swept {
+		candidate := append(append([]BinWithPriority{}, current...), bin)
+		fits := len(candidate) <= target &&
+			(limits.MaxBins <= 0 || len(candidate) <= limits.MaxBins) &&
+			(limits.MaxMinutes <= 0 || EstimateRouteMinutes(nearestNeighborOrder(candidate, startLocation), startLocation) <= limits.MaxMinutes)
+		if fits {
+			current = candidate
+			continue
+		}
+		if len(current) == 0 {
+			// Too far to serve even on its own
+			unassigned = append(unassigned, bin)
+			continue
+		}
+
+		routes = append(routes, current)
+		current = []BinWithPriority{}
+		if len(routes) == vehicles {
+			unassigned = append(unassigned, swept[i:]...)
+			break
+		}
+		target = evenShare(len(swept)-i, vehicles-len(routes))
+		if limits.MaxMinutes > 0 && EstimateRouteMinutes([]BinWithPriority{bin}, startLocation) > limits.MaxMinutes {
+			unassigned = append(unassigned, bin)
+			continue
+		}
+		current = append(current, bin)
+	}
+	if len(current) > 0 {
+		routes = append(routes, current)
+	}
+
+	for i, route := range routes {
+		routes[i] = ro.OptimizeRoute(route, startLocation)
+	}
+
+	log.Printf("✅ Split into %d routes (%d bins unassigned)", len(routes), len(unassigned))
+	return routes, unassigned
+}
+
+// EstimateRouteMinutes estimates the time to drive an ordered route from the start and back to it, plus the
+// service time at each stop
+func EstimateRouteMinutes(route []BinWithPriority, startLocation OptimizerLocation) float64 {
+	return RouteDistanceKm(route, startLocation)/optimizerAverageSpeedKmh*60 + float64(len(route))*optimizerServiceMinutes
+}
+
+// RouteDistanceKm is the straight-line length of an ordered route from the start and back to it
+func RouteDistanceKm(route []BinWithPriority, startLocation OptimizerLocation) float64 {
+	if len(route) == 0 {
+		return 0
+	}
+	total := 0.0
+	current := startLocation
+	for _, bin := range route {
+		total += haversineDistance(current.Latitude, current.Longitude, bin.Latitude, bin.Longitude)
+		current = OptimizerLocation{Latitude: bin.Latitude, Longitude: bin.Longitude}
+	}
+	return total + haversineDistance(current.Latitude, current.Longitude, startLocation.Latitude, startLocation.Longitude)
+}
+
+// sweepOrder sorts bins by bearing around the start, beginning just after the widest gap between bearings
+// so that no route straddles the emptiest direction
+func sweepOrder(bins []BinWithPriority, startLocation OptimizerLocation) []BinWithPriority {
+	type sweptBin struct {
+		bin     BinWithPriority
+		bearing float64
+		km      float64
+	}
+	swept := make([]sweptBin, len(bins))
+	for i, bin := range bins {
+		swept[i] = sweptBin{
+			bin:     bin,
+			bearing: math.Atan2(bin.Latitude-startLocation.Latitude, (bin.Longitude-startLocation.Longitude)*math.Cos(startLocation.Latitude*math.Pi/180)),
+			km:      haversineDistance(startLocation.Latitude, startLocation.Longitude, bin.Latitude, bin.Longitude),
+		}
+	}
+	sort.SliceStable(swept, func(i, j int) bool {
+		if swept[i].bearing != swept[j].bearing {
+			return swept[i].bearing < swept[j].bearing
+		}
+		return swept[i].km < swept[j].km
+	})
+
+	first := 0
+	widest := -1.0
+	for i := range swept {
+		previous := swept[(i+len(swept)-1)%len(swept)].bearing
+		gap := swept[i].bearing - previous
+		if gap < 0 || i == 0 {
+			gap += 2 * math.Pi
+		}
+		if gap > widest {
+			widest, first = gap, i
+		}
+	}
+
+	ordered := make([]BinWithPriority, 0, len(swept))
+	for i := range swept {
+		ordered = append(ordered, swept[(first+i)%len(swept)].bin)
+	}
+	return ordered
+}
+
+// nearestNeighborOrder orders bins by plain nearest neighbor from the start (without OptimizeRoute's logging,
+// for estimates made many times while splitting)
+func nearestNeighborOrder(bins []BinWithPriority, startLocation OptimizerLocation) []BinWithPriority {
+	remaining := append([]BinWithPriority{}, bins...)
+	ordered := make([]BinWithPriority, 0, len(bins))
+	current := startLocation
+	for len(remaining) > 0 {
+		best, bestDistance := 0, math.MaxFloat64
+		for i, bin := range remaining {
+			if d := haversineDistance(current.Latitude, current.Longitude, bin.Latitude, bin.Longitude); d < bestDistance {
+				best, bestDistance = i, d
+			}
+		}
+		ordered = append(ordered, remaining[best])
+		current = OptimizerLocation{Latitude: remaining[best].Latitude, Longitude: remaining[best].Longitude}
+		remaining = append(remaining[:best], remaining[best+1:]...)
+	}
+	return ordered
+}
+
+// evenShare is how many of n bins each of k vehicles takes when they're shared evenly (rounded up)
+func evenShare(n, k int) int {
+	return (n + k - 1) / k
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ShiftIncidentBackfillResult summarizes the one-time incident counter backfill
+type ShiftIncidentBackfillResult struct {
+	HistoryRowsUpdated int64 `json:"history_rows_updated"` // shift_history rows whose counters were corrected
+	ShiftsUpdated      int64 `json:"shifts_updated"`       // Live shift counters initialized
+	RanAt              int64 `json:"ran_at"`
+}
+
+// BackfillShiftIncidentCounters fills incidents_reported and field_observations on shift_history and
+// on unfinished shifts from their zone_incidents, once: completion is recorded in the settings table
+// (SettingKeyShiftIncidentBackfill) and later calls return nil without touching anything
+// Counters are maintained live after this (incident and field observation reporting increment them)
+func BackfillShiftIncidentCounters(db *sqlx.DB) (*ShiftIncidentBackfillResult, error) {
+	if _, err := database.GetSetting(db, models.SettingKeyShiftIncidentBackfill); err == nil {
+		return nil, nil
+	} else if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to check incident backfill marker: %w", err)
+	}
+
+	now := time.Now().Unix()
+	result := &ShiftIncidentBackfillResult{RanAt: now}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start incident backfill transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		UPDATE shift_history sh
+		SET incidents_reported = counts.total, field_observations = counts.observations
+		FROM (
+			SELECT shift_id, COUNT(*) AS total, COUNT(*) FILTER (WHERE is_field_observation = true) AS observations
+			FROM zone_incidents
+			WHERE shift_id IS NOT NULL
+			GROUP BY shift_id
+		) counts
+		WHERE sh.id = counts.shift_id
+		  AND (sh.incidents_reported IS DISTINCT FROM counts.total OR sh.field_observations IS DISTINCT FROM counts.observations)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to backfill shift history incident counters: %w", err)
+	}
+	result.HistoryRowsUpdated, _ = res.RowsAffected()
+
+	res, err = tx.Exec(`
+		UPDATE shifts s
+		SET incidents_reported = counts.total, field_observations = counts.observations
+		FROM (
+			SELECT shift_id, COUNT(*) AS total, COUNT(*) FILTER (WHERE is_field_observation = true) AS observations
+			FROM zone_incidents
+			WHERE shift_id IS NOT NULL
+			GROUP BY shift_id
+		) counts
+		WHERE s.id = counts.shift_id
+		  AND (s.incidents_reported <> counts.total OR s.field_observations <> counts.observations)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to backfill live shift incident counters: %w", err)
+	}
+	result.ShiftsUpdated, _ = res.RowsAffected()
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit incident backfill: %w", err)
+	}
+
+	if err := database.UpsertSetting(db, models.SettingKeyShiftIncidentBackfill, result, nil, now); err != nil {
+		return result, err
+	}
+	log.Printf("✅ [INCIDENT-BACKFILL] Corrected %d shift history rows and %d shifts", result.HistoryRowsUpdated, result.ShiftsUpdated)
+	return result, nil
+}
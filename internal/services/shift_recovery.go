@@ -0,0 +1,188 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// ShiftRecoveryResult summarizes the startup reconciliation
+type ShiftRecoveryResult struct {
+	DriversDisconnected  int64 `json:"drivers_disconnected"`   // Still marked connected from before the restart
+	InFlightShifts       int   `json:"in_flight_shifts"`       // Active or paused shifts checked
+	PausesReopened       int   `json:"pauses_reopened"`        // Paused shifts missing their open pause period
+	PausesClosed         int   `json:"pauses_closed"`          // Running shifts with a pause period left open
+	PauseTotalsCorrected int   `json:"pause_totals_corrected"` // total_pause_seconds raised to match the pause periods
+	OutboxEventsResumed  int64 `json:"outbox_events_resumed"`  // Pending notifications made due immediately
+	RanAt                int64 `json:"ran_at"`
+}
+
+type recoveryShift struct {
+	ID                string `db:"id"`
+	Status            string `db:"status"`
+	PauseStartTime    *int64 `db:"pause_start_time"`
+	TotalPauseSeconds int    `db:"total_pause_seconds"`
+	UpdatedAt         int64  `db:"updated_at"`
+}
+
+type recoveryPause struct {
+	ID        string `db:"id"`
+	ShiftID   string `db:"shift_id"`
+	PausedAt  int64  `db:"paused_at"`
+	ResumedAt *int64 `db:"resumed_at"`
+}
+
+// RecoverAfterRestart reconciles state that was in flight when the server stopped; run it once at startup
+// before the WebSocket hub and dispatchers start:
+//   - drivers still marked connected are marked disconnected (they reconnect to a fresh hub)
+//   - pause bookkeeping of active and paused shifts is repaired and total_pause_seconds recomputed
+//     from the recorded pause periods (wall clock), so pause timers don't drift across restarts
+//   - pending notifications waiting out a retry backoff are made due so the dispatcher delivers them now
+func RecoverAfterRestart(db *sqlx.DB) (*ShiftRecoveryResult, error) {
+	now := time.Now().Unix()
+	result := &ShiftRecoveryResult{RanAt: now}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start recovery transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`UPDATE driver_current_location SET is_connected = FALSE, updated_at = $1 WHERE is_connected = TRUE`, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reset driver connections: %w", err)
+	}
+	result.DriversDisconnected, _ = res.RowsAffected()
+
+	var shifts []recoveryShift
+	err = tx.Select(&shifts, `
+		SELECT id, status, pause_start_time, total_pause_seconds, updated_at
+		FROM shifts
+		WHERE status IN ('active', 'paused')
+		FOR UPDATE
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load in-flight shifts: %w", err)
+	}
+	result.InFlightShifts = len(shifts)
+
+	if len(shifts) > 0 {
+		shiftIDs := make([]string, len(shifts))
+		for i, shift := range shifts {
+			shiftIDs[i] = shift.ID
+		}
+		var pauses []recoveryPause
+		err = tx.Select(&pauses, `
+			SELECT id, shift_id, paused_at, resumed_at
+			FROM shift_pauses
+			WHERE shift_id = ANY($1)
+			ORDER BY paused_at ASC
+		`, pq.Array(shiftIDs))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load shift pauses: %w", err)
+		}
+		pausesByShift := make(map[string][]recoveryPause)
+		for _, pause := range pauses {
+			pausesByShift[pause.ShiftID] = append(pausesByShift[pause.ShiftID], pause)
+		}
+
+		for _, shift := range shifts {
+			if err := recoverShiftPauses(tx, shift, pausesByShift[shift.ID], now, result); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	res, err = tx.Exec(`
+		UPDATE notification_outbox SET next_attempt_at = $1, updated_at = $1
+		WHERE status = 'pending' AND next_attempt_at > $1
+	`, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resume notification outbox: %w", err)
+	}
+	result.OutboxEventsResumed, _ = res.RowsAffected()
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit recovery: %w", err)
+	}
+
+	log.Printf("♻️  [RECOVERY] %d driver(s) marked disconnected, %d in-flight shift(s) checked (%d pause(s) reopened, %d closed, %d total(s) corrected), %d notification(s) resumed",
+		result.DriversDisconnected, result.InFlightShifts, result.PausesReopened, result.PausesClosed,
+		result.PauseTotalsCorrected, result.OutboxEventsResumed)
+	return result, nil
+}
+
+// recoverShiftPauses makes one shift's pause state consistent with its pause periods
+func recoverShiftPauses(tx *sqlx.Tx, shift recoveryShift, pauses []recoveryPause, now int64, result *ShiftRecoveryResult) error {
+	var open *recoveryPause
+	closedSeconds := 0
+	for i := range pauses {
+		if pauses[i].ResumedAt == nil {
+			open = &pauses[i] // The latest open period (ordered by paused_at)
+		} else {
+			closedSeconds += int(*pauses[i].ResumedAt - pauses[i].PausedAt)
+		}
+	}
+
+	pauseStart := shift.PauseStartTime
+	if shift.Status == "paused" {
+		// A pause start in the future (clock moved back) would make the running pause negative
+		if pauseStart != nil && *pauseStart > now {
+			pauseStart = &now
+		}
+		if pauseStart == nil {
+			start := shift.UpdatedAt
+			if open != nil {
+				start = open.PausedAt
+			}
+			pauseStart = &start
+		}
+		if open == nil {
+			_, err := tx.Exec(`INSERT INTO shift_pauses (id, shift_id, paused_at) VALUES ($1, $2, $3)`,
+				uuid.New().String(), shift.ID, *pauseStart)
+			if err != nil {
+				return fmt.Errorf("failed to reopen pause for shift %s: %w", shift.ID, err)
+			}
+			result.PausesReopened++
+		}
+	} else {
+		pauseStart = nil
+		if open != nil {
+			// The shift was resumed but the period never closed; total_pause_seconds already includes it
+			duration := shift.TotalPauseSeconds - closedSeconds
+			if duration < 0 {
+				duration = 0
+			}
+			resumedAt := open.PausedAt + int64(duration)
+			if _, err := tx.Exec(`UPDATE shift_pauses SET resumed_at = $1 WHERE id = $2`, resumedAt, open.ID); err != nil {
+				return fmt.Errorf("failed to close pause for shift %s: %w", shift.ID, err)
+			}
+			closedSeconds += duration
+			result.PausesClosed++
+		}
+	}
+
+	// The periods are a lower bound: pause periods that failed to record are only in the total
+	totalPause := shift.TotalPauseSeconds
+	if closedSeconds > totalPause {
+		log.Printf("♻️  [RECOVERY] Shift %s pause total %ds -> %ds (from pause periods)", shift.ID, totalPause, closedSeconds)
+		totalPause = closedSeconds
+		result.PauseTotalsCorrected++
+	}
+
+	pauseStartChanged := (pauseStart == nil) != (shift.PauseStartTime == nil) ||
+		(pauseStart != nil && *pauseStart != *shift.PauseStartTime)
+	if totalPause == shift.TotalPauseSeconds && !pauseStartChanged {
+		return nil
+	}
+	_, err := tx.Exec(`UPDATE shifts SET total_pause_seconds = $1, pause_start_time = $2, updated_at = $3 WHERE id = $4`,
+		totalPause, pauseStart, now, shift.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update pause state of shift %s: %w", shift.ID, err)
+	}
+	return nil
+}
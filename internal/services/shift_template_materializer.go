@@ -0,0 +1,293 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/store"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// ShiftTemplateMaterializer instantiates tomorrow's ready shifts from active shift templates
+// Each template produces at most one shift per service date; conflicts are recorded as skipped
+// runs and retried on the next run until the shift is created
+type ShiftTemplateMaterializer struct {
+	db *sqlx.DB
+}
+
+// ShiftTemplateMaterializeResult summarizes a single materializer run
+type ShiftTemplateMaterializeResult struct {
+	Created   int                       `json:"created"`
+	Skipped   int                       `json:"skipped"`
+	Conflicts []models.ShiftTemplateRun `json:"conflicts"`
+	RanAt     int64                     `json:"ran_at"`
+}
+
+// NewShiftTemplateMaterializer creates a new shift template materializer
+func NewShiftTemplateMaterializer(db *sqlx.DB) *ShiftTemplateMaterializer {
+	return &ShiftTemplateMaterializer{db: db}
+}
+
+// Start runs the materializer immediately and then on every interval until the process exits
+func (m *ShiftTemplateMaterializer) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := m.Run(time.Now()); err != nil {
+				log.Printf("❌ [SHIFT-TEMPLATES] Run failed: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// Run materializes the shifts due the day after now (in each template's timezone)
+func (m *ShiftTemplateMaterializer) Run(now time.Time) (*ShiftTemplateMaterializeResult, error) {
+	result := &ShiftTemplateMaterializeResult{
+		Conflicts: []models.ShiftTemplateRun{},
+		RanAt:     now.Unix(),
+	}
+
+	var templates []models.ShiftTemplate
+	if err := m.db.Select(&templates, `SELECT * FROM shift_templates WHERE is_active = TRUE ORDER BY created_at ASC`); err != nil {
+		return nil, fmt.Errorf("failed to load shift templates: %w", err)
+	}
+
+	for _, template := range templates {
+		loc, err := time.LoadLocation(template.Timezone)
+		if err != nil {
+			log.Printf("⚠️  [SHIFT-TEMPLATES] Template %s has invalid timezone %q, using UTC", template.ID, template.Timezone)
+			loc = time.UTC
+		}
+		serviceDate := now.In(loc).AddDate(0, 0, 1)
+		if !template.RunsOn(serviceDate.Weekday()) {
+			continue
+		}
+
+		run, err := m.materialize(template, serviceDate, now.Unix())
+		if err != nil {
+			log.Printf("❌ [SHIFT-TEMPLATES] Template %s (%s): %v", template.ID, template.Name, err)
+			continue
+		}
+		if run == nil {
+			continue // Already created for this date
+		}
+
+		switch run.Status {
+		case models.ShiftTemplateRunCreated:
+			result.Created++
+			log.Printf("✅ [SHIFT-TEMPLATES] Created shift %s from template %q for %s", *run.ShiftID, template.Name, run.ServiceDate)
+		case models.ShiftTemplateRunSkipped:
+			result.Skipped++
+			result.Conflicts = append(result.Conflicts, *run)
+			log.Printf("⚠️  [SHIFT-TEMPLATES] Skipped template %q for %s: %s", template.Name, run.ServiceDate, *run.Reason)
+		}
+	}
+
+	log.Printf("✅ [SHIFT-TEMPLATES] Run complete: %d created, %d skipped", result.Created, result.Skipped)
+	return result, nil
+}
+
+// materialize creates the template's shift for one service date, or records why it could not
+// Returns nil when the shift was already created by an earlier run
+func (m *ShiftTemplateMaterializer) materialize(template models.ShiftTemplate, serviceDate time.Time, now int64) (*models.ShiftTemplateRun, error) {
+	run := &models.ShiftTemplateRun{
+		ID:           uuid.New().String(),
+		TemplateID:   template.ID,
+		TemplateName: &template.Name,
+		ServiceDate:  serviceDate.Format("2006-01-02"),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	tx, err := m.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the template so a manual run and the scheduled run cannot both create the shift
+	var lockedID string
+	if err := tx.Get(&lockedID, `SELECT id FROM shift_templates WHERE id = $1 FOR UPDATE`, template.ID); err != nil {
+		return nil, fmt.Errorf("failed to lock template: %w", err)
+	}
+
+	var existingStatus string
+	err = tx.Get(&existingStatus, `
+		SELECT status FROM shift_template_runs
+		WHERE template_id = $1 AND service_date = $2`, template.ID, run.ServiceDate)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to check previous runs: %w", err)
+	}
+	if existingStatus == models.ShiftTemplateRunCreated {
+		return nil, nil
+	}
+
+	reason, err := m.conflict(tx, template)
+	if err != nil {
+		return nil, err
+	}
+
+	if reason != "" {
+		run.Status = models.ShiftTemplateRunSkipped
+		run.Reason = &reason
+	} else {
+		shiftID, err := m.createShift(tx, template, serviceDate, now)
+		if err != nil {
+			return nil, err
+		}
+		run.Status = models.ShiftTemplateRunCreated
+		run.ShiftID = &shiftID
+	}
+
+	_, err = tx.NamedExec(`
+		INSERT INTO shift_template_runs (id, template_id, service_date, status, shift_id, reason, created_at, updated_at)
+		VALUES (:id, :template_id, :service_date, :status, :shift_id, :reason, :created_at, :updated_at)
+		ON CONFLICT (template_id, service_date) DO UPDATE
+		SET status = EXCLUDED.status,
+		    shift_id = EXCLUDED.shift_id,
+		    reason = EXCLUDED.reason,
+		    updated_at = EXCLUDED.updated_at`, run)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record run: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit: %w", err)
+	}
+	return run, nil
+}
+
+// conflict returns why the template cannot be instantiated right now ("" when it can)
+func (m *ShiftTemplateMaterializer) conflict(tx *sqlx.Tx, template models.ShiftTemplate) (string, error) {
+	if template.DriverID == nil {
+		return "template has no preferred driver", nil
+	}
+
+	var driver struct {
+		Role          string `db:"role"`
+		DeactivatedAt *int64 `db:"deactivated_at"`
+	}
+	err := tx.Get(&driver, `SELECT role, deactivated_at FROM users WHERE id = $1`, *template.DriverID)
+	if err == sql.ErrNoRows {
+		return "preferred driver no longer exists", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to load driver %s: %w", *template.DriverID, err)
+	}
+	if driver.Role != "driver" {
+		return "preferred user is not a driver", nil
+	}
+	if driver.DeactivatedAt != nil {
+		return "preferred driver is deactivated", nil
+	}
+
+	// Drivers work one shift at a time - the next run retries once the open shift ends
+	var openShiftID string
+	err = tx.Get(&openShiftID, `
+		SELECT id FROM shifts
+		WHERE driver_id = $1 AND status IN ('ready', 'active', 'paused')
+		LIMIT 1`, *template.DriverID)
+	if err == nil {
+		return fmt.Sprintf("driver already has open shift %s", openShiftID), nil
+	}
+	if err != sql.ErrNoRows {
+		return "", fmt.Errorf("failed to check open shifts for driver %s: %w", *template.DriverID, err)
+	}
+
+	var binCount int
+	err = tx.Get(&binCount, `
+		SELECT COUNT(*) FROM route_bins rb
+		JOIN bins b ON b.id = rb.bin_id
+		WHERE rb.route_id = $1 AND b.latitude IS NOT NULL AND b.longitude IS NOT NULL`, template.RouteID)
+	if err != nil {
+		return "", fmt.Errorf("failed to count bins on route %s: %w", template.RouteID, err)
+	}
+	if binCount == 0 {
+		return "route has no bins with coordinates", nil
+	}
+
+	return "", nil
+}
+
+// costServiceTimeSeconds is the time at each stop in cost estimates (the same as route previews use)
+const costServiceTimeSeconds = 300
+
+// createShift inserts a ready shift with the route's bins in their blueprint order
+func (m *ShiftTemplateMaterializer) createShift(tx *sqlx.Tx, template models.ShiftTemplate, serviceDate time.Time, now int64) (string, error) {
+	scheduledStart, scheduledEnd, err := template.StartWindow(serviceDate)
+	if err != nil {
+		return "", fmt.Errorf("invalid start window: %w", err)
+	}
+
+	// Bins without coordinates can't be stops; they join the shifts once geocoded
+	var routeBins []models.RouteBin
+	err = tx.Select(&routeBins, `
+		SELECT rb.id, rb.route_id, rb.bin_id, rb.sequence_order, rb.created_at
+		FROM route_bins rb
+		JOIN bins b ON b.id = rb.bin_id
+		WHERE rb.route_id = $1 AND b.latitude IS NOT NULL AND b.longitude IS NOT NULL
+		ORDER BY rb.sequence_order`, template.RouteID)
+	if err != nil {
+		return "", fmt.Errorf("failed to load bins of route %s: %w", template.RouteID, err)
+	}
+
+	routeVersion, err := database.CurrentRouteVersion(tx, template.RouteID, now)
+	if err != nil {
+		return "", err
+	}
+
+	shiftID := uuid.New().String()
+	_, err = tx.Exec(`
+		INSERT INTO shifts (id, driver_id, route_id, route_version, status, total_bins, template_id, scheduled_start, scheduled_end, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, 'ready', $5, $6, $7, $8, $9, $9)`,
+		shiftID, *template.DriverID, template.RouteID, routeVersion, len(routeBins), template.ID, scheduledStart, scheduledEnd, now)
+	if err != nil {
+		return "", fmt.Errorf("failed to create shift: %w", err)
+	}
+
+	shifts := store.NewShiftStore(tx)
+	for _, rb := range routeBins {
+		if err := shifts.InsertCollectionStop(shiftID, rb.BinID, &template.RouteID, rb.SequenceOrder, now); err != nil {
+			return "", err
+		}
+	}
+
+	// Price the shift for cost tracking: straight lines between the stops in blueprint order, driven at the
+	// optimizer's average speed plus time at each stop (the route's estimated duration wins when set)
+	var stops []struct {
+		Latitude  float64 `db:"latitude"`
+		Longitude float64 `db:"longitude"`
+	}
+	err = tx.Select(&stops, `
+		SELECT b.latitude, b.longitude
+		FROM route_bins rb
+		JOIN bins b ON b.id = rb.bin_id
+		WHERE rb.route_id = $1 AND b.latitude IS NOT NULL AND b.longitude IS NOT NULL
+		ORDER BY rb.sequence_order`, template.RouteID)
+	if err != nil {
+		return "", fmt.Errorf("failed to load stops of route %s: %w", template.RouteID, err)
+	}
+	distanceKm := 0.0
+	for i := 1; i < len(stops); i++ {
+		distanceKm += haversineDistance(stops[i-1].Latitude, stops[i-1].Longitude, stops[i].Latitude, stops[i].Longitude)
+	}
+	hours := distanceKm/optimizerAverageSpeedKmh + float64(len(routeBins)*costServiceTimeSeconds)/3600
+	rates, err := database.GetCostRates(m.db)
+	if err != nil {
+		log.Printf("⚠️  [SHIFT-TEMPLATES] %v (using default cost rates)", err)
+	}
+	if _, err := database.RecordShiftCostEstimate(tx, shiftID, template.RouteID, hours, distanceKm, rates); err != nil {
+		return "", err
+	}
+
+	return shiftID, nil
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"ropacal-backend/internal/websocket"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Arrival detection tuning
+const (
+	stopDepartureRadiusFactor = 1.5   // Leaving needs this multiple of the arrival radius, so GPS jitter at the edge doesn't flap
+	stopArrivalMaxAccuracy    = 100.0 // Fixes less accurate than this (meters) are ignored
+)
+
+// StopArrivalDetector records when a driver on an active shift comes within the arrival radius of their next
+// stop (route_tasks.arrived_at) and when they leave it again (departed_at), and tells the driver and the
+// manager dashboards with arrived_at_stop / departed_stop events
+// It listens to every GPS source through websocket.Hub.OnLocation
+type StopArrivalDetector struct {
+	db           *sqlx.DB
+	hub          *websocket.Hub
+	radiusMeters float64
+}
+
+// NewStopArrivalDetector creates a detector that treats fixes within radiusMeters of a stop as an arrival
+func NewStopArrivalDetector(db *sqlx.DB, hub *websocket.Hub, radiusMeters float64) *StopArrivalDetector {
+	return &StopArrivalDetector{db: db, hub: hub, radiusMeters: radiusMeters}
+}
+
+// arrivalStop is a stop considered by the detector
+type arrivalStop struct {
+	ID            string  `db:"id"`
+	ShiftID       string  `db:"shift_id"`
+	SequenceOrder int     `db:"sequence_order"`
+	TaskType      string  `db:"task_type"`
+	BinID         *string `db:"bin_id"`
+	BinNumber     *int    `db:"bin_number"`
+	MoveRequestID *string `db:"move_request_id"`
+	Latitude      float64 `db:"latitude"`
+	Longitude     float64 `db:"longitude"`
+	IsCompleted   int     `db:"is_completed"`
+	ArrivedAt     *int64  `db:"arrived_at"`
+	DepartedAt    *int64  `db:"departed_at"`
+}
+
+// Observe processes a driver's fixes in order (registered with websocket.Hub.OnLocation)
+func (d *StopArrivalDetector) Observe(driverID string, fixes []websocket.LocationFix) {
+	var shiftID string
+	err := d.db.Get(&shiftID, `SELECT id FROM shifts WHERE driver_id = $1 AND status = 'active' LIMIT 1`, driverID)
+	if err == sql.ErrNoRows {
+		return
+	}
+	if err != nil {
+		log.Printf("⚠️  [ARRIVAL] Failed to load active shift of %s: %v", driverID, err)
+		return
+	}
+
+	for _, fix := range fixes {
+		if fix.Accuracy != nil && *fix.Accuracy > stopArrivalMaxAccuracy {
+			continue
+		}
+		if err := d.observe(driverID, shiftID, fix); err != nil {
+			log.Printf("⚠️  [ARRIVAL] Driver %s: %v", driverID, err)
+			return
+		}
+	}
+}
+
+// observe closes open stops the driver has left, then opens the next stop if the driver is at it
+func (d *StopArrivalDetector) observe(driverID, shiftID string, fix websocket.LocationFix) error {
+	at := fix.Timestamp / 1000
+	if now := time.Now().Unix(); at <= 0 || at > now {
+		at = now
+	}
+
+	const columns = `id, shift_id, sequence_order, task_type, bin_id, bin_number, move_request_id,
+		latitude, longitude, is_completed, arrived_at, departed_at`
+
+	var open []arrivalStop
+	err := d.db.Select(&open, `SELECT `+columns+` FROM route_tasks
+		WHERE shift_id = $1 AND arrived_at IS NOT NULL AND departed_at IS NULL`, shiftID)
+	if err != nil {
+		return fmt.Errorf("failed to load open stops: %w", err)
+	}
+	for _, stop := range open {
+		if d.distanceMeters(stop, fix) <= d.radiusMeters*stopDepartureRadiusFactor {
+			continue
+		}
+		result, err := d.db.Exec(`
+			UPDATE route_tasks SET departed_at = $2
+			WHERE id = $1 AND departed_at IS NULL AND arrived_at <= $2`, stop.ID, at)
+		if err != nil {
+			return fmt.Errorf("failed to record departure from stop %s: %w", stop.ID, err)
+		}
+		if n, _ := result.RowsAffected(); n == 1 {
+			stop.DepartedAt = &at
+			d.emit(driverID, "departed_stop", stop, at)
+		}
+	}
+
+	var next arrivalStop
+	err = d.db.Get(&next, `SELECT `+columns+` FROM route_tasks
+		WHERE shift_id = $1 AND is_completed = 0
+		ORDER BY sequence_order ASC
+		LIMIT 1`, shiftID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load next stop: %w", err)
+	}
+	if d.distanceMeters(next, fix) > d.radiusMeters {
+		return nil
+	}
+
+	if next.ArrivedAt != nil {
+		// Back at a stop they drove away from without completing: the visit continues from the first arrival
+		if next.DepartedAt != nil {
+			if _, err := d.db.Exec(`UPDATE route_tasks SET departed_at = NULL WHERE id = $1 AND is_completed = 0`, next.ID); err != nil {
+				return fmt.Errorf("failed to reopen stop %s: %w", next.ID, err)
+			}
+		}
+		return nil
+	}
+
+	result, err := d.db.Exec(`UPDATE route_tasks SET arrived_at = $2 WHERE id = $1 AND arrived_at IS NULL`, next.ID, at)
+	if err != nil {
+		return fmt.Errorf("failed to record arrival at stop %s: %w", next.ID, err)
+	}
+	if n, _ := result.RowsAffected(); n == 1 {
+		next.ArrivedAt = &at
+		d.emit(driverID, "arrived_at_stop", next, at)
+	}
+	return nil
+}
+
+// emit sends an arrival or departure event to the driver and the manager dashboards
+func (d *StopArrivalDetector) emit(driverID, eventType string, stop arrivalStop, at int64) {
+	data := map[string]interface{}{
+		"driver_id":       driverID,
+		"shift_id":        stop.ShiftID,
+		"task_id":         stop.ID,
+		"sequence_order":  stop.SequenceOrder,
+		"task_type":       stop.TaskType,
+		"bin_id":          stop.BinID,
+		"bin_number":      stop.BinNumber,
+		"move_request_id": stop.MoveRequestID,
+		"is_completed":    stop.IsCompleted == 1,
+		"arrived_at":      stop.ArrivedAt,
+		"departed_at":     stop.DepartedAt,
+		"timestamp":       at,
+	}
+	if stop.ArrivedAt != nil && stop.DepartedAt != nil {
+		data["dwell_seconds"] = *stop.DepartedAt - *stop.ArrivedAt
+	}
+
+	message := map[string]interface{}{"type": eventType, "data": data}
+	d.hub.BroadcastToUser(driverID, message)
+	d.hub.BroadcastToRoleScoped("admin", websocket.EventScope{Type: eventType, DriverID: driverID}, message)
+	log.Printf("📍 [ARRIVAL] %s: driver %s, stop %s (#%d %s)", eventType, driverID, stop.ID, stop.SequenceOrder, stop.TaskType)
+}
+
+func (d *StopArrivalDetector) distanceMeters(stop arrivalStop, fix websocket.LocationFix) float64 {
+	return haversineDistance(stop.Latitude, stop.Longitude, fix.Latitude, fix.Longitude) * 1000
+}
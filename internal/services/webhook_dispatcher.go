@@ -0,0 +1,201 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"ropacal-backend/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Webhook delivery policy
+const (
+	webhookMaxAttempts    = 8                // Deliveries are marked failed after this many attempts
+	webhookBaseBackoff    = 30 * time.Second // Delay before the 2nd attempt; doubles after each failure
+	webhookMaxBackoff     = 6 * time.Hour
+	webhookBatchSize      = 100 // Deliveries sent per run
+	webhookRequestTimeout = 10 * time.Second
+	webhookMaxErrorLength = 500 // Response body / error text kept in last_error
+)
+
+// WebhookDispatcher delivers queued webhook events with HMAC signatures, retrying failures with exponential backoff
+type WebhookDispatcher struct {
+	db     *sqlx.DB
+	client *http.Client
+	mu     sync.Mutex // Serializes runs so a delivery is never sent twice concurrently
+}
+
+// WebhookDispatchResult summarizes a single dispatch run
+type WebhookDispatchResult struct {
+	Attempted int   `json:"attempted"`
+	Succeeded int   `json:"succeeded"`
+	Retrying  int   `json:"retrying"`
+	Failed    int   `json:"failed"` // Gave up (max attempts reached)
+	RanAt     int64 `json:"ran_at"`
+}
+
+// NewWebhookDispatcher creates a new webhook dispatcher
+func NewWebhookDispatcher(db *sqlx.DB) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		db:     db,
+		client: &http.Client{Timeout: webhookRequestTimeout},
+	}
+}
+
+// Start runs the dispatcher immediately and then on every interval until the process exits
+func (d *WebhookDispatcher) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := d.Run(); err != nil {
+				log.Printf("❌ [WEBHOOKS] Dispatch failed: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// SignWebhookPayload returns the X-Ropacal-Signature value for a payload:
+// "sha256=" + hex(HMAC-SHA256(secret, "<timestamp>.<body>"))
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff returns the delay before the next attempt after the given number of failed attempts
+func webhookBackoff(attempts int) time.Duration {
+	backoff := webhookBaseBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= webhookMaxBackoff {
+			return webhookMaxBackoff
+		}
+	}
+	return backoff
+}
+
+type dueWebhookDelivery struct {
+	models.WebhookDelivery
+	URL    string `db:"url"`
+	Secret string `db:"secret"`
+}
+
+// Run sends every pending delivery that is due, oldest first
+// Deliveries for webhooks deactivated since the event was queued are left pending until reactivated
+func (d *WebhookDispatcher) Run() (*WebhookDispatchResult, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	result := &WebhookDispatchResult{RanAt: time.Now().Unix()}
+
+	var deliveries []dueWebhookDelivery
+	err := d.db.Select(&deliveries, `
+		SELECT wd.*, w.url, w.secret
+		FROM webhook_deliveries wd
+		JOIN webhooks w ON w.id = wd.webhook_id
+		WHERE wd.status = $1 AND wd.next_attempt_at <= $2 AND w.is_active = TRUE
+		ORDER BY wd.next_attempt_at ASC
+		LIMIT $3
+	`, models.WebhookDeliveryPending, result.RanAt, webhookBatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load due deliveries: %w", err)
+	}
+
+	for _, delivery := range deliveries {
+		result.Attempted++
+		switch d.deliver(delivery) {
+		case models.WebhookDeliverySucceeded:
+			result.Succeeded++
+		case models.WebhookDeliveryFailed:
+			result.Failed++
+		default:
+			result.Retrying++
+		}
+	}
+
+	if result.Attempted > 0 {
+		log.Printf("📤 [WEBHOOKS] Dispatched %d deliveries: %d succeeded, %d retrying, %d failed",
+			result.Attempted, result.Succeeded, result.Retrying, result.Failed)
+	}
+	return result, nil
+}
+
+// deliver POSTs one delivery and records the outcome, returning the delivery's new status
+func (d *WebhookDispatcher) deliver(delivery dueWebhookDelivery) string {
+	now := time.Now()
+	attempts := delivery.Attempts + 1
+
+	var statusCode *int
+	var deliveryErr error
+
+	req, err := http.NewRequest(http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		deliveryErr = fmt.Errorf("invalid request: %w", err)
+	} else {
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "Ropacal-Webhooks/1.0")
+		req.Header.Set("X-Ropacal-Event", delivery.EventType)
+		req.Header.Set("X-Ropacal-Delivery", delivery.ID)
+		req.Header.Set("X-Ropacal-Timestamp", strconv.FormatInt(now.Unix(), 10))
+		req.Header.Set("X-Ropacal-Signature", SignWebhookPayload(delivery.Secret, now.Unix(), delivery.Payload))
+
+		resp, err := d.client.Do(req)
+		if err != nil {
+			deliveryErr = err
+		} else {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookMaxErrorLength))
+			resp.Body.Close()
+			statusCode = &resp.StatusCode
+			if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+				deliveryErr = fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+			}
+		}
+	}
+
+	status := models.WebhookDeliverySucceeded
+	nextAttemptAt := delivery.NextAttemptAt
+	var lastError *string
+	if deliveryErr != nil {
+		message := deliveryErr.Error()
+		if len(message) > webhookMaxErrorLength {
+			message = message[:webhookMaxErrorLength]
+		}
+		lastError = &message
+
+		if attempts >= webhookMaxAttempts {
+			status = models.WebhookDeliveryFailed
+			log.Printf("❌ [WEBHOOKS] Giving up on delivery %s (%s) after %d attempts: %s", delivery.ID, delivery.EventType, attempts, message)
+		} else {
+			status = models.WebhookDeliveryPending
+			nextAttemptAt = now.Add(webhookBackoff(attempts)).Unix()
+			log.Printf("⚠️  [WEBHOOKS] Delivery %s (%s) attempt %d failed, retrying at %d: %s", delivery.ID, delivery.EventType, attempts, nextAttemptAt, message)
+		}
+	}
+
+	_, err = d.db.Exec(`
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, next_attempt_at = $3, last_attempt_at = $4,
+		    last_status_code = $5, last_error = $6, updated_at = $4
+		WHERE id = $7
+	`, status, attempts, nextAttemptAt, now.Unix(), statusCode, lastError, delivery.ID)
+	if err != nil {
+		log.Printf("❌ [WEBHOOKS] Failed to record outcome of delivery %s: %v", delivery.ID, err)
+	}
+
+	return status
+}
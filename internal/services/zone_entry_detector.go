@@ -0,0 +1,247 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/store"
+	"ropacal-backend/internal/websocket"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Zone entry detection tuning
+const (
+	zoneExitRadiusFactor = 1.2              // Leaving needs this multiple of the zone radius, so GPS jitter at the edge doesn't flap
+	zoneMaxFixAccuracy   = 100.0            // Fixes less accurate than this (meters) are ignored
+	zoneListTTL          = 60 * time.Second // How long the list of active zones is reused between fixes
+)
+
+// ZoneEntryDetector records when a driver drives into an active no-go zone and when they leave it
+// (zone_visits), and alerts the manager dashboards with driver_entered_zone / driver_exited_zone events
+// A driver who comes back into a zone within the re-entry window continues the earlier visit: the entry
+// event is sent again with reentry=true so live maps stay right, and clients shouldn't alert on it
+// It listens to every GPS source through websocket.Hub.OnLocation
+type ZoneEntryDetector struct {
+	db      *sqlx.DB
+	hub     *websocket.Hub
+	reentry int64 // Seconds
+
+	mu       sync.Mutex
+	zones    map[string]models.NoGoZone
+	loadedAt time.Time
+}
+
+// NewZoneEntryDetector creates a detector; re-entries within reentryWindow continue the previous visit
+func NewZoneEntryDetector(db *sqlx.DB, hub *websocket.Hub, reentryWindow time.Duration) *ZoneEntryDetector {
+	return &ZoneEntryDetector{db: db, hub: hub, reentry: int64(reentryWindow.Seconds())}
+}
+
+// openZoneVisit is a visit the driver hasn't left yet
+type openZoneVisit struct {
+	ID        string `db:"id"`
+	ZoneID    string `db:"zone_id"`
+	EnteredAt int64  `db:"entered_at"`
+}
+
+// Observe processes a driver's fixes in order (registered with websocket.Hub.OnLocation)
+func (d *ZoneEntryDetector) Observe(driverID string, fixes []websocket.LocationFix) {
+	zones, err := d.activeZones()
+	if err != nil {
+		log.Printf("⚠️  [ZONE-ALERT] %v", err)
+		return
+	}
+
+	var open []openZoneVisit
+	err = d.db.Select(&open, `SELECT id, zone_id, entered_at FROM zone_visits WHERE driver_id = $1 AND exited_at IS NULL`, driverID)
+	if err != nil {
+		log.Printf("⚠️  [ZONE-ALERT] Failed to load open visits of %s: %v", driverID, err)
+		return
+	}
+	if len(zones) == 0 && len(open) == 0 {
+		return
+	}
+
+	var shiftID *string
+	var id string
+	err = d.db.Get(&id, `SELECT id FROM shifts WHERE driver_id = $1 AND status IN ('active', 'paused') LIMIT 1`, driverID)
+	if err == nil {
+		shiftID = &id
+	} else if err != sql.ErrNoRows {
+		log.Printf("⚠️  [ZONE-ALERT] Failed to load open shift of %s: %v", driverID, err)
+	}
+
+	openByZone := make(map[string]openZoneVisit, len(open))
+	for _, visit := range open {
+		openByZone[visit.ZoneID] = visit
+	}
+
+	for _, fix := range fixes {
+		if fix.Accuracy != nil && *fix.Accuracy > zoneMaxFixAccuracy {
+			continue
+		}
+		if err := d.observe(driverID, shiftID, zones, openByZone, fix); err != nil {
+			log.Printf("⚠️  [ZONE-ALERT] Driver %s: %v", driverID, err)
+			return
+		}
+	}
+}
+
+// observe closes visits to zones the driver has left (or that are no longer active), then opens visits
+// to the zones the fix is inside
+func (d *ZoneEntryDetector) observe(driverID string, shiftID *string, zones map[string]models.NoGoZone,
+	openByZone map[string]openZoneVisit, fix websocket.LocationFix) error {
+	at := fix.Timestamp / 1000
+	if now := time.Now().Unix(); at <= 0 || at > now {
+		at = now
+	}
+
+	for zoneID, visit := range openByZone {
+		zone, active := zones[zoneID]
+		if active && zoneDistanceMeters(zone, fix) <= float64(zone.RadiusMeters)*zoneExitRadiusFactor {
+			continue
+		}
+		result, err := d.db.Exec(`
+			UPDATE zone_visits SET exited_at = $2, exit_latitude = $3, exit_longitude = $4
+			WHERE id = $1 AND exited_at IS NULL`, visit.ID, at, fix.Latitude, fix.Longitude)
+		if err != nil {
+			return fmt.Errorf("failed to record exit from zone %s: %w", zoneID, err)
+		}
+		delete(openByZone, zoneID)
+		if n, _ := result.RowsAffected(); n == 1 {
+			if !active {
+				// Resolved or moved to monitoring while the driver was inside
+				zone = models.NoGoZone{ID: zoneID}
+				if err := d.db.Get(&zone, `
+					SELECT id, name, center_latitude, center_longitude, radius_meters, conflict_score, status
+					FROM no_go_zones WHERE id = $1`, zoneID); err != nil && err != sql.ErrNoRows {
+					log.Printf("⚠️  [ZONE-ALERT] Failed to load zone %s: %v", zoneID, err)
+				}
+			}
+			d.emit("driver_exited_zone", driverID, shiftID, zone, visit.ID, visit.EnteredAt, &at, fix, false)
+		}
+	}
+
+	for zoneID, zone := range zones {
+		if _, inside := openByZone[zoneID]; inside || zoneDistanceMeters(zone, fix) > float64(zone.RadiusMeters) {
+			continue
+		}
+
+		// Back within the re-entry window: the earlier visit continues
+		var recent openZoneVisit
+		err := d.db.Get(&recent, `
+			SELECT id, zone_id, entered_at FROM zone_visits
+			WHERE driver_id = $1 AND zone_id = $2 AND exited_at >= $3
+			ORDER BY exited_at DESC
+			LIMIT 1`, driverID, zoneID, at-d.reentry)
+		if err == nil {
+			result, err := d.db.Exec(`
+				UPDATE zone_visits SET exited_at = NULL, exit_latitude = NULL, exit_longitude = NULL
+				WHERE id = $1 AND exited_at IS NOT NULL
+				  AND NOT EXISTS (SELECT 1 FROM zone_visits WHERE driver_id = $2 AND zone_id = $3 AND exited_at IS NULL)`,
+				recent.ID, driverID, zoneID)
+			if err != nil {
+				return fmt.Errorf("failed to reopen visit to zone %s: %w", zoneID, err)
+			}
+			openByZone[zoneID] = recent
+			if n, _ := result.RowsAffected(); n == 1 {
+				d.emit("driver_entered_zone", driverID, shiftID, zone, recent.ID, recent.EnteredAt, nil, fix, true)
+			}
+			continue
+		}
+		if err != sql.ErrNoRows {
+			return fmt.Errorf("failed to load recent visit to zone %s: %w", zoneID, err)
+		}
+
+		visit := openZoneVisit{ID: uuid.New().String(), ZoneID: zoneID, EnteredAt: at}
+		result, err := d.db.Exec(`
+			INSERT INTO zone_visits (id, zone_id, driver_id, shift_id, entered_at, entry_latitude, entry_longitude)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT (driver_id, zone_id) WHERE exited_at IS NULL DO NOTHING`,
+			visit.ID, zoneID, driverID, shiftID, at, fix.Latitude, fix.Longitude)
+		if err != nil {
+			return fmt.Errorf("failed to record entry into zone %s: %w", zoneID, err)
+		}
+		// Another report for the same driver may have opened the visit first
+		if n, _ := result.RowsAffected(); n == 0 {
+			continue
+		}
+		openByZone[zoneID] = visit
+		d.emit("driver_entered_zone", driverID, shiftID, zone, visit.ID, visit.EnteredAt, nil, fix, false)
+	}
+	return nil
+}
+
+// emit sends an entry or exit event to the manager dashboards
+func (d *ZoneEntryDetector) emit(eventType, driverID string, shiftID *string, zone models.NoGoZone, visitID string,
+	enteredAt int64, exitedAt *int64, fix websocket.LocationFix, reentry bool) {
+	driverName, err := store.NewUserStore(d.db).Name(driverID)
+	if err != nil {
+		log.Printf("⚠️  [ZONE-ALERT] Failed to get name of driver %s: %v", driverID, err)
+	}
+
+	data := map[string]interface{}{
+		"visit_id":    visitID,
+		"driver_id":   driverID,
+		"driver_name": driverName,
+		"shift_id":    shiftID,
+		"zone": map[string]interface{}{
+			"id":               zone.ID,
+			"name":             zone.Name,
+			"status":           zone.Status,
+			"conflict_score":   zone.ConflictScore,
+			"center_latitude":  zone.CenterLatitude,
+			"center_longitude": zone.CenterLongitude,
+			"radius_meters":    zone.RadiusMeters,
+		},
+		"latitude":   fix.Latitude,
+		"longitude":  fix.Longitude,
+		"entered_at": enteredAt,
+		"exited_at":  exitedAt,
+		"reentry":    reentry,
+	}
+	if exitedAt != nil {
+		data["duration_seconds"] = *exitedAt - enteredAt
+	}
+
+	d.hub.BroadcastToRoleScoped("admin", websocket.EventScope{
+		Type:      eventType,
+		DriverID:  driverID,
+		Latitude:  &fix.Latitude,
+		Longitude: &fix.Longitude,
+	}, map[string]interface{}{"type": eventType, "data": data})
+	log.Printf("🚧 [ZONE-ALERT] %s: driver %s, zone %s (%s)", eventType, driverID, zone.ID, zone.Name)
+}
+
+// activeZones returns the active no-go zones by ID, reloading them at most once per zoneListTTL
+func (d *ZoneEntryDetector) activeZones() (map[string]models.NoGoZone, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.zones != nil && time.Since(d.loadedAt) < zoneListTTL {
+		return d.zones, nil
+	}
+
+	var zones []models.NoGoZone
+	err := d.db.Select(&zones, `
+		SELECT id, name, center_latitude, center_longitude, radius_meters, conflict_score, status
+		FROM no_go_zones
+		WHERE status = 'active'`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load active zones: %w", err)
+	}
+	d.zones = make(map[string]models.NoGoZone, len(zones))
+	for _, zone := range zones {
+		d.zones[zone.ID] = zone
+	}
+	d.loadedAt = time.Now()
+	return d.zones, nil
+}
+
+func zoneDistanceMeters(zone models.NoGoZone, fix websocket.LocationFix) float64 {
+	return haversineDistance(zone.CenterLatitude, zone.CenterLongitude, fix.Latitude, fix.Longitude) * 1000
+}
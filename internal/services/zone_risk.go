@@ -0,0 +1,93 @@
+package services
+
+import (
+	"fmt"
+
+	"ropacal-backend/internal/database"
+	"ropacal-backend/internal/models"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// riskZone is an active or monitored no-go zone, as used for routing
+type riskZone struct {
+	ID              string  `db:"id"`
+	Name            string  `db:"name"`
+	CenterLatitude  float64 `db:"center_latitude"`
+	CenterLongitude float64 `db:"center_longitude"`
+	RadiusMeters    int     `db:"radius_meters"`
+	ConflictScore   int     `db:"conflict_score"`
+	Status          string  `db:"status"`
+}
+
+// ZoneRisks returns the risk of every bin that lies inside an active or monitored no-go zone, keyed by bin ID
+// Bins in several zones take the riskiest one; weights are 0 while zone risk routing is disabled
+func ZoneRisks(db sqlx.Queryer, binIDs []string) (map[string]models.StopRisk, error) {
+	risks := map[string]models.StopRisk{}
+	if len(binIDs) == 0 {
+		return risks, nil
+	}
+
+	settings, err := database.GetZoneRiskRoutingSettings(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var zones []riskZone
+	err = sqlx.Select(db, &zones, `
+		SELECT id, name, center_latitude, center_longitude, radius_meters, conflict_score, status
+		FROM no_go_zones
+		WHERE status IN ('active', 'monitoring')
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load no-go zones: %w", err)
+	}
+	if len(zones) == 0 {
+		return risks, nil
+	}
+
+	var bins []struct {
+		ID        string  `db:"id"`
+		Latitude  float64 `db:"latitude"`
+		Longitude float64 `db:"longitude"`
+	}
+	err = sqlx.Select(db, &bins, `
+		SELECT id, latitude, longitude FROM bins
+		WHERE id = ANY($1) AND latitude IS NOT NULL AND longitude IS NOT NULL
+	`, pq.Array(binIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load bin locations: %w", err)
+	}
+
+	for _, bin := range bins {
+		for _, zone := range zones {
+			distanceMeters := haversineDistance(bin.Latitude, bin.Longitude, zone.CenterLatitude, zone.CenterLongitude) * 1000
+			if distanceMeters > float64(zone.RadiusMeters) {
+				continue
+			}
+
+			risk := models.StopRisk{
+				Level:    models.RiskLevelElevated,
+				Weight:   settings.Weight(zone.Status, zone.ConflictScore),
+				ZoneID:   zone.ID,
+				ZoneName: zone.Name,
+			}
+			if zone.Status == "active" {
+				risk.Level = models.RiskLevelHigh
+			}
+			if current, ok := risks[bin.ID]; !ok || riskRank(risk) > riskRank(current) {
+				risks[bin.ID] = risk
+			}
+		}
+	}
+	return risks, nil
+}
+
+// riskRank orders risks: high before elevated, then by weight
+func riskRank(risk models.StopRisk) float64 {
+	if risk.Level == models.RiskLevelHigh {
+		return 100 + risk.Weight
+	}
+	return risk.Weight
+}
@@ -0,0 +1,92 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"ropacal-backend/internal/cache"
+	"ropacal-backend/internal/models"
+)
+
+// binSummaries caches the identifying fields of bins, which list handlers look up once per row
+var binSummaries = cache.New[string, models.Bin](5000)
+
+// BinStore reads bins
+type BinStore interface {
+	// Get returns a bin by ID (sql.ErrNoRows if it doesn't exist)
+	Get(binID string) (*models.Bin, error)
+	// Summary returns a bin's number, address, coordinates and status (sql.ErrNoRows if it doesn't exist), cached briefly
+	// Other fields are left zero; use Get for the full row
+	Summary(binID string) (*models.Bin, error)
+	// CountExisting returns how many of the given IDs are bins
+	CountExisting(binIDs []string) (int, error)
+}
+
+type binStore struct {
+	db sqlx.Ext
+}
+
+// NewBinStore returns a BinStore backed by Postgres
+func NewBinStore(db sqlx.Ext) BinStore {
+	return &binStore{db: db}
+}
+
+func (s *binStore) Get(binID string) (*models.Bin, error) {
+	var bin models.Bin
+	err := sqlx.Get(s.db, &bin, `SELECT * FROM bins WHERE id = $1`, binID)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bin %s: %w", binID, err)
+	}
+	return &bin, nil
+}
+
+func (s *binStore) Summary(binID string) (*models.Bin, error) {
+	bin, err := binSummaries.GetOrLoad(binID, func() (models.Bin, error) {
+		var bin models.Bin
+		err := sqlx.Get(s.db, &bin, `
+			SELECT id, bin_number, current_street, city, zip, latitude, longitude, status
+			FROM bins WHERE id = $1
+		`, binID)
+		if err == sql.ErrNoRows {
+			return bin, err
+		}
+		if err != nil {
+			return bin, fmt.Errorf("failed to get bin %s: %w", binID, err)
+		}
+		return bin, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &bin, nil
+}
+
+// InvalidateBins drops cached summaries for bins whose number, address, coordinates or status changed
+// With no IDs (bulk updates) every summary is dropped
+func InvalidateBins(binIDs ...string) {
+	if len(binIDs) == 0 {
+		binSummaries.Clear()
+		return
+	}
+	binSummaries.Delete(binIDs...)
+}
+
+func (s *binStore) CountExisting(binIDs []string) (int, error) {
+	if len(binIDs) == 0 {
+		return 0, nil
+	}
+	query, args, err := sqlx.In(`SELECT COUNT(*) FROM bins WHERE id IN (?)`, binIDs)
+	if err != nil {
+		return 0, fmt.Errorf("failed to build bin count query: %w", err)
+	}
+
+	var count int
+	if err := sqlx.Get(s.db, &count, s.db.Rebind(query), args...); err != nil {
+		return 0, fmt.Errorf("failed to count bins: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,126 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"ropacal-backend/internal/models"
+)
+
+// MoveRequestStore reads and updates bin move requests
+type MoveRequestStore interface {
+	// Get returns a move request by ID (sql.ErrNoRows if it doesn't exist)
+	Get(moveRequestID string) (*models.BinMoveRequest, error)
+	// GetForUpdate is Get with a row lock held for the rest of the transaction, so status checks made on the
+	// result hold until commit
+	GetForUpdate(moveRequestID string) (*models.BinMoveRequest, error)
+	// AssignToShift puts a move request in fromStatus on a shift (clearing any manual assignment)
+	// Returns ErrMoveRequestChanged when the move request is no longer in fromStatus
+	AssignToShift(moveRequestID, shiftID, fromStatus, status string, now int64) error
+	// ReleaseInProgress returns the shifts' in-progress (or picked-up) move requests to pending and unassigns them
+	ReleaseInProgress(now int64, shiftIDs ...string) (int64, error)
+	// Attachments returns the attachments of the move requests, oldest first, keyed by move request ID
+	Attachments(moveRequestIDs ...string) (map[string][]models.MoveRequestAttachment, error)
+}
+
+// ErrMoveRequestChanged is returned by writes guarded on a move request's status when the status changed
+// since the caller read it (a concurrent assign, cancel or completion got there first)
+var ErrMoveRequestChanged = errors.New("move request changed since it was read")
+
+type moveRequestStore struct {
+	db sqlx.Ext
+}
+
+// NewMoveRequestStore returns a MoveRequestStore backed by Postgres
+func NewMoveRequestStore(db sqlx.Ext) MoveRequestStore {
+	return &moveRequestStore{db: db}
+}
+
+func (s *moveRequestStore) Get(moveRequestID string) (*models.BinMoveRequest, error) {
+	var moveRequest models.BinMoveRequest
+	err := sqlx.Get(s.db, &moveRequest, `SELECT * FROM bin_move_requests WHERE id = $1`, moveRequestID)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get move request %s: %w", moveRequestID, err)
+	}
+	return &moveRequest, nil
+}
+
+func (s *moveRequestStore) GetForUpdate(moveRequestID string) (*models.BinMoveRequest, error) {
+	var moveRequest models.BinMoveRequest
+	err := sqlx.Get(s.db, &moveRequest, `SELECT * FROM bin_move_requests WHERE id = $1 FOR UPDATE`, moveRequestID)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock move request %s: %w", moveRequestID, err)
+	}
+	return &moveRequest, nil
+}
+
+func (s *moveRequestStore) AssignToShift(moveRequestID, shiftID, fromStatus, status string, now int64) error {
+	result, err := s.db.Exec(`
+		UPDATE bin_move_requests
+		SET assignment_type = 'shift', assigned_shift_id = $1, assigned_user_id = NULL, status = $2, updated_at = $3
+		WHERE id = $4 AND status = $5
+	`, shiftID, status, now, moveRequestID, fromStatus)
+	if err != nil {
+		return fmt.Errorf("failed to assign move request %s to shift: %w", moveRequestID, err)
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to assign move request %s to shift: %w", moveRequestID, err)
+	} else if rows == 0 {
+		return ErrMoveRequestChanged
+	}
+	return nil
+}
+
+func (s *moveRequestStore) ReleaseInProgress(now int64, shiftIDs ...string) (int64, error) {
+	if len(shiftIDs) == 0 {
+		return 0, nil
+	}
+	query, args, err := sqlx.In(`
+		UPDATE bin_move_requests
+		SET status = ?,
+		    assigned_shift_id = NULL,
+		    picked_up_at = NULL,
+		    updated_at = ?
+		WHERE assigned_shift_id IN (?)
+		AND status IN (?)
+	`, models.MoveStatusPending, now, shiftIDs, []string{models.MoveStatusInProgress, models.MoveStatusPickedUp})
+	if err != nil {
+		return 0, fmt.Errorf("failed to build move request release query: %w", err)
+	}
+	result, err := s.db.Exec(s.db.Rebind(query), args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to release move requests: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+func (s *moveRequestStore) Attachments(moveRequestIDs ...string) (map[string][]models.MoveRequestAttachment, error) {
+	byMove := make(map[string][]models.MoveRequestAttachment)
+	if len(moveRequestIDs) == 0 {
+		return byMove, nil
+	}
+	query, args, err := sqlx.In(`
+		SELECT * FROM move_request_attachments
+		WHERE move_request_id IN (?)
+		ORDER BY created_at ASC
+	`, moveRequestIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build attachments query: %w", err)
+	}
+	var attachments []models.MoveRequestAttachment
+	if err := sqlx.Select(s.db, &attachments, s.db.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("failed to get move request attachments: %w", err)
+	}
+	for _, attachment := range attachments {
+		byMove[attachment.MoveRequestID] = append(byMove[attachment.MoveRequestID], attachment)
+	}
+	return byMove, nil
+}
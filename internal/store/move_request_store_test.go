@@ -0,0 +1,86 @@
+package store_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"ropacal-backend/internal/models"
+	"ropacal-backend/internal/store"
+	"ropacal-backend/internal/testdb"
+)
+
+func TestAssignToShiftGuardsStatus(t *testing.T) {
+	db := testdb.Open(t)
+	manager := testdb.User(t, db, "admin")
+	driver := testdb.User(t, db, "driver")
+	shiftID := testdb.Shift(t, db, driver, "ready")
+	moveID := testdb.MoveRequest(t, db, testdb.Bin(t, db), manager, models.MoveStatusPending)
+	moves := store.NewMoveRequestStore(db)
+	now := time.Now().Unix()
+
+	// Validated against a status the move request is no longer in
+	err := moves.AssignToShift(moveID, shiftID, models.MoveStatusAssigned, models.MoveStatusInProgress, now)
+	if !errors.Is(err, store.ErrMoveRequestChanged) {
+		t.Fatalf("AssignToShift from a stale status: got %v, want ErrMoveRequestChanged", err)
+	}
+
+	if err := moves.AssignToShift(moveID, shiftID, models.MoveStatusPending, models.MoveStatusAssigned, now); err != nil {
+		t.Fatalf("AssignToShift: %v", err)
+	}
+	move, err := moves.Get(moveID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if move.Status != models.MoveStatusAssigned || move.AssignedShiftID == nil || *move.AssignedShiftID != shiftID {
+		t.Fatalf("move request after assignment: status %s, shift %v", move.Status, move.AssignedShiftID)
+	}
+}
+
+// Two transactions validated against the same status: the second waits for the first's row lock, then
+// finds the status changed instead of overwriting it
+func TestAssignToShiftConcurrent(t *testing.T) {
+	db := testdb.Open(t)
+	manager := testdb.User(t, db, "admin")
+	firstShift := testdb.Shift(t, db, testdb.User(t, db, "driver"), "ready")
+	secondShift := testdb.Shift(t, db, testdb.User(t, db, "driver"), "ready")
+	moveID := testdb.MoveRequest(t, db, testdb.Bin(t, db), manager, models.MoveStatusPending)
+	now := time.Now().Unix()
+
+	first := db.MustBegin()
+	defer first.Rollback()
+	if err := store.NewMoveRequestStore(first).AssignToShift(moveID, firstShift, models.MoveStatusPending, models.MoveStatusAssigned, now); err != nil {
+		t.Fatalf("first AssignToShift: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		second := db.MustBegin()
+		defer second.Rollback()
+		err := store.NewMoveRequestStore(second).AssignToShift(moveID, secondShift, models.MoveStatusPending, models.MoveStatusAssigned, now)
+		if err == nil {
+			err = second.Commit()
+		}
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf("second AssignToShift didn't wait for the first transaction (err %v)", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+	if err := first.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	if err := <-done; !errors.Is(err, store.ErrMoveRequestChanged) {
+		t.Fatalf("second AssignToShift: got %v, want ErrMoveRequestChanged", err)
+	}
+
+	move, err := store.NewMoveRequestStore(db).Get(moveID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if move.AssignedShiftID == nil || *move.AssignedShiftID != firstShift {
+		t.Fatalf("move request is on shift %v, want %s", move.AssignedShiftID, firstShift)
+	}
+}
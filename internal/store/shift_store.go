@@ -0,0 +1,335 @@
+package store
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"ropacal-backend/internal/models"
+)
+
+// ShiftStore owns a shift's stops (route_tasks rows)
+// sequence_order = 0 marks collection stops of an unoptimized route (optimized at shift start)
+type ShiftStore interface {
+	// Lock takes a row lock on the shift for the rest of the transaction
+	// Writers that renumber a shift's stops take it first so they serialize
+	Lock(shiftID string) error
+	// Stops returns a shift's stops with bin details, in sequence order
+	Stops(shiftID string) ([]models.ShiftBinWithDetails, error)
+	// CountStops returns how many stops a shift has
+	CountStops(shiftID string) (int, error)
+	// InsertStop adds a stop; an ID is generated when empty
+	InsertStop(task *models.RouteTask) error
+	// InsertCollectionStop adds a collection stop for a bin at its current location
+	InsertCollectionStop(shiftID, binID string, routeID *string, sequenceOrder int, now int64) error
+	// ShiftSequence pushes every stop at or after sequence order from back by n
+	ShiftSequence(shiftID string, from, n int) error
+	// SetSequence sets one stop's sequence order
+	SetSequence(taskID string, sequenceOrder int) error
+	// RemoveMoveStops deletes the incomplete pickup/dropoff stops of a move request
+	RemoveMoveStops(shiftID, moveRequestID string) (int64, error)
+	// RemoveReleasedMoveStops deletes the incomplete stops of move requests released back to pending
+	RemoveReleasedMoveStops(shiftIDs ...string) (int64, error)
+	// Reindex renumbers a shift's sequenced stops 1..N in their current order
+	Reindex(shiftID string) (int64, error)
+	// SequenceConflicts lists shifts whose stops share a sequence order or have gaps
+	SequenceConflicts(shiftID string) ([]SequenceConflict, error)
+	// CompletedStops lists the completed stops of a shift for the earnings calculation
+	CompletedStops(shiftID string) ([]models.EarningsStop, error)
+	// EfficiencyStops lists a shift's sequenced stops with completion times for the efficiency calculation
+	EfficiencyStops(shiftID string) ([]models.EfficiencyStop, error)
+	// CountIncident bumps the shift's incidents_reported counter, and field_observations too for a field observation
+	CountIncident(shiftID string, fieldObservation bool) error
+}
+
+// SequenceConflict is a shift whose stops share a sequence_order or have gaps
+type SequenceConflict struct {
+	ShiftID         string `json:"shift_id" db:"shift_id"`
+	Status          string `json:"status" db:"status"`
+	Stops           int    `json:"stops" db:"stops"`
+	DuplicateOrders int    `json:"duplicate_orders" db:"duplicate_orders"` // Stops sharing a sequence_order with an earlier stop
+	MaxSequence     int    `json:"max_sequence" db:"max_sequence"`
+	Repaired        bool   `json:"repaired"`
+	ChangedStops    int64  `json:"changed_stops"`
+}
+
+type shiftStore struct {
+	db sqlx.Ext
+}
+
+// NewShiftStore returns a ShiftStore backed by Postgres
+func NewShiftStore(db sqlx.Ext) ShiftStore {
+	return &shiftStore{db: db}
+}
+
+func (s *shiftStore) Lock(shiftID string) error {
+	var id string
+	if err := sqlx.Get(s.db, &id, `SELECT id FROM shifts WHERE id = $1 FOR UPDATE`, shiftID); err != nil {
+		return fmt.Errorf("failed to lock shift %s: %w", shiftID, err)
+	}
+	return nil
+}
+
+func (s *shiftStore) Stops(shiftID string) ([]models.ShiftBinWithDetails, error) {
+	query := `
+		SELECT
+			0 as id,  -- route_tasks uses string id, not auto-increment
+			rt.id as task_id,
+			rt.shift_id,
+			COALESCE(rt.bin_id, '') as bin_id,
+			rt.sequence_order,
+			rt.is_completed,
+			rt.completed_at,
+			rt.updated_fill_percentage,
+			rt.created_at,
+			COALESCE(b.bin_number, rt.bin_number, 0) as bin_number,
+			COALESCE(rt.address, '') as current_street,
+			COALESCE(b.city, '') as city,
+			COALESCE(b.zip, '') as zip,
+			COALESCE(b.fill_percentage, 0) as fill_percentage,
+			rt.latitude,
+			rt.longitude,
+			rt.task_type as stop_type,
+			rt.move_request_id,
+			rt.address as original_address,
+			rt.destination_address as new_address,
+			rt.destination_latitude as new_latitude,
+			rt.destination_longitude as new_longitude,
+			rt.move_type,
+			mr.instructions as move_instructions,
+			CASE WHEN mr.time_window_start IS NOT NULL THEN mr.time_window_start ELSE b.time_window_start END as time_window_start,
+			CASE WHEN mr.time_window_start IS NOT NULL THEN mr.time_window_end ELSE b.time_window_end END as time_window_end,
+			(rt.task_type = 'collection' AND (COALESCE(b.photo_required, false) OR COALESCE(a.photo_required, false))) as photo_required
+		FROM route_tasks rt
+		LEFT JOIN bins b ON rt.bin_id = b.id
+		LEFT JOIN areas a ON b.area_id = a.id
+		LEFT JOIN bin_move_requests mr ON rt.move_request_id = mr.id
+		WHERE rt.shift_id = $1
+		ORDER BY rt.sequence_order ASC, rt.created_at ASC`
+
+	stops := []models.ShiftBinWithDetails{}
+	if err := sqlx.Select(s.db, &stops, query, shiftID); err != nil {
+		return nil, fmt.Errorf("failed to get stops for shift %s: %w", shiftID, err)
+	}
+
+	// Move stops carry their move request's attachments for the driver
+	var moveRequestIDs []string
+	seen := map[string]bool{}
+	for _, stop := range stops {
+		if stop.MoveRequestID != nil && !seen[*stop.MoveRequestID] {
+			seen[*stop.MoveRequestID] = true
+			moveRequestIDs = append(moveRequestIDs, *stop.MoveRequestID)
+		}
+	}
+	if len(moveRequestIDs) > 0 {
+		attachments, err := NewMoveRequestStore(s.db).Attachments(moveRequestIDs...)
+		if err != nil {
+			return nil, err
+		}
+		for i := range stops {
+			if stops[i].MoveRequestID != nil {
+				stops[i].MoveAttachments = attachments[*stops[i].MoveRequestID]
+			}
+		}
+	}
+	return stops, nil
+}
+
+func (s *shiftStore) CountIncident(shiftID string, fieldObservation bool) error {
+	_, err := s.db.Exec(`
+		UPDATE shifts
+		SET incidents_reported = incidents_reported + 1,
+		    field_observations = field_observations + CASE WHEN $2 THEN 1 ELSE 0 END
+		WHERE id = $1
+	`, shiftID, fieldObservation)
+	if err != nil {
+		return fmt.Errorf("failed to count incident for shift %s: %w", shiftID, err)
+	}
+	return nil
+}
+
+func (s *shiftStore) CountStops(shiftID string) (int, error) {
+	var count int
+	if err := sqlx.Get(s.db, &count, `SELECT COUNT(*) FROM route_tasks WHERE shift_id = $1`, shiftID); err != nil {
+		return 0, fmt.Errorf("failed to count stops for shift %s: %w", shiftID, err)
+	}
+	return count, nil
+}
+
+func (s *shiftStore) InsertStop(task *models.RouteTask) error {
+	if task.ID == "" {
+		task.ID = uuid.New().String()
+	}
+
+	_, err := sqlx.NamedExec(s.db, `
+		INSERT INTO route_tasks (
+			id, shift_id, sequence_order, task_type, latitude, longitude, address,
+			bin_id, bin_number, fill_percentage,
+			potential_location_id, new_bin_number,
+			move_request_id, destination_latitude, destination_longitude, destination_address, move_type,
+			warehouse_action, bins_to_load,
+			route_id, is_completed, skipped, created_at
+		) VALUES (
+			:id, :shift_id, :sequence_order, :task_type, :latitude, :longitude, :address,
+			:bin_id, :bin_number, :fill_percentage,
+			:potential_location_id, :new_bin_number,
+			:move_request_id, :destination_latitude, :destination_longitude, :destination_address, :move_type,
+			:warehouse_action, :bins_to_load,
+			:route_id, :is_completed, :skipped, :created_at
+		)`, task)
+	if err != nil {
+		return fmt.Errorf("failed to insert %s stop for shift %s: %w", task.TaskType, task.ShiftID, err)
+	}
+	return nil
+}
+
+func (s *shiftStore) InsertCollectionStop(shiftID, binID string, routeID *string, sequenceOrder int, now int64) error {
+	result, err := s.db.Exec(`
+		INSERT INTO route_tasks (
+			id, shift_id, sequence_order, task_type, latitude, longitude, address,
+			bin_id, bin_number, fill_percentage, route_id, is_completed, skipped, created_at
+		)
+		SELECT $1, $2, $3, 'collection', b.latitude, b.longitude, b.current_street,
+		       b.id, b.bin_number, b.fill_percentage, $4, 0, false, $5
+		FROM bins b
+		WHERE b.id = $6`,
+		uuid.New().String(), shiftID, sequenceOrder, routeID, now, binID)
+	if err != nil {
+		return fmt.Errorf("failed to insert collection stop for bin %s: %w", binID, err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("bin %s not found", binID)
+	}
+	return nil
+}
+
+func (s *shiftStore) ShiftSequence(shiftID string, from, n int) error {
+	_, err := s.db.Exec(`
+		UPDATE route_tasks
+		SET sequence_order = sequence_order + $1
+		WHERE shift_id = $2 AND sequence_order >= $3
+	`, n, shiftID, from)
+	if err != nil {
+		return fmt.Errorf("failed to shift sequence order for shift %s: %w", shiftID, err)
+	}
+	return nil
+}
+
+func (s *shiftStore) SetSequence(taskID string, sequenceOrder int) error {
+	_, err := s.db.Exec(`UPDATE route_tasks SET sequence_order = $1 WHERE id = $2`, sequenceOrder, taskID)
+	if err != nil {
+		return fmt.Errorf("failed to update sequence order of stop %s: %w", taskID, err)
+	}
+	return nil
+}
+
+func (s *shiftStore) RemoveMoveStops(shiftID, moveRequestID string) (int64, error) {
+	result, err := s.db.Exec(`
+		DELETE FROM route_tasks
+		WHERE shift_id = $1 AND move_request_id = $2 AND is_completed = 0
+	`, shiftID, moveRequestID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove move stops from shift %s: %w", shiftID, err)
+	}
+	return result.RowsAffected()
+}
+
+// RemoveReleasedMoveStops keeps completed stops (and every collection) for shift history
+func (s *shiftStore) RemoveReleasedMoveStops(shiftIDs ...string) (int64, error) {
+	if len(shiftIDs) == 0 {
+		return 0, nil
+	}
+	query, args, err := sqlx.In(`
+		DELETE FROM route_tasks
+		WHERE shift_id IN (?)
+		AND is_completed = 0
+		AND move_request_id IN (
+			SELECT id FROM bin_move_requests
+			WHERE assigned_shift_id IS NULL
+			AND status = 'pending'
+		)
+	`, shiftIDs)
+	if err != nil {
+		return 0, fmt.Errorf("failed to build move stop delete query: %w", err)
+	}
+	result, err := s.db.Exec(s.db.Rebind(query), args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove released move stops: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// Reindex breaks ties completed-first then by insertion order; sequence_order = 0 is left untouched
+func (s *shiftStore) Reindex(shiftID string) (int64, error) {
+	result, err := s.db.Exec(`
+		UPDATE route_tasks rt
+		SET sequence_order = ordered.new_sequence
+		FROM (
+			SELECT id, ROW_NUMBER() OVER (ORDER BY sequence_order ASC, is_completed DESC, created_at ASC, id ASC) AS new_sequence
+			FROM route_tasks
+			WHERE shift_id = $1 AND sequence_order > 0
+		) ordered
+		WHERE rt.id = ordered.id AND rt.sequence_order <> ordered.new_sequence
+	`, shiftID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reindex shift %s: %w", shiftID, err)
+	}
+	return result.RowsAffected()
+}
+
+// SequenceConflicts checks every shift when shiftID is empty (sequence_order = 0 is ignored)
+func (s *shiftStore) SequenceConflicts(shiftID string) ([]SequenceConflict, error) {
+	query := `
+		SELECT rt.shift_id, s.status,
+		       COUNT(*) AS stops,
+		       COUNT(*) - COUNT(DISTINCT rt.sequence_order) AS duplicate_orders,
+		       MAX(rt.sequence_order) AS max_sequence
+		FROM route_tasks rt
+		JOIN shifts s ON s.id = rt.shift_id
+		WHERE rt.sequence_order > 0
+		  AND ($1 = '' OR rt.shift_id = $1)
+		GROUP BY rt.shift_id, s.status
+		HAVING COUNT(*) <> COUNT(DISTINCT rt.sequence_order)
+		    OR MAX(rt.sequence_order) <> COUNT(*)
+		ORDER BY rt.shift_id`
+
+	conflicts := []SequenceConflict{}
+	if err := sqlx.Select(s.db, &conflicts, query, shiftID); err != nil {
+		return nil, fmt.Errorf("failed to scan shift sequences: %w", err)
+	}
+	return conflicts, nil
+}
+
+// CompletedStops skips skipped stops and warehouse stops
+func (s *shiftStore) CompletedStops(shiftID string) ([]models.EarningsStop, error) {
+	query := `
+		SELECT rt.task_type AS stop_type,
+		       rt.updated_fill_percentage AS fill_percentage,
+		       COALESCE(mr.urgency = 'urgent', false) AS urgent
+		FROM route_tasks rt
+		LEFT JOIN bin_move_requests mr ON mr.id = rt.move_request_id
+		WHERE rt.shift_id = $1 AND rt.is_completed = 1 AND rt.skipped = false
+		  AND rt.task_type <> 'warehouse_stop'`
+
+	var stops []models.EarningsStop
+	if err := sqlx.Select(s.db, &stops, query, shiftID); err != nil {
+		return nil, fmt.Errorf("failed to get completed stops for shift %s: %w", shiftID, err)
+	}
+	return stops, nil
+}
+
+// EfficiencyStops skips warehouse stops; skipped stops come back with a nil completed_at
+func (s *shiftStore) EfficiencyStops(shiftID string) ([]models.EfficiencyStop, error) {
+	query := `
+		SELECT sequence_order, latitude, longitude,
+		       CASE WHEN is_completed = 1 AND skipped = false THEN completed_at END AS completed_at
+		FROM route_tasks
+		WHERE shift_id = $1 AND task_type <> 'warehouse_stop'
+		ORDER BY sequence_order ASC, created_at ASC`
+
+	var stops []models.EfficiencyStop
+	if err := sqlx.Select(s.db, &stops, query, shiftID); err != nil {
+		return nil, fmt.Errorf("failed to get stops for shift %s: %w", shiftID, err)
+	}
+	return stops, nil
+}
@@ -0,0 +1,33 @@
+// Package store owns the SQL for shifts' stops, bins, move requests and user lookups.
+//
+// Stores take a sqlx.Ext so the same store works on *sqlx.DB or inside a *sqlx.Tx;
+// handlers depend on the interfaces, which can be replaced with fakes in tests.
+//
+// Hot lookups (user names, bin summaries) are read through a short-lived in-memory cache
+// shared by every Stores; code that writes those columns calls InvalidateUsers/InvalidateBins.
+//
+// Shift stops live in route_tasks. The legacy shift_bins table is no longer read or
+// written (its rows were backfilled into route_tasks by a migration).
+package store
+
+import (
+	"github.com/jmoiron/sqlx"
+)
+
+// Stores bundles the stores bound to one connection or transaction
+type Stores struct {
+	Bins         BinStore
+	Shifts       ShiftStore
+	MoveRequests MoveRequestStore
+	Users        UserStore
+}
+
+// New returns stores that run their queries on db (a *sqlx.DB or *sqlx.Tx)
+func New(db sqlx.Ext) Stores {
+	return Stores{
+		Bins:         NewBinStore(db),
+		Shifts:       NewShiftStore(db),
+		MoveRequests: NewMoveRequestStore(db),
+		Users:        NewUserStore(db),
+	}
+}
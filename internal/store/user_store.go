@@ -0,0 +1,46 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"ropacal-backend/internal/cache"
+)
+
+// userNames caches display names, which handlers look up once per row when building lists and history entries
+var userNames = cache.New[string, string](2000)
+
+// UserStore reads users
+type UserStore interface {
+	// Name returns a user's display name (sql.ErrNoRows if the user doesn't exist), cached briefly
+	Name(userID string) (string, error)
+}
+
+type userStore struct {
+	db sqlx.Ext
+}
+
+// NewUserStore returns a UserStore backed by Postgres
+func NewUserStore(db sqlx.Ext) UserStore {
+	return &userStore{db: db}
+}
+
+func (s *userStore) Name(userID string) (string, error) {
+	return userNames.GetOrLoad(userID, func() (string, error) {
+		var name string
+		err := sqlx.Get(s.db, &name, `SELECT name FROM users WHERE id = $1`, userID)
+		if err == sql.ErrNoRows {
+			return "", err
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to get name of user %s: %w", userID, err)
+		}
+		return name, nil
+	})
+}
+
+// InvalidateUsers drops cached lookups for users that were renamed or removed
+func InvalidateUsers(userIDs ...string) {
+	userNames.Delete(userIDs...)
+}
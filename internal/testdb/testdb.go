@@ -0,0 +1,213 @@
+// Package testdb connects tests to a real Postgres database for the transactional paths fakes can't cover
+// (row locks, guarded updates, rollbacks).
+//
+// Tests use the database at TEST_DATABASE_URL when it is set (CI sets it to its Postgres service). Otherwise
+// the first test to open the database starts a disposable Postgres container (testcontainers, so Docker must
+// be running), shared by the package's tests and removed when they exit. Without either, the tests are
+// skipped locally and fail under CI.
+// Open runs the migrations on the database and tests leave their rows behind (every fixture gets fresh IDs,
+// so runs don't collide), so TEST_DATABASE_URL must point at a disposable database.
+//
+//	go test ./...
+//	TEST_DATABASE_URL=postgres://localhost/ropacal_test?sslmode=disable go test ./...
+package testdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"ropacal-backend/internal/database"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+)
+
+// containerImage is the Postgres started when TEST_DATABASE_URL isn't set (production runs Postgres 16)
+const containerImage = "postgres:16-alpine"
+
+// Every fixture bin is at this location (downtown San Jose), so a driver placed there is at all the stops
+const (
+	BinLatitude  = 37.3382
+	BinLongitude = -121.8863
+)
+
+// errNoContainer means no test database could be started; tests skip on it outside CI
+var errNoContainer = errors.New("no test database")
+
+var (
+	once    sync.Once
+	shared  *sqlx.DB
+	openErr error
+)
+
+// Open returns the migrated test database, skipping the test when there is none (see the package doc)
+// The connection is shared by every test of the package
+func Open(t testing.TB) *sqlx.DB {
+	t.Helper()
+	once.Do(func() {
+		url := os.Getenv("TEST_DATABASE_URL")
+		if url == "" {
+			url, openErr = startContainer()
+			if openErr != nil {
+				return
+			}
+		}
+		shared, openErr = open(url)
+	})
+	if errors.Is(openErr, errNoContainer) && os.Getenv("CI") == "" {
+		t.Skipf("TEST_DATABASE_URL not set and %v", openErr)
+	}
+	if openErr != nil {
+		t.Fatalf("test database: %v", openErr)
+	}
+	return shared
+}
+
+// startContainer runs a throwaway Postgres and returns its URL; the container is removed when the test
+// binary exits (testcontainers' reaper)
+func startContainer() (url string, err error) {
+	// testcontainers panics when it finds no Docker host
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: Docker is unavailable: %v", errNoContainer, r)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	container, err := postgres.Run(ctx, containerImage,
+		postgres.WithDatabase("ropacal_test"),
+		postgres.WithUsername("ropacal"),
+		postgres.WithPassword("ropacal"),
+		postgres.BasicWaitStrategies(),
+	)
+	if err != nil {
+		return "", fmt.Errorf("%w: failed to start %s: %v", errNoContainer, containerImage, err)
+	}
+	return container.ConnectionString(ctx, "sslmode=disable")
+}
+
+// open connects and migrates; routes and route_bins come from migrations/create_routes_table.sql, which
+// production applies by hand, so it runs between two passes of the (idempotent) migrations
+func open(url string) (*sqlx.DB, error) {
+	db, err := database.Connect(url)
+	if err != nil {
+		return nil, err
+	}
+	if err := database.Migrate(db); err != nil {
+		return nil, fmt.Errorf("failed to migrate: %w", err)
+	}
+	_, file, _, _ := runtime.Caller(0)
+	routesSQL, err := os.ReadFile(filepath.Join(filepath.Dir(file), "..", "..", "migrations", "create_routes_table.sql"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read routes migration: %w", err)
+	}
+	if _, err := db.Exec(string(routesSQL)); err != nil {
+		return nil, fmt.Errorf("failed to create routes: %w", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		return nil, fmt.Errorf("failed to migrate: %w", err)
+	}
+	return db, nil
+}
+
+// User inserts a user with the role (driver or admin) and returns its ID
+func User(t testing.TB, db *sqlx.DB, role string) string {
+	t.Helper()
+	id := uuid.New().String()
+	_, err := db.Exec(`INSERT INTO users (id, email, password, name, role) VALUES ($1, $2, 'x', $3, $4)`,
+		id, id+"@test.ropacal.com", "Test "+role, role)
+	if err != nil {
+		t.Fatalf("failed to insert user: %v", err)
+	}
+	return id
+}
+
+// Bin inserts an active bin with coordinates and returns its ID
+func Bin(t testing.TB, db *sqlx.DB) string {
+	t.Helper()
+	id := uuid.New().String()
+	_, err := db.Exec(`
+		INSERT INTO bins (id, bin_number, current_street, city, zip, status, latitude, longitude)
+		VALUES ($1, (SELECT COALESCE(MAX(bin_number), 0) + 1 FROM bins), '1 Test St', 'San Jose', '95112', 'active', $2, $3)
+	`, id, BinLatitude, BinLongitude)
+	if err != nil {
+		t.Fatalf("failed to insert bin: %v", err)
+	}
+	return id
+}
+
+// Shift inserts a shift of the driver in the status (ready, active, ...) with no stops and returns its ID
+func Shift(t testing.TB, db *sqlx.DB, driverID, status string) string {
+	t.Helper()
+	id := uuid.New().String()
+	now := time.Now().Unix()
+	_, err := db.Exec(`INSERT INTO shifts (id, driver_id, status, created_at, updated_at) VALUES ($1, $2, $3, $4, $4)`,
+		id, driverID, status, now)
+	if err != nil {
+		t.Fatalf("failed to insert shift: %v", err)
+	}
+	return id
+}
+
+// DriverLocation reports the driver's GPS (connected, as of now) at the fixture bins' location
+func DriverLocation(t testing.TB, db *sqlx.DB, driverID string) {
+	t.Helper()
+	now := time.Now().Unix()
+	_, err := db.Exec(`
+		INSERT INTO driver_current_location (driver_id, latitude, longitude, timestamp, is_connected, updated_at)
+		VALUES ($1, $2, $3, $4, TRUE, $4)
+		ON CONFLICT (driver_id) DO UPDATE
+		SET latitude = EXCLUDED.latitude, longitude = EXCLUDED.longitude, timestamp = EXCLUDED.timestamp,
+		    is_connected = TRUE, updated_at = EXCLUDED.updated_at
+	`, driverID, BinLatitude, BinLongitude, now)
+	if err != nil {
+		t.Fatalf("failed to insert driver location: %v", err)
+	}
+}
+
+// MoveRequest inserts a store move of the bin in the status (requested, pending, ...) and returns its ID
+func MoveRequest(t testing.TB, db *sqlx.DB, binID, requestedBy, status string) string {
+	t.Helper()
+	id := uuid.New().String()
+	now := time.Now().Unix()
+	_, err := db.Exec(`
+		INSERT INTO bin_move_requests (id, bin_id, scheduled_date, urgency, requested_by, status,
+		                               original_latitude, original_longitude, original_address, move_type, created_at, updated_at)
+		VALUES ($1, $2, $3, 'scheduled', $4, $5, $6, $7, '1 Test St, San Jose 95112', 'store', $8, $8)
+	`, id, binID, now+7*86400, requestedBy, status, BinLatitude, BinLongitude, now)
+	if err != nil {
+		t.Fatalf("failed to insert move request: %v", err)
+	}
+	return id
+}
+
+// Route inserts a published route with the bins in order and returns its ID
+func Route(t testing.TB, db *sqlx.DB, binIDs ...string) string {
+	t.Helper()
+	id := uuid.New().String()
+	now := time.Now().Unix()
+	_, err := db.Exec(`
+		INSERT INTO routes (id, name, geographic_area, bin_count, created_at, updated_at)
+		VALUES ($1, $2, 'San Jose', $3, $4, $4)
+	`, id, "Test route "+id[:8], len(binIDs), now)
+	if err != nil {
+		t.Fatalf("failed to insert route: %v", err)
+	}
+	for i, binID := range binIDs {
+		_, err := db.Exec(`INSERT INTO route_bins (route_id, bin_id, sequence_order, created_at) VALUES ($1, $2, $3, $4)`,
+			id, binID, i+1, now)
+		if err != nil {
+			t.Fatalf("failed to insert route bin: %v", err)
+		}
+	}
+	return id
+}
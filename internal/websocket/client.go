@@ -3,6 +3,7 @@ package websocket
 import (
 	"encoding/json"
 	"log"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -32,6 +33,13 @@ type Client struct {
 	hub      *Hub
 	send     chan []byte
 	db       interface{} // Database connection (will be *sqlx.DB)
+
+	connectedAt    time.Time
+	lastReceivedAt atomic.Int64                 // Unix seconds of the last message from the client (0 = none yet)
+	lastSentAt     atomic.Int64                 // Unix seconds of the last message written to the client
+	subscription   atomic.Pointer[Subscription] // Filters role broadcasts (nil = receive everything)
+	areaScope      atomic.Pointer[[]string]     // Areas a manager is assigned to (nil = every area)
+	allAreas       bool                         // Connected with all_areas=true, so assignments don't scope it
 }
 
 // IncomingMessage represents a message from the client
@@ -50,7 +58,18 @@ func NewClient(userID string, userRole string, conn *websocket.Conn, hub *Hub, d
 		hub:      hub,
 		send:     make(chan []byte, 256),
 		db:       db,
+
+		connectedAt: time.Now(),
+	}
+}
+
+// setAreaScope limits the client's role broadcasts to the areas (empty = every area)
+func (c *Client) setAreaScope(areaIDs []string) {
+	if len(areaIDs) == 0 {
+		c.areaScope.Store(nil)
+		return
 	}
+	c.areaScope.Store(&areaIDs)
 }
 
 // ReadPump pumps messages from the WebSocket connection to the hub
@@ -77,6 +96,7 @@ func (c *Client) ReadPump() {
 			}
 			break
 		}
+		c.lastReceivedAt.Store(time.Now().Unix())
 
 		// Parse incoming message
 		var msg IncomingMessage
@@ -103,6 +123,13 @@ func (c *Client) ReadPump() {
 		case "driver_log":
 			// Handle driver log streaming
 			c.handleDriverLog(msg.Data)
+
+		case "subscribe":
+			// Narrow the role broadcasts this client receives
+			c.handleSubscribe(msg.Data, false)
+
+		case "unsubscribe":
+			c.handleSubscribe(nil, true)
 		}
 	}
 }
@@ -141,6 +168,7 @@ func (c *Client) WritePump() {
 			if err := w.Close(); err != nil {
 				return
 			}
+			c.lastSentAt.Store(time.Now().Unix())
 
 		case <-ticker.C:
 			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
@@ -168,6 +196,7 @@ func (c *Client) handleLocationUpdate(data map[string]interface{}) {
 		log.Printf("❌ Invalid longitude in location update")
 		return
 	}
+	c.hub.recordLocationPing(c.UserID)
 
 	// Optional fields (may be nil)
 	var heading, speed, accuracy *float64
@@ -270,6 +299,7 @@ func (c *Client) handleLocationUpdate(data map[string]interface{}) {
 		log.Printf("❌ Error saving location to database: %v", err)
 		return
 	}
+	c.hub.ReportLocation(c.UserID, LocationFix{Latitude: latitude, Longitude: longitude, Accuracy: accuracy, Timestamp: int64(timestamp)})
 
 	// log.Printf("✅ Location updated in database for driver %s", c.UserID)
 
@@ -289,8 +319,13 @@ func (c *Client) handleLocationUpdate(data map[string]interface{}) {
 		},
 	}
 
-	// Broadcast to all managers (users with role "admin")
-	c.hub.BroadcastToRole("admin", locationUpdate)
+	// Broadcast to the managers subscribed to this driver or the area they're in
+	c.hub.BroadcastToRoleScoped("admin", EventScope{
+		Type:      "driver_location_update",
+		DriverID:  c.UserID,
+		Latitude:  &latitude,
+		Longitude: &longitude,
+	}, locationUpdate)
 	// log.Printf("📤 Broadcasted location update to all managers (snapped if needed)")
 }
 
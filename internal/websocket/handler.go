@@ -5,10 +5,13 @@ import (
 	"net/http"
 	"os"
 
+	"ropacal-backend/internal/database"
 	"ropacal-backend/internal/middleware"
+	"ropacal-backend/pkg/utils"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/gorilla/websocket"
+	"github.com/jmoiron/sqlx"
 )
 
 var upgrader = websocket.Upgrader{
@@ -34,7 +37,7 @@ func HandleWebSocket(hub *Hub, db interface{}) http.HandlerFunc {
 			jwtSecret := os.Getenv("APP_JWT_SECRET")
 			if jwtSecret == "" {
 				log.Println("❌ JWT secret not configured")
-				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				utils.RespondError(w, http.StatusInternalServerError, "Internal server error")
 				return
 			}
 
@@ -49,7 +52,7 @@ func HandleWebSocket(hub *Hub, db interface{}) http.HandlerFunc {
 
 			if err != nil || !token.Valid {
 				log.Printf("❌ Invalid token in query parameter: %v", err)
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
 				return
 			}
 
@@ -57,23 +60,37 @@ func HandleWebSocket(hub *Hub, db interface{}) http.HandlerFunc {
 			claims, ok := token.Claims.(jwt.MapClaims)
 			if !ok {
 				log.Println("❌ Failed to parse claims")
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
 				return
 			}
 
 			// Convert to UserClaims struct
-			userClaims = middleware.UserClaims{
-				UserID: claims["user_id"].(string),
-				Email:  claims["email"].(string),
-				Role:   claims["role"].(string),
-			}
+			userClaims = middleware.UserClaimsFromJWT(claims)
 		} else {
 			// Fallback: Get user from context (set by Auth middleware)
 			var ok bool
 			userClaims, ok = middleware.GetUserFromContext(r)
 			if !ok {
 				log.Println("❌ No user in context for WebSocket connection")
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
+				return
+			}
+		}
+
+		// Support mode is read-only; a socket would send location updates and replace the driver's own connection
+		if userClaims.IsImpersonated() {
+			log.Printf("⛔ WebSocket rejected for support mode session %s (%s as %s)",
+				userClaims.ImpersonationID, userClaims.ImpersonatorEmail, userClaims.Email)
+			utils.RespondErrorCode(w, http.StatusForbidden, utils.CodeSupportModeReadOnly, "Support mode can't open a WebSocket", nil)
+			return
+		}
+
+		// Tokens outlive deactivation, so check the account is still active
+		if sqlxDB, ok := db.(*sqlx.DB); ok {
+			lookup := func(userID string) (bool, error) { return database.IsUserDeactivated(sqlxDB, userID) }
+			if middleware.IsUserDeactivated(lookup, userClaims.UserID) {
+				log.Printf("⛔ WebSocket rejected for deactivated user %s", userClaims.Email)
+				utils.RespondError(w, http.StatusForbidden, "Account deactivated")
 				return
 			}
 		}
@@ -88,6 +105,18 @@ func HandleWebSocket(hub *Hub, db interface{}) http.HandlerFunc {
 		// Create client
 		client := NewClient(userClaims.UserID, userClaims.Role, conn, hub, db)
 
+		// Managers assigned to areas only get live updates from them, unless they connect with all_areas=true
+		if userClaims.Role == "admin" {
+			client.allAreas = r.URL.Query().Get("all_areas") == "true"
+			if sqlxDB, ok := db.(*sqlx.DB); ok && !client.allAreas {
+				areaIDs, err := database.GetManagerAreaIDs(sqlxDB, userClaims.UserID)
+				if err != nil {
+					log.Printf("❌ Failed to load area scope for %s: %v", userClaims.Email, err)
+				}
+				client.setAreaScope(areaIDs)
+			}
+		}
+
 		// Register client
 		hub.register <- client
 
@@ -3,9 +3,13 @@ package websocket
 import (
 	"encoding/json"
 	"log"
+	"sort"
 	"sync"
+	"time"
 
 	"ropacal-backend/internal/services/roads"
+
+	"github.com/gorilla/websocket"
 )
 
 // Hub maintains active WebSocket connections and broadcasts messages
@@ -25,6 +29,21 @@ type Hub struct {
 	// Roads API client for snap-to-roads functionality
 	roadsClient *roads.RoadsClient
 
+	// Last location_update received per user (unix seconds), including pings skipped as too close to store
+	lastLocationPing map[string]int64
+
+	// Called (in its own goroutine) after a client disconnects
+	onDisconnect []func(userID, role string)
+
+	// Called (in order, in one goroutine per report) with each batch of driver GPS fixes
+	onLocation []func(userID string, fixes []LocationFix)
+
+	// Resolves event locations to areas for area subscriptions (nil = area filters match nothing)
+	areaLocator AreaLocator
+
+	// Area of each driver's last located broadcast, for driver events without a location
+	driverAreas map[string]*string
+
 	// Mutex for thread-safe client map access
 	mu sync.RWMutex
 }
@@ -43,6 +62,9 @@ func NewHub() *Hub {
 		register:    make(chan *Client),
 		unregister:  make(chan *Client),
 		roadsClient: roads.NewRoadsClient(),
+
+		lastLocationPing: make(map[string]int64),
+		driverAreas:      make(map[string]*string),
 	}
 }
 
@@ -72,6 +94,9 @@ func (h *Hub) Run() {
 				log.Printf("   Role: %s", client.UserRole)
 				log.Printf("   Remaining connected clients: %d", len(h.clients))
 				log.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+				for _, fn := range h.onDisconnect {
+					go fn(client.UserID, client.UserRole)
+				}
 			}
 			h.mu.Unlock()
 
@@ -102,6 +127,44 @@ func (h *Hub) Run() {
 	}
 }
 
+// OnDisconnect registers fn to run whenever a client disconnects (call before Run)
+func (h *Hub) OnDisconnect(fn func(userID, role string)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.onDisconnect = append(h.onDisconnect, fn)
+}
+
+// LocationFix is a GPS position reported by a driver
+type LocationFix struct {
+	Latitude  float64
+	Longitude float64
+	Accuracy  *float64 // Meters
+	Timestamp int64    // Unix milliseconds (device clock)
+}
+
+// OnLocation registers fn to receive driver GPS fixes from every source (call before Run)
+func (h *Hub) OnLocation(fn func(userID string, fixes []LocationFix)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.onLocation = append(h.onLocation, fn)
+}
+
+// ReportLocation passes a driver's fixes (oldest first) to the OnLocation listeners without blocking
+// Called for WebSocket location_update messages and by the HTTP location endpoints
+func (h *Hub) ReportLocation(userID string, fixes ...LocationFix) {
+	h.mu.RLock()
+	listeners := h.onLocation
+	h.mu.RUnlock()
+	if len(listeners) == 0 || len(fixes) == 0 {
+		return
+	}
+	go func() {
+		for _, fn := range listeners {
+			fn(userID, fixes)
+		}
+	}()
+}
+
 // BroadcastToUser sends a message to a specific user
 func (h *Hub) BroadcastToUser(userID string, data interface{}) {
 	h.broadcast <- &Message{
@@ -110,21 +173,40 @@ func (h *Hub) BroadcastToUser(userID string, data interface{}) {
 	}
 }
 
+// SetAreaLocator sets how area subscriptions resolve event locations (call before Run)
+func (h *Hub) SetAreaLocator(locator AreaLocator) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.areaLocator = locator
+}
+
 // BroadcastToRole sends a message to all users with a specific role
+// Subscribed clients only get it if they subscribed to its event type (the message's "type")
 func (h *Hub) BroadcastToRole(role string, data interface{}) {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
+	h.BroadcastToRoleScoped(role, EventScope{Type: eventType(data)}, data)
+}
 
-	// log.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-	// log.Printf("🔊 BroadcastToRole CALLED")
-	// log.Printf("   Target role: '%s'", role)
-	// log.Printf("   Total connected clients: %d", len(h.clients))
+// BroadcastToRoleScoped sends a message to the users with a role whose subscriptions and area assignments
+// match the scope
+// The message is marshaled once, and the area is only looked up if a recipient filters by area
+func (h *Hub) BroadcastToRoleScoped(role string, scope EventScope, data interface{}) {
+	h.mu.RLock()
+	needsArea := false
+	for _, client := range h.clients {
+		if client.UserRole != role {
+			continue
+		}
+		if subscription := client.subscription.Load(); (subscription != nil && len(subscription.AreaIDs) > 0) || client.areaScope.Load() != nil {
+			needsArea = true
+			break
+		}
+	}
+	h.mu.RUnlock()
 
-	// Log all connected clients and their roles
-	// for userID, client := range h.clients {
-	// 	log.Printf("   Client: %s (role: %s) %s", userID, client.UserRole,
-	// 		map[bool]string{true: "✅ MATCH", false: "❌ no match"}[client.UserRole == role])
-	// }
+	var areaID *string
+	if needsArea {
+		areaID = h.scopeArea(scope)
+	}
 
 	dataBytes, err := json.Marshal(data)
 	if err != nil {
@@ -132,24 +214,62 @@ func (h *Hub) BroadcastToRole(role string, data interface{}) {
 		return
 	}
 
-	// log.Printf("   Message size: %d bytes", len(dataBytes))
-	// log.Printf("   Message preview: %s", string(dataBytes[:min(len(dataBytes), 200)]))
-
-	// sentCount := 0
+	h.mu.RLock()
+	defer h.mu.RUnlock()
 	for _, client := range h.clients {
-		if client.UserRole == role {
-			select {
-			case client.send <- dataBytes:
-				// sentCount++
-				// log.Printf("   ✅ Sent to %s", userID)
-			default:
-				// log.Printf("⚠️ Client buffer full, skipping: %s", userID)
-			}
+		if client.UserRole != role {
+			continue
+		}
+		if subscription := client.subscription.Load(); subscription != nil && !subscription.matches(scope, areaID) {
+			continue
+		}
+		if !client.inAreaScope(scope, areaID) {
+			continue
+		}
+		select {
+		case client.send <- dataBytes:
+		default:
+			// Client buffer full, skip
 		}
 	}
+}
+
+// SetUserAreaScope limits a connected manager's role broadcasts to events in the areas (empty = every area)
+// Connections opened with all_areas=true keep receiving every area
+func (h *Hub) SetUserAreaScope(userID string, areaIDs []string) {
+	h.mu.RLock()
+	client, ok := h.clients[userID]
+	h.mu.RUnlock()
+	if !ok || client.allAreas {
+		return
+	}
+	client.setAreaScope(areaIDs)
+}
+
+// scopeArea returns the area an event happened in: its location's area, or the area of the driver's last
+// located event; located driver events update the latter
+func (h *Hub) scopeArea(scope EventScope) *string {
+	h.mu.RLock()
+	locator := h.areaLocator
+	lastArea := h.driverAreas[scope.DriverID]
+	h.mu.RUnlock()
 
-	// log.Printf("📤 Broadcast to role '%s': sent to %d clients", role, sentCount)
-	// log.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	if scope.Latitude == nil || scope.Longitude == nil {
+		if scope.DriverID == "" {
+			return nil
+		}
+		return lastArea
+	}
+	if locator == nil {
+		return nil
+	}
+	areaID := locator(*scope.Latitude, *scope.Longitude)
+	if scope.DriverID != "" {
+		h.mu.Lock()
+		h.driverAreas[scope.DriverID] = areaID
+		h.mu.Unlock()
+	}
+	return areaID
 }
 
 // GetClientCount returns the number of connected clients
@@ -177,3 +297,91 @@ func (h *Hub) GetConnectedClientIDs() []string {
 	}
 	return ids
 }
+
+// recordLocationPing notes that a location update arrived from a user
+func (h *Hub) recordLocationPing(userID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastLocationPing[userID] = time.Now().Unix()
+}
+
+// LastLocationPing returns when the user last sent a location update (unix seconds) since the server started
+func (h *Hub) LastLocationPing(userID string) (int64, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	at, ok := h.lastLocationPing[userID]
+	return at, ok
+}
+
+// ClientInfo describes a connected client for the admin occupancy view
+type ClientInfo struct {
+	UserID             string        `json:"user_id"`
+	Role               string        `json:"role"`
+	ConnectedAt        int64         `json:"connected_at"`
+	ConnectedSeconds   int64         `json:"connected_seconds"`
+	LastMessageAt      *int64        `json:"last_message_at"` // Last message received from the client (nil if none yet)
+	LastSentAt         *int64        `json:"last_sent_at"`    // Last message written to the client
+	LastLocationPingAt *int64        `json:"last_location_ping_at,omitempty"`
+	QueuedMessages     int           `json:"queued_messages"`    // Waiting in the send buffer
+	Topics             []string      `json:"topics"`             // Channels the client receives: its user and its role
+	Subscription       *Subscription `json:"subscription"`       // Filter on its role broadcasts (nil = everything)
+	AreaIDs            []string      `json:"area_ids,omitempty"` // Assigned areas its role broadcasts are limited to
+}
+
+// Clients lists the connected clients, longest-connected first
+func (h *Hub) Clients() []ClientInfo {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	now := time.Now()
+	clients := make([]ClientInfo, 0, len(h.clients))
+	for _, client := range h.clients {
+		info := ClientInfo{
+			UserID:           client.UserID,
+			Role:             client.UserRole,
+			ConnectedAt:      client.connectedAt.Unix(),
+			ConnectedSeconds: int64(now.Sub(client.connectedAt).Seconds()),
+			QueuedMessages:   len(client.send),
+			Topics:           []string{"user:" + client.UserID, "role:" + client.UserRole},
+			Subscription:     client.subscription.Load(),
+		}
+		if areaIDs := client.areaScope.Load(); areaIDs != nil {
+			info.AreaIDs = *areaIDs
+		}
+		if at := client.lastReceivedAt.Load(); at > 0 {
+			info.LastMessageAt = &at
+		}
+		if at := client.lastSentAt.Load(); at > 0 {
+			info.LastSentAt = &at
+		}
+		if at, ok := h.lastLocationPing[client.UserID]; ok {
+			info.LastLocationPingAt = &at
+		}
+		clients = append(clients, info)
+	}
+	sort.Slice(clients, func(i, j int) bool { return clients[i].ConnectedAt < clients[j].ConnectedAt })
+	return clients
+}
+
+// DisconnectUser closes a user's connection with a close frame carrying the reason
+// The client unregisters through its read pump as usual; returns false if the user isn't connected
+func (h *Hub) DisconnectUser(userID, reason string) bool {
+	h.mu.RLock()
+	client, ok := h.clients[userID]
+	h.mu.RUnlock()
+	if !ok {
+		return false
+	}
+
+	// Close reasons are limited to 123 bytes
+	if len(reason) > 120 {
+		reason = reason[:120]
+	}
+	closeMessage := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, reason)
+	if err := client.conn.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(writeWait)); err != nil {
+		log.Printf("⚠️ [WEBSOCKET] Failed to send close frame to %s: %v", userID, err)
+	}
+	client.conn.Close()
+	log.Printf("⛔ [WEBSOCKET] Force-disconnected %s (%s): %s", userID, client.UserRole, reason)
+	return true
+}
@@ -0,0 +1,153 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+// Subscription narrows which role broadcasts a client receives
+// Clients send {"type": "subscribe", "data": {"driver_ids": [...], "area_ids": [...], "event_types": [...]}}
+// to replace their subscription and {"type": "unsubscribe"} to go back to receiving everything
+//
+// Each list left empty doesn't filter. Events about a driver or a place pass the driver/area filter when
+// the driver is in driver_ids or the place is inside one of area_ids (events without a location use the
+// area of the driver's last location); events about neither (bins, move requests...) always pass it
+// Messages sent to a specific user are never filtered
+type Subscription struct {
+	DriverIDs  []string `json:"driver_ids"`
+	AreaIDs    []string `json:"area_ids"`
+	EventTypes []string `json:"event_types"`
+}
+
+// maxSubscriptionEntries caps each list of a subscription
+const maxSubscriptionEntries = 500
+
+// EventScope says what a broadcast is about, for matching against client subscriptions
+type EventScope struct {
+	Type      string   // Event type, e.g. "driver_location_update"
+	DriverID  string   // Driver the event concerns ("" if none)
+	Latitude  *float64 // Where it happened (nil if unknown)
+	Longitude *float64
+}
+
+// AreaLocator returns the ID of the area containing a point, or nil if it's outside every area
+type AreaLocator func(lat, lng float64) *string
+
+// filtersPlace reports whether the subscription limits drivers or areas
+func (s *Subscription) filtersPlace() bool {
+	return len(s.DriverIDs) > 0 || len(s.AreaIDs) > 0
+}
+
+// matches reports whether an event with the given scope (in areaID, nil if unknown) should be sent
+func (s *Subscription) matches(scope EventScope, areaID *string) bool {
+	if len(s.EventTypes) > 0 && !containsString(s.EventTypes, scope.Type) {
+		return false
+	}
+	if !s.filtersPlace() || (scope.DriverID == "" && scope.Latitude == nil) {
+		return true
+	}
+	if scope.DriverID != "" && containsString(s.DriverIDs, scope.DriverID) {
+		return true
+	}
+	return areaID != nil && containsString(s.AreaIDs, *areaID)
+}
+
+// inAreaScope reports whether a client limited to a manager's assigned areas gets an event (in areaID, nil if
+// unknown); events about neither a driver nor a place always pass, like with subscriptions
+func (c *Client) inAreaScope(scope EventScope, areaID *string) bool {
+	areaIDs := c.areaScope.Load()
+	if areaIDs == nil || (scope.DriverID == "" && scope.Latitude == nil) {
+		return true
+	}
+	return areaID != nil && containsString(*areaIDs, *areaID)
+}
+
+// parseSubscription reads the data of a subscribe message
+func parseSubscription(data map[string]interface{}) (*Subscription, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	subscription := &Subscription{}
+	if err := json.Unmarshal(raw, subscription); err != nil {
+		return nil, fmt.Errorf("driver_ids, area_ids and event_types must be lists of strings")
+	}
+	for name, list := range map[string][]string{
+		"driver_ids":  subscription.DriverIDs,
+		"area_ids":    subscription.AreaIDs,
+		"event_types": subscription.EventTypes,
+	} {
+		if len(list) > maxSubscriptionEntries {
+			return nil, fmt.Errorf("%s has more than %d entries", name, maxSubscriptionEntries)
+		}
+	}
+	if subscription.DriverIDs == nil {
+		subscription.DriverIDs = []string{}
+	}
+	if subscription.AreaIDs == nil {
+		subscription.AreaIDs = []string{}
+	}
+	if subscription.EventTypes == nil {
+		subscription.EventTypes = []string{}
+	}
+	return subscription, nil
+}
+
+// handleSubscribe replaces the client's subscription (nil clears it) and acknowledges it
+func (c *Client) handleSubscribe(data map[string]interface{}, clear bool) {
+	var subscription *Subscription
+	if !clear {
+		var err error
+		subscription, err = parseSubscription(data)
+		if err != nil {
+			c.sendJSON(map[string]interface{}{
+				"type": "subscription_error",
+				"data": map[string]interface{}{"error": err.Error()},
+			})
+			return
+		}
+	}
+	c.subscription.Store(subscription)
+
+	if subscription == nil {
+		c.sendJSON(map[string]interface{}{"type": "unsubscribed"})
+		log.Printf("📡 [WEBSOCKET] %s cleared their subscription", c.UserID)
+		return
+	}
+	c.sendJSON(map[string]interface{}{"type": "subscribed", "data": subscription})
+	log.Printf("📡 [WEBSOCKET] %s subscribed: %d drivers, %d areas, %d event types",
+		c.UserID, len(subscription.DriverIDs), len(subscription.AreaIDs), len(subscription.EventTypes))
+}
+
+// sendJSON queues a reply to the client, dropping it if the send buffer is full
+func (c *Client) sendJSON(data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("❌ Failed to marshal message: %v", err)
+		return
+	}
+	select {
+	case c.send <- payload:
+	default:
+	}
+}
+
+// eventType returns the "type" of a broadcast message, or ""
+func eventType(data interface{}) string {
+	if message, ok := data.(map[string]interface{}); ok {
+		if t, ok := message["type"].(string); ok {
+			return t
+		}
+	}
+	return ""
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
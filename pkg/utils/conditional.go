@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SetCacheValidators sets the ETag and Last-Modified headers of a response clients should revalidate before
+// reusing (see NotModified)
+func SetCacheValidators(w http.ResponseWriter, etag string, lastModified int64) {
+	w.Header().Set("ETag", etag)
+	if lastModified > 0 {
+		w.Header().Set("Last-Modified", time.Unix(lastModified, 0).UTC().Format(http.TimeFormat))
+	}
+	w.Header().Set("Cache-Control", "private, no-cache")
+}
+
+// NotModified reports whether the client's cached copy is current, so a 304 can be sent instead of the body
+// If-None-Match is compared weakly against etag; If-Modified-Since is only used without it, as in RFC 9110
+func NotModified(r *http.Request, etag string, lastModified int64) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	if since := r.Header.Get("If-Modified-Since"); since != "" && lastModified > 0 {
+		if t, err := http.ParseTime(since); err == nil {
+			return lastModified <= t.Unix()
+		}
+	}
+	return false
+}
+
+// RespondNotModified sends a 304 with the validators and no body
+func RespondNotModified(w http.ResponseWriter, etag string, lastModified int64) {
+	SetCacheValidators(w, etag, lastModified)
+	w.WriteHeader(http.StatusNotModified)
+}
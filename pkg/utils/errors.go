@@ -0,0 +1,202 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/lib/pq"
+)
+
+// Error codes returned in the "code" field of error responses
+// Clients branch on the code; the message is for display and may change (or be translated)
+const (
+	CodeBadRequest       = "bad_request"
+	CodeValidationFailed = "validation_failed"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeConflict         = "conflict"
+	CodeAlreadyExists    = "already_exists"
+	CodeInvalidReference = "invalid_reference"
+	CodeGone             = "gone"
+	CodePayloadTooLarge  = "payload_too_large"
+	CodePrecondition     = "precondition_required"
+	CodeUnprocessable    = "unprocessable"
+	CodeRateLimited      = "rate_limited"
+	CodeInternal         = "internal_error"
+	CodeUnavailable      = "service_unavailable"
+	CodeTimeout          = "timeout"
+
+	// Domain-specific codes
+	CodeChecklistRequired        = "pre_start_checklist_required"    // Driver must submit the pre-start checklist first
+	CodeWorkloadExceeded         = "workload_limit_exceeded"         // Assignment breaks the driver's workload limits (resend with force)
+	CodeMoveFinalized            = "move_request_finalized"          // Completed, cancelled or rejected move requests can't be changed
+	CodeMoveAwaitingApproval     = "move_request_awaiting_approval"  // The move request must be approved first (or is no longer awaiting approval)
+	CodeStaleUpdate              = "stale_update"                    // The record changed since the client loaded it
+	CodeInProgressActionRequired = "in_progress_action_required"     // Editing a move the driver is working on needs in_progress_action
+	CodeActiveShiftConfirmation  = "active_shift_change_unconfirmed" // Editing a move on an active route needs confirm_active_shift_change
+	CodeTimeWindowViolation      = "time_window_violation"           // The route can't reach every stop within its time window
+	CodePhotoRequired            = "photo_required"                  // The bin (or its area) requires a photo with every check
+	CodeInvalidStatusTransition  = "invalid_status_transition"       // The bin or move request can't move from its current status to the requested one
+	CodeAfterServiceHours        = "after_service_hours"             // The assignment would finish after service hours (resend with force)
+	CodeMissingCoordinates       = "bin_missing_coordinates"         // Bins without latitude/longitude can't be put on a route (geocode them first)
+	CodeSupportModeReadOnly      = "support_mode_read_only"          // Impersonation (support mode) tokens can't change anything
+	CodeSupportModeEnded         = "support_mode_ended"              // The impersonation session expired or was ended
+	CodeBinReserved              = "bin_reserved"                    // The bin is already on another open shift (details list the shifts)
+	CodeDebriefRequired          = "shift_debrief_required"          // The organization requires the end-of-shift debrief
+	CodeTooFarFromStop           = "too_far_from_stop"               // The driver's GPS is further from the stop than the check-in proximity limit
+)
+
+// RequestIDHeader carries the request ID on responses (set by middleware.RequestIDHeader)
+const RequestIDHeader = "X-Request-Id"
+
+// SupportModeHeader is "true" on responses to impersonated requests, so clients can show a support mode banner
+// SupportModeByHeader names the admin impersonating the user
+const (
+	SupportModeHeader   = "X-Support-Mode"
+	SupportModeByHeader = "X-Support-Mode-By"
+)
+
+// ErrorResponse is the body of every error response
+// Error duplicates Message for clients written against the older {success, error} body
+type ErrorResponse struct {
+	Success   bool        `json:"success"`
+	Code      string      `json:"code"`
+	Message   string      `json:"message"`
+	Error     string      `json:"error"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
+}
+
+// CodeForStatus is the default error code of an HTTP status
+func CodeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusGone:
+		return CodeGone
+	case http.StatusRequestEntityTooLarge:
+		return CodePayloadTooLarge
+	case http.StatusPreconditionRequired, http.StatusPreconditionFailed:
+		return CodePrecondition
+	case http.StatusUnprocessableEntity:
+		return CodeUnprocessable
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	case http.StatusGatewayTimeout:
+		return CodeTimeout
+	}
+	if status >= 500 {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
+
+// RespondError sends an error response with the status's default code
+func RespondError(w http.ResponseWriter, status int, message string) {
+	RespondErrorCode(w, status, CodeForStatus(status), message, nil)
+}
+
+// RespondErrorCode sends an error response with an explicit code and optional details
+func RespondErrorCode(w http.ResponseWriter, status int, code, message string, details interface{}) {
+	message = cleanErrorMessage(message)
+	RespondJSON(w, status, ErrorResponse{
+		Success:   false,
+		Code:      code,
+		Message:   message,
+		Error:     message,
+		Details:   details,
+		RequestID: w.Header().Get(RequestIDHeader),
+	})
+}
+
+// RespondDBError maps a database error to a response: missing rows are 404, constraint violations
+// 409/400 and cancelled or timed-out queries 504; anything else is a 500 with the given message
+func RespondDBError(w http.ResponseWriter, err error, message string) {
+	status, code := DBErrorStatus(err)
+	switch code {
+	case CodeNotFound:
+		RespondErrorCode(w, status, code, "Not found", nil)
+	case CodeAlreadyExists:
+		RespondErrorCode(w, status, code, "Already exists", constraintDetails(err))
+	case CodeInvalidReference:
+		RespondErrorCode(w, status, code, "Referenced record does not exist or is still in use", constraintDetails(err))
+	case CodeValidationFailed:
+		RespondErrorCode(w, status, code, "Invalid value", constraintDetails(err))
+	case CodeTimeout:
+		RespondErrorCode(w, status, code, "The request took too long", nil)
+	default:
+		RespondErrorCode(w, status, code, message, nil)
+	}
+}
+
+// DBErrorStatus classifies a database error as an HTTP status and error code
+func DBErrorStatus(err error) (int, string) {
+	if errors.Is(err, sql.ErrNoRows) {
+		return http.StatusNotFound, CodeNotFound
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return http.StatusGatewayTimeout, CodeTimeout
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "23505": // unique_violation
+			return http.StatusConflict, CodeAlreadyExists
+		case "23503": // foreign_key_violation
+			return http.StatusConflict, CodeInvalidReference
+		case "23502", "23514", "22P02", "22001": // not_null, check, invalid_text_representation, string_data_right_truncation
+			return http.StatusBadRequest, CodeValidationFailed
+		case "57014": // query_canceled (statement timeout)
+			return http.StatusGatewayTimeout, CodeTimeout
+		}
+	}
+	return http.StatusInternalServerError, CodeInternal
+}
+
+// constraintDetails names the violated constraint and column, when Postgres reports them
+func constraintDetails(err error) interface{} {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return nil
+	}
+	details := map[string]string{}
+	if pqErr.Constraint != "" {
+		details["constraint"] = pqErr.Constraint
+	}
+	if pqErr.Column != "" {
+		details["column"] = pqErr.Column
+	}
+	if len(details) == 0 {
+		return nil
+	}
+	return details
+}
+
+// cleanErrorMessage drops emoji and other pictographs (clients display the message as-is)
+func cleanErrorMessage(message string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		if unicode.Is(unicode.So, r) || r == '\uFE0F' || r == '\u200D' {
+			return -1
+		}
+		return r
+	}, message)
+	return strings.TrimSpace(strings.ReplaceAll(cleaned, "  ", " "))
+}
@@ -0,0 +1,164 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// FieldSet is the JSON fields a response can be projected to with ?fields=
+// A field with a nested set (objects, or arrays of objects) can also be selected as "parent.child"
+type FieldSet map[string]FieldSet
+
+var jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// FieldsOf returns the JSON fields of a struct from its json tags
+// Embedded structs are flattened; struct and slice-of-struct fields get nested sets
+func FieldsOf(model interface{}) FieldSet {
+	return fieldsOfType(reflect.TypeOf(model))
+}
+
+func fieldsOfType(t reflect.Type) FieldSet {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType) {
+		return nil
+	}
+
+	fields := FieldSet{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || (!f.IsExported() && !f.Anonymous) {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if f.Anonymous && name == "" {
+			for embedded, nested := range fieldsOfType(f.Type) {
+				fields[embedded] = nested
+			}
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = fieldsOfType(f.Type)
+	}
+	return fields
+}
+
+// Names lists the selectable field paths, sorted
+func (fs FieldSet) Names() []string {
+	var names []string
+	for name, nested := range fs {
+		names = append(names, name)
+		for _, child := range nested.Names() {
+			names = append(names, name+"."+child)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Projection is a validated ?fields= selection; a nil Projection keeps everything
+type Projection map[string]Projection
+
+// ParseFields reads ?fields=a,b,parent.child and checks every name against allowed
+// Returns nil when the parameter is absent or empty, and an error naming any unknown field
+func ParseFields(r *http.Request, allowed FieldSet) (Projection, error) {
+	raw := strings.TrimSpace(r.URL.Query().Get("fields"))
+	if raw == "" {
+		return nil, nil
+	}
+
+	projection := Projection{}
+	var unknown []string
+	for _, path := range strings.Split(raw, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+
+		node, set := projection, allowed
+		parts := strings.Split(path, ".")
+		for i, part := range parts {
+			nested, ok := set[part]
+			if !ok || (i < len(parts)-1 && nested == nil) {
+				unknown = append(unknown, path)
+				break
+			}
+			child, selected := node[part]
+			if i == len(parts)-1 {
+				node[part] = nil // The whole field, even if children were selected before
+				break
+			}
+			if selected && child == nil {
+				break // The whole field was already selected
+			}
+			if child == nil {
+				child = Projection{}
+				node[part] = child
+			}
+			node, set = child, nested
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("unknown fields: %s", strings.Join(unknown, ", "))
+	}
+	if len(projection) == 0 {
+		return nil, nil
+	}
+	return projection, nil
+}
+
+// Apply returns v with only the selected fields; v may be an object or an array of objects
+// The result is generic JSON (maps and slices) ready to encode
+func (p Projection) Apply(v interface{}) interface{} {
+	if p == nil {
+		return v
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var generic interface{}
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return v
+	}
+	return p.apply(generic)
+}
+
+func (p Projection) apply(v interface{}) interface{} {
+	switch value := v.(type) {
+	case []interface{}:
+		for i := range value {
+			value[i] = p.apply(value[i])
+		}
+		return value
+	case map[string]interface{}:
+		projected := make(map[string]interface{}, len(p))
+		for name, child := range p {
+			field, ok := value[name]
+			if !ok {
+				continue // omitempty fields stay omitted
+			}
+			if child != nil {
+				field = child.apply(field)
+			}
+			projected[name] = field
+		}
+		return projected
+	}
+	return v
+}
+
+// RespondFieldsError sends the 400 for an invalid ?fields= selection, listing the allowed names
+func RespondFieldsError(w http.ResponseWriter, err error, allowed FieldSet) {
+	RespondErrorCode(w, http.StatusBadRequest, CodeValidationFailed, err.Error(), map[string]interface{}{
+		"allowed_fields": allowed.Names(),
+	})
+}
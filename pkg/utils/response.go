@@ -12,7 +12,7 @@ func JSON(w http.ResponseWriter, status int, data interface{}) {
 }
 
 func Error(w http.ResponseWriter, status int, message string) {
-	JSON(w, status, map[string]string{"error": message})
+	RespondError(w, status, message)
 }
 
 func Success(w http.ResponseWriter, data interface{}) {
@@ -25,11 +25,3 @@ func RespondJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(data)
 }
-
-// RespondError sends an error response
-func RespondError(w http.ResponseWriter, status int, message string) {
-	RespondJSON(w, status, map[string]interface{}{
-		"success": false,
-		"error":   message,
-	})
-}